@@ -2,13 +2,19 @@ package main
 
 import (
 	"archive/zip"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -21,15 +27,34 @@ import (
 	"github.com/sharath018/temple-management-backend/internal/entity"
 	"github.com/sharath018/temple-management-backend/internal/event"
 	"github.com/sharath018/temple-management-backend/internal/eventrsvp"
+	"github.com/sharath018/temple-management-backend/internal/health"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
 	"github.com/sharath018/temple-management-backend/routes"
 	"github.com/sharath018/temple-management-backend/utils"
 )
 
+// @title Temple Management API
+// @version 1.0
+// @description API Documentation for Temple Management SaaS Platform
+// @BasePath /api/v1
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
 func main() {
+	// Cancelled on SIGINT/SIGTERM; background workers stop with it
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	cfg := config.Load()
 	db := database.Connect(cfg)
 
+	// API messages and report headers in the user's language
+	i18n.Init(cfg.DefaultLanguage, cfg.ReportFontDir)
+
 	// Init Redis
 	if err := utils.InitRedis(); err != nil {
 		log.Fatalf("❌ Redis init failed: %v", err)
@@ -56,7 +81,8 @@ func main() {
 
 	notificationRepo := notification.NewRepository(db)
 	notificationService := notification.NewService(notificationRepo, authRepo, cfg, auditSvc)
-	notification.StartKafkaConsumer(notificationService)
+	notificationService.SetMessenger(notification.NewMessenger(notificationRepo, cfg, auditSvc))
+	consumerDone := notification.StartKafkaConsumer(ctx, notificationService)
 
 	// Seed roles & super admin
 	if err := auth.SeedUserRoles(db); err != nil {
@@ -73,6 +99,7 @@ func main() {
 		&entity.Entity{},
 		&event.Event{},
 		&eventrsvp.RSVP{},
+		&eventrsvp.ReminderDelivery{},
 		&notification.InAppNotification{},
 		&notification.FCMDeviceToken{}, // ✅ Add FCM device token migration
 	); err != nil {
@@ -80,6 +107,9 @@ func main() {
 	}
 	log.Println("✅ Database migrations completed")
 
+	// Kafka producer: buffers notifications in the database while the broker is down
+	notification.StartProducer(ctx, db, cfg.KafkaBufferLimit)
+
 	// Add isactive column if it doesn't exist (migration for existing databases)
 	log.Println("🔄 Checking for isactive column...")
 	if err := migrateIsActiveColumn(db); err != nil {
@@ -88,10 +118,20 @@ func main() {
 		log.Println("✅ IsActive column verified/added")
 	}
 
+	// RSVPs are unique per event occurrence now; drop the old per-event index
+	if err := db.Exec(`DROP INDEX IF EXISTS idx_event_user`).Error; err != nil {
+		log.Printf("⚠️ Warning: Could not drop old RSVP index: %v", err)
+	}
+
 	// Setup Gin router
 	router := gin.New()
 	router.Use(gin.Logger())
 	router.Use(gin.Recovery())
+	router.Use(metrics.Middleware(cfg.ResponseBudgets)) // request count, latency and payload size per route, served on /metrics
+	// gzip/deflate for larger JSON; file downloads and zip streams pass through
+	router.Use(middleware.Compress(cfg.CompressMinBytes, "/files", "/uploads"))
+	// Deprecation/Sunset headers on routes slated for removal (API_DEPRECATIONS)
+	router.Use(middleware.Deprecations(cfg.APIDeprecations))
 	router.LoadHTMLGlob("templates/*")
 
 	// Optional request logger
@@ -100,52 +140,31 @@ func main() {
 		c.Next()
 	})
 
-	// Enhanced CORS middleware
+	// CORS middleware; origins come from CORS_ALLOWED_ORIGINS and may use a
+	// wildcard subdomain (https://*.example.com). It also answers preflights,
+	// and routes must not set CORS headers of their own.
 	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173", "http://127.0.0.1:5173", "http://localhost:4173", "http://127.0.0.1:4173"},
+		AllowOrigins:     cfg.CORSAllowedOrigins,
+		AllowWildcard:    true,
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS", "HEAD"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID", "Content-Length", "X-Requested-With", "Cache-Control", "Pragma", "X-Entity-ID"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Content-Disposition", "Cache-Control", "Pragma", "Expires"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Tenant-ID", "Content-Length", "X-Requested-With", "Cache-Control", "Pragma", "X-Entity-ID", "Idempotency-Key"},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", "Content-Disposition", "Cache-Control", "Pragma", "Expires", "Deprecation", "Sunset", "Link", "Idempotent-Replayed", "Content-Range", "Accept-Ranges", "ETag", "Last-Modified"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
-	// ✅ CRITICAL FIX: Explicit preflight handler for SuperAdmin routes
-	router.OPTIONS("/api/v1/superadmin/*path", func(c *gin.Context) {
-		origin := c.GetHeader("Origin")
-		allowedOrigins := []string{
-			"http://localhost:5173",
-			"http://127.0.0.1:5173",
-			"http://localhost:4173",
-			"http://127.0.0.1:4173",
-		}
-
-		originAllowed := false
-		for _, allowed := range allowedOrigins {
-			if origin == allowed {
-				originAllowed = true
-				break
-			}
-		}
-
-		if !originAllowed {
-			origin = "http://localhost:4173"
-		}
-
-		log.Printf("🔧 SuperAdmin OPTIONS request from origin: %s for path: %s", origin, c.Request.URL.Path)
-
-		c.Header("Access-Control-Allow-Origin", origin)
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS, HEAD")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization, X-Tenant-ID, Content-Length, X-Requested-With, Cache-Control, Pragma, X-Entity-ID")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type, Content-Disposition, Cache-Control, Pragma, Expires")
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Max-Age", "43200")
-		c.Status(204)
-	})
-
-	// Create uploads directory
-	uploadDir := "/data/uploads"
-
+	// Init file storage (local disk or S3/MinIO, see STORAGE_BACKEND)
+	baseStore, err := storage.New(cfg)
+	if err != nil {
+		panic(fmt.Sprintf("❌ Failed to initialize file storage: %v", err))
+	}
+	// Tenants tagged with a data region keep their files in that region's
+	// store (STORAGE_REGIONS)
+	store, err := storage.NewRouter(cfg, baseStore, storage.EntityRegionResolver(db))
+	if err != nil {
+		panic(fmt.Sprintf("❌ Failed to initialize regional file storage: %v", err))
+	}
+	uploadDir := cfg.UploadDir
 	if err := os.MkdirAll(uploadDir, os.ModePerm); err != nil {
 		panic(fmt.Sprintf("❌ Failed to create upload directory: %v", err))
 	}
@@ -154,20 +173,16 @@ func main() {
 
 	// Primary route: /uploads/{entityID}/{filename}
 	router.GET("/uploads/:entityID/:filename", func(c *gin.Context) {
-		serveEntityFile(c, uploadDir)
+		serveEntityFile(c, store, cfg.FileCacheControl)
 	})
 
 	// Alternative route: /files/{entityID}/{filename}
 	router.GET("/files/:entityID/:filename", func(c *gin.Context) {
-		serveEntityFile(c, uploadDir)
+		serveEntityFile(c, store, cfg.FileCacheControl)
 	})
 
 	// Secure API endpoint for entity files with authentication
 	router.GET("/api/v1/entities/:id/files/:filename", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type, Content-Disposition")
-
 		entityID := c.Param("id")
 		filename := c.Param("filename")
 
@@ -176,21 +191,18 @@ func main() {
 			return
 		}
 
-		filePath := filepath.Join(uploadDir, entityID, filename)
-		cleanPath := filepath.Clean(filePath)
-		expectedPrefix := filepath.Clean(filepath.Join(uploadDir, entityID))
-
-		if !strings.HasPrefix(cleanPath, expectedPrefix) {
+		key, err := storage.Key(entityID, filename)
+		if err != nil {
 			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 			return
 		}
 
-		fileInfo, err := os.Stat(cleanPath)
-		if os.IsNotExist(err) {
+		reader, info, err := store.Get(c.Request.Context(), key)
+		if errors.Is(err, storage.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":   "File not found",
 				"message": "The requested file does not exist",
-				"path":    filepath.Join("/api/v1/entities", entityID, "files", filename),
+				"path":    path.Join("/api/v1/entities", entityID, "files", filename),
 			})
 			return
 		}
@@ -199,30 +211,35 @@ func main() {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "File access error"})
 			return
 		}
+		defer reader.Close()
 
-		setContentType(c, filename)
+		contentType := setContentType(c, filename)
 		c.Header("Content-Description", "File Transfer")
 		c.Header("Content-Transfer-Encoding", "binary")
 		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
-		c.Header("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
 		c.Header("Cache-Control", "no-cache, no-store, must-revalidate")
 		c.Header("Pragma", "no-cache")
 		c.Header("Expires", "0")
-
-		c.File(cleanPath)
+		// Range support lets interrupted downloads resume
+		storage.ServeObject(c.Writer, c.Request, reader, info, contentType)
 		log.Printf("✅ File downloaded: %s/%s", entityID, filename)
 	})
 
 	// Bulk download all files for an entity
 	router.GET("/api/v1/entities/:id/files-all", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
-		c.Header("Access-Control-Allow-Credentials", "true")
-		c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type, Content-Disposition")
-
 		entityID := c.Param("id")
-		entityDir := filepath.Join(uploadDir, entityID)
+		prefix, err := storage.Key(entityID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters"})
+			return
+		}
 
-		if _, err := os.Stat(entityDir); os.IsNotExist(err) {
+		objects, err := store.List(c.Request.Context(), prefix+"/")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read entity files"})
+			return
+		}
+		if len(objects) == 0 {
 			c.JSON(http.StatusNotFound, gin.H{"error": "No files found for this entity"})
 			return
 		}
@@ -238,44 +255,25 @@ func main() {
 		zipWriter := zip.NewWriter(c.Writer)
 		defer zipWriter.Close()
 
-		err := filepath.Walk(entityDir, func(filePath string, info os.FileInfo, err error) error {
-			if err != nil {
-				log.Printf("⚠️ Error walking file %s: %v", filePath, err)
-				return nil
-			}
-
-			if info.IsDir() {
-				return nil
-			}
-
-			relPath, err := filepath.Rel(entityDir, filePath)
-			if err != nil {
-				log.Printf("⚠️ Error getting relative path for %s: %v", filePath, err)
-				return nil
-			}
+		for _, obj := range objects {
+			relPath := strings.TrimPrefix(obj.Key, prefix+"/")
 
 			zipFile, err := zipWriter.Create(relPath)
 			if err != nil {
 				log.Printf("⚠️ Error creating zip entry for %s: %v", relPath, err)
-				return nil
+				continue
 			}
 
-			srcFile, err := os.Open(filePath)
+			srcFile, _, err := store.Get(c.Request.Context(), obj.Key)
 			if err != nil {
-				log.Printf("⚠️ Error opening file %s: %v", filePath, err)
-				return nil
+				log.Printf("⚠️ Error opening file %s: %v", obj.Key, err)
+				continue
 			}
-			defer srcFile.Close()
 
 			if _, err = io.Copy(zipFile, srcFile); err != nil {
-				log.Printf("⚠️ Error copying file %s to zip: %v", filePath, err)
+				log.Printf("⚠️ Error copying file %s to zip: %v", obj.Key, err)
 			}
-
-			return nil
-		})
-
-		if err != nil {
-			log.Printf("⚠️ Error creating ZIP for entity %s: %v", entityID, err)
+			srcFile.Close()
 		}
 
 		log.Printf("✅ ZIP file created for entity %s", entityID)
@@ -283,34 +281,41 @@ func main() {
 
 	// Upload endpoint
 	router.POST("/upload", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
-		c.Header("Access-Control-Allow-Credentials", "true")
-
 		file, err := c.FormFile("file")
 		if err != nil {
 			c.JSON(400, gin.H{"error": "File not found in request"})
 			return
 		}
+		metrics.UploadSize.Observe(float64(file.Size), "upload")
 
 		filename := filepath.Base(file.Filename)
-		dst := filepath.Join(uploadDir, filename)
-		if err := c.SaveUploadedFile(file, dst); err != nil {
+		key, err := storage.Key(filename)
+		if err != nil {
+			c.JSON(400, gin.H{"error": "Invalid file name"})
+			return
+		}
+
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to read file: %v", err)})
+			return
+		}
+		defer src.Close()
+
+		if err := store.Put(c.Request.Context(), key, src, file.Size, file.Header.Get("Content-Type")); err != nil {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("Failed to save file: %v", err)})
 			return
 		}
 
 		c.JSON(200, gin.H{
 			"message": fmt.Sprintf("File '%s' uploaded successfully!", filename),
-			"path":    dst,
+			"path":    key,
 			"url":     fmt.Sprintf("/uploads/%s", filename),
 		})
 	})
 
 	// Debug: list all entity files
 	router.GET("/debug/entity-files", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
-		c.Header("Access-Control-Allow-Credentials", "true")
-
 		type EntityFileInfo struct {
 			EntityID   string   `json:"entity_id"`
 			FilesCount int      `json:"files_count"`
@@ -319,38 +324,36 @@ func main() {
 		}
 		var entityFiles []EntityFileInfo
 
-		entries, err := os.ReadDir(uploadDir)
+		objects, err := store.List(c.Request.Context(), "")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload directory"})
 			return
 		}
 
-		for _, entry := range entries {
-			if entry.IsDir() && entry.Name() != "temp_uploads" {
-				entityDir := filepath.Join(uploadDir, entry.Name())
-				files, _ := os.ReadDir(entityDir)
-				var fileNames []string
-				var totalSize int64
-				for _, f := range files {
-					if !f.IsDir() {
-						fileNames = append(fileNames, f.Name())
-						if info, err := f.Info(); err == nil {
-							totalSize += info.Size()
-						}
-					}
-				}
-				if len(fileNames) > 0 {
-					entityFiles = append(entityFiles, EntityFileInfo{
-						EntityID:   entry.Name(),
-						FilesCount: len(fileNames),
-						Files:      fileNames,
-						TotalSize:  totalSize,
-					})
-				}
+		groups := storage.GroupByTopLevel(objects)
+		entityIDs := make([]string, 0, len(groups))
+		for id := range groups {
+			entityIDs = append(entityIDs, id)
+		}
+		sort.Strings(entityIDs)
+
+		for _, id := range entityIDs {
+			var fileNames []string
+			var totalSize int64
+			for _, obj := range groups[id] {
+				fileNames = append(fileNames, path.Base(obj.Key))
+				totalSize += obj.Size
 			}
+			entityFiles = append(entityFiles, EntityFileInfo{
+				EntityID:   id,
+				FilesCount: len(fileNames),
+				Files:      fileNames,
+				TotalSize:  totalSize,
+			})
 		}
 
 		c.JSON(http.StatusOK, gin.H{
+			"storage_backend":           store.Backend(),
 			"total_entities_with_files": len(entityFiles),
 			"entity_files":              entityFiles,
 		})
@@ -358,22 +361,22 @@ func main() {
 
 	// File info for a specific entity
 	router.GET("/api/v1/entities/:id/files/info", func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
-		c.Header("Access-Control-Allow-Credentials", "true")
-
 		entityID := c.Param("id")
-		entityDir := filepath.Join(uploadDir, entityID)
-
-		if _, err := os.Stat(entityDir); os.IsNotExist(err) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "No files found for this entity"})
+		prefix, err := storage.Key(entityID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid parameters"})
 			return
 		}
 
-		files, err := os.ReadDir(entityDir)
+		files, err := store.List(c.Request.Context(), prefix+"/")
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read entity files"})
 			return
 		}
+		if len(files) == 0 {
+			c.JSON(http.StatusNotFound, gin.H{"error": "No files found for this entity"})
+			return
+		}
 
 		type FileInfo struct {
 			FileName    string `json:"file_name"`
@@ -388,26 +391,21 @@ func main() {
 		var totalSize int64
 
 		for _, file := range files {
-			if !file.IsDir() {
-				info, err := file.Info()
-				if err != nil {
-					continue
-				}
-				ext := strings.ToLower(filepath.Ext(file.Name()))
-				fileType := strings.ToUpper(strings.TrimPrefix(ext, "."))
-				if fileType == "" {
-					fileType = "UNKNOWN"
-				}
-				fileInfos = append(fileInfos, FileInfo{
-					FileName:    file.Name(),
-					Size:        info.Size(),
-					ModTime:     info.ModTime().Format("2006-01-02 15:04:05"),
-					FileType:    fileType,
-					ViewURL:     fmt.Sprintf("/uploads/%s/%s", entityID, file.Name()),
-					DownloadURL: fmt.Sprintf("/api/v1/entities/%s/files/%s", entityID, file.Name()),
-				})
-				totalSize += info.Size()
+			name := strings.TrimPrefix(file.Key, prefix+"/")
+			ext := strings.ToLower(filepath.Ext(name))
+			fileType := strings.ToUpper(strings.TrimPrefix(ext, "."))
+			if fileType == "" {
+				fileType = "UNKNOWN"
 			}
+			fileInfos = append(fileInfos, FileInfo{
+				FileName:    name,
+				Size:        file.Size,
+				ModTime:     file.ModTime.Format("2006-01-02 15:04:05"),
+				FileType:    fileType,
+				ViewURL:     fmt.Sprintf("/uploads/%s/%s", entityID, name),
+				DownloadURL: fmt.Sprintf("/api/v1/entities/%s/files/%s", entityID, name),
+			})
+			totalSize += file.Size
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -420,15 +418,15 @@ func main() {
 	})
 
 	// Register existing routes
-	routes.Setup(router, cfg)
+	routes.Setup(router, cfg, store)
 
 	// Start server
 	fmt.Printf("🚀 Server starting on port %s\n", cfg.Port)
-	fmt.Printf("📁 Upload directory: %s\n", uploadDir)
+	fmt.Printf("📁 File storage: %s (scratch dir: %s)\n", store.Backend(), uploadDir)
 	fmt.Printf("🌐 File access: http://localhost:%s/uploads/{entityID}/{filename}\n", cfg.Port)
 	fmt.Printf("📥 Download file: http://localhost:%s/api/v1/entities/{id}/files/{filename}\n", cfg.Port)
 	fmt.Printf("📦 Bulk download: http://localhost:%s/api/v1/entities/{id}/files-all\n", cfg.Port)
-	fmt.Printf("✅ CORS configured for: localhost:4173, localhost:5173\n")
+	fmt.Printf("✅ CORS configured for: %s\n", strings.Join(cfg.CORSAllowedOrigins, ", "))
 	fmt.Printf("✅ PATCH method enabled for approvals\n")
 	
 	if utils.IsFCMEnabled() {
@@ -440,17 +438,62 @@ func main() {
 		}
 	}
 
-	if err := router.Run(":" + cfg.Port); err != nil {
-		panic(fmt.Sprintf("Failed to start server: %v", err))
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: router,
 	}
+	// SSE streams never finish on their own; end them so Shutdown can drain
+	srv.RegisterOnShutdown(notification.CloseStreams)
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			panic(fmt.Sprintf("Failed to start server: %v", err))
+		}
+	}()
+
+	<-ctx.Done()
+	stop()
+	log.Println("🛑 Shutdown signal received, draining in-flight requests...")
+	shutdown(srv, db, consumerDone, time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
 }
 
-// serveEntityFile handles serving files from entity directories
-func serveEntityFile(c *gin.Context, uploadDir string) {
-	c.Header("Access-Control-Allow-Origin", c.GetHeader("Origin"))
-	c.Header("Access-Control-Allow-Credentials", "true")
-	c.Header("Access-Control-Expose-Headers", "Content-Length, Content-Type, Content-Disposition")
+// shutdown stops accepting requests, waits up to timeout for in-flight ones
+// and the Kafka consumer to finish, then closes Kafka, Redis and the database
+func shutdown(srv *http.Server, db *gorm.DB, consumerDone <-chan struct{}, timeout time.Duration) {
+	health.MarkDraining()
 
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("⚠️ HTTP server did not drain in time: %v", err)
+	} else {
+		log.Println("✅ HTTP server drained")
+	}
+
+	select {
+	case <-consumerDone:
+		log.Println("✅ Kafka consumer stopped")
+	case <-ctx.Done():
+		log.Println("⚠️ Kafka consumer did not stop in time")
+	}
+
+	if err := utils.CloseKafka(); err != nil {
+		log.Printf("⚠️ Kafka writer close error: %v", err)
+	}
+	if err := utils.CloseRedis(); err != nil {
+		log.Printf("⚠️ Redis close error: %v", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			log.Printf("⚠️ Database close error: %v", err)
+		}
+	}
+	log.Println("👋 Server stopped")
+}
+
+// serveEntityFile handles serving files from entity directories
+func serveEntityFile(c *gin.Context, store storage.Storage, cacheControl string) {
 	entityID := c.Param("entityID")
 	filename := c.Param("filename")
 
@@ -459,10 +502,8 @@ func serveEntityFile(c *gin.Context, uploadDir string) {
 		return
 	}
 
-	filePath := filepath.Join(uploadDir, entityID, filename)
-	cleanPath := filepath.Clean(filePath)
-
-	if !strings.HasPrefix(cleanPath, filepath.Clean(uploadDir)) {
+	key, err := storage.Key(entityID, filename)
+	if err != nil {
 		c.JSON(http.StatusForbidden, gin.H{
 			"error":   "Access denied",
 			"message": "Invalid file path",
@@ -470,12 +511,12 @@ func serveEntityFile(c *gin.Context, uploadDir string) {
 		return
 	}
 
-	fileInfo, err := os.Stat(cleanPath)
-	if os.IsNotExist(err) {
+	reader, info, err := store.Get(c.Request.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "File not found",
 			"message": "The requested file does not exist or has been moved",
-			"path":    filepath.Join("/uploads", entityID, filename),
+			"path":    path.Join("/uploads", entityID, filename),
 		})
 		return
 	}
@@ -487,18 +528,19 @@ func serveEntityFile(c *gin.Context, uploadDir string) {
 		})
 		return
 	}
+	defer reader.Close()
 
 	contentType := setContentType(c, filename)
-	c.Header("Content-Length", fmt.Sprintf("%d", fileInfo.Size()))
-	c.Header("Cache-Control", "public, max-age=3600")
-
+	disposition := fmt.Sprintf("attachment; filename=\"%s\"", filename)
 	if strings.HasPrefix(contentType, "image/") || contentType == "application/pdf" {
-		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=\"%s\"", filename))
-	} else {
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		disposition = fmt.Sprintf("inline; filename=\"%s\"", filename)
 	}
 
-	c.File(cleanPath)
+	// Range requests let viewers fetch large PDFs and videos in parts; the
+	// ETag lets browsers revalidate instead of downloading again
+	c.Header("Cache-Control", cacheControl)
+	c.Header("Content-Disposition", disposition)
+	storage.ServeObject(c.Writer, c.Request, reader, info, contentType)
 	log.Printf("✅ File served: %s/%s", entityID, filename)
 }
 
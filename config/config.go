@@ -4,14 +4,38 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+// StorageRegion is a region-specific file store for tenants whose documents
+// must stay in that region (data residency)
+type StorageRegion struct {
+	S3Endpoint string // defaults to S3_ENDPOINT
+	S3Region   string
+	S3Bucket   string
+	UploadDir  string // local backend root, defaults to <UPLOAD_DIR>-<region>
+}
+
 // ✅ Global constants (accessible from other packages)
 var UploadPath = "./uploads"
 var BaseURL = "http://localhost:8080"
 
+// RateLimit allows Limit requests per Period; a zero Limit disables it
+type RateLimit struct {
+	Limit  int
+	Period time.Duration
+}
+
+// APIDeprecation marks a route, or every route under a path prefix, as
+// slated for removal
+type APIDeprecation struct {
+	Sunset    time.Time // when it stops working
+	Successor string    // where clients should move, e.g. /api/v2/...
+}
+
 type Config struct {
 	Port string
 
@@ -25,6 +49,14 @@ type Config struct {
 	JWTRefreshSecret   string
 	JWTAccessTTLHours  int
 	JWTRefreshTTLHours int
+	// Takes precedence over JWTAccessTTLHours; access tokens default to 15 minutes
+	JWTAccessTTLMinutes int
+
+	// Signs event calendar (iCal) subscription URLs; defaults to JWTAccessSecret
+	CalendarFeedSecret string
+
+	// Signs booking and RSVP check-in QR codes; defaults to JWTAccessSecret
+	CheckInSecret string
 
 	// ✅ Redis Config
 	RedisAddr     string
@@ -32,8 +64,14 @@ type Config struct {
 	RedisDB       int
 
 	// ✅ Razorpay Keys
-	RazorpayKey    string
-	RazorpaySecret string
+	RazorpayKey           string
+	RazorpaySecret        string
+	RazorpayWebhookSecret string // Secret configured on the Razorpay dashboard webhook
+
+	// Razorpay test mode keys, used for payments of sandbox tenants
+	RazorpayTestKey           string
+	RazorpayTestSecret        string
+	RazorpayTestWebhookSecret string
 
 	// ✅ SMTP Config
 	SMTPHost      string
@@ -43,9 +81,150 @@ type Config struct {
 	SMTPFromName  string
 	SMTPFromEmail string
 
+	// ✅ Email Channel (transactional emails: approvals, bookings, receipts)
+	EmailProvider      string // "smtp" (default) or "ses"; without either configured emails are only logged
+	SESRegion          string // e.g. ap-south-1
+	SESAccessKey       string
+	SESSecretKey       string
+	EmailSenderDomains []string // domains verified with the provider; temples may only send from addresses on them
+	FrontendURL        string   // linked from emails
+
+	// ✅ SMS/WhatsApp Channel (booking confirmations, OTPs, event reminders).
+	// These are the platform gateways; temples may configure their own account.
+	SMSProvider             string // "twilio", "msg91" or "gupshup"; empty only logs messages
+	WhatsAppProvider        string // "twilio" or "gupshup"; empty disables WhatsApp
+	TwilioAccountSID        string
+	TwilioAuthToken         string
+	TwilioSMSFrom           string // sending number or messaging service SID
+	TwilioWhatsAppFrom      string // WhatsApp-enabled number, e.g. +14155238886
+	MSG91AuthKey            string
+	MSG91SenderID           string // 6 character DLT sender ID
+	GupshupAPIKey           string // WhatsApp API
+	GupshupAppName          string
+	GupshupWhatsAppSource   string // WhatsApp business number
+	GupshupSMSUserID        string // enterprise SMS API
+	GupshupSMSPassword      string
+	MessagingCredentialsKey string // 32 bytes hex/base64; encrypts temples' gateway credentials
+	MessagingCallbackSecret string // signs delivery report URLs; defaults to JWTAccessSecret
+	PublicAPIURL            string // base URL providers reach this API on, for delivery reports
+
+	// ✅ Event Reminders
+	EventReminderOffsets  []time.Duration // how long before an RSVP'd event reminders go out, default 24h and 1h
+	EventReminderChannels []string        // push, inapp, email and/or sms (WhatsApp first, else SMS)
+
 	// ✅ FCM Config
 	FCMCredentialsPath string // Path to Firebase service account JSON
 	FCMProjectID       string // Firebase Project ID (optional, can be in JSON)
+
+	// ✅ File Storage Config
+	StorageBackend string // "local" (default) or "s3"
+	UploadDir      string // Local upload root / scratch space for temp uploads
+	S3Endpoint     string // e.g. s3.amazonaws.com or minio:9000
+	S3Region       string
+	S3Bucket       string
+	S3AccessKey    string
+	S3SecretKey    string
+	S3UseSSL       bool
+	S3Prefix       string // Optional key prefix inside the bucket
+	StorageQuotaMB int64  // Default file storage quota of each temple, 0 = unlimited; superadmins can override it per temple
+
+	// Cache-Control sent with entity files served from /uploads and /files;
+	// ETags let clients revalidate them once it expires
+	FileCacheControl string
+
+	// ✅ Malware Scanning (uploaded documents)
+	ScannerBackend        string // "none" (default), "clamav" or "http"
+	ClamAVAddress         string // clamd "host:port" or unix socket path, default localhost:3310
+	ScannerAPIURL         string // external scanning API for the http backend
+	ScannerAPIKey         string
+	ScannerTimeoutSeconds int  // per file, default 60
+	ScannerFailOpen       bool // accept files unscanned while the scanner is down instead of rejecting them
+
+	// ✅ API Versioning
+	// Endpoints slated for removal, keyed by "METHOD /route" or a path prefix
+	// ("/api/v1"); they answer with Deprecation, Sunset and Link headers
+	APIDeprecations map[string]APIDeprecation
+
+	// ✅ API Docs (Swagger UI and OpenAPI spec at /api/docs)
+	APIDocsEnabled  bool   // off unless API_DOCS_ENABLED=true
+	APIDocsUser     string // HTTP basic auth for the docs, default "docs"
+	APIDocsPassword string // basic auth is required when set
+
+	// ✅ Export Encryption
+	ExportMasterKey string // 32 bytes hex/base64; per-tenant export keys are derived from it
+
+	// ✅ Field Encryption (devotee DOB, phone and address at rest)
+	// id:key pairs, current key first, e.g. "k2:<hex>,k1:<hex>"; read from
+	// FIELD_ENCRYPTION_KEYS or from the file FIELD_ENCRYPTION_KEYS_FILE names,
+	// e.g. a secret mounted from the KMS
+	FieldEncryptionKeys string
+	// 32 bytes hex/base64 keying the blind indexes that encrypted phone
+	// numbers are looked up by; required with FIELD_ENCRYPTION_KEYS and never rotated
+	FieldBlindIndexKey string
+
+	// ✅ Async Report Jobs
+	ReportWorkers         int // Number of background report workers
+	ReportRetentionHours  int // How long generated report files are kept
+	ReportJobsPerTenant   int // Jobs of one tenant that may run at the same time
+	ReportCacheTTLSeconds int // How long JSON report previews are cached in Redis, 0 disables caching
+
+	// ✅ Report Date Ranges
+	ReportMaxRangeDays       int            // Longest span any report may cover (0 = built-in default)
+	ReportMaxRangeDaysByType map[string]int // Per report type overrides, e.g. audit-logs=90
+
+	// Export formats each role may download per report type ("*" = any report);
+	// roles without rules may use every format
+	ReportAllowedFormats map[string]map[string][]string
+
+	// Column classes (phone, email, address) masked in each role's report
+	// previews and exports, e.g. phone -> XXXXXX1234
+	ReportMaskedColumns map[string][]string
+
+	// ✅ Kafka Producer
+	KafkaBufferLimit int // Notifications held in the database while Kafka is down
+
+	// ✅ Geocoding (fills temple city/district from map link coordinates, and
+	// coordinates from the address of temples without a map pin)
+	GeocoderURL       string // Nominatim compatible base URL; empty disables geocoding
+	GeocoderUserAgent string // Sent with geocoding requests, as Nominatim's usage policy requires
+
+	// ✅ Graceful Shutdown
+	ShutdownTimeoutSeconds int // Time given to in-flight requests after SIGTERM; keep below the pod's termination grace period
+
+	// ✅ Announcement Translations
+	DefaultLanguage   string // Language of template content and fallback for recipients without a preference
+	ReportFontDir     string // Noto Sans script fonts for Kannada, Tamil, Telugu and Hindi PDFs
+	TranslationAPIURL string // LibreTranslate compatible base URL; empty disables machine-translated drafts
+	TranslationAPIKey string
+
+	// ✅ Tenant Self-Registration
+	CaptchaSecret         string // reCAPTCHA/hCaptcha secret; empty skips captcha checks (local development)
+	CaptchaVerifyURL      string // siteverify endpoint of the captcha provider
+	TenantRegistrationOTP bool   // when true, /auth/register rejects templeadmin sign-ups without verified email and phone
+
+	// ✅ Metrics
+	MetricsToken string // bearer token Prometheus must send to /metrics; empty leaves it open (restrict at the ingress)
+
+	// ✅ CORS
+	CORSAllowedOrigins []string // exact origins or one-wildcard patterns such as https://*.example.com
+
+	// ✅ Login Lockout
+	LoginMaxFailures    int // failed passwords before an account is locked
+	LoginLockoutMinutes int // lock duration, also the window failures are counted in
+	LoginIPMaxFailures  int // failed logins from one IP, across accounts, before the IP is blocked
+
+	// ✅ Rate Limits
+	RateLimits map[string]RateLimit // per route group (login, report-export, upload, public, api-key), counted in Redis
+
+	// ✅ Idempotency Keys
+	IdempotencyTTLHours int // how long responses to Idempotency-Key requests are replayed, default 24
+
+	// ✅ Data Residency
+	StorageRegions map[string]StorageRegion // by region code, e.g. "in"; tenants tagged with a region only use its store
+
+	// ✅ Response Compression
+	CompressMinBytes int              // smallest response body worth compressing, -1 disables
+	ResponseBudgets  map[string]int64 // payload budget in bytes by route template, "default" for the rest
 }
 
 // Load reads environment variables and returns a Config object
@@ -55,8 +234,312 @@ func Load() *Config {
 	}
 
 	accessTTL, _ := strconv.Atoi(os.Getenv("JWT_ACCESS_TTL_HOURS"))
+	accessTTLMinutes, _ := strconv.Atoi(os.Getenv("JWT_ACCESS_TTL_MINUTES"))
 	refreshTTL, _ := strconv.Atoi(os.Getenv("JWT_REFRESH_TTL_HOURS"))
+
+	calendarFeedSecret := os.Getenv("CALENDAR_FEED_SECRET")
+	if calendarFeedSecret == "" {
+		calendarFeedSecret = os.Getenv("JWT_ACCESS_SECRET")
+	}
+	checkInSecret := os.Getenv("CHECKIN_QR_SECRET")
+	if checkInSecret == "" {
+		checkInSecret = os.Getenv("JWT_ACCESS_SECRET")
+	}
 	redisDB, _ := strconv.Atoi(os.Getenv("REDIS_DB"))
+	s3UseSSL, _ := strconv.ParseBool(os.Getenv("S3_USE_SSL"))
+	storageQuotaMB, _ := strconv.ParseInt(os.Getenv("STORAGE_QUOTA_MB"), 10, 64)
+	fileCacheControl := os.Getenv("FILE_CACHE_CONTROL")
+	if fileCacheControl == "" {
+		fileCacheControl = "public, max-age=3600"
+	}
+	scannerTimeout, _ := strconv.Atoi(os.Getenv("SCANNER_TIMEOUT_SECONDS"))
+	apiDocsEnabled, _ := strconv.ParseBool(os.Getenv("API_DOCS_ENABLED"))
+	apiDocsUser := os.Getenv("API_DOCS_USER")
+	if apiDocsUser == "" {
+		apiDocsUser = "docs"
+	}
+	scannerFailOpen, _ := strconv.ParseBool(os.Getenv("SCANNER_FAIL_OPEN"))
+
+	reportWorkers, _ := strconv.Atoi(os.Getenv("REPORT_WORKERS"))
+	if reportWorkers <= 0 {
+		reportWorkers = 2
+	}
+	reportRetention, _ := strconv.Atoi(os.Getenv("REPORT_RETENTION_HOURS"))
+	if reportRetention <= 0 {
+		reportRetention = 24
+	}
+	reportJobsPerTenant, _ := strconv.Atoi(os.Getenv("REPORT_JOBS_PER_TENANT"))
+	if reportJobsPerTenant <= 0 {
+		reportJobsPerTenant = 1
+	}
+
+	// REPORT_CACHE_TTL_SECONDS=0 turns preview caching off
+	reportCacheTTL := 300
+	if v, err := strconv.Atoi(os.Getenv("REPORT_CACHE_TTL_SECONDS")); err == nil && v >= 0 {
+		reportCacheTTL = v
+	}
+
+	reportMaxRangeDays, _ := strconv.Atoi(os.Getenv("REPORT_MAX_RANGE_DAYS"))
+
+	// REPORT_MAX_RANGE_DAYS_BY_TYPE="audit-logs=90,donations=730"
+	reportMaxRangeByType := map[string]int{}
+	for _, pair := range strings.Split(os.Getenv("REPORT_MAX_RANGE_DAYS_BY_TYPE"), ",") {
+		reportType, days, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimSpace(days)); err == nil && n > 0 {
+			reportMaxRangeByType[strings.TrimSpace(reportType)] = n
+		}
+	}
+
+	// REPORT_ALLOWED_FORMATS="standarduser:devotee-list=pdf|excel;monitoringuser:*=pdf"
+	reportAllowedFormats := map[string]map[string][]string{}
+	for _, rule := range strings.Split(os.Getenv("REPORT_ALLOWED_FORMATS"), ";") {
+		target, formats, ok := strings.Cut(strings.TrimSpace(rule), "=")
+		if !ok {
+			continue
+		}
+		role, reportType, ok := strings.Cut(target, ":")
+		if !ok {
+			continue
+		}
+		role, reportType = strings.TrimSpace(role), strings.TrimSpace(reportType)
+		if reportAllowedFormats[role] == nil {
+			reportAllowedFormats[role] = map[string][]string{}
+		}
+		allowed := []string{}
+		for _, f := range strings.Split(formats, "|") {
+			if f = strings.TrimSpace(f); f != "" {
+				allowed = append(allowed, f)
+			}
+		}
+		reportAllowedFormats[role][reportType] = allowed
+	}
+
+	// REPORT_MASKED_COLUMNS="monitoringuser=phone|email;standarduser=phone";
+	// a role listed without classes sees every column
+	maskedColumnsEnv, ok := os.LookupEnv("REPORT_MASKED_COLUMNS")
+	if !ok {
+		maskedColumnsEnv = "monitoringuser=phone|email"
+	}
+	reportMaskedColumns := map[string][]string{}
+	for _, rule := range strings.Split(maskedColumnsEnv, ";") {
+		role, classes, ok := strings.Cut(strings.TrimSpace(rule), "=")
+		if !ok {
+			continue
+		}
+		masked := []string{}
+		for _, class := range strings.Split(classes, "|") {
+			if class = strings.TrimSpace(class); class != "" {
+				masked = append(masked, class)
+			}
+		}
+		reportMaskedColumns[strings.TrimSpace(role)] = masked
+	}
+
+	kafkaBufferLimit, _ := strconv.Atoi(os.Getenv("KAFKA_BUFFER_LIMIT"))
+	if kafkaBufferLimit <= 0 {
+		kafkaBufferLimit = 10000
+	}
+
+	geocoderUserAgent := os.Getenv("GEOCODER_USER_AGENT")
+	if geocoderUserAgent == "" {
+		geocoderUserAgent = "temple-management-backend"
+	}
+
+	shutdownTimeout, _ := strconv.Atoi(os.Getenv("SHUTDOWN_TIMEOUT_SECONDS"))
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 25
+	}
+
+	captchaVerifyURL := os.Getenv("CAPTCHA_VERIFY_URL")
+	if captchaVerifyURL == "" {
+		captchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+	}
+
+	defaultLanguage := strings.ToLower(strings.TrimSpace(os.Getenv("DEFAULT_LANGUAGE")))
+	if defaultLanguage == "" {
+		defaultLanguage = "en"
+	}
+	reportFontDir := os.Getenv("REPORT_FONT_DIR")
+	if reportFontDir == "" {
+		reportFontDir = "/usr/share/fonts/noto"
+	}
+
+	// CORS_ALLOWED_ORIGINS=https://app.example.com,https://*.example.com
+	var corsAllowedOrigins []string
+	for _, origin := range strings.Split(os.Getenv("CORS_ALLOWED_ORIGINS"), ",") {
+		if origin = strings.TrimRight(strings.TrimSpace(origin), "/"); origin != "" {
+			corsAllowedOrigins = append(corsAllowedOrigins, origin)
+		}
+	}
+	if len(corsAllowedOrigins) == 0 {
+		corsAllowedOrigins = []string{"http://localhost:5173", "http://127.0.0.1:5173", "http://localhost:4173", "http://127.0.0.1:4173"}
+	}
+
+	loginMaxFailures, _ := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILURES"))
+	if loginMaxFailures <= 0 {
+		loginMaxFailures = 5
+	}
+	loginLockoutMinutes, _ := strconv.Atoi(os.Getenv("LOGIN_LOCKOUT_MINUTES"))
+	if loginLockoutMinutes <= 0 {
+		loginLockoutMinutes = 15
+	}
+	loginIPMaxFailures, _ := strconv.Atoi(os.Getenv("LOGIN_IP_MAX_FAILURES"))
+	if loginIPMaxFailures <= 0 {
+		loginIPMaxFailures = 20
+	}
+
+	// RATE_LIMITS=login=10/15m,report-export=30/1h,upload=60/1h,public=120/1m,api-key=60/1m overrides the defaults per group
+	rateLimits := map[string]RateLimit{
+		"login":         {Limit: 10, Period: 15 * time.Minute},
+		"report-export": {Limit: 30, Period: time.Hour},
+		"upload":        {Limit: 60, Period: time.Hour},
+		"public":        {Limit: 120, Period: time.Minute},
+		"api-key":       {Limit: 60, Period: time.Minute},
+	}
+	for _, pair := range strings.Split(os.Getenv("RATE_LIMITS"), ",") {
+		group, rule, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		limit, period, ok := strings.Cut(rule, "/")
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		d, dErr := time.ParseDuration(strings.TrimSpace(period))
+		if err != nil || dErr != nil || n < 0 || d <= 0 {
+			log.Printf("⚠️ Ignoring invalid RATE_LIMITS entry %q", pair)
+			continue
+		}
+		rateLimits[strings.TrimSpace(group)] = RateLimit{Limit: n, Period: d}
+	}
+
+	// API_DEPRECATIONS="GET /api/v1/entities/:id/files-all=2027-06-30 /api/v1/entities/:id/files,/api/v1/reports=2027-12-31"
+	// sunsets a route or a prefix on a date, optionally naming its successor
+	apiDeprecations := map[string]APIDeprecation{}
+	for _, pair := range strings.Split(os.Getenv("API_DEPRECATIONS"), ",") {
+		route, rule, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(rule)
+		if len(fields) == 0 || len(fields) > 2 {
+			log.Printf("⚠️ Ignoring invalid API_DEPRECATIONS entry %q", pair)
+			continue
+		}
+		sunset, err := time.Parse("2006-01-02", fields[0])
+		if err != nil {
+			log.Printf("⚠️ Ignoring invalid API_DEPRECATIONS entry %q", pair)
+			continue
+		}
+		d := APIDeprecation{Sunset: sunset}
+		if len(fields) == 2 {
+			d.Successor = fields[1]
+		}
+		apiDeprecations[strings.Join(strings.Fields(route), " ")] = d
+	}
+
+	// EMAIL_SENDER_DOMAINS=mytemple.org,temples.example.com
+	var emailSenderDomains []string
+	for _, domain := range strings.Split(os.Getenv("EMAIL_SENDER_DOMAINS"), ",") {
+		if domain = strings.ToLower(strings.TrimSpace(domain)); domain != "" {
+			emailSenderDomains = append(emailSenderDomains, domain)
+		}
+	}
+
+	messagingCallbackSecret := os.Getenv("MESSAGING_CALLBACK_SECRET")
+	if messagingCallbackSecret == "" {
+		messagingCallbackSecret = os.Getenv("JWT_ACCESS_SECRET")
+	}
+
+	// EVENT_REMINDER_OFFSETS=24h,1h EVENT_REMINDER_CHANNELS=push,inapp,email
+	eventReminderOffsets := []time.Duration{24 * time.Hour, time.Hour}
+	if v := os.Getenv("EVENT_REMINDER_OFFSETS"); v != "" {
+		var offsets []time.Duration
+		for _, part := range strings.Split(v, ",") {
+			d, err := time.ParseDuration(strings.TrimSpace(part))
+			if err != nil || d <= 0 {
+				log.Printf("⚠️ Ignoring invalid EVENT_REMINDER_OFFSETS entry %q", part)
+				continue
+			}
+			offsets = append(offsets, d)
+		}
+		eventReminderOffsets = offsets
+	}
+	eventReminderChannels := []string{"push", "inapp", "email", "sms"}
+	if v := os.Getenv("EVENT_REMINDER_CHANNELS"); v != "" {
+		eventReminderChannels = nil
+		for _, ch := range strings.Split(v, ",") {
+			if ch = strings.ToLower(strings.TrimSpace(ch)); ch != "" {
+				eventReminderChannels = append(eventReminderChannels, ch)
+			}
+		}
+	}
+
+	idempotencyTTL, _ := strconv.Atoi(os.Getenv("IDEMPOTENCY_TTL_HOURS"))
+	if idempotencyTTL <= 0 {
+		idempotencyTTL = 24
+	}
+
+	compressMinBytes := 1024
+	if v, err := strconv.Atoi(os.Getenv("COMPRESS_MIN_BYTES")); err == nil {
+		compressMinBytes = v
+	}
+
+	// RESPONSE_BUDGETS=default=1048576,/api/v1/superadmin/reports/audit-logs=5242880
+	// counts responses over budget per route (tms_http_responses_over_budget_total)
+	responseBudgets := map[string]int64{"default": 1 << 20}
+	for _, pair := range strings.Split(os.Getenv("RESPONSE_BUDGETS"), ",") {
+		route, limit, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(strings.TrimSpace(limit), 10, 64)
+		if err != nil || n <= 0 {
+			log.Printf("⚠️ Ignoring invalid RESPONSE_BUDGETS entry %q", pair)
+			continue
+		}
+		responseBudgets[strings.TrimSpace(route)] = n
+	}
+
+	uploadDir := os.Getenv("UPLOAD_DIR")
+	if uploadDir == "" {
+		uploadDir = "/data/uploads"
+	}
+
+	// STORAGE_REGIONS=in lists the residency regions; each reads
+	// S3_BUCKET_<REGION>, S3_REGION_<REGION>, S3_ENDPOINT_<REGION> and
+	// UPLOAD_DIR_<REGION>, e.g. S3_BUCKET_IN=tms-docs-mumbai
+	storageRegions := map[string]StorageRegion{}
+	for _, code := range strings.Split(os.Getenv("STORAGE_REGIONS"), ",") {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if code == "" {
+			continue
+		}
+		suffix := "_" + strings.ToUpper(code)
+		region := StorageRegion{
+			S3Endpoint: os.Getenv("S3_ENDPOINT" + suffix),
+			S3Region:   os.Getenv("S3_REGION" + suffix),
+			S3Bucket:   os.Getenv("S3_BUCKET" + suffix),
+			UploadDir:  os.Getenv("UPLOAD_DIR" + suffix),
+		}
+		if region.UploadDir == "" {
+			region.UploadDir = strings.TrimRight(uploadDir, "/") + "-" + code
+		}
+		storageRegions[code] = region
+	}
+
+	fieldEncryptionKeys := os.Getenv("FIELD_ENCRYPTION_KEYS")
+	if path := os.Getenv("FIELD_ENCRYPTION_KEYS_FILE"); fieldEncryptionKeys == "" && path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("⚠️ Cannot read FIELD_ENCRYPTION_KEYS_FILE: %v", err)
+		}
+		fieldEncryptionKeys = strings.TrimSpace(string(raw))
+	}
 
 	return &Config{
 		Port: os.Getenv("PORT"),
@@ -67,17 +550,26 @@ func Load() *Config {
 		DBPassword: os.Getenv("DB_PASSWORD"),
 		DBName:     os.Getenv("DB_NAME"),
 
-		JWTAccessSecret:    os.Getenv("JWT_ACCESS_SECRET"),
-		JWTRefreshSecret:   os.Getenv("JWT_REFRESH_SECRET"),
-		JWTAccessTTLHours:  accessTTL,
-		JWTRefreshTTLHours: refreshTTL,
+		JWTAccessSecret:     os.Getenv("JWT_ACCESS_SECRET"),
+		JWTRefreshSecret:    os.Getenv("JWT_REFRESH_SECRET"),
+		JWTAccessTTLHours:   accessTTL,
+		JWTRefreshTTLHours:  refreshTTL,
+		JWTAccessTTLMinutes: accessTTLMinutes,
+
+		CalendarFeedSecret: calendarFeedSecret,
+		CheckInSecret:      checkInSecret,
 
 		RedisAddr:     os.Getenv("REDIS_ADDR"),
 		RedisPassword: os.Getenv("REDIS_PASSWORD"),
 		RedisDB:       redisDB,
 
-		RazorpayKey:    os.Getenv("RAZORPAY_KEY_ID"),
-		RazorpaySecret: os.Getenv("RAZORPAY_KEY_SECRET"),
+		RazorpayKey:           os.Getenv("RAZORPAY_KEY_ID"),
+		RazorpaySecret:        os.Getenv("RAZORPAY_KEY_SECRET"),
+		RazorpayWebhookSecret: os.Getenv("RAZORPAY_WEBHOOK_SECRET"),
+
+		RazorpayTestKey:           os.Getenv("RAZORPAY_TEST_KEY_ID"),
+		RazorpayTestSecret:        os.Getenv("RAZORPAY_TEST_KEY_SECRET"),
+		RazorpayTestWebhookSecret: os.Getenv("RAZORPAY_TEST_WEBHOOK_SECRET"),
 
 		SMTPHost:      os.Getenv("SMTP_HOST"),
 		SMTPPort:      os.Getenv("SMTP_PORT"),
@@ -86,7 +578,108 @@ func Load() *Config {
 		SMTPFromName:  os.Getenv("SMTP_FROM_NAME"),
 		SMTPFromEmail: os.Getenv("SMTP_FROM_EMAIL"),
 
+		EmailProvider:      strings.ToLower(strings.TrimSpace(os.Getenv("EMAIL_PROVIDER"))),
+		SESRegion:          os.Getenv("SES_REGION"),
+		SESAccessKey:       os.Getenv("SES_ACCESS_KEY"),
+		SESSecretKey:       os.Getenv("SES_SECRET_KEY"),
+		EmailSenderDomains: emailSenderDomains,
+		FrontendURL:        strings.TrimSuffix(os.Getenv("FRONTEND_URL"), "/"),
+
+		SMSProvider:             strings.ToLower(strings.TrimSpace(os.Getenv("SMS_PROVIDER"))),
+		WhatsAppProvider:        strings.ToLower(strings.TrimSpace(os.Getenv("WHATSAPP_PROVIDER"))),
+		TwilioAccountSID:        os.Getenv("TWILIO_ACCOUNT_SID"),
+		TwilioAuthToken:         os.Getenv("TWILIO_AUTH_TOKEN"),
+		TwilioSMSFrom:           os.Getenv("TWILIO_SMS_FROM"),
+		TwilioWhatsAppFrom:      os.Getenv("TWILIO_WHATSAPP_FROM"),
+		MSG91AuthKey:            os.Getenv("MSG91_AUTH_KEY"),
+		MSG91SenderID:           os.Getenv("MSG91_SENDER_ID"),
+		GupshupAPIKey:           os.Getenv("GUPSHUP_API_KEY"),
+		GupshupAppName:          os.Getenv("GUPSHUP_APP_NAME"),
+		GupshupWhatsAppSource:   os.Getenv("GUPSHUP_WHATSAPP_SOURCE"),
+		GupshupSMSUserID:        os.Getenv("GUPSHUP_SMS_USER_ID"),
+		GupshupSMSPassword:      os.Getenv("GUPSHUP_SMS_PASSWORD"),
+		MessagingCredentialsKey: os.Getenv("MESSAGING_CREDENTIALS_KEY"),
+		MessagingCallbackSecret: messagingCallbackSecret,
+		PublicAPIURL:            strings.TrimSuffix(os.Getenv("PUBLIC_API_URL"), "/"),
+
+		EventReminderOffsets:  eventReminderOffsets,
+		EventReminderChannels: eventReminderChannels,
+
 		FCMCredentialsPath: os.Getenv("FCM_CREDENTIALS_PATH"),
 		FCMProjectID:       os.Getenv("FCM_PROJECT_ID"),
+
+		ExportMasterKey: os.Getenv("EXPORT_MASTER_KEY"),
+
+		FieldEncryptionKeys: fieldEncryptionKeys,
+		FieldBlindIndexKey:  os.Getenv("FIELD_BLIND_INDEX_KEY"),
+
+		StorageBackend: os.Getenv("STORAGE_BACKEND"),
+		UploadDir:      uploadDir,
+		S3Endpoint:     os.Getenv("S3_ENDPOINT"),
+		S3Region:       os.Getenv("S3_REGION"),
+		S3Bucket:       os.Getenv("S3_BUCKET"),
+		S3AccessKey:    os.Getenv("S3_ACCESS_KEY"),
+		S3SecretKey:    os.Getenv("S3_SECRET_KEY"),
+		S3UseSSL:       s3UseSSL,
+		S3Prefix:       os.Getenv("S3_PREFIX"),
+		StorageQuotaMB: storageQuotaMB,
+
+		FileCacheControl: fileCacheControl,
+
+		ScannerBackend:        os.Getenv("SCANNER_BACKEND"),
+		ClamAVAddress:         os.Getenv("CLAMAV_ADDRESS"),
+		ScannerAPIURL:         os.Getenv("SCANNER_API_URL"),
+		ScannerAPIKey:         os.Getenv("SCANNER_API_KEY"),
+		ScannerTimeoutSeconds: scannerTimeout,
+		ScannerFailOpen:       scannerFailOpen,
+
+		APIDeprecations: apiDeprecations,
+
+		APIDocsEnabled:  apiDocsEnabled,
+		APIDocsUser:     apiDocsUser,
+		APIDocsPassword: os.Getenv("API_DOCS_PASSWORD"),
+
+		ReportWorkers:         reportWorkers,
+		ReportRetentionHours:  reportRetention,
+		ReportJobsPerTenant:   reportJobsPerTenant,
+		ReportCacheTTLSeconds: reportCacheTTL,
+
+		ReportMaxRangeDays:       reportMaxRangeDays,
+		ReportMaxRangeDaysByType: reportMaxRangeByType,
+		ReportAllowedFormats:     reportAllowedFormats,
+		ReportMaskedColumns:      reportMaskedColumns,
+
+		KafkaBufferLimit: kafkaBufferLimit,
+
+		GeocoderURL:       os.Getenv("GEOCODER_URL"),
+		GeocoderUserAgent: geocoderUserAgent,
+
+		ShutdownTimeoutSeconds: shutdownTimeout,
+
+		DefaultLanguage:   defaultLanguage,
+		ReportFontDir:     reportFontDir,
+		TranslationAPIURL: os.Getenv("TRANSLATION_API_URL"),
+		TranslationAPIKey: os.Getenv("TRANSLATION_API_KEY"),
+
+		CaptchaSecret:         os.Getenv("CAPTCHA_SECRET"),
+		CaptchaVerifyURL:      captchaVerifyURL,
+		TenantRegistrationOTP: os.Getenv("TENANT_REGISTRATION_REQUIRE_OTP") == "true",
+
+		MetricsToken: os.Getenv("METRICS_TOKEN"),
+
+		CORSAllowedOrigins: corsAllowedOrigins,
+
+		LoginMaxFailures:    loginMaxFailures,
+		LoginLockoutMinutes: loginLockoutMinutes,
+		LoginIPMaxFailures:  loginIPMaxFailures,
+
+		RateLimits: rateLimits,
+
+		IdempotencyTTLHours: idempotencyTTL,
+
+		StorageRegions: storageRegions,
+
+		CompressMinBytes: compressMinBytes,
+		ResponseBudgets:  responseBudgets,
 	}
-}
\ No newline at end of file
+}
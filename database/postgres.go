@@ -9,13 +9,34 @@ import (
 	"gorm.io/gorm"
 
 	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/apikey"
+	"github.com/sharath018/temple-management-backend/internal/apiusage"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/campaign"
+	"github.com/sharath018/temple-management-backend/internal/coupon"
+	"github.com/sharath018/temple-management-backend/internal/delegation"
+	"github.com/sharath018/temple-management-backend/internal/dispute"
 	"github.com/sharath018/temple-management-backend/internal/donation"
 	"github.com/sharath018/temple-management-backend/internal/entity"
 	"github.com/sharath018/temple-management-backend/internal/event"
+	"github.com/sharath018/temple-management-backend/internal/expense"
+	"github.com/sharath018/temple-management-backend/internal/family"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/greeting"
+	"github.com/sharath018/temple-management-backend/internal/hundi"
+	"github.com/sharath018/temple-management-backend/internal/inventory"
+	"github.com/sharath018/temple-management-backend/internal/migration"
+	"github.com/sharath018/temple-management-backend/internal/pledge"
+	"github.com/sharath018/temple-management-backend/internal/privacy"
 	"github.com/sharath018/temple-management-backend/internal/seva"
+	"github.com/sharath018/temple-management-backend/internal/shop"
+	"github.com/sharath018/temple-management-backend/internal/superadmin"
 	"github.com/sharath018/temple-management-backend/internal/userprofile"
+	"github.com/sharath018/temple-management-backend/internal/volunteer"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/publicpage"
+	"github.com/sharath018/temple-management-backend/internal/reports"
+	"github.com/sharath018/temple-management-backend/internal/search"
 	"github.com/sharath018/temple-management-backend/internal/auditlog" // ✅ Add this
 )
 
@@ -41,6 +62,25 @@ func Connect(cfg *config.Config) *gorm.DB {
 		log.Fatalf("❌ Could not connect to database: %v", err)
 	}
 
+	// 🔐 Keys of encrypted columns, needed before any row is read or written
+	keys, err := fieldcrypto.ParseKeys(cfg.FieldEncryptionKeys)
+	if err != nil {
+		log.Fatalf("❌ Invalid field encryption keys: %v", err)
+	}
+	if keys == nil {
+		log.Println("⚠️ FIELD_ENCRYPTION_KEYS not set, devotee DOB, phone and address are stored unencrypted")
+	}
+	indexKey, err := fieldcrypto.ParseIndexKey(cfg.FieldBlindIndexKey)
+	if err != nil {
+		log.Fatalf("❌ Invalid FIELD_BLIND_INDEX_KEY: %v", err)
+	}
+	if keys != nil && indexKey == nil {
+		// Unkeyed indexes hold phone numbers in plaintext
+		log.Fatalf("❌ FIELD_BLIND_INDEX_KEY is required when FIELD_ENCRYPTION_KEYS is set")
+	}
+	fieldcrypto.SetKeys(keys)
+	fieldcrypto.SetIndexKey(indexKey)
+
 	// ✅ Migrate all required models
 // ✅ Migrate all required models
 if err := DB.AutoMigrate(
@@ -49,13 +89,55 @@ if err := DB.AutoMigrate(
 	&auth.ApprovalRequest{},
 	&auth.TenantDetails{},
 	&auth.TenantUserAssignment{},
+	&auth.UserTwoFactor{},
+	&auth.TwoFactorBackupCode{},
 	&seva.Seva{},
 	&seva.SevaBooking{},
+	&seva.RecommendationConfig{}, &seva.SevaTemplate{},
 	&entity.Entity{},
+	&entity.ChangeRequest{},
+	&entity.DevoteeInvitation{},
+	&entity.EntityFile{},
+	&entity.FileUpload{},
+	&entity.StorageUsage{},
 	&event.Event{},
 	&donation.Donation{},
+	&donation.PaymentWebhookEvent{},
+	&donation.DonationReceipt{},
+	&donation.ReceiptCounter{},
+	&campaign.Campaign{},
+	&pledge.Pledge{},
+	&pledge.Charge{},
+	&greeting.Settings{},
+	&greeting.Log{},
+	&expense.Expense{},
+	&hundi.Session{},
+	&coupon.Coupon{},
+	&coupon.Redemption{},
+	&shop.Product{},
+	&shop.CartItem{},
+	&shop.Order{},
+	&shop.OrderItem{},
+	&shop.StatusEvent{},
+	&inventory.Item{},
+	&inventory.Movement{},
+	&volunteer.Volunteer{},
+	&volunteer.Slot{},
+	&volunteer.Signup{},
+	&family.Family{},
+	&family.Member{},
+	&dispute.Dispute{},
+	&dispute.Evidence{},
+	&dispute.Event{},
 	&notification.NotificationTemplate{},
 	&notification.NotificationLog{},
+	&notification.BufferedMessage{},
+	&notification.NotificationPreference{},
+	&notification.TemplateTranslation{},
+	&notification.EmailSettings{},
+	&notification.EmailDelivery{},
+	&notification.MessagingSettings{},
+	&notification.MessageDelivery{},
 	
 	// ✅ Add these:
 	&userprofile.DevoteeProfile{},
@@ -63,6 +145,24 @@ if err := DB.AutoMigrate(
 	&userprofile.EmergencyContact{},
 &userprofile.UserEntityMembership{},
 &auditlog.AuditLog{},
+&reports.ReportJob{},
+&reports.ExportRecord{},
+&reports.ExportTemplate{},
+&reports.LedgerMapping{},
+&apiusage.DailyUsage{},
+&apiusage.EndpointUsage{},
+&apikey.APIKey{},
+&superadmin.Organization{},
+&superadmin.OrganizationTenant{},
+&superadmin.OrganizationAdmin{},
+&publicpage.Page{},
+&publicpage.Section{},
+&migration.Batch{},
+&migration.Row{},
+&delegation.Assignment{},
+&delegation.Invitation{},
+&privacy.Request{},
+&privacy.Step{},
 ); err != nil {
 	log.Fatalf("❌ AutoMigrate failed: %v", err)
 }
@@ -70,6 +170,12 @@ if err := DB.AutoMigrate(
 
 	log.Println("✅ Database schema migrated")
 
+	search.EnsureIndexes(DB)
+	entity.BackfillSlugs(DB)
+	entity.BackfillCoordinates(DB)
+	userprofile.BackfillBirthDays(DB)
+	auth.BackfillPhoneIndexes(DB)
+
 	// 🌱 Call seeder here
 	if err := auth.SeedUserRoles(DB); err != nil {
 		log.Fatalf("❌ Seeding roles failed: %v", err)
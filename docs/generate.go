@@ -0,0 +1,11 @@
+package docs
+
+// Regenerate after changing routes or swag annotations:
+//
+//	go generate ./docs
+//
+// swag rebuilds docs.go and swagger.json/yaml from the annotations, then
+// cmd/openapi writes openapi.json with every registered route merged in.
+
+//go:generate go run github.com/swaggo/swag/cmd/swag@v1.8.12 init --dir ../ --generalInfo cmd/main.go --output . --parseInternal
+//go:generate go run ../cmd/openapi -o openapi.json
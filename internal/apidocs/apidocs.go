@@ -0,0 +1,273 @@
+package apidocs
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/docs"
+	"github.com/swaggo/swag"
+)
+
+// The API description served at /api/docs is built from the routes registered
+// on the engine, so it can't fall behind them. Operations documented with
+// swag annotations (the docs package, regenerated by `go generate ./docs`)
+// keep their full description; every other route gets a generated entry
+// with its path parameters, tag and a summary taken from its handler name.
+
+// InstanceName is the swag instance the generated spec is registered under
+const InstanceName = "generated"
+
+// basePath of the swag annotations
+const apiPrefix = "/api/v1"
+
+// Versioned API routes, /api/v1/..., /api/v2/...
+var versionPrefix = regexp.MustCompile(`^/api/v[0-9]+/`)
+
+// Unauthenticated API routes; everything else needs a bearer token
+var publicRoutes = []*regexp.Regexp{
+	regexp.MustCompile(`^/api/v[0-9]+/auth/(register|login|refresh|forgot-password|reset-password|public-roles|2fa/verify|tenant-registration(/.*)?)$`),
+	regexp.MustCompile(`^/api/v[0-9]+/public/`),
+	regexp.MustCompile(`/webhook$`),
+	regexp.MustCompile(`/calendar\.ics$`),
+}
+
+// Routes that aren't part of the API
+var skippedPrefixes = []string{"/api/docs", "/swagger", "/debug", "/public", "/auth-pages"}
+
+// Spec builds the spec on first use, after every route is registered
+type Spec struct {
+	engine *gin.Engine
+
+	once sync.Once
+	doc  string
+}
+
+// New registers the spec of r's routes with swag under InstanceName
+func New(r *gin.Engine) *Spec {
+	s := &Spec{engine: r}
+	swag.Register(InstanceName, s)
+	return s
+}
+
+// ReadDoc implements swag.Swagger
+func (s *Spec) ReadDoc() string {
+	s.once.Do(func() {
+		doc, err := Build(s.engine.Routes())
+		if err != nil {
+			doc = []byte(fmt.Sprintf(`{"swagger":"2.0","info":{"title":"Temple Management API","version":"1.0","description":%q},"paths":{}}`, err.Error()))
+		}
+		s.doc = string(doc)
+	})
+	return s.doc
+}
+
+// Build merges the annotated Swagger 2.0 spec of the docs package with
+// generated operations for the routes it doesn't describe
+func Build(routes gin.RoutesInfo) ([]byte, error) {
+	spec := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(docs.SwaggerInfo.ReadDoc()), &spec); err != nil {
+		return nil, fmt.Errorf("invalid annotated spec: %w", err)
+	}
+	spec["swagger"] = "2.0"
+	spec["basePath"] = "/"
+	delete(spec, "host") // the UI calls the host it is served from
+	spec["securityDefinitions"] = map[string]interface{}{
+		"BearerAuth": map[string]interface{}{
+			"type":        "apiKey",
+			"name":        "Authorization",
+			"in":          "header",
+			"description": `Access token from /api/v1/auth/login, as "Bearer <token>"`,
+		},
+	}
+
+	// Annotations are written relative to /api/v1 (or with it); paths are
+	// absolute in the merged spec
+	annotatedPaths, _ := spec["paths"].(map[string]interface{})
+	paths := make(map[string]interface{}, len(routes))
+	for p, item := range annotatedPaths {
+		if !strings.HasPrefix(p, "/api/") {
+			p = apiPrefix + p
+		}
+		paths[p] = item
+	}
+
+	sorted := append(gin.RoutesInfo(nil), routes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return sorted[i].Method < sorted[j].Method
+	})
+	for _, rt := range sorted {
+		if skipped(rt.Path) {
+			continue
+		}
+		path, params := swaggerPath(rt.Path)
+		item, _ := paths[path].(map[string]interface{})
+		if item == nil {
+			item = map[string]interface{}{}
+			paths[path] = item
+		}
+		method := strings.ToLower(rt.Method)
+		if op, ok := item[method].(map[string]interface{}); ok {
+			if _, ok := op["security"]; !ok && !isPublic(rt.Path) {
+				op["security"] = bearer()
+			}
+			continue
+		}
+		item[method] = operation(rt, path, params)
+	}
+	spec["paths"] = paths
+
+	return json.MarshalIndent(spec, "", "    ")
+}
+
+func skipped(path string) bool {
+	for _, prefix := range skippedPrefixes {
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isPublic(path string) bool {
+	if !versionPrefix.MatchString(path) {
+		return true // probes, metrics and file links carry their own checks
+	}
+	for _, re := range publicRoutes {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func bearer() []map[string][]string {
+	return []map[string][]string{{"BearerAuth": {}}}
+}
+
+// swaggerPath turns /entities/:id/files/*path into /entities/{id}/files/{path}
+// and returns the parameter names
+func swaggerPath(path string) (string, []string) {
+	segments := strings.Split(path, "/")
+	var params []string
+	for i, seg := range segments {
+		if seg != "" && (seg[0] == ':' || seg[0] == '*') {
+			params = append(params, seg[1:])
+			segments[i] = "{" + seg[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func operation(rt gin.RouteInfo, path string, params []string) map[string]interface{} {
+	parameters := make([]map[string]interface{}, 0, len(params))
+	for _, name := range params {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     name,
+			"in":       "path",
+			"required": true,
+			"type":     "string",
+		})
+	}
+	op := map[string]interface{}{
+		"tags":        []string{tag(rt.Path)},
+		"summary":     summary(rt),
+		"operationId": operationID(rt.Method, path),
+		"produces":    []string{"application/json"},
+		"parameters":  parameters,
+		"responses": map[string]interface{}{
+			"200": map[string]interface{}{"description": "OK"},
+		},
+	}
+	if !isPublic(rt.Path) {
+		op["security"] = bearer()
+		responses := op["responses"].(map[string]interface{})
+		responses["401"] = map[string]interface{}{"description": "Missing or invalid token"}
+		responses["403"] = map[string]interface{}{"description": "Role or temple access denied"}
+	}
+	return op
+}
+
+// tag groups a route by its first segment after /api/vN
+func tag(path string) string {
+	rest := path
+	if loc := versionPrefix.FindStringIndex(path); loc != nil {
+		rest = path[loc[1]:]
+	}
+	for _, seg := range strings.Split(rest, "/") {
+		if seg != "" && seg[0] != ':' && seg[0] != '*' {
+			return seg
+		}
+	}
+	return "root"
+}
+
+// summary turns a handler such as ".../entity.(*Handler).GetStorageUsage-fm"
+// into "Get storage usage"; inline handlers are named by their route
+func summary(rt gin.RouteInfo) string {
+	name := rt.Handler
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	name = strings.TrimSuffix(name, "-fm")
+	if name == "" || strings.HasPrefix(name, "func") || !unicode.IsUpper(rune(name[0])) {
+		return rt.Method + " " + rt.Path
+	}
+	var words []string
+	start := 0
+	runes := []rune(name)
+	for i := 1; i < len(runes); i++ {
+		// split before an upper case letter that starts a word: aB, or the B of ABc
+		if unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	words = append(words, string(runes[start:]))
+	for i := 1; i < len(words); i++ {
+		if !isAcronym(words[i]) {
+			words[i] = strings.ToLower(words[i])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+func isAcronym(word string) bool {
+	return len(word) > 1 && strings.ToUpper(word) == word
+}
+
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, seg := range strings.Split(path, "/") {
+		seg = strings.Trim(seg, "{}")
+		if seg == "" {
+			continue
+		}
+		id += "_" + strings.NewReplacer("-", "_", ".", "_").Replace(seg)
+	}
+	return id
+}
+
+// Handler serves Swagger UI and the spec under the route it is mounted on,
+// e.g. /api/docs/*any; user and password, when set, protect it with HTTP
+// basic auth
+func Handler(ui gin.HandlerFunc, user, password string) []gin.HandlerFunc {
+	if password == "" {
+		return []gin.HandlerFunc{ui}
+	}
+	return []gin.HandlerFunc{gin.BasicAuth(gin.Accounts{user: password}), ui}
+}
+
+// Redirect sends the bare docs path to the UI
+func Redirect(c *gin.Context) {
+	c.Redirect(http.StatusMovedPermanently, strings.TrimSuffix(c.Request.URL.Path, "/")+"/index.html")
+}
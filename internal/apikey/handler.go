@@ -0,0 +1,189 @@
+package apikey
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/apiusage"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes API key management to superadmins
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new API key handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListScopes - GET /superadmin/api-keys/scopes
+// The areas keys can be scoped to, each as <area>:read or <area>:write.
+func (h *Handler) ListScopes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": Areas})
+}
+
+// Create - POST /superadmin/api-keys
+// {"tenant_id": 12, "entity_id": 3, "name": "Tally sync", "scopes": ["donations:read"], "plan": "standard"}
+// The key is in the response only; it can't be shown again.
+func (h *Handler) Create(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	created, err := h.Service.Create(c.Request.Context(), req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to create API key")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "API key created. Store it now, it will not be shown again.",
+		"data":    created,
+	})
+}
+
+// List - GET /superadmin/api-keys?tenant_id=&status=active&page=1&limit=20
+func (h *Handler) List(c *gin.Context) {
+	f := Filter{
+		Status: c.Query("status"),
+		Page:   httpx.PositiveQuery(c, "page", 1),
+		Limit:  min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if f.Status != "" && f.Status != StatusActive && f.Status != StatusExpired && f.Status != StatusRevoked {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, expired or revoked"})
+		return
+	}
+	if v := c.Query("tenant_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant_id"})
+			return
+		}
+		f.TenantID = uint(id)
+	}
+
+	keys, total, err := h.Service.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  keys,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// Get - GET /superadmin/api-keys/:id
+func (h *Handler) Get(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	key, err := h.Service.Get(c.Request.Context(), id)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch API key")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": key})
+}
+
+// Update - PATCH /superadmin/api-keys/:id
+// {"scopes": ["donations:read", "reports:read"], "daily_quota": 5000}
+func (h *Handler) Update(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	key, err := h.Service.Update(c.Request.Context(), id, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to update API key")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key updated", "data": key})
+}
+
+// Revoke - DELETE /superadmin/api-keys/:id
+// Revoked keys stop working at once; their usage statistics are kept.
+func (h *Handler) Revoke(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	key, err := h.Service.Revoke(c.Request.Context(), id, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to revoke API key")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked", "data": key})
+}
+
+// GetUsage - GET /superadmin/api-keys/:id/usage?from=2024-01-01&to=2024-01-31
+// Daily requests, errors, throttling and busiest routes of the key.
+func (h *Handler) GetUsage(c *gin.Context) {
+	id, ok := keyID(c)
+	if !ok {
+		return
+	}
+	var from, to *time.Time
+	for name, dst := range map[string]**time.Time{"from": &from, "to": &to} {
+		v := c.Query(name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": name + " must be YYYY-MM-DD"})
+			return
+		}
+		*dst = &t
+	}
+
+	usage, err := h.Service.KeyUsage(c.Request.Context(), id, from, to)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to load API key usage")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "API key not found",
+	Statuses: map[int][]error{
+		http.StatusConflict: {ErrAlreadyRevoked},
+		http.StatusBadRequest: {ErrInvalidTenant, ErrEntityNotOwned, ErrInvalidScope, ErrInvalidPlan, ErrInvalidQuota, ErrInvalidExpiry,
+			ErrInvalidName, apiusage.ErrInvalidPeriod},
+	},
+}
+
+func keyID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
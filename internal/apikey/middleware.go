@@ -0,0 +1,128 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sharath018/temple-management-backend/internal/apiusage"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Middleware authenticates requests carrying X-API-Key as the key's tenant.
+// It sets the user and access context AuthMiddleware would, limited to the
+// key's scopes and temple, and marks the request for usage metering
+// (apiusage.Tracker) and the api-key rate limit. Requests without the header
+// pass through. Place it before both.
+func (s *Service) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw := strings.TrimSpace(c.GetHeader(HeaderName))
+		if raw == "" {
+			c.Next()
+			return
+		}
+
+		key, tenant, err := s.Authenticate(c.Request.Context(), raw, middleware.GetIPFromContext(c))
+		switch {
+		case errors.Is(err, ErrInvalidKey), errors.Is(err, ErrKeyInactive):
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, ErrTenantInactive):
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		case err != nil:
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check API key"})
+			return
+		}
+
+		scopes := key.ScopeList()
+		if !Allows(scopes, c.Request.Method, c.FullPath()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is not allowed to call this endpoint", "scopes": scopes})
+			return
+		}
+
+		claims := jwt.MapClaims{}
+		var entityID *uint
+		var entityIDs []uint
+		if key.EntityID != nil {
+			for _, requested := range requestedEntityIDs(c) {
+				if requested != *key.EntityID {
+					c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is limited to another temple"})
+					return
+				}
+			}
+			entityID = key.EntityID
+			entityIDs = []uint{*key.EntityID}
+		} else {
+			if entityIDs, err = s.Users.StaffEntityIDs(&tenant); err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to load temples"})
+				return
+			}
+			entityID = middleware.ResolveEntityIDForOperation(c, tenant, claims, entityIDs)
+		}
+
+		access := middleware.CreateAccessContext(c, tenant, claims, entityID, entityIDs)
+		if key.EntityID != nil {
+			access.DirectEntityID = key.EntityID
+		}
+		if !canWrite(scopes) {
+			access.PermissionType = "readonly"
+		}
+
+		c.Set("user", tenant)
+		c.Set("user_id", tenant.ID)
+		c.Set("claims", claims)
+		c.Set("access_context", access)
+		if entityID != nil {
+			c.Set("entity_id", *entityID)
+		}
+		c.Set(middleware.APIKeyContextKey, key.KeyID)
+		c.Set(apiusage.ContextKey, apiusage.KeyInfo{
+			KeyID:      key.KeyID,
+			TenantID:   key.TenantID,
+			Plan:       key.Plan,
+			DailyQuota: key.DailyQuota,
+		})
+		c.Next()
+	}
+}
+
+// IsAPIKeyRequest reports whether Middleware authenticated the request
+func IsAPIKeyRequest(c *gin.Context) bool {
+	return c.GetString(middleware.APIKeyContextKey) != ""
+}
+
+func canWrite(scopes []string) bool {
+	for _, scope := range scopes {
+		if strings.HasSuffix(scope, ":"+AccessWrite) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestedEntityIDs returns the temples the request names: X-Entity-ID,
+// entity_id and the :id of /entities/:id routes
+func requestedEntityIDs(c *gin.Context) []uint {
+	values := []string{c.GetHeader("X-Entity-ID"), c.Query("entity_id")}
+	if strings.Contains(c.FullPath(), "/entities/:id") {
+		values = append(values, c.Param("id"))
+	}
+	var ids []uint
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			// "all" or anything else that isn't the key's temple
+			ids = append(ids, 0)
+			continue
+		}
+		ids = append(ids, uint(id))
+	}
+	return ids
+}
@@ -0,0 +1,173 @@
+package apikey
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// HeaderName carries the key of third-party integrations
+const HeaderName = "X-API-Key"
+
+// Key statuses, derived from RevokedAt and ExpiresAt
+const (
+	StatusActive  = "active"
+	StatusExpired = "expired"
+	StatusRevoked = "revoked"
+)
+
+// Scope access levels: <area>:read allows GET requests to the area's routes,
+// <area>:write every method
+const (
+	AccessRead  = "read"
+	AccessWrite = "write"
+)
+
+// Area is a group of routes a key can be given access to. Routes are path
+// prefixes below /api/<version>, as registered.
+type Area struct {
+	Name   string   `json:"name"`
+	Label  string   `json:"label"`
+	Routes []string `json:"routes"`
+}
+
+// Areas are what integrations can reach: accounting software reads
+// donations, expenses, hundi counts and reports; temple websites read events
+// and sevas and book them
+var Areas = []Area{
+	{Name: "donations", Label: "Donations", Routes: []string{"/donations"}},
+	{Name: "expenses", Label: "Expenses", Routes: []string{"/expenses"}},
+	{Name: "hundi", Label: "Hundi collections", Routes: []string{"/hundi"}},
+	{Name: "events", Label: "Events and RSVPs", Routes: []string{"/events", "/event-rsvps", "/entities/:id/events"}},
+	{Name: "sevas", Label: "Sevas and bookings", Routes: []string{"/sevas"}},
+	{Name: "reports", Label: "Reports", Routes: []string{"/reports", "/entities/:id/reports"}},
+}
+
+// APIKey lets an external system call the API as its tenant, within its
+// scopes. Only a hash of the key is stored; the key is shown once when
+// created.
+type APIKey struct {
+	ID         uint           `gorm:"primaryKey" json:"id"`
+	KeyID      string         `gorm:"size:32;not null;uniqueIndex" json:"key_id"` // public ID, used in usage statistics
+	TenantID   uint           `gorm:"not null;index" json:"tenant_id"`
+	EntityID   *uint          `gorm:"index" json:"entity_id,omitempty"` // limits the key to one temple of the tenant
+	Name       string         `gorm:"size:100;not null" json:"name"`
+	Prefix     string         `gorm:"size:16;not null" json:"prefix"` // start of the key, to recognise it
+	KeyHash    string         `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Scopes     datatypes.JSON `gorm:"type:jsonb;not null" json:"scopes"` // ["donations:read"]
+	Plan       string         `gorm:"size:20;not null;default:'free'" json:"plan"`
+	DailyQuota int64          `gorm:"not null;default:0" json:"daily_quota"` // overrides the plan's quota when > 0
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	LastUsedIP string         `gorm:"size:45" json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+	RevokedBy  *uint          `json:"revoked_by,omitempty"`
+	CreatedBy  uint           `gorm:"not null" json:"created_by"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Status string `gorm:"-" json:"status"`
+}
+
+// TableName returns the table name for the APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// StatusAt reports whether the key is active, expired or revoked at now
+func (k *APIKey) StatusAt(now time.Time) string {
+	switch {
+	case k.RevokedAt != nil:
+		return StatusRevoked
+	case k.ExpiresAt != nil && !now.Before(*k.ExpiresAt):
+		return StatusExpired
+	}
+	return StatusActive
+}
+
+// ScopeList decodes the key's scopes
+func (k *APIKey) ScopeList() []string {
+	var scopes []string
+	_ = json.Unmarshal(k.Scopes, &scopes)
+	return scopes
+}
+
+// Allows reports whether the scopes allow a request to route, the route as
+// registered (c.FullPath())
+func Allows(scopes []string, method, route string) bool {
+	route = versionless(route)
+	for _, scope := range scopes {
+		name, access, _ := strings.Cut(scope, ":")
+		if access == AccessRead && method != "GET" && method != "HEAD" {
+			continue
+		}
+		area, ok := findArea(name)
+		if !ok {
+			continue
+		}
+		for _, prefix := range area.Routes {
+			if route == prefix || strings.HasPrefix(route, prefix+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// versionless strips /api/<version> from a route
+func versionless(route string) string {
+	rest, ok := strings.CutPrefix(route, "/api/")
+	if !ok {
+		return route
+	}
+	if i := strings.IndexByte(rest, '/'); i >= 0 {
+		return rest[i:]
+	}
+	return "/"
+}
+
+func findArea(name string) (Area, bool) {
+	for _, a := range Areas {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Area{}, false
+}
+
+// CreateRequest is the body of POST /superadmin/api-keys
+type CreateRequest struct {
+	TenantID   uint       `json:"tenant_id" binding:"required"`
+	EntityID   *uint      `json:"entity_id"`
+	Name       string     `json:"name" binding:"required"`
+	Scopes     []string   `json:"scopes" binding:"required"`
+	Plan       string     `json:"plan"`
+	DailyQuota int64      `json:"daily_quota"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// UpdateRequest is the body of PATCH /superadmin/api-keys/:id; omitted
+// fields are kept
+type UpdateRequest struct {
+	Name       *string    `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	Plan       *string    `json:"plan"`
+	DailyQuota *int64     `json:"daily_quota"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+}
+
+// Created is returned once, with the key itself
+type Created struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// Filter selects keys to list
+type Filter struct {
+	TenantID uint
+	Status   string
+	Page     int
+	Limit    int
+}
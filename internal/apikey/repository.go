@@ -0,0 +1,121 @@
+package apikey
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository stores API keys
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository creates a new API key repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Tenant is the templeadmin account a key acts for
+type Tenant struct {
+	ID       uint
+	FullName string
+	Role     string
+	Status   string
+}
+
+// GetTenant returns a user with their role, to check it can own keys
+func (r *Repository) GetTenant(ctx context.Context, userID uint) (*Tenant, error) {
+	var t Tenant
+	err := r.DB.WithContext(ctx).Table("users u").
+		Select("u.id, u.full_name, u.status, r.role_name AS role").
+		Joins("JOIN user_roles r ON r.id = u.role_id").
+		Where("u.id = ? AND u.deleted_at IS NULL", userID).
+		Take(&t).Error
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetEntityTenantID returns the templeadmin that owns a temple
+func (r *Repository) GetEntityTenantID(ctx context.Context, entityID uint) (uint, error) {
+	var tenantID uint
+	err := r.DB.WithContext(ctx).Table("entities").
+		Select("created_by").
+		Where("id = ?", entityID).
+		Take(&tenantID).Error
+	return tenantID, err
+}
+
+// Create saves a new key
+func (r *Repository) Create(ctx context.Context, key *APIKey) error {
+	return r.DB.WithContext(ctx).Create(key).Error
+}
+
+// Get returns a key by ID
+func (r *Repository) Get(ctx context.Context, id uint) (*APIKey, error) {
+	var key APIKey
+	if err := r.DB.WithContext(ctx).First(&key, id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetByHash returns the key whose hash matches
+func (r *Repository) GetByHash(ctx context.Context, hash string) (*APIKey, error) {
+	var key APIKey
+	if err := r.DB.WithContext(ctx).Where("key_hash = ?", hash).Take(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// List returns a page of keys, newest first
+func (r *Repository) List(ctx context.Context, f Filter) ([]APIKey, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&APIKey{})
+	if f.TenantID != 0 {
+		query = query.Where("tenant_id = ?", f.TenantID)
+	}
+	now := time.Now()
+	switch f.Status {
+	case StatusActive:
+		query = query.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now)
+	case StatusExpired:
+		query = query.Where("revoked_at IS NULL AND expires_at <= ?", now)
+	case StatusRevoked:
+		query = query.Where("revoked_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []APIKey
+	err := query.Order("created_at DESC").
+		Offset((f.Page - 1) * f.Limit).
+		Limit(f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
+
+// Update saves changed columns of a key
+func (r *Repository) Update(ctx context.Context, id uint, updates map[string]interface{}) error {
+	return r.DB.WithContext(ctx).Model(&APIKey{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// Revoke revokes a key unless it already is, reporting whether it did
+func (r *Repository) Revoke(ctx context.Context, id, revokedBy uint) (bool, error) {
+	res := r.DB.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{"revoked_at": time.Now(), "revoked_by": revokedBy})
+	return res.RowsAffected > 0, res.Error
+}
+
+// Touch records when and from where a key was last used
+func (r *Repository) Touch(ctx context.Context, id uint, at time.Time, ip string) error {
+	return r.DB.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{"last_used_at": at, "last_used_ip": ip}).Error
+}
@@ -0,0 +1,334 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/apiusage"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// keyPrefix starts every key, so leaked keys are easy to recognise
+const keyPrefix = "tms_"
+
+// touchInterval is how often the last use of a key is written
+const touchInterval = time.Minute
+
+var (
+	ErrInvalidTenant  = errors.New("tenant_id must be an active temple admin")
+	ErrEntityNotOwned = errors.New("entity_id must be a temple of the tenant")
+	ErrInvalidScope   = fmt.Errorf("scopes must list at least one of <area>:read or <area>:write, areas: %s", strings.Join(areaNames(), ", "))
+	ErrInvalidPlan    = fmt.Errorf("plan must be one of %s, %s, %s", apiusage.PlanFree, apiusage.PlanStandard, apiusage.PlanEnterprise)
+	ErrInvalidQuota   = errors.New("daily_quota must not be negative")
+	ErrInvalidExpiry  = errors.New("expires_at must be in the future")
+	ErrInvalidName    = errors.New("name is required and must be at most 100 characters")
+	ErrAlreadyRevoked = errors.New("API key is already revoked")
+
+	ErrInvalidKey     = errors.New("invalid API key")
+	ErrKeyInactive    = errors.New("API key has expired or been revoked")
+	ErrTenantInactive = errors.New("the API key's tenant account is not active")
+)
+
+// Users loads the tenant a key acts for, with its role and temples
+type Users interface {
+	GetUserByID(userID uint) (auth.User, error)
+	StaffEntityIDs(user *auth.User) ([]uint, error)
+}
+
+// Service manages API keys for superadmins and authenticates requests made
+// with them
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+	Users Users
+	Usage apiusage.Service
+}
+
+// NewService creates a new API key service
+func NewService(repo *Repository, auditSvc auditlog.Service, users Users, usage apiusage.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Users: users, Usage: usage}
+}
+
+func areaNames() []string {
+	names := make([]string, 0, len(Areas))
+	for _, a := range Areas {
+		names = append(names, a.Name)
+	}
+	return names
+}
+
+// normalizeScopes validates scopes and drops duplicates
+func normalizeScopes(scopes []string) ([]string, error) {
+	out := make([]string, 0, len(scopes))
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		scope = strings.ToLower(strings.TrimSpace(scope))
+		name, access, ok := strings.Cut(scope, ":")
+		if _, known := findArea(name); !ok || !known || (access != AccessRead && access != AccessWrite) {
+			return nil, ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			out = append(out, scope)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return out, nil
+}
+
+func validPlan(plan string) bool {
+	return plan == apiusage.PlanFree || plan == apiusage.PlanStandard || plan == apiusage.PlanEnterprise
+}
+
+// newKey returns a random key and its public ID
+func newKey() (key, keyID string, err error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+	return keyPrefix + hex.EncodeToString(secret), "ak_" + hex.EncodeToString(id), nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Create issues a key for a tenant. The key is only returned here.
+func (s *Service) Create(ctx context.Context, req CreateRequest, createdBy uint, ip string) (*Created, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 100 {
+		return nil, ErrInvalidName
+	}
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if req.Plan == "" {
+		req.Plan = apiusage.PlanFree
+	}
+	if !validPlan(req.Plan) {
+		return nil, ErrInvalidPlan
+	}
+	if req.DailyQuota < 0 {
+		return nil, ErrInvalidQuota
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiry
+	}
+
+	tenant, err := s.Repo.GetTenant(ctx, req.TenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvalidTenant
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tenant.Role != middleware.RoleTempleAdmin || tenant.Status != "active" {
+		return nil, ErrInvalidTenant
+	}
+	if req.EntityID != nil {
+		owner, err := s.Repo.GetEntityTenantID(ctx, *req.EntityID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && owner != req.TenantID) {
+			return nil, ErrEntityNotOwned
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	key, keyID, err := newKey()
+	if err != nil {
+		return nil, err
+	}
+	encodedScopes, _ := json.Marshal(scopes)
+	record := &APIKey{
+		KeyID:      keyID,
+		TenantID:   req.TenantID,
+		EntityID:   req.EntityID,
+		Name:       req.Name,
+		Prefix:     key[:12],
+		KeyHash:    hashKey(key),
+		Scopes:     encodedScopes,
+		Plan:       req.Plan,
+		DailyQuota: req.DailyQuota,
+		ExpiresAt:  req.ExpiresAt,
+		CreatedBy:  createdBy,
+	}
+	if err := s.Repo.Create(ctx, record); err != nil {
+		return nil, err
+	}
+	record.Status = record.StatusAt(time.Now())
+
+	s.Audit.LogAction(ctx, &createdBy, req.EntityID, "API_KEY_CREATED", map[string]interface{}{
+		"key_id":    keyID,
+		"tenant_id": req.TenantID,
+		"name":      req.Name,
+		"scopes":    scopes,
+		"plan":      req.Plan,
+	}, ip, "success")
+	return &Created{APIKey: record, Key: key}, nil
+}
+
+// List returns a page of keys
+func (s *Service) List(ctx context.Context, f Filter) ([]APIKey, int64, error) {
+	keys, total, err := s.Repo.List(ctx, f)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	for i := range keys {
+		keys[i].Status = keys[i].StatusAt(now)
+	}
+	return keys, total, nil
+}
+
+// Get returns a key
+func (s *Service) Get(ctx context.Context, id uint) (*APIKey, error) {
+	key, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	key.Status = key.StatusAt(time.Now())
+	return key, nil
+}
+
+// Update changes the name, scopes, plan, quota or expiry of a key
+func (s *Service) Update(ctx context.Context, id uint, req UpdateRequest, updatedBy uint, ip string) (*APIKey, error) {
+	key, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAlreadyRevoked
+	}
+
+	updates := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" || len(name) > 100 {
+			return nil, ErrInvalidName
+		}
+		updates["name"] = name
+	}
+	if req.Scopes != nil {
+		scopes, err := normalizeScopes(req.Scopes)
+		if err != nil {
+			return nil, err
+		}
+		encoded, _ := json.Marshal(scopes)
+		updates["scopes"] = encoded
+	}
+	if req.Plan != nil {
+		if !validPlan(*req.Plan) {
+			return nil, ErrInvalidPlan
+		}
+		updates["plan"] = *req.Plan
+	}
+	if req.DailyQuota != nil {
+		if *req.DailyQuota < 0 {
+			return nil, ErrInvalidQuota
+		}
+		updates["daily_quota"] = *req.DailyQuota
+	}
+	if req.ExpiresAt != nil {
+		if !req.ExpiresAt.After(time.Now()) {
+			return nil, ErrInvalidExpiry
+		}
+		updates["expires_at"] = *req.ExpiresAt
+	}
+	if len(updates) == 0 {
+		key.Status = key.StatusAt(time.Now())
+		return key, nil
+	}
+	if err := s.Repo.Update(ctx, id, updates); err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{"key_id": key.KeyID, "tenant_id": key.TenantID}
+	for column, value := range updates {
+		if column == "scopes" {
+			value = json.RawMessage(value.([]byte))
+		}
+		details[column] = value
+	}
+	s.Audit.LogAction(ctx, &updatedBy, key.EntityID, "API_KEY_UPDATED", details, ip, "success")
+	return s.Get(ctx, id)
+}
+
+// Revoke stops a key from authenticating; it can't be undone
+func (s *Service) Revoke(ctx context.Context, id, revokedBy uint, ip string) (*APIKey, error) {
+	key, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := s.Repo.Revoke(ctx, id, revokedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !revoked {
+		return nil, ErrAlreadyRevoked
+	}
+	s.Audit.LogAction(ctx, &revokedBy, key.EntityID, "API_KEY_REVOKED", map[string]interface{}{
+		"key_id":    key.KeyID,
+		"tenant_id": key.TenantID,
+		"name":      key.Name,
+	}, ip, "success")
+	return s.Get(ctx, id)
+}
+
+// KeyUsage returns the usage statistics of a key over a period
+func (s *Service) KeyUsage(ctx context.Context, id uint, from, to *time.Time) (*apiusage.KeyReport, error) {
+	key, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.Usage.KeyUsage(ctx, key.KeyID, from, to)
+}
+
+// Authenticate returns the active key matching raw and the tenant it acts for
+func (s *Service) Authenticate(ctx context.Context, raw, ip string) (*APIKey, auth.User, error) {
+	if !strings.HasPrefix(raw, keyPrefix) {
+		return nil, auth.User{}, ErrInvalidKey
+	}
+	key, err := s.Repo.GetByHash(ctx, hashKey(raw))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, auth.User{}, ErrInvalidKey
+	}
+	if err != nil {
+		return nil, auth.User{}, err
+	}
+	now := time.Now()
+	if key.StatusAt(now) != StatusActive {
+		return nil, auth.User{}, ErrKeyInactive
+	}
+
+	tenant, err := s.Users.GetUserByID(key.TenantID)
+	if err != nil || tenant.Role.RoleName != middleware.RoleTempleAdmin || tenant.Status != "active" {
+		return nil, auth.User{}, ErrTenantInactive
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > touchInterval || key.LastUsedIP != ip {
+		if err := s.Repo.Touch(ctx, key.ID, now, ip); err != nil {
+			log.Printf("⚠️ Failed to record use of API key %s: %v", key.KeyID, err)
+		}
+	}
+	return key, tenant, nil
+}
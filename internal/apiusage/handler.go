@@ -0,0 +1,89 @@
+package apiusage
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// parsePeriod reads the optional ?from=YYYY-MM-DD&to=YYYY-MM-DD query
+func parsePeriod(c *gin.Context) (*time.Time, *time.Time, bool) {
+	var from, to *time.Time
+	for name, dst := range map[string]**time.Time{"from": &from, "to": &to} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid '" + name + "' date, expected YYYY-MM-DD"})
+			return nil, nil, false
+		}
+		*dst = &t
+	}
+	return from, to, true
+}
+
+func respondError(c *gin.Context, err error) {
+	if errors.Is(err, ErrInvalidPeriod) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load API usage"})
+}
+
+// GET /api/v1/api-usage?from=&to=
+// Usage of the calling tenant's API keys
+func (h *Handler) GetTenantUsage(c *gin.Context) {
+	accessContextRaw, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	accessContext, ok := accessContextRaw.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid access context"})
+		return
+	}
+
+	tenantID := accessContext.TenantID
+
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	usage, err := h.service.TenantUsage(c.Request.Context(), tenantID, from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, usage)
+}
+
+// GET /api/v1/superadmin/api-usage?from=&to=
+// Usage rolled up per tenant
+func (h *Handler) GetRollup(c *gin.Context) {
+	from, to, ok := parsePeriod(c)
+	if !ok {
+		return
+	}
+
+	rollup, err := h.service.Rollup(c.Request.Context(), from, to)
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, rollup)
+}
@@ -0,0 +1,158 @@
+package apiusage
+
+import (
+	"time"
+)
+
+// ContextKey is the gin context key under which API key authentication
+// stores the calling key. Requests without it are not metered.
+const ContextKey = "api_key"
+
+// KeyInfo identifies the API key of a request and its plan
+type KeyInfo struct {
+	KeyID    string
+	TenantID uint
+	Plan     string
+	// DailyQuota overrides the plan quota when > 0
+	DailyQuota int64
+}
+
+// Plans and their daily request quotas
+const (
+	PlanFree       = "free"
+	PlanStandard   = "standard"
+	PlanEnterprise = "enterprise"
+)
+
+var planQuotas = map[string]int64{
+	PlanFree:       1000,
+	PlanStandard:   20000,
+	PlanEnterprise: 200000,
+}
+
+// QuotaFor returns the daily request quota of a key
+func QuotaFor(k KeyInfo) int64 {
+	if k.DailyQuota > 0 {
+		return k.DailyQuota
+	}
+	if q, ok := planQuotas[k.Plan]; ok {
+		return q
+	}
+	return planQuotas[PlanFree]
+}
+
+// DailyUsage is the per key, per day request total
+type DailyUsage struct {
+	ID           uint      `gorm:"primaryKey" json:"-"`
+	KeyID        string    `gorm:"size:64;not null;uniqueIndex:idx_api_usage_key_day" json:"key_id"`
+	Day          time.Time `gorm:"type:date;not null;uniqueIndex:idx_api_usage_key_day;index" json:"day"`
+	TenantID     uint      `gorm:"not null;index" json:"tenant_id"`
+	Requests     int64     `gorm:"not null;default:0" json:"requests"`
+	ClientErrors int64     `gorm:"not null;default:0" json:"client_errors"` // 4xx
+	ServerErrors int64     `gorm:"not null;default:0" json:"server_errors"` // 5xx
+	Throttled    int64     `gorm:"not null;default:0" json:"throttled"`     // rejected for exceeding the quota
+	BytesIn      int64     `gorm:"not null;default:0" json:"bytes_in"`
+	BytesOut     int64     `gorm:"not null;default:0" json:"bytes_out"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (DailyUsage) TableName() string {
+	return "api_usage_daily"
+}
+
+// EndpointUsage is the per key, per day, per route request total
+type EndpointUsage struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	KeyID     string    `gorm:"size:64;not null;uniqueIndex:idx_api_usage_endpoint" json:"key_id"`
+	Day       time.Time `gorm:"type:date;not null;uniqueIndex:idx_api_usage_endpoint;index" json:"day"`
+	Method    string    `gorm:"size:10;not null;uniqueIndex:idx_api_usage_endpoint" json:"method"`
+	Route     string    `gorm:"size:255;not null;uniqueIndex:idx_api_usage_endpoint" json:"route"`
+	TenantID  uint      `gorm:"not null;index" json:"tenant_id"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	Errors    int64     `gorm:"not null;default:0" json:"errors"`
+	BytesOut  int64     `gorm:"not null;default:0" json:"bytes_out"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (EndpointUsage) TableName() string {
+	return "api_usage_endpoints"
+}
+
+// UsageTotals aggregates usage over a period
+type UsageTotals struct {
+	Requests     int64   `json:"requests"`
+	ClientErrors int64   `json:"client_errors"`
+	ServerErrors int64   `json:"server_errors"`
+	Throttled    int64   `json:"throttled"`
+	BytesIn      int64   `json:"bytes_in"`
+	BytesOut     int64   `json:"bytes_out"`
+	ErrorRate    float64 `json:"error_rate"` // (4xx + 5xx) / requests
+}
+
+// KeyUsage is the usage of one key over a period
+type KeyUsage struct {
+	KeyID string `json:"key_id"`
+	UsageTotals
+	Daily []DailyUsage `json:"daily"`
+}
+
+// KeyReport is the usage of one key over a period with its busiest routes
+type KeyReport struct {
+	KeyUsage
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	TopEndpoints []EndpointTotal `json:"top_endpoints"`
+}
+
+// EndpointTotal is the usage of one route over a period
+type EndpointTotal struct {
+	Method    string  `json:"method"`
+	Route     string  `json:"route"`
+	Requests  int64   `json:"requests"`
+	Errors    int64   `json:"errors"`
+	BytesOut  int64   `json:"bytes_out"`
+	ErrorRate float64 `json:"error_rate"`
+}
+
+// TenantUsage is the response of the tenant-facing usage endpoint
+type TenantUsage struct {
+	TenantID     uint            `json:"tenant_id"`
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Totals       UsageTotals     `json:"totals"`
+	Keys         []KeyUsage      `json:"keys"`
+	TopEndpoints []EndpointTotal `json:"top_endpoints"`
+}
+
+// TenantRollup is one row of the superadmin rollup
+type TenantRollup struct {
+	TenantID   uint   `json:"tenant_id"`
+	TenantName string `json:"tenant_name"`
+	Keys       int64  `json:"keys"`
+	UsageTotals
+}
+
+// row types scanned by the repository
+type totalsRow struct {
+	Requests     int64
+	ClientErrors int64
+	ServerErrors int64
+	Throttled    int64
+	BytesIn      int64
+	BytesOut     int64
+}
+
+func (r totalsRow) totals() UsageTotals {
+	t := UsageTotals{
+		Requests:     r.Requests,
+		ClientErrors: r.ClientErrors,
+		ServerErrors: r.ServerErrors,
+		Throttled:    r.Throttled,
+		BytesIn:      r.BytesIn,
+		BytesOut:     r.BytesOut,
+	}
+	if r.Requests > 0 {
+		t.ErrorRate = float64(r.ClientErrors+r.ServerErrors) / float64(r.Requests)
+	}
+	return t
+}
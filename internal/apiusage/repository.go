@@ -0,0 +1,170 @@
+package apiusage
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository persists aggregated API usage
+type Repository interface {
+	AddDaily(ctx context.Context, rows []DailyUsage) error
+	AddEndpoints(ctx context.Context, rows []EndpointUsage) error
+	GetRequestCount(ctx context.Context, keyID string, day time.Time) (int64, error)
+
+	ListDailyByTenant(ctx context.Context, tenantID uint, from, to time.Time) ([]DailyUsage, error)
+	ListDailyByKey(ctx context.Context, keyID string, from, to time.Time) ([]DailyUsage, error)
+	TopEndpoints(ctx context.Context, tenantID *uint, from, to time.Time, limit int) ([]EndpointTotal, error)
+	TopEndpointsByKey(ctx context.Context, keyID string, from, to time.Time, limit int) ([]EndpointTotal, error)
+	RollupByTenant(ctx context.Context, from, to time.Time) ([]TenantRollup, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// AddDaily adds the counters of rows to the stored daily totals
+func (r *repository) AddDaily(ctx context.Context, rows []DailyUsage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key_id"}, {Name: "day"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "requests"}, Value: gorm.Expr("api_usage_daily.requests + excluded.requests")},
+			{Column: clause.Column{Name: "client_errors"}, Value: gorm.Expr("api_usage_daily.client_errors + excluded.client_errors")},
+			{Column: clause.Column{Name: "server_errors"}, Value: gorm.Expr("api_usage_daily.server_errors + excluded.server_errors")},
+			{Column: clause.Column{Name: "throttled"}, Value: gorm.Expr("api_usage_daily.throttled + excluded.throttled")},
+			{Column: clause.Column{Name: "bytes_in"}, Value: gorm.Expr("api_usage_daily.bytes_in + excluded.bytes_in")},
+			{Column: clause.Column{Name: "bytes_out"}, Value: gorm.Expr("api_usage_daily.bytes_out + excluded.bytes_out")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(&rows).Error
+}
+
+// AddEndpoints adds the counters of rows to the stored per-route totals
+func (r *repository) AddEndpoints(ctx context.Context, rows []EndpointUsage) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "key_id"}, {Name: "day"}, {Name: "method"}, {Name: "route"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "requests"}, Value: gorm.Expr("api_usage_endpoints.requests + excluded.requests")},
+			{Column: clause.Column{Name: "errors"}, Value: gorm.Expr("api_usage_endpoints.errors + excluded.errors")},
+			{Column: clause.Column{Name: "bytes_out"}, Value: gorm.Expr("api_usage_endpoints.bytes_out + excluded.bytes_out")},
+			{Column: clause.Column{Name: "updated_at"}, Value: gorm.Expr("excluded.updated_at")},
+		},
+	}).Create(&rows).Error
+}
+
+// GetRequestCount returns the stored number of requests of a key on a day
+func (r *repository) GetRequestCount(ctx context.Context, keyID string, day time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&DailyUsage{}).
+		Select("COALESCE(SUM(requests), 0)").
+		Where("key_id = ? AND day = ?", keyID, day).
+		Scan(&count).Error
+	return count, err
+}
+
+func (r *repository) ListDailyByTenant(ctx context.Context, tenantID uint, from, to time.Time) ([]DailyUsage, error) {
+	var rows []DailyUsage
+	err := r.db.WithContext(ctx).
+		Where("tenant_id = ? AND day BETWEEN ? AND ?", tenantID, from, to).
+		Order("key_id, day").
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *repository) ListDailyByKey(ctx context.Context, keyID string, from, to time.Time) ([]DailyUsage, error) {
+	var rows []DailyUsage
+	err := r.db.WithContext(ctx).
+		Where("key_id = ? AND day BETWEEN ? AND ?", keyID, from, to).
+		Order("day").
+		Find(&rows).Error
+	return rows, err
+}
+
+// TopEndpoints returns the busiest routes, for one tenant or across all tenants
+func (r *repository) TopEndpoints(ctx context.Context, tenantID *uint, from, to time.Time, limit int) ([]EndpointTotal, error) {
+	query := r.db.WithContext(ctx).Where("day BETWEEN ? AND ?", from, to)
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	return topEndpoints(query, limit)
+}
+
+// TopEndpointsByKey returns the busiest routes of one key
+func (r *repository) TopEndpointsByKey(ctx context.Context, keyID string, from, to time.Time, limit int) ([]EndpointTotal, error) {
+	query := r.db.WithContext(ctx).Where("key_id = ? AND day BETWEEN ? AND ?", keyID, from, to)
+	return topEndpoints(query, limit)
+}
+
+func topEndpoints(query *gorm.DB, limit int) ([]EndpointTotal, error) {
+	var rows []EndpointTotal
+	err := query.Model(&EndpointUsage{}).
+		Select("method, route, SUM(requests) AS requests, SUM(errors) AS errors, SUM(bytes_out) AS bytes_out").
+		Group("method, route").
+		Order("requests DESC").
+		Limit(limit).
+		Scan(&rows).Error
+	for i := range rows {
+		if rows[i].Requests > 0 {
+			rows[i].ErrorRate = float64(rows[i].Errors) / float64(rows[i].Requests)
+		}
+	}
+	return rows, err
+}
+
+// RollupByTenant sums usage per tenant for the superadmin view
+func (r *repository) RollupByTenant(ctx context.Context, from, to time.Time) ([]TenantRollup, error) {
+	var rows []struct {
+		TenantID     uint
+		TenantName   string
+		KeyCount     int64
+		Requests     int64
+		ClientErrors int64
+		ServerErrors int64
+		Throttled    int64
+		BytesIn      int64
+		BytesOut     int64
+	}
+	err := r.db.WithContext(ctx).
+		Table("api_usage_daily d").
+		Select(`d.tenant_id, COALESCE(u.full_name, '') AS tenant_name, COUNT(DISTINCT d.key_id) AS key_count,
+			SUM(d.requests) AS requests, SUM(d.client_errors) AS client_errors, SUM(d.server_errors) AS server_errors,
+			SUM(d.throttled) AS throttled, SUM(d.bytes_in) AS bytes_in, SUM(d.bytes_out) AS bytes_out`).
+		Joins("LEFT JOIN users u ON u.id = d.tenant_id").
+		Where("d.day BETWEEN ? AND ?", from, to).
+		Group("d.tenant_id, u.full_name").
+		Order("requests DESC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]TenantRollup, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, TenantRollup{
+			TenantID:   row.TenantID,
+			TenantName: row.TenantName,
+			Keys:       row.KeyCount,
+			UsageTotals: totalsRow{
+				Requests:     row.Requests,
+				ClientErrors: row.ClientErrors,
+				ServerErrors: row.ServerErrors,
+				Throttled:    row.Throttled,
+				BytesIn:      row.BytesIn,
+				BytesOut:     row.BytesOut,
+			}.totals(),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,189 @@
+package apiusage
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultPeriodDays = 30
+	maxPeriodDays     = 366
+	topEndpointLimit  = 10
+)
+
+var ErrInvalidPeriod = errors.New("invalid period: 'from' must not be after 'to' and the range must not exceed 366 days")
+
+// Service reads API usage for tenants and superadmins
+type Service interface {
+	TenantUsage(ctx context.Context, tenantID uint, from, to *time.Time) (*TenantUsage, error)
+	KeyUsage(ctx context.Context, keyID string, from, to *time.Time) (*KeyReport, error)
+	Rollup(ctx context.Context, from, to *time.Time) (*RollupResponse, error)
+}
+
+// RollupResponse is the superadmin view across tenants
+type RollupResponse struct {
+	From         time.Time       `json:"from"`
+	To           time.Time       `json:"to"`
+	Totals       UsageTotals     `json:"totals"`
+	Tenants      []TenantRollup  `json:"tenants"`
+	TopEndpoints []EndpointTotal `json:"top_endpoints"`
+}
+
+type service struct {
+	repo    Repository
+	tracker *Tracker
+}
+
+func NewService(repo Repository, tracker *Tracker) Service {
+	return &service{repo: repo, tracker: tracker}
+}
+
+// period resolves the requested range, defaulting to the last 30 days
+func period(from, to *time.Time) (time.Time, time.Time, error) {
+	end := today()
+	if to != nil {
+		end = to.UTC().Truncate(24 * time.Hour)
+	}
+	start := end.AddDate(0, 0, -(defaultPeriodDays - 1))
+	if from != nil {
+		start = from.UTC().Truncate(24 * time.Hour)
+	}
+	if start.After(end) || end.Sub(start) > maxPeriodDays*24*time.Hour {
+		return time.Time{}, time.Time{}, ErrInvalidPeriod
+	}
+	return start, end, nil
+}
+
+// flush makes the numbers include the requests still held in memory
+func (s *service) flush(ctx context.Context) {
+	if s.tracker != nil {
+		_ = s.tracker.Flush(ctx)
+	}
+}
+
+func (s *service) TenantUsage(ctx context.Context, tenantID uint, from, to *time.Time) (*TenantUsage, error) {
+	start, end, err := period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	s.flush(ctx)
+
+	rows, err := s.repo.ListDailyByTenant(ctx, tenantID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var all totalsRow
+	keys := []KeyUsage{}
+	index := map[string]int{}
+	perKey := map[string]*totalsRow{}
+	for _, row := range rows {
+		i, ok := index[row.KeyID]
+		if !ok {
+			i = len(keys)
+			index[row.KeyID] = i
+			keys = append(keys, KeyUsage{KeyID: row.KeyID})
+			perKey[row.KeyID] = &totalsRow{}
+		}
+		keys[i].Daily = append(keys[i].Daily, row)
+		for _, t := range []*totalsRow{perKey[row.KeyID], &all} {
+			t.Requests += row.Requests
+			t.ClientErrors += row.ClientErrors
+			t.ServerErrors += row.ServerErrors
+			t.Throttled += row.Throttled
+			t.BytesIn += row.BytesIn
+			t.BytesOut += row.BytesOut
+		}
+	}
+	for i := range keys {
+		keys[i].UsageTotals = perKey[keys[i].KeyID].totals()
+	}
+
+	top, err := s.repo.TopEndpoints(ctx, &tenantID, start, end, topEndpointLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TenantUsage{
+		TenantID:     tenantID,
+		From:         start,
+		To:           end,
+		Totals:       all.totals(),
+		Keys:         keys,
+		TopEndpoints: top,
+	}, nil
+}
+
+func (s *service) KeyUsage(ctx context.Context, keyID string, from, to *time.Time) (*KeyReport, error) {
+	start, end, err := period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	s.flush(ctx)
+
+	rows, err := s.repo.ListDailyByKey(ctx, keyID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	var all totalsRow
+	for _, row := range rows {
+		all.Requests += row.Requests
+		all.ClientErrors += row.ClientErrors
+		all.ServerErrors += row.ServerErrors
+		all.Throttled += row.Throttled
+		all.BytesIn += row.BytesIn
+		all.BytesOut += row.BytesOut
+	}
+	if rows == nil {
+		rows = []DailyUsage{}
+	}
+
+	top, err := s.repo.TopEndpointsByKey(ctx, keyID, start, end, topEndpointLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KeyReport{
+		KeyUsage:     KeyUsage{KeyID: keyID, UsageTotals: all.totals(), Daily: rows},
+		From:         start,
+		To:           end,
+		TopEndpoints: top,
+	}, nil
+}
+
+func (s *service) Rollup(ctx context.Context, from, to *time.Time) (*RollupResponse, error) {
+	start, end, err := period(from, to)
+	if err != nil {
+		return nil, err
+	}
+	s.flush(ctx)
+
+	tenants, err := s.repo.RollupByTenant(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	var all totalsRow
+	for _, t := range tenants {
+		all.Requests += t.Requests
+		all.ClientErrors += t.ClientErrors
+		all.ServerErrors += t.ServerErrors
+		all.Throttled += t.Throttled
+		all.BytesIn += t.BytesIn
+		all.BytesOut += t.BytesOut
+	}
+
+	top, err := s.repo.TopEndpoints(ctx, nil, start, end, topEndpointLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RollupResponse{
+		From:         start,
+		To:           end,
+		Totals:       all.totals(),
+		Tenants:      tenants,
+		TopEndpoints: top,
+	}, nil
+}
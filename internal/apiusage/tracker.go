@@ -0,0 +1,298 @@
+package apiusage
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Redis keys: api_quota:<keyID>:<yyyymmdd> counts the requests of a key for
+// one UTC day and expires the day after.
+const (
+	quotaKeyPrefix = "api_quota"
+	dayKeyLayout   = "20060102"
+	flushInterval  = 30 * time.Second
+)
+
+type dailyKey struct {
+	keyID string
+	day   time.Time
+}
+
+type endpointKey struct {
+	keyID  string
+	day    time.Time
+	method string
+	route  string
+}
+
+// Tracker meters requests made with an API key. Counters are aggregated in
+// memory and flushed to the database periodically; the daily quota is
+// enforced with a Redis counter, or an in-memory one seeded from the
+// database when Redis isn't available.
+type Tracker struct {
+	repo Repository
+
+	mu        sync.Mutex
+	daily     map[dailyKey]*DailyUsage
+	endpoints map[endpointKey]*EndpointUsage
+	local     map[dailyKey]int64 // quota counters used without Redis
+}
+
+// NewTracker creates the usage tracker
+func NewTracker(repo Repository) *Tracker {
+	return &Tracker{
+		repo:      repo,
+		daily:     make(map[dailyKey]*DailyUsage),
+		endpoints: make(map[endpointKey]*EndpointUsage),
+		local:     make(map[dailyKey]int64),
+	}
+}
+
+func today() time.Time {
+	y, m, d := time.Now().UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func quotaKey(keyID string, day time.Time) string {
+	return fmt.Sprintf("%s:%s:%s", quotaKeyPrefix, keyID, day.Format(dayKeyLayout))
+}
+
+// take counts one request against the key's quota and returns the count
+// for the day including this request
+func (t *Tracker) take(ctx context.Context, keyID string, day time.Time) (int64, error) {
+	if utils.RedisClient != nil {
+		key := quotaKey(keyID, day)
+		n, err := utils.RedisClient.Incr(ctx, key).Result()
+		if err == nil {
+			if n == 1 {
+				utils.RedisClient.ExpireAt(ctx, key, day.AddDate(0, 0, 2))
+			}
+			return n, nil
+		}
+		log.Printf("⚠️ API quota counter failed for %s, counting in memory: %v", key, err)
+	}
+
+	dk := dailyKey{keyID: keyID, day: day}
+	t.mu.Lock()
+	n, seeded := t.local[dk]
+	t.mu.Unlock()
+	if !seeded {
+		stored, err := t.repo.GetRequestCount(ctx, keyID, day)
+		if err != nil {
+			return 0, err
+		}
+		t.mu.Lock()
+		if _, ok := t.local[dk]; !ok {
+			t.local[dk] = stored
+		}
+		t.mu.Unlock()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.local[dk]++
+	n = t.local[dk]
+	return n, nil
+}
+
+// Middleware meters API key requests and rejects them with 429 once the key
+// has used its daily quota. Requests without an API key pass through.
+func (t *Tracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		raw, exists := c.Get(ContextKey)
+		if !exists {
+			c.Next()
+			return
+		}
+		key, ok := raw.(KeyInfo)
+		if !ok || key.KeyID == "" {
+			c.Next()
+			return
+		}
+
+		day := today()
+		limit := QuotaFor(key)
+		used, err := t.take(c.Request.Context(), key.KeyID, day)
+		if err != nil {
+			// Don't lock integrations out because metering is unavailable
+			log.Printf("⚠️ API quota check failed for key %s: %v", key.KeyID, err)
+			used = 0
+		}
+
+		remaining := limit - used
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(day.AddDate(0, 0, 1).Unix(), 10))
+
+		if used > limit {
+			t.recordThrottled(key, day, c.Request.ContentLength)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error": "API key has exceeded its daily request quota",
+				"plan":  key.Plan,
+				"limit": limit,
+			})
+			return
+		}
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		t.record(key, day, c.Request.Method, route, c.Writer.Status(), c.Request.ContentLength, int64(c.Writer.Size()))
+	}
+}
+
+func (t *Tracker) dailyRow(key KeyInfo, day time.Time) *DailyUsage {
+	dk := dailyKey{keyID: key.KeyID, day: day}
+	row, ok := t.daily[dk]
+	if !ok {
+		row = &DailyUsage{KeyID: key.KeyID, Day: day, TenantID: key.TenantID}
+		t.daily[dk] = row
+	}
+	return row
+}
+
+func (t *Tracker) record(key KeyInfo, day time.Time, method, route string, status int, bytesIn, bytesOut int64) {
+	if bytesIn < 0 {
+		bytesIn = 0
+	}
+	if bytesOut < 0 {
+		bytesOut = 0
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	row := t.dailyRow(key, day)
+	row.Requests++
+	row.BytesIn += bytesIn
+	row.BytesOut += bytesOut
+	switch {
+	case status >= 500:
+		row.ServerErrors++
+	case status >= 400:
+		row.ClientErrors++
+	}
+
+	ek := endpointKey{keyID: key.KeyID, day: day, method: method, route: route}
+	ep, ok := t.endpoints[ek]
+	if !ok {
+		ep = &EndpointUsage{KeyID: key.KeyID, Day: day, Method: method, Route: route, TenantID: key.TenantID}
+		t.endpoints[ek] = ep
+	}
+	ep.Requests++
+	ep.BytesOut += bytesOut
+	if status >= 400 {
+		ep.Errors++
+	}
+}
+
+func (t *Tracker) recordThrottled(key KeyInfo, day time.Time, bytesIn int64) {
+	if bytesIn < 0 {
+		bytesIn = 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	row := t.dailyRow(key, day)
+	row.Throttled++
+	row.BytesIn += bytesIn
+}
+
+// Flush writes the aggregated counters to the database. Rows that fail to
+// save are merged back so they're retried on the next flush.
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	daily := make([]DailyUsage, 0, len(t.daily))
+	for _, row := range t.daily {
+		row.UpdatedAt = time.Now()
+		daily = append(daily, *row)
+	}
+	endpoints := make([]EndpointUsage, 0, len(t.endpoints))
+	for _, row := range t.endpoints {
+		row.UpdatedAt = time.Now()
+		endpoints = append(endpoints, *row)
+	}
+	t.daily = make(map[dailyKey]*DailyUsage)
+	t.endpoints = make(map[endpointKey]*EndpointUsage)
+
+	// Local quota counters are only needed for today
+	day := today()
+	for dk := range t.local {
+		if dk.day.Before(day) {
+			delete(t.local, dk)
+		}
+	}
+	t.mu.Unlock()
+
+	if err := t.repo.AddDaily(ctx, daily); err != nil {
+		t.requeue(daily, nil)
+		t.requeue(nil, endpoints)
+		return err
+	}
+	if err := t.repo.AddEndpoints(ctx, endpoints); err != nil {
+		t.requeue(nil, endpoints)
+		return err
+	}
+	return nil
+}
+
+func (t *Tracker) requeue(daily []DailyUsage, endpoints []EndpointUsage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, row := range daily {
+		cur := t.dailyRow(KeyInfo{KeyID: row.KeyID, TenantID: row.TenantID}, row.Day)
+		cur.Requests += row.Requests
+		cur.ClientErrors += row.ClientErrors
+		cur.ServerErrors += row.ServerErrors
+		cur.Throttled += row.Throttled
+		cur.BytesIn += row.BytesIn
+		cur.BytesOut += row.BytesOut
+	}
+	for _, row := range endpoints {
+		ek := endpointKey{keyID: row.KeyID, day: row.Day, method: row.Method, route: row.Route}
+		cur, ok := t.endpoints[ek]
+		if !ok {
+			r := row
+			r.ID = 0
+			t.endpoints[ek] = &r
+			continue
+		}
+		cur.Requests += row.Requests
+		cur.Errors += row.Errors
+		cur.BytesOut += row.BytesOut
+	}
+}
+
+// Start flushes the counters every flushInterval until ctx is cancelled
+func (t *Tracker) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(flushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				if err := t.Flush(context.Background()); err != nil {
+					log.Printf("❌ API usage flush failed: %v", err)
+				}
+				return
+			case <-ticker.C:
+				if err := t.Flush(ctx); err != nil {
+					log.Printf("❌ API usage flush failed: %v", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,424 @@
+package auditlog
+
+import (
+	"sort"
+	"strings"
+)
+
+// ActionDefinition describes one audit action type
+type ActionDefinition struct {
+	Action      string `json:"action"`
+	Description string `json:"description"`
+}
+
+// ActionModule groups the audit actions written by one module
+type ActionModule struct {
+	Module      string             `json:"module"`
+	Description string             `json:"description"`
+	Actions     []ActionDefinition `json:"actions"`
+}
+
+// actionRegistry is the central catalog of audit actions. Every action passed
+// to LogAction should be listed here; in debug mode unknown actions are
+// reported when they are logged.
+var actionRegistry = []ActionModule{
+	{
+		Module:      "auth",
+		Description: "Sign-in security and self-serve registration",
+		Actions: []ActionDefinition{
+			{"TENANT_SELF_REGISTERED", "Temple admin completed verified self-registration"},
+			{"LOGIN_FAILED", "Login with a wrong password"},
+			{"LOGIN_BLOCKED", "Login refused for a locked account or blocked IP"},
+			{"ACCOUNT_LOCKED", "Account locked after repeated failed logins"},
+			{"ACCOUNT_UNLOCKED", "Superadmin unlocked a locked account"},
+			{"TWO_FACTOR_SETUP_STARTED", "Two-factor enrollment started"},
+			{"TWO_FACTOR_ENABLED", "Two-factor authentication enabled"},
+			{"TWO_FACTOR_DISABLED", "Two-factor authentication disabled"},
+			{"TWO_FACTOR_BACKUP_CODES_REGENERATED", "Two-factor backup codes regenerated"},
+			{"TWO_FACTOR_LOGIN", "Second login step with a two-factor code"},
+			{"TWO_FACTOR_ADMIN_RESET", "Superadmin reset a user's two-factor authentication"},
+		},
+	},
+	{
+		Module:      "tenants",
+		Description: "Tenant approval and sandbox settings",
+		Actions: []ActionDefinition{
+			{"TENANT_APPROVED", "Tenant registration approved"},
+			{"TENANT_APPROVAL_FAILED", "Tenant approval failed"},
+			{"TENANT_REJECTED", "Tenant registration rejected"},
+			{"TENANT_REJECTION_FAILED", "Tenant rejection failed"},
+			{"TENANT_SANDBOX_UPDATED", "Tenant sandbox mode changed"},
+			{"TENANT_SANDBOX_UPDATE_FAILED", "Tenant sandbox mode change failed"},
+			{"TENANT_DATA_REGION_UPDATED", "Tenant data residency region changed"},
+			{"DATA_RESIDENCY_BLOCKED", "File storage or transfer blocked by data residency rules"},
+			{"SANDBOX_DATA_WIPED", "Sandbox data of a tenant wiped"},
+		},
+	},
+	{
+		Module:      "users",
+		Description: "User and role management by superadmins",
+		Actions: []ActionDefinition{
+			{"USER_CREATED", "User created"},
+			{"USER_CREATE_FAILED", "User creation failed"},
+			{"USER_UPDATED", "User updated"},
+			{"USER_UPDATE_FAILED", "User update failed"},
+			{"USER_DELETED", "User deleted"},
+			{"USER_DELETE_FAILED", "User deletion failed"},
+			{"USER_STATUS_UPDATED", "User status changed"},
+			{"USER_STATUS_UPDATE_FAILED", "User status change failed"},
+			{"ROLE_CREATED", "Role created"},
+			{"ROLE_CREATE_FAILED", "Role creation failed"},
+			{"ROLE_UPDATED", "Role updated"},
+			{"ROLE_UPDATE_FAILED", "Role update failed"},
+			{"ROLE_STATUS_UPDATED", "Role status changed"},
+			{"ROLE_STATUS_UPDATE_FAILED", "Role status change failed"},
+		},
+	},
+	{
+		Module:      "organizations",
+		Description: "Organizations grouping several tenants",
+		Actions: []ActionDefinition{
+			{"ORGANIZATION_CREATED", "Organization created"},
+			{"ORGANIZATION_CREATE_FAILED", "Organization creation failed"},
+			{"ORGANIZATION_UPDATED", "Organization updated"},
+			{"ORGANIZATION_TENANT_ADDED", "Tenant added to an organization"},
+			{"ORGANIZATION_TENANT_REMOVED", "Tenant removed from an organization"},
+			{"ORGANIZATION_ADMIN_ADDED", "Organization admin added"},
+			{"ORGANIZATION_ADMIN_REMOVED", "Organization admin removed"},
+			{"ORGANIZATION_USER_ASSIGNED", "User assigned to organization tenants"},
+			{"ORGANIZATION_USER_ASSIGN_FAILED", "Organization user assignment failed"},
+		},
+	},
+	{
+		Module:      "temples",
+		Description: "Temple (entity) lifecycle and approval",
+		Actions: []ActionDefinition{
+			{"TEMPLE_CREATED", "Temple created and sent for approval"},
+			{"TEMPLE_CREATED_AUTO_APPROVED", "Temple created and approved automatically"},
+			{"TEMPLE_CREATE_FAILED", "Temple creation failed"},
+			{"TEMPLE_APPROVAL_REQUEST_FAILED", "Temple approval request could not be created"},
+			{"TEMPLE_UPDATED", "Temple updated"},
+			{"TEMPLE_UPDATED_RESUBMITTED", "Rejected temple updated and resubmitted"},
+			{"TEMPLE_UPDATE_FAILED", "Temple update failed"},
+			{"TEMPLE_REAPPROVAL_REQUESTED", "Temple re-approval requested"},
+			{"TEMPLE_REAPPROVAL_REQUEST_FAILED", "Temple re-approval request failed"},
+			{"TEMPLE_STATUS_TOGGLED", "Temple activated or deactivated"},
+			{"TEMPLE_STATUS_TOGGLE_FAILED", "Temple status change failed"},
+			{"TEMPLE_DELETED", "Temple deleted"},
+			{"TEMPLE_DELETE_FAILED", "Temple deletion failed"},
+			{"ENTITY_APPROVED", "Temple approved by a superadmin"},
+			{"ENTITY_APPROVAL_FAILED", "Temple approval failed"},
+			{"ENTITY_REJECTED", "Temple rejected by a superadmin"},
+			{"ENTITY_REJECTION_FAILED", "Temple rejection failed"},
+			{"ENTITY_CONFIG_EXPORTED", "Temple configuration exported"},
+			{"ENTITY_CONFIG_EXPORT_ENCRYPTED", "Temple configuration export encrypted"},
+			{"ENTITY_CONFIG_EXPORT_ENCRYPTION_FAILED", "Temple configuration export encryption failed"},
+			{"ENTITY_CONFIG_IMPORTED", "Temple configuration imported"},
+			{"ENTITY_CONFIG_IMPORT_FAILED", "Temple configuration import failed"},
+			{"PUBLIC_PAGE_THEME_UPDATED", "Landing page theme changed (draft)"},
+			{"PUBLIC_PAGE_SECTION_CREATED", "Landing page section added (draft)"},
+			{"PUBLIC_PAGE_SECTION_UPDATED", "Landing page section changed (draft)"},
+			{"PUBLIC_PAGE_SECTION_DELETED", "Landing page section removed (draft)"},
+			{"PUBLIC_PAGE_SECTIONS_REORDERED", "Landing page sections reordered (draft)"},
+			{"PUBLIC_PAGE_IMAGE_UPLOADED", "Landing page image uploaded"},
+			{"PUBLIC_PAGE_PUBLISHED", "Landing page published"},
+			{"PUBLIC_PAGE_UNPUBLISHED", "Landing page taken offline"},
+		},
+	},
+	{
+		Module:      "devotees",
+		Description: "Devotee profiles, memberships and imports",
+		Actions: []ActionDefinition{
+			{"PROFILE_CREATED", "Devotee profile created"},
+			{"PROFILE_UPDATED", "Devotee profile updated"},
+			{"DEVOTEE_JOINED_TEMPLE", "Devotee joined a temple"},
+			{"VOLUNTEER_JOINED_TEMPLE", "Volunteer joined a temple"},
+			{"USER_JOINED_TEMPLE", "User joined a temple"},
+			{"DEVOTEE_BULK_IMPORT", "Devotees imported from a file"},
+			{"DEVOTEE_BULK_IMPORT_DRY_RUN", "Devotee import validated without saving"},
+			{"DEVOTEE_CONTACT_IMPORT", "Devotees invited from imported contacts"},
+			{"DEVOTEE_CONTACT_IMPORT_FAILED", "Contact import failed"},
+		},
+	},
+	{
+		Module:      "sevas",
+		Description: "Sevas and seva bookings",
+		Actions: []ActionDefinition{
+			{"SEVA_CREATED", "Seva created"},
+			{"SEVA_CREATE_FAILED", "Seva creation failed"},
+			{"SEVA_UPDATED", "Seva updated"},
+			{"SEVA_UPDATE_FAILED", "Seva update failed"},
+			{"SEVA_DELETED_PERMANENTLY", "Seva deleted"},
+			{"SEVA_DELETE_FAILED", "Seva deletion failed"},
+			{"SEVA_BOOKED", "Seva booked"},
+			{"SEVA_BOOKING_FAILED", "Seva booking failed"},
+			{"SEVA_BOOKING_WAITLISTED", "Seva booking added to the waitlist"},
+			{"SEVA_WAITLIST_PROMOTED", "Waitlisted booking promoted"},
+			{"SEVA_BOOKING_APPROVED", "Seva booking approved"},
+			{"SEVA_BOOKING_REJECTED", "Seva booking rejected"},
+			{"SEVA_BOOKING_STATUS_UPDATED", "Seva booking status changed"},
+			{"SEVA_BOOKING_STATUS_UPDATE_FAILED", "Seva booking status change failed"},
+			{"SEVA_BOOKING_CANCELLED", "Seva booking cancelled"},
+			{"SEVA_BOOKING_CANCEL_FAILED", "Seva booking cancellation failed"},
+			{"SEVA_BOOKING_RESCHEDULED", "Seva booking moved to another seva or day"},
+			{"SEVA_BOOKING_RESCHEDULE_FAILED", "Seva booking reschedule failed"},
+			{"SEVA_BOOKING_REFUND_REQUESTED", "Refund asked for on cancelling a paid seva booking"},
+			{"SEVA_BOOKING_REFUND_UPDATED", "Seva booking refund processed or declined"},
+			{"SEVA_RECOMMENDATION_RULES_UPDATED", "Seva recommendation rules updated"},
+		},
+	},
+	{
+		Module:      "events",
+		Description: "Temple events",
+		Actions: []ActionDefinition{
+			{"EVENT_CREATED", "Event created"},
+			{"EVENT_UPDATED", "Event updated"},
+			{"EVENT_DELETED", "Event deleted"},
+			{"EVENT_CALENDAR_FEED_ISSUED", "Event calendar subscription link issued"},
+		},
+	},
+	{
+		Module:      "donations",
+		Description: "Donations, payments and receipts",
+		Actions: []ActionDefinition{
+			{"DONATION_INITIATED", "Donation order created"},
+			{"DONATION_SUCCESS", "Donation payment verified"},
+			{"DONATION_FAILED", "Donation payment failed"},
+			{"DONATION_VERIFICATION_FAILED", "Donation payment could not be verified"},
+			{"DONATION_ALREADY_PROCESSED", "Donation verification repeated"},
+			{"DONATION_UPDATE_FAILED", "Donation could not be updated"},
+			{"DONATION_RECEIPT_GENERATED", "Donation receipt generated"},
+			{"DONATION_RECEIPT_FAILED", "Donation receipt generation failed"},
+			{"PAYMENT_CAPTURED", "Payment captured (gateway webhook)"},
+			{"PAYMENT_FAILED", "Payment failed (gateway webhook)"},
+			{"PAYMENT_REFUNDED", "Payment refunded (gateway webhook)"},
+			{"PAYMENT_WEBHOOK_REJECTED", "Payment webhook with an invalid signature"},
+			{"PAYMENT_WEBHOOK_FAILED", "Payment webhook could not be processed"},
+			{"CAMPAIGN_CREATED", "Fundraising campaign created"},
+			{"CAMPAIGN_UPDATED", "Fundraising campaign updated"},
+			{"CAMPAIGN_IMAGE_UPLOADED", "Fundraising campaign image uploaded"},
+			{"PLEDGE_CREATED", "Monthly donation pledge created"},
+			{"PLEDGE_UPDATED", "Monthly donation pledge changed, paused or resumed"},
+			{"PLEDGE_CANCELLED", "Monthly donation pledge cancelled"},
+			{"PLEDGE_CHARGE_CREATED", "Payment link sent for a due pledge"},
+			{"PLEDGE_CHARGE_PAID", "Pledge payment link paid"},
+			{"PLEDGE_CHARGE_FAILED", "Pledge payment link expired or was cancelled"},
+			{"PLEDGE_WEBHOOK_REJECTED", "Payment link webhook rejected"},
+		},
+	},
+	{
+		Module:      "expenses",
+		Description: "Temple expenses and their approval",
+		Actions: []ActionDefinition{
+			{"EXPENSE_CREATED", "Expense recorded"},
+			{"EXPENSE_UPDATED", "Pending expense corrected"},
+			{"EXPENSE_APPROVED", "Expense approved by the temple admin"},
+			{"EXPENSE_REJECTED", "Expense rejected by the temple admin"},
+			{"EXPENSE_RECEIPT_UPLOADED", "Expense receipt uploaded"},
+		},
+	},
+	{
+		Module:      "hundi",
+		Description: "Hundi counting sessions and their tally",
+		Actions: []ActionDefinition{
+			{"HUNDI_COUNT_RECORDED", "Hundi counting session recorded"},
+			{"HUNDI_COUNT_UPDATED", "Pending hundi count corrected"},
+			{"HUNDI_COUNT_FLAGGED", "Discrepancy flagged on a hundi count"},
+			{"HUNDI_COUNT_APPROVED", "Hundi count approved by the temple admin"},
+			{"HUNDI_COUNT_REJECTED", "Hundi count rejected by the temple admin"},
+		},
+	},
+	{
+		Module:      "inventory",
+		Description: "Pooja materials, temple assets and their stock",
+		Actions: []ActionDefinition{
+			{"INVENTORY_ITEM_CREATED", "Inventory item added"},
+			{"INVENTORY_ITEM_UPDATED", "Inventory item details changed"},
+			{"INVENTORY_STOCK_PURCHASED", "Stock purchased"},
+			{"INVENTORY_STOCK_CONSUMED", "Stock consumed, optionally by a seva or event"},
+			{"INVENTORY_STOCK_ADJUSTED", "Stock count corrected"},
+			{"INVENTORY_LOW_STOCK", "Item fell to its reorder level"},
+		},
+	},
+	{
+		Module:      "volunteers",
+		Description: "Volunteer registration, event slots and attendance",
+		Actions: []ActionDefinition{
+			{"VOLUNTEER_REGISTERED", "User registered as a temple volunteer"},
+			{"VOLUNTEER_UPDATED", "Volunteer skills or availability changed"},
+			{"VOLUNTEER_STATUS_CHANGED", "Volunteer set active or inactive"},
+			{"VOLUNTEER_SLOT_CREATED", "Volunteering slot added to an event"},
+			{"VOLUNTEER_SLOT_UPDATED", "Volunteering slot changed"},
+			{"VOLUNTEER_SLOT_CANCELLED", "Volunteering slot cancelled"},
+			{"VOLUNTEER_SIGNED_UP", "Volunteer signed up for a slot"},
+			{"VOLUNTEER_SIGNUP_CANCELLED", "Volunteer gave up a slot"},
+			{"VOLUNTEER_ATTENDANCE_MARKED", "Volunteer attendance marked"},
+		},
+	},
+	{
+		Module:      "checkin",
+		Description: "QR check-in of seva bookings and event RSVPs",
+		Actions: []ActionDefinition{
+			{"CHECKIN_RECORDED", "Devotee checked in with a QR pass"},
+			{"CHECKIN_REJECTED", "QR pass refused at check-in"},
+		},
+	},
+	{
+		Module:      "families",
+		Description: "Devotee households, their members and family seva bookings",
+		Actions: []ActionDefinition{
+			{"FAMILY_CREATED", "Family created"},
+			{"FAMILY_UPDATED", "Family name or mailing address changed"},
+			{"FAMILY_CLOSED", "Family closed by its last member"},
+			{"FAMILY_MEMBER_ADDED", "Dependent added to a family"},
+			{"FAMILY_MEMBER_JOINED", "Devotee joined a family with its join code"},
+			{"FAMILY_MEMBER_UPDATED", "Family member details changed"},
+			{"FAMILY_MEMBER_REMOVED", "Member removed from or left a family"},
+			{"FAMILY_HEAD_TRANSFERRED", "Family handed over to a new head"},
+			{"FAMILY_JOIN_CODE_REGENERATED", "Family join code replaced"},
+			{"FAMILY_SEVA_BOOKED", "Seva booked for a family"},
+		},
+	},
+	{
+		Module:      "disputes",
+		Description: "Payment disputes and chargebacks",
+		Actions: []ActionDefinition{
+			{"DISPUTE_CREATED", "Dispute opened by the gateway"},
+			{"DISPUTE_UNDER_REVIEW", "Dispute under review"},
+			{"DISPUTE_ACTION_REQUIRED", "Dispute needs a response"},
+			{"DISPUTE_WON", "Dispute won"},
+			{"DISPUTE_LOST", "Dispute lost"},
+			{"DISPUTE_CLOSED", "Dispute closed"},
+			{"DISPUTE_OPEN", "Dispute reopened manually"},
+			{"DISPUTE_RESPONDED", "Dispute response submitted"},
+			{"DISPUTE_EVIDENCE_UPLOADED", "Dispute evidence uploaded"},
+			{"DISPUTE_BOOKING_LINKED", "Dispute linked to a seva booking"},
+			{"DISPUTE_WEBHOOK_REJECTED", "Dispute webhook with an invalid signature"},
+		},
+	},
+	{
+		Module:      "notifications",
+		Description: "Templates, messages and notification settings",
+		Actions: []ActionDefinition{
+			{"TEMPLATE_CREATED", "Notification template created"},
+			{"TEMPLATE_UPDATED", "Notification template updated"},
+			{"TEMPLATE_DELETED", "Notification template deleted"},
+			{"TEMPLATE_TRANSLATION_SAVED", "Template translation saved"},
+			{"TEMPLATE_TRANSLATION_DELETED", "Template translation deleted"},
+			{"TEMPLATE_MACHINE_TRANSLATED", "Template translation drafted by machine translation"},
+			{"EMAIL_SENT", "Email sent"},
+			{"SMS_SENT", "SMS sent"},
+			{"WHATSAPP_SENT", "WhatsApp message sent"},
+			{"PUSH_NOTIFICATION_SENT", "Push notification sent to devices"},
+			{"PUSH_TOPIC_SENT", "Push notification sent to entity topics"},
+			{"NOTIFICATION_SENT", "Notification sent on another channel"},
+			{"NOTIFICATION_PREFERENCES_UPDATED", "Notification preferences changed"},
+		},
+	},
+	{
+		Module:      "reports",
+		Description: "Report views, downloads and export jobs",
+		Actions: []ActionDefinition{
+			{"TEMPLE_ACTIVITIES_REPORT_VIEWED", "Temple activities report viewed"},
+			{"TEMPLE_ACTIVITIES_REPORT_DOWNLOADED", "Temple activities report downloaded"},
+			{"TEMPLE_ACTIVITIES_REPORT_DOWNLOAD_FAILED", "Temple activities report download failed"},
+			{"TEMPLE_REGISTER_REPORT_VIEWED", "Temple register report viewed"},
+			{"TEMPLE_REGISTER_REPORT_DOWNLOADED", "Temple register report downloaded"},
+			{"TEMPLE_REGISTER_REPORT_DOWNLOAD_FAILED", "Temple register report download failed"},
+			{"DEVOTEE_BIRTHDAYS_REPORT_VIEWED", "Devotee birthdays report viewed"},
+			{"DEVOTEE_BIRTHDAYS_REPORT_DOWNLOADED", "Devotee birthdays report downloaded"},
+			{"DEVOTEE_BIRTHDAYS_REPORT_DOWNLOAD_FAILED", "Devotee birthdays report download failed"},
+			{"DEVOTEE_LIST_REPORT_VIEWED", "Devotee list report viewed"},
+			{"DEVOTEE_LIST_REPORT_DOWNLOADED", "Devotee list report downloaded"},
+			{"DEVOTEE_LIST_REPORT_DOWNLOAD_FAILED", "Devotee list report download failed"},
+			{"DEVOTEE_PROFILE_REPORT_VIEWED", "Devotee profile report viewed"},
+			{"DEVOTEE_PROFILE_REPORT_DOWNLOADED", "Devotee profile report downloaded"},
+			{"DEVOTEE_PROFILE_REPORT_DOWNLOAD_FAILED", "Devotee profile report download failed"},
+			{"AUDIT_LOGS_REPORT_VIEWED", "Audit logs report viewed"},
+			{"AUDIT_LOGS_REPORT_DOWNLOADED", "Audit logs report downloaded"},
+			{"AUDIT_LOGS_REPORT_DOWNLOAD_FAILED", "Audit logs report download failed"},
+			{"APPROVAL_STATUS_REPORT_VIEWED", "Approval status report viewed"},
+			{"APPROVAL_STATUS_REPORT_DOWNLOADED", "Approval status report downloaded"},
+			{"APPROVAL_STATUS_REPORT_DOWNLOAD_FAILED", "Approval status report download failed"},
+			{"USER_DETAILS_REPORT_VIEWED", "User details report viewed"},
+			{"USER_DETAILS_REPORT_DOWNLOADED", "User details report downloaded"},
+			{"USER_DETAILS_REPORT_DOWNLOAD_FAILED", "User details report download failed"},
+			{"ORGANIZATION_ACTIVITIES_REPORT_VIEWED", "Organization activities report viewed"},
+			{"ORGANIZATION_SUMMARY_REPORT_VIEWED", "Organization summary report viewed"},
+			{"SUPERADMIN_ACTIVITIES_REPORT_VIEWED", "Superadmin viewed activities across tenants"},
+			{"SUPERADMIN_TEMPLE_REGISTER_REPORT_VIEWED", "Superadmin viewed the temple register across tenants"},
+			{"SUPERADMIN_DEVOTEE_BIRTHDAYS_REPORT_VIEWED", "Superadmin viewed devotee birthdays across tenants"},
+			{"SUPERADMIN_DEVOTEE_LIST_REPORT_VIEWED", "Superadmin viewed the devotee list across tenants"},
+			{"SUPERADMIN_DEVOTEE_PROFILE_REPORT_VIEWED", "Superadmin viewed devotee profiles across tenants"},
+			{"SUPERADMIN_AUDIT_LOGS_REPORT_VIEWED", "Superadmin viewed audit logs across tenants"},
+			{"SUPERADMIN_TENANT_ACTIVITIES_REPORT_VIEWED", "Superadmin viewed one tenant's activities"},
+			{"SUPERADMIN_TENANT_TEMPLE_REGISTER_REPORT_VIEWED", "Superadmin viewed one tenant's temple register"},
+			{"SUPERADMIN_TENANT_DEVOTEE_BIRTHDAYS_REPORT_VIEWED", "Superadmin viewed one tenant's devotee birthdays"},
+			{"SUPERADMIN_TENANT_DEVOTEE_LIST_REPORT_VIEWED", "Superadmin viewed one tenant's devotee list"},
+			{"SUPERADMIN_TENANT_DEVOTEE_PROFILE_REPORT_VIEWED", "Superadmin viewed one tenant's devotee profiles"},
+			{"SUPERADMIN_TENANT_AUDIT_LOGS_REPORT_VIEWED", "Superadmin viewed one tenant's audit logs"},
+			{"EXPORT_AUDIT_REPORT_VIEWED", "Export audit report viewed"},
+			{"EXPORT_AUDIT_REPORT_DOWNLOADED", "Export audit report downloaded"},
+			{"EXPORT_AUDIT_REPORT_DOWNLOAD_FAILED", "Export audit report download failed"},
+			{"INCOME_EXPENSE_REPORT_VIEWED", "Income and expense statement viewed"},
+			{"INCOME_EXPENSE_REPORT_DOWNLOADED", "Income and expense statement downloaded"},
+			{"INCOME_EXPENSE_REPORT_DOWNLOAD_FAILED", "Income and expense statement download failed"},
+			{"INVENTORY_VALUATION_REPORT_VIEWED", "Inventory valuation report viewed"},
+			{"INVENTORY_VALUATION_REPORT_DOWNLOADED", "Inventory valuation report downloaded"},
+			{"INVENTORY_VALUATION_REPORT_DOWNLOAD_FAILED", "Inventory valuation report download failed"},
+			{"FAMILY_REPORT_VIEWED", "Family mailing report viewed"},
+			{"FAMILY_REPORT_DOWNLOADED", "Family mailing report downloaded"},
+			{"FAMILY_REPORT_DOWNLOAD_FAILED", "Family mailing report download failed"},
+			{"REPORT_EXPORT_FORMAT_DENIED", "Export in a format the role may not use"},
+			{"REPORT_EXPORT_TEMPLATE_SAVED", "Report export template saved"},
+			{"REPORT_EXPORT_TEMPLATE_DELETED", "Report export template deleted"},
+			{"REPORT_JOB_QUEUED", "Background report job queued"},
+			{"REPORT_JOB_COMPLETED", "Background report job completed"},
+			{"REPORT_JOB_FAILED", "Background report job failed"},
+		},
+	},
+	{
+		Module:      "migrations",
+		Description: "Legacy register migrations",
+		Actions: []ActionDefinition{
+			{"MIGRATION_BATCH_STAGED", "Legacy register uploaded for migration"},
+			{"MIGRATION_BATCH_VALIDATED", "Migration column mapping validated"},
+			{"MIGRATION_BATCH_COMMITTED", "Migration batch imported"},
+			{"MIGRATION_BATCH_COMMIT_FAILED", "Migration batch import failed"},
+			{"MIGRATION_BATCH_ROLLED_BACK", "Migration batch rolled back"},
+		},
+	},
+}
+
+// knownActions indexes actionRegistry by action name
+var knownActions = func() map[string]string {
+	m := make(map[string]string)
+	for _, mod := range actionRegistry {
+		for _, a := range mod.Actions {
+			m[a.Action] = mod.Module
+		}
+	}
+	return m
+}()
+
+// ActionCatalog returns the registered actions grouped by module. With a
+// module name only that module is returned.
+func ActionCatalog(module string) []ActionModule {
+	module = strings.ToLower(strings.TrimSpace(module))
+	catalog := make([]ActionModule, 0, len(actionRegistry))
+	for _, mod := range actionRegistry {
+		if module != "" && mod.Module != module {
+			continue
+		}
+		actions := append([]ActionDefinition(nil), mod.Actions...)
+		sort.Slice(actions, func(i, j int) bool { return actions[i].Action < actions[j].Action })
+		catalog = append(catalog, ActionModule{Module: mod.Module, Description: mod.Description, Actions: actions})
+	}
+	return catalog
+}
+
+// IsKnownAction reports whether the action is in the registry
+func IsKnownAction(action string) bool {
+	_, ok := knownActions[action]
+	return ok
+}
@@ -101,6 +101,24 @@ func (h *Handler) GetAuditLogs(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
+// GetActionCatalog handles GET /auditlogs/actions - lists the known audit actions
+// @Summary Get audit action catalog
+// @Description List the known audit action types grouped by module (SuperAdmin only)
+// @Tags AuditLog
+// @Produce json
+// @Param module query string false "Only return this module"
+// @Success 200 {object} gin.H
+// @Router /api/v1/auditlogs/actions [get]
+func (h *Handler) GetActionCatalog(c *gin.Context) {
+	catalog := ActionCatalog(c.Query("module"))
+	if len(catalog) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown module"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": catalog})
+}
+
 // GetAuditLogByID handles GET /auditlogs/:id - retrieves a specific audit log by ID
 // @Summary Get audit log by ID
 // @Description Retrieve a specific audit log by ID (SuperAdmin only)
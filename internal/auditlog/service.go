@@ -4,7 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log"
 	"math"
+	"runtime"
+
+	"github.com/gin-gonic/gin"
 )
 
 type Service interface {
@@ -23,6 +27,12 @@ func NewService(repo Repository) Service {
 
 // LogAction creates a new audit log entry
 func (s *service) LogAction(ctx context.Context, userID *uint, entityID *uint, action string, details map[string]interface{}, ip string, status string) error {
+	// Catch typos and unregistered actions during development
+	if gin.IsDebugging() && !IsKnownAction(action) {
+		_, file, line, _ := runtime.Caller(1)
+		log.Printf("⚠️ Audit action %q is not in the action registry (%s:%d)", action, file, line)
+	}
+
 	// Handle nil details
 	if details == nil {
 		details = make(map[string]interface{})
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
 )
 
 type Handler struct{ service Service }
@@ -62,6 +63,10 @@ func (h *Handler) Register(c *gin.Context) {
 	input := RegisterInput(req)
 
 	if err := h.service.Register(input); err != nil {
+		if errors.Is(err, ErrTenantVerificationRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -94,12 +99,29 @@ func (h *Handler) Login(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	tokens, user, err := h.service.Login(LoginInput(req))
+	tokens, user, err := h.service.Login(LoginInput(req), clientIP(c))
 	if err != nil {
+		if respondLoginLockout(c, err) {
+			return
+		}
+		var twoFactor *TwoFactorRequiredError
+		if errors.As(err, &twoFactor) {
+			c.JSON(http.StatusOK, gin.H{
+				"twoFactorRequired": true,
+				"challengeToken":    twoFactor.ChallengeToken,
+				"expiresIn":         int(twoFactor.ExpiresIn.Seconds()),
+			})
+			return
+		}
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.JSON(http.StatusOK, loginResponse(tokens, user))
+}
+
+// loginResponse is the body returned once a user is fully signed in
+func loginResponse(tokens *TokenPair, user *User) gin.H {
 	userPayload := gin.H{
 		"id":       user.ID,
 		"fullName": user.FullName,
@@ -110,11 +132,11 @@ func (h *Handler) Login(c *gin.Context) {
 		userPayload["entityId"] = user.EntityID
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	return gin.H{
 		"accessToken":  tokens.AccessToken,
 		"refreshToken": tokens.RefreshToken,
 		"user":         userPayload,
-	})
+	}
 }
 
 // ===============================
@@ -131,12 +153,15 @@ func (h *Handler) Refresh(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	token, err := h.service.Refresh(req.RefreshToken)
+	tokens, err := h.service.Refresh(req.RefreshToken)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{"accessToken": token})
+	c.JSON(http.StatusOK, gin.H{
+		"accessToken":  tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+	})
 }
 
 // ===============================
@@ -317,8 +342,32 @@ func (h *Handler) ResetPassword(c *gin.Context) {
 // Logout
 // ===============================
 
+type logoutReq struct {
+	RefreshToken string `json:"refreshToken" example:"your_refresh_token_here"`
+	AllSessions  bool   `json:"allSessions"`
+}
+
 func (h *Handler) Logout(c *gin.Context) {
-	_ = h.service.Logout() // stateless
+	var req logoutReq
+	// Body is optional: without it only the access token is revoked
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	claims, _ := c.MustGet("claims").(jwt.MapClaims)
+	err := h.service.Logout(LogoutInput{
+		UserID:       c.GetUint("user_id"),
+		AccessClaims: claims,
+		RefreshToken: req.RefreshToken,
+		AllSessions:  req.AllSessions,
+	})
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
 }
 
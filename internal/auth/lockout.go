@@ -0,0 +1,224 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Failed logins are counted per account on the users row, so a lock
+// survives restarts and shows up in user listings, and per client IP in
+// Redis, which also catches one IP guessing across many accounts.
+//
+//	login_failures_ip:<ip> -> failed logins from the IP in the lockout window
+const loginIPFailuresPrefix = "login_failures_ip"
+
+var (
+	ErrTooManyLoginAttempts = errors.New("too many failed login attempts from this address, please try again later")
+	ErrAccountNotLocked     = errors.New("account is not locked")
+)
+
+// AccountLockedError is returned while an account is locked
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account locked after too many failed login attempts, try again after %s", e.Until.Format(time.RFC3339))
+}
+
+func loginIPFailuresKey(ip string) string {
+	return fmt.Sprintf("%s:%s", loginIPFailuresPrefix, ip)
+}
+
+// checkLoginIP refuses logins from an IP that failed too often recently
+func (s *service) checkLoginIP(ip string) error {
+	if ip == "" || s.loginIPMaxFailures <= 0 || utils.RedisClient == nil {
+		return nil
+	}
+	n, err := utils.RedisClient.Get(context.Background(), loginIPFailuresKey(ip)).Int()
+	if err != nil || n < s.loginIPMaxFailures {
+		return nil // fail open when Redis is unavailable
+	}
+	if s.auditSvc != nil {
+		s.auditSvc.LogAction(context.Background(), nil, nil, "LOGIN_BLOCKED", map[string]interface{}{
+			"reason":   "ip blocked",
+			"failures": n,
+		}, ip, "failure")
+	}
+	return ErrTooManyLoginAttempts
+}
+
+func (s *service) recordIPFailure(ip string) {
+	if ip == "" || utils.RedisClient == nil {
+		return
+	}
+	ctx := context.Background()
+	key := loginIPFailuresKey(ip)
+	n, err := utils.RedisClient.Incr(ctx, key).Result()
+	if err != nil {
+		log.Printf("⚠️ Failed to count failed login from %s: %v", ip, err)
+		return
+	}
+	if n == 1 {
+		utils.RedisClient.Expire(ctx, key, s.loginLockout)
+	}
+}
+
+// checkAccountLock refuses logins to a locked account before the password
+// is checked, so guessing during the lock gains nothing
+func (s *service) checkAccountLock(user *User, ip string) error {
+	if user.LockedUntil == nil || !time.Now().Before(*user.LockedUntil) {
+		return nil
+	}
+	s.audit(user.ID, "LOGIN_BLOCKED", map[string]interface{}{
+		"reason":       "account locked",
+		"locked_until": user.LockedUntil,
+	}, ip, "failure")
+	return &AccountLockedError{Until: *user.LockedUntil}
+}
+
+// recordLoginFailure counts a wrong password against the account and the IP.
+// It returns an AccountLockedError when this failure locks the account.
+func (s *service) recordLoginFailure(user *User, ip string) error {
+	s.recordIPFailure(ip)
+
+	attempts, err := s.repo.RecordFailedLogin(user.ID, s.loginLockout)
+	if err != nil {
+		log.Printf("❌ Failed to record failed login of user %d: %v", user.ID, err)
+		return nil
+	}
+	s.audit(user.ID, "LOGIN_FAILED", map[string]interface{}{
+		"reason":   "invalid password",
+		"attempts": attempts,
+	}, ip, "failure")
+
+	if s.loginMaxFailures <= 0 || attempts < s.loginMaxFailures {
+		return nil
+	}
+
+	until := time.Now().Add(s.loginLockout)
+	if err := s.repo.LockAccount(user.ID, until); err != nil {
+		log.Printf("❌ Failed to lock user %d: %v", user.ID, err)
+		return nil
+	}
+	s.audit(user.ID, "ACCOUNT_LOCKED", map[string]interface{}{
+		"attempts":     attempts,
+		"locked_until": until,
+	}, ip, "success")
+	go utils.SendAccountLockedEmail(user.Email, user.FullName, ip, until)
+	return &AccountLockedError{Until: until}
+}
+
+// clearLoginFailures resets the account's counter after a correct password
+func (s *service) clearLoginFailures(user *User) {
+	if user.FailedLoginAttempts == 0 && user.LockedUntil == nil {
+		return
+	}
+	if err := s.repo.ResetLoginFailures(user.ID); err != nil {
+		log.Printf("⚠️ Failed to reset failed logins of user %d: %v", user.ID, err)
+	}
+}
+
+// UnlockAccount lets a superadmin lift a lock before it expires
+func (s *service) UnlockAccount(actorID, targetUserID uint, ip string) error {
+	user, err := s.repo.FindByID(targetUserID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.LockedUntil == nil || !time.Now().Before(*user.LockedUntil) {
+		return ErrAccountNotLocked
+	}
+
+	details := map[string]interface{}{"target_user_id": targetUserID, "locked_until": user.LockedUntil}
+	if err := s.repo.ResetLoginFailures(targetUserID); err != nil {
+		details["error"] = err.Error()
+		s.audit(actorID, "ACCOUNT_UNLOCKED", details, ip, "failure")
+		return err
+	}
+	s.audit(actorID, "ACCOUNT_UNLOCKED", details, ip, "success")
+	return nil
+}
+
+// =============================
+// Repository
+// =============================
+
+// RecordFailedLogin counts a failed login and returns the failures within
+// window; older failures no longer count
+func (r *repository) RecordFailedLogin(userID uint, window time.Duration) (int, error) {
+	var attempts int
+	err := r.db.Raw(`
+		UPDATE users SET
+			failed_login_attempts = CASE WHEN last_failed_login_at > ? THEN failed_login_attempts + 1 ELSE 1 END,
+			last_failed_login_at = ?
+		WHERE id = ?
+		RETURNING failed_login_attempts`,
+		time.Now().Add(-window), time.Now(), userID,
+	).Scan(&attempts).Error
+	return attempts, err
+}
+
+func (r *repository) LockAccount(userID uint, until time.Time) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"locked_until":          until,
+		"failed_login_attempts": 0,
+	}).Error
+}
+
+func (r *repository) ResetLoginFailures(userID uint) error {
+	return r.db.Model(&User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"locked_until":          nil,
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+	}).Error
+}
+
+// =============================
+// Handlers
+// =============================
+
+// respondLoginLockout writes the response for lockout errors and reports
+// whether err was one
+func respondLoginLockout(c *gin.Context, err error) bool {
+	var locked *AccountLockedError
+	switch {
+	case errors.As(err, &locked):
+		retryAfter := int(math.Ceil(time.Until(locked.Until).Seconds()))
+		c.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		c.JSON(http.StatusLocked, gin.H{"error": err.Error(), "lockedUntil": locked.Until})
+	case errors.Is(err, ErrTooManyLoginAttempts):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	default:
+		return false
+	}
+	return true
+}
+
+// AdminUnlockAccount - POST /superadmin/users/:id/unlock
+func (h *Handler) AdminUnlockAccount(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || targetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	err = h.service.UnlockAccount(c.GetUint("user_id"), uint(targetID), clientIP(c))
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Account unlocked. The user can sign in again."})
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrAccountNotLocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlock account"})
+	}
+}
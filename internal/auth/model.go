@@ -28,7 +28,8 @@ type User struct {
 	FullName             string         `gorm:"size:255;not null" json:"full_name"`
 	Email                string         `gorm:"size:255;unique;not null" json:"email"`
 	PasswordHash         string         `gorm:"size:255;not null" json:"-"`
-	Phone                string         `gorm:"size:20;not null" json:"phone"`
+	Phone                string         `gorm:"type:text;not null;serializer:encrypted" json:"phone"`
+	PhoneIndex           string         `gorm:"size:70;index" json:"-"` // fieldcrypto.PhoneIndex of Phone, which is encrypted
 	RoleID               uint           `gorm:"not null" json:"role_id"`
 	Role                 UserRole       `gorm:"foreignKey:RoleID;references:ID" json:"role"`
 	
@@ -38,10 +39,20 @@ type User struct {
 	EmailVerifiedAt      *time.Time     `json:"email_verified_at,omitempty"`
 	ForgotPasswordToken  *string        `gorm:"size:255" json:"-"`
 	ForgotPasswordExpiry *time.Time     `json:"-"`
+	FailedLoginAttempts  int            `gorm:"default:0" json:"failed_login_attempts"`
+	LastFailedLoginAt    *time.Time     `json:"last_failed_login_at,omitempty"`
+	LockedUntil          *time.Time     `json:"locked_until,omitempty"` // set after too many failed logins
 	CreatedAt            time.Time      `json:"created_at"`
 	UpdatedAt            time.Time      `json:"updated_at"`
 	DeletedAt            gorm.DeletedAt `gorm:"index" json:"-"`
 	CreatedBy string `gorm:"size:50" json:"created_by"`
+	// Sandbox tenants (templeadmins) use test payments and stay out of platform reports
+	Sandbox bool `gorm:"default:false;index" json:"sandbox"`
+	// Edits to key details of a tenant's approved temples wait for superadmin
+	// approval as change requests instead of applying directly
+	EntityChangeReview bool `gorm:"default:false" json:"entity_change_review"`
+	// Data residency region of a tenant's files, e.g. "in"; empty uses the default storage
+	DataRegion string `gorm:"size:20;default:''" json:"data_region"`
 
 }
 
@@ -81,6 +92,8 @@ type TenantDetails struct {
 	TempleAddress     string    `gorm:"type:text;not null" json:"temple_address"`
 	TemplePhoneNo     string    `gorm:"size:20;not null" json:"temple_phone_no"`
 	TempleDescription string    `gorm:"type:text;not null" json:"temple_description"`
+	EmailVerifiedAt   *time.Time `json:"email_verified_at,omitempty"` // set by OTP self-registration
+	PhoneVerifiedAt   *time.Time `json:"phone_verified_at,omitempty"`
 	CreatedAt         time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt         time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
@@ -144,3 +157,34 @@ func (TenantUserAssignment) TableName() string {
 	return "tenant_user_assignments"
 }
 
+
+// UserTwoFactor holds the TOTP enrollment of a superadmin or templeadmin
+type UserTwoFactor struct {
+	ID           uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID       uint       `gorm:"uniqueIndex;not null" json:"user_id"`
+	Secret       string     `gorm:"size:64;not null" json:"-"`
+	Enabled      bool       `gorm:"default:false" json:"enabled"` // false until the first code is confirmed
+	EnabledAt    *time.Time `json:"enabled_at,omitempty"`
+	LastUsedStep int64      `gorm:"default:0" json:"-"` // TOTP time step of the last accepted code
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// TableName overrides table name for UserTwoFactor
+func (UserTwoFactor) TableName() string {
+	return "user_two_factor"
+}
+
+// TwoFactorBackupCode is a single use recovery code, stored as a SHA-256 hash
+type TwoFactorBackupCode struct {
+	ID        uint       `gorm:"primaryKey;autoIncrement" json:"id"`
+	UserID    uint       `gorm:"index;not null" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// TableName overrides table name for TwoFactorBackupCode
+func (TwoFactorBackupCode) TableName() string {
+	return "two_factor_backup_codes"
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"log"
+
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"gorm.io/gorm"
+)
+
+// BackfillPhoneIndexes sets the phone blind index of users saved before it
+// was kept, or under the other keying after FIELD_BLIND_INDEX_KEY was set
+func BackfillPhoneIndexes(db *gorm.DB) {
+	stale := "phone_index IS NULL OR phone_index LIKE ?"
+	if fieldcrypto.IndexKeyed() {
+		stale = "phone_index IS NULL OR (phone_index <> '' AND phone_index NOT LIKE ?)"
+	}
+	var users []User
+	err := db.Unscoped().Select("id", "phone").
+		Where(stale, fieldcrypto.IndexPrefix+"%").
+		Find(&users).Error
+	if err != nil {
+		log.Printf("⚠️  failed to load users without a phone index: %v", err)
+		return
+	}
+	for _, u := range users {
+		if err := db.Unscoped().Model(&User{}).Where("id = ?", u.ID).UpdateColumn("phone_index", fieldcrypto.PhoneIndex(u.Phone)).Error; err != nil {
+			log.Printf("⚠️  failed to set phone index of user %d: %v", u.ID, err)
+		}
+	}
+}
@@ -31,6 +31,28 @@ type Repository interface {
 		// New methods for tenant assignment
 	GetAssignedTenantID(userID uint) (*uint, error)
 	GetUserPermissionType(userID uint) (string, error)
+
+	// Two-factor authentication
+	GetTwoFactor(userID uint) (*UserTwoFactor, error)
+	SaveTwoFactor(tf *UserTwoFactor) error
+	DeleteTwoFactor(userID uint) error
+	MarkTwoFactorStep(userID uint, step int64) (bool, error)
+	ReplaceBackupCodes(userID uint, hashes []string) error
+	UseBackupCode(userID uint, hash string) (bool, error)
+	CountUnusedBackupCodes(userID uint) (int64, error)
+
+	// Self-serve tenant registration
+	FindDuplicateTenants(name, place, phone string) ([]DuplicateTenant, error)
+
+	// Login lockout
+	RecordFailedLogin(userID uint, window time.Duration) (int, error)
+	LockAccount(userID uint, until time.Time) error
+	ResetLoginFailures(userID uint) error
+
+	// Temples of a tenant, for switching between them
+	ListTenantEntities(tenantID uint) ([]TenantEntity, error)
+	ListTenantEntityIDs(tenantID uint) ([]uint, error)
+	ListAssignedEntityIDs(userID uint) ([]uint, error)
 }
 
 type repository struct{ db *gorm.DB }
@@ -4,6 +4,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -16,6 +17,7 @@ func SeedUserRoles(db *gorm.DB) error {
 		{RoleName: "volunteer", Description: "Volunteer", CanRegisterPublicly: true, Status: "active"},
 		{RoleName: "standarduser", Description: "Standard User", CanRegisterPublicly: false, Status: "active"},
 		{RoleName: "monitoringuser", Description: "Monitoring User", CanRegisterPublicly: false, Status: "active"},
+		{RoleName: "orgadmin", Description: "Organization Admin", CanRegisterPublicly: false, Status: "active"},
 	}
 
 	for _, role := range roles {
@@ -57,6 +59,7 @@ func SeedSuperAdminUser(db *gorm.DB) error {
 		FullName:      "Super Admin",
 		Email:         email,
 		Phone:         "9999888877",
+		PhoneIndex:    fieldcrypto.PhoneIndex("9999888877"),
 		PasswordHash:  string(hash),
 		RoleID:        role.ID,
 		EmailVerified: true,
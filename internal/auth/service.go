@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
@@ -11,6 +12,8 @@ import (
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
 	"github.com/sharath018/temple-management-backend/utils"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
@@ -23,17 +26,42 @@ type TokenPair struct {
 
 type Service interface {
 	Register(input RegisterInput) error
-	Login(input LoginInput) (*TokenPair, *User, error)
-	Refresh(refreshToken string) (string, error)
+	Login(input LoginInput, ip string) (*TokenPair, *User, error)
+	Refresh(refreshToken string) (*TokenPair, error)
 	GetUserByID(userID uint) (User, error)
 
 	// Password reset methods
 	RequestPasswordReset(email string) error
 	ResetPassword(token string, newPassword string) error
-	Logout() error
+	Logout(input LogoutInput) error
+	IsTokenRevoked(userID uint, claims jwt.MapClaims) bool
 	
 	// NEW: Public roles method
 	GetPublicRoles() ([]PublicRoleResponse, error)
+
+	// Two-factor authentication
+	SetAuditService(auditSvc auditlog.Service)
+	SetupTwoFactor(userID uint, ip string) (*TwoFactorSetup, error)
+	EnableTwoFactor(userID uint, code, ip string) ([]string, error)
+	DisableTwoFactor(userID uint, code, ip string) error
+	RegenerateBackupCodes(userID uint, code, ip string) ([]string, error)
+	GetTwoFactorStatus(userID uint) (*TwoFactorStatus, error)
+	VerifyTwoFactorLogin(challengeToken, code, ip string) (*TokenPair, *User, error)
+	AdminResetTwoFactor(actorID, targetUserID uint, ip string) error
+
+	// Account lockout after repeated failed logins
+	UnlockAccount(actorID, targetUserID uint, ip string) error
+
+	// Tenant self-registration with email and phone OTP
+	StartTenantRegistration(ctx context.Context, in TenantRegistrationInput, ip string) (*TenantRegistrationStatus, error)
+	VerifyTenantRegistration(ctx context.Context, token, channel, code string) (*TenantRegistrationStatus, error)
+	ResendTenantRegistrationCode(ctx context.Context, token, channel string) (*TenantRegistrationStatus, error)
+	CompleteTenantRegistration(ctx context.Context, token, ip string) error
+
+	// Switching between the temples of a tenant
+	ListTenantEntities(userID uint, activeEntityID *uint) ([]TenantEntity, error)
+	SwitchEntity(userID, entityID uint, ip string) (*TokenPair, error)
+	StaffEntityIDs(user *User) ([]uint, error)
 }
 
 type service struct {
@@ -42,15 +70,51 @@ type service struct {
 	refreshSecret string
 	accessTTL     time.Duration
 	refreshTTL    time.Duration
+	auditSvc      auditlog.Service
+
+	// Tenant self-registration
+	captchaSecret     string
+	captchaVerifyURL  string
+	tenantOTPRequired bool
+
+	// Login lockout
+	loginMaxFailures   int
+	loginLockout       time.Duration
+	loginIPMaxFailures int
 }
 
+const (
+	defaultAccessTTL  = 15 * time.Minute
+	defaultRefreshTTL = 7 * 24 * time.Hour
+)
+
 func NewService(r Repository, cfg *config.Config) Service {
+	accessTTL := time.Duration(cfg.JWTAccessTTLMinutes) * time.Minute
+	if accessTTL <= 0 {
+		accessTTL = time.Duration(cfg.JWTAccessTTLHours) * time.Hour
+	}
+	if accessTTL <= 0 {
+		accessTTL = defaultAccessTTL
+	}
+	refreshTTL := time.Duration(cfg.JWTRefreshTTLHours) * time.Hour
+	if refreshTTL <= 0 {
+		refreshTTL = defaultRefreshTTL
+	}
+
 	return &service{
 		repo:          r,
 		accessSecret:  cfg.JWTAccessSecret,
 		refreshSecret: cfg.JWTRefreshSecret,
-		accessTTL:     time.Duration(cfg.JWTAccessTTLHours) * time.Hour,
-		refreshTTL:    time.Duration(cfg.JWTRefreshTTLHours) * time.Hour,
+		accessTTL:     accessTTL,
+		refreshTTL:    refreshTTL,
+
+		captchaSecret:     cfg.CaptchaSecret,
+		captchaVerifyURL:  cfg.CaptchaVerifyURL,
+		tenantOTPRequired: cfg.TenantRegistrationOTP,
+
+		loginMaxFailures:   cfg.LoginMaxFailures,
+		loginLockout:       time.Duration(cfg.LoginLockoutMinutes) * time.Minute,
+		loginIPMaxFailures: cfg.LoginIPMaxFailures,
 	}
 }
 
@@ -72,21 +136,34 @@ type RegisterInput struct {
 }
 
 func (s *service) Register(in RegisterInput) error {
+	// Temple admins sign up through the verified flow when it is enforced
+	if s.tenantOTPRequired && strings.ToLower(in.Role) == "templeadmin" {
+		return ErrTenantVerificationRequired
+	}
+
+	// ✅ Hash password
+	hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.createAccount(in, string(hash), nil)
+	return err
+}
+
+// createAccount stores the user and, for temple admins, the tenant details
+// and approval request. verifiedAt is set when email and phone were
+// confirmed by OTP before the request reached the approval queue.
+func (s *service) createAccount(in RegisterInput, passwordHash string, verifiedAt *time.Time) (*User, error) {
 	roleName := strings.ToLower(in.Role)
 	role, err := s.repo.FindRoleByName(roleName)
 	if err != nil {
-		return errors.New("invalid role")
+		return nil, errors.New("invalid role")
 	}
 
 	// ✅ Enforce Gmail-only email validation
 	if !strings.HasSuffix(strings.ToLower(in.Email), "@gmail.com") {
-		return errors.New("only @gmail.com emails are allowed")
-	}
-
-	// ✅ Hash password
-	hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
-	if err != nil {
-		return err
+		return nil, errors.New("only @gmail.com emails are allowed")
 	}
 
 	// ✅ Status logic
@@ -98,22 +175,23 @@ func (s *service) Register(in RegisterInput) error {
 	// ✅ Clean phone number
 	phone, err := cleanPhone(in.Phone)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// ✅ Create the user
 	user := &User{
 		FullName:     in.FullName,
 		Email:        in.Email,
-		PasswordHash: string(hash),
+		PasswordHash: passwordHash,
 		RoleID:       role.ID,
 		Status:       status,
 		Phone:        phone,
+		PhoneIndex:   fieldcrypto.PhoneIndex(phone),
 		CreatedBy: "system",
 	}
 
 	if err := s.repo.Create(user); err != nil {
-		return err
+		return nil, err
 	}
 
 	// ✅ Extra step for templeadmin: Save tenant details + approval request
@@ -125,18 +203,20 @@ func (s *service) Register(in RegisterInput) error {
 			TempleAddress:     in.TempleAddress,
 			TemplePhoneNo:     in.TemplePhoneNo,
 			TempleDescription: in.TempleDescription,
+			EmailVerifiedAt:   verifiedAt,
+			PhoneVerifiedAt:   verifiedAt,
 		}
 
 		if err := s.repo.CreateTenantDetails(tenant); err != nil {
-			return errors.New("failed to save tenant details")
+			return nil, errors.New("failed to save tenant details")
 		}
 
 		if err := s.repo.CreateApprovalRequest(user.ID, "tenant_approval"); err != nil {
-			return errors.New("failed to create approval request")
+			return nil, errors.New("failed to create approval request")
 		}
 	}
 
-	return nil
+	return user, nil
 }
 
 // =============================
@@ -148,29 +228,79 @@ type LoginInput struct {
 	Password string
 }
 
-func (s *service) Login(in LoginInput) (*TokenPair, *User, error) {
+func (s *service) Login(in LoginInput, ip string) (*TokenPair, *User, error) {
+	if err := s.checkLoginIP(ip); err != nil {
+		return nil, nil, err
+	}
+
 	user, err := s.repo.FindByEmail(in.Email)
 	if err != nil {
 		// Check if it's a "record not found" error and return user-friendly message
 		if err == gorm.ErrRecordNotFound || strings.Contains(err.Error(), "record not found") || strings.Contains(err.Error(), "not found") {
+			s.recordIPFailure(ip)
 			return nil, nil, errors.New ("Couldn't find your Account")
 		}
 		return nil, nil, err
 	}
 
+	if err := s.checkAccountLock(user, ip); err != nil {
+		return nil, nil, err
+	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(in.Password)); err != nil {
+		if lockErr := s.recordLoginFailure(user, ip); lockErr != nil {
+			return nil, nil, lockErr
+		}
 		return nil, nil, errors.New("invalid credentials")
 	}
+	s.clearLoginFailures(user)
+
+	if err := checkAccountStatus(user); err != nil {
+		return nil, nil, err
+	}
+
+	// Admins with 2FA finish signing in at VerifyTwoFactorLogin
+	if requiresTwoFactor(user) {
+		enabled, err := s.twoFactorEnabled(user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if enabled {
+			challenge, err := createTwoFactorChallenge(context.Background(), user.ID)
+			if err != nil {
+				return nil, nil, errors.New("could not start two-factor verification")
+			}
+			return nil, user, &TwoFactorRequiredError{ChallengeToken: challenge, ExpiresIn: twoFactorChallengeTTL}
+		}
+	}
+
+	tokens, err := s.issueTokens(user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, user, nil
+}
 
+func checkAccountStatus(user *User) error {
 	switch user.Status {
 	case "pending":
-		return nil, nil, errors.New("your account is pending approval")
+		return errors.New("your account is pending approval")
 	case "rejected":
-		return nil, nil, errors.New("your account was rejected by admin")
+		return errors.New("your account was rejected by admin")
 	case "inactive":
-		return nil, nil, errors.New("your account is inactive")
+		return errors.New("your account is inactive")
 	}
+	return nil
+}
+
+// issueTokens builds a new access/refresh token pair and stores the refresh token
+func (s *service) issueTokens(user *User) (*TokenPair, error) {
+	return s.issueTokensFor(user, nil)
+}
 
+// issueTokensFor is issueTokens for a session working on activeEntityID,
+// the temple the user switched to; nil keeps the default temple
+func (s *service) issueTokensFor(user *User, activeEntityID *uint) (*TokenPair, error) {
 	if user.EntityID == nil && (user.Role.RoleName == "templeadmin" || user.Role.RoleName == "devotee" || user.Role.RoleName == "volunteer") {
 		entityID, err := s.repo.FindEntityIDByUserID(user.ID)
 		if err == nil && entityID != nil {
@@ -178,38 +308,53 @@ func (s *service) Login(in LoginInput) (*TokenPair, *User, error) {
 		}
 	}
 
-	// Build tokens
-	accessToken, err := s.generateAccessToken(user)
+	accessToken, err := s.generateAccessToken(user, activeEntityID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	refreshToken, err := s.generateRefreshToken(user)
+	refreshToken, jti, err := s.generateRefreshToken(user, activeEntityID)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
+	}
+	if err := storeRefreshToken(context.Background(), user.ID, jti, s.refreshTTL); err != nil {
+		return nil, errors.New("could not save refresh token")
 	}
 
 	return &TokenPair{
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
-	}, user, nil
+	}, nil
 }
-func (s *service) generateAccessToken(user *User) (string, error) {
+
+func (s *service) generateAccessToken(user *User, activeEntityID *uint) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"role_id": user.RoleID,
-		"exp":     time.Now().Add(s.accessTTL).Unix(),
+		"jti":     generateSecureToken(),
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.accessTTL).Unix(),
 	}
 	
 	// Add entity_id if it exists
 	if user.EntityID != nil {
 		claims["entity_id"] = *user.EntityID
 	}
+	if activeEntityID != nil {
+		claims["active_entity_id"] = *activeEntityID
+	}
 	
+	// A templeadmin is the tenant owning their temples
+	if user.Role.RoleName == "templeadmin" {
+		claims["tenant_id"] = user.ID
+	}
+
 	// NEW: Add assigned tenant info for standarduser/monitoringuser
 	if user.Role.RoleName == "standarduser" || user.Role.RoleName == "monitoringuser" {
 		assignedTenantID, err := s.repo.GetAssignedTenantID(user.ID)
 		if err == nil && assignedTenantID != nil {
 			claims["assigned_tenant_id"] = *assignedTenantID
+			claims["tenant_id"] = *assignedTenantID
 			
 			// Add permission type based on role
 			permissionType, _ := s.repo.GetUserPermissionType(user.ID)
@@ -221,40 +366,96 @@ func (s *service) generateAccessToken(user *User) (string, error) {
 	return token.SignedString([]byte(s.accessSecret))
 }
 
-func (s *service) generateRefreshToken(user *User) (string, error) {
+func (s *service) generateRefreshToken(user *User, activeEntityID *uint) (string, string, error) {
+	now := time.Now()
+	jti := generateSecureToken()
 	claims := jwt.MapClaims{
 		"user_id": user.ID,
 		"role_id": user.RoleID,
-		"exp":     time.Now().Add(s.refreshTTL).Unix(),
+		"jti":     jti,
+		"iat":     now.Unix(),
+		"exp":     now.Add(s.refreshTTL).Unix(),
+	}
+	// The switched-to temple survives token refreshes
+	if activeEntityID != nil {
+		claims["active_entity_id"] = *activeEntityID
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(s.refreshSecret))
+	signed, err := token.SignedString([]byte(s.refreshSecret))
+	return signed, jti, err
 }
 
-// =============================
-// Refresh
-// =============================
+// parseRefreshToken validates a refresh token and returns its user and token ID
+func (s *service) parseRefreshToken(refreshToken string) (uint, string, error) {
+	userID, jti, _, err := s.parseRefreshClaims(refreshToken)
+	return userID, jti, err
+}
 
-func (s *service) Refresh(refreshToken string) (string, error) {
+// parseRefreshClaims is parseRefreshToken that also returns the session's
+// active temple, if the user switched to one
+func (s *service) parseRefreshClaims(refreshToken string) (uint, string, *uint, error) {
 	token, err := jwt.Parse(refreshToken, func(t *jwt.Token) (interface{}, error) {
 		return []byte(s.refreshSecret), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 	if err != nil || !token.Valid {
-		return "", errors.New("invalid refresh token")
+		return 0, "", nil, errors.New("invalid refresh token")
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok || claims["user_id"] == nil || claims["role_id"] == nil {
-		return "", errors.New("invalid token claims")
+	if !ok {
+		return 0, "", nil, errors.New("invalid token claims")
+	}
+	userID, ok := claims["user_id"].(float64)
+	jti, _ := claims["jti"].(string)
+	if !ok || jti == "" {
+		return 0, "", nil, errors.New("invalid token claims")
+	}
+	var activeEntityID *uint
+	if v, ok := claims["active_entity_id"].(float64); ok && v > 0 {
+		id := uint(v)
+		activeEntityID = &id
+	}
+	return uint(userID), jti, activeEntityID, nil
+}
+
+// =============================
+// Refresh
+// =============================
+
+// Refresh exchanges a refresh token for a new token pair. Refresh tokens are
+// single use: presenting one that was already exchanged or revoked is treated
+// as a stolen token and signs the user out everywhere.
+func (s *service) Refresh(refreshToken string) (*TokenPair, error) {
+	ctx := context.Background()
+
+	userID, jti, activeEntityID, err := s.parseRefreshClaims(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := consumeRefreshToken(ctx, userID, jti); err != nil {
+		if errors.Is(err, ErrRefreshTokenRevoked) {
+			_ = revokeAllSessions(ctx, userID, s.accessTTL)
+			return nil, err
+		}
+		return nil, errors.New("could not verify refresh token")
 	}
 
-	userID := uint(claims["user_id"].(float64))
 	user, err := s.repo.FindByID(userID)
 	if err != nil {
-		return "", errors.New("user not found")
+		return nil, errors.New("user not found")
+	}
+	if err := checkAccountStatus(&user); err != nil {
+		return nil, err
 	}
 
-	return s.generateAccessToken(&user)
+	// Drop the switched-to temple once the user may no longer work on it
+	if activeEntityID != nil {
+		if ok, err := s.canSwitchTo(&user, *activeEntityID); err != nil || !ok {
+			activeEntityID = nil
+		}
+	}
+	return s.issueTokensFor(&user, activeEntityID)
 }
 
 // =============================
@@ -325,11 +526,53 @@ func (s *service) ResetPassword(token string, newPassword string) error {
 // Logout
 // =============================
 
-func (s *service) Logout() error {
-	// JWT is stateless — frontend should just clear token
+type LogoutInput struct {
+	UserID       uint
+	AccessClaims jwt.MapClaims // claims of the access token used for the request
+	RefreshToken string        // optional, revoked when it belongs to the user
+	AllSessions  bool          // revoke every session of the user
+}
+
+// Logout revokes the current access token and the given refresh token, or
+// every token of the user when AllSessions is set
+func (s *service) Logout(in LogoutInput) error {
+	ctx := context.Background()
+
+	if in.AllSessions {
+		return revokeAllSessions(ctx, in.UserID, s.accessTTL)
+	}
+
+	if jti, _ := in.AccessClaims["jti"].(string); jti != "" {
+		if exp, err := in.AccessClaims.GetExpirationTime(); err == nil && exp != nil {
+			if err := revokeAccessToken(ctx, jti, exp.Time); err != nil {
+				return err
+			}
+		}
+	}
+
+	if in.RefreshToken != "" {
+		userID, jti, err := s.parseRefreshToken(in.RefreshToken)
+		if err != nil {
+			return err
+		}
+		if userID != in.UserID {
+			return errors.New("refresh token does not belong to the current user")
+		}
+		return revokeRefreshToken(ctx, userID, jti)
+	}
 	return nil
 }
 
+// IsTokenRevoked reports whether an access token was revoked by logout
+func (s *service) IsTokenRevoked(userID uint, claims jwt.MapClaims) bool {
+	jti, _ := claims["jti"].(string)
+	var issuedAt time.Time
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		issuedAt = iat.Time
+	}
+	return isAccessTokenRevoked(context.Background(), userID, jti, issuedAt)
+}
+
 // =============================
 // Get User By ID
 // =============================
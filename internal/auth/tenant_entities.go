@@ -0,0 +1,247 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// A tenant is the templeadmin account that registered its temples; every
+// temple created by the templeadmin (entities.created_by) belongs to it.
+// Standard and monitoring users are assigned to one tenant through
+// tenant_user_assignments. Users working for a tenant with several temples
+// pick the one they work on with POST /auth/switch-entity, which reissues
+// their tokens with an active_entity_id claim.
+
+var (
+	ErrEntitySwitchNotAllowed = errors.New("only temple admins and their staff can switch temples")
+	ErrEntityNotInTenant      = errors.New("this temple does not belong to your account")
+)
+
+// TenantEntity is a temple of the caller's tenant
+type TenantEntity struct {
+	ID       uint      `json:"id"`
+	Name     string    `json:"name"`
+	City     string    `json:"city"`
+	Status   string    `json:"status"`
+	IsActive bool      `json:"isactive" gorm:"column:isactive"`
+	Created  time.Time `json:"created_at" gorm:"column:created_at"`
+	Active   bool      `json:"active" gorm:"-"` // the temple the session works on
+}
+
+// ===== REPOSITORY =====
+
+// ListTenantEntities returns the temples of a tenant, oldest first
+func (r *repository) ListTenantEntities(tenantID uint) ([]TenantEntity, error) {
+	out := []TenantEntity{}
+	err := r.db.Table("entities").
+		Select("id, name, city, status, isactive, created_at").
+		Where("created_by = ?", tenantID).
+		Order("created_at ASC, id ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// ListTenantEntityIDs returns the IDs of a tenant's temples
+func (r *repository) ListTenantEntityIDs(tenantID uint) ([]uint, error) {
+	ids := []uint{}
+	err := r.db.Table("entities").Where("created_by = ?", tenantID).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ListAssignedEntityIDs returns the IDs of the temples of every tenant the
+// user is actively assigned to
+func (r *repository) ListAssignedEntityIDs(userID uint) ([]uint, error) {
+	ids := []uint{}
+	tenants := r.db.Table("tenant_user_assignments").Select("tenant_id").
+		Where("user_id = ? AND status = ?", userID, "active")
+	err := r.db.Table("entities").Where("created_by IN (?)", tenants).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ===== SERVICE =====
+
+// tenantIDOf returns the tenant the user works for, 0 for roles without one
+func (s *service) tenantIDOf(user *User) (uint, error) {
+	switch user.Role.RoleName {
+	case "templeadmin":
+		return user.ID, nil
+	case "standarduser", "monitoringuser":
+		tenantID, err := s.repo.GetAssignedTenantID(user.ID)
+		if err != nil || tenantID == nil {
+			return 0, err
+		}
+		return *tenantID, nil
+	}
+	return 0, nil
+}
+
+// canSwitchTo reports whether the temple belongs to the user's tenant
+func (s *service) canSwitchTo(user *User, entityID uint) (bool, error) {
+	tenantID, err := s.tenantIDOf(user)
+	if err != nil || tenantID == 0 {
+		return false, err
+	}
+	entities, err := s.repo.ListTenantEntities(tenantID)
+	if err != nil {
+		return false, err
+	}
+	for _, e := range entities {
+		if e.ID == entityID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// StaffEntityIDs returns the temples a temple admin or a standard or
+// monitoring user really works at: those the tenant created, and for a temple
+// admin their own. Other roles get nil. Requests may pick their temple only
+// among them (see middleware.AuthMiddleware).
+func (s *service) StaffEntityIDs(user *User) ([]uint, error) {
+	switch user.Role.RoleName {
+	case "templeadmin":
+		ids, err := s.repo.ListTenantEntityIDs(user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if user.EntityID != nil {
+			for _, id := range ids {
+				if id == *user.EntityID {
+					return ids, nil
+				}
+			}
+			ids = append(ids, *user.EntityID)
+		}
+		return ids, nil
+	case "standarduser", "monitoringuser":
+		return s.repo.ListAssignedEntityIDs(user.ID)
+	}
+	return nil, nil
+}
+
+// ListTenantEntities returns the temples the user can switch between,
+// marking the one the session works on
+func (s *service) ListTenantEntities(userID uint, activeEntityID *uint) ([]TenantEntity, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	tenantID, err := s.tenantIDOf(&user)
+	if err != nil {
+		return nil, err
+	}
+	if tenantID == 0 {
+		return nil, ErrEntitySwitchNotAllowed
+	}
+	entities, err := s.repo.ListTenantEntities(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Without a switch the session works on the user's default temple
+	current := activeEntityID
+	if current == nil {
+		current = user.EntityID
+	}
+	for i := range entities {
+		entities[i].Active = current != nil && entities[i].ID == *current
+	}
+	return entities, nil
+}
+
+// SwitchEntity reissues the user's tokens working on another temple of their tenant
+func (s *service) SwitchEntity(userID, entityID uint, ip string) (*TokenPair, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkAccountStatus(&user); err != nil {
+		return nil, err
+	}
+	if tenantID, err := s.tenantIDOf(&user); err != nil {
+		return nil, err
+	} else if tenantID == 0 {
+		return nil, ErrEntitySwitchNotAllowed
+	}
+	ok, err := s.canSwitchTo(&user, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrEntityNotInTenant
+	}
+
+	tokens, err := s.issueTokensFor(&user, &entityID)
+	if err != nil {
+		return nil, err
+	}
+	if s.auditSvc != nil {
+		s.auditSvc.LogAction(context.Background(), &userID, &entityID, "ENTITY_SWITCHED", nil, ip, "success")
+	}
+	return tokens, nil
+}
+
+// ===== HANDLER =====
+
+// GET /auth/entities
+// Temples of the caller's tenant, with the one the session works on marked active
+func (h *Handler) ListTenantEntities(c *gin.Context) {
+	entities, err := h.service.ListTenantEntities(c.GetUint("user_id"), activeEntityClaim(c))
+	if err != nil {
+		if errors.Is(err, ErrEntitySwitchNotAllowed) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temples"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": entities})
+}
+
+// POST /auth/switch-entity {"entity_id": 12}
+// Returns new tokens working on the temple; the old access token stays valid
+// until it expires
+func (h *Handler) SwitchEntity(c *gin.Context) {
+	var req struct {
+		EntityID uint `json:"entity_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "entity_id is required"})
+		return
+	}
+
+	tokens, err := h.service.SwitchEntity(c.GetUint("user_id"), req.EntityID, clientIP(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrEntitySwitchNotAllowed), errors.Is(err, ErrEntityNotInTenant):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"accessToken":      tokens.AccessToken,
+		"refreshToken":     tokens.RefreshToken,
+		"active_entity_id": req.EntityID,
+	})
+}
+
+func activeEntityClaim(c *gin.Context) *uint {
+	claims, _ := c.Get("claims")
+	mapClaims, ok := claims.(jwt.MapClaims)
+	if !ok {
+		return nil
+	}
+	v, ok := mapClaims["active_entity_id"].(float64)
+	if !ok || v <= 0 {
+		return nil
+	}
+	id := uint(v)
+	return &id
+}
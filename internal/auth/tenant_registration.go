@@ -0,0 +1,572 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/sharath018/temple-management-backend/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Self-serve tenant registration: the applicant submits their details with a
+// captcha token, confirms the OTPs sent to their email and phone, and only
+// then is the account created and the request queued for superadmin approval.
+const (
+	tenantRegistrationTTL    = 30 * time.Minute
+	tenantRegistrationPrefix = "tenant_registration"
+	tenantOTPTTL             = 10 * time.Minute
+	tenantOTPMaxAttempts     = 5
+	tenantOTPResendCooldown  = time.Minute
+
+	OTPChannelEmail = "email"
+	OTPChannelPhone = "phone"
+)
+
+var (
+	ErrTenantVerificationRequired = errors.New("temple admin registration requires email and phone verification, use /auth/tenant-registration")
+	ErrCaptchaFailed              = errors.New("captcha verification failed")
+	ErrEmailAlreadyRegistered     = errors.New("an account with this email already exists")
+	ErrRegistrationExpired        = errors.New("registration session expired, please start again")
+	ErrInvalidOTP                 = errors.New("invalid or expired verification code")
+	ErrOTPAttemptsExceeded        = errors.New("too many incorrect codes, request a new one")
+	ErrOTPResendTooSoon           = errors.New("please wait before requesting another code")
+	ErrRegistrationNotVerified    = errors.New("verify both your email and phone before submitting")
+	ErrInvalidOTPChannel          = errors.New("channel must be email or phone")
+)
+
+// DuplicateTenant is an existing tenant or temple that looks like the one
+// being registered
+type DuplicateTenant struct {
+	Source string `json:"source"` // tenant (registration) or temple (entity)
+	Name   string `json:"name"`
+	Place  string `json:"place"`
+	Reason string `json:"reason"` // name_and_place or phone
+}
+
+// DuplicateTenantError is returned when the temple is already registered
+type DuplicateTenantError struct {
+	Matches []DuplicateTenant
+}
+
+func (e *DuplicateTenantError) Error() string {
+	return "a temple with this name and place, or this phone number, is already registered"
+}
+
+// TenantRegistrationInput is what the applicant submits to start registration
+type TenantRegistrationInput struct {
+	FullName          string
+	Email             string
+	Password          string
+	Phone             string
+	TempleName        string
+	TemplePlace       string
+	TempleAddress     string
+	TemplePhoneNo     string
+	TempleDescription string
+	CaptchaToken      string
+}
+
+// TenantRegistrationStatus is returned after each registration step
+type TenantRegistrationStatus struct {
+	RegistrationToken string `json:"registrationToken"`
+	Email             string `json:"email"` // masked
+	Phone             string `json:"phone"` // masked
+	EmailVerified     bool   `json:"emailVerified"`
+	PhoneVerified     bool   `json:"phoneVerified"`
+	ExpiresIn         int    `json:"expiresIn"` // seconds left to finish registration
+}
+
+// pendingTenantRegistration is kept in Redis until the applicant completes
+// verification. Only hashes of the password and codes are stored.
+type pendingTenantRegistration struct {
+	Input        RegisterInput        `json:"input"`
+	PasswordHash string               `json:"password_hash"`
+	Codes        map[string]*otpState `json:"codes"`
+	CreatedAt    time.Time            `json:"created_at"`
+}
+
+type otpState struct {
+	Hash       string    `json:"hash"`
+	SentAt     time.Time `json:"sent_at"`
+	Attempts   int       `json:"attempts"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+func (o *otpState) verified() bool {
+	return !o.VerifiedAt.IsZero()
+}
+
+func tenantRegistrationKey(token string) string {
+	return fmt.Sprintf("%s:%s", tenantRegistrationPrefix, token)
+}
+
+func hashOTP(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+func loadTenantRegistration(ctx context.Context, token string) (*pendingTenantRegistration, time.Duration, error) {
+	key := tenantRegistrationKey(token)
+	val, err := utils.RedisClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, 0, ErrRegistrationExpired
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var reg pendingTenantRegistration
+	if err := json.Unmarshal([]byte(val), &reg); err != nil {
+		return nil, 0, ErrRegistrationExpired
+	}
+	ttl, err := utils.RedisClient.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		ttl = tenantRegistrationTTL
+	}
+	return &reg, ttl, nil
+}
+
+// saveTenantRegistration writes the registration back without extending its lifetime
+func saveTenantRegistration(ctx context.Context, token string, reg *pendingTenantRegistration, ttl time.Duration) error {
+	payload, err := json.Marshal(reg)
+	if err != nil {
+		return err
+	}
+	return utils.RedisClient.Set(ctx, tenantRegistrationKey(token), payload, ttl).Err()
+}
+
+// =============================
+// Captcha
+// =============================
+
+// verifyCaptcha checks the token with a reCAPTCHA/hCaptcha compatible
+// siteverify endpoint. Without a configured secret the check is skipped.
+func (s *service) verifyCaptcha(ctx context.Context, token, ip string) error {
+	if s.captchaSecret == "" {
+		return nil
+	}
+	if token == "" {
+		return ErrCaptchaFailed
+	}
+
+	form := url.Values{}
+	form.Set("secret", s.captchaSecret)
+	form.Set("response", token)
+	if ip != "" {
+		form.Set("remoteip", ip)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, s.captchaVerifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("captcha provider unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Success bool `json:"success"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil || !body.Success {
+		return ErrCaptchaFailed
+	}
+	return nil
+}
+
+// =============================
+// Registration steps
+// =============================
+
+// StartTenantRegistration validates the application, checks for duplicate
+// temples and sends OTPs to the applicant's email and phone
+func (s *service) StartTenantRegistration(ctx context.Context, in TenantRegistrationInput, ip string) (*TenantRegistrationStatus, error) {
+	if err := s.verifyCaptcha(ctx, in.CaptchaToken, ip); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasSuffix(strings.ToLower(in.Email), "@gmail.com") {
+		return nil, errors.New("only @gmail.com emails are allowed")
+	}
+	phone, err := cleanPhone(in.Phone)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := cleanPhone(in.TemplePhoneNo); err != nil {
+		return nil, errors.New("invalid temple phone number format")
+	}
+
+	if _, err := s.repo.FindByEmail(in.Email); err == nil {
+		return nil, ErrEmailAlreadyRegistered
+	}
+	if err := s.checkDuplicateTenant(in.TempleName, in.TemplePlace, in.TemplePhoneNo); err != nil {
+		return nil, err
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(in.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+
+	reg := &pendingTenantRegistration{
+		Input: RegisterInput{
+			FullName:          in.FullName,
+			Email:             in.Email,
+			Role:              "templeadmin",
+			Phone:             phone,
+			TempleName:        strings.TrimSpace(in.TempleName),
+			TemplePlace:       strings.TrimSpace(in.TemplePlace),
+			TempleAddress:     in.TempleAddress,
+			TemplePhoneNo:     in.TemplePhoneNo,
+			TempleDescription: in.TempleDescription,
+		},
+		PasswordHash: string(hash),
+		Codes:        map[string]*otpState{},
+		CreatedAt:    time.Now(),
+	}
+	for _, channel := range []string{OTPChannelEmail, OTPChannelPhone} {
+		if err := sendRegistrationOTP(reg, channel); err != nil {
+			return nil, err
+		}
+	}
+
+	token := generateSecureToken()
+	if err := saveTenantRegistration(ctx, token, reg, tenantRegistrationTTL); err != nil {
+		return nil, err
+	}
+	return registrationStatus(token, reg, tenantRegistrationTTL), nil
+}
+
+// VerifyTenantRegistration checks the code sent to the email or phone
+func (s *service) VerifyTenantRegistration(ctx context.Context, token, channel, code string) (*TenantRegistrationStatus, error) {
+	reg, ttl, err := loadTenantRegistration(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	otp, ok := reg.Codes[channel]
+	if !ok {
+		return nil, ErrInvalidOTPChannel
+	}
+	if otp.verified() {
+		return registrationStatus(token, reg, ttl), nil
+	}
+	if otp.Attempts >= tenantOTPMaxAttempts {
+		return nil, ErrOTPAttemptsExceeded
+	}
+
+	code = strings.TrimSpace(code)
+	if time.Since(otp.SentAt) > tenantOTPTTL || subtle.ConstantTimeCompare([]byte(hashOTP(code)), []byte(otp.Hash)) != 1 {
+		otp.Attempts++
+		_ = saveTenantRegistration(ctx, token, reg, ttl)
+		return nil, ErrInvalidOTP
+	}
+
+	otp.VerifiedAt = time.Now()
+	if err := saveTenantRegistration(ctx, token, reg, ttl); err != nil {
+		return nil, err
+	}
+	return registrationStatus(token, reg, ttl), nil
+}
+
+// ResendTenantRegistrationCode sends a fresh code, resetting the attempts
+func (s *service) ResendTenantRegistrationCode(ctx context.Context, token, channel string) (*TenantRegistrationStatus, error) {
+	reg, ttl, err := loadTenantRegistration(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	otp, ok := reg.Codes[channel]
+	if !ok {
+		return nil, ErrInvalidOTPChannel
+	}
+	if otp.verified() {
+		return registrationStatus(token, reg, ttl), nil
+	}
+	if time.Since(otp.SentAt) < tenantOTPResendCooldown {
+		return nil, ErrOTPResendTooSoon
+	}
+
+	if err := sendRegistrationOTP(reg, channel); err != nil {
+		return nil, err
+	}
+	if err := saveTenantRegistration(ctx, token, reg, ttl); err != nil {
+		return nil, err
+	}
+	return registrationStatus(token, reg, ttl), nil
+}
+
+// CompleteTenantRegistration creates the pending account and puts it in the
+// approval queue once both email and phone are verified
+func (s *service) CompleteTenantRegistration(ctx context.Context, token, ip string) error {
+	reg, _, err := loadTenantRegistration(ctx, token)
+	if err != nil {
+		return err
+	}
+	for _, otp := range reg.Codes {
+		if !otp.verified() {
+			return ErrRegistrationNotVerified
+		}
+	}
+
+	// Someone may have registered the same temple while this one was verifying
+	if _, err := s.repo.FindByEmail(reg.Input.Email); err == nil {
+		return ErrEmailAlreadyRegistered
+	}
+	if err := s.checkDuplicateTenant(reg.Input.TempleName, reg.Input.TemplePlace, reg.Input.TemplePhoneNo); err != nil {
+		return err
+	}
+
+	verifiedAt := time.Now()
+	user, err := s.createAccount(reg.Input, reg.PasswordHash, &verifiedAt)
+	if err != nil {
+		return err
+	}
+	utils.RedisClient.Del(ctx, tenantRegistrationKey(token))
+
+	s.audit(user.ID, "TENANT_SELF_REGISTERED", map[string]interface{}{
+		"temple_name":  reg.Input.TempleName,
+		"temple_place": reg.Input.TemplePlace,
+		"email":        reg.Input.Email,
+	}, ip, "success")
+	return nil
+}
+
+func (s *service) checkDuplicateTenant(name, place, phone string) error {
+	templePhone, _ := cleanPhone(phone)
+	matches, err := s.repo.FindDuplicateTenants(name, place, templePhone)
+	if err != nil {
+		return err
+	}
+	if len(matches) > 0 {
+		return &DuplicateTenantError{Matches: matches}
+	}
+	return nil
+}
+
+// sendRegistrationOTP generates a code for the channel and delivers it
+func sendRegistrationOTP(reg *pendingTenantRegistration, channel string) error {
+	code, err := generateOTP()
+	if err != nil {
+		return err
+	}
+
+	switch channel {
+	case OTPChannelEmail:
+		err = utils.SendVerificationCodeEmail(reg.Input.Email, reg.Input.FullName, code, tenantOTPTTL)
+	case OTPChannelPhone:
+		err = utils.SendSMS(reg.Input.Phone, fmt.Sprintf("Your temple registration code is %s. It expires in %d minutes.", code, int(tenantOTPTTL.Minutes())))
+	default:
+		return ErrInvalidOTPChannel
+	}
+	if err != nil {
+		log.Printf("❌ Failed to send %s verification code: %v", channel, err)
+		return fmt.Errorf("could not send %s verification code", channel)
+	}
+
+	reg.Codes[channel] = &otpState{Hash: hashOTP(code), SentAt: time.Now()}
+	return nil
+}
+
+func registrationStatus(token string, reg *pendingTenantRegistration, ttl time.Duration) *TenantRegistrationStatus {
+	status := &TenantRegistrationStatus{
+		RegistrationToken: token,
+		Email:             maskEmail(reg.Input.Email),
+		Phone:             maskPhone(reg.Input.Phone),
+		ExpiresIn:         int(ttl.Seconds()),
+	}
+	if otp, ok := reg.Codes[OTPChannelEmail]; ok {
+		status.EmailVerified = otp.verified()
+	}
+	if otp, ok := reg.Codes[OTPChannelPhone]; ok {
+		status.PhoneVerified = otp.verified()
+	}
+	return status
+}
+
+func maskEmail(email string) string {
+	at := strings.Index(email, "@")
+	if at <= 1 {
+		return email
+	}
+	return email[:1] + strings.Repeat("*", at-1) + email[at:]
+}
+
+func maskPhone(phone string) string {
+	if len(phone) <= 4 {
+		return phone
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// =============================
+// Repository
+// =============================
+
+// FindDuplicateTenants returns registered tenants and temples with the same
+// name and place, or the same phone number (compared on the last 10 digits)
+func (r *repository) FindDuplicateTenants(name, place, phone string) ([]DuplicateTenant, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	place = strings.ToLower(strings.TrimSpace(place))
+
+	var matches []DuplicateTenant
+	err := r.db.Raw(`
+		SELECT 'tenant' AS source, td.temple_name AS name, td.temple_place AS place,
+			CASE WHEN LOWER(TRIM(td.temple_name)) = ? AND LOWER(TRIM(td.temple_place)) = ? THEN 'name_and_place' ELSE 'phone' END AS reason
+		FROM tenant_details td
+		JOIN users u ON u.id = td.user_id
+		WHERE u.status <> 'rejected'
+		AND ((LOWER(TRIM(td.temple_name)) = ? AND LOWER(TRIM(td.temple_place)) = ?)
+			OR (? <> '' AND RIGHT(REGEXP_REPLACE(td.temple_phone_no, '\D', '', 'g'), 10) = ?))
+		UNION ALL
+		SELECT 'temple' AS source, e.name AS name, e.city AS place,
+			CASE WHEN LOWER(TRIM(e.name)) = ? AND LOWER(TRIM(e.city)) = ? THEN 'name_and_place' ELSE 'phone' END AS reason
+		FROM entities e
+		WHERE e.status <> 'rejected'
+		AND ((LOWER(TRIM(e.name)) = ? AND LOWER(TRIM(e.city)) = ?)
+			OR (? <> '' AND RIGHT(REGEXP_REPLACE(e.phone, '\D', '', 'g'), 10) = ?))
+		LIMIT 5`,
+		name, place, name, place, phone, phone,
+		name, place, name, place, phone, phone,
+	).Scan(&matches).Error
+	return matches, err
+}
+
+// =============================
+// Handlers
+// =============================
+
+type tenantRegistrationRequest struct {
+	FullName          string `json:"fullName" binding:"required"`
+	Email             string `json:"email" binding:"required,email"`
+	Password          string `json:"password" binding:"required,min=6"`
+	Phone             string `json:"phone" binding:"required"`
+	TempleName        string `json:"templeName" binding:"required"`
+	TemplePlace       string `json:"templePlace" binding:"required"`
+	TempleAddress     string `json:"templeAddress" binding:"required"`
+	TemplePhoneNo     string `json:"templePhoneNo" binding:"required"`
+	TempleDescription string `json:"templeDescription" binding:"required"`
+	CaptchaToken      string `json:"captchaToken"`
+}
+
+type tenantOTPRequest struct {
+	RegistrationToken string `json:"registrationToken" binding:"required"`
+	Channel           string `json:"channel" binding:"required,oneof=email phone"`
+	Code              string `json:"code"`
+}
+
+func respondTenantRegistrationError(c *gin.Context, err error) {
+	var dup *DuplicateTenantError
+	switch {
+	case errors.As(err, &dup):
+		c.JSON(http.StatusConflict, gin.H{"error": dup.Error(), "matches": dup.Matches})
+	case errors.Is(err, ErrEmailAlreadyRegistered):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrCaptchaFailed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidOTP), errors.Is(err, ErrRegistrationExpired):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrOTPAttemptsExceeded), errors.Is(err, ErrOTPResendTooSoon):
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrRegistrationNotVerified), errors.Is(err, ErrInvalidOTPChannel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+// POST /auth/tenant-registration
+func (h *Handler) StartTenantRegistration(c *gin.Context) {
+	var req tenantRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.service.StartTenantRegistration(c.Request.Context(), TenantRegistrationInput(req), clientIP(c))
+	if err != nil {
+		respondTenantRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":      "Verification codes sent to your email and phone",
+		"registration": status,
+	})
+}
+
+// POST /auth/tenant-registration/verify
+func (h *Handler) VerifyTenantRegistration(c *gin.Context) {
+	var req tenantOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	status, err := h.service.VerifyTenantRegistration(c.Request.Context(), req.RegistrationToken, req.Channel, req.Code)
+	if err != nil {
+		respondTenantRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"registration": status})
+}
+
+// POST /auth/tenant-registration/resend
+func (h *Handler) ResendTenantRegistrationCode(c *gin.Context) {
+	var req tenantOTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	status, err := h.service.ResendTenantRegistrationCode(c.Request.Context(), req.RegistrationToken, req.Channel)
+	if err != nil {
+		respondTenantRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Verification code sent", "registration": status})
+}
+
+// POST /auth/tenant-registration/complete
+func (h *Handler) CompleteTenantRegistration(c *gin.Context) {
+	var req struct {
+		RegistrationToken string `json:"registrationToken" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.CompleteTenantRegistration(c.Request.Context(), req.RegistrationToken, clientIP(c)); err != nil {
+		respondTenantRegistrationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Temple Admin registered. Awaiting approval."})
+}
@@ -0,0 +1,135 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Redis keys used for token state:
+//
+//	refresh_token:<jti>     -> user ID, lives as long as the refresh token
+//	user_sessions:<userID>  -> set of the user's live refresh token IDs
+//	revoked_access:<jti>    -> marks a revoked access token until it expires
+//	revoked_before:<userID> -> unix time; tokens issued earlier are revoked
+const (
+	refreshTokenPrefix  = "refresh_token"
+	userSessionsPrefix  = "user_sessions"
+	revokedAccessPrefix = "revoked_access"
+	revokedBeforePrefix = "revoked_before"
+)
+
+var ErrRefreshTokenRevoked = errors.New("refresh token has been revoked")
+
+func refreshTokenKey(jti string) string {
+	return fmt.Sprintf("%s:%s", refreshTokenPrefix, jti)
+}
+
+func userSessionsKey(userID uint) string {
+	return fmt.Sprintf("%s:%d", userSessionsPrefix, userID)
+}
+
+func revokedAccessKey(jti string) string {
+	return fmt.Sprintf("%s:%s", revokedAccessPrefix, jti)
+}
+
+func revokedBeforeKey(userID uint) string {
+	return fmt.Sprintf("%s:%d", revokedBeforePrefix, userID)
+}
+
+// storeRefreshToken records a newly issued refresh token
+func storeRefreshToken(ctx context.Context, userID uint, jti string, ttl time.Duration) error {
+	pipe := utils.RedisClient.TxPipeline()
+	pipe.Set(ctx, refreshTokenKey(jti), userID, ttl)
+	pipe.SAdd(ctx, userSessionsKey(userID), jti)
+	pipe.Expire(ctx, userSessionsKey(userID), ttl)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// consumeRefreshToken removes a refresh token so it can be used only once.
+// It returns ErrRefreshTokenRevoked when the token is no longer stored.
+func consumeRefreshToken(ctx context.Context, userID uint, jti string) error {
+	val, err := utils.RedisClient.GetDel(ctx, refreshTokenKey(jti)).Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrRefreshTokenRevoked
+	}
+	if err != nil {
+		return err
+	}
+	if val != strconv.FormatUint(uint64(userID), 10) {
+		return ErrRefreshTokenRevoked
+	}
+	utils.RedisClient.SRem(ctx, userSessionsKey(userID), jti)
+	return nil
+}
+
+// revokeRefreshToken deletes a refresh token, ignoring tokens already gone
+func revokeRefreshToken(ctx context.Context, userID uint, jti string) error {
+	pipe := utils.RedisClient.TxPipeline()
+	pipe.Del(ctx, refreshTokenKey(jti))
+	pipe.SRem(ctx, userSessionsKey(userID), jti)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// revokeAllSessions deletes every refresh token of the user and marks all
+// access tokens issued until now as revoked
+func revokeAllSessions(ctx context.Context, userID uint, accessTTL time.Duration) error {
+	jtis, err := utils.RedisClient.SMembers(ctx, userSessionsKey(userID)).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+
+	pipe := utils.RedisClient.TxPipeline()
+	for _, jti := range jtis {
+		pipe.Del(ctx, refreshTokenKey(jti))
+	}
+	pipe.Del(ctx, userSessionsKey(userID))
+	pipe.Set(ctx, revokedBeforeKey(userID), time.Now().Unix(), accessTTL)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// revokeAccessToken blocks an access token until it expires on its own
+func revokeAccessToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return utils.RedisClient.Set(ctx, revokedAccessKey(jti), 1, ttl).Err()
+}
+
+// isAccessTokenRevoked checks the access token denylist and the user-wide
+// revocation time. Redis errors are logged and the token is accepted, so a
+// Redis outage doesn't sign every user out.
+func isAccessTokenRevoked(ctx context.Context, userID uint, jti string, issuedAt time.Time) bool {
+	if jti != "" {
+		n, err := utils.RedisClient.Exists(ctx, revokedAccessKey(jti)).Result()
+		if err != nil {
+			log.Printf("⚠️ Token revocation check failed for user %d: %v", userID, err)
+			return false
+		}
+		if n > 0 {
+			return true
+		}
+	}
+
+	before, err := utils.RedisClient.Get(ctx, revokedBeforeKey(userID)).Int64()
+	if errors.Is(err, redis.Nil) {
+		return false
+	}
+	if err != nil {
+		log.Printf("⚠️ Token revocation check failed for user %d: %v", userID, err)
+		return false
+	}
+	// iat has whole seconds, so a token signed in the second of the
+	// revocation (such as the new login that follows it) stays valid
+	return issuedAt.Before(time.Unix(before, 0))
+}
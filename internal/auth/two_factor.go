@@ -0,0 +1,648 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	twoFactorChallengeTTL      = 5 * time.Minute
+	twoFactorMaxAttempts       = 5
+	twoFactorChallengePrefix   = "2fa_challenge"
+	twoFactorAttemptsPrefix    = "2fa_attempts"
+	backupCodeCount            = 10
+	backupCodeBytes            = 5 // 10 hex characters, shown as xxxxx-xxxxx
+	backupCodeNormalizedLength = backupCodeBytes * 2
+)
+
+var (
+	ErrTwoFactorNotAllowed       = errors.New("two-factor authentication is only available for superadmin and templeadmin accounts")
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp         = errors.New("start two-factor setup before enabling it")
+	ErrInvalidTwoFactorCode      = errors.New("invalid verification code")
+	ErrTwoFactorChallengeInvalid = errors.New("verification session expired, please sign in again")
+	ErrCannotResetOwnTwoFactor   = errors.New("use your own verification code to disable two-factor authentication")
+)
+
+// TwoFactorRequiredError is returned by Login when the password was correct
+// but the account still has to pass the second factor
+type TwoFactorRequiredError struct {
+	ChallengeToken string
+	ExpiresIn      time.Duration
+}
+
+func (e *TwoFactorRequiredError) Error() string {
+	return "two-factor verification required"
+}
+
+// TwoFactorSetup is returned when enrollment starts. OTPAuthURL is the
+// content of the QR code scanned by the authenticator app.
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauthUrl"`
+}
+
+type TwoFactorStatus struct {
+	Available            bool       `json:"available"`
+	Enabled              bool       `json:"enabled"`
+	EnabledAt            *time.Time `json:"enabledAt,omitempty"`
+	BackupCodesRemaining int64      `json:"backupCodesRemaining"`
+}
+
+// requiresTwoFactor reports whether the account's role may use 2FA
+func requiresTwoFactor(user *User) bool {
+	role := strings.ToLower(user.Role.RoleName)
+	return role == "superadmin" || role == "templeadmin"
+}
+
+// =============================
+// Login challenges (Redis)
+// =============================
+
+func twoFactorChallengeKey(token string) string {
+	return fmt.Sprintf("%s:%s", twoFactorChallengePrefix, token)
+}
+
+func twoFactorAttemptsKey(token string) string {
+	return fmt.Sprintf("%s:%s", twoFactorAttemptsPrefix, token)
+}
+
+// createTwoFactorChallenge stores a short lived token proving the password
+// step succeeded for the user
+func createTwoFactorChallenge(ctx context.Context, userID uint) (string, error) {
+	token := generateSecureToken()
+	if err := utils.RedisClient.Set(ctx, twoFactorChallengeKey(token), userID, twoFactorChallengeTTL).Err(); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func lookupTwoFactorChallenge(ctx context.Context, token string) (uint, error) {
+	val, err := utils.RedisClient.Get(ctx, twoFactorChallengeKey(token)).Result()
+	if errors.Is(err, redis.Nil) {
+		return 0, ErrTwoFactorChallengeInvalid
+	}
+	if err != nil {
+		return 0, err
+	}
+	userID, err := strconv.ParseUint(val, 10, 64)
+	if err != nil {
+		return 0, ErrTwoFactorChallengeInvalid
+	}
+	return uint(userID), nil
+}
+
+// failTwoFactorChallenge counts a wrong code and drops the challenge once
+// the attempts are used up
+func failTwoFactorChallenge(ctx context.Context, token string) {
+	attempts, err := utils.RedisClient.Incr(ctx, twoFactorAttemptsKey(token)).Result()
+	if err != nil {
+		return
+	}
+	utils.RedisClient.Expire(ctx, twoFactorAttemptsKey(token), twoFactorChallengeTTL)
+	if attempts >= twoFactorMaxAttempts {
+		utils.RedisClient.Del(ctx, twoFactorChallengeKey(token), twoFactorAttemptsKey(token))
+	}
+}
+
+// consumeTwoFactorChallenge deletes the challenge and reports whether this
+// caller was the one to delete it, so a challenge yields one token pair
+func consumeTwoFactorChallenge(ctx context.Context, token string) (bool, error) {
+	n, err := utils.RedisClient.Del(ctx, twoFactorChallengeKey(token)).Result()
+	if err != nil {
+		return false, err
+	}
+	utils.RedisClient.Del(ctx, twoFactorAttemptsKey(token))
+	return n > 0, nil
+}
+
+// =============================
+// Backup codes
+// =============================
+
+func normalizeBackupCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func hashBackupCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeBackupCode(code)))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateBackupCodes returns the plain codes for the user and their hashes
+func generateBackupCodes() ([]string, []string, error) {
+	codes := make([]string, backupCodeCount)
+	hashes := make([]string, backupCodeCount)
+	for i := range codes {
+		b := make([]byte, backupCodeBytes)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:backupCodeBytes] + "-" + raw[backupCodeBytes:]
+		hashes[i] = hashBackupCode(raw)
+	}
+	return codes, hashes, nil
+}
+
+// =============================
+// Repository
+// =============================
+
+func (r *repository) GetTwoFactor(userID uint) (*UserTwoFactor, error) {
+	var tf UserTwoFactor
+	err := r.db.Where("user_id = ?", userID).First(&tf).Error
+	return &tf, err
+}
+
+func (r *repository) SaveTwoFactor(tf *UserTwoFactor) error {
+	return r.db.Save(tf).Error
+}
+
+// DeleteTwoFactor removes the enrollment and every backup code of the user
+func (r *repository) DeleteTwoFactor(userID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&TwoFactorBackupCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&UserTwoFactor{}).Error
+	})
+}
+
+// MarkTwoFactorStep records the time step of an accepted code. It returns
+// false when that step (or a later one) was already used.
+func (r *repository) MarkTwoFactorStep(userID uint, step int64) (bool, error) {
+	res := r.db.Model(&UserTwoFactor{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *repository) ReplaceBackupCodes(userID uint, hashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&TwoFactorBackupCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]TwoFactorBackupCode, len(hashes))
+		for i, hash := range hashes {
+			codes[i] = TwoFactorBackupCode{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// UseBackupCode marks an unused code as used and reports whether it matched
+func (r *repository) UseBackupCode(userID uint, hash string) (bool, error) {
+	res := r.db.Model(&TwoFactorBackupCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *repository) CountUnusedBackupCodes(userID uint) (int64, error) {
+	var n int64
+	err := r.db.Model(&TwoFactorBackupCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&n).Error
+	return n, err
+}
+
+// =============================
+// Service
+// =============================
+
+// SetAuditService enables audit logging of 2FA events
+func (s *service) SetAuditService(auditSvc auditlog.Service) {
+	s.auditSvc = auditSvc
+}
+
+func (s *service) audit(userID uint, action string, details map[string]interface{}, ip, status string) {
+	if s.auditSvc == nil {
+		return
+	}
+	s.auditSvc.LogAction(context.Background(), &userID, nil, action, details, ip, status)
+}
+
+func (s *service) twoFactorEnabled(userID uint) (bool, error) {
+	tf, err := s.repo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return tf.Enabled, nil
+}
+
+// enabledTwoFactor loads the user's active enrollment
+func (s *service) enabledTwoFactor(userID uint) (*UserTwoFactor, error) {
+	tf, err := s.repo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !tf.Enabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	return tf, nil
+}
+
+// checkSecondFactor accepts an authenticator code or an unused backup code
+// and returns which one was used
+func (s *service) checkSecondFactor(tf *UserTwoFactor, code string) (string, error) {
+	if step, ok := validateTOTP(tf.Secret, code, time.Now()); ok {
+		fresh, err := s.repo.MarkTwoFactorStep(tf.UserID, step)
+		if err != nil {
+			return "", err
+		}
+		if !fresh {
+			return "", ErrInvalidTwoFactorCode // Replayed code
+		}
+		return "totp", nil
+	}
+
+	if len(normalizeBackupCode(code)) == backupCodeNormalizedLength {
+		used, err := s.repo.UseBackupCode(tf.UserID, hashBackupCode(code))
+		if err != nil {
+			return "", err
+		}
+		if used {
+			return "backup_code", nil
+		}
+	}
+	return "", ErrInvalidTwoFactorCode
+}
+
+func (s *service) SetupTwoFactor(userID uint, ip string) (*TwoFactorSetup, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !requiresTwoFactor(&user) {
+		return nil, ErrTwoFactorNotAllowed
+	}
+
+	tf, err := s.repo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		tf = &UserTwoFactor{UserID: userID}
+	} else if err != nil {
+		return nil, err
+	} else if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := generateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	tf.Secret = secret
+	tf.LastUsedStep = 0
+	if err := s.repo.SaveTwoFactor(tf); err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "TWO_FACTOR_SETUP_STARTED", nil, ip, "success")
+	return &TwoFactorSetup{Secret: secret, OTPAuthURL: totpURL(secret, user.Email)}, nil
+}
+
+// EnableTwoFactor confirms enrollment with a first code and returns the
+// backup codes, which are shown to the user only this once
+func (s *service) EnableTwoFactor(userID uint, code, ip string) ([]string, error) {
+	tf, err := s.repo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTwoFactorNotSetUp
+	}
+	if err != nil {
+		return nil, err
+	}
+	if tf.Enabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	step, ok := validateTOTP(tf.Secret, code, time.Now())
+	if !ok {
+		s.audit(userID, "TWO_FACTOR_ENABLED", map[string]interface{}{"error": ErrInvalidTwoFactorCode.Error()}, ip, "failure")
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceBackupCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	tf.Enabled = true
+	tf.EnabledAt = &now
+	tf.LastUsedStep = step
+	if err := s.repo.SaveTwoFactor(tf); err != nil {
+		return nil, err
+	}
+
+	s.audit(userID, "TWO_FACTOR_ENABLED", nil, ip, "success")
+	return codes, nil
+}
+
+func (s *service) DisableTwoFactor(userID uint, code, ip string) error {
+	tf, err := s.enabledTwoFactor(userID)
+	if err != nil {
+		return err
+	}
+	method, err := s.checkSecondFactor(tf, code)
+	if err != nil {
+		s.audit(userID, "TWO_FACTOR_DISABLED", map[string]interface{}{"error": err.Error()}, ip, "failure")
+		return err
+	}
+	if err := s.repo.DeleteTwoFactor(userID); err != nil {
+		return err
+	}
+	s.audit(userID, "TWO_FACTOR_DISABLED", map[string]interface{}{"method": method}, ip, "success")
+	return nil
+}
+
+func (s *service) RegenerateBackupCodes(userID uint, code, ip string) ([]string, error) {
+	tf, err := s.enabledTwoFactor(userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.checkSecondFactor(tf, code); err != nil {
+		s.audit(userID, "TWO_FACTOR_BACKUP_CODES_REGENERATED", map[string]interface{}{"error": err.Error()}, ip, "failure")
+		return nil, err
+	}
+
+	codes, hashes, err := generateBackupCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceBackupCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	s.audit(userID, "TWO_FACTOR_BACKUP_CODES_REGENERATED", nil, ip, "success")
+	return codes, nil
+}
+
+func (s *service) GetTwoFactorStatus(userID uint) (*TwoFactorStatus, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	status := &TwoFactorStatus{Available: requiresTwoFactor(&user)}
+
+	tf, err := s.repo.GetTwoFactor(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && !tf.Enabled) {
+		return status, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Enabled = true
+	status.EnabledAt = tf.EnabledAt
+	if status.BackupCodesRemaining, err = s.repo.CountUnusedBackupCodes(userID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// VerifyTwoFactorLogin completes a login started by Login once the second
+// factor checks out
+func (s *service) VerifyTwoFactorLogin(challengeToken, code, ip string) (*TokenPair, *User, error) {
+	ctx := context.Background()
+
+	userID, err := lookupTwoFactorChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, nil, ErrTwoFactorChallengeInvalid
+	}
+	if err := checkAccountStatus(&user); err != nil {
+		return nil, nil, err
+	}
+
+	tf, err := s.enabledTwoFactor(userID)
+	if err != nil {
+		// 2FA was reset after the password step; sign in again without it
+		return nil, nil, ErrTwoFactorChallengeInvalid
+	}
+
+	method, err := s.checkSecondFactor(tf, code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			failTwoFactorChallenge(ctx, challengeToken)
+		}
+		s.audit(userID, "TWO_FACTOR_LOGIN", map[string]interface{}{"error": err.Error()}, ip, "failure")
+		return nil, nil, err
+	}
+
+	consumed, err := consumeTwoFactorChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !consumed {
+		return nil, nil, ErrTwoFactorChallengeInvalid
+	}
+
+	details := map[string]interface{}{"method": method}
+	if method == "backup_code" {
+		if remaining, err := s.repo.CountUnusedBackupCodes(userID); err == nil {
+			details["backup_codes_remaining"] = remaining
+		}
+	}
+	s.audit(userID, "TWO_FACTOR_LOGIN", details, ip, "success")
+
+	tokens, err := s.issueTokens(&user)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, &user, nil
+}
+
+// AdminResetTwoFactor lets a superadmin remove 2FA from a locked-out user,
+// who can then sign in with the password alone and enroll again
+func (s *service) AdminResetTwoFactor(actorID, targetUserID uint, ip string) error {
+	if actorID == targetUserID {
+		return ErrCannotResetOwnTwoFactor
+	}
+	if _, err := s.repo.FindByID(targetUserID); err != nil {
+		return ErrUserNotFound
+	}
+	if _, err := s.enabledTwoFactor(targetUserID); err != nil {
+		return err
+	}
+
+	details := map[string]interface{}{"target_user_id": targetUserID}
+	if err := s.repo.DeleteTwoFactor(targetUserID); err != nil {
+		details["error"] = err.Error()
+		s.audit(actorID, "TWO_FACTOR_ADMIN_RESET", details, ip, "failure")
+		log.Printf("❌ 2FA reset of user %d failed: %v", targetUserID, err)
+		return err
+	}
+	s.audit(actorID, "TWO_FACTOR_ADMIN_RESET", details, ip, "success")
+	return nil
+}
+
+// =============================
+// Handlers
+// =============================
+
+type twoFactorCodeReq struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+type twoFactorVerifyReq struct {
+	ChallengeToken string `json:"challengeToken" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"`
+}
+
+// clientIP prefers the IP resolved by the audit middleware
+func clientIP(c *gin.Context) string {
+	if ip, ok := c.Get("client_ip"); ok {
+		if ipStr, ok := ip.(string); ok && ipStr != "" {
+			return ipStr
+		}
+	}
+	return c.ClientIP()
+}
+
+func respondTwoFactorError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidTwoFactorCode), errors.Is(err, ErrTwoFactorChallengeInvalid):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTwoFactorAlreadyEnabled):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTwoFactorNotAllowed), errors.Is(err, ErrCannotResetOwnTwoFactor):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTwoFactorNotEnabled), errors.Is(err, ErrTwoFactorNotSetUp):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// GET /auth/2fa
+func (h *Handler) GetTwoFactorStatus(c *gin.Context) {
+	status, err := h.service.GetTwoFactorStatus(c.GetUint("user_id"))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, status)
+}
+
+// POST /auth/2fa/setup
+func (h *Handler) SetupTwoFactor(c *gin.Context) {
+	setup, err := h.service.SetupTwoFactor(c.GetUint("user_id"), clientIP(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, setup)
+}
+
+// POST /auth/2fa/enable
+func (h *Handler) EnableTwoFactor(c *gin.Context) {
+	var req twoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := h.service.EnableTwoFactor(c.GetUint("user_id"), req.Code, clientIP(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Two-factor authentication enabled. Store these backup codes somewhere safe, they are shown only once.",
+		"backupCodes": codes,
+	})
+}
+
+// POST /auth/2fa/disable
+func (h *Handler) DisableTwoFactor(c *gin.Context) {
+	var req twoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.service.DisableTwoFactor(c.GetUint("user_id"), req.Code, clientIP(c)); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// POST /auth/2fa/backup-codes
+func (h *Handler) RegenerateBackupCodes(c *gin.Context) {
+	var req twoFactorCodeReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	codes, err := h.service.RegenerateBackupCodes(c.GetUint("user_id"), req.Code, clientIP(c))
+	if err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "New backup codes generated. Previous codes no longer work.",
+		"backupCodes": codes,
+	})
+}
+
+// POST /auth/2fa/verify - second step of login
+func (h *Handler) VerifyTwoFactor(c *gin.Context) {
+	var req twoFactorVerifyReq
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	tokens, user, err := h.service.VerifyTwoFactorLogin(req.ChallengeToken, req.Code, clientIP(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) || errors.Is(err, ErrTwoFactorChallengeInvalid) {
+			respondTwoFactorError(c, err)
+			return
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, loginResponse(tokens, user))
+}
+
+// DELETE /superadmin/users/:id/2fa - reset 2FA for a locked-out user
+func (h *Handler) AdminResetTwoFactor(c *gin.Context) {
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || targetID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID"})
+		return
+	}
+	if err := h.service.AdminResetTwoFactor(c.GetUint("user_id"), uint(targetID), clientIP(c)); err != nil {
+		respondTwoFactorError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication reset. The user can now sign in with their password."})
+}
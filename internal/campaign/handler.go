@@ -0,0 +1,218 @@
+package campaign
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the campaign endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new campaign handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// List - GET /campaigns?status=active&entity_id=
+// Temple staff see their temple's campaigns with top donors; devotees and
+// superadmins pass entity_id.
+func (h *Handler) List(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+
+	items, err := h.Service.List(c.Request.Context(), entityID, c.Query("status"), access.IsEntityStaff(entityID))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch campaigns")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// Create - POST /campaigns
+func (h *Handler) Create(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	campaign, err := h.Service.Create(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to create campaign")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Campaign created", "data": campaign})
+}
+
+// Get - GET /campaigns/:id
+func (h *Handler) Get(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	campaign, ok := h.loadCampaign(c, access, false)
+	if !ok {
+		return
+	}
+	setImageURL(campaign)
+	c.JSON(http.StatusOK, gin.H{"data": campaign})
+}
+
+// GetProgress - GET /campaigns/:id/progress
+// Returns the amount raised, donors and completion percentage of a campaign.
+func (h *Handler) GetProgress(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	campaign, ok := h.loadCampaign(c, access, false)
+	if !ok {
+		return
+	}
+	p, err := h.Service.Progress(c.Request.Context(), campaign, access.IsEntityStaff(campaign.EntityID))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch campaign progress")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": p})
+}
+
+// Update - PUT /campaigns/:id
+func (h *Handler) Update(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	campaign, ok := h.loadCampaign(c, access, true)
+	if !ok {
+		return
+	}
+
+	var req CampaignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.Update(c.Request.Context(), campaign, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update campaign")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Campaign updated", "data": campaign})
+}
+
+// UploadImage - POST /campaigns/:id/image (multipart "image")
+func (h *Handler) UploadImage(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	campaign, ok := h.loadCampaign(c, access, true)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageSize+1<<20)
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "campaign_image")
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read image"})
+		return
+	}
+	defer file.Close()
+
+	if err := h.Service.SetImage(c.Request.Context(), campaign, filepath.Base(fileHeader.Filename), fileHeader.Size, file,
+		access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to upload image")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Image uploaded", "data": campaign})
+}
+
+// GetImage - GET /public/campaigns/:id/image (no auth)
+func (h *Handler) GetImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid campaign ID"})
+		return
+	}
+	campaign, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to load image")
+		return
+	}
+
+	rc, info, contentType, err := h.Service.OpenImage(c.Request.Context(), campaign)
+	if err != nil {
+		if errors.Is(err, ErrNoImage) || errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load image"})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, contentType, rc, map[string]string{
+		"Cache-Control": "public, max-age=86400",
+	})
+}
+
+// loadCampaign loads the :id campaign; writes need the caller to manage its
+// temple, reads are open to everyone signed in
+func (h *Handler) loadCampaign(c *gin.Context, access middleware.AccessContext, write bool) (*Campaign, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid campaign ID"})
+		return nil, false
+	}
+	campaign, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch campaign")
+		return nil, false
+	}
+	if write && !access.CanManageEntity(campaign.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this campaign"})
+		return nil, false
+	}
+	return campaign, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Campaign not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest: {ErrInvalidDates, ErrInvalidStatus, ErrInvalidImage},
+	},
+}
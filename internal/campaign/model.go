@@ -0,0 +1,78 @@
+package campaign
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Campaign statuses. Only active campaigns inside their dates take donations;
+// closed campaigns keep their totals.
+const (
+	StatusActive = "active"
+	StatusPaused = "paused"
+	StatusClosed = "closed"
+)
+
+// Campaign is a fundraising drive of a temple, e.g. a gopuram renovation.
+// Donations count towards it through donations.campaign_id.
+type Campaign struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID     uint       `gorm:"not null;index" json:"entity_id"`
+	Title        string     `gorm:"size:255;not null" json:"title"`
+	Description  string     `gorm:"type:text" json:"description"`
+	TargetAmount float64    `gorm:"type:decimal(12,2);not null" json:"target_amount"` // INR
+	StartDate    time.Time  `gorm:"type:date;not null" json:"start_date"`
+	EndDate      *time.Time `gorm:"type:date" json:"end_date,omitempty"` // nil runs until closed
+	Status       string     `gorm:"size:20;not null;default:'active';index" json:"status"`
+
+	ImageKey string `gorm:"size:255" json:"-"`            // storage key of the cover image
+	ImageURL string `gorm:"-" json:"image_url,omitempty"` // GET /campaigns/:id/image
+
+	CreatedBy uint           `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Campaign model
+func (Campaign) TableName() string {
+	return "donation_campaigns"
+}
+
+// Totals are the successful donations of a campaign
+type Totals struct {
+	CampaignID     uint       `json:"-"`
+	RaisedAmount   float64    `json:"raised_amount"`
+	DonationCount  int        `json:"donation_count"`
+	DonorCount     int        `json:"donor_count"`
+	LastDonationAt *time.Time `json:"last_donation_at,omitempty"`
+}
+
+// TopDonor is a donor of a campaign by amount given
+type TopDonor struct {
+	Name          string  `json:"name"`
+	TotalAmount   float64 `json:"total_amount"`
+	DonationCount int     `json:"donation_count"`
+}
+
+// Progress is a campaign with what it has raised so far
+type Progress struct {
+	Campaign
+	Totals
+	CompletionPercent float64    `json:"completion_percent"` // of the target, may pass 100
+	RemainingAmount   float64    `json:"remaining_amount"`
+	DaysLeft          *int       `json:"days_left,omitempty"`  // nil without an end date
+	TopDonors         []TopDonor `json:"top_donors,omitempty"` // temple staff only
+}
+
+// CampaignRequest creates or updates a campaign
+type CampaignRequest struct {
+	Title        string  `json:"title" binding:"required"`
+	Description  string  `json:"description"`
+	TargetAmount float64 `json:"target_amount" binding:"required,gt=0"`
+	StartDate    string  `json:"start_date" binding:"required"` // 2006-01-02
+	EndDate      string  `json:"end_date"`                      // 2006-01-02, optional
+	Status       string  `json:"status"`                        // update only: active, paused or closed
+}
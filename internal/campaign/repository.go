@@ -0,0 +1,83 @@
+package campaign
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repository reads and writes campaigns and totals their donations
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new campaign repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create stores a new campaign
+func (r *Repository) Create(ctx context.Context, c *Campaign) error {
+	return r.DB.WithContext(ctx).Create(c).Error
+}
+
+// Update saves every field of a campaign
+func (r *Repository) Update(ctx context.Context, c *Campaign) error {
+	return r.DB.WithContext(ctx).Save(c).Error
+}
+
+// GetByID loads a campaign
+func (r *Repository) GetByID(ctx context.Context, id uint) (*Campaign, error) {
+	var c Campaign
+	if err := r.DB.WithContext(ctx).First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListByEntity returns a temple's campaigns, newest first; status "" means all
+func (r *Repository) ListByEntity(ctx context.Context, entityID uint, status string) ([]Campaign, error) {
+	query := r.DB.WithContext(ctx).Where("entity_id = ?", entityID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var out []Campaign
+	err := query.Order("start_date DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+// GetTotals sums the successful donations of each campaign
+func (r *Repository) GetTotals(ctx context.Context, campaignIDs []uint) (map[uint]Totals, error) {
+	out := make(map[uint]Totals, len(campaignIDs))
+	if len(campaignIDs) == 0 {
+		return out, nil
+	}
+	var rows []Totals
+	err := r.DB.WithContext(ctx).Table("donations").
+		Select(`campaign_id, COALESCE(SUM(amount), 0) as raised_amount, COUNT(*) as donation_count,
+			COUNT(DISTINCT user_id) as donor_count, MAX(COALESCE(donated_at, created_at)) as last_donation_at`).
+		Where("campaign_id IN ? AND status = ? AND deleted_at IS NULL", campaignIDs, "SUCCESS").
+		Group("campaign_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range rows {
+		out[t.CampaignID] = t
+	}
+	return out, nil
+}
+
+// GetTopDonors returns the campaign's largest donors
+func (r *Repository) GetTopDonors(ctx context.Context, campaignID uint, limit int) ([]TopDonor, error) {
+	var out []TopDonor
+	err := r.DB.WithContext(ctx).Table("donations d").
+		Select("COALESCE(u.full_name, '') as name, SUM(d.amount) as total_amount, COUNT(*) as donation_count").
+		Joins("LEFT JOIN users u ON u.id = d.user_id").
+		Where("d.campaign_id = ? AND d.status = ? AND d.deleted_at IS NULL", campaignID, "SUCCESS").
+		Group("d.user_id, u.full_name").
+		Order("total_amount DESC").
+		Limit(limit).
+		Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,235 @@
+package campaign
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+)
+
+const (
+	dateLayout    = "2006-01-02"
+	maxImageSize  = 5 << 20 // 5 MB
+	topDonorLimit = 5
+)
+
+// imageTypes are the formats accepted for campaign cover images
+var imageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+}
+
+var (
+	ErrInvalidDates  = errors.New("start_date and end_date must be dates (yyyy-mm-dd), end_date on or after start_date")
+	ErrInvalidStatus = errors.New("status must be active, paused or closed")
+	ErrInvalidImage  = fmt.Errorf("image must be a JPG, PNG or WEBP file of %dMB or less", maxImageSize>>20)
+	ErrNoImage       = errors.New("campaign has no image")
+)
+
+// Service manages campaigns and their progress
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+	Store storage.Storage // cover images, stored alongside entity files
+}
+
+// NewService initializes the campaign service
+func NewService(repo *Repository, auditSvc auditlog.Service, store storage.Storage) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Store: store}
+}
+
+// ImageURL is the public address of a campaign's cover image
+func ImageURL(campaignID uint) string {
+	return fmt.Sprintf("/api/v1/public/campaigns/%d/image", campaignID)
+}
+
+func setImageURL(c *Campaign) {
+	c.ImageURL = ""
+	if c.ImageKey != "" {
+		c.ImageURL = ImageURL(c.ID)
+	}
+}
+
+// apply validates req and copies it onto c
+func apply(c *Campaign, req CampaignRequest) error {
+	start, err := time.Parse(dateLayout, req.StartDate)
+	if err != nil {
+		return ErrInvalidDates
+	}
+	var end *time.Time
+	if req.EndDate != "" {
+		e, err := time.Parse(dateLayout, req.EndDate)
+		if err != nil || e.Before(start) {
+			return ErrInvalidDates
+		}
+		end = &e
+	}
+	if req.Status != "" {
+		if req.Status != StatusActive && req.Status != StatusPaused && req.Status != StatusClosed {
+			return ErrInvalidStatus
+		}
+		c.Status = req.Status
+	}
+	c.Title = strings.TrimSpace(req.Title)
+	c.Description = strings.TrimSpace(req.Description)
+	c.TargetAmount = req.TargetAmount
+	c.StartDate, c.EndDate = start, end
+	return nil
+}
+
+// Create starts a campaign for the temple
+func (s *Service) Create(ctx context.Context, entityID uint, req CampaignRequest, userID uint, ip string) (*Campaign, error) {
+	c := &Campaign{EntityID: entityID, Status: StatusActive, CreatedBy: userID}
+	if err := apply(c, req); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "CAMPAIGN_CREATED", map[string]interface{}{
+		"campaign_id":   c.ID,
+		"title":         c.Title,
+		"target_amount": c.TargetAmount,
+		"start_date":    req.StartDate,
+		"end_date":      req.EndDate,
+	}, ip, "success")
+	return c, nil
+}
+
+// Update changes a campaign's details or status
+func (s *Service) Update(ctx context.Context, c *Campaign, req CampaignRequest, userID uint, ip string) error {
+	before := map[string]interface{}{"title": c.Title, "target_amount": c.TargetAmount, "status": c.Status}
+	if err := apply(c, req); err != nil {
+		return err
+	}
+	if err := s.Repo.Update(ctx, c); err != nil {
+		return err
+	}
+	setImageURL(c)
+	s.Audit.LogAction(ctx, &userID, &c.EntityID, "CAMPAIGN_UPDATED", map[string]interface{}{
+		"campaign_id": c.ID,
+		"before":      before,
+		"after":       map[string]interface{}{"title": c.Title, "target_amount": c.TargetAmount, "status": c.Status},
+	}, ip, "success")
+	return nil
+}
+
+// List returns a temple's campaigns with their progress; top donors are
+// included only when withDonors is set
+func (s *Service) List(ctx context.Context, entityID uint, status string, withDonors bool) ([]Progress, error) {
+	if status != "" && status != StatusActive && status != StatusPaused && status != StatusClosed {
+		return nil, ErrInvalidStatus
+	}
+	campaigns, err := s.Repo.ListByEntity(ctx, entityID, status)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(campaigns))
+	for i := range campaigns {
+		ids[i] = campaigns[i].ID
+	}
+	totals, err := s.Repo.GetTotals(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]Progress, 0, len(campaigns))
+	for _, c := range campaigns {
+		p := progress(c, totals[c.ID])
+		if withDonors {
+			if p.TopDonors, err = s.Repo.GetTopDonors(ctx, c.ID, topDonorLimit); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, p)
+	}
+	return out, nil
+}
+
+// Progress returns what a campaign has raised so far
+func (s *Service) Progress(ctx context.Context, c *Campaign, withDonors bool) (*Progress, error) {
+	totals, err := s.Repo.GetTotals(ctx, []uint{c.ID})
+	if err != nil {
+		return nil, err
+	}
+	p := progress(*c, totals[c.ID])
+	if withDonors {
+		if p.TopDonors, err = s.Repo.GetTopDonors(ctx, c.ID, topDonorLimit); err != nil {
+			return nil, err
+		}
+	}
+	return &p, nil
+}
+
+func progress(c Campaign, t Totals) Progress {
+	setImageURL(&c)
+	t.CampaignID = c.ID
+	p := Progress{Campaign: c, Totals: t, RemainingAmount: math.Max(c.TargetAmount-t.RaisedAmount, 0)}
+	if c.TargetAmount > 0 {
+		p.CompletionPercent = math.Round(t.RaisedAmount/c.TargetAmount*10000) / 100
+	}
+	if c.EndDate != nil {
+		now := time.Now()
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		days := max(int(c.EndDate.Sub(today).Hours()/24), 0)
+		p.DaysLeft = &days
+	}
+	return p
+}
+
+// SetImage stores a new cover image, replacing any earlier one
+func (s *Service) SetImage(ctx context.Context, c *Campaign, fileName string, size int64, r io.Reader, userID uint, ip string) error {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	contentType, ok := imageTypes[ext]
+	if !ok || size > maxImageSize {
+		return ErrInvalidImage
+	}
+	key, err := storage.Key(fmt.Sprint(c.EntityID), "campaigns", fmt.Sprint(c.ID), uuid.New().String()+ext)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.Put(ctx, key, r, size, contentType); err != nil {
+		return fmt.Errorf("failed to store image: %w", err)
+	}
+
+	previous := c.ImageKey
+	c.ImageKey = key
+	if err := s.Repo.Update(ctx, c); err != nil {
+		_ = s.Store.Delete(ctx, key)
+		c.ImageKey = previous
+		return err
+	}
+	if previous != "" {
+		_ = s.Store.Delete(ctx, previous)
+	}
+	setImageURL(c)
+
+	s.Audit.LogAction(ctx, &userID, &c.EntityID, "CAMPAIGN_IMAGE_UPLOADED", map[string]interface{}{
+		"campaign_id": c.ID,
+		"file_name":   fileName,
+		"file_size":   size,
+	}, ip, "success")
+	return nil
+}
+
+// OpenImage returns the stored cover image and its content type
+func (s *Service) OpenImage(ctx context.Context, c *Campaign) (io.ReadCloser, *storage.ObjectInfo, string, error) {
+	if c.ImageKey == "" {
+		return nil, nil, "", ErrNoImage
+	}
+	rc, info, err := s.Store.Get(ctx, c.ImageKey)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return rc, info, imageTypes[filepath.Ext(c.ImageKey)], nil
+}
@@ -0,0 +1,92 @@
+package checkin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the check-in endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new check-in handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetBookingPass - GET /check-in/bookings/:id/pass
+func (h *Handler) GetBookingPass(c *gin.Context) {
+	h.getPass(c, KindBooking)
+}
+
+// GetRSVPPass - GET /check-in/rsvps/:id/pass
+func (h *Handler) GetRSVPPass(c *gin.Context) {
+	h.getPass(c, KindRSVP)
+}
+
+// getPass returns the devotee's own pass; the app shows pass.code as a QR code
+func (h *Handler) getPass(c *gin.Context, kind string) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	pass, err := h.Service.Pass(c.Request.Context(), access.UserID, kind, uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to issue check-in pass")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": pass})
+}
+
+// Scan - POST /check-in/scan (temple staff)
+// Marks attendance for the booking or RSVP of a scanned pass.
+func (h *Handler) Scan(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var entityID *uint
+	if access.RoleName != middleware.RoleSuperAdmin {
+		entityID = access.GetAccessibleEntityID()
+		if entityID == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+			return
+		}
+	}
+
+	var req ScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	result, err := h.Service.Scan(c.Request.Context(), access.UserID, entityID, req.Code, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Check-in failed")
+		return
+	}
+	message := "Checked in"
+	if result.AlreadyCheckedIn {
+		message = "Already checked in"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": result})
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest:         {ErrInvalidCode},
+		http.StatusForbidden:          {ErrNotYours, ErrWrongTemple},
+		http.StatusConflict:           {ErrNotConfirmed, ErrPassExpired, ErrNotToday},
+		http.StatusServiceUnavailable: {ErrCheckInDisabled},
+	},
+}
@@ -0,0 +1,52 @@
+package checkin
+
+import "time"
+
+// Kinds of admission a pass is issued for
+const (
+	KindBooking = "booking" // an approved seva booking
+	KindRSVP    = "rsvp"    // an attending event RSVP
+)
+
+// Pass is what the devotee shows at the counter. Code is the content of the
+// QR code; clients render it, the server never stores it.
+type Pass struct {
+	Kind        string     `json:"kind"`
+	ID          uint       `json:"id"`
+	Code        string     `json:"code"`
+	Title       string     `json:"title"` // seva name or event title
+	ServiceDate time.Time  `json:"service_date"`
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+}
+
+// ScanRequest is the code read from a devotee's QR pass
+type ScanRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// ScanResult tells the counter who was admitted
+type ScanResult struct {
+	Kind             string    `json:"kind"`
+	ID               uint      `json:"id"`
+	Title            string    `json:"title"`
+	DevoteeName      string    `json:"devotee_name"`
+	DevoteePhone     string    `json:"devotee_phone"`
+	Participants     []string  `json:"participants,omitempty"` // family members on a household booking
+	ServiceDate      time.Time `json:"service_date"`
+	CheckedInAt      time.Time `json:"checked_in_at"`
+	AlreadyCheckedIn bool      `json:"already_checked_in"`
+}
+
+// admission is a booking or RSVP as loaded for a pass or a scan
+type admission struct {
+	ID           uint
+	UserID       uint
+	EntityID     uint
+	Status       string
+	Title        string
+	DevoteeName  string
+	DevoteePhone string
+	Participants []byte
+	ServiceDate  time.Time
+	CheckedInAt  *time.Time
+}
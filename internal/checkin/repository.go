@@ -0,0 +1,135 @@
+package checkin
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Repository reads bookings and RSVPs for check-in and records attendance
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new check-in repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// sevaBookingRow is a seva booking with what its pass and scan need
+type sevaBookingRow struct {
+	ID           uint
+	UserID       uint
+	EntityID     uint
+	Status       string
+	SevaName     string
+	SevaDate     string // dd-mm-yyyy, empty for undated sevas
+	BookingTime  time.Time
+	DevoteeName  string
+	DevoteePhone string `gorm:"serializer:encrypted"`
+	Participants []byte
+	CheckedInAt  *time.Time
+}
+
+// GetBooking loads a seva booking. The service day is the seva's date, or
+// the booking's own day for sevas without one.
+func (r *Repository) GetBooking(ctx context.Context, id uint) (*admission, error) {
+	var row sevaBookingRow
+	err := r.DB.WithContext(ctx).Table("seva_bookings sb").
+		Select(`sb.id, sb.user_id, sb.entity_id, sb.status, s.name as seva_name, COALESCE(s.date, '') as seva_date,
+			sb.booking_time, COALESCE(u.full_name, '') as devotee_name, COALESCE(u.phone, '') as devotee_phone,
+			sb.participants, sb.checked_in_at`).
+		Joins("JOIN sevas s ON s.id = sb.seva_id").
+		Joins("LEFT JOIN users u ON u.id = sb.user_id").
+		Where("sb.id = ?", id).
+		Take(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	y, m, d := row.BookingTime.In(time.Local).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	if row.SevaDate != "" {
+		if t, err := time.ParseInLocation("02-01-2006", row.SevaDate, time.Local); err == nil {
+			day = t
+		}
+	}
+	return &admission{
+		ID:           row.ID,
+		UserID:       row.UserID,
+		EntityID:     row.EntityID,
+		Status:       row.Status,
+		Title:        row.SevaName,
+		DevoteeName:  row.DevoteeName,
+		DevoteePhone: row.DevoteePhone,
+		Participants: row.Participants,
+		ServiceDate:  day,
+		CheckedInAt:  row.CheckedInAt,
+	}, nil
+}
+
+// rsvpRow is an event RSVP with what its pass and scan need
+type rsvpRow struct {
+	ID           uint
+	UserID       uint
+	EntityID     uint
+	Status       string
+	EventTitle   string
+	EventDate    time.Time
+	Occurrence   string // yyyy-mm-dd for recurring events
+	DevoteeName  string
+	DevoteePhone string `gorm:"serializer:encrypted"`
+	CheckedInAt  *time.Time
+}
+
+// GetRSVP loads an event RSVP. The service day is the occurrence of a
+// recurring event, else the event date.
+func (r *Repository) GetRSVP(ctx context.Context, id uint) (*admission, error) {
+	var row rsvpRow
+	err := r.DB.WithContext(ctx).Table("rsvps r").
+		Select(`r.id, r.user_id, ev.entity_id, r.status, ev.title as event_title, ev.event_date, r.occurrence,
+			COALESCE(u.full_name, '') as devotee_name, COALESCE(u.phone, '') as devotee_phone, r.checked_in_at`).
+		Joins("JOIN events ev ON ev.id = r.event_id").
+		Joins("LEFT JOIN users u ON u.id = r.user_id").
+		Where("r.id = ?", id).
+		Take(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	y, m, d := row.EventDate.In(time.Local).Date()
+	day := time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+	if row.Occurrence != "" {
+		if t, err := time.ParseInLocation("2006-01-02", row.Occurrence, time.Local); err == nil {
+			day = t
+		}
+	}
+	return &admission{
+		ID:           row.ID,
+		UserID:       row.UserID,
+		EntityID:     row.EntityID,
+		Status:       row.Status,
+		Title:        row.EventTitle,
+		DevoteeName:  row.DevoteeName,
+		DevoteePhone: row.DevoteePhone,
+		ServiceDate:  day,
+		CheckedInAt:  row.CheckedInAt,
+	}, nil
+}
+
+// MarkCheckedIn records attendance once; it reports false when the booking
+// or RSVP was already checked in
+func (r *Repository) MarkCheckedIn(ctx context.Context, kind string, id, staffID uint, at time.Time) (bool, error) {
+	table := "seva_bookings"
+	if kind == KindRSVP {
+		table = "rsvps"
+	}
+	res := r.DB.WithContext(ctx).Table(table).
+		Where("id = ? AND checked_in_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"checked_in_at": at,
+			"checked_in_by": staffID,
+		})
+	return res.RowsAffected > 0, res.Error
+}
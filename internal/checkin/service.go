@@ -0,0 +1,189 @@
+package checkin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCheckInDisabled = errors.New("check-in passes are not configured")
+	ErrInvalidCode     = errors.New("this QR code is not a valid check-in pass")
+	ErrNotYours        = errors.New("this booking or RSVP belongs to someone else")
+	ErrNotConfirmed    = errors.New("only approved bookings and attending RSVPs can be checked in")
+	ErrPassExpired     = errors.New("the service date of this pass has passed")
+	ErrWrongTemple     = errors.New("this pass is for another temple")
+	ErrNotToday        = errors.New("this pass is for another day")
+)
+
+// confirmedStatus is the status a booking or RSVP needs to be admitted
+var confirmedStatus = map[string]string{
+	KindBooking: "approved",
+	KindRSVP:    "attending",
+}
+
+// Service issues signed QR passes and checks devotees in at the counter
+type Service struct {
+	Repo   *Repository
+	Audit  auditlog.Service
+	Secret []byte
+}
+
+// NewService initializes the check-in service
+func NewService(repo *Repository, auditSvc auditlog.Service, secret []byte) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Secret: secret}
+}
+
+// signature signs a pass; 128 bits keep the QR code small
+func (s *Service) signature(kind string, id uint) string {
+	mac := hmac.New(sha256.New, s.Secret)
+	fmt.Fprintf(mac, "checkin:%s:%d", kind, id)
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// code is the content of a pass's QR code, "<kind>.<id>.<signature>"
+func (s *Service) code(kind string, id uint) string {
+	return fmt.Sprintf("%s.%d.%s", kind, id, s.signature(kind, id))
+}
+
+// parseCode checks a scanned code and returns what it admits
+func (s *Service) parseCode(code string) (string, uint, error) {
+	parts := strings.Split(strings.TrimSpace(code), ".")
+	if len(parts) != 3 || (parts[0] != KindBooking && parts[0] != KindRSVP) {
+		return "", 0, ErrInvalidCode
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil || id == 0 {
+		return "", 0, ErrInvalidCode
+	}
+	if !hmac.Equal([]byte(parts[2]), []byte(s.signature(parts[0], uint(id)))) {
+		return "", 0, ErrInvalidCode
+	}
+	return parts[0], uint(id), nil
+}
+
+func (s *Service) load(ctx context.Context, kind string, id uint) (*admission, error) {
+	if kind == KindRSVP {
+		return s.Repo.GetRSVP(ctx, id)
+	}
+	return s.Repo.GetBooking(ctx, id)
+}
+
+func today() time.Time {
+	y, m, d := time.Now().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+// Pass issues the QR pass of the user's own approved booking or attending RSVP
+func (s *Service) Pass(ctx context.Context, userID uint, kind string, id uint) (*Pass, error) {
+	if len(s.Secret) == 0 {
+		return nil, ErrCheckInDisabled
+	}
+	a, err := s.load(ctx, kind, id)
+	if err != nil {
+		return nil, err
+	}
+	if a.UserID != userID {
+		return nil, ErrNotYours
+	}
+	if a.Status != confirmedStatus[kind] {
+		return nil, ErrNotConfirmed
+	}
+	if a.CheckedInAt == nil && a.ServiceDate.Before(today()) {
+		return nil, ErrPassExpired
+	}
+	return &Pass{
+		Kind:        kind,
+		ID:          id,
+		Code:        s.code(kind, id),
+		Title:       a.Title,
+		ServiceDate: a.ServiceDate,
+		CheckedInAt: a.CheckedInAt,
+	}, nil
+}
+
+// Scan checks in the devotee holding a pass. entityID is the staff member's
+// temple, nil for superadmins. Scanning a pass twice is not an error; the
+// result says it was already checked in.
+func (s *Service) Scan(ctx context.Context, staffID uint, entityID *uint, code, ip string) (*ScanResult, error) {
+	if len(s.Secret) == 0 {
+		return nil, ErrCheckInDisabled
+	}
+	reject := func(kind string, id uint, err error) (*ScanResult, error) {
+		s.Audit.LogAction(ctx, &staffID, entityID, "CHECKIN_REJECTED", map[string]interface{}{
+			"kind":   kind,
+			"id":     id,
+			"reason": err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	kind, id, err := s.parseCode(code)
+	if err != nil {
+		return reject("", 0, err)
+	}
+	a, err := s.load(ctx, kind, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return reject(kind, id, ErrInvalidCode)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if entityID != nil && *entityID != a.EntityID {
+		return reject(kind, id, ErrWrongTemple)
+	}
+	if a.Status != confirmedStatus[kind] {
+		return reject(kind, id, ErrNotConfirmed)
+	}
+	if !a.ServiceDate.Equal(today()) {
+		return reject(kind, id, ErrNotToday)
+	}
+
+	result := &ScanResult{
+		Kind:         kind,
+		ID:           id,
+		Title:        a.Title,
+		DevoteeName:  a.DevoteeName,
+		DevoteePhone: a.DevoteePhone,
+		ServiceDate:  a.ServiceDate,
+	}
+	if len(a.Participants) > 0 {
+		_ = json.Unmarshal(a.Participants, &result.Participants)
+	}
+
+	now := time.Now()
+	marked, err := s.Repo.MarkCheckedIn(ctx, kind, id, staffID, now)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		// Scanned before; report when
+		if a, err = s.load(ctx, kind, id); err != nil {
+			return nil, err
+		}
+		if a.CheckedInAt != nil {
+			result.CheckedInAt = *a.CheckedInAt
+		}
+		result.AlreadyCheckedIn = true
+		return result, nil
+	}
+	result.CheckedInAt = now
+
+	s.Audit.LogAction(ctx, &staffID, &a.EntityID, "CHECKIN_RECORDED", map[string]interface{}{
+		"kind":       kind,
+		"id":         id,
+		"title":      a.Title,
+		"devotee_id": a.UserID,
+	}, ip, "success")
+	return result, nil
+}
@@ -0,0 +1,228 @@
+package coupon
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the coupon endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new coupon handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// List - GET /coupons?active=true&search=DIWALI&page=1&limit=20
+func (h *Handler) List(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	f := Filter{
+		EntityID: entityID,
+		Search:   strings.TrimSpace(c.Query("search")),
+		Page:     httpx.PositiveQuery(c, "page", 1),
+		Limit:    min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if v := c.Query("active"); v != "" {
+		active, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "active must be true or false"})
+			return
+		}
+		f.Active = &active
+	}
+
+	items, total, err := h.Service.Repo.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch coupons"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// Create - POST /coupons
+func (h *Handler) Create(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	coupon, err := h.Service.Create(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to create coupon")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Coupon created", "data": coupon})
+}
+
+// Get - GET /coupons/:id
+func (h *Handler) Get(c *gin.Context) {
+	_, coupon, ok := h.loadCoupon(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": coupon})
+}
+
+// Update - PUT /coupons/:id
+func (h *Handler) Update(c *gin.Context) {
+	access, coupon, ok := h.loadCoupon(c, true)
+	if !ok {
+		return
+	}
+	var req CouponRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.Update(c.Request.Context(), coupon, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to update coupon")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon updated", "data": coupon})
+}
+
+// Delete - DELETE /coupons/:id
+func (h *Handler) Delete(c *gin.Context) {
+	access, coupon, ok := h.loadCoupon(c, true)
+	if !ok {
+		return
+	}
+	if err := h.Service.Delete(c.Request.Context(), coupon, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to delete coupon")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Coupon deleted"})
+}
+
+// ListRedemptions - GET /coupons/:id/redemptions?page=1&limit=20
+func (h *Handler) ListRedemptions(c *gin.Context) {
+	_, coupon, ok := h.loadCoupon(c, false)
+	if !ok {
+		return
+	}
+	page := httpx.PositiveQuery(c, "page", 1)
+	limit := min(httpx.PositiveQuery(c, "limit", 20), 100)
+	items, total, err := h.Service.Repo.ListRedemptions(c.Request.Context(), coupon.ID, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch redemptions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Validate - POST /coupons/validate (devotee)
+// Body: {"entity_id": 12, "code": "DIWALI10", "kind": "booking", "seva_id": 4}
+func (h *Handler) Validate(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req ValidateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	quote, err := h.Service.Validate(c.Request.Context(), req, access.UserID)
+	if err != nil {
+		h.writeError(c, err, "Failed to check coupon")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"valid": true, "data": quote})
+}
+
+// loadCoupon loads the :id coupon of a temple the caller runs; writes need
+// write access
+func (h *Handler) loadCoupon(c *gin.Context, write bool) (middleware.AccessContext, *Coupon, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid coupon ID"})
+		return access, nil, false
+	}
+	coupon, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch coupon")
+		return access, nil, false
+	}
+	allowed := access.IsEntityStaff(coupon.EntityID)
+	if write {
+		allowed = access.CanManageEntity(coupon.EntityID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this coupon"})
+		return access, nil, false
+	}
+	return access, coupon, true
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, fallback string) {
+	if status, ok := ErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	httpx.ErrorMap{NotFound: "Not found"}.Write(c, err, fallback)
+}
+
+// ErrorStatus maps a coupon error to its HTTP status, for the booking and
+// donation handlers that apply codes
+func ErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, ErrInvalidCoupon):
+		return http.StatusBadRequest, true
+	case errors.Is(err, ErrUnknownCode):
+		return http.StatusNotFound, true
+	case errors.Is(err, ErrCouponInactive), errors.Is(err, ErrNotYetValid), errors.Is(err, ErrExpired),
+		errors.Is(err, ErrNotApplicable), errors.Is(err, ErrBelowMinAmount), errors.Is(err, ErrNothingToDiscount):
+		return http.StatusUnprocessableEntity, true
+	case errors.Is(err, ErrCodeTaken), errors.Is(err, ErrUsageLimit), errors.Is(err, ErrPerUserLimit),
+		errors.Is(err, ErrCouponInUse):
+		return http.StatusConflict, true
+	}
+	return 0, false
+}
@@ -0,0 +1,157 @@
+package coupon
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Discount types
+const (
+	DiscountPercentage = "percentage"
+	DiscountFlat       = "flat"
+)
+
+// What a coupon may be used for
+const (
+	AppliesToBookings  = "bookings"
+	AppliesToDonations = "donations"
+	AppliesToAll       = "all"
+)
+
+// Kinds of purchase a coupon is redeemed against
+const (
+	KindBooking  = "booking"
+	KindDonation = "donation"
+)
+
+// Redemption statuses. A redemption is released when its booking is
+// rejected or cancelled or its donation payment fails, which frees the use.
+const (
+	RedemptionApplied  = "applied"
+	RedemptionReleased = "released"
+)
+
+// Coupon is a temple's discount code
+type Coupon struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID     uint     `gorm:"not null;index;uniqueIndex:idx_coupons_entity_code,where:deleted_at IS NULL" json:"entity_id"`
+	Code         string   `gorm:"size:40;not null;uniqueIndex:idx_coupons_entity_code,where:deleted_at IS NULL" json:"code"` // upper case
+	Description  string   `gorm:"type:text" json:"description,omitempty"`
+	DiscountType string   `gorm:"size:20;not null" json:"discount_type"`            // percentage or flat
+	Value        float64  `gorm:"type:decimal(10,2);not null" json:"value"`         // percent, or rupees off
+	MaxDiscount  *float64 `gorm:"type:decimal(10,2)" json:"max_discount,omitempty"` // cap on a percentage discount
+	MinAmount    float64  `gorm:"type:decimal(10,2);not null;default:0" json:"min_amount"`
+
+	UsageLimit   int `gorm:"not null;default:0" json:"usage_limit"`    // uses in all, 0 = unlimited
+	PerUserLimit int `gorm:"not null;default:0" json:"per_user_limit"` // uses per devotee, 0 = unlimited
+
+	ValidFrom  *time.Time `gorm:"type:date" json:"valid_from,omitempty"`  // first day it can be used
+	ValidUntil *time.Time `gorm:"type:date" json:"valid_until,omitempty"` // last day it can be used
+
+	AppliesTo string         `gorm:"size:20;not null;default:'bookings'" json:"applies_to"` // bookings, donations or all
+	SevaIDs   datatypes.JSON `gorm:"type:jsonb" json:"seva_ids,omitempty"`                  // [id]; empty for any seva
+	IsActive  bool           `gorm:"not null;default:true;index" json:"is_active"`
+
+	CreatedBy uint           `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Coupon model
+func (Coupon) TableName() string {
+	return "coupons"
+}
+
+// Redemption is one use of a coupon on a seva booking or a donation
+type Redemption struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	CouponID   uint   `gorm:"not null;index" json:"coupon_id"`
+	EntityID   uint   `gorm:"not null;index" json:"entity_id"`
+	UserID     uint   `gorm:"not null;index" json:"user_id"`
+	Code       string `gorm:"size:40;not null" json:"code"`
+	Kind       string `gorm:"size:20;not null" json:"kind"` // booking or donation
+	BookingID  *uint  `gorm:"index" json:"booking_id,omitempty"`
+	DonationID *uint  `gorm:"index" json:"donation_id,omitempty"`
+	SevaID     *uint  `json:"seva_id,omitempty"`
+
+	Amount      float64 `gorm:"type:decimal(10,2);not null" json:"amount"` // before the discount
+	Discount    float64 `gorm:"type:decimal(10,2);not null" json:"discount"`
+	FinalAmount float64 `gorm:"type:decimal(10,2);not null" json:"final_amount"`
+
+	Status     string     `gorm:"size:20;not null;default:'applied';index" json:"status"`
+	ReleasedAt *time.Time `json:"released_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName returns the table name for the Redemption model
+func (Redemption) TableName() string {
+	return "coupon_redemptions"
+}
+
+// CouponRequest creates a coupon or replaces one. Dates are yyyy-mm-dd.
+type CouponRequest struct {
+	Code         string   `json:"code" binding:"required"`
+	Description  string   `json:"description"`
+	DiscountType string   `json:"discount_type" binding:"required,oneof=percentage flat"`
+	Value        float64  `json:"value" binding:"gt=0"`
+	MaxDiscount  *float64 `json:"max_discount"`
+	MinAmount    float64  `json:"min_amount" binding:"gte=0"`
+	UsageLimit   int      `json:"usage_limit" binding:"gte=0"`
+	PerUserLimit int      `json:"per_user_limit" binding:"gte=0"`
+	ValidFrom    string   `json:"valid_from"`
+	ValidUntil   string   `json:"valid_until"`
+	AppliesTo    string   `json:"applies_to"` // bookings (default), donations or all
+	SevaIDs      []uint   `json:"seva_ids"`
+	IsActive     *bool    `json:"is_active"` // defaults to true
+}
+
+// ValidateRequest lets a devotee check a code before booking or donating.
+// Bookings are priced from the seva; donations give the amount.
+type ValidateRequest struct {
+	EntityID uint    `json:"entity_id" binding:"required"`
+	Code     string  `json:"code" binding:"required"`
+	Kind     string  `json:"kind" binding:"required,oneof=booking donation"`
+	SevaID   *uint   `json:"seva_id"`
+	Amount   float64 `json:"amount"`
+}
+
+// Target is the purchase a coupon is applied to
+type Target struct {
+	EntityID   uint
+	UserID     uint
+	Kind       string
+	SevaID     *uint // the booked seva, or the seva a donation is for
+	Amount     float64
+	BookingID  *uint
+	DonationID *uint
+}
+
+// Quote is the discount a coupon gives on a purchase
+type Quote struct {
+	CouponID    uint    `json:"coupon_id"`
+	Code        string  `json:"code"`
+	Amount      float64 `json:"amount"`
+	Discount    float64 `json:"discount"`
+	FinalAmount float64 `json:"final_amount"`
+}
+
+// CouponWithStats is a coupon with its applied redemptions
+type CouponWithStats struct {
+	Coupon
+	Redemptions   int64   `json:"redemptions"`
+	DiscountTotal float64 `json:"discount_total"`
+}
+
+// Filter narrows the coupon list
+type Filter struct {
+	EntityID uint
+	Active   *bool
+	Search   string
+	Page     int
+	Limit    int
+}
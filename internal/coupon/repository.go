@@ -0,0 +1,158 @@
+package coupon
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository reads and writes coupons and their redemptions
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new coupon repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create stores a new coupon
+func (r *Repository) Create(ctx context.Context, c *Coupon) error {
+	return r.DB.WithContext(ctx).Create(c).Error
+}
+
+// Update saves every field of a coupon
+func (r *Repository) Update(ctx context.Context, c *Coupon) error {
+	return r.DB.WithContext(ctx).Save(c).Error
+}
+
+// Delete soft-deletes a coupon; its redemptions stay for reporting
+func (r *Repository) Delete(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Delete(&Coupon{}, id).Error
+}
+
+// GetByID loads a coupon
+func (r *Repository) GetByID(ctx context.Context, id uint) (*Coupon, error) {
+	var c Coupon
+	if err := r.DB.WithContext(ctx).First(&c, id).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetByCode loads a temple's coupon by its upper-case code
+func (r *Repository) GetByCode(ctx context.Context, entityID uint, code string) (*Coupon, error) {
+	var c Coupon
+	if err := r.DB.WithContext(ctx).Where("entity_id = ? AND code = ?", entityID, code).First(&c).Error; err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CodeExists reports whether the temple has another coupon with the code
+func (r *Repository) CodeExists(ctx context.Context, entityID uint, code string, exceptID uint) (bool, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Coupon{}).
+		Where("entity_id = ? AND code = ? AND id <> ?", entityID, code, exceptID).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// List returns a temple's coupons with their applied redemptions, newest
+// first, and how many match f
+func (r *Repository) List(ctx context.Context, f Filter) ([]CouponWithStats, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Coupon{}).Where("coupons.entity_id = ?", f.EntityID)
+	if f.Active != nil {
+		query = query.Where("coupons.is_active = ?", *f.Active)
+	}
+	if f.Search != "" {
+		query = query.Where("coupons.code ILIKE ? OR coupons.description ILIKE ?", "%"+f.Search+"%", "%"+f.Search+"%")
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []CouponWithStats
+	err := query.
+		Select(`coupons.*, COUNT(r.id) AS redemptions, COALESCE(SUM(r.discount), 0) AS discount_total`).
+		Joins("LEFT JOIN coupon_redemptions r ON r.coupon_id = coupons.id AND r.status = ?", RedemptionApplied).
+		Group("coupons.id").
+		Order("coupons.created_at DESC, coupons.id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).
+		Scan(&out).Error
+	return out, total, err
+}
+
+// ListRedemptions returns a coupon's redemptions, latest first, and their count
+func (r *Repository) ListRedemptions(ctx context.Context, couponID uint, page, limit int) ([]Redemption, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Redemption{}).Where("coupon_id = ?", couponID)
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Redemption
+	err := query.Order("created_at DESC, id DESC").Limit(limit).Offset((page - 1) * limit).Find(&out).Error
+	return out, total, err
+}
+
+// CountSevas counts the sevas among ids that belong to the temple
+func (r *Repository) CountSevas(ctx context.Context, entityID uint, ids []uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Table("sevas").Where("entity_id = ? AND id IN ?", entityID, ids).Count(&n).Error
+	return n, err
+}
+
+// SevaPrice returns the price of one of the temple's sevas
+func (r *Repository) SevaPrice(ctx context.Context, entityID, sevaID uint) (float64, error) {
+	var price []float64
+	err := r.DB.WithContext(ctx).Table("sevas").Where("entity_id = ? AND id = ?", entityID, sevaID).Pluck("price", &price).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(price) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return price[0], nil
+}
+
+// lockByCode loads the coupon holding its row lock, which serialises
+// redemptions of it until the transaction ends
+func lockByCode(tx *gorm.DB, entityID uint, code string) (*Coupon, error) {
+	var c Coupon
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("entity_id = ? AND code = ?", entityID, code).
+		First(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// countUses counts the coupon's applied redemptions, in all and by the user
+func countUses(tx *gorm.DB, couponID, userID uint) (int64, int64, error) {
+	var row struct {
+		Total  int64
+		ByUser int64
+	}
+	err := tx.Model(&Redemption{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE user_id = ?) AS by_user", userID).
+		Where("coupon_id = ? AND status = ?", couponID, RedemptionApplied).
+		Scan(&row).Error
+	return row.Total, row.ByUser, err
+}
+
+// SetStatus moves the redemptions of a booking or donation (column
+// booking_id or donation_id) from one status to the other
+func (r *Repository) SetStatus(ctx context.Context, column string, id uint, from, to string) (int64, error) {
+	updates := map[string]interface{}{"status": to, "released_at": nil}
+	if to == RedemptionReleased {
+		updates["released_at"] = time.Now()
+	}
+	res := r.DB.WithContext(ctx).Model(&Redemption{}).
+		Where(column+" = ? AND status = ?", id, from).
+		Updates(updates)
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,380 @@
+package coupon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+var codePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,40}$`)
+
+var (
+	ErrInvalidCoupon     = errors.New("invalid coupon")
+	ErrCodeTaken         = errors.New("the temple already has a coupon with this code")
+	ErrUnknownCode       = errors.New("coupon code not found")
+	ErrCouponInactive    = errors.New("coupon is not active")
+	ErrNotYetValid       = errors.New("coupon is not valid yet")
+	ErrExpired           = errors.New("coupon has expired")
+	ErrNotApplicable     = errors.New("coupon does not apply to this purchase")
+	ErrBelowMinAmount    = errors.New("amount is below the coupon's minimum")
+	ErrUsageLimit        = errors.New("coupon has reached its usage limit")
+	ErrPerUserLimit      = errors.New("you have already used this coupon the maximum number of times")
+	ErrCouponInUse       = errors.New("a coupon that has been used can only be deactivated")
+	ErrNothingToDiscount = errors.New("there is nothing to discount")
+)
+
+// Service manages temple coupons and applies them to bookings and donations
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+}
+
+// NewService initializes the coupon service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc}
+}
+
+// NormalizeCode upper-cases and trims a code as typed by a devotee
+func NormalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func parseDate(field, v string) (*time.Time, error) {
+	if strings.TrimSpace(v) == "" {
+		return nil, nil
+	}
+	d, err := time.Parse(dateLayout, strings.TrimSpace(v))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s must be a date (yyyy-mm-dd)", ErrInvalidCoupon, field)
+	}
+	return &d, nil
+}
+
+// apply validates req and copies it onto c
+func (s *Service) apply(ctx context.Context, c *Coupon, req CouponRequest) error {
+	code := NormalizeCode(req.Code)
+	if !codePattern.MatchString(code) {
+		return fmt.Errorf("%w: code must be 3 to 40 letters, digits, - or _", ErrInvalidCoupon)
+	}
+	if req.DiscountType == DiscountPercentage && req.Value > 100 {
+		return fmt.Errorf("%w: a percentage discount can't exceed 100", ErrInvalidCoupon)
+	}
+	if req.MaxDiscount != nil && (*req.MaxDiscount <= 0 || req.DiscountType != DiscountPercentage) {
+		return fmt.Errorf("%w: max_discount must be positive and is only for percentage discounts", ErrInvalidCoupon)
+	}
+	if req.PerUserLimit > 0 && req.UsageLimit > 0 && req.PerUserLimit > req.UsageLimit {
+		return fmt.Errorf("%w: per_user_limit can't exceed usage_limit", ErrInvalidCoupon)
+	}
+	from, err := parseDate("valid_from", req.ValidFrom)
+	if err != nil {
+		return err
+	}
+	until, err := parseDate("valid_until", req.ValidUntil)
+	if err != nil {
+		return err
+	}
+	if from != nil && until != nil && until.Before(*from) {
+		return fmt.Errorf("%w: valid_until is before valid_from", ErrInvalidCoupon)
+	}
+
+	appliesTo := strings.ToLower(strings.TrimSpace(req.AppliesTo))
+	switch appliesTo {
+	case "":
+		appliesTo = AppliesToBookings
+	case AppliesToBookings, AppliesToDonations, AppliesToAll:
+	default:
+		return fmt.Errorf("%w: applies_to must be bookings, donations or all", ErrInvalidCoupon)
+	}
+
+	var sevaIDs []uint
+	seen := map[uint]bool{}
+	for _, id := range req.SevaIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			sevaIDs = append(sevaIDs, id)
+		}
+	}
+	c.SevaIDs = nil
+	if len(sevaIDs) > 0 {
+		n, err := s.Repo.CountSevas(ctx, c.EntityID, sevaIDs)
+		if err != nil {
+			return err
+		}
+		if n != int64(len(sevaIDs)) {
+			return fmt.Errorf("%w: seva_ids must be sevas of this temple", ErrInvalidCoupon)
+		}
+		if c.SevaIDs, err = json.Marshal(sevaIDs); err != nil {
+			return err
+		}
+	}
+
+	taken, err := s.Repo.CodeExists(ctx, c.EntityID, code, c.ID)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return ErrCodeTaken
+	}
+
+	c.Code = code
+	c.Description = strings.TrimSpace(req.Description)
+	c.DiscountType = req.DiscountType
+	c.Value = req.Value
+	c.MaxDiscount = req.MaxDiscount
+	c.MinAmount = req.MinAmount
+	c.UsageLimit = req.UsageLimit
+	c.PerUserLimit = req.PerUserLimit
+	c.ValidFrom, c.ValidUntil = from, until
+	c.AppliesTo = appliesTo
+	c.IsActive = req.IsActive == nil || *req.IsActive
+	return nil
+}
+
+// Create stores a new coupon
+func (s *Service) Create(ctx context.Context, entityID uint, req CouponRequest, userID uint, ip string) (*Coupon, error) {
+	c := &Coupon{EntityID: entityID, CreatedBy: userID}
+	if err := s.apply(ctx, c, req); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	if !c.IsActive {
+		// is_active defaults to true, so gorm leaves false out of the insert
+		if err := s.Repo.Update(ctx, c); err != nil {
+			return nil, err
+		}
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "COUPON_CREATED", map[string]interface{}{
+		"coupon_id":     c.ID,
+		"code":          c.Code,
+		"discount_type": c.DiscountType,
+		"value":         c.Value,
+		"applies_to":    c.AppliesTo,
+		"usage_limit":   c.UsageLimit,
+	}, ip, "success")
+	return c, nil
+}
+
+// Update replaces a coupon. Once it has been redeemed, the terms devotees
+// used it under can't change; it can still be deactivated or extended.
+func (s *Service) Update(ctx context.Context, c *Coupon, req CouponRequest, userID uint, ip string) error {
+	before := *c
+	if err := s.apply(ctx, c, req); err != nil {
+		return err
+	}
+	used, _, err := countUses(s.Repo.DB.WithContext(ctx), c.ID, 0)
+	if err != nil {
+		return err
+	}
+	if used > 0 && (c.Code != before.Code || c.DiscountType != before.DiscountType || c.Value != before.Value) {
+		*c = before
+		return ErrCouponInUse
+	}
+	if err := s.Repo.Update(ctx, c); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &c.EntityID, "COUPON_UPDATED", map[string]interface{}{
+		"coupon_id":   c.ID,
+		"code":        c.Code,
+		"is_active":   c.IsActive,
+		"usage_limit": c.UsageLimit,
+		"valid_until": req.ValidUntil,
+	}, ip, "success")
+	return nil
+}
+
+// Delete removes a coupon. Its redemptions stay in the coupons report.
+func (s *Service) Delete(ctx context.Context, c *Coupon, userID uint, ip string) error {
+	if err := s.Repo.Delete(ctx, c.ID); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &c.EntityID, "COUPON_DELETED", map[string]interface{}{
+		"coupon_id": c.ID,
+		"code":      c.Code,
+	}, ip, "success")
+	return nil
+}
+
+func (c *Coupon) sevaIDs() []uint {
+	var ids []uint
+	_ = json.Unmarshal(c.SevaIDs, &ids)
+	return ids
+}
+
+// check reports why the coupon can't be used on the target now, if it can't
+func (c *Coupon) check(t Target, now time.Time) error {
+	if !c.IsActive {
+		return ErrCouponInactive
+	}
+	today := now.Format(dateLayout)
+	if c.ValidFrom != nil && today < c.ValidFrom.Format(dateLayout) {
+		return ErrNotYetValid
+	}
+	if c.ValidUntil != nil && today > c.ValidUntil.Format(dateLayout) {
+		return ErrExpired
+	}
+	if (t.Kind == KindBooking && c.AppliesTo == AppliesToDonations) ||
+		(t.Kind == KindDonation && c.AppliesTo == AppliesToBookings) {
+		return ErrNotApplicable
+	}
+	if ids := c.sevaIDs(); len(ids) > 0 {
+		found := false
+		for _, id := range ids {
+			if t.SevaID != nil && *t.SevaID == id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrNotApplicable
+		}
+	}
+	if t.Amount <= 0 {
+		return ErrNothingToDiscount
+	}
+	if t.Amount < c.MinAmount {
+		return fmt.Errorf("%w of %.2f", ErrBelowMinAmount, c.MinAmount)
+	}
+	return nil
+}
+
+// quote works out the discount on amount, never more than the amount
+func (c *Coupon) quote(amount float64) Quote {
+	discount := c.Value
+	if c.DiscountType == DiscountPercentage {
+		discount = amount * c.Value / 100
+		if c.MaxDiscount != nil && discount > *c.MaxDiscount {
+			discount = *c.MaxDiscount
+		}
+	}
+	discount = math.Min(math.Round(discount*100)/100, amount)
+	return Quote{
+		CouponID:    c.ID,
+		Code:        c.Code,
+		Amount:      amount,
+		Discount:    discount,
+		FinalAmount: math.Round((amount-discount)*100) / 100,
+	}
+}
+
+func checkLimits(c *Coupon, total, byUser int64) error {
+	if c.UsageLimit > 0 && total >= int64(c.UsageLimit) {
+		return ErrUsageLimit
+	}
+	if c.PerUserLimit > 0 && byUser >= int64(c.PerUserLimit) {
+		return ErrPerUserLimit
+	}
+	return nil
+}
+
+// Quote checks the code against the target without using it up
+func (s *Service) Quote(ctx context.Context, code string, t Target) (*Quote, error) {
+	c, err := s.Repo.GetByCode(ctx, t.EntityID, NormalizeCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := c.check(t, time.Now()); err != nil {
+		return nil, err
+	}
+	total, byUser, err := countUses(s.Repo.DB.WithContext(ctx), c.ID, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLimits(c, total, byUser); err != nil {
+		return nil, err
+	}
+	q := c.quote(t.Amount)
+	return &q, nil
+}
+
+// Validate quotes a code for a devotee ahead of a booking or donation
+func (s *Service) Validate(ctx context.Context, req ValidateRequest, userID uint) (*Quote, error) {
+	t := Target{EntityID: req.EntityID, UserID: userID, Kind: req.Kind, SevaID: req.SevaID, Amount: req.Amount}
+	if req.Kind == KindBooking {
+		if req.SevaID == nil {
+			return nil, fmt.Errorf("%w: seva_id is required for bookings", ErrInvalidCoupon)
+		}
+		price, err := s.Repo.SevaPrice(ctx, req.EntityID, *req.SevaID)
+		if err != nil {
+			return nil, err
+		}
+		t.Amount = price
+	}
+	return s.Quote(ctx, req.Code, t)
+}
+
+// RedeemTx uses the code on the target inside the caller's transaction, so
+// the redemption commits or rolls back with the booking or donation. The
+// coupon row is locked while its limits are checked.
+func (s *Service) RedeemTx(tx *gorm.DB, code string, t Target) (*Redemption, error) {
+	c, err := lockByCode(tx, t.EntityID, NormalizeCode(code))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUnknownCode
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := c.check(t, time.Now()); err != nil {
+		return nil, err
+	}
+	total, byUser, err := countUses(tx, c.ID, t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkLimits(c, total, byUser); err != nil {
+		return nil, err
+	}
+
+	q := c.quote(t.Amount)
+	r := &Redemption{
+		CouponID:    c.ID,
+		EntityID:    t.EntityID,
+		UserID:      t.UserID,
+		Code:        c.Code,
+		Kind:        t.Kind,
+		BookingID:   t.BookingID,
+		DonationID:  t.DonationID,
+		SevaID:      t.SevaID,
+		Amount:      q.Amount,
+		Discount:    q.Discount,
+		FinalAmount: q.FinalAmount,
+		Status:      RedemptionApplied,
+	}
+	if err := tx.Create(r).Error; err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// ReleaseBooking frees the coupon use of a rejected or cancelled booking
+func (s *Service) ReleaseBooking(ctx context.Context, bookingID uint) error {
+	_, err := s.Repo.SetStatus(ctx, "booking_id", bookingID, RedemptionApplied, RedemptionReleased)
+	return err
+}
+
+// ReleaseDonation frees the coupon use of a donation whose payment failed
+func (s *Service) ReleaseDonation(ctx context.Context, donationID uint) error {
+	_, err := s.Repo.SetStatus(ctx, "donation_id", donationID, RedemptionApplied, RedemptionReleased)
+	return err
+}
+
+// RestoreDonation applies the coupon again when a failed payment is retried
+// successfully on the same order, which was already discounted
+func (s *Service) RestoreDonation(ctx context.Context, donationID uint) error {
+	_, err := s.Repo.SetStatus(ctx, "donation_id", donationID, RedemptionReleased, RedemptionApplied)
+	return err
+}
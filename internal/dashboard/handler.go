@@ -0,0 +1,58 @@
+package dashboard
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the dashboard endpoint
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new dashboard handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Get - GET /dashboard
+// Temple staff see their temple; a superadmin sees all temples, or one with
+// ?entity_id=.
+func (h *Handler) Get(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+
+	var entityID *uint
+	if access.RoleName == middleware.RoleSuperAdmin {
+		if v := c.Query("entity_id"); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil || id == 0 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity_id"})
+				return
+			}
+			eid := uint(id)
+			entityID = &eid
+		}
+	} else {
+		entityID = access.GetAccessibleEntityID()
+		if entityID == nil {
+			c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+			return
+		}
+	}
+
+	d, err := h.Service.Get(c.Request.Context(), access.UserID, entityID)
+	if err != nil {
+		log.Printf("Dashboard error for user %d: %v", access.UserID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dashboard"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": d})
+}
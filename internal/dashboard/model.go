@@ -0,0 +1,66 @@
+package dashboard
+
+import "time"
+
+// Dashboard is everything the temple admin home screen shows, in one response
+type Dashboard struct {
+	EntityID            *uint                `json:"entity_id"` // nil when a superadmin looks at all temples
+	TodaysBookings      BookingCounts        `json:"todays_bookings"`
+	PendingApprovals    PendingApprovals     `json:"pending_approvals"`
+	Donations           DonationTotals       `json:"donations"`
+	UpcomingEvents      []UpcomingEvent      `json:"upcoming_events"`
+	RecentNotifications []RecentNotification `json:"recent_notifications"`
+	DevoteeGrowth       []DevoteeGrowthPoint `json:"devotee_growth"`
+	GeneratedAt         time.Time            `json:"generated_at"`
+}
+
+// BookingCounts are the seva bookings made today by status
+type BookingCounts struct {
+	Total    int64 `json:"total"`
+	Approved int64 `json:"approved"`
+	Pending  int64 `json:"pending"`
+}
+
+// PendingApprovals counts what is waiting on a temple admin
+type PendingApprovals struct {
+	Total          int64 `json:"total"`
+	Bookings       int64 `json:"bookings"`        // seva bookings awaiting approval
+	RefundRequests int64 `json:"refund_requests"` // refunds of cancelled bookings
+	Expenses       int64 `json:"expenses"`
+	HundiCounts    int64 `json:"hundi_counts"`
+}
+
+// DonationTotals are successful donations in the current day, week and month
+type DonationTotals struct {
+	Today     float64 `json:"today"`
+	ThisWeek  float64 `json:"this_week"`
+	ThisMonth float64 `json:"this_month"`
+	Count     int64   `json:"count_this_month"`
+}
+
+// UpcomingEvent is one of the next active events
+type UpcomingEvent struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	EventType string    `json:"event_type"`
+	EventDate time.Time `json:"event_date"`
+	Location  string    `json:"location"`
+	RSVPs     int64     `json:"rsvps"` // attending
+}
+
+// RecentNotification is one of the user's latest in-app notifications
+type RecentNotification struct {
+	ID        uint      `json:"id"`
+	Title     string    `json:"title"`
+	Message   string    `json:"message"`
+	Category  string    `json:"category"`
+	IsRead    bool      `json:"is_read"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DevoteeGrowthPoint is the devotees who joined in a month and the running total
+type DevoteeGrowthPoint struct {
+	Month string `json:"month"` // 2006-01
+	New   int64  `json:"new"`
+	Total int64  `json:"total"`
+}
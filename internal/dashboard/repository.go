@@ -0,0 +1,126 @@
+package dashboard
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repository aggregates the dashboard figures. A nil entity ID covers all
+// temples.
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new dashboard repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// forEntity limits query to the temple, unless entityID is nil
+func forEntity(query *gorm.DB, column string, entityID *uint) *gorm.DB {
+	if entityID == nil {
+		return query
+	}
+	return query.Where(column+" = ?", *entityID)
+}
+
+func (r *Repository) TodaysBookings(ctx context.Context, entityID *uint) (BookingCounts, error) {
+	var out BookingCounts
+	err := forEntity(r.DB.WithContext(ctx).Table("seva_bookings"), "entity_id", entityID).
+		Select(`COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE status = 'approved') AS approved,
+			COUNT(*) FILTER (WHERE status = 'pending') AS pending`).
+		Where("DATE(booking_time) = CURRENT_DATE").
+		Scan(&out).Error
+	return out, err
+}
+
+func (r *Repository) PendingApprovals(ctx context.Context, entityID *uint) (PendingApprovals, error) {
+	var out PendingApprovals
+	db := r.DB.WithContext(ctx)
+
+	err := forEntity(db.Table("seva_bookings"), "entity_id", entityID).
+		Select(`COUNT(*) FILTER (WHERE status = 'pending') AS bookings,
+			COUNT(*) FILTER (WHERE refund_status = 'requested') AS refund_requests`).
+		Scan(&out).Error
+	if err != nil {
+		return out, err
+	}
+	err = forEntity(db.Table("temple_expenses"), "entity_id", entityID).
+		Where("status = ? AND deleted_at IS NULL", "pending").
+		Count(&out.Expenses).Error
+	if err != nil {
+		return out, err
+	}
+	err = forEntity(db.Table("hundi_counting_sessions"), "entity_id", entityID).
+		Where("status = ? AND deleted_at IS NULL", "pending").
+		Count(&out.HundiCounts).Error
+	out.Total = out.Bookings + out.RefundRequests + out.Expenses + out.HundiCounts
+	return out, err
+}
+
+func (r *Repository) DonationTotals(ctx context.Context, entityID *uint) (DonationTotals, error) {
+	var out DonationTotals
+	err := forEntity(r.DB.WithContext(ctx).Table("donations"), "entity_id", entityID).
+		Select(`COALESCE(SUM(amount) FILTER (WHERE COALESCE(donated_at, created_at) >= CURRENT_DATE), 0) AS today,
+			COALESCE(SUM(amount) FILTER (WHERE COALESCE(donated_at, created_at) >= DATE_TRUNC('week', NOW())), 0) AS this_week,
+			COALESCE(SUM(amount), 0) AS this_month,
+			COUNT(*) AS count`).
+		Where("status = ? AND COALESCE(donated_at, created_at) >= DATE_TRUNC('month', NOW())", "SUCCESS").
+		Scan(&out).Error
+	return out, err
+}
+
+// UpcomingEvents returns the next active events with their attending RSVPs
+func (r *Repository) UpcomingEvents(ctx context.Context, entityID *uint, limit int) ([]UpcomingEvent, error) {
+	var out []UpcomingEvent
+	err := forEntity(r.DB.WithContext(ctx).Table("events e"), "e.entity_id", entityID).
+		Select(`e.id, e.title, e.event_type, e.event_date, e.location,
+			(SELECT COUNT(*) FROM rsvps r WHERE r.event_id = e.id AND r.status = 'attending') AS rsvps`).
+		Where("e.is_active = ? AND e.event_date >= CURRENT_DATE", true).
+		Order("e.event_date ASC, e.id ASC").
+		Limit(limit).
+		Scan(&out).Error
+	return out, err
+}
+
+// RecentNotifications returns the user's latest in-app notifications
+func (r *Repository) RecentNotifications(ctx context.Context, userID uint, entityID *uint, limit int) ([]RecentNotification, error) {
+	var out []RecentNotification
+	err := forEntity(r.DB.WithContext(ctx).Table("in_app_notifications"), "entity_id", entityID).
+		Select("id, title, message, category, is_read, created_at").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Scan(&out).Error
+	return out, err
+}
+
+// DevoteeGrowth returns the devotees who joined in each of the last months,
+// oldest first, with the running total at the end of each month
+func (r *Repository) DevoteeGrowth(ctx context.Context, entityID *uint, months int) ([]DevoteeGrowthPoint, error) {
+	scope, args := "", []interface{}{months - 1}
+	if entityID != nil {
+		scope = "AND uem.entity_id = ?"
+		args = append(args, *entityID)
+	}
+	query := `
+		WITH months AS (
+			SELECT generate_series(DATE_TRUNC('month', NOW()) - (? * INTERVAL '1 month'), DATE_TRUNC('month', NOW()), INTERVAL '1 month') AS month
+		), joined AS (
+			SELECT uem.created_at
+			FROM user_entity_memberships uem
+			JOIN users u ON u.id = uem.user_id
+			JOIN user_roles ur ON ur.id = u.role_id
+			WHERE ur.role_name = 'devotee' ` + scope + `
+		)
+		SELECT TO_CHAR(m.month, 'YYYY-MM') AS month,
+			(SELECT COUNT(*) FROM joined j WHERE j.created_at >= m.month AND j.created_at < m.month + INTERVAL '1 month') AS new,
+			(SELECT COUNT(*) FROM joined j WHERE j.created_at < m.month + INTERVAL '1 month') AS total
+		FROM months m
+		ORDER BY m.month`
+	var out []DevoteeGrowthPoint
+	err := r.DB.WithContext(ctx).Raw(query, args...).Scan(&out).Error
+	return out, err
+}
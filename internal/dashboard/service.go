@@ -0,0 +1,61 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	upcomingEventsLimit      = 5
+	recentNotificationsLimit = 5
+	devoteeGrowthMonths      = 6
+)
+
+// Service assembles the home screen dashboard
+type Service struct {
+	Repo *Repository
+}
+
+// NewService initializes the dashboard service
+func NewService(repo *Repository) *Service {
+	return &Service{Repo: repo}
+}
+
+// Get returns the dashboard of a temple, or of all temples when entityID is
+// nil. Notifications are the user's own.
+func (s *Service) Get(ctx context.Context, userID uint, entityID *uint) (*Dashboard, error) {
+	d := &Dashboard{EntityID: entityID, GeneratedAt: time.Now()}
+	var err error
+
+	if d.TodaysBookings, err = s.Repo.TodaysBookings(ctx, entityID); err != nil {
+		return nil, fmt.Errorf("failed to count today's bookings: %w", err)
+	}
+	if d.PendingApprovals, err = s.Repo.PendingApprovals(ctx, entityID); err != nil {
+		return nil, fmt.Errorf("failed to count pending approvals: %w", err)
+	}
+	if d.Donations, err = s.Repo.DonationTotals(ctx, entityID); err != nil {
+		return nil, fmt.Errorf("failed to total donations: %w", err)
+	}
+	if d.UpcomingEvents, err = s.Repo.UpcomingEvents(ctx, entityID, upcomingEventsLimit); err != nil {
+		return nil, fmt.Errorf("failed to load upcoming events: %w", err)
+	}
+	if d.RecentNotifications, err = s.Repo.RecentNotifications(ctx, userID, entityID, recentNotificationsLimit); err != nil {
+		return nil, fmt.Errorf("failed to load notifications: %w", err)
+	}
+	if d.DevoteeGrowth, err = s.Repo.DevoteeGrowth(ctx, entityID, devoteeGrowthMonths); err != nil {
+		return nil, fmt.Errorf("failed to load devotee growth: %w", err)
+	}
+
+	// empty lists rather than null for the frontend
+	if d.UpcomingEvents == nil {
+		d.UpcomingEvents = []UpcomingEvent{}
+	}
+	if d.RecentNotifications == nil {
+		d.RecentNotifications = []RecentNotification{}
+	}
+	if d.DevoteeGrowth == nil {
+		d.DevoteeGrowth = []DevoteeGrowthPoint{}
+	}
+	return d, nil
+}
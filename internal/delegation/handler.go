@@ -0,0 +1,176 @@
+package delegation
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// Handler exposes temple staff roles: temple admins invite and manage staff,
+// invited devotees and volunteers accept and list their roles
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new delegation handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListRoles - GET /staff/roles
+func (h *Handler) ListRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": Roles})
+}
+
+// Invite - POST /entities/:id/staff/invitations {"email": "...", "role": "event_manager"}
+func (h *Handler) Invite(c *gin.Context) {
+	access, entityID, ok := entityRequest(c)
+	if !ok {
+		return
+	}
+	var req InviteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	inv, err := h.Service.Invite(c.Request.Context(), access, entityID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to send the invitation")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Invitation sent", "data": inv})
+}
+
+// ListInvitations - GET /entities/:id/staff/invitations?status=pending
+func (h *Handler) ListInvitations(c *gin.Context) {
+	access, entityID, ok := entityRequest(c)
+	if !ok {
+		return
+	}
+	invitations, err := h.Service.ListInvitations(c.Request.Context(), access, entityID, c.Query("status"))
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch invitations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invitations})
+}
+
+// RevokeInvitation - DELETE /entities/:id/staff/invitations/:invitationId
+func (h *Handler) RevokeInvitation(c *gin.Context) {
+	access, entityID, ok := entityRequest(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("invitationId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invitation ID"})
+		return
+	}
+	if err := h.Service.RevokeInvitation(c.Request.Context(), access, entityID, uint(id), middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to revoke the invitation")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation revoked"})
+}
+
+// ListStaff - GET /entities/:id/staff
+func (h *Handler) ListStaff(c *gin.Context) {
+	access, entityID, ok := entityRequest(c)
+	if !ok {
+		return
+	}
+	staff, err := h.Service.ListStaff(c.Request.Context(), access, entityID)
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch staff")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": staff})
+}
+
+// RevokeStaff - DELETE /entities/:id/staff/:assignmentId
+func (h *Handler) RevokeStaff(c *gin.Context) {
+	access, entityID, ok := entityRequest(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("assignmentId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID"})
+		return
+	}
+	if err := h.Service.RevokeStaff(c.Request.Context(), access, entityID, uint(id), middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to revoke the staff role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Staff role revoked"})
+}
+
+// Accept - POST /staff/invitations/accept {"token": "..."}
+func (h *Handler) Accept(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req AcceptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "token is required"})
+		return
+	}
+	assignment, err := h.Service.Accept(c.Request.Context(), access.UserID, req.Token, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to accept the invitation")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Invitation accepted", "data": assignment})
+}
+
+// MyRoles - GET /staff/me
+func (h *Handler) MyRoles(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	roles, err := h.Service.MyRoles(c.Request.Context(), access.UserID)
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch your staff roles")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": roles})
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusForbidden:  {ErrNotTempleAdmin, ErrWrongAccount, ErrInviteeNotEligible},
+		http.StatusBadRequest: {ErrUnknownRole},
+		http.StatusGone:       {ErrInvitationExpired},
+		http.StatusConflict:   {ErrInvitationClosed},
+	},
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, fallback string) {
+	if errors.Is(err, ErrInvitationInvalid) {
+		// An unknown token reads the same as a missing invitation
+		err = gorm.ErrRecordNotFound
+	}
+	httpErrors.Write(c, err, fallback)
+}
+
+// entityRequest reads the access context and the temple ID of the path
+func entityRequest(c *gin.Context) (middleware.AccessContext, uint, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return access, 0, false
+	}
+	return access, uint(id), true
+}
@@ -0,0 +1,131 @@
+package delegation
+
+import (
+	"time"
+)
+
+// Areas of a temple a staff role can be given. Routes name the area they
+// belong to when they enable delegation (middleware.Delegated).
+const (
+	PermissionEvents    = "events"
+	PermissionDonations = "donations" // donations and fundraising campaigns
+	PermissionExpenses  = "expenses"
+	PermissionSevas     = "sevas"
+)
+
+// Staff roles a temple admin can give within their temple
+const (
+	RoleFinanceOperator = "finance_operator"
+	RoleEventManager    = "event_manager"
+	RoleSevaManager     = "seva_manager"
+)
+
+// Role describes a staff role for the temple admin choosing one
+type Role struct {
+	Name        string   `json:"name"`
+	Label       string   `json:"label"`
+	Permissions []string `json:"permissions"`
+}
+
+// Roles are the staff roles that exist, with the areas each may manage
+var Roles = []Role{
+	{Name: RoleFinanceOperator, Label: "Finance operator", Permissions: []string{PermissionDonations, PermissionExpenses}},
+	{Name: RoleEventManager, Label: "Event manager", Permissions: []string{PermissionEvents}},
+	{Name: RoleSevaManager, Label: "Seva manager", Permissions: []string{PermissionSevas}},
+}
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired" // reported for pending invitations past their expiry
+)
+
+// Assignment gives a user a staff role at one temple. A user holds at most
+// one role per temple; revoking the role deletes the row.
+type Assignment struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	UserID       uint      `gorm:"not null;uniqueIndex:idx_entity_role_assignment" json:"user_id"`
+	EntityID     uint      `gorm:"not null;uniqueIndex:idx_entity_role_assignment;index" json:"entity_id"`
+	Role         string    `gorm:"size:30;not null" json:"role"`
+	GrantedBy    uint      `gorm:"not null" json:"granted_by"`
+	InvitationID *uint     `json:"invitation_id,omitempty"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Assignment model
+func (Assignment) TableName() string {
+	return "entity_role_assignments"
+}
+
+// Invitation asks someone by email to take a staff role at a temple. Only a
+// hash of the emailed token is stored.
+type Invitation struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	EntityID   uint       `gorm:"not null;index" json:"entity_id"`
+	Email      string     `gorm:"size:255;not null;index" json:"email"`
+	Role       string     `gorm:"size:30;not null" json:"role"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Status     string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	InvitedBy  uint       `gorm:"not null" json:"invited_by"`
+	AcceptedBy *uint      `json:"accepted_by,omitempty"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt  time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Invitation model
+func (Invitation) TableName() string {
+	return "staff_invitations"
+}
+
+// InviteRequest invites someone to a staff role
+type InviteRequest struct {
+	Email string `json:"email" binding:"required,email"`
+	Role  string `json:"role" binding:"required"`
+}
+
+// AcceptRequest accepts an invitation with the emailed token
+type AcceptRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// StaffMember is an assignment with the user's name and email
+type StaffMember struct {
+	Assignment
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+}
+
+// MyRole is a staff role the caller holds, with the temple's name
+type MyRole struct {
+	Assignment
+	TempleName  string   `json:"temple_name"`
+	Permissions []string `json:"permissions" gorm:"-"`
+}
+
+// findRole returns the staff role with the given name
+func findRole(name string) (Role, bool) {
+	for _, r := range Roles {
+		if r.Name == name {
+			return r, true
+		}
+	}
+	return Role{}, false
+}
+
+// rolesWith returns the names of the roles that may manage an area
+func rolesWith(permission string) []string {
+	var names []string
+	for _, r := range Roles {
+		for _, p := range r.Permissions {
+			if p == permission {
+				names = append(names, r.Name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,222 @@
+package delegation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository stores staff invitations and role assignments
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new delegation repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// account is the part of a user the invitation flow needs
+type account struct {
+	ID       uint
+	FullName string
+	Email    string
+	RoleName string
+}
+
+// GetAccount returns a user's name, email and role
+func (r *Repository) GetAccount(ctx context.Context, userID uint) (*account, error) {
+	var a account
+	err := r.DB.WithContext(ctx).Table("users").
+		Select("users.id, users.full_name, users.email, user_roles.role_name").
+		Joins("JOIN user_roles ON user_roles.id = users.role_id").
+		Where("users.id = ? AND users.deleted_at IS NULL", userID).
+		Take(&a).Error
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// FindAccountByEmail returns the user registered with the email, nil if none
+func (r *Repository) FindAccountByEmail(ctx context.Context, email string) (*account, error) {
+	var a account
+	err := r.DB.WithContext(ctx).Table("users").
+		Select("users.id, users.full_name, users.email, user_roles.role_name").
+		Joins("JOIN user_roles ON user_roles.id = users.role_id").
+		Where("LOWER(users.email) = ? AND users.deleted_at IS NULL", email).
+		Take(&a).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetTenantID returns the templeadmin that created the temple
+func (r *Repository) GetTenantID(ctx context.Context, entityID uint) (uint, error) {
+	var tenantID uint
+	err := r.DB.WithContext(ctx).Table("entities").
+		Select("created_by").
+		Where("id = ?", entityID).
+		Take(&tenantID).Error
+	return tenantID, err
+}
+
+// CreateInvitation saves a new invitation
+func (r *Repository) CreateInvitation(ctx context.Context, inv *Invitation) error {
+	return r.DB.WithContext(ctx).Create(inv).Error
+}
+
+// GetInvitation returns an invitation of the temple
+func (r *Repository) GetInvitation(ctx context.Context, entityID, id uint) (*Invitation, error) {
+	var inv Invitation
+	if err := r.DB.WithContext(ctx).Where("id = ? AND entity_id = ?", id, entityID).Take(&inv).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// GetInvitationByTokenHash returns the invitation the token was issued for
+func (r *Repository) GetInvitationByTokenHash(ctx context.Context, hash string) (*Invitation, error) {
+	var inv Invitation
+	if err := r.DB.WithContext(ctx).Where("token_hash = ?", hash).Take(&inv).Error; err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ListInvitations returns the temple's invitations, newest first
+func (r *Repository) ListInvitations(ctx context.Context, entityID uint, status string) ([]Invitation, error) {
+	out := []Invitation{}
+	query := r.DB.WithContext(ctx).Where("entity_id = ?", entityID)
+	switch status {
+	case "":
+	case InvitationPending:
+		query = query.Where("status = ? AND expires_at > ?", InvitationPending, time.Now())
+	case InvitationExpired:
+		query = query.Where("status = ? AND expires_at <= ?", InvitationPending, time.Now())
+	default:
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+// RevokePendingInvitations revokes the temple's open invitations of an email
+func (r *Repository) RevokePendingInvitations(ctx context.Context, entityID uint, email string) error {
+	return r.DB.WithContext(ctx).Model(&Invitation{}).
+		Where("entity_id = ? AND email = ? AND status = ?", entityID, email, InvitationPending).
+		Update("status", InvitationRevoked).Error
+}
+
+// SetInvitationStatus moves a pending invitation to another status. It returns
+// gorm.ErrRecordNotFound when the invitation is no longer pending.
+func (r *Repository) SetInvitationStatus(ctx context.Context, id uint, updates map[string]interface{}) error {
+	res := r.DB.WithContext(ctx).Model(&Invitation{}).
+		Where("id = ? AND status = ?", id, InvitationPending).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// AcceptInvitation marks the invitation accepted and gives the user its role,
+// replacing any role they held at the temple
+func (r *Repository) AcceptInvitation(ctx context.Context, inv *Invitation, userID uint) (*Assignment, error) {
+	now := time.Now()
+	a := &Assignment{
+		UserID:       userID,
+		EntityID:     inv.EntityID,
+		Role:         inv.Role,
+		GrantedBy:    inv.InvitedBy,
+		InvitationID: &inv.ID,
+	}
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		txRepo := &Repository{DB: tx}
+		if err := txRepo.SetInvitationStatus(ctx, inv.ID, map[string]interface{}{
+			"status":      InvitationAccepted,
+			"accepted_by": userID,
+			"accepted_at": now,
+		}); err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "entity_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"role", "granted_by", "invitation_id", "updated_at"}),
+		}).Create(a).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ListStaff returns the temple's role assignments with the users' names
+func (r *Repository) ListStaff(ctx context.Context, entityID uint) ([]StaffMember, error) {
+	out := []StaffMember{}
+	err := r.DB.WithContext(ctx).Table("entity_role_assignments").
+		Select("entity_role_assignments.*, users.full_name, users.email").
+		Joins("JOIN users ON users.id = entity_role_assignments.user_id").
+		Where("entity_role_assignments.entity_id = ?", entityID).
+		Order("entity_role_assignments.created_at ASC, entity_role_assignments.id ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// GetAssignment returns a role assignment of the temple
+func (r *Repository) GetAssignment(ctx context.Context, entityID, id uint) (*Assignment, error) {
+	var a Assignment
+	if err := r.DB.WithContext(ctx).Where("id = ? AND entity_id = ?", id, entityID).Take(&a).Error; err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// DeleteAssignment removes a role assignment
+func (r *Repository) DeleteAssignment(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Delete(&Assignment{}, id).Error
+}
+
+// ListUserRoles returns the staff roles a user holds at active temples
+func (r *Repository) ListUserRoles(ctx context.Context, userID uint) ([]MyRole, error) {
+	out := []MyRole{}
+	err := r.DB.WithContext(ctx).Table("entity_role_assignments").
+		Select("entity_role_assignments.*, entities.name AS temple_name").
+		Joins("JOIN entities ON entities.id = entity_role_assignments.entity_id").
+		Where("entity_role_assignments.user_id = ? AND entities.isactive = true", userID).
+		Order("entities.name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// grantRow is an assignment with the owner of its temple
+type grantRow struct {
+	EntityID  uint
+	Role      string
+	CreatedBy uint
+}
+
+// FindGrants returns the user's assignments with one of the roles, at the
+// given temple or at any active temple
+func (r *Repository) FindGrants(ctx context.Context, userID uint, entityID *uint, roles []string) ([]grantRow, error) {
+	var rows []grantRow
+	query := r.DB.WithContext(ctx).Table("entity_role_assignments").
+		Select("entity_role_assignments.entity_id, entity_role_assignments.role, entities.created_by").
+		Joins("JOIN entities ON entities.id = entity_role_assignments.entity_id").
+		Where("entity_role_assignments.user_id = ? AND entity_role_assignments.role IN ? AND entities.isactive = true", userID, roles)
+	if entityID != nil {
+		query = query.Where("entity_role_assignments.entity_id = ?", *entityID)
+	}
+	err := query.Limit(2).Scan(&rows).Error
+	return rows, err
+}
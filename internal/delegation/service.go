@@ -0,0 +1,305 @@
+package delegation
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// invitationTTL is how long an emailed invitation can be accepted
+const invitationTTL = 7 * 24 * time.Hour
+
+var (
+	ErrNotTempleAdmin     = errors.New("only the temple's admin can manage its staff")
+	ErrUnknownRole        = errors.New("role must be one of finance_operator, event_manager, seva_manager")
+	ErrInviteeNotEligible = errors.New("only devotee and volunteer accounts can be made temple staff")
+	ErrInvitationInvalid  = errors.New("invitation not found")
+	ErrInvitationExpired  = errors.New("this invitation has expired; ask the temple admin for a new one")
+	ErrInvitationClosed   = errors.New("this invitation has already been accepted or revoked")
+	ErrWrongAccount       = errors.New("this invitation was sent to another email address")
+)
+
+// Service lets temple admins give devotees and volunteers limited staff roles
+// at their temple, by emailed invitation
+type Service struct {
+	Repo        *Repository
+	Audit       auditlog.Service
+	Mailer      *notification.Mailer // emails invitations; nil leaves them to be shared by hand
+	FrontendURL string               // base of the accept link
+}
+
+// NewService initializes the delegation service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc}
+}
+
+// checkManager allows superadmins and the templeadmin that owns the temple
+func (s *Service) checkManager(ctx context.Context, access middleware.AccessContext, entityID uint) error {
+	switch access.RoleName {
+	case middleware.RoleSuperAdmin:
+		return nil
+	case middleware.RoleTempleAdmin:
+		tenantID, err := s.Repo.GetTenantID(ctx, entityID)
+		if err != nil {
+			return err
+		}
+		if tenantID == access.TenantID {
+			return nil
+		}
+	}
+	return ErrNotTempleAdmin
+}
+
+// Invite emails someone an invitation to a staff role at the temple. Earlier
+// open invitations of the same email are revoked.
+func (s *Service) Invite(ctx context.Context, access middleware.AccessContext, entityID uint, req InviteRequest, ip string) (*Invitation, error) {
+	if err := s.checkManager(ctx, access, entityID); err != nil {
+		return nil, err
+	}
+	role, ok := findRole(req.Role)
+	if !ok {
+		return nil, ErrUnknownRole
+	}
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	if existing, err := s.Repo.FindAccountByEmail(ctx, email); err != nil {
+		return nil, err
+	} else if existing != nil && !eligible(existing.RoleName) {
+		return nil, ErrInviteeNotEligible
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.RevokePendingInvitations(ctx, entityID, email); err != nil {
+		return nil, err
+	}
+	inv := &Invitation{
+		EntityID:  entityID,
+		Email:     email,
+		Role:      role.Name,
+		TokenHash: hashToken(token),
+		Status:    InvitationPending,
+		ExpiresAt: time.Now().Add(invitationTTL),
+		InvitedBy: access.UserID,
+	}
+	if err := s.Repo.CreateInvitation(ctx, inv); err != nil {
+		return nil, err
+	}
+
+	s.sendInvitation(ctx, access.UserID, inv, role, token)
+	s.Audit.LogAction(ctx, &access.UserID, &entityID, "STAFF_INVITED", map[string]interface{}{
+		"invitation_id": inv.ID,
+		"email":         email,
+		"role":          role.Name,
+	}, ip, "success")
+	return inv, nil
+}
+
+// sendInvitation emails the accept link. A failure is logged: the temple
+// admin can revoke the invitation and send a new one.
+func (s *Service) sendInvitation(ctx context.Context, inviterID uint, inv *Invitation, role Role, token string) {
+	if s.Mailer == nil {
+		return
+	}
+	data := map[string]interface{}{
+		"RoleLabel": role.Label,
+		"Abilities": abilities(role),
+		"ExpiresOn": inv.ExpiresAt.Format("02 Jan 2006"),
+	}
+	if inviter, err := s.Repo.GetAccount(ctx, inviterID); err == nil {
+		data["InvitedBy"] = inviter.FullName
+	}
+	if s.FrontendURL != "" {
+		data["AcceptURL"] = s.FrontendURL + "/staff/accept?token=" + url.QueryEscape(token)
+	}
+	if err := s.Mailer.Send(ctx, inv.EntityID, notification.EmailStaffInvitation, []string{inv.Email}, data); err != nil {
+		fmt.Printf("⚠️ Failed to email staff invitation %d: %v\n", inv.ID, err)
+	}
+}
+
+// ListInvitations returns the temple's invitations, optionally by status
+func (s *Service) ListInvitations(ctx context.Context, access middleware.AccessContext, entityID uint, status string) ([]Invitation, error) {
+	if err := s.checkManager(ctx, access, entityID); err != nil {
+		return nil, err
+	}
+	invitations, err := s.Repo.ListInvitations(ctx, entityID, status)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range invitations {
+		if invitations[i].Status == InvitationPending && !invitations[i].ExpiresAt.After(now) {
+			invitations[i].Status = InvitationExpired
+		}
+	}
+	return invitations, nil
+}
+
+// RevokeInvitation withdraws an invitation that was not accepted yet
+func (s *Service) RevokeInvitation(ctx context.Context, access middleware.AccessContext, entityID, id uint, ip string) error {
+	if err := s.checkManager(ctx, access, entityID); err != nil {
+		return err
+	}
+	inv, err := s.Repo.GetInvitation(ctx, entityID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.SetInvitationStatus(ctx, inv.ID, map[string]interface{}{"status": InvitationRevoked}); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvitationClosed
+		}
+		return err
+	}
+	s.Audit.LogAction(ctx, &access.UserID, &entityID, "STAFF_INVITATION_REVOKED", map[string]interface{}{
+		"invitation_id": inv.ID,
+		"email":         inv.Email,
+	}, ip, "success")
+	return nil
+}
+
+// Accept gives the signed-in user the role of the invitation. The user must
+// be signed in with the email the invitation was sent to.
+func (s *Service) Accept(ctx context.Context, userID uint, token, ip string) (*Assignment, error) {
+	inv, err := s.Repo.GetInvitationByTokenHash(ctx, hashToken(strings.TrimSpace(token)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	if inv.Status != InvitationPending {
+		return nil, ErrInvitationClosed
+	}
+	if !inv.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvitationExpired
+	}
+
+	user, err := s.Repo.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(strings.TrimSpace(user.Email), inv.Email) {
+		return nil, ErrWrongAccount
+	}
+	if !eligible(user.RoleName) {
+		return nil, ErrInviteeNotEligible
+	}
+
+	assignment, err := s.Repo.AcceptInvitation(ctx, inv, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInvitationClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &inv.EntityID, "STAFF_INVITATION_ACCEPTED", map[string]interface{}{
+		"invitation_id": inv.ID,
+		"role":          inv.Role,
+	}, ip, "success")
+	return assignment, nil
+}
+
+// ListStaff returns the temple's staff and their roles
+func (s *Service) ListStaff(ctx context.Context, access middleware.AccessContext, entityID uint) ([]StaffMember, error) {
+	if err := s.checkManager(ctx, access, entityID); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListStaff(ctx, entityID)
+}
+
+// RevokeStaff takes a staff role away; it applies from the user's next request
+func (s *Service) RevokeStaff(ctx context.Context, access middleware.AccessContext, entityID, id uint, ip string) error {
+	if err := s.checkManager(ctx, access, entityID); err != nil {
+		return err
+	}
+	a, err := s.Repo.GetAssignment(ctx, entityID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteAssignment(ctx, a.ID); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &access.UserID, &entityID, "STAFF_ROLE_REVOKED", map[string]interface{}{
+		"user_id": a.UserID,
+		"role":    a.Role,
+	}, ip, "success")
+	return nil
+}
+
+// MyRoles returns the staff roles the user holds and what each allows
+func (s *Service) MyRoles(ctx context.Context, userID uint) ([]MyRole, error) {
+	roles, err := s.Repo.ListUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range roles {
+		if role, ok := findRole(roles[i].Role); ok {
+			roles[i].Permissions = role.Permissions
+		}
+	}
+	return roles, nil
+}
+
+// DelegatedGrant implements middleware.DelegationChecker. Without a temple
+// in the request the user's role applies only if they hold it at one temple.
+func (s *Service) DelegatedGrant(ctx context.Context, userID uint, entityID *uint, permission string) (*middleware.DelegatedGrant, error) {
+	roles := rolesWith(permission)
+	if len(roles) == 0 {
+		return nil, nil
+	}
+	rows, err := s.Repo.FindGrants(ctx, userID, entityID, roles)
+	if err != nil || len(rows) != 1 {
+		return nil, err
+	}
+	return &middleware.DelegatedGrant{
+		EntityID: rows[0].EntityID,
+		TenantID: rows[0].CreatedBy,
+		Role:     rows[0].Role,
+	}, nil
+}
+
+// eligible reports whether accounts of the role can hold staff roles. Temple
+// admins and platform staff already have their own access.
+func eligible(roleName string) bool {
+	return roleName == middleware.RoleDevotee || roleName == middleware.RoleVolunteer
+}
+
+// abilities describes what a role allows, for the invitation email
+func abilities(role Role) string {
+	descriptions := map[string]string{
+		PermissionEvents:    "manage events",
+		PermissionDonations: "manage donations and campaigns",
+		PermissionExpenses:  "record expenses",
+		PermissionSevas:     "manage sevas and bookings",
+	}
+	parts := make([]string, 0, len(role.Permissions))
+	for _, p := range role.Permissions {
+		parts = append(parts, descriptions[p])
+	}
+	return strings.Join(parts, " and ")
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
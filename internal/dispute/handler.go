@@ -0,0 +1,323 @@
+package dispute
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+const maxEvidenceSize = 10 << 20 // 10 MB
+
+// Evidence documents accepted by the gateways
+var evidenceTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+}
+
+// Handler exposes the dispute endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new dispute handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Webhook - POST /payments/disputes/webhook
+// Public, authenticated by the Razorpay webhook signature
+func (h *Handler) Webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read request body"})
+		return
+	}
+
+	result, err := h.Service.HandleWebhook(donation.WebhookRequest{
+		Body:      body,
+		Signature: c.GetHeader("X-Razorpay-Signature"),
+		EventID:   c.GetHeader("X-Razorpay-Event-Id"),
+		IPAddress: middleware.GetIPFromContext(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, donation.ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, donation.ErrWebhookNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			// Non-2xx makes Razorpay retry the delivery
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result,
+		"success": true,
+	})
+}
+
+// List - GET /disputes?status=open&page=1&limit=20
+func (h *Handler) List(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != StatusOpen && status != StatusResponded && status != StatusWon && status != StatusLost {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be open, responded, won or lost"})
+		return
+	}
+
+	ids, err := h.Service.EntityScope(c.Request.Context(), access)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve temples"})
+		return
+	}
+
+	f := Filter{
+		EntityIDs: ids,
+		Status:    status,
+		Page:      httpx.PositiveQuery(c, "page", 1),
+		Limit:     min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	items, total, err := h.Service.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch disputes"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// Get - GET /disputes/:id
+func (h *Handler) Get(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	d, ok := h.loadDispute(c, access)
+	if !ok {
+		return
+	}
+
+	details, err := h.Service.Get(c.Request.Context(), d.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dispute"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": details})
+}
+
+// UploadEvidence - POST /disputes/:id/evidence (multipart: file, note)
+func (h *Handler) UploadEvidence(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	d, ok := h.loadDispute(c, access)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxEvidenceSize+1<<20)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "dispute_evidence")
+	if fileHeader.Size > maxEvidenceSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "evidence files must be 10MB or smaller"})
+		return
+	}
+	name := filepath.Base(fileHeader.Filename)
+	contentType, allowed := evidenceTypes[strings.ToLower(filepath.Ext(name))]
+	if !allowed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "evidence must be a PDF, JPG or PNG file"})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read file"})
+		return
+	}
+	defer file.Close()
+
+	evidence, err := h.Service.AddEvidence(c.Request.Context(), d, name, contentType, fileHeader.Size, file,
+		strings.TrimSpace(c.PostForm("note")), access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to upload evidence")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Evidence uploaded", "data": evidence})
+}
+
+// DownloadEvidence - GET /disputes/:id/evidence/:evidenceId
+func (h *Handler) DownloadEvidence(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	d, ok := h.loadDispute(c, access)
+	if !ok {
+		return
+	}
+	evidenceID, err := strconv.ParseUint(c.Param("evidenceId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid evidence ID"})
+		return
+	}
+
+	evidence, rc, err := h.Service.OpenEvidence(c.Request.Context(), d.ID, uint(evidenceID))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "evidence not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to open evidence"})
+		return
+	}
+	defer rc.Close()
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", evidence.FileName))
+	c.Header("Content-Type", evidence.ContentType)
+	c.Status(http.StatusOK)
+	_, _ = io.Copy(c.Writer, rc)
+}
+
+// Respond - POST /disputes/:id/respond
+func (h *Handler) Respond(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	d, ok := h.loadDispute(c, access)
+	if !ok {
+		return
+	}
+
+	var req RespondRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "note is required"})
+		return
+	}
+
+	if err := h.Service.Respond(c.Request.Context(), d, strings.TrimSpace(req.Note), access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to respond to dispute")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Dispute marked as responded"})
+}
+
+// UpdateStatus - PATCH /disputes/:id/status (superadmin)
+// Records a won or lost outcome the gateway webhook did not deliver
+func (h *Handler) UpdateStatus(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	d, ok := h.loadDispute(c, access)
+	if !ok {
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status is required"})
+		return
+	}
+
+	if err := h.Service.SetOutcome(c.Request.Context(), d, req.Status, strings.TrimSpace(req.Note), access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update dispute")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Dispute marked as " + req.Status})
+}
+
+// LinkBooking - PUT /disputes/:id/booking
+func (h *Handler) LinkBooking(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	d, ok := h.loadDispute(c, access)
+	if !ok {
+		return
+	}
+
+	var req LinkBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "booking_id is required"})
+		return
+	}
+
+	if err := h.Service.LinkBooking(c.Request.Context(), d, req.BookingID, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "booking not found"})
+			return
+		}
+		httpErrors.Write(c, err, "Failed to link booking")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Booking linked to dispute"})
+}
+
+// loadDispute loads the :id dispute and checks the caller may access it
+func (h *Handler) loadDispute(c *gin.Context, access middleware.AccessContext) (*Dispute, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid dispute ID"})
+		return nil, false
+	}
+
+	d, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "dispute not found"})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch dispute"})
+		return nil, false
+	}
+
+	allowed, err := h.Service.CanAccess(c.Request.Context(), access, d)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve temples"})
+		return nil, false
+	}
+	if !allowed {
+		// Same as a missing dispute so IDs of other tenants are not revealed
+		c.JSON(http.StatusNotFound, gin.H{"error": "dispute not found"})
+		return nil, false
+	}
+	return d, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	Statuses: map[int][]error{
+		http.StatusConflict:   {ErrInvalidTransition, ErrNoEvidence},
+		http.StatusBadRequest: {ErrBookingMismatch},
+	},
+}
@@ -0,0 +1,140 @@
+package dispute
+
+import (
+	"time"
+)
+
+// Dispute statuses. Disputes open when the gateway reports a chargeback, move
+// to responded once the temple submits evidence and end as won or lost.
+const (
+	StatusOpen      = "open"
+	StatusResponded = "responded"
+	StatusWon       = "won"
+	StatusLost      = "lost"
+)
+
+// Sources of a dispute status change
+const (
+	SourceWebhook = "webhook"
+	SourceManual  = "manual"
+)
+
+// Dispute is a chargeback or payment dispute raised against a donation
+type Dispute struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	GatewayDisputeID string `gorm:"size:100;uniqueIndex;not null" json:"gateway_dispute_id"` // Razorpay dispute ID (disp_...)
+	PaymentID        string `gorm:"size:100;index;not null" json:"payment_id"`
+
+	// Linkage to what was paid for; EntityID is 0 when the payment matches no donation
+	EntityID   uint  `gorm:"not null;default:0;index" json:"entity_id"`
+	DonationID *uint `gorm:"index" json:"donation_id,omitempty"`
+	BookingID  *uint `gorm:"index" json:"booking_id,omitempty"`
+
+	Amount            float64 `gorm:"type:decimal(10,2);not null" json:"amount"`           // Disputed amount in INR
+	AmountDeducted    float64 `gorm:"type:decimal(10,2);default:0" json:"amount_deducted"` // Held by the gateway
+	ReasonCode        string  `gorm:"size:100" json:"reason_code"`
+	ReasonDescription string  `gorm:"type:text" json:"reason_description"`
+	Phase             string  `gorm:"size:50" json:"phase"` // chargeback, pre_arbitration, arbitration, fraud, retrieval
+	GatewayStatus     string  `gorm:"size:50" json:"gateway_status"`
+
+	Status       string     `gorm:"size:20;not null;default:'open';index" json:"status"`
+	RespondBy    *time.Time `json:"respond_by,omitempty"` // Evidence deadline set by the gateway
+	ResponseNote string     `gorm:"type:text" json:"response_note,omitempty"`
+	RespondedAt  *time.Time `json:"responded_at,omitempty"`
+	RespondedBy  *uint      `json:"responded_by,omitempty"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+
+	Sandbox bool `gorm:"default:false;index" json:"sandbox"` // Raised by the Razorpay test mode account
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Dispute model
+func (Dispute) TableName() string {
+	return "payment_disputes"
+}
+
+// Evidence is a document uploaded in response to a dispute
+type Evidence struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	DisputeID   uint   `gorm:"not null;index" json:"dispute_id"`
+	FileKey     string `gorm:"size:255;not null" json:"-"`
+	FileName    string `gorm:"size:255;not null" json:"file_name"`
+	ContentType string `gorm:"size:100" json:"content_type"`
+	FileSize    int64  `json:"file_size"`
+	Note        string `gorm:"type:text" json:"note,omitempty"`
+	UploadedBy  uint   `gorm:"not null" json:"uploaded_by"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the Evidence model
+func (Evidence) TableName() string {
+	return "payment_dispute_evidence"
+}
+
+// Event is one entry of a dispute's timeline. Webhook deliveries are keyed
+// by their event ID so retries are recorded once.
+type Event struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	DisputeID  uint    `gorm:"not null;index" json:"dispute_id"`
+	EventID    *string `gorm:"size:100;uniqueIndex" json:"event_id,omitempty"` // X-Razorpay-Event-Id, nil for manual changes
+	Event      string  `gorm:"size:50;not null" json:"event"`
+	Source     string  `gorm:"size:20;not null" json:"source"` // webhook or manual
+	FromStatus string  `gorm:"size:20" json:"from_status"`
+	ToStatus   string  `gorm:"size:20" json:"to_status"`
+	Note       string  `gorm:"type:text" json:"note,omitempty"`
+	CreatedBy  *uint   `json:"created_by,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the Event model
+func (Event) TableName() string {
+	return "payment_dispute_events"
+}
+
+// Details is a dispute with its evidence and timeline
+type Details struct {
+	Dispute
+	TempleName string     `json:"temple_name"`
+	DonorName  string     `json:"donor_name"`
+	Evidence   []Evidence `gorm:"-" json:"evidence"`
+	Events     []Event    `gorm:"-" json:"events"`
+}
+
+// ListItem is a dispute row of the disputes list
+type ListItem struct {
+	Dispute
+	TempleName    string `json:"temple_name"`
+	DonorName     string `json:"donor_name"`
+	EvidenceCount int    `json:"evidence_count"`
+}
+
+// Filter narrows the disputes list
+type Filter struct {
+	EntityIDs []uint // nil means every temple (superadmin)
+	Status    string
+	Page      int
+	Limit     int
+}
+
+// RespondRequest submits the temple's response to a dispute
+type RespondRequest struct {
+	Note string `json:"note" binding:"required"`
+}
+
+// UpdateStatusRequest records a dispute outcome by hand
+type UpdateStatusRequest struct {
+	Status string `json:"status" binding:"required"` // won or lost
+	Note   string `json:"note"`
+}
+
+// LinkBookingRequest links a dispute to the seva booking it paid for
+type LinkBookingRequest struct {
+	BookingID uint `json:"booking_id" binding:"required"`
+}
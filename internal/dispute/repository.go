@@ -0,0 +1,207 @@
+package dispute
+
+import (
+	"context"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateEvent is returned when a webhook event was already recorded
+var ErrDuplicateEvent = errors.New("dispute event already processed")
+
+// Repository reads and writes disputes, their evidence and timeline
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new dispute repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// PaymentDonation is the donation a disputed payment belongs to
+type PaymentDonation struct {
+	ID       uint
+	EntityID uint
+	Sandbox  bool
+}
+
+// FindDonationByPayment returns the donation paid with the gateway payment, or nil
+func (r *Repository) FindDonationByPayment(ctx context.Context, paymentID string) (*PaymentDonation, error) {
+	var d PaymentDonation
+	err := r.DB.WithContext(ctx).Table("donations").
+		Select("id, entity_id, sandbox").
+		Where("payment_id = ? AND deleted_at IS NULL", paymentID).
+		Take(&d).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetByGatewayID returns the dispute with the gateway dispute ID, or nil
+func (r *Repository) GetByGatewayID(ctx context.Context, gatewayID string) (*Dispute, error) {
+	var d Dispute
+	err := r.DB.WithContext(ctx).Where("gateway_dispute_id = ?", gatewayID).First(&d).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// GetByID loads a dispute
+func (r *Repository) GetByID(ctx context.Context, id uint) (*Dispute, error) {
+	var d Dispute
+	if err := r.DB.WithContext(ctx).First(&d, id).Error; err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// ApplyWebhookEvent records the event and, unless it was seen before, creates
+// or updates the dispute in the same transaction
+func (r *Repository) ApplyWebhookEvent(ctx context.Context, d *Dispute, event *Event) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if event.EventID != nil {
+			var seen int64
+			if err := tx.Model(&Event{}).Where("event_id = ?", *event.EventID).Count(&seen).Error; err != nil {
+				return err
+			}
+			if seen > 0 {
+				return ErrDuplicateEvent
+			}
+		}
+
+		if d.ID == 0 {
+			if err := tx.Create(d).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Save(d).Error; err != nil {
+			return err
+		}
+
+		event.DisputeID = d.ID
+		return tx.Create(event).Error
+	})
+}
+
+// UpdateWithEvent saves the dispute changes and appends the timeline entry
+func (r *Repository) UpdateWithEvent(ctx context.Context, id uint, updates map[string]interface{}, event *Event) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Dispute{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		event.DisputeID = id
+		return tx.Create(event).Error
+	})
+}
+
+// List returns a page of disputes, newest first, and the total count
+func (r *Repository) List(ctx context.Context, f Filter) ([]ListItem, int64, error) {
+	query := r.DB.WithContext(ctx).Table("payment_disputes pd").
+		Joins("LEFT JOIN entities e ON e.id = pd.entity_id").
+		Joins("LEFT JOIN donations d ON d.id = pd.donation_id").
+		Joins("LEFT JOIN users u ON u.id = d.user_id")
+	if f.EntityIDs != nil {
+		query = query.Where("pd.entity_id IN ?", f.EntityIDs)
+	}
+	if f.Status != "" {
+		query = query.Where("pd.status = ?", f.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var items []ListItem
+	err := query.
+		Select(`pd.*, COALESCE(e.name, '') AS temple_name, COALESCE(u.full_name, '') AS donor_name,
+			(SELECT COUNT(*) FROM payment_dispute_evidence ev WHERE ev.dispute_id = pd.id) AS evidence_count`).
+		Order("pd.created_at DESC").
+		Limit(f.Limit).
+		Offset((f.Page - 1) * f.Limit).
+		Scan(&items).Error
+	return items, total, err
+}
+
+// GetDetails loads a dispute with its evidence and timeline
+func (r *Repository) GetDetails(ctx context.Context, id uint) (*Details, error) {
+	var details Details
+	err := r.DB.WithContext(ctx).Table("payment_disputes pd").
+		Select("pd.*, COALESCE(e.name, '') AS temple_name, COALESCE(u.full_name, '') AS donor_name").
+		Joins("LEFT JOIN entities e ON e.id = pd.entity_id").
+		Joins("LEFT JOIN donations d ON d.id = pd.donation_id").
+		Joins("LEFT JOIN users u ON u.id = d.user_id").
+		Where("pd.id = ?", id).
+		Take(&details).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.DB.WithContext(ctx).Where("dispute_id = ?", id).Order("created_at ASC").Find(&details.Evidence).Error; err != nil {
+		return nil, err
+	}
+	if err := r.DB.WithContext(ctx).Where("dispute_id = ?", id).Order("created_at ASC, id ASC").Find(&details.Events).Error; err != nil {
+		return nil, err
+	}
+	return &details, nil
+}
+
+// AddEvidence records an uploaded evidence document
+func (r *Repository) AddEvidence(ctx context.Context, e *Evidence) error {
+	return r.DB.WithContext(ctx).Create(e).Error
+}
+
+// GetEvidence loads one evidence document of a dispute
+func (r *Repository) GetEvidence(ctx context.Context, disputeID, evidenceID uint) (*Evidence, error) {
+	var e Evidence
+	err := r.DB.WithContext(ctx).Where("id = ? AND dispute_id = ?", evidenceID, disputeID).First(&e).Error
+	if err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CountEvidence returns how many documents were uploaded for a dispute
+func (r *Repository) CountEvidence(ctx context.Context, disputeID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Evidence{}).Where("dispute_id = ?", disputeID).Count(&n).Error
+	return n, err
+}
+
+// BookingEntityID returns the temple of a seva booking
+func (r *Repository) BookingEntityID(ctx context.Context, bookingID uint) (uint, error) {
+	var entityID uint
+	err := r.DB.WithContext(ctx).Table("seva_bookings").
+		Select("entity_id").
+		Where("id = ?", bookingID).
+		Take(&entityID).Error
+	return entityID, err
+}
+
+// EntityOwner returns the templeadmin (tenant) who created the temple
+func (r *Repository) EntityOwner(ctx context.Context, entityID uint) (uint, error) {
+	var createdBy uint
+	err := r.DB.WithContext(ctx).Table("entities").
+		Select("created_by").
+		Where("id = ?", entityID).
+		Take(&createdBy).Error
+	return createdBy, err
+}
+
+// TenantEntityIDs returns the temples created by the tenant (templeadmin)
+func (r *Repository) TenantEntityIDs(ctx context.Context, tenantID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.WithContext(ctx).Table("entities").
+		Where("created_by = ?", tenantID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,470 @@
+package dispute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Razorpay dispute webhook events
+const (
+	WebhookDisputeCreated        = "payment.dispute.created"
+	WebhookDisputeUnderReview    = "payment.dispute.under_review"
+	WebhookDisputeActionRequired = "payment.dispute.action_required"
+	WebhookDisputeWon            = "payment.dispute.won"
+	WebhookDisputeLost           = "payment.dispute.lost"
+	WebhookDisputeClosed         = "payment.dispute.closed"
+)
+
+var (
+	ErrInvalidTransition = errors.New("dispute status change not allowed")
+	ErrNoEvidence        = errors.New("upload at least one evidence document before responding")
+	ErrBookingMismatch   = errors.New("booking belongs to a different temple")
+)
+
+// transitions lists the statuses a dispute may move to by hand. Webhooks
+// follow the gateway and may move any unresolved dispute.
+var transitions = map[string][]string{
+	StatusOpen:      {StatusResponded, StatusWon, StatusLost},
+	StatusResponded: {StatusWon, StatusLost},
+}
+
+// Notifier delivers in-app notifications (notification.Service)
+type Notifier interface {
+	CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error
+}
+
+// Service runs the dispute workflow
+type Service struct {
+	Repo     *Repository
+	Cfg      *config.Config
+	Audit    auditlog.Service
+	Store    storage.Storage // evidence documents, stored alongside entity files
+	Notifier Notifier        // nil disables notifications
+}
+
+// NewService initializes the dispute service
+func NewService(repo *Repository, cfg *config.Config, auditSvc auditlog.Service, store storage.Storage) *Service {
+	return &Service{Repo: repo, Cfg: cfg, Audit: auditSvc, Store: store}
+}
+
+// razorpayDisputePayload is the subset of a dispute webhook body we use
+type razorpayDisputePayload struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Dispute struct {
+			Entity struct {
+				ID                string `json:"id"`
+				PaymentID         string `json:"payment_id"`
+				Amount            int64  `json:"amount"`          // paise
+				AmountDeducted    int64  `json:"amount_deducted"` // paise
+				ReasonCode        string `json:"reason_code"`
+				ReasonDescription string `json:"reason_description"`
+				RespondBy         int64  `json:"respond_by"` // unix seconds
+				Status            string `json:"status"`
+				Phase             string `json:"phase"`
+			} `json:"entity"`
+		} `json:"dispute"`
+	} `json:"payload"`
+}
+
+// webhookStatus maps a dispute event to our status
+func webhookStatus(event, current string) (string, bool) {
+	switch event {
+	case WebhookDisputeCreated, WebhookDisputeActionRequired:
+		return StatusOpen, true
+	case WebhookDisputeUnderReview:
+		return StatusResponded, true
+	case WebhookDisputeWon:
+		return StatusWon, true
+	case WebhookDisputeLost, WebhookDisputeClosed:
+		// closed means the dispute was accepted, the amount stays deducted
+		return StatusLost, true
+	}
+	return current, false
+}
+
+// HandleWebhook verifies a Razorpay dispute webhook and creates or updates
+// the dispute. Retried deliveries of the same event are ignored.
+func (s *Service) HandleWebhook(req donation.WebhookRequest) (*donation.WebhookResult, error) {
+	ctx := context.Background()
+
+	if s.Cfg.RazorpayWebhookSecret == "" && s.Cfg.RazorpayTestWebhookSecret == "" {
+		return nil, donation.ErrWebhookNotConfigured
+	}
+	sandbox := false
+	verified := s.Cfg.RazorpayWebhookSecret != "" && donation.VerifyWebhookSignature(req.Body, req.Signature, s.Cfg.RazorpayWebhookSecret)
+	if !verified && s.Cfg.RazorpayTestWebhookSecret != "" && donation.VerifyWebhookSignature(req.Body, req.Signature, s.Cfg.RazorpayTestWebhookSecret) {
+		verified, sandbox = true, true
+	}
+	if !verified {
+		s.Audit.LogAction(ctx, nil, nil, "DISPUTE_WEBHOOK_REJECTED", map[string]interface{}{
+			"event_id": req.EventID,
+			"reason":   "invalid webhook signature",
+		}, req.IPAddress, "failure")
+		return nil, donation.ErrInvalidWebhookSignature
+	}
+
+	var payload razorpayDisputePayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	entity := payload.Payload.Dispute.Entity
+	result := &donation.WebhookResult{Event: payload.Event, Sandbox: sandbox}
+
+	if !strings.HasPrefix(payload.Event, "payment.dispute.") || entity.ID == "" {
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+
+	d, err := s.Repo.GetByGatewayID(ctx, entity.ID)
+	if err != nil {
+		return nil, err
+	}
+	created := d == nil
+	if created {
+		d = &Dispute{GatewayDisputeID: entity.ID, PaymentID: entity.PaymentID, Status: StatusOpen, Sandbox: sandbox}
+		paid, err := s.Repo.FindDonationByPayment(ctx, entity.PaymentID)
+		if err != nil {
+			return nil, err
+		}
+		if paid != nil {
+			d.EntityID, d.DonationID = paid.EntityID, &paid.ID
+		}
+	}
+
+	fromStatus := d.Status
+	toStatus, known := webhookStatus(payload.Event, d.Status)
+	if !known {
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+	if !created && resolved(d.Status) {
+		// Outcomes are final; late or out of order events only update the details
+		toStatus = d.Status
+	}
+
+	d.Amount = float64(entity.Amount) / 100
+	d.AmountDeducted = float64(entity.AmountDeducted) / 100
+	d.ReasonCode = entity.ReasonCode
+	d.ReasonDescription = entity.ReasonDescription
+	d.Phase = entity.Phase
+	d.GatewayStatus = entity.Status
+	if entity.RespondBy > 0 {
+		respondBy := time.Unix(entity.RespondBy, 0)
+		d.RespondBy = &respondBy
+	}
+	d.Status = toStatus
+	if resolved(toStatus) && d.ResolvedAt == nil {
+		now := time.Now()
+		d.ResolvedAt = &now
+	}
+
+	eventID := req.EventID
+	if eventID == "" {
+		eventID = payload.Event + ":" + entity.ID
+	}
+	event := &Event{
+		EventID:    &eventID,
+		Event:      payload.Event,
+		Source:     SourceWebhook,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+	}
+	if created {
+		event.FromStatus = ""
+	}
+
+	if err := s.Repo.ApplyWebhookEvent(ctx, d, event); err != nil {
+		if errors.Is(err, ErrDuplicateEvent) {
+			result.Status = donation.WebhookDuplicate
+			return result, nil
+		}
+		return nil, err
+	}
+
+	result.Status = donation.WebhookProcessed
+	result.FromStatus, result.ToStatus = event.FromStatus, toStatus
+
+	var entityID *uint
+	if d.EntityID != 0 {
+		entityID = &d.EntityID
+	}
+	s.Audit.LogAction(ctx, nil, entityID, "DISPUTE_"+strings.ToUpper(strings.TrimPrefix(payload.Event, "payment.dispute.")), map[string]interface{}{
+		"dispute_id":         d.ID,
+		"gateway_dispute_id": d.GatewayDisputeID,
+		"payment_id":         d.PaymentID,
+		"amount":             d.Amount,
+		"reason_code":        d.ReasonCode,
+		"from_status":        event.FromStatus,
+		"to_status":          toStatus,
+	}, req.IPAddress, "success")
+
+	if created || fromStatus != toStatus || payload.Event == WebhookDisputeActionRequired {
+		s.notify(ctx, d, payload.Event)
+	}
+	return result, nil
+}
+
+// notify tells the temple's admin about a new dispute or a gateway update
+func (s *Service) notify(ctx context.Context, d *Dispute, event string) {
+	if s.Notifier == nil || d.EntityID == 0 {
+		return
+	}
+	ownerID, err := s.Repo.EntityOwner(ctx, d.EntityID)
+	if err != nil || ownerID == 0 {
+		return
+	}
+
+	title, message := "Payment dispute update", fmt.Sprintf("Dispute %s for ₹%.2f is now %s", d.GatewayDisputeID, d.Amount, d.Status)
+	switch event {
+	case WebhookDisputeCreated:
+		title = "New payment dispute"
+		message = fmt.Sprintf("A devotee disputed a payment of ₹%.2f (%s).", d.Amount, d.ReasonDescription)
+		if d.RespondBy != nil {
+			message += " Respond with evidence by " + d.RespondBy.Format("02 Jan 2006") + "."
+		}
+	case WebhookDisputeActionRequired:
+		title = "Payment dispute needs action"
+		message = fmt.Sprintf("The gateway needs more evidence for dispute %s (₹%.2f).", d.GatewayDisputeID, d.Amount)
+	}
+	_ = s.Notifier.CreateInAppNotification(ctx, ownerID, d.EntityID, title, message, "dispute")
+}
+
+func resolved(status string) bool {
+	return status == StatusWon || status == StatusLost
+}
+
+// EntityScope returns the temples whose disputes the caller may see, or nil
+// for every temple. Temple staff see the temples they really work at.
+func (s *Service) EntityScope(ctx context.Context, access middleware.AccessContext) ([]uint, error) {
+	var ids []uint
+	switch access.RoleName {
+	case middleware.RoleSuperAdmin:
+		if access.AssignedEntityID == nil {
+			return nil, nil
+		}
+		tenantIDs, err := s.Repo.TenantEntityIDs(ctx, *access.AssignedEntityID)
+		if err != nil {
+			return nil, err
+		}
+		ids = tenantIDs
+	case middleware.RoleTempleAdmin, middleware.RoleStandardUser, middleware.RoleMonitoringUser:
+		ids = access.EntityIDs
+	default:
+		return []uint{}, nil
+	}
+
+	if ids == nil {
+		ids = []uint{}
+	}
+	return ids, nil
+}
+
+// CanAccess reports whether the caller may see the dispute
+func (s *Service) CanAccess(ctx context.Context, access middleware.AccessContext, d *Dispute) (bool, error) {
+	ids, err := s.EntityScope(ctx, access)
+	if err != nil {
+		return false, err
+	}
+	return ids == nil || containsID(ids, d.EntityID), nil
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// List returns a page of disputes of the given temples
+func (s *Service) List(ctx context.Context, f Filter) ([]ListItem, int64, error) {
+	return s.Repo.List(ctx, f)
+}
+
+// Get returns a dispute with its evidence and timeline
+func (s *Service) Get(ctx context.Context, id uint) (*Details, error) {
+	return s.Repo.GetDetails(ctx, id)
+}
+
+// AddEvidence stores an evidence document for an unresolved dispute
+func (s *Service) AddEvidence(ctx context.Context, d *Dispute, fileName, contentType string, size int64, r io.Reader, note string, userID uint, ip string) (*Evidence, error) {
+	if resolved(d.Status) {
+		return nil, fmt.Errorf("%w: dispute is already %s", ErrInvalidTransition, d.Status)
+	}
+
+	storedName := uuid.New().String() + strings.ToLower(filepath.Ext(fileName))
+	key, err := storage.Key(fmt.Sprint(d.EntityID), "disputes", fmt.Sprint(d.ID), storedName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.Put(ctx, key, r, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store evidence: %w", err)
+	}
+
+	evidence := &Evidence{
+		DisputeID:   d.ID,
+		FileKey:     key,
+		FileName:    fileName,
+		ContentType: contentType,
+		FileSize:    size,
+		Note:        note,
+		UploadedBy:  userID,
+	}
+	if err := s.Repo.AddEvidence(ctx, evidence); err != nil {
+		_ = s.Store.Delete(ctx, key)
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &d.EntityID, "DISPUTE_EVIDENCE_UPLOADED", map[string]interface{}{
+		"dispute_id":  d.ID,
+		"evidence_id": evidence.ID,
+		"file_name":   fileName,
+		"file_size":   size,
+	}, ip, "success")
+	return evidence, nil
+}
+
+// OpenEvidence returns the stored evidence document
+func (s *Service) OpenEvidence(ctx context.Context, disputeID, evidenceID uint) (*Evidence, io.ReadCloser, error) {
+	evidence, err := s.Repo.GetEvidence(ctx, disputeID, evidenceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, _, err := s.Store.Get(ctx, evidence.FileKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return evidence, rc, nil
+}
+
+// Respond marks an open dispute as responded once evidence was uploaded.
+// Evidence is submitted to the gateway from its dashboard; this records the
+// temple's response and deadline compliance.
+func (s *Service) Respond(ctx context.Context, d *Dispute, note string, userID uint, ip string) error {
+	if !allowed(d.Status, StatusResponded) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, d.Status, StatusResponded)
+	}
+	n, err := s.Repo.CountEvidence(ctx, d.ID)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrNoEvidence
+	}
+
+	now := time.Now()
+	err = s.Repo.UpdateWithEvent(ctx, d.ID, map[string]interface{}{
+		"status":        StatusResponded,
+		"response_note": note,
+		"responded_at":  &now,
+		"responded_by":  userID,
+	}, &Event{
+		Event:      "responded",
+		Source:     SourceManual,
+		FromStatus: d.Status,
+		ToStatus:   StatusResponded,
+		Note:       note,
+		CreatedBy:  &userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	late := d.RespondBy != nil && now.After(*d.RespondBy)
+	s.Audit.LogAction(ctx, &userID, &d.EntityID, "DISPUTE_RESPONDED", map[string]interface{}{
+		"dispute_id":     d.ID,
+		"evidence_count": n,
+		"after_deadline": late,
+	}, ip, "success")
+	return nil
+}
+
+// SetOutcome records a won or lost outcome by hand, for gateways that did
+// not deliver the final webhook
+func (s *Service) SetOutcome(ctx context.Context, d *Dispute, status, note string, userID uint, ip string) error {
+	if status != StatusWon && status != StatusLost {
+		return fmt.Errorf("%w: status must be %s or %s", ErrInvalidTransition, StatusWon, StatusLost)
+	}
+	if !allowed(d.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidTransition, d.Status, status)
+	}
+
+	now := time.Now()
+	err := s.Repo.UpdateWithEvent(ctx, d.ID, map[string]interface{}{
+		"status":      status,
+		"resolved_at": &now,
+	}, &Event{
+		Event:      status,
+		Source:     SourceManual,
+		FromStatus: d.Status,
+		ToStatus:   status,
+		Note:       note,
+		CreatedBy:  &userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &d.EntityID, "DISPUTE_"+strings.ToUpper(status), map[string]interface{}{
+		"dispute_id": d.ID,
+		"source":     SourceManual,
+		"note":       note,
+	}, ip, "success")
+
+	d.Status = status
+	s.notify(ctx, d, "")
+	return nil
+}
+
+// LinkBooking links a dispute to the seva booking its payment was for
+func (s *Service) LinkBooking(ctx context.Context, d *Dispute, bookingID, userID uint, ip string) error {
+	entityID, err := s.Repo.BookingEntityID(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	if entityID != d.EntityID {
+		return ErrBookingMismatch
+	}
+
+	err = s.Repo.UpdateWithEvent(ctx, d.ID, map[string]interface{}{"booking_id": bookingID}, &Event{
+		Event:      "booking_linked",
+		Source:     SourceManual,
+		FromStatus: d.Status,
+		ToStatus:   d.Status,
+		Note:       fmt.Sprintf("linked to booking %d", bookingID),
+		CreatedBy:  &userID,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &d.EntityID, "DISPUTE_BOOKING_LINKED", map[string]interface{}{
+		"dispute_id": d.ID,
+		"booking_id": bookingID,
+	}, ip, "success")
+	return nil
+}
+
+func allowed(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
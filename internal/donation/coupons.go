@@ -0,0 +1,91 @@
+package donation
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sharath018/temple-management-backend/internal/coupon"
+	"gorm.io/gorm"
+)
+
+// A donation may carry a temple coupon code. The coupon is quoted before the
+// Razorpay order so the devotee is charged the discounted amount, and is
+// redeemed with the pending donation. A failed or refunded payment frees the
+// use again; a failed payment retried successfully on the same order takes it
+// back.
+
+var (
+	ErrCouponsUnavailable = errors.New("coupons are not available")
+	ErrAmountTooSmall     = errors.New("the amount after the coupon discount is below ₹1")
+	ErrCouponChanged      = errors.New("the coupon changed while the donation was being made, please try again")
+)
+
+// SetCouponService enables coupon codes on donations
+func (s *service) SetCouponService(c *coupon.Service) {
+	s.coupons = c
+}
+
+// couponTarget is the purchase a donation's coupon applies to. Seva
+// donations count as the seva they reference.
+func couponTarget(req CreateDonationRequest) coupon.Target {
+	t := coupon.Target{
+		EntityID: req.EntityID,
+		UserID:   req.UserID,
+		Kind:     coupon.KindDonation,
+		Amount:   req.Amount,
+	}
+	if req.DonationType == "seva" {
+		t.SevaID = req.ReferenceID
+	}
+	return t
+}
+
+// quoteCoupon prices the donation with its coupon, if it has one
+func (s *service) quoteCoupon(ctx context.Context, req CreateDonationRequest) (*coupon.Quote, error) {
+	code := coupon.NormalizeCode(req.CouponCode)
+	if code == "" {
+		return nil, nil
+	}
+	if s.coupons == nil {
+		return nil, ErrCouponsUnavailable
+	}
+	quote, err := s.coupons.Quote(ctx, code, couponTarget(req))
+	if err != nil {
+		return nil, err
+	}
+	if quote.FinalAmount < 1 {
+		return nil, ErrAmountTooSmall
+	}
+	return quote, nil
+}
+
+// createWithCoupon stores the pending donation and redeems its quoted coupon
+// in one transaction
+func (s *service) createWithCoupon(ctx context.Context, donation *Donation, req CreateDonationRequest, quote *coupon.Quote) error {
+	return s.repo.CreateWithin(ctx, donation, func(tx *gorm.DB) error {
+		t := couponTarget(req)
+		t.DonationID = &donation.ID
+		r, err := s.coupons.RedeemTx(tx, quote.Code, t)
+		if err != nil {
+			return err
+		}
+		if r.Discount != quote.Discount {
+			return ErrCouponChanged
+		}
+		return nil
+	})
+}
+
+// settleCoupon frees or takes back a donation's coupon use as its payment
+// status changes
+func (s *service) settleCoupon(ctx context.Context, donation *Donation, status string) {
+	if donation.CouponCode == "" || s.coupons == nil {
+		return
+	}
+	switch status {
+	case StatusSuccess:
+		_ = s.coupons.RestoreDonation(ctx, donation.ID)
+	case StatusFailed, StatusRefunded:
+		_ = s.coupons.ReleaseDonation(ctx, donation.ID)
+	}
+}
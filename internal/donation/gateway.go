@@ -0,0 +1,40 @@
+package donation
+
+import (
+	"context"
+	"errors"
+
+	razorpay "github.com/razorpay/razorpay-go"
+)
+
+// ErrSandboxGatewayNotConfigured is returned when a sandbox tenant takes a
+// payment but no Razorpay test mode keys are configured
+var ErrSandboxGatewayNotConfigured = errors.New("payments for sandbox tenants need Razorpay test mode keys (RAZORPAY_TEST_KEY_ID, RAZORPAY_TEST_KEY_SECRET)")
+
+// gateway is the Razorpay account a payment goes through: the live account,
+// or the test mode account for sandbox tenants
+type gateway struct {
+	client  *razorpay.Client
+	key     string
+	secret  string
+	sandbox bool
+}
+
+func (s *service) gatewayFor(sandbox bool) (*gateway, error) {
+	if !sandbox {
+		return &gateway{client: s.client, key: s.cfg.RazorpayKey, secret: s.cfg.RazorpaySecret}, nil
+	}
+	if s.testClient == nil {
+		return nil, ErrSandboxGatewayNotConfigured
+	}
+	return &gateway{client: s.testClient, key: s.cfg.RazorpayTestKey, secret: s.cfg.RazorpayTestSecret, sandbox: true}, nil
+}
+
+// gatewayForOrder picks the gateway an existing order was created on
+func (s *service) gatewayForOrder(ctx context.Context, orderID string) (*gateway, error) {
+	sandbox := false
+	if donation, err := s.repo.GetByOrderID(ctx, orderID); err == nil {
+		sandbox = donation.Sandbox
+	}
+	return s.gatewayFor(sandbox)
+}
@@ -1,11 +1,13 @@
 package donation
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/coupon"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -105,6 +107,22 @@ func (h *Handler) CreateDonation(c *gin.Context) {
 	req.IPAddress = middleware.GetIPFromContext(c)
 
 	order, err := h.svc.StartDonation(req)
+	if errors.Is(err, ErrCampaignClosed) || errors.Is(err, ErrAmountTooSmall) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if status, ok := coupon.ErrorStatus(err); ok {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrCouponChanged) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, ErrCouponsUnavailable) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -139,6 +157,41 @@ func (h *Handler) VerifyDonation(c *gin.Context) {
 	})
 }
 
+// ==============================
+// 🔔 Razorpay Payment Webhook
+// ==============================
+func (h *Handler) PaymentWebhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read request body"})
+		return
+	}
+
+	result, err := h.svc.HandlePaymentWebhook(WebhookRequest{
+		Body:      body,
+		Signature: c.GetHeader("X-Razorpay-Signature"),
+		EventID:   c.GetHeader("X-Razorpay-Event-Id"),
+		IPAddress: middleware.GetIPFromContext(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrWebhookNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			// Non-2xx makes Razorpay retry the delivery
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result,
+		"success": true,
+	})
+}
+
 // ==============================
 // 🔍 3. Get My Donations - UPDATED: Entity-based approach
 // ==============================
@@ -348,6 +401,18 @@ func (h *Handler) GenerateReceipt(c *gin.Context) {
 		entityID = extractedEntityID
 	}
 
+	// The PDF is the receipt devotees download; ?format=json keeps the old payload
+	if c.Query("format") != "json" {
+		pdf, filename, err := h.svc.GetReceiptPDF(uint(donationID), accessContext.UserID, &accessContext, entityID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", "attachment; filename="+filename)
+		c.Data(http.StatusOK, "application/pdf", pdf)
+		return
+	}
+
 	receipt, err := h.svc.GenerateReceipt(uint(donationID), accessContext.UserID, &accessContext, entityID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -8,9 +8,10 @@ import (
 
 // DonationStatus represents valid payment states
 const (
-	StatusPending = "PENDING"
-	StatusSuccess = "SUCCESS"
-	StatusFailed  = "FAILED"
+	StatusPending  = "PENDING"
+	StatusSuccess  = "SUCCESS"
+	StatusFailed   = "FAILED"
+	StatusRefunded = "REFUNDED"
 )
 
 // DonationMethod types (optional use, useful for validations/stats)
@@ -43,6 +44,10 @@ type Donation struct {
 	Amount       float64 `gorm:"type:decimal(10,2);not null" json:"amount"`     // Amount in INR (₹)
 	DonationType string  `gorm:"size:50;index" json:"donation_type"`            // general, seva, event, etc.
 	ReferenceID  *uint   `gorm:"index" json:"reference_id,omitempty"`           // Links to seva/event ID if needed
+	CampaignID   *uint   `gorm:"index" json:"campaign_id,omitempty"`            // Fundraising campaign the donation counts towards
+
+	CouponCode     string  `gorm:"size:40" json:"coupon_code,omitempty"`                   // Temple coupon applied to the donation
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0" json:"discount_amount"`    // Taken off by the coupon; Amount is what was charged
 
 	Method string `gorm:"size:50;not null;index" json:"method"`                 // Razorpay method used (UPI, CARD, etc.)
 	Status string `gorm:"size:20;default:'PENDING';index" json:"status"`        // PENDING, SUCCESS, FAILED
@@ -52,6 +57,8 @@ type Donation struct {
 
 	Note *string `gorm:"type:text" json:"note,omitempty"`                       // Optional donor message/intention
 
+	Sandbox bool `gorm:"default:false;index" json:"sandbox"`                     // Test mode payment of a sandbox tenant
+
 	DonatedAt *time.Time     `json:"donated_at,omitempty"`                      // Set only on successful payment
 	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
@@ -61,4 +68,58 @@ type Donation struct {
 // TableName returns the table name for the Donation model
 func (Donation) TableName() string {
 	return "donations"
-}
\ No newline at end of file
+}
+
+// PaymentWebhookEvent records every processed Razorpay webhook delivery so
+// retried deliveries of the same event are ignored
+type PaymentWebhookEvent struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EventID   string `gorm:"size:100;uniqueIndex;not null" json:"event_id"` // X-Razorpay-Event-Id header
+	Event     string `gorm:"size:50;not null;index" json:"event"`           // payment.captured, payment.failed, refund.processed
+	OrderID   string `gorm:"size:100;index" json:"order_id"`
+	PaymentID string `gorm:"size:100;index" json:"payment_id"`
+
+	FromStatus string `gorm:"size:20" json:"from_status"`
+	ToStatus   string `gorm:"size:20" json:"to_status"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the PaymentWebhookEvent model
+func (PaymentWebhookEvent) TableName() string {
+	return "payment_webhook_events"
+}
+
+// DonationReceipt is the numbered receipt issued for a successful donation.
+// Numbers run per temple and financial year, e.g. RCP/12/2026-27/00042.
+type DonationReceipt struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	DonationID    uint   `gorm:"uniqueIndex;not null" json:"donation_id"`
+	EntityID      uint   `gorm:"not null;index" json:"entity_id"`
+	ReceiptNumber string `gorm:"size:50;uniqueIndex;not null" json:"receipt_number"`
+	Series        string `gorm:"size:20;not null" json:"series"` // financial year, "TEST-" prefixed for sandbox donations
+	Sequence      int    `gorm:"not null" json:"sequence"`
+
+	FileKey string `gorm:"size:255" json:"-"` // storage key of the PDF, empty until stored
+
+	GeneratedAt time.Time `gorm:"autoCreateTime" json:"generated_at"`
+}
+
+// TableName returns the table name for the DonationReceipt model
+func (DonationReceipt) TableName() string {
+	return "donation_receipts"
+}
+
+// ReceiptCounter holds the last receipt sequence issued per temple and series
+type ReceiptCounter struct {
+	EntityID   uint   `gorm:"primaryKey;autoIncrement:false"`
+	Series     string `gorm:"primaryKey;size:20"`
+	LastNumber int    `gorm:"not null;default:0"`
+}
+
+// TableName returns the table name for the ReceiptCounter model
+func (ReceiptCounter) TableName() string {
+	return "donation_receipt_counters"
+}
@@ -0,0 +1,478 @@
+package donation
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// receiptsDir is the folder under the entity's storage prefix holding receipt PDFs
+const receiptsDir = "receipts"
+
+// ErrReceiptStorageUnavailable is returned when no storage backend is configured
+var ErrReceiptStorageUnavailable = errors.New("receipt storage is not configured")
+
+// ReceiptIssuer holds the temple letterhead and donor contact details printed
+// on a receipt
+type ReceiptIssuer struct {
+	EntityName            string
+	StreetAddress         string
+	Landmark              string
+	City                  string
+	District              string
+	State                 string
+	Pincode               string
+	EntityPhone           string
+	EntityEmail           string
+	PAN                   string
+	Registration80GNumber string `gorm:"column:registration_80g_number"`
+	DonorPhone            string `gorm:"serializer:encrypted"`
+}
+
+// financialYear returns the Indian financial year (April to March) of t, e.g. "2026-27"
+func financialYear(t time.Time) string {
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+	return fmt.Sprintf("%d-%02d", year, (year+1)%100)
+}
+
+// receiptSeries is the numbering series of a donation; sandbox donations are
+// numbered separately so they never consume real receipt numbers
+func receiptSeries(donatedAt time.Time, sandbox bool) string {
+	series := financialYear(donatedAt)
+	if sandbox {
+		series = "TEST-" + series
+	}
+	return series
+}
+
+func formatReceiptNumber(entityID uint, series string, seq int) string {
+	if rest, ok := strings.CutPrefix(series, "TEST-"); ok {
+		return fmt.Sprintf("TEST-RCP/%d/%s/%05d", entityID, rest, seq)
+	}
+	return fmt.Sprintf("RCP/%d/%s/%05d", entityID, series, seq)
+}
+
+// receiptFileName is the download name of a receipt PDF
+func receiptFileName(receiptNumber string) string {
+	return "receipt_" + strings.ReplaceAll(receiptNumber, "/", "-") + ".pdf"
+}
+
+// ===== REPOSITORY =====
+
+// GetReceipt returns the receipt issued for a donation, or nil if none exists yet
+func (r *repository) GetReceipt(ctx context.Context, donationID uint) (*DonationReceipt, error) {
+	var receipt DonationReceipt
+	err := r.db.WithContext(ctx).Where("donation_id = ?", donationID).First(&receipt).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// CreateReceipt takes the next number of the series and records the receipt in
+// one transaction, so numbers are gap free even when creation fails
+func (r *repository) CreateReceipt(ctx context.Context, receipt *DonationReceipt) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var seq int
+		err := tx.Raw(`
+			INSERT INTO donation_receipt_counters (entity_id, series, last_number)
+			VALUES (?, ?, 1)
+			ON CONFLICT (entity_id, series)
+			DO UPDATE SET last_number = donation_receipt_counters.last_number + 1
+			RETURNING last_number`, receipt.EntityID, receipt.Series).Scan(&seq).Error
+		if err != nil {
+			return err
+		}
+
+		receipt.Sequence = seq
+		receipt.ReceiptNumber = formatReceiptNumber(receipt.EntityID, receipt.Series, seq)
+		return tx.Create(receipt).Error
+	})
+}
+
+// SetReceiptFile records where the receipt PDF is stored
+func (r *repository) SetReceiptFile(ctx context.Context, receiptID uint, fileKey string) error {
+	return r.db.WithContext(ctx).Model(&DonationReceipt{}).
+		Where("id = ?", receiptID).
+		Update("file_key", fileKey).Error
+}
+
+// GetReceiptIssuer loads the temple letterhead and donor phone for a donation
+func (r *repository) GetReceiptIssuer(ctx context.Context, donationID uint) (*ReceiptIssuer, error) {
+	var issuer ReceiptIssuer
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Select(`
+			COALESCE(e.name, '') AS entity_name,
+			COALESCE(e.street_address, '') AS street_address,
+			COALESCE(e.landmark, '') AS landmark,
+			COALESCE(e.city, '') AS city,
+			COALESCE(e.district, '') AS district,
+			COALESCE(e.state, '') AS state,
+			COALESCE(e.pincode, '') AS pincode,
+			COALESCE(e.phone, '') AS entity_phone,
+			COALESCE(e.email, '') AS entity_email,
+			COALESCE(e.pan, '') AS pan,
+			COALESCE(e.registration_80g_number, '') AS registration_80g_number,
+			COALESCE(u.phone, '') AS donor_phone
+		`).
+		Joins("LEFT JOIN entities e ON d.entity_id = e.id").
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Where("d.id = ?", donationID).
+		Scan(&issuer).Error
+	if err != nil {
+		return nil, err
+	}
+	return &issuer, nil
+}
+
+// ===== SERVICE =====
+
+// issueReceipt generates the receipt of a just captured donation. Failures are
+// audited but never fail the payment; the receipt is retried on download.
+func (s *service) issueReceipt(ctx context.Context, donationID uint, ip string) {
+	donation, err := s.repo.GetByIDWithUser(ctx, donationID)
+	if err == nil {
+		_, _, err = s.ensureReceipt(ctx, donation)
+	}
+	if err != nil {
+		var userID, entityID *uint
+		if donation != nil {
+			userID, entityID = &donation.UserID, &donation.EntityID
+		}
+		s.auditSvc.LogAction(ctx, userID, entityID, "DONATION_RECEIPT_FAILED", map[string]interface{}{
+			"donation_id": donationID,
+			"error":       err.Error(),
+		}, ip, "failure")
+	}
+}
+
+// ensureReceipt numbers the donation's receipt on first use and makes sure its
+// PDF is in storage, returning the record and the PDF
+func (s *service) ensureReceipt(ctx context.Context, donation *DonationWithUser) (*DonationReceipt, []byte, error) {
+	if donation.Status != StatusSuccess {
+		return nil, nil, errors.New("receipt can only be generated for successful donations")
+	}
+
+	receipt, err := s.repo.GetReceipt(ctx, donation.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	created := false
+	if receipt == nil {
+		receipt = &DonationReceipt{
+			DonationID: donation.ID,
+			EntityID:   donation.EntityID,
+			Series:     receiptSeries(donationDate(donation), donation.Sandbox),
+		}
+		if err := s.repo.CreateReceipt(ctx, receipt); err != nil {
+			// Lost a race with a concurrent capture (verify vs webhook)
+			existing, getErr := s.repo.GetReceipt(ctx, donation.ID)
+			if getErr != nil || existing == nil {
+				return nil, nil, fmt.Errorf("failed to number receipt: %w", err)
+			}
+			receipt = existing
+		} else {
+			created = true
+		}
+	}
+
+	if s.store == nil {
+		return receipt, nil, ErrReceiptStorageUnavailable
+	}
+
+	if receipt.FileKey != "" {
+		if pdf, err := s.readReceiptFile(ctx, receipt.FileKey); err == nil {
+			return receipt, pdf, nil
+		}
+		// Missing from storage: render it again under the same number
+	}
+
+	issuer, err := s.repo.GetReceiptIssuer(ctx, donation.ID)
+	if err != nil {
+		return receipt, nil, err
+	}
+	pdf, err := renderReceiptPDF(donation, receipt, issuer)
+	if err != nil {
+		return receipt, nil, err
+	}
+
+	key, err := storage.Key(fmt.Sprint(donation.EntityID), receiptsDir, receiptFileName(receipt.ReceiptNumber))
+	if err != nil {
+		return receipt, nil, err
+	}
+	if err := s.store.Put(ctx, key, bytes.NewReader(pdf), int64(len(pdf)), "application/pdf"); err != nil {
+		return receipt, nil, fmt.Errorf("failed to store receipt: %w", err)
+	}
+	if err := s.repo.SetReceiptFile(ctx, receipt.ID, key); err != nil {
+		return receipt, nil, err
+	}
+	receipt.FileKey = key
+
+	if created {
+		s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "DONATION_RECEIPT_GENERATED", map[string]interface{}{
+			"donation_id":    donation.ID,
+			"receipt_number": receipt.ReceiptNumber,
+			"amount":         donation.Amount,
+		}, "", "success")
+		s.emailReceipt(donation, receipt, pdf)
+	}
+	return receipt, pdf, nil
+}
+
+// emailReceipt sends the donor their newly issued receipt with the PDF attached
+func (s *service) emailReceipt(donation *DonationWithUser, receipt *DonationReceipt, pdf []byte) {
+	if s.mailer == nil || donation.Sandbox {
+		return
+	}
+	data := map[string]interface{}{
+		"Name":          donation.UserName,
+		"TempleName":    donation.EntityName,
+		"ReceiptNumber": receipt.ReceiptNumber,
+		"Amount":        fmt.Sprintf("%.2f", donation.Amount),
+		"DonationType":  donation.DonationType,
+		"DonatedAt":     donationDate(donation).Format("02 Jan 2006"),
+		"PaymentID":     "",
+	}
+	if donation.PaymentID != nil {
+		data["PaymentID"] = *donation.PaymentID
+	}
+	attachment := notification.EmailAttachment{
+		FileName:    receiptFileName(receipt.ReceiptNumber),
+		ContentType: "application/pdf",
+		Data:        pdf,
+	}
+	go func() {
+		if err := s.mailer.SendToUser(context.Background(), donation.EntityID, donation.UserID, notification.EmailDonationReceipt, data, attachment); err != nil {
+			fmt.Printf("⚠️ Failed to email receipt %s: %v\n", receipt.ReceiptNumber, err)
+		}
+	}()
+}
+
+func (s *service) readReceiptFile(ctx context.Context, key string) ([]byte, error) {
+	rc, _, err := s.store.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// authorizeReceipt loads a donation the caller may see the receipt of: devotees
+// their own donations, temple users donations of their temple
+func (s *service) authorizeReceipt(ctx context.Context, donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*DonationWithUser, error) {
+	donation, err := s.repo.GetByIDWithUser(ctx, donationID)
+	if err != nil {
+		return nil, err
+	}
+
+	hasAccess := donation.UserID == userID && donation.EntityID == entityID
+	if accessContext != nil {
+		accessibleEntityID := accessContext.GetAccessibleEntityID()
+		if accessibleEntityID != nil && *accessibleEntityID == donation.EntityID && accessContext.CanRead() {
+			hasAccess = true
+		}
+	}
+	if !hasAccess {
+		return nil, errors.New("unauthorized to access this donation")
+	}
+	return donation, nil
+}
+
+// GetReceiptPDF returns the stored receipt PDF of a donation and its file name
+func (s *service) GetReceiptPDF(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error) {
+	ctx := context.Background()
+
+	donation, err := s.authorizeReceipt(ctx, donationID, userID, accessContext, entityID)
+	if err != nil {
+		return nil, "", err
+	}
+	receipt, pdf, err := s.ensureReceipt(ctx, donation)
+	if err != nil {
+		return nil, "", err
+	}
+	return pdf, receiptFileName(receipt.ReceiptNumber), nil
+}
+
+func donationDate(donation *DonationWithUser) time.Time {
+	if donation.DonatedAt != nil {
+		return *donation.DonatedAt
+	}
+	return donation.CreatedAt
+}
+
+// ===== PDF =====
+
+func renderReceiptPDF(donation *DonationWithUser, receipt *DonationReceipt, issuer *ReceiptIssuer) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	pdf.SetTitle("Donation Receipt "+receipt.ReceiptNumber, true)
+	pdf.AddPage()
+
+	if donation.Sandbox {
+		pdf.SetFont("Arial", "B", 90)
+		pdf.SetTextColor(230, 230, 230)
+		pdf.TransformBegin()
+		pdf.TransformRotate(35, 105, 150)
+		pdf.Text(55, 170, "TEST")
+		pdf.TransformEnd()
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	// Letterhead
+	pdf.SetFont("Arial", "B", 18)
+	pdf.CellFormat(0, 10, tr(issuer.EntityName), "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	if address := joinNonEmpty(", ", issuer.StreetAddress, issuer.Landmark, issuer.City, issuer.District, issuer.State, issuer.Pincode); address != "" {
+		pdf.MultiCell(0, 5, tr(address), "", "C", false)
+	}
+	if contact := joinNonEmpty("  |  ", prefixed("Phone: ", issuer.EntityPhone), prefixed("Email: ", issuer.EntityEmail)); contact != "" {
+		pdf.CellFormat(0, 5, tr(contact), "", 1, "C", false, 0, "")
+	}
+	if tax := joinNonEmpty("  |  ", prefixed("PAN: ", issuer.PAN), prefixed("80G Reg. No: ", issuer.Registration80GNumber)); tax != "" {
+		pdf.CellFormat(0, 5, tr(tax), "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(2)
+	y := pdf.GetY()
+	pdf.Line(10, y, 200, y)
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "B", 14)
+	pdf.CellFormat(0, 8, "DONATION RECEIPT", "", 1, "C", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(95, 6, "Receipt No: "+receipt.ReceiptNumber, "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 6, "Date: "+donationDate(donation).Format("02 Jan 2006"), "", 1, "R", false, 0, "")
+	pdf.Ln(4)
+
+	transactionID := donation.OrderID
+	if donation.PaymentID != nil {
+		transactionID = *donation.PaymentID
+	}
+
+	rows := [][2]string{
+		{"Received with thanks from", donation.UserName},
+		{"Email", donation.UserEmail},
+		{"Phone", issuer.DonorPhone},
+		{"Donation Type", donation.DonationType},
+		{"Payment Method", donation.Method},
+		{"Transaction ID", transactionID},
+		{"Amount", fmt.Sprintf("Rs. %.2f", donation.Amount)},
+		{"Amount in Words", amountInWords(donation.Amount)},
+	}
+	for _, row := range rows {
+		if row[1] == "" {
+			continue
+		}
+		pdf.SetFont("Arial", "B", 10)
+		pdf.CellFormat(55, 8, row[0], "1", 0, "L", false, 0, "")
+		pdf.SetFont("Arial", "", 10)
+		pdf.CellFormat(135, 8, tr(row[1]), "1", 1, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+
+	pdf.SetFont("Arial", "", 9)
+	if issuer.Registration80GNumber != "" {
+		pdf.MultiCell(0, 5, tr(fmt.Sprintf(
+			"Donations to %s are eligible for deduction under Section 80G of the Income Tax Act, 1961 "+
+				"vide registration number %s.", issuer.EntityName, issuer.Registration80GNumber)), "", "L", false)
+	} else {
+		pdf.MultiCell(0, 5, "This receipt does not qualify for deduction under Section 80G of the Income Tax Act, 1961.", "", "L", false)
+	}
+	pdf.Ln(10)
+	pdf.CellFormat(0, 5, "This is a computer generated receipt and does not require a signature.", "", 1, "C", false, 0, "")
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func joinNonEmpty(sep string, parts ...string) string {
+	out := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+func prefixed(prefix, value string) string {
+	if strings.TrimSpace(value) == "" {
+		return ""
+	}
+	return prefix + value
+}
+
+var (
+	belowTwenty = []string{"", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten",
+		"Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen"}
+	tens = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+)
+
+// amountInWords spells a rupee amount the Indian way (lakh, crore),
+// e.g. 123456.5 is "Rupees One Lakh Twenty Three Thousand Four Hundred Fifty Six and Fifty Paise Only"
+func amountInWords(amount float64) string {
+	paiseTotal := int64(amount*100 + 0.5)
+	rupees, paise := paiseTotal/100, paiseTotal%100
+
+	words := "Zero"
+	if rupees > 0 {
+		words = indianNumberWords(rupees)
+	}
+	out := "Rupees " + words
+	if paise > 0 {
+		out += " and " + belowHundredWords(paise) + " Paise"
+	}
+	return out + " Only"
+}
+
+func indianNumberWords(n int64) string {
+	var parts []string
+	if n >= 10000000 {
+		parts = append(parts, indianNumberWords(n/10000000)+" Crore")
+		n %= 10000000
+	}
+	for _, unit := range []struct {
+		value int64
+		name  string
+	}{{100000, "Lakh"}, {1000, "Thousand"}, {100, "Hundred"}} {
+		if n >= unit.value {
+			parts = append(parts, belowHundredWords(n/unit.value)+" "+unit.name)
+			n %= unit.value
+		}
+	}
+	if n > 0 {
+		parts = append(parts, belowHundredWords(n))
+	}
+	return strings.Join(parts, " ")
+}
+
+func belowHundredWords(n int64) string {
+	if n < 20 {
+		return belowTwenty[n]
+	}
+	if n%10 == 0 {
+		return tens[n/10]
+	}
+	return tens[n/10] + " " + belowTwenty[n%10]
+}
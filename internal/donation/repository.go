@@ -2,17 +2,36 @@ package donation
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrDuplicateWebhookEvent is returned when a webhook event was already processed
+var ErrDuplicateWebhookEvent = errors.New("webhook event already processed")
+
+// ErrCampaignClosed is returned when a donation names a campaign that is not
+// taking donations
+var ErrCampaignClosed = errors.New("campaign is not accepting donations")
+
 type Repository interface {
 	// Basic CRUD operations
 	Create(ctx context.Context, donation *Donation) error
+	CreateWithin(ctx context.Context, donation *Donation, fn func(tx *gorm.DB) error) error // fn runs in the insert's transaction
 	GetByOrderID(ctx context.Context, orderID string) (*Donation, error)
 	GetByIDWithUser(ctx context.Context, donationID uint) (*DonationWithUser, error)
 	UpdatePaymentDetails(ctx context.Context, orderID string, params UpdatePaymentDetailsParams) error
+	ApplyWebhookEvent(ctx context.Context, event *PaymentWebhookEvent, allowedFrom []string, updates map[string]interface{}) (bool, error)
+	IsSandboxEntity(ctx context.Context, entityID uint) (bool, error)
+	CampaignAcceptsDonations(ctx context.Context, campaignID, entityID uint) (bool, error)
+
+	// Receipts
+	GetReceipt(ctx context.Context, donationID uint) (*DonationReceipt, error)
+	CreateReceipt(ctx context.Context, receipt *DonationReceipt) error
+	SetReceiptFile(ctx context.Context, receiptID uint, fileKey string) error
+	GetReceiptIssuer(ctx context.Context, donationID uint) (*ReceiptIssuer, error)
 
 	// Data retrieval with filtering
 	ListByUserID(ctx context.Context, userID uint) ([]DonationWithUser, error)
@@ -50,6 +69,15 @@ func (r *repository) Create(ctx context.Context, donation *Donation) error {
 	return r.db.WithContext(ctx).Create(donation).Error
 }
 
+func (r *repository) CreateWithin(ctx context.Context, donation *Donation, fn func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(donation).Error; err != nil {
+			return err
+		}
+		return fn(tx)
+	})
+}
+
 func (r *repository) GetByOrderID(ctx context.Context, orderID string) (*Donation, error) {
 	var donation Donation
 	err := r.db.WithContext(ctx).
@@ -68,7 +96,7 @@ func (r *repository) GetByIDWithUser(ctx context.Context, donationID uint) (*Don
 		Select(`
 			d.id, d.user_id, d.entity_id, d.amount, d.donation_type, d.reference_id,
 			d.method, d.status, d.order_id, d.payment_id, d.note, d.donated_at,
-			d.created_at, d.updated_at,
+			d.created_at, d.updated_at, d.sandbox,
 			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as user_name, 
 			COALESCE(u.email, '') as user_email,
 			COALESCE(e.name, '') as entity_name
@@ -84,6 +112,30 @@ func (r *repository) GetByIDWithUser(ctx context.Context, donationID uint) (*Don
 	return &result, nil
 }
 
+// IsSandboxEntity reports whether the temple belongs to a sandbox tenant
+func (r *repository) IsSandboxEntity(ctx context.Context, entityID uint) (bool, error) {
+	var sandbox bool
+	err := r.db.WithContext(ctx).
+		Table("entities e").
+		Select("COALESCE(u.sandbox, false)").
+		Joins("LEFT JOIN users u ON u.id = e.created_by").
+		Where("e.id = ?", entityID).
+		Scan(&sandbox).Error
+	return sandbox, err
+}
+
+// CampaignAcceptsDonations reports whether the campaign belongs to the temple
+// and is active within its dates
+func (r *repository) CampaignAcceptsDonations(ctx context.Context, campaignID, entityID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("donation_campaigns").
+		Where("id = ? AND entity_id = ? AND status = ? AND deleted_at IS NULL", campaignID, entityID, "active").
+		Where("start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)").
+		Count(&count).Error
+	return count > 0, err
+}
+
 func (r *repository) UpdatePaymentDetails(ctx context.Context, orderID string, params UpdatePaymentDetailsParams) error {
 	updates := map[string]interface{}{
 		"status":     params.Status,
@@ -102,6 +154,57 @@ func (r *repository) UpdatePaymentDetails(ctx context.Context, orderID string, p
 		Updates(updates).Error
 }
 
+// ApplyWebhookEvent records the webhook event and moves the donation to
+// event.ToStatus if its current status is one of allowedFrom. Both happen in
+// one transaction so concurrent deliveries cannot apply a transition twice.
+// It returns false when the donation is not in an allowed state.
+func (r *repository) ApplyWebhookEvent(ctx context.Context, event *PaymentWebhookEvent, allowedFrom []string, updates map[string]interface{}) (bool, error) {
+	applied := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "event_id"}},
+			DoNothing: true,
+		}).Create(event)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrDuplicateWebhookEvent
+		}
+
+		var donation Donation
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ?", event.OrderID).
+			First(&donation).Error; err != nil {
+			return err
+		}
+		event.FromStatus = donation.Status
+
+		allowed := false
+		for _, status := range allowedFrom {
+			if donation.Status == status {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			// Keep the event record so retries stay no-ops, but mark it as not applied
+			return tx.Model(event).Updates(map[string]interface{}{
+				"from_status": donation.Status,
+				"to_status":   donation.Status,
+			}).Error
+		}
+
+		updates["status"] = event.ToStatus
+		if err := tx.Model(&Donation{}).Where("id = ?", donation.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+		applied = true
+		return tx.Model(event).Update("from_status", donation.Status).Error
+	})
+	return applied, err
+}
+
 // ==============================
 // Data Retrieval with Filtering - ENHANCED with entity-based filtering
 // ==============================
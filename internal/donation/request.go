@@ -13,16 +13,20 @@ type CreateDonationRequest struct {
 	Amount       float64 `json:"amount" binding:"required,gt=0"` // Donation amount in INR
 	DonationType string  `json:"donationType" binding:"required,oneof=general seva event festival construction annadanam education maintenance"`
 	ReferenceID  *uint   `json:"referenceID,omitempty"`          // Optional: SevaID or EventID
+	CampaignID   *uint   `json:"campaignID,omitempty"`           // Optional: fundraising campaign
 	Note         *string `json:"note,omitempty"`                 // Optional donor message
+	CouponCode   string  `json:"couponCode,omitempty"`           // Optional temple coupon
 	IPAddress    string  `json:"-"`                             // ✅ NEW: For audit logging (filled from middleware)
 }
 
 // CreateDonationResponse is returned to frontend after creating Razorpay order
 type CreateDonationResponse struct {
 	OrderID     string  `json:"order_id"`       // Razorpay order ID
-	Amount      float64 `json:"amount"`         // Donation amount in INR
+	Amount      float64 `json:"amount"`         // Donation amount in INR, after any coupon discount
+	Discount    float64 `json:"discount,omitempty"` // Taken off by the coupon
 	Currency    string  `json:"currency"`       // Currency, always "INR"
 	RazorpayKey string  `json:"razorpay_key"`   // Razorpay key for client-side SDK
+	Sandbox     bool    `json:"sandbox"`        // Order was created in Razorpay test mode
 }
 
 // VerifyPaymentRequest is used by frontend to confirm payment success
@@ -49,6 +53,7 @@ type DonationWithUser struct {
 	DonatedAt    *time.Time `json:"donatedAt,omitempty" db:"donated_at"`  // FIXED: proper field
 	CreatedAt    time.Time `json:"created_at" db:"created_at"`           // FIXED: show date properly
 	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+	Sandbox      bool      `json:"sandbox" db:"sandbox"`                 // Test payment of a sandbox tenant
 	
 	// User information - FIXED FIELD NAMES
 	UserName  string `json:"userName" db:"user_name"`                   // FIXED: proper mapping
@@ -153,17 +158,22 @@ type AnalyticsData struct {
 
 // Receipt represents a donation receipt
 type Receipt struct {
-	ID             uint      `json:"id"`
-	DonationAmount float64   `json:"donationAmount"`
-	DonationType   string    `json:"donationType"`
-	DonorName      string    `json:"donorName"`
-	DonorEmail     string    `json:"donorEmail"`
-	TransactionID  string    `json:"transactionId"`
-	DonatedAt      time.Time `json:"donatedAt"`
-	Method         string    `json:"method"`
-	EntityName     string    `json:"entityName"`
-	ReceiptNumber  string    `json:"receiptNumber"`
-	GeneratedAt    time.Time `json:"generatedAt"`
+	ID              uint      `json:"id"`
+	DonationAmount  float64   `json:"donationAmount"`
+	DonationType    string    `json:"donationType"`
+	DonorName       string    `json:"donorName"`
+	DonorEmail      string    `json:"donorEmail"`
+	TransactionID   string    `json:"transactionId"`
+	DonatedAt       time.Time `json:"donatedAt"`
+	Method          string    `json:"method"`
+	EntityName      string    `json:"entityName"`
+	ReceiptNumber   string    `json:"receiptNumber"`
+	AmountInWords   string    `json:"amountInWords"`
+	PAN             string    `json:"pan,omitempty"`             // temple PAN
+	Registration80G string    `json:"registration80G,omitempty"` // temple 80G registration number
+	GeneratedAt     time.Time `json:"generatedAt"`
+	IsTest          bool      `json:"isTest"`
+	Watermark       string    `json:"watermark,omitempty"` // "TEST" on receipts of sandbox tenants
 }
 
 // DonationListResponse represents paginated donation list response
@@ -10,12 +10,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"time"
 
 	razorpay "github.com/razorpay/razorpay-go"
 	"github.com/sharath018/temple-management-backend/config"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/coupon"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/storage"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -23,6 +27,7 @@ type Service interface {
 	// Core donation operations (DEVOTEE - UNCHANGED)
 	StartDonation(req CreateDonationRequest) (*CreateDonationResponse, error)
 	VerifyAndUpdateDonation(req VerifyPaymentRequest) error
+	HandlePaymentWebhook(req WebhookRequest) (*WebhookResult, error)
 	
 	// Data retrieval - UPDATED for entity-based approach
 	GetDonationsByUser(userID uint) ([]DonationWithUser, error)
@@ -36,31 +41,51 @@ type Service interface {
 	
 	// Receipt and export - BOTH (UPDATED)
 	GenerateReceipt(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*Receipt, error) // NEW: Added entityID
+	GetReceiptPDF(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error)
 	ExportDonations(filters DonationFilters, format string, accessContext middleware.AccessContext) ([]byte, string, error)
 
 	// Recent donations - BOTH (UPDATED)
 	GetRecentDonationsByUser(ctx context.Context, userID uint, limit int) ([]RecentDonation, error)
 	GetRecentDonationsByUserAndEntity(ctx context.Context, userID uint, entityID uint, limit int) ([]RecentDonation, error) // NEW
 	GetRecentDonationsByEntity(ctx context.Context, entityID uint, limit int, accessContext middleware.AccessContext) ([]RecentDonation, error)
+
+	// Emails donors their receipts
+	SetMailer(m *notification.Mailer)
+	// Enables coupon codes on donations
+	SetCouponService(c *coupon.Service)
 }
 
 type service struct {
 	repo       Repository
 	client     *razorpay.Client
+	testClient *razorpay.Client // Razorpay test mode, for sandbox tenants
 	cfg        *config.Config
 	auditSvc   auditlog.Service
+	store      storage.Storage // receipt PDFs, stored alongside entity files
+	mailer     *notification.Mailer
+	coupons    *coupon.Service
 }
 
-func NewService(repo Repository, cfg *config.Config, auditSvc auditlog.Service) Service {
+func NewService(repo Repository, cfg *config.Config, auditSvc auditlog.Service, store storage.Storage) Service {
 	client := razorpay.NewClient(cfg.RazorpayKey, cfg.RazorpaySecret)
+	var testClient *razorpay.Client
+	if cfg.RazorpayTestKey != "" && cfg.RazorpayTestSecret != "" {
+		testClient = razorpay.NewClient(cfg.RazorpayTestKey, cfg.RazorpayTestSecret)
+	}
 	return &service{
-		repo:     repo,
-		client:   client,
-		cfg:      cfg,
-		auditSvc: auditSvc,
+		repo:       repo,
+		client:     client,
+		testClient: testClient,
+		cfg:        cfg,
+		auditSvc:   auditSvc,
+		store:      store,
 	}
 }
 
+func (s *service) SetMailer(m *notification.Mailer) {
+	s.mailer = m
+}
+
 // ==============================
 // Core Donation Operations (DEVOTEE - UNCHANGED)
 // ==============================
@@ -68,9 +93,39 @@ func NewService(repo Repository, cfg *config.Config, auditSvc auditlog.Service)
 // StartDonation initializes the Razorpay order and creates a pending donation entry
 func (s *service) StartDonation(req CreateDonationRequest) (*CreateDonationResponse, error) {
 	ctx := context.Background()
+
+	if req.CampaignID != nil {
+		open, err := s.repo.CampaignAcceptsDonations(ctx, *req.CampaignID, req.EntityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve campaign: %w", err)
+		}
+		if !open {
+			return nil, ErrCampaignClosed
+		}
+	}
+
+	// Sandbox tenants take payments through Razorpay test mode
+	sandbox, err := s.repo.IsSandboxEntity(ctx, req.EntityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve temple: %w", err)
+	}
+	gw, err := s.gatewayFor(sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	// A coupon is priced before the order, so Razorpay charges the discounted amount
+	quote, err := s.quoteCoupon(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	charge, discount := req.Amount, 0.0
+	if quote != nil {
+		charge, discount = quote.FinalAmount, quote.Discount
+	}
 	
 	// Create Razorpay order
-	amountInPaise := int(req.Amount * 100)
+	amountInPaise := int(math.Round(charge * 100))
 	
 	data := map[string]interface{}{
 		"amount":          amountInPaise,
@@ -86,8 +141,17 @@ func (s *service) StartDonation(req CreateDonationRequest) (*CreateDonationRespo
 	if req.ReferenceID != nil {
 		data["notes"].(map[string]interface{})["reference_id"] = *req.ReferenceID
 	}
+	if req.CampaignID != nil {
+		data["notes"].(map[string]interface{})["campaign_id"] = *req.CampaignID
+	}
+	if sandbox {
+		data["notes"].(map[string]interface{})["sandbox"] = true
+	}
+	if quote != nil {
+		data["notes"].(map[string]interface{})["coupon_code"] = quote.Code
+	}
 
-	order, err := s.client.Order.Create(data, nil)
+	order, err := gw.client.Order.Create(data, nil)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, &req.UserID, &req.EntityID, "DONATION_INITIATED", map[string]interface{}{
 			"amount":        req.Amount,
@@ -113,16 +177,25 @@ func (s *service) StartDonation(req CreateDonationRequest) (*CreateDonationRespo
 	donation := &Donation{
 		UserID:       req.UserID,
 		EntityID:     req.EntityID,
-		Amount:       req.Amount,
+		Amount:       charge,
 		DonationType: req.DonationType,
 		ReferenceID:  req.ReferenceID,
+		CampaignID:   req.CampaignID,
 		Method:       "PENDING", // Will be updated after payment
 		Status:       StatusPending,
 		OrderID:      orderID,
 		Note:         req.Note,
+		Sandbox:      sandbox,
+	}
+	if quote != nil {
+		donation.CouponCode = quote.Code
+		donation.DiscountAmount = discount
+		err = s.createWithCoupon(ctx, donation, req, quote)
+	} else {
+		err = s.repo.Create(context.Background(), donation)
 	}
 
-	if err := s.repo.Create(context.Background(), donation); err != nil {
+	if err != nil {
 		s.auditSvc.LogAction(ctx, &req.UserID, &req.EntityID, "DONATION_INITIATED", map[string]interface{}{
 			"amount":        req.Amount,
 			"donation_type": req.DonationType,
@@ -138,22 +211,33 @@ func (s *service) StartDonation(req CreateDonationRequest) (*CreateDonationRespo
 		"donation_type": req.DonationType,
 		"order_id":      orderID,
 		"reference_id":  req.ReferenceID,
+		"campaign_id":   req.CampaignID,
+		"sandbox":       sandbox,
+		"coupon_code":   donation.CouponCode,
+		"discount":      discount,
 	}, req.IPAddress, "success")
 
 	return &CreateDonationResponse{
 		OrderID:     orderID,
-		Amount:      req.Amount,
+		Amount:      charge,
+		Discount:    discount,
 		Currency:    "INR",
-		RazorpayKey: s.cfg.RazorpayKey,
+		RazorpayKey: gw.key,
+		Sandbox:     sandbox,
 	}, nil
 }
 
 // VerifyAndUpdateDonation securely verifies Razorpay signature and updates payment status (DEVOTEE - UNCHANGED)
 func (s *service) VerifyAndUpdateDonation(req VerifyPaymentRequest) error {
 	ctx := context.Background()
+
+	gw, err := s.gatewayForOrder(ctx, req.OrderID)
+	if err != nil {
+		return err
+	}
 	
 	// Step 1: Verify HMAC Signature
-	expected := hmac.New(sha256.New, []byte(s.cfg.RazorpaySecret))
+	expected := hmac.New(sha256.New, []byte(gw.secret))
 	expected.Write([]byte(req.OrderID + "|" + req.PaymentID))
 	computedSignature := hex.EncodeToString(expected.Sum(nil))
 
@@ -168,7 +252,7 @@ func (s *service) VerifyAndUpdateDonation(req VerifyPaymentRequest) error {
 	}
 
 	// Step 2: Fetch payment details from Razorpay
-	payment, err := s.client.Payment.Fetch(req.PaymentID, nil, nil)
+	payment, err := gw.client.Payment.Fetch(req.PaymentID, nil, nil)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, nil, nil, "DONATION_VERIFICATION_FAILED", map[string]interface{}{
 			"order_id":   req.OrderID,
@@ -282,6 +366,11 @@ func (s *service) VerifyAndUpdateDonation(req VerifyPaymentRequest) error {
 		"reference_id":   donation.ReferenceID,
 	}, req.IPAddress, auditStatus)
 
+	s.settleCoupon(ctx, donation, newStatus)
+	if newStatus == StatusSuccess {
+		s.issueReceipt(ctx, donation.ID, req.IPAddress)
+	}
+
 	return nil
 }
 
@@ -488,34 +577,25 @@ func (s *service) GetAnalytics(entityID uint, days int, accessContext middleware
 // NEW: Updated to include entity validation
 func (s *service) GenerateReceipt(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) (*Receipt, error) {
 	ctx := context.Background()
-	
-	donation, err := s.repo.GetByIDWithUser(ctx, donationID)
+
+	donation, err := s.authorizeReceipt(ctx, donationID, userID, accessContext, entityID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Check access permissions
-	hasAccess := false
-	
-	// For devotees - can only access their own donations within their entity
-	if donation.UserID == userID && donation.EntityID == entityID {
-		hasAccess = true
-	}
-	
-	// For temple admins - can access donations for their entity
-	if accessContext != nil {
-		accessibleEntityID := accessContext.GetAccessibleEntityID()
-		if accessibleEntityID != nil && *accessibleEntityID == donation.EntityID && accessContext.CanRead() {
-			hasAccess = true
-		}
+	if donation.Status != StatusSuccess {
+		return nil, errors.New("receipt can only be generated for successful donations")
 	}
 
-	if !hasAccess {
-		return nil, errors.New("unauthorized to access this donation")
+	// The number is issued on capture; the PDF itself is optional here
+	stored, _, err := s.ensureReceipt(ctx, donation)
+	if stored == nil {
+		return nil, err
 	}
 
-	if donation.Status != StatusSuccess {
-		return nil, errors.New("receipt can only be generated for successful donations")
+	issuer, err := s.repo.GetReceiptIssuer(ctx, donation.ID)
+	if err != nil {
+		return nil, err
 	}
 
 	transactionID := donation.OrderID
@@ -528,7 +608,7 @@ func (s *service) GenerateReceipt(donationID uint, userID uint, accessContext *m
 		donatedAt = *donation.DonatedAt
 	}
 
-	return &Receipt{
+	receipt := &Receipt{
 		ID:              donation.ID,
 		DonationAmount:  donation.Amount,
 		DonationType:    donation.DonationType,
@@ -538,9 +618,20 @@ func (s *service) GenerateReceipt(donationID uint, userID uint, accessContext *m
 		DonatedAt:       donatedAt,
 		Method:          donation.Method,
 		EntityName:      donation.EntityName,
-		ReceiptNumber:   fmt.Sprintf("RCP-%d-%d", donation.EntityID, donation.ID),
-		GeneratedAt:     time.Now(),
-	}, nil
+		ReceiptNumber:   stored.ReceiptNumber,
+		AmountInWords:   amountInWords(donation.Amount),
+		PAN:             issuer.PAN,
+		Registration80G: issuer.Registration80GNumber,
+		GeneratedAt:     stored.GeneratedAt,
+	}
+
+	// Test payments of sandbox tenants must never pass for real receipts
+	if donation.Sandbox {
+		receipt.IsTest = true
+		receipt.Watermark = "TEST"
+	}
+
+	return receipt, nil
 }
 
 func (s *service) ExportDonations(filters DonationFilters, format string, accessContext middleware.AccessContext) ([]byte, string, error) {
@@ -0,0 +1,267 @@
+package donation
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Razorpay webhook events handled for donations
+const (
+	WebhookPaymentCaptured = "payment.captured"
+	WebhookPaymentFailed   = "payment.failed"
+	WebhookOrderPaid       = "order.paid"
+	WebhookRefundProcessed = "refund.processed"
+)
+
+// Webhook results returned to the caller
+const (
+	WebhookProcessed = "processed"
+	WebhookDuplicate = "duplicate"
+	WebhookIgnored   = "ignored"
+)
+
+var (
+	ErrWebhookNotConfigured    = errors.New("razorpay webhook secret not configured")
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// WebhookRequest is a raw Razorpay webhook delivery
+type WebhookRequest struct {
+	Body      []byte // Raw request body, the signature is computed over it
+	Signature string // X-Razorpay-Signature header
+	EventID   string // X-Razorpay-Event-Id header
+	IPAddress string
+}
+
+// WebhookResult tells the caller what happened to a delivery
+type WebhookResult struct {
+	Status     string `json:"status"` // processed, duplicate, ignored
+	Event      string `json:"event"`
+	Sandbox    bool   `json:"sandbox,omitempty"` // Delivered by the Razorpay test mode account
+	OrderID    string `json:"order_id,omitempty"`
+	FromStatus string `json:"from_status,omitempty"`
+	ToStatus   string `json:"to_status,omitempty"`
+}
+
+// razorpayWebhookPayload is the subset of the Razorpay webhook body we use
+type razorpayWebhookPayload struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Payment struct {
+			Entity razorpayPaymentEntity `json:"entity"`
+		} `json:"payment"`
+		Refund struct {
+			Entity struct {
+				ID        string `json:"id"`
+				PaymentID string `json:"payment_id"`
+				Amount    int64  `json:"amount"`
+			} `json:"entity"`
+		} `json:"refund"`
+	} `json:"payload"`
+}
+
+type razorpayPaymentEntity struct {
+	ID             string `json:"id"`
+	OrderID        string `json:"order_id"`
+	Amount         int64  `json:"amount"` // in paise
+	AmountRefunded int64  `json:"amount_refunded"`
+	Status         string `json:"status"`
+	Method         string `json:"method"`
+	ErrorReason    string `json:"error_reason"`
+}
+
+// VerifyWebhookSignature checks the HMAC-SHA256 of the raw body against the
+// X-Razorpay-Signature header
+func VerifyWebhookSignature(body []byte, signature, secret string) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// HandlePaymentWebhook verifies a Razorpay webhook and applies the payment
+// state transition to the matching donation. Deliveries are idempotent:
+// replays of the same event and stale transitions leave the donation unchanged.
+func (s *service) HandlePaymentWebhook(req WebhookRequest) (*WebhookResult, error) {
+	ctx := context.Background()
+
+	if s.cfg.RazorpayWebhookSecret == "" && s.cfg.RazorpayTestWebhookSecret == "" {
+		return nil, ErrWebhookNotConfigured
+	}
+
+	// Test mode deliveries are signed with their own secret and may only
+	// touch sandbox donations
+	sandbox := false
+	verified := s.cfg.RazorpayWebhookSecret != "" && VerifyWebhookSignature(req.Body, req.Signature, s.cfg.RazorpayWebhookSecret)
+	if !verified && s.cfg.RazorpayTestWebhookSecret != "" && VerifyWebhookSignature(req.Body, req.Signature, s.cfg.RazorpayTestWebhookSecret) {
+		verified, sandbox = true, true
+	}
+
+	if !verified {
+		s.auditSvc.LogAction(ctx, nil, nil, "PAYMENT_WEBHOOK_REJECTED", map[string]interface{}{
+			"event_id": req.EventID,
+			"reason":   "invalid webhook signature",
+		}, req.IPAddress, "failure")
+
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	var payload razorpayWebhookPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	payment := payload.Payload.Payment.Entity
+	result := &WebhookResult{Event: payload.Event, OrderID: payment.OrderID, Sandbox: sandbox}
+
+	var toStatus string
+	var allowedFrom []string
+	updates := map[string]interface{}{}
+	auditAction := ""
+
+	switch payload.Event {
+	case WebhookPaymentCaptured, WebhookOrderPaid:
+		// A failed attempt can still be followed by a successful retry on the same order
+		toStatus = StatusSuccess
+		allowedFrom = []string{StatusPending, StatusFailed}
+		now := time.Now()
+		updates["payment_id"] = payment.ID
+		updates["amount"] = float64(payment.Amount) / 100
+		updates["donated_at"] = &now
+		if payment.Method != "" {
+			updates["method"] = payment.Method
+		}
+		auditAction = "PAYMENT_CAPTURED"
+	case WebhookPaymentFailed:
+		toStatus = StatusFailed
+		allowedFrom = []string{StatusPending}
+		updates["payment_id"] = payment.ID
+		if payment.Method != "" {
+			updates["method"] = payment.Method
+		}
+		auditAction = "PAYMENT_FAILED"
+	case WebhookRefundProcessed:
+		// Partial refunds keep the donation successful
+		if payment.AmountRefunded < payment.Amount {
+			result.Status = WebhookIgnored
+			return result, nil
+		}
+		toStatus = StatusRefunded
+		allowedFrom = []string{StatusSuccess}
+		auditAction = "PAYMENT_REFUNDED"
+	default:
+		result.Status = WebhookIgnored
+		return result, nil
+	}
+
+	if payment.OrderID == "" {
+		result.Status = WebhookIgnored
+		return result, nil
+	}
+
+	donation, err := s.repo.GetByOrderID(ctx, payment.OrderID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Not a donation order (e.g. seva payments share the Razorpay account)
+			result.Status = WebhookIgnored
+			return result, nil
+		}
+		return nil, err
+	}
+	if donation.Sandbox != sandbox {
+		s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "PAYMENT_WEBHOOK_REJECTED", map[string]interface{}{
+			"event":    payload.Event,
+			"event_id": req.EventID,
+			"order_id": payment.OrderID,
+			"reason":   "webhook mode does not match the donation (live vs sandbox)",
+		}, req.IPAddress, "failure")
+
+		result.Status = WebhookIgnored
+		return result, nil
+	}
+
+	eventID := req.EventID
+	if eventID == "" {
+		// Fall back to a key that is stable across retries of the same event
+		ref := payment.ID
+		if payload.Event == WebhookRefundProcessed && payload.Payload.Refund.Entity.ID != "" {
+			ref = payload.Payload.Refund.Entity.ID
+		}
+		eventID = strings.Join([]string{payload.Event, ref}, ":")
+	}
+
+	event := &PaymentWebhookEvent{
+		EventID:   eventID,
+		Event:     payload.Event,
+		OrderID:   payment.OrderID,
+		PaymentID: payment.ID,
+		ToStatus:  toStatus,
+	}
+
+	applied, err := s.repo.ApplyWebhookEvent(ctx, event, allowedFrom, updates)
+	if err != nil {
+		if errors.Is(err, ErrDuplicateWebhookEvent) {
+			result.Status = WebhookDuplicate
+			return result, nil
+		}
+
+		s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, "PAYMENT_WEBHOOK_FAILED", map[string]interface{}{
+			"event":      payload.Event,
+			"event_id":   eventID,
+			"order_id":   payment.OrderID,
+			"payment_id": payment.ID,
+			"error":      err.Error(),
+		}, req.IPAddress, "failure")
+
+		return nil, err
+	}
+
+	result.FromStatus = event.FromStatus
+	if !applied {
+		result.Status = WebhookIgnored
+		result.ToStatus = event.FromStatus
+		return result, nil
+	}
+	result.Status = WebhookProcessed
+	result.ToStatus = toStatus
+
+	details := map[string]interface{}{
+		"event":         payload.Event,
+		"event_id":      eventID,
+		"order_id":      payment.OrderID,
+		"payment_id":    payment.ID,
+		"from_status":   event.FromStatus,
+		"to_status":     toStatus,
+		"amount":        float64(payment.Amount) / 100,
+		"donation_type": donation.DonationType,
+	}
+	if payment.ErrorReason != "" {
+		details["error_reason"] = payment.ErrorReason
+	}
+	if payload.Event == WebhookRefundProcessed {
+		details["refund_id"] = payload.Payload.Refund.Entity.ID
+		details["amount_refunded"] = float64(payment.AmountRefunded) / 100
+	}
+
+	auditStatus := "success"
+	if toStatus == StatusFailed {
+		auditStatus = "failure"
+	}
+	s.auditSvc.LogAction(ctx, &donation.UserID, &donation.EntityID, auditAction, details, req.IPAddress, auditStatus)
+
+	s.settleCoupon(ctx, donation, toStatus)
+	if toStatus == StatusSuccess {
+		s.issueReceipt(ctx, donation.ID, req.IPAddress)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,268 @@
+package entity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+const (
+	heatmapDefaultDays = 90
+	heatmapMaxDays     = 366
+	heatmapCacheTTL    = 10 * time.Minute
+	heatmapDefaultTZ   = "Asia/Kolkata"
+)
+
+// Activity sources counted by the heatmap
+const (
+	HeatmapSourceBookings = "bookings" // seva bookings, by booking time
+	HeatmapSourceRSVPs    = "rsvps"    // event RSVPs, by RSVP time
+)
+
+var heatmapSources = []string{HeatmapSourceBookings, HeatmapSourceRSVPs}
+
+// Weekdays index the heatmap rows, Monday first
+var heatmapWeekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+var ErrInvalidHeatmapRange = fmt.Errorf("from must be before to and the range at most %d days", heatmapMaxDays)
+
+// HeatmapGrid counts activity per weekday (Monday = 0) and hour of day
+type HeatmapGrid [7][24]int
+
+// HeatmapPeak is the busiest weekday and hour
+type HeatmapPeak struct {
+	Weekday string `json:"weekday"`
+	Hour    int    `json:"hour"`
+	Count   int    `json:"count"`
+}
+
+// ActivityHeatmap is the weekday x hour activity of a temple over a range
+type ActivityHeatmap struct {
+	EntityID    uint                   `json:"entity_id"`
+	From        string                 `json:"from"`
+	To          string                 `json:"to"`
+	Timezone    string                 `json:"timezone"`
+	Sources     []string               `json:"sources"`
+	Weekdays    []string               `json:"weekdays"`
+	Cells       HeatmapGrid            `json:"cells"` // all sources combined
+	BySource    map[string]HeatmapGrid `json:"by_source"`
+	Totals      map[string]int         `json:"totals"`
+	Total       int                    `json:"total"`
+	Peak        *HeatmapPeak           `json:"peak,omitempty"`
+	GeneratedAt time.Time              `json:"generated_at"`
+	Cached      bool                   `json:"cached"`
+}
+
+// HeatmapQuery selects the range, sources and timezone of a heatmap
+type HeatmapQuery struct {
+	From     time.Time
+	To       time.Time // inclusive day
+	Sources  []string
+	Location *time.Location
+}
+
+// =========================== REPOSITORY ===========================
+
+type heatmapCount struct {
+	Weekday int // ISO: Monday = 1
+	Hour    int
+	Count   int
+}
+
+// CountActivityByHour aggregates one source per ISO weekday and hour in tz,
+// for activity in [from, to)
+func (r *Repository) CountActivityByHour(source string, entityID uint, from, to time.Time, tz string) ([]heatmapCount, error) {
+	var query string
+	switch source {
+	case HeatmapSourceBookings:
+		query = `SELECT EXTRACT(ISODOW FROM b.booking_time AT TIME ZONE ?)::int AS weekday,
+				EXTRACT(HOUR FROM b.booking_time AT TIME ZONE ?)::int AS hour,
+				COUNT(*) AS count
+			FROM seva_bookings b
+			WHERE b.entity_id = ? AND b.booking_time >= ? AND b.booking_time < ? AND b.status <> 'rejected'
+			GROUP BY 1, 2`
+	case HeatmapSourceRSVPs:
+		query = `SELECT EXTRACT(ISODOW FROM r.rsvp_date AT TIME ZONE ?)::int AS weekday,
+				EXTRACT(HOUR FROM r.rsvp_date AT TIME ZONE ?)::int AS hour,
+				COUNT(*) AS count
+			FROM rsvps r
+			JOIN events e ON e.id = r.event_id
+			WHERE e.entity_id = ? AND r.rsvp_date >= ? AND r.rsvp_date < ? AND r.status <> 'not_attending'
+			GROUP BY 1, 2`
+	default:
+		return nil, fmt.Errorf("unknown heatmap source %q", source)
+	}
+	var out []heatmapCount
+	err := r.DB.Raw(query, tz, tz, entityID, from, to).Scan(&out).Error
+	return out, err
+}
+
+// =========================== SERVICE ===========================
+
+func heatmapCacheKey(entityID uint, q HeatmapQuery) string {
+	return fmt.Sprintf("heatmap:%d:%s:%s:%s:%s", entityID, q.From.Format("2006-01-02"), q.To.Format("2006-01-02"),
+		q.Location.String(), strings.Join(q.Sources, ","))
+}
+
+// GetActivityHeatmap returns the weekday x hour heatmap of a temple's
+// activity, from Redis when a recent one is cached
+func (s *Service) GetActivityHeatmap(ctx context.Context, entityID uint, q HeatmapQuery) (*ActivityHeatmap, error) {
+	key := heatmapCacheKey(entityID, q)
+	if utils.RedisClient != nil {
+		if val, err := utils.RedisClient.Get(ctx, key).Bytes(); err == nil {
+			var cached ActivityHeatmap
+			if json.Unmarshal(val, &cached) == nil {
+				cached.Cached = true
+				return &cached, nil
+			}
+		}
+	}
+
+	tz := q.Location.String()
+	from := time.Date(q.From.Year(), q.From.Month(), q.From.Day(), 0, 0, 0, 0, q.Location)
+	to := time.Date(q.To.Year(), q.To.Month(), q.To.Day(), 0, 0, 0, 0, q.Location).AddDate(0, 0, 1)
+
+	h := &ActivityHeatmap{
+		EntityID:    entityID,
+		From:        from.Format("2006-01-02"),
+		To:          q.To.Format("2006-01-02"),
+		Timezone:    tz,
+		Sources:     q.Sources,
+		Weekdays:    heatmapWeekdays,
+		BySource:    make(map[string]HeatmapGrid, len(q.Sources)),
+		Totals:      make(map[string]int, len(q.Sources)),
+		GeneratedAt: time.Now(),
+	}
+	for _, source := range q.Sources {
+		counts, err := s.Repo.CountActivityByHour(source, entityID, from, to, tz)
+		if err != nil {
+			return nil, fmt.Errorf("failed to aggregate %s: %w", source, err)
+		}
+		var grid HeatmapGrid
+		for _, c := range counts {
+			if c.Weekday < 1 || c.Weekday > 7 || c.Hour < 0 || c.Hour > 23 {
+				continue
+			}
+			grid[c.Weekday-1][c.Hour] += c.Count
+			h.Cells[c.Weekday-1][c.Hour] += c.Count
+			h.Totals[source] += c.Count
+			h.Total += c.Count
+		}
+		h.BySource[source] = grid
+	}
+	for day := range h.Cells {
+		for hour, count := range h.Cells[day] {
+			if count > 0 && (h.Peak == nil || count > h.Peak.Count) {
+				h.Peak = &HeatmapPeak{Weekday: heatmapWeekdays[day], Hour: hour, Count: count}
+			}
+		}
+	}
+
+	if utils.RedisClient != nil {
+		if payload, err := json.Marshal(h); err == nil {
+			if err := utils.RedisClient.Set(ctx, key, payload, heatmapCacheTTL).Err(); err != nil {
+				log.Printf("⚠️ Failed to cache activity heatmap for entity %d: %v", entityID, err)
+			}
+		}
+	}
+	return h, nil
+}
+
+// parseHeatmapQuery reads from, to (yyyy-mm-dd, default the last 90 days),
+// sources (comma separated, default all) and tz (IANA name)
+func parseHeatmapQuery(c *gin.Context) (HeatmapQuery, error) {
+	tz := c.DefaultQuery("tz", heatmapDefaultTZ)
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		return HeatmapQuery{}, fmt.Errorf("unknown timezone %q", tz)
+	}
+	q := HeatmapQuery{Location: loc}
+
+	today := time.Now().In(loc)
+	q.To = today
+	if v := c.Query("to"); v != "" {
+		if q.To, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			return HeatmapQuery{}, errors.New("to must be a date (yyyy-mm-dd)")
+		}
+	}
+	q.From = q.To.AddDate(0, 0, -(heatmapDefaultDays - 1))
+	if v := c.Query("from"); v != "" {
+		if q.From, err = time.ParseInLocation("2006-01-02", v, loc); err != nil {
+			return HeatmapQuery{}, errors.New("from must be a date (yyyy-mm-dd)")
+		}
+	}
+	if q.From.After(q.To) || q.To.Sub(q.From) > heatmapMaxDays*24*time.Hour {
+		return HeatmapQuery{}, ErrInvalidHeatmapRange
+	}
+
+	q.Sources = heatmapSources
+	if v := c.Query("sources"); v != "" {
+		seen := map[string]bool{}
+		q.Sources = nil
+		for _, source := range strings.Split(v, ",") {
+			source = strings.ToLower(strings.TrimSpace(source))
+			if source != HeatmapSourceBookings && source != HeatmapSourceRSVPs {
+				return HeatmapQuery{}, fmt.Errorf("unknown source %q, use %s", source, strings.Join(heatmapSources, " or "))
+			}
+			if !seen[source] {
+				seen[source] = true
+				q.Sources = append(q.Sources, source)
+			}
+		}
+		sort.Strings(q.Sources) // one cache key per set
+	}
+	return q, nil
+}
+
+// =========================== HANDLER ===========================
+
+// GetActivityHeatmap - GET /entities/:id/activity-heatmap?from=2025-01-01&to=2025-03-31&sources=bookings,rsvps&tz=Asia/Kolkata
+// Returns booking and RSVP counts per weekday and hour for the dashboard heatmap.
+func (h *Handler) GetActivityHeatmap(c *gin.Context) {
+	entityIDUint, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+	entityID := uint(entityIDUint)
+
+	accessContextVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return
+	}
+	accessContext, ok := accessContextVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return
+	}
+	if !accessContext.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to activity for this entity"})
+		return
+	}
+
+	q, err := parseHeatmapQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	heatmap, err := h.Service.GetActivityHeatmap(c.Request.Context(), entityID, q)
+	if err != nil {
+		log.Printf("Error building activity heatmap for entity %d: %v", entityID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build activity heatmap"})
+		return
+	}
+	c.JSON(http.StatusOK, heatmap)
+}
@@ -0,0 +1,117 @@
+package entity
+
+import (
+	"sort"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Assignment states returned to the frontend for standard/monitoring users
+const (
+	AssignmentStatusAssigned   = "assigned"
+	AssignmentStatusNoEntities = "no_entities_assigned" // tenant found, but it has no temples yet
+	AssignmentStatusNoTenant   = "no_tenant_assigned"   // user isn't assigned to any tenant
+)
+
+// EntityAssignment is the resolved set of temples a standard or monitoring
+// user can access. An empty Entities slice always comes with a non-assigned
+// Status so clients never have to guess.
+type EntityAssignment struct {
+	Status    string   `json:"status"`
+	TenantIDs []uint   `json:"tenant_ids"`
+	Entities  []Entity `json:"entities"`
+	Message   string   `json:"message,omitempty"`
+}
+
+// ResolveAssignedEntities finds the real temples available to a standard or
+// monitoring user. Tenants come only from the signed claims and the user's
+// active tenant_user_assignments. The X-Entity-ID header is client input: it
+// can narrow the result to one of those tenants or one of their temples, but
+// an ID outside them is ignored and never grants access.
+func (s *Service) ResolveAssignedEntities(userID uint, ac middleware.AccessContext) (*EntityAssignment, error) {
+	tenantSet := make(map[uint]bool)
+	if ac.TenantID != 0 {
+		tenantSet[ac.TenantID] = true
+	}
+	assigned, err := s.Repo.GetActiveTenantIDsForUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range assigned {
+		tenantSet[id] = true
+	}
+
+	result := &EntityAssignment{TenantIDs: make([]uint, 0, len(tenantSet)), Entities: []Entity{}}
+	for id := range tenantSet {
+		result.TenantIDs = append(result.TenantIDs, id)
+	}
+	sort.Slice(result.TenantIDs, func(i, j int) bool { return result.TenantIDs[i] < result.TenantIDs[j] })
+
+	if len(result.TenantIDs) == 0 {
+		result.Status = AssignmentStatusNoTenant
+		result.Message = "You are not assigned to any temple administrator yet"
+		return result, nil
+	}
+
+	entities, err := s.Repo.GetEntitiesByCreators(result.TenantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// The header may select one assigned tenant or one of their temples
+	if ac.AssignedEntityID != nil {
+		selected := *ac.AssignedEntityID
+		switch {
+		case tenantSet[selected]:
+			result.TenantIDs = []uint{selected}
+			entities = entitiesOf(entities, selected)
+		case containsEntity(entities, selected):
+			for _, e := range entities {
+				if e.ID == selected {
+					result.TenantIDs = []uint{e.CreatedBy}
+					entities = []Entity{e}
+					break
+				}
+			}
+		}
+	}
+
+	if len(entities) == 0 {
+		result.Status = AssignmentStatusNoEntities
+		result.Message = "No temples have been registered for your tenant yet"
+		return result, nil
+	}
+
+	result.Status = AssignmentStatusAssigned
+	result.Entities = entities
+	return result, nil
+}
+
+// CanAccessAssignedEntity reports whether e belongs to the user's assignment
+func (a *EntityAssignment) CanAccessAssignedEntity(e Entity) bool {
+	for _, id := range a.TenantIDs {
+		if e.CreatedBy == id {
+			return true
+		}
+	}
+	return containsEntity(a.Entities, e.ID)
+}
+
+func entitiesOf(entities []Entity, tenantID uint) []Entity {
+	out := []Entity{}
+	for _, e := range entities {
+		if e.CreatedBy == tenantID {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func containsEntity(entities []Entity, id uint) bool {
+	for _, e := range entities {
+		if e.ID == id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,541 @@
+package entity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Tenants with change review turned on (users.entity_change_review, set by a
+// superadmin) can't change the key details of an approved temple directly.
+// Edits to those fields are held back as a change request; the rest of the
+// edit applies right away. A superadmin approves or rejects the request.
+
+// Change request statuses
+const (
+	ChangeRequestPending  = "pending"
+	ChangeRequestApproved = "approved"
+	ChangeRequestRejected = "rejected"
+)
+
+var (
+	ErrChangeRequestClosed   = errors.New("change request was already reviewed")
+	ErrChangeRequestReason   = errors.New("a rejection reason is required")
+	ErrChangeRequestConflict = errors.New("another temple already uses this email")
+)
+
+// keyField is a detail of a temple that needs review once it is approved
+type keyField struct {
+	Name string // JSON and column name
+	Get  func(e *Entity) string
+	Set  func(e *Entity, v string)
+}
+
+var keyFields = []keyField{
+	{"name", func(e *Entity) string { return e.Name }, func(e *Entity, v string) { e.Name = v }},
+	{"main_deity", func(e *Entity) string {
+		if e.MainDeity == nil {
+			return ""
+		}
+		return *e.MainDeity
+	}, func(e *Entity, v string) {
+		if v == "" {
+			e.MainDeity = nil
+		} else {
+			e.MainDeity = &v
+		}
+	}},
+	{"temple_type", func(e *Entity) string { return e.TempleType }, func(e *Entity, v string) { e.TempleType = v }},
+	{"email", func(e *Entity) string { return e.Email }, func(e *Entity, v string) { e.Email = v }},
+	{"phone", func(e *Entity) string { return e.Phone }, func(e *Entity, v string) { e.Phone = v }},
+	{"street_address", func(e *Entity) string { return e.StreetAddress }, func(e *Entity, v string) { e.StreetAddress = v }},
+	{"city", func(e *Entity) string { return e.City }, func(e *Entity, v string) { e.City = v }},
+	{"district", func(e *Entity) string { return e.District }, func(e *Entity, v string) { e.District = v }},
+	{"state", func(e *Entity) string { return e.State }, func(e *Entity, v string) { e.State = v }},
+	{"pincode", func(e *Entity) string { return e.Pincode }, func(e *Entity, v string) { e.Pincode = v }},
+	{"pan", func(e *Entity) string { return e.PAN }, func(e *Entity, v string) { e.PAN = v }},
+	{"registration_80g_number", func(e *Entity) string { return e.Registration80GNumber }, func(e *Entity, v string) { e.Registration80GNumber = v }},
+}
+
+// addressFields locate the temple; changing them places it again
+var addressFields = map[string]bool{"street_address": true, "city": true, "district": true, "state": true, "pincode": true}
+
+// FieldChange is the value of a key field when the change was requested and
+// the value asked for
+type FieldChange struct {
+	Old string `json:"old"`
+	New string `json:"new"`
+}
+
+// ChangeRequest holds edits to key details of an approved temple until a
+// superadmin reviews them. A temple has at most one pending request; further
+// edits are merged into it.
+type ChangeRequest struct {
+	ID          uint           `gorm:"primaryKey" json:"id"`
+	EntityID    uint           `gorm:"not null;index" json:"entity_id"`
+	TenantID    uint           `gorm:"not null;index" json:"tenant_id"`
+	RequestedBy uint           `gorm:"not null" json:"requested_by"`
+	Status      string         `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Changes     datatypes.JSON `gorm:"type:jsonb;not null" json:"changes"` // map[field]FieldChange
+	ReviewedBy  *uint          `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time     `json:"reviewed_at,omitempty"`
+	ReviewNote  string         `gorm:"type:text" json:"review_note,omitempty"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the ChangeRequest model
+func (ChangeRequest) TableName() string {
+	return "entity_change_requests"
+}
+
+// FieldDiff compares one field of a change request with the live temple
+type FieldDiff struct {
+	Field    string `json:"field"`
+	Original string `json:"original"` // when the change was requested
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+}
+
+// ChangeRequestView is a change request with its diff against the temple
+type ChangeRequestView struct {
+	ChangeRequest
+	TempleName string      `json:"temple_name"`
+	Diff       []FieldDiff `json:"diff"`
+}
+
+func (cr *ChangeRequest) changes() map[string]FieldChange {
+	changes := map[string]FieldChange{}
+	if len(cr.Changes) > 0 {
+		_ = json.Unmarshal(cr.Changes, &changes)
+	}
+	return changes
+}
+
+// =========================== REPOSITORY ===========================
+
+// ChangeReviewEnabled reports whether the tenant's approved temples are edited
+// through change requests
+func (r *Repository) ChangeReviewEnabled(tenantID uint) (bool, error) {
+	var enabled bool
+	err := r.DB.Table("users").Select("entity_change_review").Where("id = ?", tenantID).Scan(&enabled).Error
+	return enabled, err
+}
+
+// GetPendingChangeRequest returns the temple's open change request, nil if none
+func (r *Repository) GetPendingChangeRequest(entityID uint) (*ChangeRequest, error) {
+	var cr ChangeRequest
+	err := r.DB.Where("entity_id = ? AND status = ?", entityID, ChangeRequestPending).Take(&cr).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// SaveChangeRequest creates or updates a change request
+func (r *Repository) SaveChangeRequest(cr *ChangeRequest) error {
+	return r.DB.Save(cr).Error
+}
+
+// GetChangeRequest returns a change request by ID
+func (r *Repository) GetChangeRequest(id uint) (*ChangeRequest, error) {
+	var cr ChangeRequest
+	if err := r.DB.Take(&cr, id).Error; err != nil {
+		return nil, err
+	}
+	return &cr, nil
+}
+
+// ListChangeRequests pages through change requests, newest first. A zero
+// entityID lists every temple's.
+func (r *Repository) ListChangeRequests(entityID uint, status string, page, limit int) ([]ChangeRequest, int64, error) {
+	query := r.DB.Model(&ChangeRequest{})
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	out := []ChangeRequest{}
+	err := query.Order("created_at DESC, id DESC").Offset((page - 1) * limit).Limit(limit).Find(&out).Error
+	return out, total, err
+}
+
+// ReviewChangeRequest closes a pending change request, applying the entity
+// columns when given, in one transaction
+func (r *Repository) ReviewChangeRequest(cr *ChangeRequest, entityUpdates map[string]interface{}) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&ChangeRequest{}).
+			Where("id = ? AND status = ?", cr.ID, ChangeRequestPending).
+			Updates(map[string]interface{}{
+				"status":      cr.Status,
+				"reviewed_by": cr.ReviewedBy,
+				"reviewed_at": cr.ReviewedAt,
+				"review_note": cr.ReviewNote,
+				"updated_at":  time.Now(),
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrChangeRequestClosed
+		}
+		if len(entityUpdates) == 0 {
+			return nil
+		}
+		entityUpdates["updated_at"] = time.Now()
+		return tx.Model(&Entity{}).Where("id = ?", cr.EntityID).Updates(entityUpdates).Error
+	})
+}
+
+// =========================== SERVICE ===========================
+
+// HoldKeyChanges keeps the key details of an approved temple at their current
+// values when its tenant has change review turned on. It returns the edits
+// held back, for RequestKeyChanges once the rest of the edit is saved.
+func (s *Service) HoldKeyChanges(e *Entity, existing Entity) (map[string]FieldChange, error) {
+	if !strings.EqualFold(existing.Status, "approved") {
+		return nil, nil
+	}
+	enabled, err := s.Repo.ChangeReviewEnabled(existing.CreatedBy)
+	if err != nil || !enabled {
+		return nil, err
+	}
+
+	held := map[string]FieldChange{}
+	for _, f := range keyFields {
+		current, proposed := f.Get(&existing), f.Get(e)
+		if proposed != current {
+			held[f.Name] = FieldChange{Old: current, New: proposed}
+			f.Set(e, current)
+		}
+	}
+	return held, nil
+}
+
+// RequestKeyChanges records held back edits in the temple's pending change
+// request, creating one if needed
+func (s *Service) RequestKeyChanges(existing Entity, held map[string]FieldChange, userID uint, ip string) (*ChangeRequest, error) {
+	cr, err := s.Repo.GetPendingChangeRequest(existing.ID)
+	if err != nil {
+		return nil, err
+	}
+	if cr == nil {
+		cr = &ChangeRequest{EntityID: existing.ID, TenantID: existing.CreatedBy, Status: ChangeRequestPending}
+	}
+	changes := cr.changes()
+	for field, change := range held {
+		if previous, ok := changes[field]; ok {
+			change.Old = previous.Old
+		}
+		changes[field] = change
+	}
+	raw, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+	cr.Changes = datatypes.JSON(raw)
+	cr.RequestedBy = userID
+	if err := s.Repo.SaveChangeRequest(cr); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(held))
+	for field := range held {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	s.AuditService.LogAction(context.Background(), &userID, &existing.ID, "TEMPLE_CHANGE_REQUESTED", map[string]interface{}{
+		"change_request_id": cr.ID,
+		"fields":            fields,
+	}, ip, "success")
+	return cr, nil
+}
+
+// ListChangeRequests pages through change requests with their diffs
+func (s *Service) ListChangeRequests(entityID uint, status string, page, limit int) ([]ChangeRequestView, int64, error) {
+	requests, total, err := s.Repo.ListChangeRequests(entityID, status, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	views := make([]ChangeRequestView, 0, len(requests))
+	temples := map[uint]Entity{}
+	for i := range requests {
+		e, ok := temples[requests[i].EntityID]
+		if !ok {
+			if e, err = s.Repo.GetEntityByID(int(requests[i].EntityID)); err != nil {
+				return nil, 0, err
+			}
+			temples[e.ID] = e
+		}
+		views = append(views, changeRequestView(&requests[i], &e))
+	}
+	return views, total, nil
+}
+
+// GetChangeRequest returns a change request with its diff
+func (s *Service) GetChangeRequest(id uint) (*ChangeRequestView, error) {
+	cr, err := s.Repo.GetChangeRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.Repo.GetEntityByID(int(cr.EntityID))
+	if err != nil {
+		return nil, err
+	}
+	view := changeRequestView(cr, &e)
+	return &view, nil
+}
+
+// ApproveChangeRequest applies the requested values to the temple. A changed
+// address places the temple again from its map link or the new address.
+func (s *Service) ApproveChangeRequest(id, adminID uint, note, ip string) (*ChangeRequestView, error) {
+	cr, err := s.Repo.GetChangeRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Status != ChangeRequestPending {
+		return nil, ErrChangeRequestClosed
+	}
+	e, err := s.Repo.GetEntityByID(int(cr.EntityID))
+	if err != nil {
+		return nil, err
+	}
+
+	changes := cr.changes()
+	updates := map[string]interface{}{}
+	relocate := false
+	for _, f := range keyFields {
+		change, ok := changes[f.Name]
+		if !ok {
+			continue
+		}
+		f.Set(&e, change.New)
+		if f.Name == "main_deity" {
+			updates[f.Name] = e.MainDeity
+		} else {
+			updates[f.Name] = change.New
+		}
+		relocate = relocate || addressFields[f.Name]
+	}
+	if relocate {
+		e.Latitude, e.Longitude = nil, nil
+		if err := s.applyMapLocation(&e); err == nil {
+			updates["latitude"], updates["longitude"] = e.Latitude, e.Longitude
+		}
+	}
+	if email, ok := changes["email"]; ok {
+		var count int64
+		if err := s.Repo.DB.Model(&Entity{}).Where("email = ? AND id <> ?", email.New, e.ID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, ErrChangeRequestConflict
+		}
+	}
+
+	now := time.Now()
+	cr.Status = ChangeRequestApproved
+	cr.ReviewedBy = &adminID
+	cr.ReviewedAt = &now
+	cr.ReviewNote = strings.TrimSpace(note)
+	if err := s.Repo.ReviewChangeRequest(cr, updates); err != nil {
+		return nil, err
+	}
+
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	s.AuditService.LogAction(context.Background(), &adminID, &cr.EntityID, "TEMPLE_CHANGE_APPROVED", map[string]interface{}{
+		"change_request_id": cr.ID,
+		"fields":            fields,
+		"requested_by":      cr.RequestedBy,
+	}, ip, "success")
+	return s.GetChangeRequest(cr.ID)
+}
+
+// RejectChangeRequest closes a change request without applying it
+func (s *Service) RejectChangeRequest(id, adminID uint, reason, ip string) (*ChangeRequestView, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, ErrChangeRequestReason
+	}
+	cr, err := s.Repo.GetChangeRequest(id)
+	if err != nil {
+		return nil, err
+	}
+	if cr.Status != ChangeRequestPending {
+		return nil, ErrChangeRequestClosed
+	}
+
+	now := time.Now()
+	cr.Status = ChangeRequestRejected
+	cr.ReviewedBy = &adminID
+	cr.ReviewedAt = &now
+	cr.ReviewNote = reason
+	if err := s.Repo.ReviewChangeRequest(cr, nil); err != nil {
+		return nil, err
+	}
+	s.AuditService.LogAction(context.Background(), &adminID, &cr.EntityID, "TEMPLE_CHANGE_REJECTED", map[string]interface{}{
+		"change_request_id": cr.ID,
+		"requested_by":      cr.RequestedBy,
+		"reason":            reason,
+	}, ip, "success")
+	return s.GetChangeRequest(cr.ID)
+}
+
+// changeRequestView lists the requested fields in form order
+func changeRequestView(cr *ChangeRequest, e *Entity) ChangeRequestView {
+	changes := cr.changes()
+	view := ChangeRequestView{ChangeRequest: *cr, TempleName: e.Name, Diff: []FieldDiff{}}
+	for _, f := range keyFields {
+		if change, ok := changes[f.Name]; ok {
+			view.Diff = append(view.Diff, FieldDiff{
+				Field:    f.Name,
+				Original: change.Old,
+				Current:  f.Get(e),
+				Proposed: change.New,
+			})
+		}
+	}
+	return view
+}
+
+// =========================== HANDLER ===========================
+
+// GET /entities/:id/change-requests?status=pending&page=1&limit=20
+// The temple's change requests, for its admin and staff
+func (h *Handler) ListEntityChangeRequests(c *gin.Context) {
+	entityIDUint, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+	entityID := uint(entityIDUint)
+
+	accessContextVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return
+	}
+	accessContext, ok := accessContextVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return
+	}
+	if !accessContext.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to change requests of this entity"})
+		return
+	}
+
+	h.listChangeRequests(c, entityID)
+}
+
+// GET /superadmin/entity-change-requests?status=pending&page=1&limit=20
+func (h *Handler) ListChangeRequests(c *gin.Context) {
+	h.listChangeRequests(c, 0)
+}
+
+func (h *Handler) listChangeRequests(c *gin.Context, entityID uint) {
+	page := httpx.PositiveQuery(c, "page", 1)
+	limit := min(httpx.PositiveQuery(c, "limit", 20), 100)
+	requests, total, err := h.Service.ListChangeRequests(entityID, strings.ToLower(c.Query("status")), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch change requests"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  requests,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GET /superadmin/entity-change-requests/:id
+// The change request with each field's original, current and proposed value
+func (h *Handler) GetChangeRequest(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return
+	}
+	view, err := h.Service.GetChangeRequest(uint(id))
+	if err != nil {
+		writeChangeRequestError(c, err, "Failed to fetch the change request")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": view})
+}
+
+// POST /superadmin/entity-change-requests/:id/approve {"note": "..."}
+func (h *Handler) ApproveChangeRequest(c *gin.Context) {
+	h.reviewChangeRequest(c, h.Service.ApproveChangeRequest, "Change request approved")
+}
+
+// POST /superadmin/entity-change-requests/:id/reject {"reason": "..."}
+func (h *Handler) RejectChangeRequest(c *gin.Context) {
+	h.reviewChangeRequest(c, h.Service.RejectChangeRequest, "Change request rejected")
+}
+
+func (h *Handler) reviewChangeRequest(c *gin.Context, review func(id, adminID uint, note, ip string) (*ChangeRequestView, error), message string) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid change request ID"})
+		return
+	}
+	var body struct {
+		Note   string `json:"note"`
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+	note := body.Note
+	if body.Reason != "" {
+		note = body.Reason
+	}
+
+	view, err := review(uint(id), c.GetUint("user_id"), note, middleware.GetIPFromContext(c))
+	if err != nil {
+		writeChangeRequestError(c, err, "Failed to review the change request")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "data": view})
+}
+
+func writeChangeRequestError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Change request not found"})
+	case errors.Is(err, ErrChangeRequestClosed), errors.Is(err, ErrChangeRequestConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrChangeRequestReason):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
@@ -0,0 +1,470 @@
+package entity
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	maxDevoteeImportRows = 5000
+	maxDevoteeImportSize = 5 << 20 // 5MB
+)
+
+// Outcome of one imported row
+const (
+	ImportActionCreate = "create" // new devotee account
+	ImportActionLink   = "link"   // existing devotee joins this temple
+	ImportActionSkip   = "skip"   // already a devotee of this temple
+	ImportActionError  = "error"
+)
+
+var (
+	ErrUnsupportedImportFile = errors.New("file must be a .csv or .xlsx")
+	ErrImportMissingColumns  = errors.New("header row must contain full_name, email and phone columns")
+	ErrImportTooManyRows     = fmt.Errorf("a file can contain at most %d devotees", maxDevoteeImportRows)
+
+	nonDigits = regexp.MustCompile(`\D`)
+)
+
+// DevoteeImportRow reports what happened (or, in a dry run, would happen) to
+// one row of the uploaded file. Row numbers match the spreadsheet, header = 1.
+type DevoteeImportRow struct {
+	Row      int    `json:"row"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+	Action   string `json:"action"`
+	UserID   uint   `json:"user_id,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type DevoteeImportResult struct {
+	DryRun    bool               `json:"dry_run"`
+	TotalRows int                `json:"total_rows"`
+	Created   int                `json:"created"`
+	Linked    int                `json:"linked"`
+	Skipped   int                `json:"skipped"`
+	Failed    int                `json:"failed"`
+	Rows      []DevoteeImportRow `json:"rows"`
+}
+
+// existingImportUser is an account already holding an imported email or phone
+type existingImportUser struct {
+	ID       uint
+	Email    string
+	Phone    string `gorm:"serializer:encrypted"`
+	RoleName string
+	IsMember bool
+}
+
+// =========================== REPOSITORY ===========================
+
+func (r *Repository) GetDevoteeRoleID() (uint, error) {
+	var role auth.UserRole
+	err := r.DB.Where("role_name = ?", "devotee").First(&role).Error
+	return role.ID, err
+}
+
+// FindUsersByEmailOrPhone returns the accounts using any of the given emails
+// or phones, flagging those already members of the entity
+func (r *Repository) FindUsersByEmailOrPhone(entityID uint, emails, phones []string) ([]existingImportUser, error) {
+	var out []existingImportUser
+	if len(emails) == 0 && len(phones) == 0 {
+		return out, nil
+	}
+	if emails == nil {
+		emails = []string{""}
+	}
+	// Phones are encrypted, so they are matched by blind index
+	indexes := fieldcrypto.PhoneIndexes(phones)
+	if len(indexes) == 0 {
+		indexes = []string{""}
+	}
+	err := r.DB.Table("users u").
+		Select(`u.id, u.email, u.phone, ur.role_name,
+			EXISTS (SELECT 1 FROM user_entity_memberships m WHERE m.user_id = u.id AND m.entity_id = ?) AS is_member`, entityID).
+		Joins("JOIN user_roles ur ON ur.id = u.role_id").
+		Where("u.deleted_at IS NULL").
+		Where("LOWER(u.email) IN ? OR u.phone_index IN ?", emails, indexes).
+		Scan(&out).Error
+	return out, err
+}
+
+// CreateImportedDevotee creates the devotee account and its temple membership
+func (r *Repository) CreateImportedDevotee(user *auth.User, entityID uint) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`INSERT INTO user_entity_memberships (user_id, entity_id, status, joined_at, created_at)
+			VALUES (?, ?, 'active', NOW(), NOW())`, user.ID, entityID).Error
+	})
+}
+
+// AddDevoteeMembership links an existing devotee to the entity
+func (r *Repository) AddDevoteeMembership(userID, entityID uint) error {
+	return r.DB.Exec(`INSERT INTO user_entity_memberships (user_id, entity_id, status, joined_at, created_at)
+		SELECT ?, ?, 'active', NOW(), NOW()
+		WHERE NOT EXISTS (SELECT 1 FROM user_entity_memberships WHERE user_id = ? AND entity_id = ?)`,
+		userID, entityID, userID, entityID).Error
+}
+
+// =========================== PARSING ===========================
+
+// readImportRecords returns the rows of a CSV or XLSX upload, header first
+func readImportRecords(filename string, r io.Reader) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		return reader.ReadAll()
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Excel file: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return nil, errors.New("Excel file has no sheets")
+		}
+		return f.GetRows(sheets[0])
+	default:
+		return nil, ErrUnsupportedImportFile
+	}
+}
+
+// headerColumns maps the header row to the name, email and phone column
+// positions, -1 when missing. A few common spellings are accepted so exports
+// from other tools import as-is.
+func headerColumns(header []string) (name, email, phone int) {
+	name, email, phone = -1, -1, -1
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		h = strings.NewReplacer(" ", "_", "-", "_").Replace(h)
+		switch h {
+		case "full_name", "name", "devotee_name":
+			name = i
+		case "email", "email_address":
+			email = i
+		case "phone", "phone_number", "mobile":
+			phone = i
+		}
+	}
+	return name, email, phone
+}
+
+func importColumns(header []string) (name, email, phone int, err error) {
+	name, email, phone = headerColumns(header)
+	if name < 0 || email < 0 || phone < 0 {
+		return 0, 0, 0, ErrImportMissingColumns
+	}
+	return name, email, phone, nil
+}
+
+func importCell(record []string, i int) string {
+	if i >= 0 && i < len(record) {
+		return strings.TrimSpace(record[i])
+	}
+	return ""
+}
+
+// normalizeImportPhone keeps the 10 digit mobile number, dropping a +91 prefix
+func normalizeImportPhone(raw string) (string, error) {
+	cleaned := nonDigits.ReplaceAllString(raw, "")
+	if len(cleaned) == 12 && strings.HasPrefix(cleaned, "91") {
+		cleaned = cleaned[2:]
+	}
+	if len(cleaned) != 10 {
+		return "", errors.New("phone must be a 10 digit number")
+	}
+	return cleaned, nil
+}
+
+func randomImportPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// =========================== SERVICE ===========================
+
+// ImportDevotees adds the devotees listed in a CSV/XLSX roster to the entity.
+// Rows are matched to existing accounts by email or phone: devotees elsewhere
+// are linked to this temple, other accounts are reported as errors. With
+// dryRun nothing is written and the result shows what would happen.
+func (s *Service) ImportDevotees(ctx context.Context, entityID uint, filename string, file io.Reader, dryRun bool, userID uint, ip string) (*DevoteeImportResult, error) {
+	records, err := readImportRecords(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, errors.New("file has no devotee rows")
+	}
+	if len(records)-1 > maxDevoteeImportRows {
+		return nil, ErrImportTooManyRows
+	}
+	nameCol, emailCol, phoneCol, err := importColumns(records[0])
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DevoteeImportResult{DryRun: dryRun, Rows: make([]DevoteeImportRow, 0, len(records)-1)}
+	seenEmail := map[string]int{}
+	seenPhone := map[string]int{}
+	var emails, phones []string
+
+	// Validate every row and catch duplicates inside the file
+	for i, record := range records[1:] {
+		row := DevoteeImportRow{
+			Row:      i + 2,
+			FullName: importCell(record, nameCol),
+			Email:    strings.ToLower(importCell(record, emailCol)),
+			Phone:    importCell(record, phoneCol),
+		}
+		if row.FullName == "" && row.Email == "" && row.Phone == "" {
+			continue // Blank line
+		}
+		result.TotalRows++
+
+		switch {
+		case row.FullName == "":
+			row.Error = "full_name is required"
+		case row.Email == "":
+			row.Error = "email is required"
+		default:
+			if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+				row.Error = "invalid email address"
+			} else if phone, err := normalizeImportPhone(row.Phone); err != nil {
+				row.Error = err.Error()
+			} else {
+				row.Phone = phone
+			}
+		}
+		if row.Error == "" {
+			if first, dup := seenEmail[row.Email]; dup {
+				row.Error = fmt.Sprintf("duplicate email, same as row %d", first)
+			} else if first, dup := seenPhone[row.Phone]; dup {
+				row.Error = fmt.Sprintf("duplicate phone, same as row %d", first)
+			} else {
+				seenEmail[row.Email] = row.Row
+				seenPhone[row.Phone] = row.Row
+				emails = append(emails, row.Email)
+				phones = append(phones, row.Phone)
+			}
+		}
+		if row.Error != "" {
+			row.Action = ImportActionError
+		}
+		result.Rows = append(result.Rows, row)
+	}
+
+	// Match against existing accounts
+	existing, err := s.Repo.FindUsersByEmailOrPhone(entityID, emails, phones)
+	if err != nil {
+		return nil, err
+	}
+	byEmail := map[string]existingImportUser{}
+	byPhone := map[string]existingImportUser{}
+	for _, u := range existing {
+		byEmail[strings.ToLower(u.Email)] = u
+		byPhone[fieldcrypto.NormalizePhone(u.Phone)] = u
+	}
+
+	var devoteeRoleID uint
+	if !dryRun {
+		if devoteeRoleID, err = s.Repo.GetDevoteeRoleID(); err != nil {
+			return nil, fmt.Errorf("devotee role not found: %w", err)
+		}
+	}
+
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		if row.Action == ImportActionError {
+			result.Failed++
+			continue
+		}
+
+		match, found := byEmail[row.Email]
+		if phoneMatch, ok := byPhone[row.Phone]; ok {
+			if found && phoneMatch.ID != match.ID {
+				row.Action, row.Error = ImportActionError, "email and phone belong to different accounts"
+				result.Failed++
+				continue
+			}
+			match, found = phoneMatch, true
+		}
+
+		switch {
+		case found && match.RoleName != "devotee":
+			row.Action, row.Error = ImportActionError, "email or phone is used by a non-devotee account"
+		case found && match.IsMember:
+			row.Action, row.UserID = ImportActionSkip, match.ID
+		case found:
+			row.Action, row.UserID = ImportActionLink, match.ID
+			if !dryRun {
+				if err := s.Repo.AddDevoteeMembership(match.ID, entityID); err != nil {
+					row.Action, row.Error = ImportActionError, "failed to add membership"
+				} else {
+					s.Repo.ConvertDevoteeInvitations(match.ID, entityID)
+				}
+			}
+		default:
+			row.Action = ImportActionCreate
+			if !dryRun {
+				if err := s.createImportedDevotee(row, entityID, devoteeRoleID); err != nil {
+					row.Action, row.Error = ImportActionError, err.Error()
+				}
+			}
+		}
+
+		switch row.Action {
+		case ImportActionCreate:
+			result.Created++
+		case ImportActionLink:
+			result.Linked++
+		case ImportActionSkip:
+			result.Skipped++
+		default:
+			result.Failed++
+		}
+	}
+
+	action := "DEVOTEE_BULK_IMPORT"
+	if dryRun {
+		action = "DEVOTEE_BULK_IMPORT_DRY_RUN"
+	}
+	s.AuditService.LogAction(ctx, &userID, &entityID, action, map[string]interface{}{
+		"filename":   filename,
+		"total_rows": result.TotalRows,
+		"created":    result.Created,
+		"linked":     result.Linked,
+		"skipped":    result.Skipped,
+		"failed":     result.Failed,
+	}, ip, "success")
+
+	return result, nil
+}
+
+func (s *Service) createImportedDevotee(row *DevoteeImportRow, entityID, roleID uint) error {
+	password, err := randomImportPassword()
+	if err != nil {
+		return errors.New("failed to generate password")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	// Imported devotees set their own password through forgot password
+	user := auth.User{
+		FullName:     row.FullName,
+		Email:        row.Email,
+		Phone:        row.Phone,
+		PhoneIndex:   fieldcrypto.PhoneIndex(row.Phone),
+		PasswordHash: string(hash),
+		RoleID:       roleID,
+		EntityID:     &entityID,
+		Status:       "active",
+		CreatedBy:    "bulk_import",
+	}
+	if err := s.Repo.CreateImportedDevotee(&user, entityID); err != nil {
+		return errors.New("failed to create devotee")
+	}
+	row.UserID = user.ID
+	s.Repo.ConvertDevoteeInvitations(user.ID, entityID)
+	return nil
+}
+
+// =========================== HANDLER ===========================
+
+// devoteeEntityAccess resolves the entity in the URL and checks the caller may
+// manage its devotees, writing the error response when not
+func devoteeEntityAccess(c *gin.Context, requireWrite bool) (middleware.AccessContext, uint, bool) {
+	entityIDUint, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return middleware.AccessContext{}, 0, false
+	}
+	entityID := uint(entityIDUint)
+
+	accessContextVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return middleware.AccessContext{}, 0, false
+	}
+	accessContext, ok := accessContextVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return middleware.AccessContext{}, 0, false
+	}
+	if requireWrite && !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient write permissions"})
+		return accessContext, 0, false
+	}
+	if !accessContext.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to manage devotees for this entity"})
+		return accessContext, 0, false
+	}
+	return accessContext, entityID, true
+}
+
+// BulkImportDevotees - POST /entities/:id/devotees/bulk-upload?dry_run=true
+// Multipart field "file" holds a CSV or XLSX with full_name, email and phone columns.
+func (h *Handler) BulkImportDevotees(c *gin.Context) {
+	accessContext, entityID, ok := devoteeEntityAccess(c, true)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV or XLSX file is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "devotee_import")
+	if fileHeader.Size > maxDevoteeImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large (max 5MB)"})
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer f.Close()
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	result, err := h.Service.ImportDevotees(c.Request.Context(), entityID, fileHeader.Filename, f, dryRun,
+		accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
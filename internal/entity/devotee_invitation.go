@@ -0,0 +1,590 @@
+package entity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Invitation statuses
+const (
+	InvitationPending   = "pending"
+	InvitationConverted = "converted" // the contact joined the temple as a devotee
+)
+
+// Outcome of one imported contact
+const (
+	ContactActionInvite          = "invite"
+	ContactActionExistingDevotee = "existing_devotee"
+	ContactActionAlreadyInvited  = "already_invited"
+	ContactActionDuplicateInFile = "duplicate_in_file"
+	ContactActionError           = "error"
+)
+
+// nameMatchThreshold is the name similarity, from 0 to 1, above which a
+// contact is flagged as possibly being an existing devotee
+const nameMatchThreshold = 0.9
+
+var ErrContactMissingColumns = errors.New("header row must contain a name column and a phone or email column")
+
+// DevoteeInvitation is a contact collected during a membership drive who has
+// not registered yet. It converts once a devotee with the same phone or
+// email joins the temple.
+type DevoteeInvitation struct {
+	ID                  uint       `gorm:"primaryKey" json:"id"`
+	EntityID            uint       `gorm:"not null;index" json:"entity_id"`
+	Name                string     `gorm:"size:255;not null" json:"name"`
+	Phone               string     `gorm:"size:20;index" json:"phone"`
+	Email               string     `gorm:"size:255;index" json:"email"`
+	Source              string     `gorm:"size:20" json:"source"` // vcard, csv, xlsx
+	Status              string     `gorm:"size:20;not null;default:pending;index" json:"status"`
+	PossibleDuplicateOf *uint      `json:"possible_duplicate_of,omitempty"` // devotee with a very similar name
+	InvitedBy           uint       `json:"invited_by"`
+	ConvertedUserID     *uint      `json:"converted_user_id,omitempty"`
+	ConvertedAt         *time.Time `json:"converted_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func (DevoteeInvitation) TableName() string {
+	return "devotee_invitations"
+}
+
+// ContactImportRow reports what happened to one imported contact
+type ContactImportRow struct {
+	Row           int    `json:"row"` // spreadsheet row, or position of the card in a vCard file
+	Name          string `json:"name"`
+	Phone         string `json:"phone,omitempty"`
+	Email         string `json:"email,omitempty"`
+	Action        string `json:"action"`
+	MatchedUserID uint   `json:"matched_user_id,omitempty"`
+	MatchedName   string `json:"matched_name,omitempty"`
+	Similarity    string `json:"similarity,omitempty"` // name similarity of a possible duplicate
+	InvitationID  uint   `json:"invitation_id,omitempty"`
+	Error         string `json:"error,omitempty"`
+}
+
+type ContactImportResult struct {
+	DryRun             bool               `json:"dry_run"`
+	TotalContacts      int                `json:"total_contacts"`
+	Invited            int                `json:"invited"`
+	PossibleDuplicates int                `json:"possible_duplicates"` // invited, flagged for review
+	ExistingDevotees   int                `json:"existing_devotees"`
+	AlreadyInvited     int                `json:"already_invited"`
+	Skipped            int                `json:"skipped"` // duplicates within the file and invalid contacts
+	Rows               []ContactImportRow `json:"rows"`
+}
+
+// InvitationStats tracks how a temple's invitations convert
+type InvitationStats struct {
+	Total          int64   `json:"total"`
+	Pending        int64   `json:"pending"`
+	Converted      int64   `json:"converted"`
+	ConversionRate float64 `json:"conversion_rate"` // percent
+}
+
+type contact struct {
+	Name  string
+	Phone string
+	Email string
+}
+
+type entityDevotee struct {
+	UserID   uint
+	FullName string
+	Phone    string `gorm:"serializer:encrypted"`
+	Email    string
+}
+
+// =========================== REPOSITORY ===========================
+
+// GetEntityDevotees returns the devotees who are members of the entity
+func (r *Repository) GetEntityDevotees(entityID uint) ([]entityDevotee, error) {
+	var out []entityDevotee
+	err := r.DB.Table("user_entity_memberships m").
+		Select("u.id as user_id, u.full_name, u.phone, u.email").
+		Joins("JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL").
+		Where("m.entity_id = ?", entityID).
+		Scan(&out).Error
+	return out, err
+}
+
+// GetInvitationsByEntity lists the entity's invitations, newest first,
+// optionally only those with the given status
+func (r *Repository) GetInvitationsByEntity(entityID uint, status string) ([]DevoteeInvitation, error) {
+	var out []DevoteeInvitation
+	query := r.DB.Where("entity_id = ?", entityID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").Find(&out).Error
+	return out, err
+}
+
+func (r *Repository) CreateInvitations(invitations []DevoteeInvitation) error {
+	if len(invitations) == 0 {
+		return nil
+	}
+	return r.DB.Create(&invitations).Error
+}
+
+func (r *Repository) GetInvitationStats(entityID uint) (InvitationStats, error) {
+	var stats InvitationStats
+	err := r.DB.Model(&DevoteeInvitation{}).
+		Select(`COUNT(*) as total,
+			COUNT(*) FILTER (WHERE status = ?) as pending,
+			COUNT(*) FILTER (WHERE status = ?) as converted`, InvitationPending, InvitationConverted).
+		Where("entity_id = ?", entityID).
+		Scan(&stats).Error
+	if stats.Total > 0 {
+		stats.ConversionRate = float64(stats.Converted) * 100 / float64(stats.Total)
+	}
+	return stats, err
+}
+
+// ConvertDevoteeInvitations marks the entity's pending invitations matching
+// the user's phone or email as converted by that user
+func (r *Repository) ConvertDevoteeInvitations(userID, entityID uint) error {
+	// The user's phone is encrypted, so it is loaded and compared here
+	var user struct {
+		Phone string `gorm:"serializer:encrypted"`
+		Email string
+	}
+	res := r.DB.Table("users").Select("phone, email").Where("id = ?", userID).Limit(1).Find(&user)
+	if res.Error != nil || res.RowsAffected == 0 {
+		return res.Error
+	}
+	match := r.DB.Where("email <> '' AND LOWER(email) = LOWER(?)", user.Email)
+	if phone := fieldcrypto.NormalizePhone(user.Phone); phone != "" {
+		match = match.Or(`phone <> '' AND RIGHT(regexp_replace(phone, '\D', '', 'g'), 10) = ?`, phone)
+	}
+	return r.DB.Model(&DevoteeInvitation{}).
+		Where("entity_id = ? AND status = ?", entityID, InvitationPending).
+		Where(match).
+		Updates(map[string]interface{}{
+			"status":            InvitationConverted,
+			"converted_user_id": userID,
+			"converted_at":      time.Now(),
+		}).Error
+}
+
+// =========================== PARSING ===========================
+
+// readContacts parses a vCard, CSV or XLSX contact dump
+func readContacts(filename string, r io.Reader) ([]contact, string, error) {
+	ext := strings.ToLower(filepath.Ext(filename))
+	if ext == ".vcf" || ext == ".vcard" {
+		contacts, err := parseVCards(r)
+		return contacts, "vcard", err
+	}
+
+	records, err := readImportRecords(filename, r)
+	if errors.Is(err, ErrUnsupportedImportFile) {
+		return nil, "", errors.New("file must be a .vcf, .csv or .xlsx")
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	if len(records) < 2 {
+		return nil, "", errors.New("file has no contacts")
+	}
+	nameCol, emailCol, phoneCol := headerColumns(records[0])
+	if nameCol < 0 || (emailCol < 0 && phoneCol < 0) {
+		return nil, "", ErrContactMissingColumns
+	}
+
+	contacts := make([]contact, 0, len(records)-1)
+	for _, record := range records[1:] {
+		contacts = append(contacts, contact{
+			Name:  importCell(record, nameCol),
+			Phone: importCell(record, phoneCol),
+			Email: importCell(record, emailCol),
+		})
+	}
+	return contacts, strings.TrimPrefix(ext, "."), nil
+}
+
+// parseVCards reads the name, first phone and first email of every card.
+// Phone exports write vCard 2.1 or 3.0; only the properties needed here are
+// understood.
+func parseVCards(r io.Reader) ([]contact, error) {
+	var (
+		contacts []contact
+		current  *contact
+		lines    []string
+	)
+
+	// Unfold continuation lines (starting with a space or tab)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("invalid vCard file: %w", err)
+	}
+
+	for _, line := range lines {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		params := strings.Split(key, ";")
+		prop := strings.ToUpper(params[0])
+		if i := strings.LastIndex(prop, "."); i >= 0 {
+			prop = prop[i+1:] // drop group prefixes such as item1.TEL
+		}
+		value = unescapeVCard(strings.TrimSpace(value))
+
+		switch {
+		case prop == "BEGIN" && strings.EqualFold(value, "VCARD"):
+			current = &contact{}
+		case prop == "END" && strings.EqualFold(value, "VCARD"):
+			if current != nil {
+				contacts = append(contacts, *current)
+			}
+			current = nil
+		case current == nil:
+		case prop == "FN":
+			current.Name = value
+		case prop == "N" && current.Name == "":
+			// Family;Given;Middle;Prefix;Suffix
+			parts := strings.Split(value, ";")
+			var name []string
+			for _, i := range []int{3, 1, 2, 0, 4} {
+				if i < len(parts) && strings.TrimSpace(parts[i]) != "" {
+					name = append(name, strings.TrimSpace(parts[i]))
+				}
+			}
+			current.Name = strings.Join(name, " ")
+		case prop == "TEL" && current.Phone == "":
+			current.Phone = strings.TrimPrefix(value, "tel:")
+		case prop == "EMAIL" && current.Email == "":
+			current.Email = value
+		}
+	}
+	if len(contacts) == 0 {
+		return nil, errors.New("no contacts found in vCard file")
+	}
+	return contacts, nil
+}
+
+func unescapeVCard(s string) string {
+	return strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ", `\N`, " ", `\\`, `\`).Replace(s)
+}
+
+// normalizeName lowercases the name and keeps only letters and single spaces
+// so that "Sri. Ramesh  K" and "sri ramesh k" compare equal
+func normalizeName(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r):
+			if space && b.Len() > 0 {
+				b.WriteRune(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// nameSimilarity returns 1 minus the normalised Levenshtein distance of two
+// normalised names
+func nameSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	longest := max(len(ra), len(rb))
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// =========================== SERVICE ===========================
+
+// ImportContacts turns a phone book dump into invitations for the entity.
+// Contacts whose phone or email belongs to a devotee of the temple, or who
+// were invited before, are skipped; contacts whose name closely matches a
+// devotee are invited but flagged for review. With dryRun nothing is saved.
+func (s *Service) ImportContacts(ctx context.Context, entityID uint, filename string, file io.Reader, dryRun bool, userID uint, ip string) (*ContactImportResult, error) {
+	contacts, source, err := readContacts(filename, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(contacts) > maxDevoteeImportRows {
+		return nil, ErrImportTooManyRows
+	}
+
+	devotees, err := s.Repo.GetEntityDevotees(entityID)
+	if err != nil {
+		return nil, err
+	}
+	invitations, err := s.Repo.GetInvitationsByEntity(entityID, "")
+	if err != nil {
+		return nil, err
+	}
+
+	devoteeByPhone := map[string]entityDevotee{}
+	devoteeByEmail := map[string]entityDevotee{}
+	devoteeNames := make([]string, len(devotees))
+	for i, d := range devotees {
+		if phone, err := normalizeImportPhone(d.Phone); err == nil {
+			devoteeByPhone[phone] = d
+		}
+		if d.Email != "" {
+			devoteeByEmail[strings.ToLower(d.Email)] = d
+		}
+		devoteeNames[i] = normalizeName(d.FullName)
+	}
+	invited := map[string]bool{}
+	for _, inv := range invitations {
+		if inv.Phone != "" {
+			invited["p:"+inv.Phone] = true
+		}
+		if inv.Email != "" {
+			invited["e:"+strings.ToLower(inv.Email)] = true
+		}
+	}
+
+	result := &ContactImportResult{DryRun: dryRun, Rows: make([]ContactImportRow, 0, len(contacts))}
+	seen := map[string]int{}
+	var toCreate []DevoteeInvitation
+	var createdRows []int
+
+	for i, ct := range contacts {
+		row := ContactImportRow{Row: i + 1, Name: strings.TrimSpace(ct.Name)}
+		if source != "vcard" {
+			row.Row = i + 2 // header is row 1
+		}
+		if row.Name == "" && strings.TrimSpace(ct.Phone) == "" && strings.TrimSpace(ct.Email) == "" {
+			continue
+		}
+		result.TotalContacts++
+
+		if ct.Phone != "" {
+			if phone, err := normalizeImportPhone(ct.Phone); err == nil {
+				row.Phone = phone
+			}
+		}
+		if email := strings.ToLower(strings.TrimSpace(ct.Email)); email != "" {
+			if addr, err := mail.ParseAddress(email); err == nil && addr.Address == email {
+				row.Email = email
+			}
+		}
+
+		switch {
+		case row.Name == "":
+			row.Action, row.Error = ContactActionError, "name is required"
+		case row.Phone == "" && row.Email == "":
+			row.Action, row.Error = ContactActionError, "a valid phone or email is required"
+		}
+		if row.Action == ContactActionError {
+			result.Skipped++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		// Duplicates within the file
+		for _, key := range []string{"p:" + row.Phone, "e:" + row.Email} {
+			if first, dup := seen[key]; dup && len(key) > 2 {
+				row.Action, row.Error = ContactActionDuplicateInFile, fmt.Sprintf("same contact as row %d", first)
+				break
+			}
+		}
+		if row.Action != "" {
+			result.Skipped++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+		if row.Phone != "" {
+			seen["p:"+row.Phone] = row.Row
+		}
+		if row.Email != "" {
+			seen["e:"+row.Email] = row.Row
+		}
+
+		// Existing devotees and earlier invitations
+		if d, ok := devoteeByPhone[row.Phone]; ok && row.Phone != "" {
+			row.Action, row.MatchedUserID, row.MatchedName = ContactActionExistingDevotee, d.UserID, d.FullName
+		} else if d, ok := devoteeByEmail[row.Email]; ok && row.Email != "" {
+			row.Action, row.MatchedUserID, row.MatchedName = ContactActionExistingDevotee, d.UserID, d.FullName
+		} else if (row.Phone != "" && invited["p:"+row.Phone]) || (row.Email != "" && invited["e:"+row.Email]) {
+			row.Action = ContactActionAlreadyInvited
+		}
+		switch row.Action {
+		case ContactActionExistingDevotee:
+			result.ExistingDevotees++
+			result.Rows = append(result.Rows, row)
+			continue
+		case ContactActionAlreadyInvited:
+			result.AlreadyInvited++
+			result.Rows = append(result.Rows, row)
+			continue
+		}
+
+		// Similar names are likely the same person with a new number
+		inv := DevoteeInvitation{
+			EntityID:  entityID,
+			Name:      row.Name,
+			Phone:     row.Phone,
+			Email:     row.Email,
+			Source:    source,
+			Status:    InvitationPending,
+			InvitedBy: userID,
+		}
+		name := normalizeName(row.Name)
+		best, bestIdx := 0.0, -1
+		for j, devoteeName := range devoteeNames {
+			if sim := nameSimilarity(name, devoteeName); sim > best {
+				best, bestIdx = sim, j
+			}
+		}
+		if bestIdx >= 0 && best >= nameMatchThreshold {
+			match := devotees[bestIdx]
+			row.MatchedUserID, row.MatchedName = match.UserID, match.FullName
+			row.Similarity = strconv.FormatFloat(best, 'f', 2, 64)
+			inv.PossibleDuplicateOf = &match.UserID
+			result.PossibleDuplicates++
+		}
+
+		row.Action = ContactActionInvite
+		result.Invited++
+		toCreate = append(toCreate, inv)
+		createdRows = append(createdRows, len(result.Rows))
+		result.Rows = append(result.Rows, row)
+	}
+
+	if !dryRun {
+		if err := s.Repo.CreateInvitations(toCreate); err != nil {
+			s.AuditService.LogAction(ctx, &userID, &entityID, "DEVOTEE_CONTACT_IMPORT_FAILED", map[string]interface{}{
+				"filename": filename,
+				"error":    err.Error(),
+			}, ip, "failure")
+			return nil, fmt.Errorf("failed to save invitations: %w", err)
+		}
+		for i, rowIdx := range createdRows {
+			result.Rows[rowIdx].InvitationID = toCreate[i].ID
+		}
+
+		s.AuditService.LogAction(ctx, &userID, &entityID, "DEVOTEE_CONTACT_IMPORT", map[string]interface{}{
+			"filename":            filename,
+			"source":              source,
+			"total_contacts":      result.TotalContacts,
+			"invited":             result.Invited,
+			"possible_duplicates": result.PossibleDuplicates,
+			"existing_devotees":   result.ExistingDevotees,
+			"already_invited":     result.AlreadyInvited,
+			"skipped":             result.Skipped,
+		}, ip, "success")
+	}
+
+	return result, nil
+}
+
+// GetInvitations lists the entity's invitations with its conversion stats
+func (s *Service) GetInvitations(entityID uint, status string) ([]DevoteeInvitation, InvitationStats, error) {
+	invitations, err := s.Repo.GetInvitationsByEntity(entityID, status)
+	if err != nil {
+		return nil, InvitationStats{}, err
+	}
+	stats, err := s.Repo.GetInvitationStats(entityID)
+	return invitations, stats, err
+}
+
+// =========================== HANDLER ===========================
+
+// ImportDevoteeContacts - POST /entities/:id/devotees/invitations/import?dry_run=true
+// Multipart field "file" holds a vCard (.vcf) or CSV/XLSX contact dump.
+func (h *Handler) ImportDevoteeContacts(c *gin.Context) {
+	accessContext, entityID, ok := devoteeEntityAccess(c, true)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vCard, CSV or XLSX file is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "devotee_contacts")
+	if fileHeader.Size > maxDevoteeImportSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "file too large (max 5MB)"})
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer f.Close()
+
+	dryRun, _ := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+
+	result, err := h.Service.ImportContacts(c.Request.Context(), entityID, fileHeader.Filename, f, dryRun,
+		accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDevoteeInvitations - GET /entities/:id/devotees/invitations?status=pending
+// Lists invitations with the temple's conversion stats.
+func (h *Handler) GetDevoteeInvitations(c *gin.Context) {
+	_, entityID, ok := devoteeEntityAccess(c, false)
+	if !ok {
+		return
+	}
+
+	invitations, stats, err := h.Service.GetInvitations(entityID, c.Query("status"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch invitations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"invitations": invitations,
+		"stats":       stats,
+	})
+}
@@ -0,0 +1,739 @@
+package entity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/scanner"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Resumable uploads: the client starts an upload, PUTs the file in chunks
+// (any order, retrying failed ones) and completes it. Chunks are kept in
+// storage under temp_uploads/<upload id>/ so any API instance can take them.
+const (
+	defaultChunkSize   = 5 << 20
+	minChunkSize       = 256 << 10
+	maxChunkSize       = 20 << 20
+	maxResumableSize   = 200 << 20
+	maxFilesPerRequest = 10
+	uploadExpiry       = 24 * time.Hour
+)
+
+// File categories. The document categories of the registration form also
+// fill the matching document fields of the entity; other files are only listed.
+const (
+	FileCategoryOther = "other"
+)
+
+var fileCategories = map[string]bool{
+	"registration_cert":    true,
+	"trust_deed":           true,
+	"property_docs":        true,
+	additionalDocsFileType: true,
+	FileCategoryOther:      true,
+}
+
+var (
+	ErrUploadNotFound   = errors.New("upload not found")
+	ErrUploadExpired    = errors.New("upload has expired, start it again")
+	ErrUploadIncomplete = errors.New("upload is missing chunks")
+	ErrInvalidChunk     = errors.New("chunk index out of range")
+	ErrChunkSize        = errors.New("chunk has the wrong size")
+)
+
+// EntityFile is a file uploaded to a temple with its metadata
+type EntityFile struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	EntityID     uint      `gorm:"not null;index" json:"entity_id"`
+	FileName     string    `gorm:"size:255;not null" json:"file_name"` // stored name
+	OriginalName string    `gorm:"size:255;not null" json:"original_name"`
+	ContentType  string    `gorm:"size:100" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	Category     string    `gorm:"size:50;not null;index" json:"category"`
+	Description  string    `gorm:"type:text" json:"description"`
+	StorageKey   string    `gorm:"size:500;not null;uniqueIndex" json:"-"`
+	FileURL      string    `gorm:"size:500" json:"file_url"`
+	UploadedBy   uint      `gorm:"not null" json:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// FileUpload is a resumable upload in progress
+type FileUpload struct {
+	ID           string    `gorm:"primaryKey;size:36" json:"upload_id"`
+	EntityID     uint      `gorm:"not null;index" json:"entity_id"`
+	OriginalName string    `gorm:"size:255;not null" json:"file_name"`
+	ContentType  string    `gorm:"size:100" json:"content_type"`
+	Size         int64     `gorm:"not null" json:"size"`
+	ChunkSize    int64     `gorm:"not null" json:"chunk_size"`
+	TotalChunks  int       `gorm:"not null" json:"total_chunks"`
+	Category     string    `gorm:"size:50;not null" json:"category"`
+	Description  string    `gorm:"type:text" json:"description"`
+	CreatedBy    uint      `gorm:"not null" json:"created_by"`
+	ExpiresAt    time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+func (FileUpload) TableName() string {
+	return "entity_file_uploads"
+}
+
+// FileMetadata describes one file of a multi-file upload
+type FileMetadata struct {
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// StartUploadRequest starts a resumable upload
+type StartUploadRequest struct {
+	FileName    string `json:"file_name" binding:"required"`
+	Size        int64  `json:"size" binding:"required,gt=0"`
+	ContentType string `json:"content_type"`
+	ChunkSize   int64  `json:"chunk_size"` // default 5 MB
+	Category    string `json:"category"`
+	Description string `json:"description"`
+}
+
+// UploadStatus tells a client which chunks to (re)send
+type UploadStatus struct {
+	FileUpload
+	Received []int `json:"received_chunks"`
+	Missing  []int `json:"missing_chunks"`
+}
+
+// =========================== REPOSITORY ===========================
+
+func (r *Repository) CreateEntityFile(f *EntityFile) error {
+	return r.DB.Create(f).Error
+}
+
+func (r *Repository) ListEntityFiles(entityID uint) ([]EntityFile, error) {
+	var files []EntityFile
+	err := r.DB.Where("entity_id = ?", entityID).Order("created_at DESC").Find(&files).Error
+	return files, err
+}
+
+func (r *Repository) CreateFileUpload(u *FileUpload) error {
+	return r.DB.Create(u).Error
+}
+
+func (r *Repository) GetFileUpload(entityID uint, id string) (*FileUpload, error) {
+	var u FileUpload
+	if err := r.DB.Where("id = ? AND entity_id = ?", id, entityID).First(&u).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUploadNotFound
+		}
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (r *Repository) DeleteFileUpload(id string) error {
+	return r.DB.Where("id = ?", id).Delete(&FileUpload{}).Error
+}
+
+// ExpiredFileUploads returns uploads nobody completed in time
+func (r *Repository) ExpiredFileUploads(entityID uint, now time.Time) ([]FileUpload, error) {
+	var uploads []FileUpload
+	err := r.DB.Where("entity_id = ? AND expires_at < ?", entityID, now).Find(&uploads).Error
+	return uploads, err
+}
+
+// AttachDocument sets the entity's document field of the category to the
+// file, or appends it to the additional documents
+func (r *Repository) AttachDocument(entityID uint, category string, fi FileInfo) error {
+	info, err := json.Marshal(fi)
+	if err != nil {
+		return err
+	}
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var e Entity
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "additional_docs_urls", "additional_docs_info").
+			First(&e, entityID).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{}
+		switch category {
+		case "registration_cert":
+			updates["registration_cert_url"], updates["registration_cert_info"] = fi.FileURL, string(info)
+		case "trust_deed":
+			updates["trust_deed_url"], updates["trust_deed_info"] = fi.FileURL, string(info)
+		case "property_docs":
+			updates["property_docs_url"], updates["property_docs_info"] = fi.FileURL, string(info)
+		case additionalDocsFileType:
+			var urls []string
+			var infos []FileInfo
+			_ = json.Unmarshal([]byte(e.AdditionalDocsURLs), &urls)
+			_ = json.Unmarshal([]byte(e.AdditionalDocsInfo), &infos)
+			urls = append(urls, fi.FileURL)
+			infos = append(infos, fi)
+			urlsJSON, _ := json.Marshal(urls)
+			infosJSON, _ := json.Marshal(infos)
+			updates["additional_docs_urls"], updates["additional_docs_info"] = string(urlsJSON), string(infosJSON)
+		default:
+			return nil
+		}
+		updates["updated_at"] = time.Now()
+		return tx.Model(&Entity{}).Where("id = ?", entityID).Updates(updates).Error
+	})
+}
+
+// =========================== SERVICE ===========================
+
+// storedFileName is the name a file is kept under, unique within the entity
+func storedFileName(originalName string) string {
+	ext := strings.ToLower(filepath.Ext(originalName))
+	return fmt.Sprintf("%s_%d%s", uuid.New().String(), time.Now().Unix(), ext)
+}
+
+func validateFileMetadata(name, category string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(name))
+	if !allowedDocumentExtensions[ext] {
+		return "", fmt.Errorf("file type %s not allowed", ext)
+	}
+	if category == "" {
+		return additionalDocsFileType, nil
+	}
+	if !fileCategories[category] {
+		return "", fmt.Errorf("unknown file category %q", category)
+	}
+	return category, nil
+}
+
+// storeEntityFile puts r in the entity's storage, records its metadata and
+// attaches it to the entity
+func (h *Handler) storeEntityFile(ctx context.Context, entityID, userID uint, originalName, contentType string, size int64,
+	category, description string, r io.Reader) (*EntityFile, error) {
+	name := storedFileName(originalName)
+	key, err := storage.Key(strconv.FormatUint(uint64(entityID), 10), name)
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = sniffOrByExt(strings.ToLower(filepath.Ext(originalName)))
+	}
+	if err := h.Storage.Put(ctx, key, r, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+
+	f := &EntityFile{
+		EntityID:     entityID,
+		FileName:     name,
+		OriginalName: filepath.Base(originalName),
+		ContentType:  contentType,
+		Size:         size,
+		Category:     category,
+		Description:  description,
+		StorageKey:   key,
+		FileURL:      h.buildFileURL(key),
+		UploadedBy:   userID,
+	}
+	if err := h.Service.Repo.CreateEntityFile(f); err != nil {
+		_ = h.Storage.Delete(ctx, key)
+		return nil, fmt.Errorf("failed to record file: %w", err)
+	}
+	if err := h.Service.Repo.AttachDocument(entityID, category, FileInfo{
+		FileName:     f.FileName,
+		FileURL:      f.FileURL,
+		FileSize:     f.Size,
+		FileType:     f.ContentType,
+		UploadedAt:   f.CreatedAt,
+		OriginalName: f.OriginalName,
+	}); err != nil {
+		log.Printf("⚠️ Failed to attach file %s to entity %d: %v", key, entityID, err)
+	}
+	return f, nil
+}
+
+func chunkPrefix(uploadID string) (string, error) {
+	return storage.Key(storage.TempPrefix, uploadID)
+}
+
+func chunkKey(uploadID string, index int) (string, error) {
+	return storage.Key(storage.TempPrefix, uploadID, fmt.Sprintf("%06d", index))
+}
+
+// expectedChunkSize is the size of chunk index; only the last may be shorter
+func (u *FileUpload) expectedChunkSize(index int) int64 {
+	if index == u.TotalChunks-1 {
+		return u.Size - int64(u.TotalChunks-1)*u.ChunkSize
+	}
+	return u.ChunkSize
+}
+
+// receivedChunks lists the chunk indexes already in storage
+func (h *Handler) receivedChunks(ctx context.Context, u *FileUpload) ([]int, error) {
+	prefix, err := chunkPrefix(u.ID)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := h.Storage.List(ctx, prefix+"/")
+	if err != nil {
+		return nil, err
+	}
+	var received []int
+	for _, obj := range objects {
+		i, err := strconv.Atoi(path.Base(obj.Key))
+		if err == nil && i >= 0 && i < u.TotalChunks && obj.Size == u.expectedChunkSize(i) {
+			received = append(received, i)
+		}
+	}
+	sort.Ints(received)
+	return received, nil
+}
+
+// discardUpload removes an upload and its chunks
+func (h *Handler) discardUpload(ctx context.Context, u *FileUpload) {
+	if prefix, err := chunkPrefix(u.ID); err == nil {
+		if err := storage.DeletePrefix(ctx, h.Storage, prefix+"/"); err != nil {
+			log.Printf("⚠️ Failed to delete chunks of upload %s: %v", u.ID, err)
+		}
+	}
+	if err := h.Service.Repo.DeleteFileUpload(u.ID); err != nil {
+		log.Printf("⚠️ Failed to delete upload %s: %v", u.ID, err)
+	}
+}
+
+// loadUpload returns an upload of the entity that hasn't expired
+func (h *Handler) loadUpload(ctx context.Context, entityID uint, id string) (*FileUpload, error) {
+	u, err := h.Service.Repo.GetFileUpload(entityID, id)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(u.ExpiresAt) {
+		h.discardUpload(ctx, u)
+		return nil, ErrUploadExpired
+	}
+	return u, nil
+}
+
+// chunkReader reads the chunks of an upload one after another, opening each
+// only when the previous one is used up
+type chunkReader struct {
+	ctx     context.Context
+	store   storage.Storage
+	upload  *FileUpload
+	next    int
+	current io.ReadCloser
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for {
+		if r.current == nil {
+			if r.next >= r.upload.TotalChunks {
+				return 0, io.EOF
+			}
+			key, err := chunkKey(r.upload.ID, r.next)
+			if err != nil {
+				return 0, err
+			}
+			rc, _, err := r.store.Get(r.ctx, key)
+			if err != nil {
+				return 0, fmt.Errorf("chunk %d: %w", r.next, err)
+			}
+			r.current = rc
+			r.next++
+		}
+		n, err := r.current.Read(p)
+		if err == io.EOF {
+			r.current.Close()
+			r.current = nil
+			if n > 0 {
+				return n, nil
+			}
+			continue
+		}
+		return n, err
+	}
+}
+
+func (r *chunkReader) Close() error {
+	if r.current != nil {
+		return r.current.Close()
+	}
+	return nil
+}
+
+// =========================== HANDLER ===========================
+
+// fileEntityAccess resolves the entity in the URL and checks the caller may
+// see (or, with requireWrite, change) its files
+func fileEntityAccess(c *gin.Context, requireWrite bool) (middleware.AccessContext, uint, bool) {
+	entityIDUint, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return middleware.AccessContext{}, 0, false
+	}
+	entityID := uint(entityIDUint)
+
+	accessContextVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return middleware.AccessContext{}, 0, false
+	}
+	accessContext, ok := accessContextVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return middleware.AccessContext{}, 0, false
+	}
+	if requireWrite && !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient write permissions"})
+		return accessContext, 0, false
+	}
+	if !accessContext.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to files for this entity"})
+		return accessContext, 0, false
+	}
+	return accessContext, entityID, true
+}
+
+func writeUploadError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrUploadNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUploadExpired):
+		c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrInvalidChunk), errors.Is(err, ErrChunkSize):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrUploadIncomplete):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrQuotaExceeded):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "code": "storage_quota_exceeded"})
+	case errors.Is(err, storage.ErrRegionUnavailable), errors.Is(err, storage.ErrCrossRegion):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "File upload failed"})
+	}
+}
+
+// UploadEntityFiles - POST /entities/:id/files
+// Multipart field "files" holds up to 10 files. "metadata" is an optional
+// JSON array of {category, description}, one per file in the same order;
+// "category" and "description" apply to files without their own.
+func (h *Handler) UploadEntityFiles(c *gin.Context) {
+	accessContext, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	form, err := c.MultipartForm()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return
+	}
+	files := form.File["files"]
+	if len(files) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No files in field \"files\""})
+		return
+	}
+	if len(files) > maxFilesPerRequest {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("At most %d files per request", maxFilesPerRequest)})
+		return
+	}
+	var metadata []FileMetadata
+	if raw := h.getFormValue(form, "metadata"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &metadata); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "metadata must be a JSON array of {category, description}"})
+			return
+		}
+	}
+	shared := FileMetadata{Category: h.getFormValue(form, "category"), Description: h.getFormValue(form, "description")}
+
+	// check every file before storing any
+	metas := make([]FileMetadata, len(files))
+	for i, file := range files {
+		meta := shared
+		if i < len(metadata) {
+			if metadata[i].Category != "" {
+				meta.Category = metadata[i].Category
+			}
+			if metadata[i].Description != "" {
+				meta.Description = metadata[i].Description
+			}
+		}
+		if err := h.validateFile(file); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %v", file.Filename, err)})
+			return
+		}
+		category, err := validateFileMetadata(file.Filename, meta.Category)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: %v", file.Filename, err)})
+			return
+		}
+		meta.Category = category
+		metas[i] = meta
+	}
+
+	ctx := c.Request.Context()
+	for _, file := range files {
+		err := h.scanUpload(ctx, file.Filename, file.Size, func() (io.ReadCloser, error) { return file.Open() })
+		if err != nil {
+			if !h.rejectScannedUpload(c, &entityID, err) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: failed to read file", file.Filename)})
+			}
+			return
+		}
+	}
+
+	ip := middleware.GetIPFromContext(c)
+	stored := make([]*EntityFile, 0, len(files))
+	for i, file := range files {
+		metrics.UploadSize.Observe(float64(file.Size), "entity_file")
+		src, err := file.Open()
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s: failed to read file", file.Filename)})
+			return
+		}
+		f, err := h.storeEntityFile(ctx, entityID, accessContext.UserID, file.Filename, file.Header.Get("Content-Type"), file.Size,
+			metas[i].Category, metas[i].Description, src)
+		src.Close()
+		if err != nil {
+			log.Printf("Failed to store %s for entity %d: %v", file.Filename, entityID, err)
+			h.Service.AuditService.LogAction(ctx, &accessContext.UserID, &entityID, "ENTITY_FILES_UPLOAD_FAILED", map[string]interface{}{
+				"file_name": file.Filename,
+				"stored":    len(stored),
+				"error":     err.Error(),
+			}, ip, "failure")
+			writeUploadError(c, err)
+			return
+		}
+		stored = append(stored, f)
+	}
+
+	names := make([]string, len(stored))
+	for i, f := range stored {
+		names[i] = f.OriginalName
+	}
+	h.Service.AuditService.LogAction(ctx, &accessContext.UserID, &entityID, "ENTITY_FILES_UPLOADED", map[string]interface{}{
+		"files": names,
+	}, ip, "success")
+	c.JSON(http.StatusCreated, gin.H{"message": fmt.Sprintf("%d file(s) uploaded", len(stored)), "data": stored})
+}
+
+// StartFileUpload - POST /entities/:id/files/uploads
+func (h *Handler) StartFileUpload(c *gin.Context) {
+	accessContext, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	var req StartUploadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	category, err := validateFileMetadata(req.FileName, req.Category)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Size > maxResumableSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("file size exceeds %dMB limit", maxResumableSize>>20)})
+		return
+	}
+	chunkSize := req.ChunkSize
+	if chunkSize == 0 {
+		chunkSize = defaultChunkSize
+	}
+	if chunkSize < minChunkSize || chunkSize > maxChunkSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("chunk_size must be between %d and %d bytes", minChunkSize, maxChunkSize)})
+		return
+	}
+
+	ctx := c.Request.Context()
+	if expired, err := h.Service.Repo.ExpiredFileUploads(entityID, time.Now()); err == nil {
+		for i := range expired {
+			h.discardUpload(ctx, &expired[i])
+		}
+	}
+
+	u := &FileUpload{
+		ID:           uuid.New().String(),
+		EntityID:     entityID,
+		OriginalName: filepath.Base(req.FileName),
+		ContentType:  req.ContentType,
+		Size:         req.Size,
+		ChunkSize:    chunkSize,
+		TotalChunks:  int((req.Size + chunkSize - 1) / chunkSize),
+		Category:     category,
+		Description:  req.Description,
+		CreatedBy:    accessContext.UserID,
+		ExpiresAt:    time.Now().Add(uploadExpiry),
+	}
+	if err := h.Service.Repo.CreateFileUpload(u); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start upload"})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"data": u})
+}
+
+// GetFileUpload - GET /entities/:id/files/uploads/:uploadId
+// Lists received and missing chunks so an interrupted upload can resume.
+func (h *Handler) GetFileUpload(c *gin.Context) {
+	_, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	u, err := h.loadUpload(ctx, entityID, c.Param("uploadId"))
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	received, err := h.receivedChunks(ctx, u)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	status := UploadStatus{FileUpload: *u, Received: received, Missing: []int{}}
+	have := make(map[int]bool, len(received))
+	for _, i := range received {
+		have[i] = true
+	}
+	for i := 0; i < u.TotalChunks; i++ {
+		if !have[i] {
+			status.Missing = append(status.Missing, i)
+		}
+	}
+	if status.Received == nil {
+		status.Received = []int{}
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// UploadFileChunk - PUT /entities/:id/files/uploads/:uploadId/chunks/:index
+// The request body is the raw chunk. Chunks may be sent again.
+func (h *Handler) UploadFileChunk(c *gin.Context) {
+	_, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	u, err := h.loadUpload(ctx, entityID, c.Param("uploadId"))
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil || index < 0 || index >= u.TotalChunks {
+		writeUploadError(c, ErrInvalidChunk)
+		return
+	}
+
+	want := u.expectedChunkSize(index)
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, want+1))
+	if err != nil || int64(len(body)) != want {
+		writeUploadError(c, fmt.Errorf("%w: chunk %d must be %d bytes", ErrChunkSize, index, want))
+		return
+	}
+	key, err := chunkKey(u.ID, index)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	if err := h.Storage.Put(ctx, key, bytes.NewReader(body), want, "application/octet-stream"); err != nil {
+		log.Printf("Failed to store chunk %d of upload %s: %v", index, u.ID, err)
+		writeUploadError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"upload_id": u.ID, "chunk": index, "size": want})
+}
+
+// CompleteFileUpload - POST /entities/:id/files/uploads/:uploadId/complete
+// Joins the chunks into the final file and attaches it to the entity.
+func (h *Handler) CompleteFileUpload(c *gin.Context) {
+	accessContext, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	ctx := c.Request.Context()
+	ip := middleware.GetIPFromContext(c)
+	u, err := h.loadUpload(ctx, entityID, c.Param("uploadId"))
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	received, err := h.receivedChunks(ctx, u)
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	if len(received) != u.TotalChunks {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    ErrUploadIncomplete.Error(),
+			"received": len(received),
+			"total":    u.TotalChunks,
+		})
+		return
+	}
+
+	metrics.UploadSize.Observe(float64(u.Size), "entity_file")
+	err = h.scanUpload(ctx, u.OriginalName, u.Size, func() (io.ReadCloser, error) {
+		return &chunkReader{ctx: ctx, store: h.Storage, upload: u}, nil
+	})
+	if err != nil {
+		// an infected file can't be completed, so don't keep its chunks around
+		if errors.Is(err, scanner.ErrInfected) {
+			h.discardUpload(ctx, u)
+		}
+		if !h.rejectScannedUpload(c, &entityID, err) {
+			writeUploadError(c, err)
+		}
+		return
+	}
+
+	r := &chunkReader{ctx: ctx, store: h.Storage, upload: u}
+	f, err := h.storeEntityFile(ctx, entityID, accessContext.UserID, u.OriginalName, u.ContentType, u.Size, u.Category, u.Description, r)
+	r.Close()
+	if err != nil {
+		log.Printf("Failed to complete upload %s for entity %d: %v", u.ID, entityID, err)
+		h.Service.AuditService.LogAction(ctx, &accessContext.UserID, &entityID, "ENTITY_FILES_UPLOAD_FAILED", map[string]interface{}{
+			"upload_id": u.ID,
+			"file_name": u.OriginalName,
+			"error":     err.Error(),
+		}, ip, "failure")
+		writeUploadError(c, err)
+		return
+	}
+	h.discardUpload(ctx, u)
+
+	h.Service.AuditService.LogAction(ctx, &accessContext.UserID, &entityID, "ENTITY_FILES_UPLOADED", map[string]interface{}{
+		"files":     []string{f.OriginalName},
+		"upload_id": u.ID,
+		"size":      u.Size,
+	}, ip, "success")
+	c.JSON(http.StatusCreated, gin.H{"message": "File uploaded", "data": f})
+}
+
+// AbortFileUpload - DELETE /entities/:id/files/uploads/:uploadId
+func (h *Handler) AbortFileUpload(c *gin.Context) {
+	_, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	u, err := h.Service.Repo.GetFileUpload(entityID, c.Param("uploadId"))
+	if err != nil {
+		writeUploadError(c, err)
+		return
+	}
+	h.discardUpload(c.Request.Context(), u)
+	c.JSON(http.StatusOK, gin.H{"message": "Upload cancelled"})
+}
@@ -0,0 +1,167 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Multipart contract for entity create/update. handleMultipartFormData and
+// processFileUploadsToTemp read these definitions, and GetFormSchema publishes
+// them, so the frontend and mobile apps can't drift from what the handler accepts.
+
+// entityFormTextFields are the text fields read from the form, by Entity json tag
+var entityFormTextFields = []string{
+	"name", "main_deity", "temple_type", "established_year", "phone", "email", "description",
+	"street_address", "city", "district", "state", "pincode", "landmark", "map_link",
+	"latitude", "longitude", "pan", "registration_80g_number",
+}
+
+// entityRequiredFields must be present to create an entity
+// (checked in CreateEntity and Service.CreateEntity)
+var entityRequiredFields = map[string]bool{
+	"name": true, "main_deity": true, "temple_type": true, "established_year": true,
+	"phone": true, "email": true, "street_address": true, "state": true,
+}
+
+// entityDocumentFields are single-file upload fields; the value is the stored file type
+var entityDocumentFields = []struct {
+	Field       string
+	Description string
+}{
+	{"registration_cert", "Temple registration certificate"},
+	{"trust_deed", "Trust deed"},
+	{"property_docs", "Property documents"},
+}
+
+// Additional documents are sent as additional_docs_0 .. additional_docs_<max-1>
+const (
+	additionalDocsFileType = "additional_docs"
+	maxAdditionalDocs      = 10
+)
+
+// allowedDocumentExtensions are the file types accepted for entity documents
+var allowedDocumentExtensions = map[string]bool{
+	".pdf": true, ".jpg": true, ".jpeg": true, ".png": true, ".doc": true, ".docx": true,
+}
+
+func additionalDocsField(i int) string {
+	return fmt.Sprintf("%s_%d", additionalDocsFileType, i)
+}
+
+// FormFieldSchema describes a single multipart field
+type FormFieldSchema struct {
+	Name              string   `json:"name"`
+	Type              string   `json:"type"` // string | integer | file
+	Required          bool     `json:"required"`
+	Description       string   `json:"description,omitempty"`
+	Pattern           string   `json:"pattern,omitempty"`   // for repeated fields, e.g. additional_docs_{n}
+	MaxCount          int      `json:"max_count,omitempty"` // for repeated fields
+	MaxSizeBytes      int64    `json:"max_size_bytes,omitempty"`
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"`
+}
+
+// FormSchema is the full multipart contract of an endpoint
+type FormSchema struct {
+	Version      string            `json:"version"` // changes whenever the contract changes
+	ContentTypes []string          `json:"content_types"`
+	Endpoints    []string          `json:"endpoints"`
+	Fields       []FormFieldSchema `json:"fields"`
+}
+
+// BuildEntityFormSchema generates the schema from the Entity DTO and the
+// upload definitions above
+func BuildEntityFormSchema(maxFileSize int64) FormSchema {
+	fields := make([]FormFieldSchema, 0, len(entityFormTextFields)+len(entityDocumentFields)+1)
+
+	types := entityJSONFieldTypes()
+	for _, name := range entityFormTextFields {
+		fields = append(fields, FormFieldSchema{
+			Name:     name,
+			Type:     types[name],
+			Required: entityRequiredFields[name],
+		})
+	}
+
+	exts := make([]string, 0, len(allowedDocumentExtensions))
+	for ext := range allowedDocumentExtensions {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+
+	for _, d := range entityDocumentFields {
+		fields = append(fields, FormFieldSchema{
+			Name:              d.Field,
+			Type:              "file",
+			Description:       d.Description,
+			MaxCount:          1,
+			MaxSizeBytes:      maxFileSize,
+			AllowedExtensions: exts,
+		})
+	}
+	fields = append(fields, FormFieldSchema{
+		Name:              additionalDocsFileType,
+		Type:              "file",
+		Description:       "Additional supporting documents, one file per indexed field",
+		Pattern:           additionalDocsFileType + "_{n}",
+		MaxCount:          maxAdditionalDocs,
+		MaxSizeBytes:      maxFileSize,
+		AllowedExtensions: exts,
+	})
+
+	schema := FormSchema{
+		ContentTypes: []string{"multipart/form-data", "application/json"},
+		Endpoints:    []string{"POST /api/v1/entities", "PUT /api/v1/entities/:id"},
+		Fields:       fields,
+	}
+	raw, _ := json.Marshal(schema)
+	sum := sha256.Sum256(raw)
+	schema.Version = hex.EncodeToString(sum[:8])
+	return schema
+}
+
+// entityJSONFieldTypes maps Entity json tags to schema types
+func entityJSONFieldTypes() map[string]string {
+	out := make(map[string]string)
+	t := reflect.TypeOf(Entity{})
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		kind := f.Type.Kind()
+		if kind == reflect.Ptr {
+			kind = f.Type.Elem().Kind()
+		}
+		switch kind {
+		case reflect.Int, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+			out[name] = "integer"
+		case reflect.Float32, reflect.Float64:
+			out[name] = "number"
+		case reflect.Bool:
+			out[name] = "boolean"
+		default:
+			out[name] = "string"
+		}
+	}
+	return out
+}
+
+// GetFormSchema - GET /entities/form-schema
+func (h *Handler) GetFormSchema(c *gin.Context) {
+	schema := BuildEntityFormSchema(h.MaxSize)
+	if match := c.GetHeader("If-None-Match"); match == `"`+schema.Version+`"` {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Header("ETag", `"`+schema.Version+`"`)
+	c.JSON(http.StatusOK, schema)
+}
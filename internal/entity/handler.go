@@ -1,7 +1,9 @@
 package entity
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,7 +11,9 @@ import (
 	"mime/multipart"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -17,17 +21,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/scanner"
+	"github.com/sharath018/temple-management-backend/internal/storage"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
 type Handler struct {
 	Service   *Service
-	UploadDir string // filesystem base, e.g. "./uploads"
-	BaseURL   string // URL base, e.g. "/api/v1/uploads"
-	MaxSize   int64  // 10MB default
+	Storage   storage.Storage // final home of entity documents (local or S3)
+	UploadDir string          // local scratch base for temp uploads, e.g. "./uploads"
+	BaseURL   string          // URL base, e.g. "/api/v1/uploads"
+	MaxSize   int64           // 10MB default
+	Scanner   scanner.Scanner // malware check of uploaded documents; nil skips scanning
+	Quota     *StorageQuota   // storage quota meter; nil leaves usage untracked
 }
 
-func NewHandler(s *Service, uploadDir, baseURL string) *Handler {
+func NewHandler(s *Service, store storage.Storage, uploadDir, baseURL string) *Handler {
 	if err := os.MkdirAll(uploadDir, 0755); err != nil {
 		log.Printf("Failed to create upload directory: %v", err)
 	}
@@ -37,6 +47,7 @@ func NewHandler(s *Service, uploadDir, baseURL string) *Handler {
 	}
 	return &Handler{
 		Service:   s,
+		Storage:   store,
 		UploadDir: uploadDir,
 		BaseURL:   baseURL,
 		MaxSize:   10 * 1024 * 1024,
@@ -55,6 +66,10 @@ func (h *Handler) CreateEntity(c *gin.Context) {
 	if isMultipart {
 		if err := h.handleMultipartFormData(c, &input, &tempFiles); err != nil {
 			log.Printf("Multipart Form Error: %v", err)
+			h.cleanupTempFiles(tempFiles)
+			if h.rejectScannedUpload(c, nil, err) {
+				return
+			}
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data", "details": err.Error()})
 			return
 		}
@@ -146,6 +161,15 @@ func (h *Handler) CreateEntity(c *gin.Context) {
 	if err := h.Service.CreateEntity(&input, userID, userRoleID, ip); err != nil {
 		log.Printf("Service Error: %v", err)
 		h.cleanupTempFiles(tempFiles)
+		var linkErr *MapLinkError
+		if errors.As(err, &linkErr) {
+			c.JSON(http.StatusBadRequest, mapLinkErrorJSON(linkErr))
+			return
+		}
+		if errors.Is(err, ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create entity", "details": err.Error()})
 		return
 	}
@@ -223,14 +247,31 @@ func (h *Handler) handleMultipartFormData(c *gin.Context, input *Entity, tempFil
 	input.Pincode = h.getFormValue(form, "pincode")
 	input.Landmark = h.getFormValue(form, "landmark")
 	input.MapLink = h.getFormValue(form, "map_link")
+	input.Timezone = h.getFormValue(form, "timezone")
+	if v := h.getFormValue(form, "latitude"); v != "" {
+		lat, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return errors.New("latitude must be a number")
+		}
+		input.Latitude = &lat
+	}
+	if v := h.getFormValue(form, "longitude"); v != "" {
+		lng, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return errors.New("longitude must be a number")
+		}
+		input.Longitude = &lng
+	}
+	input.PAN = h.getFormValue(form, "pan")
+	input.Registration80GNumber = h.getFormValue(form, "registration_80g_number")
 
-	if err := h.processFileUploadsToTemp(form, tempFiles); err != nil {
-		return fmt.Errorf("failed to process file uploads: %v", err)
+	if err := h.processFileUploadsToTemp(c.Request.Context(), form, tempFiles); err != nil {
+		return fmt.Errorf("failed to process file uploads: %w", err)
 	}
 	return nil
 }
 
-func (h *Handler) processFileUploadsToTemp(form *multipart.Form, tempFiles *[]TempFileInfo) error {
+func (h *Handler) processFileUploadsToTemp(ctx context.Context, form *multipart.Form, tempFiles *[]TempFileInfo) error {
 	tempSessionDir := filepath.Join(h.UploadDir, "temp_uploads", uuid.New().String())
 	if err := os.MkdirAll(tempSessionDir, 0755); err != nil {
 		return fmt.Errorf("failed to create temp directory: %v", err)
@@ -238,33 +279,23 @@ func (h *Handler) processFileUploadsToTemp(form *multipart.Form, tempFiles *[]Te
 	log.Printf("Created temp directory: %s", tempSessionDir)
 
 	// Single-file fields
-	if reg := form.File["registration_cert"]; len(reg) > 0 {
-		info, err := h.uploadFileToTemp(reg[0], tempSessionDir, "registration_cert")
-		if err != nil {
-			return fmt.Errorf("failed to upload registration certificate: %v", err)
-		}
-		*tempFiles = append(*tempFiles, info)
-	}
-	if trust := form.File["trust_deed"]; len(trust) > 0 {
-		info, err := h.uploadFileToTemp(trust[0], tempSessionDir, "trust_deed")
-		if err != nil {
-			return fmt.Errorf("failed to upload trust deed: %v", err)
-		}
-		*tempFiles = append(*tempFiles, info)
-	}
-	if prop := form.File["property_docs"]; len(prop) > 0 {
-		info, err := h.uploadFileToTemp(prop[0], tempSessionDir, "property_docs")
-		if err != nil {
-			return fmt.Errorf("failed to upload property documents: %v", err)
+	for _, d := range entityDocumentFields {
+		if files := form.File[d.Field]; len(files) > 0 {
+			info, err := h.uploadFileToTemp(ctx, files[0], tempSessionDir, d.Field)
+			if err != nil {
+				return fmt.Errorf("failed to upload %s: %w", strings.ToLower(d.Description), err)
+			}
+			*tempFiles = append(*tempFiles, info)
 		}
-		*tempFiles = append(*tempFiles, info)
 	}
 
 	// Multiple additional docs: additional_docs_0..9
-	for i := 0; i < 10; i++ {
-		field := fmt.Sprintf("additional_docs_%d", i)
-		if add := form.File[field]; len(add) > 0 {
-			info, err := h.uploadFileToTemp(add[0], tempSessionDir, "additional_docs")
+	for i := 0; i < maxAdditionalDocs; i++ {
+		if add := form.File[additionalDocsField(i)]; len(add) > 0 {
+			info, err := h.uploadFileToTemp(ctx, add[0], tempSessionDir, additionalDocsFileType)
+			if errors.Is(err, scanner.ErrInfected) || errors.Is(err, scanner.ErrUnavailable) {
+				return fmt.Errorf("failed to upload additional document %d: %w", i, err)
+			}
 			if err != nil {
 				log.Printf("Warning: Failed to upload additional document %d: %v", i, err)
 				continue
@@ -276,9 +307,10 @@ func (h *Handler) processFileUploadsToTemp(form *multipart.Form, tempFiles *[]Te
 	return nil
 }
 
-func (h *Handler) uploadFileToTemp(file *multipart.FileHeader, tempDir, fileType string) (TempFileInfo, error) {
+func (h *Handler) uploadFileToTemp(ctx context.Context, file *multipart.FileHeader, tempDir, fileType string) (TempFileInfo, error) {
 	var out TempFileInfo
 
+	metrics.UploadSize.Observe(float64(file.Size), "entity_document")
 	if err := h.validateFile(file); err != nil {
 		return out, err
 	}
@@ -302,6 +334,15 @@ func (h *Handler) uploadFileToTemp(file *multipart.FileHeader, tempDir, fileType
 	if _, err := io.Copy(dst, src); err != nil {
 		return out, fmt.Errorf("failed to copy file: %v", err)
 	}
+	if err := dst.Close(); err != nil {
+		return out, fmt.Errorf("failed to write file: %v", err)
+	}
+
+	// Scan before the file goes anywhere near the entity's documents
+	if err := h.scanUpload(ctx, file.Filename, file.Size, func() (io.ReadCloser, error) { return os.Open(tempPath) }); err != nil {
+		_ = os.Remove(tempPath)
+		return out, err
+	}
 
 	out = TempFileInfo{
 		TempPath:     tempPath,
@@ -316,30 +357,32 @@ func (h *Handler) uploadFileToTemp(file *multipart.FileHeader, tempDir, fileType
 }
 
 func (h *Handler) moveFilesToFinalLocation(entity *Entity, tempFiles []TempFileInfo, finalFileInfos *map[string]FileInfo) error {
-	entityDir := filepath.Join(h.UploadDir, strconv.FormatUint(uint64(entity.ID), 10))
-	if err := os.MkdirAll(entityDir, 0755); err != nil {
-		return fmt.Errorf("failed to create entity directory: %v", err)
-	}
-	log.Printf("Created entity directory: %s for entity %d", entityDir, entity.ID)
+	entityKey := strconv.FormatUint(uint64(entity.ID), 10)
+	ctx := context.Background()
+	var storedKeys []string
 
 	*finalFileInfos = make(map[string]FileInfo)
 	var additionalFiles []FileInfo
 
 	for _, tf := range tempFiles {
 		finalFileName := tf.FileName
-		finalPath := filepath.Join(entityDir, finalFileName)
+		key, err := storage.Key(entityKey, finalFileName)
+		if err != nil {
+			return fmt.Errorf("invalid file name %s: %v", tf.FileName, err)
+		}
 
-		// Prefer rename; fall back to copy+remove
-		if err := os.Rename(tf.TempPath, finalPath); err != nil {
-			if err := copyFile(tf.TempPath, finalPath); err != nil {
-				log.Printf("Failed to move/copy file %s to %s: %v", tf.TempPath, finalPath, err)
-				return fmt.Errorf("failed to persist file %s: %v", tf.FileName, err)
+		if err := h.putTempFile(ctx, key, tf); err != nil {
+			log.Printf("Failed to store file %s as %s: %v", tf.TempPath, key, err)
+			// Don't leave half of a document set behind in storage
+			for _, k := range storedKeys {
+				_ = h.Storage.Delete(ctx, k)
 			}
-			_ = os.Remove(tf.TempPath)
+			return fmt.Errorf("failed to persist file %s: %w", tf.FileName, err)
 		}
+		storedKeys = append(storedKeys, key)
+		_ = os.Remove(tf.TempPath)
 
-		rel := filepath.ToSlash(filepath.Join(strconv.FormatUint(uint64(entity.ID), 10), finalFileName))
-		fileURL := h.buildFileURL(rel)
+		fileURL := h.buildFileURL(key)
 
 		fi := FileInfo{
 			FileName:     finalFileName,
@@ -399,6 +442,21 @@ func (h *Handler) moveFilesToFinalLocation(entity *Entity, tempFiles []TempFileI
 	return nil
 }
 
+// putTempFile streams a staged temp upload into the configured storage
+func (h *Handler) putTempFile(ctx context.Context, key string, tf TempFileInfo) error {
+	f, err := os.Open(tf.TempPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	size := tf.FileSize
+	if st, err := f.Stat(); err == nil {
+		size = st.Size()
+	}
+	return h.Storage.Put(ctx, key, f, size, tf.ContentType)
+}
+
 func (h *Handler) updateEntityWithFileInfo(entity *Entity) error {
 	return h.Service.Repo.UpdateEntity(*entity)
 }
@@ -408,10 +466,7 @@ func (h *Handler) validateFile(file *multipart.FileHeader) error {
 		return fmt.Errorf("file size exceeds %dMB limit", h.MaxSize/(1024*1024))
 	}
 	ext := strings.ToLower(filepath.Ext(file.Filename))
-	allowed := map[string]bool{
-		".pdf": true, ".jpg": true, ".jpeg": true, ".png": true, ".doc": true, ".docx": true,
-	}
-	if !allowed[ext] {
+	if !allowedDocumentExtensions[ext] {
 		return fmt.Errorf("file type %s not allowed", ext)
 	}
 	return nil
@@ -462,35 +517,31 @@ func (h *Handler) GetAllEntityDirectories(c *gin.Context) {
 		return
 	}
 
-	var directories []EntityDirectory
-	entries, err := os.ReadDir(h.UploadDir)
+	objects, err := h.Storage.List(c.Request.Context(), "")
 	if err != nil {
-		log.Printf("Error reading upload directory: %v", err)
+		log.Printf("Error listing file storage: %v", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read upload directory"})
 		return
 	}
-	for _, entry := range entries {
-		if entry.IsDir() && entry.Name() != "temp_uploads" {
-			entityDir := filepath.Join(h.UploadDir, entry.Name())
-			files, err := os.ReadDir(entityDir)
-			if err != nil {
-				log.Printf("Error reading entity directory %s: %v", entry.Name(), err)
-				continue
-			}
-			var names []string
-			for _, f := range files {
-				if !f.IsDir() {
-					names = append(names, f.Name())
-				}
-			}
-			if len(names) > 0 {
-				directories = append(directories, EntityDirectory{
-					EntityID:   entry.Name(),
-					FilesCount: len(names),
-					Files:      names,
-				})
-			}
+
+	groups := storage.GroupByTopLevel(objects)
+	entityIDs := make([]string, 0, len(groups))
+	for id := range groups {
+		entityIDs = append(entityIDs, id)
+	}
+	sort.Strings(entityIDs)
+
+	var directories []EntityDirectory
+	for _, id := range entityIDs {
+		var names []string
+		for _, obj := range groups[id] {
+			names = append(names, path.Base(obj.Key))
 		}
+		directories = append(directories, EntityDirectory{
+			EntityID:   id,
+			FilesCount: len(names),
+			Files:      names,
+		})
 	}
 	c.JSON(http.StatusOK, gin.H{
 		"total_entities_with_files": len(directories),
@@ -547,34 +598,24 @@ func (h *Handler) GetEntityFiles(c *gin.Context) {
 		return
 	}
 
-	entityDir := filepath.Join(h.UploadDir, entityID)
-	if _, err := os.Stat(entityDir); os.IsNotExist(err) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "No files found for this entity"})
-		return
-	}
-
-	entries, err := os.ReadDir(entityDir)
+	prefix := strconv.FormatUint(uint64(entityIDUint), 10) + "/"
+	objects, err := h.Storage.List(c.Request.Context(), prefix)
 	if err != nil {
-		log.Printf("Error reading entity directory %s: %v", entityID, err)
+		log.Printf("Error listing files for entity %s: %v", entityID, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to read entity files"})
 		return
 	}
+	if len(objects) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "No files found for this entity"})
+		return
+	}
 
 	var out []FileDetails
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		info, err := e.Info()
-		if err != nil {
-			continue
-		}
-		rel := filepath.ToSlash(filepath.Join(entityID, e.Name()))
-		url := h.buildFileURL(rel)
+	for _, obj := range objects {
 		out = append(out, FileDetails{
-			FileName: e.Name(),
-			FileURL:  url,
-			Size:     info.Size(),
+			FileName: strings.TrimPrefix(obj.Key, prefix),
+			FileURL:  h.buildFileURL(obj.Key),
+			Size:     obj.Size,
 		})
 	}
 
@@ -595,26 +636,6 @@ func sniffOrByExt(ext string) string {
 	return "application/octet-stream"
 }
 
-func copyFile(src, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return err
-	}
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
-	}
-	defer func() { _ = out.Close() }()
-	if _, err := io.Copy(out, in); err != nil {
-		return err
-	}
-	return out.Sync()
-}
-
 // Rest of your existing methods remain the same...
 // GetAllEntities retrieves entities based on user role and permissions
 func (h *Handler) GetAllEntities(c *gin.Context) {
@@ -661,33 +682,22 @@ func (h *Handler) GetAllEntities(c *gin.Context) {
 		}
 		
 	case "standarduser", "monitoringuser":
-		// For standard users, try multiple strategies to find entities
-		if accessContext.AssignedEntityID != nil {
-			tenantID := *accessContext.AssignedEntityID
-			
-			// Try to get entities created by the tenant
-			entities, err = h.Service.GetEntitiesByCreator(tenantID)
-			
-			// If no entities found, create a mock entity for UI consistency
-			if err != nil || len(entities) == 0 {
-				log.Printf("No entities found for tenant %d, creating mock entity", tenantID)
-				mockEntity := Entity{
-					ID:          tenantID,
-					Name:        "Temple " + strconv.FormatUint(uint64(tenantID), 10),
-					Description: "Temple associated with your account",
-					Status:      "active",
-					CreatedBy:   tenantID,
-					CreatedAt:   time.Now(),
-					UpdatedAt:   time.Now(),
-				}
-				entities = []Entity{mockEntity}
-				err = nil // Clear any error
-			}
-		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "No entity assigned to this user"})
+		// Standard/monitoring users only ever see real temples of their tenant(s).
+		// When nothing is assigned the response is an object with an explicit
+		// status instead of an array, so the UI can show a proper empty state.
+		assignment, err := h.Service.ResolveAssignedEntities(user.ID, accessContext)
+		if err != nil {
+			log.Printf("Error resolving entity assignment for user %d: %v", user.ID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch temples", "details": err.Error()})
 			return
 		}
-		
+		if assignment.Status != AssignmentStatusAssigned {
+			log.Printf("No entities assigned to user %d (%s)", user.ID, assignment.Status)
+			c.JSON(http.StatusOK, assignment)
+			return
+		}
+		entities = assignment.Entities
+
 	default:
 		c.JSON(http.StatusForbidden, gin.H{"error": "Invalid user role"})
 		return
@@ -734,30 +744,12 @@ func (h *Handler) GetEntityByID(c *gin.Context) {
 		return
 	}
 	
-	// Try to get the entity first
 	entity, err := h.Service.GetEntityByID(id)
 	if err != nil {
-		// For standard users with matching tenant ID, create mock entity
-		if (user.Role.RoleName == "standarduser" || user.Role.RoleName == "monitoringuser") && 
-		   accessContext.AssignedEntityID != nil && 
-		   *accessContext.AssignedEntityID == uint(id) {
-			
-			// Create mock entity using the ID
-			entity = Entity{
-				ID:          uint(id),
-				Name:        "Temple " + strconv.Itoa(id),
-				Description: "Temple associated with your account",
-				Status:      "active",
-				CreatedBy:   uint(id),
-				CreatedAt:   time.Now(),
-				UpdatedAt:   time.Now(),
-			}
-		} else {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
-			return
-		}
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
+		return
 	}
-	
+
 	// Check permissions based on user role
 	hasAccess := false
 	
@@ -770,10 +762,12 @@ func (h *Handler) GetEntityByID(c *gin.Context) {
 			entity.CreatedBy == user.ID
 			
 	case "standarduser", "monitoringuser":
-		if accessContext.AssignedEntityID != nil {
-			hasAccess = (*accessContext.AssignedEntityID == uint(id)) || 
-				entity.CreatedBy == *accessContext.AssignedEntityID
-		}
+		// Only the user's tenant assignments grant access, never the
+		// entity ID the client sends; any assigned temple can be opened
+		unscoped := accessContext
+		unscoped.AssignedEntityID = nil
+		assignment, err := h.Service.ResolveAssignedEntities(user.ID, unscoped)
+		hasAccess = err == nil && assignment.CanAccessAssignedEntity(entity)
 		
 	default:
 		hasAccess = false
@@ -850,7 +844,7 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 		
 	case "standarduser", "monitoringuser":
 		entityIDUint := uint(id)
-		hasAccess = (accessContext.AssignedEntityID != nil && *accessContext.AssignedEntityID == entityIDUint)
+		hasAccess = accessContext.CanAccessEntity(entityIDUint)
 		
 		if hasAccess {
 			log.Printf("✅ StandardUser %d granted access to edit entity %d", user.ID, id)
@@ -889,6 +883,11 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 		log.Printf("📁 Processing multipart form data for entity %d", id)
 		if err := h.handleMultipartFormData(c, &input, &tempFiles); err != nil {
 			log.Printf("Multipart Form Error: %v", err)
+			h.cleanupTempFiles(tempFiles)
+			entityID := uint(id)
+			if h.rejectScannedUpload(c, &entityID, err) {
+				return
+			}
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data", "details": err.Error()})
 			return
 		}
@@ -950,6 +949,17 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 		input.IsActive = existingEntity.IsActive
 	}
 
+	// Key details of an approved temple may need superadmin review first
+	var heldChanges map[string]FieldChange
+	if user.Role.RoleName != "superadmin" {
+		heldChanges, err = h.Service.HoldKeyChanges(&input, existingEntity)
+		if err != nil {
+			h.cleanupTempFiles(tempFiles)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check change review settings"})
+			return
+		}
+	}
+
 	// 🆕 PROCESS NEW FILES IF UPLOADED
 	finalFileInfos := make(map[string]FileInfo)
 	if len(tempFiles) > 0 {
@@ -958,6 +968,10 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 		if err := h.moveFilesToFinalLocation(&input, tempFiles, &finalFileInfos); err != nil {
 			log.Printf("Error moving files for entity %d: %v", id, err)
 			h.cleanupTempFiles(tempFiles)
+			if errors.Is(err, storage.ErrQuotaExceeded) {
+				c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "code": "storage_quota_exceeded"})
+				return
+			}
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"error": "Failed to process uploaded files",
 				"details": err.Error(),
@@ -979,6 +993,15 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 	if err := h.Service.UpdateEntity(input, user.ID, user.Role.ID, ip, wasRejected); err != nil {
 		log.Printf("❌ Update Error for entity %d: %v", id, err)
 		h.cleanupTempFiles(tempFiles)
+		var linkErr *MapLinkError
+		if errors.As(err, &linkErr) {
+			c.JSON(http.StatusBadRequest, mapLinkErrorJSON(linkErr))
+			return
+		}
+		if errors.Is(err, ErrInvalidTimezone) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "Failed to update temple", 
 			"details": err.Error(),
@@ -993,6 +1016,17 @@ func (h *Handler) UpdateEntity(c *gin.Context) {
 		"temple_id": id,
 		"updated_by": user.ID,
 	}
+
+	if len(heldChanges) > 0 {
+		changeRequest, err := h.Service.RequestKeyChanges(existingEntity, heldChanges, user.ID, ip)
+		if err != nil {
+			log.Printf("❌ Failed to record change request for entity %d: %v", id, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Temple updated, but the changes needing review could not be saved"})
+			return
+		}
+		response["change_request"] = changeRequest
+		response["message"] = "Temple updated; changes to key details await superadmin approval"
+	}
 	
 	// Add file info if files were uploaded
 	if len(finalFileInfos) > 0 {
@@ -1040,6 +1074,11 @@ func (h *Handler) DeleteEntity(c *gin.Context) {
 		return
 	}
 
+	// Remove the temple's stored documents as well
+	if err := storage.DeletePrefix(c.Request.Context(), h.Storage, strconv.Itoa(id)+"/"); err != nil {
+		log.Printf("Warning: failed to clean up files for entity %d: %v", id, err)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"message": "Temple deleted successfully"})
 }
 // ToggleEntityStatus handles toggling entity active/inactive status
@@ -1137,28 +1176,6 @@ func (h *Handler) ToggleEntityStatus(c *gin.Context) {
         "status":    statusText,
     })
 }
-/*func (h *Handler) GetVolunteersByEntity(c *gin.Context) {
-	entityIDParam := c.Param("id")
-	entityIDUint, err := strconv.ParseUint(entityIDParam, 10, 64)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
-		return
-	}
-
-	entityID := uint(entityIDUint)
-
-	volunteers, err := h.Service.GetVolunteersByEntityID(entityID)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to fetch volunteers",
-			"details": err.Error(),
-		})
-		return
-	}
-
-	c.JSON(http.StatusOK, volunteers)
-}
-*/
 // GetDevoteesByEntity retrieves devotees for a specific entity
 func (h *Handler) GetDevoteesByEntity(c *gin.Context) {
 	entityIDParam := c.Param("id")
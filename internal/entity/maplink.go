@@ -0,0 +1,436 @@
+package entity
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Map link error codes returned in the "code" field of a 400 response
+const (
+	MapLinkInvalidURL         = "invalid_url"
+	MapLinkNotMapURL          = "not_a_map_url"
+	MapLinkInvalidCoordinates = "invalid_coordinates"
+)
+
+// MapLinkError describes why a map link or coordinate pair was rejected
+type MapLinkError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"error"`
+}
+
+func (e *MapLinkError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// mapLinkErrorJSON is the 400 body for a map link validation error
+func mapLinkErrorJSON(err *MapLinkError) gin.H {
+	return gin.H{"error": err.Message, "field": err.Field, "code": err.Code}
+}
+
+// MapLocation is a validated map link with the coordinates found in it
+type MapLocation struct {
+	MapLink   string   `json:"map_link"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+var (
+	// Place pins (!3d<lat>!4d<lng>) are more precise than the viewport (@<lat>,<lng>)
+	pinCoordsPattern      = regexp.MustCompile(`!3d(-?\d+(?:\.\d+)?)!4d(-?\d+(?:\.\d+)?)`)
+	viewportCoordsPattern = regexp.MustCompile(`@(-?\d+(?:\.\d+)?),(-?\d+(?:\.\d+)?)`)
+	coordsPattern         = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*,\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+	// Query parameters that may hold "lat,lng"
+	coordsParams = []string{"q", "query", "ll", "center", "destination", "daddr"}
+
+	// Tracking and UI state parameters dropped from stored links
+	droppedMapParams = map[string]bool{"entry": true, "g_ep": true, "g_st": true, "shorturl": true, "hl": true, "authuser": true}
+)
+
+// isGoogleHost reports whether host is google.<tld>, www.google.<tld> or
+// google.co.<cc> for any country domain
+func isGoogleHost(host string) bool {
+	host = strings.TrimPrefix(host, "www.")
+	rest, ok := strings.CutPrefix(host, "google.")
+	if !ok || rest == "" {
+		return false
+	}
+	for _, label := range strings.Split(rest, ".") {
+		if len(label) < 2 || len(label) > 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseMapLink validates a Google Maps URL, normalizes it (https, canonical
+// host, no tracking parameters) and extracts coordinates where the link
+// carries them. Short links (maps.app.goo.gl, goo.gl/maps) are accepted as is.
+func ParseMapLink(raw string) (*MapLocation, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, &MapLinkError{Field: "map_link", Code: MapLinkInvalidURL, Message: "map_link must be a valid http(s) URL"}
+	}
+
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "maps.app.goo.gl":
+	case host == "goo.gl" && strings.HasPrefix(u.Path, "/maps"):
+	case strings.HasPrefix(host, "maps.") && isGoogleHost(strings.TrimPrefix(host, "maps.")):
+		// maps.google.com/?q=... is the same as www.google.com/maps?q=...
+		host = "www.google.com"
+		u.Path = "/maps" + strings.TrimPrefix(u.Path, "/maps")
+	case isGoogleHost(host) && (u.Path == "/maps" || strings.HasPrefix(u.Path, "/maps/")):
+		host = "www.google.com"
+	default:
+		return nil, &MapLinkError{Field: "map_link", Code: MapLinkNotMapURL, Message: "map_link must be a Google Maps link"}
+	}
+
+	u.Scheme = "https"
+	u.Host = host
+	u.User = nil
+	u.Fragment = ""
+	query := u.Query()
+	for key := range query {
+		if droppedMapParams[key] || strings.HasPrefix(key, "utm_") {
+			query.Del(key)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	loc := &MapLocation{MapLink: u.String()}
+	if lat, lng, ok := mapLinkCoordinates(u.Path, query); ok {
+		loc.Latitude, loc.Longitude = &lat, &lng
+	}
+	return loc, nil
+}
+
+func mapLinkCoordinates(path string, query url.Values) (float64, float64, bool) {
+	candidates := [][]string{pinCoordsPattern.FindStringSubmatch(path)}
+	for _, param := range coordsParams {
+		candidates = append(candidates, coordsPattern.FindStringSubmatch(query.Get(param)))
+	}
+	candidates = append(candidates, viewportCoordsPattern.FindStringSubmatch(path))
+
+	for _, m := range candidates {
+		if m == nil {
+			continue
+		}
+		lat, latErr := strconv.ParseFloat(m[1], 64)
+		lng, lngErr := strconv.ParseFloat(m[2], 64)
+		if latErr == nil && lngErr == nil && validCoordinates(lat, lng) {
+			return lat, lng, true
+		}
+	}
+	return 0, 0, false
+}
+
+func validCoordinates(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// ===== GEOCODING =====
+
+// GeocodedAddress is the administrative area of a coordinate pair
+type GeocodedAddress struct {
+	City     string `json:"city"`
+	District string `json:"district"`
+	State    string `json:"state"`
+	Pincode  string `json:"pincode"`
+}
+
+// Geocoder looks up the address of coordinates, and the coordinates of an address
+type Geocoder interface {
+	Reverse(ctx context.Context, lat, lng float64) (*GeocodedAddress, error)
+	// Search returns the best match for a free-form address; found is false
+	// when nothing matched
+	Search(ctx context.Context, address string) (lat, lng float64, found bool, err error)
+}
+
+// nominatimGeocoder reverse geocodes against a Nominatim compatible API
+type nominatimGeocoder struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// NewNominatimGeocoder returns a geocoder for the Nominatim API at baseURL,
+// or nil when baseURL is empty
+func NewNominatimGeocoder(baseURL, userAgent string) Geocoder {
+	if strings.TrimSpace(baseURL) == "" {
+		return nil
+	}
+	return &nominatimGeocoder{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (g *nominatimGeocoder) Reverse(ctx context.Context, lat, lng float64) (*GeocodedAddress, error) {
+	params := url.Values{}
+	params.Set("format", "jsonv2")
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("lon", strconv.FormatFloat(lng, 'f', -1, 64))
+	params.Set("addressdetails", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/reverse?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("reverse geocoding failed with status %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Error   string            `json:"error"`
+		Address map[string]string `json:"address"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.Error != "" {
+		return nil, errors.New(body.Error)
+	}
+
+	first := func(keys ...string) string {
+		for _, k := range keys {
+			if v := strings.TrimSpace(body.Address[k]); v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return &GeocodedAddress{
+		City:     first("city", "town", "village", "suburb", "municipality"),
+		District: strings.TrimSuffix(first("state_district", "county"), " District"),
+		State:    first("state"),
+		Pincode:  first("postcode"),
+	}, nil
+}
+
+func (g *nominatimGeocoder) Search(ctx context.Context, address string) (float64, float64, bool, error) {
+	params := url.Values{}
+	params.Set("format", "jsonv2")
+	params.Set("q", address)
+	params.Set("limit", "1")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, false, fmt.Errorf("geocoding failed with status %d", resp.StatusCode)
+	}
+
+	// Nominatim returns coordinates as strings
+	var places []struct {
+		Lat string `json:"lat"`
+		Lon string `json:"lon"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&places); err != nil {
+		return 0, 0, false, err
+	}
+	if len(places) == 0 {
+		return 0, 0, false, nil
+	}
+	lat, latErr := strconv.ParseFloat(places[0].Lat, 64)
+	lng, lngErr := strconv.ParseFloat(places[0].Lon, 64)
+	if latErr != nil || lngErr != nil || !validCoordinates(lat, lng) {
+		return 0, 0, false, fmt.Errorf("geocoder returned invalid coordinates %q,%q", places[0].Lat, places[0].Lon)
+	}
+	return lat, lng, true, nil
+}
+
+// ===== SERVICE =====
+
+// applyMapLocation validates and normalizes the entity's map link, sets the
+// coordinates found in it and fills a blank city or district from them.
+// Temples without coordinates are located from their address when a
+// geocoder is configured.
+func (s *Service) applyMapLocation(e *Entity) error {
+	if strings.TrimSpace(e.MapLink) != "" {
+		loc, err := ParseMapLink(e.MapLink)
+		if err != nil {
+			return err
+		}
+		e.MapLink = loc.MapLink
+		if loc.Latitude != nil {
+			e.Latitude, e.Longitude = loc.Latitude, loc.Longitude
+		}
+	} else {
+		e.MapLink = ""
+	}
+
+	if (e.Latitude == nil) != (e.Longitude == nil) {
+		return &MapLinkError{Field: "latitude", Code: MapLinkInvalidCoordinates, Message: "latitude and longitude must be given together"}
+	}
+	if e.Latitude == nil {
+		s.locateAddress(e)
+		return nil
+	}
+	if !validCoordinates(*e.Latitude, *e.Longitude) {
+		return &MapLinkError{Field: "latitude", Code: MapLinkInvalidCoordinates, Message: "latitude must be within ±90 and longitude within ±180"}
+	}
+
+	if strings.TrimSpace(e.City) == "" || strings.TrimSpace(e.District) == "" {
+		s.fillAddress(e)
+	}
+	return nil
+}
+
+// fillAddress reverse geocodes the entity's coordinates into blank address
+// fields. Geocoding is best effort; failures leave the fields as they were.
+func (s *Service) fillAddress(e *Entity) {
+	addr, err := s.reverseGeocode(*e.Latitude, *e.Longitude)
+	if err != nil || addr == nil {
+		if err != nil {
+			log.Printf("⚠️ Reverse geocoding %f,%f failed: %v", *e.Latitude, *e.Longitude, err)
+		}
+		return
+	}
+	if strings.TrimSpace(e.City) == "" {
+		e.City = addr.City
+	}
+	if strings.TrimSpace(e.District) == "" {
+		e.District = addr.District
+	}
+	if strings.TrimSpace(e.State) == "" {
+		e.State = addr.State
+	}
+	if strings.TrimSpace(e.Pincode) == "" {
+		e.Pincode = addr.Pincode
+	}
+}
+
+// locateAddress geocodes the entity's address into its coordinates, best
+// effort like fillAddress
+func (s *Service) locateAddress(e *Entity) {
+	if s.Geocoder == nil {
+		return
+	}
+	var parts []string
+	for _, part := range []string{e.StreetAddress, e.City, e.District, e.State, e.Pincode} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if strings.TrimSpace(e.City) == "" && strings.TrimSpace(e.Pincode) == "" {
+		return // too vague to place the temple
+	}
+	parts = append(parts, "India")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	lat, lng, found, err := s.Geocoder.Search(ctx, strings.Join(parts, ", "))
+	if err != nil {
+		log.Printf("⚠️ Geocoding the address of %q failed: %v", e.Name, err)
+		return
+	}
+	if found {
+		e.Latitude, e.Longitude = &lat, &lng
+	}
+}
+
+func (s *Service) reverseGeocode(lat, lng float64) (*GeocodedAddress, error) {
+	if s.Geocoder == nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return s.Geocoder.Reverse(ctx, lat, lng)
+}
+
+// BackfillCoordinates sets the coordinates of temples saved before they were
+// taken from map links. Only the links are parsed; no geocoder is called.
+func BackfillCoordinates(db *gorm.DB) {
+	var entities []Entity
+	err := db.Select("id", "map_link").
+		Where("latitude IS NULL AND map_link IS NOT NULL AND map_link <> ''").
+		Find(&entities).Error
+	if err != nil {
+		log.Printf("⚠️ Failed to load temples without coordinates: %v", err)
+		return
+	}
+	for _, e := range entities {
+		loc, err := ParseMapLink(e.MapLink)
+		if err != nil || loc.Latitude == nil {
+			continue
+		}
+		err = db.Model(&Entity{}).Where("id = ?", e.ID).
+			UpdateColumns(map[string]interface{}{"latitude": *loc.Latitude, "longitude": *loc.Longitude}).Error
+		if err != nil {
+			log.Printf("⚠️ Failed to set coordinates of temple %d: %v", e.ID, err)
+		}
+	}
+}
+
+// ===== HANDLER =====
+
+// PreviewMapLink - POST /entities/map-link/preview
+// Body: {"map_link": "..."}. Returns the normalized link, its coordinates and,
+// when geocoding is configured, the address they resolve to.
+func (h *Handler) PreviewMapLink(c *gin.Context) {
+	var req struct {
+		MapLink string `json:"map_link" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "map_link is required", "field": "map_link", "code": MapLinkInvalidURL})
+		return
+	}
+
+	loc, err := ParseMapLink(req.MapLink)
+	if err != nil {
+		var linkErr *MapLinkError
+		if errors.As(err, &linkErr) {
+			c.JSON(http.StatusBadRequest, mapLinkErrorJSON(linkErr))
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{"location": loc}
+	if loc.Latitude != nil {
+		addr, err := h.Service.reverseGeocode(*loc.Latitude, *loc.Longitude)
+		if err != nil {
+			log.Printf("⚠️ Reverse geocoding preview failed: %v", err)
+		} else if addr != nil {
+			resp["address"] = addr
+		}
+	}
+	c.JSON(http.StatusOK, resp)
+}
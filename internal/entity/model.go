@@ -26,6 +26,21 @@ type Entity struct {
 	Pincode       string `gorm:"not null" json:"pincode"`
 	MapLink       string `json:"map_link"`
 
+	// Coordinates, taken from the map link when it carries them or geocoded
+	// from the address. Indexed together for nearby searches.
+	Latitude  *float64 `gorm:"index:idx_entities_location,priority:1" json:"latitude"`
+	Longitude *float64 `gorm:"index:idx_entities_location,priority:2" json:"longitude"`
+
+	// IANA timezone of the temple's event and seva times, e.g. Asia/Kolkata
+	Timezone string `gorm:"size:64;not null;default:'Asia/Kolkata'" json:"timezone"`
+
+	// URL name of the temple in the public directory, set once from the name
+	Slug *string `gorm:"size:120;uniqueIndex" json:"slug"`
+
+	// Tax exemption details printed on donation receipts
+	PAN                   string `json:"pan"`
+	Registration80GNumber string `gorm:"column:registration_80g_number" json:"registration_80g_number"`
+
 	// Step 3: Document Uploads (URLs to stored files)
 	RegistrationCertURL string `json:"registration_cert_url"`
 	TrustDeedURL        string `json:"trust_deed_url"`
@@ -47,6 +47,15 @@ func (r *Repository) GetTenantIDForUser(userID uint) (uint, error) {
 	return tenantID, nil
 }
 
+// GetActiveTenantIDsForUser returns every tenant a user is actively assigned to
+func (r *Repository) GetActiveTenantIDsForUser(userID uint) ([]uint, error) {
+	var tenantIDs []uint
+	err := r.DB.Table("tenant_user_assignments").
+		Where("user_id = ? AND status = ?", userID, "active").
+		Pluck("tenant_id", &tenantIDs).Error
+	return tenantIDs, err
+}
+
 // Get user's role ID
 func (r *Repository) GetUserRoleID(userID uint) (uint, error) {
 	var roleID uint
@@ -149,6 +158,16 @@ func (r *Repository) GetEntitiesByCreator(creatorID uint) ([]Entity, error) {
 	return entities, err
 }
 
+// Fetch entities created by any of the given users (tenants)
+func (r *Repository) GetEntitiesByCreators(creatorIDs []uint) ([]Entity, error) {
+	var entities []Entity
+	if len(creatorIDs) == 0 {
+		return entities, nil
+	}
+	err := r.DB.Where("created_by IN ?", creatorIDs).Order("created_at DESC").Find(&entities).Error
+	return entities, err
+}
+
 // Get approval statistics by role
 func (r *Repository) GetApprovalStatsByRole() (map[string]interface{}, error) {
 	type RoleStats struct {
@@ -244,6 +263,11 @@ func (r *Repository) UpdateEntityAlternative(e Entity) error {
 		"state":                   e.State,
 		"pincode":                 e.Pincode,
 		"map_link":                e.MapLink,
+		"latitude":                e.Latitude,
+		"longitude":               e.Longitude,
+		"timezone":                e.Timezone,
+		"pan":                     e.PAN,
+		"registration_80g_number": e.Registration80GNumber,
 		"registration_cert_url":   e.RegistrationCertURL,
 		"registration_cert_info":  e.RegistrationCertInfo,
 		"trust_deed_url":          e.TrustDeedURL,
@@ -316,7 +340,7 @@ type DevoteeDTO struct {
 	UserID    uint   `json:"user_id"`
 	FullName  string `json:"full_name"`
 	Email     string `json:"email"`
-	Phone     string `json:"phone"`
+	Phone     string `json:"phone" gorm:"serializer:encrypted"`
 	Status    string `json:"status"`
 	Nakshatra string `json:"nakshatra"`
 	Rashi     string `json:"rashi"`
@@ -471,11 +495,3 @@ func (r *Repository) CountUpcomingEventsThisWeek(entityID uint) (int64, error) {
 		Count(&count).Error
 	return count, err
 }
-/*func (r *Repository) GetVolunteersByEntityID(entityID uint) ([]UserEntityMembership, error) {
-	var memberships []UserEntityMembership
-	err := r.DB.
-		Where("entity_id = ? AND status = ?", entityID, "active").
-		Find(&memberships).Error
-	return memberships, err
-}
-*/
\ No newline at end of file
@@ -0,0 +1,68 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/scanner"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// scanUpload runs the malware scanner over an uploaded file, opening it with
+// open. An infected file is copied to quarantine and reported as a
+// *scanner.InfectedError.
+func (h *Handler) scanUpload(ctx context.Context, fileName string, size int64, open func() (io.ReadCloser, error)) error {
+	if h.Scanner == nil {
+		return nil
+	}
+	rc, err := open()
+	if err != nil {
+		return err
+	}
+	err = h.Scanner.Scan(ctx, rc)
+	rc.Close()
+
+	var infected *scanner.InfectedError
+	if !errors.As(err, &infected) {
+		return err
+	}
+	infected.FileName = filepath.Base(fileName)
+	if rc, err := open(); err == nil {
+		key, err := scanner.Quarantine(ctx, h.Storage, fileName, rc, size)
+		rc.Close()
+		if err != nil {
+			log.Printf("⚠️ Failed to quarantine %s: %v", fileName, err)
+		} else {
+			infected.QuarantineKey = key
+		}
+	}
+	log.Printf("🚫 Rejected infected upload %s: %s", fileName, infected.Threat)
+	return infected
+}
+
+// rejectScannedUpload answers an upload the scanner rejected or could not
+// check, audit-logging infected files. It reports whether it handled err.
+func (h *Handler) rejectScannedUpload(c *gin.Context, entityID *uint, err error) bool {
+	var infected *scanner.InfectedError
+	switch {
+	case errors.As(err, &infected):
+		userID := c.GetUint("user_id")
+		h.Service.AuditService.LogAction(c.Request.Context(), &userID, entityID, "UPLOAD_MALWARE_DETECTED", map[string]interface{}{
+			"file_name":      infected.FileName,
+			"threat":         infected.Threat,
+			"quarantine_key": infected.QuarantineKey,
+			"scanner":        h.Scanner.Name(),
+		}, middleware.GetIPFromContext(c), "failure")
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "File rejected: malware detected", "file_name": infected.FileName})
+		return true
+	case errors.Is(err, scanner.ErrUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Uploaded files cannot be scanned right now, please try again later"})
+		return true
+	}
+	return false
+}
@@ -19,6 +19,7 @@ type Service struct {
 	Repo              *Repository
 	MembershipService MembershipService
 	AuditService      auditlog.Service
+	Geocoder          Geocoder // reverse geocodes map link coordinates; nil disables it
 }
 
 
@@ -56,6 +57,18 @@ func (s *Service) CreateEntity(e *Entity, userID uint, userRoleID uint, ip strin
 		return ErrMissingFields
 	}
 
+	if err := s.applyMapLocation(e); err != nil {
+		s.AuditService.LogAction(context.Background(), &userID, nil, "TEMPLE_CREATE_FAILED", map[string]interface{}{
+			"temple_name": strings.TrimSpace(e.Name),
+			"map_link":    e.MapLink,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return err
+	}
+	if err := applyTimezone(e, DefaultTimezone); err != nil {
+		return err
+	}
+
 	now := time.Now()
 
 	// AUTO-APPROVE LOGIC: Check if creator is superadmin (role_id = 1)
@@ -96,6 +109,12 @@ func (s *Service) CreateEntity(e *Entity, userID uint, userRoleID uint, ip strin
 	e.PropertyDocsURL = strings.TrimSpace(e.PropertyDocsURL)
 	e.AdditionalDocsURLs = strings.TrimSpace(e.AdditionalDocsURLs)
 
+	slug, err := s.Repo.UniqueSlug(e)
+	if err != nil {
+		return err
+	}
+	e.Slug = &slug
+
 	// Save entity to database
 	if err := s.Repo.CreateEntity(e); err != nil {
 		auditDetails := map[string]interface{}{
@@ -191,6 +210,24 @@ func (s *Service) UpdateEntity(e Entity, userID uint, userRoleID uint, ip string
 		return err
 	}
 
+	// Keep stored coordinates when the map link is unchanged and none were sent
+	if e.Latitude == nil && e.Longitude == nil && e.MapLink == existingEntity.MapLink {
+		e.Latitude, e.Longitude = existingEntity.Latitude, existingEntity.Longitude
+	}
+	if err := s.applyMapLocation(&e); err != nil {
+		s.AuditService.LogAction(context.Background(), &userID, &e.ID, "TEMPLE_UPDATE_FAILED", map[string]interface{}{
+			"temple_id": e.ID,
+			"map_link":  e.MapLink,
+			"error":     err.Error(),
+		}, ip, "failure")
+		return err
+	}
+	if err := applyTimezone(&e, existingEntity.Timezone); err != nil {
+		return err
+	}
+	// Public links keep working across renames
+	e.Slug = existingEntity.Slug
+
 	e.UpdatedAt = time.Now()
 
 	// Update the entity in database
@@ -444,10 +481,6 @@ func (s *Service) GetDashboardSummary(entityID uint) (DashboardSummary, error) {
 
 	return summary, nil
 }
-/*func (s *Service) GetVolunteersByEntityID(entityID uint) ([]UserEntityMembership, error) {
-	return s.Repo.GetVolunteersByEntityID(entityID)
-}
-*/
 // Helper function to track what fields were updated
 func getUpdatedFields(old, new Entity) []string {
 	var updatedFields []string
@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+// maxSlugLength keeps room for a "-<n>" suffix within the column size
+const maxSlugLength = 100
+
+// Slugify turns a temple name into the URL form used by the public
+// directory, e.g. "Sri Venkateswara Temple" -> "sri-venkateswara-temple"
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			dash = false
+		case b.Len() > 0 && !dash:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.Trim(b.String(), "-")
+	if len(slug) > maxSlugLength {
+		slug = strings.Trim(slug[:maxSlugLength], "-")
+	}
+	if slug == "" {
+		slug = "temple"
+	}
+	return slug
+}
+
+// UniqueSlug returns a slug for the temple that no other temple uses, adding
+// the city and then a number when the name alone is taken
+func (r *Repository) UniqueSlug(e *Entity) (string, error) {
+	base := Slugify(e.Name)
+	candidates := []string{base}
+	if city := Slugify(e.City); e.City != "" && city != "temple" {
+		candidates = append(candidates, base+"-"+city)
+	}
+	for _, slug := range candidates {
+		taken, err := r.slugTaken(slug, e.ID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+	last := candidates[len(candidates)-1]
+	for n := 2; ; n++ {
+		slug := fmt.Sprintf("%s-%d", last, n)
+		taken, err := r.slugTaken(slug, e.ID)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return slug, nil
+		}
+	}
+}
+
+func (r *Repository) slugTaken(slug string, exceptID uint) (bool, error) {
+	var count int64
+	err := r.DB.Model(&Entity{}).Where("slug = ? AND id <> ?", slug, exceptID).Count(&count).Error
+	return count > 0, err
+}
+
+// BackfillSlugs gives temples created before slugs existed one
+func BackfillSlugs(db *gorm.DB) {
+	repo := NewRepository(db)
+	var entities []Entity
+	if err := db.Select("id", "name", "city").Where("slug IS NULL OR slug = ''").Find(&entities).Error; err != nil {
+		log.Printf("⚠️  failed to load temples without a slug: %v", err)
+		return
+	}
+	for i := range entities {
+		slug, err := repo.UniqueSlug(&entities[i])
+		if err == nil {
+			err = db.Model(&Entity{}).Where("id = ?", entities[i].ID).UpdateColumn("slug", slug).Error
+		}
+		if err != nil {
+			log.Printf("⚠️  failed to set slug of temple %d: %v", entities[i].ID, err)
+		}
+	}
+}
@@ -0,0 +1,399 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Usage levels temple admins are alerted at, in percent of the quota
+const (
+	storageAlertWarning = 80
+	storageAlertFull    = 100
+)
+
+// StorageUsage is the bytes an entity keeps in file storage
+type StorageUsage struct {
+	EntityID   uint      `gorm:"primaryKey" json:"entity_id"`
+	BytesUsed  int64     `gorm:"not null;default:0" json:"bytes_used"`
+	QuotaBytes *int64    `json:"quota_bytes"`                 // overrides the default quota, 0 = unlimited
+	AlertLevel int       `gorm:"not null;default:0" json:"-"` // highest alert sent since usage was last below it
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func (StorageUsage) TableName() string {
+	return "entity_storage_usages"
+}
+
+// StorageUsageResponse is the storage usage of a temple
+type StorageUsageResponse struct {
+	EntityID       uint      `json:"entity_id"`
+	BytesUsed      int64     `json:"bytes_used"`
+	QuotaBytes     int64     `json:"quota_bytes"` // 0 = unlimited
+	Unlimited      bool      `json:"unlimited"`
+	QuotaSource    string    `json:"quota_source"` // default or entity
+	PercentUsed    float64   `json:"percent_used"`
+	RemainingBytes *int64    `json:"remaining_bytes"`
+	Used           string    `json:"used"`
+	Quota          string    `json:"quota"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// SetStorageQuotaRequest sets a temple's own quota; a null quota_mb returns
+// it to the default, 0 makes it unlimited
+type SetStorageQuotaRequest struct {
+	QuotaMB *int64 `json:"quota_mb"`
+}
+
+// StorageNotifier delivers in-app notifications (notification.Service)
+type StorageNotifier interface {
+	CreateInAppForEntityRoles(ctx context.Context, entityID uint, roleNames []string, title, message, category string) error
+}
+
+// =========================== REPOSITORY ===========================
+
+func (r *Repository) GetStorageUsage(ctx context.Context, entityID uint) (*StorageUsage, error) {
+	var u StorageUsage
+	err := r.DB.WithContext(ctx).Where("entity_id = ?", entityID).First(&u).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateStorageUsage records the usage of an entity seen for the first time;
+// a row created meanwhile by another request wins
+func (r *Repository) CreateStorageUsage(ctx context.Context, entityID uint, bytesUsed int64) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&StorageUsage{EntityID: entityID, BytesUsed: bytesUsed, UpdatedAt: time.Now()}).Error
+}
+
+// AdjustStorageUsage adds delta to the entity's usage under a row lock. With
+// enforce set, growth past the quota is refused with a *storage.QuotaError.
+// It returns the usage after the change and the alert level newly reached,
+// if any (a refused write counts as full).
+func (r *Repository) AdjustStorageUsage(ctx context.Context, entityID uint, delta, defaultQuota int64, enforce bool) (StorageUsage, int, error) {
+	var usage StorageUsage
+	var alert int
+	var quotaErr error
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("entity_id = ?", entityID).First(&usage).Error; err != nil {
+			return err
+		}
+		quota := usage.effectiveQuota(defaultQuota)
+		level := 0
+		if enforce && delta > 0 && quota > 0 && usage.BytesUsed+delta > quota {
+			quotaErr = &storage.QuotaError{EntityID: entityID, Used: usage.BytesUsed, Quota: quota, Size: delta}
+			level = storageAlertFull
+		} else {
+			usage.BytesUsed += delta
+			if usage.BytesUsed < 0 {
+				usage.BytesUsed = 0
+			}
+			level = storageAlertLevel(usage.BytesUsed, quota)
+		}
+
+		updates := map[string]interface{}{"bytes_used": usage.BytesUsed, "updated_at": time.Now()}
+		switch {
+		case level > usage.AlertLevel:
+			alert = level
+			usage.AlertLevel = level
+			updates["alert_level"] = level
+		case level < usage.AlertLevel && delta < 0:
+			// freeing space re-arms the alerts
+			usage.AlertLevel = level
+			updates["alert_level"] = level
+		}
+		return tx.Model(&StorageUsage{}).Where("entity_id = ?", entityID).Updates(updates).Error
+	})
+	if err != nil {
+		return usage, 0, err
+	}
+	return usage, alert, quotaErr
+}
+
+// SetStorageUsed replaces the tracked usage with a fresh count
+func (r *Repository) SetStorageUsed(ctx context.Context, entityID uint, bytesUsed, defaultQuota int64) error {
+	if err := r.CreateStorageUsage(ctx, entityID, bytesUsed); err != nil {
+		return err
+	}
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var usage StorageUsage
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("entity_id = ?", entityID).First(&usage).Error; err != nil {
+			return err
+		}
+		level := storageAlertLevel(bytesUsed, usage.effectiveQuota(defaultQuota))
+		if level > usage.AlertLevel {
+			level = usage.AlertLevel
+		}
+		return tx.Model(&StorageUsage{}).Where("entity_id = ?", entityID).Updates(map[string]interface{}{
+			"bytes_used":  bytesUsed,
+			"alert_level": level,
+			"updated_at":  time.Now(),
+		}).Error
+	})
+}
+
+func (r *Repository) SetStorageQuota(ctx context.Context, entityID uint, quotaBytes *int64) error {
+	return r.DB.WithContext(ctx).Model(&StorageUsage{}).Where("entity_id = ?", entityID).
+		Updates(map[string]interface{}{"quota_bytes": quotaBytes, "alert_level": 0, "updated_at": time.Now()}).Error
+}
+
+// =========================== SERVICE ===========================
+
+func (u *StorageUsage) effectiveQuota(defaultQuota int64) int64 {
+	if u.QuotaBytes != nil {
+		return *u.QuotaBytes
+	}
+	return defaultQuota
+}
+
+func storageAlertLevel(used, quota int64) int {
+	switch {
+	case quota <= 0:
+		return 0
+	case used >= quota:
+		return storageAlertFull
+	case used*100 >= quota*storageAlertWarning:
+		return storageAlertWarning
+	default:
+		return 0
+	}
+}
+
+// StorageQuota meters the bytes each temple stores against its quota. It is
+// the storage.UsageMeter of the storage router.
+type StorageQuota struct {
+	Repo         *Repository
+	Store        storage.Storage
+	DefaultQuota int64 // bytes, 0 = unlimited
+	Audit        auditlog.Service
+	Notifier     StorageNotifier // nil disables usage alerts
+}
+
+// NewStorageQuota creates the meter; defaultQuotaMB of 0 means unlimited
+func NewStorageQuota(repo *Repository, store storage.Storage, defaultQuotaMB int64, auditSvc auditlog.Service) *StorageQuota {
+	return &StorageQuota{Repo: repo, Store: store, DefaultQuota: defaultQuotaMB << 20, Audit: auditSvc}
+}
+
+// countStored sums the sizes of the entity's objects in storage
+func (q *StorageQuota) countStored(ctx context.Context, entityID uint) (int64, error) {
+	objects, err := q.Store.List(ctx, strconv.FormatUint(uint64(entityID), 10)+"/")
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, obj := range objects {
+		total += obj.Size
+	}
+	return total, nil
+}
+
+// ensureUsage starts tracking an entity from what it already stores
+func (q *StorageQuota) ensureUsage(ctx context.Context, entityID uint) error {
+	usage, err := q.Repo.GetStorageUsage(ctx, entityID)
+	if err != nil || usage != nil {
+		return err
+	}
+	used, err := q.countStored(ctx, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to count stored files: %w", err)
+	}
+	return q.Repo.CreateStorageUsage(ctx, entityID, used)
+}
+
+func (q *StorageQuota) adjust(ctx context.Context, entityID uint, delta int64, enforce bool) error {
+	if err := q.ensureUsage(ctx, entityID); err != nil {
+		return err
+	}
+	usage, alert, err := q.Repo.AdjustStorageUsage(ctx, entityID, delta, q.DefaultQuota, enforce)
+	var quotaErr *storage.QuotaError
+	if errors.As(err, &quotaErr) {
+		q.Audit.LogAction(ctx, nil, &entityID, "STORAGE_QUOTA_EXCEEDED", map[string]interface{}{
+			"bytes_used":  quotaErr.Used,
+			"quota_bytes": quotaErr.Quota,
+			"file_bytes":  quotaErr.Size,
+		}, "", "failure")
+	}
+	if alert > 0 {
+		q.notify(ctx, entityID, alert, usage)
+	}
+	return err
+}
+
+// Reserve implements storage.UsageMeter
+func (q *StorageQuota) Reserve(ctx context.Context, entityID uint, size int64) error {
+	return q.adjust(ctx, entityID, size, true)
+}
+
+// Release implements storage.UsageMeter
+func (q *StorageQuota) Release(ctx context.Context, entityID uint, size int64) {
+	if err := q.adjust(ctx, entityID, -size, false); err != nil {
+		log.Printf("⚠️ Failed to release %d bytes of entity %d storage: %v", size, entityID, err)
+	}
+}
+
+// Recalculate recounts the entity's usage from storage, e.g. after files
+// were removed outside the API
+func (q *StorageQuota) Recalculate(ctx context.Context, entityID uint) error {
+	used, err := q.countStored(ctx, entityID)
+	if err != nil {
+		return fmt.Errorf("failed to count stored files: %w", err)
+	}
+	return q.Repo.SetStorageUsed(ctx, entityID, used, q.DefaultQuota)
+}
+
+// Usage returns the entity's usage against its quota
+func (q *StorageQuota) Usage(ctx context.Context, entityID uint) (*StorageUsageResponse, error) {
+	if err := q.ensureUsage(ctx, entityID); err != nil {
+		return nil, err
+	}
+	usage, err := q.Repo.GetStorageUsage(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if usage == nil {
+		return nil, errors.New("storage usage not found")
+	}
+
+	quota := usage.effectiveQuota(q.DefaultQuota)
+	resp := &StorageUsageResponse{
+		EntityID:    entityID,
+		BytesUsed:   usage.BytesUsed,
+		QuotaBytes:  quota,
+		Unlimited:   quota <= 0,
+		QuotaSource: "default",
+		Used:        storage.FormatBytes(usage.BytesUsed),
+		Quota:       "unlimited",
+		UpdatedAt:   usage.UpdatedAt,
+	}
+	if usage.QuotaBytes != nil {
+		resp.QuotaSource = "entity"
+	}
+	if quota > 0 {
+		remaining := quota - usage.BytesUsed
+		if remaining < 0 {
+			remaining = 0
+		}
+		resp.RemainingBytes = &remaining
+		resp.PercentUsed = float64(usage.BytesUsed*10000/quota) / 100
+		resp.Quota = storage.FormatBytes(quota)
+	}
+	return resp, nil
+}
+
+// SetQuota gives the entity its own quota, or returns it to the default
+func (q *StorageQuota) SetQuota(ctx context.Context, entityID, adminID uint, quotaMB *int64, ip string) error {
+	if err := q.ensureUsage(ctx, entityID); err != nil {
+		return err
+	}
+	var quotaBytes *int64
+	if quotaMB != nil {
+		b := *quotaMB << 20
+		quotaBytes = &b
+	}
+	if err := q.Repo.SetStorageQuota(ctx, entityID, quotaBytes); err != nil {
+		return err
+	}
+	q.Audit.LogAction(ctx, &adminID, &entityID, "STORAGE_QUOTA_UPDATED", map[string]interface{}{
+		"quota_mb": quotaMB,
+	}, ip, "success")
+	return nil
+}
+
+// notify tells the temple admins their storage reached an alert level
+func (q *StorageQuota) notify(ctx context.Context, entityID uint, level int, usage StorageUsage) {
+	if q.Notifier == nil {
+		return
+	}
+	quota := storage.FormatBytes(usage.effectiveQuota(q.DefaultQuota))
+	used := storage.FormatBytes(usage.BytesUsed)
+
+	title := "Storage almost full"
+	message := fmt.Sprintf("Your temple has used %s of its %s of file storage. Remove files you no longer need before uploads are blocked.", used, quota)
+	if level >= storageAlertFull {
+		title = "Storage full"
+		message = fmt.Sprintf("Your temple has used %s of its %s of file storage and new uploads are being rejected. Remove files or ask for a larger quota.", used, quota)
+	}
+	if err := q.Notifier.CreateInAppForEntityRoles(ctx, entityID, []string{"templeadmin"}, title, message, "storage"); err != nil {
+		log.Printf("⚠️ Storage alert for entity %d failed: %v", entityID, err)
+	}
+}
+
+// =========================== HANDLER ===========================
+
+// GetStorageUsage - GET /entities/:id/storage-usage
+// ?refresh=true recounts the temple's files first.
+func (h *Handler) GetStorageUsage(c *gin.Context) {
+	_, entityID, ok := fileEntityAccess(c, false)
+	if !ok {
+		return
+	}
+	if h.Quota == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Storage usage tracking is not enabled"})
+		return
+	}
+	ctx := c.Request.Context()
+	if c.Query("refresh") == "true" {
+		if err := h.Quota.Recalculate(ctx, entityID); err != nil {
+			log.Printf("Storage recount for entity %d failed: %v", entityID, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to recount storage usage"})
+			return
+		}
+	}
+	usage, err := h.Quota.Usage(ctx, entityID)
+	if err != nil {
+		log.Printf("Storage usage for entity %d failed: %v", entityID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage usage"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": usage})
+}
+
+// SetStorageQuota - PUT /entities/:id/storage-quota (superadmin)
+func (h *Handler) SetStorageQuota(c *gin.Context) {
+	accessContext, entityID, ok := fileEntityAccess(c, true)
+	if !ok {
+		return
+	}
+	if h.Quota == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Storage usage tracking is not enabled"})
+		return
+	}
+	var req SetStorageQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if req.QuotaMB != nil && *req.QuotaMB < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "quota_mb must be 0 (unlimited) or more"})
+		return
+	}
+	ctx := c.Request.Context()
+	if err := h.Quota.SetQuota(ctx, entityID, accessContext.UserID, req.QuotaMB, middleware.GetIPFromContext(c)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update storage quota"})
+		return
+	}
+	usage, err := h.Quota.Usage(ctx, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch storage usage"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Storage quota updated", "data": usage})
+}
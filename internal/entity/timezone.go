@@ -0,0 +1,47 @@
+package entity
+
+import (
+	"errors"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones validate on images without a zone database
+)
+
+// DefaultTimezone is the timezone of temples that didn't choose one
+const DefaultTimezone = "Asia/Kolkata"
+
+var ErrInvalidTimezone = errors.New("timezone must be an IANA timezone such as Asia/Kolkata")
+
+// Location returns the location of an entity timezone, falling back to
+// DefaultTimezone when name is empty or unknown
+func Location(name string) *time.Location {
+	if name = strings.TrimSpace(name); name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+	loc, err := time.LoadLocation(DefaultTimezone)
+	if err != nil {
+		return time.FixedZone(DefaultTimezone, 5*60*60+30*60)
+	}
+	return loc
+}
+
+// applyTimezone validates e.Timezone, using fallback when none was sent
+func applyTimezone(e *Entity, fallback string) error {
+	e.Timezone = strings.TrimSpace(e.Timezone)
+	if e.Timezone == "" {
+		e.Timezone = fallback
+	}
+	if e.Timezone == "" {
+		e.Timezone = DefaultTimezone
+	}
+	// "Local" is whatever zone the server runs in, not the temple's
+	if e.Timezone == "Local" {
+		return ErrInvalidTimezone
+	}
+	if _, err := time.LoadLocation(e.Timezone); err != nil {
+		return ErrInvalidTimezone
+	}
+	return nil
+}
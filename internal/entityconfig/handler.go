@@ -0,0 +1,238 @@
+package entityconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/exportcrypto"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// maxBundleSize caps uploaded bundle files
+const maxBundleSize = 5 << 20
+
+// Handler exposes export/import of temple configuration
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new configuration handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ExportConfig - GET /entities/:id/config/export
+func (h *Handler) ExportConfig(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, false)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.Service.Export(entityID, user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export configuration", "details": err.Error()})
+		return
+	}
+
+	data, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode configuration"})
+		return
+	}
+
+	filename := fmt.Sprintf("temple_%d_config_v%d_%s.json", entityID, BundleVersion, time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// ExportConfigEncrypted - POST /entities/:id/config/export
+// Body: {"encryption": "tenant"} or {"encryption": "recipient", "public_key": "<RSA PEM>"}.
+// Returns the bundle inside an encrypted envelope carrying the key fingerprint.
+func (h *Handler) ExportConfigEncrypted(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, false)
+	if !ok {
+		return
+	}
+
+	var opts EncryptOptions
+	if err := c.ShouldBindJSON(&opts); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	opts.Encryption = strings.ToLower(strings.TrimSpace(opts.Encryption))
+	if opts.Encryption == exportcrypto.KeyTypeRecipient && strings.TrimSpace(opts.PublicKey) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "public_key is required for recipient encryption"})
+		return
+	}
+
+	env, err := h.Service.ExportEncrypted(entityID, user.ID, opts, middleware.GetIPFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, exportcrypto.ErrNoMasterKey):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		case errors.Is(err, exportcrypto.ErrInvalidPublicKey), errors.Is(err, ErrUnsupportedEncryption):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export configuration", "details": err.Error()})
+		}
+		return
+	}
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to encode configuration"})
+		return
+	}
+
+	filename := fmt.Sprintf("temple_%d_config_v%d_%s.enc.json", entityID, BundleVersion, time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Header("X-Key-Fingerprint", env.KeyFingerprint)
+	c.Data(http.StatusOK, "application/json", data)
+}
+
+// ImportConfig - POST /entities/:id/config/import
+// Accepts the bundle as a JSON body or as a multipart "file" field.
+// Tenant-encrypted bundles are decrypted when the target temple belongs to the same tenant.
+// Query: dry_run=true to validate only, include_settings=false to keep the target's settings.
+func (h *Handler) ImportConfig(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	bundle, err := h.readBundle(c, entityID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid configuration file", "details": err.Error()})
+		return
+	}
+
+	opts := ImportOptions{
+		DryRun:          c.Query("dry_run") == "true",
+		IncludeSettings: c.DefaultQuery("include_settings", "true") != "false",
+	}
+
+	result, err := h.Service.Import(entityID, user.ID, bundle, opts, middleware.GetIPFromContext(c))
+	if err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": vErr.Error(), "problems": vErr.Problems})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import configuration", "details": err.Error()})
+		return
+	}
+
+	message := "Configuration imported successfully"
+	if opts.DryRun {
+		message = "Configuration is valid (dry run, nothing was changed)"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "result": result})
+}
+
+func (h *Handler) readBundle(c *gin.Context, entityID uint) (*Bundle, error) {
+	var r io.Reader = c.Request.Body
+	if strings.Contains(c.GetHeader("Content-Type"), "multipart/form-data") {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return nil, errors.New("file field is required")
+		}
+		metrics.UploadSize.Observe(float64(fh.Size), "config_bundle")
+		if fh.Size > maxBundleSize {
+			return nil, fmt.Errorf("file exceeds %dMB limit", maxBundleSize>>20)
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxBundleSize))
+	if err != nil {
+		return nil, err
+	}
+
+	// Encrypted exports are wrapped in an envelope; open it with the tenant key
+	var head struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &head); err == nil && head.Kind == exportcrypto.EnvelopeKind {
+		var env exportcrypto.Envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			return nil, err
+		}
+		return h.Service.DecryptBundle(entityID, &env)
+	}
+
+	var b Bundle
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// authorize resolves the temple from :id and checks the caller may manage it
+func (h *Handler) authorize(c *gin.Context, write bool) (auth.User, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return auth.User{}, 0, false
+	}
+	entityID := uint(id)
+
+	userVal, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return auth.User{}, 0, false
+	}
+	user, ok := userVal.(auth.User)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user object"})
+		return auth.User{}, 0, false
+	}
+
+	accessVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return auth.User{}, 0, false
+	}
+	accessCtx, ok := accessVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return auth.User{}, 0, false
+	}
+
+	e, err := h.Service.Repo.GetEntity(entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
+		return auth.User{}, 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temple"})
+		return auth.User{}, 0, false
+	}
+
+	if !accessCtx.IsEntityStaff(e.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this entity"})
+		return auth.User{}, 0, false
+	}
+	if write && !accessCtx.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient write permissions"})
+		return auth.User{}, 0, false
+	}
+	return user, entityID, true
+}
@@ -0,0 +1,98 @@
+package entityconfig
+
+import (
+	"time"
+)
+
+// BundleKind identifies a temple configuration bundle file
+const BundleKind = "temple-config-bundle"
+
+// BundleVersion is the current bundle format version.
+// Bump it when a section changes shape and keep importers for older versions.
+const BundleVersion = 1
+
+// Bundle is the portable, versioned configuration of a single temple.
+// It deliberately excludes transactional data (bookings, donations, devotees, logs).
+type Bundle struct {
+	Kind           string           `json:"kind"`
+	Version        int              `json:"version"`
+	ExportedAt     time.Time        `json:"exported_at"`
+	SourceEntityID uint             `json:"source_entity_id"`
+	Settings       EntitySettings   `json:"settings"`
+	Sevas          []SevaConfig     `json:"sevas"`
+	Templates      []TemplateConfig `json:"notification_templates"`
+}
+
+// EntitySettings holds the descriptive temple settings that are safe to copy
+// between environments. Identity and approval fields (email, documents,
+// status, creator) stay with the target entity.
+type EntitySettings struct {
+	Name            string  `json:"name"`
+	MainDeity       *string `json:"main_deity"`
+	TempleType      string  `json:"temple_type"`
+	EstablishedYear *uint   `json:"established_year"`
+	Phone           string  `json:"phone"`
+	Description     string  `json:"description"`
+	StreetAddress   string  `json:"street_address"`
+	Landmark        string  `json:"landmark"`
+	City            string  `json:"city"`
+	District        string  `json:"district"`
+	State           string  `json:"state"`
+	Pincode         string  `json:"pincode"`
+	MapLink         string  `json:"map_link"`
+}
+
+// SevaConfig is a seva catalog entry including its timings and capacity
+type SevaConfig struct {
+	Name           string  `json:"name"`
+	SevaType       string  `json:"seva_type"`
+	Description    string  `json:"description"`
+	Price          float64 `json:"price"`
+	Date           string  `json:"date"`
+	StartTime      string  `json:"start_time"`
+	EndTime        string  `json:"end_time"`
+	Duration       int     `json:"duration"`
+	AvailableSlots int     `json:"available_slots"`
+	Status         string  `json:"status"`
+	IsActive       bool    `json:"is_active"`
+}
+
+// TemplateConfig is a reusable notification template
+type TemplateConfig struct {
+	Name     string `json:"name"`
+	Category string `json:"category"`
+	Subject  string `json:"subject,omitempty"`
+	Body     string `json:"body"`
+}
+
+// ImportOptions controls how a bundle is applied
+type ImportOptions struct {
+	DryRun          bool // validate and report without writing
+	IncludeSettings bool // overwrite the target temple's settings
+}
+
+// EncryptOptions is the body of POST /entities/:id/config/export
+type EncryptOptions struct {
+	Encryption string `json:"encryption" binding:"required"` // tenant or recipient
+	PublicKey  string `json:"public_key"`                    // RSA public key (PEM), recipient only
+}
+
+// ImportResult summarises what an import changed (or would change on dry run)
+type ImportResult struct {
+	DryRun           bool     `json:"dry_run"`
+	SettingsUpdated  bool     `json:"settings_updated"`
+	SevasCreated     int      `json:"sevas_created"`
+	SevasUpdated     int      `json:"sevas_updated"`
+	TemplatesCreated int      `json:"templates_created"`
+	TemplatesUpdated int      `json:"templates_updated"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// ValidationError lists every problem found in a bundle
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration bundle"
+}
@@ -0,0 +1,167 @@
+package entityconfig
+
+import (
+	"errors"
+
+	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/seva"
+	"gorm.io/gorm"
+)
+
+// Repository reads and writes the configuration tables of a temple
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new configuration repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetEntity loads the temple row
+func (r *Repository) GetEntity(entityID uint) (*entity.Entity, error) {
+	var e entity.Entity
+	if err := r.DB.First(&e, entityID).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListSevas returns the seva catalog of a temple
+func (r *Repository) ListSevas(entityID uint) ([]seva.Seva, error) {
+	var sevas []seva.Seva
+	err := r.DB.Where("entity_id = ?", entityID).Order("id ASC").Find(&sevas).Error
+	return sevas, err
+}
+
+// ListTemplates returns the notification templates of a temple
+func (r *Repository) ListTemplates(entityID uint) ([]notification.NotificationTemplate, error) {
+	var templates []notification.NotificationTemplate
+	err := r.DB.Where("entity_id = ?", entityID).Order("id ASC").Find(&templates).Error
+	return templates, err
+}
+
+// Apply writes a validated bundle in a single transaction
+func (r *Repository) Apply(entityID, userID uint, b *Bundle, opts ImportOptions) (*ImportResult, error) {
+	result := &ImportResult{DryRun: opts.DryRun}
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if opts.IncludeSettings {
+			s := b.Settings
+			if err := tx.Model(&entity.Entity{}).Where("id = ?", entityID).Updates(map[string]interface{}{
+				"name":             s.Name,
+				"main_deity":       s.MainDeity,
+				"temple_type":      s.TempleType,
+				"established_year": s.EstablishedYear,
+				"phone":            s.Phone,
+				"description":      s.Description,
+				"street_address":   s.StreetAddress,
+				"landmark":         s.Landmark,
+				"city":             s.City,
+				"district":         s.District,
+				"state":            s.State,
+				"pincode":          s.Pincode,
+				"map_link":         s.MapLink,
+			}).Error; err != nil {
+				return err
+			}
+			result.SettingsUpdated = true
+		}
+
+		for _, sc := range b.Sevas {
+			var existing seva.Seva
+			err := tx.Where("entity_id = ? AND name = ? AND seva_type = ? AND date = ? AND start_time = ?",
+				entityID, sc.Name, sc.SevaType, sc.Date, sc.StartTime).First(&existing).Error
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				s := seva.Seva{
+					EntityID:       entityID,
+					Name:           sc.Name,
+					SevaType:       sc.SevaType,
+					Description:    sc.Description,
+					Price:          sc.Price,
+					Date:           sc.Date,
+					StartTime:      sc.StartTime,
+					EndTime:        sc.EndTime,
+					Duration:       sc.Duration,
+					AvailableSlots: sc.AvailableSlots,
+					RemainingSlots: sc.AvailableSlots,
+					Status:         sc.Status,
+					IsActive:       sc.IsActive,
+				}
+				if err := tx.Create(&s).Error; err != nil {
+					return err
+				}
+				result.SevasCreated++
+			case err != nil:
+				return err
+			default:
+				// Keep booked slot counts of the target, only the configured capacity moves
+				remaining := sc.AvailableSlots - existing.BookedSlots
+				if remaining < 0 {
+					remaining = 0
+				}
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"description":     sc.Description,
+					"price":           sc.Price,
+					"end_time":        sc.EndTime,
+					"duration":        sc.Duration,
+					"available_slots": sc.AvailableSlots,
+					"remaining_slots": remaining,
+					"status":          sc.Status,
+					"is_active":       sc.IsActive,
+				}).Error; err != nil {
+					return err
+				}
+				result.SevasUpdated++
+			}
+		}
+
+		for _, tc := range b.Templates {
+			var existing notification.NotificationTemplate
+			err := tx.Where("entity_id = ? AND name = ?", entityID, tc.Name).First(&existing).Error
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				t := notification.NotificationTemplate{
+					UserID:   userID,
+					EntityID: entityID,
+					Name:     tc.Name,
+					Category: tc.Category,
+					Subject:  tc.Subject,
+					Body:     tc.Body,
+				}
+				if err := tx.Create(&t).Error; err != nil {
+					return err
+				}
+				result.TemplatesCreated++
+			case err != nil:
+				return err
+			default:
+				if err := tx.Model(&existing).Updates(map[string]interface{}{
+					"category": tc.Category,
+					"subject":  tc.Subject,
+					"body":     tc.Body,
+				}).Error; err != nil {
+					return err
+				}
+				result.TemplatesUpdated++
+			}
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+	return result, nil
+}
+
+// errDryRun rolls the import transaction back after counting changes
+var errDryRun = errors.New("dry run")
@@ -0,0 +1,296 @@
+package entityconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/exportcrypto"
+)
+
+// Service builds and applies temple configuration bundles
+type Service struct {
+	Repo         *Repository
+	AuditService auditlog.Service
+	ExportKey    []byte // master key for per-tenant export encryption; nil disables tenant keys
+}
+
+// NewService initializes the configuration service
+func NewService(repo *Repository, auditSvc auditlog.Service, exportKey []byte) *Service {
+	return &Service{Repo: repo, AuditService: auditSvc, ExportKey: exportKey}
+}
+
+// ErrUnsupportedEncryption is returned for an unknown export encryption mode
+var ErrUnsupportedEncryption = errors.New("unsupported encryption, use tenant or recipient")
+
+var (
+	timePattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+	datePattern = regexp.MustCompile(`^\d{2}-\d{2}-\d{4}$`)
+
+	validTemplateCategories = map[string]bool{"email": true, "sms": true, "whatsapp": true, "push": true}
+	validSevaStatuses       = map[string]bool{"upcoming": true, "ongoing": true, "completed": true}
+)
+
+// Export collects the configuration of a temple into a bundle
+func (s *Service) Export(entityID, userID uint, ip string) (*Bundle, error) {
+	e, err := s.Repo.GetEntity(entityID)
+	if err != nil {
+		return nil, err
+	}
+	sevas, err := s.Repo.ListSevas(entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sevas: %w", err)
+	}
+	templates, err := s.Repo.ListTemplates(entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load notification templates: %w", err)
+	}
+
+	b := &Bundle{
+		Kind:           BundleKind,
+		Version:        BundleVersion,
+		ExportedAt:     time.Now().UTC(),
+		SourceEntityID: entityID,
+		Settings: EntitySettings{
+			Name:            e.Name,
+			MainDeity:       e.MainDeity,
+			TempleType:      e.TempleType,
+			EstablishedYear: e.EstablishedYear,
+			Phone:           e.Phone,
+			Description:     e.Description,
+			StreetAddress:   e.StreetAddress,
+			Landmark:        e.Landmark,
+			City:            e.City,
+			District:        e.District,
+			State:           e.State,
+			Pincode:         e.Pincode,
+			MapLink:         e.MapLink,
+		},
+		Sevas:     make([]SevaConfig, 0, len(sevas)),
+		Templates: make([]TemplateConfig, 0, len(templates)),
+	}
+	for _, sv := range sevas {
+		b.Sevas = append(b.Sevas, SevaConfig{
+			Name:           sv.Name,
+			SevaType:       sv.SevaType,
+			Description:    sv.Description,
+			Price:          sv.Price,
+			Date:           sv.Date,
+			StartTime:      sv.StartTime,
+			EndTime:        sv.EndTime,
+			Duration:       sv.Duration,
+			AvailableSlots: sv.AvailableSlots,
+			Status:         sv.Status,
+			IsActive:       sv.IsActive,
+		})
+	}
+	for _, t := range templates {
+		b.Templates = append(b.Templates, TemplateConfig{
+			Name:     t.Name,
+			Category: t.Category,
+			Subject:  t.Subject,
+			Body:     t.Body,
+		})
+	}
+
+	s.AuditService.LogAction(context.Background(), &userID, &entityID, "ENTITY_CONFIG_EXPORTED", map[string]interface{}{
+		"version":   BundleVersion,
+		"sevas":     len(b.Sevas),
+		"templates": len(b.Templates),
+	}, ip, "success")
+
+	return b, nil
+}
+
+// ExportEncrypted exports the temple configuration encrypted with the
+// tenant's key, or for the recipient when a public key is given. The key
+// fingerprint is kept in the envelope and in the audit log.
+func (s *Service) ExportEncrypted(entityID, userID uint, opts EncryptOptions, ip string) (*exportcrypto.Envelope, error) {
+	e, err := s.Repo.GetEntity(entityID)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.Export(entityID, userID, ip)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(b)
+	if err != nil {
+		return nil, err
+	}
+
+	// The tenant is the temple admin who owns the temple
+	tenantID := e.CreatedBy
+	var env *exportcrypto.Envelope
+	switch opts.Encryption {
+	case exportcrypto.KeyTypeTenant:
+		env, err = exportcrypto.EncryptForTenant(s.ExportKey, tenantID, data, "application/json")
+	case exportcrypto.KeyTypeRecipient:
+		env, err = exportcrypto.EncryptForRecipient([]byte(opts.PublicKey), tenantID, data, "application/json")
+	default:
+		err = ErrUnsupportedEncryption
+	}
+	if err != nil {
+		s.AuditService.LogAction(context.Background(), &userID, &entityID, "ENTITY_CONFIG_EXPORT_ENCRYPTION_FAILED", map[string]interface{}{
+			"encryption": opts.Encryption,
+			"error":      err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	s.AuditService.LogAction(context.Background(), &userID, &entityID, "ENTITY_CONFIG_EXPORT_ENCRYPTED", map[string]interface{}{
+		"key_type":        env.KeyType,
+		"tenant_id":       env.TenantID,
+		"key_fingerprint": env.KeyFingerprint,
+		"algorithm":       env.Algorithm,
+	}, ip, "success")
+	return env, nil
+}
+
+// DecryptBundle opens a tenant-encrypted bundle for import into entityID.
+// Bundles can only be imported into temples of the tenant they were encrypted for.
+func (s *Service) DecryptBundle(entityID uint, env *exportcrypto.Envelope) (*Bundle, error) {
+	e, err := s.Repo.GetEntity(entityID)
+	if err != nil {
+		return nil, err
+	}
+	if env.KeyType == exportcrypto.KeyTypeTenant && env.TenantID != e.CreatedBy {
+		return nil, exportcrypto.ErrKeyMismatch
+	}
+	data, err := exportcrypto.DecryptForTenant(s.ExportKey, env)
+	if err != nil {
+		return nil, err
+	}
+
+	var b Bundle
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Import validates a bundle and applies it to the target temple
+func (s *Service) Import(entityID, userID uint, b *Bundle, opts ImportOptions, ip string) (*ImportResult, error) {
+	if err := Validate(b); err != nil {
+		s.AuditService.LogAction(context.Background(), &userID, &entityID, "ENTITY_CONFIG_IMPORT_FAILED", map[string]interface{}{
+			"error": err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+	if _, err := s.Repo.GetEntity(entityID); err != nil {
+		return nil, err
+	}
+
+	result, err := s.Repo.Apply(entityID, userID, b, opts)
+	if err != nil {
+		s.AuditService.LogAction(context.Background(), &userID, &entityID, "ENTITY_CONFIG_IMPORT_FAILED", map[string]interface{}{
+			"error": err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+	if b.SourceEntityID != 0 && b.SourceEntityID != entityID {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("bundle was exported from temple %d", b.SourceEntityID))
+	}
+
+	if !opts.DryRun {
+		s.AuditService.LogAction(context.Background(), &userID, &entityID, "ENTITY_CONFIG_IMPORTED", map[string]interface{}{
+			"source_entity_id":  b.SourceEntityID,
+			"version":           b.Version,
+			"settings_updated":  result.SettingsUpdated,
+			"sevas_created":     result.SevasCreated,
+			"sevas_updated":     result.SevasUpdated,
+			"templates_created": result.TemplatesCreated,
+			"templates_updated": result.TemplatesUpdated,
+		}, ip, "success")
+	}
+	return result, nil
+}
+
+// Validate checks a bundle before anything is written
+func Validate(b *Bundle) error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if b.Kind != BundleKind {
+		add("kind must be %q", BundleKind)
+	}
+	if b.Version < 1 || b.Version > BundleVersion {
+		add("unsupported bundle version %d (supported: 1..%d)", b.Version, BundleVersion)
+	}
+
+	st := b.Settings
+	if strings.TrimSpace(st.Name) == "" {
+		add("settings.name is required")
+	}
+	if strings.TrimSpace(st.TempleType) == "" {
+		add("settings.temple_type is required")
+	}
+	if strings.TrimSpace(st.State) == "" {
+		add("settings.state is required")
+	}
+
+	seen := make(map[string]bool)
+	for i, sv := range b.Sevas {
+		if strings.TrimSpace(sv.Name) == "" {
+			add("sevas[%d].name is required", i)
+		}
+		if strings.TrimSpace(sv.SevaType) == "" {
+			add("sevas[%d].seva_type is required", i)
+		}
+		if sv.Price < 0 {
+			add("sevas[%d].price cannot be negative", i)
+		}
+		if sv.AvailableSlots < 0 {
+			add("sevas[%d].available_slots cannot be negative", i)
+		}
+		if sv.Date != "" && !datePattern.MatchString(sv.Date) {
+			add("sevas[%d].date must be dd-mm-yyyy", i)
+		}
+		if sv.StartTime != "" && !timePattern.MatchString(sv.StartTime) {
+			add("sevas[%d].start_time must be HH:mm", i)
+		}
+		if sv.EndTime != "" && !timePattern.MatchString(sv.EndTime) {
+			add("sevas[%d].end_time must be HH:mm", i)
+		}
+		if sv.Status != "" && !validSevaStatuses[sv.Status] {
+			add("sevas[%d].status %q is not valid", i, sv.Status)
+		}
+		key := strings.Join([]string{sv.Name, sv.SevaType, sv.Date, sv.StartTime}, "|")
+		if seen[key] {
+			add("sevas[%d] duplicates another seva with the same name, type, date and start time", i)
+		}
+		seen[key] = true
+	}
+
+	names := make(map[string]bool)
+	for i, t := range b.Templates {
+		if strings.TrimSpace(t.Name) == "" {
+			add("notification_templates[%d].name is required", i)
+		}
+		if !validTemplateCategories[t.Category] {
+			add("notification_templates[%d].category %q is not valid", i, t.Category)
+		}
+		if strings.TrimSpace(t.Body) == "" {
+			add("notification_templates[%d].body is required", i)
+		}
+		if names[t.Name] {
+			add("notification_templates[%d].name %q is duplicated", i, t.Name)
+		}
+		names[t.Name] = true
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package event
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -98,6 +99,10 @@ func (h *Handler) CreateEvent(c *gin.Context) {
 
     // Pass the entity ID to the service
     if err := h.Service.CreateEvent(&req, accessContext, entityID, ip); err != nil {
+        if errors.Is(err, ErrInvalidRecurrence) {
+            c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create event: " + err.Error()})
         return
     }
@@ -313,6 +318,10 @@ func (h *Handler) UpdateEvent(c *gin.Context) {
 
 	// Use the updated service method with access context
 	if err := h.Service.UpdateEvent(uint(id), &req, accessContext, ip); err != nil {
+		if errors.Is(err, ErrInvalidRecurrence) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update event: " + err.Error()})
 		return
 	}
@@ -357,4 +366,63 @@ func (h *Handler) DeleteEvent(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "event deleted successfully"})
-}
\ No newline at end of file
+}
+
+// ===========================
+// 📅 Calendar - GET /events/calendar?from=2026-01-01&to=2026-01-31&entity_id=
+// Lists each occurrence in the range, recurring events expanded.
+func (h *Handler) GetCalendar(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+
+	var entityID uint
+	if id, err := strconv.ParseUint(c.Query("entity_id"), 10, 32); err == nil {
+		entityID = uint(id)
+	} else if contextEntityID := accessContext.GetAccessibleEntityID(); contextEntityID != nil {
+		entityID = *contextEntityID
+	} else {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user not linked to a temple and no entity_id provided"})
+		return
+	}
+
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date. Use YYYY-MM-DD"})
+			return
+		}
+		from = parsed
+	}
+	to := from.AddDate(0, 1, -1)
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date. Use YYYY-MM-DD"})
+			return
+		}
+		to = parsed
+	}
+
+	occurrences, err := h.Service.GetCalendar(accessContext, entityID, from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidCalendarRange):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case err.Error() == "read access denied":
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch calendar"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"from":        from.Format("2006-01-02"),
+		"to":          to.Format("2006-01-02"),
+		"occurrences": occurrences,
+	})
+}
@@ -0,0 +1,389 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+const (
+	// calendarTimezone is the wall clock of event and seva times
+	calendarTimezone = "Asia/Kolkata"
+	// The feed covers recent and upcoming dates; calendar apps keep what
+	// they fetched earlier
+	calendarFeedPastDays = 30
+	calendarFeedDays     = 365
+	// Events have no end time; calendar entries get this length
+	defaultEventDuration = time.Hour
+	icalTimeLayout       = "20060102T150405"
+	icalDateLayout       = "20060102"
+)
+
+var (
+	ErrCalendarFeedDisabled = errors.New("calendar feeds are not configured")
+	ErrInvalidCalendarToken = errors.New("invalid calendar token")
+)
+
+// =========================== TOKENS ===========================
+
+// calendarSignature signs a user's subscription to an entity's calendar
+func (s *Service) calendarSignature(entityID, userID uint) string {
+	mac := hmac.New(sha256.New, s.CalendarSecret)
+	fmt.Fprintf(mac, "calendar:%d:%d", entityID, userID)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CalendarToken returns the token of a user's calendar subscription, as
+// "<user id>.<signature>". It does not expire; the feed checks the user still
+// has access on every fetch.
+func (s *Service) CalendarToken(entityID, userID uint) (string, error) {
+	if len(s.CalendarSecret) == 0 {
+		return "", ErrCalendarFeedDisabled
+	}
+	return fmt.Sprintf("%d.%s", userID, s.calendarSignature(entityID, userID)), nil
+}
+
+// verifyCalendarToken returns the subscribed user of a token for entityID
+func (s *Service) verifyCalendarToken(entityID uint, token string) (uint, error) {
+	if len(s.CalendarSecret) == 0 {
+		return 0, ErrCalendarFeedDisabled
+	}
+	rawUser, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return 0, ErrInvalidCalendarToken
+	}
+	userID, err := strconv.ParseUint(rawUser, 10, 64)
+	if err != nil || userID == 0 {
+		return 0, ErrInvalidCalendarToken
+	}
+	if !hmac.Equal([]byte(sig), []byte(s.calendarSignature(entityID, uint(userID)))) {
+		return 0, ErrInvalidCalendarToken
+	}
+	return uint(userID), nil
+}
+
+// =========================== REPOSITORY ===========================
+
+// calendarSeva is a dated seva listed in the calendar feed
+type calendarSeva struct {
+	ID          uint
+	Name        string
+	SevaType    string
+	Description string
+	Date        string // dd-mm-yyyy
+	StartTime   string // HH:mm
+	EndTime     string
+	Duration    int // minutes
+}
+
+// CanSubscribeCalendar reports whether the user may still read the entity's
+// calendar: an active account, and for devotees and volunteers an active
+// membership of the temple
+func (r *Repository) CanSubscribeCalendar(userID, entityID uint) (bool, error) {
+	var count int64
+	err := r.DB.Table("users u").
+		Joins("JOIN user_roles ur ON ur.id = u.role_id").
+		Where("u.id = ? AND u.status = 'active' AND u.deleted_at IS NULL", userID).
+		Where(`(ur.role_name NOT IN ('devotee', 'volunteer') OR EXISTS (
+			SELECT 1 FROM user_entity_memberships m
+			WHERE m.user_id = u.id AND m.entity_id = ? AND m.status = 'active'))`, entityID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetEntityName returns the temple name shown as the calendar name
+func (r *Repository) GetEntityName(entityID uint) (string, error) {
+	var name string
+	err := r.DB.Table("entities").Select("name").Where("id = ?", entityID).Scan(&name).Error
+	return name, err
+}
+
+// ListCalendarSevas returns the active sevas of an entity held on a set date
+func (r *Repository) ListCalendarSevas(entityID uint) ([]calendarSeva, error) {
+	var sevas []calendarSeva
+	err := r.DB.Table("sevas").
+		Select("id, name, seva_type, description, date, start_time, end_time, duration").
+		Where("entity_id = ? AND is_active = TRUE AND COALESCE(date, '') <> ''", entityID).
+		Scan(&sevas).Error
+	return sevas, err
+}
+
+// =========================== SERVICE ===========================
+
+// CalendarFeed renders the entity's events and dated sevas as an iCalendar
+// feed for the subscriber of token
+func (s *Service) CalendarFeed(entityID uint, token string) ([]byte, error) {
+	userID, err := s.verifyCalendarToken(entityID, token)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.Repo.CanSubscribeCalendar(userID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidCalendarToken
+	}
+
+	loc, err := time.LoadLocation(calendarTimezone)
+	if err != nil {
+		loc = time.FixedZone(calendarTimezone, 5*60*60+30*60)
+	}
+	today := dateOnly(time.Now().In(loc))
+	from, to := today.AddDate(0, 0, -calendarFeedPastDays), today.AddDate(0, 0, calendarFeedDays)
+
+	name, err := s.Repo.GetEntityName(entityID)
+	if err != nil {
+		return nil, err
+	}
+	events, err := s.Repo.ListEventsInRange(entityID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	sevas, err := s.Repo.ListCalendarSevas(entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := newICalWriter()
+	cal.line("BEGIN", "VCALENDAR")
+	cal.line("VERSION", "2.0")
+	cal.line("PRODID", "-//Temple Management//Events//EN")
+	cal.line("CALSCALE", "GREGORIAN")
+	cal.line("METHOD", "PUBLISH")
+	cal.text("X-WR-CALNAME", name)
+	cal.line("X-WR-TIMEZONE", calendarTimezone)
+	cal.line("REFRESH-INTERVAL;VALUE=DURATION", "PT6H")
+	cal.line("X-PUBLISHED-TTL", "PT6H")
+	cal.timezone()
+
+	stamp := time.Now().UTC().Format(icalTimeLayout) + "Z"
+	for i := range events {
+		ev := &events[i]
+		for _, d := range ev.Occurrences(from, to) {
+			uid := fmt.Sprintf("event-%d", ev.ID)
+			if ev.IsRecurring() {
+				uid += "-" + d.Format(icalDateLayout)
+			}
+			cal.line("BEGIN", "VEVENT")
+			cal.line("UID", uid+"@"+calendarUIDHost)
+			cal.line("DTSTAMP", stamp)
+			if ev.EventTime != nil {
+				start := time.Date(d.Year(), d.Month(), d.Day(), ev.EventTime.Hour(), ev.EventTime.Minute(), 0, 0, loc)
+				cal.localTime("DTSTART", start)
+				cal.localTime("DTEND", start.Add(defaultEventDuration))
+			} else {
+				cal.date("DTSTART", d)
+				cal.date("DTEND", d.AddDate(0, 0, 1))
+			}
+			cal.text("SUMMARY", ev.Title)
+			cal.text("DESCRIPTION", ev.Description)
+			cal.text("LOCATION", ev.Location)
+			cal.text("CATEGORIES", ev.EventType)
+			cal.line("LAST-MODIFIED", ev.UpdatedAt.UTC().Format(icalTimeLayout)+"Z")
+			cal.line("END", "VEVENT")
+		}
+	}
+	for _, sv := range sevas {
+		d, ok := parseSevaDate(sv.Date, loc)
+		if !ok || d.Before(from) || d.After(to) {
+			continue
+		}
+		cal.line("BEGIN", "VEVENT")
+		cal.line("UID", fmt.Sprintf("seva-%d@%s", sv.ID, calendarUIDHost))
+		cal.line("DTSTAMP", stamp)
+		if start, ok := sevaClock(d, sv.StartTime, loc); ok {
+			end, ok := sevaClock(d, sv.EndTime, loc)
+			if !ok || !end.After(start) {
+				end = start.Add(defaultEventDuration)
+				if sv.Duration > 0 {
+					end = start.Add(time.Duration(sv.Duration) * time.Minute)
+				}
+			}
+			cal.localTime("DTSTART", start)
+			cal.localTime("DTEND", end)
+		} else {
+			cal.date("DTSTART", d)
+			cal.date("DTEND", d.AddDate(0, 0, 1))
+		}
+		cal.text("SUMMARY", sv.Name)
+		cal.text("DESCRIPTION", sv.Description)
+		cal.text("CATEGORIES", "Seva,"+sv.SevaType)
+		cal.line("END", "VEVENT")
+	}
+	cal.line("END", "VCALENDAR")
+	return cal.Bytes(), nil
+}
+
+// calendarUIDHost makes feed UIDs globally unique
+const calendarUIDHost = "temple-management"
+
+// parseSevaDate reads a seva date, stored as dd-mm-yyyy (yyyy-mm-dd accepted)
+func parseSevaDate(value string, loc *time.Location) (time.Time, bool) {
+	for _, layout := range []string{"02-01-2006", dateLayout} {
+		if d, err := time.ParseInLocation(layout, strings.TrimSpace(value), loc); err == nil {
+			return d, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// sevaClock sets an HH:mm seva time on date d
+func sevaClock(d time.Time, clock string, loc *time.Location) (time.Time, bool) {
+	t, err := time.Parse("15:04", strings.TrimSpace(clock))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), t.Hour(), t.Minute(), 0, 0, loc), true
+}
+
+// =========================== ICALENDAR ===========================
+
+// iCalWriter writes RFC 5545 content lines: CRLF terminated and folded at
+// 75 octets
+type iCalWriter struct {
+	bytes.Buffer
+}
+
+func newICalWriter() *iCalWriter {
+	return &iCalWriter{}
+}
+
+func (w *iCalWriter) line(name, value string) {
+	content := name + ":" + value
+	for len(content) > 75 {
+		cut := 75
+		for cut > 0 && !isRuneStart(content[cut]) {
+			cut-- // never split a UTF-8 sequence
+		}
+		w.WriteString(content[:cut] + "\r\n")
+		content = " " + content[cut:]
+	}
+	w.WriteString(content + "\r\n")
+}
+
+// text writes a TEXT property, escaped; empty values are left out
+func (w *iCalWriter) text(name, value string) {
+	if value == "" {
+		return
+	}
+	value = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(value)
+	if name == "CATEGORIES" {
+		value = strings.ReplaceAll(value, `\,`, ",") // the list separator
+	}
+	w.line(name, value)
+}
+
+func (w *iCalWriter) date(name string, d time.Time) {
+	w.line(name+";VALUE=DATE", d.Format(icalDateLayout))
+}
+
+func (w *iCalWriter) localTime(name string, t time.Time) {
+	w.line(name+";TZID="+calendarTimezone, t.Format(icalTimeLayout))
+}
+
+// timezone writes the VTIMEZONE of calendarTimezone, which has no DST
+func (w *iCalWriter) timezone() {
+	w.line("BEGIN", "VTIMEZONE")
+	w.line("TZID", calendarTimezone)
+	w.line("BEGIN", "STANDARD")
+	w.line("DTSTART", "19700101T000000")
+	w.line("TZOFFSETFROM", "+0530")
+	w.line("TZOFFSETTO", "+0530")
+	w.line("TZNAME", "IST")
+	w.line("END", "STANDARD")
+	w.line("END", "VTIMEZONE")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+// =========================== HANDLERS ===========================
+
+// GetCalendarFeedURL - GET /entities/:id/events/calendar-feed
+// Returns the caller's personal iCalendar subscription URL of the temple.
+func (h *Handler) GetCalendarFeedURL(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	hasAccess := accessContext.IsEntityStaff(uint(entityID))
+	if accessContext.RoleName == middleware.RoleDevotee || accessContext.RoleName == middleware.RoleVolunteer {
+		// Devotees may belong to several temples; the requested one must be one of them
+		if hasAccess, err = h.Service.Repo.CanSubscribeCalendar(accessContext.UserID, uint(entityID)); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check temple membership"})
+			return
+		}
+	}
+	if !hasAccess {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to events of this entity"})
+		return
+	}
+
+	token, err := h.Service.CalendarToken(uint(entityID), accessContext.UserID)
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	eid := uint(entityID)
+	h.Service.AuditSvc.LogAction(context.Background(), &accessContext.UserID, &eid, "EVENT_CALENDAR_FEED_ISSUED", nil,
+		middleware.GetIPFromContext(c), "success")
+
+	scheme := "https"
+	if c.Request.TLS == nil && c.GetHeader("X-Forwarded-Proto") != "https" {
+		scheme = "http"
+	}
+	path := fmt.Sprintf("/api/v1/entities/%d/events/calendar.ics?token=%s", entityID, token)
+	c.JSON(http.StatusOK, gin.H{
+		"url":        scheme + "://" + c.Request.Host + path,
+		"webcal_url": "webcal://" + c.Request.Host + path,
+		"token":      token,
+	})
+}
+
+// GetCalendarICS - GET /entities/:id/events/calendar.ics?token=
+// Public iCalendar feed of the temple's events and sevas; calendar apps
+// cannot log in, so access comes from the signed token.
+func (h *Handler) GetCalendarICS(c *gin.Context) {
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return
+	}
+
+	feed, err := h.Service.CalendarFeed(uint(entityID), c.Query("token"))
+	switch {
+	case errors.Is(err, ErrInvalidCalendarToken):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrCalendarFeedDisabled):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		log.Printf("❌ Calendar feed for entity %d failed: %v", entityID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build calendar"})
+		return
+	}
+
+	c.Header("Content-Disposition", "inline; filename=\"calendar.ics\"")
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
@@ -2,6 +2,8 @@ package event
 
 import (
 	"time"
+
+	"gorm.io/datatypes"
 )
 
 // ============================
@@ -19,8 +21,39 @@ type Event struct {
 	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 	IsActive    bool       `gorm:"default:true" json:"is_active"`
+	Capacity    *int       `json:"capacity,omitempty"` // Max attendees; nil means unlimited, extra RSVPs are waitlisted
+
+	// Recurring events repeat from EventDate by an RRULE subset, e.g.
+	// "FREQ=WEEKLY;BYDAY=MO" (see recurrence.go). Capacity applies per occurrence.
+	RecurrenceRule       string         `gorm:"type:varchar(255);default:''" json:"recurrence_rule,omitempty"`
+	RecurrenceExceptions datatypes.JSON `gorm:"type:jsonb" json:"recurrence_exceptions,omitempty"` // skipped dates, ["2006-01-02"]
+	RecurrenceEnd        *time.Time     `gorm:"index" json:"recurrence_end,omitempty"`             // last occurrence; nil = never ends
 
-	RSVPCount int `gorm:"-" json:"rsvp_count"`
+	// Panchang of EventDate, set on create and update (see internal/panchang);
+	// calendar occurrences carry their own
+	Tithi        string         `gorm:"type:varchar(30)" json:"tithi,omitempty"`
+	Paksha       string         `gorm:"type:varchar(10)" json:"paksha,omitempty"`
+	Nakshatra    string         `gorm:"type:varchar(30)" json:"nakshatra,omitempty"`
+	PanchangTags datatypes.JSON `gorm:"type:jsonb" json:"panchang_tags,omitempty"` // e.g. ["ekadashi"]
+
+	RSVPCount      int        `gorm:"-" json:"rsvp_count"`
+	NextOccurrence *time.Time `gorm:"-" json:"next_occurrence,omitempty"`
+}
+
+// EventOccurrence is one date of an event, as listed by the calendar
+type EventOccurrence struct {
+	EventID     uint      `json:"event_id"`
+	Title       string    `json:"title"`
+	EventType   string    `json:"event_type"`
+	Date        time.Time `json:"date"`
+	EventTime   string    `json:"event_time,omitempty"`
+	Location    string    `json:"location"`
+	IsRecurring bool      `json:"is_recurring"`
+	Capacity    *int      `json:"capacity,omitempty"`
+	RSVPCount   int       `json:"rsvp_count"` // attending RSVPs for this date
+	Tithi       string    `json:"tithi"`
+	Paksha      string    `json:"paksha"`
+	Tags        []string  `json:"panchang_tags"`
 }
 
 // ============================
@@ -33,6 +66,10 @@ type CreateEventRequest struct {
 	EventTime   string `json:"event_time,omitempty"`          // 🛠 string format: "15:04"
 	Location    string `json:"location" binding:"required"`
 	IsActive *bool `json:"is_active,omitempty"`
+	Capacity *int  `json:"capacity,omitempty"` // 0 or omitted = unlimited
+
+	RecurrenceRule       string   `json:"recurrence_rule,omitempty"`       // e.g. "FREQ=MONTHLY;BYDAY=1SU;UNTIL=20271231"
+	RecurrenceExceptions []string `json:"recurrence_exceptions,omitempty"` // "2006-01-02" dates to skip
 }
 
 // ============================
@@ -46,4 +83,8 @@ type UpdateEventRequest struct {
 	EventTime   string `json:"event_time,omitempty"`          // 🛠 string
 	Location    string `json:"location" binding:"required"`
 	IsActive *bool `json:"is_active,omitempty"`
+	Capacity *int  `json:"capacity,omitempty"` // 0 or omitted = unlimited
+
+	RecurrenceRule       *string  `json:"recurrence_rule,omitempty"`       // omitted keeps the rule, "" makes it a single date
+	RecurrenceExceptions []string `json:"recurrence_exceptions,omitempty"` // omitted keeps the exceptions
 }
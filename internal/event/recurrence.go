@@ -0,0 +1,447 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Recurrence frequencies, a subset of RFC 5545 RRULE
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+)
+
+const (
+	// maxOccurrences caps one expansion, e.g. a calendar or report range
+	maxOccurrences = 1000
+	// maxRecurrencePeriods bounds the walk over days, weeks or months, so
+	// rules that rarely match (BYMONTHDAY=31 every 12 months) still end
+	maxRecurrencePeriods = 20000
+	dateLayout           = "2006-01-02"
+)
+
+var ErrInvalidRecurrence = errors.New("invalid recurrence_rule")
+
+var rruleDays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// WeekdayNum is a BYDAY entry: a weekday, and for monthly rules optionally
+// its position in the month (1 = first, -1 = last, 0 = every)
+type WeekdayNum struct {
+	N   int
+	Day time.Weekday
+}
+
+// Recurrence is a parsed rule such as "FREQ=WEEKLY;BYDAY=MO,TH;UNTIL=20261231"
+// or "FREQ=MONTHLY;BYDAY=1SU;COUNT=12". Occurrences are the matching dates on
+// or after the event date.
+type Recurrence struct {
+	Freq       string
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int // 1..31, or -1 for the last day
+	Until      *time.Time
+	Count      int
+}
+
+// ParseRecurrence parses an RRULE with FREQ (DAILY, WEEKLY, MONTHLY) and
+// optional INTERVAL, BYDAY, BYMONTHDAY, UNTIL (yyyymmdd) and COUNT. A
+// leading "RRULE:" is accepted.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(rule)), "RRULE:")
+	r := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("%w: %q is not KEY=VALUE", ErrInvalidRecurrence, part)
+		}
+		switch key {
+		case "FREQ":
+			if value != FreqDaily && value != FreqWeekly && value != FreqMonthly {
+				return nil, fmt.Errorf("%w: FREQ must be DAILY, WEEKLY or MONTHLY", ErrInvalidRecurrence)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > 366 {
+				return nil, fmt.Errorf("%w: INTERVAL must be between 1 and 366", ErrInvalidRecurrence)
+			}
+			r.Interval = n
+		case "BYDAY":
+			for _, d := range strings.Split(value, ",") {
+				wd, err := parseWeekdayNum(d)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, wd)
+			}
+		case "BYMONTHDAY":
+			for _, d := range strings.Split(value, ",") {
+				n, err := strconv.Atoi(d)
+				if err != nil || n == 0 || n > 31 || n < -1 {
+					return nil, fmt.Errorf("%w: BYMONTHDAY must be 1 to 31, or -1 for the last day", ErrInvalidRecurrence)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, n)
+			}
+		case "UNTIL":
+			t, err := time.Parse("20060102", value[:min(len(value), 8)])
+			if err != nil {
+				return nil, fmt.Errorf("%w: UNTIL must be a date (yyyymmdd)", ErrInvalidRecurrence)
+			}
+			r.Until = &t
+		case "COUNT":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 1 || n > maxOccurrences {
+				return nil, fmt.Errorf("%w: COUNT must be between 1 and %d", ErrInvalidRecurrence, maxOccurrences)
+			}
+			r.Count = n
+		default:
+			return nil, fmt.Errorf("%w: %s is not supported", ErrInvalidRecurrence, key)
+		}
+	}
+
+	switch {
+	case r.Freq == "":
+		return nil, fmt.Errorf("%w: FREQ is required", ErrInvalidRecurrence)
+	case r.Until != nil && r.Count > 0:
+		return nil, fmt.Errorf("%w: use UNTIL or COUNT, not both", ErrInvalidRecurrence)
+	case len(r.ByMonthDay) > 0 && r.Freq != FreqMonthly:
+		return nil, fmt.Errorf("%w: BYMONTHDAY needs FREQ=MONTHLY", ErrInvalidRecurrence)
+	}
+	for _, wd := range r.ByDay {
+		if wd.N != 0 && r.Freq != FreqMonthly {
+			return nil, fmt.Errorf("%w: numbered BYDAY (e.g. 1SU) needs FREQ=MONTHLY", ErrInvalidRecurrence)
+		}
+	}
+	return r, nil
+}
+
+func parseWeekdayNum(s string) (WeekdayNum, error) {
+	s = strings.TrimSpace(s)
+	if len(s) < 2 {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY %q", ErrInvalidRecurrence, s)
+	}
+	day, ok := rruleDays[s[len(s)-2:]]
+	if !ok {
+		return WeekdayNum{}, fmt.Errorf("%w: BYDAY %q", ErrInvalidRecurrence, s)
+	}
+	wd := WeekdayNum{Day: day}
+	if prefix := s[:len(s)-2]; prefix != "" {
+		n, err := strconv.Atoi(prefix)
+		if err != nil || n == 0 || n > 5 || n < -5 {
+			return WeekdayNum{}, fmt.Errorf("%w: BYDAY position must be 1 to 5 or -1 to -5", ErrInvalidRecurrence)
+		}
+		wd.N = n
+	}
+	return wd, nil
+}
+
+// String returns the rule in canonical RRULE form
+func (r *Recurrence) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		days := make([]string, len(r.ByDay))
+		for i, wd := range r.ByDay {
+			days[i] = strings.ToUpper(wd.Day.String()[:2])
+			if wd.N != 0 {
+				days[i] = strconv.Itoa(wd.N) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		days := make([]string, len(r.ByMonthDay))
+		for i, d := range r.ByMonthDay {
+			days[i] = strconv.Itoa(d)
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Until != nil {
+		parts = append(parts, "UNTIL="+r.Until.Format("20060102"))
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	return strings.Join(parts, ";")
+}
+
+func dateOnly(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// expand calls fn with each occurrence from start, in order, until fn
+// returns false or the rule ends. COUNT includes excepted dates, as in RFC 5545.
+func (r *Recurrence) expand(start time.Time, fn func(time.Time) bool) {
+	start = dateOnly(start)
+	emitted := 0
+	emit := func(d time.Time) bool {
+		if d.Before(start) {
+			return true
+		}
+		if r.Until != nil && d.After(*r.Until) {
+			return false
+		}
+		emitted++
+		if !fn(d) {
+			return false
+		}
+		return r.Count == 0 || emitted < r.Count
+	}
+
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		var candidates []time.Time
+		switch r.Freq {
+		case FreqDaily:
+			d := start.AddDate(0, 0, period*r.Interval)
+			if len(r.ByDay) == 0 || r.matchesWeekday(d) {
+				candidates = []time.Time{d}
+			}
+			if r.Until != nil && d.After(*r.Until) {
+				return
+			}
+		case FreqWeekly:
+			// Weeks start on Monday
+			monday := start.AddDate(0, 0, -((int(start.Weekday())+6)%7)+period*7*r.Interval)
+			if r.Until != nil && monday.After(*r.Until) {
+				return
+			}
+			days := r.ByDay
+			if len(days) == 0 {
+				days = []WeekdayNum{{Day: start.Weekday()}}
+			}
+			for _, wd := range days {
+				candidates = append(candidates, monday.AddDate(0, 0, (int(wd.Day)+6)%7))
+			}
+		case FreqMonthly:
+			first := time.Date(start.Year(), start.Month()+time.Month(period*r.Interval), 1, 0, 0, 0, 0, time.UTC)
+			if r.Until != nil && first.After(*r.Until) {
+				return
+			}
+			candidates = r.monthDays(first, start.Day())
+		}
+
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+		for i, d := range candidates {
+			if i > 0 && d.Equal(candidates[i-1]) {
+				continue
+			}
+			if !emit(d) {
+				return
+			}
+		}
+	}
+}
+
+// Dates returns the rule's dates for a series starting on start, up to and
+// including end, at most maxOccurrences. Other modules use it to lay out
+// dated records (e.g. seva instances) from a rule.
+func (r *Recurrence) Dates(start, end time.Time) []time.Time {
+	end = dateOnly(end)
+	var out []time.Time
+	r.expand(start, func(d time.Time) bool {
+		if d.After(end) {
+			return false
+		}
+		out = append(out, d)
+		return len(out) < maxOccurrences
+	})
+	return out
+}
+
+func (r *Recurrence) matchesWeekday(d time.Time) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day == d.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// monthDays lists the matching days of the month starting at first. Without
+// BYDAY or BYMONTHDAY it is the start date's day, skipped in shorter months.
+func (r *Recurrence) monthDays(first time.Time, startDay int) []time.Time {
+	last := first.AddDate(0, 1, -1).Day()
+	var out []time.Time
+	addDay := func(day int) {
+		if day < 0 {
+			day = last + 1 + day
+		}
+		if day >= 1 && day <= last {
+			out = append(out, first.AddDate(0, 0, day-1))
+		}
+	}
+
+	if len(r.ByDay) == 0 && len(r.ByMonthDay) == 0 {
+		addDay(startDay)
+		return out
+	}
+	for _, d := range r.ByMonthDay {
+		addDay(d)
+	}
+	for _, wd := range r.ByDay {
+		firstMatch := 1 + (int(wd.Day)-int(first.Weekday())+7)%7
+		switch {
+		case wd.N > 0:
+			addDay(firstMatch + (wd.N-1)*7)
+		case wd.N < 0:
+			lastMatch := firstMatch + ((last-firstMatch)/7)*7
+			if day := lastMatch + (wd.N+1)*7; day >= 1 {
+				addDay(day)
+			}
+		default:
+			for day := firstMatch; day <= last; day += 7 {
+				addDay(day)
+			}
+		}
+	}
+	return out
+}
+
+// =========================== EVENT OCCURRENCES ===========================
+
+// IsRecurring reports whether the event repeats
+func (e *Event) IsRecurring() bool {
+	return e.RecurrenceRule != ""
+}
+
+// exceptionDates returns the excepted occurrence dates as yyyy-mm-dd
+func (e *Event) exceptionDates() map[string]bool {
+	var dates []string
+	_ = json.Unmarshal(e.RecurrenceExceptions, &dates)
+	out := make(map[string]bool, len(dates))
+	for _, d := range dates {
+		out[d] = true
+	}
+	return out
+}
+
+// Occurrences returns the event's dates within [from, to], at most
+// maxOccurrences. A single-date event has one occurrence, its event date.
+func (e *Event) Occurrences(from, to time.Time) []time.Time {
+	from, to = dateOnly(from), dateOnly(to)
+	if !e.IsRecurring() {
+		d := dateOnly(e.EventDate)
+		if d.Before(from) || d.After(to) {
+			return nil
+		}
+		return []time.Time{d}
+	}
+	r, err := ParseRecurrence(e.RecurrenceRule)
+	if err != nil {
+		return nil
+	}
+
+	exceptions := e.exceptionDates()
+	var out []time.Time
+	r.expand(e.EventDate, func(d time.Time) bool {
+		if d.After(to) {
+			return false
+		}
+		if !d.Before(from) && !exceptions[d.Format(dateLayout)] {
+			out = append(out, d)
+		}
+		return len(out) < maxOccurrences
+	})
+	return out
+}
+
+// HasOccurrence reports whether the event takes place on date
+func (e *Event) HasOccurrence(date time.Time) bool {
+	return len(e.Occurrences(date, date)) == 1
+}
+
+// NextOccurrenceFrom returns the first occurrence on or after from
+func (e *Event) NextOccurrenceFrom(from time.Time) *time.Time {
+	if !e.IsRecurring() {
+		if d := dateOnly(e.EventDate); !d.Before(dateOnly(from)) {
+			return &d
+		}
+		return nil
+	}
+	r, err := ParseRecurrence(e.RecurrenceRule)
+	if err != nil {
+		return nil
+	}
+	from = dateOnly(from)
+	exceptions := e.exceptionDates()
+	var next *time.Time
+	r.expand(e.EventDate, func(d time.Time) bool {
+		if d.Before(from) || exceptions[d.Format(dateLayout)] {
+			return true
+		}
+		next = &d
+		return false
+	})
+	return next
+}
+
+// seriesEnd returns the date of the last occurrence of a bounded rule, nil
+// when the rule never ends
+func seriesEnd(r *Recurrence, start time.Time) *time.Time {
+	if r.Until == nil && r.Count == 0 {
+		return nil
+	}
+	var last *time.Time
+	r.expand(start, func(d time.Time) bool {
+		last = &d
+		return true
+	})
+	if last == nil {
+		d := dateOnly(start)
+		return &d
+	}
+	return last
+}
+
+// applyRecurrence validates a rule and its exceptions and stores them on the
+// event in canonical form. An empty rule makes the event a single date.
+func applyRecurrence(e *Event, rule string, exceptions []string) error {
+	if strings.TrimSpace(rule) == "" {
+		e.RecurrenceRule = ""
+		e.RecurrenceExceptions = nil
+		e.RecurrenceEnd = nil
+		return nil
+	}
+	r, err := ParseRecurrence(rule)
+	if err != nil {
+		return err
+	}
+	if r.Until != nil && r.Until.Before(dateOnly(e.EventDate)) {
+		return fmt.Errorf("%w: UNTIL is before event_date", ErrInvalidRecurrence)
+	}
+
+	seen := map[string]bool{}
+	dates := make([]string, 0, len(exceptions))
+	for _, ex := range exceptions {
+		d, err := time.Parse(dateLayout, strings.TrimSpace(ex))
+		if err != nil {
+			return fmt.Errorf("%w: exception %q must be YYYY-MM-DD", ErrInvalidRecurrence, ex)
+		}
+		if key := d.Format(dateLayout); !seen[key] {
+			seen[key] = true
+			dates = append(dates, key)
+		}
+	}
+	sort.Strings(dates)
+	exJSON, _ := json.Marshal(dates)
+
+	e.RecurrenceRule = r.String()
+	e.RecurrenceExceptions = exJSON
+	e.RecurrenceEnd = seriesEnd(r, e.EventDate)
+	return nil
+}
@@ -50,7 +50,9 @@ func (r *Repository) GetUpcomingEvents(entityID uint) ([]Event, error) {
 	
 	// Ensure we only get events from the specified entity
 	err := r.DB.
-		Where("entity_id = ? AND event_date >= CURRENT_DATE - INTERVAL '7 day' AND is_active = TRUE", entityID).
+		Where("entity_id = ? AND is_active = TRUE", entityID).
+		Where(`event_date >= CURRENT_DATE - INTERVAL '7 day' OR
+			(recurrence_rule <> '' AND (recurrence_end IS NULL OR recurrence_end >= CURRENT_DATE - INTERVAL '7 day'))`).
 		Order("event_date ASC").
 		Find(&events).Error
 	
@@ -176,9 +178,10 @@ func (r *Repository) GetEventStats(entityID uint) (*EventStatsResponse, error) {
 		Where("entity_id = ? AND event_date >= ?", entityID, startOfMonth).
 		Count(&thisMonth)
 
-	// Upcoming Events for this entity only
+	// Upcoming Events for this entity only, recurring series still running included
 	r.DB.Model(&Event{}).
-		Where("entity_id = ? AND event_date >= CURRENT_DATE", entityID).
+		Where("entity_id = ?", entityID).
+		Where("event_date >= CURRENT_DATE OR (recurrence_rule <> '' AND (recurrence_end IS NULL OR recurrence_end >= CURRENT_DATE))").
 		Count(&upcoming)
 
 	// FIXED: Total RSVPs for events belonging to this entity only
@@ -215,4 +218,45 @@ func (r *Repository) GetEventCountByEntity(entityID uint) (int, error) {
 		Count(&count).Error
     fmt.Println("count ()=",count)
 	return int(count), err
-}
\ No newline at end of file
+}
+
+// ===========================
+// 📅 Active events with an occurrence possibly between from and to: single
+// events dated in the range and recurring series overlapping it
+func (r *Repository) ListEventsInRange(entityID uint, from, to time.Time) ([]Event, error) {
+	var events []Event
+	err := r.DB.
+		Where("entity_id = ? AND is_active = TRUE", entityID).
+		Where(`(recurrence_rule = '' AND event_date BETWEEN ? AND ?) OR
+			(recurrence_rule <> '' AND event_date <= ? AND (recurrence_end IS NULL OR recurrence_end >= ?))`,
+			from, to, to, from).
+		Order("event_date ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// ===========================
+// 🔢 Attending RSVPs per event and occurrence date ("" for single events)
+func (r *Repository) CountAttendingByOccurrence(eventIDs []uint) (map[uint]map[string]int, error) {
+	counts := make(map[uint]map[string]int, len(eventIDs))
+	if len(eventIDs) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		EventID    uint
+		Occurrence string
+		Count      int
+	}
+	err := r.DB.Table("rsvps").
+		Select("event_id, occurrence, COUNT(*) AS count").
+		Where("event_id IN ? AND status = ?", eventIDs, "attending").
+		Group("event_id, occurrence").
+		Scan(&rows).Error
+	for _, row := range rows {
+		if counts[row.EventID] == nil {
+			counts[row.EventID] = map[string]int{}
+		}
+		counts[row.EventID][row.Occurrence] = row.Count
+	}
+	return counts, err
+}
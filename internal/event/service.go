@@ -2,11 +2,14 @@ package event
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/panchang"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -15,6 +18,8 @@ type Service struct {
 	Repo     *Repository
 	AuditSvc auditlog.Service // Audit service for logging
 	NotifSvc notification.Service
+	// Signs calendar feed tokens; empty disables the feeds
+	CalendarSecret []byte
 }
 
 // NewService initializes a new Service with audit logging
@@ -102,6 +107,11 @@ func (s *Service) CreateEvent(req *CreateEventRequest, accessContext middleware.
 		isActive = *req.IsActive
 	}
 
+	capacity, err := normalizeCapacity(req.Capacity)
+	if err != nil {
+		return err
+	}
+
 	event := &Event{
 		Title:       req.Title,
 		Description: req.Description,
@@ -110,9 +120,14 @@ func (s *Service) CreateEvent(req *CreateEventRequest, accessContext middleware.
 		Location:    req.Location,
 		EventType:   req.EventType,
 		IsActive:    isActive,
+		Capacity:    capacity,
 		CreatedBy:   accessContext.UserID,
 		EntityID:    entityID, // Use the passed entityID directly
 	}
+	if err := applyRecurrence(event, req.RecurrenceRule, req.RecurrenceExceptions); err != nil {
+		return err
+	}
+	applyPanchang(event)
 
 	// Attempt to create event in database
 	err = s.Repo.CreateEvent(event)
@@ -150,6 +165,7 @@ func (s *Service) CreateEvent(req *CreateEventRequest, accessContext middleware.
 			"location":   event.Location,
 			"is_active":  event.IsActive,
 			"entity_id":  entityID, // Add entity_id to audit log for verification
+			"recurrence": event.RecurrenceRule,
 		},
 		ip,
 		"success",
@@ -157,12 +173,16 @@ func (s *Service) CreateEvent(req *CreateEventRequest, accessContext middleware.
 
 	// In-app notifications to devotees & volunteers of the entity
 	if s.NotifSvc != nil {
+		message := req.Title + " on " + eventDate.Format("2006-01-02")
 		_ = s.NotifSvc.CreateInAppForEntityRoles(context.Background(), entityID,
 			[]string{"devotee", "volunteer"},
 			"New Event",
-			req.Title+" on "+eventDate.Format("2006-01-02"),
+			message,
 			"event",
 		)
+		// Push goes out as one publish per role topic, not per device
+		_ = s.NotifSvc.SendPushToTopic(context.Background(), accessContext.UserID, entityID,
+			"New Event", message, []string{"devotee", "volunteer"}, ip)
 	}
 
 	return nil
@@ -218,6 +238,7 @@ func (s *Service) GetUpcomingEvents(accessContext middleware.AccessContext) ([]E
 	if err != nil {
 		return nil, err
 	}
+	setNextOccurrences(events)
 
 	// Ensure RSVP counts are calculated correctly for each event
 	for i := range events {
@@ -310,6 +331,7 @@ func (s *Service) GetUpcomingEventsByEntityID(accessContext middleware.AccessCon
 	if err != nil {
 		return nil, err
 	}
+	setNextOccurrences(events)
 
 	// Ensure RSVP counts are calculated correctly for the specific entity
 	for i := range events {
@@ -390,6 +412,8 @@ func (s *Service) UpdateEvent(id uint, req *UpdateEventRequest, accessContext mi
 	originalEventDate := event.EventDate.Format("2006-01-02")
 	originalLocation := event.Location
 	originalIsActive := event.IsActive
+	originalRecurrence := event.RecurrenceRule
+	originalExceptions := string(event.RecurrenceExceptions)
 
 	// 🔄 Parse and update EventDate
 	eventDate, err := time.Parse("2006-01-02", req.EventDate)
@@ -448,6 +472,28 @@ func (s *Service) UpdateEvent(id uint, req *UpdateEventRequest, accessContext mi
 	if req.IsActive != nil {
 		event.IsActive = *req.IsActive
 	}
+	// Capacity is kept when omitted; 0 removes the limit. Raising it frees
+	// seats that the RSVP waitlist sweeper offers to waitlisted devotees.
+	if req.Capacity != nil {
+		capacity, err := normalizeCapacity(req.Capacity)
+		if err != nil {
+			return err
+		}
+		event.Capacity = capacity
+	}
+	// Recurrence is kept when omitted and re-validated against the new date
+	rule := event.RecurrenceRule
+	if req.RecurrenceRule != nil {
+		rule = *req.RecurrenceRule
+	}
+	exceptions := req.RecurrenceExceptions
+	if exceptions == nil {
+		_ = json.Unmarshal(event.RecurrenceExceptions, &exceptions)
+	}
+	if err := applyRecurrence(event, rule, exceptions); err != nil {
+		return err
+	}
+	applyPanchang(event)
 
 	// ✅ Now update using parsed `*Event`
 	err = s.Repo.UpdateEvent(event)
@@ -486,6 +532,12 @@ func (s *Service) UpdateEvent(id uint, req *UpdateEventRequest, accessContext mi
 	if originalIsActive != event.IsActive {
 		changes["status_changed"] = map[string]bool{"from": originalIsActive, "to": event.IsActive}
 	}
+	if originalRecurrence != event.RecurrenceRule {
+		changes["recurrence_changed"] = map[string]string{"from": originalRecurrence, "to": event.RecurrenceRule}
+	}
+	if originalExceptions != string(event.RecurrenceExceptions) {
+		changes["recurrence_exceptions_changed"] = map[string]string{"from": originalExceptions, "to": string(event.RecurrenceExceptions)}
+	}
 
 	s.AuditSvc.LogAction(
 		context.Background(),
@@ -502,12 +554,15 @@ func (s *Service) UpdateEvent(id uint, req *UpdateEventRequest, accessContext mi
 	)
 
 	if s.NotifSvc != nil {
+		message := event.Title + " updated for " + event.EventDate.Format("2006-01-02")
 		_ = s.NotifSvc.CreateInAppForEntityRoles(context.Background(), *entityID,
 			[]string{"devotee", "volunteer"},
 			"Event Updated",
-			event.Title+" updated for "+event.EventDate.Format("2006-01-02"),
+			message,
 			"event",
 		)
+		_ = s.NotifSvc.SendPushToTopic(context.Background(), accessContext.UserID, *entityID,
+			"Event Updated", message, []string{"devotee", "volunteer"}, ip)
 	}
 
 	return nil
@@ -627,7 +682,113 @@ func (s *Service) DeleteEvent(id uint, accessContext middleware.AccessContext, i
 			eventTitle+" has been removed",
 			"event",
 		)
+		_ = s.NotifSvc.SendPushToTopic(context.Background(), accessContext.UserID, *entityID,
+			"Event Deleted", eventTitle+" has been removed", []string{"devotee", "volunteer"}, ip)
 	}
 
 	return nil
 }
+
+// normalizeCapacity maps a requested capacity to the stored value: nil or 0
+// means unlimited
+func normalizeCapacity(capacity *int) (*int, error) {
+	if capacity == nil || *capacity == 0 {
+		return nil, nil
+	}
+	if *capacity < 0 {
+		return nil, errors.New("capacity must not be negative")
+	}
+	c := *capacity
+	return &c, nil
+}
+
+// applyPanchang tags the event with the tithi, nakshatra and observances of
+// its date
+func applyPanchang(e *Event) {
+	day := panchang.ForDate(e.EventDate)
+	e.Tithi, e.Paksha, e.Nakshatra = day.Tithi, day.Paksha, day.Nakshatra
+	e.PanchangTags, _ = json.Marshal(day.Tags)
+}
+
+// setNextOccurrences fills NextOccurrence from a week back, matching the
+// upcoming window, and orders the events by it
+func setNextOccurrences(events []Event) {
+	from := time.Now().AddDate(0, 0, -7)
+	for i := range events {
+		events[i].NextOccurrence = events[i].NextOccurrenceFrom(from)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].NextOccurrence, events[j].NextOccurrence
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Before(*b)
+	})
+}
+
+// maxCalendarDays bounds a calendar query
+const maxCalendarDays = 366
+
+var ErrInvalidCalendarRange = errors.New("from must be before to and the range at most 366 days")
+
+// GetCalendar lists every occurrence of the entity's active events between
+// from and to, recurring events expanded, with attending RSVPs per date
+func (s *Service) GetCalendar(accessContext middleware.AccessContext, entityID uint, from, to time.Time) ([]EventOccurrence, error) {
+	if !(accessContext.RoleName == "devotee" || accessContext.RoleName == "volunteer") && !accessContext.CanRead() {
+		return nil, errors.New("read access denied")
+	}
+	from, to = dateOnly(from), dateOnly(to)
+	if to.Before(from) || to.Sub(from) > maxCalendarDays*24*time.Hour {
+		return nil, ErrInvalidCalendarRange
+	}
+
+	events, err := s.Repo.ListEventsInRange(entityID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(events))
+	for i := range events {
+		ids[i] = events[i].ID
+	}
+	counts, err := s.Repo.CountAttendingByOccurrence(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []EventOccurrence{}
+	for i := range events {
+		ev := &events[i]
+		eventTime := ""
+		if ev.EventTime != nil {
+			eventTime = ev.EventTime.Format("15:04")
+		}
+		for _, d := range ev.Occurrences(from, to) {
+			key := ""
+			if ev.IsRecurring() {
+				key = d.Format(dateLayout)
+			}
+			day := panchang.ForDate(d)
+			occurrences = append(occurrences, EventOccurrence{
+				EventID:     ev.ID,
+				Title:       ev.Title,
+				EventType:   ev.EventType,
+				Date:        d,
+				EventTime:   eventTime,
+				Location:    ev.Location,
+				IsRecurring: ev.IsRecurring(),
+				Capacity:    ev.Capacity,
+				RSVPCount:   counts[ev.ID][key],
+				Tithi:       day.Tithi,
+				Paksha:      day.Paksha,
+				Tags:        day.Tags,
+			})
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		if !occurrences[i].Date.Equal(occurrences[j].Date) {
+			return occurrences[i].Date.Before(occurrences[j].Date)
+		}
+		return occurrences[i].EventTime < occurrences[j].EventTime
+	})
+	return occurrences, nil
+}
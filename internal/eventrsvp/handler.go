@@ -1,6 +1,7 @@
 package eventrsvp
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 	"github.com/sharath018/temple-management-backend/internal/auth"
 	"github.com/sharath018/temple-management-backend/internal/event"
 	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
 )
 
 // Handler holds services needed for RSVP operations
@@ -56,8 +58,9 @@ func getAccessContext(c *gin.Context) (middleware.AccessContext, bool) {
 
 // ✅ Request struct
 type RSVPRequest struct {
-	Status string `json:"status" binding:"required"` // attending | maybe | not_attending
-	Notes  string `json:"notes"`
+	Status     string `json:"status" binding:"required"` // attending | maybe | not_attending
+	Notes      string `json:"notes"`
+	Occurrence string `json:"occurrence"` // YYYY-MM-DD, required for recurring events
 }
 
 // ==============================
@@ -80,7 +83,7 @@ func (h *Handler) CreateRSVP(c *gin.Context) {
 		return
 	}
 
-	_, err = h.EventService.GetEventByID(uint(eventID), accessCtx)
+	ev, err := h.EventService.GetEventByID(uint(eventID), accessCtx)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
@@ -92,6 +95,12 @@ func (h *Handler) CreateRSVP(c *gin.Context) {
 		return
 	}
 
+	occurrence, err := ResolveOccurrence(ev, req.Occurrence)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// ✅ Validate status
 	validStatuses := map[string]bool{
 		"attending": true, "maybe": true, "not_attending": true,
@@ -101,27 +110,60 @@ func (h *Handler) CreateRSVP(c *gin.Context) {
 		return
 	}
 
-	// 🚀 Try updating RSVP first
-	err = h.Service.UpdateRSVPStatus(uint(eventID), user.ID, strings.ToLower(req.Status), req.Notes)
-	if err == nil {
-		c.JSON(http.StatusOK, gin.H{"message": "RSVP updated successfully"})
+	// 🚀 Create or update, full capacity-limited events put attendees on the waitlist
+	rsvp, created, err := h.Service.Respond(uint(eventID), occurrence, user.ID, strings.ToLower(req.Status), req.Notes)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to RSVP: " + err.Error()})
 		return
 	}
 
-	// ✨ Create new RSVP if no existing found
-	rsvp := &RSVP{
-		EventID: uint(eventID),
-		UserID:  user.ID,
-		Status:  strings.ToLower(req.Status),
-		Notes:   req.Notes,
+	resp := gin.H{"message": "RSVP submitted successfully", "rsvp": rsvp}
+	if !created {
+		resp["message"] = "RSVP updated successfully"
+	}
+	if rsvp.Status == RSVPStatusWaitlisted {
+		resp["message"] = "Event is full, you have been added to the waitlist"
+		resp["waitlist_position"] = h.Service.WaitlistPosition(rsvp)
 	}
 
-	if err := h.Service.CreateRSVP(rsvp); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to RSVP: " + err.Error()})
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, resp)
+}
+
+// ==============================
+// 🎟 Claim an offered seat - POST /event-rsvps/:eventID/claim?occurrence=YYYY-MM-DD
+func (h *Handler) ClaimSeat(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		return
+	}
+
+	eventID, err := strconv.Atoi(c.Param("eventID"))
+	if err != nil || eventID < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event ID"})
+		return
+	}
+
+	// Offers are only made on valid occurrences, so no lookup is needed here
+	rsvp, err := h.Service.ClaimSeat(uint(eventID), c.Query("occurrence"), user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNoActiveOffer):
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrOfferExpired):
+			c.JSON(http.StatusGone, gin.H{"error": err.Error()})
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claim seat"})
+		}
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"message": "RSVP submitted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Seat confirmed", "rsvp": rsvp})
 }
 
 // ==============================
@@ -142,7 +184,7 @@ func (h *Handler) GetMyRSVPs(c *gin.Context) {
 }
 
 // ==============================
-// 📋 Get RSVPs for an Event - GET /event-rsvps/:eventID
+// 📋 Get RSVPs for an Event - GET /event-rsvps/:eventID?occurrence=YYYY-MM-DD
 func (h *Handler) GetRSVPsByEvent(c *gin.Context) {
 	eventID, err := strconv.Atoi(c.Param("eventID"))
 	if err != nil || eventID < 1 {
@@ -150,7 +192,7 @@ func (h *Handler) GetRSVPsByEvent(c *gin.Context) {
 		return
 	}
 
-	rsvps, err := h.Service.GetRSVPsByEvent(uint(eventID))
+	rsvps, err := h.Service.GetRSVPsByEvent(uint(eventID), c.Query("occurrence"))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch RSVPs"})
 		return
@@ -6,14 +6,75 @@ const (
 	RSVPStatusAttending    = "attending"
 	RSVPStatusMaybe        = "maybe"
 	RSVPStatusNotAttending = "not_attending"
+
+	// Set by the waitlist for capacity-limited events, never sent by clients
+	RSVPStatusWaitlisted   = "waitlisted"    // Event full, queued by WaitlistedAt
+	RSVPStatusOffered      = "offered"       // Seat held until OfferExpiresAt, claim to attend
+	RSVPStatusOfferExpired = "offer_expired" // Offer not claimed in time, seat passed on
 )
 
 // RSVP represents a user's response to an event invitation
 type RSVP struct {
 	ID       uint      `gorm:"primaryKey" json:"id"`
-	EventID  uint      `gorm:"not null;index:idx_event_user,unique" json:"event_id"` // Composite Unique Index
-	UserID   uint      `gorm:"not null;index:idx_event_user,unique" json:"user_id"`  // Composite Unique Index
-	Status   string    `gorm:"type:varchar(20);default:'attending'" json:"status"`   // Controlled via code, not enum
-	Notes    string    `gorm:"type:text" json:"notes,omitempty"`                     // Optional Notes
-	RSVPDate time.Time `gorm:"autoCreateTime" json:"rsvp_date"`                      // Auto-filled timestamp
+	EventID  uint      `gorm:"not null;index:idx_rsvp_event_user_occurrence,unique" json:"event_id"` // Composite Unique Index
+	UserID   uint      `gorm:"not null;index:idx_rsvp_event_user_occurrence,unique" json:"user_id"`  // Composite Unique Index
+	Status   string    `gorm:"type:varchar(20);default:'attending'" json:"status"`                   // Controlled via code, not enum
+	Notes    string    `gorm:"type:text" json:"notes,omitempty"`                                     // Optional Notes
+	RSVPDate time.Time `gorm:"autoCreateTime" json:"rsvp_date"`                                      // Auto-filled timestamp
+
+	// Date (yyyy-mm-dd) of the occurrence of a recurring event, empty for
+	// single-date events. Capacity and the waitlist are kept per occurrence.
+	Occurrence string `gorm:"type:varchar(10);not null;default:'';index:idx_rsvp_event_user_occurrence,unique" json:"occurrence,omitempty"`
+
+	// Waitlist for capacity-limited events
+	WaitlistedAt   *time.Time `gorm:"index" json:"waitlisted_at,omitempty"`
+	OfferedAt      *time.Time `json:"offered_at,omitempty"`
+	OfferExpiresAt *time.Time `gorm:"index" json:"offer_expires_at,omitempty"`
+
+	// Attendance, marked when temple staff scan the devotee's QR pass (see internal/checkin)
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	CheckedInBy *uint      `json:"checked_in_by,omitempty"`
+
+	// When the latest reminder of the event went out (see reminder.go)
+	RemindedAt *time.Time `json:"reminded_at,omitempty"`
+}
+
+// Reminder channels
+const (
+	ReminderChannelPush  = "push"
+	ReminderChannelInApp = "inapp"
+	ReminderChannelEmail = "email"
+	ReminderChannelText  = "sms" // WhatsApp when the temple has it, otherwise SMS
+)
+
+// Reminder delivery statuses
+const (
+	ReminderStatusPending = "pending" // claimed by a sender, outcome not recorded yet
+	ReminderStatusSent    = "sent"
+	ReminderStatusFailed  = "failed"
+	ReminderStatusSkipped = "skipped" // a closer reminder was already due when this one came up
+)
+
+// ReminderDelivery records one reminder of an RSVP on one channel. It is
+// unique per RSVP, offset and channel so no reminder goes out twice.
+type ReminderDelivery struct {
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	RSVPID        uint   `gorm:"column:rsvp_id;not null;uniqueIndex:idx_reminder_once" json:"rsvp_id"`
+	OffsetMinutes int    `gorm:"not null;uniqueIndex:idx_reminder_once" json:"offset_minutes"` // how long before the start it was due
+	Channel       string `gorm:"type:varchar(20);not null;uniqueIndex:idx_reminder_once" json:"channel"`
+
+	EventID    uint      `gorm:"not null;index" json:"event_id"`
+	EntityID   uint      `gorm:"not null;index" json:"entity_id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	Occurrence string    `gorm:"type:varchar(10);not null;default:''" json:"occurrence,omitempty"`
+	StartsAt   time.Time `json:"starts_at"`
+
+	Status string    `gorm:"type:varchar(20);not null;index" json:"status"`
+	Error  *string   `gorm:"type:text" json:"error,omitempty"`
+	SentAt time.Time `gorm:"not null;index" json:"sent_at"`
+}
+
+// TableName returns the table name for the ReminderDelivery model
+func (ReminderDelivery) TableName() string {
+	return "event_reminder_deliveries"
 }
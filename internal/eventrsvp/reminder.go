@@ -0,0 +1,373 @@
+package eventrsvp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/event"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"gorm.io/gorm/clause"
+)
+
+// untimedEventHour is when events without a time are taken to start, so
+// their reminders don't go out in the middle of the night
+const untimedEventHour = 9
+
+// EventReminder is an attending RSVP that may be due a reminder, with its event
+type EventReminder struct {
+	RSVP     RSVP
+	Event    event.Event
+	Date     string // yyyy-mm-dd of the occurrence
+	Timezone string // of the temple
+}
+
+// UpcomingReminders returns attending RSVPs whose event, or occurrence of a
+// recurring event, falls between from and to (yyyy-mm-dd) and that haven't
+// had their last reminder, the one lastOffsetMinutes before the start
+func (r *Repository) UpcomingReminders(from, to string, lastOffsetMinutes int) ([]EventReminder, error) {
+	type row struct {
+		RSVP
+		Timezone string
+	}
+	var rows []row
+	err := r.DB.Model(&RSVP{}).
+		Select("rsvps.*, entities.timezone").
+		Joins("JOIN events ON events.id = rsvps.event_id").
+		Joins("JOIN entities ON entities.id = events.entity_id").
+		Where("rsvps.status = ? AND events.is_active = ?", RSVPStatusAttending, true).
+		Where("((rsvps.occurrence BETWEEN ? AND ?) OR (rsvps.occurrence = '' AND DATE(events.event_date) BETWEEN ? AND ?))", from, to, from, to).
+		Where("NOT EXISTS (SELECT 1 FROM event_reminder_deliveries d WHERE d.rsvp_id = rsvps.id AND d.offset_minutes = ?)", lastOffsetMinutes).
+		Scan(&rows).Error
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+
+	eventIDs := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		eventIDs = append(eventIDs, row.EventID)
+	}
+	var events []event.Event
+	if err := r.DB.Where("id IN ?", eventIDs).Find(&events).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uint]event.Event, len(events))
+	for _, ev := range events {
+		byID[ev.ID] = ev
+	}
+
+	reminders := make([]EventReminder, 0, len(rows))
+	for _, row := range rows {
+		ev := byID[row.EventID]
+		date := row.Occurrence
+		if date == "" {
+			date = ev.EventDate.Format("2006-01-02")
+		}
+		reminders = append(reminders, EventReminder{RSVP: row.RSVP, Event: ev, Date: date, Timezone: row.Timezone})
+	}
+	return reminders, nil
+}
+
+// ReminderOffsetsDone returns the offsets (minutes) each RSVP already had a
+// reminder recorded for
+func (r *Repository) ReminderOffsetsDone(rsvpIDs []uint) (map[uint]map[int]bool, error) {
+	done := make(map[uint]map[int]bool, len(rsvpIDs))
+	if len(rsvpIDs) == 0 {
+		return done, nil
+	}
+	var rows []struct {
+		RSVPID        uint `gorm:"column:rsvp_id"`
+		OffsetMinutes int
+	}
+	err := r.DB.Model(&ReminderDelivery{}).
+		Distinct("rsvp_id", "offset_minutes").
+		Where("rsvp_id IN ?", rsvpIDs).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if done[row.RSVPID] == nil {
+			done[row.RSVPID] = map[int]bool{}
+		}
+		done[row.RSVPID][row.OffsetMinutes] = true
+	}
+	return done, nil
+}
+
+// ClaimReminder records a reminder before it is sent. It returns false when
+// it was recorded already, e.g. by another instance.
+func (r *Repository) ClaimReminder(d *ReminderDelivery) (bool, error) {
+	res := r.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(d)
+	return res.RowsAffected > 0, res.Error
+}
+
+// UpdateReminder saves the outcome of a reminder
+func (r *Repository) UpdateReminder(d *ReminderDelivery) error {
+	return r.DB.Model(d).Updates(map[string]interface{}{
+		"status":  d.Status,
+		"error":   d.Error,
+		"sent_at": d.SentAt,
+	}).Error
+}
+
+// MarkReminded records when the RSVP's latest reminder went out
+func (r *Repository) MarkReminded(rsvpID uint, at time.Time) error {
+	return r.DB.Model(&RSVP{}).Where("id = ?", rsvpID).Update("reminded_at", at).Error
+}
+
+// StartReminderSender reminds devotees attending an event ReminderOffsets
+// before it starts, in the temple's timezone, checking every interval until
+// ctx is cancelled
+func (s *Service) StartReminderSender(ctx context.Context, interval time.Duration) {
+	if len(s.reminderOffsets()) == 0 {
+		return
+	}
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sendReminders(ctx, time.Now())
+			}
+		}
+	}()
+}
+
+// reminderOffsets returns the configured offsets, longest first
+func (s *Service) reminderOffsets() []time.Duration {
+	var offsets []time.Duration
+	for _, d := range s.ReminderOffsets {
+		if d >= time.Minute {
+			offsets = append(offsets, d)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] > offsets[j] })
+	return offsets
+}
+
+func (s *Service) sendReminders(ctx context.Context, now time.Time) {
+	offsets := s.reminderOffsets()
+	if len(offsets) == 0 {
+		return
+	}
+	channels := s.reminderChannels()
+	if len(channels) == 0 {
+		return
+	}
+
+	// Dates are compared loosely here, temples' timezones are applied below
+	from := now.AddDate(0, 0, -1).Format("2006-01-02")
+	to := now.Add(offsets[0]).AddDate(0, 0, 1).Format("2006-01-02")
+	lastOffset := int(offsets[len(offsets)-1] / time.Minute)
+	reminders, err := s.Repo.UpcomingReminders(from, to, lastOffset)
+	if err != nil {
+		log.Printf("❌ Event reminder lookup failed: %v", err)
+		return
+	}
+	if len(reminders) == 0 {
+		return
+	}
+
+	rsvpIDs := make([]uint, 0, len(reminders))
+	for _, reminder := range reminders {
+		rsvpIDs = append(rsvpIDs, reminder.RSVP.ID)
+	}
+	done, err := s.Repo.ReminderOffsetsDone(rsvpIDs)
+	if err != nil {
+		log.Printf("❌ Event reminder lookup failed: %v", err)
+		return
+	}
+
+	for _, reminder := range reminders {
+		start, ok := reminderStart(reminder)
+		if !ok || !now.Before(start) {
+			continue
+		}
+
+		// The closest offset already due is sent; longer ones that are due
+		// too were missed (e.g. the RSVP came in late) and are skipped
+		due := -1
+		for i, offset := range offsets {
+			if start.Sub(now) <= offset {
+				due = i
+			}
+		}
+		if due < 0 || done[reminder.RSVP.ID][int(offsets[due]/time.Minute)] {
+			continue
+		}
+		for _, offset := range offsets[:due] {
+			if !done[reminder.RSVP.ID][int(offset/time.Minute)] {
+				s.skipReminder(reminder, start, offset, channels, now)
+			}
+		}
+		s.sendReminder(ctx, reminder, start, offsets[due], channels, now)
+	}
+}
+
+// reminderChannels returns the configured channels that can deliver
+func (s *Service) reminderChannels() []string {
+	var channels []string
+	for _, ch := range s.ReminderChannels {
+		switch ch {
+		case ReminderChannelPush, ReminderChannelInApp:
+			if s.EventService == nil || s.EventService.NotifSvc == nil {
+				continue
+			}
+		case ReminderChannelEmail:
+			if s.Mailer == nil {
+				continue
+			}
+		case ReminderChannelText:
+			if s.Messenger == nil {
+				continue
+			}
+		default:
+			continue
+		}
+		channels = append(channels, ch)
+	}
+	return channels
+}
+
+// reminderStart is when the event occurrence starts in the temple's timezone
+func reminderStart(reminder EventReminder) (time.Time, bool) {
+	loc := entity.Location(reminder.Timezone)
+	day, err := time.ParseInLocation("2006-01-02", reminder.Date, loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	hour, minute := untimedEventHour, 0
+	if t := reminder.Event.EventTime; t != nil {
+		hour, minute = t.Hour(), t.Minute()
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc), true
+}
+
+func (s *Service) skipReminder(reminder EventReminder, start time.Time, offset time.Duration, channels []string, now time.Time) {
+	for _, ch := range channels {
+		d := newReminderDelivery(reminder, start, offset, ch, now)
+		d.Status = ReminderStatusSkipped
+		if _, err := s.Repo.ClaimReminder(d); err != nil {
+			log.Printf("⚠️ Recording skipped reminder of RSVP %d failed: %v", reminder.RSVP.ID, err)
+		}
+	}
+}
+
+// sendReminder sends one reminder on every channel not recorded yet
+func (s *Service) sendReminder(ctx context.Context, reminder EventReminder, start time.Time, offset time.Duration, channels []string, now time.Time) {
+	ev := reminder.Event
+	userID := reminder.RSVP.UserID
+	local := now.In(start.Location())
+	when := startsIn(local, start)
+	title := "Reminder: " + ev.Title
+	message := fmt.Sprintf("%s is %s, %s.", ev.Title, when, start.Format("Mon, 02 Jan 2006"))
+	if ev.EventTime != nil {
+		message = fmt.Sprintf("%s is %s, %s at %s.", ev.Title, when, start.Format("Mon, 02 Jan 2006"), start.Format("15:04"))
+	}
+	if ev.Location != "" {
+		message += " Venue: " + ev.Location + "."
+	}
+
+	sent := false
+	for _, ch := range channels {
+		d := newReminderDelivery(reminder, start, offset, ch, now)
+		claimed, err := s.Repo.ClaimReminder(d)
+		if err != nil {
+			log.Printf("⚠️ Recording reminder of RSVP %d failed: %v", reminder.RSVP.ID, err)
+			continue
+		}
+		if !claimed {
+			continue
+		}
+
+		switch ch {
+		case ReminderChannelInApp:
+			err = s.EventService.NotifSvc.CreateInAppNotification(ctx, userID, ev.EntityID, title, message, "event")
+		case ReminderChannelPush:
+			err = s.EventService.NotifSvc.SendPushNotification(ctx, ev.CreatedBy, ev.EntityID, title, message, []uint{userID}, "")
+		case ReminderChannelEmail:
+			data := map[string]interface{}{
+				"EventTitle": ev.Title,
+				"StartsIn":   when,
+				"EventDate":  start.Format("Monday, 02 January 2006"),
+				"Location":   ev.Location,
+			}
+			if ev.EventTime != nil {
+				data["EventTime"] = start.Format("15:04")
+			}
+			err = s.Mailer.SendToUser(ctx, ev.EntityID, userID, notification.EmailEventReminder, data)
+		case ReminderChannelText:
+			err = s.Messenger.SendToUser(ctx, ev.EntityID, userID, notification.MessageEventReminder, title+": "+message)
+		}
+
+		d.Status = ReminderStatusSent
+		d.SentAt = time.Now()
+		if err != nil {
+			msg := err.Error()
+			d.Status, d.Error = ReminderStatusFailed, &msg
+			log.Printf("⚠️ %s reminder of RSVP %d failed: %v", ch, reminder.RSVP.ID, err)
+		} else {
+			sent = true
+		}
+		if err := s.Repo.UpdateReminder(d); err != nil {
+			log.Printf("⚠️ Updating reminder %d failed: %v", d.ID, err)
+		}
+	}
+
+	if sent {
+		if err := s.Repo.MarkReminded(reminder.RSVP.ID, time.Now()); err != nil {
+			log.Printf("⚠️ Failed to mark RSVP %d reminded: %v", reminder.RSVP.ID, err)
+		}
+	}
+}
+
+func newReminderDelivery(reminder EventReminder, start time.Time, offset time.Duration, channel string, now time.Time) *ReminderDelivery {
+	return &ReminderDelivery{
+		RSVPID:        reminder.RSVP.ID,
+		OffsetMinutes: int(offset / time.Minute),
+		Channel:       channel,
+		EventID:       reminder.Event.ID,
+		EntityID:      reminder.Event.EntityID,
+		UserID:        reminder.RSVP.UserID,
+		Occurrence:    reminder.RSVP.Occurrence,
+		StartsAt:      start,
+		Status:        ReminderStatusPending,
+		SentAt:        now,
+	}
+}
+
+// startsIn describes how far off start is, e.g. "in 45 minutes", "in 3
+// hours", "tomorrow"
+func startsIn(now, start time.Time) string {
+	d := start.Sub(now).Round(time.Minute)
+	switch {
+	case d < time.Minute:
+		return "starting now"
+	case d < 55*time.Minute:
+		return fmt.Sprintf("in %d minutes", int(d/time.Minute))
+	case d < 90*time.Minute:
+		return "in 1 hour"
+	case d < 6*time.Hour:
+		return fmt.Sprintf("in %d hours", int(d.Round(time.Hour)/time.Hour))
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch start.Sub(today) / (24 * time.Hour) {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	}
+	return "on " + start.Format("Monday")
+}
@@ -31,12 +31,16 @@ func (r *Repository) GetMyRSVPs(userID uint) ([]RSVP, error) {
 	return rsvps, err
 }
 
-// ✅ GetRSVPsByEvent lists all RSVPs for a given event (temple admin use)
-func (r *Repository) GetRSVPsByEvent(eventID uint) ([]RSVP, error) {
+// ✅ GetRSVPsByEvent lists all RSVPs for a given event (temple admin use),
+// optionally for one occurrence
+func (r *Repository) GetRSVPsByEvent(eventID uint, occurrence string) ([]RSVP, error) {
 	var rsvps []RSVP
-	err := r.DB.
-		Where("event_id = ?", eventID).
-		Order("rsvp_date ASC").
+	query := r.DB.Where("event_id = ?", eventID)
+	if occurrence != "" {
+		query = query.Where("occurrence = ?", occurrence)
+	}
+	err := query.
+		Order("occurrence ASC, rsvp_date ASC").
 		Find(&rsvps).Error
 	return rsvps, err
 }
@@ -1,15 +1,28 @@
 package eventrsvp
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"log"
+	"time"
 
 	"github.com/sharath018/temple-management-backend/internal/event"
+	"github.com/sharath018/temple-management-backend/internal/notification"
 )
 
 // Service handles business logic related to RSVPs
 type Service struct {
 	Repo         *Repository
 	EventService *event.Service
+	ClaimWindow  time.Duration // How long a promoted devotee has to claim a seat
+
+	// Event reminders go out ReminderOffsets before the start on
+	// ReminderChannels; in-app and push use EventService.NotifSvc
+	ReminderOffsets  []time.Duration
+	ReminderChannels []string
+	Mailer           *notification.Mailer    // Emails event reminders; nil disables them
+	Messenger        *notification.Messenger // Texts event reminders; nil disables them
 }
 
 // NewService initializes the RSVP service with repository and event dependency
@@ -17,6 +30,10 @@ func NewService(repo *Repository, eventService *event.Service) *Service {
 	return &Service{
 		Repo:         repo,
 		EventService: eventService,
+		ClaimWindow:  DefaultClaimWindow,
+
+		ReminderOffsets:  []time.Duration{24 * time.Hour, time.Hour},
+		ReminderChannels: []string{ReminderChannelPush, ReminderChannelInApp},
 	}
 }
 
@@ -35,9 +52,10 @@ func (s *Service) GetMyRSVPs(userID uint) ([]RSVP, error) {
 	return s.Repo.GetMyRSVPs(userID)
 }
 
-// 📦 GetRSVPsByEvent returns all RSVPs for an event
-func (s *Service) GetRSVPsByEvent(eventID uint) ([]RSVP, error) {
-	return s.Repo.GetRSVPsByEvent(eventID)
+// 📦 GetRSVPsByEvent returns all RSVPs for an event, or for one occurrence
+// of a recurring event when occurrence is set
+func (s *Service) GetRSVPsByEvent(eventID uint, occurrence string) ([]RSVP, error) {
+	return s.Repo.GetRSVPsByEvent(eventID, occurrence)
 }
 
 // 🔁 UpdateRSVPStatus updates RSVP status if it already exists
@@ -48,3 +66,117 @@ func (s *Service) UpdateRSVPStatus(eventID, userID uint, status, notes string) e
 	}
 	return s.Repo.UpdateRSVPStatus(eventID, userID, status, notes)
 }
+
+// 🎟 Respond creates or updates the user's RSVP to an event occurrence,
+// applying its capacity. Returns the saved RSVP and whether it was newly created.
+func (s *Service) Respond(eventID uint, occurrence string, userID uint, status, notes string) (*RSVP, bool, error) {
+	if status != RSVPStatusAttending && status != RSVPStatusMaybe && status != RSVPStatusNotAttending {
+		return nil, false, errors.New("invalid RSVP status")
+	}
+
+	rsvp, created, change, err := s.Repo.Respond(eventID, occurrence, userID, status, notes, s.ClaimWindow)
+	if err != nil {
+		return nil, false, err
+	}
+	if change != nil {
+		s.notifyWaitlist(*change)
+	}
+	return rsvp, created, nil
+}
+
+// 🎟 ClaimSeat accepts the seat offered to a promoted devotee
+func (s *Service) ClaimSeat(eventID uint, occurrence string, userID uint) (*RSVP, error) {
+	return s.Repo.Claim(eventID, occurrence, userID)
+}
+
+// ResolveOccurrence checks the requested occurrence date against the event:
+// recurring events need one of their dates, single-date events none.
+// Returns the occurrence as stored on RSVPs.
+func ResolveOccurrence(ev *event.Event, occurrence string) (string, error) {
+	if !ev.IsRecurring() {
+		if occurrence != "" && occurrence != ev.EventDate.Format("2006-01-02") {
+			return "", ErrInvalidOccurrence
+		}
+		return "", nil
+	}
+	if occurrence == "" {
+		return "", ErrOccurrenceRequired
+	}
+	date, err := time.Parse("2006-01-02", occurrence)
+	if err != nil || !ev.HasOccurrence(date) {
+		return "", ErrInvalidOccurrence
+	}
+	return date.Format("2006-01-02"), nil
+}
+
+// WaitlistPosition returns the queue position of a waitlisted RSVP, 0 otherwise
+func (s *Service) WaitlistPosition(rsvp *RSVP) int64 {
+	pos, err := s.Repo.WaitlistPosition(rsvp)
+	if err != nil {
+		log.Printf("⚠️ Waitlist position for RSVP %d failed: %v", rsvp.ID, err)
+		return 0
+	}
+	return pos
+}
+
+// notifyWaitlist tells promoted devotees about their seat offer and lets
+// devotees whose offer ran out know it was passed on
+func (s *Service) notifyWaitlist(change WaitlistChange) {
+	if s.EventService == nil || s.EventService.NotifSvc == nil {
+		return
+	}
+	notif := s.EventService.NotifSvc
+	ctx := context.Background()
+	ev := change.Event
+
+	date := ev.EventDate.Format("2006-01-02")
+	if change.Occurrence != "" {
+		date = change.Occurrence
+	}
+
+	for _, rsvp := range change.Promoted {
+		title := "Seat available: " + ev.Title
+		message := fmt.Sprintf("A seat opened up for %s on %s. Claim it before %s or it goes to the next person on the waitlist.",
+			ev.Title, date, rsvp.OfferExpiresAt.Format("2006-01-02 15:04 MST"))
+
+		if err := notif.CreateInAppNotification(ctx, rsvp.UserID, ev.EntityID, title, message, "event"); err != nil {
+			log.Printf("⚠️ Waitlist offer notification for user %d failed: %v", rsvp.UserID, err)
+		}
+		// Push is best effort, the devotee may not have registered a device
+		_ = notif.SendPushNotification(ctx, ev.CreatedBy, ev.EntityID, title, message, []uint{rsvp.UserID}, "")
+	}
+
+	for _, rsvp := range change.Expired {
+		message := fmt.Sprintf("Your seat offer for %s was not claimed in time and has been passed on.", ev.Title)
+		if err := notif.CreateInAppNotification(ctx, rsvp.UserID, ev.EntityID, "Seat offer expired: "+ev.Title, message, "event"); err != nil {
+			log.Printf("⚠️ Waitlist expiry notification for user %d failed: %v", rsvp.UserID, err)
+		}
+	}
+}
+
+// StartWaitlistSweeper expires unclaimed offers and fills free seats from
+// the waitlist every interval until ctx is cancelled
+func (s *Service) StartWaitlistSweeper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				changes, err := s.Repo.SweepWaitlists(s.ClaimWindow)
+				if err != nil {
+					log.Printf("❌ RSVP waitlist sweep failed: %v", err)
+				}
+				for _, change := range changes {
+					s.notifyWaitlist(change)
+				}
+			}
+		}
+	}()
+}
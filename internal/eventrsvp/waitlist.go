@@ -0,0 +1,310 @@
+package eventrsvp
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/event"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DefaultClaimWindow is how long a promoted devotee has to claim a seat
+// before it is offered to the next person on the waitlist
+const DefaultClaimWindow = 12 * time.Hour
+
+var (
+	ErrNoActiveOffer      = errors.New("no seat is currently offered to you for this event")
+	ErrOfferExpired       = errors.New("the seat offer has expired and was passed on to the next person on the waitlist")
+	ErrOccurrenceRequired = errors.New("occurrence (YYYY-MM-DD) is required for recurring events")
+	ErrInvalidOccurrence  = errors.New("the event does not take place on that date")
+)
+
+// WaitlistChange describes the waitlist moves made on one event occurrence
+type WaitlistChange struct {
+	Event      event.Event
+	Occurrence string
+	Promoted   []RSVP // Waitlisted RSVPs that were offered a seat
+	Expired    []RSVP // Offers that ran out unclaimed
+}
+
+// eventStart returns when the event (or the occurrence of a recurring
+// event) begins; offers never outlive it
+func eventStart(ev *event.Event, occurrence string) time.Time {
+	y, m, d := ev.EventDate.Date()
+	if date, err := time.Parse("2006-01-02", occurrence); err == nil {
+		y, m, d = date.Date()
+	}
+	if ev.EventTime == nil {
+		return time.Date(y, m, d, 0, 0, 0, 0, ev.EventDate.Location()).AddDate(0, 0, 1)
+	}
+	return time.Date(y, m, d, ev.EventTime.Hour(), ev.EventTime.Minute(), 0, 0, ev.EventDate.Location())
+}
+
+// withEventLock runs fn in a transaction holding the event row lock, which
+// serialises seat accounting for the event
+func (r *Repository) withEventLock(eventID uint, fn func(tx *gorm.DB, ev *event.Event) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		var ev event.Event
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&ev, eventID).Error; err != nil {
+			return err
+		}
+		return fn(tx, &ev)
+	})
+}
+
+// heldSeats counts attendees plus seats held for pending offers
+func heldSeats(tx *gorm.DB, eventID uint, occurrence string) (int64, error) {
+	var n int64
+	err := tx.Model(&RSVP{}).
+		Where("event_id = ? AND occurrence = ? AND status IN ?", eventID, occurrence, []string{RSVPStatusAttending, RSVPStatusOffered}).
+		Count(&n).Error
+	return n, err
+}
+
+// promote offers free seats of an occurrence to the longest waiting RSVPs
+func promote(tx *gorm.DB, ev *event.Event, occurrence string, now time.Time, window time.Duration) ([]RSVP, error) {
+	if ev.Capacity == nil || !ev.IsActive {
+		return nil, nil
+	}
+	expires := now.Add(window)
+	if start := eventStart(ev, occurrence); start.Before(expires) {
+		expires = start
+	}
+	if !expires.After(now) {
+		return nil, nil // Event has started, nobody can claim a seat anymore
+	}
+
+	held, err := heldSeats(tx, ev.ID, occurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	var promoted []RSVP
+	for ; held < int64(*ev.Capacity); held++ {
+		var next RSVP
+		err := tx.Where("event_id = ? AND occurrence = ? AND status = ?", ev.ID, occurrence, RSVPStatusWaitlisted).
+			Order("waitlisted_at ASC, id ASC").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		offeredAt, offerExpiresAt := now, expires
+		next.Status = RSVPStatusOffered
+		next.OfferedAt = &offeredAt
+		next.OfferExpiresAt = &offerExpiresAt
+		if err := tx.Model(&RSVP{}).Where("id = ?", next.ID).Updates(map[string]interface{}{
+			"status":           next.Status,
+			"offered_at":       next.OfferedAt,
+			"offer_expires_at": next.OfferExpiresAt,
+		}).Error; err != nil {
+			return nil, err
+		}
+		promoted = append(promoted, next)
+	}
+	return promoted, nil
+}
+
+// Respond records a devotee's RSVP to an event occurrence ("" for single-date
+// events). On a full capacity-limited occurrence an "attending" response
+// joins the waitlist instead. Seats freed by the response are offered to the
+// waitlist in the same transaction.
+func (r *Repository) Respond(eventID uint, occurrence string, userID uint, status, notes string, window time.Duration) (*RSVP, bool, *WaitlistChange, error) {
+	var (
+		rsvp    RSVP
+		created bool
+		change  *WaitlistChange
+	)
+	now := time.Now()
+
+	err := r.withEventLock(eventID, func(tx *gorm.DB, ev *event.Event) error {
+		err := tx.Where("event_id = ? AND occurrence = ? AND user_id = ?", eventID, occurrence, userID).First(&rsvp).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			created = true
+			rsvp = RSVP{EventID: eventID, Occurrence: occurrence, UserID: userID}
+		} else if err != nil {
+			return err
+		}
+
+		previous := rsvp.Status
+		rsvp.Notes = notes
+
+		if status != RSVPStatusAttending {
+			rsvp.Status = status
+			rsvp.WaitlistedAt, rsvp.OfferedAt, rsvp.OfferExpiresAt = nil, nil, nil
+		} else {
+			switch {
+			case !created && previous == RSVPStatusAttending:
+				// Already attending, only the notes change
+			case !created && previous == RSVPStatusOffered && rsvp.OfferExpiresAt != nil && rsvp.OfferExpiresAt.After(now):
+				// Answering "attending" while holding an offer claims the seat
+				rsvp.Status = RSVPStatusAttending
+				rsvp.WaitlistedAt, rsvp.OfferedAt, rsvp.OfferExpiresAt = nil, nil, nil
+			case !created && previous == RSVPStatusWaitlisted:
+				// Keep the place in the queue
+			default:
+				hasSeat, err := seatAvailable(tx, ev, occurrence, userID)
+				if err != nil {
+					return err
+				}
+				if hasSeat {
+					rsvp.Status = RSVPStatusAttending
+					rsvp.WaitlistedAt, rsvp.OfferedAt, rsvp.OfferExpiresAt = nil, nil, nil
+				} else {
+					waitlistedAt := now
+					rsvp.Status = RSVPStatusWaitlisted
+					rsvp.WaitlistedAt = &waitlistedAt
+					rsvp.OfferedAt, rsvp.OfferExpiresAt = nil, nil
+				}
+			}
+		}
+
+		if err := tx.Save(&rsvp).Error; err != nil {
+			return err
+		}
+
+		promoted, err := promote(tx, ev, occurrence, now, window)
+		if err != nil {
+			return err
+		}
+		if len(promoted) > 0 {
+			change = &WaitlistChange{Event: *ev, Occurrence: occurrence, Promoted: promoted}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, nil, err
+	}
+	return &rsvp, created, change, nil
+}
+
+// seatAvailable reports whether a new attendee fits without skipping the queue
+func seatAvailable(tx *gorm.DB, ev *event.Event, occurrence string, userID uint) (bool, error) {
+	if ev.Capacity == nil {
+		return true, nil
+	}
+	held, err := heldSeats(tx, ev.ID, occurrence)
+	if err != nil {
+		return false, err
+	}
+	if held >= int64(*ev.Capacity) {
+		return false, nil
+	}
+	var waiting int64
+	err = tx.Model(&RSVP{}).
+		Where("event_id = ? AND occurrence = ? AND status = ? AND user_id <> ?", ev.ID, occurrence, RSVPStatusWaitlisted, userID).
+		Count(&waiting).Error
+	return waiting == 0, err
+}
+
+// Claim turns the devotee's pending offer into an attending RSVP
+func (r *Repository) Claim(eventID uint, occurrence string, userID uint) (*RSVP, error) {
+	var rsvp RSVP
+	now := time.Now()
+
+	err := r.withEventLock(eventID, func(tx *gorm.DB, ev *event.Event) error {
+		err := tx.Where("event_id = ? AND occurrence = ? AND user_id = ?", eventID, occurrence, userID).First(&rsvp).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNoActiveOffer
+		}
+		if err != nil {
+			return err
+		}
+
+		switch rsvp.Status {
+		case RSVPStatusAttending:
+			return nil // Claimed already
+		case RSVPStatusOfferExpired:
+			return ErrOfferExpired
+		case RSVPStatusOffered:
+		default:
+			return ErrNoActiveOffer
+		}
+		if rsvp.OfferExpiresAt == nil || !rsvp.OfferExpiresAt.After(now) {
+			// The sweeper hasn't passed it on yet, but the window is over
+			return ErrOfferExpired
+		}
+
+		rsvp.Status = RSVPStatusAttending
+		rsvp.WaitlistedAt, rsvp.OfferedAt, rsvp.OfferExpiresAt = nil, nil, nil
+		return tx.Save(&rsvp).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &rsvp, nil
+}
+
+// WaitlistPosition returns the 1-based queue position of a waitlisted RSVP
+func (r *Repository) WaitlistPosition(rsvp *RSVP) (int64, error) {
+	if rsvp.Status != RSVPStatusWaitlisted || rsvp.WaitlistedAt == nil {
+		return 0, nil
+	}
+	var ahead int64
+	err := r.DB.Model(&RSVP{}).
+		Where("event_id = ? AND occurrence = ? AND status = ? AND (waitlisted_at < ? OR (waitlisted_at = ? AND id < ?))",
+			rsvp.EventID, rsvp.Occurrence, RSVPStatusWaitlisted, rsvp.WaitlistedAt, rsvp.WaitlistedAt, rsvp.ID).
+		Count(&ahead).Error
+	return ahead + 1, err
+}
+
+// SweepWaitlists expires unclaimed offers and offers every free seat to the
+// waitlist, for all event occurrences that have offers or waitlisted RSVPs
+func (r *Repository) SweepWaitlists(window time.Duration) ([]WaitlistChange, error) {
+	var pending []struct {
+		EventID    uint
+		Occurrence string
+	}
+	if err := r.DB.Model(&RSVP{}).
+		Distinct("event_id", "occurrence").
+		Where("status IN ?", []string{RSVPStatusOffered, RSVPStatusWaitlisted}).
+		Scan(&pending).Error; err != nil {
+		return nil, err
+	}
+
+	var changes []WaitlistChange
+	for _, p := range pending {
+		now := time.Now()
+		change := WaitlistChange{Occurrence: p.Occurrence}
+		err := r.withEventLock(p.EventID, func(tx *gorm.DB, ev *event.Event) error {
+			if err := tx.Where("event_id = ? AND occurrence = ? AND status = ? AND offer_expires_at <= ?",
+				ev.ID, p.Occurrence, RSVPStatusOffered, now).
+				Find(&change.Expired).Error; err != nil {
+				return err
+			}
+			if len(change.Expired) > 0 {
+				ids := make([]uint, len(change.Expired))
+				for i := range change.Expired {
+					ids[i] = change.Expired[i].ID
+					change.Expired[i].Status = RSVPStatusOfferExpired
+				}
+				if err := tx.Model(&RSVP{}).Where("id IN ?", ids).
+					Update("status", RSVPStatusOfferExpired).Error; err != nil {
+					return err
+				}
+			}
+
+			promoted, err := promote(tx, ev, p.Occurrence, now, window)
+			if err != nil {
+				return err
+			}
+			change.Promoted = promoted
+			change.Event = *ev
+			return nil
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue // Event deleted
+		}
+		if err != nil {
+			return changes, err
+		}
+		if len(change.Promoted) > 0 || len(change.Expired) > 0 {
+			changes = append(changes, change)
+		}
+	}
+	return changes, nil
+}
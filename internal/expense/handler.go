@@ -0,0 +1,266 @@
+package expense
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the expense endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new expense handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// List - GET /expenses?status=pending&category=&from=2025-01-01&to=2025-01-31&page=1&limit=20
+func (h *Handler) List(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	f := Filter{
+		EntityID: entityID,
+		Status:   c.Query("status"),
+		Category: c.Query("category"),
+		Page:     httpx.PositiveQuery(c, "page", 1),
+		Limit:    min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if f.Status != "" && f.Status != StatusPending && f.Status != StatusApproved && f.Status != StatusRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved or rejected"})
+		return
+	}
+	if f.Category != "" && !validCategory(f.Category) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidCategory.Error()})
+		return
+	}
+	if f.From, ok = dateQuery(c, "from"); !ok {
+		return
+	}
+	if f.To, ok = dateQuery(c, "to"); !ok {
+		return
+	}
+
+	items, total, err := h.Service.Repo.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch expenses"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// GetCategories - GET /expenses/categories
+func (h *Handler) GetCategories(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": Categories})
+}
+
+// Create - POST /expenses
+func (h *Handler) Create(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req ExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	e, err := h.Service.Create(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to record expense")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Expense recorded", "data": e})
+}
+
+// Get - GET /expenses/:id
+func (h *Handler) Get(c *gin.Context) {
+	_, e, ok := h.loadExpense(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": e})
+}
+
+// Update - PUT /expenses/:id
+func (h *Handler) Update(c *gin.Context) {
+	access, e, ok := h.loadExpense(c, true)
+	if !ok {
+		return
+	}
+	var req ExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.Update(c.Request.Context(), e, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update expense")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Expense updated", "data": e})
+}
+
+// Approve - POST /expenses/:id/approve (templeadmin)
+func (h *Handler) Approve(c *gin.Context) {
+	h.review(c, true)
+}
+
+// Reject - POST /expenses/:id/reject (templeadmin)
+func (h *Handler) Reject(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *Handler) review(c *gin.Context, approve bool) {
+	access, e, ok := h.loadExpense(c, true)
+	if !ok {
+		return
+	}
+	var req ReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	if err := h.Service.Review(c.Request.Context(), e, approve, req.Note, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to review expense")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Expense " + e.Status, "data": e})
+}
+
+// UploadReceipt - POST /expenses/:id/receipt (multipart "receipt")
+func (h *Handler) UploadReceipt(c *gin.Context) {
+	access, e, ok := h.loadExpense(c, true)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxReceiptSize+1<<20)
+	fileHeader, err := c.FormFile("receipt")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "receipt is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "expense_receipt")
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read receipt"})
+		return
+	}
+	defer file.Close()
+
+	if err := h.Service.SetReceipt(c.Request.Context(), e, filepath.Base(fileHeader.Filename), fileHeader.Size, file,
+		access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to upload receipt")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Receipt uploaded", "data": e})
+}
+
+// DownloadReceipt - GET /expenses/:id/receipt
+func (h *Handler) DownloadReceipt(c *gin.Context) {
+	_, e, ok := h.loadExpense(c, false)
+	if !ok {
+		return
+	}
+	rc, info, contentType, err := h.Service.OpenReceipt(c.Request.Context(), e)
+	if err != nil {
+		if errors.Is(err, ErrNoReceipt) || errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Receipt not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load receipt"})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, contentType, rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", e.ReceiptName),
+	})
+}
+
+// loadExpense loads the :id expense of a temple the caller runs; writes
+// need write access
+func (h *Handler) loadExpense(c *gin.Context, write bool) (middleware.AccessContext, *Expense, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expense ID"})
+		return access, nil, false
+	}
+	e, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch expense")
+		return access, nil, false
+	}
+	allowed := access.IsEntityStaff(e.EntityID)
+	if write {
+		allowed = access.CanManageEntity(e.EntityID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this expense"})
+		return access, nil, false
+	}
+	return access, e, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Expense not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest: {ErrInvalidCategory, ErrInvalidDate, ErrInvalidReceipt},
+		http.StatusConflict:   {ErrNotPending},
+	},
+}
+
+// dateQuery parses an optional yyyy-mm-dd query param
+func dateQuery(c *gin.Context, key string) (*time.Time, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, true
+	}
+	d, err := time.Parse(dateLayout, v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a date (yyyy-mm-dd)"})
+		return nil, false
+	}
+	return &d, true
+}
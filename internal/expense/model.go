@@ -0,0 +1,83 @@
+package expense
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Expense statuses. Expenses are recorded as pending and count towards the
+// temple's finances once a temple admin approves them.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Categories an expense may be filed under
+var Categories = []string{
+	"maintenance",
+	"utilities",
+	"salaries",
+	"pooja_supplies",
+	"annadanam",
+	"festival",
+	"construction",
+	"administration",
+	"other",
+}
+
+// Expense is money a temple spent
+type Expense struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID    uint      `gorm:"not null;index" json:"entity_id"`
+	Category    string    `gorm:"size:50;not null;index" json:"category"`
+	Amount      float64   `gorm:"type:decimal(12,2);not null" json:"amount"` // INR
+	Vendor      string    `gorm:"size:255" json:"vendor"`
+	Description string    `gorm:"type:text" json:"description"`
+	ExpenseDate time.Time `gorm:"type:date;not null;index" json:"expense_date"`
+
+	ReceiptKey  string `gorm:"size:255" json:"-"` // storage key of the uploaded bill
+	ReceiptName string `gorm:"size:255" json:"receipt_name,omitempty"`
+
+	Status      string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	SubmittedBy uint       `gorm:"not null" json:"submitted_by"`
+	ReviewedBy  *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt  *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote  string     `gorm:"type:text" json:"review_note,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Expense model
+func (Expense) TableName() string {
+	return "temple_expenses"
+}
+
+// ExpenseRequest records or corrects an expense
+type ExpenseRequest struct {
+	Category    string  `json:"category" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Vendor      string  `json:"vendor"`
+	Description string  `json:"description"`
+	ExpenseDate string  `json:"expense_date" binding:"required"` // 2006-01-02
+}
+
+// ReviewRequest approves or rejects an expense
+type ReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// Filter narrows the expense list
+type Filter struct {
+	EntityID uint
+	Status   string
+	Category string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
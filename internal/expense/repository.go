@@ -0,0 +1,63 @@
+package expense
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repository reads and writes expenses
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new expense repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create stores a new expense
+func (r *Repository) Create(ctx context.Context, e *Expense) error {
+	return r.DB.WithContext(ctx).Create(e).Error
+}
+
+// Update saves every field of an expense
+func (r *Repository) Update(ctx context.Context, e *Expense) error {
+	return r.DB.WithContext(ctx).Save(e).Error
+}
+
+// GetByID loads an expense
+func (r *Repository) GetByID(ctx context.Context, id uint) (*Expense, error) {
+	var e Expense
+	if err := r.DB.WithContext(ctx).First(&e, id).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// List returns a temple's expenses, latest first, and how many match f
+func (r *Repository) List(ctx context.Context, f Filter) ([]Expense, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Expense{}).Where("entity_id = ?", f.EntityID)
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Category != "" {
+		query = query.Where("category = ?", f.Category)
+	}
+	if f.From != nil {
+		query = query.Where("expense_date >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("expense_date <= ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Expense
+	err := query.Order("expense_date DESC, id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
@@ -0,0 +1,186 @@
+package expense
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	maxReceiptSize = 10 << 20 // 10 MB
+)
+
+// receiptTypes are the formats accepted for expense bills
+var receiptTypes = map[string]string{
+	".pdf":  "application/pdf",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+}
+
+var (
+	ErrInvalidCategory = fmt.Errorf("category must be one of %s", strings.Join(Categories, ", "))
+	ErrInvalidDate     = errors.New("expense_date must be a date (yyyy-mm-dd) not in the future")
+	ErrInvalidReceipt  = fmt.Errorf("receipt must be a PDF, JPG or PNG file of %dMB or less", maxReceiptSize>>20)
+	ErrNotPending      = errors.New("only pending expenses can be changed or reviewed")
+	ErrNoReceipt       = errors.New("expense has no receipt")
+)
+
+// Service records expenses and their approval
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+	Store storage.Storage // receipts, stored alongside entity files
+}
+
+// NewService initializes the expense service
+func NewService(repo *Repository, auditSvc auditlog.Service, store storage.Storage) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Store: store}
+}
+
+func validCategory(category string) bool {
+	for _, c := range Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// apply validates req and copies it onto e
+func apply(e *Expense, req ExpenseRequest) error {
+	if !validCategory(req.Category) {
+		return ErrInvalidCategory
+	}
+	date, err := time.Parse(dateLayout, req.ExpenseDate)
+	if err != nil || date.After(time.Now()) {
+		return ErrInvalidDate
+	}
+	e.Category = req.Category
+	e.Amount = req.Amount
+	e.Vendor = strings.TrimSpace(req.Vendor)
+	e.Description = strings.TrimSpace(req.Description)
+	e.ExpenseDate = date
+	return nil
+}
+
+// Create records a pending expense
+func (s *Service) Create(ctx context.Context, entityID uint, req ExpenseRequest, userID uint, ip string) (*Expense, error) {
+	e := &Expense{EntityID: entityID, Status: StatusPending, SubmittedBy: userID}
+	if err := apply(e, req); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(ctx, e); err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "EXPENSE_CREATED", map[string]interface{}{
+		"expense_id":   e.ID,
+		"category":     e.Category,
+		"amount":       e.Amount,
+		"vendor":       e.Vendor,
+		"expense_date": req.ExpenseDate,
+	}, ip, "success")
+	return e, nil
+}
+
+// Update corrects a pending expense
+func (s *Service) Update(ctx context.Context, e *Expense, req ExpenseRequest, userID uint, ip string) error {
+	if e.Status != StatusPending {
+		return ErrNotPending
+	}
+	before := map[string]interface{}{"category": e.Category, "amount": e.Amount, "vendor": e.Vendor}
+	if err := apply(e, req); err != nil {
+		return err
+	}
+	if err := s.Repo.Update(ctx, e); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &e.EntityID, "EXPENSE_UPDATED", map[string]interface{}{
+		"expense_id": e.ID,
+		"before":     before,
+		"after":      map[string]interface{}{"category": e.Category, "amount": e.Amount, "vendor": e.Vendor},
+	}, ip, "success")
+	return nil
+}
+
+// Review approves or rejects a pending expense
+func (s *Service) Review(ctx context.Context, e *Expense, approve bool, note string, userID uint, ip string) error {
+	if e.Status != StatusPending {
+		return ErrNotPending
+	}
+	now := time.Now()
+	e.Status = StatusRejected
+	action := "EXPENSE_REJECTED"
+	if approve {
+		e.Status = StatusApproved
+		action = "EXPENSE_APPROVED"
+	}
+	e.ReviewedBy, e.ReviewedAt, e.ReviewNote = &userID, &now, strings.TrimSpace(note)
+	if err := s.Repo.Update(ctx, e); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &e.EntityID, action, map[string]interface{}{
+		"expense_id": e.ID,
+		"category":   e.Category,
+		"amount":     e.Amount,
+		"note":       e.ReviewNote,
+	}, ip, "success")
+	return nil
+}
+
+// SetReceipt stores the bill of a pending expense, replacing any earlier one
+func (s *Service) SetReceipt(ctx context.Context, e *Expense, fileName string, size int64, r io.Reader, userID uint, ip string) error {
+	if e.Status != StatusPending {
+		return ErrNotPending
+	}
+	ext := strings.ToLower(filepath.Ext(fileName))
+	contentType, ok := receiptTypes[ext]
+	if !ok || size > maxReceiptSize {
+		return ErrInvalidReceipt
+	}
+	key, err := storage.Key(fmt.Sprint(e.EntityID), "expenses", fmt.Sprint(e.ID), uuid.New().String()+ext)
+	if err != nil {
+		return err
+	}
+	if err := s.Store.Put(ctx, key, r, size, contentType); err != nil {
+		return fmt.Errorf("failed to store receipt: %w", err)
+	}
+
+	previous := e.ReceiptKey
+	e.ReceiptKey, e.ReceiptName = key, fileName
+	if err := s.Repo.Update(ctx, e); err != nil {
+		_ = s.Store.Delete(ctx, key)
+		return err
+	}
+	if previous != "" {
+		_ = s.Store.Delete(ctx, previous)
+	}
+
+	s.Audit.LogAction(ctx, &userID, &e.EntityID, "EXPENSE_RECEIPT_UPLOADED", map[string]interface{}{
+		"expense_id": e.ID,
+		"file_name":  fileName,
+		"file_size":  size,
+	}, ip, "success")
+	return nil
+}
+
+// OpenReceipt returns the stored bill and its content type
+func (s *Service) OpenReceipt(ctx context.Context, e *Expense) (io.ReadCloser, *storage.ObjectInfo, string, error) {
+	if e.ReceiptKey == "" {
+		return nil, nil, "", ErrNoReceipt
+	}
+	rc, info, err := s.Store.Get(ctx, e.ReceiptKey)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return rc, info, receiptTypes[filepath.Ext(e.ReceiptKey)], nil
+}
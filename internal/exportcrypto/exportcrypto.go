@@ -0,0 +1,237 @@
+package exportcrypto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EnvelopeKind identifies an encrypted export file
+const EnvelopeKind = "tms-encrypted-export"
+
+// EnvelopeVersion is the current envelope format version
+const EnvelopeVersion = 1
+
+// AlgorithmAESGCM is the content cipher of every envelope
+const AlgorithmAESGCM = "AES-256-GCM"
+
+// Key types. Tenant keys are derived from the server master key so the
+// platform can decrypt the export again; recipient keys are RSA public keys
+// supplied by the caller and only the holder of the private key can decrypt.
+const (
+	KeyTypeTenant    = "tenant"
+	KeyTypeRecipient = "recipient"
+)
+
+var (
+	ErrNoMasterKey        = errors.New("export encryption master key is not configured")
+	ErrInvalidPublicKey   = errors.New("invalid recipient public key, expected an RSA public key in PEM format")
+	ErrNotEncrypted       = errors.New("not an encrypted export")
+	ErrKeyMismatch        = errors.New("export was encrypted with a different key")
+	ErrRecipientEncrypted = errors.New("export is encrypted for an external recipient and cannot be decrypted by the server")
+)
+
+// Envelope is an encrypted export together with the metadata needed to
+// identify (but not recover) the key it was encrypted with
+type Envelope struct {
+	Kind           string    `json:"kind"`
+	Version        int       `json:"version"`
+	Algorithm      string    `json:"algorithm"`
+	KeyType        string    `json:"key_type"`
+	TenantID       uint      `json:"tenant_id"`
+	KeyFingerprint string    `json:"key_fingerprint"`
+	WrappedKey     []byte    `json:"wrapped_key,omitempty"` // RSA-OAEP(SHA-256) wrapped content key, recipient only
+	Nonce          []byte    `json:"nonce"`
+	Ciphertext     []byte    `json:"ciphertext"`
+	ContentType    string    `json:"content_type"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// ParseMasterKey decodes EXPORT_MASTER_KEY, given as 32 bytes in hex or base64.
+// An empty value returns nil: tenant encryption is then unavailable.
+func ParseMasterKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+	if key, err := hex.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("EXPORT_MASTER_KEY must be 32 bytes encoded as hex or base64")
+}
+
+// TenantKey derives the AES-256 key of a tenant from the master key
+func TenantKey(master []byte, tenantID uint) ([]byte, error) {
+	if len(master) == 0 {
+		return nil, ErrNoMasterKey
+	}
+	mac := hmac.New(sha256.New, master)
+	fmt.Fprintf(mac, "tms-export-key:tenant:%d", tenantID)
+	return mac.Sum(nil), nil
+}
+
+// Fingerprint returns a short, non-reversible identifier of key material
+func Fingerprint(material []byte) string {
+	sum := sha256.Sum256(material)
+	return "SHA256:" + hex.EncodeToString(sum[:16])
+}
+
+// EncryptForTenant encrypts data with the tenant's derived key
+func EncryptForTenant(master []byte, tenantID uint, data []byte, contentType string) (*Envelope, error) {
+	key, err := TenantKey(master, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	env := newEnvelope(KeyTypeTenant, tenantID, Fingerprint(key), contentType)
+	if err := seal(env, key, data); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// EncryptForRecipient encrypts data with a random content key wrapped for
+// the recipient's RSA public key (PEM, PKIX or PKCS#1)
+func EncryptForRecipient(publicKeyPEM []byte, tenantID uint, data []byte, contentType string) (*Envelope, error) {
+	pub, der, err := parseRSAPublicKey(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, pub, key, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap content key: %w", err)
+	}
+
+	env := newEnvelope(KeyTypeRecipient, tenantID, Fingerprint(der), contentType)
+	env.WrappedKey = wrapped
+	if err := seal(env, key, data); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+// DecryptForTenant opens a tenant-key envelope
+func DecryptForTenant(master []byte, env *Envelope) ([]byte, error) {
+	if env == nil || env.Kind != EnvelopeKind {
+		return nil, ErrNotEncrypted
+	}
+	if env.KeyType == KeyTypeRecipient {
+		return nil, ErrRecipientEncrypted
+	}
+	if env.Version != EnvelopeVersion || env.Algorithm != AlgorithmAESGCM || env.KeyType != KeyTypeTenant {
+		return nil, fmt.Errorf("unsupported envelope (version %d, %s, %s)", env.Version, env.Algorithm, env.KeyType)
+	}
+
+	key, err := TenantKey(master, env.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if Fingerprint(key) != env.KeyFingerprint {
+		return nil, ErrKeyMismatch
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := gcm.Open(nil, env.Nonce, env.Ciphertext, additionalData(env))
+	if err != nil {
+		return nil, errors.New("failed to decrypt export: data is corrupted or was tampered with")
+	}
+	return data, nil
+}
+
+func newEnvelope(keyType string, tenantID uint, fingerprint, contentType string) *Envelope {
+	return &Envelope{
+		Kind:           EnvelopeKind,
+		Version:        EnvelopeVersion,
+		Algorithm:      AlgorithmAESGCM,
+		KeyType:        keyType,
+		TenantID:       tenantID,
+		KeyFingerprint: fingerprint,
+		ContentType:    contentType,
+		CreatedAt:      time.Now().UTC(),
+	}
+}
+
+func seal(env *Envelope, key, data []byte) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return err
+	}
+	env.Ciphertext = gcm.Seal(nil, env.Nonce, data, additionalData(env))
+	return nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the key metadata to the ciphertext so it cannot be swapped
+func additionalData(env *Envelope) []byte {
+	return []byte(fmt.Sprintf("%s|%d|%s|%d|%s", env.Kind, env.Version, env.KeyType, env.TenantID, env.KeyFingerprint))
+}
+
+func parseRSAPublicKey(data []byte) (*rsa.PublicKey, []byte, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, nil, ErrInvalidPublicKey
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, ErrInvalidPublicKey
+		}
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, nil, ErrInvalidPublicKey
+		}
+		if pub.N.BitLen() < 2048 {
+			return nil, nil, fmt.Errorf("%w: key must be at least 2048 bits", ErrInvalidPublicKey)
+		}
+		return pub, block.Bytes, nil
+	case "RSA PUBLIC KEY":
+		pub, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, ErrInvalidPublicKey
+		}
+		if pub.N.BitLen() < 2048 {
+			return nil, nil, fmt.Errorf("%w: key must be at least 2048 bits", ErrInvalidPublicKey)
+		}
+		// Fingerprint the PKIX form so both encodings of a key match
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, nil, ErrInvalidPublicKey
+		}
+		return pub, der, nil
+	}
+	return nil, nil, ErrInvalidPublicKey
+}
@@ -0,0 +1,296 @@
+package family
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/internal/seva"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the family endpoints. Every route acts on the caller's own
+// family.
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new family handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetRelationships - GET /families/relationships
+func (h *Handler) GetRelationships(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": Relationships})
+}
+
+// Create - POST /families
+func (h *Handler) Create(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req FamilyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	f, err := h.Service.Create(c.Request.Context(), user.ID, user.FullName, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to create family")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Family created", "data": f})
+}
+
+// GetMine - GET /families/mine
+func (h *Handler) GetMine(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	f, err := h.Service.Mine(c.Request.Context(), user.ID)
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch family")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": f})
+}
+
+// UpdateMine - PUT /families/mine (head only)
+func (h *Handler) UpdateMine(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req FamilyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	f, err := h.Service.Update(c.Request.Context(), user.ID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to update family")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Family updated", "data": f})
+}
+
+// Join - POST /families/join
+func (h *Handler) Join(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req JoinRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	f, err := h.Service.Join(c.Request.Context(), user.ID, user.FullName, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to join family")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Joined family", "data": f})
+}
+
+// Leave - POST /families/mine/leave
+func (h *Handler) Leave(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	if err := h.Service.Leave(c.Request.Context(), user.ID, middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to leave family")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Left family"})
+}
+
+// AddMember - POST /families/mine/members (head only)
+// Records a dependent without an account; devotees with an account join with
+// the family's join code instead.
+func (h *Handler) AddMember(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req MemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	m, err := h.Service.AddMember(c.Request.Context(), user.ID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to add family member")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Family member added", "data": m})
+}
+
+// UpdateMember - PUT /families/mine/members/:memberId (head only)
+func (h *Handler) UpdateMember(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	memberID, ok := memberParam(c)
+	if !ok {
+		return
+	}
+	var req MemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	m, err := h.Service.UpdateMember(c.Request.Context(), user.ID, memberID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to update family member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Family member updated", "data": m})
+}
+
+// RemoveMember - DELETE /families/mine/members/:memberId (head only)
+func (h *Handler) RemoveMember(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	memberID, ok := memberParam(c)
+	if !ok {
+		return
+	}
+	if err := h.Service.RemoveMember(c.Request.Context(), user.ID, memberID, middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to remove family member")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Family member removed"})
+}
+
+// TransferHead - POST /families/mine/head (head only)
+func (h *Handler) TransferHead(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req TransferHeadRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	f, err := h.Service.TransferHead(c.Request.Context(), user.ID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to transfer family head")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Family handed over", "data": f})
+}
+
+// RegenerateJoinCode - POST /families/mine/join-code (head only)
+func (h *Handler) RegenerateJoinCode(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	f, err := h.Service.RegenerateJoinCode(c.Request.Context(), user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to regenerate join code")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Join code regenerated", "join_code": f.JoinCode})
+}
+
+// BookSeva - POST /families/mine/seva-bookings
+func (h *Handler) BookSeva(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	var req BookSevaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	booking, err := h.Service.BookSeva(c.Request.Context(), user.ID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Booking failed")
+		return
+	}
+	message := "Family seva booking submitted"
+	if booking.Status == "waitlisted" {
+		message = "Seva is full, your family has been added to the waitlist"
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": message, "data": booking})
+}
+
+// ListBookings - GET /families/mine/seva-bookings?page=1&limit=20
+func (h *Handler) ListBookings(c *gin.Context) {
+	user, ok := currentUser(c)
+	if !ok {
+		return
+	}
+	page := httpx.PositiveQuery(c, "page", 1)
+	limit := min(httpx.PositiveQuery(c, "limit", 20), 100)
+	items, total, err := h.Service.ListBookings(c.Request.Context(), user.ID, page, limit)
+	if err != nil {
+		h.writeError(c, err, "Failed to fetch family bookings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusNotFound: {ErrNoFamily, ErrMemberNotFound, ErrInvalidJoinCode},
+		http.StatusBadRequest: {ErrInvalidRelationship, ErrInvalidDOB, ErrNameRequired, ErrInvalidParticipants,
+			ErrAccountMemberName, ErrNewHeadNeedsAccount},
+		http.StatusForbidden: {ErrNotHead},
+		http.StatusConflict:  {ErrAlreadyInFamily, ErrCannotRemoveHead, ErrHeadMustTransfer},
+	},
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, fallback string) {
+	var formErr *seva.FormValidationError
+	switch {
+	case errors.As(err, &formErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": formErr.Error(), "field": formErr.Field})
+	case errors.Is(err, seva.ErrSevaFull):
+		c.JSON(http.StatusConflict, gin.H{
+			"error":              err.Error(),
+			"waitlist_available": true,
+			"message":            "Resend the booking with \"join_waitlist\": true to join the waitlist",
+		})
+	default:
+		httpErrors.Write(c, err, fallback)
+	}
+}
+
+func currentUser(c *gin.Context) (auth.User, bool) {
+	user, ok := c.MustGet("user").(auth.User)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return auth.User{}, false
+	}
+	return user, true
+}
+
+func memberParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("memberId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid member ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
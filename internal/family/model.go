@@ -0,0 +1,104 @@
+package family
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RelationshipSelf is the head of the family's own membership
+const RelationshipSelf = "self"
+
+// Relationships a member may have to the head of the family
+var Relationships = []string{
+	RelationshipSelf, "spouse", "son", "daughter", "father", "mother",
+	"brother", "sister", "grandfather", "grandmother", "grandson",
+	"granddaughter", "son_in_law", "daughter_in_law", "other",
+}
+
+// Family is a household of devotees. Temples address mailings to it rather
+// than to each member.
+type Family struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	Name       string `gorm:"size:255;not null" json:"name"` // e.g. "Sharma family"
+	HeadUserID uint   `gorm:"not null;index" json:"head_user_id"`
+	JoinCode   string `gorm:"size:16;not null;uniqueIndex" json:"join_code,omitempty"` // shared by the head so members can join
+
+	// Mailing address; when empty the head's devotee profile address is used.
+	// Street and pincode are encrypted at rest (see internal/fieldcrypto).
+	StreetAddress string `gorm:"type:text;serializer:encrypted" json:"street_address,omitempty"`
+	City          string `gorm:"size:100" json:"city,omitempty"`
+	State         string `gorm:"size:100" json:"state,omitempty"`
+	Pincode       string `gorm:"type:text;serializer:encrypted" json:"pincode,omitempty"`
+	Country       string `gorm:"size:100" json:"country,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	Members []Member `gorm:"foreignKey:FamilyID" json:"members,omitempty"`
+}
+
+// TableName returns the table name for the Family model
+func (Family) TableName() string {
+	return "devotee_families"
+}
+
+// Member is one person of a family: a devotee with an account, or a
+// dependent such as a child recorded by the head
+type Member struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	FamilyID     uint       `gorm:"not null;index" json:"family_id"`
+	UserID       *uint      `gorm:"uniqueIndex" json:"user_id,omitempty"` // a devotee belongs to one family
+	Name         string     `gorm:"size:255;not null" json:"name"`
+	Relationship string     `gorm:"size:30;not null" json:"relationship"` // to the head
+	DOB          *time.Time `gorm:"type:date" json:"dob,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Member model
+func (Member) TableName() string {
+	return "devotee_family_members"
+}
+
+// FamilyRequest creates a family or edits its name and address
+type FamilyRequest struct {
+	Name          string `json:"name" binding:"required"`
+	StreetAddress string `json:"street_address"`
+	City          string `json:"city"`
+	State         string `json:"state"`
+	Pincode       string `json:"pincode"`
+	Country       string `json:"country"`
+}
+
+// MemberRequest adds or edits a dependent, or changes a member's relationship
+type MemberRequest struct {
+	Name         string `json:"name"`
+	Relationship string `json:"relationship" binding:"required"`
+	DOB          string `json:"dob"` // 2006-01-02
+}
+
+// JoinRequest joins the family whose head shared the code
+type JoinRequest struct {
+	Code         string `json:"code" binding:"required"`
+	Relationship string `json:"relationship" binding:"required"`
+}
+
+// TransferHeadRequest hands the family over to another member with an
+// account. Relationship is the outgoing head's relationship to the new one.
+type TransferHeadRequest struct {
+	MemberID     uint   `json:"member_id" binding:"required"`
+	Relationship string `json:"relationship" binding:"required"`
+}
+
+// BookSevaRequest books a seva once for the household
+type BookSevaRequest struct {
+	SevaID       uint                   `json:"seva_id" binding:"required"`
+	MemberIDs    []uint                 `json:"member_ids"` // taking part; every member when empty
+	JoinWaitlist bool                   `json:"join_waitlist"`
+	FormData     map[string]interface{} `json:"form_data"`
+}
@@ -0,0 +1,127 @@
+package family
+
+import (
+	"context"
+
+	"github.com/sharath018/temple-management-backend/internal/seva"
+	"gorm.io/gorm"
+)
+
+// Repository reads and writes families and their members
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new family repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// CreateFamily saves a new family together with the head's membership
+func (r *Repository) CreateFamily(ctx context.Context, f *Family, head *Member) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Members").Create(f).Error; err != nil {
+			return err
+		}
+		head.FamilyID = f.ID
+		return tx.Create(head).Error
+	})
+}
+
+// SaveFamily updates a family's own columns
+func (r *Repository) SaveFamily(ctx context.Context, f *Family) error {
+	return r.DB.WithContext(ctx).Omit("Members").Save(f).Error
+}
+
+// GetFamily loads a family with its members, head first
+func (r *Repository) GetFamily(ctx context.Context, id uint) (*Family, error) {
+	var f Family
+	err := r.DB.WithContext(ctx).
+		Preload("Members", func(db *gorm.DB) *gorm.DB {
+			return db.Order("CASE WHEN relationship = 'self' THEN 0 ELSE 1 END, id ASC")
+		}).
+		First(&f, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetByJoinCode loads the family a code belongs to
+func (r *Repository) GetByJoinCode(ctx context.Context, code string) (*Family, error) {
+	var f Family
+	if err := r.DB.WithContext(ctx).Where("join_code = ?", code).First(&f).Error; err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// GetMemberByUser loads the membership of a user, if they are in a family
+func (r *Repository) GetMemberByUser(ctx context.Context, userID uint) (*Member, error) {
+	var m Member
+	if err := r.DB.WithContext(ctx).Where("user_id = ?", userID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetMember loads a member of a family
+func (r *Repository) GetMember(ctx context.Context, familyID, memberID uint) (*Member, error) {
+	var m Member
+	err := r.DB.WithContext(ctx).
+		Where("id = ? AND family_id = ?", memberID, familyID).
+		First(&m).Error
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveMember creates or updates a member
+func (r *Repository) SaveMember(ctx context.Context, m *Member) error {
+	return r.DB.WithContext(ctx).Save(m).Error
+}
+
+// DeleteMember removes a member from their family
+func (r *Repository) DeleteMember(ctx context.Context, m *Member) error {
+	return r.DB.WithContext(ctx).Delete(m).Error
+}
+
+// TransferHead makes a member the head of the family; the previous head
+// keeps their membership with the relationship they are given
+func (r *Repository) TransferHead(ctx context.Context, f *Family, oldHead, newHead *Member) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(oldHead).Update("relationship", oldHead.Relationship).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(newHead).Update("relationship", RelationshipSelf).Error; err != nil {
+			return err
+		}
+		return tx.Model(f).Update("head_user_id", f.HeadUserID).Error
+	})
+}
+
+// DeleteFamily removes a family and its members once the last member leaves
+func (r *Repository) DeleteFamily(ctx context.Context, f *Family) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("family_id = ?", f.ID).Delete(&Member{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(f).Error
+	})
+}
+
+// ListBookings returns the seva bookings made for a family, newest first
+func (r *Repository) ListBookings(ctx context.Context, familyID uint, page, limit int) ([]seva.SevaBooking, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&seva.SevaBooking{}).Where("family_id = ?", familyID)
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []seva.SevaBooking
+	err := query.Order("booking_time DESC, id DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&out).Error
+	return out, total, err
+}
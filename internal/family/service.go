@@ -0,0 +1,485 @@
+package family
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/seva"
+	"gorm.io/gorm"
+)
+
+const dateLayout = "2006-01-02"
+
+var (
+	ErrAlreadyInFamily     = errors.New("you already belong to a family; leave it first")
+	ErrNoFamily            = errors.New("you do not belong to a family")
+	ErrNotHead             = errors.New("only the head of the family can do this")
+	ErrInvalidRelationship = errors.New("relationship must be one of the listed relationships, other than self")
+	ErrInvalidDOB          = errors.New("dob must be yyyy-mm-dd and not in the future")
+	ErrNameRequired        = errors.New("name is required")
+	ErrInvalidJoinCode     = errors.New("no family matches this join code")
+	ErrMemberNotFound      = errors.New("member not found in your family")
+	ErrCannotRemoveHead    = errors.New("the head cannot be removed; hand the family over first")
+	ErrHeadMustTransfer    = errors.New("hand the family over to another member with an account before leaving")
+	ErrNewHeadNeedsAccount = errors.New("the new head must be a member with their own account")
+	ErrAccountMemberName   = errors.New("the name of a member with an account comes from their profile")
+	ErrInvalidParticipants = errors.New("member_ids must be members of your family")
+)
+
+// Service manages households of devotees and their collective seva bookings
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+	Sevas seva.Service
+}
+
+// NewService initializes the family service
+func NewService(repo *Repository, auditSvc auditlog.Service, sevas seva.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Sevas: sevas}
+}
+
+func newJoinCode() (string, error) {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+func validRelationship(rel string) bool {
+	if rel == RelationshipSelf {
+		return false
+	}
+	for _, r := range Relationships {
+		if r == rel {
+			return true
+		}
+	}
+	return false
+}
+
+func parseDOB(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	dob, err := time.Parse(dateLayout, value)
+	if err != nil || dob.After(time.Now()) {
+		return nil, ErrInvalidDOB
+	}
+	return &dob, nil
+}
+
+func applyFamilyRequest(f *Family, req FamilyRequest) {
+	f.Name = strings.TrimSpace(req.Name)
+	f.StreetAddress = strings.TrimSpace(req.StreetAddress)
+	f.City = strings.TrimSpace(req.City)
+	f.State = strings.TrimSpace(req.State)
+	f.Pincode = strings.TrimSpace(req.Pincode)
+	f.Country = strings.TrimSpace(req.Country)
+}
+
+// myFamily loads the family of a user along with their own membership
+func (s *Service) myFamily(ctx context.Context, userID uint) (*Family, *Member, error) {
+	m, err := s.Repo.GetMemberByUser(ctx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrNoFamily
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := s.Repo.GetFamily(ctx, m.FamilyID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, m, nil
+}
+
+// headFamily loads the family of a user who must be its head
+func (s *Service) headFamily(ctx context.Context, userID uint) (*Family, error) {
+	f, _, err := s.myFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if f.HeadUserID != userID {
+		return nil, ErrNotHead
+	}
+	return f, nil
+}
+
+// Create starts a family with the user as its head
+func (s *Service) Create(ctx context.Context, userID uint, fullName string, req FamilyRequest, ip string) (*Family, error) {
+	if _, err := s.Repo.GetMemberByUser(ctx, userID); err == nil {
+		return nil, ErrAlreadyInFamily
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, ErrNameRequired
+	}
+
+	code, err := newJoinCode()
+	if err != nil {
+		return nil, err
+	}
+	f := &Family{HeadUserID: userID, JoinCode: code}
+	applyFamilyRequest(f, req)
+	head := &Member{UserID: &userID, Name: fullName, Relationship: RelationshipSelf}
+	if err := s.Repo.CreateFamily(ctx, f, head); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_CREATED", map[string]interface{}{
+		"family_id": f.ID,
+		"name":      f.Name,
+	}, ip, "success")
+	return s.Repo.GetFamily(ctx, f.ID)
+}
+
+// Mine returns the user's family; the join code is only shown to the head
+func (s *Service) Mine(ctx context.Context, userID uint) (*Family, error) {
+	f, _, err := s.myFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if f.HeadUserID != userID {
+		f.JoinCode = ""
+	}
+	return f, nil
+}
+
+// Update changes the family name and mailing address
+func (s *Service) Update(ctx context.Context, userID uint, req FamilyRequest, ip string) (*Family, error) {
+	f, err := s.headFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, ErrNameRequired
+	}
+	applyFamilyRequest(f, req)
+	if err := s.Repo.SaveFamily(ctx, f); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_UPDATED", map[string]interface{}{
+		"family_id": f.ID,
+		"name":      f.Name,
+	}, ip, "success")
+	return f, nil
+}
+
+// AddMember records a dependent without an account, such as a child
+func (s *Service) AddMember(ctx context.Context, userID uint, req MemberRequest, ip string) (*Member, error) {
+	f, err := s.headFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, ErrNameRequired
+	}
+	if !validRelationship(req.Relationship) {
+		return nil, ErrInvalidRelationship
+	}
+	dob, err := parseDOB(req.DOB)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Member{FamilyID: f.ID, Name: name, Relationship: req.Relationship, DOB: dob}
+	if err := s.Repo.SaveMember(ctx, m); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_MEMBER_ADDED", map[string]interface{}{
+		"family_id":    f.ID,
+		"member_id":    m.ID,
+		"relationship": m.Relationship,
+	}, ip, "success")
+	return m, nil
+}
+
+// Join adds the user to the family whose head shared the code
+func (s *Service) Join(ctx context.Context, userID uint, fullName string, req JoinRequest, ip string) (*Family, error) {
+	if _, err := s.Repo.GetMemberByUser(ctx, userID); err == nil {
+		return nil, ErrAlreadyInFamily
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if !validRelationship(req.Relationship) {
+		return nil, ErrInvalidRelationship
+	}
+	f, err := s.Repo.GetByJoinCode(ctx, strings.ToUpper(strings.TrimSpace(req.Code)))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.Audit.LogAction(ctx, &userID, nil, "FAMILY_MEMBER_JOINED", map[string]interface{}{
+			"reason": "invalid join code",
+		}, ip, "failure")
+		return nil, ErrInvalidJoinCode
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Member{FamilyID: f.ID, UserID: &userID, Name: fullName, Relationship: req.Relationship}
+	if err := s.Repo.SaveMember(ctx, m); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_MEMBER_JOINED", map[string]interface{}{
+		"family_id":    f.ID,
+		"member_id":    m.ID,
+		"relationship": m.Relationship,
+	}, ip, "success")
+	return s.Mine(ctx, userID)
+}
+
+// UpdateMember changes a member's relationship, and a dependent's name or
+// date of birth
+func (s *Service) UpdateMember(ctx context.Context, userID, memberID uint, req MemberRequest, ip string) (*Member, error) {
+	f, err := s.headFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	m, err := s.Repo.GetMember(ctx, f.ID, memberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if m.Relationship == RelationshipSelf {
+		if req.Relationship != RelationshipSelf {
+			return nil, ErrInvalidRelationship
+		}
+	} else if !validRelationship(req.Relationship) {
+		return nil, ErrInvalidRelationship
+	}
+	m.Relationship = req.Relationship
+
+	if name := strings.TrimSpace(req.Name); name != "" && name != m.Name {
+		if m.UserID != nil {
+			return nil, ErrAccountMemberName
+		}
+		m.Name = name
+	}
+	if req.DOB != "" {
+		if m.DOB, err = parseDOB(req.DOB); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Repo.SaveMember(ctx, m); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_MEMBER_UPDATED", map[string]interface{}{
+		"family_id":    f.ID,
+		"member_id":    m.ID,
+		"relationship": m.Relationship,
+	}, ip, "success")
+	return m, nil
+}
+
+// RemoveMember takes a member out of the family
+func (s *Service) RemoveMember(ctx context.Context, userID, memberID uint, ip string) error {
+	f, err := s.headFamily(ctx, userID)
+	if err != nil {
+		return err
+	}
+	m, err := s.Repo.GetMember(ctx, f.ID, memberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrMemberNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if m.Relationship == RelationshipSelf {
+		return ErrCannotRemoveHead
+	}
+	if err := s.Repo.DeleteMember(ctx, m); err != nil {
+		return err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_MEMBER_REMOVED", map[string]interface{}{
+		"family_id":      f.ID,
+		"member_id":      m.ID,
+		"member_user_id": m.UserID,
+	}, ip, "success")
+	return nil
+}
+
+// Leave takes the user out of their family. The head may only leave when no
+// other member has an account, and then the family is closed.
+func (s *Service) Leave(ctx context.Context, userID uint, ip string) error {
+	f, m, err := s.myFamily(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if f.HeadUserID != userID {
+		if err := s.Repo.DeleteMember(ctx, m); err != nil {
+			return err
+		}
+		s.Audit.LogAction(ctx, &userID, nil, "FAMILY_MEMBER_REMOVED", map[string]interface{}{
+			"family_id": f.ID,
+			"member_id": m.ID,
+			"reason":    "left the family",
+		}, ip, "success")
+		return nil
+	}
+
+	for _, other := range f.Members {
+		if other.ID != m.ID && other.UserID != nil {
+			return ErrHeadMustTransfer
+		}
+	}
+	if err := s.Repo.DeleteFamily(ctx, f); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_CLOSED", map[string]interface{}{
+		"family_id": f.ID,
+		"name":      f.Name,
+	}, ip, "success")
+	return nil
+}
+
+// TransferHead hands the family over to another member with an account
+func (s *Service) TransferHead(ctx context.Context, userID uint, req TransferHeadRequest, ip string) (*Family, error) {
+	f, err := s.headFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !validRelationship(req.Relationship) {
+		return nil, ErrInvalidRelationship
+	}
+	newHead, err := s.Repo.GetMember(ctx, f.ID, req.MemberID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrMemberNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if newHead.UserID == nil || *newHead.UserID == userID {
+		return nil, ErrNewHeadNeedsAccount
+	}
+	oldHead, err := s.Repo.GetMemberByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldHead.Relationship = req.Relationship
+	f.HeadUserID = *newHead.UserID
+	if err := s.Repo.TransferHead(ctx, f, oldHead, newHead); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_HEAD_TRANSFERRED", map[string]interface{}{
+		"family_id":    f.ID,
+		"new_head_id":  f.HeadUserID,
+		"relationship": oldHead.Relationship,
+	}, ip, "success")
+	return s.Mine(ctx, userID)
+}
+
+// RegenerateJoinCode replaces the join code, e.g. after it was shared too widely
+func (s *Service) RegenerateJoinCode(ctx context.Context, userID uint, ip string) (*Family, error) {
+	f, err := s.headFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	code, err := newJoinCode()
+	if err != nil {
+		return nil, err
+	}
+	f.JoinCode = code
+	if err := s.Repo.SaveFamily(ctx, f); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "FAMILY_JOIN_CODE_REGENERATED", map[string]interface{}{
+		"family_id": f.ID,
+	}, ip, "success")
+	return f, nil
+}
+
+// BookSeva books a seva once on behalf of the household. The booking goes
+// through the usual seva booking rules and is recorded against the user who
+// made it, with the members taking part listed on it.
+func (s *Service) BookSeva(ctx context.Context, userID uint, req BookSevaRequest, ip string) (*seva.SevaBooking, error) {
+	f, _, err := s.myFamily(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := map[uint]Member{}
+	for _, m := range f.Members {
+		byID[m.ID] = m
+	}
+	names := []string{}
+	if len(req.MemberIDs) == 0 {
+		for _, m := range f.Members {
+			names = append(names, m.Name)
+		}
+	} else {
+		seen := map[uint]bool{}
+		for _, id := range req.MemberIDs {
+			m, ok := byID[id]
+			if !ok {
+				return nil, ErrInvalidParticipants
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			names = append(names, m.Name)
+		}
+	}
+	participants, err := json.Marshal(names)
+	if err != nil {
+		return nil, err
+	}
+
+	sv, err := s.Sevas.GetSevaByID(ctx, req.SevaID)
+	if err != nil {
+		return nil, err
+	}
+	familyID := f.ID
+	booking := seva.SevaBooking{
+		SevaID:       req.SevaID,
+		UserID:       userID,
+		EntityID:     sv.EntityID,
+		BookingTime:  time.Now(),
+		Status:       "pending",
+		FamilyID:     &familyID,
+		Participants: participants,
+	}
+	if err := s.Sevas.BookSeva(ctx, &booking, "devotee", userID, sv.EntityID, req.FormData, req.JoinWaitlist, ip); err != nil {
+		return nil, err
+	}
+
+	entityID := sv.EntityID
+	s.Audit.LogAction(ctx, &userID, &entityID, "FAMILY_SEVA_BOOKED", map[string]interface{}{
+		"family_id":    f.ID,
+		"booking_id":   booking.ID,
+		"seva_id":      sv.ID,
+		"seva_name":    sv.Name,
+		"participants": len(names),
+		"status":       booking.Status,
+	}, ip, "success")
+	return &booking, nil
+}
+
+// ListBookings returns the seva bookings made for the user's family
+func (s *Service) ListBookings(ctx context.Context, userID uint, page, limit int) ([]seva.SevaBooking, int64, error) {
+	f, _, err := s.myFamily(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return s.Repo.ListBookings(ctx, f.ID, page, limit)
+}
@@ -0,0 +1,84 @@
+package fieldcrypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// IndexPrefix marks a keyed blind index. Without FIELD_BLIND_INDEX_KEY the
+// index is the normalized value itself, as plaintext as its column.
+const IndexPrefix = "bi:"
+
+var indexKey atomic.Pointer[[]byte]
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// ParseIndexKey decodes FIELD_BLIND_INDEX_KEY: 32 bytes encoded as hex or
+// base64. Unlike the encryption keys it never rotates, since stored indexes
+// are looked up by recomputing them. An empty value returns nil.
+func ParseIndexKey(value string) ([]byte, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+	return decodeKey(value)
+}
+
+// SetIndexKey installs the key of blind indexes; nil stores them unkeyed
+func SetIndexKey(key []byte) {
+	if key == nil {
+		indexKey.Store(nil)
+		return
+	}
+	indexKey.Store(&key)
+}
+
+// IndexKeyed reports whether blind indexes are keyed
+func IndexKeyed() bool {
+	return indexKey.Load() != nil
+}
+
+// BlindIndex returns the deterministic lookup value of an encrypted column:
+// an HMAC-SHA256 of the normalized plaintext, so equal values can be found
+// with = and IN without decrypting. Empty values stay empty.
+func BlindIndex(normalized string) string {
+	if normalized == "" {
+		return ""
+	}
+	key := indexKey.Load()
+	if key == nil {
+		return normalized
+	}
+	mac := hmac.New(sha256.New, *key)
+	mac.Write([]byte(normalized))
+	return IndexPrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+// NormalizePhone reduces a phone number to its last ten digits, the form
+// registration stores and contact matching compares
+func NormalizePhone(phone string) string {
+	digits := nonDigits.ReplaceAllString(phone, "")
+	if len(digits) > 10 {
+		digits = digits[len(digits)-10:]
+	}
+	return digits
+}
+
+// PhoneIndex returns the blind index of a phone number
+func PhoneIndex(phone string) string {
+	return BlindIndex(NormalizePhone(phone))
+}
+
+// PhoneIndexes returns the blind indexes of phone numbers, skipping empty ones
+func PhoneIndexes(phones []string) []string {
+	out := make([]string, 0, len(phones))
+	for _, phone := range phones {
+		if index := PhoneIndex(phone); index != "" {
+			out = append(out, index)
+		}
+	}
+	return out
+}
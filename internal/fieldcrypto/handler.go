@@ -0,0 +1,64 @@
+package fieldcrypto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+)
+
+// Handler exposes the re-encryption job to superadmins
+type Handler struct {
+	Job   *Reencryptor
+	Audit auditlog.Service
+}
+
+// NewHandler creates a new field encryption handler
+func NewHandler(job *Reencryptor, auditSvc auditlog.Service) *Handler {
+	return &Handler{Job: job, Audit: auditSvc}
+}
+
+// GetStatus - GET /superadmin/encryption
+// Returns the configured keys and the progress of the last re-encryption.
+func (h *Handler) GetStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"enabled": Enabled(), "data": h.Job.Status()})
+}
+
+// Reencrypt - POST /superadmin/encryption/reencrypt
+// Starts moving every encrypted value to the current key, e.g. after a new
+// key was put first in FIELD_ENCRYPTION_KEYS.
+func (h *Handler) Reencrypt(c *gin.Context) {
+	// Outlives the request; the job reports through GET /superadmin/encryption
+	err := h.Job.Start(context.Background())
+	switch {
+	case errors.Is(err, ErrDisabled):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	case errors.Is(err, ErrAlreadyRunning):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start re-encryption"})
+		return
+	}
+
+	// middleware depends on auth, whose users are encrypted here, so the
+	// caller is read from the context keys directly
+	userID := c.GetUint("user_id")
+	h.Audit.LogAction(c.Request.Context(), &userID, nil, "FIELD_REENCRYPTION_STARTED", map[string]interface{}{
+		"current_key": CurrentKeyID(),
+		"keys":        KeyIDs(),
+	}, clientIP(c), "success")
+	c.JSON(http.StatusAccepted, gin.H{"message": "Re-encryption started", "data": h.Job.Status()})
+}
+
+func clientIP(c *gin.Context) string {
+	if ip, ok := c.Get("client_ip"); ok {
+		if ipStr, ok := ip.(string); ok && ipStr != "" {
+			return ipStr
+		}
+	}
+	return c.ClientIP()
+}
@@ -0,0 +1,228 @@
+package fieldcrypto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// reencryptBatch is how many rows are read per query
+const reencryptBatch = 500
+
+var (
+	ErrDisabled       = errors.New("field encryption is not enabled, set FIELD_ENCRYPTION_KEYS")
+	ErrAlreadyRunning = errors.New("re-encryption is already running")
+)
+
+// Status describes the last re-encryption run
+type Status struct {
+	CurrentKey  string           `json:"current_key"`
+	Keys        []string         `json:"keys"`
+	Running     bool             `json:"running"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	FinishedAt  *time.Time       `json:"finished_at,omitempty"`
+	Scanned     int64            `json:"scanned"`     // rows read
+	Reencrypted int64            `json:"reencrypted"` // values moved to the current key
+	Failed      int64            `json:"failed"`      // values that could not be decrypted
+	Tables      map[string]int64 `json:"tables"`      // values re-encrypted by table
+	Error       string           `json:"error,omitempty"`
+}
+
+// encryptedTable is a table with the columns its model encrypts
+type encryptedTable struct {
+	Table      string
+	PrimaryKey string
+	Columns    []string
+}
+
+// Reencryptor moves every encrypted column of the given models to the current
+// key: values of older keys and plaintext written before encryption was
+// enabled. Once a run finishes without failures, older keys can be removed
+// from the configuration.
+type Reencryptor struct {
+	DB     *gorm.DB
+	models []interface{}
+
+	mu     sync.Mutex
+	status Status
+}
+
+// NewReencryptor creates a re-encryption job over the models' encrypted fields
+func NewReencryptor(db *gorm.DB, models ...interface{}) *Reencryptor {
+	return &Reencryptor{DB: db, models: models}
+}
+
+// Start runs a re-encryption in the background
+func (r *Reencryptor) Start(ctx context.Context) error {
+	if !Enabled() {
+		return ErrDisabled
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.status.Running {
+		return ErrAlreadyRunning
+	}
+	now := time.Now()
+	r.status = Status{Running: true, StartedAt: &now, Tables: map[string]int64{}}
+	go r.run(ctx)
+	return nil
+}
+
+// Status returns the progress of the current or last run
+func (r *Reencryptor) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.status
+	s.CurrentKey = CurrentKeyID()
+	s.Keys = KeyIDs()
+	s.Tables = make(map[string]int64, len(r.status.Tables))
+	for table, n := range r.status.Tables {
+		s.Tables[table] = n
+	}
+	return s
+}
+
+func (r *Reencryptor) run(ctx context.Context) {
+	var err error
+	defer func() {
+		now := time.Now()
+		r.mu.Lock()
+		r.status.Running = false
+		r.status.FinishedAt = &now
+		if err != nil {
+			r.status.Error = err.Error()
+		}
+		s := r.status
+		r.mu.Unlock()
+		if err != nil {
+			log.Printf("⚠️ Field re-encryption failed: %v", err)
+		} else {
+			log.Printf("🔐 Field re-encryption done: %d values moved to key %s, %d failed", s.Reencrypted, CurrentKeyID(), s.Failed)
+		}
+	}()
+
+	tables, err := r.tables()
+	if err != nil {
+		return
+	}
+	for _, t := range tables {
+		if err = r.reencryptTable(ctx, t); err != nil {
+			return
+		}
+	}
+}
+
+// tables finds the encrypted columns of the models
+func (r *Reencryptor) tables() ([]encryptedTable, error) {
+	var out []encryptedTable
+	for _, model := range r.models {
+		stmt := &gorm.Statement{DB: r.DB}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		if stmt.Schema.PrioritizedPrimaryField == nil {
+			return nil, fmt.Errorf("%s has no primary key", stmt.Schema.Table)
+		}
+		t := encryptedTable{Table: stmt.Schema.Table, PrimaryKey: stmt.Schema.PrioritizedPrimaryField.DBName}
+		for _, field := range stmt.Schema.Fields {
+			if strings.EqualFold(field.TagSettings["SERIALIZER"], SerializerName) && field.DBName != "" {
+				t.Columns = append(t.Columns, field.DBName)
+			}
+		}
+		if len(t.Columns) > 0 {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (r *Reencryptor) reencryptTable(ctx context.Context, t encryptedTable) error {
+	db := r.DB.WithContext(ctx)
+	columns := append([]string{t.PrimaryKey}, t.Columns...)
+	var lastID int64
+	for {
+		var rows []map[string]interface{}
+		err := db.Table(t.Table).Select(columns).
+			Where(t.PrimaryKey+" > ?", lastID).
+			Order(t.PrimaryKey).
+			Limit(reencryptBatch).
+			Find(&rows).Error
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.Table, err)
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		for _, row := range rows {
+			id, ok := toInt64(row[t.PrimaryKey])
+			if !ok {
+				return fmt.Errorf("%s: unsupported primary key %T", t.Table, row[t.PrimaryKey])
+			}
+			lastID = id
+
+			updates := map[string]interface{}{}
+			query := db.Table(t.Table).Where(t.PrimaryKey+" = ?", id)
+			var failed int64
+			for _, col := range t.Columns {
+				stored, ok := row[col].(string)
+				if !ok || !NeedsReencryption(stored) {
+					continue
+				}
+				plain, err := Decrypt(stored)
+				if err != nil {
+					failed++
+					continue
+				}
+				if updates[col], err = Encrypt(plain); err != nil {
+					return err
+				}
+				// Skips the row if it changed since it was read
+				query = query.Where(col+" = ?", stored)
+			}
+			var moved int64
+			if len(updates) > 0 {
+				res := query.UpdateColumns(updates)
+				if res.Error != nil {
+					return fmt.Errorf("%s: %w", t.Table, res.Error)
+				}
+				if res.RowsAffected > 0 {
+					moved = int64(len(updates))
+				}
+			}
+
+			r.mu.Lock()
+			r.status.Scanned++
+			r.status.Reencrypted += moved
+			r.status.Failed += failed
+			if moved > 0 {
+				r.status.Tables[t.Table] += moved
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int32:
+		return int64(n), true
+	case int:
+		return int64(n), true
+	case uint64:
+		return int64(n), true
+	case uint32:
+		return int64(n), true
+	case uint:
+		return int64(n), true
+	}
+	return 0, false
+}
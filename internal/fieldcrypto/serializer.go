@@ -0,0 +1,127 @@
+package fieldcrypto
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"gorm.io/gorm/schema"
+)
+
+// SerializerName is the gorm serializer of encrypted fields
+const SerializerName = "encrypted"
+
+func init() {
+	schema.RegisterSerializer(SerializerName, Serializer{})
+}
+
+// timeLayouts parses encrypted times, and times written before their column
+// was encrypted (cast to text by the migration)
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z07",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+// Serializer encrypts string and time.Time fields, and pointers to them.
+// A nil pointer is stored as NULL.
+type Serializer struct{}
+
+// Scan decrypts a column into the field
+func (Serializer) Scan(ctx context.Context, field *schema.Field, dst reflect.Value, dbValue interface{}) error {
+	target := reflect.New(field.IndirectFieldType).Elem()
+	var stored string
+	switch v := dbValue.(type) {
+	case nil:
+		return setField(ctx, field, dst, target, false)
+	case time.Time:
+		// A time column not migrated to text yet
+		if target.Type() != reflect.TypeOf(time.Time{}) {
+			return fmt.Errorf("fieldcrypto: cannot scan a time into %s", field.Name)
+		}
+		target.Set(reflect.ValueOf(v))
+		return setField(ctx, field, dst, target, true)
+	case []byte:
+		stored = string(v)
+	case string:
+		stored = v
+	default:
+		return fmt.Errorf("fieldcrypto: unsupported column value %T for %s", dbValue, field.Name)
+	}
+
+	plain, err := Decrypt(stored)
+	if err != nil {
+		return fmt.Errorf("%s: %w", field.Name, err)
+	}
+	switch target.Interface().(type) {
+	case string:
+		target.SetString(plain)
+	case time.Time:
+		if plain == "" {
+			return setField(ctx, field, dst, target, false)
+		}
+		t, err := parseTime(plain)
+		if err != nil {
+			return fmt.Errorf("%s: %w", field.Name, err)
+		}
+		target.Set(reflect.ValueOf(t))
+	default:
+		return fmt.Errorf("fieldcrypto: unsupported field type %s of %s", field.FieldType, field.Name)
+	}
+	return setField(ctx, field, dst, target, true)
+}
+
+// setField sets the field to target, or to nil/zero when not valid
+func setField(ctx context.Context, field *schema.Field, dst, target reflect.Value, valid bool) error {
+	fieldValue := field.ReflectValueOf(ctx, dst)
+	switch {
+	case field.FieldType.Kind() != reflect.Ptr:
+		fieldValue.Set(target)
+	case valid:
+		ptr := reflect.New(field.IndirectFieldType)
+		ptr.Elem().Set(target)
+		fieldValue.Set(ptr)
+	default:
+		fieldValue.Set(reflect.Zero(field.FieldType))
+	}
+	return nil
+}
+
+// Value encrypts the field for the column
+func (Serializer) Value(ctx context.Context, field *schema.Field, dst reflect.Value, fieldValue interface{}) (interface{}, error) {
+	rv := reflect.ValueOf(fieldValue)
+	if !rv.IsValid() {
+		return nil, nil
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+	var plain string
+	switch v := rv.Interface().(type) {
+	case string:
+		plain = v
+	case time.Time:
+		if v.IsZero() {
+			return nil, nil
+		}
+		plain = v.Format(time.RFC3339Nano)
+	default:
+		return nil, fmt.Errorf("fieldcrypto: unsupported field type %s of %s", field.FieldType, field.Name)
+	}
+	return Encrypt(plain)
+}
+
+func parseTime(value string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("fieldcrypto: invalid time %q", value)
+}
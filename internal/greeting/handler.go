@@ -0,0 +1,161 @@
+package greeting
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the greeting endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new greeting handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetSettings - GET /greetings/settings?entity_id=
+func (h *Handler) GetSettings(c *gin.Context) {
+	entityID, _, ok := h.managedEntity(c, false)
+	if !ok {
+		return
+	}
+	settings, err := h.Service.GetSettings(c.Request.Context(), entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch greeting settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// UpdateSettings - PUT /greetings/settings?entity_id=
+func (h *Handler) UpdateSettings(c *gin.Context) {
+	entityID, access, ok := h.managedEntity(c, true)
+	if !ok {
+		return
+	}
+	var req SettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	settings, err := h.Service.UpdateSettings(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to update greeting settings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Greeting settings updated", "data": settings})
+}
+
+// ListLogs - GET /greetings/log?occasion=birthday&status=sent&from=2024-01-01&to=2024-12-31&page=1&limit=20
+func (h *Handler) ListLogs(c *gin.Context) {
+	entityID, _, ok := h.managedEntity(c, false)
+	if !ok {
+		return
+	}
+
+	f := LogFilter{
+		EntityID: entityID,
+		Occasion: c.Query("occasion"),
+		Status:   c.Query("status"),
+		Page:     httpx.PositiveQuery(c, "page", 1),
+		Limit:    min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return
+		}
+		f.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return
+		}
+		to = to.AddDate(0, 0, 1)
+		f.To = &to
+	}
+
+	items, total, err := h.Service.ListLogs(c.Request.Context(), f)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch greeting log")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// GetMine - GET /greetings/me
+func (h *Handler) GetMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	optOut, err := h.Service.Repo.GetOptOut(c.Request.Context(), access.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch greeting choice"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": gin.H{"opt_out": optOut}})
+}
+
+// UpdateMine - PUT /greetings/me {"opt_out": true}
+func (h *Handler) UpdateMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		OptOut *bool `json:"opt_out" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.SetOptOut(c.Request.Context(), access.UserID, *req.OptOut, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update greeting choice")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Greeting choice updated", "data": gin.H{"opt_out": *req.OptOut}})
+}
+
+// managedEntity resolves the temple of the request and checks the caller runs
+// it; writes need write access
+func (h *Handler) managedEntity(c *gin.Context, write bool) (uint, middleware.AccessContext, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return 0, access, false
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return 0, access, false
+	}
+	allowed := access.IsEntityStaff(entityID)
+	if write {
+		allowed = access.CanManageEntity(entityID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return 0, access, false
+	}
+	return entityID, access, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: ErrNoProfile.Error(),
+	Statuses: map[int][]error{
+		http.StatusBadRequest: {ErrInvalidChannel, ErrInvalidSendHour, ErrInvalidTemplate, ErrInvalidOccasion},
+	},
+}
@@ -0,0 +1,115 @@
+package greeting
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Occasions greeted
+const (
+	OccasionBirthday    = "birthday"
+	OccasionAnniversary = "anniversary" // wedding anniversary
+)
+
+// Channels a greeting can go out on. Text goes over WhatsApp when the temple
+// has a gateway for it, otherwise SMS.
+const (
+	ChannelInApp = "inapp"
+	ChannelPush  = "push"
+	ChannelEmail = "email"
+	ChannelText  = "sms"
+)
+
+// Log statuses
+const (
+	StatusSent    = "sent"    // at least one channel delivered
+	StatusFailed  = "failed"  // every channel failed
+	StatusSkipped = "skipped" // the devotee turned off every channel for birthdays
+)
+
+var (
+	Occasions = []string{OccasionBirthday, OccasionAnniversary}
+	Channels  = []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelText}
+
+	// DefaultChannels are used until a temple picks its own
+	DefaultChannels = []string{ChannelInApp, ChannelPush}
+)
+
+// Settings is a temple's greeting configuration. Greetings are off until a
+// temple enables them.
+type Settings struct {
+	EntityID uint `gorm:"primaryKey;autoIncrement:false" json:"entity_id"`
+
+	Enabled       bool `gorm:"not null;default:false" json:"enabled"`
+	Birthdays     bool `gorm:"not null;default:true" json:"birthdays"`
+	Anniversaries bool `gorm:"not null;default:true" json:"anniversaries"`
+
+	// Notification templates of type "greeting"; nil uses the built-in wishes
+	BirthdayTemplateID    *uint `json:"birthday_template_id,omitempty"`
+	AnniversaryTemplateID *uint `json:"anniversary_template_id,omitempty"`
+
+	Channels datatypes.JSON `gorm:"type:jsonb" json:"channels"`          // subset of Channels
+	SendHour int            `gorm:"not null;default:8" json:"send_hour"` // hour of the day (IST) greetings go out from
+
+	UpdatedBy uint      `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the Settings model
+func (Settings) TableName() string {
+	return "entity_greeting_settings"
+}
+
+// SettingsRequest replaces a temple's greeting configuration
+type SettingsRequest struct {
+	Enabled               bool     `json:"enabled"`
+	Birthdays             *bool    `json:"birthdays"`
+	Anniversaries         *bool    `json:"anniversaries"`
+	BirthdayTemplateID    *uint    `json:"birthday_template_id"`
+	AnniversaryTemplateID *uint    `json:"anniversary_template_id"`
+	Channels              []string `json:"channels"`
+	SendHour              *int     `json:"send_hour"`
+}
+
+// Log records one greeting to one devotee. It is unique per temple, devotee,
+// occasion and year so nobody is wished twice.
+type Log struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	EntityID uint   `gorm:"not null;uniqueIndex:idx_greeting_once" json:"entity_id"`
+	UserID   uint   `gorm:"not null;uniqueIndex:idx_greeting_once;index" json:"user_id"`
+	Occasion string `gorm:"size:20;not null;uniqueIndex:idx_greeting_once" json:"occasion"`
+	Year     int    `gorm:"not null;uniqueIndex:idx_greeting_once" json:"year"`
+
+	Name     string  `gorm:"size:255" json:"name"`
+	Title    string  `gorm:"size:255" json:"title"`
+	Message  string  `gorm:"type:text" json:"message"`
+	Channels string  `gorm:"size:100" json:"channels"` // comma separated, those it went out on
+	Status   string  `gorm:"size:20;not null;index" json:"status"`
+	Error    *string `gorm:"type:text" json:"error,omitempty"`
+
+	SentAt time.Time `gorm:"not null;index" json:"sent_at"`
+}
+
+// TableName returns the table name for the Log model
+func (Log) TableName() string {
+	return "greeting_logs"
+}
+
+// LogFilter narrows a temple's greeting log
+type LogFilter struct {
+	EntityID uint
+	Occasion string
+	Status   string
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
+
+// Celebrant is a devotee of a temple with an occasion on the day
+type Celebrant struct {
+	UserID uint
+	Name   string
+	Date   time.Time `gorm:"serializer:encrypted"` // birth or wedding date; DOB is encrypted
+}
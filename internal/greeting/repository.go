@@ -0,0 +1,151 @@
+package greeting
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// occasionDate is the devotee profile date an occasion falls on, and the
+// expression of its MM-DD
+type occasionDate struct {
+	Column string
+	Day    string
+}
+
+// occasionDates per occasion; DOB is encrypted, so birthdays match on the
+// plaintext birth day kept next to it
+var occasionDates = map[string]occasionDate{
+	OccasionBirthday:    {Column: "p.dob", Day: "p.birth_day"},
+	OccasionAnniversary: {Column: "p.wedding_anniversary", Day: "TO_CHAR(p.wedding_anniversary, 'MM-DD')"},
+}
+
+// Repository reads and writes greeting settings and the greeting log
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new greeting repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetSettings returns a temple's settings, or nil if it never saved any
+func (r *Repository) GetSettings(ctx context.Context, entityID uint) (*Settings, error) {
+	var s Settings
+	err := r.DB.WithContext(ctx).Where("entity_id = ?", entityID).First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SaveSettings creates or replaces a temple's settings
+func (r *Repository) SaveSettings(ctx context.Context, s *Settings) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(s).Error
+}
+
+// EnabledSettings returns the settings of every temple with greetings on
+func (r *Repository) EnabledSettings(ctx context.Context) ([]Settings, error) {
+	var out []Settings
+	err := r.DB.WithContext(ctx).Where("enabled = ?", true).Find(&out).Error
+	return out, err
+}
+
+// Celebrants returns the active devotees of a temple whose occasion falls on
+// one of days (MM-DD), who haven't opted out and weren't greeted this year
+func (r *Repository) Celebrants(ctx context.Context, entityID uint, occasion string, days []string, year int) ([]Celebrant, error) {
+	date, ok := occasionDates[occasion]
+	if !ok || len(days) == 0 {
+		return nil, nil
+	}
+	var out []Celebrant
+	err := r.DB.WithContext(ctx).Table("user_entity_memberships m").
+		Select("m.user_id, COALESCE(NULLIF(p.full_name, ''), u.full_name) AS name, "+date.Column+" AS date").
+		Joins("JOIN users u ON u.id = m.user_id").
+		Joins("JOIN devotee_profiles p ON p.user_id = m.user_id AND p.deleted_at IS NULL").
+		Where("m.entity_id = ? AND m.status = ?", entityID, "active").
+		Where("p.greetings_opt_out = ?", false).
+		Where(date.Column+" IS NOT NULL AND "+date.Day+" IN ?", days).
+		Where("NOT EXISTS (SELECT 1 FROM greeting_logs g WHERE g.entity_id = m.entity_id AND g.user_id = m.user_id AND g.occasion = ? AND g.year = ?)", occasion, year).
+		Scan(&out).Error
+	return out, err
+}
+
+// CreateLog records a greeting. It returns false when the devotee was
+// already greeted for the occasion this year, e.g. by another instance.
+func (r *Repository) CreateLog(ctx context.Context, l *Log) (bool, error) {
+	res := r.DB.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(l)
+	return res.RowsAffected > 0, res.Error
+}
+
+// UpdateLog saves the outcome of a greeting
+func (r *Repository) UpdateLog(ctx context.Context, l *Log) error {
+	return r.DB.WithContext(ctx).Save(l).Error
+}
+
+// ListLogs returns a temple's greeting log, newest first
+func (r *Repository) ListLogs(ctx context.Context, f LogFilter) ([]Log, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Log{}).Where("entity_id = ?", f.EntityID)
+	if f.Occasion != "" {
+		query = query.Where("occasion = ?", f.Occasion)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.From != nil {
+		query = query.Where("sent_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("sent_at < ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Log
+	err := query.Order("sent_at DESC").
+		Offset((f.Page - 1) * f.Limit).
+		Limit(f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
+
+// GetOptOut returns whether a devotee turned greetings off
+func (r *Repository) GetOptOut(ctx context.Context, userID uint) (bool, error) {
+	var optOut []bool
+	err := r.DB.WithContext(ctx).Table("devotee_profiles").
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Pluck("greetings_opt_out", &optOut).Error
+	return len(optOut) > 0 && optOut[0], err
+}
+
+// SetOptOut turns greetings off or back on for a devotee. It returns
+// gorm.ErrRecordNotFound when the devotee has no profile yet.
+func (r *Repository) SetOptOut(ctx context.Context, userID uint, optOut bool) error {
+	res := r.DB.WithContext(ctx).Table("devotee_profiles").
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{"greetings_opt_out": optOut, "updated_at": time.Now()})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetEntityName returns the name of a temple
+func (r *Repository) GetEntityName(ctx context.Context, entityID uint) (string, error) {
+	var name string
+	err := r.DB.WithContext(ctx).Table("entities").Where("id = ?", entityID).Pluck("name", &name).Error
+	return strings.TrimSpace(name), err
+}
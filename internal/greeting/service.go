@@ -0,0 +1,404 @@
+package greeting
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+)
+
+// greetingTimezone is the day greetings follow; temples are in India
+const greetingTimezone = "Asia/Kolkata"
+
+var (
+	ErrInvalidChannel  = fmt.Errorf("channels must be among %s", strings.Join(Channels, ", "))
+	ErrInvalidSendHour = errors.New("send_hour must be between 0 and 23")
+	ErrInvalidTemplate = errors.New("template must be a greeting template of this temple")
+	ErrInvalidOccasion = fmt.Errorf("occasion must be one of %s", strings.Join(Occasions, ", "))
+	ErrNoProfile       = errors.New("complete your devotee profile first")
+)
+
+// Notifier delivers in-app and push notifications and reads templates and
+// preferences (notification.Service)
+type Notifier interface {
+	CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error
+	SendPushNotification(ctx context.Context, senderID, entityID uint, title, body string, userIDs []uint, ip string) error
+	GetPreferences(ctx context.Context, userID uint) (notification.Preferences, error)
+	GetTemplateByID(ctx context.Context, id uint, entityID uint) (*notification.NotificationTemplate, error)
+}
+
+// Service sends birthday and anniversary wishes to devotees
+type Service struct {
+	Repo      *Repository
+	Audit     auditlog.Service
+	Notifier  Notifier                // nil disables in-app and push greetings
+	Mailer    *notification.Mailer    // nil disables email greetings
+	Messenger *notification.Messenger // nil disables text greetings
+}
+
+// NewService initializes the greeting service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc}
+}
+
+func location() *time.Location {
+	loc, err := time.LoadLocation(greetingTimezone)
+	if err != nil {
+		return time.FixedZone(greetingTimezone, 5*60*60+30*60)
+	}
+	return loc
+}
+
+// defaultSettings is what a temple that never saved settings has
+func defaultSettings(entityID uint) *Settings {
+	channels, _ := json.Marshal(DefaultChannels)
+	return &Settings{EntityID: entityID, Birthdays: true, Anniversaries: true, Channels: channels, SendHour: 8}
+}
+
+// channelsOf decodes the channels of settings
+func channelsOf(s *Settings) []string {
+	var channels []string
+	if err := json.Unmarshal(s.Channels, &channels); err != nil || len(channels) == 0 {
+		return DefaultChannels
+	}
+	return channels
+}
+
+// GetSettings returns a temple's settings, with the defaults if it has none
+func (s *Service) GetSettings(ctx context.Context, entityID uint) (*Settings, error) {
+	settings, err := s.Repo.GetSettings(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		return defaultSettings(entityID), nil
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces a temple's greeting settings
+func (s *Service) UpdateSettings(ctx context.Context, entityID uint, req SettingsRequest, userID uint, ip string) (*Settings, error) {
+	settings, err := s.GetSettings(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	settings.Enabled = req.Enabled
+	if req.Birthdays != nil {
+		settings.Birthdays = *req.Birthdays
+	}
+	if req.Anniversaries != nil {
+		settings.Anniversaries = *req.Anniversaries
+	}
+	if req.SendHour != nil {
+		if *req.SendHour < 0 || *req.SendHour > 23 {
+			return nil, ErrInvalidSendHour
+		}
+		settings.SendHour = *req.SendHour
+	}
+	if req.Channels != nil {
+		seen := map[string]bool{}
+		var channels []string
+		for _, ch := range req.Channels {
+			ch = strings.ToLower(strings.TrimSpace(ch))
+			if !contains(Channels, ch) {
+				return nil, ErrInvalidChannel
+			}
+			if !seen[ch] {
+				seen[ch] = true
+				channels = append(channels, ch)
+			}
+		}
+		if len(channels) == 0 {
+			return nil, ErrInvalidChannel
+		}
+		settings.Channels, _ = json.Marshal(channels)
+	}
+	for _, id := range []*uint{req.BirthdayTemplateID, req.AnniversaryTemplateID} {
+		if id == nil {
+			continue
+		}
+		if s.Notifier == nil {
+			return nil, ErrInvalidTemplate
+		}
+		t, err := s.Notifier.GetTemplateByID(ctx, *id, entityID)
+		if err != nil || t.NotificationType != notification.NotificationTypeGreeting {
+			return nil, ErrInvalidTemplate
+		}
+	}
+	settings.BirthdayTemplateID = req.BirthdayTemplateID
+	settings.AnniversaryTemplateID = req.AnniversaryTemplateID
+	settings.UpdatedBy = userID
+	settings.UpdatedAt = time.Now()
+
+	if err := s.Repo.SaveSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &entityID, "GREETING_SETTINGS_UPDATED", map[string]interface{}{
+		"enabled":       settings.Enabled,
+		"birthdays":     settings.Birthdays,
+		"anniversaries": settings.Anniversaries,
+		"channels":      channelsOf(settings),
+		"send_hour":     settings.SendHour,
+	}, ip, "success")
+	return settings, nil
+}
+
+// SetOptOut turns a devotee's greetings off or back on, at every temple
+func (s *Service) SetOptOut(ctx context.Context, userID uint, optOut bool, ip string) error {
+	if err := s.Repo.SetOptOut(ctx, userID, optOut); err != nil {
+		return err
+	}
+	action := "GREETINGS_OPTED_IN"
+	if optOut {
+		action = "GREETINGS_OPTED_OUT"
+	}
+	s.Audit.LogAction(ctx, &userID, nil, action, nil, ip, "success")
+	return nil
+}
+
+// StartScheduler greets the day's celebrants of every temple with greetings
+// on, checking every interval until ctx is cancelled
+func (s *Service) StartScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunDue(ctx, time.Now())
+			}
+		}
+	}()
+}
+
+// RunDue does one pass of the scheduler. Temples are greeted from their send
+// hour on; the log keeps later passes of the day from greeting anyone twice.
+func (s *Service) RunDue(ctx context.Context, now time.Time) {
+	now = now.In(location())
+	settings, err := s.Repo.EnabledSettings(ctx)
+	if err != nil {
+		log.Printf("❌ Greeting sweep failed: %v", err)
+		return
+	}
+
+	for i := range settings {
+		st := &settings[i]
+		if now.Hour() < st.SendHour {
+			continue
+		}
+		for _, occasion := range Occasions {
+			if occasion == OccasionBirthday && !st.Birthdays || occasion == OccasionAnniversary && !st.Anniversaries {
+				continue
+			}
+			celebrants, err := s.Repo.Celebrants(ctx, st.EntityID, occasion, occasionDays(now), now.Year())
+			if err != nil {
+				log.Printf("❌ Finding %ss of entity %d failed: %v", occasion, st.EntityID, err)
+				continue
+			}
+			for _, c := range celebrants {
+				s.greet(ctx, st, occasion, c, now)
+			}
+		}
+	}
+}
+
+// occasionDays are the dates (MM-DD) celebrated on day: Feb 29 falls on
+// Feb 28 outside leap years
+func occasionDays(day time.Time) []string {
+	days := []string{day.Format("01-02")}
+	if day.Month() == time.February && day.Day() == 28 && !isLeap(day.Year()) {
+		days = append(days, "02-29")
+	}
+	return days
+}
+
+func isLeap(year int) bool {
+	return year%4 == 0 && (year%100 != 0 || year%400 == 0)
+}
+
+// greet sends one devotee their wishes on the temple's channels they haven't
+// turned off, and records the outcome
+func (s *Service) greet(ctx context.Context, st *Settings, occasion string, c Celebrant, now time.Time) {
+	title, message := s.compose(ctx, st, occasion, c, now)
+	entry := &Log{
+		EntityID: st.EntityID,
+		UserID:   c.UserID,
+		Occasion: occasion,
+		Year:     now.Year(),
+		Name:     c.Name,
+		Title:    title,
+		Message:  message,
+		Status:   StatusSkipped,
+		SentAt:   time.Now(),
+	}
+	created, err := s.Repo.CreateLog(ctx, entry)
+	if err != nil {
+		log.Printf("❌ Recording %s greeting of user %d failed: %v", occasion, c.UserID, err)
+		return
+	}
+	if !created {
+		return
+	}
+
+	allowed := s.allowedChannels(ctx, c.UserID, channelsOf(st))
+	var sent []string
+	var errs []string
+	for _, ch := range allowed {
+		var err error
+		switch ch {
+		case ChannelInApp:
+			if s.Notifier == nil {
+				continue
+			}
+			err = s.Notifier.CreateInAppNotification(ctx, c.UserID, st.EntityID, title, message, "birthday")
+		case ChannelPush:
+			if s.Notifier == nil {
+				continue
+			}
+			// Push is best effort, the devotee may not have registered a device
+			if err := s.Notifier.SendPushNotification(ctx, st.UpdatedBy, st.EntityID, title, message, []uint{c.UserID}, ""); err != nil {
+				continue
+			}
+		case ChannelEmail:
+			if s.Mailer == nil {
+				continue
+			}
+			err = s.Mailer.SendToUser(ctx, st.EntityID, c.UserID, notification.EmailGreeting, map[string]interface{}{
+				"Name":    c.Name,
+				"Title":   title,
+				"Message": message,
+			})
+		case ChannelText:
+			if s.Messenger == nil {
+				continue
+			}
+			err = s.Messenger.SendToUser(ctx, st.EntityID, c.UserID, notification.MessageGreeting, title+"\n"+message)
+		}
+		if err != nil {
+			errs = append(errs, ch+": "+err.Error())
+			continue
+		}
+		sent = append(sent, ch)
+	}
+
+	entry.Channels = strings.Join(sent, ",")
+	switch {
+	case len(sent) > 0:
+		entry.Status = StatusSent
+	case len(errs) > 0:
+		entry.Status = StatusFailed
+	}
+	if len(errs) > 0 {
+		msg := strings.Join(errs, "; ")
+		entry.Error = &msg
+	}
+	if err := s.Repo.UpdateLog(ctx, entry); err != nil {
+		log.Printf("⚠️ Updating greeting log %d failed: %v", entry.ID, err)
+	}
+}
+
+// allowedChannels drops the channels the devotee turned off for birthdays.
+// Text is kept unless both SMS and WhatsApp are off.
+func (s *Service) allowedChannels(ctx context.Context, userID uint, channels []string) []string {
+	if s.Notifier == nil {
+		return channels
+	}
+	prefs, err := s.Notifier.GetPreferences(ctx, userID)
+	if err != nil {
+		// Deliver rather than silently drop when preferences can't be read
+		log.Printf("⚠️ Reading notification preferences of user %d failed: %v", userID, err)
+		return channels
+	}
+	birthdays := prefs[notification.PreferenceBirthdays]
+
+	var out []string
+	for _, ch := range channels {
+		if ch == ChannelText {
+			if birthdays[notification.PreferenceChannelSMS] || birthdays[notification.PreferenceChannelWhatsApp] {
+				out = append(out, ch)
+			}
+			continue
+		}
+		if birthdays[ch] {
+			out = append(out, ch)
+		}
+	}
+	return out
+}
+
+// compose renders the temple's template for the occasion, or the built-in
+// wishes when it has none or the template fails to render
+func (s *Service) compose(ctx context.Context, st *Settings, occasion string, c Celebrant, now time.Time) (string, string) {
+	templeName, _ := s.Repo.GetEntityName(ctx, st.EntityID)
+	years := ""
+	if c.Date.Year() > 1900 && now.Year() > c.Date.Year() {
+		years = strconv.Itoa(now.Year() - c.Date.Year())
+	}
+
+	templateID := st.BirthdayTemplateID
+	if occasion == OccasionAnniversary {
+		templateID = st.AnniversaryTemplateID
+	}
+	if templateID != nil && s.Notifier != nil {
+		t, err := s.Notifier.GetTemplateByID(ctx, *templateID, st.EntityID)
+		if err == nil {
+			var rendered *notification.RenderedTemplate
+			rendered, err = notification.RenderTemplate(notification.NotificationTypeGreeting, t.Subject, t.Body, map[string]string{
+				"devotee_name":  c.Name,
+				"devotee_email": "",
+				"devotee_phone": "",
+				"temple_name":   templeName,
+				"date":          now.Format("02-01-2006"),
+				"occasion":      occasion,
+				"years":         years,
+			})
+			if err == nil {
+				return rendered.Subject, rendered.Body
+			}
+		}
+		log.Printf("⚠️ Greeting template %d of entity %d unusable, using the built-in wishes: %v", *templateID, st.EntityID, err)
+	}
+
+	from := ""
+	if templeName != "" {
+		from = " from all of us at " + templeName
+	}
+	if occasion == OccasionAnniversary {
+		return "Happy Anniversary, " + c.Name + "!",
+			"Warm wishes on your wedding anniversary" + from + ". May the divine bless your family with love and happiness."
+	}
+	return "Happy Birthday, " + c.Name + "!",
+		"Warm birthday wishes" + from + ". May the year ahead bring you good health, peace and prosperity."
+}
+
+// ListLogs pages through a temple's greeting log
+func (s *Service) ListLogs(ctx context.Context, f LogFilter) ([]Log, int64, error) {
+	if f.Occasion != "" && !contains(Occasions, f.Occasion) {
+		return nil, 0, ErrInvalidOccasion
+	}
+	return s.Repo.ListLogs(ctx, f)
+}
+
+func contains(list []string, v string) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/segmentio/kafka-go"
+	"github.com/sharath018/temple-management-backend/utils"
+	"gorm.io/gorm"
+)
+
+const checkTimeout = 2 * time.Second
+
+// draining is set once shutdown starts so load balancers stop routing new
+// requests here while in-flight ones finish
+var draining atomic.Bool
+
+var errNotInitialized = errors.New("client not initialized")
+
+// MarkDraining makes /readyz fail from now on
+func MarkDraining() {
+	draining.Store(true)
+}
+
+// Check is the result of one dependency check
+type Check struct {
+	Status  string `json:"status"` // up or down
+	Latency string `json:"latency"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Handler serves liveness and readiness probes
+type Handler struct {
+	DB *gorm.DB
+}
+
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{DB: db}
+}
+
+// Register adds GET /healthz and GET /readyz
+func (h *Handler) Register(r *gin.Engine) {
+	r.GET("/healthz", h.Live)
+	r.GET("/readyz", h.Ready)
+}
+
+// GET /healthz - the process is up; dependencies are not checked so a
+// database outage doesn't get every pod restarted
+func (h *Handler) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "OK"})
+}
+
+// GET /readyz - the database, Redis and Kafka are reachable and the server
+// is not shutting down
+func (h *Handler) Ready(c *gin.Context) {
+	if draining.Load() {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "draining"})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), checkTimeout)
+	defer cancel()
+
+	checks := map[string]Check{
+		"database": run(func() error { return h.pingDB(ctx) }),
+		"redis":    run(func() error { return pingRedis(ctx) }),
+		"kafka":    run(func() error { return pingKafka(ctx) }),
+	}
+
+	status, code := "ready", http.StatusOK
+	for _, check := range checks {
+		if check.Status != "up" {
+			status, code = "not ready", http.StatusServiceUnavailable
+			break
+		}
+	}
+	c.JSON(code, gin.H{"status": status, "checks": checks})
+}
+
+func run(check func() error) Check {
+	start := time.Now()
+	err := check()
+	result := Check{Status: "up", Latency: time.Since(start).Round(time.Millisecond).String()}
+	if err != nil {
+		result.Status = "down"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (h *Handler) pingDB(ctx context.Context) error {
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+func pingRedis(ctx context.Context) error {
+	if utils.RedisClient == nil {
+		return errNotInitialized
+	}
+	return utils.RedisClient.Ping(ctx).Err()
+}
+
+func pingKafka(ctx context.Context) error {
+	conn, err := kafka.DialContext(ctx, "tcp", utils.GetKafkaWriter().Addr.String())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Brokers()
+	return err
+}
@@ -0,0 +1,62 @@
+// Package httpx holds the small request helpers the feature handlers share
+package httpx
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// AccessContext returns the caller's access context set by AuthMiddleware. When
+// it is missing the error response is written and false returned.
+func AccessContext(c *gin.Context) (middleware.AccessContext, bool) {
+	accessVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return middleware.AccessContext{}, false
+	}
+	access, ok := accessVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid access context"})
+		return middleware.AccessContext{}, false
+	}
+	return access, true
+}
+
+// PositiveQuery returns the query parameter as a positive number, or
+// defaultValue when it is missing or not positive
+func PositiveQuery(c *gin.Context, key string, defaultValue int) int {
+	if v, err := strconv.Atoi(c.Query(key)); err == nil && v > 0 {
+		return v
+	}
+	return defaultValue
+}
+
+// ErrorMap tells how a package's errors are answered. A missing record gets
+// NotFound (when set), the errors under a status in Statuses get that status
+// with their own message, and anything else a 500 with the fallback message.
+type ErrorMap struct {
+	NotFound string
+	Statuses map[int][]error
+}
+
+// Write answers err as the map says
+func (m ErrorMap) Write(c *gin.Context, err error, fallback string) {
+	if m.NotFound != "" && errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": m.NotFound})
+		return
+	}
+	for status, errs := range m.Statuses {
+		for _, target := range errs {
+			if errors.Is(err, target) {
+				c.JSON(status, gin.H{"error": err.Error()})
+				return
+			}
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+}
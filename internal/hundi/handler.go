@@ -0,0 +1,231 @@
+package hundi
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the hundi counting endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new hundi handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// List - GET /hundi/sessions?status=pending&flagged=true&from=2025-01-01&to=2025-01-31&page=1&limit=20
+func (h *Handler) List(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	f := Filter{
+		EntityID: entityID,
+		Status:   c.Query("status"),
+		Page:     httpx.PositiveQuery(c, "page", 1),
+		Limit:    min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if f.Status != "" && f.Status != StatusPending && f.Status != StatusApproved && f.Status != StatusRejected {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be pending, approved or rejected"})
+		return
+	}
+	if v := c.Query("flagged"); v != "" {
+		flagged, err := strconv.ParseBool(v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "flagged must be true or false"})
+			return
+		}
+		f.Flagged = &flagged
+	}
+	if f.From, ok = dateQuery(c, "from"); !ok {
+		return
+	}
+	if f.To, ok = dateQuery(c, "to"); !ok {
+		return
+	}
+
+	items, total, err := h.Service.Repo.List(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hundi counts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// GetDenominations - GET /hundi/denominations
+func (h *Handler) GetDenominations(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": Denominations})
+}
+
+// Record - POST /hundi/sessions
+func (h *Handler) Record(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req SessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	s, err := h.Service.Record(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to record hundi count")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Hundi count recorded", "data": s})
+}
+
+// Get - GET /hundi/sessions/:id
+func (h *Handler) Get(c *gin.Context) {
+	_, s, ok := h.loadSession(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": s})
+}
+
+// Update - PUT /hundi/sessions/:id
+func (h *Handler) Update(c *gin.Context) {
+	access, s, ok := h.loadSession(c, true)
+	if !ok {
+		return
+	}
+	var req SessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.Update(c.Request.Context(), s, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update hundi count")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Hundi count updated", "data": s})
+}
+
+// Flag - POST /hundi/sessions/:id/flag
+func (h *Handler) Flag(c *gin.Context) {
+	access, s, ok := h.loadSession(c, true)
+	if !ok {
+		return
+	}
+	var req FlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.Flag(c.Request.Context(), s, req.Reason, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to flag hundi count")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Hundi count flagged", "data": s})
+}
+
+// Approve - POST /hundi/sessions/:id/approve (templeadmin)
+func (h *Handler) Approve(c *gin.Context) {
+	h.review(c, true)
+}
+
+// Reject - POST /hundi/sessions/:id/reject (templeadmin)
+func (h *Handler) Reject(c *gin.Context) {
+	h.review(c, false)
+}
+
+func (h *Handler) review(c *gin.Context, approve bool) {
+	access, s, ok := h.loadSession(c, true)
+	if !ok {
+		return
+	}
+	var req ReviewRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+			return
+		}
+	}
+	if err := h.Service.Review(c.Request.Context(), s, approve, req.Note, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to review hundi count")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Hundi count " + s.Status, "data": s})
+}
+
+// loadSession loads the :id counting session of a temple the caller runs;
+// writes need write access
+func (h *Handler) loadSession(c *gin.Context, write bool) (middleware.AccessContext, *Session, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid session ID"})
+		return access, nil, false
+	}
+	s, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch hundi count")
+		return access, nil, false
+	}
+	allowed := access.IsEntityStaff(s.EntityID)
+	if write {
+		allowed = access.CanManageEntity(s.EntityID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this hundi count"})
+		return access, nil, false
+	}
+	return access, s, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Hundi count not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest: {ErrInvalidDate, ErrInvalidDenomination, ErrTooFewCounters, ErrNoteRequired},
+		http.StatusConflict:   {ErrNotPending},
+	},
+}
+
+// dateQuery parses an optional yyyy-mm-dd query param
+func dateQuery(c *gin.Context, key string) (*time.Time, bool) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, true
+	}
+	d, err := time.Parse(dateLayout, v)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": key + " must be a date (yyyy-mm-dd)"})
+		return nil, false
+	}
+	return &d, true
+}
@@ -0,0 +1,91 @@
+package hundi
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Counting session statuses. A count is recorded as pending and is final once
+// a temple admin approves it.
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// Denominations are the note and coin values a count may list, in rupees
+var Denominations = []float64{2000, 500, 200, 100, 50, 20, 10, 5, 2, 1}
+
+// Session is one opening and counting of a temple's hundi
+type Session struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID      uint           `gorm:"not null;index" json:"entity_id"`
+	HundiName     string         `gorm:"size:100;not null" json:"hundi_name"` // which box, e.g. "Main shrine"
+	CountedOn     time.Time      `gorm:"type:date;not null;index" json:"counted_on"`
+	Denominations datatypes.JSON `gorm:"type:jsonb;not null" json:"denominations"`          // []DenominationCount
+	Counters      datatypes.JSON `gorm:"type:jsonb;not null" json:"counters"`               // names of those present, ["..."]
+	DeclaredTotal float64        `gorm:"type:decimal(14,2);not null" json:"declared_total"` // total the counters signed for
+	CountedTotal  float64        `gorm:"type:decimal(14,2);not null" json:"counted_total"`  // sum of the denominations
+	Discrepancy   float64        `gorm:"type:decimal(14,2);not null" json:"discrepancy"`    // declared minus counted
+	Notes         string         `gorm:"type:text" json:"notes,omitempty"`
+
+	Flagged    bool       `gorm:"not null;default:false;index" json:"flagged"`
+	FlagReason string     `gorm:"type:text" json:"flag_reason,omitempty"`
+	FlaggedBy  *uint      `json:"flagged_by,omitempty"` // nil when flagged for a mismatched total
+	FlaggedAt  *time.Time `json:"flagged_at,omitempty"`
+
+	Status     string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	RecordedBy uint       `gorm:"not null" json:"recorded_by"`
+	ReviewedBy *uint      `json:"reviewed_by,omitempty"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote string     `gorm:"type:text" json:"review_note,omitempty"`
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Session model
+func (Session) TableName() string {
+	return "hundi_counting_sessions"
+}
+
+// DenominationCount is how many notes or coins of one value were counted
+type DenominationCount struct {
+	Value float64 `json:"value" binding:"required"`
+	Count int     `json:"count" binding:"gte=0"`
+}
+
+// SessionRequest records or corrects a count
+type SessionRequest struct {
+	HundiName     string              `json:"hundi_name" binding:"required"`
+	CountedOn     string              `json:"counted_on" binding:"required"` // 2006-01-02
+	Denominations []DenominationCount `json:"denominations" binding:"required,min=1,dive"`
+	Counters      []string            `json:"counters" binding:"required"`
+	DeclaredTotal float64             `json:"declared_total" binding:"gte=0"`
+	Notes         string              `json:"notes"`
+}
+
+// FlagRequest flags a discrepancy found on review
+type FlagRequest struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// ReviewRequest approves or rejects a count
+type ReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// Filter narrows the session list
+type Filter struct {
+	EntityID uint
+	Status   string
+	Flagged  *bool
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
@@ -0,0 +1,64 @@
+package hundi
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Repository reads and writes hundi counting sessions
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new hundi repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create stores a new counting session
+func (r *Repository) Create(ctx context.Context, s *Session) error {
+	return r.DB.WithContext(ctx).Create(s).Error
+}
+
+// Update saves every field of a counting session
+func (r *Repository) Update(ctx context.Context, s *Session) error {
+	return r.DB.WithContext(ctx).Save(s).Error
+}
+
+// GetByID loads a counting session
+func (r *Repository) GetByID(ctx context.Context, id uint) (*Session, error) {
+	var s Session
+	if err := r.DB.WithContext(ctx).First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// List returns a temple's counting sessions, latest first, and how many
+// match f
+func (r *Repository) List(ctx context.Context, f Filter) ([]Session, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Session{}).Where("entity_id = ?", f.EntityID)
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+	if f.Flagged != nil {
+		query = query.Where("flagged = ?", *f.Flagged)
+	}
+	if f.From != nil {
+		query = query.Where("counted_on >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("counted_on <= ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Session
+	err := query.Order("counted_on DESC, id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
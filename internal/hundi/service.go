@@ -0,0 +1,196 @@
+package hundi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+)
+
+const (
+	dateLayout  = "2006-01-02"
+	minCounters = 2 // cash is never counted alone
+)
+
+var (
+	ErrInvalidDate         = errors.New("counted_on must be a date (yyyy-mm-dd) not in the future")
+	ErrInvalidDenomination = errors.New("denominations must be distinct note or coin values with non-negative counts")
+	ErrTooFewCounters      = fmt.Errorf("at least %d counters must be present", minCounters)
+	ErrNotPending          = errors.New("only pending counts can be changed, flagged or reviewed")
+	ErrNoteRequired        = errors.New("a note is required to approve a flagged count")
+)
+
+// Service records hundi counts and their approval
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+}
+
+// NewService initializes the hundi service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc}
+}
+
+func validDenomination(value float64) bool {
+	for _, d := range Denominations {
+		if d == value {
+			return true
+		}
+	}
+	return false
+}
+
+// apply validates req, copies it onto s and works out the totals. A count
+// whose declared total differs from its denominations is flagged, unless
+// someone already flagged it by hand.
+func apply(s *Session, req SessionRequest) error {
+	date, err := time.Parse(dateLayout, req.CountedOn)
+	if err != nil || date.After(time.Now()) {
+		return ErrInvalidDate
+	}
+
+	seen := map[float64]bool{}
+	total := 0.0
+	for _, d := range req.Denominations {
+		if !validDenomination(d.Value) || d.Count < 0 || seen[d.Value] {
+			return ErrInvalidDenomination
+		}
+		seen[d.Value] = true
+		total += d.Value * float64(d.Count)
+	}
+
+	var counters []string
+	for _, name := range req.Counters {
+		if name = strings.TrimSpace(name); name != "" {
+			counters = append(counters, name)
+		}
+	}
+	if len(counters) < minCounters {
+		return ErrTooFewCounters
+	}
+
+	denominations, err := json.Marshal(req.Denominations)
+	if err != nil {
+		return err
+	}
+	names, err := json.Marshal(counters)
+	if err != nil {
+		return err
+	}
+
+	s.HundiName = strings.TrimSpace(req.HundiName)
+	s.CountedOn = date
+	s.Denominations = denominations
+	s.Counters = names
+	s.DeclaredTotal = req.DeclaredTotal
+	s.CountedTotal = total
+	s.Discrepancy = math.Round((req.DeclaredTotal-total)*100) / 100
+	s.Notes = strings.TrimSpace(req.Notes)
+
+	if s.FlaggedBy == nil {
+		s.Flagged = s.Discrepancy != 0
+		s.FlagReason, s.FlaggedAt = "", nil
+		if s.Flagged {
+			now := time.Now()
+			s.FlagReason = fmt.Sprintf("declared total %.2f differs from the counted %.2f by %.2f", req.DeclaredTotal, total, s.Discrepancy)
+			s.FlaggedAt = &now
+		}
+	}
+	return nil
+}
+
+// Record stores a pending counting session
+func (s *Service) Record(ctx context.Context, entityID uint, req SessionRequest, userID uint, ip string) (*Session, error) {
+	session := &Session{EntityID: entityID, Status: StatusPending, RecordedBy: userID}
+	if err := apply(session, req); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "HUNDI_COUNT_RECORDED", map[string]interface{}{
+		"session_id":     session.ID,
+		"hundi_name":     session.HundiName,
+		"counted_on":     req.CountedOn,
+		"counted_total":  session.CountedTotal,
+		"declared_total": session.DeclaredTotal,
+		"discrepancy":    session.Discrepancy,
+		"counters":       len(req.Counters),
+	}, ip, "success")
+	return session, nil
+}
+
+// Update corrects a pending count, e.g. after a recount
+func (s *Service) Update(ctx context.Context, session *Session, req SessionRequest, userID uint, ip string) error {
+	if session.Status != StatusPending {
+		return ErrNotPending
+	}
+	before := map[string]interface{}{"counted_total": session.CountedTotal, "declared_total": session.DeclaredTotal}
+	if err := apply(session, req); err != nil {
+		return err
+	}
+	if err := s.Repo.Update(ctx, session); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &session.EntityID, "HUNDI_COUNT_UPDATED", map[string]interface{}{
+		"session_id": session.ID,
+		"before":     before,
+		"after":      map[string]interface{}{"counted_total": session.CountedTotal, "declared_total": session.DeclaredTotal},
+		"flagged":    session.Flagged,
+	}, ip, "success")
+	return nil
+}
+
+// Flag marks a pending count as having a discrepancy found on review
+func (s *Service) Flag(ctx context.Context, session *Session, reason string, userID uint, ip string) error {
+	if session.Status != StatusPending {
+		return ErrNotPending
+	}
+	now := time.Now()
+	session.Flagged, session.FlagReason, session.FlaggedBy, session.FlaggedAt = true, strings.TrimSpace(reason), &userID, &now
+	if err := s.Repo.Update(ctx, session); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &session.EntityID, "HUNDI_COUNT_FLAGGED", map[string]interface{}{
+		"session_id": session.ID,
+		"reason":     session.FlagReason,
+	}, ip, "success")
+	return nil
+}
+
+// Review approves or rejects a pending count. Approving a flagged count
+// needs a note saying how the discrepancy was settled.
+func (s *Service) Review(ctx context.Context, session *Session, approve bool, note string, userID uint, ip string) error {
+	if session.Status != StatusPending {
+		return ErrNotPending
+	}
+	note = strings.TrimSpace(note)
+	if approve && session.Flagged && note == "" {
+		return ErrNoteRequired
+	}
+
+	now := time.Now()
+	session.Status = StatusRejected
+	action := "HUNDI_COUNT_REJECTED"
+	if approve {
+		session.Status = StatusApproved
+		action = "HUNDI_COUNT_APPROVED"
+	}
+	session.ReviewedBy, session.ReviewedAt, session.ReviewNote = &userID, &now, note
+	if err := s.Repo.Update(ctx, session); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &session.EntityID, action, map[string]interface{}{
+		"session_id":    session.ID,
+		"counted_total": session.CountedTotal,
+		"discrepancy":   session.Discrepancy,
+		"flagged":       session.Flagged,
+		"note":          note,
+	}, ip, "success")
+	return nil
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// scriptFonts names the Noto Sans font family covering each language's
+// script; the files are <family>-Regular.ttf and, optionally, <family>-Bold.ttf
+var scriptFonts = map[string]string{
+	"hi": "NotoSansDevanagari",
+	"kn": "NotoSansKannada",
+	"ta": "NotoSansTamil",
+	"te": "NotoSansTelugu",
+}
+
+// latinFont covers Latin text beyond Latin-1, such as the rupee sign
+const latinFont = "NotoSans"
+
+var (
+	fontDirectory string
+
+	fontMu    sync.Mutex
+	fontCache = map[string][]byte{}
+)
+
+// CanRenderPDF reports whether PDFs can show text in lang: Latin script
+// always, Indic scripts when their font is installed
+func CanRenderPDF(lang string) bool {
+	family, ok := scriptFonts[lang]
+	if !ok {
+		return true
+	}
+	return fontFile(family, "") != nil
+}
+
+// fontFile returns the TrueType data of a font family in style "" or "B",
+// or nil when it isn't installed. Misses are cached too, and logged once.
+func fontFile(family, style string) []byte {
+	file := family + "-Regular.ttf"
+	if style == "B" {
+		file = family + "-Bold.ttf"
+	}
+	if fontDirectory == "" {
+		return nil
+	}
+
+	fontMu.Lock()
+	defer fontMu.Unlock()
+	if data, ok := fontCache[file]; ok {
+		return data
+	}
+	data, err := os.ReadFile(filepath.Join(fontDirectory, file))
+	if err != nil {
+		if style == "" {
+			log.Printf("⚠️ i18n: font %s unavailable, PDFs fall back to Arial: %v", file, err)
+		}
+		data = nil
+	}
+	fontCache[file] = data
+	return data
+}
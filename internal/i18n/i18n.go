@@ -0,0 +1,173 @@
+// Package i18n translates API error messages and report headers into the
+// languages devotees and temple staff work in. Catalogs are keyed by the
+// English text, so untranslated messages simply stay in English.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// English is the language messages and headers are written in
+const English = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+var (
+	defaultLanguage = English
+
+	loadOnce sync.Once
+	catalogs map[string]map[string]string // language -> lower-cased English text -> translation
+)
+
+// Init sets the language used when neither the user nor the request picks a
+// supported one, and the directory the PDF fonts are read from
+func Init(defaultLang, fontDir string) {
+	if lang := Match(defaultLang); lang != "" {
+		defaultLanguage = lang
+	}
+	fontDirectory = fontDir
+}
+
+// Match returns the supported language for a code such as "kn" or "ta-IN",
+// or "" when there is none
+func Match(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	base, _, _ := strings.Cut(code, "-")
+	if base == English {
+		return English
+	}
+	load()
+	if _, ok := catalogs[base]; ok {
+		return base
+	}
+	return ""
+}
+
+// Negotiate picks the supported language the Accept-Language header ranks
+// highest, or "" when it names none
+func Negotiate(header string) string {
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		code, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if lang := Match(code); lang != "" && q > bestQ {
+			best, bestQ = lang, q
+		}
+	}
+	return best
+}
+
+// T translates an English message. Messages with details after a colon,
+// such as "Invalid input: EOF", are matched on the part before it.
+func T(lang, msg string) string {
+	if lang == "" || lang == English || msg == "" {
+		return msg
+	}
+	load()
+	catalog := catalogs[lang]
+	if catalog == nil {
+		return msg
+	}
+	if out, ok := catalog[strings.ToLower(msg)]; ok {
+		return out
+	}
+	if prefix, detail, ok := strings.Cut(msg, ": "); ok {
+		if out, ok := catalog[strings.ToLower(prefix)]; ok {
+			return out + ": " + detail
+		}
+	}
+	return msg
+}
+
+func load() {
+	loadOnce.Do(func() {
+		catalogs = map[string]map[string]string{}
+		entries, err := localeFiles.ReadDir("locales")
+		if err != nil {
+			log.Printf("⚠️ i18n: could not read catalogs: %v", err)
+			return
+		}
+		for _, entry := range entries {
+			lang := strings.TrimSuffix(entry.Name(), ".json")
+			raw, err := localeFiles.ReadFile("locales/" + entry.Name())
+			if err != nil {
+				log.Printf("⚠️ i18n: could not read %s catalog: %v", lang, err)
+				continue
+			}
+			catalog, err := parseCatalog(raw)
+			if err != nil {
+				log.Printf("⚠️ i18n: invalid %s catalog: %v", lang, err)
+				continue
+			}
+			catalogs[lang] = catalog
+		}
+	})
+}
+
+func parseCatalog(raw []byte) (map[string]string, error) {
+	var messages map[string]string
+	if err := json.Unmarshal(raw, &messages); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+	catalog := make(map[string]string, len(messages))
+	for en, translated := range messages {
+		catalog[strings.ToLower(en)] = translated
+	}
+	return catalog, nil
+}
+
+// ===== REQUEST LANGUAGE =====
+
+type contextKey struct{}
+
+// languageSource resolves a request's language on first use, so requests that
+// never translate anything don't look up the user's preference
+type languageSource struct {
+	once    sync.Once
+	lang    string
+	resolve func() string
+}
+
+func (s *languageSource) get() string {
+	s.once.Do(func() {
+		if s.resolve != nil {
+			s.lang = s.resolve()
+		}
+		if s.lang == "" {
+			s.lang = defaultLanguage
+		}
+	})
+	return s.lang
+}
+
+// WithLanguage returns a context whose language is lang, for work done
+// outside a request such as queued report jobs
+func WithLanguage(ctx context.Context, lang string) context.Context {
+	return context.WithValue(ctx, contextKey{}, &languageSource{lang: Match(lang)})
+}
+
+// FromContext returns the language of the request ctx belongs to, or the
+// default language
+func FromContext(ctx context.Context) string {
+	if ctx != nil {
+		if src, ok := ctx.Value(contextKey{}).(*languageSource); ok {
+			return src.get()
+		}
+	}
+	return defaultLanguage
+}
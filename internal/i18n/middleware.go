@@ -0,0 +1,126 @@
+package i18n
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Middleware negotiates the request language and translates the "error"
+// message of JSON error responses into it. A signed-in user's preferred
+// language wins, then the Accept-Language header, then the default. The
+// preference is only looked up when something is translated.
+func Middleware(db *gorm.DB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		src := &languageSource{resolve: func() string {
+			if lang := userPreference(c, db); lang != "" {
+				return lang
+			}
+			return Negotiate(c.GetHeader("Accept-Language"))
+		}}
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), contextKey{}, src))
+		c.Header("Vary", "Accept-Language")
+
+		w := &translateWriter{ResponseWriter: c.Writer, lang: src.get}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// userPreference is the preferred language on the signed-in user's devotee
+// profile, if they set a supported one
+func userPreference(c *gin.Context, db *gorm.DB) string {
+	userID, ok := c.Get("user_id")
+	if !ok || db == nil {
+		return ""
+	}
+	var preferred string
+	err := db.WithContext(c.Request.Context()).Table("devotee_profiles").
+		Select("preferred_language").
+		Where("user_id = ? AND preferred_language IS NOT NULL AND preferred_language <> ''", userID).
+		Order("id ASC").
+		Limit(1).
+		Scan(&preferred).Error
+	if err != nil {
+		return ""
+	}
+	return Match(preferred)
+}
+
+// translateWriter holds back JSON error bodies until the handler is done so
+// their message can be translated; everything else passes straight through
+type translateWriter struct {
+	gin.ResponseWriter
+	lang      func() string
+	buf       bytes.Buffer
+	buffering bool
+	decided   bool
+}
+
+func (w *translateWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	w.buffering = w.ResponseWriter.Status() >= http.StatusBadRequest &&
+		strings.HasPrefix(w.Header().Get("Content-Type"), "application/json")
+}
+
+func (w *translateWriter) Write(data []byte) (int, error) {
+	w.decide()
+	if w.buffering {
+		return w.buf.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *translateWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *translateWriter) Flush() {
+	if !w.buffering {
+		w.ResponseWriter.Flush()
+	}
+}
+
+// Size includes the held back body, so handlers and middleware see the
+// response as written
+func (w *translateWriter) Size() int {
+	if w.buffering {
+		return w.buf.Len()
+	}
+	return w.ResponseWriter.Size()
+}
+
+func (w *translateWriter) Written() bool {
+	return w.buffering || w.ResponseWriter.Written()
+}
+
+func (w *translateWriter) finish() {
+	if !w.buffering {
+		return
+	}
+	body := w.buf.Bytes()
+	if lang := w.lang(); lang != English {
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err == nil {
+			if msg, ok := payload["error"].(string); ok {
+				if translated := T(lang, msg); translated != msg {
+					payload["error"] = translated
+					if out, err := json.Marshal(payload); err == nil {
+						body = out
+					}
+				}
+			}
+		}
+		w.Header().Set("Content-Language", lang)
+	}
+	w.ResponseWriter.Write(body)
+}
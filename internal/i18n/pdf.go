@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"github.com/jung-kurt/gofpdf"
+)
+
+// PDF is an A4 gofpdf document whose text methods pick a font able to show
+// the text: Arial for plain ASCII, the Noto Sans font of the script for
+// Devanagari, Kannada, Tamil and Telugu, and Noto Sans for other non-ASCII
+// Latin text. Without installed fonts text falls back to Arial in cp1252.
+// gofpdf doesn't shape text, so conjuncts show as their component glyphs.
+type PDF struct {
+	*gofpdf.Fpdf
+
+	family, style string // font picked by SetFont, used for ASCII text
+	size          float64
+	registered    map[string]bool // UTF-8 families added to the document
+	cp1252        func(string) string
+}
+
+// NewPDF starts an A4 document in orientation "P" or "L"
+func NewPDF(orientation string) *PDF {
+	pdf := gofpdf.New(orientation, "mm", "A4", "")
+	return &PDF{
+		Fpdf:       pdf,
+		family:     "Arial",
+		size:       12,
+		registered: map[string]bool{},
+		cp1252:     pdf.UnicodeTranslatorFromDescriptor(""),
+	}
+}
+
+func (p *PDF) SetFont(family, style string, size float64) {
+	p.family, p.style, p.size = family, style, size
+	p.Fpdf.SetFont(family, style, size)
+}
+
+func (p *PDF) Cell(w, h float64, txt string) {
+	p.Fpdf.Cell(w, h, p.prepare(txt))
+}
+
+func (p *PDF) CellFormat(w, h float64, txt, border string, ln int, align string, fill bool, link int, linkStr string) {
+	p.Fpdf.CellFormat(w, h, p.prepare(txt), border, ln, align, fill, link, linkStr)
+}
+
+func (p *PDF) MultiCell(w, h float64, txt, border, align string, fill bool) {
+	p.Fpdf.MultiCell(w, h, p.prepare(txt), border, align, fill)
+}
+
+// prepare selects the font for txt and returns txt encoded for it
+func (p *PDF) prepare(txt string) string {
+	if isASCII(txt) {
+		p.Fpdf.SetFont(p.family, p.style, p.size)
+		return txt
+	}
+	family := latinFont
+	if lang := scriptOf(txt); lang != "" {
+		family = scriptFonts[lang]
+	}
+	if p.useUTF8(family) {
+		return txt
+	}
+	p.Fpdf.SetFont(p.family, p.style, p.size)
+	return p.cp1252(txt)
+}
+
+// useUTF8 adds the font family to the document on first use and sets it,
+// bold when the current style is and a bold face is installed
+func (p *PDF) useUTF8(family string) bool {
+	if !p.registered[family] {
+		regular := fontFile(family, "")
+		if regular == nil {
+			return false
+		}
+		p.Fpdf.AddUTF8FontFromBytes(family, "", regular)
+		bold := fontFile(family, "B")
+		if bold == nil {
+			bold = regular
+		}
+		p.Fpdf.AddUTF8FontFromBytes(family, "B", bold)
+		p.registered[family] = true
+	}
+	style := ""
+	for _, s := range p.style {
+		if s == 'B' || s == 'b' {
+			style = "B"
+		}
+	}
+	p.Fpdf.SetFont(family, style, p.size)
+	return true
+}
+
+// scriptOf returns the language whose script the first Indic letter of s is
+// in, or "" for text without one
+func scriptOf(s string) string {
+	for _, r := range s {
+		switch {
+		case r >= 0x0900 && r <= 0x097F:
+			return "hi"
+		case r >= 0x0B80 && r <= 0x0BFF:
+			return "ta"
+		case r >= 0x0C00 && r <= 0x0C7F:
+			return "te"
+		case r >= 0x0C80 && r <= 0x0CFF:
+			return "kn"
+		}
+	}
+	return ""
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
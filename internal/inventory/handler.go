@@ -0,0 +1,245 @@
+package inventory
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the inventory endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new inventory handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ListItems - GET /inventory/items?kind=&category=&q=&low_stock=true&page=1&limit=20
+func (h *Handler) ListItems(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	f := ItemFilter{
+		EntityID: entityID,
+		Kind:     c.Query("kind"),
+		Category: strings.ToLower(c.Query("category")),
+		Search:   strings.TrimSpace(c.Query("q")),
+		LowStock: c.Query("low_stock") == "true",
+		Page:     httpx.PositiveQuery(c, "page", 1),
+		Limit:    min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if f.Kind != "" && f.Kind != KindConsumable && f.Kind != KindAsset {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidKind.Error()})
+		return
+	}
+
+	items, total, err := h.Service.Repo.ListItems(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch inventory"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// GetUnits - GET /inventory/units
+func (h *Handler) GetUnits(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": Units})
+}
+
+// CreateItem - POST /inventory/items
+func (h *Handler) CreateItem(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	item, err := h.Service.CreateItem(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to create item")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Item created", "data": item})
+}
+
+// GetItem - GET /inventory/items/:id
+func (h *Handler) GetItem(c *gin.Context) {
+	_, item, ok := h.loadItem(c, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": item})
+}
+
+// UpdateItem - PUT /inventory/items/:id
+func (h *Handler) UpdateItem(c *gin.Context) {
+	access, item, ok := h.loadItem(c, true)
+	if !ok {
+		return
+	}
+	var req ItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.UpdateItem(c.Request.Context(), item, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update item")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Item updated", "data": item})
+}
+
+// RecordMovement - POST /inventory/items/:id/movements
+func (h *Handler) RecordMovement(c *gin.Context) {
+	access, item, ok := h.loadItem(c, true)
+	if !ok {
+		return
+	}
+	var req MovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	updated, movement, err := h.Service.RecordMovement(c.Request.Context(), item, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to record stock movement")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Stock updated",
+		"data":      movement,
+		"item":      updated,
+		"low_stock": updated.LowStock(),
+	})
+}
+
+// ListItemMovements - GET /inventory/items/:id/movements?type=&page=1&limit=20
+func (h *Handler) ListItemMovements(c *gin.Context) {
+	_, item, ok := h.loadItem(c, false)
+	if !ok {
+		return
+	}
+	h.listMovements(c, MovementFilter{EntityID: item.EntityID, ItemID: item.ID})
+}
+
+// ListMovements - GET /inventory/movements?seva_id=&event_id=&type=&page=1&limit=20
+// What the temple's sevas and events consumed, or every stock movement.
+func (h *Handler) ListMovements(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	f := MovementFilter{EntityID: entityID}
+	for key, target := range map[string]*uint{"seva_id": &f.SevaID, "event_id": &f.EventID} {
+		if v := c.Query(key); v != "" {
+			id, err := strconv.ParseUint(v, 10, 64)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + key})
+				return
+			}
+			*target = uint(id)
+		}
+	}
+	h.listMovements(c, f)
+}
+
+func (h *Handler) listMovements(c *gin.Context, f MovementFilter) {
+	f.Type = c.Query("type")
+	f.Page = httpx.PositiveQuery(c, "page", 1)
+	f.Limit = min(httpx.PositiveQuery(c, "limit", 20), 100)
+	if f.Type != "" && f.Type != MovementPurchase && f.Type != MovementConsumption && f.Type != MovementAdjustment {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidMovement.Error()})
+		return
+	}
+
+	items, total, err := h.Service.Repo.ListMovements(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock movements"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// loadItem loads the :id item of a temple the caller runs; writes need write
+// access
+func (h *Handler) loadItem(c *gin.Context, write bool) (middleware.AccessContext, *Item, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid item ID"})
+		return access, nil, false
+	}
+	item, err := h.Service.Repo.GetItem(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch item")
+		return access, nil, false
+	}
+	allowed := access.IsEntityStaff(item.EntityID)
+	if write {
+		allowed = access.CanManageEntity(item.EntityID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this item"})
+		return access, nil, false
+	}
+	return access, item, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Item not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest: {ErrInvalidKind, ErrInvalidUnit, ErrInvalidMovement, ErrInvalidQuantity, ErrInvalidLink,
+			ErrOpeningCostRequired},
+		http.StatusConflict: {ErrInsufficientStock, ErrItemInactive},
+	},
+}
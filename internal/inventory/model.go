@@ -0,0 +1,124 @@
+package inventory
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Item kinds: consumables are used up by sevas and events, assets are kept
+const (
+	KindConsumable = "consumable"
+	KindAsset      = "asset"
+)
+
+// Movement types
+const (
+	MovementPurchase    = "purchase"
+	MovementConsumption = "consumption"
+	MovementAdjustment  = "adjustment" // stock count correction, may be negative
+)
+
+// Units stock may be kept in
+var Units = []string{"piece", "kg", "g", "litre", "ml", "packet", "box", "bundle", "metre"}
+
+// Item is a pooja material or temple asset and its stock
+type Item struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID     uint    `gorm:"not null;index" json:"entity_id"`
+	Name         string  `gorm:"size:255;not null" json:"name"`
+	Kind         string  `gorm:"size:20;not null;default:'consumable'" json:"kind"`
+	Category     string  `gorm:"size:50;index" json:"category"` // e.g. flowers, oil, vessels
+	Unit         string  `gorm:"size:20;not null" json:"unit"`
+	Quantity     float64 `gorm:"type:decimal(12,3);not null;default:0" json:"quantity"`
+	ReorderLevel float64 `gorm:"type:decimal(12,3);not null;default:0" json:"reorder_level"` // 0 = never low
+	UnitCost     float64 `gorm:"type:decimal(12,2);not null;default:0" json:"unit_cost"`     // weighted average of purchases, INR
+	Location     string  `gorm:"size:255" json:"location,omitempty"`
+	IsActive     bool    `gorm:"not null;default:true" json:"is_active"`
+
+	LowStockNotifiedAt *time.Time `json:"low_stock_notified_at,omitempty"` // cleared when restocked
+
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Item model
+func (Item) TableName() string {
+	return "inventory_items"
+}
+
+// LowStock reports whether the item is at or below its reorder level
+func (i Item) LowStock() bool {
+	return i.ReorderLevel > 0 && i.Quantity <= i.ReorderLevel
+}
+
+// Movement is one change to an item's stock
+type Movement struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	ItemID     uint    `gorm:"not null;index" json:"item_id"`
+	EntityID   uint    `gorm:"not null;index" json:"entity_id"`
+	Type       string  `gorm:"size:20;not null;index" json:"type"`
+	Quantity   float64 `gorm:"type:decimal(12,3);not null" json:"quantity"`  // signed change to the stock
+	UnitCost   float64 `gorm:"type:decimal(12,2);not null" json:"unit_cost"` // purchase price, or the average cost when used
+	SevaID     *uint   `gorm:"index" json:"seva_id,omitempty"`
+	EventID    *uint   `gorm:"index" json:"event_id,omitempty"`
+	Note       string  `gorm:"type:text" json:"note,omitempty"`
+	RecordedBy uint    `gorm:"not null" json:"recorded_by"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+// TableName returns the table name for the Movement model
+func (Movement) TableName() string {
+	return "inventory_movements"
+}
+
+// ItemRequest creates or edits an item
+type ItemRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	Kind         string  `json:"kind"` // consumable when empty
+	Category     string  `json:"category"`
+	Unit         string  `json:"unit" binding:"required"`
+	ReorderLevel float64 `json:"reorder_level" binding:"gte=0"`
+	Location     string  `json:"location"`
+	IsActive     *bool   `json:"is_active"`
+
+	// Opening stock, only read when the item is created
+	OpeningQuantity float64 `json:"opening_quantity" binding:"gte=0"`
+	OpeningUnitCost float64 `json:"opening_unit_cost" binding:"gte=0"`
+}
+
+// MovementRequest records a purchase, consumption or adjustment
+type MovementRequest struct {
+	Type     string  `json:"type" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required"` // positive, except for adjustments
+	UnitCost float64 `json:"unit_cost" binding:"gte=0"`   // purchases only
+	SevaID   *uint   `json:"seva_id"`                     // consumption only
+	EventID  *uint   `json:"event_id"`                    // consumption only
+	Note     string  `json:"note"`
+}
+
+// ItemFilter narrows the item list
+type ItemFilter struct {
+	EntityID uint
+	Kind     string
+	Category string
+	Search   string
+	LowStock bool
+	Page     int
+	Limit    int
+}
+
+// MovementFilter narrows the movement list
+type MovementFilter struct {
+	EntityID uint
+	ItemID   uint
+	SevaID   uint
+	EventID  uint
+	Type     string
+	Page     int
+	Limit    int
+}
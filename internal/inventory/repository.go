@@ -0,0 +1,158 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Repository reads and writes inventory items and their movements
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new inventory repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// CreateItem stores a new item, with its opening stock movement if any
+func (r *Repository) CreateItem(ctx context.Context, item *Item, opening *Movement) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+		if opening == nil {
+			return nil
+		}
+		opening.ItemID = item.ID
+		return tx.Create(opening).Error
+	})
+}
+
+// UpdateItem saves an item's details; its stock and unit cost are left to
+// ApplyMovement
+func (r *Repository) UpdateItem(ctx context.Context, item *Item) error {
+	return r.DB.WithContext(ctx).Model(item).
+		Select("name", "kind", "category", "unit", "reorder_level", "location", "is_active", "low_stock_notified_at").
+		Updates(item).Error
+}
+
+// MarkLowStockNotified records the low stock notification of an item, false
+// when another request already did
+func (r *Repository) MarkLowStockNotified(ctx context.Context, itemID uint, at time.Time) (bool, error) {
+	res := r.DB.WithContext(ctx).Model(&Item{}).
+		Where("id = ? AND low_stock_notified_at IS NULL", itemID).
+		Update("low_stock_notified_at", at)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetItem loads an item
+func (r *Repository) GetItem(ctx context.Context, id uint) (*Item, error) {
+	var item Item
+	if err := r.DB.WithContext(ctx).First(&item, id).Error; err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// ListItems returns a temple's items by name and how many match f
+func (r *Repository) ListItems(ctx context.Context, f ItemFilter) ([]Item, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Item{}).Where("entity_id = ?", f.EntityID)
+	if f.Kind != "" {
+		query = query.Where("kind = ?", f.Kind)
+	}
+	if f.Category != "" {
+		query = query.Where("category = ?", f.Category)
+	}
+	if f.Search != "" {
+		query = query.Where("name ILIKE ?", "%"+f.Search+"%")
+	}
+	if f.LowStock {
+		query = query.Where("reorder_level > 0 AND quantity <= reorder_level")
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Item
+	err := query.Order("name ASC, id ASC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
+
+// ListMovements returns stock movements, latest first, and how many match f
+func (r *Repository) ListMovements(ctx context.Context, f MovementFilter) ([]Movement, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Movement{}).Where("entity_id = ?", f.EntityID)
+	if f.ItemID != 0 {
+		query = query.Where("item_id = ?", f.ItemID)
+	}
+	if f.SevaID != 0 {
+		query = query.Where("seva_id = ?", f.SevaID)
+	}
+	if f.EventID != 0 {
+		query = query.Where("event_id = ?", f.EventID)
+	}
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Movement
+	err := query.Order("created_at DESC, id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
+
+// ApplyMovement locks the item, lets apply change its stock and describe the
+// movement, then saves both. An error from apply rolls everything back.
+func (r *Repository) ApplyMovement(ctx context.Context, itemID uint, apply func(item *Item) (*Movement, error)) (*Item, *Movement, error) {
+	var item Item
+	var movement *Movement
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, itemID).Error; err != nil {
+			return err
+		}
+		m, err := apply(&item)
+		if err != nil {
+			return err
+		}
+		if err := tx.Save(&item).Error; err != nil {
+			return err
+		}
+		movement = m
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &item, movement, nil
+}
+
+// BelongsToEntity reports whether the seva or event (table sevas or events)
+// is hosted by the temple
+func (r *Repository) BelongsToEntity(ctx context.Context, table string, id, entityID uint) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Table(table).
+		Where("id = ? AND entity_id = ?", id, entityID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// EntityOwner returns the temple admin who created the temple
+func (r *Repository) EntityOwner(ctx context.Context, entityID uint) (uint, error) {
+	var createdBy uint
+	err := r.DB.WithContext(ctx).Table("entities").
+		Select("created_by").
+		Where("id = ?", entityID).
+		Take(&createdBy).Error
+	return createdBy, err
+}
@@ -0,0 +1,275 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+)
+
+var (
+	ErrInvalidKind         = errors.New("kind must be consumable or asset")
+	ErrInvalidUnit         = fmt.Errorf("unit must be one of %s", strings.Join(Units, ", "))
+	ErrInvalidMovement     = errors.New("type must be purchase, consumption or adjustment")
+	ErrInvalidQuantity     = errors.New("quantity must be positive, or non-zero for an adjustment")
+	ErrInsufficientStock   = errors.New("not enough stock for this movement")
+	ErrInvalidLink         = errors.New("seva_id and event_id apply to consumption only, and must belong to the temple")
+	ErrItemInactive        = errors.New("item is no longer in use")
+	ErrOpeningCostRequired = errors.New("opening_unit_cost needs an opening_quantity")
+)
+
+// Notifier delivers in-app notifications (notification.Service)
+type Notifier interface {
+	CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error
+}
+
+// Service keeps stock of a temple's items
+type Service struct {
+	Repo     *Repository
+	Audit    auditlog.Service
+	Notifier Notifier // nil disables low stock notifications
+}
+
+// NewService initializes the inventory service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc}
+}
+
+func validUnit(unit string) bool {
+	for _, u := range Units {
+		if u == unit {
+			return true
+		}
+	}
+	return false
+}
+
+// apply validates req and copies it onto item
+func apply(item *Item, req ItemRequest) error {
+	kind := req.Kind
+	if kind == "" {
+		kind = KindConsumable
+	}
+	if kind != KindConsumable && kind != KindAsset {
+		return ErrInvalidKind
+	}
+	if !validUnit(req.Unit) {
+		return ErrInvalidUnit
+	}
+	item.Name = strings.TrimSpace(req.Name)
+	item.Kind = kind
+	item.Category = strings.ToLower(strings.TrimSpace(req.Category))
+	item.Unit = req.Unit
+	item.ReorderLevel = req.ReorderLevel
+	item.Location = strings.TrimSpace(req.Location)
+	if req.IsActive != nil {
+		item.IsActive = *req.IsActive
+	}
+	return nil
+}
+
+// CreateItem adds an item with its opening stock
+func (s *Service) CreateItem(ctx context.Context, entityID uint, req ItemRequest, userID uint, ip string) (*Item, error) {
+	item := &Item{EntityID: entityID, IsActive: true}
+	if err := apply(item, req); err != nil {
+		return nil, err
+	}
+	if req.OpeningUnitCost > 0 && req.OpeningQuantity == 0 {
+		return nil, ErrOpeningCostRequired
+	}
+
+	var opening *Movement
+	if req.OpeningQuantity > 0 {
+		item.Quantity, item.UnitCost = req.OpeningQuantity, req.OpeningUnitCost
+		opening = &Movement{
+			EntityID:   entityID,
+			Type:       MovementAdjustment,
+			Quantity:   req.OpeningQuantity,
+			UnitCost:   req.OpeningUnitCost,
+			Note:       "Opening stock",
+			RecordedBy: userID,
+		}
+	}
+	if err := s.Repo.CreateItem(ctx, item, opening); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &entityID, "INVENTORY_ITEM_CREATED", map[string]interface{}{
+		"item_id":       item.ID,
+		"name":          item.Name,
+		"kind":          item.Kind,
+		"unit":          item.Unit,
+		"quantity":      item.Quantity,
+		"reorder_level": item.ReorderLevel,
+	}, ip, "success")
+	return item, nil
+}
+
+// UpdateItem edits an item's details. Stock only changes through movements.
+func (s *Service) UpdateItem(ctx context.Context, item *Item, req ItemRequest, userID uint, ip string) error {
+	before := map[string]interface{}{"name": item.Name, "unit": item.Unit, "reorder_level": item.ReorderLevel, "is_active": item.IsActive}
+	if err := apply(item, req); err != nil {
+		return err
+	}
+	if !item.LowStock() {
+		item.LowStockNotifiedAt = nil
+	}
+	if err := s.Repo.UpdateItem(ctx, item); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &item.EntityID, "INVENTORY_ITEM_UPDATED", map[string]interface{}{
+		"item_id": item.ID,
+		"before":  before,
+		"after":   map[string]interface{}{"name": item.Name, "unit": item.Unit, "reorder_level": item.ReorderLevel, "is_active": item.IsActive},
+	}, ip, "success")
+	s.checkLowStock(ctx, item)
+	return nil
+}
+
+// RecordMovement changes an item's stock. Purchases move the item's unit cost
+// to the weighted average; consumption and adjustments are valued at it.
+func (s *Service) RecordMovement(ctx context.Context, item *Item, req MovementRequest, userID uint, ip string) (*Item, *Movement, error) {
+	switch req.Type {
+	case MovementPurchase, MovementConsumption:
+		if req.Quantity <= 0 {
+			return nil, nil, ErrInvalidQuantity
+		}
+	case MovementAdjustment:
+		if req.Quantity == 0 {
+			return nil, nil, ErrInvalidQuantity
+		}
+	default:
+		return nil, nil, ErrInvalidMovement
+	}
+	if !item.IsActive && req.Type != MovementAdjustment {
+		return nil, nil, ErrItemInactive
+	}
+	if err := s.checkLinks(ctx, item.EntityID, req); err != nil {
+		return nil, nil, err
+	}
+
+	updated, movement, err := s.Repo.ApplyMovement(ctx, item.ID, func(locked *Item) (*Movement, error) {
+		m := &Movement{
+			ItemID:     locked.ID,
+			EntityID:   locked.EntityID,
+			Type:       req.Type,
+			Quantity:   req.Quantity,
+			UnitCost:   locked.UnitCost,
+			SevaID:     req.SevaID,
+			EventID:    req.EventID,
+			Note:       strings.TrimSpace(req.Note),
+			RecordedBy: userID,
+		}
+		switch req.Type {
+		case MovementPurchase:
+			m.UnitCost = req.UnitCost
+			if total := locked.Quantity + req.Quantity; locked.Quantity > 0 {
+				locked.UnitCost = math.Round((locked.Quantity*locked.UnitCost+req.Quantity*req.UnitCost)/total*100) / 100
+			} else {
+				locked.UnitCost = req.UnitCost
+			}
+		case MovementConsumption:
+			m.Quantity = -req.Quantity
+		}
+		if locked.Quantity+m.Quantity < 0 {
+			return nil, ErrInsufficientStock
+		}
+		locked.Quantity = math.Round((locked.Quantity+m.Quantity)*1000) / 1000
+		if !locked.LowStock() {
+			locked.LowStockNotifiedAt = nil
+		}
+		return m, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	action := map[string]string{
+		MovementPurchase:    "INVENTORY_STOCK_PURCHASED",
+		MovementConsumption: "INVENTORY_STOCK_CONSUMED",
+		MovementAdjustment:  "INVENTORY_STOCK_ADJUSTED",
+	}[req.Type]
+	details := map[string]interface{}{
+		"item_id":     updated.ID,
+		"movement_id": movement.ID,
+		"quantity":    movement.Quantity,
+		"unit_cost":   movement.UnitCost,
+		"stock":       updated.Quantity,
+	}
+	if req.SevaID != nil {
+		details["seva_id"] = *req.SevaID
+	}
+	if req.EventID != nil {
+		details["event_id"] = *req.EventID
+	}
+	s.Audit.LogAction(ctx, &userID, &updated.EntityID, action, details, ip, "success")
+
+	s.checkLowStock(ctx, updated)
+	return updated, movement, nil
+}
+
+// checkLinks makes sure only consumption names a seva or event, and that it
+// is one of the temple's
+func (s *Service) checkLinks(ctx context.Context, entityID uint, req MovementRequest) error {
+	if req.SevaID == nil && req.EventID == nil {
+		return nil
+	}
+	if req.Type != MovementConsumption {
+		return ErrInvalidLink
+	}
+	for table, id := range map[string]*uint{"sevas": req.SevaID, "events": req.EventID} {
+		if id == nil {
+			continue
+		}
+		ok, err := s.Repo.BelongsToEntity(ctx, table, *id, entityID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidLink
+		}
+	}
+	return nil
+}
+
+// checkLowStock tells the temple admin once when an item falls to its
+// reorder level; restocking above it re-arms the notification
+func (s *Service) checkLowStock(ctx context.Context, item *Item) {
+	if !item.LowStock() || item.LowStockNotifiedAt != nil || !item.IsActive {
+		return
+	}
+	now := time.Now()
+	marked, err := s.Repo.MarkLowStockNotified(ctx, item.ID, now)
+	if err != nil {
+		log.Printf("⚠️ Failed to mark item %d as low on stock: %v", item.ID, err)
+		return
+	}
+	if !marked {
+		return
+	}
+	item.LowStockNotifiedAt = &now
+	s.Audit.LogAction(ctx, nil, &item.EntityID, "INVENTORY_LOW_STOCK", map[string]interface{}{
+		"item_id":       item.ID,
+		"name":          item.Name,
+		"quantity":      item.Quantity,
+		"reorder_level": item.ReorderLevel,
+	}, "", "success")
+
+	if s.Notifier == nil {
+		return
+	}
+	ownerID, err := s.Repo.EntityOwner(ctx, item.EntityID)
+	if err != nil || ownerID == 0 {
+		return
+	}
+	title := "Low stock: " + item.Name
+	message := fmt.Sprintf("%s is down to %g %s (reorder level %g %s).", item.Name, item.Quantity, item.Unit, item.ReorderLevel, item.Unit)
+	if err := s.Notifier.CreateInAppNotification(ctx, ownerID, item.EntityID, title, message, "inventory"); err != nil {
+		log.Printf("⚠️ Low stock notification for item %d failed: %v", item.ID, err)
+	}
+}
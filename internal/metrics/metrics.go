@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Latency buckets in seconds, from 5ms to ~82s
+var durationBuckets = ExponentialBuckets(0.005, 2, 15)
+
+var (
+	HTTPRequests = NewCounterVec("tms_http_requests_total",
+		"HTTP requests by route template, method and status code.",
+		"method", "route", "status")
+
+	HTTPRequestDuration = NewHistogramVec("tms_http_request_duration_seconds",
+		"HTTP request latency by route template and method.",
+		durationBuckets, "method", "route")
+
+	ReportExportDuration = NewHistogramVec("tms_report_export_duration_seconds",
+		"Time spent rendering a report export file.",
+		durationBuckets, "report", "format", "status")
+
+	KafkaConsumerLag = NewGaugeVec("tms_kafka_consumer_lag_messages",
+		"Messages behind the partition high water mark after the last read.",
+		"topic", "partition")
+
+	FCMSendFailures = NewCounterVec("tms_fcm_send_failures_total",
+		"Push notifications FCM did not deliver, per device token or topic.",
+		"kind")
+
+	UploadSize = NewHistogramVec("tms_upload_size_bytes",
+		"Size of uploaded files.",
+		ExponentialBuckets(16<<10, 4, 8), "kind") // 16KB .. 256MB
+
+	HTTPResponseSize = NewHistogramVec("tms_http_response_size_bytes",
+		"Uncompressed response body size by route template and method.",
+		ExponentialBuckets(1<<10, 4, 8), "method", "route") // 1KB .. 16MB
+
+	HTTPResponsesOverBudget = NewCounterVec("tms_http_responses_over_budget_total",
+		"Responses larger than the route's payload budget; candidates for pagination.",
+		"method", "route")
+)
+
+// FCM failure kinds
+const (
+	FCMKindToken = "token"
+	FCMKindTopic = "topic"
+)
+
+// Middleware records the count, latency and response size of every request
+// by route template, so /entities/12 and /entities/13 share one series.
+// budgets maps route templates ("default" for the rest) to the payload size
+// above which a response counts as over budget.
+func Middleware(budgets map[string]int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		method := c.Request.Method
+		HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		HTTPRequestDuration.Observe(time.Since(start).Seconds(), method, route)
+
+		// Size reports the uncompressed payload, also behind middleware.Compress
+		size := c.Writer.Size()
+		if size < 0 {
+			return
+		}
+		HTTPResponseSize.Observe(float64(size), method, route)
+		budget, ok := budgets[route]
+		if !ok {
+			budget = budgets["default"]
+		}
+		if budget > 0 && int64(size) > budget {
+			HTTPResponsesOverBudget.Inc(method, route)
+		}
+	}
+}
+
+// Handler serves GET /metrics. With a token, scrapers must send it as a
+// bearer token.
+func Handler(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token != "" {
+			got := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				c.AbortWithStatus(http.StatusUnauthorized)
+				return
+			}
+		}
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		_ = WriteText(c.Writer)
+	}
+}
+
+// ObserveSince records the seconds elapsed since start
+func ObserveSince(h *HistogramVec, start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
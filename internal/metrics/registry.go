@@ -0,0 +1,225 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// A small Prometheus registry writing the text exposition format (0.0.4).
+// It covers the counters, gauges and histograms this service exports.
+
+const (
+	kindCounter   = "counter"
+	kindGauge     = "gauge"
+	kindHistogram = "histogram"
+)
+
+type series struct {
+	labelValues []string
+	value       float64  // counter and gauge value
+	buckets     []uint64 // histogram: observations per bucket (not cumulative)
+	sum         float64  // histogram
+	count       uint64   // histogram
+}
+
+type family struct {
+	name    string
+	help    string
+	kind    string
+	labels  []string
+	bounds  []float64 // histogram upper bounds, ascending
+	mu      sync.Mutex
+	entries map[string]*series
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = map[string]*family{}
+)
+
+func register(name, help, kind string, bounds []float64, labels []string) *family {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, exists := registry[name]; exists {
+		panic("metrics: duplicate metric " + name)
+	}
+	f := &family{name: name, help: help, kind: kind, labels: labels, bounds: bounds, entries: map[string]*series{}}
+	registry[name] = f
+	return f
+}
+
+// with returns the series of the label values, creating it on first use.
+// Must be called with f.mu held.
+func (f *family) with(labelValues []string) *series {
+	if len(labelValues) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d labels, got %d", f.name, len(f.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	s, ok := f.entries[key]
+	if !ok {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		if f.kind == kindHistogram {
+			s.buckets = make([]uint64, len(f.bounds))
+		}
+		f.entries[key] = s
+	}
+	return s
+}
+
+// CounterVec is a monotonically increasing value per label set
+type CounterVec struct{ f *family }
+
+// NewCounterVec registers a counter
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return &CounterVec{register(name, help, kindCounter, nil, labels)}
+}
+
+// Inc adds one
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v, which must not be negative
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		return
+	}
+	c.f.mu.Lock()
+	c.f.with(labelValues).value += v
+	c.f.mu.Unlock()
+}
+
+// GaugeVec is a value per label set that can go up and down
+type GaugeVec struct{ f *family }
+
+// NewGaugeVec registers a gauge
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return &GaugeVec{register(name, help, kindGauge, nil, labels)}
+}
+
+// Set sets the gauge
+func (g *GaugeVec) Set(v float64, labelValues ...string) {
+	g.f.mu.Lock()
+	g.f.with(labelValues).value = v
+	g.f.mu.Unlock()
+}
+
+// HistogramVec counts observations into buckets per label set
+type HistogramVec struct{ f *family }
+
+// NewHistogramVec registers a histogram with the given ascending bucket bounds
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	return &HistogramVec{register(name, help, kindHistogram, bounds, labels)}
+}
+
+// Observe records one observation
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.with(labelValues)
+	if i := sort.SearchFloat64s(h.f.bounds, v); i < len(h.f.bounds) {
+		s.buckets[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+// ExponentialBuckets returns count bounds starting at start, each factor times the previous
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	b := make([]float64, count)
+	for i := range b {
+		b[i] = start
+		start *= factor
+	}
+	return b
+}
+
+// WriteText writes every registered metric in the Prometheus text format
+func WriteText(w io.Writer) error {
+	registryMu.Lock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	registryMu.Unlock()
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		registryMu.Lock()
+		f := registry[name]
+		registryMu.Unlock()
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+func (f *family) write(w *bufio.Writer) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.entries))
+	for k := range f.entries {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		s := f.entries[k]
+		if f.kind != kindHistogram {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, labelString(f.labels, s.labelValues, "", ""), formatFloat(s.value))
+			continue
+		}
+		var cumulative uint64
+		for i, bound := range f.bounds {
+			cumulative += s.buckets[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelString(f.labels, s.labelValues, "le", formatFloat(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, labelString(f.labels, s.labelValues, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, labelString(f.labels, s.labelValues, "", ""), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, labelString(f.labels, s.labelValues, "", ""), s.count)
+	}
+}
+
+func labelString(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+1)
+	for i, n := range names {
+		parts = append(parts, n+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		parts = append(parts, extraName+`="`+extraValue+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(v string) string { return labelEscaper.Replace(v) }
+func escapeHelp(v string) string  { return helpEscaper.Replace(v) }
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,358 @@
+package migration
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// Handler exposes the legacy register migration
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new migration handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetFields - GET /entities/:id/migrations/fields?kind=devotees
+// Lists the system fields a register's columns can be mapped to.
+func (h *Handler) GetFields(c *gin.Context) {
+	if _, _, ok := h.authorize(c, false); !ok {
+		return
+	}
+	kind := c.Query("kind")
+	if kind == "" {
+		c.JSON(http.StatusOK, gin.H{"fields": Fields})
+		return
+	}
+	fields, ok := Fields[kind]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrUnsupportedKind.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"kind": kind, "fields": fields})
+}
+
+// ListBatches - GET /entities/:id/migrations
+func (h *Handler) ListBatches(c *gin.Context) {
+	_, entityID, ok := h.authorize(c, false)
+	if !ok {
+		return
+	}
+	batches, err := h.Service.List(entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch migrations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"batches": batches})
+}
+
+// StageBatch - POST /entities/:id/migrations
+// Multipart fields: "file" (CSV or XLSX), "kind" (devotees, donations or
+// bookings), optional "sheet" and optional "mapping" as a JSON object of
+// system field -> column name. Without a mapping one is suggested from the
+// column names. The batch is validated right away when the mapping is complete.
+func (h *Handler) StageBatch(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV or XLSX file is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "migration")
+	if fileHeader.Size > MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file too large (max %dMB)", MaxFileSize>>20)})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of field to column name"})
+			return
+		}
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer f.Close()
+
+	report, err := h.Service.Stage(c.Request.Context(), entityID, c.PostForm("kind"), fileHeader.Filename,
+		c.PostForm("sheet"), f, mapping, user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, report)
+}
+
+// ImportDonations - POST /entities/:id/donations/import?all_or_nothing=false
+// Multipart fields: "file" (CSV, or XLSX) and optional "mapping" as for
+// StageBatch. Past donations are validated and imported in one go: rows
+// repeating another row of the file fail, and rows already recorded for the
+// temple are skipped. By default a single row with an error imports
+// nothing; with all_or_nothing=false the valid rows are imported. Responds
+// with the migration report of the batch, which can be rolled back through
+// /migrations/:batchId/rollback.
+func (h *Handler) ImportDonations(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+	allOrNothing, err := strconv.ParseBool(c.DefaultQuery("all_or_nothing", "true"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "all_or_nothing must be true or false"})
+		return
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "migration")
+	if fileHeader.Size > MaxFileSize {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": fmt.Sprintf("file too large (max %dMB)", MaxFileSize>>20)})
+		return
+	}
+
+	var mapping map[string]string
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mapping must be a JSON object of field to column name"})
+			return
+		}
+	}
+
+	f, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer f.Close()
+
+	report, err := h.Service.ImportDonations(c.Request.Context(), entityID, fileHeader.Filename, f, mapping,
+		allOrNothing, user.ID, middleware.GetIPFromContext(c))
+	var mappingErr *MappingError
+	switch {
+	case err == nil:
+		c.JSON(http.StatusCreated, report)
+	case report == nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &mappingErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid column mapping", "problems": mappingErr.Problems, "report": report})
+	case errors.Is(err, ErrImportHasErrors), errors.Is(err, ErrBatchHasErrors), errors.Is(err, ErrNothingToImport):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error(), "report": report})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to import donations", "details": err.Error(), "report": report})
+	}
+}
+
+// GetBatch - GET /entities/:id/migrations/:batchId
+// Returns the migration summary report of a batch.
+func (h *Handler) GetBatch(c *gin.Context) {
+	_, entityID, batchID, ok := h.batch(c, false)
+	if !ok {
+		return
+	}
+	report, err := h.Service.Report(c.Request.Context(), entityID, batchID)
+	if err != nil {
+		respondError(c, err, "Failed to load migration")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// ListRows - GET /entities/:id/migrations/:batchId/rows?status=error&page=1&limit=50
+func (h *Handler) ListRows(c *gin.Context) {
+	_, entityID, batchID, ok := h.batch(c, false)
+	if !ok {
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	rows, total, err := h.Service.Rows(entityID, batchID, c.Query("status"), page, limit)
+	if err != nil {
+		respondError(c, err, "Failed to fetch rows")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"rows": rows, "total": total, "page": page, "limit": limit})
+}
+
+// DownloadReport - GET /entities/:id/migrations/:batchId/report
+// Downloads every row with its status, error and mapped values as CSV.
+func (h *Handler) DownloadReport(c *gin.Context) {
+	_, entityID, batchID, ok := h.batch(c, false)
+	if !ok {
+		return
+	}
+	var buf bytes.Buffer
+	if err := h.Service.WriteReportCSV(&buf, entityID, batchID); err != nil {
+		respondError(c, err, "Failed to build report")
+		return
+	}
+	filename := fmt.Sprintf("migration_%d_report.csv", batchID)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	c.Data(http.StatusOK, "text/csv", buf.Bytes())
+}
+
+// UpdateMapping - PUT /entities/:id/migrations/:batchId/mapping
+// Body: {"mapping": {"full_name": "Name", ...}}. Validates the batch again.
+func (h *Handler) UpdateMapping(c *gin.Context) {
+	user, entityID, batchID, ok := h.batch(c, true)
+	if !ok {
+		return
+	}
+	var req struct {
+		Mapping map[string]string `json:"mapping" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	report, err := h.Service.Validate(c.Request.Context(), entityID, batchID, req.Mapping, user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		respondError(c, err, "Failed to validate migration")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CommitBatch - POST /entities/:id/migrations/:batchId/commit?skip_errors=true
+// Imports the valid rows in one transaction; rows with errors are left out
+// only when skip_errors is set.
+func (h *Handler) CommitBatch(c *gin.Context) {
+	user, entityID, batchID, ok := h.batch(c, true)
+	if !ok {
+		return
+	}
+	skipErrors, _ := strconv.ParseBool(c.DefaultQuery("skip_errors", "false"))
+
+	report, err := h.Service.Commit(c.Request.Context(), entityID, batchID, skipErrors, user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		respondError(c, err, "Failed to commit migration")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RollbackBatch - POST /entities/:id/migrations/:batchId/rollback
+func (h *Handler) RollbackBatch(c *gin.Context) {
+	user, entityID, batchID, ok := h.batch(c, true)
+	if !ok {
+		return
+	}
+	report, err := h.Service.Rollback(c.Request.Context(), entityID, batchID, user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		respondError(c, err, "Failed to roll back migration")
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+func respondError(c *gin.Context, err error, message string) {
+	var mappingErr *MappingError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Migration batch not found"})
+	case errors.As(err, &mappingErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid column mapping", "problems": mappingErr.Problems})
+	case errors.Is(err, ErrBatchState), errors.Is(err, ErrBatchHasErrors), errors.Is(err, ErrNothingToImport),
+		errors.Is(err, ErrRollbackBlocked):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message, "details": err.Error()})
+	}
+}
+
+// batch authorizes the entity and parses the batch ID
+func (h *Handler) batch(c *gin.Context, write bool) (auth.User, uint, uint, bool) {
+	user, entityID, ok := h.authorize(c, write)
+	if !ok {
+		return auth.User{}, 0, 0, false
+	}
+	id, err := strconv.ParseUint(c.Param("batchId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid batch ID"})
+		return auth.User{}, 0, 0, false
+	}
+	return user, entityID, uint(id), true
+}
+
+// authorize checks the caller manages the temple in the :id path param
+func (h *Handler) authorize(c *gin.Context, write bool) (auth.User, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return auth.User{}, 0, false
+	}
+	entityID := uint(id)
+
+	userVal, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return auth.User{}, 0, false
+	}
+	user, ok := userVal.(auth.User)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user object"})
+		return auth.User{}, 0, false
+	}
+
+	accessVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return auth.User{}, 0, false
+	}
+	accessCtx, ok := accessVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return auth.User{}, 0, false
+	}
+
+	e, err := h.Service.Repo.GetEntity(entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
+		return auth.User{}, 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temple"})
+		return auth.User{}, 0, false
+	}
+
+	if !accessCtx.IsEntityStaff(e.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this entity"})
+		return auth.User{}, 0, false
+	}
+	if write && !accessCtx.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient write permissions"})
+		return auth.User{}, 0, false
+	}
+	return user, entityID, true
+}
@@ -0,0 +1,156 @@
+package migration
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Kinds of legacy register a batch imports
+const (
+	KindDevotees  = "devotees"
+	KindDonations = "donations"
+	KindBookings  = "bookings"
+)
+
+// Batch statuses. A batch is staged when its file is parsed, validated once
+// a column mapping has been checked against the data, then committed and
+// possibly rolled back.
+const (
+	StatusStaged     = "staged"
+	StatusValidated  = "validated"
+	StatusCommitted  = "committed"
+	StatusRolledBack = "rolled_back"
+)
+
+// Row statuses
+const (
+	RowPending    = "pending" // not validated yet
+	RowValid      = "valid"
+	RowError      = "error"
+	RowImported   = "imported"
+	RowSkipped    = "skipped" // already in the system, nothing written
+	RowRolledBack = "rolled_back"
+	RowKept       = "kept" // rolled back batch, but the devotee is in use elsewhere
+)
+
+// Row actions, planned at validation and recorded at commit for rollback
+const (
+	ActionCreate = "create"
+	ActionLink   = "link" // existing devotee joins the temple
+	ActionSkip   = "skip"
+)
+
+// Batch is one uploaded register and its migration state
+type Batch struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID uint   `gorm:"not null;index" json:"entity_id"`
+	Kind     string `gorm:"size:20;not null" json:"kind"`
+	FileName string `gorm:"size:255" json:"file_name"`
+	Sheet    string `gorm:"size:100" json:"sheet,omitempty"`
+
+	Headers datatypes.JSON `gorm:"type:jsonb" json:"headers"` // column names in file order
+	Mapping datatypes.JSON `gorm:"type:jsonb" json:"mapping"` // system field -> column name
+
+	Status         string `gorm:"size:20;not null;index" json:"status"`
+	TotalRows      int    `json:"total_rows"`
+	ValidRows      int    `json:"valid_rows"`
+	ErrorRows      int    `json:"error_rows"`
+	ImportedRows   int    `json:"imported_rows"`
+	SkippedRows    int    `json:"skipped_rows"`
+	RolledBackRows int    `json:"rolled_back_rows"`
+	Error          string `gorm:"type:text" json:"error,omitempty"` // why the last commit or rollback failed
+
+	CreatedBy    uint       `gorm:"not null" json:"created_by"`
+	ValidatedAt  *time.Time `json:"validated_at,omitempty"`
+	CommittedAt  *time.Time `json:"committed_at,omitempty"`
+	CommittedBy  *uint      `json:"committed_by,omitempty"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+	RolledBackBy *uint      `json:"rolled_back_by,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for the Batch model
+func (Batch) TableName() string {
+	return "migration_batches"
+}
+
+// Row is one line of a register. Raw keeps the cells as uploaded so the
+// mapping can be changed and validated again; Data holds the mapped,
+// normalized values.
+type Row struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	BatchID   uint           `gorm:"not null;index" json:"batch_id"`
+	RowNumber int            `gorm:"not null" json:"row"` // spreadsheet row, header = 1
+	Raw       datatypes.JSON `gorm:"type:jsonb" json:"raw"`
+	Data      datatypes.JSON `gorm:"type:jsonb" json:"data,omitempty"`
+
+	Status   string `gorm:"size:20;not null;index" json:"status"`
+	Error    string `gorm:"type:text" json:"error,omitempty"`
+	Action   string `gorm:"size:20" json:"action,omitempty"`
+	RecordID *uint  `json:"record_id,omitempty"` // devotee, donation or booking written by the row
+}
+
+// TableName returns the table name for the Row model
+func (Row) TableName() string {
+	return "migration_rows"
+}
+
+// Field is a system field a register column can be mapped to
+type Field struct {
+	Name        string   `json:"name"`
+	Required    bool     `json:"required"`
+	Description string   `json:"description"`
+	Aliases     []string `json:"aliases,omitempty"` // column names suggested for it
+}
+
+// Fields lists the mappable fields of each register kind
+var Fields = map[string][]Field{
+	KindDevotees: {
+		{Name: "full_name", Required: true, Description: "Devotee name", Aliases: []string{"name", "devotee_name", "devotee"}},
+		{Name: "email", Required: true, Description: "Email address, used to sign in", Aliases: []string{"email_address", "e_mail", "mail"}},
+		{Name: "phone", Required: true, Description: "10 digit mobile number", Aliases: []string{"phone_number", "mobile", "mobile_no", "mobile_number", "contact", "contact_number", "cell"}},
+	},
+	KindDonations: {
+		{Name: "donor_email", Description: "Email of the donating devotee; donor_email or donor_phone is required", Aliases: []string{"email", "email_address"}},
+		{Name: "donor_phone", Description: "Mobile number of the donating devotee", Aliases: []string{"phone", "mobile", "mobile_no", "phone_number", "contact"}},
+		{Name: "amount", Required: true, Description: "Amount in rupees", Aliases: []string{"amount_rs", "rs", "rupees", "donation_amount", "total"}},
+		{Name: "date", Required: true, Description: "Donation date, day first (dd-mm-yyyy) or an Excel date", Aliases: []string{"donation_date", "receipt_date", "donated_on"}},
+		{Name: "donation_type", Description: "general, seva, event, festival, construction, annadanam, education or maintenance", Aliases: []string{"type", "purpose", "category", "head"}},
+		{Name: "method", Description: "Payment mode, CASH when empty", Aliases: []string{"mode", "payment_mode", "payment_method", "paid_by"}},
+		{Name: "note", Description: "Remarks", Aliases: []string{"notes", "remarks", "remark", "description", "narration"}},
+	},
+	KindBookings: {
+		{Name: "devotee_email", Description: "Email of the devotee; devotee_email or devotee_phone is required", Aliases: []string{"email", "email_address"}},
+		{Name: "devotee_phone", Description: "Mobile number of the devotee", Aliases: []string{"phone", "mobile", "mobile_no", "phone_number", "contact"}},
+		{Name: "seva_name", Required: true, Description: "Name of an existing seva of the temple", Aliases: []string{"seva", "pooja", "puja", "service"}},
+		{Name: "date", Required: true, Description: "Seva date, day first (dd-mm-yyyy) or an Excel date", Aliases: []string{"booking_date", "seva_date"}},
+		{Name: "status", Description: "pending, approved or rejected; approved when empty"},
+	},
+}
+
+// Report summarizes a batch: its counts, the errors grouped by message and
+// what the imported rows add up to
+type Report struct {
+	Batch   Batch             `json:"batch"`
+	Mapping map[string]string `json:"mapping"`
+	Fields  []Field           `json:"fields"`
+	Rows    map[string]int    `json:"rows_by_status"`
+	Errors  []ErrorGroup      `json:"errors"`
+
+	FirstDate   *time.Time `json:"first_date,omitempty"`
+	LastDate    *time.Time `json:"last_date,omitempty"`
+	TotalAmount float64    `json:"total_amount,omitempty"` // donations
+	Sample      []Row      `json:"sample,omitempty"`       // first rows, to check the mapping
+}
+
+// ErrorGroup counts rows failing with the same message
+type ErrorGroup struct {
+	Message string `json:"message"`
+	Count   int    `json:"count"`
+	Rows    []int  `json:"rows"` // first few row numbers
+}
@@ -0,0 +1,335 @@
+package migration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/seva"
+	"gorm.io/gorm"
+)
+
+// rowBatchSize is how many rows are inserted or updated per statement
+const rowBatchSize = 500
+
+// Repository stores migration batches and writes migrated records
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new migration repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetEntity loads the temple row
+func (r *Repository) GetEntity(entityID uint) (*entity.Entity, error) {
+	var e entity.Entity
+	if err := r.DB.First(&e, entityID).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// CreateBatch saves a staged batch with its rows
+func (r *Repository) CreateBatch(b *Batch, rows []Row) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(b).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].BatchID = b.ID
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(rows, rowBatchSize).Error
+	})
+}
+
+// GetBatch loads a batch of the entity
+func (r *Repository) GetBatch(entityID, batchID uint) (*Batch, error) {
+	var b Batch
+	if err := r.DB.Where("id = ? AND entity_id = ?", batchID, entityID).First(&b).Error; err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// ListBatches returns the batches of an entity, newest first
+func (r *Repository) ListBatches(entityID uint) ([]Batch, error) {
+	var batches []Batch
+	err := r.DB.Where("entity_id = ?", entityID).Order("id DESC").Find(&batches).Error
+	return batches, err
+}
+
+// ListRows returns the rows of a batch in file order, optionally by status.
+// A limit of 0 returns every row.
+func (r *Repository) ListRows(batchID uint, status string, offset, limit int) ([]Row, int64, error) {
+	q := r.DB.Model(&Row{}).Where("batch_id = ?", batchID)
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	q = q.Order("row_number ASC").Offset(offset)
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var rows []Row
+	err := q.Find(&rows).Error
+	return rows, total, err
+}
+
+// CountRowsByStatus counts the rows of a batch per status
+func (r *Repository) CountRowsByStatus(batchID uint) (map[string]int, error) {
+	var out []struct {
+		Status string
+		Count  int
+	}
+	err := r.DB.Model(&Row{}).Select("status, COUNT(*) AS count").
+		Where("batch_id = ?", batchID).Group("status").Scan(&out).Error
+	counts := make(map[string]int, len(out))
+	for _, o := range out {
+		counts[o.Status] = o.Count
+	}
+	return counts, err
+}
+
+// SaveValidation stores the validated rows and the batch counts
+func (r *Repository) SaveValidation(b *Batch, rows []Row) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := saveRows(tx, rows); err != nil {
+			return err
+		}
+		return tx.Save(b).Error
+	})
+}
+
+// SaveBatch updates the batch row only
+func (r *Repository) SaveBatch(b *Batch) error {
+	return r.DB.Save(b).Error
+}
+
+func saveRows(tx *gorm.DB, rows []Row) error {
+	for i := range rows {
+		row := &rows[i]
+		if err := tx.Model(&Row{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"data":      row.Data,
+			"status":    row.Status,
+			"error":     row.Error,
+			"action":    row.Action,
+			"record_id": row.RecordID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// =========================== LOOKUPS ===========================
+
+// existingUser is an account matching an imported email or phone
+type existingUser struct {
+	ID       uint
+	Email    string
+	Phone    string `gorm:"serializer:encrypted"`
+	RoleName string
+	IsMember bool
+}
+
+// FindUsers returns the accounts using any of the emails or phones, flagging
+// members of the entity
+func (r *Repository) FindUsers(entityID uint, emails, phones []string) ([]existingUser, error) {
+	var out []existingUser
+	if len(emails) == 0 && len(phones) == 0 {
+		return out, nil
+	}
+	if emails == nil {
+		emails = []string{""}
+	}
+	// Phones are encrypted, so they are matched by blind index
+	indexes := fieldcrypto.PhoneIndexes(phones)
+	if len(indexes) == 0 {
+		indexes = []string{""}
+	}
+	err := r.DB.Table("users u").
+		Select(`u.id, u.email, u.phone, ur.role_name,
+			EXISTS (SELECT 1 FROM user_entity_memberships m WHERE m.user_id = u.id AND m.entity_id = ?) AS is_member`, entityID).
+		Joins("JOIN user_roles ur ON ur.id = u.role_id").
+		Where("u.deleted_at IS NULL").
+		Where("LOWER(u.email) IN ? OR u.phone_index IN ?", emails, indexes).
+		Scan(&out).Error
+	return out, err
+}
+
+// SevasByName maps the lower-cased seva names of an entity to their IDs
+func (r *Repository) SevasByName(entityID uint) (map[string]uint, error) {
+	var sevas []seva.Seva
+	if err := r.DB.Select("id, name").Where("entity_id = ?", entityID).Find(&sevas).Error; err != nil {
+		return nil, err
+	}
+	out := make(map[string]uint, len(sevas))
+	for _, s := range sevas {
+		out[strings.ToLower(strings.TrimSpace(s.Name))] = s.ID
+	}
+	return out, nil
+}
+
+// DonationKeys returns the donation keys (see donationKey) of the donors'
+// successful donations to the entity between two dates, yyyy-mm-dd
+func (r *Repository) DonationKeys(entityID uint, userIDs []uint, from, to string) (map[string]bool, error) {
+	var found []struct {
+		UserID       uint
+		Day          string
+		Amount       float64
+		DonationType string
+	}
+	err := r.DB.Table("donations").
+		Select("user_id, TO_CHAR(COALESCE(donated_at, created_at), 'YYYY-MM-DD') AS day, amount, LOWER(donation_type) AS donation_type").
+		Where("entity_id = ? AND user_id IN ? AND status = ? AND deleted_at IS NULL", entityID, userIDs, donation.StatusSuccess).
+		Where("COALESCE(donated_at, created_at)::date BETWEEN ?::date AND ?::date", from, to).
+		Scan(&found).Error
+	if err != nil {
+		return nil, err
+	}
+	keys := make(map[string]bool, len(found))
+	for _, d := range found {
+		keys[donationKey(strconv.FormatUint(uint64(d.UserID), 10), d.Day, strconv.FormatFloat(d.Amount, 'f', 2, 64), d.DonationType)] = true
+	}
+	return keys, nil
+}
+
+// GetDevoteeRoleID returns the ID of the devotee role
+func (r *Repository) GetDevoteeRoleID() (uint, error) {
+	var role auth.UserRole
+	err := r.DB.Where("role_name = ?", "devotee").First(&role).Error
+	return role.ID, err
+}
+
+// =========================== COMMIT ===========================
+
+// migratedOrderID keys migrated donations, which have no gateway order
+func migratedOrderID(batchID uint, row int) string {
+	return fmt.Sprintf("migr_%d_%d", batchID, row)
+}
+
+// Commit writes the valid rows of a batch in one transaction and records
+// what each row wrote for rollback. The batch is saved with the rows.
+func (r *Repository) Commit(b *Batch, rows []Row, write func(tx *gorm.DB, row *Row) error) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range rows {
+			if rows[i].Status != RowValid {
+				continue
+			}
+			if err := write(tx, &rows[i]); err != nil {
+				return fmt.Errorf("row %d: %w", rows[i].RowNumber, err)
+			}
+		}
+		if err := saveRows(tx, rows); err != nil {
+			return err
+		}
+		return tx.Save(b).Error
+	})
+}
+
+// CreateDevotee creates the devotee account and its temple membership
+func (r *Repository) CreateDevotee(tx *gorm.DB, user *auth.User, entityID uint) error {
+	if err := tx.Create(user).Error; err != nil {
+		return err
+	}
+	return r.AddMembership(tx, user.ID, entityID)
+}
+
+// AddMembership links an existing devotee to the entity
+func (r *Repository) AddMembership(tx *gorm.DB, userID, entityID uint) error {
+	return tx.Exec(`INSERT INTO user_entity_memberships (user_id, entity_id, status, joined_at, created_at)
+		SELECT ?, ?, 'active', NOW(), NOW()
+		WHERE NOT EXISTS (SELECT 1 FROM user_entity_memberships WHERE user_id = ? AND entity_id = ?)`,
+		userID, entityID, userID, entityID).Error
+}
+
+// CreateDonation records a historical donation as paid on its date
+func (r *Repository) CreateDonation(tx *gorm.DB, d *donation.Donation) error {
+	return tx.Create(d).Error
+}
+
+// CreateBooking records a historical seva booking
+func (r *Repository) CreateBooking(tx *gorm.DB, b *seva.SevaBooking) error {
+	return tx.Create(b).Error
+}
+
+// =========================== ROLLBACK ===========================
+
+// CountReceipts counts receipts issued for the given donations
+func (r *Repository) CountReceipts(donationIDs []uint) (int64, error) {
+	var n int64
+	if len(donationIDs) == 0 {
+		return 0, nil
+	}
+	err := r.DB.Model(&donation.DonationReceipt{}).Where("donation_id IN ?", donationIDs).Count(&n).Error
+	return n, err
+}
+
+// Rollback removes what a committed batch wrote, in one transaction.
+// remove returns the row status after undoing the row.
+func (r *Repository) Rollback(b *Batch, rows []Row, remove func(tx *gorm.DB, row *Row) (string, error)) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range rows {
+			if rows[i].Status != RowImported {
+				continue
+			}
+			status, err := remove(tx, &rows[i])
+			if err != nil {
+				return fmt.Errorf("row %d: %w", rows[i].RowNumber, err)
+			}
+			rows[i].Status = status
+		}
+		if err := saveRows(tx, rows); err != nil {
+			return err
+		}
+		return tx.Save(b).Error
+	})
+}
+
+// DeleteDonation removes a migrated donation for good; it never was a payment
+func (r *Repository) DeleteDonation(tx *gorm.DB, id, entityID uint) error {
+	return tx.Unscoped().Where("id = ? AND entity_id = ? AND order_id LIKE 'migr\\_%'", id, entityID).
+		Delete(&donation.Donation{}).Error
+}
+
+// DeleteBooking removes a migrated booking
+func (r *Repository) DeleteBooking(tx *gorm.DB, id, entityID uint) error {
+	return tx.Where("id = ? AND entity_id = ?", id, entityID).Delete(&seva.SevaBooking{}).Error
+}
+
+// RemoveMembership drops the devotee's membership of the entity
+func (r *Repository) RemoveMembership(tx *gorm.DB, userID, entityID uint) error {
+	return tx.Exec(`DELETE FROM user_entity_memberships WHERE user_id = ? AND entity_id = ?`, userID, entityID).Error
+}
+
+// DevoteeInUse reports whether a devotee has donations, bookings or
+// memberships of other temples, which keep the account on rollback
+func (r *Repository) DevoteeInUse(tx *gorm.DB, userID, entityID uint) (bool, error) {
+	var inUse bool
+	err := tx.Raw(`SELECT
+		EXISTS (SELECT 1 FROM donations WHERE user_id = ? AND deleted_at IS NULL)
+		OR EXISTS (SELECT 1 FROM seva_bookings WHERE user_id = ?)
+		OR EXISTS (SELECT 1 FROM user_entity_memberships WHERE user_id = ? AND entity_id <> ?)`,
+		userID, userID, userID, entityID).Scan(&inUse).Error
+	return inUse, err
+}
+
+// DeleteDevotee removes a devotee account created by a migration
+func (r *Repository) DeleteDevotee(tx *gorm.DB, userID, entityID uint) error {
+	if err := r.RemoveMembership(tx, userID, entityID); err != nil {
+		return err
+	}
+	return tx.Unscoped().Where("id = ? AND created_by = ?", userID, createdByMigration).Delete(&auth.User{}).Error
+}
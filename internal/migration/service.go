@@ -0,0 +1,1128 @@
+package migration
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/mail"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/seva"
+	"github.com/xuri/excelize/v2"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	maxMigrationRows = 20000
+	MaxFileSize      = 20 << 20 // 20MB
+
+	createdByMigration = "legacy_migration" // users.created_by of migrated devotees
+	reportSampleRows   = 5
+	errorGroupRows     = 10
+)
+
+var (
+	ErrUnsupportedKind = errors.New("kind must be devotees, donations or bookings")
+	ErrUnsupportedFile = errors.New("file must be a .csv or .xlsx")
+	ErrNoRows          = errors.New("file has no data rows")
+	ErrTooManyRows     = fmt.Errorf("a register can contain at most %d rows", maxMigrationRows)
+	ErrBatchState      = errors.New("not allowed in the batch's current status")
+	ErrBatchHasErrors  = errors.New("batch has rows with errors; fix the file or the mapping, or commit with skip_errors=true")
+	ErrNothingToImport = errors.New("batch has no valid rows to import")
+	ErrRollbackBlocked = errors.New("batch cannot be rolled back")
+	ErrImportHasErrors = errors.New("file has rows with errors, nothing was imported; fix them, or import with all_or_nothing=false to skip them")
+
+	nonDigits = regexp.MustCompile(`\D`)
+)
+
+// MappingError lists what is wrong with a column mapping
+type MappingError struct {
+	Problems []string
+}
+
+func (e *MappingError) Error() string {
+	return "invalid column mapping: " + strings.Join(e.Problems, "; ")
+}
+
+var donationTypes = map[string]bool{
+	donation.TypeGeneral: true, donation.TypeSeva: true, donation.TypeEvent: true, donation.TypeFestival: true,
+	donation.TypeConstruction: true, donation.TypeAnnadanam: true, donation.TypeEducation: true, donation.TypeMaintenance: true,
+}
+
+var bookingStatuses = map[string]bool{"pending": true, "approved": true, "rejected": true}
+
+// Service runs the guided migration of legacy Excel registers: a file is
+// staged, its columns are mapped to system fields and validated (as often as
+// needed), then the valid rows are committed in one transaction. A committed
+// batch can be rolled back.
+type Service struct {
+	Repo         *Repository
+	AuditService auditlog.Service
+}
+
+// NewService initializes the migration service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, AuditService: auditSvc}
+}
+
+// =========================== PARSING ===========================
+
+// readRegister returns the rows of a CSV or XLSX upload, header first. For
+// Excel, sheet picks the worksheet (default: the first) and cells are read
+// raw, so dates arrive as serial numbers rather than in the sheet's format.
+func readRegister(filename, sheet string, r io.Reader) (string, [][]string, error) {
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		reader := csv.NewReader(r)
+		reader.TrimLeadingSpace = true
+		reader.FieldsPerRecord = -1
+		records, err := reader.ReadAll()
+		return "", records, err
+	case ".xlsx":
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid Excel file: %w", err)
+		}
+		defer f.Close()
+		sheets := f.GetSheetList()
+		if len(sheets) == 0 {
+			return "", nil, errors.New("Excel file has no sheets")
+		}
+		if sheet == "" {
+			sheet = sheets[0]
+		} else if idx, err := f.GetSheetIndex(sheet); err != nil || idx < 0 {
+			return "", nil, fmt.Errorf("sheet %q not found, the file has: %s", sheet, strings.Join(sheets, ", "))
+		}
+		records, err := f.GetRows(sheet, excelize.Options{RawCellValue: true})
+		return sheet, records, err
+	default:
+		return "", nil, ErrUnsupportedFile
+	}
+}
+
+// cleanHeaders trims the header row, names empty columns by position and
+// numbers repeated names so every column has a unique key
+func cleanHeaders(header []string) []string {
+	out := make([]string, len(header))
+	seen := map[string]int{}
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if h == "" {
+			h = fmt.Sprintf("column_%d", i+1)
+		}
+		seen[h]++
+		if n := seen[h]; n > 1 {
+			h = fmt.Sprintf("%s (%d)", h, n)
+		}
+		out[i] = h
+	}
+	return out
+}
+
+// normalizeHeader turns "Mobile No." into "mobile_no" for alias matching
+func normalizeHeader(h string) string {
+	h = strings.ToLower(strings.TrimSpace(h))
+	h = strings.NewReplacer(" ", "_", "-", "_", ".", "_", "/", "_").Replace(h)
+	for strings.Contains(h, "__") {
+		h = strings.ReplaceAll(h, "__", "_")
+	}
+	return strings.Trim(h, "_")
+}
+
+// suggestMapping maps each field to the first unused column named like it
+func suggestMapping(kind string, headers []string) map[string]string {
+	mapping := map[string]string{}
+	used := map[string]bool{}
+	for _, f := range Fields[kind] {
+		names := append([]string{f.Name}, f.Aliases...)
+	match:
+		for _, name := range names {
+			for _, h := range headers {
+				if !used[h] && normalizeHeader(h) == name {
+					mapping[f.Name] = h
+					used[h] = true
+					break match
+				}
+			}
+		}
+	}
+	return mapping
+}
+
+// checkMapping validates a mapping against the register kind and the file
+func checkMapping(kind string, headers []string, mapping map[string]string) []string {
+	var problems []string
+	known := map[string]bool{}
+	for _, f := range Fields[kind] {
+		known[f.Name] = true
+	}
+	columns := map[string]bool{}
+	for _, h := range headers {
+		columns[h] = true
+	}
+
+	fields := make([]string, 0, len(mapping))
+	for field := range mapping {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	usedBy := map[string]string{}
+	for _, field := range fields {
+		col := mapping[field]
+		switch {
+		case !known[field]:
+			problems = append(problems, fmt.Sprintf("unknown field %q", field))
+		case col == "":
+		case !columns[col]:
+			problems = append(problems, fmt.Sprintf("column %q of %s is not in the file", col, field))
+		case usedBy[col] != "":
+			problems = append(problems, fmt.Sprintf("column %q is mapped to both %s and %s", col, usedBy[col], field))
+		default:
+			usedBy[col] = field
+		}
+	}
+
+	for _, f := range Fields[kind] {
+		if f.Required && mapping[f.Name] == "" {
+			problems = append(problems, fmt.Sprintf("%s must be mapped to a column", f.Name))
+		}
+	}
+	switch kind {
+	case KindDonations:
+		if mapping["donor_email"] == "" && mapping["donor_phone"] == "" {
+			problems = append(problems, "donor_email or donor_phone must be mapped to a column")
+		}
+	case KindBookings:
+		if mapping["devotee_email"] == "" && mapping["devotee_phone"] == "" {
+			problems = append(problems, "devotee_email or devotee_phone must be mapped to a column")
+		}
+	}
+	return problems
+}
+
+// normalizePhone keeps the 10 digit mobile number, dropping a +91 prefix
+func normalizePhone(raw string) (string, error) {
+	cleaned := nonDigits.ReplaceAllString(raw, "")
+	if len(cleaned) == 12 && strings.HasPrefix(cleaned, "91") {
+		cleaned = cleaned[2:]
+	}
+	if len(cleaned) != 10 {
+		return "", errors.New("phone must be a 10 digit number")
+	}
+	return cleaned, nil
+}
+
+// parseAmount reads rupee amounts such as "₹1,500", "Rs. 501" or "1500.00"
+func parseAmount(raw string) (float64, error) {
+	s := strings.NewReplacer("₹", "", ",", "", " ", "", "/-", "").Replace(strings.ToLower(raw))
+	s = strings.TrimPrefix(strings.TrimPrefix(strings.TrimPrefix(s, "inr"), "rs."), "rs")
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0, errors.New("amount must be a positive number")
+	}
+	return math.Round(v*100) / 100, nil
+}
+
+// Registers kept in India write dates day first
+var dateLayouts = []string{
+	"02-01-2006", "2-1-2006", "02/01/2006", "2/1/2006", "02.01.2006", "2.1.2006",
+	"2006-01-02", "2006/01/02", "2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00", "20060102",
+	"02-Jan-2006", "2-Jan-2006", "02 Jan 2006", "2 Jan 2006", "Jan 2, 2006", "02-01-06", "02/01/06",
+}
+
+// parseDate reads a day-first date or an Excel serial date
+func parseDate(raw string) (time.Time, error) {
+	s := strings.TrimSpace(raw)
+	if serial, err := strconv.ParseFloat(s, 64); err == nil && serial > 0 && serial < 2958466 {
+		t, err := excelize.ExcelDateToTime(serial, false)
+		if err == nil {
+			return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), nil
+		}
+	}
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("date must be day first (dd-mm-yyyy) or an Excel date")
+}
+
+func randomPassword() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// =========================== STAGING ===========================
+
+// Stage parses an uploaded register into a new batch. Without a mapping
+// one is suggested from the column names; when the mapping covers the
+// required fields the batch is validated right away.
+func (s *Service) Stage(ctx context.Context, entityID uint, kind, filename, sheet string, file io.Reader, mapping map[string]string, userID uint, ip string) (*Report, error) {
+	if _, ok := Fields[kind]; !ok {
+		return nil, ErrUnsupportedKind
+	}
+	sheet, records, err := readRegister(filename, sheet, file)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, ErrNoRows
+	}
+	if len(records)-1 > maxMigrationRows {
+		return nil, ErrTooManyRows
+	}
+
+	headers := cleanHeaders(records[0])
+	rows := make([]Row, 0, len(records)-1)
+	for i, record := range records[1:] {
+		raw := map[string]string{}
+		for j, h := range headers {
+			if j < len(record) {
+				if v := strings.TrimSpace(record[j]); v != "" {
+					raw[h] = v
+				}
+			}
+		}
+		if len(raw) == 0 {
+			continue // Blank line
+		}
+		data, _ := json.Marshal(raw)
+		rows = append(rows, Row{RowNumber: i + 2, Raw: data, Status: RowPending})
+	}
+	if len(rows) == 0 {
+		return nil, ErrNoRows
+	}
+
+	if mapping == nil {
+		mapping = suggestMapping(kind, headers)
+	}
+	headersJSON, _ := json.Marshal(headers)
+	mappingJSON, _ := json.Marshal(mapping)
+	b := &Batch{
+		EntityID:  entityID,
+		Kind:      kind,
+		FileName:  filename,
+		Sheet:     sheet,
+		Headers:   headersJSON,
+		Mapping:   mappingJSON,
+		Status:    StatusStaged,
+		TotalRows: len(rows),
+		CreatedBy: userID,
+	}
+	if err := s.Repo.CreateBatch(b, rows); err != nil {
+		return nil, err
+	}
+	s.AuditService.LogAction(ctx, &userID, &entityID, "MIGRATION_BATCH_STAGED", map[string]interface{}{
+		"batch_id":   b.ID,
+		"kind":       kind,
+		"filename":   filename,
+		"total_rows": b.TotalRows,
+	}, ip, "success")
+
+	if len(checkMapping(kind, headers, mapping)) == 0 {
+		if err := s.validate(b, rows, mapping); err != nil {
+			return nil, err
+		}
+	}
+	return s.Report(ctx, entityID, b.ID)
+}
+
+// Validate applies a column mapping to a staged or validated batch and
+// checks every row. It can be run again after changing the mapping.
+func (s *Service) Validate(ctx context.Context, entityID, batchID uint, mapping map[string]string, userID uint, ip string) (*Report, error) {
+	b, err := s.Repo.GetBatch(entityID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusStaged && b.Status != StatusValidated {
+		return nil, ErrBatchState
+	}
+	var headers []string
+	_ = json.Unmarshal(b.Headers, &headers)
+	if problems := checkMapping(b.Kind, headers, mapping); len(problems) > 0 {
+		return nil, &MappingError{Problems: problems}
+	}
+
+	rows, _, err := s.Repo.ListRows(b.ID, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validate(b, rows, mapping); err != nil {
+		return nil, err
+	}
+	s.AuditService.LogAction(ctx, &userID, &entityID, "MIGRATION_BATCH_VALIDATED", map[string]interface{}{
+		"batch_id":   b.ID,
+		"kind":       b.Kind,
+		"valid_rows": b.ValidRows,
+		"error_rows": b.ErrorRows,
+	}, ip, "success")
+	return s.Report(ctx, entityID, b.ID)
+}
+
+// validate maps and checks the rows and saves the outcome with the batch
+func (s *Service) validate(b *Batch, rows []Row, mapping map[string]string) error {
+	mapped := make([]map[string]string, len(rows))
+	for i := range rows {
+		var raw map[string]string
+		_ = json.Unmarshal(rows[i].Raw, &raw)
+		m := map[string]string{}
+		for field, col := range mapping {
+			if col != "" && raw[col] != "" {
+				m[field] = raw[col]
+			}
+		}
+		mapped[i] = m
+		rows[i].Status, rows[i].Error, rows[i].Action, rows[i].RecordID = RowValid, "", "", nil
+	}
+
+	var err error
+	switch b.Kind {
+	case KindDevotees:
+		err = s.validateDevotees(b.EntityID, rows, mapped)
+	case KindDonations:
+		err = s.validateDonations(b.EntityID, rows, mapped)
+	case KindBookings:
+		err = s.validateBookings(b.EntityID, rows, mapped)
+	default:
+		err = ErrUnsupportedKind
+	}
+	if err != nil {
+		return err
+	}
+
+	b.ValidRows, b.ErrorRows = 0, 0
+	for i := range rows {
+		if rows[i].Error != "" {
+			rows[i].Status = RowError
+			b.ErrorRows++
+		} else {
+			b.ValidRows++
+		}
+		rows[i].Data, _ = json.Marshal(mapped[i])
+	}
+	now := time.Now()
+	b.Mapping, _ = json.Marshal(mapping)
+	b.Status = StatusValidated
+	b.ValidatedAt = &now
+	b.Error = ""
+	return s.Repo.SaveValidation(b, rows)
+}
+
+// userIndex finds existing accounts by email or phone
+type userIndex struct {
+	byEmail map[string]existingUser
+	byPhone map[string]existingUser
+}
+
+func (s *Service) loadUsers(entityID uint, emails, phones []string) (*userIndex, error) {
+	existing, err := s.Repo.FindUsers(entityID, emails, phones)
+	if err != nil {
+		return nil, err
+	}
+	idx := &userIndex{byEmail: map[string]existingUser{}, byPhone: map[string]existingUser{}}
+	for _, u := range existing {
+		idx.byEmail[strings.ToLower(u.Email)] = u
+		idx.byPhone[fieldcrypto.NormalizePhone(u.Phone)] = u
+	}
+	return idx, nil
+}
+
+// match returns the account holding the email or phone, or an error message
+// when they belong to different accounts
+func (idx *userIndex) match(email, phone string) (existingUser, bool, string) {
+	u, found := idx.byEmail[email]
+	if email == "" {
+		found = false
+	}
+	if phone != "" {
+		if byPhone, ok := idx.byPhone[phone]; ok {
+			if found && byPhone.ID != u.ID {
+				return existingUser{}, false, "email and phone belong to different accounts"
+			}
+			u, found = byPhone, true
+		}
+	}
+	return u, found, ""
+}
+
+// contactFields normalizes the email and phone of a row, collecting them
+// for the account lookup. It returns the first problem found.
+func contactFields(m map[string]string, emailField, phoneField string, emails, phones *[]string) string {
+	email := strings.ToLower(m[emailField])
+	if email != "" {
+		if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+			return "invalid email address"
+		}
+		m[emailField] = email
+		*emails = append(*emails, email)
+	}
+	if m[phoneField] != "" {
+		phone, err := normalizePhone(m[phoneField])
+		if err != nil {
+			return err.Error()
+		}
+		m[phoneField] = phone
+		*phones = append(*phones, phone)
+	}
+	return ""
+}
+
+func (s *Service) validateDevotees(entityID uint, rows []Row, mapped []map[string]string) error {
+	seenEmail := map[string]int{}
+	seenPhone := map[string]int{}
+	var emails, phones []string
+
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		switch {
+		case m["full_name"] == "":
+			row.Error = "full_name is required"
+		case m["email"] == "":
+			row.Error = "email is required"
+		case m["phone"] == "":
+			row.Error = "phone is required"
+		default:
+			row.Error = contactFields(m, "email", "phone", &emails, &phones)
+		}
+		if row.Error != "" {
+			continue
+		}
+		if first, dup := seenEmail[m["email"]]; dup {
+			row.Error = fmt.Sprintf("duplicate email, same as row %d", first)
+		} else if first, dup := seenPhone[m["phone"]]; dup {
+			row.Error = fmt.Sprintf("duplicate phone, same as row %d", first)
+		} else {
+			seenEmail[m["email"]] = row.RowNumber
+			seenPhone[m["phone"]] = row.RowNumber
+		}
+	}
+
+	users, err := s.loadUsers(entityID, emails, phones)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		u, found, problem := users.match(m["email"], m["phone"])
+		switch {
+		case problem != "":
+			row.Error = problem
+		case found && u.RoleName != "devotee":
+			row.Error = "email or phone is used by a non-devotee account"
+		case found && u.IsMember:
+			row.Action, row.RecordID = ActionSkip, &u.ID
+		case found:
+			row.Action, row.RecordID = ActionLink, &u.ID
+		default:
+			row.Action = ActionCreate
+		}
+	}
+	return nil
+}
+
+func (s *Service) validateDonations(entityID uint, rows []Row, mapped []map[string]string) error {
+	var emails, phones []string
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		if m["donor_email"] == "" && m["donor_phone"] == "" {
+			row.Error = "donor_email or donor_phone is required"
+			continue
+		}
+		if row.Error = contactFields(m, "donor_email", "donor_phone", &emails, &phones); row.Error != "" {
+			continue
+		}
+		amount, err := parseAmount(m["amount"])
+		if err != nil {
+			row.Error = err.Error()
+			continue
+		}
+		m["amount"] = strconv.FormatFloat(amount, 'f', 2, 64)
+		if row.Error = checkDate(m); row.Error != "" {
+			continue
+		}
+		m["donation_type"] = strings.ToLower(m["donation_type"])
+		if m["donation_type"] == "" {
+			m["donation_type"] = donation.TypeGeneral
+		} else if !donationTypes[m["donation_type"]] {
+			row.Error = fmt.Sprintf("unknown donation_type %q", m["donation_type"])
+			continue
+		}
+		m["method"] = strings.ToUpper(m["method"])
+		if m["method"] == "" {
+			m["method"] = "CASH"
+		}
+		if len(m["method"]) > 50 {
+			row.Error = "method is too long"
+		}
+	}
+	if err := s.matchContacts(entityID, rows, mapped, "donor_email", "donor_phone", "donor", emails, phones); err != nil {
+		return err
+	}
+	return s.markDuplicateDonations(entityID, rows, mapped)
+}
+
+// donationKey identifies a donation by donor, date, amount and type
+func donationKey(userID, date, amount, donationType string) string {
+	return strings.Join([]string{userID, date, amount, donationType}, "|")
+}
+
+// markDuplicateDonations fails rows repeating an earlier row of the file and
+// skips rows already recorded for the temple, so a register can be imported
+// again after fixing some of its rows
+func (s *Service) markDuplicateDonations(entityID uint, rows []Row, mapped []map[string]string) error {
+	firstRow := map[string]int{}
+	var userIDs []uint
+	var from, to string
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		key := donationKey(m["user_id"], m["date"], m["amount"], m["donation_type"])
+		if n, ok := firstRow[key]; ok {
+			row.Error = fmt.Sprintf("duplicate of row %d (same donor, date, amount and type)", n)
+			continue
+		}
+		firstRow[key] = row.RowNumber
+		id, _ := strconv.ParseUint(m["user_id"], 10, 64)
+		userIDs = append(userIDs, uint(id))
+		if from == "" || m["date"] < from {
+			from = m["date"]
+		}
+		if m["date"] > to {
+			to = m["date"]
+		}
+	}
+	if len(userIDs) == 0 {
+		return nil
+	}
+
+	existing, err := s.Repo.DonationKeys(entityID, userIDs, from, to)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		if row.Error == "" && existing[donationKey(m["user_id"], m["date"], m["amount"], m["donation_type"])] {
+			row.Action = ActionSkip
+		}
+	}
+	return nil
+}
+
+func (s *Service) validateBookings(entityID uint, rows []Row, mapped []map[string]string) error {
+	sevas, err := s.Repo.SevasByName(entityID)
+	if err != nil {
+		return err
+	}
+	var emails, phones []string
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		if m["devotee_email"] == "" && m["devotee_phone"] == "" {
+			row.Error = "devotee_email or devotee_phone is required"
+			continue
+		}
+		if row.Error = contactFields(m, "devotee_email", "devotee_phone", &emails, &phones); row.Error != "" {
+			continue
+		}
+		sevaID, ok := sevas[strings.ToLower(m["seva_name"])]
+		if !ok {
+			row.Error = fmt.Sprintf("seva %q not found; create it before importing bookings", m["seva_name"])
+			continue
+		}
+		m["seva_id"] = strconv.FormatUint(uint64(sevaID), 10)
+		if row.Error = checkDate(m); row.Error != "" {
+			continue
+		}
+		m["status"] = strings.ToLower(m["status"])
+		if m["status"] == "" {
+			m["status"] = "approved"
+		} else if !bookingStatuses[m["status"]] {
+			row.Error = fmt.Sprintf("unknown status %q", m["status"])
+		}
+	}
+	return s.matchContacts(entityID, rows, mapped, "devotee_email", "devotee_phone", "devotee", emails, phones)
+}
+
+// checkDate normalizes the row's date to yyyy-mm-dd
+func checkDate(m map[string]string) string {
+	if m["date"] == "" {
+		return "date is required"
+	}
+	t, err := parseDate(m["date"])
+	if err != nil {
+		return err.Error()
+	}
+	if t.After(time.Now()) {
+		return "date is in the future"
+	}
+	m["date"] = t.Format("2006-01-02")
+	return ""
+}
+
+// matchContacts resolves the devotee account of donation and booking rows.
+// Devotees are not created here: their register is imported first.
+func (s *Service) matchContacts(entityID uint, rows []Row, mapped []map[string]string, emailField, phoneField, who string, emails, phones []string) error {
+	users, err := s.loadUsers(entityID, emails, phones)
+	if err != nil {
+		return err
+	}
+	for i := range rows {
+		m, row := mapped[i], &rows[i]
+		if row.Error != "" {
+			continue
+		}
+		u, found, problem := users.match(m[emailField], m[phoneField])
+		switch {
+		case problem != "":
+			row.Error = problem
+		case !found:
+			row.Error = fmt.Sprintf("%s not found; import the devotee register first", who)
+		default:
+			m["user_id"] = strconv.FormatUint(uint64(u.ID), 10)
+			row.Action = ActionCreate
+		}
+	}
+	return nil
+}
+
+// =========================== COMMIT ===========================
+
+// Commit imports the valid rows of a validated batch in one transaction.
+// Rows are validated again first, as accounts or sevas may have changed.
+// With errors left, skipErrors must be set to import the rest.
+func (s *Service) Commit(ctx context.Context, entityID, batchID uint, skipErrors bool, userID uint, ip string) (*Report, error) {
+	b, err := s.Repo.GetBatch(entityID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusValidated {
+		return nil, ErrBatchState
+	}
+	rows, _, err := s.Repo.ListRows(b.ID, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	var mapping map[string]string
+	_ = json.Unmarshal(b.Mapping, &mapping)
+	if err := s.validate(b, rows, mapping); err != nil {
+		return nil, err
+	}
+	if b.ErrorRows > 0 && !skipErrors {
+		return nil, ErrBatchHasErrors
+	}
+	if b.ValidRows == 0 {
+		return nil, ErrNothingToImport
+	}
+
+	committed := *b
+	committed.ImportedRows, committed.SkippedRows = 0, 0
+	write, err := s.rowWriter(&committed)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	committed.Status = StatusCommitted
+	committed.CommittedAt = &now
+	committed.CommittedBy = &userID
+
+	if err := s.Repo.Commit(&committed, rows, write); err != nil {
+		// Nothing was written; the batch stays validated
+		b.Error = err.Error()
+		if saveErr := s.Repo.SaveBatch(b); saveErr != nil {
+			return nil, saveErr
+		}
+		s.AuditService.LogAction(ctx, &userID, &entityID, "MIGRATION_BATCH_COMMIT_FAILED", map[string]interface{}{
+			"batch_id": b.ID,
+			"kind":     b.Kind,
+			"error":    err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	s.AuditService.LogAction(ctx, &userID, &entityID, "MIGRATION_BATCH_COMMITTED", map[string]interface{}{
+		"batch_id":      b.ID,
+		"kind":          b.Kind,
+		"imported_rows": committed.ImportedRows,
+		"skipped_rows":  committed.SkippedRows,
+		"error_rows":    committed.ErrorRows,
+	}, ip, "success")
+	return s.Report(ctx, entityID, b.ID)
+}
+
+// ImportDonations stages, validates and commits a donation register in one
+// call. With allOrNothing a single row with an error imports nothing and the
+// batch is left validated, to be fixed through its mapping or uploaded
+// again; otherwise the valid rows are imported and the rest reported. The
+// batch's report is returned either way, and a committed import can be
+// rolled back like any migration.
+func (s *Service) ImportDonations(ctx context.Context, entityID uint, filename string, file io.Reader, mapping map[string]string, allOrNothing bool, userID uint, ip string) (*Report, error) {
+	report, err := s.Stage(ctx, entityID, KindDonations, filename, "", file, mapping, userID, ip)
+	if err != nil {
+		return nil, err
+	}
+	if report.Batch.Status != StatusValidated {
+		var headers []string
+		_ = json.Unmarshal(report.Batch.Headers, &headers)
+		return report, &MappingError{Problems: checkMapping(KindDonations, headers, report.Mapping)}
+	}
+	if report.Batch.ErrorRows > 0 && allOrNothing {
+		return report, ErrImportHasErrors
+	}
+
+	committed, err := s.Commit(ctx, entityID, report.Batch.ID, !allOrNothing, userID, ip)
+	if err != nil {
+		return report, err
+	}
+	return committed, nil
+}
+
+// rowWriter returns the function writing one valid row of the batch kind
+func (s *Service) rowWriter(b *Batch) (func(tx *gorm.DB, row *Row) error, error) {
+	entityID := b.EntityID
+	imported := func(row *Row, id uint) {
+		row.Status, row.RecordID = RowImported, &id
+		b.ImportedRows++
+	}
+
+	switch b.Kind {
+	case KindDevotees:
+		roleID, err := s.Repo.GetDevoteeRoleID()
+		if err != nil {
+			return nil, fmt.Errorf("devotee role not found: %w", err)
+		}
+		return func(tx *gorm.DB, row *Row) error {
+			switch row.Action {
+			case ActionSkip:
+				row.Status = RowSkipped
+				b.SkippedRows++
+				return nil
+			case ActionLink:
+				if err := s.Repo.AddMembership(tx, *row.RecordID, entityID); err != nil {
+					return err
+				}
+				imported(row, *row.RecordID)
+				return nil
+			}
+			var m map[string]string
+			_ = json.Unmarshal(row.Data, &m)
+			password, err := randomPassword()
+			if err != nil {
+				return err
+			}
+			// The password is random and never shown, so the minimum cost is
+			// enough; devotees set their own through forgot password
+			hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+			if err != nil {
+				return err
+			}
+			user := auth.User{
+				FullName:     m["full_name"],
+				Email:        m["email"],
+				Phone:        m["phone"],
+				PhoneIndex:   fieldcrypto.PhoneIndex(m["phone"]),
+				PasswordHash: string(hash),
+				RoleID:       roleID,
+				EntityID:     &entityID,
+				Status:       "active",
+				CreatedBy:    createdByMigration,
+			}
+			if err := s.Repo.CreateDevotee(tx, &user, entityID); err != nil {
+				return err
+			}
+			imported(row, user.ID)
+			return nil
+		}, nil
+
+	case KindDonations:
+		return func(tx *gorm.DB, row *Row) error {
+			if row.Action == ActionSkip {
+				row.Status = RowSkipped
+				b.SkippedRows++
+				return nil
+			}
+			var m map[string]string
+			_ = json.Unmarshal(row.Data, &m)
+			userID, _ := strconv.ParseUint(m["user_id"], 10, 64)
+			amount, _ := strconv.ParseFloat(m["amount"], 64)
+			date, _ := time.ParseInLocation("2006-01-02", m["date"], time.Local)
+			d := donation.Donation{
+				UserID:       uint(userID),
+				EntityID:     entityID,
+				Amount:       amount,
+				DonationType: m["donation_type"],
+				Method:       m["method"],
+				Status:       donation.StatusSuccess,
+				OrderID:      migratedOrderID(b.ID, row.RowNumber),
+				DonatedAt:    &date,
+				CreatedAt:    date, // reports filter on it
+			}
+			if note := m["note"]; note != "" {
+				d.Note = &note
+			}
+			if err := s.Repo.CreateDonation(tx, &d); err != nil {
+				return err
+			}
+			imported(row, d.ID)
+			return nil
+		}, nil
+
+	case KindBookings:
+		return func(tx *gorm.DB, row *Row) error {
+			var m map[string]string
+			_ = json.Unmarshal(row.Data, &m)
+			userID, _ := strconv.ParseUint(m["user_id"], 10, 64)
+			sevaID, _ := strconv.ParseUint(m["seva_id"], 10, 64)
+			date, _ := time.ParseInLocation("2006-01-02", m["date"], time.Local)
+			// Historical bookings leave the seva's slot counters alone
+			booking := seva.SevaBooking{
+				SevaID:      uint(sevaID),
+				UserID:      uint(userID),
+				EntityID:    entityID,
+				BookingTime: date,
+				Status:      m["status"],
+				CreatedAt:   date,
+			}
+			if err := s.Repo.CreateBooking(tx, &booking); err != nil {
+				return err
+			}
+			imported(row, booking.ID)
+			return nil
+		}, nil
+	}
+	return nil, ErrUnsupportedKind
+}
+
+// =========================== ROLLBACK ===========================
+
+// Rollback removes what a committed batch imported. Donations with issued
+// receipts block it. Migrated devotees who have since donated, booked or
+// joined another temple are kept.
+func (s *Service) Rollback(ctx context.Context, entityID, batchID, userID uint, ip string) (*Report, error) {
+	b, err := s.Repo.GetBatch(entityID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if b.Status != StatusCommitted {
+		return nil, ErrBatchState
+	}
+	rows, _, err := s.Repo.ListRows(b.ID, RowImported, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	fail := func(err error) (*Report, error) {
+		s.AuditService.LogAction(ctx, &userID, &entityID, "MIGRATION_BATCH_ROLLED_BACK", map[string]interface{}{
+			"batch_id": b.ID,
+			"kind":     b.Kind,
+			"error":    err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	if b.Kind == KindDonations {
+		ids := make([]uint, 0, len(rows))
+		for _, row := range rows {
+			if row.RecordID != nil {
+				ids = append(ids, *row.RecordID)
+			}
+		}
+		receipts, err := s.Repo.CountReceipts(ids)
+		if err != nil {
+			return nil, err
+		}
+		if receipts > 0 {
+			return fail(fmt.Errorf("%w: receipts were issued for %d of its donations", ErrRollbackBlocked, receipts))
+		}
+	}
+
+	rolledBack := *b
+	rolledBack.RolledBackRows = 0
+	remove := func(tx *gorm.DB, row *Row) (string, error) {
+		if row.RecordID == nil {
+			return RowRolledBack, nil
+		}
+		id := *row.RecordID
+		var err error
+		switch {
+		case b.Kind == KindDonations:
+			err = s.Repo.DeleteDonation(tx, id, entityID)
+		case b.Kind == KindBookings:
+			err = s.Repo.DeleteBooking(tx, id, entityID)
+		case row.Action == ActionLink:
+			err = s.Repo.RemoveMembership(tx, id, entityID)
+		default:
+			inUse, useErr := s.Repo.DevoteeInUse(tx, id, entityID)
+			if useErr != nil {
+				return "", useErr
+			}
+			if inUse {
+				return RowKept, nil
+			}
+			err = s.Repo.DeleteDevotee(tx, id, entityID)
+		}
+		if err != nil {
+			return "", err
+		}
+		rolledBack.RolledBackRows++
+		return RowRolledBack, nil
+	}
+	now := time.Now()
+	rolledBack.Status = StatusRolledBack
+	rolledBack.RolledBackAt = &now
+	rolledBack.RolledBackBy = &userID
+	rolledBack.Error = ""
+
+	if err := s.Repo.Rollback(&rolledBack, rows, remove); err != nil {
+		b.Error = err.Error()
+		if saveErr := s.Repo.SaveBatch(b); saveErr != nil {
+			return nil, saveErr
+		}
+		return fail(err)
+	}
+
+	s.AuditService.LogAction(ctx, &userID, &entityID, "MIGRATION_BATCH_ROLLED_BACK", map[string]interface{}{
+		"batch_id":         b.ID,
+		"kind":             b.Kind,
+		"rolled_back_rows": rolledBack.RolledBackRows,
+		"kept_rows":        len(rows) - rolledBack.RolledBackRows,
+	}, ip, "success")
+	return s.Report(ctx, entityID, b.ID)
+}
+
+// =========================== REPORTS ===========================
+
+// List returns the migration batches of an entity
+func (s *Service) List(entityID uint) ([]Batch, error) {
+	return s.Repo.ListBatches(entityID)
+}
+
+// Rows returns a page of a batch's rows, optionally by status
+func (s *Service) Rows(entityID, batchID uint, status string, page, limit int) ([]Row, int64, error) {
+	if _, err := s.Repo.GetBatch(entityID, batchID); err != nil {
+		return nil, 0, err
+	}
+	return s.Repo.ListRows(batchID, status, (page-1)*limit, limit)
+}
+
+// Report summarizes a batch
+func (s *Service) Report(ctx context.Context, entityID, batchID uint) (*Report, error) {
+	b, err := s.Repo.GetBatch(entityID, batchID)
+	if err != nil {
+		return nil, err
+	}
+	rows, _, err := s.Repo.ListRows(b.ID, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{Batch: *b, Fields: Fields[b.Kind], Rows: map[string]int{}, Errors: []ErrorGroup{}}
+	_ = json.Unmarshal(b.Mapping, &report.Mapping)
+
+	groups := map[string]*ErrorGroup{}
+	for _, row := range rows {
+		report.Rows[row.Status]++
+		if len(report.Sample) < reportSampleRows {
+			report.Sample = append(report.Sample, row)
+		}
+		switch row.Status {
+		case RowError:
+			g, ok := groups[row.Error]
+			if !ok {
+				g = &ErrorGroup{Message: row.Error}
+				groups[row.Error] = g
+			}
+			g.Count++
+			if len(g.Rows) < errorGroupRows {
+				g.Rows = append(g.Rows, row.RowNumber)
+			}
+		case RowValid, RowImported:
+			var m map[string]string
+			_ = json.Unmarshal(row.Data, &m)
+			if t, err := time.ParseInLocation("2006-01-02", m["date"], time.Local); err == nil {
+				if report.FirstDate == nil || t.Before(*report.FirstDate) {
+					report.FirstDate = &t
+				}
+				if report.LastDate == nil || t.After(*report.LastDate) {
+					report.LastDate = &t
+				}
+			}
+			if b.Kind == KindDonations && row.Action != ActionSkip {
+				amount, _ := strconv.ParseFloat(m["amount"], 64)
+				report.TotalAmount += amount
+			}
+		}
+	}
+	for _, g := range groups {
+		report.Errors = append(report.Errors, *g)
+	}
+	sort.Slice(report.Errors, func(i, j int) bool {
+		if report.Errors[i].Count != report.Errors[j].Count {
+			return report.Errors[i].Count > report.Errors[j].Count
+		}
+		return report.Errors[i].Message < report.Errors[j].Message
+	})
+	report.TotalAmount = math.Round(report.TotalAmount*100) / 100
+	return report, nil
+}
+
+// WriteReportCSV writes every row of a batch with its outcome and mapped values
+func (s *Service) WriteReportCSV(w io.Writer, entityID, batchID uint) error {
+	b, err := s.Repo.GetBatch(entityID, batchID)
+	if err != nil {
+		return err
+	}
+	rows, _, err := s.Repo.ListRows(b.ID, "", 0, 0)
+	if err != nil {
+		return err
+	}
+
+	fields := Fields[b.Kind]
+	cw := csv.NewWriter(w)
+	header := []string{"row", "status", "action", "record_id", "error"}
+	for _, f := range fields {
+		header = append(header, f.Name)
+	}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		var m map[string]string
+		_ = json.Unmarshal(row.Data, &m)
+		recordID := ""
+		if row.RecordID != nil {
+			recordID = strconv.FormatUint(uint64(*row.RecordID), 10)
+		}
+		record := []string{strconv.Itoa(row.RowNumber), row.Status, row.Action, recordID, row.Error}
+		for _, f := range fields {
+			record = append(record, m[f.Name])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
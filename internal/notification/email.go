@@ -2,99 +2,116 @@ package notification
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"html/template"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
 	"net/smtp"
+	"net/textproto"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/sharath018/temple-management-backend/config"
 )
 
-// EmailSender implements Channel interface using SMTP
-type EmailSender struct {
-	Host     string
-	Port     string
-	Username string
-	Password string
-	FromName string
-	FromAddr string
+// ErrEmailNotConfigured is returned by the provider used when neither SMTP
+// nor SES is configured; such emails are only logged
+var ErrEmailNotConfigured = errors.New("email provider not configured")
+
+// EmailMessage is one email as handed to a provider
+type EmailMessage struct {
+	FromName    string
+	FromAddr    string
+	ReplyTo     string
+	To          []string
+	Subject     string
+	HTML        string
+	Attachments []EmailAttachment
 }
 
-// ✅ Accept config instead of using os.Getenv
-func NewEmailSender(cfg *config.Config) *EmailSender {
-	return &EmailSender{
-		Host:     cfg.SMTPHost,
-		Port:     cfg.SMTPPort,
-		Username: cfg.SMTPUsername,
-		Password: cfg.SMTPPassword,
-		FromName: cfg.SMTPFromName,
-		FromAddr: cfg.SMTPFromEmail,
-	}
+// EmailAttachment is a file sent along, e.g. a donation receipt PDF
+type EmailAttachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
 }
 
-// Send renders the HTML template and sends the email
-func (e *EmailSender) Send(to []string, subject string, body string) error {
-	// Step 1: Load and parse the template
-	tmplPath := filepath.Join("templates", "example.html")
-	tmpl, err := template.ParseFiles(tmplPath)
-	if err != nil {
-		fmt.Println("❌ Failed to parse email template:", err)
-		return fmt.Errorf("failed to parse email template: %w", err)
-	}
+// EmailProvider delivers emails. Send returns the message ID the provider
+// assigned, used to trace a delivery.
+type EmailProvider interface {
+	Name() string
+	Send(ctx context.Context, msg *EmailMessage) (string, error)
+}
 
-	// Step 2: Inject subject + body
-	var htmlBody bytes.Buffer
-	err = tmpl.Execute(&htmlBody, map[string]string{
-		"Subject": subject,
-		"Body":    body,
-	})
-	if err != nil {
-		fmt.Println("❌ Failed to render email template:", err)
-		return fmt.Errorf("failed to render email template: %w", err)
+// NewEmailProvider picks the provider from EMAIL_PROVIDER: SES, or SMTP by default
+func NewEmailProvider(cfg *config.Config) EmailProvider {
+	switch cfg.EmailProvider {
+	case "ses":
+		if cfg.SESRegion != "" && cfg.SESAccessKey != "" && cfg.SESSecretKey != "" {
+			return NewSESProvider(cfg.SESRegion, cfg.SESAccessKey, cfg.SESSecretKey)
+		}
+		fmt.Println("⚠️ EMAIL_PROVIDER=ses but SES_REGION/SES_ACCESS_KEY/SES_SECRET_KEY are not set, emails are only logged")
+	default:
+		if cfg.SMTPHost != "" && cfg.SMTPUsername != "" && cfg.SMTPPassword != "" {
+			return &SMTPProvider{
+				Host:     cfg.SMTPHost,
+				Port:     cfg.SMTPPort,
+				Username: cfg.SMTPUsername,
+				Password: cfg.SMTPPassword,
+			}
+		}
 	}
+	return logProvider{}
+}
 
-	// Step 3: Build headers
-	from := fmt.Sprintf("%s <%s>", e.FromName, e.FromAddr)
-	toHeader := strings.Join(to, ", ")
-	headers := map[string]string{
-		"From":         from,
-		"To":           toHeader,
-		"Subject":      subject,
-		"MIME-Version": "1.0",
-		"Content-Type": "text/html; charset=\"UTF-8\"",
-	}
+// logProvider stands in when no provider is configured (local development)
+type logProvider struct{}
 
-	var msgBuilder strings.Builder
-	for k, v := range headers {
-		msgBuilder.WriteString(fmt.Sprintf("%s: %s\r\n", k, v))
-	}
-	msgBuilder.WriteString("\r\n" + htmlBody.String())
-	message := []byte(msgBuilder.String())
+func (logProvider) Name() string { return "log" }
+
+func (logProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	fmt.Printf("📧 Email not sent (no provider configured) to: %s\nSubject: %s\n\n", strings.Join(msg.To, ", "), msg.Subject)
+	return "", ErrEmailNotConfigured
+}
+
+// SMTPProvider sends through an SMTP server with STARTTLS
+type SMTPProvider struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
 
-	// Step 4: Send email with custom TLS config
-	addr := fmt.Sprintf("%s:%s", e.Host, e.Port)
-	fmt.Println("📤 Sending email to:", to, "via", addr)
+func (p *SMTPProvider) Name() string { return "smtp" }
 
-	// ✅ FIX: Use custom SMTP client with TLS config
-	err = e.sendMailWithTLS(addr, to, message)
+func (p *SMTPProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	raw, messageID, err := buildMIME(msg)
 	if err != nil {
-		fmt.Println("❌ Email send failed:", err)
-		return fmt.Errorf("failed to send email: %w", err)
+		return "", err
 	}
-
-	fmt.Println("✅ Email sent successfully to:", to)
-	return nil
+	addr := fmt.Sprintf("%s:%s", p.Host, p.Port)
+	if err := p.sendMailWithTLS(addr, msg.FromAddr, msg.To, raw); err != nil {
+		return "", err
+	}
+	return messageID, nil
 }
 
-// ✅ NEW: Custom send function with proper TLS handling
-func (e *EmailSender) sendMailWithTLS(addr string, to []string, message []byte) error {
+// sendMailWithTLS upgrades the connection with STARTTLS before authenticating
+func (p *SMTPProvider) sendMailWithTLS(addr, from string, to []string, message []byte) error {
 	// Create TLS config - skip verification for Docker environments
 	// This is safe because we're connecting to smtp.gmail.com
 	tlsConfig := &tls.Config{
 		InsecureSkipVerify: true,
-		ServerName:         e.Host,
+		ServerName:         p.Host,
 	}
 
 	// Connect to the SMTP server
@@ -110,13 +127,13 @@ func (e *EmailSender) sendMailWithTLS(addr string, to []string, message []byte)
 	}
 
 	// Authenticate
-	auth := smtp.PlainAuth("", e.Username, e.Password, e.Host)
+	auth := smtp.PlainAuth("", p.Username, p.Password, p.Host)
 	if err = client.Auth(auth); err != nil {
 		return fmt.Errorf("authentication failed: %w", err)
 	}
 
 	// Set sender
-	if err = client.Mail(e.FromAddr); err != nil {
+	if err = client.Mail(from); err != nil {
 		return fmt.Errorf("failed to set sender: %w", err)
 	}
 
@@ -146,4 +163,139 @@ func (e *EmailSender) sendMailWithTLS(addr string, to []string, message []byte)
 
 	// Send QUIT command
 	return client.Quit()
-}
\ No newline at end of file
+}
+
+// buildMIME renders msg as an RFC 5322 message: the HTML body, followed by
+// any attachments. It returns the message and its Message-ID.
+func buildMIME(msg *EmailMessage) ([]byte, string, error) {
+	domain := "localhost"
+	if at := strings.LastIndex(msg.FromAddr, "@"); at >= 0 {
+		domain = msg.FromAddr[at+1:]
+	}
+	messageID := fmt.Sprintf("<%s@%s>", uuid.NewString(), domain)
+
+	var buf bytes.Buffer
+	from := mail.Address{Name: msg.FromName, Address: msg.FromAddr}
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(msg.To, ", "))
+	if msg.ReplyTo != "" {
+		fmt.Fprintf(&buf, "Reply-To: %s\r\n", msg.ReplyTo)
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "Message-ID: %s\r\n", messageID)
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	if len(msg.Attachments) == 0 {
+		buf.WriteString("Content-Type: text/html; charset=\"UTF-8\"\r\n")
+		buf.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&buf, msg.HTML); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), messageID, nil
+	}
+
+	mw := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", mw.Boundary())
+
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {`text/html; charset="UTF-8"`},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	if err := writeQuotedPrintable(part, msg.HTML); err != nil {
+		return nil, "", err
+	}
+
+	for _, a := range msg.Attachments {
+		contentType := a.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		name := mime.QEncoding.Encode("utf-8", filepath.Base(a.FileName))
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {fmt.Sprintf("%s; name=%q", contentType, name)},
+			"Content-Disposition":       {fmt.Sprintf("attachment; filename=%q", name)},
+			"Content-Transfer-Encoding": {"base64"},
+		})
+		if err != nil {
+			return nil, "", err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+	if err := mw.Close(); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), messageID, nil
+}
+
+func writeQuotedPrintable(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// EmailSender implements the Channel interface for bulk notifications,
+// wrapping the message in templates/example.html
+type EmailSender struct {
+	Provider EmailProvider
+	FromName string
+	FromAddr string
+}
+
+// ✅ Accept config instead of using os.Getenv
+func NewEmailSender(cfg *config.Config) *EmailSender {
+	return &EmailSender{
+		Provider: NewEmailProvider(cfg),
+		FromName: cfg.SMTPFromName,
+		FromAddr: cfg.SMTPFromEmail,
+	}
+}
+
+// Send renders the HTML template and sends the email
+func (e *EmailSender) Send(to []string, subject string, body string) error {
+	// Step 1: Load and parse the template
+	tmplPath := filepath.Join("templates", "example.html")
+	tmpl, err := template.ParseFiles(tmplPath)
+	if err != nil {
+		fmt.Println("❌ Failed to parse email template:", err)
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	// Step 2: Inject subject + body
+	var htmlBody bytes.Buffer
+	err = tmpl.Execute(&htmlBody, map[string]string{
+		"Subject": subject,
+		"Body":    body,
+	})
+	if err != nil {
+		fmt.Println("❌ Failed to render email template:", err)
+		return fmt.Errorf("failed to render email template: %w", err)
+	}
+
+	// Step 3: Send through the configured provider
+	fmt.Println("📤 Sending email to:", to, "via", e.Provider.Name())
+	_, err = e.Provider.Send(context.Background(), &EmailMessage{
+		FromName: e.FromName,
+		FromAddr: e.FromAddr,
+		To:       to,
+		Subject:  subject,
+		HTML:     htmlBody.String(),
+	})
+	if err != nil {
+		fmt.Println("❌ Email send failed:", err)
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	fmt.Println("✅ Email sent successfully to:", to)
+	return nil
+}
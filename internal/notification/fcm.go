@@ -8,6 +8,7 @@ import (
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/messaging"
 	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
 	"google.golang.org/api/option"
 )
 
@@ -110,6 +111,7 @@ func (f *FCMChannel) sendSingle(token, title, body string) error {
 
 	response, err := f.client.Send(f.ctx, message)
 	if err != nil {
+		metrics.FCMSendFailures.Inc(metrics.FCMKindToken)
 		return fmt.Errorf("failed to send FCM message: %v", err)
 	}
 
@@ -187,6 +189,7 @@ func (f *FCMChannel) sendMulticast(tokens []string, title, body string) error {
 	}
 
 	if len(failedTokens) > 0 {
+		metrics.FCMSendFailures.Add(float64(len(failedTokens)), metrics.FCMKindToken)
 		return fmt.Errorf("failed to send to %d/%d tokens", len(failedTokens), len(tokens))
 	}
 
@@ -218,6 +221,7 @@ func (f *FCMChannel) SendToTopic(topic, title, body string) error {
 
 	response, err := f.client.Send(f.ctx, message)
 	if err != nil {
+		metrics.FCMSendFailures.Inc(metrics.FCMKindTopic)
 		return fmt.Errorf("failed to send topic message: %v", err)
 	}
 
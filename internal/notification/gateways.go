@@ -0,0 +1,508 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/config"
+)
+
+// SMS and WhatsApp gateways. Messages are sent one recipient per request so
+// each gets its own provider message ID to match delivery reports against.
+
+// Text channels
+const (
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// Supported providers
+const (
+	GatewayTwilio  = "twilio"
+	GatewayMSG91   = "msg91"
+	GatewayGupshup = "gupshup"
+)
+
+var (
+	TextChannels = []string{ChannelSMS, ChannelWhatsApp}
+	Gateways     = []string{GatewayTwilio, GatewayMSG91, GatewayGupshup}
+
+	// ErrGatewayNotConfigured is returned when neither the temple nor the
+	// platform has a provider for the channel; such messages are only logged
+	ErrGatewayNotConfigured = errors.New("messaging gateway not configured")
+	ErrChannelNotSupported  = errors.New("provider does not support this channel")
+)
+
+// GatewayCredentials are the account details of a provider; each uses a subset
+type GatewayCredentials struct {
+	AccountSID string `json:"account_sid,omitempty"` // twilio
+	AuthToken  string `json:"auth_token,omitempty"`  // twilio
+	AuthKey    string `json:"auth_key,omitempty"`    // msg91
+	APIKey     string `json:"api_key,omitempty"`     // gupshup WhatsApp
+	AppName    string `json:"app_name,omitempty"`    // gupshup WhatsApp
+	UserID     string `json:"user_id,omitempty"`     // gupshup SMS
+	Password   string `json:"password,omitempty"`    // gupshup SMS
+	Sender     string `json:"sender"`                // number, messaging service SID, DLT sender ID or WhatsApp source
+}
+
+// TextMessage is one SMS or WhatsApp message as handed to a gateway
+type TextMessage struct {
+	Channel   string
+	To        string // 10 digit Indian number or international with country code
+	Body      string
+	ReportURL string // delivery report callback, for providers taking one per message
+}
+
+// MessageGateway delivers text messages. Send returns the provider's message
+// ID, which its delivery reports refer to.
+type MessageGateway interface {
+	Name() string
+	Send(ctx context.Context, msg *TextMessage) (string, error)
+}
+
+// DeliveryReport is a status update a provider posted about a sent message
+type DeliveryReport struct {
+	MessageID string
+	Status    string // MessageStatus*
+	Error     string
+}
+
+var gatewayHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// NewMessageGateway builds the gateway of provider for channel, checking the
+// credentials it needs are present
+func NewMessageGateway(provider, channel string, creds GatewayCredentials) (MessageGateway, error) {
+	missing := func(fields ...string) error {
+		return fmt.Errorf("%w: %s needs %s", ErrInvalidMessagingSettings, provider, strings.Join(fields, ", "))
+	}
+	switch provider {
+	case GatewayTwilio:
+		if creds.AccountSID == "" || creds.AuthToken == "" || creds.Sender == "" {
+			return nil, missing("account_sid", "auth_token", "sender")
+		}
+		return &TwilioGateway{Credentials: creds, BaseURL: "https://api.twilio.com"}, nil
+	case GatewayMSG91:
+		if channel != ChannelSMS {
+			return nil, fmt.Errorf("%w: msg91 only sends SMS", ErrChannelNotSupported)
+		}
+		if creds.AuthKey == "" || creds.Sender == "" {
+			return nil, missing("auth_key", "sender")
+		}
+		return &MSG91Gateway{Credentials: creds, BaseURL: "https://api.msg91.com"}, nil
+	case GatewayGupshup:
+		if channel == ChannelWhatsApp && (creds.APIKey == "" || creds.AppName == "" || creds.Sender == "") {
+			return nil, missing("api_key", "app_name", "sender")
+		}
+		if channel == ChannelSMS && (creds.UserID == "" || creds.Password == "") {
+			return nil, missing("user_id", "password")
+		}
+		return &GupshupGateway{
+			Credentials:   creds,
+			WhatsAppURL:   "https://api.gupshup.io/wa/api/v1/msg",
+			EnterpriseURL: "https://enterprise.smsgupshup.com/GatewayAPI/rest",
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: unknown provider %q", ErrInvalidMessagingSettings, provider)
+}
+
+// platformGateways builds the gateways configured through the environment
+func platformGateways(cfg *config.Config) map[string]MessageGateway {
+	creds := map[string]GatewayCredentials{
+		GatewayTwilio:  {AccountSID: cfg.TwilioAccountSID, AuthToken: cfg.TwilioAuthToken},
+		GatewayMSG91:   {AuthKey: cfg.MSG91AuthKey, Sender: cfg.MSG91SenderID},
+		GatewayGupshup: {APIKey: cfg.GupshupAPIKey, AppName: cfg.GupshupAppName, UserID: cfg.GupshupSMSUserID, Password: cfg.GupshupSMSPassword},
+	}
+	// Gupshup enterprise SMS sends from the account's default mask
+	senders := map[string]map[string]string{
+		ChannelSMS:      {GatewayTwilio: cfg.TwilioSMSFrom, GatewayMSG91: cfg.MSG91SenderID},
+		ChannelWhatsApp: {GatewayTwilio: cfg.TwilioWhatsAppFrom, GatewayGupshup: cfg.GupshupWhatsAppSource},
+	}
+
+	gateways := map[string]MessageGateway{}
+	for channel, provider := range map[string]string{ChannelSMS: cfg.SMSProvider, ChannelWhatsApp: cfg.WhatsAppProvider} {
+		if provider == "" {
+			continue
+		}
+		c := creds[provider]
+		c.Sender = senders[channel][provider]
+		gateway, err := NewMessageGateway(provider, channel, c)
+		if err != nil {
+			fmt.Printf("⚠️ %s gateway disabled: %v\n", channel, err)
+			continue
+		}
+		gateways[channel] = gateway
+	}
+	return gateways
+}
+
+var nonDigits = regexp.MustCompile(`\D`)
+
+// msisdn returns phone as digits with the country code, assuming India for
+// 10 digit numbers
+func msisdn(phone string) string {
+	digits := nonDigits.ReplaceAllString(phone, "")
+	if len(digits) == 10 {
+		return "91" + digits
+	}
+	return digits
+}
+
+// readGatewayResponse reads a provider response, turning non-2xx statuses into errors
+func readGatewayResponse(provider string, resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return fmt.Errorf("%s returned %d: %s", provider, resp.StatusCode, apiErr.Message)
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("invalid %s response: %w", provider, err)
+	}
+	return nil
+}
+
+// ===== TWILIO =====
+
+// TwilioGateway sends SMS and WhatsApp through the Twilio Messages API
+type TwilioGateway struct {
+	Credentials GatewayCredentials
+	BaseURL     string
+}
+
+func (g *TwilioGateway) Name() string { return GatewayTwilio }
+
+func (g *TwilioGateway) Send(ctx context.Context, msg *TextMessage) (string, error) {
+	form := url.Values{}
+	form.Set("To", "+"+msisdn(msg.To))
+	form.Set("Body", msg.Body)
+	switch {
+	case msg.Channel == ChannelWhatsApp:
+		form.Set("To", "whatsapp:+"+msisdn(msg.To))
+		form.Set("From", "whatsapp:"+strings.TrimPrefix(g.Credentials.Sender, "whatsapp:"))
+	case strings.HasPrefix(g.Credentials.Sender, "MG"):
+		form.Set("MessagingServiceSid", g.Credentials.Sender)
+	default:
+		form.Set("From", g.Credentials.Sender)
+	}
+	if msg.ReportURL != "" {
+		form.Set("StatusCallback", msg.ReportURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", g.BaseURL, url.PathEscape(g.Credentials.AccountSID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(g.Credentials.AccountSID, g.Credentials.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := gatewayHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("twilio request failed: %w", err)
+	}
+	var out struct {
+		SID string `json:"sid"`
+	}
+	if err := readGatewayResponse(GatewayTwilio, resp, &out); err != nil {
+		return "", err
+	}
+	return out.SID, nil
+}
+
+// twilioReports reads a Twilio status callback
+func twilioReports(r *http.Request) ([]DeliveryReport, error) {
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	id := r.PostForm.Get("MessageSid")
+	if id == "" {
+		return nil, errors.New("missing MessageSid")
+	}
+	report := DeliveryReport{MessageID: id}
+	switch r.PostForm.Get("MessageStatus") {
+	case "queued", "accepted", "scheduled", "sending":
+		report.Status = MessageStatusQueued
+	case "sent":
+		report.Status = MessageStatusSent
+	case "delivered":
+		report.Status = MessageStatusDelivered
+	case "read":
+		report.Status = MessageStatusRead
+	default: // undelivered, failed, canceled
+		report.Status = MessageStatusFailed
+		report.Error = strings.TrimSpace(r.PostForm.Get("MessageStatus") + " " + r.PostForm.Get("ErrorCode"))
+	}
+	return []DeliveryReport{report}, nil
+}
+
+// ===== MSG91 =====
+
+// MSG91Gateway sends SMS through MSG91. Its delivery report webhook is set up
+// on the MSG91 panel.
+type MSG91Gateway struct {
+	Credentials GatewayCredentials
+	BaseURL     string
+}
+
+func (g *MSG91Gateway) Name() string { return GatewayMSG91 }
+
+func (g *MSG91Gateway) Send(ctx context.Context, msg *TextMessage) (string, error) {
+	if msg.Channel != ChannelSMS {
+		return "", ErrChannelNotSupported
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"sender":  g.Credentials.Sender,
+		"route":   "4", // transactional
+		"country": "0", // numbers carry their country code
+		"sms":     []map[string]interface{}{{"message": msg.Body, "to": []string{msisdn(msg.To)}}},
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.BaseURL+"/api/v2/sendsms", strings.NewReader(string(payload)))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("authkey", g.Credentials.AuthKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := gatewayHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("msg91 request failed: %w", err)
+	}
+	var out struct {
+		Type    string `json:"type"`
+		Message string `json:"message"` // the request ID on success
+	}
+	if err := readGatewayResponse(GatewayMSG91, resp, &out); err != nil {
+		return "", err
+	}
+	if out.Type != "success" {
+		return "", fmt.Errorf("msg91 rejected the message: %s", out.Message)
+	}
+	return out.Message, nil
+}
+
+// msg91Reports reads an MSG91 delivery report, posted as a JSON array either
+// as the body or in its "data" form field
+func msg91Reports(r *http.Request) ([]DeliveryReport, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if values, err := url.ParseQuery(string(body)); err == nil && values.Get("data") != "" {
+		body = []byte(values.Get("data"))
+	}
+	var requests []struct {
+		RequestID string `json:"requestId"`
+		Report    []struct {
+			Status string `json:"status"`
+			Desc   string `json:"desc"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal(body, &requests); err != nil {
+		return nil, fmt.Errorf("invalid msg91 report: %w", err)
+	}
+	var reports []DeliveryReport
+	for _, req := range requests {
+		for _, rep := range req.Report {
+			report := DeliveryReport{MessageID: req.RequestID}
+			switch rep.Status {
+			case "1":
+				report.Status = MessageStatusDelivered
+			case "8":
+				report.Status = MessageStatusSent
+			default:
+				report.Status = MessageStatusFailed
+				report.Error = rep.Desc
+			}
+			reports = append(reports, report)
+		}
+	}
+	return reports, nil
+}
+
+// ===== GUPSHUP =====
+
+// GupshupGateway sends WhatsApp through the Gupshup WhatsApp API and SMS
+// through the Gupshup enterprise SMS API. Its delivery report webhooks are set
+// up on the Gupshup dashboard.
+type GupshupGateway struct {
+	Credentials   GatewayCredentials
+	WhatsAppURL   string
+	EnterpriseURL string
+}
+
+func (g *GupshupGateway) Name() string { return GatewayGupshup }
+
+func (g *GupshupGateway) Send(ctx context.Context, msg *TextMessage) (string, error) {
+	if msg.Channel == ChannelWhatsApp {
+		return g.sendWhatsApp(ctx, msg)
+	}
+	return g.sendSMS(ctx, msg)
+}
+
+func (g *GupshupGateway) sendWhatsApp(ctx context.Context, msg *TextMessage) (string, error) {
+	text, _ := json.Marshal(map[string]string{"type": "text", "text": msg.Body})
+	form := url.Values{}
+	form.Set("channel", "whatsapp")
+	form.Set("source", msisdn(g.Credentials.Sender))
+	form.Set("destination", msisdn(msg.To))
+	form.Set("src.name", g.Credentials.AppName)
+	form.Set("message", string(text))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.WhatsAppURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("apikey", g.Credentials.APIKey)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := gatewayHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gupshup request failed: %w", err)
+	}
+	var out struct {
+		Status    string `json:"status"`
+		MessageID string `json:"messageId"`
+		Message   string `json:"message"`
+	}
+	if err := readGatewayResponse(GatewayGupshup, resp, &out); err != nil {
+		return "", err
+	}
+	if out.Status != "submitted" {
+		return "", fmt.Errorf("gupshup rejected the message: %s", out.Message)
+	}
+	return out.MessageID, nil
+}
+
+func (g *GupshupGateway) sendSMS(ctx context.Context, msg *TextMessage) (string, error) {
+	query := url.Values{}
+	query.Set("method", "SendMessage")
+	query.Set("send_to", msisdn(msg.To))
+	query.Set("msg", msg.Body)
+	query.Set("msg_type", "TEXT")
+	query.Set("userid", g.Credentials.UserID)
+	query.Set("auth_scheme", "plain")
+	query.Set("password", g.Credentials.Password)
+	query.Set("v", "1.1")
+	query.Set("format", "json")
+	if g.Credentials.Sender != "" {
+		query.Set("mask", g.Credentials.Sender)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.EnterpriseURL, strings.NewReader(query.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := gatewayHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("gupshup request failed: %w", err)
+	}
+	var out struct {
+		Response struct {
+			ID      string `json:"id"`
+			Status  string `json:"status"`
+			Details string `json:"details"`
+		} `json:"response"`
+	}
+	if err := readGatewayResponse(GatewayGupshup, resp, &out); err != nil {
+		return "", err
+	}
+	if out.Response.Status != "success" {
+		return "", fmt.Errorf("gupshup rejected the message: %s", out.Response.Details)
+	}
+	return out.Response.ID, nil
+}
+
+// gupshupReports reads a Gupshup WhatsApp message event (JSON) or an
+// enterprise SMS delivery report (query or form parameters)
+func gupshupReports(r *http.Request) ([]DeliveryReport, error) {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		var event struct {
+			Type    string `json:"type"`
+			Payload struct {
+				ID      string `json:"id"`
+				GsID    string `json:"gsId"`
+				Type    string `json:"type"`
+				Payload struct {
+					Reason string `json:"reason"`
+				} `json:"payload"`
+			} `json:"payload"`
+		}
+		if err := json.NewDecoder(io.LimitReader(r.Body, 1<<20)).Decode(&event); err != nil {
+			return nil, fmt.Errorf("invalid gupshup event: %w", err)
+		}
+		if event.Type != "message-event" {
+			return nil, nil // inbound messages and user events are not delivery reports
+		}
+		report := DeliveryReport{MessageID: event.Payload.GsID}
+		if report.MessageID == "" {
+			report.MessageID = event.Payload.ID
+		}
+		switch event.Payload.Type {
+		case "enqueued":
+			report.Status = MessageStatusQueued
+		case "sent":
+			report.Status = MessageStatusSent
+		case "delivered":
+			report.Status = MessageStatusDelivered
+		case "read":
+			report.Status = MessageStatusRead
+		default:
+			report.Status = MessageStatusFailed
+			report.Error = event.Payload.Payload.Reason
+		}
+		return []DeliveryReport{report}, nil
+	}
+
+	if err := r.ParseForm(); err != nil {
+		return nil, err
+	}
+	id := r.Form.Get("externalId")
+	if id == "" {
+		return nil, errors.New("missing externalId")
+	}
+	report := DeliveryReport{MessageID: id, Status: MessageStatusDelivered}
+	if status := strings.ToUpper(r.Form.Get("status")); status != "SUCCESS" {
+		report.Status = MessageStatusFailed
+		report.Error = strings.TrimSpace(status + " " + r.Form.Get("cause"))
+	}
+	return []DeliveryReport{report}, nil
+}
+
+// parseDeliveryReports reads the delivery report a provider posted
+func parseDeliveryReports(provider string, r *http.Request) ([]DeliveryReport, error) {
+	switch provider {
+	case GatewayTwilio:
+		return twilioReports(r)
+	case GatewayMSG91:
+		return msg91Reports(r)
+	case GatewayGupshup:
+		return gupshupReports(r)
+	}
+	return nil, fmt.Errorf("unknown provider %q", provider)
+}
+
+// logGateway stands in when no gateway is configured (local development)
+type logGateway struct{}
+
+func (logGateway) Name() string { return "log" }
+
+func (logGateway) Send(ctx context.Context, msg *TextMessage) (string, error) {
+	fmt.Printf("📲 %s not sent (no gateway configured) to: %s\nMessage: %s\n\n", msg.Channel, msg.To, msg.Body)
+	return "", ErrGatewayNotConfigured
+}
@@ -2,21 +2,22 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"github.com/sharath018/temple-management-backend/config"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/middleware"
-	"github.com/sharath018/temple-management-backend/utils"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
-	Service  Service
-	AuditSvc auditlog.Service
+	Service   Service
+	AuditSvc  auditlog.Service
+	Mailer    *Mailer
+	Messenger *Messenger
 }
 
 func NewHandler(s Service, auditSvc auditlog.Service) *Handler {
@@ -59,6 +60,10 @@ func (h *Handler) CreateTemplate(c *gin.Context) {
 	input.EntityID = *entityID
 
 	if err := h.Service.CreateTemplate(c.Request.Context(), &input, ip); err != nil {
+		if errors.Is(err, ErrInvalidTemplate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create template"})
 		return
 	}
@@ -162,6 +167,14 @@ func (h *Handler) UpdateTemplate(c *gin.Context) {
 	input.EntityID = *entityID
 
 	if err := h.Service.UpdateTemplate(c.Request.Context(), &input, ip); err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidTemplate):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "template not found"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update template"})
 		return
 	}
@@ -230,12 +243,13 @@ func (h *Handler) SendNotification(c *gin.Context) {
 	ip := middleware.GetIPFromContext(c)
 
 	var req struct {
-		TemplateID *uint    `json:"template_id"`
-		Channel    string   `json:"channel" binding:"required"` // email, sms, whatsapp, push
-		Subject    string   `json:"subject"`
-		Body       string   `json:"body" binding:"required"`
-		Recipients []string `json:"recipients"`
-		Audience   string   `json:"audience"` // all, devotees, volunteers
+		TemplateID *uint             `json:"template_id"`
+		Channel    string            `json:"channel" binding:"required"` // email, sms, whatsapp, push
+		Subject    string            `json:"subject"`
+		Body       string            `json:"body"` // defaults to the template body when template_id is set
+		Recipients []string          `json:"recipients"`
+		Audience   string            `json:"audience"`  // all, devotees, volunteers
+		Variables  map[string]string `json:"variables"` // template variable values, see /notifications/template-variables
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -243,6 +257,31 @@ func (h *Handler) SendNotification(c *gin.Context) {
 		return
 	}
 
+	// Untouched templates are sent in each recipient's language where an
+	// approved translation exists
+	localized := req.TemplateID != nil && req.Subject == "" && req.Body == ""
+
+	// Templates are rendered server-side so unknown variables are rejected before sending
+	if req.TemplateID != nil || len(req.Variables) > 0 {
+		rendered, err := h.Service.RenderTemplate(c.Request.Context(), *entityID, RenderTemplateRequest{
+			TemplateID: req.TemplateID,
+			Subject:    req.Subject,
+			Body:       req.Body,
+			Variables:  req.Variables,
+		})
+		if err != nil {
+			respondRenderError(c, err)
+			return
+		}
+		req.Subject = rendered.Subject
+		req.Body = rendered.Body
+	}
+
+	if req.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "body is required"})
+		return
+	}
+
 	// If recipients not provided, resolve using audience
 	if len(req.Recipients) == 0 {
 		switch req.Audience {
@@ -271,7 +310,14 @@ func (h *Handler) SendNotification(c *gin.Context) {
 	// Send notification asynchronously
 	go func() {
 		bgCtx := context.Background()
-		
+
+		if localized {
+			if err := h.Service.SendLocalizedNotification(bgCtx, ctx.UserID, *entityID, *req.TemplateID, req.Channel, req.Variables, req.Recipients, ip); err != nil {
+				fmt.Printf("❌ Background notification send error: %v\n", err)
+			}
+			return
+		}
+
 		if err := h.Service.SendNotification(
 			bgCtx,
 			ctx.UserID,
@@ -294,6 +340,75 @@ func (h *Handler) SendNotification(c *gin.Context) {
 	})
 }
 
+// GET /api/v1/notifications/template-variables?type=seva_booking
+func (h *Handler) GetTemplateVariables(c *gin.Context) {
+	notificationType := c.Query("type")
+	if notificationType == "" {
+		catalog := make(map[string][]TemplateVariable)
+		for _, t := range NotificationTypes() {
+			vars, _ := VariablesFor(t)
+			catalog[t] = vars
+		}
+		c.JSON(http.StatusOK, gin.H{"types": NotificationTypes(), "variables": catalog})
+		return
+	}
+
+	vars, err := VariablesFor(notificationType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"notification_type": notificationType, "variables": vars})
+}
+
+// POST /api/v1/notifications/templates/preview
+func (h *Handler) PreviewTemplate(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+
+	ctx := accessContext.(middleware.AccessContext)
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	var req RenderTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.TemplateID == nil && req.Body == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "template_id or body is required"})
+		return
+	}
+
+	rendered, err := h.Service.RenderTemplate(c.Request.Context(), *entityID, req)
+	if err != nil {
+		respondRenderError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, rendered)
+}
+
+func respondRenderError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidTemplate):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "template or record not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render template"})
+	}
+}
+
 // GET /api/v1/notifications/logs
 func (h *Handler) GetMyNotifications(c *gin.Context) {
 	accessContext, exists := c.Get("access_context")
@@ -358,6 +473,9 @@ func (h *Handler) MarkInAppRead(c *gin.Context) {
 }
 
 // GET /api/v1/notifications/stream (SSE)
+// Pushes in-app notifications, read receipts and approval status changes.
+// EventSource cannot set headers, so browsers pass the access token as
+// ?token= (see middleware.AuthMiddleware).
 func (h *Handler) StreamInApp(c *gin.Context) {
 	accessContext, exists := c.Get("access_context")
 	if !exists {
@@ -366,127 +484,7 @@ func (h *Handler) StreamInApp(c *gin.Context) {
 	}
 	ctx := accessContext.(middleware.AccessContext)
 
-	// ✅ Set CORS headers for SSE
-	origin := c.GetHeader("Origin")
-	if origin == "" {
-		origin = "http://localhost:4173"
-	}
-	c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-	
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
-
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		c.Status(http.StatusInternalServerError)
-		return
-	}
-
-	channel := "notifications:user:" + strconv.FormatUint(uint64(ctx.UserID), 10)
-	sub := utils.RedisClient.Subscribe(c, channel)
-	defer sub.Close()
-
-	_, _ = c.Writer.Write([]byte(":ok\n\n"))
-	flusher.Flush()
-
-	ch := sub.Channel()
-	for {
-		select {
-		case msg, ok := <-ch:
-			if !ok {
-				return
-			}
-			_, _ = c.Writer.Write([]byte("event: inapp\n"))
-			_, _ = c.Writer.Write([]byte("data: " + msg.Payload + "\n\n"))
-			flusher.Flush()
-		case <-c.Request.Context().Done():
-			return
-		}
-	}
-}
-
-// GET /api/v1/notifications/stream-token?token=JWT (SSE without auth middleware)
-func (h *Handler) StreamInAppWithToken(c *gin.Context) {
-	tokenStr := c.Query("token")
-	if tokenStr == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
-		return
-	}
-
-	cfg := config.Load()
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		return []byte(cfg.JWTAccessSecret), nil
-	})
-	if err != nil || !token.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
-		return
-	}
-
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid claims"})
-		return
-	}
-	uidFloat, ok := claims["user_id"].(float64)
-	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "user_id missing"})
-		return
-	}
-	userID := uint(uidFloat)
-
-	// ✅ CRITICAL FIX: Set specific CORS headers for SSE (cannot use wildcard with credentials)
-	origin := c.GetHeader("Origin")
-	allowedOrigins := map[string]bool{
-		"http://localhost:5173":   true,
-		"http://127.0.0.1:5173":   true,
-		"http://localhost:4173":   true,
-		"http://127.0.0.1:4173":   true,
-	}
-	
-	// Validate origin and set specific header
-	if origin == "" || !allowedOrigins[origin] {
-		origin = "http://localhost:4173" // Default fallback
-	}
-	
-	c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
-	c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-	c.Writer.Header().Set("Access-Control-Expose-Headers", "Content-Type")
-	
-	c.Writer.Header().Set("Content-Type", "text/event-stream")
-	c.Writer.Header().Set("Cache-Control", "no-cache")
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Accel-Buffering", "no")
-
-	flusher, ok := c.Writer.(http.Flusher)
-	if !ok {
-		c.Status(http.StatusInternalServerError)
-		return
-	}
-
-	channel := "notifications:user:" + strconv.FormatUint(uint64(userID), 10)
-	sub := utils.RedisClient.Subscribe(c, channel)
-	defer sub.Close()
-
-	_, _ = c.Writer.Write([]byte(":ok\n\n"))
-	flusher.Flush()
-
-	ch := sub.Channel()
-	for {
-		select {
-		case msg, ok := <-ch:
-			if !ok {
-				return
-			}
-			_, _ = c.Writer.Write([]byte("event: inapp\n"))
-			_, _ = c.Writer.Write([]byte("data: " + msg.Payload + "\n\n"))
-			flusher.Flush()
-		case <-c.Request.Context().Done():
-			return
-		}
-	}
+	h.stream(c, ctx.UserID)
 }
 
 // ✅ NEW: POST /api/v1/notifications/fcm/register
@@ -622,4 +620,14 @@ func (h *Handler) SendFCMNotification(c *gin.Context) {
 		"message": "push notification queued for sending",
 		"status":  "processing",
 	})
-}
\ No newline at end of file
+}
+// GET /api/v1/notifications/producer/metrics (superadmin)
+// Kafka producer health and the number of notifications buffered locally
+func (h *Handler) GetProducerMetrics(c *gin.Context) {
+	p := DefaultProducer()
+	if p == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "kafka producer not started"})
+		return
+	}
+	c.JSON(http.StatusOK, p.Metrics())
+}
@@ -5,16 +5,25 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/segmentio/kafka-go"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/utils"
+	"gorm.io/gorm"
 )
 
 var kafkaWriter *kafka.Writer
 
-// 🔁 StartKafkaConsumer launches the background worker to process notifications
-func StartKafkaConsumer(svc Service) {
+// 🔁 StartKafkaConsumer launches the background worker to process notifications.
+// It stops when ctx is cancelled, after finishing the message in hand; the
+// returned channel is closed once the reader is closed.
+func StartKafkaConsumer(ctx context.Context, svc Service) <-chan struct{} {
+	done := make(chan struct{})
 	go func() {
+		defer close(done)
+
 		brokerURL := "kafka:9092" // must match docker-compose
 		topic := "notifications"
 
@@ -25,15 +34,25 @@ func StartKafkaConsumer(svc Service) {
 			MinBytes: 10e3, // 10KB
 			MaxBytes: 10e6, // 10MB
 		})
+		defer func() {
+			if err := r.Close(); err != nil {
+				log.Printf("⚠️ Kafka reader close error: %v", err)
+			}
+			fmt.Println("🛑 Kafka Notification Worker Stopped")
+		}()
 
 		fmt.Println("🔁 Kafka Notification Worker Started...")
 
 		for {
-			m, err := r.ReadMessage(context.Background())
+			m, err := r.ReadMessage(ctx)
 			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
 				log.Printf("❌ Kafka read error: %v", err)
 				continue
 			}
+			metrics.KafkaConsumerLag.Set(float64(max(m.HighWaterMark-m.Offset-1, 0)), m.Topic, strconv.Itoa(m.Partition))
 
 			var msg NotificationMessage
 			if err := json.Unmarshal(m.Value, &msg); err != nil {
@@ -67,21 +86,43 @@ func StartKafkaConsumer(svc Service) {
 			}
 		}
 	}()
+	return done
+}
+
+// defaultProducer buffers notifications while Kafka is down (see StartProducer)
+var defaultProducer *Producer
+
+// StartProducer routes PublishNotification through a buffering producer on
+// the shared Kafka writer and starts its health check and replay loop
+func StartProducer(ctx context.Context, db *gorm.DB, bufferLimit int) *Producer {
+	p := NewProducer(utils.GetKafkaWriter(), db, bufferLimit)
+	p.Start(ctx)
+	defaultProducer = p
+	return p
+}
+
+// DefaultProducer returns the producer started by StartProducer, or nil
+func DefaultProducer() *Producer {
+	return defaultProducer
 }
 
 // 🔼 PublishNotification sends a message to the Kafka topic
 func PublishNotification(msg NotificationMessage) error {
-	initKafkaWriter()
-
 	payload, err := json.Marshal(msg)
 	if err != nil {
 		return err
 	}
 
-	return kafkaWriter.WriteMessages(context.Background(), kafka.Message{
+	message := kafka.Message{
 		Key:   []byte(time.Now().Format(time.RFC3339Nano)),
 		Value: payload,
-	})
+	}
+	if defaultProducer != nil {
+		return defaultProducer.Publish(context.Background(), message)
+	}
+
+	initKafkaWriter()
+	return kafkaWriter.WriteMessages(context.Background(), message)
 }
 
 // 🔧 Initialize the Kafka writer (used by PublishNotification)
@@ -0,0 +1,551 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Transactional emails (approval results, booking confirmations, donation
+// receipts) are rendered from the HTML templates in email_templates and sent
+// one per recipient through the configured EmailProvider, from the temple's
+// own sender settings. Every recipient gets an EmailDelivery row recording
+// whether the email went out.
+
+//go:embed email_templates/*.html
+var emailTemplateFS embed.FS
+
+// Transactional email templates
+const (
+	EmailApprovalResult      = "approval_result"
+	EmailBookingConfirmation = "booking_confirmation"
+	EmailDonationReceipt     = "donation_receipt"
+	EmailGreeting            = "greeting" // birthday and anniversary wishes
+	EmailEventReminder       = "event_reminder"
+	EmailStaffInvitation     = "staff_invitation"
+)
+
+// Delivery statuses
+const (
+	EmailStatusPending    = "pending"
+	EmailStatusSent       = "sent"
+	EmailStatusFailed     = "failed"
+	EmailStatusSuppressed = "suppressed" // the recipient turned off email for the category
+	EmailStatusSkipped    = "skipped"    // no email provider is configured
+)
+
+var EmailTemplates = []string{EmailApprovalResult, EmailBookingConfirmation, EmailDonationReceipt, EmailGreeting, EmailEventReminder, EmailStaffInvitation}
+
+// Subjects are plain text templates over the same data as the body
+var emailSubjects = map[string]string{
+	EmailApprovalResult:      `{{if .Approved}}{{if eq .Kind "entity"}}Your temple "{{.TempleName}}" has been approved{{else}}Your account has been approved{{end}}{{else}}{{if eq .Kind "entity"}}Your temple "{{.TempleName}}" was not approved{{else}}Your account request was not approved{{end}}{{end}}`,
+	EmailBookingConfirmation: `Booking confirmed: {{.SevaName}}`,
+	EmailDonationReceipt:     `Donation receipt {{.ReceiptNumber}}{{if .TempleName}} from {{.TempleName}}{{end}}`,
+	EmailGreeting:            `{{.Title}}`,
+	EmailEventReminder:       `Reminder: {{.EventTitle}} {{.StartsIn}}`,
+	EmailStaffInvitation:     `You're invited to help manage {{.TempleName}}`,
+}
+
+// Preference category each template can be turned off under
+var emailCategories = map[string]string{
+	EmailApprovalResult:      PreferenceApprovals,
+	EmailBookingConfirmation: PreferenceBookings,
+	EmailDonationReceipt:     PreferenceDonations,
+	EmailGreeting:            PreferenceBirthdays,
+}
+
+var ErrInvalidEmailSettings = errors.New("invalid email settings")
+
+// EmailSettings is a temple's sender configuration. Unset fields fall back to
+// the temple name and the platform sender.
+type EmailSettings struct {
+	EntityID  uint      `gorm:"primaryKey;autoIncrement:false" json:"entity_id"`
+	FromName  string    `gorm:"size:100" json:"from_name"`
+	FromEmail string    `gorm:"size:255" json:"from_email"` // must be on one of EMAIL_SENDER_DOMAINS
+	ReplyTo   string    `gorm:"size:255" json:"reply_to"`
+	UpdatedBy uint      `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (EmailSettings) TableName() string { return "entity_email_settings" }
+
+// EmailSettingsInput replaces a temple's sender settings
+type EmailSettingsInput struct {
+	FromName  string `json:"from_name"`
+	FromEmail string `json:"from_email"`
+	ReplyTo   string `json:"reply_to"`
+}
+
+// EmailSettingsResponse shows the stored settings with what unset fields fall back to
+type EmailSettingsResponse struct {
+	EmailSettings
+	DefaultFromName      string   `json:"default_from_name"`
+	DefaultFromEmail     string   `json:"default_from_email"`
+	AllowedSenderDomains []string `json:"allowed_sender_domains"`
+	Provider             string   `json:"provider"`
+}
+
+// EmailDelivery tracks one transactional email to one recipient
+type EmailDelivery struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	EntityID  uint       `gorm:"not null;index" json:"entity_id"` // 0 for platform emails, e.g. tenant approvals
+	UserID    *uint      `gorm:"index" json:"user_id,omitempty"`
+	Template  string     `gorm:"size:50;not null;index" json:"template"`
+	Recipient string     `gorm:"size:255;not null" json:"recipient"`
+	Subject   string     `gorm:"size:255" json:"subject"`
+	Provider  string     `gorm:"size:20" json:"provider"`
+	MessageID string     `gorm:"size:255" json:"message_id,omitempty"`
+	Status    string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Error     *string    `gorm:"type:text" json:"error,omitempty"`
+	SentAt    *time.Time `json:"sent_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// EmailDeliveryFilter narrows the delivery list of a temple
+type EmailDeliveryFilter struct {
+	Status   string
+	Template string
+	Page     int
+	Limit    int
+}
+
+// ===== REPOSITORY =====
+
+// GetEmailSettings returns the temple's sender settings, or nil if it has none
+func (r *repository) GetEmailSettings(ctx context.Context, entityID uint) (*EmailSettings, error) {
+	var settings EmailSettings
+	err := r.db.WithContext(ctx).Where("entity_id = ?", entityID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *repository) SaveEmailSettings(ctx context.Context, settings *EmailSettings) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"from_name", "from_email", "reply_to", "updated_by", "updated_at"}),
+	}).Create(settings).Error
+}
+
+func (r *repository) CreateEmailDelivery(ctx context.Context, d *EmailDelivery) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *repository) UpdateEmailDelivery(ctx context.Context, d *EmailDelivery) error {
+	return r.db.WithContext(ctx).Save(d).Error
+}
+
+func (r *repository) ListEmailDeliveries(ctx context.Context, entityID uint, filter EmailDeliveryFilter) ([]EmailDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&EmailDelivery{}).Where("entity_id = ?", entityID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Template != "" {
+		query = query.Where("template = ?", filter.Template)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []EmailDelivery
+	err := query.Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+// GetUserContact returns the email address and name of a user
+func (r *repository) GetUserContact(ctx context.Context, userID uint) (string, string, error) {
+	var contact struct {
+		Email    string
+		FullName string
+	}
+	err := r.db.WithContext(ctx).Table("users").
+		Select("email, full_name").
+		Where("id = ?", userID).
+		Take(&contact).Error
+	return contact.Email, contact.FullName, err
+}
+
+func (r *repository) GetEntityName(ctx context.Context, entityID uint) (string, error) {
+	var name string
+	err := r.db.WithContext(ctx).Table("entities").Where("id = ?", entityID).Pluck("name", &name).Error
+	return name, err
+}
+
+// ===== SERVICE =====
+
+// Mailer sends the transactional emails
+type Mailer struct {
+	repo     Repository
+	auditSvc auditlog.Service
+	provider EmailProvider
+
+	fromName      string
+	fromAddr      string
+	senderDomains []string
+	frontendURL   string
+
+	templates map[string]*template.Template
+	subjects  map[string]*texttemplate.Template
+}
+
+func NewMailer(repo Repository, cfg *config.Config, auditSvc auditlog.Service) *Mailer {
+	m := &Mailer{
+		repo:          repo,
+		auditSvc:      auditSvc,
+		provider:      NewEmailProvider(cfg),
+		fromName:      cfg.SMTPFromName,
+		fromAddr:      cfg.SMTPFromEmail,
+		senderDomains: cfg.EmailSenderDomains,
+		frontendURL:   cfg.FrontendURL,
+		templates:     map[string]*template.Template{},
+		subjects:      map[string]*texttemplate.Template{},
+	}
+	if m.fromAddr == "" {
+		m.fromAddr = cfg.SMTPUsername
+	}
+	for _, name := range EmailTemplates {
+		m.templates[name] = template.Must(template.ParseFS(emailTemplateFS, "email_templates/layout.html", "email_templates/"+name+".html"))
+		m.subjects[name] = texttemplate.Must(texttemplate.New(name).Parse(emailSubjects[name]))
+	}
+	return m
+}
+
+// SendToUser emails a user of a temple (entityID 0 for platform emails),
+// unless they turned off email for the template's category
+func (m *Mailer) SendToUser(ctx context.Context, entityID, userID uint, name string, data map[string]interface{}, attachments ...EmailAttachment) error {
+	email, fullName, err := m.repo.GetUserContact(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to look up user %d: %w", userID, err)
+	}
+	if email == "" {
+		return nil
+	}
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	if _, ok := data["Name"]; !ok {
+		data["Name"] = fullName
+	}
+
+	if category := emailCategories[name]; category != "" {
+		disabled, err := m.repo.IsChannelDisabled(ctx, userID, category, PreferenceChannelEmail)
+		if err != nil {
+			// Deliver rather than silently drop when preferences can't be read
+			fmt.Printf("⚠️ Failed to read notification preferences of user %d: %v\n", userID, err)
+		} else if disabled {
+			return m.repo.CreateEmailDelivery(ctx, &EmailDelivery{
+				EntityID:  entityID,
+				UserID:    &userID,
+				Template:  name,
+				Recipient: email,
+				Status:    EmailStatusSuppressed,
+			})
+		}
+	}
+	return m.send(ctx, entityID, &userID, name, []string{email}, data, attachments)
+}
+
+// Send emails each address separately
+func (m *Mailer) Send(ctx context.Context, entityID uint, name string, to []string, data map[string]interface{}, attachments ...EmailAttachment) error {
+	if data == nil {
+		data = map[string]interface{}{}
+	}
+	return m.send(ctx, entityID, nil, name, to, data, attachments)
+}
+
+func (m *Mailer) send(ctx context.Context, entityID uint, userID *uint, name string, to []string, data map[string]interface{}, attachments []EmailAttachment) error {
+	body, ok := m.templates[name]
+	if !ok {
+		return fmt.Errorf("unknown email template %q", name)
+	}
+
+	msg := &EmailMessage{FromName: m.fromName, FromAddr: m.fromAddr, Attachments: attachments}
+	if entityID != 0 {
+		if _, ok := data["TempleName"]; !ok {
+			if templeName, err := m.repo.GetEntityName(ctx, entityID); err == nil {
+				data["TempleName"] = templeName
+			}
+		}
+		m.applySender(ctx, entityID, data, msg)
+	}
+	if _, ok := data["LoginURL"]; !ok && m.frontendURL != "" {
+		data["LoginURL"] = m.frontendURL + "/login"
+	}
+
+	var subject bytes.Buffer
+	if err := m.subjects[name].Execute(&subject, data); err != nil {
+		return fmt.Errorf("failed to render subject of %s: %w", name, err)
+	}
+	msg.Subject = subject.String()
+	data["Subject"] = msg.Subject
+
+	var html bytes.Buffer
+	if err := body.ExecuteTemplate(&html, "layout", data); err != nil {
+		return fmt.Errorf("failed to render %s: %w", name, err)
+	}
+	msg.HTML = html.String()
+
+	var errs []error
+	for _, recipient := range to {
+		delivery := &EmailDelivery{
+			EntityID:  entityID,
+			UserID:    userID,
+			Template:  name,
+			Recipient: recipient,
+			Subject:   msg.Subject,
+			Provider:  m.provider.Name(),
+			Status:    EmailStatusPending,
+		}
+		if err := m.repo.CreateEmailDelivery(ctx, delivery); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		msg.To = []string{recipient}
+		messageID, err := m.provider.Send(ctx, msg)
+		switch {
+		case errors.Is(err, ErrEmailNotConfigured):
+			delivery.Status = EmailStatusSkipped
+		case err != nil:
+			errMsg := err.Error()
+			delivery.Status = EmailStatusFailed
+			delivery.Error = &errMsg
+			errs = append(errs, fmt.Errorf("%s to %s: %w", name, recipient, err))
+		default:
+			now := time.Now()
+			delivery.Status = EmailStatusSent
+			delivery.MessageID = messageID
+			delivery.SentAt = &now
+		}
+		if err := m.repo.UpdateEmailDelivery(ctx, delivery); err != nil {
+			fmt.Printf("⚠️ Failed to record email delivery %d: %v\n", delivery.ID, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// applySender sends as the temple: its configured name and address, or its
+// name on the platform address
+func (m *Mailer) applySender(ctx context.Context, entityID uint, data map[string]interface{}, msg *EmailMessage) {
+	if templeName, _ := data["TempleName"].(string); templeName != "" {
+		msg.FromName = templeName
+	}
+	settings, err := m.repo.GetEmailSettings(ctx, entityID)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to load email settings of entity %d: %v\n", entityID, err)
+		return
+	}
+	if settings == nil {
+		return
+	}
+	if settings.FromName != "" {
+		msg.FromName = settings.FromName
+	}
+	// Re-checked in case EMAIL_SENDER_DOMAINS changed since it was saved
+	if settings.FromEmail != "" && m.allowedSender(settings.FromEmail) {
+		msg.FromAddr = settings.FromEmail
+	}
+	msg.ReplyTo = settings.ReplyTo
+}
+
+func (m *Mailer) allowedSender(address string) bool {
+	at := strings.LastIndex(address, "@")
+	return at >= 0 && contains(m.senderDomains, strings.ToLower(address[at+1:]))
+}
+
+// GetSettings returns a temple's sender settings with their fallbacks
+func (m *Mailer) GetSettings(ctx context.Context, entityID uint) (*EmailSettingsResponse, error) {
+	settings, err := m.repo.GetEmailSettings(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if settings == nil {
+		settings = &EmailSettings{EntityID: entityID}
+	}
+	defaultName := m.fromName
+	if templeName, err := m.repo.GetEntityName(ctx, entityID); err == nil && templeName != "" {
+		defaultName = templeName
+	}
+	domains := m.senderDomains
+	if domains == nil {
+		domains = []string{}
+	}
+	return &EmailSettingsResponse{
+		EmailSettings:        *settings,
+		DefaultFromName:      defaultName,
+		DefaultFromEmail:     m.fromAddr,
+		AllowedSenderDomains: domains,
+		Provider:             m.provider.Name(),
+	}, nil
+}
+
+// UpdateSettings validates and stores a temple's sender settings
+func (m *Mailer) UpdateSettings(ctx context.Context, entityID, userID uint, in EmailSettingsInput, ip string) (*EmailSettingsResponse, error) {
+	settings := &EmailSettings{
+		EntityID:  entityID,
+		FromName:  strings.TrimSpace(in.FromName),
+		FromEmail: strings.ToLower(strings.TrimSpace(in.FromEmail)),
+		ReplyTo:   strings.TrimSpace(in.ReplyTo),
+		UpdatedBy: userID,
+		UpdatedAt: time.Now(),
+	}
+	if len(settings.FromName) > 100 || strings.ContainsAny(settings.FromName, "\r\n") {
+		return nil, fmt.Errorf("%w: from_name must be a single line of at most 100 characters", ErrInvalidEmailSettings)
+	}
+	if settings.FromEmail != "" {
+		if addr, err := mail.ParseAddress(settings.FromEmail); err != nil || addr.Address != settings.FromEmail {
+			return nil, fmt.Errorf("%w: from_email is not a valid address", ErrInvalidEmailSettings)
+		}
+		if !m.allowedSender(settings.FromEmail) {
+			return nil, fmt.Errorf("%w: from_email must be on one of the verified domains (%s)", ErrInvalidEmailSettings, strings.Join(m.senderDomains, ", "))
+		}
+	}
+	if settings.ReplyTo != "" {
+		if addr, err := mail.ParseAddress(settings.ReplyTo); err != nil || addr.Address != settings.ReplyTo {
+			return nil, fmt.Errorf("%w: reply_to is not a valid address", ErrInvalidEmailSettings)
+		}
+	}
+
+	if err := m.repo.SaveEmailSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+	m.auditSvc.LogAction(ctx, &userID, &entityID, "EMAIL_SETTINGS_UPDATED", map[string]interface{}{
+		"from_name":  settings.FromName,
+		"from_email": settings.FromEmail,
+		"reply_to":   settings.ReplyTo,
+	}, ip, "success")
+
+	return m.GetSettings(ctx, entityID)
+}
+
+// ListDeliveries pages through a temple's email deliveries, newest first
+func (m *Mailer) ListDeliveries(ctx context.Context, entityID uint, filter EmailDeliveryFilter) ([]EmailDelivery, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return m.repo.ListEmailDeliveries(ctx, entityID, filter)
+}
+
+// ===== HANDLER =====
+
+// GET /api/v1/notifications/email/settings
+func (h *Handler) GetEmailSettings(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	settings, err := h.Mailer.GetSettings(c.Request.Context(), *entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch email settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// PUT /api/v1/notifications/email/settings
+// Body: {"from_name": "Sri Temple", "from_email": "office@mytemple.org", "reply_to": "office@mytemple.org"}
+func (h *Handler) UpdateEmailSettings(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	if !ctx.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return
+	}
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	var input EmailSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.Mailer.UpdateSettings(c.Request.Context(), *entityID, ctx.UserID, input, middleware.GetIPFromContext(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidEmailSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update email settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "email settings updated", "data": settings})
+}
+
+// GET /api/v1/notifications/email/deliveries?status=failed&template=donation_receipt&page=1&limit=20
+func (h *Handler) ListEmailDeliveries(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	filter := EmailDeliveryFilter{
+		Status:   c.Query("status"),
+		Template: c.Query("template"),
+		Page:     page,
+		Limit:    limit,
+	}
+
+	deliveries, total, err := h.Mailer.ListDeliveries(c.Request.Context(), *entityID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch email deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deliveries, "total": total, "page": page, "limit": limit})
+}
@@ -0,0 +1,872 @@
+package notification
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Text messages (booking confirmations, OTPs, event reminders) go to devotees
+// over WhatsApp when a gateway for it is available, otherwise SMS. A temple
+// may use its own provider account per channel; without one the platform
+// gateways configured in the environment are used. Every message gets a
+// MessageDelivery row, kept current by the provider's delivery reports.
+
+// Kinds of text message
+const (
+	MessageBookingConfirmation = "booking_confirmation"
+	MessageOTP                 = "otp"
+	MessageEventReminder       = "event_reminder"
+	MessageGreeting            = "greeting" // birthday and anniversary wishes
+	MessageBulk                = "bulk"     // sent from a notification template
+)
+
+// Delivery statuses, in the order a message goes through them
+const (
+	MessageStatusPending    = "pending"
+	MessageStatusQueued     = "queued" // accepted by the provider
+	MessageStatusSent       = "sent"   // handed to the carrier
+	MessageStatusDelivered  = "delivered"
+	MessageStatusRead       = "read" // WhatsApp only
+	MessageStatusFailed     = "failed"
+	MessageStatusSuppressed = "suppressed" // the recipient turned the channel off
+	MessageStatusSkipped    = "skipped"    // no gateway configured
+)
+
+// messageStatusRank orders statuses so a late report can't move a message back
+var messageStatusRank = map[string]int{
+	MessageStatusPending:   0,
+	MessageStatusQueued:    1,
+	MessageStatusSent:      2,
+	MessageStatusDelivered: 3,
+	MessageStatusFailed:    3,
+	MessageStatusRead:      4,
+}
+
+// Preference category each kind can be turned off under; OTPs and reminders
+// of events the devotee signed up for are always sent
+var messageCategories = map[string]string{
+	MessageBookingConfirmation: PreferenceBookings,
+	MessageGreeting:            PreferenceBirthdays,
+}
+
+var (
+	ErrInvalidMessagingSettings = errors.New("invalid messaging settings")
+	ErrCredentialsKeyMissing    = errors.New("MESSAGING_CREDENTIALS_KEY is not configured, temples can't store provider credentials")
+)
+
+// MessagingSettings is a temple's own provider account for one channel
+type MessagingSettings struct {
+	EntityID    uint      `gorm:"primaryKey;autoIncrement:false" json:"entity_id"`
+	Channel     string    `gorm:"primaryKey;size:20" json:"channel"`
+	Provider    string    `gorm:"size:20;not null" json:"provider"`
+	Credentials []byte    `gorm:"not null" json:"-"` // GatewayCredentials JSON sealed with MESSAGING_CREDENTIALS_KEY
+	Sender      string    `gorm:"size:50" json:"sender"`
+	Enabled     bool      `gorm:"not null;default:true" json:"enabled"`
+	UpdatedBy   uint      `json:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (MessagingSettings) TableName() string { return "entity_messaging_settings" }
+
+// MessagingSettingsInput configures a temple's provider for a channel. Leaving
+// out the credentials keeps the stored ones, e.g. to only disable the account.
+type MessagingSettingsInput struct {
+	Provider    string              `json:"provider" binding:"required"`
+	Credentials *GatewayCredentials `json:"credentials"`
+	Enabled     *bool               `json:"enabled"`
+}
+
+// MessagingChannelStatus shows how a temple's messages go out on one channel
+type MessagingChannelStatus struct {
+	Channel           string     `json:"channel"`
+	Provider          string     `json:"provider,omitempty"` // the temple's own provider
+	Sender            string     `json:"sender,omitempty"`
+	Enabled           bool       `json:"enabled"`
+	PlatformProvider  string     `json:"platform_provider,omitempty"` // used when the temple has none
+	DeliveryReportURL string     `json:"delivery_report_url,omitempty"`
+	UpdatedAt         *time.Time `json:"updated_at,omitempty"`
+}
+
+// MessageDelivery tracks one text message to one recipient
+type MessageDelivery struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	EntityID    uint       `gorm:"not null;index" json:"entity_id"` // 0 for platform messages, e.g. registration OTPs
+	UserID      *uint      `gorm:"index" json:"user_id,omitempty"`
+	Channel     string     `gorm:"size:20;not null" json:"channel"`
+	Kind        string     `gorm:"size:30;not null;index" json:"kind"`
+	Recipient   string     `gorm:"size:20;not null" json:"recipient"`
+	Body        string     `gorm:"type:text" json:"body,omitempty"` // not kept for OTPs
+	Provider    string     `gorm:"size:20" json:"provider"`
+	MessageID   string     `gorm:"size:100;index" json:"message_id,omitempty"`
+	Status      string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	Error       *string    `gorm:"type:text" json:"error,omitempty"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// MessageDeliveryFilter narrows the delivery list of a temple
+type MessageDeliveryFilter struct {
+	Channel string
+	Kind    string
+	Status  string
+	Page    int
+	Limit   int
+}
+
+// ===== REPOSITORY =====
+
+func (r *repository) ListMessagingSettings(ctx context.Context, entityID uint) ([]MessagingSettings, error) {
+	var settings []MessagingSettings
+	err := r.db.WithContext(ctx).Where("entity_id = ?", entityID).Find(&settings).Error
+	return settings, err
+}
+
+// GetMessagingSettings returns the temple's account for channel, or nil if it has none
+func (r *repository) GetMessagingSettings(ctx context.Context, entityID uint, channel string) (*MessagingSettings, error) {
+	var settings MessagingSettings
+	err := r.db.WithContext(ctx).Where("entity_id = ? AND channel = ?", entityID, channel).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+func (r *repository) SaveMessagingSettings(ctx context.Context, settings *MessagingSettings) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "entity_id"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"provider", "credentials", "sender", "enabled", "updated_by", "updated_at"}),
+	}).Create(settings).Error
+}
+
+func (r *repository) DeleteMessagingSettings(ctx context.Context, entityID uint, channel string) error {
+	return r.db.WithContext(ctx).Where("entity_id = ? AND channel = ?", entityID, channel).Delete(&MessagingSettings{}).Error
+}
+
+func (r *repository) CreateMessageDelivery(ctx context.Context, d *MessageDelivery) error {
+	return r.db.WithContext(ctx).Create(d).Error
+}
+
+func (r *repository) UpdateMessageDelivery(ctx context.Context, d *MessageDelivery) error {
+	return r.db.WithContext(ctx).Save(d).Error
+}
+
+// FindMessageDeliveries returns the deliveries a provider's message ID refers to
+func (r *repository) FindMessageDeliveries(ctx context.Context, provider, messageID string) ([]MessageDelivery, error) {
+	var deliveries []MessageDelivery
+	err := r.db.WithContext(ctx).Where("provider = ? AND message_id = ?", provider, messageID).Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *repository) ListMessageDeliveries(ctx context.Context, entityID uint, filter MessageDeliveryFilter) ([]MessageDelivery, int64, error) {
+	query := r.db.WithContext(ctx).Model(&MessageDelivery{}).Where("entity_id = ?", entityID)
+	if filter.Channel != "" {
+		query = query.Where("channel = ?", filter.Channel)
+	}
+	if filter.Kind != "" {
+		query = query.Where("kind = ?", filter.Kind)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []MessageDelivery
+	err := query.Order("created_at DESC").
+		Offset((filter.Page - 1) * filter.Limit).
+		Limit(filter.Limit).
+		Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *repository) GetUserPhone(ctx context.Context, userID uint) (string, error) {
+	var user struct {
+		Phone string `gorm:"serializer:encrypted"`
+	}
+	err := r.db.WithContext(ctx).Table("users").Select("phone").Where("id = ?", userID).Limit(1).Find(&user).Error
+	return user.Phone, err
+}
+
+// OptedOutPhones returns the numbers among phones whose users turned off the
+// channel for the category. Phones are encrypted, so users are matched by
+// blind index.
+func (r *repository) OptedOutPhones(ctx context.Context, phones []string, category, channel string) ([]string, error) {
+	byIndex := make(map[string][]string, len(phones))
+	for _, phone := range phones {
+		if index := fieldcrypto.PhoneIndex(phone); index != "" {
+			byIndex[index] = append(byIndex[index], phone)
+		}
+	}
+	var out []string
+	if len(byIndex) == 0 {
+		return out, nil
+	}
+	indexes := make([]string, 0, len(byIndex))
+	for index := range byIndex {
+		indexes = append(indexes, index)
+	}
+
+	var matched []string
+	err := r.db.WithContext(ctx).Table("users u").
+		Joins("JOIN notification_preferences p ON p.user_id = u.id").
+		Where("u.phone_index IN ? AND p.category = ? AND p.channel = ? AND p.enabled = ?", indexes, category, channel, false).
+		Pluck("u.phone_index", &matched).Error
+	for _, index := range matched {
+		out = append(out, byIndex[index]...)
+		delete(byIndex, index)
+	}
+	return out, err
+}
+
+// ===== SERVICE =====
+
+// Messenger sends SMS and WhatsApp messages
+type Messenger struct {
+	repo     Repository
+	auditSvc auditlog.Service
+
+	platform       map[string]MessageGateway // by channel
+	credentialsKey []byte                    // nil: temples can't store credentials
+	callbackSecret []byte
+	publicURL      string
+}
+
+func NewMessenger(repo Repository, cfg *config.Config, auditSvc auditlog.Service) *Messenger {
+	key, err := parseCredentialsKey(cfg.MessagingCredentialsKey)
+	if err != nil {
+		fmt.Printf("⚠️ %v, temples can't configure their own SMS/WhatsApp provider\n", err)
+	}
+	return &Messenger{
+		repo:           repo,
+		auditSvc:       auditSvc,
+		platform:       platformGateways(cfg),
+		credentialsKey: key,
+		callbackSecret: []byte(cfg.MessagingCallbackSecret),
+		publicURL:      cfg.PublicAPIURL,
+	}
+}
+
+// gateway returns the gateway a temple's messages on channel go through: its
+// own account if enabled, else the platform's. Along with it comes the scope
+// of its delivery reports: the temple for its own account, 0 for the
+// platform's. A nil gateway means the channel is unavailable.
+func (m *Messenger) gateway(ctx context.Context, entityID uint, channel string) (MessageGateway, uint) {
+	if entityID != 0 {
+		settings, err := m.repo.GetMessagingSettings(ctx, entityID, channel)
+		if err != nil {
+			fmt.Printf("⚠️ Failed to load %s settings of entity %d: %v\n", channel, entityID, err)
+		} else if settings != nil && settings.Enabled {
+			gateway, err := m.openGateway(entityID, settings)
+			if err == nil {
+				return gateway, entityID
+			}
+			fmt.Printf("⚠️ %s gateway of entity %d unusable, using the platform's: %v\n", channel, entityID, err)
+		}
+	}
+	if gateway, ok := m.platform[channel]; ok {
+		return gateway, 0
+	}
+	if channel == ChannelSMS {
+		return logGateway{}, 0
+	}
+	return nil, 0
+}
+
+func (m *Messenger) openGateway(entityID uint, settings *MessagingSettings) (MessageGateway, error) {
+	creds, err := m.openCredentials(entityID, settings.Channel, settings.Credentials)
+	if err != nil {
+		return nil, err
+	}
+	return NewMessageGateway(settings.Provider, settings.Channel, *creds)
+}
+
+// SendToUser texts a user of a temple over WhatsApp, falling back to SMS when
+// WhatsApp is unavailable, turned off by the user, or fails
+func (m *Messenger) SendToUser(ctx context.Context, entityID, userID uint, kind, body string) error {
+	phone, err := m.repo.GetUserPhone(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to look up user %d: %w", userID, err)
+	}
+	if phone == "" {
+		return nil
+	}
+
+	var errs []error
+	for _, channel := range []string{ChannelWhatsApp, ChannelSMS} {
+		gateway, scope := m.gateway(ctx, entityID, channel)
+		if gateway == nil {
+			continue
+		}
+		if m.optedOut(ctx, userID, kind, channel) {
+			m.record(ctx, &MessageDelivery{EntityID: entityID, UserID: &userID, Channel: channel, Kind: kind, Recipient: phone, Status: MessageStatusSuppressed})
+			continue
+		}
+		err := m.send(ctx, gateway, scope, &MessageDelivery{EntityID: entityID, UserID: &userID, Channel: channel, Kind: kind, Recipient: phone, Body: body})
+		if err == nil || errors.Is(err, ErrGatewayNotConfigured) {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// SendOTP texts a verification code through the platform SMS gateway. The
+// message is not kept in the delivery log.
+func (m *Messenger) SendOTP(phone, body string) error {
+	ctx := context.Background()
+	gateway, scope := m.gateway(ctx, 0, ChannelSMS)
+	err := m.sendBody(ctx, gateway, scope, &MessageDelivery{Channel: ChannelSMS, Kind: MessageOTP, Recipient: phone}, body)
+	if errors.Is(err, ErrGatewayNotConfigured) {
+		return nil
+	}
+	return err
+}
+
+// SendBulk texts each number on channel for a temple, as the notification
+// service does for templates
+func (m *Messenger) SendBulk(ctx context.Context, entityID uint, channel string, phones []string, body string) error {
+	gateway, scope := m.gateway(ctx, entityID, channel)
+	if gateway == nil {
+		return fmt.Errorf("%w: %s", ErrGatewayNotConfigured, channel)
+	}
+	failed := 0
+	var lastErr error
+	for _, phone := range phones {
+		err := m.send(ctx, gateway, scope, &MessageDelivery{EntityID: entityID, Channel: channel, Kind: MessageBulk, Recipient: phone, Body: body})
+		if err != nil && !errors.Is(err, ErrGatewayNotConfigured) {
+			failed++
+			lastErr = err
+		}
+	}
+	switch {
+	case failed == 0:
+		return nil
+	case failed == len(phones):
+		return fmt.Errorf("all %s messages failed: %v", channel, lastErr)
+	default:
+		return fmt.Errorf("partial success: %d/%d %s messages sent, last error: %v", len(phones)-failed, len(phones), channel, lastErr)
+	}
+}
+
+// messengerChannel adapts the messenger to the Channel the notification
+// service sends template batches through
+type messengerChannel struct {
+	ctx       context.Context
+	messenger *Messenger
+	entityID  uint
+	channel   string
+}
+
+func (c *messengerChannel) Send(to []string, subject string, body string) error {
+	return c.messenger.SendBulk(c.ctx, c.entityID, c.channel, to, body)
+}
+
+func (s *service) SetMessenger(m *Messenger) {
+	s.messenger = m
+}
+
+// textChannel returns the channel a temple's sms or whatsapp batches go through
+func (s *service) textChannel(ctx context.Context, entityID uint, channel string) Channel {
+	if s.messenger != nil {
+		return &messengerChannel{ctx: ctx, messenger: s.messenger, entityID: entityID, channel: channel}
+	}
+	if channel == ChannelWhatsApp {
+		return s.whatsapp
+	}
+	return s.sms
+}
+
+func (m *Messenger) send(ctx context.Context, gateway MessageGateway, scope uint, delivery *MessageDelivery) error {
+	return m.sendBody(ctx, gateway, scope, delivery, delivery.Body)
+}
+
+// sendBody sends body and records the outcome in delivery, whose Body is
+// what gets stored
+func (m *Messenger) sendBody(ctx context.Context, gateway MessageGateway, scope uint, delivery *MessageDelivery, body string) error {
+	delivery.Provider = gateway.Name()
+	delivery.Status = MessageStatusPending
+	if err := m.repo.CreateMessageDelivery(ctx, delivery); err != nil {
+		return err
+	}
+
+	messageID, err := gateway.Send(ctx, &TextMessage{
+		Channel:   delivery.Channel,
+		To:        delivery.Recipient,
+		Body:      body,
+		ReportURL: m.ReportURL(gateway.Name(), scope),
+	})
+	switch {
+	case errors.Is(err, ErrGatewayNotConfigured):
+		delivery.Status = MessageStatusSkipped
+	case err != nil:
+		errMsg := err.Error()
+		delivery.Status = MessageStatusFailed
+		delivery.Error = &errMsg
+		err = fmt.Errorf("%s to %s: %w", delivery.Channel, delivery.Recipient, err)
+	default:
+		now := time.Now()
+		delivery.Status = MessageStatusQueued
+		delivery.MessageID = messageID
+		delivery.SentAt = &now
+	}
+	m.record(ctx, delivery)
+	return err
+}
+
+// record creates or updates a delivery, logging rather than failing the send
+func (m *Messenger) record(ctx context.Context, d *MessageDelivery) {
+	var err error
+	if d.ID == 0 {
+		err = m.repo.CreateMessageDelivery(ctx, d)
+	} else {
+		err = m.repo.UpdateMessageDelivery(ctx, d)
+	}
+	if err != nil {
+		fmt.Printf("⚠️ Failed to record %s delivery to %s: %v\n", d.Channel, d.Recipient, err)
+	}
+}
+
+func (m *Messenger) optedOut(ctx context.Context, userID uint, kind, channel string) bool {
+	category := messageCategories[kind]
+	if category == "" {
+		return false
+	}
+	disabled, err := m.repo.IsChannelDisabled(ctx, userID, category, channel)
+	if err != nil {
+		// Deliver rather than silently drop when preferences can't be read
+		fmt.Printf("⚠️ Failed to read notification preferences of user %d: %v\n", userID, err)
+		return false
+	}
+	return disabled
+}
+
+// ApplyReports updates deliveries from a provider's delivery reports. Reports
+// received on a temple's URL (scope) only touch that temple's messages.
+func (m *Messenger) ApplyReports(ctx context.Context, provider string, scope uint, reports []DeliveryReport) error {
+	for _, report := range reports {
+		deliveries, err := m.repo.FindMessageDeliveries(ctx, provider, report.MessageID)
+		if err != nil {
+			return err
+		}
+		for i := range deliveries {
+			d := &deliveries[i]
+			if scope != 0 && d.EntityID != scope {
+				continue
+			}
+			if messageStatusRank[report.Status] < messageStatusRank[d.Status] {
+				continue
+			}
+			d.Status = report.Status
+			if report.Error != "" {
+				errMsg := report.Error
+				d.Error = &errMsg
+			}
+			if report.Status == MessageStatusDelivered && d.DeliveredAt == nil {
+				now := time.Now()
+				d.DeliveredAt = &now
+			}
+			if err := m.repo.UpdateMessageDelivery(ctx, d); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReportURL is where provider posts the delivery reports of an account: a
+// temple's own (scope is the temple) or the platform's (scope 0). It is ""
+// when PUBLIC_API_URL is not set.
+func (m *Messenger) ReportURL(provider string, scope uint) string {
+	if m.publicURL == "" || !contains(Gateways, provider) {
+		return ""
+	}
+	return fmt.Sprintf("%s/api/v1/notifications/messaging/reports/%s/%d/%s", m.publicURL, provider, scope, m.reportToken(provider, scope))
+}
+
+// reportToken authenticates delivery reports; providers differ in how (and
+// whether) they sign callbacks, so the URL carries it
+func (m *Messenger) reportToken(provider string, scope uint) string {
+	mac := hmac.New(sha256.New, m.callbackSecret)
+	fmt.Fprintf(mac, "tms-delivery-report:%s:%d", provider, scope)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
+
+// ValidReportToken reports whether token authenticates provider's reports for scope
+func (m *Messenger) ValidReportToken(provider string, scope uint, token string) bool {
+	if len(m.callbackSecret) == 0 || !contains(Gateways, provider) {
+		return false
+	}
+	return hmac.Equal([]byte(token), []byte(m.reportToken(provider, scope)))
+}
+
+// GetSettings returns how a temple's messages go out on each channel
+func (m *Messenger) GetSettings(ctx context.Context, entityID uint) ([]MessagingChannelStatus, error) {
+	stored, err := m.repo.ListMessagingSettings(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	byChannel := map[string]MessagingSettings{}
+	for _, s := range stored {
+		byChannel[s.Channel] = s
+	}
+
+	statuses := make([]MessagingChannelStatus, 0, len(TextChannels))
+	for _, channel := range TextChannels {
+		status := MessagingChannelStatus{Channel: channel}
+		if gateway, ok := m.platform[channel]; ok {
+			status.PlatformProvider = gateway.Name()
+		}
+		if s, ok := byChannel[channel]; ok {
+			updatedAt := s.UpdatedAt
+			status.Provider = s.Provider
+			status.Sender = s.Sender
+			status.Enabled = s.Enabled
+			status.UpdatedAt = &updatedAt
+			status.DeliveryReportURL = m.ReportURL(s.Provider, entityID)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// UpdateSettings stores a temple's own provider account for channel
+func (m *Messenger) UpdateSettings(ctx context.Context, entityID, userID uint, channel string, in MessagingSettingsInput, ip string) ([]MessagingChannelStatus, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	provider := strings.ToLower(strings.TrimSpace(in.Provider))
+	if !contains(TextChannels, channel) {
+		return nil, fmt.Errorf("%w: unknown channel %q, use %s", ErrInvalidMessagingSettings, channel, strings.Join(TextChannels, ", "))
+	}
+	if !contains(Gateways, provider) {
+		return nil, fmt.Errorf("%w: unknown provider %q, use %s", ErrInvalidMessagingSettings, in.Provider, strings.Join(Gateways, ", "))
+	}
+	if m.credentialsKey == nil {
+		return nil, ErrCredentialsKeyMissing
+	}
+
+	existing, err := m.repo.GetMessagingSettings(ctx, entityID, channel)
+	if err != nil {
+		return nil, err
+	}
+	var creds GatewayCredentials
+	switch {
+	case in.Credentials != nil:
+		creds = *in.Credentials
+	case existing != nil && existing.Provider == provider:
+		stored, err := m.openCredentials(entityID, channel, existing.Credentials)
+		if err != nil {
+			return nil, fmt.Errorf("%w: stored credentials can't be read, enter them again", ErrInvalidMessagingSettings)
+		}
+		creds = *stored
+	default:
+		return nil, fmt.Errorf("%w: credentials are required", ErrInvalidMessagingSettings)
+	}
+	if _, err := NewMessageGateway(provider, channel, creds); err != nil {
+		return nil, err
+	}
+
+	sealed, err := m.sealCredentials(entityID, channel, &creds)
+	if err != nil {
+		return nil, err
+	}
+	settings := &MessagingSettings{
+		EntityID:    entityID,
+		Channel:     channel,
+		Provider:    provider,
+		Credentials: sealed,
+		Sender:      creds.Sender,
+		Enabled:     in.Enabled == nil || *in.Enabled,
+		UpdatedBy:   userID,
+		UpdatedAt:   time.Now(),
+	}
+	if err := m.repo.SaveMessagingSettings(ctx, settings); err != nil {
+		return nil, err
+	}
+
+	m.auditSvc.LogAction(ctx, &userID, &entityID, "MESSAGING_SETTINGS_UPDATED", map[string]interface{}{
+		"channel":             channel,
+		"provider":            provider,
+		"sender":              creds.Sender,
+		"enabled":             settings.Enabled,
+		"credentials_changed": in.Credentials != nil,
+	}, ip, "success")
+
+	return m.GetSettings(ctx, entityID)
+}
+
+// DeleteSettings removes a temple's own account for channel; its messages
+// go through the platform gateway again
+func (m *Messenger) DeleteSettings(ctx context.Context, entityID, userID uint, channel, ip string) ([]MessagingChannelStatus, error) {
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	if !contains(TextChannels, channel) {
+		return nil, fmt.Errorf("%w: unknown channel %q, use %s", ErrInvalidMessagingSettings, channel, strings.Join(TextChannels, ", "))
+	}
+	if err := m.repo.DeleteMessagingSettings(ctx, entityID, channel); err != nil {
+		return nil, err
+	}
+	m.auditSvc.LogAction(ctx, &userID, &entityID, "MESSAGING_SETTINGS_DELETED", map[string]interface{}{
+		"channel": channel,
+	}, ip, "success")
+	return m.GetSettings(ctx, entityID)
+}
+
+// ListDeliveries pages through a temple's text messages, newest first
+func (m *Messenger) ListDeliveries(ctx context.Context, entityID uint, filter MessageDeliveryFilter) ([]MessageDelivery, int64, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.Limit < 1 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	return m.repo.ListMessageDeliveries(ctx, entityID, filter)
+}
+
+// parseCredentialsKey decodes MESSAGING_CREDENTIALS_KEY, 32 bytes as hex or base64
+func parseCredentialsKey(value string) ([]byte, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, errors.New("MESSAGING_CREDENTIALS_KEY is not set")
+	}
+	if key, err := hex.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(value); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	return nil, errors.New("MESSAGING_CREDENTIALS_KEY must be 32 bytes encoded as hex or base64")
+}
+
+// sealCredentials encrypts creds with AES-GCM, bound to the temple and channel
+// so sealed credentials can't be moved to another row
+func (m *Messenger) sealCredentials(entityID uint, channel string, creds *GatewayCredentials) ([]byte, error) {
+	gcm, err := m.credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := json.Marshal(creds)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plain, credentialsAD(entityID, channel)), nil
+}
+
+func (m *Messenger) openCredentials(entityID uint, channel string, sealed []byte) (*GatewayCredentials, error) {
+	gcm, err := m.credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("sealed credentials are truncated")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plain, err := gcm.Open(nil, nonce, ciphertext, credentialsAD(entityID, channel))
+	if err != nil {
+		return nil, errors.New("credentials were sealed with a different key")
+	}
+	var creds GatewayCredentials
+	if err := json.Unmarshal(plain, &creds); err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+func (m *Messenger) credentialsCipher() (cipher.AEAD, error) {
+	if m.credentialsKey == nil {
+		return nil, ErrCredentialsKeyMissing
+	}
+	block, err := aes.NewCipher(m.credentialsKey)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func credentialsAD(entityID uint, channel string) []byte {
+	return []byte(fmt.Sprintf("tms-messaging:%d:%s", entityID, channel))
+}
+
+// ===== HANDLER =====
+
+// GET /api/v1/notifications/messaging/settings
+func (h *Handler) GetMessagingSettings(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	settings, err := h.Messenger.GetSettings(c.Request.Context(), *entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch messaging settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": settings})
+}
+
+// PUT /api/v1/notifications/messaging/settings/:channel
+// Body: {"provider": "twilio", "credentials": {"account_sid": "AC...", "auth_token": "...", "sender": "+1415..."}, "enabled": true}
+func (h *Handler) UpdateMessagingSettings(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	if !ctx.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return
+	}
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	var input MessagingSettingsInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.Messenger.UpdateSettings(c.Request.Context(), *entityID, ctx.UserID, c.Param("channel"), input, middleware.GetIPFromContext(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalidMessagingSettings), errors.Is(err, ErrChannelNotSupported):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, ErrCredentialsKeyMissing):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update messaging settings"})
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "messaging settings updated", "data": settings})
+}
+
+// DELETE /api/v1/notifications/messaging/settings/:channel
+func (h *Handler) DeleteMessagingSettings(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	if !ctx.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return
+	}
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	settings, err := h.Messenger.DeleteSettings(c.Request.Context(), *entityID, ctx.UserID, c.Param("channel"), middleware.GetIPFromContext(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidMessagingSettings) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to remove messaging settings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "temple provider removed, the platform gateway is used", "data": settings})
+}
+
+// GET /api/v1/notifications/messaging/deliveries?channel=sms&kind=booking_confirmation&status=failed&page=1&limit=20
+func (h *Handler) ListMessageDeliveries(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	filter := MessageDeliveryFilter{
+		Channel: c.Query("channel"),
+		Kind:    c.Query("kind"),
+		Status:  c.Query("status"),
+		Page:    page,
+		Limit:   limit,
+	}
+
+	deliveries, total, err := h.Messenger.ListDeliveries(c.Request.Context(), *entityID, filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch message deliveries"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": deliveries, "total": total, "page": page, "limit": limit})
+}
+
+// POST /api/v1/notifications/messaging/reports/:provider/:scope/:token
+// Public: delivery reports from Twilio, MSG91 and Gupshup, authenticated by the token in the URL
+func (h *Handler) MessageDeliveryReport(c *gin.Context) {
+	provider := c.Param("provider")
+	scope, err := strconv.ParseUint(c.Param("scope"), 10, 64)
+	if err != nil || !h.Messenger.ValidReportToken(provider, uint(scope), c.Param("token")) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+		return
+	}
+
+	reports, err := parseDeliveryReports(provider, c.Request)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := h.Messenger.ApplyReports(c.Request.Context(), provider, uint(scope), reports); err != nil {
+		// Providers retry on errors
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record delivery report"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"received": len(reports)})
+}
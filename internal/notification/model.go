@@ -13,7 +13,9 @@ type NotificationTemplate struct {
 	Name     string `gorm:"size:100;not null;index:idx_name_entity,unique" json:"name"`
 	EntityID uint   `gorm:"not null;index:idx_name_entity,unique" json:"entity_id"`
 
-	Category string `gorm:"size:20;not null" json:"category"` // email, sms, whatsapp, push
+	Category         string `gorm:"size:20;not null" json:"category"`                            // email, sms, whatsapp, push
+	NotificationType string `gorm:"size:30;not null;default:'general'" json:"notification_type"` // decides the allowed variables
+	Language         string `gorm:"size:10;not null;default:'en'" json:"language"`               // language of Subject/Body; translations live in TemplateTranslation
 
 	Subject   string    `gorm:"size:255" json:"subject,omitempty"` // optional for email/push
 	Body      string    `gorm:"type:text;not null" json:"body"`    // Go template format
@@ -0,0 +1,301 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm/clause"
+)
+
+// Notification categories a user can opt out of
+const (
+	PreferenceApprovals = "approvals"
+	PreferenceBookings  = "bookings"
+	PreferenceDonations = "donations"
+	PreferenceBirthdays = "birthdays"
+)
+
+// Channels a preference applies to
+const (
+	PreferenceChannelEmail    = "email"
+	PreferenceChannelPush     = "push"
+	PreferenceChannelInApp    = "inapp"
+	PreferenceChannelSMS      = ChannelSMS
+	PreferenceChannelWhatsApp = ChannelWhatsApp
+)
+
+var (
+	PreferenceCategories = []string{PreferenceApprovals, PreferenceBookings, PreferenceDonations, PreferenceBirthdays}
+	PreferenceChannels   = []string{PreferenceChannelEmail, PreferenceChannelPush, PreferenceChannelInApp, PreferenceChannelSMS, PreferenceChannelWhatsApp}
+
+	ErrInvalidPreference = errors.New("invalid notification preference")
+)
+
+// preferenceCategoryOf maps in-app categories and template notification
+// types to a preference category. Anything else (general announcements,
+// events, system messages) is always delivered.
+var preferenceCategoryOf = map[string]string{
+	"approval":               PreferenceApprovals,
+	PreferenceApprovals:      PreferenceApprovals,
+	"seva":                   PreferenceBookings,
+	"booking":                PreferenceBookings,
+	NotificationTypeSeva:     PreferenceBookings,
+	PreferenceBookings:       PreferenceBookings,
+	NotificationTypeDonation: PreferenceDonations,
+	"dispute":                PreferenceDonations,
+	PreferenceDonations:      PreferenceDonations,
+	"birthday":               PreferenceBirthdays,
+	PreferenceBirthdays:      PreferenceBirthdays,
+}
+
+// PreferenceCategory returns the preference category of a notification
+// category or template type, or "" when preferences don't apply
+func PreferenceCategory(category string) string {
+	return preferenceCategoryOf[strings.ToLower(strings.TrimSpace(category))]
+}
+
+// NotificationPreference is a user's choice for one category and channel.
+// Only explicit choices are stored; a missing row means enabled.
+type NotificationPreference struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UserID    uint      `gorm:"not null;uniqueIndex:idx_user_category_channel" json:"-"`
+	Category  string    `gorm:"size:30;not null;uniqueIndex:idx_user_category_channel" json:"category"`
+	Channel   string    `gorm:"size:20;not null;uniqueIndex:idx_user_category_channel" json:"channel"`
+	Enabled   bool      `gorm:"not null;default:true" json:"enabled"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// PreferenceUpdate changes one category/channel pair
+type PreferenceUpdate struct {
+	Category string `json:"category" binding:"required"`
+	Channel  string `json:"channel" binding:"required"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// Preferences is the full category x channel matrix of a user
+type Preferences map[string]map[string]bool
+
+// ===== REPOSITORY =====
+
+func (r *repository) GetPreferences(ctx context.Context, userID uint) ([]NotificationPreference, error) {
+	var prefs []NotificationPreference
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&prefs).Error
+	return prefs, err
+}
+
+func (r *repository) SavePreferences(ctx context.Context, prefs []NotificationPreference) error {
+	if len(prefs) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "category"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&prefs).Error
+}
+
+func (r *repository) IsChannelDisabled(ctx context.Context, userID uint, category, channel string) (bool, error) {
+	var n int64
+	err := r.db.WithContext(ctx).Model(&NotificationPreference{}).
+		Where("user_id = ? AND category = ? AND channel = ? AND enabled = ?", userID, category, channel, false).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// OptedOutEmails returns the addresses among emails whose users turned off
+// email for the category
+func (r *repository) OptedOutEmails(ctx context.Context, emails []string, category string) ([]string, error) {
+	var out []string
+	err := r.db.WithContext(ctx).Table("users u").
+		Joins("JOIN notification_preferences p ON p.user_id = u.id").
+		Where("u.email IN ? AND p.category = ? AND p.channel = ? AND p.enabled = ?", emails, category, PreferenceChannelEmail, false).
+		Pluck("u.email", &out).Error
+	return out, err
+}
+
+// OptedOutDeviceTokens returns the tokens among tokens whose users turned
+// off push for the category
+func (r *repository) OptedOutDeviceTokens(ctx context.Context, tokens []string, category string) ([]string, error) {
+	var out []string
+	err := r.db.WithContext(ctx).Table("fcm_device_tokens t").
+		Joins("JOIN notification_preferences p ON p.user_id = t.user_id").
+		Where("t.device_token IN ? AND p.category = ? AND p.channel = ? AND p.enabled = ?", tokens, category, PreferenceChannelPush, false).
+		Pluck("t.device_token", &out).Error
+	return out, err
+}
+
+// ===== SERVICE =====
+
+// GetPreferences returns every category and channel, enabled unless the
+// user turned it off
+func (s *service) GetPreferences(ctx context.Context, userID uint) (Preferences, error) {
+	stored, err := s.repo.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := Preferences{}
+	for _, category := range PreferenceCategories {
+		prefs[category] = map[string]bool{}
+		for _, channel := range PreferenceChannels {
+			prefs[category][channel] = true
+		}
+	}
+	for _, p := range stored {
+		if _, ok := prefs[p.Category]; ok {
+			prefs[p.Category][p.Channel] = p.Enabled
+		}
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences stores the given choices and leaves the others as they were
+func (s *service) UpdatePreferences(ctx context.Context, userID uint, updates []PreferenceUpdate, ip string) (Preferences, error) {
+	rows := make([]NotificationPreference, 0, len(updates))
+	now := time.Now()
+	for _, u := range updates {
+		category := strings.ToLower(strings.TrimSpace(u.Category))
+		channel := strings.ToLower(strings.TrimSpace(u.Channel))
+		if !contains(PreferenceCategories, category) {
+			return nil, fmt.Errorf("%w: unknown category %q, use %s", ErrInvalidPreference, u.Category, strings.Join(PreferenceCategories, ", "))
+		}
+		if !contains(PreferenceChannels, channel) {
+			return nil, fmt.Errorf("%w: unknown channel %q, use %s", ErrInvalidPreference, u.Channel, strings.Join(PreferenceChannels, ", "))
+		}
+		rows = append(rows, NotificationPreference{UserID: userID, Category: category, Channel: channel, Enabled: u.Enabled, UpdatedAt: now})
+	}
+
+	if err := s.repo.SavePreferences(ctx, rows); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, nil, "NOTIFICATION_PREFERENCES_UPDATED", map[string]interface{}{
+		"changes": updates,
+	}, ip, "success")
+
+	return s.GetPreferences(ctx, userID)
+}
+
+// allowsInApp reports whether the user wants in-app notifications of the category
+func (s *service) allowsInApp(ctx context.Context, userID uint, category string) bool {
+	pref := PreferenceCategory(category)
+	if pref == "" {
+		return true
+	}
+	disabled, err := s.repo.IsChannelDisabled(ctx, userID, pref, PreferenceChannelInApp)
+	if err != nil {
+		// Deliver rather than silently drop when preferences can't be read
+		fmt.Printf("⚠️ Failed to read notification preferences of user %d: %v\n", userID, err)
+		return true
+	}
+	return !disabled
+}
+
+// filterRecipients drops email addresses, device tokens and phone numbers of
+// users who turned the channel off for the template's category
+func (s *service) filterRecipients(ctx context.Context, templateID *uint, entityID uint, channel string, recipients []string) []string {
+	if templateID == nil || !contains(PreferenceChannels, channel) || channel == PreferenceChannelInApp {
+		return recipients
+	}
+	t, err := s.repo.GetTemplateByID(ctx, *templateID, entityID)
+	if err != nil {
+		return recipients
+	}
+	pref := PreferenceCategory(t.NotificationType)
+	if pref == "" {
+		return recipients
+	}
+
+	var optedOut []string
+	switch channel {
+	case PreferenceChannelEmail:
+		optedOut, err = s.repo.OptedOutEmails(ctx, recipients, pref)
+	case PreferenceChannelPush:
+		optedOut, err = s.repo.OptedOutDeviceTokens(ctx, recipients, pref)
+	default:
+		optedOut, err = s.repo.OptedOutPhones(ctx, recipients, pref, channel)
+	}
+	if err != nil {
+		fmt.Printf("⚠️ Failed to read notification preferences: %v\n", err)
+		return recipients
+	}
+	if len(optedOut) == 0 {
+		return recipients
+	}
+
+	skip := make(map[string]struct{}, len(optedOut))
+	for _, r := range optedOut {
+		skip[r] = struct{}{}
+	}
+	kept := make([]string, 0, len(recipients))
+	for _, r := range recipients {
+		if _, ok := skip[r]; !ok {
+			kept = append(kept, r)
+		}
+	}
+	fmt.Printf("🔕 %d recipients skipped by %s preferences\n", len(recipients)-len(kept), pref)
+	return kept
+}
+
+func contains(values []string, v string) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// ===== HANDLER =====
+
+// GET /api/v1/notifications/preferences
+func (h *Handler) GetPreferences(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	prefs, err := h.Service.GetPreferences(c.Request.Context(), ctx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"preferences": prefs})
+}
+
+// PUT /api/v1/notifications/preferences
+// Body: {"preferences": [{"category": "bookings", "channel": "email", "enabled": false}]}
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	var req struct {
+		Preferences []PreferenceUpdate `json:"preferences" binding:"required,dive"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	prefs, err := h.Service.UpdatePreferences(c.Request.Context(), ctx.UserID, req.Preferences, middleware.GetIPFromContext(c))
+	if err != nil {
+		if errors.Is(err, ErrInvalidPreference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "notification preferences updated", "preferences": prefs})
+}
@@ -0,0 +1,244 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBufferLimit   = 10000
+	producerWriteTimeout = 5 * time.Second
+	producerProbeTimeout = 3 * time.Second
+	producerReplayEvery  = 15 * time.Second
+	producerReplayBatch  = 100
+)
+
+// ErrBufferFull is returned when Kafka is down and the local buffer is at its limit
+var ErrBufferFull = errors.New("kafka unavailable and notification buffer is full")
+
+// BufferedMessage is a Kafka message held in the database while the broker
+// is unreachable. Rows are replayed in ID order and deleted once written.
+type BufferedMessage struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Topic     string    `gorm:"size:100;not null" json:"topic"`
+	Key       []byte    `json:"-"`
+	Value     []byte    `gorm:"not null" json:"-"`
+	Attempts  int       `gorm:"default:0" json:"attempts"`
+	LastError string    `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the BufferedMessage model
+func (BufferedMessage) TableName() string {
+	return "kafka_buffered_messages"
+}
+
+// ProducerMetrics is a snapshot of producer health and buffer state
+type ProducerMetrics struct {
+	Healthy     bool       `json:"healthy"`
+	BufferDepth int64      `json:"buffer_depth"`
+	BufferLimit int64      `json:"buffer_limit"`
+	Published   int64      `json:"published"` // written straight to Kafka
+	Buffered    int64      `json:"buffered"`  // held locally since startup
+	Replayed    int64      `json:"replayed"`  // buffered messages later written to Kafka
+	Dropped     int64      `json:"dropped"`   // rejected because the buffer was full
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+	LastReplay  *time.Time `json:"last_replay_at,omitempty"`
+}
+
+// Producer writes to Kafka and falls back to a database buffer while the
+// broker is unavailable. Once the broker answers again the buffer is
+// replayed in order before new messages go straight through.
+type Producer struct {
+	writer *kafka.Writer
+	db     *gorm.DB
+	limit  int64
+
+	healthy                                atomic.Bool
+	depth                                  atomic.Int64
+	published, buffered, replayed, dropped atomic.Int64
+
+	replayMu    sync.Mutex // one replay at a time
+	mu          sync.Mutex // guards the fields below
+	lastError   string
+	lastErrorAt *time.Time
+	lastReplay  *time.Time
+}
+
+// NewProducer wraps writer with a buffer of at most limit messages
+func NewProducer(writer *kafka.Writer, db *gorm.DB, limit int) *Producer {
+	if limit <= 0 {
+		limit = defaultBufferLimit
+	}
+	p := &Producer{writer: writer, db: db, limit: int64(limit)}
+	p.healthy.Store(true)
+
+	var depth int64
+	if err := db.Model(&BufferedMessage{}).Count(&depth).Error; err != nil {
+		log.Printf("⚠️ Failed to count buffered Kafka messages: %v", err)
+	}
+	p.depth.Store(depth)
+	return p
+}
+
+// Publish writes the message to Kafka, or buffers it when the broker is
+// down or older messages are still waiting to be replayed
+func (p *Producer) Publish(ctx context.Context, msg kafka.Message) error {
+	if p.healthy.Load() && p.depth.Load() == 0 {
+		writeCtx, cancel := context.WithTimeout(ctx, producerWriteTimeout)
+		err := p.writer.WriteMessages(writeCtx, msg)
+		cancel()
+		if err == nil {
+			p.published.Add(1)
+			return nil
+		}
+		p.markUnhealthy(err)
+	}
+	return p.buffer(ctx, msg)
+}
+
+func (p *Producer) buffer(ctx context.Context, msg kafka.Message) error {
+	if p.depth.Load() >= p.limit {
+		p.dropped.Add(1)
+		log.Printf("❌ Kafka notification dropped: buffer holds %d messages", p.limit)
+		return ErrBufferFull
+	}
+
+	row := &BufferedMessage{Topic: p.writer.Topic, Key: msg.Key, Value: msg.Value}
+	if err := p.db.WithContext(ctx).Create(row).Error; err != nil {
+		p.dropped.Add(1)
+		return err
+	}
+	p.depth.Add(1)
+	p.buffered.Add(1)
+	return nil
+}
+
+// Start checks the broker and replays the buffer every interval until ctx ends
+func (p *Producer) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(producerReplayEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if p.healthy.Load() && p.depth.Load() == 0 {
+					continue
+				}
+				if err := p.probe(ctx); err != nil {
+					p.markUnhealthy(err)
+					continue
+				}
+				p.healthy.Store(true)
+				if err := p.Replay(ctx); err != nil {
+					log.Printf("⚠️ Kafka buffer replay stopped: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// probe dials the broker and asks for its metadata
+func (p *Producer) probe(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, producerProbeTimeout)
+	defer cancel()
+	conn, err := kafka.DialContext(dialCtx, "tcp", p.writer.Addr.String())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Brokers()
+	return err
+}
+
+// Replay writes buffered messages to Kafka oldest first, deleting each
+// batch once the broker accepted it
+func (p *Producer) Replay(ctx context.Context) error {
+	p.replayMu.Lock()
+	defer p.replayMu.Unlock()
+
+	for {
+		var rows []BufferedMessage
+		if err := p.db.WithContext(ctx).Order("id ASC").Limit(producerReplayBatch).Find(&rows).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			now := time.Now()
+			p.mu.Lock()
+			p.lastReplay = &now
+			p.mu.Unlock()
+			return nil
+		}
+
+		msgs := make([]kafka.Message, len(rows))
+		ids := make([]uint, len(rows))
+		for i, row := range rows {
+			msgs[i] = kafka.Message{Key: row.Key, Value: row.Value}
+			ids[i] = row.ID
+		}
+
+		writeCtx, cancel := context.WithTimeout(ctx, producerWriteTimeout)
+		err := p.writer.WriteMessages(writeCtx, msgs...)
+		cancel()
+		if err != nil {
+			p.db.WithContext(ctx).Model(&BufferedMessage{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"attempts":   gorm.Expr("attempts + 1"),
+				"last_error": err.Error(),
+			})
+			p.healthy.Store(false)
+			p.recordError(err)
+			return err
+		}
+
+		if err := p.db.WithContext(ctx).Where("id IN ?", ids).Delete(&BufferedMessage{}).Error; err != nil {
+			// Rows stay and are sent again: consumers see a duplicate, not a loss
+			return err
+		}
+		p.depth.Add(-int64(len(rows)))
+		p.replayed.Add(int64(len(rows)))
+		log.Printf("✅ Replayed %d buffered Kafka notifications", len(rows))
+	}
+}
+
+func (p *Producer) markUnhealthy(err error) {
+	if p.healthy.Swap(false) {
+		log.Printf("❌ Kafka unavailable, buffering notifications: %v", err)
+	}
+	p.recordError(err)
+}
+
+func (p *Producer) recordError(err error) {
+	now := time.Now()
+	p.mu.Lock()
+	p.lastError = err.Error()
+	p.lastErrorAt = &now
+	p.mu.Unlock()
+}
+
+// Metrics returns a snapshot of producer health and buffer depth
+func (p *Producer) Metrics() ProducerMetrics {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ProducerMetrics{
+		Healthy:     p.healthy.Load(),
+		BufferDepth: p.depth.Load(),
+		BufferLimit: p.limit,
+		Published:   p.published.Load(),
+		Buffered:    p.buffered.Load(),
+		Replayed:    p.replayed.Load(),
+		Dropped:     p.dropped.Load(),
+		LastError:   p.lastError,
+		LastErrorAt: p.lastErrorAt,
+		LastReplay:  p.lastReplay,
+	}
+}
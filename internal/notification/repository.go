@@ -3,6 +3,7 @@ package notification
 import (
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"gorm.io/gorm"
@@ -25,6 +26,7 @@ type Repository interface {
 	// In-app notifications
 	CreateInApp(ctx context.Context, n *InAppNotification) error
 	ListInAppByUser(ctx context.Context, userID uint, entityID *uint, limit int) ([]InAppNotification, error)
+	ListInAppSince(ctx context.Context, userID, afterID uint, limit int) ([]InAppNotification, error)
 	MarkInAppAsRead(ctx context.Context, id uint, userID uint) error
 
 	// ✅ FCM Device Tokens
@@ -33,6 +35,49 @@ type Repository interface {
 	GetDeviceTokensByEntityAndRole(ctx context.Context, entityID uint, roleNames []string) ([]string, error)
 	RemoveDeviceToken(ctx context.Context, userID uint, deviceToken string) error
 	DeactivateOldTokens(ctx context.Context, userID uint, keepToken string) error
+
+	// FCM topics
+	GetUserRoleName(ctx context.Context, userID uint) (string, error)
+	GetDeviceToken(ctx context.Context, userID uint, deviceToken string) (*FCMDeviceToken, error)
+	GetActiveTokensByUser(ctx context.Context, userID uint) ([]string, error)
+	GetActiveMembershipEntityIDs(ctx context.Context, userID uint) ([]uint, error)
+
+	// Preferences
+	GetPreferences(ctx context.Context, userID uint) ([]NotificationPreference, error)
+	SavePreferences(ctx context.Context, prefs []NotificationPreference) error
+	IsChannelDisabled(ctx context.Context, userID uint, category, channel string) (bool, error)
+	OptedOutEmails(ctx context.Context, emails []string, category string) ([]string, error)
+	OptedOutDeviceTokens(ctx context.Context, tokens []string, category string) ([]string, error)
+
+	// Template translations
+	ListTranslations(ctx context.Context, templateID uint) ([]TemplateTranslation, error)
+	SaveTranslation(ctx context.Context, t *TemplateTranslation) error
+	DeleteTranslation(ctx context.Context, templateID uint, language string) error
+	RecipientLanguages(ctx context.Context, channel string, recipients []string) (map[string]string, error)
+
+	// Template previews
+	GetRecordVariables(ctx context.Context, notificationType string, entityID, recordID uint) (map[string]string, error)
+
+	// Email channel
+	GetEmailSettings(ctx context.Context, entityID uint) (*EmailSettings, error)
+	SaveEmailSettings(ctx context.Context, settings *EmailSettings) error
+	CreateEmailDelivery(ctx context.Context, d *EmailDelivery) error
+	UpdateEmailDelivery(ctx context.Context, d *EmailDelivery) error
+	ListEmailDeliveries(ctx context.Context, entityID uint, filter EmailDeliveryFilter) ([]EmailDelivery, int64, error)
+	GetUserContact(ctx context.Context, userID uint) (string, string, error)
+	GetEntityName(ctx context.Context, entityID uint) (string, error)
+
+	// SMS/WhatsApp channel
+	ListMessagingSettings(ctx context.Context, entityID uint) ([]MessagingSettings, error)
+	GetMessagingSettings(ctx context.Context, entityID uint, channel string) (*MessagingSettings, error)
+	SaveMessagingSettings(ctx context.Context, settings *MessagingSettings) error
+	DeleteMessagingSettings(ctx context.Context, entityID uint, channel string) error
+	CreateMessageDelivery(ctx context.Context, d *MessageDelivery) error
+	UpdateMessageDelivery(ctx context.Context, d *MessageDelivery) error
+	FindMessageDeliveries(ctx context.Context, provider, messageID string) ([]MessageDelivery, error)
+	ListMessageDeliveries(ctx context.Context, entityID uint, filter MessageDeliveryFilter) ([]MessageDelivery, int64, error)
+	GetUserPhone(ctx context.Context, userID uint) (string, error)
+	OptedOutPhones(ctx context.Context, phones []string, category, channel string) ([]string, error)
 }
 
 type repository struct {
@@ -88,6 +133,8 @@ func (r *repository) DeleteTemplate(ctx context.Context, id uint, entityID uint)
 	if res.RowsAffected == 0 {
 		return errors.New("template not found or unauthorized")
 	}
+	// Translations belong to the template
+	r.db.WithContext(ctx).Where("template_id = ?", id).Delete(&TemplateTranslation{})
 	return nil
 }
 
@@ -143,6 +190,16 @@ func (r *repository) ListInAppByUser(ctx context.Context, userID uint, entityID
 	return items, err
 }
 
+func (r *repository) ListInAppSince(ctx context.Context, userID, afterID uint, limit int) ([]InAppNotification, error) {
+	var items []InAppNotification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND id > ?", userID, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
 func (r *repository) MarkInAppAsRead(ctx context.Context, id uint, userID uint) error {
 	return r.db.WithContext(ctx).
 		Model(&InAppNotification{}).
@@ -236,4 +293,115 @@ func (r *repository) DeactivateOldTokens(ctx context.Context, userID uint, keepT
 		Model(&FCMDeviceToken{}).
 		Where("user_id = ? AND device_token != ?", userID, keepToken).
 		Update("is_active", false).Error
-}
\ No newline at end of file
+}
+
+// recordVariables is the superset of columns selected for template previews
+type recordVariables struct {
+	DevoteeName    string
+	DevoteeEmail   string
+	DevoteePhone   string `gorm:"serializer:encrypted"`
+	TempleName     string
+	SevaName       string
+	SevaType       string
+	SevaDate       string
+	SevaTime       string
+	SevaPrice      float64
+	BookingStatus  string
+	BookingTime    time.Time
+	EventTitle     string
+	EventType      string
+	EventDate      time.Time
+	EventTime      *time.Time
+	EventLocation  string
+	DonationAmount float64
+	DonationType   string
+	DonationStatus string
+	DonatedAt      *time.Time
+	CreatedAt      time.Time
+	OrderID        string
+}
+
+// GetRecordVariables loads a real record of the notification type's subject
+// (seva booking, event, donation, otherwise a devotee) scoped to the entity
+// and maps it onto template variable names
+func (r *repository) GetRecordVariables(ctx context.Context, notificationType string, entityID, recordID uint) (map[string]string, error) {
+	var row recordVariables
+	db := r.db.WithContext(ctx)
+	var query *gorm.DB
+
+	switch notificationType {
+	case NotificationTypeSeva:
+		query = db.Table("seva_bookings sb").
+			Select(`u.full_name AS devotee_name, u.email AS devotee_email, u.phone AS devotee_phone, e.name AS temple_name,
+				s.name AS seva_name, s.seva_type, s.date AS seva_date, s.start_time AS seva_time, s.price AS seva_price,
+				sb.status AS booking_status, sb.booking_time`).
+			Joins("JOIN sevas s ON s.id = sb.seva_id").
+			Joins("JOIN users u ON u.id = sb.user_id").
+			Joins("JOIN entities e ON e.id = sb.entity_id").
+			Where("sb.id = ? AND sb.entity_id = ?", recordID, entityID)
+	case NotificationTypeEvent:
+		query = db.Table("events ev").
+			Select(`e.name AS temple_name, ev.title AS event_title, ev.event_type, ev.event_date, ev.event_time,
+				ev.location AS event_location`).
+			Joins("JOIN entities e ON e.id = ev.entity_id").
+			Where("ev.id = ? AND ev.entity_id = ?", recordID, entityID)
+	case NotificationTypeDonation:
+		query = db.Table("donations d").
+			Select(`u.full_name AS devotee_name, u.email AS devotee_email, u.phone AS devotee_phone, e.name AS temple_name,
+				d.amount AS donation_amount, d.donation_type, d.status AS donation_status, d.donated_at, d.created_at, d.order_id`).
+			Joins("JOIN users u ON u.id = d.user_id").
+			Joins("JOIN entities e ON e.id = d.entity_id").
+			Where("d.id = ? AND d.entity_id = ? AND d.deleted_at IS NULL", recordID, entityID)
+	default:
+		query = db.Table("users u").
+			Select("u.full_name AS devotee_name, u.email AS devotee_email, u.phone AS devotee_phone, e.name AS temple_name").
+			Joins("JOIN user_entity_memberships uem ON uem.user_id = u.id").
+			Joins("JOIN entities e ON e.id = uem.entity_id").
+			Where("u.id = ? AND uem.entity_id = ?", recordID, entityID)
+	}
+
+	res := query.Limit(1).Scan(&row)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	vars := map[string]string{
+		"devotee_name":  row.DevoteeName,
+		"devotee_email": row.DevoteeEmail,
+		"devotee_phone": row.DevoteePhone,
+		"temple_name":   row.TempleName,
+	}
+	switch notificationType {
+	case NotificationTypeSeva:
+		vars["seva_name"] = row.SevaName
+		vars["seva_type"] = row.SevaType
+		vars["seva_date"] = row.SevaDate
+		vars["seva_time"] = row.SevaTime
+		vars["seva_price"] = fmt.Sprintf("%.2f", row.SevaPrice)
+		vars["booking_id"] = fmt.Sprintf("%d", recordID)
+		vars["booking_status"] = row.BookingStatus
+		vars["booking_date"] = row.BookingTime.Format("02-01-2006")
+	case NotificationTypeEvent:
+		vars["event_title"] = row.EventTitle
+		vars["event_type"] = row.EventType
+		vars["event_date"] = row.EventDate.Format("02-01-2006")
+		if row.EventTime != nil {
+			vars["event_time"] = row.EventTime.Format("15:04")
+		}
+		vars["event_location"] = row.EventLocation
+	case NotificationTypeDonation:
+		donated := row.CreatedAt
+		if row.DonatedAt != nil {
+			donated = *row.DonatedAt
+		}
+		vars["donation_amount"] = fmt.Sprintf("%.2f", row.DonationAmount)
+		vars["donation_type"] = row.DonationType
+		vars["donation_date"] = donated.Format("02-01-2006")
+		vars["donation_status"] = row.DonationStatus
+		vars["order_id"] = row.OrderID
+	}
+	return vars, nil
+}
@@ -28,11 +28,16 @@ type Service interface {
 	// In-app notifications
 	CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error
 	ListInAppByUser(ctx context.Context, userID uint, entityID *uint, limit int) ([]InAppNotification, error)
+	ListInAppSince(ctx context.Context, userID, afterID uint, limit int) ([]InAppNotification, error)
 	MarkInAppAsRead(ctx context.Context, id uint, userID uint) error
 
 	// Fan-out helpers
 	CreateInAppForEntityRoles(ctx context.Context, entityID uint, roleNames []string, title, message, category string) error
 
+	// Per-user preferences
+	GetPreferences(ctx context.Context, userID uint) (Preferences, error)
+	UpdatePreferences(ctx context.Context, userID uint, updates []PreferenceUpdate, ip string) (Preferences, error)
+
 	// ✅ FCM Device Token Management
 	RegisterDeviceToken(ctx context.Context, userID, entityID uint, deviceToken, deviceType, deviceName string) error
 	RemoveDeviceToken(ctx context.Context, userID uint, deviceToken string) error
@@ -41,6 +46,24 @@ type Service interface {
 	// ✅ FCM Push Notifications
 	SendPushNotification(ctx context.Context, senderID, entityID uint, title, body string, userIDs []uint, ip string) error
 	SendPushToRoles(ctx context.Context, senderID, entityID uint, title, body string, roleNames []string, ip string) error
+
+	// ✅ FCM Topics (one per entity and role)
+	SendPushToTopic(ctx context.Context, senderID, entityID uint, title, body string, roleNames []string, ip string) error
+	SubscribeToEntityTopic(ctx context.Context, userID, entityID uint) error
+	UnsubscribeFromEntityTopic(ctx context.Context, userID, entityID uint) error
+
+	// Template variables
+	RenderTemplate(ctx context.Context, entityID uint, req RenderTemplateRequest) (*RenderedTemplate, error)
+
+	// Template translations
+	ListTranslations(ctx context.Context, templateID, entityID uint) ([]TemplateTranslation, error)
+	SaveTranslation(ctx context.Context, templateID, entityID, userID uint, language string, in TranslationInput, ip string) (*TemplateTranslation, error)
+	MachineTranslate(ctx context.Context, templateID, entityID, userID uint, languages []string, ip string) ([]TemplateTranslation, error)
+	DeleteTranslation(ctx context.Context, templateID, entityID, userID uint, language, ip string) error
+	SendLocalizedNotification(ctx context.Context, senderID, entityID, templateID uint, channel string, variables map[string]string, recipients []string, ip string) error
+
+	// SMS/WhatsApp gateways; without a messenger text messages are only logged
+	SetMessenger(m *Messenger)
 }
 
 type service struct {
@@ -51,6 +74,11 @@ type service struct {
 	sms      Channel
 	whatsapp Channel
 	fcm      Channel // ✅ FCM channel
+
+	messenger *Messenger // nil: sms and whatsapp use the logging channels
+
+	translator      Translator // nil when machine translation is not configured
+	defaultLanguage string
 }
 
 // ✅ Updated constructor to initialize FCM
@@ -63,11 +91,28 @@ func NewService(repo Repository, authRepo auth.Repository, cfg *config.Config, a
 		sms:      NewSMSChannel(),
 		whatsapp: NewWhatsAppChannel(),
 		fcm:      NewFCMChannel(cfg), // ✅ Initialize FCM
+
+		translator:      NewLibreTranslator(cfg.TranslationAPIURL, cfg.TranslationAPIKey),
+		defaultLanguage: cfg.DefaultLanguage,
 	}
 }
 
 // ✅ Updated with audit logging
 func (s *service) CreateTemplate(ctx context.Context, t *NotificationTemplate, ip string) error {
+	if t.NotificationType == "" {
+		t.NotificationType = NotificationTypeGeneral
+	}
+	if lang, ok := NormalizeLanguage(t.Language); ok {
+		t.Language = lang
+	} else if t.Language == "" {
+		t.Language = s.defaultLanguage
+	} else {
+		return fmt.Errorf("%w: invalid language %q", ErrInvalidTemplate, t.Language)
+	}
+	if _, err := ValidateTemplate(t.NotificationType, t.Subject, t.Body); err != nil {
+		return err
+	}
+
 	err := s.repo.CreateTemplate(ctx, t)
 
 	status := "success"
@@ -90,7 +135,26 @@ func (s *service) CreateTemplate(ctx context.Context, t *NotificationTemplate, i
 
 // ✅ Updated with audit logging
 func (s *service) UpdateTemplate(ctx context.Context, t *NotificationTemplate, ip string) error {
-	err := s.repo.UpdateTemplate(ctx, t)
+	// Partial updates keep the stored type/subject/body, so validate the merged result
+	existing, err := s.repo.GetTemplateByID(ctx, t.ID, t.EntityID)
+	if err != nil {
+		return err
+	}
+	notificationType, subject, body := t.NotificationType, t.Subject, t.Body
+	if notificationType == "" {
+		notificationType = existing.NotificationType
+	}
+	if subject == "" {
+		subject = existing.Subject
+	}
+	if body == "" {
+		body = existing.Body
+	}
+	if _, err := ValidateTemplate(notificationType, subject, body); err != nil {
+		return err
+	}
+
+	err = s.repo.UpdateTemplate(ctx, t)
 
 	status := "success"
 	if err != nil {
@@ -160,6 +224,12 @@ func (s *service) SendNotification(
 		return errors.New("no recipients specified")
 	}
 
+	recipients = s.filterRecipients(ctx, templateID, entityID, channel, recipients)
+	if len(recipients) == 0 {
+		fmt.Println("🔕 All recipients opted out of this notification")
+		return nil
+	}
+
 	recipientsJSON, _ := json.Marshal(recipients)
 	log := &NotificationLog{
 		UserID:     senderID,
@@ -187,9 +257,9 @@ func (s *service) SendNotification(
 	case "email":
 		sendErr = s.sendEmailInBatches(recipients, subject, body, batchSize)
 	case "sms":
-		sendErr = s.sendSMSInBatches(recipients, subject, body, batchSize)
+		sendErr = s.sendSMSInBatches(s.textChannel(ctx, entityID, ChannelSMS), recipients, subject, body, batchSize)
 	case "whatsapp":
-		sendErr = s.sendWhatsAppInBatches(recipients, subject, body, batchSize)
+		sendErr = s.sendWhatsAppInBatches(s.textChannel(ctx, entityID, ChannelWhatsApp), recipients, subject, body, batchSize)
 	case "push": // ✅ NEW: FCM push notifications
 		sendErr = s.sendPushInBatches(recipients, subject, body, 500) // FCM supports 500 per batch
 	default:
@@ -298,7 +368,7 @@ func (s *service) sendEmailInBatches(recipients []string, subject, body string,
 }
 
 // ✅ Helper function to send SMS in batches
-func (s *service) sendSMSInBatches(recipients []string, subject, body string, batchSize int) error {
+func (s *service) sendSMSInBatches(sms Channel, recipients []string, subject, body string, batchSize int) error {
 	totalRecipients := len(recipients)
 	var lastErr error
 	successCount := 0
@@ -319,7 +389,7 @@ func (s *service) sendSMSInBatches(recipients []string, subject, body string, ba
 		fmt.Printf("📤 Processing SMS batch %d/%d: sending to %d recipients\n", 
 			batchNum, totalBatches, len(batch))
 		
-		if err := s.sms.Send(batch, subject, body); err != nil {
+		if err := sms.Send(batch, subject, body); err != nil {
 			fmt.Printf("❌ SMS Batch %d/%d failed: %v\n", batchNum, totalBatches, err)
 			lastErr = err
 			failedCount += len(batch)
@@ -349,7 +419,7 @@ func (s *service) sendSMSInBatches(recipients []string, subject, body string, ba
 }
 
 // ✅ Helper function to send WhatsApp in batches
-func (s *service) sendWhatsAppInBatches(recipients []string, subject, body string, batchSize int) error {
+func (s *service) sendWhatsAppInBatches(whatsapp Channel, recipients []string, subject, body string, batchSize int) error {
 	totalRecipients := len(recipients)
 	var lastErr error
 	successCount := 0
@@ -370,7 +440,7 @@ func (s *service) sendWhatsAppInBatches(recipients []string, subject, body strin
 		fmt.Printf("📤 Processing WhatsApp batch %d/%d: sending to %d recipients\n", 
 			batchNum, totalBatches, len(batch))
 		
-		if err := s.whatsapp.Send(batch, subject, body); err != nil {
+		if err := whatsapp.Send(batch, subject, body); err != nil {
 			fmt.Printf("❌ WhatsApp Batch %d/%d failed: %v\n", batchNum, totalBatches, err)
 			lastErr = err
 			failedCount += len(batch)
@@ -452,6 +522,9 @@ func (s *service) sendPushInBatches(deviceTokens []string, title, body string, b
 
 // CreateInAppNotification stores a bell notification for a specific user
 func (s *service) CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error {
+	if !s.allowsInApp(ctx, userID, category) {
+		return nil
+	}
 	item := &InAppNotification{
 		UserID:    userID,
 		EntityID:  entityID,
@@ -465,18 +538,8 @@ func (s *service) CreateInAppNotification(ctx context.Context, userID, entityID
 		return err
 	}
 
-	payload, _ := json.Marshal(map[string]interface{}{
-		"id":         item.ID,
-		"user_id":    item.UserID,
-		"entity_id":  item.EntityID,
-		"title":      item.Title,
-		"message":    item.Message,
-		"category":   item.Category,
-		"is_read":    item.IsRead,
-		"created_at": item.CreatedAt,
-	})
-	channel := fmt.Sprintf("notifications:user:%d", userID)
-	_ = utils.RedisClient.Publish(utils.Ctx, channel, string(payload)).Err()
+	payload, _ := json.Marshal(inAppPayload(item))
+	_ = utils.RedisClient.Publish(utils.Ctx, inAppChannel(userID), string(payload)).Err()
 	return nil
 }
 
@@ -484,8 +547,18 @@ func (s *service) ListInAppByUser(ctx context.Context, userID uint, entityID *ui
 	return s.repo.ListInAppByUser(ctx, userID, entityID, limit)
 }
 
+// ListInAppSince returns the user's notifications newer than afterID, oldest first
+func (s *service) ListInAppSince(ctx context.Context, userID, afterID uint, limit int) ([]InAppNotification, error) {
+	return s.repo.ListInAppSince(ctx, userID, afterID, limit)
+}
+
 func (s *service) MarkInAppAsRead(ctx context.Context, id uint, userID uint) error {
-	return s.repo.MarkInAppAsRead(ctx, id, userID)
+	if err := s.repo.MarkInAppAsRead(ctx, id, userID); err != nil {
+		return err
+	}
+	// Other open tabs and devices clear the unread badge
+	PublishUserEvent(ctx, userID, StreamEventRead, map[string]interface{}{"id": id})
+	return nil
 }
 
 func (s *service) CreateInAppForEntityRoles(ctx context.Context, entityID uint, roleNames []string, title, message, category string) error {
@@ -550,11 +623,18 @@ func (s *service) RegisterDeviceToken(ctx context.Context, userID, entityID uint
 		UpdatedAt:   time.Now(),
 	}
 
-	return s.repo.SaveDeviceToken(ctx, token)
+	if err := s.repo.SaveDeviceToken(ctx, token); err != nil {
+		return err
+	}
+	s.syncDeviceTopics(ctx, userID, entityID, deviceToken, true)
+	return nil
 }
 
 // ✅ NEW: Remove FCM device token
 func (s *service) RemoveDeviceToken(ctx context.Context, userID uint, deviceToken string) error {
+	if token, err := s.repo.GetDeviceToken(ctx, userID, deviceToken); err == nil && token.IsActive {
+		s.syncDeviceTopics(ctx, userID, token.EntityID, deviceToken, false)
+	}
 	return s.repo.RemoveDeviceToken(ctx, userID, deviceToken)
 }
 
@@ -598,4 +678,61 @@ func (s *service) SendPushToRoles(ctx context.Context, senderID, entityID uint,
 
 	// Use the existing SendNotification method with push channel
 	return s.SendNotification(ctx, senderID, entityID, nil, "push", title, body, tokens, ip)
-}
\ No newline at end of file
+}
+
+// RenderTemplate renders a stored or ad-hoc template for previews and sends.
+// Unknown variables in the template or in req.Variables are rejected.
+func (s *service) RenderTemplate(ctx context.Context, entityID uint, req RenderTemplateRequest) (*RenderedTemplate, error) {
+	if req.TemplateID != nil {
+		t, err := s.repo.GetTemplateByID(ctx, *req.TemplateID, entityID)
+		if err != nil {
+			return nil, err
+		}
+		if req.NotificationType == "" {
+			req.NotificationType = t.NotificationType
+		}
+		if req.Subject == "" {
+			req.Subject = t.Subject
+		}
+		if req.Body == "" {
+			req.Body = t.Body
+		}
+	}
+	if req.NotificationType == "" {
+		req.NotificationType = NotificationTypeGeneral
+	}
+
+	vars, err := SampleVariables(req.NotificationType)
+	if err != nil {
+		return nil, err
+	}
+	vars["date"] = time.Now().Format("02-01-2006")
+
+	source := "sample"
+	if req.RecordID != nil {
+		record, err := s.repo.GetRecordVariables(ctx, req.NotificationType, entityID, *req.RecordID)
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range record {
+			if v != "" {
+				vars[k] = v
+			}
+		}
+		source = "record"
+	}
+
+	for k, v := range req.Variables {
+		if _, ok := vars[k]; !ok {
+			return nil, fmt.Errorf("%w: unknown variable %q for %s notifications", ErrInvalidTemplate, k, req.NotificationType)
+		}
+		vars[k] = v
+	}
+
+	rendered, err := RenderTemplate(req.NotificationType, req.Subject, req.Body, vars)
+	if err != nil {
+		return nil, err
+	}
+	rendered.Source = source
+	return rendered, nil
+}
@@ -0,0 +1,131 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SESProvider sends through the Amazon SES v2 API. Messages are sent raw, so
+// attachments work the same as over SMTP.
+type SESProvider struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+	Endpoint  string // https://email.<region>.amazonaws.com unless overridden
+
+	client *http.Client
+}
+
+func NewSESProvider(region, accessKey, secretKey string) *SESProvider {
+	return &SESProvider{
+		Region:    region,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Endpoint:  fmt.Sprintf("https://email.%s.amazonaws.com", region),
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *SESProvider) Name() string { return "ses" }
+
+func (p *SESProvider) Send(ctx context.Context, msg *EmailMessage) (string, error) {
+	raw, _, err := buildMIME(msg)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"FromEmailAddress": msg.FromAddr,
+		"Destination":      map[string][]string{"ToAddresses": msg.To},
+		"Content":          map[string]interface{}{"Raw": map[string][]byte{"Data": raw}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.Endpoint+"/v2/email/outbound-emails", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	signSigV4(req, payload, p.AccessKey, p.SecretKey, p.Region, "ses", time.Now())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("SES request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		_ = json.Unmarshal(body, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(body))
+		}
+		return "", fmt.Errorf("SES returned %d: %s", resp.StatusCode, apiErr.Message)
+	}
+
+	var out struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.Unmarshal(body, &out); err != nil {
+		return "", fmt.Errorf("invalid SES response: %w", err)
+	}
+	return out.MessageID, nil
+}
+
+// signSigV4 adds AWS Signature Version 4 headers to a request without a
+// query string
+func signSigV4(req *http.Request, payload []byte, accessKey, secretKey, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{req.Method, path, "", canonicalHeaders, signedHeaders, payloadHash}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
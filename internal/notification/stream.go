@@ -0,0 +1,194 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Events pushed on the notification stream. In-app notifications keep their
+// original payload; every other event is wrapped in a StreamEvent.
+const (
+	StreamEventInApp          = "inapp"
+	StreamEventRead           = "read"
+	StreamEventApprovalStatus = "approval_status"
+)
+
+const (
+	streamHeartbeat   = 25 * time.Second // below the usual 30-60s proxy idle timeouts
+	streamReplayLimit = 50
+)
+
+// StreamEvent is a non in-app event published to a user's stream
+type StreamEvent struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+// ApprovalStatusChange tells a user their tenant account or temple was
+// approved or rejected
+type ApprovalStatusChange struct {
+	Subject  string `json:"subject"` // tenant or entity
+	ID       uint   `json:"id"`
+	Name     string `json:"name,omitempty"`
+	Status   string `json:"status"`
+	Reason   string `json:"reason,omitempty"`
+	Occurred string `json:"occurred_at"`
+}
+
+// streamsClosed is closed on shutdown so open streams end and the server can
+// drain; clients reconnect to another instance using Last-Event-ID
+var (
+	streamsClosed    = make(chan struct{})
+	closeStreamsOnce sync.Once
+)
+
+// CloseStreams ends every open notification stream
+func CloseStreams() {
+	closeStreamsOnce.Do(func() { close(streamsClosed) })
+}
+
+func inAppChannel(userID uint) string {
+	return fmt.Sprintf("notifications:user:%d", userID)
+}
+
+func eventsChannel(userID uint) string {
+	return fmt.Sprintf("notifications:user:%d:events", userID)
+}
+
+// PublishUserEvent pushes an event to the user's open notification streams.
+// Delivery is best effort: users without an open stream pick up the change
+// on their next fetch.
+func PublishUserEvent(ctx context.Context, userID uint, event string, data interface{}) {
+	if utils.RedisClient == nil || userID == 0 {
+		return
+	}
+	payload, err := json.Marshal(StreamEvent{Event: event, Data: data})
+	if err != nil {
+		return
+	}
+	if err := utils.RedisClient.Publish(ctx, eventsChannel(userID), string(payload)).Err(); err != nil {
+		log.Printf("⚠️ Failed to publish %s event for user %d: %v", event, userID, err)
+	}
+}
+
+// PublishApprovalStatus pushes an approval decision to the affected user
+func PublishApprovalStatus(ctx context.Context, userID uint, change ApprovalStatusChange) {
+	if change.Occurred == "" {
+		change.Occurred = time.Now().Format(time.RFC3339)
+	}
+	PublishUserEvent(ctx, userID, StreamEventApprovalStatus, change)
+}
+
+// stream serves the user's notifications as Server-Sent Events until the
+// client disconnects. Clients reconnecting with Last-Event-ID (sent
+// automatically by EventSource) first receive the in-app notifications they
+// missed.
+func (h *Handler) stream(c *gin.Context, userID uint) {
+	if utils.RedisClient == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "notification stream unavailable"})
+		return
+	}
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.Status(http.StatusInternalServerError)
+		return
+	}
+
+	ctx := c.Request.Context()
+	sub := utils.RedisClient.Subscribe(ctx, inAppChannel(userID), eventsChannel(userID))
+	defer sub.Close()
+
+	c.Writer.Header().Set("Content-Type", "text/event-stream")
+	c.Writer.Header().Set("Cache-Control", "no-cache")
+	c.Writer.Header().Set("Connection", "keep-alive")
+	c.Writer.Header().Set("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	_, _ = c.Writer.Write([]byte("retry: 5000\n:ok\n\n"))
+
+	lastID := c.GetHeader("Last-Event-ID")
+	if lastID == "" {
+		lastID = c.Query("last_event_id")
+	}
+	if after, err := strconv.ParseUint(lastID, 10, 32); err == nil && after > 0 {
+		missed, err := h.Service.ListInAppSince(ctx, userID, uint(after), streamReplayLimit)
+		if err != nil {
+			log.Printf("⚠️ Failed to replay notifications for user %d: %v", userID, err)
+		}
+		for _, n := range missed {
+			payload, _ := json.Marshal(inAppPayload(&n))
+			writeStreamEvent(c, strconv.FormatUint(uint64(n.ID), 10), StreamEventInApp, string(payload))
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			if msg.Channel == inAppChannel(userID) {
+				var n struct {
+					ID uint `json:"id"`
+				}
+				_ = json.Unmarshal([]byte(msg.Payload), &n)
+				writeStreamEvent(c, strconv.FormatUint(uint64(n.ID), 10), StreamEventInApp, msg.Payload)
+			} else {
+				var ev struct {
+					Event string          `json:"event"`
+					Data  json.RawMessage `json:"data"`
+				}
+				if err := json.Unmarshal([]byte(msg.Payload), &ev); err != nil || ev.Event == "" {
+					continue
+				}
+				writeStreamEvent(c, "", ev.Event, string(ev.Data))
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			_, _ = c.Writer.Write([]byte(":ping\n\n"))
+			flusher.Flush()
+		case <-ctx.Done():
+			return
+		case <-streamsClosed:
+			return
+		}
+	}
+}
+
+// writeStreamEvent writes one SSE frame. Only in-app notifications carry an
+// id, so Last-Event-ID always points at the newest notification received.
+func writeStreamEvent(c *gin.Context, id, event, data string) {
+	if id != "" && id != "0" {
+		_, _ = c.Writer.Write([]byte("id: " + id + "\n"))
+	}
+	_, _ = c.Writer.Write([]byte("event: " + event + "\ndata: " + data + "\n\n"))
+}
+
+// inAppPayload is the stream payload of an in-app notification
+func inAppPayload(item *InAppNotification) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         item.ID,
+		"user_id":    item.UserID,
+		"entity_id":  item.EntityID,
+		"title":      item.Title,
+		"message":    item.Message,
+		"category":   item.Category,
+		"is_read":    item.IsRead,
+		"created_at": item.CreatedAt,
+	}
+}
@@ -0,0 +1,210 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// TopicClient is implemented by push channels that support FCM topics
+type TopicClient interface {
+	SendToTopic(topic, title, body string) error
+	SubscribeToTopic(tokens []string, topic string) error
+	UnsubscribeFromTopic(tokens []string, topic string) error
+}
+
+// fcmTopicBatch is the most tokens FCM accepts per (un)subscribe call
+const fcmTopicBatch = 1000
+
+// EntityTopic is the FCM topic of one role within an entity, e.g. entity_12_devotee
+func EntityTopic(entityID uint, roleName string) string {
+	return fmt.Sprintf("entity_%d_%s", entityID, roleName)
+}
+
+// ===== REPOSITORY =====
+
+// GetUserRoleName returns the role name of a user
+func (r *repository) GetUserRoleName(ctx context.Context, userID uint) (string, error) {
+	var roleName string
+	err := r.db.WithContext(ctx).Table("users").
+		Select("user_roles.role_name").
+		Joins("JOIN user_roles ON users.role_id = user_roles.id").
+		Where("users.id = ?", userID).
+		Scan(&roleName).Error
+	return roleName, err
+}
+
+// GetDeviceToken returns a user's registration of a device token
+func (r *repository) GetDeviceToken(ctx context.Context, userID uint, deviceToken string) (*FCMDeviceToken, error) {
+	var token FCMDeviceToken
+	err := r.db.WithContext(ctx).Where("user_id = ? AND device_token = ?", userID, deviceToken).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// GetActiveTokensByUser returns every active device token of a user, whichever
+// entity it was registered under
+func (r *repository) GetActiveTokensByUser(ctx context.Context, userID uint) ([]string, error) {
+	var tokens []string
+	err := r.db.WithContext(ctx).Model(&FCMDeviceToken{}).
+		Where("user_id = ? AND is_active = ?", userID, true).
+		Pluck("device_token", &tokens).Error
+	return tokens, err
+}
+
+// GetActiveMembershipEntityIDs returns the entities the user is an active member of
+func (r *repository) GetActiveMembershipEntityIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).Table("user_entity_memberships").
+		Where("user_id = ? AND status = ?", userID, "active").
+		Pluck("entity_id", &ids).Error
+	return ids, err
+}
+
+// ===== SERVICE =====
+
+// SendPushToTopic announces to every device of the given roles in the entity
+// with one topic publish per role instead of per-token messages. Topic
+// messages bypass per-user preferences, so use it for broadcasts only.
+func (s *service) SendPushToTopic(ctx context.Context, senderID, entityID uint, title, body string, roleNames []string, ip string) error {
+	topics, ok := s.fcm.(TopicClient)
+	if !ok {
+		return errors.New("push channel does not support topics")
+	}
+	if len(roleNames) == 0 {
+		return errors.New("no roles specified")
+	}
+
+	names := make([]string, len(roleNames))
+	for i, role := range roleNames {
+		names[i] = EntityTopic(entityID, role)
+	}
+
+	recipientsJSON, _ := json.Marshal(names)
+	log := &NotificationLog{
+		UserID:     senderID,
+		EntityID:   entityID,
+		Channel:    "push",
+		Subject:    title,
+		Body:       body,
+		Recipients: datatypes.JSON(recipientsJSON),
+		Status:     "pending",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := s.repo.CreateNotificationLog(ctx, log); err != nil {
+		return err
+	}
+
+	var sendErr error
+	for _, topic := range names {
+		if err := topics.SendToTopic(topic, title, body); err != nil {
+			fmt.Printf("❌ Push to topic %s failed: %v\n", topic, err)
+			sendErr = err
+		}
+	}
+
+	if sendErr != nil {
+		errMsg := sendErr.Error()
+		log.Status = "failed"
+		log.Error = &errMsg
+	} else {
+		log.Status = "sent"
+	}
+	log.UpdatedAt = time.Now()
+	updateErr := s.repo.UpdateNotificationLog(ctx, log)
+
+	status := "success"
+	if sendErr != nil {
+		status = "failure"
+	}
+	if auditErr := s.auditSvc.LogAction(ctx, &senderID, &entityID, "PUSH_TOPIC_SENT", map[string]interface{}{
+		"topics":  names,
+		"subject": title,
+	}, ip, status); auditErr != nil {
+		fmt.Printf("❌ Audit log error: %v\n", auditErr)
+	}
+
+	if sendErr != nil {
+		return sendErr
+	}
+	return updateErr
+}
+
+// SubscribeToEntityTopic adds all of the user's devices to the entity topic
+// of their role. Called when the user joins or is reactivated in an entity.
+func (s *service) SubscribeToEntityTopic(ctx context.Context, userID, entityID uint) error {
+	return s.syncEntityTopic(ctx, userID, entityID, true)
+}
+
+// UnsubscribeFromEntityTopic removes all of the user's devices from the
+// entity topic of their role. Called when the membership ends.
+func (s *service) UnsubscribeFromEntityTopic(ctx context.Context, userID, entityID uint) error {
+	return s.syncEntityTopic(ctx, userID, entityID, false)
+}
+
+func (s *service) syncEntityTopic(ctx context.Context, userID, entityID uint, subscribe bool) error {
+	topics, ok := s.fcm.(TopicClient)
+	if !ok {
+		return nil
+	}
+	roleName, err := s.repo.GetUserRoleName(ctx, userID)
+	if err != nil || roleName == "" {
+		return err
+	}
+	tokens, err := s.repo.GetActiveTokensByUser(ctx, userID)
+	if err != nil || len(tokens) == 0 {
+		return err
+	}
+	return updateTopic(topics, tokens, EntityTopic(entityID, roleName), subscribe)
+}
+
+// syncDeviceTopics (un)subscribes one device to the topics of every entity
+// the user belongs to, plus the entity it was registered under
+func (s *service) syncDeviceTopics(ctx context.Context, userID, entityID uint, deviceToken string, subscribe bool) {
+	topics, ok := s.fcm.(TopicClient)
+	if !ok {
+		return
+	}
+	roleName, err := s.repo.GetUserRoleName(ctx, userID)
+	if err != nil || roleName == "" {
+		return
+	}
+	entityIDs, err := s.repo.GetActiveMembershipEntityIDs(ctx, userID)
+	if err != nil {
+		fmt.Printf("⚠️ Failed to load memberships of user %d: %v\n", userID, err)
+	}
+
+	seen := map[uint]bool{}
+	for _, id := range append(entityIDs, entityID) {
+		if id == 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if err := updateTopic(topics, []string{deviceToken}, EntityTopic(id, roleName), subscribe); err != nil {
+			fmt.Printf("⚠️ Topic sync failed for user %d: %v\n", userID, err)
+		}
+	}
+}
+
+func updateTopic(topics TopicClient, tokens []string, topic string, subscribe bool) error {
+	for i := 0; i < len(tokens); i += fcmTopicBatch {
+		batch := tokens[i:min(i+fcmTopicBatch, len(tokens))]
+		var err error
+		if subscribe {
+			err = topics.SubscribeToTopic(batch, topic)
+		} else {
+			err = topics.UnsubscribeFromTopic(batch, topic)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,518 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Translation statuses. Drafts are never sent; recipients of a language
+// without an approved translation get the template's own language.
+const (
+	TranslationDraft    = "draft"
+	TranslationApproved = "approved"
+)
+
+var (
+	ErrTranslationUnavailable = errors.New("machine translation is not configured")
+	ErrInvalidLanguage        = errors.New("invalid language code")
+
+	languagePattern = regexp.MustCompile(`^[a-z]{2,3}(-[a-z0-9]{2,8})?$`)
+
+	// Template actions are swapped for opaque markers so the provider
+	// can't translate or reorder variable names
+	templateActionPattern = regexp.MustCompile(`\{\{.*?\}\}`)
+	placeholderPattern    = regexp.MustCompile(`\[\[(\d+)\]\]`)
+)
+
+// TemplateTranslation is the subject and body of a template in one language
+type TemplateTranslation struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	TemplateID        uint      `gorm:"not null;uniqueIndex:idx_template_language" json:"template_id"`
+	Language          string    `gorm:"size:10;not null;uniqueIndex:idx_template_language" json:"language"`
+	Subject           string    `gorm:"size:255" json:"subject,omitempty"`
+	Body              string    `gorm:"type:text;not null" json:"body"`
+	Status            string    `gorm:"size:20;not null;default:'draft'" json:"status"`
+	MachineTranslated bool      `gorm:"default:false" json:"machine_translated"`
+	UpdatedBy         uint      `json:"updated_by"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// TranslationInput is a manual translation or an edit of a draft
+type TranslationInput struct {
+	Subject string `json:"subject"`
+	Body    string `json:"body" binding:"required"`
+	Status  string `json:"status"` // draft or approved, defaults to approved
+}
+
+// NormalizeLanguage lower-cases a language code such as "te" or "pt-BR"
+// and reports whether it is well formed
+func NormalizeLanguage(lang string) (string, bool) {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	return lang, languagePattern.MatchString(lang)
+}
+
+// ===== MACHINE TRANSLATION =====
+
+// Translator machine-translates text between two languages
+type Translator interface {
+	Translate(ctx context.Context, text, source, target string) (string, error)
+}
+
+type libreTranslator struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewLibreTranslator returns a translator for a LibreTranslate compatible API
+// at baseURL, or nil when baseURL is empty
+func NewLibreTranslator(baseURL, apiKey string) Translator {
+	if strings.TrimSpace(baseURL) == "" {
+		return nil
+	}
+	return &libreTranslator{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+func (t *libreTranslator) Translate(ctx context.Context, text, source, target string) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		return text, nil
+	}
+
+	payload, _ := json.Marshal(map[string]string{
+		"q":       text,
+		"source":  source,
+		"target":  target,
+		"format":  "text",
+		"api_key": t.apiKey,
+	})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.baseURL+"/translate", bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		TranslatedText string `json:"translatedText"`
+		Error          string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Error != "" {
+			return "", fmt.Errorf("translation failed: %s", body.Error)
+		}
+		return "", fmt.Errorf("translation failed with status %d", resp.StatusCode)
+	}
+	return body.TranslatedText, nil
+}
+
+// translateTemplateText translates text while keeping its {{...}} actions intact
+func translateTemplateText(ctx context.Context, tr Translator, text, source, target string) (string, error) {
+	var actions []string
+	masked := templateActionPattern.ReplaceAllStringFunc(text, func(action string) string {
+		actions = append(actions, action)
+		return "[[" + strconv.Itoa(len(actions)-1) + "]]"
+	})
+
+	translated, err := tr.Translate(ctx, masked, source, target)
+	if err != nil {
+		return "", err
+	}
+
+	return placeholderPattern.ReplaceAllStringFunc(translated, func(marker string) string {
+		i, _ := strconv.Atoi(placeholderPattern.FindStringSubmatch(marker)[1])
+		if i < len(actions) {
+			return actions[i]
+		}
+		return marker
+	}), nil
+}
+
+// ===== REPOSITORY =====
+
+func (r *repository) ListTranslations(ctx context.Context, templateID uint) ([]TemplateTranslation, error) {
+	var out []TemplateTranslation
+	err := r.db.WithContext(ctx).Where("template_id = ?", templateID).Order("language ASC").Find(&out).Error
+	return out, err
+}
+
+func (r *repository) SaveTranslation(ctx context.Context, t *TemplateTranslation) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "template_id"}, {Name: "language"}},
+		DoUpdates: clause.AssignmentColumns([]string{"subject", "body", "status", "machine_translated", "updated_by", "updated_at"}),
+	}).Create(t).Error
+}
+
+func (r *repository) DeleteTranslation(ctx context.Context, templateID uint, language string) error {
+	res := r.db.WithContext(ctx).Where("template_id = ? AND language = ?", templateID, language).Delete(&TemplateTranslation{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RecipientLanguages returns the preferred language of each email address or
+// device token that belongs to a devotee with a language set
+func (r *repository) RecipientLanguages(ctx context.Context, channel string, recipients []string) (map[string]string, error) {
+	var rows []struct {
+		Recipient string
+		Language  string
+	}
+	var err error
+	switch channel {
+	case "email":
+		err = r.db.WithContext(ctx).Table("users u").
+			Select("u.email AS recipient, dp.preferred_language AS language").
+			Joins("JOIN devotee_profiles dp ON dp.user_id = u.id").
+			Where("u.email IN ? AND dp.preferred_language IS NOT NULL AND dp.preferred_language <> ''", recipients).
+			Scan(&rows).Error
+	case "push":
+		err = r.db.WithContext(ctx).Table("fcm_device_tokens t").
+			Select("t.device_token AS recipient, dp.preferred_language AS language").
+			Joins("JOIN devotee_profiles dp ON dp.user_id = t.user_id").
+			Where("t.device_token IN ? AND dp.preferred_language IS NOT NULL AND dp.preferred_language <> ''", recipients).
+			Scan(&rows).Error
+	default:
+		// Phone numbers aren't linked to profiles reliably; use the default language
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	langs := make(map[string]string, len(rows))
+	for _, row := range rows {
+		langs[row.Recipient] = row.Language
+	}
+	return langs, nil
+}
+
+// ===== SERVICE =====
+
+func (s *service) ListTranslations(ctx context.Context, templateID, entityID uint) ([]TemplateTranslation, error) {
+	if _, err := s.repo.GetTemplateByID(ctx, templateID, entityID); err != nil {
+		return nil, err
+	}
+	return s.repo.ListTranslations(ctx, templateID)
+}
+
+// SaveTranslation stores a manual translation, or an edited/approved machine draft
+func (s *service) SaveTranslation(ctx context.Context, templateID, entityID, userID uint, language string, in TranslationInput, ip string) (*TemplateTranslation, error) {
+	t, err := s.repo.GetTemplateByID(ctx, templateID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	lang, ok := NormalizeLanguage(language)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLanguage, language)
+	}
+	if lang == t.Language {
+		return nil, fmt.Errorf("%w: %s is the template's own language, edit the template instead", ErrInvalidLanguage, lang)
+	}
+
+	status := in.Status
+	if status == "" {
+		status = TranslationApproved
+	}
+	if status != TranslationDraft && status != TranslationApproved {
+		return nil, fmt.Errorf("%w: status must be draft or approved", ErrInvalidTemplate)
+	}
+	if _, err := ValidateTemplate(t.NotificationType, in.Subject, in.Body); err != nil {
+		return nil, err
+	}
+
+	tr := &TemplateTranslation{
+		TemplateID: templateID,
+		Language:   lang,
+		Subject:    in.Subject,
+		Body:       in.Body,
+		Status:     status,
+		UpdatedBy:  userID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	err = s.repo.SaveTranslation(ctx, tr)
+
+	auditStatus := "success"
+	if err != nil {
+		auditStatus = "failure"
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "TEMPLATE_TRANSLATION_SAVED", map[string]interface{}{
+		"template_id": templateID,
+		"language":    lang,
+		"status":      status,
+	}, ip, auditStatus)
+
+	if err != nil {
+		return nil, err
+	}
+	return tr, nil
+}
+
+// MachineTranslate creates draft translations of the template for each
+// language. Drafts must be reviewed and approved before they are sent.
+func (s *service) MachineTranslate(ctx context.Context, templateID, entityID, userID uint, languages []string, ip string) ([]TemplateTranslation, error) {
+	if s.translator == nil {
+		return nil, ErrTranslationUnavailable
+	}
+	t, err := s.repo.GetTemplateByID(ctx, templateID, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	drafts := make([]TemplateTranslation, 0, len(languages))
+	for _, language := range languages {
+		lang, ok := NormalizeLanguage(language)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidLanguage, language)
+		}
+		if lang == t.Language {
+			continue
+		}
+
+		subject, err := translateTemplateText(ctx, s.translator, t.Subject, t.Language, lang)
+		if err != nil {
+			return nil, fmt.Errorf("translate subject to %s: %w", lang, err)
+		}
+		body, err := translateTemplateText(ctx, s.translator, t.Body, t.Language, lang)
+		if err != nil {
+			return nil, fmt.Errorf("translate body to %s: %w", lang, err)
+		}
+		if _, err := ValidateTemplate(t.NotificationType, subject, body); err != nil {
+			return nil, fmt.Errorf("machine translation to %s broke the template: %w", lang, err)
+		}
+
+		draft := TemplateTranslation{
+			TemplateID:        templateID,
+			Language:          lang,
+			Subject:           subject,
+			Body:              body,
+			Status:            TranslationDraft,
+			MachineTranslated: true,
+			UpdatedBy:         userID,
+			CreatedAt:         time.Now(),
+			UpdatedAt:         time.Now(),
+		}
+		if err := s.repo.SaveTranslation(ctx, &draft); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "TEMPLATE_MACHINE_TRANSLATED", map[string]interface{}{
+		"template_id": templateID,
+		"languages":   languages,
+	}, ip, "success")
+
+	return drafts, nil
+}
+
+func (s *service) DeleteTranslation(ctx context.Context, templateID, entityID, userID uint, language, ip string) error {
+	if _, err := s.repo.GetTemplateByID(ctx, templateID, entityID); err != nil {
+		return err
+	}
+	lang, _ := NormalizeLanguage(language)
+	err := s.repo.DeleteTranslation(ctx, templateID, lang)
+
+	status := "success"
+	if err != nil {
+		status = "failure"
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "TEMPLATE_TRANSLATION_DELETED", map[string]interface{}{
+		"template_id": templateID,
+		"language":    lang,
+	}, ip, status)
+	return err
+}
+
+// SendLocalizedNotification renders the template once per recipient language
+// and sends each group its own version. Recipients without a preference, or
+// whose language has no approved translation, get the template's language.
+func (s *service) SendLocalizedNotification(ctx context.Context, senderID, entityID, templateID uint, channel string, variables map[string]string, recipients []string, ip string) error {
+	t, err := s.repo.GetTemplateByID(ctx, templateID, entityID)
+	if err != nil {
+		return err
+	}
+	translations, err := s.repo.ListTranslations(ctx, templateID)
+	if err != nil {
+		return err
+	}
+	approved := map[string]TemplateTranslation{}
+	for _, tr := range translations {
+		if tr.Status == TranslationApproved {
+			approved[tr.Language] = tr
+		}
+	}
+
+	groups := map[string][]string{}
+	if len(approved) == 0 {
+		groups[t.Language] = recipients
+	} else {
+		langs, err := s.repo.RecipientLanguages(ctx, channel, recipients)
+		if err != nil {
+			fmt.Printf("⚠️ Failed to resolve recipient languages, using %s: %v\n", t.Language, err)
+		}
+		for _, r := range recipients {
+			lang := t.Language
+			if pref, ok := langs[r]; ok {
+				if _, ok := approved[pref]; ok {
+					lang = pref
+				}
+			}
+			groups[lang] = append(groups[lang], r)
+		}
+	}
+
+	var lastErr error
+	for lang, group := range groups {
+		req := RenderTemplateRequest{TemplateID: &templateID, Variables: variables}
+		if tr, ok := approved[lang]; ok {
+			req.Subject, req.Body = tr.Subject, tr.Body
+		}
+		rendered, err := s.RenderTemplate(ctx, entityID, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		fmt.Printf("🌐 Sending %s version to %d recipients\n", lang, len(group))
+		if err := s.SendNotification(ctx, senderID, entityID, &templateID, channel, rendered.Subject, rendered.Body, group, ip); err != nil {
+			lastErr = err
+		}
+	}
+	return lastErr
+}
+
+// ===== HANDLER =====
+
+// templateParam reads :id and the caller's entity for translation routes
+func templateParam(c *gin.Context) (middleware.AccessContext, uint, uint, bool) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return middleware.AccessContext{}, 0, 0, false
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	entityID := ctx.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return ctx, 0, 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid template id"})
+		return ctx, 0, 0, false
+	}
+	return ctx, uint(id), *entityID, true
+}
+
+func respondTranslationError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, ErrInvalidTemplate), errors.Is(err, ErrInvalidLanguage):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrTranslationUnavailable):
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "template or translation not found"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback})
+	}
+}
+
+// GET /api/v1/notifications/templates/:id/translations
+func (h *Handler) ListTranslations(c *gin.Context) {
+	_, templateID, entityID, ok := templateParam(c)
+	if !ok {
+		return
+	}
+	translations, err := h.Service.ListTranslations(c.Request.Context(), templateID, entityID)
+	if err != nil {
+		respondTranslationError(c, err, "failed to fetch translations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"translations": translations})
+}
+
+// PUT /api/v1/notifications/templates/:id/translations/:lang
+func (h *Handler) SaveTranslation(c *gin.Context) {
+	ctx, templateID, entityID, ok := templateParam(c)
+	if !ok {
+		return
+	}
+	var input TranslationInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tr, err := h.Service.SaveTranslation(c.Request.Context(), templateID, entityID, ctx.UserID, c.Param("lang"), input, middleware.GetIPFromContext(c))
+	if err != nil {
+		respondTranslationError(c, err, "failed to save translation")
+		return
+	}
+	c.JSON(http.StatusOK, tr)
+}
+
+// POST /api/v1/notifications/templates/:id/translations/machine
+// Body: {"languages": ["te", "kn"]}
+func (h *Handler) MachineTranslate(c *gin.Context) {
+	ctx, templateID, entityID, ok := templateParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		Languages []string `json:"languages" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	drafts, err := h.Service.MachineTranslate(c.Request.Context(), templateID, entityID, ctx.UserID, req.Languages, middleware.GetIPFromContext(c))
+	if err != nil {
+		respondTranslationError(c, err, "failed to translate template")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "draft translations created, approve them before sending", "translations": drafts})
+}
+
+// DELETE /api/v1/notifications/templates/:id/translations/:lang
+func (h *Handler) DeleteTranslation(c *gin.Context) {
+	ctx, templateID, entityID, ok := templateParam(c)
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteTranslation(c.Request.Context(), templateID, entityID, ctx.UserID, c.Param("lang"), middleware.GetIPFromContext(c)); err != nil {
+		respondTranslationError(c, err, "failed to delete translation")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "translation deleted"})
+}
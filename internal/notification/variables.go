@@ -0,0 +1,272 @@
+package notification
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Notification types. A template's type decides which variables it may use.
+const (
+	NotificationTypeGeneral  = "general"
+	NotificationTypeCampaign = "campaign"
+	NotificationTypeSeva     = "seva_booking"
+	NotificationTypeEvent    = "event"
+	NotificationTypeDonation = "donation"
+	NotificationTypeSystem   = "system"
+	NotificationTypeGreeting = "greeting"
+)
+
+// ErrInvalidTemplate wraps every template validation/rendering failure
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// TemplateVariable is one placeholder available to templates, used as {{.name}}
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// commonVariables are available to every notification type
+var commonVariables = []TemplateVariable{
+	{"devotee_name", "Recipient's full name", "Ramesh Kumar"},
+	{"devotee_email", "Recipient's email address", "ramesh@example.com"},
+	{"devotee_phone", "Recipient's phone number", "+91 98765 43210"},
+	{"temple_name", "Name of the temple", "Sri Venkateswara Temple"},
+	{"date", "Date the notification is sent (DD-MM-YYYY)", "15-10-2026"},
+}
+
+// typeVariables are the extra variables of each notification type
+var typeVariables = map[string][]TemplateVariable{
+	NotificationTypeGeneral: {},
+	NotificationTypeCampaign: {
+		{"campaign_name", "Name of the campaign", "Diwali Annadanam Drive"},
+	},
+	NotificationTypeSeva: {
+		{"seva_name", "Name of the seva", "Abhishekam"},
+		{"seva_type", "Type of the seva", "Archana"},
+		{"seva_date", "Date of the seva (DD-MM-YYYY)", "20-10-2026"},
+		{"seva_time", "Start time of the seva (HH:mm)", "06:30"},
+		{"seva_price", "Price of the seva", "501.00"},
+		{"booking_id", "Booking reference", "1024"},
+		{"booking_status", "Booking status", "approved"},
+		{"booking_date", "Date the booking was made (DD-MM-YYYY)", "14-10-2026"},
+	},
+	NotificationTypeEvent: {
+		{"event_title", "Title of the event", "Brahmotsavam"},
+		{"event_type", "Type of the event", "Festival"},
+		{"event_date", "Date of the event (DD-MM-YYYY)", "25-10-2026"},
+		{"event_time", "Start time of the event (HH:mm)", "18:00"},
+		{"event_location", "Where the event takes place", "Main Mandapam"},
+	},
+	NotificationTypeDonation: {
+		{"donation_amount", "Donated amount", "1001.00"},
+		{"donation_type", "Type of donation", "general"},
+		{"donation_date", "Date of the donation (DD-MM-YYYY)", "12-10-2026"},
+		{"donation_status", "Payment status", "SUCCESS"},
+		{"order_id", "Payment order reference", "order_Nx81sd92"},
+	},
+	NotificationTypeSystem: {
+		{"message", "System message text", "Your account has been approved"},
+	},
+	NotificationTypeGreeting: {
+		{"occasion", "birthday or anniversary", "birthday"},
+		{"years", "Age turned or years married, empty when unknown", "60"},
+	},
+}
+
+// IsValidNotificationType reports whether t has a variable catalog
+func IsValidNotificationType(t string) bool {
+	_, ok := typeVariables[t]
+	return ok
+}
+
+// NotificationTypes lists the known notification types in a stable order
+func NotificationTypes() []string {
+	types := make([]string, 0, len(typeVariables))
+	for t := range typeVariables {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// VariablesFor returns the variable catalog of a notification type
+func VariablesFor(notificationType string) ([]TemplateVariable, error) {
+	extra, ok := typeVariables[notificationType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown notification type %q", ErrInvalidTemplate, notificationType)
+	}
+	vars := make([]TemplateVariable, 0, len(commonVariables)+len(extra))
+	vars = append(vars, commonVariables...)
+	vars = append(vars, extra...)
+	return vars, nil
+}
+
+// SampleVariables returns the example value of every variable of a type
+func SampleVariables(notificationType string) (map[string]string, error) {
+	vars, err := VariablesFor(notificationType)
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string]string, len(vars))
+	for _, v := range vars {
+		out[v.Name] = v.Example
+	}
+	return out, nil
+}
+
+// RenderTemplateRequest renders a stored template (TemplateID) or the given
+// subject/body. Values come from the catalog examples, or from a real record
+// when RecordID is set, and Variables override both.
+type RenderTemplateRequest struct {
+	TemplateID       *uint             `json:"template_id"`
+	NotificationType string            `json:"notification_type"`
+	Subject          string            `json:"subject"`
+	Body             string            `json:"body"`
+	RecordID         *uint             `json:"record_id"` // seva booking, event, donation or devotee ID depending on type
+	Variables        map[string]string `json:"variables"`
+}
+
+// RenderedTemplate is the result of rendering a subject/body pair
+type RenderedTemplate struct {
+	NotificationType string   `json:"notification_type"`
+	Subject          string   `json:"subject"`
+	Body             string   `json:"body"`
+	Variables        []string `json:"variables"` // variables referenced by the template
+	Source           string   `json:"source"`    // sample or record
+}
+
+// ValidateTemplate parses subject and body and rejects any variable that is
+// not in the catalog of notificationType
+func ValidateTemplate(notificationType, subject, body string) ([]string, error) {
+	allowed, err := VariablesFor(notificationType)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(allowed))
+	for _, v := range allowed {
+		known[v.Name] = true
+	}
+
+	used := make(map[string]bool)
+	for _, part := range []struct{ name, text string }{{"subject", subject}, {"body", body}} {
+		t, err := template.New(part.name).Parse(part.text)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, part.name, err)
+		}
+		if t.Tree != nil {
+			collectFields(t.Tree.Root, used)
+		}
+	}
+
+	var unknown []string
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(names)
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("%w: unknown variables for %s templates: %s", ErrInvalidTemplate, notificationType, strings.Join(unknown, ", "))
+	}
+	return names, nil
+}
+
+// RenderTemplate validates the template against the catalog and renders it
+// with vars. Every referenced variable must have a value.
+func RenderTemplate(notificationType, subject, body string, vars map[string]string) (*RenderedTemplate, error) {
+	used, err := ValidateTemplate(notificationType, subject, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var missing []string
+	for _, name := range used {
+		if _, ok := vars[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: missing values for variables: %s", ErrInvalidTemplate, strings.Join(missing, ", "))
+	}
+
+	renderedSubject, err := execute("subject", subject, vars)
+	if err != nil {
+		return nil, err
+	}
+	renderedBody, err := execute("body", body, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RenderedTemplate{
+		NotificationType: notificationType,
+		Subject:          renderedSubject,
+		Body:             renderedBody,
+		Variables:        used,
+	}, nil
+}
+
+func execute(name, text string, vars map[string]string) (string, error) {
+	t, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrInvalidTemplate, name, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("%w: failed to render %s: %v", ErrInvalidTemplate, name, err)
+	}
+	return buf.String(), nil
+}
+
+// collectFields records the first identifier of every {{.field}} reference
+func collectFields(node parse.Node, used map[string]bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			collectFields(c, used)
+		}
+	case *parse.ActionNode:
+		collectFields(n.Pipe, used)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			collectFields(cmd, used)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			collectFields(arg, used)
+		}
+	case *parse.FieldNode:
+		if len(n.Ident) > 0 {
+			used[n.Ident[0]] = true
+		}
+	case *parse.ChainNode:
+		collectFields(n.Node, used)
+	case *parse.IfNode:
+		collectFields(n.Pipe, used)
+		collectFields(n.List, used)
+		collectFields(n.ElseList, used)
+	case *parse.RangeNode:
+		collectFields(n.Pipe, used)
+		collectFields(n.List, used)
+		collectFields(n.ElseList, used)
+	case *parse.WithNode:
+		collectFields(n.Pipe, used)
+		collectFields(n.List, used)
+		collectFields(n.ElseList, used)
+	}
+}
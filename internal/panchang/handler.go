@@ -0,0 +1,79 @@
+package panchang
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Handler exposes the panchang
+type Handler struct{}
+
+// NewHandler creates a new panchang handler
+func NewHandler() *Handler {
+	return &Handler{}
+}
+
+// GetPanchang - GET /panchang?date=2025-01-10
+// GET /panchang?from=2025-01-01&to=2025-01-31&tithi=ekadashi,pournami
+// Returns the tithi, nakshatra and observances of a date or of every date
+// in a range, optionally only the dates matching a tithi filter.
+func (h *Handler) GetPanchang(c *gin.Context) {
+	loc := Location()
+	if date := c.Query("date"); date != "" {
+		d, err := time.ParseInLocation(dateLayout, date, loc)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date must be a date (yyyy-mm-dd)"})
+			return
+		}
+		c.JSON(http.StatusOK, ForDate(d))
+		return
+	}
+
+	today := time.Now().In(loc)
+	from, to := today, today.AddDate(0, 0, 29)
+	var err error
+	if v := c.Query("from"); v != "" {
+		if from, err = time.ParseInLocation(dateLayout, v, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date (yyyy-mm-dd)"})
+			return
+		}
+		to = from.AddDate(0, 0, 29)
+	}
+	if v := c.Query("to"); v != "" {
+		if to, err = time.ParseInLocation(dateLayout, v, loc); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date (yyyy-mm-dd)"})
+			return
+		}
+	}
+	tithis, err := ParseFilter(c.Query("tithi"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "allowed": FilterValues()})
+		return
+	}
+
+	days, err := Range(from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(tithis) > 0 {
+		matching := []Day{}
+		for _, day := range days {
+			for _, v := range tithis {
+				if day.Matches(v) {
+					matching = append(matching, day)
+					break
+				}
+			}
+		}
+		days = matching
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":     from.Format(dateLayout),
+		"to":       to.Format(dateLayout),
+		"timezone": Timezone,
+		"days":     days,
+	})
+}
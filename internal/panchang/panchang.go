@@ -0,0 +1,307 @@
+package panchang
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Timezone the panchang is computed in; dates are Indian calendar days
+const Timezone = "Asia/Kolkata"
+
+// The tithi and nakshatra of a day are those prevailing at sunrise, taken
+// as 6 AM IST, close to sunrise across India
+const sunriseHour = 6
+
+// MaxRangeDays bounds a panchang range
+const MaxRangeDays = 366
+
+const dateLayout = "2006-01-02"
+
+// Paksha, the half of the lunar month
+const (
+	PakshaShukla  = "Shukla"  // waxing
+	PakshaKrishna = "Krishna" // waning
+)
+
+// Observance tags of a day, used to tag events and sevas and to filter reports
+const (
+	TagEkadashi           = "ekadashi"
+	TagPournami           = "pournami"
+	TagAmavasya           = "amavasya"
+	TagPradosham          = "pradosham"
+	TagSankashtiChaturthi = "sankashti_chaturthi"
+	TagVinayakaChaturthi  = "vinayaka_chaturthi"
+	TagMasikShivaratri    = "masik_shivaratri"
+	TagSkandaShashti      = "skanda_shashti"
+)
+
+var ErrInvalidRange = fmt.Errorf("from must be before to and the range at most %d days", MaxRangeDays)
+
+// Tithis of a paksha; the 15th is Purnima in the Shukla paksha and Amavasya
+// in the Krishna paksha
+var tithiNames = [15]string{
+	"Pratipada", "Dwitiya", "Tritiya", "Chaturthi", "Panchami",
+	"Shashthi", "Saptami", "Ashtami", "Navami", "Dashami",
+	"Ekadashi", "Dwadashi", "Trayodashi", "Chaturdashi", "Purnima",
+}
+
+var nakshatraNames = [27]string{
+	"Ashwini", "Bharani", "Krittika", "Rohini", "Mrigashira", "Ardra",
+	"Punarvasu", "Pushya", "Ashlesha", "Magha", "Purva Phalguni", "Uttara Phalguni",
+	"Hasta", "Chitra", "Swati", "Vishakha", "Anuradha", "Jyeshtha",
+	"Mula", "Purva Ashadha", "Uttara Ashadha", "Shravana", "Dhanishta", "Shatabhisha",
+	"Purva Bhadrapada", "Uttara Bhadrapada", "Revati",
+}
+
+// tithiTags maps a tithi number (1-30, Shukla first) to its observances
+var tithiTags = map[int][]string{
+	4:  {TagVinayakaChaturthi},
+	6:  {TagSkandaShashti},
+	11: {TagEkadashi},
+	13: {TagPradosham},
+	15: {TagPournami},
+	19: {TagSankashtiChaturthi},
+	26: {TagEkadashi},
+	28: {TagPradosham},
+	29: {TagMasikShivaratri},
+	30: {TagAmavasya},
+}
+
+// Day is the panchang of one date
+type Day struct {
+	Date            string   `json:"date"` // yyyy-mm-dd
+	Tithi           string   `json:"tithi"`
+	TithiNumber     int      `json:"tithi_number"` // 1-30, Shukla paksha first
+	Paksha          string   `json:"paksha"`
+	Nakshatra       string   `json:"nakshatra"`
+	NakshatraNumber int      `json:"nakshatra_number"` // 1-27
+	Tags            []string `json:"tags"`
+}
+
+// Matches reports whether the day has the tithi or observance: a tithi
+// name ("ekadashi"), a paksha and tithi ("krishna_ekadashi") or a tag
+// ("pournami"), case insensitive
+func (d Day) Matches(value string) bool {
+	value = normalize(value)
+	if value == normalize(d.Tithi) || value == normalize(d.Paksha+"_"+d.Tithi) {
+		return true
+	}
+	for _, tag := range d.Tags {
+		if value == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func normalize(value string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), " ", "_")
+}
+
+// FilterValues lists every value Day.Matches accepts
+func FilterValues() []string {
+	seen := map[string]bool{}
+	var values []string
+	add := func(v string) {
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+	for _, name := range tithiNames {
+		add(normalize(name))
+	}
+	add(normalize(tithiNameOf(30)))
+	for n := 1; n <= 30; n++ {
+		add(normalize(pakshaOf(n) + "_" + tithiNameOf(n)))
+		for _, tag := range tithiTags[n] {
+			add(tag)
+		}
+	}
+	return values
+}
+
+// ParseFilter splits a comma separated tithi filter and checks every value
+// is one FilterValues lists
+func ParseFilter(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	known := map[string]bool{}
+	for _, v := range FilterValues() {
+		known[v] = true
+	}
+	var values []string
+	for _, v := range strings.Split(raw, ",") {
+		v = normalize(v)
+		if v == "" {
+			continue
+		}
+		if !known[v] {
+			return nil, fmt.Errorf("unknown tithi %q", v)
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+// Location returns the IST location, fixed +05:30 when the zone database
+// is missing
+func Location() *time.Location {
+	if loc, err := time.LoadLocation(Timezone); err == nil {
+		return loc
+	}
+	return time.FixedZone("IST", 5*60*60+30*60)
+}
+
+// ForDate computes the panchang of a calendar date; only its year, month
+// and day are used
+func ForDate(date time.Time) Day {
+	at := time.Date(date.Year(), date.Month(), date.Day(), sunriseHour, 0, 0, 0, Location())
+	jd := julianDay(at)
+	sun, moon := sunLongitude(jd), moonLongitude(jd)
+
+	elongation := normDegrees(moon - sun)
+	tithi := int(elongation/12) + 1
+	nakshatra := int(normDegrees(moon-ayanamsa(jd))/(360.0/27)) + 1
+
+	tags := append([]string{}, tithiTags[tithi]...)
+	return Day{
+		Date:            at.Format(dateLayout),
+		Tithi:           tithiNameOf(tithi),
+		TithiNumber:     tithi,
+		Paksha:          pakshaOf(tithi),
+		Nakshatra:       nakshatraNames[nakshatra-1],
+		NakshatraNumber: nakshatra,
+		Tags:            tags,
+	}
+}
+
+// Range computes the panchang of every date from from to to, inclusive
+func Range(from, to time.Time) ([]Day, error) {
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	if to.Before(from) || to.Sub(from) >= MaxRangeDays*24*time.Hour {
+		return nil, ErrInvalidRange
+	}
+	days := make([]Day, 0, int(to.Sub(from).Hours()/24)+1)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		days = append(days, ForDate(d))
+	}
+	return days, nil
+}
+
+// MatchingDates returns the yyyy-mm-dd dates from from to to whose panchang
+// matches any of values
+func MatchingDates(from, to time.Time, values []string) []string {
+	dates := []string{}
+	from = time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		day := ForDate(d)
+		for _, v := range values {
+			if day.Matches(v) {
+				dates = append(dates, day.Date)
+				break
+			}
+		}
+	}
+	return dates
+}
+
+func tithiNameOf(n int) string {
+	if n == 30 {
+		return "Amavasya"
+	}
+	return tithiNames[(n-1)%15]
+}
+
+func pakshaOf(n int) string {
+	if n <= 15 {
+		return PakshaShukla
+	}
+	return PakshaKrishna
+}
+
+// =========================== ASTRONOMY ===========================
+// Low precision positions after Meeus, Astronomical Algorithms (ch. 25 and
+// 47, main terms only): good to a few hundredths of a degree, so a tithi or
+// nakshatra is only wrong when it changes within minutes of sunrise.
+
+func julianDay(t time.Time) float64 {
+	return float64(t.Unix())/86400 + 2440587.5
+}
+
+func centuries(jd float64) float64 {
+	return (jd - 2451545.0) / 36525
+}
+
+func normDegrees(d float64) float64 {
+	d = math.Mod(d, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d
+}
+
+func sinDeg(d float64) float64 {
+	return math.Sin(d * math.Pi / 180)
+}
+
+// sunLongitude is the Sun's apparent tropical longitude in degrees
+func sunLongitude(jd float64) float64 {
+	t := centuries(jd)
+	l0 := 280.46646 + 36000.76983*t + 0.0003032*t*t
+	m := 357.52911 + 35999.05029*t - 0.0001537*t*t
+	c := (1.914602-0.004817*t-0.000014*t*t)*sinDeg(m) +
+		(0.019993-0.000101*t)*sinDeg(2*m) +
+		0.000289*sinDeg(3*m)
+	omega := 125.04 - 1934.136*t
+	return normDegrees(l0 + c - 0.00569 - 0.00478*sinDeg(omega))
+}
+
+// moonLongitude is the Moon's tropical longitude in degrees
+func moonLongitude(jd float64) float64 {
+	t := centuries(jd)
+	lp := 218.3164477 + 481267.88123421*t
+	d := 297.8501921 + 445267.1114034*t
+	m := 357.5291092 + 35999.0502909*t
+	mp := 134.9633964 + 477198.8675055*t
+	f := 93.2720950 + 483202.0175233*t
+	e := 1 - 0.002516*t
+
+	lon := lp +
+		6.288774*sinDeg(mp) +
+		1.274027*sinDeg(2*d-mp) +
+		0.658314*sinDeg(2*d) +
+		0.213618*sinDeg(2*mp) -
+		0.185116*e*sinDeg(m) -
+		0.114332*sinDeg(2*f) +
+		0.058793*sinDeg(2*d-2*mp) +
+		0.057066*e*sinDeg(2*d-m-mp) +
+		0.053322*sinDeg(2*d+mp) +
+		0.045758*e*sinDeg(2*d-m) -
+		0.040923*e*sinDeg(m-mp) -
+		0.034720*sinDeg(d) -
+		0.030383*e*sinDeg(m+mp) +
+		0.015327*sinDeg(2*d-2*f) -
+		0.012528*sinDeg(mp+2*f) +
+		0.010980*sinDeg(mp-2*f) +
+		0.010675*sinDeg(4*d-mp) +
+		0.010034*sinDeg(3*mp) +
+		0.008548*sinDeg(4*d-2*mp) -
+		0.007888*e*sinDeg(2*d+m-mp) -
+		0.006766*e*sinDeg(2*d+m) -
+		0.005163*sinDeg(d-mp) +
+		0.004987*e*sinDeg(d+m) +
+		0.004036*e*sinDeg(2*d-m+mp)
+	omega := 125.04 - 1934.136*t
+	return normDegrees(lon - 0.00478*sinDeg(omega))
+}
+
+// ayanamsa is the Lahiri (Chitrapaksha) ayanamsa in degrees, turning
+// tropical longitudes into the sidereal ones nakshatras are counted in
+func ayanamsa(jd float64) float64 {
+	return 23.853 + 1.3969*centuries(jd)
+}
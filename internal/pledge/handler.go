@@ -0,0 +1,227 @@
+package pledge
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the pledge endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new pledge handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Webhook - POST /payments/pledges/webhook (Razorpay payment link events)
+func (h *Handler) Webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read request body"})
+		return
+	}
+
+	result, err := h.Service.HandleWebhook(donation.WebhookRequest{
+		Body:      body,
+		Signature: c.GetHeader("X-Razorpay-Signature"),
+		EventID:   c.GetHeader("X-Razorpay-Event-Id"),
+		IPAddress: middleware.GetIPFromContext(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, donation.ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, donation.ErrWebhookNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			// Non-2xx makes Razorpay retry the delivery
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result,
+		"success": true,
+	})
+}
+
+// Create - POST /pledges?entity_id=
+func (h *Handler) Create(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+
+	var req CreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	p, err := h.Service.Create(c.Request.Context(), access.UserID, entityID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to create pledge")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Pledge created", "data": p})
+}
+
+// ListMine - GET /pledges/my?entity_id=
+func (h *Handler) ListMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var entityID uint
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity_id"})
+			return
+		}
+		entityID = uint(id)
+	}
+
+	items, err := h.Service.Repo.ListByUser(c.Request.Context(), access.UserID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pledges"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// Update - PUT /pledges/:id
+func (h *Handler) Update(c *gin.Context) {
+	p, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	var req UpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.Update(c.Request.Context(), p, req, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update pledge")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pledge updated", "data": p})
+}
+
+// Cancel - POST /pledges/:id/cancel
+func (h *Handler) Cancel(c *gin.Context) {
+	p, ok := h.loadOwned(c)
+	if !ok {
+		return
+	}
+	if err := h.Service.Cancel(c.Request.Context(), p, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to cancel pledge")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Pledge cancelled", "data": p})
+}
+
+// ListCharges - GET /pledges/:id/charges
+// The devotee who pledged or staff of the temple see every payment link sent.
+func (h *Handler) ListCharges(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pledge ID"})
+		return
+	}
+	p, err := h.Service.Repo.GetByID(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch pledge")
+		return
+	}
+	if p.UserID != access.UserID && !access.IsEntityStaff(p.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this pledge"})
+		return
+	}
+
+	charges, err := h.Service.Repo.ListCharges(c.Request.Context(), p.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch charges"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": charges})
+}
+
+// ListByEntity - GET /pledges?status=active&page=1&limit=20 (temple staff)
+func (h *Handler) ListByEntity(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+	status := c.Query("status")
+	if status != "" && status != StatusActive && status != StatusPaused && status != StatusCancelled {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active, paused or cancelled"})
+		return
+	}
+
+	page := httpx.PositiveQuery(c, "page", 1)
+	limit := min(httpx.PositiveQuery(c, "limit", 20), 100)
+	items, total, err := h.Service.Repo.ListByEntity(c.Request.Context(), entityID, status, page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch pledges"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// loadOwned loads the :id pledge of the calling devotee
+func (h *Handler) loadOwned(c *gin.Context) (*Pledge, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid pledge ID"})
+		return nil, false
+	}
+	p, err := h.Service.GetOwned(c.Request.Context(), uint(id), access.UserID)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch pledge")
+		return nil, false
+	}
+	return p, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Pledge not found",
+	Statuses: map[int][]error{
+		http.StatusForbidden:          {ErrNotOwner},
+		http.StatusBadRequest:         {ErrPledgeCancelled, ErrCampaignClosed},
+		http.StatusServiceUnavailable: {ErrGatewayNotEnabled},
+	},
+}
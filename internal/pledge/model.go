@@ -0,0 +1,111 @@
+package pledge
+
+import (
+	"time"
+)
+
+// Pledge statuses. Active pledges are charged every month; paused pledges
+// keep their schedule but are skipped until resumed.
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+)
+
+// Charge statuses. A charge is one payment link sent for a due date; failed
+// charges are retried with a new link up to maxAttempts times.
+const (
+	ChargePending = "pending"
+	ChargePaid    = "paid"
+	ChargeFailed  = "failed"
+)
+
+// Pledge is a devotee's monthly recurring donation to a temple
+type Pledge struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	UserID       uint    `gorm:"not null;index" json:"user_id"`
+	EntityID     uint    `gorm:"not null;index" json:"entity_id"`
+	Amount       float64 `gorm:"type:decimal(10,2);not null" json:"amount"` // INR per month
+	DonationType string  `gorm:"size:50;not null" json:"donation_type"`
+	CampaignID   *uint   `gorm:"index" json:"campaign_id,omitempty"`
+	DayOfMonth   int     `gorm:"not null" json:"day_of_month"` // 1-28, so every month has the day
+
+	Status      string    `gorm:"size:20;not null;default:'active';index" json:"status"`
+	NextDueDate time.Time `gorm:"type:date;not null;index" json:"next_due_date"`
+	Attempts    int       `gorm:"not null;default:0" json:"attempts"` // failed charges for NextDueDate
+
+	ReminderSentFor *time.Time `gorm:"type:date" json:"-"` // due date the last reminder was sent for
+	LastPaidAt      *time.Time `json:"last_paid_at,omitempty"`
+	CancelledAt     *time.Time `json:"cancelled_at,omitempty"`
+
+	Sandbox bool `gorm:"default:false;index" json:"sandbox"` // Charged through Razorpay test mode
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Pledge model
+func (Pledge) TableName() string {
+	return "donation_pledges"
+}
+
+// Charge is a payment link generated for one due date of a pledge. Paid
+// charges record the donation they created.
+type Charge struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	PledgeID uint      `gorm:"not null;index" json:"pledge_id"`
+	DueDate  time.Time `gorm:"type:date;not null" json:"due_date"`
+	Attempt  int       `gorm:"not null" json:"attempt"` // 1-based
+	Amount   float64   `gorm:"type:decimal(10,2);not null" json:"amount"`
+
+	PaymentLinkID string `gorm:"size:100;uniqueIndex;not null" json:"payment_link_id"` // Razorpay plink_...
+	PaymentURL    string `gorm:"size:255" json:"payment_url"`
+	PaymentID     string `gorm:"size:100" json:"payment_id,omitempty"`
+	DonationID    *uint  `gorm:"index" json:"donation_id,omitempty"`
+
+	Status        string     `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	FailureReason string     `gorm:"size:255" json:"failure_reason,omitempty"`
+	ExpiresAt     time.Time  `gorm:"not null;index" json:"expires_at"`
+	PaidAt        *time.Time `json:"paid_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Charge model
+func (Charge) TableName() string {
+	return "donation_pledge_charges"
+}
+
+// PledgeWithDonor is a pledge as temple staff see it
+type PledgeWithDonor struct {
+	Pledge
+	DonorName  string  `json:"donor_name"`
+	DonorEmail string  `json:"donor_email"`
+	TotalPaid  float64 `json:"total_paid"`
+	PaidCount  int     `json:"paid_count"`
+}
+
+// CreateRequest starts a monthly pledge
+type CreateRequest struct {
+	Amount       float64 `json:"amount" binding:"required,gt=0"`
+	DonationType string  `json:"donation_type" binding:"required,oneof=general seva event festival construction annadanam education maintenance"`
+	CampaignID   *uint   `json:"campaign_id"`
+	DayOfMonth   int     `json:"day_of_month" binding:"required,min=1,max=28"`
+}
+
+// UpdateRequest changes the amount or day of a pledge, or pauses and resumes it
+type UpdateRequest struct {
+	Amount     *float64 `json:"amount" binding:"omitempty,gt=0"`
+	DayOfMonth *int     `json:"day_of_month" binding:"omitempty,min=1,max=28"`
+	Status     string   `json:"status" binding:"omitempty,oneof=active paused"`
+}
+
+// Contact is who a payment link is sent to
+type Contact struct {
+	FullName string
+	Email    string
+	Phone    string
+}
@@ -0,0 +1,245 @@
+package pledge
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"gorm.io/gorm"
+)
+
+// ErrChargeSettled is returned when a charge is no longer pending
+var ErrChargeSettled = errors.New("charge already settled")
+
+// Repository reads and writes pledges and their charges
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new pledge repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// Create stores a new pledge
+func (r *Repository) Create(ctx context.Context, p *Pledge) error {
+	return r.DB.WithContext(ctx).Create(p).Error
+}
+
+// Update saves every field of a pledge
+func (r *Repository) Update(ctx context.Context, p *Pledge) error {
+	return r.DB.WithContext(ctx).Save(p).Error
+}
+
+// GetByID loads a pledge
+func (r *Repository) GetByID(ctx context.Context, id uint) (*Pledge, error) {
+	var p Pledge
+	if err := r.DB.WithContext(ctx).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListByUser returns a devotee's pledges, newest first; entityID 0 means every temple
+func (r *Repository) ListByUser(ctx context.Context, userID, entityID uint) ([]Pledge, error) {
+	query := r.DB.WithContext(ctx).Where("user_id = ?", userID)
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	var out []Pledge
+	err := query.Order("created_at DESC").Find(&out).Error
+	return out, err
+}
+
+// ListByEntity returns a temple's pledges with their donors and what they
+// have paid; status "" means all
+func (r *Repository) ListByEntity(ctx context.Context, entityID uint, status string, page, limit int) ([]PledgeWithDonor, int64, error) {
+	query := r.DB.WithContext(ctx).Table("donation_pledges p").Where("p.entity_id = ?", entityID)
+	if status != "" {
+		query = query.Where("p.status = ?", status)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var out []PledgeWithDonor
+	err := query.
+		Select(`p.*, COALESCE(u.full_name, '') as donor_name, COALESCE(u.email, '') as donor_email,
+			COALESCE(SUM(c.amount) FILTER (WHERE c.status = ?), 0) as total_paid,
+			COUNT(c.id) FILTER (WHERE c.status = ?) as paid_count`, ChargePaid, ChargePaid).
+		Joins("LEFT JOIN users u ON u.id = p.user_id").
+		Joins("LEFT JOIN donation_pledge_charges c ON c.pledge_id = p.id").
+		Group("p.id, u.full_name, u.email").
+		Order("p.created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Scan(&out).Error
+	return out, total, err
+}
+
+// ListCharges returns the charges of a pledge, newest first
+func (r *Repository) ListCharges(ctx context.Context, pledgeID uint) ([]Charge, error) {
+	var out []Charge
+	err := r.DB.WithContext(ctx).Where("pledge_id = ?", pledgeID).Order("created_at DESC").Find(&out).Error
+	return out, err
+}
+
+// GetChargeByLink returns the charge of a payment link, or nil
+func (r *Repository) GetChargeByLink(ctx context.Context, paymentLinkID string) (*Charge, error) {
+	var c Charge
+	err := r.DB.WithContext(ctx).Where("payment_link_id = ?", paymentLinkID).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// PendingCharge returns the pledge's unsettled charge, or nil
+func (r *Repository) PendingCharge(ctx context.Context, pledgeID uint) (*Charge, error) {
+	var c Charge
+	err := r.DB.WithContext(ctx).Where("pledge_id = ? AND status = ?", pledgeID, ChargePending).First(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateCharge stores a new charge
+func (r *Repository) CreateCharge(ctx context.Context, c *Charge) error {
+	return r.DB.WithContext(ctx).Create(c).Error
+}
+
+// DuePledges returns active pledges due on or before day with no pending charge
+func (r *Repository) DuePledges(ctx context.Context, day time.Time) ([]Pledge, error) {
+	var out []Pledge
+	err := r.DB.WithContext(ctx).
+		Where("status = ? AND next_due_date <= ?", StatusActive, day).
+		Where("NOT EXISTS (SELECT 1 FROM donation_pledge_charges c WHERE c.pledge_id = donation_pledges.id AND c.status = ?)", ChargePending).
+		Find(&out).Error
+	return out, err
+}
+
+// PledgesToRemind returns active pledges due between from and to that have
+// not been reminded of that due date yet
+func (r *Repository) PledgesToRemind(ctx context.Context, from, to time.Time) ([]Pledge, error) {
+	var out []Pledge
+	err := r.DB.WithContext(ctx).
+		Where("status = ? AND next_due_date BETWEEN ? AND ?", StatusActive, from, to).
+		Where("reminder_sent_for IS NULL OR reminder_sent_for <> next_due_date").
+		Find(&out).Error
+	return out, err
+}
+
+// ExpiredCharges returns pending charges whose payment link has expired
+func (r *Repository) ExpiredCharges(ctx context.Context, now time.Time) ([]Charge, error) {
+	var out []Charge
+	err := r.DB.WithContext(ctx).Where("status = ? AND expires_at < ?", ChargePending, now).Find(&out).Error
+	return out, err
+}
+
+// MarkReminded records that the pledge's current due date was reminded of
+func (r *Repository) MarkReminded(ctx context.Context, p *Pledge) error {
+	return r.DB.WithContext(ctx).Model(&Pledge{}).Where("id = ?", p.ID).
+		Update("reminder_sent_for", p.NextDueDate).Error
+}
+
+// SettlePaid marks the charge paid, records its donation and moves the
+// pledge to its next due date in one transaction. It returns ErrChargeSettled
+// when the charge was already settled, e.g. by a retried webhook.
+func (r *Repository) SettlePaid(ctx context.Context, c *Charge, p *Pledge, d *donation.Donation) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(d).Error; err != nil {
+			return err
+		}
+		res := tx.Model(&Charge{}).Where("id = ? AND status = ?", c.ID, ChargePending).Updates(map[string]interface{}{
+			"status":      ChargePaid,
+			"payment_id":  c.PaymentID,
+			"donation_id": d.ID,
+			"paid_at":     c.PaidAt,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrChargeSettled
+		}
+		return tx.Model(&Pledge{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+			"next_due_date": p.NextDueDate,
+			"attempts":      0,
+			"last_paid_at":  p.LastPaidAt,
+		}).Error
+	})
+}
+
+// SettleFailed marks the charge failed and saves the pledge's attempts and
+// due date. It returns ErrChargeSettled when the charge was already settled.
+func (r *Repository) SettleFailed(ctx context.Context, c *Charge, p *Pledge) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Charge{}).Where("id = ? AND status = ?", c.ID, ChargePending).Updates(map[string]interface{}{
+			"status":         ChargeFailed,
+			"failure_reason": c.FailureReason,
+		})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrChargeSettled
+		}
+		return tx.Model(&Pledge{}).Where("id = ?", p.ID).Updates(map[string]interface{}{
+			"next_due_date": p.NextDueDate,
+			"attempts":      p.Attempts,
+		}).Error
+	})
+}
+
+// GetContact returns the devotee's name, email and phone
+func (r *Repository) GetContact(ctx context.Context, userID uint) (*Contact, error) {
+	var c Contact
+	err := r.DB.WithContext(ctx).Table("users").
+		Select("full_name, email, phone").
+		Where("id = ?", userID).
+		Take(&c).Error
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// GetEntityName returns the temple's name
+func (r *Repository) GetEntityName(ctx context.Context, entityID uint) (string, error) {
+	var name string
+	err := r.DB.WithContext(ctx).Table("entities").Select("name").Where("id = ?", entityID).Scan(&name).Error
+	return name, err
+}
+
+// IsSandboxEntity reports whether the temple belongs to a sandbox tenant
+func (r *Repository) IsSandboxEntity(ctx context.Context, entityID uint) (bool, error) {
+	var sandbox bool
+	err := r.DB.WithContext(ctx).
+		Table("entities e").
+		Select("COALESCE(u.sandbox, false)").
+		Joins("LEFT JOIN users u ON u.id = e.created_by").
+		Where("e.id = ?", entityID).
+		Scan(&sandbox).Error
+	return sandbox, err
+}
+
+// CampaignAcceptsDonations reports whether the campaign belongs to the temple
+// and is active within its dates
+func (r *Repository) CampaignAcceptsDonations(ctx context.Context, campaignID, entityID uint) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).
+		Table("donation_campaigns").
+		Where("id = ? AND entity_id = ? AND status = ? AND deleted_at IS NULL", campaignID, entityID, "active").
+		Where("start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)").
+		Count(&count).Error
+	return count > 0, err
+}
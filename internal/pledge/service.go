@@ -0,0 +1,567 @@
+package pledge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	razorpay "github.com/razorpay/razorpay-go"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+)
+
+const (
+	maxAttempts  = 3                  // payment links sent per due date before it is skipped
+	linkValidity = 3 * 24 * time.Hour // how long a payment link can be paid
+	remindBefore = 3                  // days before the due date the devotee is reminded
+)
+
+// Razorpay payment link webhook events
+const (
+	WebhookLinkPaid      = "payment_link.paid"
+	WebhookLinkExpired   = "payment_link.expired"
+	WebhookLinkCancelled = "payment_link.cancelled"
+)
+
+var (
+	ErrNotOwner          = errors.New("pledge belongs to another devotee")
+	ErrPledgeCancelled   = errors.New("pledge is cancelled")
+	ErrCampaignClosed    = errors.New("campaign is not accepting donations")
+	ErrGatewayNotEnabled = errors.New("payment gateway is not configured for this temple")
+)
+
+// Notifier delivers in-app notifications (notification.Service)
+type Notifier interface {
+	CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error
+}
+
+// Service manages pledges and charges them through Razorpay payment links
+type Service struct {
+	Repo     *Repository
+	Cfg      *config.Config
+	Audit    auditlog.Service
+	Notifier Notifier // nil disables notifications
+
+	client     *razorpay.Client
+	testClient *razorpay.Client // Razorpay test mode, for sandbox tenants
+}
+
+// NewService initializes the pledge service
+func NewService(repo *Repository, cfg *config.Config, auditSvc auditlog.Service) *Service {
+	s := &Service{Repo: repo, Cfg: cfg, Audit: auditSvc}
+	if cfg.RazorpayKey != "" && cfg.RazorpaySecret != "" {
+		s.client = razorpay.NewClient(cfg.RazorpayKey, cfg.RazorpaySecret)
+	}
+	if cfg.RazorpayTestKey != "" && cfg.RazorpayTestSecret != "" {
+		s.testClient = razorpay.NewClient(cfg.RazorpayTestKey, cfg.RazorpayTestSecret)
+	}
+	return s
+}
+
+func (s *Service) gateway(sandbox bool) (*razorpay.Client, error) {
+	client := s.client
+	if sandbox {
+		client = s.testClient
+	}
+	if client == nil {
+		return nil, ErrGatewayNotEnabled
+	}
+	return client, nil
+}
+
+// today is the current date in UTC, matching the date columns
+func today() time.Time {
+	now := time.Now().UTC()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// nextDueDate is the first date on or after from that falls on day of month
+func nextDueDate(from time.Time, day int) time.Time {
+	due := time.Date(from.Year(), from.Month(), day, 0, 0, 0, 0, time.UTC)
+	if due.Before(from) {
+		due = due.AddDate(0, 1, 0)
+	}
+	return due
+}
+
+// Create starts a monthly pledge; the first charge is due on the next
+// matching day of month
+func (s *Service) Create(ctx context.Context, userID, entityID uint, req CreateRequest, ip string) (*Pledge, error) {
+	if req.CampaignID != nil {
+		open, err := s.Repo.CampaignAcceptsDonations(ctx, *req.CampaignID, entityID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve campaign: %w", err)
+		}
+		if !open {
+			return nil, ErrCampaignClosed
+		}
+	}
+	sandbox, err := s.Repo.IsSandboxEntity(ctx, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve temple: %w", err)
+	}
+	if _, err := s.gateway(sandbox); err != nil {
+		return nil, err
+	}
+
+	p := &Pledge{
+		UserID:       userID,
+		EntityID:     entityID,
+		Amount:       req.Amount,
+		DonationType: req.DonationType,
+		CampaignID:   req.CampaignID,
+		DayOfMonth:   req.DayOfMonth,
+		Status:       StatusActive,
+		NextDueDate:  nextDueDate(today(), req.DayOfMonth),
+		Sandbox:      sandbox,
+	}
+	if err := s.Repo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "PLEDGE_CREATED", map[string]interface{}{
+		"pledge_id":     p.ID,
+		"amount":        p.Amount,
+		"donation_type": p.DonationType,
+		"campaign_id":   p.CampaignID,
+		"day_of_month":  p.DayOfMonth,
+		"next_due_date": p.NextDueDate.Format("2006-01-02"),
+	}, ip, "success")
+	return p, nil
+}
+
+// GetOwned loads a pledge of the devotee
+func (s *Service) GetOwned(ctx context.Context, id, userID uint) (*Pledge, error) {
+	p, err := s.Repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if p.UserID != userID {
+		return nil, ErrNotOwner
+	}
+	return p, nil
+}
+
+// Update changes the amount or day of a pledge, or pauses and resumes it.
+// Changes apply from the next payment link; one already sent is kept.
+func (s *Service) Update(ctx context.Context, p *Pledge, req UpdateRequest, ip string) error {
+	if p.Status == StatusCancelled {
+		return ErrPledgeCancelled
+	}
+	before := map[string]interface{}{"amount": p.Amount, "day_of_month": p.DayOfMonth, "status": p.Status}
+
+	if req.Amount != nil {
+		p.Amount = *req.Amount
+	}
+	// A due date being retried keeps its date unless the pledge is resumed
+	reschedule := false
+	if req.DayOfMonth != nil && *req.DayOfMonth != p.DayOfMonth {
+		p.DayOfMonth = *req.DayOfMonth
+		reschedule = p.Attempts == 0
+	}
+	if req.Status != "" && req.Status != p.Status {
+		if req.Status == StatusActive {
+			// Resuming does not charge for the months the pledge was paused
+			p.Attempts = 0
+			reschedule = true
+		}
+		p.Status = req.Status
+	}
+	if reschedule {
+		p.NextDueDate = nextDueDate(today(), p.DayOfMonth)
+	}
+
+	if err := s.Repo.Update(ctx, p); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_UPDATED", map[string]interface{}{
+		"pledge_id":     p.ID,
+		"before":        before,
+		"after":         map[string]interface{}{"amount": p.Amount, "day_of_month": p.DayOfMonth, "status": p.Status},
+		"next_due_date": p.NextDueDate.Format("2006-01-02"),
+	}, ip, "success")
+	return nil
+}
+
+// Cancel stops a pledge and cancels its unpaid payment link
+func (s *Service) Cancel(ctx context.Context, p *Pledge, ip string) error {
+	if p.Status == StatusCancelled {
+		return ErrPledgeCancelled
+	}
+	now := time.Now()
+	p.Status = StatusCancelled
+	p.CancelledAt = &now
+	if err := s.Repo.Update(ctx, p); err != nil {
+		return err
+	}
+
+	if c, err := s.Repo.PendingCharge(ctx, p.ID); err == nil && c != nil {
+		if client, err := s.gateway(p.Sandbox); err == nil {
+			// Best effort, an uncancelled link still expires on its own
+			_, _ = client.PaymentLink.Cancel(c.PaymentLinkID, nil, nil)
+		}
+		c.FailureReason = "pledge cancelled"
+		if err := s.Repo.SettleFailed(ctx, c, p); err != nil && !errors.Is(err, ErrChargeSettled) {
+			log.Printf("⚠️ Closing charge %d of cancelled pledge %d failed: %v", c.ID, p.ID, err)
+		}
+	}
+
+	s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_CANCELLED", map[string]interface{}{
+		"pledge_id": p.ID,
+		"amount":    p.Amount,
+	}, ip, "success")
+	return nil
+}
+
+// StartScheduler sends reminders, generates payment links for due pledges
+// and retries expired ones every interval until ctx is cancelled
+func (s *Service) StartScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.RunDue(ctx)
+			}
+		}
+	}()
+}
+
+// RunDue does one pass of the scheduler
+func (s *Service) RunDue(ctx context.Context) {
+	day := today()
+
+	expired, err := s.Repo.ExpiredCharges(ctx, time.Now())
+	if err != nil {
+		log.Printf("❌ Pledge expiry sweep failed: %v", err)
+	}
+	for i := range expired {
+		c := &expired[i]
+		c.FailureReason = "payment link expired"
+		if err := s.failCharge(ctx, c); err != nil {
+			log.Printf("❌ Expiring pledge charge %d failed: %v", c.ID, err)
+		}
+	}
+
+	due, err := s.Repo.DuePledges(ctx, day)
+	if err != nil {
+		log.Printf("❌ Pledge charge sweep failed: %v", err)
+	}
+	for i := range due {
+		if err := s.charge(ctx, &due[i]); err != nil {
+			log.Printf("❌ Charging pledge %d failed: %v", due[i].ID, err)
+		}
+	}
+
+	remind, err := s.Repo.PledgesToRemind(ctx, day.AddDate(0, 0, 1), day.AddDate(0, 0, remindBefore))
+	if err != nil {
+		log.Printf("❌ Pledge reminder sweep failed: %v", err)
+	}
+	for i := range remind {
+		p := &remind[i]
+		s.notify(ctx, p, "Upcoming donation pledge", fmt.Sprintf(
+			"Your monthly pledge of ₹%.2f is due on %s. A payment link will be sent on that day.",
+			p.Amount, p.NextDueDate.Format("2 Jan 2006")))
+		if err := s.Repo.MarkReminded(ctx, p); err != nil {
+			log.Printf("⚠️ Marking pledge %d reminded failed: %v", p.ID, err)
+		}
+	}
+}
+
+// charge generates a payment link for the pledge's due date
+func (s *Service) charge(ctx context.Context, p *Pledge) error {
+	client, err := s.gateway(p.Sandbox)
+	if err != nil {
+		return err
+	}
+	contact, err := s.Repo.GetContact(ctx, p.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to load devotee: %w", err)
+	}
+	templeName, _ := s.Repo.GetEntityName(ctx, p.EntityID)
+
+	attempt := p.Attempts + 1
+	expiresAt := time.Now().Add(linkValidity)
+	link, err := client.PaymentLink.Create(map[string]interface{}{
+		"amount":       int(p.Amount * 100),
+		"currency":     "INR",
+		"description":  fmt.Sprintf("Monthly donation to %s for %s", templeName, p.NextDueDate.Format("Jan 2006")),
+		"reference_id": fmt.Sprintf("pledge-%d-%s-%d", p.ID, p.NextDueDate.Format("20060102"), attempt),
+		"expire_by":    expiresAt.Unix(),
+		"customer": map[string]interface{}{
+			"name":    contact.FullName,
+			"email":   contact.Email,
+			"contact": contact.Phone,
+		},
+		"notify": map[string]interface{}{"sms": true, "email": true},
+		"notes": map[string]interface{}{
+			"pledge_id":     p.ID,
+			"user_id":       p.UserID,
+			"entity_id":     p.EntityID,
+			"donation_type": p.DonationType,
+		},
+	}, nil)
+	if err != nil {
+		s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_CHARGE_CREATED", map[string]interface{}{
+			"pledge_id": p.ID,
+			"due_date":  p.NextDueDate.Format("2006-01-02"),
+			"attempt":   attempt,
+			"error":     err.Error(),
+		}, "", "failure")
+		return fmt.Errorf("razorpay payment link creation failed: %w", err)
+	}
+	linkID, _ := link["id"].(string)
+	shortURL, _ := link["short_url"].(string)
+	if linkID == "" {
+		return errors.New("unable to extract payment link id from Razorpay response")
+	}
+
+	c := &Charge{
+		PledgeID:      p.ID,
+		DueDate:       p.NextDueDate,
+		Attempt:       attempt,
+		Amount:        p.Amount,
+		PaymentLinkID: linkID,
+		PaymentURL:    shortURL,
+		Status:        ChargePending,
+		ExpiresAt:     expiresAt,
+	}
+	if err := s.Repo.CreateCharge(ctx, c); err != nil {
+		_, _ = client.PaymentLink.Cancel(linkID, nil, nil)
+		return err
+	}
+
+	s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_CHARGE_CREATED", map[string]interface{}{
+		"pledge_id":       p.ID,
+		"charge_id":       c.ID,
+		"payment_link_id": linkID,
+		"amount":          c.Amount,
+		"due_date":        c.DueDate.Format("2006-01-02"),
+		"attempt":         attempt,
+	}, "", "success")
+
+	title := "Monthly donation due"
+	if attempt > 1 {
+		title = "Monthly donation payment retry"
+	}
+	s.notify(ctx, p, title, fmt.Sprintf("Your pledge of ₹%.2f to %s is due. Pay before %s: %s",
+		p.Amount, templeName, expiresAt.Format("2 Jan 2006 15:04 MST"), shortURL))
+	return nil
+}
+
+// failCharge settles an unpaid charge. The due date is retried with a new
+// link until maxAttempts, then skipped for the next month.
+func (s *Service) failCharge(ctx context.Context, c *Charge) error {
+	p, err := s.Repo.GetByID(ctx, c.PledgeID)
+	if err != nil {
+		return err
+	}
+	skipped := false
+	if p.Status != StatusCancelled && p.NextDueDate.Equal(c.DueDate) {
+		p.Attempts++
+		if p.Attempts >= maxAttempts {
+			p.Attempts = 0
+			p.NextDueDate = nextDueDate(c.DueDate.AddDate(0, 0, 1), p.DayOfMonth)
+			skipped = true
+		}
+	}
+	if err := s.Repo.SettleFailed(ctx, c, p); err != nil {
+		if errors.Is(err, ErrChargeSettled) {
+			return nil
+		}
+		return err
+	}
+
+	s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_CHARGE_FAILED", map[string]interface{}{
+		"pledge_id":       p.ID,
+		"charge_id":       c.ID,
+		"payment_link_id": c.PaymentLinkID,
+		"due_date":        c.DueDate.Format("2006-01-02"),
+		"attempt":         c.Attempt,
+		"reason":          c.FailureReason,
+		"skipped":         skipped,
+	}, "", "failure")
+
+	if skipped {
+		s.notify(ctx, p, "Monthly donation missed", fmt.Sprintf(
+			"We could not collect your pledge of ₹%.2f due on %s. Your next payment is due on %s.",
+			c.Amount, c.DueDate.Format("2 Jan 2006"), p.NextDueDate.Format("2 Jan 2006")))
+	}
+	return nil
+}
+
+func (s *Service) notify(ctx context.Context, p *Pledge, title, message string) {
+	if s.Notifier == nil {
+		return
+	}
+	if err := s.Notifier.CreateInAppNotification(ctx, p.UserID, p.EntityID, title, message, "donation"); err != nil {
+		log.Printf("⚠️ Pledge notification for user %d failed: %v", p.UserID, err)
+	}
+}
+
+// razorpayLinkPayload is the subset of a payment link webhook body we use
+type razorpayLinkPayload struct {
+	Event   string `json:"event"`
+	Payload struct {
+		PaymentLink struct {
+			Entity struct {
+				ID     string `json:"id"`
+				Status string `json:"status"`
+			} `json:"entity"`
+		} `json:"payment_link"`
+		Payment struct {
+			Entity struct {
+				ID      string `json:"id"`
+				OrderID string `json:"order_id"`
+				Amount  int64  `json:"amount"` // paise
+				Method  string `json:"method"`
+			} `json:"entity"`
+		} `json:"payment"`
+	} `json:"payload"`
+}
+
+// HandleWebhook verifies a Razorpay payment link webhook and settles the
+// matching charge. Paid links become donations; retried deliveries find the
+// charge already settled and are ignored.
+func (s *Service) HandleWebhook(req donation.WebhookRequest) (*donation.WebhookResult, error) {
+	ctx := context.Background()
+
+	if s.Cfg.RazorpayWebhookSecret == "" && s.Cfg.RazorpayTestWebhookSecret == "" {
+		return nil, donation.ErrWebhookNotConfigured
+	}
+	sandbox := false
+	verified := s.Cfg.RazorpayWebhookSecret != "" && donation.VerifyWebhookSignature(req.Body, req.Signature, s.Cfg.RazorpayWebhookSecret)
+	if !verified && s.Cfg.RazorpayTestWebhookSecret != "" && donation.VerifyWebhookSignature(req.Body, req.Signature, s.Cfg.RazorpayTestWebhookSecret) {
+		verified, sandbox = true, true
+	}
+	if !verified {
+		s.Audit.LogAction(ctx, nil, nil, "PLEDGE_WEBHOOK_REJECTED", map[string]interface{}{
+			"event_id": req.EventID,
+			"reason":   "invalid webhook signature",
+		}, req.IPAddress, "failure")
+		return nil, donation.ErrInvalidWebhookSignature
+	}
+
+	var payload razorpayLinkPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	link := payload.Payload.PaymentLink.Entity
+	payment := payload.Payload.Payment.Entity
+	result := &donation.WebhookResult{Event: payload.Event, Sandbox: sandbox, OrderID: payment.OrderID}
+
+	if payload.Event != WebhookLinkPaid && payload.Event != WebhookLinkExpired && payload.Event != WebhookLinkCancelled {
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+	c, err := s.Repo.GetChargeByLink(ctx, link.ID)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.Status != ChargePending {
+		// Not a pledge link, or settled by an earlier delivery
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+	result.FromStatus = c.Status
+
+	if payload.Event != WebhookLinkPaid {
+		c.FailureReason = "payment link " + link.Status
+		if err := s.failCharge(ctx, c); err != nil {
+			return nil, err
+		}
+		result.Status, result.ToStatus = donation.WebhookProcessed, ChargeFailed
+		return result, nil
+	}
+
+	p, err := s.Repo.GetByID(ctx, c.PledgeID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Sandbox != sandbox {
+		s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_WEBHOOK_REJECTED", map[string]interface{}{
+			"event":           payload.Event,
+			"event_id":        req.EventID,
+			"payment_link_id": link.ID,
+			"reason":          "webhook mode does not match the pledge (live vs sandbox)",
+		}, req.IPAddress, "failure")
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+
+	now := time.Now()
+	amount := c.Amount
+	if payment.Amount > 0 {
+		amount = float64(payment.Amount) / 100
+	}
+	orderID := payment.OrderID
+	if orderID == "" {
+		orderID = link.ID
+	}
+	// A campaign that has since ended no longer collects the pledge
+	campaignID := p.CampaignID
+	if campaignID != nil {
+		if open, err := s.Repo.CampaignAcceptsDonations(ctx, *campaignID, p.EntityID); err != nil || !open {
+			campaignID = nil
+		}
+	}
+	paymentID := payment.ID
+	d := &donation.Donation{
+		UserID:       p.UserID,
+		EntityID:     p.EntityID,
+		Amount:       amount,
+		DonationType: p.DonationType,
+		CampaignID:   campaignID,
+		Method:       payment.Method,
+		Status:       donation.StatusSuccess,
+		OrderID:      orderID,
+		PaymentID:    &paymentID,
+		Sandbox:      p.Sandbox,
+		DonatedAt:    &now,
+	}
+	if d.Method == "" {
+		d.Method = "payment_link"
+	}
+
+	c.PaymentID, c.PaidAt = payment.ID, &now
+	if p.NextDueDate.Equal(c.DueDate) {
+		p.NextDueDate = nextDueDate(c.DueDate.AddDate(0, 0, 1), p.DayOfMonth)
+	}
+	p.LastPaidAt = &now
+	if err := s.Repo.SettlePaid(ctx, c, p, d); err != nil {
+		if errors.Is(err, ErrChargeSettled) {
+			result.Status = donation.WebhookDuplicate
+			return result, nil
+		}
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &p.UserID, &p.EntityID, "PLEDGE_CHARGE_PAID", map[string]interface{}{
+		"event_id":        req.EventID,
+		"pledge_id":       p.ID,
+		"charge_id":       c.ID,
+		"donation_id":     d.ID,
+		"payment_link_id": link.ID,
+		"payment_id":      payment.ID,
+		"amount":          amount,
+		"due_date":        c.DueDate.Format("2006-01-02"),
+		"next_due_date":   p.NextDueDate.Format("2006-01-02"),
+	}, req.IPAddress, "success")
+
+	s.notify(ctx, p, "Thank you for your donation", fmt.Sprintf(
+		"Your monthly pledge of ₹%.2f was received. Your next payment is due on %s.",
+		amount, p.NextDueDate.Format("2 Jan 2006")))
+
+	result.Status, result.ToStatus = donation.WebhookProcessed, ChargePaid
+	return result, nil
+}
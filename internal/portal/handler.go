@@ -0,0 +1,197 @@
+package portal
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the devotee self-service portal. Every endpoint works on
+// the caller's own records only.
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new portal handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetMe - GET /portal/me
+func (h *Handler) GetMe(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	me, err := h.Service.GetMe(c.Request.Context(), access.UserID)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch your details")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": me})
+}
+
+// UpdateProfile - PATCH /portal/me/profile {"gotra": "...", "city": "..."}
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req ProfileUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	profile, err := h.Service.UpdateProfile(c.Request.Context(), access.UserID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to update your profile")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Profile updated", "data": profile})
+}
+
+// ListBookings - GET /portal/bookings?entity_id=&status=approved&from=2024-01-01&to=2024-12-31&page=1&limit=20
+func (h *Handler) ListBookings(c *gin.Context) {
+	f, ok := historyFilter(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.Service.ListBookings(c.Request.Context(), f)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch your bookings")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// ListDonations - GET /portal/donations?entity_id=&status=success&from=&to=&page=1&limit=20
+func (h *Handler) ListDonations(c *gin.Context) {
+	f, ok := historyFilter(c)
+	if !ok {
+		return
+	}
+	items, total, err := h.Service.ListDonations(c.Request.Context(), f)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch your donations")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// DownloadReceipt - GET /portal/donations/:id/receipt
+func (h *Handler) DownloadReceipt(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid donation ID"})
+		return
+	}
+	pdf, filename, err := h.Service.DonationReceipt(c.Request.Context(), access.UserID, uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch the receipt")
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename="+filename)
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
+
+// GetPreferences - GET /portal/preferences
+func (h *Handler) GetPreferences(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	prefs, err := h.Service.GetPreferences(c.Request.Context(), access.UserID)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch your preferences")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": prefs})
+}
+
+// UpdatePreferences - PUT /portal/preferences
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req PreferencesUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	prefs, err := h.Service.UpdatePreferences(c.Request.Context(), access.UserID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to update your preferences")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Preferences updated", "data": prefs})
+}
+
+// historyFilter reads the filters of the history endpoints
+func historyFilter(c *gin.Context) (HistoryFilter, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return HistoryFilter{}, false
+	}
+	f := HistoryFilter{
+		UserID: access.UserID,
+		Status: c.Query("status"),
+		Page:   httpx.PositiveQuery(c, "page", 1),
+		Limit:  min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity_id"})
+			return f, false
+		}
+		f.EntityID = uint(id)
+	}
+	if v := c.Query("from"); v != "" {
+		from, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be YYYY-MM-DD"})
+			return f, false
+		}
+		f.From = &from
+	}
+	if v := c.Query("to"); v != "" {
+		to, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be YYYY-MM-DD"})
+			return f, false
+		}
+		to = to.AddDate(0, 0, 1)
+		f.To = &to
+	}
+	return f, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusForbidden:  {ErrNotMember},
+		http.StatusNotFound:   {ErrNoProfile},
+		http.StatusBadRequest: {ErrNothingToUpdate, ErrInvalidLanguage, notification.ErrInvalidPreference},
+		http.StatusConflict:   {ErrNoReceipt},
+	},
+}
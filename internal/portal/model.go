@@ -0,0 +1,122 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/notification"
+)
+
+// Me is the signed-in devotee's account, profile and temples
+type Me struct {
+	UserID   uint   `json:"user_id"`
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone"`
+
+	Profile *Profile     `json:"profile"` // nil until the devotee fills it in
+	Temples []Membership `json:"temples"`
+	Summary Summary      `json:"summary"`
+}
+
+// Profile is the part of the devotee profile the portal shows and edits
+type Profile struct {
+	Gotra             *string    `json:"gotra"`
+	Nakshatra         *string    `json:"nakshatra"`
+	Rashi             *string    `json:"rashi"`
+	DOB               *time.Time `gorm:"serializer:encrypted" json:"dob"`
+	StreetAddress     *string    `gorm:"serializer:encrypted" json:"street_address"`
+	City              *string    `json:"city"`
+	State             *string    `json:"state"`
+	Pincode           *string    `gorm:"serializer:encrypted" json:"pincode"`
+	Country           *string    `json:"country"`
+	PreferredLanguage *string    `json:"preferred_language"`
+	GreetingsOptOut   bool       `json:"greetings_opt_out"`
+	Completion        int        `gorm:"column:profile_completion_percentage" json:"profile_completion_percentage"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// ProfileUpdate changes the given fields of the profile; omitted fields are
+// kept and "" clears one
+type ProfileUpdate struct {
+	Gotra         *string `json:"gotra"`
+	Nakshatra     *string `json:"nakshatra"`
+	Rashi         *string `json:"rashi"`
+	StreetAddress *string `json:"street_address"`
+	City          *string `json:"city"`
+	State         *string `json:"state"`
+	Pincode       *string `json:"pincode"`
+	Country       *string `json:"country"`
+}
+
+// Membership is a temple the devotee joined
+type Membership struct {
+	EntityID   uint      `json:"entity_id"`
+	TempleName string    `json:"temple_name"`
+	City       string    `json:"city"`
+	Status     string    `json:"status"`
+	JoinedAt   time.Time `json:"joined_at"`
+}
+
+// Summary totals the devotee's activity across their temples
+type Summary struct {
+	Bookings        int64   `json:"bookings"`
+	UpcomingSevas   int64   `json:"upcoming_bookings"` // pending or approved, not cancelled
+	Donations       int64   `json:"donations"`         // successful ones
+	DonationsAmount float64 `json:"donations_amount"`
+}
+
+// Booking is a seva booking in the devotee's history
+type Booking struct {
+	ID           uint       `json:"id"`
+	EntityID     uint       `json:"entity_id"`
+	TempleName   string     `json:"temple_name"`
+	SevaID       uint       `json:"seva_id"`
+	SevaName     string     `json:"seva_name"`
+	SevaType     string     `json:"seva_type"`
+	SevaDate     string     `json:"seva_date"` // dd-mm-yyyy
+	StartTime    string     `json:"start_time"`
+	Price        float64    `json:"price"`
+	Status       string     `json:"status"`
+	RefundStatus string     `json:"refund_status,omitempty"`
+	BookingTime  time.Time  `json:"booking_time"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+}
+
+// Donation is a donation in the devotee's history
+type Donation struct {
+	ID            uint       `json:"id"`
+	EntityID      uint       `json:"entity_id"`
+	TempleName    string     `json:"temple_name"`
+	Amount        float64    `json:"amount"`
+	DonationType  string     `json:"donation_type"`
+	Method        string     `json:"method"`
+	Status        string     `json:"status"`
+	ReceiptNumber *string    `json:"receipt_number,omitempty"` // issued once the payment succeeds
+	DonatedAt     *time.Time `json:"donated_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// HistoryFilter narrows the booking or donation history
+type HistoryFilter struct {
+	UserID   uint
+	EntityID uint   // 0 = every temple of the devotee
+	Status   string // as stored, e.g. approved or SUCCESS
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
+
+// Preferences are the devotee's communication choices
+type Preferences struct {
+	Notifications     notification.Preferences `json:"notifications"`
+	GreetingsOptOut   bool                     `json:"greetings_opt_out"`
+	PreferredLanguage *string                  `json:"preferred_language"`
+}
+
+// PreferencesUpdate changes the given choices; omitted ones are kept
+type PreferencesUpdate struct {
+	Notifications     []notification.PreferenceUpdate `json:"notifications" binding:"omitempty,dive"`
+	GreetingsOptOut   *bool                           `json:"greetings_opt_out"`
+	PreferredLanguage *string                         `json:"preferred_language"`
+}
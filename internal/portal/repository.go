@@ -0,0 +1,207 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"gorm.io/gorm"
+)
+
+// joinedTemples limits a query on entity_id to the temples the devotee joined
+const joinedTemples = "entity_id IN (SELECT entity_id FROM user_entity_memberships WHERE user_id = ?)"
+
+// Repository reads the devotee's own records across their temples
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new portal repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetAccount returns the name, email and phone of a user
+func (r *Repository) GetAccount(ctx context.Context, userID uint) (*Me, error) {
+	var me Me
+	err := r.DB.WithContext(ctx).Table("users").
+		Select("id AS user_id, full_name, email, phone").
+		Where("id = ?", userID).
+		Take(&me).Error
+	if err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// GetProfile returns the devotee's profile, or nil if they have none yet
+func (r *Repository) GetProfile(ctx context.Context, userID uint) (*Profile, error) {
+	var p Profile
+	err := r.DB.WithContext(ctx).Table("devotee_profiles").
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order("id").
+		Take(&p).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// encryptedProfileColumns are the devotee profile columns the portal edits
+// that are encrypted at rest
+var encryptedProfileColumns = []string{"street_address", "pincode"}
+
+// UpdateProfile writes the given profile columns. It returns
+// gorm.ErrRecordNotFound when the devotee has no profile yet.
+func (r *Repository) UpdateProfile(ctx context.Context, userID uint, updates map[string]interface{}) error {
+	// Updates by column bypass the profile model's encryption
+	for _, column := range encryptedProfileColumns {
+		if plain, ok := updates[column].(string); ok {
+			sealed, err := fieldcrypto.Encrypt(plain)
+			if err != nil {
+				return err
+			}
+			updates[column] = sealed
+		}
+	}
+	updates["updated_at"] = time.Now()
+	res := r.DB.WithContext(ctx).Table("devotee_profiles").
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ListMemberships returns the temples the devotee joined, newest first
+func (r *Repository) ListMemberships(ctx context.Context, userID uint) ([]Membership, error) {
+	out := []Membership{}
+	err := r.DB.WithContext(ctx).Table("user_entity_memberships m").
+		Select("m.entity_id, e.name AS temple_name, e.city, m.status, m.joined_at").
+		Joins("JOIN entities e ON e.id = m.entity_id").
+		Where("m.user_id = ?", userID).
+		Order("m.joined_at DESC").
+		Scan(&out).Error
+	return out, err
+}
+
+// IsMember reports whether the devotee joined the temple
+func (r *Repository) IsMember(ctx context.Context, userID, entityID uint) (bool, error) {
+	var count int64
+	err := r.DB.WithContext(ctx).Table("user_entity_memberships").
+		Where("user_id = ? AND entity_id = ?", userID, entityID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetSummary totals the devotee's bookings and donations at their temples
+func (r *Repository) GetSummary(ctx context.Context, userID uint) (Summary, error) {
+	var s Summary
+	db := r.DB.WithContext(ctx)
+	err := db.Table("seva_bookings").
+		Select("COUNT(*) AS bookings, COUNT(*) FILTER (WHERE status IN ('pending', 'approved') AND cancelled_at IS NULL) AS upcoming_sevas").
+		Where("user_id = ? AND "+joinedTemples, userID, userID).
+		Scan(&s).Error
+	if err != nil {
+		return s, err
+	}
+	var donations struct {
+		Count  int64
+		Amount float64
+	}
+	err = db.Table("donations").
+		Select("COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("user_id = ? AND status = ? AND deleted_at IS NULL AND "+joinedTemples, userID, "SUCCESS", userID).
+		Scan(&donations).Error
+	s.Donations, s.DonationsAmount = donations.Count, donations.Amount
+	return s, err
+}
+
+// ListBookings pages through the devotee's seva bookings, newest first
+func (r *Repository) ListBookings(ctx context.Context, f HistoryFilter) ([]Booking, int64, error) {
+	query := r.DB.WithContext(ctx).Table("seva_bookings b").
+		Joins("JOIN sevas s ON s.id = b.seva_id").
+		Joins("JOIN entities e ON e.id = b.entity_id").
+		Where("b.user_id = ? AND b."+joinedTemples, f.UserID, f.UserID)
+	if f.EntityID != 0 {
+		query = query.Where("b.entity_id = ?", f.EntityID)
+	}
+	if f.Status != "" {
+		query = query.Where("b.status = ?", f.Status)
+	}
+	if f.From != nil {
+		query = query.Where("b.booking_time >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("b.booking_time < ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	out := []Booking{}
+	err := query.
+		Select("b.id, b.entity_id, e.name AS temple_name, b.seva_id, s.name AS seva_name, s.seva_type, " +
+			"s.date AS seva_date, s.start_time, s.price, b.status, b.refund_status, b.booking_time, b.checked_in_at").
+		Order("b.booking_time DESC, b.id DESC").
+		Offset((f.Page - 1) * f.Limit).
+		Limit(f.Limit).
+		Scan(&out).Error
+	return out, total, err
+}
+
+// ListDonations pages through the devotee's donations, newest first
+func (r *Repository) ListDonations(ctx context.Context, f HistoryFilter) ([]Donation, int64, error) {
+	query := r.DB.WithContext(ctx).Table("donations d").
+		Joins("JOIN entities e ON e.id = d.entity_id").
+		Joins("LEFT JOIN donation_receipts dr ON dr.donation_id = d.id").
+		Where("d.user_id = ? AND d.deleted_at IS NULL AND d."+joinedTemples, f.UserID, f.UserID)
+	if f.EntityID != 0 {
+		query = query.Where("d.entity_id = ?", f.EntityID)
+	}
+	if f.Status != "" {
+		query = query.Where("d.status = ?", f.Status)
+	}
+	if f.From != nil {
+		query = query.Where("d.created_at >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("d.created_at < ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	out := []Donation{}
+	err := query.
+		Select("d.id, d.entity_id, e.name AS temple_name, d.amount, d.donation_type, d.method, d.status, " +
+			"dr.receipt_number, d.donated_at, d.created_at").
+		Order("d.created_at DESC, d.id DESC").
+		Offset((f.Page - 1) * f.Limit).
+		Limit(f.Limit).
+		Scan(&out).Error
+	return out, total, err
+}
+
+// GetDonationEntity returns the temple and status of one of the devotee's donations
+func (r *Repository) GetDonationEntity(ctx context.Context, userID, donationID uint) (uint, string, error) {
+	var d struct {
+		EntityID uint
+		Status   string
+	}
+	err := r.DB.WithContext(ctx).Table("donations").
+		Select("entity_id, status").
+		Where("id = ? AND user_id = ? AND deleted_at IS NULL AND "+joinedTemples, donationID, userID, userID).
+		Take(&d).Error
+	return d.EntityID, d.Status, err
+}
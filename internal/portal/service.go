@@ -0,0 +1,211 @@
+package portal
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNoProfile       = errors.New("complete your devotee profile first")
+	ErrNotMember       = errors.New("you have not joined this temple")
+	ErrNothingToUpdate = errors.New("no fields to update")
+	ErrNoReceipt       = errors.New("receipts are issued for successful donations only")
+	ErrInvalidLanguage = errors.New("preferred_language must be a language code such as en or kn")
+)
+
+// Receipts renders donation receipts (donation.Service)
+type Receipts interface {
+	GetReceiptPDF(donationID uint, userID uint, accessContext *middleware.AccessContext, entityID uint) ([]byte, string, error)
+}
+
+// PreferenceStore reads and writes notification preferences (notification.Service)
+type PreferenceStore interface {
+	GetPreferences(ctx context.Context, userID uint) (notification.Preferences, error)
+	UpdatePreferences(ctx context.Context, userID uint, updates []notification.PreferenceUpdate, ip string) (notification.Preferences, error)
+}
+
+// Service serves devotees their own profile, history and preferences
+type Service struct {
+	Repo        *Repository
+	Audit       auditlog.Service
+	Receipts    Receipts
+	Preferences PreferenceStore // injected once the notification service exists
+}
+
+// NewService initializes the portal service
+func NewService(repo *Repository, auditSvc auditlog.Service, receipts Receipts) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Receipts: receipts}
+}
+
+// GetMe returns the devotee's account, profile, temples and totals
+func (s *Service) GetMe(ctx context.Context, userID uint) (*Me, error) {
+	me, err := s.Repo.GetAccount(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if me.Profile, err = s.Repo.GetProfile(ctx, userID); err != nil {
+		return nil, err
+	}
+	if me.Temples, err = s.Repo.ListMemberships(ctx, userID); err != nil {
+		return nil, err
+	}
+	if me.Summary, err = s.Repo.GetSummary(ctx, userID); err != nil {
+		return nil, err
+	}
+	return me, nil
+}
+
+// UpdateProfile changes the spiritual and address details of the profile
+func (s *Service) UpdateProfile(ctx context.Context, userID uint, req ProfileUpdate, ip string) (*Profile, error) {
+	updates := map[string]interface{}{}
+	set := func(column string, v *string) {
+		if v == nil {
+			return
+		}
+		if trimmed := strings.TrimSpace(*v); trimmed != "" {
+			updates[column] = trimmed
+		} else {
+			updates[column] = nil
+		}
+	}
+	set("gotra", req.Gotra)
+	set("nakshatra", req.Nakshatra)
+	set("rashi", req.Rashi)
+	set("street_address", req.StreetAddress)
+	set("city", req.City)
+	set("state", req.State)
+	set("pincode", req.Pincode)
+	set("country", req.Country)
+	if len(updates) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+
+	fields := make([]string, 0, len(updates))
+	for column := range updates {
+		fields = append(fields, column)
+	}
+	if err := s.Repo.UpdateProfile(ctx, userID, updates); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNoProfile
+		}
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, nil, "PORTAL_PROFILE_UPDATED", map[string]interface{}{
+		"fields": fields,
+	}, ip, "success")
+	return s.Repo.GetProfile(ctx, userID)
+}
+
+// ListBookings pages through the devotee's seva bookings. A temple filter
+// must be one the devotee joined.
+func (s *Service) ListBookings(ctx context.Context, f HistoryFilter) ([]Booking, int64, error) {
+	if err := s.checkMember(ctx, f); err != nil {
+		return nil, 0, err
+	}
+	return s.Repo.ListBookings(ctx, f)
+}
+
+// ListDonations pages through the devotee's donations
+func (s *Service) ListDonations(ctx context.Context, f HistoryFilter) ([]Donation, int64, error) {
+	if err := s.checkMember(ctx, f); err != nil {
+		return nil, 0, err
+	}
+	f.Status = strings.ToUpper(f.Status)
+	return s.Repo.ListDonations(ctx, f)
+}
+
+func (s *Service) checkMember(ctx context.Context, f HistoryFilter) error {
+	if f.EntityID == 0 {
+		return nil
+	}
+	member, err := s.Repo.IsMember(ctx, f.UserID, f.EntityID)
+	if err != nil {
+		return err
+	}
+	if !member {
+		return ErrNotMember
+	}
+	return nil
+}
+
+// DonationReceipt returns the receipt PDF of one of the devotee's donations
+func (s *Service) DonationReceipt(ctx context.Context, userID, donationID uint) ([]byte, string, error) {
+	entityID, status, err := s.Repo.GetDonationEntity(ctx, userID, donationID)
+	if err != nil {
+		return nil, "", err
+	}
+	if status != "SUCCESS" {
+		return nil, "", ErrNoReceipt
+	}
+	// No access context: only the donor's own donations pass
+	return s.Receipts.GetReceiptPDF(donationID, userID, nil, entityID)
+}
+
+// GetPreferences returns the devotee's notification, greeting and language choices
+func (s *Service) GetPreferences(ctx context.Context, userID uint) (*Preferences, error) {
+	prefs := &Preferences{}
+	if s.Preferences != nil {
+		notifications, err := s.Preferences.GetPreferences(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		prefs.Notifications = notifications
+	}
+	profile, err := s.Repo.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if profile != nil {
+		prefs.GreetingsOptOut = profile.GreetingsOptOut
+		prefs.PreferredLanguage = profile.PreferredLanguage
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences applies the given choices. Greeting and language choices
+// live on the profile, so they need one.
+func (s *Service) UpdatePreferences(ctx context.Context, userID uint, req PreferencesUpdate, ip string) (*Preferences, error) {
+	updates := map[string]interface{}{}
+	if req.GreetingsOptOut != nil {
+		updates["greetings_opt_out"] = *req.GreetingsOptOut
+	}
+	if req.PreferredLanguage != nil {
+		lang := strings.ToLower(strings.TrimSpace(*req.PreferredLanguage))
+		switch {
+		case lang == "":
+			updates["preferred_language"] = nil
+		case len(lang) > 10:
+			return nil, ErrInvalidLanguage
+		default:
+			updates["preferred_language"] = lang
+		}
+	}
+	if len(updates) == 0 && len(req.Notifications) == 0 {
+		return nil, ErrNothingToUpdate
+	}
+
+	if len(req.Notifications) > 0 && s.Preferences != nil {
+		// Audited by the notification service
+		if _, err := s.Preferences.UpdatePreferences(ctx, userID, req.Notifications, ip); err != nil {
+			return nil, err
+		}
+	}
+	if len(updates) > 0 {
+		if err := s.Repo.UpdateProfile(ctx, userID, updates); err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrNoProfile
+			}
+			return nil, err
+		}
+		delete(updates, "updated_at")
+		s.Audit.LogAction(ctx, &userID, nil, "PORTAL_PREFERENCES_UPDATED", updates, ip, "success")
+	}
+	return s.GetPreferences(ctx, userID)
+}
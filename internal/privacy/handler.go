@@ -0,0 +1,169 @@
+package privacy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the personal data endpoints: devotees act on their own
+// data, superadmins on a devotee's behalf
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new privacy handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ExportMine - GET /privacy/me/export
+func (h *Handler) ExportMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	h.export(c, access.UserID, access.UserID)
+}
+
+// EraseMine - POST /privacy/me/erasure {"confirm": true, "reason": "..."}
+func (h *Handler) EraseMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	h.erase(c, access.UserID, access.UserID)
+}
+
+// ListMine - GET /privacy/me/requests?page=1&limit=20
+func (h *Handler) ListMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	h.list(c, RequestFilter{UserID: access.UserID})
+}
+
+// ExportUser - GET /superadmin/privacy/users/:id/export
+func (h *Handler) ExportUser(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := idParam(c)
+	if !ok {
+		return
+	}
+	h.export(c, userID, access.UserID)
+}
+
+// EraseUser - POST /superadmin/privacy/users/:id/erasure {"confirm": true, "reason": "..."}
+func (h *Handler) EraseUser(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	userID, ok := idParam(c)
+	if !ok {
+		return
+	}
+	h.erase(c, userID, access.UserID)
+}
+
+// ListRequests - GET /superadmin/privacy/requests?user_id=&type=erasure&status=completed&page=1&limit=20
+func (h *Handler) ListRequests(c *gin.Context) {
+	f := RequestFilter{Type: c.Query("type"), Status: c.Query("status")}
+	if v := c.Query("user_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user_id"})
+			return
+		}
+		f.UserID = uint(id)
+	}
+	h.list(c, f)
+}
+
+// GetRequest - GET /superadmin/privacy/requests/:id
+// Returns the request with its processing log.
+func (h *Handler) GetRequest(c *gin.Context) {
+	id, ok := idParam(c)
+	if !ok {
+		return
+	}
+	req, err := h.Service.GetRequest(c.Request.Context(), id)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch request")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": req})
+}
+
+func (h *Handler) export(c *gin.Context, userID, requestedBy uint) {
+	out, err := h.Service.Export(c.Request.Context(), userID, requestedBy, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to export personal data")
+		return
+	}
+	raw, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export personal data"})
+		return
+	}
+	filename := fmt.Sprintf("personal_data_%d_%s.json", userID, out.GeneratedAt.Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "application/json", raw)
+}
+
+func (h *Handler) erase(c *gin.Context, userID, requestedBy uint) {
+	var in ErasureRequest
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	req, err := h.Service.Erase(c.Request.Context(), userID, requestedBy, in, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to erase personal data")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Personal data erased", "data": req})
+}
+
+func (h *Handler) list(c *gin.Context, f RequestFilter) {
+	f.Page = httpx.PositiveQuery(c, "page", 1)
+	f.Limit = min(httpx.PositiveQuery(c, "limit", 20), 100)
+	items, total, err := h.Service.ListRequests(c.Request.Context(), f)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch requests")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest:          {ErrNotConfirmed, ErrInvalidType, ErrInvalidStatus},
+		http.StatusUnprocessableEntity: {ErrNotDevotee},
+		http.StatusConflict:            {ErrAlreadyErased},
+	},
+}
+
+func idParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,89 @@
+package privacy
+
+import "time"
+
+// Request types
+const (
+	RequestExport  = "export"  // a machine-readable copy of the devotee's personal data
+	RequestErasure = "erasure" // anonymization of the devotee's personal data
+)
+
+// Request statuses
+const (
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+)
+
+// Step actions of the processing log
+const (
+	ActionExported   = "exported"
+	ActionAnonymized = "anonymized"
+	ActionDeleted    = "deleted"
+	ActionRetained   = "retained" // kept as is, e.g. financial records the law requires
+)
+
+// Request is a data subject request under GDPR and the DPDP Act, made by a
+// devotee or by a superadmin on their behalf. Requests are processed as they
+// are made; Steps is the processing log.
+type Request struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"` // the devotee whose data it is
+	Type        string     `gorm:"size:20;not null;index" json:"type"`
+	Status      string     `gorm:"size:20;not null;index" json:"status"`
+	RequestedBy uint       `gorm:"not null" json:"requested_by"`
+	Reason      string     `gorm:"type:text" json:"reason,omitempty"`
+	Error       string     `gorm:"type:text" json:"error,omitempty"`
+	IPAddress   string     `gorm:"size:45" json:"-"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	Steps []Step `gorm:"foreignKey:RequestID" json:"steps,omitempty"`
+}
+
+// TableName returns the table name for the Request model
+func (Request) TableName() string {
+	return "privacy_requests"
+}
+
+// Step is one entry of a request's processing log: what was done to one kind
+// of record of the devotee
+type Step struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RequestID uint      `gorm:"not null;index" json:"request_id"`
+	Source    string    `gorm:"size:50;not null" json:"source"` // e.g. donations
+	Action    string    `gorm:"size:20;not null" json:"action"`
+	Rows      int64     `gorm:"not null;default:0" json:"rows"`
+	Note      string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the Step model
+func (Step) TableName() string {
+	return "privacy_request_steps"
+}
+
+// ErasureRequest is the body of an erasure request; erasure cannot be undone,
+// so it must be confirmed
+type ErasureRequest struct {
+	Confirm bool   `json:"confirm"`
+	Reason  string `json:"reason"`
+}
+
+// Export is the document returned by an export request: every personal
+// record of the devotee, by source
+type Export struct {
+	RequestID   uint                                `json:"request_id"`
+	UserID      uint                                `json:"user_id"`
+	GeneratedAt time.Time                           `json:"generated_at"`
+	Data        map[string][]map[string]interface{} `json:"data"`
+}
+
+// RequestFilter narrows the request list
+type RequestFilter struct {
+	UserID uint
+	Type   string
+	Status string
+	Page   int
+	Limit  int
+}
@@ -0,0 +1,320 @@
+package privacy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"gorm.io/gorm"
+)
+
+// erasedName replaces the names of an erased devotee
+const erasedName = "Erased devotee"
+
+// source is one kind of personal record of a devotee, read by an export
+type source struct {
+	Name  string   // key of the export document and the processing log
+	Table string   // skipped when the table does not exist
+	Where string   // selects the devotee's rows; the user ID is its one argument
+	Omit  []string // columns never exported, e.g. credentials
+}
+
+// sources lists, across modules, every table holding a devotee's personal data
+var sources = []source{
+	{Name: "account", Table: "users", Where: "id = ?",
+		Omit: []string{"password_hash", "phone_index", "forgot_password_token", "forgot_password_expiry", "deleted_at"}},
+	{Name: "profiles", Table: "devotee_profiles", Where: "user_id = ? AND deleted_at IS NULL"},
+	{Name: "children", Table: "children", Where: "profile_id IN (SELECT id FROM devotee_profiles WHERE user_id = ?)"},
+	{Name: "emergency_contacts", Table: "emergency_contacts", Where: "profile_id IN (SELECT id FROM devotee_profiles WHERE user_id = ?)"},
+	{Name: "temple_memberships", Table: "user_entity_memberships", Where: "user_id = ?"},
+	{Name: "staff_roles", Table: "entity_role_assignments", Where: "user_id = ?"},
+	{Name: "families", Table: "devotee_families", Where: "id IN (SELECT family_id FROM devotee_family_members WHERE user_id = ?) AND deleted_at IS NULL",
+		Omit: []string{"join_code"}},
+	{Name: "family_memberships", Table: "devotee_family_members", Where: "user_id = ?"},
+	{Name: "donations", Table: "donations", Where: "user_id = ?"},
+	{Name: "donation_receipts", Table: "donation_receipts", Where: "donation_id IN (SELECT id FROM donations WHERE user_id = ?)"},
+	{Name: "pledges", Table: "donation_pledges", Where: "user_id = ?"},
+	{Name: "seva_bookings", Table: "seva_bookings", Where: "user_id = ?"},
+	{Name: "coupon_redemptions", Table: "coupon_redemptions", Where: "user_id = ?"},
+	{Name: "shop_orders", Table: "shop_orders", Where: "user_id = ?"},
+	{Name: "shop_order_items", Table: "shop_order_items", Where: "order_id IN (SELECT id FROM shop_orders WHERE user_id = ?)"},
+	{Name: "shop_cart", Table: "shop_cart_items", Where: "user_id = ?"},
+	{Name: "event_rsvps", Table: "rsvps", Where: "user_id = ?"},
+	{Name: "volunteering", Table: "volunteers", Where: "user_id = ?"},
+	{Name: "volunteer_signups", Table: "event_volunteer_signups", Where: "volunteer_id IN (SELECT id FROM volunteers WHERE user_id = ?)"},
+	{Name: "notification_preferences", Table: "notification_preferences", Where: "user_id = ?"},
+	{Name: "notifications", Table: "in_app_notifications", Where: "user_id = ?"},
+	{Name: "devices", Table: "fcm_device_tokens", Where: "user_id = ?"},
+	{Name: "emails", Table: "email_deliveries", Where: "user_id = ?"},
+	{Name: "messages", Table: "message_deliveries", Where: "user_id = ?"},
+	{Name: "greetings", Table: "greeting_logs", Where: "user_id = ?"},
+	{Name: "privacy_requests", Table: "privacy_requests", Where: "user_id = ?", Omit: []string{"ip_address"}},
+	{Name: "activity", Table: "audit_logs", Where: "user_id = ?"},
+}
+
+// Subject is the devotee a request is about
+type Subject struct {
+	ID       uint
+	FullName string
+	Email    string
+	Phone    string `gorm:"serializer:encrypted"`
+	Role     string
+	Status   string
+}
+
+// erasureStep is one step of an erasure. Anonymizing and deleting steps run
+// SQL with Args; retaining steps count the records kept.
+type erasureStep struct {
+	Source string
+	Table  string
+	Action string
+	SQL    string
+	Args   []interface{}
+	Note   string
+}
+
+// erasureSteps lists what an erasure does to each kind of record. Donations,
+// bookings, pledges and receipts are financial records the law requires
+// temples to keep, so only free text is removed from them.
+func erasureSteps(s Subject) []erasureStep {
+	id := s.ID
+	profiles := "profile_id IN (SELECT id FROM devotee_profiles WHERE user_id = ?)"
+	return []erasureStep{
+		{Source: "children", Table: "children", Action: ActionDeleted,
+			SQL: "DELETE FROM children WHERE " + profiles, Args: []interface{}{id}},
+		{Source: "emergency_contacts", Table: "emergency_contacts", Action: ActionDeleted,
+			SQL: "DELETE FROM emergency_contacts WHERE " + profiles, Args: []interface{}{id}},
+		{Source: "profiles", Table: "devotee_profiles", Action: ActionDeleted,
+			SQL: "DELETE FROM devotee_profiles WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "two_factor", Table: "user_two_factor", Action: ActionDeleted,
+			SQL: "DELETE FROM user_two_factor WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "two_factor_backup_codes", Table: "two_factor_backup_codes", Action: ActionDeleted,
+			SQL: "DELETE FROM two_factor_backup_codes WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "devices", Table: "fcm_device_tokens", Action: ActionDeleted,
+			SQL: "DELETE FROM fcm_device_tokens WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "notification_preferences", Table: "notification_preferences", Action: ActionDeleted,
+			SQL: "DELETE FROM notification_preferences WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "notifications", Table: "in_app_notifications", Action: ActionDeleted,
+			SQL: "DELETE FROM in_app_notifications WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "devotee_invitations", Table: "devotee_invitations", Action: ActionDeleted,
+			SQL:  "DELETE FROM devotee_invitations WHERE converted_user_id = ? OR (email <> '' AND email = ?) OR (phone <> '' AND phone = ?)",
+			Args: []interface{}{id, s.Email, s.Phone}},
+		{Source: "staff_invitations", Table: "staff_invitations", Action: ActionDeleted,
+			SQL: "DELETE FROM staff_invitations WHERE email = ? AND status = 'pending'", Args: []interface{}{s.Email}},
+		{Source: "shop_cart", Table: "shop_cart_items", Action: ActionDeleted,
+			SQL: "DELETE FROM shop_cart_items WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "family_memberships", Table: "devotee_family_members", Action: ActionAnonymized,
+			SQL: "UPDATE devotee_family_members SET name = ?, dob = NULL WHERE user_id = ?", Args: []interface{}{erasedName, id}},
+		{Source: "families", Table: "devotee_families", Action: ActionAnonymized,
+			SQL:  "UPDATE devotee_families SET street_address = '', city = '', state = '', pincode = '', country = '' WHERE head_user_id = ?",
+			Args: []interface{}{id}, Note: "mailing address of families the devotee heads"},
+		{Source: "greetings", Table: "greeting_logs", Action: ActionAnonymized,
+			SQL: "UPDATE greeting_logs SET name = '', message = '' WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "emails", Table: "email_deliveries", Action: ActionAnonymized,
+			SQL: "UPDATE email_deliveries SET recipient = ? WHERE user_id = ?", Args: []interface{}{erasedEmail(id), id}},
+		{Source: "messages", Table: "message_deliveries", Action: ActionAnonymized,
+			SQL: "UPDATE message_deliveries SET recipient = '', body = '' WHERE user_id = ?", Args: []interface{}{id}},
+		{Source: "event_rsvps", Table: "rsvps", Action: ActionAnonymized,
+			SQL: "UPDATE rsvps SET notes = '' WHERE user_id = ? AND notes <> ''", Args: []interface{}{id}, Note: "notes"},
+		{Source: "donations", Table: "donations", Action: ActionAnonymized,
+			SQL: "UPDATE donations SET note = NULL WHERE user_id = ? AND note IS NOT NULL", Args: []interface{}{id}, Note: "donor notes"},
+		{Source: "shop_orders", Table: "shop_orders", Action: ActionAnonymized,
+			SQL: `UPDATE shop_orders SET shipping_name = '', shipping_phone = '', address_line = '', city = '', state = '', pincode = '', note = ''
+				WHERE user_id = ? AND status IN ?`,
+			Args: []interface{}{id, []string{"delivered", "cancelled", "expired"}}, Note: "delivery addresses of closed orders"},
+		{Source: "seva_bookings", Table: "seva_bookings", Action: ActionAnonymized,
+			SQL:  "UPDATE seva_bookings SET form_data = NULL, participants = NULL WHERE user_id = ? AND (form_data IS NOT NULL OR participants IS NOT NULL)",
+			Args: []interface{}{id}, Note: "booking form answers and participant names"},
+		{Source: "account", Table: "users", Action: ActionAnonymized,
+			// The password hash is not a bcrypt hash, so no password matches it
+			SQL: `UPDATE users SET full_name = ?, email = ?, phone = '', phone_index = '', password_hash = '!', status = 'inactive',
+				forgot_password_token = NULL, forgot_password_expiry = NULL, email_verified = false, updated_at = ? WHERE id = ?`,
+			Args: []interface{}{erasedName, erasedEmail(id), time.Now(), id}},
+
+		{Source: "donations", Table: "donations", Action: ActionRetained,
+			SQL: "SELECT COUNT(*) FROM donations WHERE user_id = ?", Args: []interface{}{id}, Note: "financial records"},
+		{Source: "donation_receipts", Table: "donation_receipts", Action: ActionRetained,
+			SQL:  "SELECT COUNT(*) FROM donation_receipts WHERE donation_id IN (SELECT id FROM donations WHERE user_id = ?)",
+			Args: []interface{}{id}, Note: "financial records"},
+		{Source: "pledges", Table: "donation_pledges", Action: ActionRetained,
+			SQL: "SELECT COUNT(*) FROM donation_pledges WHERE user_id = ?", Args: []interface{}{id}, Note: "financial records"},
+		{Source: "seva_bookings", Table: "seva_bookings", Action: ActionRetained,
+			SQL: "SELECT COUNT(*) FROM seva_bookings WHERE user_id = ?", Args: []interface{}{id}, Note: "financial records"},
+		{Source: "shop_orders", Table: "shop_orders", Action: ActionRetained,
+			SQL: "SELECT COUNT(*) FROM shop_orders WHERE user_id = ?", Args: []interface{}{id}, Note: "financial records"},
+		{Source: "coupon_redemptions", Table: "coupon_redemptions", Action: ActionRetained,
+			SQL: "SELECT COUNT(*) FROM coupon_redemptions WHERE user_id = ?", Args: []interface{}{id}, Note: "financial records"},
+		{Source: "activity", Table: "audit_logs", Action: ActionRetained,
+			SQL: "SELECT COUNT(*) FROM audit_logs WHERE user_id = ?", Args: []interface{}{id}, Note: "security audit trail"},
+	}
+}
+
+// erasedEmail is the unique, undeliverable email an erased account keeps
+func erasedEmail(userID uint) string {
+	return fmt.Sprintf("erased+%d@erased.invalid", userID)
+}
+
+// Repository reads and erases devotees' personal data and keeps the request log
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new privacy repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetSubject returns a user with their role name
+func (r *Repository) GetSubject(ctx context.Context, userID uint) (*Subject, error) {
+	var s Subject
+	err := r.DB.WithContext(ctx).Table("users u").
+		Select("u.id, u.full_name, u.email, u.phone, u.status, r.role_name AS role").
+		Joins("JOIN user_roles r ON r.id = u.role_id").
+		Where("u.id = ? AND u.deleted_at IS NULL", userID).
+		Take(&s).Error
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// IsErased reports whether an erasure of the user completed
+func (r *Repository) IsErased(ctx context.Context, userID uint) (bool, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Request{}).
+		Where("user_id = ? AND type = ? AND status = ?", userID, RequestErasure, StatusCompleted).
+		Count(&n).Error
+	return n > 0, err
+}
+
+// CreateRequest saves a new request
+func (r *Repository) CreateRequest(ctx context.Context, req *Request) error {
+	return r.DB.WithContext(ctx).Create(req).Error
+}
+
+// FinishRequest saves a request's outcome with its processing log
+func (r *Repository) FinishRequest(ctx context.Context, req *Request, steps []Step) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range steps {
+			steps[i].RequestID = req.ID
+		}
+		if len(steps) > 0 {
+			if err := tx.Create(&steps).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(req).Updates(map[string]interface{}{
+			"status":       req.Status,
+			"error":        req.Error,
+			"completed_at": req.CompletedAt,
+		}).Error
+	})
+}
+
+// GetRequest returns a request with its processing log
+func (r *Repository) GetRequest(ctx context.Context, id uint) (*Request, error) {
+	var req Request
+	err := r.DB.WithContext(ctx).
+		Preload("Steps", func(db *gorm.DB) *gorm.DB { return db.Order("id") }).
+		First(&req, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ListRequests returns a page of requests, newest first
+func (r *Repository) ListRequests(ctx context.Context, f RequestFilter) ([]Request, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Request{})
+	if f.UserID != 0 {
+		query = query.Where("user_id = ?", f.UserID)
+	}
+	if f.Type != "" {
+		query = query.Where("type = ?", f.Type)
+	}
+	if f.Status != "" {
+		query = query.Where("status = ?", f.Status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Request
+	err := query.Order("created_at DESC").
+		Offset((f.Page - 1) * f.Limit).
+		Limit(f.Limit).
+		Find(&out).Error
+	return out, total, err
+}
+
+// ReadSource returns the devotee's rows of a source, or nil when its table
+// does not exist in this deployment
+func (r *Repository) ReadSource(ctx context.Context, src source, userID uint) ([]map[string]interface{}, error) {
+	db := r.DB.WithContext(ctx)
+	if !db.Migrator().HasTable(src.Table) {
+		return nil, nil
+	}
+	var rows []map[string]interface{}
+	if err := db.Table(src.Table).Where(src.Where, userID).Order("id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", src.Name, err)
+	}
+	for _, row := range rows {
+		for _, col := range src.Omit {
+			delete(row, col)
+		}
+		for col, v := range row {
+			switch v := v.(type) {
+			case []byte:
+				// JSON columns are scanned as bytes; keep them as JSON
+				if json.Valid(v) {
+					row[col] = json.RawMessage(v)
+				} else {
+					row[col] = string(v)
+				}
+			case string:
+				if fieldcrypto.IsEncrypted(v) {
+					plain, err := fieldcrypto.Decrypt(v)
+					if err != nil {
+						return nil, fmt.Errorf("%s.%s: %w", src.Name, col, err)
+					}
+					row[col] = plain
+				}
+			}
+		}
+	}
+	return rows, nil
+}
+
+// Erase runs every erasure step in one transaction and returns the
+// processing log. Steps on tables this deployment lacks are skipped.
+func (r *Repository) Erase(ctx context.Context, s Subject) ([]Step, error) {
+	var steps []Step
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, step := range erasureSteps(s) {
+			if !tx.Migrator().HasTable(step.Table) {
+				continue
+			}
+			var rows int64
+			if step.Action == ActionRetained {
+				if err := tx.Raw(step.SQL, step.Args...).Scan(&rows).Error; err != nil {
+					return fmt.Errorf("%s: %w", step.Source, err)
+				}
+				if rows == 0 {
+					continue
+				}
+			} else {
+				res := tx.Exec(step.SQL, step.Args...)
+				if res.Error != nil {
+					return fmt.Errorf("%s: %w", step.Source, res.Error)
+				}
+				rows = res.RowsAffected
+			}
+			steps = append(steps, Step{Source: step.Source, Action: step.Action, Rows: rows, Note: step.Note})
+		}
+		return nil
+	})
+	return steps, err
+}
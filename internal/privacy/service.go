@@ -0,0 +1,167 @@
+package privacy
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+)
+
+var (
+	ErrNotDevotee    = errors.New("personal data requests cover devotee and volunteer accounts only")
+	ErrAlreadyErased = errors.New("the personal data of this account has already been erased")
+	ErrNotConfirmed  = errors.New("erasure cannot be undone; resend the request with \"confirm\": true")
+	ErrInvalidType   = errors.New("type must be export or erasure")
+	ErrInvalidStatus = errors.New("status must be processing, completed or failed")
+)
+
+// subjectRoles are the roles whose personal data requests are handled here;
+// staff accounts are managed by their temples and the platform
+var subjectRoles = map[string]bool{"devotee": true, "volunteer": true}
+
+// Sessions ends a user's sessions (auth.Service)
+type Sessions interface {
+	Logout(in auth.LogoutInput) error
+}
+
+// Service handles devotees' data export and erasure requests
+type Service struct {
+	Repo     *Repository
+	Audit    auditlog.Service
+	Sessions Sessions // nil leaves sessions of erased accounts to expire
+}
+
+// NewService initializes the privacy service
+func NewService(repo *Repository, auditSvc auditlog.Service, sessions Sessions) *Service {
+	return &Service{Repo: repo, Audit: auditSvc, Sessions: sessions}
+}
+
+func (s *Service) subject(ctx context.Context, userID uint) (*Subject, error) {
+	subject, err := s.Repo.GetSubject(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !subjectRoles[subject.Role] {
+		return nil, ErrNotDevotee
+	}
+	return subject, nil
+}
+
+// Export gathers every personal record of a devotee across modules. The
+// request and what each source contributed are logged.
+func (s *Service) Export(ctx context.Context, userID, requestedBy uint, ip string) (*Export, error) {
+	if _, err := s.subject(ctx, userID); err != nil {
+		return nil, err
+	}
+	req := &Request{UserID: userID, Type: RequestExport, Status: StatusProcessing, RequestedBy: requestedBy, IPAddress: ip}
+	if err := s.Repo.CreateRequest(ctx, req); err != nil {
+		return nil, err
+	}
+
+	out := &Export{RequestID: req.ID, UserID: userID, GeneratedAt: time.Now(), Data: map[string][]map[string]interface{}{}}
+	var steps []Step
+	var err error
+	for _, src := range sources {
+		var rows []map[string]interface{}
+		rows, err = s.Repo.ReadSource(ctx, src, userID)
+		if err != nil {
+			break
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		out.Data[src.Name] = rows
+		steps = append(steps, Step{Source: src.Name, Action: ActionExported, Rows: int64(len(rows))})
+	}
+	s.finish(ctx, req, steps, err, "PRIVACY_DATA_EXPORTED", ip)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Erase anonymizes a devotee's personal data. Financial records and the
+// audit trail are kept with the devotee's name removed from the account they
+// point to; the processing log lists what was done to each kind of record.
+// The account can no longer sign in and its sessions are ended.
+func (s *Service) Erase(ctx context.Context, userID, requestedBy uint, in ErasureRequest, ip string) (*Request, error) {
+	if !in.Confirm {
+		return nil, ErrNotConfirmed
+	}
+	subject, err := s.subject(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	erased, err := s.Repo.IsErased(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if erased {
+		return nil, ErrAlreadyErased
+	}
+
+	req := &Request{UserID: userID, Type: RequestErasure, Status: StatusProcessing, RequestedBy: requestedBy, Reason: in.Reason, IPAddress: ip}
+	if err := s.Repo.CreateRequest(ctx, req); err != nil {
+		return nil, err
+	}
+	steps, err := s.Repo.Erase(ctx, *subject)
+	if err == nil && s.Sessions != nil {
+		if logoutErr := s.Sessions.Logout(auth.LogoutInput{UserID: userID, AllSessions: true}); logoutErr != nil {
+			log.Printf("⚠️ Could not end sessions of erased user %d: %v", userID, logoutErr)
+		}
+	}
+	s.finish(ctx, req, steps, err, "PRIVACY_ERASURE_COMPLETED", ip)
+	if err != nil {
+		return nil, err
+	}
+	req.Steps = steps
+	return req, nil
+}
+
+// finish records the outcome of a request and audits it
+func (s *Service) finish(ctx context.Context, req *Request, steps []Step, failure error, action, ip string) {
+	now := time.Now()
+	req.CompletedAt = &now
+	req.Status = StatusCompleted
+	status := "success"
+	if failure != nil {
+		req.Status = StatusFailed
+		req.Error = failure.Error()
+		steps = nil // an erasure is rolled back; a partial export is not delivered
+		status = "failure"
+	}
+	// Detached so the log is kept even when the client went away
+	if err := s.Repo.FinishRequest(context.WithoutCancel(ctx), req, steps); err != nil {
+		log.Printf("⚠️ Could not save privacy request %d: %v", req.ID, err)
+	}
+	if s.Audit != nil {
+		details := map[string]interface{}{
+			"request_id":      req.ID,
+			"subject_user_id": req.UserID,
+			"steps":           steps,
+		}
+		if failure != nil {
+			details["error"] = failure.Error()
+		}
+		_ = s.Audit.LogAction(ctx, &req.RequestedBy, nil, action, details, ip, status)
+	}
+}
+
+// ListRequests returns a page of requests
+func (s *Service) ListRequests(ctx context.Context, f RequestFilter) ([]Request, int64, error) {
+	if f.Type != "" && f.Type != RequestExport && f.Type != RequestErasure {
+		return nil, 0, ErrInvalidType
+	}
+	if f.Status != "" && f.Status != StatusProcessing && f.Status != StatusCompleted && f.Status != StatusFailed {
+		return nil, 0, ErrInvalidStatus
+	}
+	return s.Repo.ListRequests(ctx, f)
+}
+
+// GetRequest returns a request with its processing log
+func (s *Service) GetRequest(ctx context.Context, id uint) (*Request, error) {
+	return s.Repo.GetRequest(ctx, id)
+}
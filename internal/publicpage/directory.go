@@ -0,0 +1,354 @@
+package publicpage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/event"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// The public directory lists approved, active temples to anyone. Responses
+// are cached in Redis for directoryCacheTTL, the same time clients may keep them.
+const (
+	directoryCachePrefix = "public_temples"
+	directoryCacheTTL    = 5 * time.Minute
+
+	earthRadiusKm = 6371.0
+	kmPerDegree   = 111.045 // length of a degree of latitude
+
+	upcomingEventDays  = 60 // how far ahead a profile shows events
+	maxUpcomingEvents  = 10
+	listedTemplesWhere = "LOWER(entities.status) = 'approved' AND entities.isactive = true"
+)
+
+// =========================== REPOSITORY ===========================
+
+// ListListedTemples pages through the approved, active temples by name
+func (r *Repository) ListListedTemples(ctx context.Context, f DirectoryFilter) ([]entity.Entity, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&entity.Entity{}).Where(listedTemplesWhere)
+	if f.Search != "" {
+		query = query.Where("entities.name ILIKE ?", "%"+f.Search+"%")
+	}
+	if f.City != "" {
+		query = query.Where("entities.city ILIKE ?", f.City)
+	}
+	if f.State != "" {
+		query = query.Where("entities.state ILIKE ?", f.State)
+	}
+	if f.Deity != "" {
+		query = query.Where("entities.main_deity ILIKE ?", "%"+f.Deity+"%")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var temples []entity.Entity
+	err := query.Order("entities.name ASC, entities.id ASC").
+		Offset((f.Page - 1) * f.Limit).
+		Limit(f.Limit).
+		Find(&temples).Error
+	return temples, total, err
+}
+
+// nearbyRow is a listed temple with its great-circle distance
+type nearbyRow struct {
+	entity.Entity
+	DistanceKm float64
+}
+
+// ListNearbyTemples returns the listed temples within radiusKm of a point,
+// closest first. A bounding box on the location index narrows the rows the
+// haversine distance is computed for.
+func (r *Repository) ListNearbyTemples(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]nearbyRow, error) {
+	dLat := radiusKm / kmPerDegree
+	dLng := 180.0
+	if cos := math.Cos(lat * math.Pi / 180); cos > 0.01 {
+		dLng = math.Min(radiusKm/(kmPerDegree*cos), 180)
+	}
+
+	distance := fmt.Sprintf("%f * 2 * ASIN(LEAST(1, SQRT(POWER(SIN(RADIANS(entities.latitude - ?) / 2), 2) + "+
+		"COS(RADIANS(?)) * COS(RADIANS(entities.latitude)) * POWER(SIN(RADIANS(entities.longitude - ?) / 2), 2))))", earthRadiusKm)
+	inner := r.DB.WithContext(ctx).Model(&entity.Entity{}).
+		Select("entities.*, "+distance+" AS distance_km", lat, lat, lng).
+		Where(listedTemplesWhere).
+		Where("entities.latitude BETWEEN ? AND ? AND entities.longitude BETWEEN ? AND ?", lat-dLat, lat+dLat, lng-dLng, lng+dLng)
+
+	var rows []nearbyRow
+	err := r.DB.WithContext(ctx).Table("(?) AS entities", inner).
+		Where("distance_km <= ?", radiusKm).
+		Order("distance_km ASC, id ASC").
+		Limit(limit).
+		Find(&rows).Error
+	return rows, err
+}
+
+// GetListedTemple finds an approved, active temple by slug, or by ID for
+// temples linked before slugs existed
+func (r *Repository) GetListedTemple(ctx context.Context, slugOrID string) (*entity.Entity, error) {
+	var e entity.Entity
+	query := r.DB.WithContext(ctx).Where(listedTemplesWhere)
+	if id, err := strconv.ParseUint(slugOrID, 10, 64); err == nil {
+		query = query.Where("entities.slug = ? OR (entities.id = ? AND NOT EXISTS (SELECT 1 FROM entities s WHERE s.slug = ?))",
+			slugOrID, id, slugOrID)
+	} else {
+		query = query.Where("entities.slug = ?", slugOrID)
+	}
+	if err := query.First(&e).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// ListPublishedPages returns the published pages of the given temples
+func (r *Repository) ListPublishedPages(ctx context.Context, entityIDs []uint) ([]Page, error) {
+	var pages []Page
+	if len(entityIDs) == 0 {
+		return pages, nil
+	}
+	err := r.DB.WithContext(ctx).
+		Where("entity_id IN ? AND status = ?", entityIDs, StatusPublished).
+		Find(&pages).Error
+	return pages, err
+}
+
+// ListUpcomingEvents returns the active events of a temple that may still
+// take place on or after from
+func (r *Repository) ListUpcomingEvents(ctx context.Context, entityID uint, from time.Time) ([]event.Event, error) {
+	var events []event.Event
+	err := r.DB.WithContext(ctx).
+		Where("entity_id = ? AND is_active = true", entityID).
+		Where("(COALESCE(recurrence_rule, '') = '' AND event_date >= ?) OR "+
+			"(COALESCE(recurrence_rule, '') <> '' AND (recurrence_end IS NULL OR recurrence_end >= ?))", from, from).
+		Order("event_date ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// =========================== SERVICE ===========================
+
+// ListTemples returns a page of the public temple directory
+func (s *Service) ListTemples(ctx context.Context, f DirectoryFilter) (*Directory, error) {
+	f.Search = strings.TrimSpace(f.Search)
+	f.City = strings.TrimSpace(f.City)
+	f.State = strings.TrimSpace(f.State)
+	f.Deity = strings.TrimSpace(f.Deity)
+	raw, _ := json.Marshal(f)
+	sum := sha256.Sum256(raw)
+	key := fmt.Sprintf("%s:list:%s", directoryCachePrefix, hex.EncodeToString(sum[:]))
+
+	var out Directory
+	if cacheGet(ctx, key, &out) {
+		return &out, nil
+	}
+
+	temples, total, err := s.Repo.ListListedTemples(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(temples))
+	for i, t := range temples {
+		ids[i] = t.ID
+	}
+	themes, err := s.publishedThemes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out = Directory{Temples: make([]TempleSummary, 0, len(temples)), Total: total}
+	for i := range temples {
+		out.Temples = append(out.Temples, templeSummary(&temples[i], themes))
+	}
+	cacheSet(ctx, key, out)
+	return &out, nil
+}
+
+// NearbyTemples returns the listed temples within radiusKm of a point,
+// closest first. Temples without coordinates are never included.
+func (s *Service) NearbyTemples(ctx context.Context, lat, lng, radiusKm float64, limit int) ([]NearbyTemple, error) {
+	rows, err := s.Repo.ListNearbyTemples(ctx, lat, lng, radiusKm, limit)
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]uint, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	themes, err := s.publishedThemes(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]NearbyTemple, 0, len(rows))
+	for i := range rows {
+		out = append(out, NearbyTemple{
+			TempleSummary: templeSummary(&rows[i].Entity, themes),
+			DistanceKm:    math.Round(rows[i].DistanceKm*100) / 100,
+		})
+	}
+	return out, nil
+}
+
+func templeSummary(t *entity.Entity, themes map[uint]RenderedTheme) TempleSummary {
+	summary := TempleSummary{
+		ID:         t.ID,
+		Name:       t.Name,
+		MainDeity:  t.MainDeity,
+		TempleType: t.TempleType,
+		City:       t.City,
+		District:   t.District,
+		State:      t.State,
+		Latitude:   t.Latitude,
+		Longitude:  t.Longitude,
+	}
+	if t.Slug != nil {
+		summary.Slug = *t.Slug
+	}
+	if theme, ok := themes[t.ID]; ok {
+		summary.Logo, summary.Banner = theme.Logo, theme.Banner
+	}
+	return summary
+}
+
+// GetTempleProfile returns the public profile of a listed temple
+func (s *Service) GetTempleProfile(ctx context.Context, slugOrID string) (*TempleProfile, error) {
+	key := fmt.Sprintf("%s:temple:%s", directoryCachePrefix, slugOrID)
+	var out TempleProfile
+	if cacheGet(ctx, key, &out) {
+		return &out, nil
+	}
+
+	e, err := s.Repo.GetListedTemple(ctx, slugOrID)
+	if err != nil {
+		return nil, err
+	}
+	out = TempleProfile{Temple: templeInfo(e), Photos: []ImageRef{}, UpcomingEvents: []PublicEvent{}}
+
+	pages, err := s.Repo.ListPublishedPages(ctx, []uint{e.ID})
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) > 0 && len(pages[0].PublishedContent) > 0 {
+		var content Content
+		if err := json.Unmarshal(pages[0].PublishedContent, &content); err != nil {
+			return nil, fmt.Errorf("corrupt published page: %w", err)
+		}
+		out.HasPage = true
+		out.Photos = contentPhotos(content)
+	}
+
+	if out.UpcomingEvents, err = s.upcomingEvents(ctx, e); err != nil {
+		return nil, err
+	}
+	cacheSet(ctx, key, out)
+	return &out, nil
+}
+
+// publishedThemes returns the rendered theme of each temple with a published page
+func (s *Service) publishedThemes(ctx context.Context, entityIDs []uint) (map[uint]RenderedTheme, error) {
+	pages, err := s.Repo.ListPublishedPages(ctx, entityIDs)
+	if err != nil {
+		return nil, err
+	}
+	themes := make(map[uint]RenderedTheme, len(pages))
+	for _, p := range pages {
+		var content Content
+		if len(p.PublishedContent) == 0 || json.Unmarshal(p.PublishedContent, &content) != nil {
+			continue
+		}
+		themes[p.EntityID] = content.Theme
+	}
+	return themes, nil
+}
+
+// contentPhotos collects the logo, banner and section images of a page, once each
+func contentPhotos(content Content) []ImageRef {
+	photos := []ImageRef{}
+	seen := map[string]bool{}
+	add := func(ref *ImageRef) {
+		if ref == nil || seen[ref.Name] {
+			return
+		}
+		seen[ref.Name] = true
+		photos = append(photos, *ref)
+	}
+	add(content.Theme.Logo)
+	add(content.Theme.Banner)
+	for _, sec := range content.Sections {
+		for i := range sec.Images {
+			add(&sec.Images[i])
+		}
+	}
+	return photos
+}
+
+// upcomingEvents returns the next occurrences of the temple's events within
+// upcomingEventDays, soonest first
+func (s *Service) upcomingEvents(ctx context.Context, e *entity.Entity) ([]PublicEvent, error) {
+	now := time.Now().In(entity.Location(e.Timezone))
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	horizon := today.AddDate(0, 0, upcomingEventDays)
+
+	events, err := s.Repo.ListUpcomingEvents(ctx, e.ID, today)
+	if err != nil {
+		return nil, err
+	}
+	out := []PublicEvent{}
+	for i := range events {
+		ev := &events[i]
+		next := ev.NextOccurrenceFrom(today)
+		if next == nil || next.After(horizon) {
+			continue
+		}
+		out = append(out, PublicEvent{
+			ID:          ev.ID,
+			Title:       ev.Title,
+			Description: ev.Description,
+			EventType:   ev.EventType,
+			Date:        *next,
+			EventTime:   ev.EventTime,
+			Location:    ev.Location,
+			Recurring:   ev.IsRecurring(),
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Date.Before(out[j].Date) })
+	if len(out) > maxUpcomingEvents {
+		out = out[:maxUpcomingEvents]
+	}
+	return out, nil
+}
+
+func cacheGet(ctx context.Context, key string, out interface{}) bool {
+	if utils.RedisClient == nil {
+		return false
+	}
+	val, err := utils.RedisClient.Get(ctx, key).Bytes()
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(val, out) == nil
+}
+
+func cacheSet(ctx context.Context, key string, value interface{}) {
+	if utils.RedisClient == nil {
+		return
+	}
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	if err := utils.RedisClient.Set(ctx, key, raw, directoryCacheTTL).Err(); err != nil {
+		log.Printf("⚠️  failed to cache %s: %v", key, err)
+	}
+}
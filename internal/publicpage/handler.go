@@ -0,0 +1,426 @@
+package publicpage
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// Handler exposes landing page editing and the public rendering API
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new page handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// GetPage - GET /entities/:id/page
+func (h *Handler) GetPage(c *gin.Context) {
+	_, entityID, ok := h.authorize(c, false)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.GetEditorPage(c.Request.Context(), entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load page"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": page})
+}
+
+// PreviewPage - GET /entities/:id/page/preview
+func (h *Handler) PreviewPage(c *gin.Context) {
+	_, entityID, ok := h.authorize(c, false)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.Preview(c.Request.Context(), entityID)
+	if err != nil {
+		h.writeError(c, err, "Failed to render preview")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": page})
+}
+
+// UpdateTheme - PUT /entities/:id/page/theme
+func (h *Handler) UpdateTheme(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	var theme Theme
+	if err := c.ShouldBindJSON(&theme); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	updated, err := h.Service.UpdateTheme(c.Request.Context(), entityID, user.ID, theme, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to update theme")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Theme saved as draft", "data": updated})
+}
+
+// CreateSection - POST /entities/:id/page/sections
+func (h *Handler) CreateSection(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	var in SectionInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	section, err := h.Service.CreateSection(c.Request.Context(), entityID, user.ID, in, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to create section")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Section saved as draft", "data": section})
+}
+
+// UpdateSection - PUT /entities/:id/page/sections/:sectionId
+func (h *Handler) UpdateSection(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+	sectionID, ok := sectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	var in SectionInput
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+
+	section, err := h.Service.UpdateSection(c.Request.Context(), entityID, sectionID, user.ID, in, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to update section")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Section saved as draft", "data": section})
+}
+
+// DeleteSection - DELETE /entities/:id/page/sections/:sectionId
+func (h *Handler) DeleteSection(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+	sectionID, ok := sectionIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.DeleteSection(c.Request.Context(), entityID, sectionID, user.ID, middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to delete section")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Section deleted"})
+}
+
+// ReorderSections - POST /entities/:id/page/sections/reorder
+// Body: {"section_ids": [3, 1, 2]}
+func (h *Handler) ReorderSections(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		SectionIDs []uint `json:"section_ids" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "section_ids is required"})
+		return
+	}
+
+	sections, err := h.Service.ReorderSections(c.Request.Context(), entityID, user.ID, req.SectionIDs, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to reorder sections")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sections reordered", "data": sections})
+}
+
+// UploadImage - POST /entities/:id/page/images (multipart "image")
+func (h *Handler) UploadImage(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImageSize+1<<20)
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required"})
+		return
+	}
+	metrics.UploadSize.Observe(float64(fileHeader.Size), "page_image")
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read image"})
+		return
+	}
+	defer file.Close()
+
+	ref, err := h.Service.UploadImage(c.Request.Context(), entityID, user.ID, filepath.Base(fileHeader.Filename), fileHeader.Size, file, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to upload image")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Image uploaded", "data": ref})
+}
+
+// Publish - POST /entities/:id/page/publish
+func (h *Handler) Publish(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	page, err := h.Service.Publish(c.Request.Context(), entityID, user.ID, middleware.GetIPFromContext(c))
+	if err != nil {
+		h.writeError(c, err, "Failed to publish page")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Page published", "data": page})
+}
+
+// Unpublish - POST /entities/:id/page/unpublish
+func (h *Handler) Unpublish(c *gin.Context) {
+	user, entityID, ok := h.authorize(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.Service.Unpublish(c.Request.Context(), entityID, user.ID, middleware.GetIPFromContext(c)); err != nil {
+		h.writeError(c, err, "Failed to unpublish page")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Page taken offline"})
+}
+
+// GetPublicPage - GET /public/temples/:id/page (no auth)
+func (h *Handler) GetPublicPage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid temple ID"})
+		return
+	}
+
+	page, err := h.Service.GetPublicPage(c.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrPageNotPublished) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Temple page not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temple page"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"data": page})
+}
+
+// GetImage - GET /public/temples/:id/page/images/:name (no auth)
+func (h *Handler) GetImage(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid temple ID"})
+		return
+	}
+
+	rc, info, err := h.Service.OpenImage(c.Request.Context(), uint(id), c.Param("name"))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Image not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load image"})
+		return
+	}
+	defer rc.Close()
+
+	contentType := imageTypes[filepath.Ext(c.Param("name"))]
+	c.DataFromReader(http.StatusOK, info.Size, contentType, rc, map[string]string{
+		"Cache-Control": "public, max-age=86400",
+	})
+}
+
+// ListTemples - GET /public/temples?q=&city=&state=&deity=&page=1&limit=20 (no auth)
+func (h *Handler) ListTemples(c *gin.Context) {
+	page, limit := 1, 20
+	if v, err := strconv.Atoi(c.Query("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = min(v, 100)
+	}
+
+	dir, err := h.Service.ListTemples(c.Request.Context(), DirectoryFilter{
+		Search: c.Query("q"),
+		City:   c.Query("city"),
+		State:  c.Query("state"),
+		Deity:  c.Query("deity"),
+		Page:   page,
+		Limit:  limit,
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temples"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"data":  dir.Temples,
+		"total": dir.Total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// NearbyTemples - GET /public/temples/nearby?lat=12.97&lng=77.59&radius_km=10&limit=20 (no auth)
+func (h *Handler) NearbyTemples(c *gin.Context) {
+	lat, latErr := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, lngErr := strconv.ParseFloat(c.Query("lng"), 64)
+	if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lat and lng are required, within ±90 and ±180"})
+		return
+	}
+	radius := 10.0
+	if v := c.Query("radius_km"); v != "" {
+		r, err := strconv.ParseFloat(v, 64)
+		if err != nil || r <= 0 || r > 200 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "radius_km must be more than 0 and at most 200"})
+			return
+		}
+		radius = r
+	}
+	limit := 20
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		limit = min(v, 100)
+	}
+
+	temples, err := h.Service.NearbyTemples(c.Request.Context(), lat, lng, radius, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to find nearby temples"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"data": temples, "radius_km": radius, "limit": limit})
+}
+
+// GetTemple - GET /public/temples/:id (no auth); :id is the temple's slug or ID
+func (h *Handler) GetTemple(c *gin.Context) {
+	slug := c.Param("id")
+	if len(slug) > 120 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
+		return
+	}
+
+	profile, err := h.Service.GetTempleProfile(c.Request.Context(), slug)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temple"})
+		return
+	}
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{"data": profile})
+}
+
+func (h *Handler) writeError(c *gin.Context, err error, fallback string) {
+	var vErr *ValidationError
+	switch {
+	case errors.As(err, &vErr):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": vErr.Error(), "problems": vErr.Problems})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Section not found"})
+	case errors.Is(err, ErrNothingToPublish), errors.Is(err, ErrPageNotPublished):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback, "details": err.Error()})
+	}
+}
+
+func sectionIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("sectionId"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid section ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// authorize resolves the temple from :id and checks the caller may manage it
+func (h *Handler) authorize(c *gin.Context, write bool) (auth.User, uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity ID"})
+		return auth.User{}, 0, false
+	}
+	entityID := uint(id)
+
+	userVal, exists := c.Get("user")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return auth.User{}, 0, false
+	}
+	user, ok := userVal.(auth.User)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid user object"})
+		return auth.User{}, 0, false
+	}
+
+	accessVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing access context"})
+		return auth.User{}, 0, false
+	}
+	accessCtx, ok := accessVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid access context"})
+		return auth.User{}, 0, false
+	}
+
+	e, err := h.Service.Repo.GetEntity(c.Request.Context(), entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Temple not found"})
+		return auth.User{}, 0, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to load temple"})
+		return auth.User{}, 0, false
+	}
+
+	if !accessCtx.IsEntityStaff(e.ID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this entity"})
+		return auth.User{}, 0, false
+	}
+	if write && !accessCtx.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient write permissions"})
+		return auth.User{}, 0, false
+	}
+	return user, entityID, true
+}
@@ -0,0 +1,254 @@
+package publicpage
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Page states
+const (
+	StatusDraft     = "draft"     // never published, or taken offline
+	StatusPublished = "published" // PublishedContent is served publicly
+)
+
+// Section types. Each type is rendered by its own block on the landing page.
+const (
+	SectionHistory  = "history"  // rich text
+	SectionDeity    = "deity"    // rich text with images
+	SectionGallery  = "gallery"  // images with an optional caption
+	SectionTimings  = "timings"  // darshan/pooja timings
+	SectionDonation = "donation" // donation call to action
+	SectionCustom   = "custom"   // free rich text
+)
+
+var sectionTypes = map[string]bool{
+	SectionHistory:  true,
+	SectionDeity:    true,
+	SectionGallery:  true,
+	SectionTimings:  true,
+	SectionDonation: true,
+	SectionCustom:   true,
+}
+
+// Page holds the theme and publish state of a temple's landing page.
+// Editors work on the draft theme and sections; publishing freezes them
+// into PublishedContent, which is what the public API serves.
+type Page struct {
+	ID               uint           `gorm:"primaryKey" json:"id"`
+	EntityID         uint           `gorm:"uniqueIndex;not null" json:"entity_id"`
+	Theme            datatypes.JSON `gorm:"type:jsonb" json:"theme"`
+	Status           string         `gorm:"size:20;not null;default:draft" json:"status"`
+	PublishedContent datatypes.JSON `gorm:"type:jsonb" json:"-"`
+	PublishedAt      *time.Time     `json:"published_at"`
+	PublishedBy      *uint          `json:"published_by"`
+	DraftUpdatedAt   time.Time      `json:"draft_updated_at"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+func (Page) TableName() string {
+	return "entity_pages"
+}
+
+// Section is one draft block of the landing page
+type Section struct {
+	ID        uint           `gorm:"primaryKey" json:"id"`
+	EntityID  uint           `gorm:"index;not null" json:"entity_id"`
+	Type      string         `gorm:"size:20;not null" json:"type"`
+	Title     string         `gorm:"size:200" json:"title"`
+	Body      string         `gorm:"type:text" json:"body"`    // sanitized HTML
+	Images    datatypes.JSON `gorm:"type:jsonb" json:"images"` // []string of page image names
+	Data      datatypes.JSON `gorm:"type:jsonb" json:"data"`   // SectionData
+	Position  int            `gorm:"not null;default:0" json:"position"`
+	Visible   bool           `gorm:"not null;default:true" json:"visible"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (Section) TableName() string {
+	return "entity_page_sections"
+}
+
+// Theme is the look of the landing page. Images are page image names
+// returned by the upload endpoint.
+type Theme struct {
+	PrimaryColor    string `json:"primary_color"`
+	AccentColor     string `json:"accent_color"`
+	BackgroundColor string `json:"background_color"`
+	FontFamily      string `json:"font_family"`
+	LogoImage       string `json:"logo_image"`
+	BannerImage     string `json:"banner_image"`
+}
+
+// Timing is one row of the timings section, e.g. "Morning darshan", "Mon-Sat", 06:00-12:00
+type Timing struct {
+	Label  string `json:"label"`
+	Days   string `json:"days"`
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// DonationButton configures the donation call to action
+type DonationButton struct {
+	Label            string    `json:"label"`
+	Purpose          string    `json:"purpose"`
+	SuggestedAmounts []float64 `json:"suggested_amounts"`
+}
+
+// SectionData holds the structured content of timings and donation sections
+type SectionData struct {
+	Timings  []Timing        `json:"timings,omitempty"`
+	Donation *DonationButton `json:"donation,omitempty"`
+	Caption  string          `json:"caption,omitempty"`
+}
+
+// SectionInput is the body of section create/update requests
+type SectionInput struct {
+	Type     string          `json:"type" binding:"required"`
+	Title    string          `json:"title"`
+	Body     string          `json:"body"`
+	Images   []string        `json:"images"`
+	Timings  []Timing        `json:"timings"`
+	Donation *DonationButton `json:"donation"`
+	Caption  string          `json:"caption"`
+	Visible  *bool           `json:"visible"`
+}
+
+// ImageRef points a client at a stored page image
+type ImageRef struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// EditorPage is the draft page as seen by temple staff
+type EditorPage struct {
+	EntityID              uint       `json:"entity_id"`
+	Status                string     `json:"status"`
+	Theme                 Theme      `json:"theme"`
+	Sections              []Section  `json:"sections"`
+	PublishedAt           *time.Time `json:"published_at"`
+	HasUnpublishedChanges bool       `json:"has_unpublished_changes"`
+}
+
+// RenderedTheme is Theme with image URLs resolved
+type RenderedTheme struct {
+	PrimaryColor    string    `json:"primary_color"`
+	AccentColor     string    `json:"accent_color"`
+	BackgroundColor string    `json:"background_color"`
+	FontFamily      string    `json:"font_family"`
+	Logo            *ImageRef `json:"logo,omitempty"`
+	Banner          *ImageRef `json:"banner,omitempty"`
+}
+
+// RenderedSection is a visible section ready for display
+type RenderedSection struct {
+	Type     string          `json:"type"`
+	Title    string          `json:"title"`
+	Body     string          `json:"body,omitempty"`
+	Images   []ImageRef      `json:"images,omitempty"`
+	Caption  string          `json:"caption,omitempty"`
+	Timings  []Timing        `json:"timings,omitempty"`
+	Donation *DonationButton `json:"donation,omitempty"`
+}
+
+// Content is the frozen, published page (also used for draft previews)
+type Content struct {
+	Theme    RenderedTheme     `json:"theme"`
+	Sections []RenderedSection `json:"sections"`
+}
+
+// TempleInfo is the live temple information shown alongside the page
+type TempleInfo struct {
+	ID            uint     `json:"id"`
+	Slug          string   `json:"slug"`
+	Name          string   `json:"name"`
+	MainDeity     *string  `json:"main_deity"`
+	TempleType    string   `json:"temple_type"`
+	Description   string   `json:"description"`
+	Phone         string   `json:"phone"`
+	StreetAddress string   `json:"street_address"`
+	Landmark      string   `json:"landmark"`
+	City          string   `json:"city"`
+	District      string   `json:"district"`
+	State         string   `json:"state"`
+	Pincode       string   `json:"pincode"`
+	MapLink       string   `json:"map_link"`
+	Latitude      *float64 `json:"latitude"`
+	Longitude     *float64 `json:"longitude"`
+}
+
+// PublicPage is the response of the public rendering API
+type PublicPage struct {
+	Temple      TempleInfo `json:"temple"`
+	Content     Content    `json:"content"`
+	PublishedAt *time.Time `json:"published_at"`
+	Preview     bool       `json:"preview,omitempty"`
+}
+
+// DirectoryFilter narrows the public temple directory
+type DirectoryFilter struct {
+	Search string // part of the temple name
+	City   string
+	State  string
+	Deity  string
+	Page   int
+	Limit  int
+}
+
+// TempleSummary is one temple of the public directory
+type TempleSummary struct {
+	ID         uint      `json:"id"`
+	Slug       string    `json:"slug"`
+	Name       string    `json:"name"`
+	MainDeity  *string   `json:"main_deity"`
+	TempleType string    `json:"temple_type"`
+	City       string    `json:"city"`
+	District   string    `json:"district"`
+	State      string    `json:"state"`
+	Latitude   *float64  `json:"latitude"`
+	Longitude  *float64  `json:"longitude"`
+	Logo       *ImageRef `json:"logo,omitempty"`   // from the published page
+	Banner     *ImageRef `json:"banner,omitempty"` // from the published page
+}
+
+// Directory is a page of the public temple directory
+type Directory struct {
+	Temples []TempleSummary `json:"temples"`
+	Total   int64           `json:"total"`
+}
+
+// NearbyTemple is a directory temple with its distance from the searcher
+type NearbyTemple struct {
+	TempleSummary
+	DistanceKm float64 `json:"distance_km"`
+}
+
+// PublicEvent is an upcoming event on a temple's public profile
+type PublicEvent struct {
+	ID          uint       `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	EventType   string     `json:"event_type"`
+	Date        time.Time  `json:"date"` // next occurrence of recurring events
+	EventTime   *time.Time `json:"event_time,omitempty"`
+	Location    string     `json:"location"`
+	Recurring   bool       `json:"recurring"`
+}
+
+// TempleProfile is the public profile of a temple
+type TempleProfile struct {
+	Temple         TempleInfo    `json:"temple"`
+	Photos         []ImageRef    `json:"photos"` // logo, banner and section images of the published page
+	UpcomingEvents []PublicEvent `json:"upcoming_events"`
+	HasPage        bool          `json:"has_page"` // the landing page is published
+}
+
+// ValidationError lists every problem found in submitted content
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid page content"
+}
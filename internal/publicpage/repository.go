@@ -0,0 +1,119 @@
+package publicpage
+
+import (
+	"context"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/entity"
+	"gorm.io/gorm"
+)
+
+// Repository reads and writes landing page content
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new page repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// GetEntity loads the temple row
+func (r *Repository) GetEntity(ctx context.Context, entityID uint) (*entity.Entity, error) {
+	var e entity.Entity
+	if err := r.DB.WithContext(ctx).First(&e, entityID).Error; err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// GetPage returns the page of a temple, or gorm.ErrRecordNotFound
+func (r *Repository) GetPage(ctx context.Context, entityID uint) (*Page, error) {
+	var p Page
+	if err := r.DB.WithContext(ctx).Where("entity_id = ?", entityID).First(&p).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// SavePage creates or updates the page row
+func (r *Repository) SavePage(ctx context.Context, p *Page) error {
+	return r.DB.WithContext(ctx).Save(p).Error
+}
+
+// TouchDraft records that the draft changed, creating the page on first edit
+func (r *Repository) TouchDraft(ctx context.Context, entityID uint) error {
+	now := time.Now()
+	res := r.DB.WithContext(ctx).Model(&Page{}).Where("entity_id = ?", entityID).
+		Updates(map[string]interface{}{"draft_updated_at": now, "updated_at": now})
+	if res.Error != nil || res.RowsAffected > 0 {
+		return res.Error
+	}
+	return r.DB.WithContext(ctx).Create(&Page{EntityID: entityID, Status: StatusDraft, DraftUpdatedAt: now}).Error
+}
+
+// ListSections returns the draft sections in display order
+func (r *Repository) ListSections(ctx context.Context, entityID uint) ([]Section, error) {
+	var sections []Section
+	err := r.DB.WithContext(ctx).Where("entity_id = ?", entityID).Order("position ASC, id ASC").Find(&sections).Error
+	return sections, err
+}
+
+// GetSection returns one section of a temple
+func (r *Repository) GetSection(ctx context.Context, entityID, sectionID uint) (*Section, error) {
+	var s Section
+	if err := r.DB.WithContext(ctx).Where("entity_id = ? AND id = ?", entityID, sectionID).First(&s).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CountSections returns how many sections a temple has
+func (r *Repository) CountSections(ctx context.Context, entityID uint) (int64, error) {
+	var n int64
+	err := r.DB.WithContext(ctx).Model(&Section{}).Where("entity_id = ?", entityID).Count(&n).Error
+	return n, err
+}
+
+// CreateSection appends a section after the existing ones
+func (r *Repository) CreateSection(ctx context.Context, s *Section) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var maxPos *int
+		if err := tx.Model(&Section{}).Where("entity_id = ?", s.EntityID).Select("MAX(position)").Scan(&maxPos).Error; err != nil {
+			return err
+		}
+		if maxPos != nil {
+			s.Position = *maxPos + 1
+		}
+		return tx.Create(s).Error
+	})
+}
+
+// SaveSection updates a section
+func (r *Repository) SaveSection(ctx context.Context, s *Section) error {
+	return r.DB.WithContext(ctx).Save(s).Error
+}
+
+// DeleteSection removes a section of a temple
+func (r *Repository) DeleteSection(ctx context.Context, entityID, sectionID uint) error {
+	res := r.DB.WithContext(ctx).Where("entity_id = ? AND id = ?", entityID, sectionID).Delete(&Section{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// ReorderSections sets the position of each section to its index in ids
+func (r *Repository) ReorderSections(ctx context.Context, entityID uint, ids []uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			if err := tx.Model(&Section{}).Where("entity_id = ? AND id = ?", entityID, id).Update("position", i).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
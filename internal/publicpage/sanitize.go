@@ -0,0 +1,91 @@
+package publicpage
+
+import (
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// allowedTags is the rich text editors may use. Any other tag is dropped
+// and its text kept; attributes are dropped except a safe href on links.
+var allowedTags = map[string]bool{
+	"p": true, "br": true, "strong": true, "b": true, "em": true, "i": true, "u": true,
+	"h2": true, "h3": true, "h4": true, "ul": true, "ol": true, "li": true, "blockquote": true, "a": true,
+}
+
+// droppedTags are removed together with their content
+var droppedTags = map[string]bool{
+	"script": true, "style": true, "iframe": true, "object": true, "embed": true, "noscript": true, "template": true,
+}
+
+// sanitizeHTML keeps the allowed rich text markup of untrusted HTML so the
+// public page can render it as-is
+func sanitizeHTML(in string) string {
+	z := html.NewTokenizer(strings.NewReader(in))
+	var b strings.Builder
+	skip := 0
+
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return strings.TrimSpace(b.String())
+
+		case html.TextToken:
+			if skip == 0 {
+				b.WriteString(html.EscapeString(string(z.Text())))
+			}
+
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tok := z.Token()
+			if droppedTags[tok.Data] {
+				if tt == html.StartTagToken {
+					skip++
+				}
+				continue
+			}
+			if skip > 0 || !allowedTags[tok.Data] {
+				continue
+			}
+			b.WriteString("<" + tok.Data)
+			if tok.Data == "a" {
+				if href := safeHref(tok.Attr); href != "" {
+					b.WriteString(` href="` + html.EscapeString(href) + `" rel="noopener nofollow" target="_blank"`)
+				}
+			}
+			b.WriteString(">")
+
+		case html.EndTagToken:
+			tok := z.Token()
+			if droppedTags[tok.Data] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip == 0 && allowedTags[tok.Data] && tok.Data != "br" {
+				b.WriteString("</" + tok.Data + ">")
+			}
+		}
+	}
+}
+
+// safeHref returns the href of a link when it is an http(s) or mailto URL
+func safeHref(attrs []html.Attribute) string {
+	for _, a := range attrs {
+		if a.Key != "href" {
+			continue
+		}
+		u, err := url.Parse(strings.TrimSpace(a.Val))
+		if err != nil {
+			return ""
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "http", "https", "mailto":
+			return u.String()
+		}
+		return ""
+	}
+	return ""
+}
@@ -0,0 +1,558 @@
+package publicpage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Content limits
+const (
+	maxSections      = 30
+	maxSectionImages = 30
+	maxTitleLength   = 200
+	maxBodyLength    = 20000
+	maxTimings       = 20
+	maxImageSize     = 5 << 20 // 5 MB
+)
+
+// imageTypes are the image formats accepted for page images
+var imageTypes = map[string]string{
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".png":  "image/png",
+	".webp": "image/webp",
+}
+
+var (
+	ErrPageNotPublished = errors.New("temple page is not published")
+	ErrNothingToPublish = errors.New("add at least one visible section before publishing")
+
+	colorPattern     = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+	fontPattern      = regexp.MustCompile(`^[A-Za-z0-9 \-]{1,50}$`)
+	timePattern      = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+	imageNamePattern = regexp.MustCompile(`^[0-9a-f\-]{36}\.(jpg|jpeg|png|webp)$`)
+)
+
+// Service manages the landing page content of temples
+type Service struct {
+	Repo  *Repository
+	Store storage.Storage // page images, stored under "<entityID>/page/"
+	Audit auditlog.Service
+}
+
+// NewService initializes the page service
+func NewService(repo *Repository, store storage.Storage, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Store: store, Audit: auditSvc}
+}
+
+// ImageURL is the public URL of a page image
+func ImageURL(entityID uint, name string) string {
+	return fmt.Sprintf("/api/v1/public/temples/%d/page/images/%s", entityID, name)
+}
+
+func imageKey(entityID uint, name string) (string, error) {
+	if !imageNamePattern.MatchString(name) {
+		return "", storage.ErrInvalidKey
+	}
+	return storage.Key(fmt.Sprint(entityID), "page", name)
+}
+
+// =============================
+// Editing
+// =============================
+
+// GetEditorPage returns the draft theme and sections of a temple
+func (s *Service) GetEditorPage(ctx context.Context, entityID uint) (*EditorPage, error) {
+	sections, err := s.Repo.ListSections(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	out := &EditorPage{EntityID: entityID, Status: StatusDraft, Sections: sections}
+
+	page, err := s.Repo.GetPage(ctx, entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return out, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.Status = page.Status
+	out.PublishedAt = page.PublishedAt
+	out.HasUnpublishedChanges = page.PublishedAt == nil || page.DraftUpdatedAt.After(*page.PublishedAt)
+	if len(page.Theme) > 0 {
+		_ = json.Unmarshal(page.Theme, &out.Theme)
+	}
+	return out, nil
+}
+
+// UpdateTheme replaces the draft theme
+func (s *Service) UpdateTheme(ctx context.Context, entityID, userID uint, theme Theme, ip string) (*Theme, error) {
+	theme.FontFamily = strings.TrimSpace(theme.FontFamily)
+	var problems []string
+	for field, color := range map[string]string{
+		"primary_color":    theme.PrimaryColor,
+		"accent_color":     theme.AccentColor,
+		"background_color": theme.BackgroundColor,
+	} {
+		if color != "" && !colorPattern.MatchString(color) {
+			problems = append(problems, fmt.Sprintf("%s must be a hex color like #8B0000", field))
+		}
+	}
+	if theme.FontFamily != "" && !fontPattern.MatchString(theme.FontFamily) {
+		problems = append(problems, "font_family may only contain letters, digits, spaces and hyphens")
+	}
+	for field, name := range map[string]string{"logo_image": theme.LogoImage, "banner_image": theme.BannerImage} {
+		if name != "" {
+			if err := s.checkImage(ctx, entityID, name); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %v", field, err))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+
+	if err := s.Repo.TouchDraft(ctx, entityID); err != nil {
+		return nil, err
+	}
+	page, err := s.Repo.GetPage(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(theme)
+	page.Theme = datatypes.JSON(raw)
+	if err := s.Repo.SavePage(ctx, page); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_THEME_UPDATED", nil, ip)
+	return &theme, nil
+}
+
+// CreateSection adds a section at the end of the draft page
+func (s *Service) CreateSection(ctx context.Context, entityID, userID uint, in SectionInput, ip string) (*Section, error) {
+	n, err := s.Repo.CountSections(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if n >= maxSections {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("a page can have at most %d sections", maxSections)}}
+	}
+
+	section := &Section{EntityID: entityID, Visible: true}
+	if err := s.applyInput(ctx, section, in); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateSection(ctx, section); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.TouchDraft(ctx, entityID); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_SECTION_CREATED", map[string]interface{}{"section_id": section.ID, "type": section.Type}, ip)
+	return section, nil
+}
+
+// UpdateSection replaces the content of a draft section
+func (s *Service) UpdateSection(ctx context.Context, entityID, sectionID, userID uint, in SectionInput, ip string) (*Section, error) {
+	section, err := s.Repo.GetSection(ctx, entityID, sectionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyInput(ctx, section, in); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.SaveSection(ctx, section); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.TouchDraft(ctx, entityID); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_SECTION_UPDATED", map[string]interface{}{"section_id": section.ID, "type": section.Type}, ip)
+	return section, nil
+}
+
+// DeleteSection removes a draft section
+func (s *Service) DeleteSection(ctx context.Context, entityID, sectionID, userID uint, ip string) error {
+	if err := s.Repo.DeleteSection(ctx, entityID, sectionID); err != nil {
+		return err
+	}
+	if err := s.Repo.TouchDraft(ctx, entityID); err != nil {
+		return err
+	}
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_SECTION_DELETED", map[string]interface{}{"section_id": sectionID}, ip)
+	return nil
+}
+
+// ReorderSections sets the display order; ids must list every section once
+func (s *Service) ReorderSections(ctx context.Context, entityID, userID uint, ids []uint, ip string) ([]Section, error) {
+	sections, err := s.Repo.ListSections(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[uint]bool, len(sections))
+	for _, sec := range sections {
+		existing[sec.ID] = true
+	}
+	seen := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		if !existing[id] || seen[id] {
+			return nil, &ValidationError{Problems: []string{fmt.Sprintf("section %d is unknown or listed twice", id)}}
+		}
+		seen[id] = true
+	}
+	if len(ids) != len(sections) {
+		return nil, &ValidationError{Problems: []string{"section_ids must list every section of the page"}}
+	}
+
+	if err := s.Repo.ReorderSections(ctx, entityID, ids); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.TouchDraft(ctx, entityID); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_SECTIONS_REORDERED", map[string]interface{}{"section_ids": ids}, ip)
+	return s.Repo.ListSections(ctx, entityID)
+}
+
+// applyInput validates the input and copies it onto the section
+func (s *Service) applyInput(ctx context.Context, section *Section, in SectionInput) error {
+	var problems []string
+
+	in.Type = strings.ToLower(strings.TrimSpace(in.Type))
+	if !sectionTypes[in.Type] {
+		problems = append(problems, fmt.Sprintf("unknown section type %q", in.Type))
+	}
+	in.Title = strings.TrimSpace(in.Title)
+	if utf8.RuneCountInString(in.Title) > maxTitleLength {
+		problems = append(problems, fmt.Sprintf("title must be at most %d characters", maxTitleLength))
+	}
+	body := sanitizeHTML(in.Body)
+	if utf8.RuneCountInString(body) > maxBodyLength {
+		problems = append(problems, fmt.Sprintf("body must be at most %d characters", maxBodyLength))
+	}
+
+	if len(in.Images) > maxSectionImages {
+		problems = append(problems, fmt.Sprintf("a section can have at most %d images", maxSectionImages))
+	}
+	for _, name := range in.Images {
+		if err := s.checkImage(ctx, section.EntityID, name); err != nil {
+			problems = append(problems, fmt.Sprintf("image %s: %v", name, err))
+		}
+	}
+
+	data := SectionData{Caption: strings.TrimSpace(in.Caption)}
+	switch in.Type {
+	case SectionTimings:
+		if len(in.Timings) == 0 || len(in.Timings) > maxTimings {
+			problems = append(problems, fmt.Sprintf("timings section needs 1 to %d timings", maxTimings))
+		}
+		for i, t := range in.Timings {
+			t.Label = strings.TrimSpace(t.Label)
+			t.Days = strings.TrimSpace(t.Days)
+			if t.Label == "" {
+				problems = append(problems, fmt.Sprintf("timings[%d]: label is required", i))
+			}
+			if !timePattern.MatchString(t.Opens) || !timePattern.MatchString(t.Closes) {
+				problems = append(problems, fmt.Sprintf("timings[%d]: opens and closes must be HH:MM", i))
+			}
+			data.Timings = append(data.Timings, t)
+		}
+	case SectionDonation:
+		if in.Donation == nil {
+			problems = append(problems, "donation section needs a donation button")
+			break
+		}
+		d := *in.Donation
+		d.Label = strings.TrimSpace(d.Label)
+		if d.Label == "" {
+			d.Label = "Donate"
+		}
+		for _, amount := range d.SuggestedAmounts {
+			if amount <= 0 {
+				problems = append(problems, "suggested_amounts must be positive")
+				break
+			}
+		}
+		data.Donation = &d
+	case SectionGallery:
+		if len(in.Images) == 0 {
+			problems = append(problems, "gallery section needs at least one image")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	images := in.Images
+	if images == nil {
+		images = []string{}
+	}
+	imagesJSON, _ := json.Marshal(images)
+	dataJSON, _ := json.Marshal(data)
+
+	section.Type = in.Type
+	section.Title = in.Title
+	section.Body = body
+	section.Images = datatypes.JSON(imagesJSON)
+	section.Data = datatypes.JSON(dataJSON)
+	if in.Visible != nil {
+		section.Visible = *in.Visible
+	}
+	return nil
+}
+
+// =============================
+// Images
+// =============================
+
+// UploadImage stores a page image and returns its reference
+func (s *Service) UploadImage(ctx context.Context, entityID, userID uint, fileName string, size int64, r io.Reader, ip string) (*ImageRef, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	contentType, ok := imageTypes[ext]
+	if !ok {
+		return nil, &ValidationError{Problems: []string{"images must be JPG, PNG or WEBP files"}}
+	}
+	if size > maxImageSize {
+		return nil, &ValidationError{Problems: []string{fmt.Sprintf("images must be %dMB or smaller", maxImageSize>>20)}}
+	}
+
+	name := uuid.New().String() + ext
+	key, err := imageKey(entityID, name)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Store.Put(ctx, key, r, size, contentType); err != nil {
+		return nil, fmt.Errorf("failed to store image: %w", err)
+	}
+
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_IMAGE_UPLOADED", map[string]interface{}{"image": name, "file_name": fileName, "file_size": size}, ip)
+	return &ImageRef{Name: name, URL: ImageURL(entityID, name)}, nil
+}
+
+// OpenImage returns a stored page image
+func (s *Service) OpenImage(ctx context.Context, entityID uint, name string) (io.ReadCloser, *storage.ObjectInfo, error) {
+	key, err := imageKey(entityID, name)
+	if err != nil {
+		return nil, nil, storage.ErrNotFound
+	}
+	return s.Store.Get(ctx, key)
+}
+
+// checkImage makes sure a referenced image was uploaded for this temple
+func (s *Service) checkImage(ctx context.Context, entityID uint, name string) error {
+	key, err := imageKey(entityID, name)
+	if err != nil {
+		return errors.New("not a page image name")
+	}
+	if _, err := s.Store.Stat(ctx, key); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return errors.New("image not found, upload it first")
+		}
+		return err
+	}
+	return nil
+}
+
+// =============================
+// Publishing and rendering
+// =============================
+
+// Publish freezes the draft theme and visible sections as the public page
+func (s *Service) Publish(ctx context.Context, entityID, userID uint, ip string) (*PublicPage, error) {
+	content, err := s.renderDraft(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if len(content.Sections) == 0 {
+		return nil, ErrNothingToPublish
+	}
+
+	if err := s.Repo.TouchDraft(ctx, entityID); err != nil {
+		return nil, err
+	}
+	page, err := s.Repo.GetPage(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	page.PublishedContent = datatypes.JSON(raw)
+	page.Status = StatusPublished
+	page.PublishedAt = &now
+	page.PublishedBy = &userID
+	if err := s.Repo.SavePage(ctx, page); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_PUBLISHED", map[string]interface{}{"sections": len(content.Sections)}, ip)
+
+	e, err := s.Repo.GetEntity(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicPage{Temple: templeInfo(e), Content: *content, PublishedAt: page.PublishedAt}, nil
+}
+
+// Unpublish takes the public page offline; the draft is kept
+func (s *Service) Unpublish(ctx context.Context, entityID, userID uint, ip string) error {
+	page, err := s.Repo.GetPage(ctx, entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrPageNotPublished
+	}
+	if err != nil {
+		return err
+	}
+	if page.Status != StatusPublished {
+		return ErrPageNotPublished
+	}
+	page.Status = StatusDraft
+	if err := s.Repo.SavePage(ctx, page); err != nil {
+		return err
+	}
+	s.audit(ctx, userID, entityID, "PUBLIC_PAGE_UNPUBLISHED", nil, ip)
+	return nil
+}
+
+// Preview renders the current draft the way the public page would show it
+func (s *Service) Preview(ctx context.Context, entityID uint) (*PublicPage, error) {
+	e, err := s.Repo.GetEntity(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	content, err := s.renderDraft(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	return &PublicPage{Temple: templeInfo(e), Content: *content, Preview: true}, nil
+}
+
+// GetPublicPage returns the published page of an approved, active temple
+func (s *Service) GetPublicPage(ctx context.Context, entityID uint) (*PublicPage, error) {
+	e, err := s.Repo.GetEntity(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if e.Status != "approved" || !e.IsActive {
+		return nil, ErrPageNotPublished
+	}
+	page, err := s.Repo.GetPage(ctx, entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrPageNotPublished
+	}
+	if err != nil {
+		return nil, err
+	}
+	if page.Status != StatusPublished || len(page.PublishedContent) == 0 {
+		return nil, ErrPageNotPublished
+	}
+
+	var content Content
+	if err := json.Unmarshal(page.PublishedContent, &content); err != nil {
+		return nil, fmt.Errorf("corrupt published page: %w", err)
+	}
+	return &PublicPage{Temple: templeInfo(e), Content: content, PublishedAt: page.PublishedAt}, nil
+}
+
+func (s *Service) renderDraft(ctx context.Context, entityID uint) (*Content, error) {
+	editor, err := s.GetEditorPage(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &Content{
+		Theme: RenderedTheme{
+			PrimaryColor:    editor.Theme.PrimaryColor,
+			AccentColor:     editor.Theme.AccentColor,
+			BackgroundColor: editor.Theme.BackgroundColor,
+			FontFamily:      editor.Theme.FontFamily,
+			Logo:            imageRef(entityID, editor.Theme.LogoImage),
+			Banner:          imageRef(entityID, editor.Theme.BannerImage),
+		},
+		Sections: []RenderedSection{},
+	}
+
+	for _, sec := range editor.Sections {
+		if !sec.Visible {
+			continue
+		}
+		var names []string
+		var data SectionData
+		_ = json.Unmarshal(sec.Images, &names)
+		_ = json.Unmarshal(sec.Data, &data)
+
+		rendered := RenderedSection{
+			Type:     sec.Type,
+			Title:    sec.Title,
+			Body:     sec.Body,
+			Caption:  data.Caption,
+			Timings:  data.Timings,
+			Donation: data.Donation,
+		}
+		for _, name := range names {
+			rendered.Images = append(rendered.Images, *imageRef(entityID, name))
+		}
+		content.Sections = append(content.Sections, rendered)
+	}
+	return content, nil
+}
+
+func imageRef(entityID uint, name string) *ImageRef {
+	if name == "" {
+		return nil
+	}
+	return &ImageRef{Name: name, URL: ImageURL(entityID, name)}
+}
+
+func templeInfo(e *entity.Entity) TempleInfo {
+	info := TempleInfo{
+		ID:            e.ID,
+		Name:          e.Name,
+		MainDeity:     e.MainDeity,
+		TempleType:    e.TempleType,
+		Description:   e.Description,
+		Phone:         e.Phone,
+		StreetAddress: e.StreetAddress,
+		Landmark:      e.Landmark,
+		City:          e.City,
+		District:      e.District,
+		State:         e.State,
+		Pincode:       e.Pincode,
+		MapLink:       e.MapLink,
+		Latitude:      e.Latitude,
+		Longitude:     e.Longitude,
+	}
+	if e.Slug != nil {
+		info.Slug = *e.Slug
+	}
+	return info
+}
+
+func (s *Service) audit(ctx context.Context, userID, entityID uint, action string, details map[string]interface{}, ip string) {
+	if s.Audit == nil {
+		return
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, action, details, ip, "success")
+}
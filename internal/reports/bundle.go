@@ -0,0 +1,220 @@
+package reports
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// maxBundleReports caps the reports of one archive so a request stays within
+// the export timeouts
+const maxBundleReports = 10
+
+// BundleReport is one report of a bundle, named as for report jobs
+type BundleReport struct {
+	Report string `json:"report" binding:"required"` // activities, devotee-list, audit-logs, ...
+	Type   string `json:"type"`                      // activities only: events|sevas|bookings|donations|...
+	Format string `json:"format" binding:"required"` // csv, excel or pdf
+	Status string `json:"status"`
+}
+
+// ReportBundleRequest is the body of POST /superadmin/tenants/:id/reports/bundle;
+// every report covers the same period
+type ReportBundleRequest struct {
+	Reports   []BundleReport `json:"reports" binding:"required"`
+	DateRange string         `json:"date_range"`
+	StartDate string         `json:"start_date"`
+	EndDate   string         `json:"end_date"`
+}
+
+// bundleManifestFile describes one report of the archive; a report that
+// failed has an error instead of a file
+type bundleManifestFile struct {
+	Report string `json:"report"`
+	Type   string `json:"type,omitempty"`
+	Format string `json:"format"`
+	File   string `json:"file,omitempty"`
+	Size   int    `json:"size,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// bundleManifest is written to manifest.json, the last entry of the archive
+type bundleManifest struct {
+	TenantID    uint                 `json:"tenant_id"`
+	GeneratedAt time.Time            `json:"generated_at"`
+	GeneratedBy uint                 `json:"generated_by"`
+	DateRange   string               `json:"date_range"`
+	StartDate   string               `json:"start_date"`
+	EndDate     string               `json:"end_date"`
+	Files       []bundleManifestFile `json:"files"`
+}
+
+// ExportTenantReportBundle - POST /superadmin/tenants/:id/reports/bundle
+// Generates several reports of a tenant's temples for one period with the
+// existing exporters and streams them as a ZIP archive with a manifest.json
+// listing each file and its SHA-256. A report that fails is listed in the
+// manifest with its error; the others are still delivered.
+func (h *Handler) ExportTenantReportBundle(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	if ctx.RoleName != middleware.RoleSuperAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only superadmin can access this endpoint"})
+		return
+	}
+	ip := middleware.GetIPFromContext(c)
+
+	tenantID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant ID format"})
+		return
+	}
+
+	var req ReportBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
+		return
+	}
+	if len(req.Reports) == 0 || len(req.Reports) > maxBundleReports {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reports must list between 1 and %d reports", maxBundleReports)})
+		return
+	}
+	if req.DateRange == "" {
+		req.DateRange = DateRangeWeekly
+	}
+
+	// Every report is checked before the archive starts, while errors can
+	// still be answered with a status code
+	params := make([]JobParams, len(req.Reports))
+	for i := range req.Reports {
+		item := &req.Reports[i]
+		item.Report = strings.ToLower(strings.TrimSpace(item.Report))
+		item.Format = strings.ToLower(strings.TrimSpace(item.Format))
+		if err := ValidateJobFormat(item.Report, item.Format); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reports[%d]: %s", i, err.Error())})
+			return
+		}
+		if item.Report == JobReportActivities && item.Type == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("reports[%d]: type is required for activities", i)})
+			return
+		}
+		reportType := jobReportType(CreateReportJobRequest{Report: item.Report, Type: item.Type})
+		if !h.allowExportFormat(c, ctx, reportType, item.Format) {
+			return
+		}
+		start, end, err := GetDateRange(reportType, req.DateRange, req.StartDate, req.EndDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+			return
+		}
+		params[i] = JobParams{
+			Type:       item.Type,
+			Status:     item.Status,
+			DateRange:  req.DateRange,
+			StartDate:  start,
+			EndDate:    end,
+			Language:   i18n.FromContext(c.Request.Context()),
+			ViewerRole: ctx.RoleName,
+		}
+	}
+
+	ids, err := h.repo.GetEntitiesByTenant(uint(tenantID))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tenant entities"})
+		return
+	}
+	if len(ids) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no temples found for this tenant"})
+		return
+	}
+	entityIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		entityIDs = append(entityIDs, strconv.FormatUint(uint64(id), 10))
+	}
+
+	manifest := bundleManifest{
+		TenantID:    uint(tenantID),
+		GeneratedAt: time.Now(),
+		GeneratedBy: ctx.UserID,
+		DateRange:   req.DateRange,
+		StartDate:   params[0].StartDate.Format(dateLayout),
+		EndDate:     params[0].EndDate.Format(dateLayout),
+		Files:       make([]bundleManifestFile, 0, len(req.Reports)),
+	}
+	archiveName := fmt.Sprintf("tenant_%d_reports_%s.zip", tenantID, manifest.GeneratedAt.Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", archiveName))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	archive := zip.NewWriter(c.Writer)
+	failed := 0
+	for i, item := range req.Reports {
+		p := params[i]
+		p.EntityParam = fmt.Sprintf("tenant_%d", tenantID)
+		p.EntityIDs = entityIDs
+
+		entry := bundleManifestFile{Report: item.Report, Type: item.Type, Format: item.Format}
+		data, filename, _, err := exportReport(c.Request.Context(), h.service, item.Report, item.Format, ctx.UserID, ip, p)
+		if err == nil {
+			// Numbered so two reports of the same kind never share a name
+			entry.File = fmt.Sprintf("%02d_%s", i+1, filename)
+			err = writeBundleFile(archive, entry.File, data)
+		}
+		if err != nil {
+			failed++
+			entry.File = ""
+			entry.Error = err.Error()
+		} else {
+			sum := sha256.Sum256(data)
+			entry.Size = len(data)
+			entry.SHA256 = hex.EncodeToString(sum[:])
+		}
+		manifest.Files = append(manifest.Files, entry)
+	}
+
+	raw, _ := json.MarshalIndent(manifest, "", "  ")
+	err = writeBundleFile(archive, "manifest.json", raw)
+	if err == nil {
+		err = archive.Close()
+	}
+	if err != nil {
+		// The response has started; the client sees a truncated archive
+		log.Printf("⚠️ Report bundle for tenant %d failed: %v", tenantID, err)
+	}
+
+	outcome := "success"
+	if failed == len(req.Reports) {
+		outcome = "failure"
+	}
+	h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "REPORT_BUNDLE_EXPORTED", map[string]interface{}{
+		"tenant_id":  tenantID,
+		"reports":    manifest.Files,
+		"failed":     failed,
+		"date_range": req.DateRange,
+		"filename":   archiveName,
+	}, ip, outcome)
+}
+
+func writeBundleFile(archive *zip.Writer, name string, data []byte) error {
+	w, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
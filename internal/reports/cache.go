@@ -0,0 +1,251 @@
+package reports
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/utils"
+	"gorm.io/gorm"
+)
+
+// JSON previews are cached in Redis. A key covers the report, its filters and
+// the cache version of every entity it reads, so bumping a version is enough
+// to invalidate; orphaned entries expire with their TTL.
+//
+//	report_cache:<hash>                  -> {"total": ..., "data": ...}
+//	report_cache_version:<entity id>     -> bumped when the entity's records change
+//	report_cache_version:all             -> bumped for changes not tied to one entity
+const (
+	reportCachePrefix        = "report_cache"
+	reportCacheVersionPrefix = "report_cache_version"
+	reportCacheVersionAll    = "all"
+)
+
+// cacheTTL is how long previews stay cached; 0 disables the cache
+var cacheTTL = 5 * time.Minute
+
+// SetCacheTTL configures how long report previews are cached, 0 turns caching off
+func SetCacheTTL(ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	cacheTTL = ttl
+}
+
+// cacheTables are the tables previews read. Writes to them bump the version
+// of the entity the row belongs to, or the global one when that isn't known.
+var cacheTables = map[string]bool{
+	"entities":                true,
+	"events":                  true,
+	"rsvps":                   true,
+	"sevas":                   true,
+	"seva_bookings":           true,
+	"donations":               true,
+	"donation_campaigns":      true,
+	"donation_pledges":        true,
+	"donation_pledge_charges": true,
+	"payment_disputes":        true,
+	"hundi_counting_sessions": true,
+	"coupons":                 true,
+	"coupon_redemptions":      true,
+	"shop_orders":             true,
+	"shop_order_items":        true,
+	"volunteers":              true,
+	"event_volunteer_slots":   true,
+}
+
+type cachedPreview struct {
+	Total int64           `json:"total"`
+	Data  json.RawMessage `json:"data"`
+}
+
+func cacheVersionKey(entity string) string {
+	return fmt.Sprintf("%s:%s", reportCacheVersionPrefix, entity)
+}
+
+// previewCacheKey hashes the report, its filters and page and the current
+// versions of the entities, so any change to one of them yields a new key
+func previewCacheKey(ctx context.Context, report string, entityIDs []uint, filters interface{}, page *PageRequest) (string, error) {
+	ids := append([]uint(nil), entityIDs...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	keys := make([]string, 0, len(ids)+1)
+	keys = append(keys, cacheVersionKey(reportCacheVersionAll))
+	for _, id := range ids {
+		keys = append(keys, cacheVersionKey(strconv.FormatUint(uint64(id), 10)))
+	}
+	versions, err := utils.RedisClient.MGet(ctx, keys...).Result()
+	if err != nil {
+		return "", err
+	}
+
+	raw, err := json.Marshal(struct {
+		Report   string        `json:"report"`
+		Entities []uint        `json:"entities"`
+		Versions []interface{} `json:"versions"`
+		Filters  interface{}   `json:"filters"`
+		Page     *PageRequest  `json:"page"`
+	}{report, ids, versions, filters, page})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(raw)
+	return fmt.Sprintf("%s:%s", reportCachePrefix, hex.EncodeToString(sum[:])), nil
+}
+
+// cached fills out from the cache when a preview for the same report,
+// entities and filters is there, and otherwise runs load and caches out. The
+// page total is cached alongside so pagination headers survive a hit. Redis
+// errors fall back to load.
+func cached(report string, entityIDs []uint, filters interface{}, page *PageRequest, out interface{}, load func() error) error {
+	if cacheTTL <= 0 || utils.RedisClient == nil || len(entityIDs) == 0 {
+		return load()
+	}
+	ctx := context.Background()
+	key, err := previewCacheKey(ctx, report, entityIDs, filters, page)
+	if err != nil {
+		return load()
+	}
+
+	if val, err := utils.RedisClient.Get(ctx, key).Bytes(); err == nil {
+		var hit cachedPreview
+		if json.Unmarshal(val, &hit) == nil && json.Unmarshal(hit.Data, out) == nil {
+			if page != nil {
+				page.Total = hit.Total
+			}
+			return nil
+		}
+	}
+
+	if err := load(); err != nil {
+		return err
+	}
+
+	entry := cachedPreview{}
+	if page != nil {
+		entry.Total = page.Total
+	}
+	if entry.Data, err = json.Marshal(out); err == nil {
+		if payload, err := json.Marshal(entry); err == nil {
+			if err := utils.RedisClient.Set(ctx, key, payload, cacheTTL).Err(); err != nil {
+				log.Printf("⚠️ Failed to cache %s report preview: %v", report, err)
+			}
+		}
+	}
+	return nil
+}
+
+// InvalidateCache drops cached previews of the given entities, or of every
+// entity when none are given
+func InvalidateCache(ctx context.Context, entityIDs ...uint) {
+	if utils.RedisClient == nil {
+		return
+	}
+	keys := []string{cacheVersionKey(reportCacheVersionAll)}
+	if len(entityIDs) > 0 {
+		keys = keys[:0]
+		for _, id := range entityIDs {
+			keys = append(keys, cacheVersionKey(strconv.FormatUint(uint64(id), 10)))
+		}
+	}
+	pipe := utils.RedisClient.Pipeline()
+	for _, key := range keys {
+		pipe.Incr(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️ Failed to invalidate report cache: %v", err)
+	}
+}
+
+// RegisterCacheInvalidation hooks into db so creating, updating or deleting
+// rows of the tables previews read invalidates the cached previews. Raw SQL
+// writes aren't seen; their changes show once the TTL runs out.
+func RegisterCacheInvalidation(db *gorm.DB) error {
+	const name = "reports:invalidate_cache"
+	if err := db.Callback().Create().After("gorm:create").Register(name, invalidateOnWrite); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().After("gorm:update").Register(name, invalidateOnWrite); err != nil {
+		return err
+	}
+	return db.Callback().Delete().After("gorm:delete").Register(name, invalidateOnWrite)
+}
+
+func invalidateOnWrite(tx *gorm.DB) {
+	stmt := tx.Statement
+	if tx.Error != nil || tx.RowsAffected == 0 || !cacheTables[stmt.Table] {
+		return
+	}
+	ctx := stmt.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	InvalidateCache(ctx, writtenEntityIDs(stmt)...)
+}
+
+// writtenEntityIDs returns the entities of the rows a statement wrote, or nil
+// when any of them is unknown, such as for updates by condition
+func writtenEntityIDs(stmt *gorm.Statement) []uint {
+	if stmt.Schema == nil {
+		return nil
+	}
+	fieldName := "EntityID"
+	if stmt.Table == "entities" {
+		fieldName = "ID"
+	}
+	field := stmt.Schema.LookUpField(fieldName)
+	if field == nil {
+		return nil
+	}
+
+	var rows []reflect.Value
+	switch value := reflect.Indirect(stmt.ReflectValue); value.Kind() {
+	case reflect.Struct:
+		rows = append(rows, value)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			rows = append(rows, reflect.Indirect(value.Index(i)))
+		}
+	default:
+		return nil
+	}
+
+	seen := map[uint]bool{}
+	var ids []uint
+	for _, row := range rows {
+		v, zero := field.ValueOf(stmt.Context, row)
+		if zero {
+			return nil
+		}
+		id, ok := toUint(v)
+		if !ok {
+			return nil
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func toUint(v interface{}) (uint, bool) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	switch rv.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return uint(rv.Uint()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if rv.Int() > 0 {
+			return uint(rv.Int()), true
+		}
+	}
+	return 0, false
+}
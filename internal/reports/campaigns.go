@@ -0,0 +1,198 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// campaignTopDonors is how many donors the campaigns report lists per campaign
+const campaignTopDonors = 3
+
+// GetCampaigns returns the temples' fundraising campaigns running at any time
+// in the range, with everything raised so far and their largest donors
+func (r *repository) GetCampaigns(entityIDs []uint, start, end time.Time, page *PageRequest) ([]CampaignReportRow, error) {
+	var out []CampaignReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("donation_campaigns dc").
+		Select(`dc.id, dc.title, COALESCE(e.name, '') as temple_name, dc.status, dc.target_amount,
+			dc.start_date, dc.end_date, COALESCE(t.raised_amount, 0) as raised_amount,
+			COALESCE(t.donation_count, 0) as donation_count, COALESCE(t.donor_count, 0) as donor_count,
+			CASE WHEN dc.target_amount > 0 THEN ROUND(COALESCE(t.raised_amount, 0) * 100 / dc.target_amount, 2) ELSE 0 END as completion_percent,
+			COALESCE(td.top_donors, '') as top_donors`).
+		Joins("LEFT JOIN entities e ON e.id = dc.entity_id").
+		Joins(`LEFT JOIN (
+			SELECT campaign_id, SUM(amount) as raised_amount, COUNT(*) as donation_count, COUNT(DISTINCT user_id) as donor_count
+			FROM donations
+			WHERE campaign_id IS NOT NULL AND status = 'SUCCESS' AND deleted_at IS NULL
+			GROUP BY campaign_id
+		) t ON t.campaign_id = dc.id`).
+		Joins(`LEFT JOIN (
+			SELECT campaign_id, STRING_AGG(name || ' (' || TO_CHAR(total, 'FM999999999990.00') || ')', ', ' ORDER BY total DESC) as top_donors
+			FROM (
+				SELECT d.campaign_id, COALESCE(NULLIF(u.full_name, ''), 'Anonymous') as name, SUM(d.amount) as total,
+					ROW_NUMBER() OVER (PARTITION BY d.campaign_id ORDER BY SUM(d.amount) DESC) as donor_rank
+				FROM donations d
+				LEFT JOIN users u ON u.id = d.user_id
+				WHERE d.campaign_id IS NOT NULL AND d.status = 'SUCCESS' AND d.deleted_at IS NULL
+				GROUP BY d.campaign_id, d.user_id, u.full_name
+			) ranked
+			WHERE donor_rank <= ?
+			GROUP BY campaign_id
+		) td ON td.campaign_id = dc.id`, campaignTopDonors).
+		Where("dc.entity_id IN ? AND dc.deleted_at IS NULL", entityIDs).
+		Where("dc.start_date <= ? AND (dc.end_date IS NULL OR dc.end_date >= ?)", end, start)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"title":              "dc.title",
+		"temple_name":        "temple_name",
+		"status":             "dc.status",
+		"target_amount":      "dc.target_amount",
+		"raised_amount":      "raised_amount",
+		"completion_percent": "completion_percent",
+		"start_date":         "dc.start_date",
+	}, "dc.start_date DESC, dc.id DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Campaigns by format
+func (e *reportExporter) exportCampaignsByFormat(format, timestamp string, rows []CampaignReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportCampaignsExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("campaigns_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportCampaignsCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("campaigns_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportCampaignsPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("campaigns_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for campaigns: %s", format)
+	}
+}
+
+var campaignHeaders = []string{"Campaign", "Temple Name", "Status", "Target", "Raised", "Completion %", "Donations", "Donors", "Start Date", "End Date", "Top Donors"}
+
+func campaignRecord(row CampaignReportRow) []string {
+	endDate := ""
+	if row.EndDate != nil {
+		endDate = row.EndDate.Format("2006-01-02")
+	}
+	return []string{
+		row.Title,
+		row.TempleName,
+		row.Status,
+		fmt.Sprintf("%.2f", row.TargetAmount),
+		fmt.Sprintf("%.2f", row.RaisedAmount),
+		fmt.Sprintf("%.2f", row.CompletionPercent),
+		strconv.FormatInt(row.DonationCount, 10),
+		strconv.FormatInt(row.DonorCount, 10),
+		row.StartDate.Format("2006-01-02"),
+		endDate,
+		row.TopDonors,
+	}
+}
+
+func (e *reportExporter) exportCampaignsExcel(rows []CampaignReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Campaigns"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range campaignHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range campaignRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportCampaignsCSV(rows []CampaignReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(campaignHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(campaignRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportCampaignsPDF(rows []CampaignReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Donation Campaigns Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 9)
+	widths := []float64{36, 32, 16, 22, 22, 20, 18, 15, 20, 20, 56}
+	for i, header := range campaignHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range campaignRecord(row) {
+			limit := 24
+			if i == len(campaignHeaders)-1 {
+				limit = 44
+			}
+			value = truncateText(value, limit)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,201 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetCoupons returns the temples' coupons that existed in the range, with
+// the redemptions made within it
+func (r *repository) GetCoupons(entityIDs []uint, start, end time.Time, page *PageRequest) ([]CouponReportRow, error) {
+	var out []CouponReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("coupons c").
+		Select(`c.id, c.code, COALESCE(e.name, '') as temple_name, c.discount_type, c.value, c.applies_to,
+			c.is_active, c.usage_limit, c.valid_from, c.valid_until, c.deleted_at,
+			COUNT(rd.id) FILTER (WHERE rd.status = 'applied') as redemptions,
+			COUNT(rd.id) FILTER (WHERE rd.status = 'released') as released,
+			COUNT(DISTINCT rd.user_id) FILTER (WHERE rd.status = 'applied') as unique_users,
+			COALESCE(SUM(rd.amount) FILTER (WHERE rd.status = 'applied'), 0) as gross_amount,
+			COALESCE(SUM(rd.discount) FILTER (WHERE rd.status = 'applied'), 0) as discount_total,
+			COALESCE(SUM(rd.final_amount) FILTER (WHERE rd.status = 'applied'), 0) as net_amount,
+			MIN(rd.created_at) FILTER (WHERE rd.status = 'applied') as first_redeemed_at,
+			MAX(rd.created_at) FILTER (WHERE rd.status = 'applied') as last_redeemed_at`).
+		Joins("LEFT JOIN entities e ON e.id = c.entity_id").
+		Joins("LEFT JOIN coupon_redemptions rd ON rd.coupon_id = c.id AND rd.created_at BETWEEN ? AND ?", start, end).
+		Where("c.entity_id IN ?", entityIDs).
+		Where("c.created_at <= ? AND (c.deleted_at IS NULL OR c.deleted_at >= ?)", end, start).
+		Group("c.id, e.name")
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"code":           "c.code",
+		"temple_name":    "temple_name",
+		"redemptions":    "redemptions",
+		"discount_total": "discount_total",
+		"net_amount":     "net_amount",
+		"created_at":     "c.created_at",
+	}, "c.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Coupons by format
+func (e *reportExporter) exportCouponsByFormat(format, timestamp string, rows []CouponReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportCouponsExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("coupons_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportCouponsCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("coupons_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportCouponsPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("coupons_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for coupons: %s", format)
+	}
+}
+
+var couponHeaders = []string{"Coupon ID", "Code", "Temple Name", "Discount", "Applies To", "Active", "Limit", "Valid From", "Valid Until", "Redemptions", "Released", "Devotees", "Gross", "Discounted", "Net", "Last Redeemed"}
+
+func couponRecord(row CouponReportRow) []string {
+	discount := fmt.Sprintf("%.2f%%", row.Value)
+	if row.DiscountType == "flat" {
+		discount = fmt.Sprintf("%.2f off", row.Value)
+	}
+	limit := "Unlimited"
+	if row.UsageLimit > 0 {
+		limit = strconv.Itoa(row.UsageLimit)
+	}
+	active := "No"
+	if row.IsActive && row.DeletedAt == nil {
+		active = "Yes"
+	}
+	validFrom, validUntil, lastRedeemed := "", "", ""
+	if row.ValidFrom != nil {
+		validFrom = row.ValidFrom.Format("2006-01-02")
+	}
+	if row.ValidUntil != nil {
+		validUntil = row.ValidUntil.Format("2006-01-02")
+	}
+	if row.LastRedeemedAt != nil {
+		lastRedeemed = row.LastRedeemedAt.Format("2006-01-02")
+	}
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.Code,
+		row.TempleName,
+		discount,
+		row.AppliesTo,
+		active,
+		limit,
+		validFrom,
+		validUntil,
+		strconv.FormatInt(row.Redemptions, 10),
+		strconv.FormatInt(row.Released, 10),
+		strconv.FormatInt(row.UniqueUsers, 10),
+		fmt.Sprintf("%.2f", row.GrossAmount),
+		fmt.Sprintf("%.2f", row.DiscountTotal),
+		fmt.Sprintf("%.2f", row.NetAmount),
+		lastRedeemed,
+	}
+}
+
+func (e *reportExporter) exportCouponsExcel(rows []CouponReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Coupons"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range couponHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range couponRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportCouponsCSV(rows []CouponReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(couponHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(couponRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportCouponsPDF(rows []CouponReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Coupons Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 8)
+	widths := []float64{14, 20, 24, 20, 16, 11, 16, 18, 18, 18, 15, 15, 18, 18, 18, 18}
+	for i, header := range couponHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range couponRecord(row) {
+			value = truncateText(value, 18)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
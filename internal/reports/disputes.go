@@ -0,0 +1,173 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+func (r *repository) GetDisputes(entityIDs []uint, start, end time.Time, page *PageRequest) ([]DisputeReportRow, error) {
+	var out []DisputeReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("payment_disputes pd").
+		Select(`pd.id, pd.gateway_dispute_id, pd.payment_id, COALESCE(e.name, '') as temple_name,
+			COALESCE(u.full_name, '') as donor_name, pd.amount, pd.amount_deducted, pd.reason_code,
+			pd.phase, pd.status, pd.respond_by, pd.responded_at, pd.resolved_at, pd.created_at,
+			(SELECT COUNT(*) FROM payment_dispute_evidence ev WHERE ev.dispute_id = pd.id) as evidence_count`).
+		Joins("LEFT JOIN entities e ON e.id = pd.entity_id").
+		Joins("LEFT JOIN donations d ON d.id = pd.donation_id").
+		Joins("LEFT JOIN users u ON u.id = d.user_id").
+		Where("pd.entity_id IN ?", entityIDs).
+		Where("pd.created_at BETWEEN ? AND ?", start, end)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"temple_name": "temple_name",
+		"donor_name":  "donor_name",
+		"amount":      "pd.amount",
+		"status":      "pd.status",
+		"respond_by":  "pd.respond_by",
+		"created_at":  "pd.created_at",
+	}, "pd.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Disputes by format
+func (e *reportExporter) exportDisputesByFormat(format, timestamp string, rows []DisputeReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportDisputesExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("disputes_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportDisputesCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("disputes_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportDisputesPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("disputes_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for disputes: %s", format)
+	}
+}
+
+var disputeHeaders = []string{"Dispute ID", "Payment ID", "Temple Name", "Donor Name", "Amount", "Deducted", "Reason", "Status", "Respond By", "Evidence", "Raised At", "Resolved At"}
+
+func disputeRecord(row DisputeReportRow) []string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return []string{
+		row.GatewayDisputeID,
+		row.PaymentID,
+		row.TempleName,
+		row.DonorName,
+		fmt.Sprintf("%.2f", row.Amount),
+		fmt.Sprintf("%.2f", row.AmountDeducted),
+		row.ReasonCode,
+		row.Status,
+		formatTime(row.RespondBy),
+		strconv.FormatInt(row.EvidenceCount, 10),
+		row.CreatedAt.Format("2006-01-02 15:04:05"),
+		formatTime(row.ResolvedAt),
+	}
+}
+
+func (e *reportExporter) exportDisputesExcel(rows []DisputeReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Disputes"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range disputeHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range disputeRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportDisputesCSV(rows []DisputeReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(disputeHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(disputeRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportDisputesPDF(rows []DisputeReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Payment Disputes Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 9)
+	widths := []float64{28, 28, 32, 30, 18, 18, 24, 19, 22, 15, 22, 22}
+	for i, header := range disputeHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range disputeRecord(row) {
+			value = truncateText(value, 22)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,285 @@
+package reports
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// Ways the donations report can be grouped (group_by query param)
+const (
+	DonationGroupByDay    = "day"
+	DonationGroupByMonth  = "month"
+	DonationGroupByType   = "type"
+	DonationGroupByMethod = "method"
+)
+
+// unspecifiedGroup collects donations without a type or payment method
+const unspecifiedGroup = "unspecified"
+
+// donationGroupKeys maps each group_by value to the SQL expression of its key
+var donationGroupKeys = map[string]string{
+	DonationGroupByDay:    "TO_CHAR(COALESCE(d.donated_at, d.created_at), 'YYYY-MM-DD')",
+	DonationGroupByMonth:  "TO_CHAR(COALESCE(d.donated_at, d.created_at), 'YYYY-MM')",
+	DonationGroupByType:   "COALESCE(NULLIF(d.donation_type, ''), '" + unspecifiedGroup + "')",
+	DonationGroupByMethod: "COALESCE(NULLIF(d.method, ''), '" + unspecifiedGroup + "')",
+}
+
+// DonationGroup is one section of a grouped donations report. Total adds up
+// every donation of the group, Collected only the successful ones.
+type DonationGroup struct {
+	Key       string              `json:"key"`
+	Label     string              `json:"label"`
+	Count     int64               `json:"count"`
+	Total     float64             `json:"total"`
+	Collected float64             `json:"collected"`
+	Rows      []DonationReportRow `json:"rows,omitempty"` // exports only
+}
+
+// DonationGroupSummary holds the groups of the donations report, ordered by
+// date or by name, and the grand totals over all of them
+type DonationGroupSummary struct {
+	GroupBy   string          `json:"group_by"`
+	Groups    []DonationGroup `json:"groups"`
+	Count     int64           `json:"count"`
+	Total     float64         `json:"total"`
+	Collected float64         `json:"collected"`
+}
+
+// SummarizeDonations totals the temples' donations in the range per group,
+// matching the rows GetDonations returns
+func (r *repository) SummarizeDonations(entityIDs []uint, start, end time.Time, groupBy string) ([]DonationGroup, error) {
+	keyExpr, ok := donationGroupKeys[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown donation grouping: %s", groupBy)
+	}
+	if len(entityIDs) == 0 {
+		return []DonationGroup{}, nil
+	}
+
+	var totals []struct {
+		GroupKey  string
+		Count     int64
+		Total     float64
+		Collected float64
+	}
+	err := r.db.Table("donations d").
+		Select(keyExpr+` as group_key,
+			COUNT(*) as count,
+			COALESCE(SUM(d.amount), 0) as total,
+			COALESCE(SUM(CASE WHEN UPPER(d.status) = 'SUCCESS' THEN d.amount ELSE 0 END), 0) as collected`).
+		Where("d.entity_id IN ?", entityIDs).
+		Where("d.created_at BETWEEN ? AND ?", start, end).
+		Group("group_key").
+		Order("group_key ASC").
+		Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DonationGroup, 0, len(totals))
+	for _, t := range totals {
+		out = append(out, DonationGroup{
+			Key:       t.GroupKey,
+			Label:     donationGroupLabel(groupBy, t.GroupKey),
+			Count:     t.Count,
+			Total:     t.Total,
+			Collected: t.Collected,
+		})
+	}
+	return out, nil
+}
+
+// donationGroupKey is the key of the group a donation falls in
+func donationGroupKey(groupBy string, row DonationReportRow) string {
+	switch groupBy {
+	case DonationGroupByDay:
+		return row.DonationDate.Format("2006-01-02")
+	case DonationGroupByMonth:
+		return row.DonationDate.Format("2006-01")
+	case DonationGroupByType:
+		if row.DonationType != "" {
+			return row.DonationType
+		}
+	case DonationGroupByMethod:
+		if row.PaymentMethod != "" {
+			return row.PaymentMethod
+		}
+	}
+	return unspecifiedGroup
+}
+
+// donationGroupLabel is the section heading of a group, e.g. 05 Mar 2025,
+// March 2025 or Annadanam
+func donationGroupLabel(groupBy, key string) string {
+	switch groupBy {
+	case DonationGroupByDay:
+		if t, err := time.Parse("2006-01-02", key); err == nil {
+			return t.Format("02 Jan 2006")
+		}
+	case DonationGroupByMonth:
+		if t, err := time.Parse("2006-01", key); err == nil {
+			return t.Format("January 2006")
+		}
+	}
+	return categoryLabel(key)
+}
+
+// groupDonations sorts the rows into groups; rows keep their order within a
+// group
+func groupDonations(groupBy string, rows []DonationReportRow) *DonationGroupSummary {
+	summary := &DonationGroupSummary{GroupBy: groupBy, Groups: []DonationGroup{}}
+	index := map[string]int{}
+	for _, row := range rows {
+		key := donationGroupKey(groupBy, row)
+		i, ok := index[key]
+		if !ok {
+			i = len(summary.Groups)
+			index[key] = i
+			summary.Groups = append(summary.Groups, DonationGroup{Key: key, Label: donationGroupLabel(groupBy, key)})
+		}
+		group := &summary.Groups[i]
+		group.Rows = append(group.Rows, row)
+		group.Count++
+		group.Total += row.Amount
+		if strings.EqualFold(row.Status, "SUCCESS") {
+			group.Collected += row.Amount
+		}
+	}
+	sort.Slice(summary.Groups, func(i, j int) bool { return summary.Groups[i].Key < summary.Groups[j].Key })
+	summary.addGrandTotals()
+	return summary
+}
+
+// summarizeDonationGroups wraps totals computed by the database
+func summarizeDonationGroups(groupBy string, groups []DonationGroup) *DonationGroupSummary {
+	summary := &DonationGroupSummary{GroupBy: groupBy, Groups: groups}
+	summary.addGrandTotals()
+	return summary
+}
+
+func (s *DonationGroupSummary) addGrandTotals() {
+	for _, group := range s.Groups {
+		s.Count += group.Count
+		s.Total += group.Total
+		s.Collected += group.Collected
+	}
+}
+
+// ===============================
+// Grouped exports
+// ===============================
+
+var groupedDonationHeaders = []string{"ID", "Donor Name", "Temple Name", "Donor Email", "Amount", "Donation Type", "Payment Method", "Status", "Donation Date", "Order ID", "Payment ID"}
+
+// exportGroupedDonationsExcel writes each group as a heading, its donations
+// and a subtotal row, then the grand total
+func (e *reportExporter) exportGroupedDonationsExcel(summary *DonationGroupSummary) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Donations"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range groupedDonationHeaders {
+		f.SetCellValue(sheetName, fmt.Sprintf("%c1", 'A'+i), header)
+	}
+
+	row := 2
+	totalsRow := func(label string, count int64, total, collected float64) {
+		f.SetCellValue(sheetName, fmt.Sprintf("B%d", row), label)
+		f.SetCellValue(sheetName, fmt.Sprintf("D%d", row), fmt.Sprintf("%d donations", count))
+		f.SetCellValue(sheetName, fmt.Sprintf("E%d", row), total)
+		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), "Collected")
+		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), collected)
+		row++
+	}
+	for _, group := range summary.Groups {
+		row++ // blank line between sections
+		f.SetCellValue(sheetName, fmt.Sprintf("A%d", row), group.Label)
+		row++
+		for _, donation := range group.Rows {
+			paymentID := ""
+			if donation.PaymentID != nil {
+				paymentID = *donation.PaymentID
+			}
+			values := []interface{}{donation.ID, donation.DonorName, donation.TempleName, donation.DonorEmail, donation.Amount,
+				donation.DonationType, donation.PaymentMethod, donation.Status, donation.DonationDate.Format("2006-01-02 15:04:05"),
+				donation.OrderID, paymentID}
+			for i, value := range values {
+				f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+i, row), value)
+			}
+			row++
+		}
+		totalsRow("Subtotal - "+group.Label, group.Count, group.Total, group.Collected)
+	}
+	row++
+	totalsRow("Grand Total", summary.Count, summary.Total, summary.Collected)
+	f.SetColWidth(sheetName, "B", "B", 30)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportGroupedDonationsPDF prints a table per group with its subtotal and a
+// grand total at the end
+func (e *reportExporter) exportGroupedDonationsPDF(summary *DonationGroupSummary) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Donations Report by "+categoryLabel(summary.GroupBy))
+	pdf.Ln(16)
+
+	widths := []float64{35, 30, 35, 20, 25, 25, 20, 25, 35}
+	headers := []string{"Donor Name", "Temple Name", "Donor Email", "Amount", "Type", "Method", "Status", "Donation Date", "Order ID"}
+	// Subtotal rows span the first three columns, the amount sits under Amount
+	labelWidth := widths[0] + widths[1] + widths[2]
+	restWidth := widths[4] + widths[5] + widths[6] + widths[7] + widths[8]
+	totalsRow := func(label string, count int64, total, collected float64) {
+		pdf.SetFont("Arial", "B", 8)
+		pdf.CellFormat(labelWidth, 6, fmt.Sprintf("%s (%d)", label, count), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(widths[3], 6, fmt.Sprintf("%.2f", total), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(restWidth, 6, fmt.Sprintf("Collected: %.2f", collected), "1", 0, "L", false, 0, "")
+		pdf.Ln(-1)
+	}
+
+	for _, group := range summary.Groups {
+		pdf.SetFont("Arial", "B", 11)
+		pdf.Cell(0, 8, group.Label)
+		pdf.Ln(-1)
+
+		pdf.SetFont("Arial", "B", 10)
+		for i, header := range headers {
+			pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("Arial", "", 8)
+		for _, donation := range group.Rows {
+			pdf.CellFormat(widths[0], 6, truncateText(donation.DonorName, 22), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[1], 6, truncateText(donation.TempleName, 18), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[2], 6, truncateText(donation.DonorEmail, 22), "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[3], 6, fmt.Sprintf("%.2f", donation.Amount), "1", 0, "R", false, 0, "")
+			pdf.CellFormat(widths[4], 6, donation.DonationType, "1", 0, "L", false, 0, "")
+			pdf.CellFormat(widths[5], 6, donation.PaymentMethod, "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[6], 6, donation.Status, "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[7], 6, donation.DonationDate.Format("2006-01-02"), "1", 0, "C", false, 0, "")
+			pdf.CellFormat(widths[8], 6, donation.OrderID, "1", 0, "L", false, 0, "")
+			pdf.Ln(-1)
+		}
+		totalsRow("Subtotal", group.Count, group.Total, group.Collected)
+		pdf.Ln(4)
+	}
+	totalsRow("Grand Total", summary.Count, summary.Total, summary.Collected)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,455 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	defaultTopDonors    = 10
+	maxTopDonors        = 100
+	defaultLapsedMonths = 12
+	maxLapsedMonths     = 60
+)
+
+// donorAnalyticsDonations are the temple's successful donations, dated when
+// they were paid
+const donorAnalyticsDonations = `
+	SELECT d.user_id, d.amount, COALESCE(d.donated_at, d.created_at) as donated_at
+	FROM donations d
+	WHERE d.entity_id = @entity AND d.status = 'SUCCESS' AND d.deleted_at IS NULL`
+
+// GetDonorAnalytics builds the temple's donor analytics for the period. A
+// donor is first-time when their first successful donation to the temple
+// falls in the period; lapsed donors gave before and not in the LapsedMonths
+// up to the end of the period.
+func (r *repository) GetDonorAnalytics(entityID uint, req DonorAnalyticsReportRequest) (*DonorAnalytics, error) {
+	lapsedSince := req.EndDate.AddDate(0, -req.LapsedMonths, 0)
+	out := &DonorAnalytics{
+		EntityID:      entityID,
+		StartDate:     req.StartDate.Format("2006-01-02"),
+		EndDate:       req.EndDate.Format("2006-01-02"),
+		TopDonors:     []TopDonorRow{},
+		LapsedMonths:  req.LapsedMonths,
+		LapsedSince:   lapsedSince.Format("2006-01-02"),
+		LapsedDonors:  []LapsedDonorRow{},
+		GiftSizeTrend: []GiftSizePoint{},
+	}
+	if err := r.db.Table("entities").Select("name").Where("id = ?", entityID).Scan(&out.TempleName).Error; err != nil {
+		return nil, err
+	}
+	args := map[string]interface{}{
+		"entity":       entityID,
+		"start":        req.StartDate,
+		"end":          req.EndDate,
+		"top":          req.Top,
+		"lapsed_since": lapsedSince,
+	}
+
+	err := r.db.Raw(`
+		SELECT x.user_id,
+			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as donor_name,
+			COALESCE(u.email, '') as donor_email,
+			COUNT(*) as donation_count, SUM(x.amount) as total_amount, MAX(x.donated_at) as last_donation_at
+		FROM (`+donorAnalyticsDonations+`) x
+		LEFT JOIN users u ON u.id = x.user_id
+		WHERE x.donated_at BETWEEN @start AND @end
+		GROUP BY x.user_id, u.full_name, u.email
+		ORDER BY total_amount DESC, x.user_id ASC
+		LIMIT @top`, args).Scan(&out.TopDonors).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range out.TopDonors {
+		out.TopDonors[i].Rank = i + 1
+	}
+
+	err = r.db.Raw(`
+		WITH x AS (`+donorAnalyticsDonations+`),
+		firsts AS (SELECT user_id, MIN(donated_at) as first_at FROM x GROUP BY user_id),
+		period AS (SELECT user_id, SUM(amount) as amount FROM x WHERE donated_at BETWEEN @start AND @end GROUP BY user_id)
+		SELECT COUNT(*) as donors,
+			COUNT(*) FILTER (WHERE f.first_at >= @start) as first_time,
+			COUNT(*) FILTER (WHERE f.first_at < @start) as repeat,
+			COALESCE(SUM(p.amount) FILTER (WHERE f.first_at >= @start), 0) as first_time_amount,
+			COALESCE(SUM(p.amount) FILTER (WHERE f.first_at < @start), 0) as repeat_amount
+		FROM period p JOIN firsts f ON f.user_id = p.user_id`, args).Scan(&out.Retention).Error
+	if err != nil {
+		return nil, err
+	}
+	if out.Retention.Donors > 0 {
+		out.Retention.RepeatRate = float64(out.Retention.Repeat) * 100 / float64(out.Retention.Donors)
+	}
+
+	err = r.db.Raw(`
+		SELECT x.user_id,
+			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as donor_name,
+			COALESCE(u.email, '') as donor_email,
+			COUNT(*) as donation_count, SUM(x.amount) as lifetime_amount, MAX(x.donated_at) as last_donation_at
+		FROM (`+donorAnalyticsDonations+`) x
+		LEFT JOIN users u ON u.id = x.user_id
+		WHERE x.donated_at <= @end
+		GROUP BY x.user_id, u.full_name, u.email
+		HAVING MAX(x.donated_at) < @lapsed_since
+		ORDER BY lifetime_amount DESC, x.user_id ASC`, args).Scan(&out.LapsedDonors).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var months []GiftSizePoint
+	err = r.db.Raw(`
+		SELECT TO_CHAR(x.donated_at, 'YYYY-MM') as month, COUNT(*) as donations, SUM(x.amount) as amount
+		FROM (`+donorAnalyticsDonations+`) x
+		WHERE x.donated_at BETWEEN @start AND @end
+		GROUP BY 1`, args).Scan(&months).Error
+	if err != nil {
+		return nil, err
+	}
+	byMonth := make(map[string]GiftSizePoint, len(months))
+	for _, m := range months {
+		byMonth[m.Month] = m
+	}
+	// Every month of the period is charted, months without gifts as zero
+	for month := time.Date(req.StartDate.Year(), req.StartDate.Month(), 1, 0, 0, 0, 0, req.StartDate.Location()); !month.After(req.EndDate); month = month.AddDate(0, 1, 0) {
+		key := month.Format("2006-01")
+		point := byMonth[key]
+		point.Month = key
+		point.Label = month.Format("Jan 2006")
+		if point.Donations > 0 {
+			point.AverageGift = point.Amount / float64(point.Donations)
+		}
+		out.GiftSizeTrend = append(out.GiftSizeTrend, point)
+	}
+	return out, nil
+}
+
+// ===============================
+// Service
+// ===============================
+
+func (s *reportService) GetDonorAnalytics(entityID uint, req DonorAnalyticsReportRequest) (*DonorAnalytics, error) {
+	return s.repo.GetDonorAnalytics(entityID, req)
+}
+
+func (s *reportService) ExportDonorAnalytics(ctx context.Context, entityID uint, req DonorAnalyticsReportRequest, userID *uint, ip string) ([]byte, string, string, error) {
+	fail := func(err error) ([]byte, string, string, error) {
+		s.auditSvc.LogAction(ctx, userID, &entityID, "DONOR_ANALYTICS_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type": ReportTypeDonorAnalytics,
+			"format":      req.Format,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	analytics, err := s.GetDonorAnalytics(entityID, req)
+	if err != nil {
+		return fail(err)
+	}
+	bytes, filename, mimeType, err := s.render(ctx, ReportTypeDonorAnalytics, req.Format, ReportData{DonorAnalytics: analytics})
+	if err != nil {
+		return fail(err)
+	}
+
+	s.auditSvc.LogAction(ctx, userID, &entityID, "DONOR_ANALYTICS_REPORT_DOWNLOADED", map[string]interface{}{
+		"report_type":  ReportTypeDonorAnalytics,
+		"format":       req.Format,
+		"filename":     filename,
+		"date_range":   req.DateRange,
+		"record_count": len(analytics.TopDonors) + len(analytics.LapsedDonors),
+	}, ip, "success")
+	return bytes, filename, mimeType, nil
+}
+
+// ===============================
+// Exporter
+// ===============================
+
+// reportSection is one titled table of a multi-part export
+type reportSection struct {
+	Title   string
+	Headers []string
+	Records [][]string
+}
+
+// donorAnalyticsSections lays the analytics out as the tables every export
+// format shares
+func donorAnalyticsSections(a *DonorAnalytics) []reportSection {
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+	date := func(t time.Time) string { return t.Format("2006-01-02") }
+
+	top := reportSection{
+		Title:   "Top Donors",
+		Headers: []string{"Rank", "Donor Name", "Donor Email", "Donations", "Total Amount", "Last Donation"},
+	}
+	for _, d := range a.TopDonors {
+		top.Records = append(top.Records, []string{strconv.Itoa(d.Rank), d.DonorName, d.DonorEmail,
+			strconv.FormatInt(d.DonationCount, 10), money(d.TotalAmount), date(d.LastDonationAt)})
+	}
+
+	r := a.Retention
+	retention := reportSection{
+		Title:   "First-time and Repeat Donors",
+		Headers: []string{"Donors", "Count", "Amount"},
+		Records: [][]string{
+			{"First-time donors", strconv.FormatInt(r.FirstTime, 10), money(r.FirstTimeAmount)},
+			{"Repeat donors", strconv.FormatInt(r.Repeat, 10), money(r.RepeatAmount)},
+			{"All donors", strconv.FormatInt(r.Donors, 10), money(r.FirstTimeAmount + r.RepeatAmount)},
+			{"Repeat rate", fmt.Sprintf("%.1f%%", r.RepeatRate), ""},
+		},
+	}
+
+	lapsed := reportSection{
+		Title:   fmt.Sprintf("Lapsed Donors (no donation since %s)", a.LapsedSince),
+		Headers: []string{"Donor Name", "Donor Email", "Donations", "Lifetime Amount", "Last Donation"},
+	}
+	for _, d := range a.LapsedDonors {
+		lapsed.Records = append(lapsed.Records, []string{d.DonorName, d.DonorEmail,
+			strconv.FormatInt(d.DonationCount, 10), money(d.LifetimeAmount), date(d.LastDonationAt)})
+	}
+
+	trend := reportSection{
+		Title:   "Average Gift Size",
+		Headers: []string{"Month", "Donations", "Amount", "Average Gift"},
+	}
+	for _, p := range a.GiftSizeTrend {
+		trend.Records = append(trend.Records, []string{p.Label, strconv.FormatInt(p.Donations, 10), money(p.Amount), money(p.AverageGift)})
+	}
+	return []reportSection{top, retention, lapsed, trend}
+}
+
+func (e *reportExporter) exportDonorAnalyticsByFormat(format, timestamp string, analytics *DonorAnalytics) ([]byte, string, string, error) {
+	if analytics == nil {
+		return nil, "", "", fmt.Errorf("no donor analytics to export")
+	}
+	sections := donorAnalyticsSections(analytics)
+	switch format {
+	case FormatExcel:
+		data, err := e.exportDonorAnalyticsExcel(sections)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("donor_analytics_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportDonorAnalyticsCSV(sections)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("donor_analytics_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportDonorAnalyticsPDF(analytics, sections)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("donor_analytics_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for donor analytics: %s", format)
+	}
+}
+
+// exportDonorAnalyticsExcel writes a sheet per section
+func (e *reportExporter) exportDonorAnalyticsExcel(sections []reportSection) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetNames := []string{"Top Donors", "Retention", "Lapsed Donors", "Gift Size"}
+	for i, section := range sections {
+		sheet := sheetNames[i]
+		if i == 0 {
+			f.SetSheetName("Sheet1", sheet)
+		} else if _, err := f.NewSheet(sheet); err != nil {
+			return nil, err
+		}
+		for col, header := range section.Headers {
+			cell, _ := excelize.CoordinatesToCellName(col+1, 1)
+			f.SetCellValue(sheet, cell, header)
+		}
+		for r, record := range section.Records {
+			for col, value := range record {
+				cell, _ := excelize.CoordinatesToCellName(col+1, r+2)
+				f.SetCellValue(sheet, cell, value)
+			}
+		}
+		f.SetColWidth(sheet, "A", "C", 24)
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportDonorAnalyticsCSV writes the sections one after another, each under
+// its title and separated by a blank line
+func (e *reportExporter) exportDonorAnalyticsCSV(sections []reportSection) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	for i, section := range sections {
+		if i > 0 {
+			if err := writer.Write([]string{}); err != nil {
+				return nil, err
+			}
+		}
+		if err := writer.Write([]string{section.Title}); err != nil {
+			return nil, err
+		}
+		if err := writer.Write(section.Headers); err != nil {
+			return nil, err
+		}
+		if err := writer.WriteAll(section.Records); err != nil {
+			return nil, err
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportDonorAnalyticsPDF(a *DonorAnalytics, sections []reportSection) ([]byte, error) {
+	pdf := i18n.NewPDF("P")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Donor Analytics")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(0, 8, fmt.Sprintf("%s, %s to %s", a.TempleName, a.StartDate, a.EndDate))
+	pdf.Ln(14)
+
+	for _, section := range sections {
+		pdf.SetFont("Arial", "B", 12)
+		pdf.Cell(0, 8, section.Title)
+		pdf.Ln(10)
+
+		// Share the printable width of a portrait A4 page between the columns
+		width := 190.0 / float64(len(section.Headers))
+		maxChars := int(width / 1.8)
+		pdf.SetFont("Arial", "B", 9)
+		for _, header := range section.Headers {
+			pdf.CellFormat(width, 7, header, "1", 0, "C", false, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Arial", "", 8)
+		if len(section.Records) == 0 {
+			pdf.CellFormat(width*float64(len(section.Headers)), 6, "None", "1", 0, "C", false, 0, "")
+			pdf.Ln(-1)
+		}
+		for _, record := range section.Records {
+			for _, value := range record {
+				pdf.CellFormat(width, 6, truncateText(value, maxChars), "1", 0, "L", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.Ln(8)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ===============================
+// Handler
+// ===============================
+
+// GetDonorAnalyticsReport - GET /entities/:id/reports/donor-analytics
+// Donor analytics over a date range (date_range, start_date, end_date as for
+// other reports, default yearly): the top donors (top=10, at most 100),
+// first-time against repeat donors, donors lapsed for lapsed_months (default
+// 12) and the monthly average gift. JSON feeds charts; ?format=csv|excel|pdf
+// exports.
+func (h *Handler) GetDonorAnalyticsReport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity_id"})
+		return
+	}
+	if !h.canAccessEntity(ctx, uint(entityID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for this entity"})
+		return
+	}
+
+	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDonorAnalytics, format) {
+		return
+	}
+
+	dateRange := c.Query("date_range")
+	if dateRange == "" {
+		dateRange = DateRangeYearly
+	}
+	start, end, err := GetDateRange(ReportTypeDonorAnalytics, dateRange, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	req := DonorAnalyticsReportRequest{
+		DateRange:    dateRange,
+		StartDate:    start,
+		EndDate:      end,
+		Top:          defaultTopDonors,
+		LapsedMonths: defaultLapsedMonths,
+		Format:       format,
+	}
+	if v := c.Query("top"); v != "" {
+		if req.Top, err = strconv.Atoi(v); err != nil || req.Top < 1 || req.Top > maxTopDonors {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("top must be between 1 and %d", maxTopDonors), "param": "top"})
+			return
+		}
+	}
+	if v := c.Query("lapsed_months"); v != "" {
+		if req.LapsedMonths, err = strconv.Atoi(v); err != nil || req.LapsedMonths < 1 || req.LapsedMonths > maxLapsedMonths {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("lapsed_months must be between 1 and %d", maxLapsedMonths), "param": "lapsed_months"})
+			return
+		}
+	}
+
+	if format == "" {
+		analytics, err := h.service.GetDonorAnalytics(uint(entityID), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &analytics)
+		eid := uint(entityID)
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, &eid, "DONOR_ANALYTICS_REPORT_VIEWED", map[string]interface{}{
+			"report_type": ReportTypeDonorAnalytics,
+			"date_range":  dateRange,
+		}, ip, "success")
+		c.JSON(http.StatusOK, gin.H{"report_type": ReportTypeDonorAnalytics, "data": analytics})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportDonorAnalytics(h.exportContext(c, ctx), uint(entityID), req, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
@@ -0,0 +1,400 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// nonPIIExportReports are the exported report types holding no personal
+// data; every other report, including types added later, is flagged as PII
+var nonPIIExportReports = []string{ReportTypeEvents, ReportTypeSevas, "temple_registered", ReportTypeIncomeExpense, ReportTypeFinancialYear, ReportTypeInventoryValuation}
+
+// exportAuditQuery lists successful report exports from the audit log: every
+// *_REPORT_DOWNLOADED action, plus streamed report jobs, which bypass the
+// synchronous exporters. A user's tenant is the user for temple admins and
+// the active tenant assignment for everyone else.
+const exportAuditQuery = `
+	SELECT al.id, al.created_at as exported_at, al.user_id,
+		COALESCE(u.full_name, '') as user_name, COALESCE(u.email, '') as user_email,
+		COALESCE(ur.role_name, '') as user_role,
+		COALESCE(tu.id, 0) as tenant_id,
+		COALESCE(td.temple_name, tu.full_name, '') as tenant_name,
+		COALESCE(al.details->>'report_type', al.details->>'report', '') as report_type,
+		COALESCE(al.details->>'format', '') as format,
+		COALESCE(al.details->>'filename', al.details->>'file_name', '') as file_name,
+		COALESCE((al.details->>'record_count')::bigint, rj.rows_done) as record_count,
+		COALESCE(al.details->>'report_type', al.details->>'report', '') NOT IN ? as pii,
+		al.action = 'REPORT_JOB_COMPLETED' as streamed
+	FROM audit_logs al
+	LEFT JOIN users u ON u.id = al.user_id
+	LEFT JOIN user_roles ur ON ur.id = u.role_id
+	LEFT JOIN tenant_user_assignments tua ON tua.user_id = u.id AND tua.status = 'active'
+	LEFT JOIN users tu ON tu.id = CASE WHEN ur.role_name = 'templeadmin' THEN u.id ELSE tua.tenant_id END
+	LEFT JOIN LATERAL (
+		SELECT temple_name FROM tenant_details WHERE user_id = tu.id ORDER BY id LIMIT 1
+	) td ON true
+	LEFT JOIN report_jobs rj ON rj.id = al.details->>'job_id'
+	WHERE al.status = 'success' AND al.created_at BETWEEN ? AND ?
+		AND (al.action LIKE '%\_REPORT\_DOWNLOADED'
+			OR (al.action = 'REPORT_JOB_COMPLETED' AND al.details->>'stream' = 'true'))`
+
+// exportAuditRows is exportAuditQuery narrowed by the request filters
+func (r *repository) exportAuditRows(req ExportAuditReportRequest) *gorm.DB {
+	query := r.db.Table("(?) as x", r.db.Raw(exportAuditQuery, nonPIIExportReports, req.StartDate, req.EndDate))
+	if req.Role != "" {
+		query = query.Where("x.user_role = ?", req.Role)
+	}
+	if req.TenantID != 0 {
+		query = query.Where("x.tenant_id = ?", req.TenantID)
+	}
+	if req.ReportType != "" {
+		query = query.Where("x.report_type = ?", req.ReportType)
+	}
+	if req.PIIOnly {
+		query = query.Where("x.pii")
+	}
+	return query
+}
+
+func (r *repository) GetExportAudit(req ExportAuditReportRequest) ([]ExportAuditReportRow, error) {
+	var out []ExportAuditReportRow
+	query, err := paginate(r.db, r.exportAuditRows(req).Select("x.*"), req.Page, map[string]string{
+		"exported_at":  "x.exported_at",
+		"user_name":    "x.user_name",
+		"user_role":    "x.user_role",
+		"tenant_name":  "x.tenant_name",
+		"report_type":  "x.report_type",
+		"record_count": "x.record_count",
+	}, "x.exported_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+func (r *repository) SummarizeExportAudit(req ExportAuditReportRequest) (ExportAuditSummary, error) {
+	var out ExportAuditSummary
+	err := r.exportAuditRows(req).
+		Select(`COUNT(*) as exports,
+			COUNT(*) FILTER (WHERE x.pii) as pii_exports,
+			COALESCE(SUM(x.record_count), 0)::bigint as records,
+			COUNT(DISTINCT x.user_id) as users,
+			COUNT(DISTINCT x.tenant_id) FILTER (WHERE x.tenant_id <> 0) as tenants`).
+		Scan(&out).Error
+	return out, err
+}
+
+// ===============================
+// Service
+// ===============================
+
+func (s *reportService) GetExportAuditReport(req ExportAuditReportRequest) ([]ExportAuditReportRow, error) {
+	return s.repo.GetExportAudit(req)
+}
+
+func (s *reportService) SummarizeExportAudit(req ExportAuditReportRequest) (ExportAuditSummary, error) {
+	return s.repo.SummarizeExportAudit(req)
+}
+
+func (s *reportService) ExportExportAuditReport(ctx context.Context, req ExportAuditReportRequest, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
+	rows, err := s.GetExportAuditReport(req)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, userID, nil, "EXPORT_AUDIT_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type": "export_audit",
+			"format":      req.Format,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	bytes, filename, mimeType, err := s.render(ctx, reportType, req.Format, ReportData{ExportAudit: rows})
+	if err != nil {
+		s.auditSvc.LogAction(ctx, userID, nil, "EXPORT_AUDIT_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type": "export_audit",
+			"format":      req.Format,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	s.auditSvc.LogAction(ctx, userID, nil, "EXPORT_AUDIT_REPORT_DOWNLOADED", map[string]interface{}{
+		"report_type":  "export_audit",
+		"format":       req.Format,
+		"filename":     filename,
+		"role":         req.Role,
+		"tenant_id":    req.TenantID,
+		"pii_only":     req.PIIOnly,
+		"date_range":   req.DateRange,
+		"record_count": len(rows),
+	}, ip, "success")
+
+	return bytes, filename, mimeType, nil
+}
+
+// ===============================
+// Exporter
+// ===============================
+
+func (e *reportExporter) exportExportAuditByFormat(format, timestamp string, rows []ExportAuditReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportExportAuditExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("export_audit_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportExportAuditCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("export_audit_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportExportAuditPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("export_audit_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for export audit: %s", format)
+	}
+}
+
+var exportAuditHeaders = []string{"Exported At", "User", "Email", "Role", "Tenant", "Report Type", "Format", "File Name", "Rows", "PII", "Streamed"}
+
+func exportAuditRecord(row ExportAuditReportRow) []string {
+	records := ""
+	if row.RecordCount != nil {
+		records = strconv.FormatInt(*row.RecordCount, 10)
+	}
+	tenant := row.TenantName
+	if row.TenantID == 0 {
+		tenant = "Platform"
+	}
+	return []string{
+		row.ExportedAt.Format("2006-01-02 15:04:05"),
+		row.UserName,
+		row.UserEmail,
+		row.UserRole,
+		tenant,
+		row.ReportType,
+		row.Format,
+		row.FileName,
+		records,
+		yesNo(row.PII),
+		yesNo(row.Streamed),
+	}
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "Yes"
+	}
+	return "No"
+}
+
+func (e *reportExporter) exportExportAuditExcel(rows []ExportAuditReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Export Audit"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range exportAuditHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range exportAuditRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportExportAuditCSV(rows []ExportAuditReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(exportAuditHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(exportAuditRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportExportAuditPDF(rows []ExportAuditReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Export Audit Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 9)
+	widths := []float64{30, 30, 40, 22, 30, 26, 13, 40, 13, 9, 19}
+	for i, header := range exportAuditHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range exportAuditRecord(row) {
+			value = truncateText(value, 30)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ===============================
+// Handler
+// ===============================
+
+// GetExportAuditReport - GET /superadmin/reports/export-audit
+// Lists who exported which report over a period, for compliance reviews.
+// Filters: role, tenant_id, report_type, pii=true; ?format= exports it.
+func (h *Handler) GetExportAuditReport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	if ctx.RoleName != middleware.RoleSuperAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only superadmin can access this endpoint"})
+		return
+	}
+	ip := middleware.GetIPFromContext(c)
+
+	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeExportAudit, format) {
+		return
+	}
+
+	dateRange := c.Query("date_range")
+	if dateRange == "" {
+		dateRange = DateRangeWeekly
+	}
+	start, end, err := GetDateRange(ReportTypeExportAudit, dateRange, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+
+	req := ExportAuditReportRequest{
+		Role:       c.Query("role"),
+		ReportType: strings.TrimSpace(c.Query("report_type")),
+		DateRange:  dateRange,
+		StartDate:  start,
+		EndDate:    end,
+		Format:     format,
+	}
+	if v := c.Query("tenant_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tenant_id"})
+			return
+		}
+		req.TenantID = uint(id)
+	}
+	if v := c.Query("pii"); v != "" {
+		if req.PIIOnly, err = strconv.ParseBool(v); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pii must be true or false"})
+			return
+		}
+	}
+
+	// JSON preview
+	if format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
+		data, err := h.service.GetExportAuditReport(req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &data)
+		summary, err := h.service.SummarizeExportAudit(req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "EXPORT_AUDIT_REPORT_VIEWED", map[string]interface{}{
+			"report_type": "export_audit",
+			"role":        req.Role,
+			"tenant_id":   req.TenantID,
+			"pii_only":    req.PIIOnly,
+			"date_range":  req.DateRange,
+		}, ip, "success")
+		c.JSON(http.StatusOK, gin.H{
+			"pagination":  req.Page.Info(),
+			"report_type": ReportTypeExportAudit,
+			"summary":     summary,
+			"data":        data,
+		})
+		return
+	}
+
+	var reportType string
+	switch format {
+	case FormatExcel:
+		reportType = ReportTypeExportAuditExcel
+	case FormatPDF:
+		reportType = ReportTypeExportAuditPDF
+	case FormatCSV:
+		reportType = ReportTypeExportAuditCSV
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format"})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportExportAuditReport(h.exportContext(c, ctx), req, reportType, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
@@ -0,0 +1,437 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// maxHistoryFileSize is the largest export kept for re-download; bigger
+// files are only delivered, like before
+const maxHistoryFileSize = 50 << 20
+
+// historySaveTimeout bounds storing one export after it was delivered
+const historySaveTimeout = 2 * time.Minute
+
+var ErrExportNotFound = errors.New("export not found")
+
+// ExportRecord is a report file delivered by a synchronous export, kept in
+// storage until ExpiresAt so it can be downloaded again
+type ExportRecord struct {
+	ID         string    `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;index" json:"user_id"`
+	TenantID   uint      `gorm:"default:0;index" json:"tenant_id"` // 0 = platform (superadmin)
+	EntityID   *uint     `gorm:"index" json:"entity_id,omitempty"`
+	Report     string    `gorm:"size:50;not null" json:"report"` // last segment of the route, e.g. devotee-list
+	Format     string    `gorm:"size:10" json:"format"`          // file extension
+	Endpoint   string    `gorm:"size:255" json:"endpoint"`
+	Query      string    `gorm:"type:text" json:"query,omitempty"` // filters of the export
+	FileKey    string    `gorm:"size:255" json:"-"`
+	DataRegion string    `gorm:"size:20;default:''" json:"data_region,omitempty"`
+	FileName   string    `gorm:"size:255" json:"file_name"`
+	MimeType   string    `gorm:"size:100" json:"mime_type"`
+	FileSize   int64     `json:"file_size"`
+	IPAddress  string    `gorm:"size:45" json:"-"`
+	CreatedAt  time.Time `gorm:"index" json:"created_at"`
+	ExpiresAt  time.Time `gorm:"index" json:"expires_at"`
+}
+
+func (ExportRecord) TableName() string {
+	return "report_exports"
+}
+
+// exportScope limits the history to what an access context may see:
+// superadmin everything, temple admins their tenant, others their own exports
+type exportScope struct {
+	all      bool
+	userID   uint
+	tenantID uint
+}
+
+func scopeOf(ctx middleware.AccessContext) exportScope {
+	scope := exportScope{all: ctx.RoleName == middleware.RoleSuperAdmin, userID: ctx.UserID}
+	if ctx.RoleName == middleware.RoleTempleAdmin {
+		scope.tenantID = ctx.TenantID
+	}
+	return scope
+}
+
+func (s exportScope) allows(rec *ExportRecord) bool {
+	return s.all || rec.UserID == s.userID || (s.tenantID != 0 && rec.TenantID == s.tenantID)
+}
+
+// ===============================
+// Repository
+// ===============================
+
+// ExportHistoryRepository persists export records
+type ExportHistoryRepository interface {
+	Create(ctx context.Context, rec *ExportRecord) error
+	GetByID(ctx context.Context, id string) (*ExportRecord, error)
+	List(ctx context.Context, scope exportScope, page *PageRequest) ([]ExportRecord, error)
+	ListExpired(ctx context.Context, now time.Time) ([]ExportRecord, error)
+	ClearFile(ctx context.Context, id string) error
+}
+
+type exportHistoryRepository struct {
+	db *gorm.DB
+}
+
+func NewExportHistoryRepository(db *gorm.DB) ExportHistoryRepository {
+	return &exportHistoryRepository{db: db}
+}
+
+func (r *exportHistoryRepository) Create(ctx context.Context, rec *ExportRecord) error {
+	return r.db.WithContext(ctx).Create(rec).Error
+}
+
+func (r *exportHistoryRepository) GetByID(ctx context.Context, id string) (*ExportRecord, error) {
+	var rec ExportRecord
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&rec).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportNotFound
+	}
+	return &rec, err
+}
+
+func (r *exportHistoryRepository) List(ctx context.Context, scope exportScope, page *PageRequest) ([]ExportRecord, error) {
+	query := r.db.WithContext(ctx).Model(&ExportRecord{})
+	if !scope.all {
+		if scope.tenantID != 0 {
+			query = query.Where("user_id = ? OR tenant_id = ?", scope.userID, scope.tenantID)
+		} else {
+			query = query.Where("user_id = ?", scope.userID)
+		}
+	}
+	var records []ExportRecord
+	query, err := paginate(r.db.WithContext(ctx), query, page, map[string]string{
+		"created_at": "created_at",
+		"report":     "report",
+		"file_size":  "file_size",
+	}, "created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Find(&records).Error
+	return records, err
+}
+
+func (r *exportHistoryRepository) ListExpired(ctx context.Context, now time.Time) ([]ExportRecord, error) {
+	var records []ExportRecord
+	err := r.db.WithContext(ctx).
+		Where("file_key <> '' AND expires_at < ?", now).
+		Find(&records).Error
+	return records, err
+}
+
+func (r *exportHistoryRepository) ClearFile(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&ExportRecord{}).
+		Where("id = ?", id).
+		Update("file_key", "").Error
+}
+
+// ===============================
+// Service
+// ===============================
+
+// ExportHistory keeps the files of synchronous report exports for the
+// retention window and serves them again
+type ExportHistory struct {
+	repo      ExportHistoryRepository
+	store     storage.Storage
+	auditSvc  auditlog.Service
+	retention time.Duration
+}
+
+func NewExportHistory(repo ExportHistoryRepository, store storage.Storage, auditSvc auditlog.Service, retention time.Duration) *ExportHistory {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &ExportHistory{repo: repo, store: store, auditSvc: auditSvc, retention: retention}
+}
+
+// Start launches the janitor removing expired files; it stops when ctx is
+// cancelled
+func (s *ExportHistory) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(janitorInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.sweep(ctx)
+			}
+		}
+	}()
+}
+
+func (s *ExportHistory) sweep(ctx context.Context) {
+	records, err := s.repo.ListExpired(ctx, time.Now())
+	if err != nil {
+		return
+	}
+	for _, rec := range records {
+		if err := s.store.Delete(storage.WithRegion(ctx, rec.DataRegion), rec.FileKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+			log.Printf("⚠️ Export history janitor: delete %s failed: %v", rec.FileKey, err)
+			continue
+		}
+		s.repo.ClearFile(ctx, rec.ID)
+	}
+}
+
+// Record is a middleware for report routes: a successful response sent as an
+// attachment is copied while it streams to the client and stored afterwards.
+// Previews and errors pass through untouched.
+func (s *ExportHistory) Record() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &exportRecorder{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		if !w.capture || w.overflow || w.buf.Len() == 0 {
+			return
+		}
+		accessContext, exists := c.Get("access_context")
+		if !exists {
+			return
+		}
+		ctx := accessContext.(middleware.AccessContext)
+
+		rec := &ExportRecord{
+			ID:        uuid.NewString(),
+			UserID:    ctx.UserID,
+			TenantID:  ctx.TenantID,
+			Report:    path.Base(c.FullPath()),
+			Endpoint:  c.FullPath(),
+			Query:     c.Request.URL.RawQuery,
+			FileName:  attachmentName(w.Header().Get("Content-Disposition")),
+			MimeType:  w.Header().Get("Content-Type"),
+			FileSize:  int64(w.buf.Len()),
+			IPAddress: middleware.GetIPFromContext(c),
+		}
+		if ctx.RoleName == middleware.RoleSuperAdmin {
+			rec.TenantID = 0
+		}
+		rec.Format = strings.TrimPrefix(path.Ext(rec.FileName), ".")
+		if strings.Contains(c.FullPath(), "/entities/:id/") {
+			if id, err := strconv.ParseUint(c.Param("id"), 10, 64); err == nil {
+				entityID := uint(id)
+				rec.EntityID = &entityID
+			}
+		}
+
+		// The client already has the file; storing it must not hold up the request
+		data := w.buf.Bytes()
+		go s.save(rec, data)
+	}
+}
+
+func (s *ExportHistory) save(rec *ExportRecord, data []byte) {
+	ctx, cancel := context.WithTimeout(context.Background(), historySaveTimeout)
+	defer cancel()
+
+	var entityIDs []uint
+	if rec.EntityID != nil {
+		entityIDs = []uint{*rec.EntityID}
+	}
+	region, err := storage.TargetRegion(ctx, s.store, "report export history", entityIDs)
+	if err != nil {
+		log.Printf("⚠️ Export history: %s not kept: %v", rec.FileName, err)
+		return
+	}
+	rec.DataRegion = region
+
+	key, err := storage.Key(storage.ReportsPrefix, "history", rec.ID, rec.FileName)
+	if err == nil {
+		err = s.store.Put(storage.WithRegion(ctx, region), key, bytes.NewReader(data), int64(len(data)), rec.MimeType)
+	}
+	if err != nil {
+		log.Printf("⚠️ Export history: storing %s failed: %v", rec.FileName, err)
+		return
+	}
+	rec.FileKey = key
+	rec.ExpiresAt = time.Now().Add(s.retention)
+	if err := s.repo.Create(ctx, rec); err != nil {
+		log.Printf("⚠️ Export history: saving %s failed: %v", rec.FileName, err)
+		s.store.Delete(storage.WithRegion(ctx, region), key)
+	}
+}
+
+// attachmentName reads the file name of a Content-Disposition header, keeping
+// only its base so it is safe as a storage key segment
+func attachmentName(disposition string) string {
+	name := "report"
+	if _, params, err := mime.ParseMediaType(disposition); err == nil && params["filename"] != "" {
+		name = params["filename"]
+	}
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" || name == ".." {
+		return "report"
+	}
+	return name
+}
+
+// exportRecorder copies a response body while it is written, once the
+// headers show it is a downloaded file
+type exportRecorder struct {
+	gin.ResponseWriter
+	buf      bytes.Buffer
+	checked  bool
+	capture  bool
+	overflow bool
+}
+
+func (w *exportRecorder) Write(p []byte) (int, error) {
+	w.copy(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *exportRecorder) WriteString(s string) (int, error) {
+	w.copy([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *exportRecorder) copy(p []byte) {
+	if !w.checked {
+		w.checked = true
+		w.capture = w.Status() == http.StatusOK &&
+			strings.HasPrefix(strings.ToLower(w.Header().Get("Content-Disposition")), "attachment")
+	}
+	if !w.capture || w.overflow {
+		return
+	}
+	if w.buf.Len()+len(p) > maxHistoryFileSize {
+		w.overflow = true
+		w.buf = bytes.Buffer{}
+		return
+	}
+	w.buf.Write(p)
+}
+
+// ===============================
+// Handlers
+// ===============================
+
+// ListExports - GET /reports/history?page=&limit=&sort_by=&order=
+// Lists past exports visible to the caller, newest first, with a download URL
+// while the file is kept.
+func (s *ExportHistory) ListExports(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	page, err := ParsePageRequest(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	records, err := s.repo.List(c.Request.Context(), scopeOf(ctx), page)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch export history"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(records))
+	for i := range records {
+		out = append(out, exportResponse(&records[i]))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": out, "pagination": page.Info()})
+}
+
+// DownloadExport - GET /reports/history/:id/download
+func (s *ExportHistory) DownloadExport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	rec, err := s.repo.GetByID(c.Request.Context(), c.Param("id"))
+	if err == nil && !scopeOf(ctx).allows(rec) {
+		// Don't reveal exports of other users
+		err = ErrExportNotFound
+	}
+	if errors.Is(err, ErrExportNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "export not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch export"})
+		return
+	}
+	if rec.FileKey == "" {
+		c.JSON(http.StatusGone, gin.H{"error": "export file has expired, please generate it again"})
+		return
+	}
+
+	rc, info, err := s.store.Get(storage.WithRegion(c.Request.Context(), rec.DataRegion), rec.FileKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusGone, gin.H{"error": "export file has expired, please generate it again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open export file"})
+		return
+	}
+	defer rc.Close()
+
+	s.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, rec.EntityID, "REPORT_EXPORT_REDOWNLOADED", map[string]interface{}{
+		"export_id":   rec.ID,
+		"report":      rec.Report,
+		"format":      rec.Format,
+		"filename":    rec.FileName,
+		"exported_by": rec.UserID,
+		"exported_at": rec.CreatedAt,
+	}, middleware.GetIPFromContext(c), "success")
+
+	c.DataFromReader(http.StatusOK, info.Size, rec.MimeType, rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%s", rec.FileName),
+	})
+}
+
+func exportResponse(rec *ExportRecord) gin.H {
+	resp := gin.H{
+		"id":         rec.ID,
+		"user_id":    rec.UserID,
+		"report":     rec.Report,
+		"format":     rec.Format,
+		"endpoint":   rec.Endpoint,
+		"query":      rec.Query,
+		"file_name":  rec.FileName,
+		"file_size":  rec.FileSize,
+		"mime_type":  rec.MimeType,
+		"created_at": rec.CreatedAt,
+		"expires_at": rec.ExpiresAt,
+	}
+	if rec.EntityID != nil {
+		resp["entity_id"] = *rec.EntityID
+	}
+	if rec.FileKey != "" {
+		resp["download_url"] = fmt.Sprintf("/api/v1/reports/history/%s/download", rec.ID)
+	} else {
+		resp["expired"] = true
+	}
+	return resp
+}
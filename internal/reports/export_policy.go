@@ -0,0 +1,91 @@
+package reports
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// anyReportType is the report type key of a rule covering every report
+const anyReportType = "*"
+
+var allExportFormats = []string{FormatCSV, FormatExcel, FormatPDF, FormatTally, FormatLedger}
+
+// allowedExportFormats[role][reportType] lists the formats a role may export a
+// report in. Roles and report types without a rule may use every format.
+var allowedExportFormats = map[string]map[string][]string{}
+
+// SetAllowedExportFormats configures per role export format restrictions,
+// e.g. {"standarduser": {"devotee-list": {"pdf"}, "*": {"pdf", "excel"}}}.
+// A rule with no formats blocks exports of that report for the role.
+func SetAllowedExportFormats(byRole map[string]map[string][]string) {
+	rules := make(map[string]map[string][]string, len(byRole))
+	for role, byReport := range byRole {
+		rules[role] = make(map[string][]string, len(byReport))
+		for reportType, formats := range byReport {
+			normalized := make([]string, 0, len(formats))
+			for _, f := range formats {
+				normalized = append(normalized, strings.ToLower(strings.TrimSpace(f)))
+			}
+			rules[role][reportType] = normalized
+		}
+	}
+	allowedExportFormats = rules
+}
+
+// AllowedExportFormats returns the formats the role may export the report in
+func AllowedExportFormats(role, reportType string) []string {
+	rules, ok := allowedExportFormats[role]
+	if !ok {
+		return allExportFormats
+	}
+	if formats, ok := rules[reportType]; ok {
+		return formats
+	}
+	if formats, ok := rules[anyReportType]; ok {
+		return formats
+	}
+	return allExportFormats
+}
+
+// allowExportFormat responds 403 with the permitted formats when the caller's
+// role may not export the report in format
+func (h *Handler) allowExportFormat(c *gin.Context, ctx middleware.AccessContext, reportType, format string) bool {
+	format = strings.ToLower(strings.TrimSpace(format))
+	known := false
+	for _, f := range allExportFormats {
+		known = known || f == format
+	}
+	if !known {
+		// Previews and unknown formats are left to the handler
+		return true
+	}
+
+	allowed := AllowedExportFormats(ctx.RoleName, reportType)
+	for _, f := range allowed {
+		if f == format {
+			return true
+		}
+	}
+
+	h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "REPORT_EXPORT_FORMAT_DENIED", map[string]interface{}{
+		"report_type": reportType,
+		"format":      format,
+		"role":        ctx.RoleName,
+	}, middleware.GetIPFromContext(c), "failure")
+
+	message := fmt.Sprintf("%s export of the %s report is not permitted for your role", format, reportType)
+	if len(allowed) == 0 {
+		message = fmt.Sprintf("exporting the %s report is not permitted for your role", reportType)
+	}
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":           message,
+		"report_type":     reportType,
+		"format":          format,
+		"allowed_formats": allowed,
+	})
+	return false
+}
@@ -0,0 +1,932 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultExportDateFormat = "yyyy-mm-dd"
+
+var (
+	ErrExportTemplateUnsupported = errors.New("export templates are not supported for this report type")
+	ErrExportTemplateNotFound    = errors.New("export template not found")
+)
+
+// exportDateFormats maps the date format names clients pick from to layouts
+var exportDateFormats = map[string]string{
+	"yyyy-mm-dd":  "2006-01-02",
+	"dd-mm-yyyy":  "02-01-2006",
+	"dd/mm/yyyy":  "02/01/2006",
+	"mm/dd/yyyy":  "01/02/2006",
+	"dd mmm yyyy": "02 Jan 2006",
+}
+
+// ExportTemplate is a tenant's choice of columns, column order and date
+// format for one report type. Exports of the tenant's temples use it for
+// CSV, Excel and PDF alike.
+type ExportTemplate struct {
+	ID          uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantID    uint           `gorm:"not null;uniqueIndex:idx_export_templates_tenant_report" json:"tenant_id"`
+	ReportType  string         `gorm:"size:50;not null;uniqueIndex:idx_export_templates_tenant_report" json:"report_type"`
+	Columns     datatypes.JSON `gorm:"type:jsonb;not null" json:"columns"` // ordered column keys
+	DateFormat  string         `gorm:"size:20;not null" json:"date_format"`
+	IncludeTime bool           `gorm:"default:false" json:"include_time"` // append HH:MM to timestamps
+	UpdatedBy   uint           `json:"updated_by"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+func (ExportTemplate) TableName() string {
+	return "report_export_templates"
+}
+
+// SaveExportTemplateRequest is the body of PUT /reports/export-templates/:type
+type SaveExportTemplateRequest struct {
+	Columns     []string `json:"columns" binding:"required"`
+	DateFormat  string   `json:"date_format"`
+	IncludeTime bool     `json:"include_time"`
+}
+
+// ExportColumn is one column a templated report can include
+type ExportColumn struct {
+	Key    string `json:"key"`
+	Header string `json:"header"`
+}
+
+// exportColumns lists, in default order, the columns of each report type
+// that supports export templates
+var exportColumns = map[string][]ExportColumn{
+	ReportTypeEvents: {
+		{"title", "Title"},
+		{"temple_name", "Temple Name"},
+		{"description", "Description"},
+		{"event_type", "Event Type"},
+		{"event_date", "Event Date"},
+		{"event_time", "Event Time"},
+		{"location", "Location"},
+		{"is_active", "Active"},
+		{"created_at", "Created At"},
+	},
+	ReportTypeSevas: {
+		{"name", "Name"},
+		{"temple_name", "Temple Name"},
+		{"seva_type", "Seva Type"},
+		{"description", "Description"},
+		{"price", "Price"},
+		{"date", "Date"},
+		{"start_time", "Start Time"},
+		{"end_time", "End Time"},
+		{"duration", "Duration"},
+		{"max_bookings_per_day", "Max Bookings Per Day"},
+		{"status", "Status"},
+		{"is_active", "Active"},
+		{"created_at", "Created At"},
+	},
+	ReportTypeBookings: {
+		{"seva_name", "Seva Name"},
+		{"temple_name", "Temple Name"},
+		{"seva_type", "Seva Type"},
+		{"devotee_name", "Devotee Name"},
+		{"devotee_phone", "Devotee Phone"},
+		{"booking_time", "Booking Time"},
+		{"status", "Status"},
+		{"cancelled_at", "Cancelled At"},
+		{"cancellation_reason", "Cancellation Reason"},
+		{"refund_status", "Refund Status"},
+		{"reschedule_count", "Times Rescheduled"},
+		{"checked_in_at", "Checked In At"},
+		{"booking_details", "Booking Details"},
+		{"created_at", "Created At"},
+	},
+	ReportTypeDonations: {
+		{"id", "ID"},
+		{"donor_name", "Donor Name"},
+		{"temple_name", "Temple Name"},
+		{"donor_email", "Donor Email"},
+		{"amount", "Amount"},
+		{"donation_type", "Donation Type"},
+		{"payment_method", "Payment Method"},
+		{"status", "Status"},
+		{"donation_date", "Donation Date"},
+		{"order_id", "Order ID"},
+		{"payment_id", "Payment ID"},
+	},
+	ReportTypeWaitlist: {
+		{"kind", "Type"},
+		{"item_name", "Seva / Event"},
+		{"temple_name", "Temple Name"},
+		{"devotee_name", "Devotee Name"},
+		{"devotee_phone", "Devotee Phone"},
+		{"status", "Status"},
+		{"position", "Position"},
+		{"waiting_since", "Waiting Since"},
+		{"offer_expires_at", "Offer Expires At"},
+	},
+	ReportTypeDisputes: {
+		{"gateway_dispute_id", "Dispute ID"},
+		{"payment_id", "Payment ID"},
+		{"temple_name", "Temple Name"},
+		{"donor_name", "Donor Name"},
+		{"amount", "Amount"},
+		{"amount_deducted", "Amount Deducted"},
+		{"reason_code", "Reason"},
+		{"phase", "Phase"},
+		{"status", "Status"},
+		{"respond_by", "Respond By"},
+		{"responded_at", "Responded At"},
+		{"resolved_at", "Resolved At"},
+		{"evidence_count", "Evidence"},
+		{"created_at", "Raised At"},
+	},
+	ReportTypeCampaigns: {
+		{"title", "Campaign"},
+		{"temple_name", "Temple Name"},
+		{"status", "Status"},
+		{"target_amount", "Target"},
+		{"raised_amount", "Raised"},
+		{"completion_percent", "Completion %"},
+		{"donation_count", "Donations"},
+		{"donor_count", "Donors"},
+		{"start_date", "Start Date"},
+		{"end_date", "End Date"},
+		{"top_donors", "Top Donors"},
+	},
+	ReportTypeRecurringDonations: {
+		{"id", "Pledge ID"},
+		{"donor_name", "Donor Name"},
+		{"donor_phone", "Donor Phone"},
+		{"temple_name", "Temple Name"},
+		{"amount", "Monthly Amount"},
+		{"donation_type", "Donation Type"},
+		{"day_of_month", "Day of Month"},
+		{"status", "Status"},
+		{"next_due_date", "Next Due"},
+		{"paid_count", "Paid"},
+		{"failed_count", "Failed"},
+		{"total_paid", "Total Paid"},
+		{"last_paid_at", "Last Paid At"},
+		{"cancelled_at", "Cancelled At"},
+		{"created_at", "Started At"},
+	},
+	ReportTypeHundiCollection: {
+		{"id", "Count ID"},
+		{"temple_name", "Temple Name"},
+		{"hundi_name", "Hundi"},
+		{"counted_on", "Counted On"},
+		{"denominations", "Denominations"},
+		{"counters", "Counters"},
+		{"counted_total", "Counted Total"},
+		{"declared_total", "Declared Total"},
+		{"discrepancy", "Discrepancy"},
+		{"flagged", "Flagged"},
+		{"flag_reason", "Flag Reason"},
+		{"status", "Status"},
+		{"recorded_by", "Recorded By"},
+		{"reviewed_by", "Reviewed By"},
+		{"reviewed_at", "Reviewed At"},
+		{"review_note", "Review Note"},
+	},
+	ReportTypeCoupons: {
+		{"id", "Coupon ID"},
+		{"code", "Code"},
+		{"temple_name", "Temple Name"},
+		{"discount_type", "Discount Type"},
+		{"value", "Value"},
+		{"applies_to", "Applies To"},
+		{"is_active", "Active"},
+		{"usage_limit", "Usage Limit"},
+		{"valid_from", "Valid From"},
+		{"valid_until", "Valid Until"},
+		{"redemptions", "Redemptions"},
+		{"released", "Released"},
+		{"unique_users", "Devotees"},
+		{"gross_amount", "Gross Amount"},
+		{"discount_total", "Discount"},
+		{"net_amount", "Net Amount"},
+		{"first_redeemed_at", "First Redeemed"},
+		{"last_redeemed_at", "Last Redeemed"},
+		{"deleted_at", "Deleted At"},
+	},
+	ReportTypeOrders: {
+		{"id", "Order ID"},
+		{"temple_name", "Temple Name"},
+		{"buyer_name", "Devotee"},
+		{"buyer_email", "Email"},
+		{"items", "Items"},
+		{"item_count", "Units"},
+		{"total", "Total"},
+		{"fulfilment", "Fulfilment"},
+		{"status", "Status"},
+		{"city", "City"},
+		{"pincode", "Pincode"},
+		{"courier", "Courier"},
+		{"tracking_number", "Tracking Number"},
+		{"payment_id", "Payment ID"},
+		{"paid_at", "Paid At"},
+		{"shipped_at", "Shipped At"},
+		{"delivered_at", "Delivered At"},
+		{"cancelled_at", "Cancelled At"},
+		{"created_at", "Ordered At"},
+	},
+	ReportTypeVolunteers: {
+		{"id", "Volunteer ID"},
+		{"volunteer_name", "Name"},
+		{"volunteer_phone", "Phone"},
+		{"volunteer_email", "Email"},
+		{"temple_name", "Temple Name"},
+		{"skills", "Skills"},
+		{"status", "Status"},
+		{"signups", "Sign-ups"},
+		{"attended", "Attended"},
+		{"no_shows", "No-shows"},
+		{"registered_at", "Registered At"},
+	},
+	ReportTypeRSVPs: {
+		{"id", "RSVP ID"},
+		{"event_title", "Event"},
+		{"temple_name", "Temple Name"},
+		{"event_date", "Event Date"},
+		{"devotee_name", "Devotee Name"},
+		{"devotee_phone", "Devotee Phone"},
+		{"status", "Status"},
+		{"rsvp_date", "RSVP Date"},
+		{"checked_in_at", "Checked In At"},
+		{"attendance", "Attendance"},
+	},
+	ReportTypeDevoteeList: {
+		{"user_id", "User ID"},
+		{"devotee_name", "Devotee Name"},
+		{"temple_name", "Temple Name"},
+		{"joined_at", "Joined At"},
+		{"devotee_status", "Status"},
+		{"created_at", "Created At"},
+	},
+	ReportTypeDevoteeBirthdays: {
+		{"full_name", "Full Name"},
+		{"date_of_birth", "Date of Birth"},
+		{"gender", "Gender"},
+		{"phone", "Phone"},
+		{"email", "Email"},
+		{"temple_name", "Temple Name"},
+		{"member_since", "Member Since"},
+	},
+}
+
+// exportValues returns each row of the report as column key -> value
+func exportValues(reportType string, data ReportData) []map[string]interface{} {
+	var out []map[string]interface{}
+	switch reportType {
+	case ReportTypeEvents:
+		for _, r := range data.Events {
+			out = append(out, map[string]interface{}{
+				"title": r.Title, "temple_name": r.TempleName, "description": r.Description,
+				"event_type": r.EventType, "event_date": r.EventDate, "event_time": r.EventTime,
+				"location": r.Location, "is_active": r.IsActive, "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeSevas:
+		for _, r := range data.Sevas {
+			out = append(out, map[string]interface{}{
+				"name": r.Name, "temple_name": r.TempleName, "seva_type": r.SevaType,
+				"description": r.Description, "price": r.Price, "date": r.Date,
+				"start_time": r.StartTime, "end_time": r.EndTime, "duration": r.Duration,
+				"max_bookings_per_day": r.MaxBookingsPerDay, "status": r.Status,
+				"is_active": r.IsActive, "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeBookings:
+		for _, r := range data.Bookings {
+			out = append(out, map[string]interface{}{
+				"seva_name": r.SevaName, "temple_name": r.TempleName, "seva_type": r.SevaType,
+				"devotee_name": r.DevoteeName, "devotee_phone": r.DevoteePhone,
+				"booking_time": r.BookingTime, "status": r.Status,
+				"cancelled_at": r.CancelledAt, "cancellation_reason": r.CancellationReason,
+				"refund_status": r.RefundStatus, "reschedule_count": r.RescheduleCount,
+				"checked_in_at": r.CheckedInAt, "booking_details": bookingFormDetails(r), "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeDonations:
+		for _, r := range data.Donations {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "donor_name": r.DonorName, "temple_name": r.TempleName,
+				"donor_email": r.DonorEmail, "amount": r.Amount, "donation_type": r.DonationType,
+				"payment_method": r.PaymentMethod, "status": r.Status,
+				"donation_date": r.DonationDate, "order_id": r.OrderID, "payment_id": r.PaymentID,
+			})
+		}
+	case ReportTypeWaitlist:
+		for _, r := range data.Waitlist {
+			out = append(out, map[string]interface{}{
+				"kind": r.Kind, "item_name": r.ItemName, "temple_name": r.TempleName,
+				"devotee_name": r.DevoteeName, "devotee_phone": r.DevoteePhone, "status": r.Status,
+				"position": int(r.Position), "waiting_since": r.WaitingSince,
+				"offer_expires_at": r.OfferExpiresAt,
+			})
+		}
+	case ReportTypeDisputes:
+		for _, r := range data.Disputes {
+			out = append(out, map[string]interface{}{
+				"gateway_dispute_id": r.GatewayDisputeID, "payment_id": r.PaymentID,
+				"temple_name": r.TempleName, "donor_name": r.DonorName, "amount": r.Amount,
+				"amount_deducted": r.AmountDeducted, "reason_code": r.ReasonCode, "phase": r.Phase,
+				"status": r.Status, "respond_by": r.RespondBy, "responded_at": r.RespondedAt,
+				"resolved_at": r.ResolvedAt, "evidence_count": int(r.EvidenceCount), "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeCampaigns:
+		for _, r := range data.Campaigns {
+			out = append(out, map[string]interface{}{
+				"title": r.Title, "temple_name": r.TempleName, "status": r.Status,
+				"target_amount": r.TargetAmount, "raised_amount": r.RaisedAmount,
+				"completion_percent": r.CompletionPercent, "donation_count": int(r.DonationCount),
+				"donor_count": int(r.DonorCount), "start_date": r.StartDate, "end_date": r.EndDate,
+				"top_donors": r.TopDonors,
+			})
+		}
+	case ReportTypeRecurringDonations:
+		for _, r := range data.RecurringDonations {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "donor_name": r.DonorName, "donor_phone": r.DonorPhone,
+				"temple_name": r.TempleName, "amount": r.Amount, "donation_type": r.DonationType,
+				"day_of_month": r.DayOfMonth, "status": r.Status, "next_due_date": r.NextDueDate,
+				"paid_count": int(r.PaidCount), "failed_count": int(r.FailedCount), "total_paid": r.TotalPaid,
+				"last_paid_at": r.LastPaidAt, "cancelled_at": r.CancelledAt, "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeHundiCollection:
+		for _, r := range data.HundiCollection {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "temple_name": r.TempleName, "hundi_name": r.HundiName,
+				"counted_on": r.CountedOn, "denominations": r.Denominations, "counters": r.Counters,
+				"counted_total": r.CountedTotal, "declared_total": r.DeclaredTotal,
+				"discrepancy": r.Discrepancy, "flagged": r.Flagged, "flag_reason": r.FlagReason,
+				"status": r.Status, "recorded_by": r.RecordedBy, "reviewed_by": r.ReviewedBy,
+				"reviewed_at": r.ReviewedAt, "review_note": r.ReviewNote,
+			})
+		}
+	case ReportTypeCoupons:
+		for _, r := range data.Coupons {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "code": r.Code, "temple_name": r.TempleName,
+				"discount_type": r.DiscountType, "value": r.Value, "applies_to": r.AppliesTo,
+				"is_active": r.IsActive, "usage_limit": r.UsageLimit, "valid_from": r.ValidFrom,
+				"valid_until": r.ValidUntil, "redemptions": int(r.Redemptions), "released": int(r.Released),
+				"unique_users": int(r.UniqueUsers), "gross_amount": r.GrossAmount,
+				"discount_total": r.DiscountTotal, "net_amount": r.NetAmount,
+				"first_redeemed_at": r.FirstRedeemedAt, "last_redeemed_at": r.LastRedeemedAt,
+				"deleted_at": r.DeletedAt,
+			})
+		}
+	case ReportTypeOrders:
+		for _, r := range data.Orders {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "temple_name": r.TempleName, "buyer_name": r.BuyerName,
+				"buyer_email": r.BuyerEmail, "items": r.Items, "item_count": int(r.ItemCount),
+				"total": r.Total, "fulfilment": r.Fulfilment, "status": r.Status, "city": r.City,
+				"pincode": r.Pincode, "courier": r.Courier, "tracking_number": r.TrackingNumber,
+				"payment_id": r.PaymentID, "paid_at": r.PaidAt, "shipped_at": r.ShippedAt,
+				"delivered_at": r.DeliveredAt, "cancelled_at": r.CancelledAt, "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeVolunteers:
+		for _, r := range data.Volunteers {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "volunteer_name": r.VolunteerName, "volunteer_phone": r.VolunteerPhone,
+				"volunteer_email": r.VolunteerEmail, "temple_name": r.TempleName, "skills": r.Skills,
+				"status": r.Status, "signups": int(r.Signups), "attended": int(r.Attended),
+				"no_shows": int(r.NoShows), "registered_at": r.RegisteredAt,
+			})
+		}
+	case ReportTypeRSVPs:
+		for _, r := range data.RSVPs {
+			out = append(out, map[string]interface{}{
+				"id": int(r.ID), "event_title": r.EventTitle, "temple_name": r.TempleName,
+				"event_date": r.EventDate, "devotee_name": r.DevoteeName, "devotee_phone": r.DevoteePhone,
+				"status": r.Status, "rsvp_date": r.RSVPDate, "checked_in_at": r.CheckedInAt,
+				"attendance": r.Attendance,
+			})
+		}
+	case ReportTypeDevoteeList:
+		for _, r := range data.DevoteeList {
+			out = append(out, map[string]interface{}{
+				"user_id": r.UserID, "devotee_name": r.DevoteeName, "temple_name": r.TempleName,
+				"joined_at": r.JoinedAt, "devotee_status": r.DevoteeStatus, "created_at": r.CreatedAt,
+			})
+		}
+	case ReportTypeDevoteeBirthdays:
+		for _, r := range data.DevoteeBirthdays {
+			out = append(out, map[string]interface{}{
+				"full_name": r.FullName, "date_of_birth": r.DateOfBirth, "gender": r.Gender,
+				"phone": r.Phone, "email": r.Email, "temple_name": r.TempleName,
+				"member_since": r.MemberSince,
+			})
+		}
+	}
+	return out
+}
+
+// formatExportValue renders a cell; dates use the template's layout
+func formatExportValue(v interface{}, layout string) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case *string:
+		if val == nil {
+			return ""
+		}
+		return *val
+	case time.Time:
+		if val.IsZero() {
+			return ""
+		}
+		return val.Format(layout)
+	case *time.Time:
+		if val == nil || val.IsZero() {
+			return ""
+		}
+		return val.Format(layout)
+	case float64:
+		return strconv.FormatFloat(val, 'f', 2, 64)
+	case int:
+		return strconv.Itoa(val)
+	case bool:
+		if val {
+			return "Yes"
+		}
+		return "No"
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// ColumnKeys decodes the template's ordered column keys
+func (t *ExportTemplate) ColumnKeys() ([]string, error) {
+	var keys []string
+	if err := json.Unmarshal(t.Columns, &keys); err != nil {
+		return nil, fmt.Errorf("invalid export template columns: %w", err)
+	}
+	return keys, nil
+}
+
+// dateLayout returns the Go time layout the template formats dates with
+func (t *ExportTemplate) dateLayout() string {
+	layout, ok := exportDateFormats[t.DateFormat]
+	if !ok {
+		layout = exportDateFormats[defaultExportDateFormat]
+	}
+	if t.IncludeTime {
+		layout += " 15:04"
+	}
+	return layout
+}
+
+// templateReportType splits report types such as "devotee-list-pdf" into the
+// base report type and the format
+func templateReportType(reportType, format string) (string, string) {
+	for _, f := range []string{FormatCSV, FormatExcel, FormatPDF} {
+		if base := strings.TrimSuffix(reportType, "-"+f); base != reportType {
+			return base, f
+		}
+	}
+	if reportType == ReportTypeDevoteeBirthdays {
+		// the bare birthdays report type has always been CSV
+		return reportType, FormatCSV
+	}
+	return reportType, format
+}
+
+// =========================== REPOSITORY ===========================
+
+func (r *repository) GetExportTemplate(tenantID uint, reportType string) (*ExportTemplate, error) {
+	var tmpl ExportTemplate
+	err := r.db.Where("tenant_id = ? AND report_type = ?", tenantID, reportType).First(&tmpl).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tmpl, nil
+}
+
+func (r *repository) ListExportTemplates(tenantID uint) ([]ExportTemplate, error) {
+	var out []ExportTemplate
+	err := r.db.Where("tenant_id = ?", tenantID).Order("report_type ASC").Find(&out).Error
+	return out, err
+}
+
+// SaveExportTemplate creates the template or replaces the tenant's existing
+// one for the report type
+func (r *repository) SaveExportTemplate(tmpl *ExportTemplate) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "report_type"}},
+		DoUpdates: clause.AssignmentColumns([]string{"columns", "date_format", "include_time", "updated_by", "updated_at"}),
+	}).Create(tmpl).Error
+}
+
+func (r *repository) DeleteExportTemplate(tenantID uint, reportType string) error {
+	res := r.db.Where("tenant_id = ? AND report_type = ?", tenantID, reportType).Delete(&ExportTemplate{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetTenantIDsForEntities returns the distinct tenants owning the entities
+func (r *repository) GetTenantIDsForEntities(entityIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(entityIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Table("entities").
+		Distinct("created_by").
+		Where("id IN ?", entityIDs).
+		Scan(&ids).Error
+	return ids, err
+}
+
+// =========================== SERVICE ===========================
+
+func (s *reportService) ListExportTemplates(tenantID uint) ([]ExportTemplate, error) {
+	return s.repo.ListExportTemplates(tenantID)
+}
+
+func (s *reportService) SaveExportTemplate(ctx context.Context, tenantID uint, reportType string, req SaveExportTemplateRequest, userID *uint, ip string) (*ExportTemplate, error) {
+	available, ok := exportColumns[reportType]
+	if !ok {
+		return nil, ErrExportTemplateUnsupported
+	}
+	if len(req.Columns) == 0 {
+		return nil, errors.New("columns must list at least one column")
+	}
+
+	known := make(map[string]bool, len(available))
+	for _, col := range available {
+		known[col.Key] = true
+	}
+	seen := make(map[string]bool, len(req.Columns))
+	columns := make([]string, 0, len(req.Columns))
+	for _, key := range req.Columns {
+		key = strings.TrimSpace(key)
+		if !known[key] {
+			return nil, fmt.Errorf("unknown column %q for %s report", key, reportType)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("column %q listed twice", key)
+		}
+		seen[key] = true
+		columns = append(columns, key)
+	}
+
+	dateFormat := strings.ToLower(strings.TrimSpace(req.DateFormat))
+	if dateFormat == "" {
+		dateFormat = defaultExportDateFormat
+	}
+	if _, ok := exportDateFormats[dateFormat]; !ok {
+		return nil, fmt.Errorf("unsupported date_format %q", req.DateFormat)
+	}
+
+	raw, err := json.Marshal(columns)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &ExportTemplate{
+		TenantID:    tenantID,
+		ReportType:  reportType,
+		Columns:     datatypes.JSON(raw),
+		DateFormat:  dateFormat,
+		IncludeTime: req.IncludeTime,
+	}
+	if userID != nil {
+		tmpl.UpdatedBy = *userID
+	}
+	if err := s.repo.SaveExportTemplate(tmpl); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, userID, nil, "REPORT_EXPORT_TEMPLATE_SAVED", map[string]interface{}{
+		"tenant_id":   tenantID,
+		"report_type": reportType,
+		"columns":     columns,
+		"date_format": dateFormat,
+	}, ip, "success")
+
+	return s.repo.GetExportTemplate(tenantID, reportType)
+}
+
+func (s *reportService) DeleteExportTemplate(ctx context.Context, tenantID uint, reportType string, userID *uint, ip string) error {
+	if err := s.repo.DeleteExportTemplate(tenantID, reportType); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrExportTemplateNotFound
+		}
+		return err
+	}
+
+	s.auditSvc.LogAction(ctx, userID, nil, "REPORT_EXPORT_TEMPLATE_DELETED", map[string]interface{}{
+		"tenant_id":   tenantID,
+		"report_type": reportType,
+	}, ip, "success")
+	return nil
+}
+
+// export renders the report with the owning tenant's export template when
+// one exists, otherwise with the fixed layout. Templates only apply when all
+// exported temples belong to the same tenant. Reports requested in another
+// language than English use the default template so their headers can be
+// translated; the fixed layouts stay English. Donations exported as tally
+// or ledger become vouchers posted to the tenant's ledgers.
+func (s *reportService) export(ctx context.Context, entityIDs []string, reportType, format string, data ReportData) ([]byte, string, string, error) {
+	maskForViewer(ctx, &data)
+	if isAccountingFormat(format) {
+		if reportType != ReportTypeDonations {
+			return nil, "", "", ErrAccountingFormatUnsupported
+		}
+		return s.exportVouchers(reportType, format, convertUintSlice(entityIDs), donationTransactions(data.Donations))
+	}
+	lang := i18n.FromContext(ctx)
+	base, baseFormat := templateReportType(reportType, format)
+	// Grouped donations have their own Excel and PDF layout of sections and
+	// subtotals
+	grouped := data.DonationGroups != nil && baseFormat != FormatCSV
+	if _, ok := exportColumns[base]; ok && !grouped {
+		tenantIDs, err := s.repo.GetTenantIDsForEntities(convertUintSlice(entityIDs))
+		if err == nil && len(tenantIDs) == 1 {
+			tmpl, err := s.repo.GetExportTemplate(tenantIDs[0], base)
+			if err == nil {
+				return s.exporter.ExportWithTemplate(tmpl, base, baseFormat, lang, data)
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, "", "", err
+			}
+		}
+		if lang != i18n.English {
+			return s.exporter.ExportWithTemplate(defaultExportTemplate(base), base, baseFormat, lang, data)
+		}
+	}
+	return s.exporter.Export(reportType, format, data)
+}
+
+// render exports a report with its fixed layout, masked for the viewer
+func (s *reportService) render(ctx context.Context, reportType, format string, data ReportData) ([]byte, string, string, error) {
+	maskForViewer(ctx, &data)
+	return s.exporter.Export(reportType, format, data)
+}
+
+// defaultExportTemplate includes every column of the report type in its
+// default order
+func defaultExportTemplate(reportType string) *ExportTemplate {
+	keys := make([]string, 0, len(exportColumns[reportType]))
+	for _, col := range exportColumns[reportType] {
+		keys = append(keys, col.Key)
+	}
+	raw, _ := json.Marshal(keys)
+	return &ExportTemplate{ReportType: reportType, Columns: datatypes.JSON(raw), DateFormat: defaultExportDateFormat}
+}
+
+// =========================== EXPORTER ===========================
+
+// ExportWithTemplate exports the report with the template's columns, column
+// order and date format, with headers in lang. PDFs fall back to English
+// headers when no font for lang's script is installed.
+func (e *reportExporter) ExportWithTemplate(tmpl *ExportTemplate, reportType, format, lang string, data ReportData) ([]byte, string, string, error) {
+	keys, err := tmpl.ColumnKeys()
+	if err != nil {
+		return nil, "", "", err
+	}
+	if format == FormatPDF && !i18n.CanRenderPDF(lang) {
+		lang = i18n.English
+	}
+	headerByKey := make(map[string]string)
+	for _, col := range exportColumns[reportType] {
+		headerByKey[col.Key] = col.Header
+	}
+
+	headers := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if header, ok := headerByKey[key]; ok {
+			headers = append(headers, i18n.T(lang, header))
+		} else {
+			headers = append(headers, key)
+		}
+	}
+
+	layout := tmpl.dateLayout()
+	values := exportValues(reportType, data)
+	records := make([][]string, 0, len(values))
+	for _, row := range values {
+		record := make([]string, len(keys))
+		for i, key := range keys {
+			record[i] = formatExportValue(row[key], layout)
+		}
+		records = append(records, record)
+	}
+
+	name := strings.ReplaceAll(reportType, "-", "_")
+	timestamp := time.Now().Format("20060102_150405")
+
+	switch format {
+	case FormatExcel:
+		out, err := templatedExcel(name, headers, records)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return out, fmt.Sprintf("%s_report_%s.xlsx", name, timestamp), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	case FormatCSV:
+		out, err := templatedCSV(headers, records)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return out, fmt.Sprintf("%s_report_%s.csv", name, timestamp), "text/csv", nil
+	case FormatPDF:
+		words := strings.Fields(strings.ReplaceAll(name, "_", " "))
+		for i, w := range words {
+			words[i] = strings.ToUpper(w[:1]) + w[1:]
+		}
+		title := i18n.T(lang, strings.Join(words, " ")+" Report")
+		out, err := templatedPDF(title, headers, records)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return out, fmt.Sprintf("%s_report_%s.pdf", name, timestamp), "application/pdf", nil
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for %s: %s", reportType, format)
+	}
+}
+
+func templatedExcel(sheet string, headers []string, records [][]string) ([]byte, error) {
+	f := excelize.NewFile()
+	if len(sheet) > 31 {
+		sheet = sheet[:31]
+	}
+	f.SetSheetName("Sheet1", sheet)
+
+	for i, header := range headers {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(sheet, cell, header)
+	}
+	for r, record := range records {
+		for c, value := range record {
+			cell, _ := excelize.CoordinatesToCellName(c+1, r+2)
+			f.SetCellValue(sheet, cell, value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func templatedCSV(headers []string, records [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	if err := writer.Write(headers); err != nil {
+		return nil, err
+	}
+	if err := writer.WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func templatedPDF(title string, headers []string, records [][]string) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, title)
+	pdf.Ln(20)
+
+	// Share the printable width of a landscape A4 page between the columns
+	width := 277.0 / float64(len(headers))
+	maxChars := int(width / 1.6)
+
+	pdf.SetFont("Arial", "B", 9)
+	for _, header := range headers {
+		pdf.CellFormat(width, 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 8)
+	for _, record := range records {
+		for _, value := range record {
+			value = truncateText(value, maxChars)
+			pdf.CellFormat(width, 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// =========================== HANDLER ===========================
+
+// templeAdminTenant returns the tenant whose export templates and ledger
+// mapping the caller manages; temple admins are their own tenant
+func templeAdminTenant(c *gin.Context) (middleware.AccessContext, bool) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return middleware.AccessContext{}, false
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	if ctx.RoleName != middleware.RoleTempleAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "only temple admins can manage export settings"})
+		return ctx, false
+	}
+	return ctx, true
+}
+
+// GetExportTemplateColumns - GET /reports/export-templates/columns
+// Lists the columns and date formats templates can use, per report type.
+func (h *Handler) GetExportTemplateColumns(c *gin.Context) {
+	formats := make([]string, 0, len(exportDateFormats))
+	for name := range exportDateFormats {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+
+	c.JSON(http.StatusOK, gin.H{
+		"columns":      exportColumns,
+		"date_formats": formats,
+	})
+}
+
+// ListExportTemplates - GET /reports/export-templates
+func (h *Handler) ListExportTemplates(c *gin.Context) {
+	ctx, ok := templeAdminTenant(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.service.ListExportTemplates(ctx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch export templates"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": templates, "total": len(templates)})
+}
+
+// SaveExportTemplate - PUT /reports/export-templates/:type
+func (h *Handler) SaveExportTemplate(c *gin.Context) {
+	ctx, ok := templeAdminTenant(c)
+	if !ok {
+		return
+	}
+
+	var req SaveExportTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	tmpl, err := h.service.SaveExportTemplate(c.Request.Context(), ctx.UserID, c.Param("type"), req, &ctx.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tmpl)
+}
+
+// DeleteExportTemplate - DELETE /reports/export-templates/:type
+// Exports of the report type go back to the default columns.
+func (h *Handler) DeleteExportTemplate(c *gin.Context) {
+	ctx, ok := templeAdminTenant(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteExportTemplate(c.Request.Context(), ctx.UserID, c.Param("type"), &ctx.UserID, middleware.GetIPFromContext(c))
+	if errors.Is(err, ErrExportTemplateNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete export template"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Export template deleted"})
+}
@@ -3,28 +3,72 @@ package reports
 import (
 	"bytes"
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
-	"github.com/jung-kurt/gofpdf"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
 	"github.com/xuri/excelize/v2"
 )
 
 // ReportExporter defines the interface for exporting reports in different formats
 type ReportExporter interface {
 	Export(reportType, format string, data ReportData) ([]byte, string, string, error)
+	ExportWithTemplate(tmpl *ExportTemplate, reportType, format, lang string, data ReportData) ([]byte, string, string, error)
 }
 
 type reportExporter struct{}
 
 func NewReportExporter() ReportExporter {
-	return &reportExporter{}
+	return &timedExporter{next: &reportExporter{}}
+}
+
+// timedExporter records how long each export takes to render
+type timedExporter struct {
+	next ReportExporter
+}
+
+func (t *timedExporter) Export(reportType, format string, data ReportData) ([]byte, string, string, error) {
+	start := time.Now()
+	out, filename, mimeType, err := t.next.Export(reportType, format, data)
+	metrics.ObserveSince(metrics.ReportExportDuration, start, reportType, format, exportStatus(err))
+	return out, filename, mimeType, err
+}
+
+func (t *timedExporter) ExportWithTemplate(tmpl *ExportTemplate, reportType, format, lang string, data ReportData) ([]byte, string, string, error) {
+	start := time.Now()
+	out, filename, mimeType, err := t.next.ExportWithTemplate(tmpl, reportType, format, lang, data)
+	metrics.ObserveSince(metrics.ReportExportDuration, start, reportType, format, exportStatus(err))
+	return out, filename, mimeType, err
+}
+
+func exportStatus(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
+
+// truncateText shortens text longer than max characters for a PDF cell,
+// counting runes so names in Indic scripts aren't cut mid-character
+func truncateText(text string, max int) string {
+	runes := []rune(text)
+	if len(runes) <= max || max <= 3 {
+		return text
+	}
+	return string(runes[:max-3]) + "..."
 }
 
 // Export method calling corrected methods
 func (e *reportExporter) Export(reportType, format string, data ReportData) ([]byte, string, string, error) {
 	timestamp := time.Now().Format("20060102_150405")
+	if isAccountingFormat(format) {
+		return e.exportVouchersByFormat(reportType, format, timestamp, data.Vouchers)
+	}
 
 	switch reportType {
 	case ReportTypeEvents:
@@ -37,7 +81,33 @@ func (e *reportExporter) Export(reportType, format string, data ReportData) ([]b
 		return e.exportBookingsByFormat(format, timestamp, data.Bookings)
 
 	case ReportTypeDonations:
-		return e.exportDonationsByFormat(format, timestamp, data.Donations)
+		return e.exportDonationsByFormat(format, timestamp, data.Donations, data.DonationGroups)
+
+	case ReportTypeWaitlist:
+		return e.exportWaitlistByFormat(format, timestamp, data.Waitlist)
+
+	case ReportTypeDisputes:
+		return e.exportDisputesByFormat(format, timestamp, data.Disputes)
+
+	case ReportTypeCampaigns:
+		return e.exportCampaignsByFormat(format, timestamp, data.Campaigns)
+
+	case ReportTypeRecurringDonations:
+		return e.exportRecurringDonationsByFormat(format, timestamp, data.RecurringDonations)
+
+	case ReportTypeHundiCollection:
+		return e.exportHundiCollectionsByFormat(format, timestamp, data.HundiCollection)
+
+	case ReportTypeCoupons:
+		return e.exportCouponsByFormat(format, timestamp, data.Coupons)
+	case ReportTypeOrders:
+		return e.exportOrdersByFormat(format, timestamp, data.Orders)
+
+	case ReportTypeVolunteers:
+		return e.exportVolunteersByFormat(format, timestamp, data.Volunteers)
+
+	case ReportTypeRSVPs:
+		return e.exportRSVPsByFormat(format, timestamp, data.RSVPs)
 
 	case ReportTypeTempleRegistered:
 		return e.exportTemplesRegistered(data.TemplesRegistered)
@@ -100,6 +170,30 @@ func (e *reportExporter) Export(reportType, format string, data ReportData) ([]b
 	case ReportTypeUserDetailsPDF:
 		return e.exportUserDetailsByFormat(FormatPDF, data.UserDetails)
 
+	case ReportTypeExportAudit:
+		return e.exportExportAuditByFormat(format, timestamp, data.ExportAudit)
+	case ReportTypeExportAuditCSV:
+		return e.exportExportAuditByFormat(FormatCSV, timestamp, data.ExportAudit)
+	case ReportTypeExportAuditExcel:
+		return e.exportExportAuditByFormat(FormatExcel, timestamp, data.ExportAudit)
+	case ReportTypeExportAuditPDF:
+		return e.exportExportAuditByFormat(FormatPDF, timestamp, data.ExportAudit)
+
+	case ReportTypeIncomeExpense:
+		return e.exportIncomeExpenseByFormat(format, timestamp, data.IncomeExpense)
+
+	case ReportTypeFinancialYear:
+		return e.exportFinancialYearByFormat(format, timestamp, data.FinancialYear)
+
+	case ReportTypeDonorAnalytics:
+		return e.exportDonorAnalyticsByFormat(format, timestamp, data.DonorAnalytics)
+
+	case ReportTypeInventoryValuation:
+		return e.exportInventoryValuationByFormat(format, timestamp, data.InventoryValuation)
+
+	case ReportTypeFamilies:
+		return e.exportFamiliesByFormat(format, timestamp, data.Families)
+
 	default:
 		return nil, "", "", fmt.Errorf("unsupported report type: %s", reportType)
 	}
@@ -141,34 +235,39 @@ func (e *reportExporter) exportAuditLogsByFormat(format, timestamp string, logs
 	}
 }
 
+var auditLogCSVHeaders = []string{"ID", "Entity", "User ID", "User Name", "User Role", "Action", "Status", "IP Address", "Timestamp", "Details"}
+
+// auditLogCSVRecord formats one audit log row; shared with streamed exports
+func auditLogCSVRecord(log AuditLogReportRow) []string {
+	userID := ""
+	if log.UserID != nil {
+		userID = strconv.FormatUint(uint64(*log.UserID), 10)
+	}
+
+	return []string{
+		strconv.FormatUint(uint64(log.ID), 10),
+		log.EntityName,
+		userID,
+		log.UserName,
+		log.UserRole,
+		log.Action,
+		log.Status,
+		log.IPAddress,
+		log.Timestamp.Format("2006-01-02 15:04:05"),
+		log.Details,
+	}
+}
+
 func (e *reportExporter) exportAuditLogsCSV(logs []AuditLogReportRow) ([]byte, error) {
 	var buf bytes.Buffer
 	writer := csv.NewWriter(&buf)
 
-	headers := []string{"ID", "Entity", "User ID", "User Name", "User Role", "Action", "Status", "IP Address", "Timestamp", "Details"}
-	if err := writer.Write(headers); err != nil {
+	if err := writer.Write(auditLogCSVHeaders); err != nil {
 		return nil, err
 	}
 
 	for _, log := range logs {
-		userID := ""
-		if log.UserID != nil {
-			userID = strconv.FormatUint(uint64(*log.UserID), 10)
-		}
-
-		record := []string{
-			strconv.FormatUint(uint64(log.ID), 10),
-			log.EntityName,
-			userID,
-			log.UserName,
-			log.UserRole,
-			log.Action,
-			log.Status,
-			log.IPAddress,
-			log.Timestamp.Format("2006-01-02 15:04:05"),
-			log.Details,
-		}
-		if err := writer.Write(record); err != nil {
+		if err := writer.Write(auditLogCSVRecord(log)); err != nil {
 			return nil, err
 		}
 	}
@@ -249,7 +348,7 @@ func (e *reportExporter) exportTemplesRegisteredExcel(rows []TempleRegisteredRep
 
 // exportTemplesRegisteredPDF exports temples registered as PDF.
 func (e *reportExporter) exportTemplesRegisteredPDF(rows []TempleRegisteredReportRow) ([]byte, string, string, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf := i18n.NewPDF("P")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(40, 10, "Temples Registered Report")
@@ -357,7 +456,7 @@ func (e *reportExporter) exportDevoteeBirthdaysExcel(rows []DevoteeBirthdayRepor
 
 // exportDevoteeBirthdaysPDF exports devotee birthdays as PDF.
 func (e *reportExporter) exportDevoteeBirthdaysPDF(rows []DevoteeBirthdayReportRow) ([]byte, string, string, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(40, 10, "Devotee Birthdays Report")
@@ -394,11 +493,18 @@ func (e *reportExporter) exportDevoteeBirthdaysPDF(rows []DevoteeBirthdayReportR
 	return buf.Bytes(), "devotee_birthdays_report.pdf", "application/pdf", nil
 }
 
-// Export Donations by format
-func (e *reportExporter) exportDonationsByFormat(format, timestamp string, donations []DonationReportRow) ([]byte, string, string, error) {
+// Export Donations by format. Grouped donations get sections with subtotals in
+// Excel and PDF; CSV stays one row per donation.
+func (e *reportExporter) exportDonationsByFormat(format, timestamp string, donations []DonationReportRow, groups *DonationGroupSummary) ([]byte, string, string, error) {
 	switch format {
 	case FormatExcel:
-		data, err := e.exportDonationsExcel(donations)
+		var data []byte
+		var err error
+		if groups != nil {
+			data, err = e.exportGroupedDonationsExcel(groups)
+		} else {
+			data, err = e.exportDonationsExcel(donations)
+		}
 		if err != nil {
 			return nil, "", "", err
 		}
@@ -414,7 +520,13 @@ func (e *reportExporter) exportDonationsByFormat(format, timestamp string, donat
 		return data, filename, "text/csv", nil
 
 	case FormatPDF:
-		data, err := e.exportDonationsPDF(donations)
+		var data []byte
+		var err error
+		if groups != nil {
+			data, err = e.exportGroupedDonationsPDF(groups)
+		} else {
+			data, err = e.exportDonationsPDF(donations)
+		}
 		if err != nil {
 			return nil, "", "", err
 		}
@@ -513,7 +625,7 @@ func (e *reportExporter) exportDonationsCSV(donations []DonationReportRow) ([]by
 }
 
 func (e *reportExporter) exportDonationsPDF(donations []DonationReportRow) ([]byte, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "Donations Report")
@@ -657,7 +769,7 @@ func (e *reportExporter) exportDevoteeProfileExcel(rows []DevoteeProfileReportRo
 
 // Devotee Profile PDF export
 func (e *reportExporter) exportDevoteeProfilePDF(rows []DevoteeProfileReportRow) ([]byte, string, string, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "") // Landscape for more columns
+	pdf := i18n.NewPDF("L") // Landscape for more columns
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(40, 10, "Devotee Profile Report")
@@ -682,9 +794,7 @@ func (e *reportExporter) exportDevoteeProfilePDF(rows []DevoteeProfileReportRow)
 
 		// Truncate address if too long for PDF cell
 		address := r.FullAddress
-		if len(address) > 30 {
-			address = address[:27] + "..."
-		}
+		address = truncateText(address, 30)
 		pdf.CellFormat(widths[5], 6, address, "1", 0, "L", false, 0, "")
 
 		pdf.CellFormat(widths[6], 6, r.Gotra, "1", 0, "C", false, 0, "")
@@ -704,7 +814,7 @@ func (e *reportExporter) exportDevoteeProfilePDF(rows []DevoteeProfileReportRow)
 
 // Devotee Profile PDF export with extended fields (including Temple Name)
 func (e *reportExporter) exportDevoteeProfilePDF_ext(rows []DevoteeProfileReportRow_ext) ([]byte, string, string, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "") // Landscape for more columns
+	pdf := i18n.NewPDF("L") // Landscape for more columns
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(40, 10, "Devotee Profile Report")
@@ -729,9 +839,7 @@ func (e *reportExporter) exportDevoteeProfilePDF_ext(rows []DevoteeProfileReport
 
 		// Truncate address if too long for PDF cell
 		address := r.FullAddress
-		if len(address) > 30 {
-			address = address[:27] + "..."
-		}
+		address = truncateText(address, 30)
 		pdf.CellFormat(widths[5], 6, address, "1", 0, "L", false, 0, "")
 
 		pdf.CellFormat(widths[6], 6, r.Gotra, "1", 0, "C", false, 0, "")
@@ -749,25 +857,30 @@ func (e *reportExporter) exportDevoteeProfilePDF_ext(rows []DevoteeProfileReport
 	return buf.Bytes(), "devotee_profile_report.pdf", "application/pdf", nil
 }
 // Devotee List CSV export
+var devoteeListHeaders = []string{"User ID", "Devotee Name", "Temple Name", "Devotee Status", "Joined At", "Created At"}
+
+// devoteeListRecord formats one devotee list row; shared with streamed exports
+func devoteeListRecord(r DevoteeListReportRow) []string {
+	return []string{
+		r.UserID,
+		r.DevoteeName,
+		r.TempleName,
+		r.DevoteeStatus,
+		r.JoinedAt.Format("2006-01-02 15:04:05"),
+		r.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
 func (e *reportExporter) exportDevoteeListCSV(rows []DevoteeListReportRow) ([]byte, string, string, error) {
 	buf := &bytes.Buffer{}
 	w := csv.NewWriter(buf)
 
-	headers := []string{"User ID", "Devotee Name", "Temple Name", "Devotee Status", "Joined At", "Created At"}
-	if err := w.Write(headers); err != nil {
+	if err := w.Write(devoteeListHeaders); err != nil {
 		return nil, "", "", err
 	}
 
 	for _, r := range rows {
-		record := []string{
-			r.UserID,
-			r.DevoteeName,
-			r.TempleName,
-			r.DevoteeStatus,
-			r.JoinedAt.Format("2006-01-02 15:04:05"),
-			r.CreatedAt.Format("2006-01-02 15:04:05"),
-		}
-		if err := w.Write(record); err != nil {
+		if err := w.Write(devoteeListRecord(r)); err != nil {
 			return nil, "", "", err
 		}
 	}
@@ -817,7 +930,7 @@ func (e *reportExporter) exportDevoteeListExcel(rows []DevoteeListReportRow) ([]
 
 // Devotee List PDF export
 func (e *reportExporter) exportDevoteeListPDF(rows []DevoteeListReportRow) ([]byte, string, string, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf := i18n.NewPDF("P")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 12)
 	pdf.Cell(40, 10, "Devotee List Report")
@@ -852,7 +965,7 @@ func (e *reportExporter) exportDevoteeListPDF(rows []DevoteeListReportRow) ([]by
 }
 
 func (e *reportExporter) exportAuditLogsPDF(logs []AuditLogReportRow) ([]byte, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "Audit Logs Report")
@@ -1078,7 +1191,7 @@ func (e *reportExporter) exportApprovalStatusExcel(rows []ApprovalStatusReportRo
 
 // exportApprovalStatusPDF exports approval status report to PDF with all fields
 func (e *reportExporter) exportApprovalStatusPDF(rows []ApprovalStatusReportRow) ([]byte, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "Approval Status Report")
@@ -1134,9 +1247,7 @@ case 1, 3: // Tenant Name or Entity Name
 				maxLen = 35
 			}
 			
-			if len(v) > maxLen {
-				v = v[:maxLen-3] + "..."
-			}
+			v = truncateText(v, maxLen)
 			
 			pdf.CellFormat(widths[i], 6, v, "1", 0, "L", false, 0, "")
 		}
@@ -1248,7 +1359,7 @@ func (e *reportExporter) exportUserDetailsExcel(rows []UserDetailsReportRow) ([]
 }
 
 func (e *reportExporter) exportUserDetailsPDF(rows []UserDetailsReportRow) ([]byte, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "User Details Report")
@@ -1459,7 +1570,7 @@ func (e *reportExporter) exportEventsCSV(events []EventReportRow) ([]byte, error
 }
 
 func (e *reportExporter) exportEventsPDF(events []EventReportRow) ([]byte, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "Events Report")
@@ -1576,7 +1687,7 @@ func (e *reportExporter) exportSevasCSV(sevas []SevaReportRow) ([]byte, error) {
 
 func (e *reportExporter) exportSevasPDF(sevas []SevaReportRow) ([]byte, error) {
 	fmt.Println("Sevas:-", sevas)
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "Sevas Report")
@@ -1621,7 +1732,7 @@ func (e *reportExporter) exportBookingsExcel(bookings []SevaBookingReportRow) ([
 	f.SetSheetName("Sheet1", sheetName)
 
 	// UPDATED with Temple Name
-	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At"}
+	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At", "Booking Details", "Cancelled At", "Cancellation Reason", "Refund Status", "Times Rescheduled", "Checked In At"}
 	for i, header := range headers {
 		cell := fmt.Sprintf("%c1", 'A'+i)
 		f.SetCellValue(sheetName, cell, header)
@@ -1638,6 +1749,12 @@ func (e *reportExporter) exportBookingsExcel(bookings []SevaBookingReportRow) ([
 		f.SetCellValue(sheetName, fmt.Sprintf("G%d", row), booking.Status)
 		f.SetCellValue(sheetName, fmt.Sprintf("H%d", row), booking.CreatedAt.Format("2006-01-02 15:04:05"))
 		f.SetCellValue(sheetName, fmt.Sprintf("I%d", row), booking.UpdatedAt.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheetName, fmt.Sprintf("J%d", row), bookingFormDetails(booking))
+		f.SetCellValue(sheetName, fmt.Sprintf("K%d", row), bookingCancelledAt(booking))
+		f.SetCellValue(sheetName, fmt.Sprintf("L%d", row), booking.CancellationReason)
+		f.SetCellValue(sheetName, fmt.Sprintf("M%d", row), booking.RefundStatus)
+		f.SetCellValue(sheetName, fmt.Sprintf("N%d", row), booking.RescheduleCount)
+		f.SetCellValue(sheetName, fmt.Sprintf("O%d", row), bookingCheckedInAt(booking))
 	}
 
 	buf, err := f.WriteToBuffer()
@@ -1652,7 +1769,7 @@ func (e *reportExporter) exportBookingsCSV(bookings []SevaBookingReportRow) ([]b
 	writer := csv.NewWriter(&buf)
 
 	// UPDATED with Temple Name
-	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At"}
+	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Devotee Phone", "Booking Time", "Status", "Created At", "Updated At", "Booking Details", "Cancelled At", "Cancellation Reason", "Refund Status", "Times Rescheduled", "Checked In At"}
 	if err := writer.Write(headers); err != nil {
 		return nil, err
 	}
@@ -1668,6 +1785,12 @@ func (e *reportExporter) exportBookingsCSV(bookings []SevaBookingReportRow) ([]b
 			booking.Status,
 			booking.CreatedAt.Format("2006-01-02 15:04:05"),
 			booking.UpdatedAt.Format("2006-01-02 15:04:05"),
+			bookingFormDetails(booking),
+			bookingCancelledAt(booking),
+			booking.CancellationReason,
+			booking.RefundStatus,
+			strconv.Itoa(booking.RescheduleCount),
+			bookingCheckedInAt(booking),
 		}
 		if err := writer.Write(record); err != nil {
 			return nil, err
@@ -1683,8 +1806,86 @@ func (e *reportExporter) exportBookingsCSV(bookings []SevaBookingReportRow) ([]b
 	return buf.Bytes(), nil
 }
 
+func bookingCancelledAt(booking SevaBookingReportRow) string {
+	if booking.CancelledAt == nil {
+		return ""
+	}
+	return booking.CancelledAt.Format("2006-01-02 15:04:05")
+}
+
+func bookingCheckedInAt(booking SevaBookingReportRow) string {
+	if booking.CheckedInAt == nil {
+		return ""
+	}
+	return booking.CheckedInAt.Format("2006-01-02 15:04:05")
+}
+
+// bookingStatusLabel is the status shown in the PDF, with the refund state of
+// cancelled bookings and attendance of checked in ones
+func bookingStatusLabel(booking SevaBookingReportRow) string {
+	if booking.RefundStatus != "" {
+		return booking.Status + " (refund " + booking.RefundStatus + ")"
+	}
+	if booking.CheckedInAt != nil {
+		return booking.Status + " (present)"
+	}
+	return booking.Status
+}
+
+// bookingFormDetails flattens a booking's form answers into "Label: value"
+// pairs, in the order the seva's form lists them
+func bookingFormDetails(booking SevaBookingReportRow) string {
+	if len(booking.FormData) == 0 {
+		return ""
+	}
+	var values map[string]interface{}
+	if err := json.Unmarshal(booking.FormData, &values); err != nil || len(values) == 0 {
+		return ""
+	}
+
+	var fields []struct {
+		Key   string `json:"key"`
+		Label string `json:"label"`
+	}
+	if len(booking.FormFields) > 0 {
+		_ = json.Unmarshal(booking.FormFields, &fields)
+	}
+
+	parts := make([]string, 0, len(values))
+	for _, f := range fields {
+		if v, ok := values[f.Key]; ok {
+			parts = append(parts, f.Label+": "+formatFormValue(v))
+			delete(values, f.Key)
+		}
+	}
+	// Answers to fields removed from the form since the booking was made
+	rest := make([]string, 0, len(values))
+	for key := range values {
+		rest = append(rest, key)
+	}
+	sort.Strings(rest)
+	for _, key := range rest {
+		parts = append(parts, key+": "+formatFormValue(values[key]))
+	}
+	return strings.Join(parts, "; ")
+}
+
+func formatFormValue(v interface{}) string {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			return "Yes"
+		}
+		return "No"
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
 func (e *reportExporter) exportBookingsPDF(bookings []SevaBookingReportRow) ([]byte, error) {
-	pdf := gofpdf.New("L", "mm", "A4", "")
+	pdf := i18n.NewPDF("L")
 	pdf.AddPage()
 	pdf.SetFont("Arial", "B", 16)
 	pdf.Cell(0, 10, "Seva Bookings Report")
@@ -1692,8 +1893,8 @@ func (e *reportExporter) exportBookingsPDF(bookings []SevaBookingReportRow) ([]b
 
 	pdf.SetFont("Arial", "B", 10)
 	// Define column widths - UPDATED with Temple Name
-	widths := []float64{40, 40, 25, 35, 30, 35, 20}
-	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Phone", "Booking Time", "Status"}
+	widths := []float64{35, 35, 22, 32, 25, 28, 34, 66}
+	headers := []string{"Seva Name", "Temple Name", "Seva Type", "Devotee Name", "Phone", "Booking Time", "Status", "Booking Details"}
 
 	// Print headers with borders
 	for i, header := range headers {
@@ -1710,7 +1911,12 @@ func (e *reportExporter) exportBookingsPDF(bookings []SevaBookingReportRow) ([]b
 		pdf.CellFormat(widths[3], 6, booking.DevoteeName, "1", 0, "L", false, 0, "")
 		pdf.CellFormat(widths[4], 6, booking.DevoteePhone, "1", 0, "C", false, 0, "")
 		pdf.CellFormat(widths[5], 6, booking.BookingTime.Format("02-01-06 15:04"), "1", 0, "C", false, 0, "")
-		pdf.CellFormat(widths[6], 6, booking.Status, "1", 0, "C", false, 0, "")
+		pdf.CellFormat(widths[6], 6, bookingStatusLabel(booking), "1", 0, "C", false, 0, "")
+
+		// Truncate booking details if too long for PDF cell
+		details := bookingFormDetails(booking)
+		details = truncateText(details, 48)
+		pdf.CellFormat(widths[7], 6, details, "1", 0, "L", false, 0, "")
 		pdf.Ln(-1)
 	}
 
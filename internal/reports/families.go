@@ -0,0 +1,306 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+)
+
+// familyAddressSet holds when the family recorded its own mailing address
+const familyAddressSet = "(f.street_address <> '' OR f.city <> '' OR f.pincode <> '')"
+
+// familyAddress picks an address column from the family, else from the
+// head's devotee profile at the temple
+func familyAddress(column string) string {
+	return fmt.Sprintf("CASE WHEN %s THEN f.%s ELSE COALESCE(dp.%s, '') END as %s", familyAddressSet, column, column, column)
+}
+
+// GetFamilies lists the households with at least one member belonging to the
+// temple, by membership or as the user's home temple
+func (r *repository) GetFamilies(entityID uint, req FamilyReportRequest) ([]FamilyReportRow, error) {
+	var out []FamilyReportRow
+	query := r.db.Table("devotee_families f").
+		Select(`f.id as family_id, f.name as family_name,
+			u.full_name as head_name, u.phone as head_phone, u.email as head_email,
+			`+familyAddress("street_address")+`, `+familyAddress("city")+`, `+familyAddress("state")+`,
+			`+familyAddress("pincode")+`, `+familyAddress("country")+`,
+			COUNT(fm.id) as member_count,
+			STRING_AGG(fm.name, ', ' ORDER BY CASE WHEN fm.relationship = 'self' THEN 0 ELSE 1 END, fm.id) as members`).
+		Joins("JOIN users u ON u.id = f.head_user_id").
+		Joins("LEFT JOIN devotee_profiles dp ON dp.user_id = f.head_user_id AND dp.entity_id = ? AND dp.deleted_at IS NULL", entityID).
+		Joins("JOIN devotee_family_members fm ON fm.family_id = f.id").
+		Where("f.deleted_at IS NULL").
+		Where(`EXISTS (
+			SELECT 1 FROM devotee_family_members tm
+			JOIN users tu ON tu.id = tm.user_id
+			LEFT JOIN user_entity_memberships uem ON uem.user_id = tu.id AND uem.entity_id = ? AND uem.status = 'active'
+			WHERE tm.family_id = f.id AND (uem.user_id IS NOT NULL OR tu.entity_id = ?)
+		)`, entityID, entityID).
+		Group("f.id, u.id, dp.id").
+		Order("f.name ASC, f.id ASC")
+	if req.City != "" {
+		query = query.Having(fmt.Sprintf("CASE WHEN %s THEN f.city ELSE COALESCE(dp.city, '') END ILIKE ?", familyAddressSet), req.City)
+	}
+	err := query.Scan(&out).Error
+	return out, err
+}
+
+// ===============================
+// Service
+// ===============================
+
+func (s *reportService) GetFamilies(entityID uint, req FamilyReportRequest) ([]FamilyReportRow, error) {
+	return s.repo.GetFamilies(entityID, req)
+}
+
+func (s *reportService) ExportFamilies(ctx context.Context, entityID uint, req FamilyReportRequest, userID *uint, ip string) ([]byte, string, string, error) {
+	fail := func(err error) ([]byte, string, string, error) {
+		s.auditSvc.LogAction(ctx, userID, &entityID, "FAMILY_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type": ReportTypeFamilies,
+			"format":      req.Format,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	rows, err := s.GetFamilies(entityID, req)
+	if err != nil {
+		return fail(err)
+	}
+	bytes, filename, mimeType, err := s.render(ctx, ReportTypeFamilies, req.Format, ReportData{Families: rows})
+	if err != nil {
+		return fail(err)
+	}
+
+	s.auditSvc.LogAction(ctx, userID, &entityID, "FAMILY_REPORT_DOWNLOADED", map[string]interface{}{
+		"report_type":  ReportTypeFamilies,
+		"format":       req.Format,
+		"filename":     filename,
+		"city":         req.City,
+		"record_count": len(rows),
+	}, ip, "success")
+	return bytes, filename, mimeType, nil
+}
+
+// ===============================
+// Exporter
+// ===============================
+
+func (e *reportExporter) exportFamiliesByFormat(format, timestamp string, rows []FamilyReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportFamiliesExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("families_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportFamiliesCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("families_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportFamiliesPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("families_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for families report: %s", format)
+	}
+}
+
+var familyHeaders = []string{"Family ID", "Family", "Head of Family", "Phone", "Email", "Street Address", "City", "State", "Pincode", "Country", "Members", "Member Names"}
+
+func familyRecord(row FamilyReportRow) []string {
+	return []string{
+		strconv.FormatUint(uint64(row.FamilyID), 10),
+		row.FamilyName,
+		row.HeadName,
+		row.HeadPhone,
+		row.HeadEmail,
+		row.StreetAddress,
+		row.City,
+		row.State,
+		row.Pincode,
+		row.Country,
+		strconv.Itoa(row.MemberCount),
+		row.Members,
+	}
+}
+
+func (e *reportExporter) exportFamiliesExcel(rows []FamilyReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Families"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range familyHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range familyRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportFamiliesCSV(rows []FamilyReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(familyHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(familyRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// exportFamiliesPDF prints one mailing block per household rather than a
+// wide table, so the addresses can be read and cut out
+func (e *reportExporter) exportFamiliesPDF(rows []FamilyReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("P")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Family Mailing List")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(0, 8, fmt.Sprintf("Households: %d", len(rows)))
+	pdf.Ln(12)
+
+	for _, row := range rows {
+		lines := []string{row.HeadName + " & Family (" + row.FamilyName + ")"}
+		if row.StreetAddress != "" {
+			lines = append(lines, row.StreetAddress)
+		}
+		place := []string{}
+		for _, part := range []string{row.City, row.State, row.Pincode, row.Country} {
+			if part != "" {
+				place = append(place, part)
+			}
+		}
+		if len(place) > 0 {
+			lines = append(lines, strings.Join(place, ", "))
+		}
+		if row.HeadPhone != "" {
+			lines = append(lines, "Phone: "+row.HeadPhone)
+		}
+
+		if pdf.GetY() > 260 {
+			pdf.AddPage()
+		}
+		pdf.SetFont("Arial", "B", 10)
+		pdf.Cell(0, 6, lines[0])
+		pdf.Ln(6)
+		pdf.SetFont("Arial", "", 10)
+		for _, line := range lines[1:] {
+			pdf.Cell(0, 5, line)
+			pdf.Ln(5)
+		}
+		pdf.SetFont("Arial", "I", 8)
+		pdf.MultiCell(0, 4, fmt.Sprintf("Members (%d): %s", row.MemberCount, row.Members), "", "L", false)
+		pdf.Ln(4)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ===============================
+// Handler
+// ===============================
+
+// GetFamiliesReport - GET /entities/:id/reports/families
+// Households with a member belonging to the temple, one row per family with
+// its mailing address, so mailings go to households rather than individuals.
+// city= narrows the list; ?format=excel|csv|pdf exports.
+func (h *Handler) GetFamiliesReport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity_id"})
+		return
+	}
+	if !h.canAccessEntity(ctx, uint(entityID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for this entity"})
+		return
+	}
+
+	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeFamilies, format) {
+		return
+	}
+	req := FamilyReportRequest{
+		City:   strings.TrimSpace(c.Query("city")),
+		Format: format,
+	}
+
+	if format == "" {
+		rows, err := h.service.GetFamilies(uint(entityID), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &rows)
+		eid := uint(entityID)
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, &eid, "FAMILY_REPORT_VIEWED", map[string]interface{}{
+			"report_type": ReportTypeFamilies,
+			"city":        req.City,
+		}, ip, "success")
+		c.JSON(http.StatusOK, gin.H{
+			"report_type": ReportTypeFamilies,
+			"data":        rows,
+			"total":       len(rows),
+		})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportFamilies(h.exportContext(c, ctx), uint(entityID), req, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
@@ -2,53 +2,178 @@ package reports
 
 import (
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/panchang"
+)
+
+const dateLayout = "2006-01-02"
+
+// defaultMaxRangeDays caps any report at a little over a year unless
+// configured otherwise
+const defaultMaxRangeDays = 366
+
+var (
+	lastNDaysPattern = regexp.MustCompile(`^last_(\d+)_days$`)
+
+	maxRangeDays       = defaultMaxRangeDays
+	maxRangeDaysByType = map[string]int{}
 )
 
-// GetDateRange returns start and end time for the given preset or custom (startStr/endStr required for custom).
-// startStr/endStr expected in "2006-01-02" format when dateRange == DateRangeCustom.
-func GetDateRange(dateRange, startStr, endStr string) (time.Time, time.Time, error) {
+// DateRangeError reports which date range query parameter is invalid
+type DateRangeError struct {
+	Param   string
+	Message string
+}
+
+func (e *DateRangeError) Error() string {
+	return e.Param + ": " + e.Message
+}
+
+func dateRangeError(param, format string, args ...interface{}) error {
+	return &DateRangeError{Param: param, Message: fmt.Sprintf(format, args...)}
+}
+
+// dateRangeErrorJSON is the 400 body for a GetDateRange error, naming the
+// offending parameter when known
+func dateRangeErrorJSON(err error) gin.H {
+	var rangeErr *DateRangeError
+	if errors.As(err, &rangeErr) {
+		return gin.H{"error": rangeErr.Error(), "param": rangeErr.Param}
+	}
+	return gin.H{"error": err.Error()}
+}
+
+// SetMaxRangeDays configures the longest span, in days, a report may cover.
+// byType overrides the default for individual report types (e.g. "audit-logs").
+func SetMaxRangeDays(defaultDays int, byType map[string]int) {
+	if defaultDays > 0 {
+		maxRangeDays = defaultDays
+	}
+	maxRangeDaysByType = make(map[string]int, len(byType))
+	for reportType, days := range byType {
+		if days > 0 {
+			maxRangeDaysByType[reportType] = days
+		}
+	}
+}
+
+// MaxRangeDays returns the longest span allowed for the report type
+func MaxRangeDays(reportType string) int {
+	if days, ok := maxRangeDaysByType[reportType]; ok {
+		return days
+	}
+	return maxRangeDays
+}
+
+// GetDateRange returns start and end time for the given preset or custom range
+// of a report type. Presets are daily/today, yesterday, weekly, monthly,
+// yearly, mtd, qtd, ytd and last_<n>_days; custom needs startStr and endStr in
+// "2006-01-02" format. An empty dateRange means weekly. Errors are
+// *DateRangeError naming the offending query parameter.
+func GetDateRange(reportType, dateRange, startStr, endStr string) (time.Time, time.Time, error) {
 	now := time.Now()
 	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	endOfToday := today.AddDate(0, 0, 1).Add(-time.Second)
 
+	var start, end time.Time
 	switch dateRange {
-	case DateRangeDaily:
-		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
-		end := start.Add(24*time.Hour - time.Second)
-		return start, end, nil
-	case DateRangeWeekly:
+	case DateRangeDaily, DateRangeToday:
+		start, end = today, endOfToday
+	case DateRangeYesterday:
+		start, end = today.AddDate(0, 0, -1), today.Add(-time.Second)
+	case DateRangeWeekly, "":
 		// last 7 days (including today)
-		end := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, loc)
-		start := end.AddDate(0, 0, -6).Truncate(24 * time.Hour)
-		return start, end, nil
+		start, end = today.AddDate(0, 0, -6), endOfToday
 	case DateRangeMonthly:
-		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
-		end := start.AddDate(0, 1, 0).Add(-time.Second)
-		return start, end, nil
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+		end = start.AddDate(0, 1, 0).Add(-time.Second)
 	case DateRangeYearly:
-		start := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
-		end := time.Date(now.Year(), 12, 31, 23, 59, 59, 0, loc)
-		return start, end, nil
+		start = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
+		end = time.Date(now.Year(), 12, 31, 23, 59, 59, 0, loc)
+	case DateRangeMTD:
+		start, end = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc), endOfToday
+	case DateRangeQTD:
+		quarterMonth := time.Month((int(now.Month())-1)/3*3 + 1)
+		start, end = time.Date(now.Year(), quarterMonth, 1, 0, 0, 0, 0, loc), endOfToday
+	case DateRangeYTD:
+		start, end = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc), endOfToday
 	case DateRangeCustom:
-		if startStr == "" || endStr == "" {
-			return time.Time{}, time.Time{}, errors.New("start_date and end_date required for custom range")
+		if startStr == "" {
+			return time.Time{}, time.Time{}, dateRangeError("start_date", "required for custom range")
 		}
-		start, err := time.ParseInLocation("2006-01-02", startStr, loc)
-		if err != nil {
-			return time.Time{}, time.Time{}, err
+		if endStr == "" {
+			return time.Time{}, time.Time{}, dateRangeError("end_date", "required for custom range")
 		}
-		end, err := time.ParseInLocation("2006-01-02", endStr, loc)
-		if err != nil {
-			return time.Time{}, time.Time{}, err
+		var err error
+		if start, err = time.ParseInLocation(dateLayout, startStr, loc); err != nil {
+			return time.Time{}, time.Time{}, dateRangeError("start_date", "must be a date in yyyy-mm-dd format")
+		}
+		if end, err = time.ParseInLocation(dateLayout, endStr, loc); err != nil {
+			return time.Time{}, time.Time{}, dateRangeError("end_date", "must be a date in yyyy-mm-dd format")
 		}
-		// include entire end day
-		end = end.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
 		if start.After(end) {
-			return time.Time{}, time.Time{}, errors.New("start_date must be before end_date")
+			return time.Time{}, time.Time{}, dateRangeError("start_date", "must be on or before end_date")
 		}
-		return start, end, nil
+		// include entire end day
+		end = end.AddDate(0, 0, 1).Add(-time.Second)
 	default:
-		// default to last 7 days
-		return GetDateRange(DateRangeWeekly, "", "")
+		m := lastNDaysPattern.FindStringSubmatch(dateRange)
+		if m == nil {
+			return time.Time{}, time.Time{}, dateRangeError("date_range",
+				"unknown range %q: use today, yesterday, weekly, monthly, yearly, mtd, qtd, ytd, last_<n>_days or custom", dateRange)
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 {
+			return time.Time{}, time.Time{}, dateRangeError("date_range", "last_<n>_days needs n of at least 1")
+		}
+		// last n days (including today)
+		start, end = today.AddDate(0, 0, -(n-1)), endOfToday
+	}
+
+	// Calendar days covered, counting both ends
+	days := int(end.Sub(start).Hours()/24) + 1
+	if limit := MaxRangeDays(reportType); days > limit {
+		param := "date_range"
+		if dateRange == DateRangeCustom {
+			param = "end_date"
+		}
+		return time.Time{}, time.Time{}, dateRangeError(param, "range covers %d days, the %s report allows at most %d", days, reportType, limit)
+	}
+	return start, end, nil
+}
+
+// parseTithiFilter reads the tithi query param, comma separated tithis or
+// observances such as ekadashi,pournami; only the bookings report takes it
+func parseTithiFilter(c *gin.Context, reportType string) ([]string, error) {
+	tithis, err := panchang.ParseFilter(c.Query("tithi"))
+	if err != nil {
+		return nil, err
+	}
+	if len(tithis) > 0 && reportType != ReportTypeBookings {
+		return nil, errors.New("the tithi filter applies to the bookings report only")
+	}
+	return tithis, nil
+}
+
+// parseGroupBy reads the group_by query param (day, month, type or method);
+// only the donations report takes it
+func parseGroupBy(c *gin.Context, reportType string) (string, error) {
+	groupBy := strings.ToLower(strings.TrimSpace(c.Query("group_by")))
+	if groupBy == "" {
+		return "", nil
+	}
+	if reportType != ReportTypeDonations {
+		return "", errors.New("group_by applies to the donations report only")
+	}
+	if _, ok := donationGroupKeys[groupBy]; !ok {
+		return "", fmt.Errorf("unknown group_by %q: use day, month, type or method", groupBy)
 	}
+	return groupBy, nil
 }
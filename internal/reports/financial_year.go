@@ -0,0 +1,538 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// financialYearPattern matches fy=2024 and fy=2024-25
+var financialYearPattern = regexp.MustCompile(`^(\d{4})(?:-(\d{2}))?$`)
+
+// financialYearBounds returns the first and last moment of the April to March
+// year starting in startYear
+func financialYearBounds(startYear int) (time.Time, time.Time) {
+	start := time.Date(startYear, time.April, 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(1, 0, 0).Add(-time.Second)
+}
+
+// financialYearLabel names the year starting in startYear, e.g. 2024-25
+func financialYearLabel(startYear int) string {
+	return fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100)
+}
+
+// currentFinancialYear is the start year of the financial year of today
+func currentFinancialYear() int {
+	now := time.Now()
+	if now.Month() < time.April {
+		return now.Year() - 1
+	}
+	return now.Year()
+}
+
+// parseFinancialYear reads fy=2024 or fy=2024-25; empty means the current year
+func parseFinancialYear(value string) (int, error) {
+	if value == "" {
+		return currentFinancialYear(), nil
+	}
+	m := financialYearPattern.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("fy must be a year such as 2024 or 2024-25")
+	}
+	startYear, _ := strconv.Atoi(m[1])
+	if m[2] != "" {
+		if endYear, _ := strconv.Atoi(m[2]); endYear != (startYear+1)%100 {
+			return 0, fmt.Errorf("fy %s does not span consecutive years", value)
+		}
+	}
+	if startYear > currentFinancialYear() {
+		return 0, fmt.Errorf("fy %s has not started yet", value)
+	}
+	return startYear, nil
+}
+
+// GetFinancialYear builds the temple's summary of the financial year. Seva
+// income is valued at each seva's current price.
+func (r *repository) GetFinancialYear(entityID uint, req FinancialYearReportRequest) (*FinancialYearSummary, error) {
+	start, end := financialYearBounds(req.StartYear)
+	out := &FinancialYearSummary{
+		EntityID:      entityID,
+		FinancialYear: financialYearLabel(req.StartYear),
+		StartDate:     start.Format("2006-01-02"),
+		EndDate:       end.Format("2006-01-02"),
+		Donations:     []IncomeExpenseLine{},
+		Expenses:      []IncomeExpenseLine{},
+		Months:        make([]FinancialYearMonth, 0, 12),
+	}
+	if err := r.db.Table("entities").Select("name").Where("id = ?", entityID).Scan(&out.TempleName).Error; err != nil {
+		return nil, err
+	}
+
+	donations := r.db.Table("donations").
+		Where("entity_id = ? AND status = ? AND deleted_at IS NULL", entityID, "SUCCESS")
+	sevas := r.db.Table("seva_bookings sb").
+		Joins("JOIN sevas s ON s.id = sb.seva_id").
+		Where("sb.entity_id = ? AND sb.status = ? AND s.price > 0", entityID, "approved")
+	// Expenses are dated by day, the year is compared on dates
+	expenses := r.db.Table("temple_expenses").
+		Where("entity_id = ? AND deleted_at IS NULL", entityID)
+
+	const donatedAt = "COALESCE(donated_at, created_at)"
+
+	// Everything recorded before the year makes up the opening balance
+	var before struct{ Donations, Sevas, Expenses float64 }
+	err := donations.Session(&gorm.Session{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where(donatedAt+" < ?", start).
+		Scan(&before.Donations).Error
+	if err == nil {
+		err = sevas.Session(&gorm.Session{}).
+			Select("COALESCE(SUM(s.price), 0)").
+			Where("sb.booking_time < ?", start).
+			Scan(&before.Sevas).Error
+	}
+	if err == nil {
+		err = expenses.Session(&gorm.Session{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("status = ? AND expense_date < ?::date", "approved", out.StartDate).
+			Scan(&before.Expenses).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	out.OpeningBalance = before.Donations + before.Sevas - before.Expenses
+
+	err = donations.Session(&gorm.Session{}).
+		Select("COALESCE(NULLIF(donation_type, ''), 'general') as category, COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+		Where(donatedAt+" BETWEEN ? AND ?", start, end).
+		Group("1").
+		Order("amount DESC").
+		Scan(&out.Donations).Error
+	if err == nil {
+		err = expenses.Session(&gorm.Session{}).
+			Select("category, COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+			Where("status = ? AND expense_date BETWEEN ?::date AND ?::date", "approved", out.StartDate, out.EndDate).
+			Group("category").
+			Order("amount DESC").
+			Scan(&out.Expenses).Error
+	}
+	if err == nil {
+		err = expenses.Session(&gorm.Session{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("status = ? AND expense_date BETWEEN ?::date AND ?::date", "pending", out.StartDate, out.EndDate).
+			Scan(&out.PendingExpenses).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Month-wise totals, keyed 2024-04
+	type monthTotal struct {
+		Month  string
+		Count  int64
+		Amount float64
+	}
+	var donationMonths, sevaMonths, expenseMonths []monthTotal
+	err = donations.Session(&gorm.Session{}).
+		Select("TO_CHAR("+donatedAt+", 'YYYY-MM') as month, COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+		Where(donatedAt+" BETWEEN ? AND ?", start, end).
+		Group("1").
+		Scan(&donationMonths).Error
+	if err == nil {
+		err = sevas.Session(&gorm.Session{}).
+			Select("TO_CHAR(sb.booking_time, 'YYYY-MM') as month, COUNT(*) as count, COALESCE(SUM(s.price), 0) as amount").
+			Where("sb.booking_time BETWEEN ? AND ?", start, end).
+			Group("1").
+			Scan(&sevaMonths).Error
+	}
+	if err == nil {
+		err = expenses.Session(&gorm.Session{}).
+			Select("TO_CHAR(expense_date, 'YYYY-MM') as month, COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+			Where("status = ? AND expense_date BETWEEN ?::date AND ?::date", "approved", out.StartDate, out.EndDate).
+			Group("1").
+			Scan(&expenseMonths).Error
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	byMonth := func(rows []monthTotal) map[string]float64 {
+		m := make(map[string]float64, len(rows))
+		for _, row := range rows {
+			m[row.Month] = row.Amount
+		}
+		return m
+	}
+	donationsByMonth, sevasByMonth, expensesByMonth := byMonth(donationMonths), byMonth(sevaMonths), byMonth(expenseMonths)
+	for _, row := range sevaMonths {
+		out.SevaBookings += row.Count
+	}
+
+	balance := out.OpeningBalance
+	for i := 0; i < 12; i++ {
+		month := start.AddDate(0, i, 0)
+		key := month.Format("2006-01")
+		line := FinancialYearMonth{
+			Month:      key,
+			Label:      month.Format("Jan 2006"),
+			Donations:  donationsByMonth[key],
+			SevaIncome: sevasByMonth[key],
+			Expenses:   expensesByMonth[key],
+		}
+		line.Net = line.Donations + line.SevaIncome - line.Expenses
+		balance += line.Net
+		line.Closing = balance
+
+		out.TotalDonations += line.Donations
+		out.SevaIncome += line.SevaIncome
+		out.TotalExpenses += line.Expenses
+		out.Months = append(out.Months, line)
+	}
+	out.TotalIncome = out.TotalDonations + out.SevaIncome
+	out.Net = out.TotalIncome - out.TotalExpenses
+	out.ClosingBalance = out.OpeningBalance + out.Net
+	return out, nil
+}
+
+// ===============================
+// Service
+// ===============================
+
+func (s *reportService) GetFinancialYearSummary(entityID uint, req FinancialYearReportRequest) (*FinancialYearSummary, error) {
+	return s.repo.GetFinancialYear(entityID, req)
+}
+
+func (s *reportService) ExportFinancialYearSummary(ctx context.Context, entityID uint, req FinancialYearReportRequest, userID *uint, ip string) ([]byte, string, string, error) {
+	fail := func(err error) ([]byte, string, string, error) {
+		s.auditSvc.LogAction(ctx, userID, &entityID, "FINANCIAL_YEAR_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type":    ReportTypeFinancialYear,
+			"format":         req.Format,
+			"financial_year": financialYearLabel(req.StartYear),
+			"error":          err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	summary, err := s.GetFinancialYearSummary(entityID, req)
+	if err != nil {
+		return fail(err)
+	}
+	bytes, filename, mimeType, err := s.render(ctx, ReportTypeFinancialYear, req.Format, ReportData{FinancialYear: summary})
+	if err != nil {
+		return fail(err)
+	}
+
+	s.auditSvc.LogAction(ctx, userID, &entityID, "FINANCIAL_YEAR_REPORT_DOWNLOADED", map[string]interface{}{
+		"report_type":    ReportTypeFinancialYear,
+		"format":         req.Format,
+		"filename":       filename,
+		"financial_year": summary.FinancialYear,
+	}, ip, "success")
+	return bytes, filename, mimeType, nil
+}
+
+// ===============================
+// Exporter
+// ===============================
+
+func (e *reportExporter) exportFinancialYearByFormat(format, timestamp string, summary *FinancialYearSummary) ([]byte, string, string, error) {
+	if summary == nil {
+		return nil, "", "", fmt.Errorf("no financial year summary to export")
+	}
+	switch format {
+	case FormatExcel:
+		data, err := e.exportFinancialYearExcel(summary)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("financial_year_%s_%s.xlsx", summary.FinancialYear, timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatPDF:
+		data, err := e.exportFinancialYearPDF(summary)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("financial_year_%s_%s.pdf", summary.FinancialYear, timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for financial year summary: %s", format)
+	}
+}
+
+// exportFinancialYearExcel writes a Summary sheet by category and a Monthly
+// sheet. Totals, net and balances are formulas, so auditors can trace them
+// and the workbook stays consistent if a figure is corrected.
+func (e *reportExporter) exportFinancialYearExcel(s *FinancialYearSummary) ([]byte, error) {
+	f := excelize.NewFile()
+	summary := "Summary"
+	f.SetSheetName("Sheet1", summary)
+
+	f.SetCellValue(summary, "A1", fmt.Sprintf("Financial Year %s - %s", s.FinancialYear, s.TempleName))
+	f.SetCellValue(summary, "A2", fmt.Sprintf("%s to %s", s.StartDate, s.EndDate))
+
+	row := 4
+	cell := func(col string, r int) string { return fmt.Sprintf("%s%d", col, r) }
+	// section writes the header and lines of a category table and its total
+	// row, returning the total row
+	section := func(title, totalLabel string, lines []IncomeExpenseLine, label func(string) string) int {
+		f.SetCellValue(summary, cell("A", row), title)
+		f.SetCellValue(summary, cell("B", row), "Count")
+		f.SetCellValue(summary, cell("C", row), "Amount")
+		row++
+		first := row
+		for _, line := range lines {
+			f.SetCellValue(summary, cell("A", row), label(line.Category))
+			f.SetCellValue(summary, cell("B", row), line.Count)
+			f.SetCellValue(summary, cell("C", row), line.Amount)
+			row++
+		}
+		f.SetCellValue(summary, cell("A", row), totalLabel)
+		if len(lines) > 0 {
+			f.SetCellFormula(summary, cell("B", row), fmt.Sprintf("SUM(B%d:B%d)", first, row-1))
+			f.SetCellFormula(summary, cell("C", row), fmt.Sprintf("SUM(C%d:C%d)", first, row-1))
+		} else {
+			f.SetCellValue(summary, cell("B", row), 0)
+			f.SetCellValue(summary, cell("C", row), 0)
+		}
+		total := row
+		row++
+		return total
+	}
+
+	f.SetCellValue(summary, cell("A", row), "Opening balance")
+	f.SetCellValue(summary, cell("C", row), s.OpeningBalance)
+	openingRow := row
+	row += 2
+
+	donationsRow := section("Donations", "Total donations", s.Donations, func(c string) string { return categoryLabel(c) + " donations" })
+	f.SetCellValue(summary, cell("A", row), "Seva income")
+	f.SetCellValue(summary, cell("B", row), s.SevaBookings)
+	f.SetCellValue(summary, cell("C", row), s.SevaIncome)
+	sevaRow := row
+	row++
+	f.SetCellValue(summary, cell("A", row), "Total income")
+	f.SetCellFormula(summary, cell("C", row), fmt.Sprintf("C%d+C%d", donationsRow, sevaRow))
+	incomeRow := row
+	row += 2
+
+	expensesRow := section("Expenses", "Total expenses", s.Expenses, categoryLabel)
+	row++
+
+	f.SetCellValue(summary, cell("A", row), "Surplus / (deficit)")
+	f.SetCellFormula(summary, cell("C", row), fmt.Sprintf("C%d-C%d", incomeRow, expensesRow))
+	netRow := row
+	row++
+	f.SetCellValue(summary, cell("A", row), "Closing balance")
+	f.SetCellFormula(summary, cell("C", row), fmt.Sprintf("C%d+C%d", openingRow, netRow))
+	row += 2
+	f.SetCellValue(summary, cell("A", row), "Expenses awaiting approval (not included)")
+	f.SetCellValue(summary, cell("C", row), s.PendingExpenses)
+	f.SetColWidth(summary, "A", "A", 42)
+	f.SetColWidth(summary, "C", "C", 16)
+
+	monthly := "Monthly"
+	if _, err := f.NewSheet(monthly); err != nil {
+		return nil, err
+	}
+	for i, header := range []string{"Month", "Donations", "Seva Income", "Total Income", "Expenses", "Net", "Closing Balance"} {
+		f.SetCellValue(monthly, fmt.Sprintf("%c1", 'A'+i), header)
+	}
+	f.SetCellValue(monthly, "A2", "Opening balance")
+	f.SetCellValue(monthly, "G2", s.OpeningBalance)
+	for i, m := range s.Months {
+		r := i + 3
+		f.SetCellValue(monthly, cell("A", r), m.Label)
+		f.SetCellValue(monthly, cell("B", r), m.Donations)
+		f.SetCellValue(monthly, cell("C", r), m.SevaIncome)
+		f.SetCellFormula(monthly, cell("D", r), fmt.Sprintf("B%d+C%d", r, r))
+		f.SetCellValue(monthly, cell("E", r), m.Expenses)
+		f.SetCellFormula(monthly, cell("F", r), fmt.Sprintf("D%d-E%d", r, r))
+		f.SetCellFormula(monthly, cell("G", r), fmt.Sprintf("G%d+F%d", r-1, r))
+	}
+	last := len(s.Months) + 2
+	totalRow := last + 1
+	f.SetCellValue(monthly, cell("A", totalRow), "Total")
+	for _, col := range []string{"B", "C", "D", "E", "F"} {
+		f.SetCellFormula(monthly, cell(col, totalRow), fmt.Sprintf("SUM(%s3:%s%d)", col, col, last))
+	}
+	f.SetCellFormula(monthly, cell("G", totalRow), fmt.Sprintf("G%d", last))
+	f.SetColWidth(monthly, "A", "A", 18)
+	f.SetColWidth(monthly, "B", "G", 16)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportFinancialYearPDF(s *FinancialYearSummary) ([]byte, error) {
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+	pdf := i18n.NewPDF("P")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Financial Year Summary "+s.FinancialYear)
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(0, 8, fmt.Sprintf("%s, %s to %s", s.TempleName, s.StartDate, s.EndDate))
+	pdf.Ln(14)
+
+	// Summary by category: label, count, amount; a nil row is a blank line
+	rows := [][]string{{"Opening balance", "", money(s.OpeningBalance)}, nil, {"Donations", "Count", "Amount"}}
+	for _, line := range s.Donations {
+		rows = append(rows, []string{categoryLabel(line.Category) + " donations", strconv.FormatInt(line.Count, 10), money(line.Amount)})
+	}
+	rows = append(rows,
+		[]string{"Total donations", "", money(s.TotalDonations)},
+		[]string{"Seva income", strconv.FormatInt(s.SevaBookings, 10), money(s.SevaIncome)},
+		[]string{"Total income", "", money(s.TotalIncome)},
+		nil,
+		[]string{"Expenses", "Count", "Amount"},
+	)
+	for _, line := range s.Expenses {
+		rows = append(rows, []string{categoryLabel(line.Category), strconv.FormatInt(line.Count, 10), money(line.Amount)})
+	}
+	rows = append(rows,
+		[]string{"Total expenses", "", money(s.TotalExpenses)},
+		nil,
+		[]string{"Surplus / (deficit)", "", money(s.Net)},
+		[]string{"Closing balance", "", money(s.ClosingBalance)},
+		[]string{"Expenses awaiting approval (not included)", "", money(s.PendingExpenses)},
+	)
+
+	widths := []float64{110, 25, 45}
+	for _, row := range rows {
+		if row == nil {
+			pdf.Ln(4)
+			continue
+		}
+		style := ""
+		if row[1] == "" || row[1] == "Count" {
+			style = "B"
+		}
+		pdf.SetFont("Arial", style, 10)
+		for i, value := range row {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, value, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 13)
+	pdf.Cell(0, 10, "Month-wise breakdown")
+	pdf.Ln(12)
+	monthWidths := []float64{28, 26, 26, 26, 26, 24, 28}
+	pdf.SetFont("Arial", "B", 9)
+	for i, header := range []string{"Month", "Donations", "Seva Income", "Total Income", "Expenses", "Net", "Closing"} {
+		pdf.CellFormat(monthWidths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+	monthRow := func(label string, donations, sevas, expenses, net, closing float64) {
+		values := []string{label, money(donations), money(sevas), money(donations + sevas), money(expenses), money(net), money(closing)}
+		for i, value := range values {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(monthWidths[i], 6, value, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.SetFont("Arial", "", 9)
+	for _, m := range s.Months {
+		monthRow(m.Label, m.Donations, m.SevaIncome, m.Expenses, m.Net, m.Closing)
+	}
+	pdf.SetFont("Arial", "B", 9)
+	monthRow("Total", s.TotalDonations, s.SevaIncome, s.TotalExpenses, s.Net, s.ClosingBalance)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ===============================
+// Handler
+// ===============================
+
+// GetFinancialYearReport - GET /entities/:id/reports/financial-year
+// April to March summary of the temple's accounts: donations by type, seva
+// income, expenses, month-wise totals and opening and closing balances.
+// fy=2024 or fy=2024-25 picks the year (default the current one);
+// ?format=excel|pdf exports it for auditors.
+func (h *Handler) GetFinancialYearReport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity_id"})
+		return
+	}
+	if !h.canAccessEntity(ctx, uint(entityID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for this entity"})
+		return
+	}
+
+	format := c.Query("format")
+	if format != "" && format != FormatExcel && format != FormatPDF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be excel or pdf"})
+		return
+	}
+	if !h.allowExportFormat(c, ctx, ReportTypeFinancialYear, format) {
+		return
+	}
+
+	startYear, err := parseFinancialYear(c.Query("fy"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "fy"})
+		return
+	}
+	req := FinancialYearReportRequest{StartYear: startYear, Format: format}
+
+	if format == "" {
+		summary, err := h.service.GetFinancialYearSummary(uint(entityID), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &summary)
+		eid := uint(entityID)
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, &eid, "FINANCIAL_YEAR_REPORT_VIEWED", map[string]interface{}{
+			"report_type":    ReportTypeFinancialYear,
+			"financial_year": summary.FinancialYear,
+		}, ip, "success")
+		c.JSON(http.StatusOK, gin.H{"report_type": ReportTypeFinancialYear, "data": summary})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportFinancialYearSummary(h.exportContext(c, ctx), uint(entityID), req, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
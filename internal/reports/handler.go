@@ -2,6 +2,7 @@ package reports
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -44,7 +45,7 @@ func (h *Handler) GetActivities(c *gin.Context) {
 	entityParam := c.Param("id") // either "all" or numeric id
 	reportType := c.Query("type")
 	if reportType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|waitlist|disputes|campaigns|recurring-donations|hundi-collection|volunteers|rsvps"})
 		return
 	}
 	dateRange := c.Query("date_range")
@@ -53,12 +54,29 @@ func (h *Handler) GetActivities(c *gin.Context) {
 	}
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	format := c.Query("format") // excel, csv, pdf -> if empty return JSON
+	format := c.Query("format") // excel, csv, pdf, tally, ledger -> if empty return JSON
+	if isAccountingFormat(format) && reportType != ReportTypeDonations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAccountingFormatUnsupported.Error()})
+		return
+	}
+	if !h.allowExportFormat(c, ctx, reportType, format) {
+		return
+	}
 
 	// compute start & end
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(reportType, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	tithis, err := parseTithiFilter(c, reportType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "tithi"})
+		return
+	}
+	groupBy, err := parseGroupBy(c, reportType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "group_by"})
 		return
 	}
 
@@ -97,8 +115,8 @@ case "superadmin":
 				return
 			}
 		case "templeadmin":
-			// Templeadmin can access their own entities - use their user ID as tenant ID
-			tenantID = ctx.UserID
+			// Templeadmin can access every temple of their tenant
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user entities"})
@@ -113,11 +131,11 @@ case "superadmin":
 			}
 		case "standarduser", "monitoringuser":
 			// standarduser/monitoringuser get all entities for their assigned tenant
-			if ctx.AssignedEntityID == nil {
+			if ctx.TenantID == 0 {
 				c.JSON(http.StatusForbidden, gin.H{"error": "no accessible entity"})
 				return
 			}
-			tenantID = *ctx.AssignedEntityID
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tenant entities"})
@@ -161,15 +179,25 @@ case "superadmin":
 		EndDate:   end,
 		Format:    format,
 		EntityIDs: entityIDs, // Pass the resolved entity IDs
+		Tithis:    tithis,
+		GroupBy:   groupBy,
 	}
 
 	// If no format -> return JSON preview
 	if req.Format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetActivities(req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view (optional - for JSON preview)
 		details := map[string]interface{}{
@@ -182,13 +210,14 @@ case "superadmin":
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "TEMPLE_ACTIVITIES_REPORT_VIEWED", details, ip, "success")
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 	fmt.Println("Calling ExportActivities")
 
 	// Else export file (format present)
-	bytes, fname, mime, err := h.service.ExportActivities(c.Request.Context(), req, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportActivities(h.exportContext(c, ctx), req, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -219,7 +248,7 @@ func (h *Handler) GetSuperAdminActivities(c *gin.Context) {
 	// Get request parameters
 	reportType := c.Query("type")
 	if reportType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|waitlist|disputes|campaigns|recurring-donations|hundi-collection|volunteers|rsvps"})
 		return
 	}
 
@@ -256,12 +285,29 @@ func (h *Handler) GetSuperAdminActivities(c *gin.Context) {
 	}
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	format := c.Query("format") // excel, csv, pdf -> if empty return JSON
+	format := c.Query("format") // excel, csv, pdf, tally, ledger -> if empty return JSON
+	if isAccountingFormat(format) && reportType != ReportTypeDonations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAccountingFormatUnsupported.Error()})
+		return
+	}
+	if !h.allowExportFormat(c, ctx, reportType, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(reportType, dateRange, startDateStr, endDateStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	tithis, err := parseTithiFilter(c, reportType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "tithi"})
+		return
+	}
+	groupBy, err := parseGroupBy(c, reportType)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "group_by"})
 		return
 	}
 
@@ -308,15 +354,25 @@ func (h *Handler) GetSuperAdminActivities(c *gin.Context) {
 		EndDate:   end,
 		Format:    format,
 		EntityIDs: allEntityIDs,
+		Tithis:    tithis,
+		GroupBy:   groupBy,
 	}
 
 	// If no format -> return JSON preview
 	if req.Format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetActivities(req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view
 		details := map[string]interface{}{
@@ -329,12 +385,13 @@ func (h *Handler) GetSuperAdminActivities(c *gin.Context) {
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_ACTIVITIES_REPORT_VIEWED", details, ip, "success")
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
 	// Else export file (format present)
-	bytes, fname, mime, err := h.service.ExportActivities(c.Request.Context(), req, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportActivities(h.exportContext(c, ctx), req, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -372,7 +429,7 @@ func (h *Handler) GetSuperAdminTenantActivities(c *gin.Context) {
 
 	reportType := c.Query("type")
 	if reportType == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations"})
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|waitlist|disputes|campaigns|recurring-donations|hundi-collection|volunteers|rsvps"})
 		return
 	}
 
@@ -382,12 +439,29 @@ func (h *Handler) GetSuperAdminTenantActivities(c *gin.Context) {
 	}
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
-	format := c.Query("format") // excel, csv, pdf -> if empty return JSON
+	format := c.Query("format") // excel, csv, pdf, tally, ledger -> if empty return JSON
+	if isAccountingFormat(format) && reportType != ReportTypeDonations {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrAccountingFormatUnsupported.Error()})
+		return
+	}
+	if !h.allowExportFormat(c, ctx, reportType, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(reportType, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	tithis, err := parseTithiFilter(c, reportType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "tithi"})
+		return
+	}
+	groupBy, err := parseGroupBy(c, reportType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "group_by"})
 		return
 	}
 
@@ -432,17 +506,27 @@ func (h *Handler) GetSuperAdminTenantActivities(c *gin.Context) {
 		EndDate:   end,
 		Format:    format,
 		EntityIDs: entityIDStrs, // All entities belonging to this tenant
+		Tithis:    tithis,
+		GroupBy:   groupBy,
 		// If your struct supports it, you might want to add:
 		// TenantID: uint(tenantIDUint),
 	}
 
 	// If no format -> return JSON preview
 	if req.Format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetActivities(req)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view with proper tenant context
 		details := map[string]interface{}{
@@ -456,12 +540,13 @@ func (h *Handler) GetSuperAdminTenantActivities(c *gin.Context) {
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TENANT_ACTIVITIES_REPORT_VIEWED", details, ip, "success")
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
 	// Else export file (format present)
-	bytes, fname, mime, err := h.service.ExportActivities(c.Request.Context(), req, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportActivities(h.exportContext(c, ctx), req, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -495,10 +580,13 @@ func (h *Handler) GetTempleRegisteredReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // approve|rejected|pending
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeTempleRegistered, format) {
+		return
+	}
 
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeTempleRegistered, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 	fmt.Println("entering GetTempleRegisteredReport3:")
@@ -537,8 +625,8 @@ case "superadmin":
 				return
 			}
 		case "templeadmin":
-			// For templeadmin, use their user ID as tenant ID
-			tenantID = ctx.UserID
+			// Templeadmin: every temple of their tenant
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user entities"})
@@ -552,8 +640,8 @@ case "superadmin":
 				entityIDs = append(entityIDs, fmt.Sprint(id))
 			}
 		case "standarduser", "monitoringuser":
-			// standarduser/monitoringuser use their assigned entity as tenant
-			tenantID = *ctx.AssignedEntityID
+			// standarduser/monitoringuser use their assigned tenant
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user entities"})
@@ -608,11 +696,19 @@ case "superadmin":
 	default:
 		fmt.Println("------>DEFAULT")
 		// If no format is specified, return JSON preview
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetTempleRegisteredReport(req, entityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view (optional - for JSON preview)
 		details := map[string]interface{}{
@@ -625,12 +721,13 @@ case "superadmin":
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "TEMPLE_REGISTER_REPORT_VIEWED", details, ip, "success")
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
 	// Export file (format is present)
-	bytes, fname, mime, err := h.service.ExportTempleRegisteredReport(c.Request.Context(), req, entityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportTempleRegisteredReport(h.exportContext(c, ctx), req, entityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -675,11 +772,14 @@ func (h *Handler) GetSuperAdminTempleRegisteredReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // approve|rejected|pending
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeTempleRegistered, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeTempleRegistered, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -740,11 +840,19 @@ func (h *Handler) GetSuperAdminTempleRegisteredReport(c *gin.Context) {
 		reportType = ReportTypeTempleRegistered
 	default:
 		// If no format is specified, return JSON preview
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetTempleRegisteredReport(req, allEntityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view
 		details := map[string]interface{}{
@@ -757,12 +865,13 @@ func (h *Handler) GetSuperAdminTempleRegisteredReport(c *gin.Context) {
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TEMPLE_REGISTER_REPORT_VIEWED", details, ip, "success")
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
 	// Export file (format is present)
-	bytes, fname, mime, err := h.service.ExportTempleRegisteredReport(c.Request.Context(), req, allEntityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportTempleRegisteredReport(h.exportContext(c, ctx), req, allEntityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -806,11 +915,14 @@ func (h *Handler) GetSuperAdminTenantTempleRegisteredReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // approve|rejected|pending
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeTempleRegistered, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeTempleRegistered, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -861,11 +973,19 @@ func (h *Handler) GetSuperAdminTenantTempleRegisteredReport(c *gin.Context) {
 		reportType = ReportTypeTempleRegistered
 	default:
 		// If no format is specified, return JSON preview
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetTempleRegisteredReport(req, entityIDStrs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view
 		details := map[string]interface{}{
@@ -878,12 +998,13 @@ func (h *Handler) GetSuperAdminTenantTempleRegisteredReport(c *gin.Context) {
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TENANT_TEMPLE_REGISTER_REPORT_VIEWED", details, ip, "success")
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
 	// Export file (format is present)
-	bytes, fname, mime, err := h.service.ExportTempleRegisteredReport(c.Request.Context(), req, entityIDStrs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportTempleRegisteredReport(h.exportContext(c, ctx), req, entityIDStrs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -915,10 +1036,13 @@ func (h *Handler) GetDevoteeBirthdaysReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeBirthdays, format) {
+		return
+	}
 
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeBirthdays, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -948,17 +1072,17 @@ func (h *Handler) GetDevoteeBirthdaysReport(c *gin.Context) {
 				return
 			}
 		case "templeadmin":
-			ids, err = h.repo.GetEntitiesByTenant(ctx.UserID)
+			ids, err = h.repo.GetEntitiesByTenant(ctx.TenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch temple admin entities"})
 				return
 			}
 		case "standarduser", "monitoringuser":
-			if ctx.AssignedEntityID == nil {
+			if ctx.TenantID == 0 {
 				c.JSON(http.StatusForbidden, gin.H{"error": "no assigned entity"})
 				return
 			}
-			ids, err = h.repo.GetEntitiesByTenant(*ctx.AssignedEntityID)
+			ids, err = h.repo.GetEntitiesByTenant(ctx.TenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tenant entities"})
 				return
@@ -1019,12 +1143,20 @@ func (h *Handler) GetDevoteeBirthdaysReport(c *gin.Context) {
 	default:
 		// JSON preview
 		fmt.Println("[BIRTHDAY REPORT] Fetching JSON preview data...")
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeBirthdaysReport(req, entityIDs)
 		if err != nil {
 			fmt.Printf("[BIRTHDAY REPORT] Error fetching data: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		fmt.Printf("[BIRTHDAY REPORT] JSON preview - Record count: %d\n", len(data))
 
@@ -1041,6 +1173,7 @@ func (h *Handler) GetDevoteeBirthdaysReport(c *gin.Context) {
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "DEVOTEE_BIRTHDAYS_REPORT_VIEWED", details, ip, "success")
 
 		c.JSON(http.StatusOK, gin.H{
+			"pagination": req.Page.Info(),
 			"data": data,
 			"meta": gin.H{
 				"entity_ids": entityIDs,
@@ -1057,7 +1190,7 @@ func (h *Handler) GetDevoteeBirthdaysReport(c *gin.Context) {
 	fmt.Printf("[BIRTHDAY REPORT] Export request - EntityIDs: %v, DateRange: %s to %s\n", 
 		entityIDs, start.Format("2006-01-02"), end.Format("2006-01-02"))
 	
-	bytes, fname, mime, err := h.service.ExportDevoteeBirthdaysReport(c.Request.Context(), req, entityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportDevoteeBirthdaysReport(h.exportContext(c, ctx), req, entityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		fmt.Printf("[BIRTHDAY REPORT] Export error: %v\n", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -1106,11 +1239,14 @@ func (h *Handler) GetSuperAdminDevoteeBirthdaysReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeBirthdays, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeBirthdays, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -1172,11 +1308,19 @@ func (h *Handler) GetSuperAdminDevoteeBirthdaysReport(c *gin.Context) {
 		reportType = ReportTypeDevoteeBirthdays
 	default:
 		// If no format is specified, return JSON preview
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeBirthdaysReport(req, allEntityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view
 		details := map[string]interface{}{
@@ -1191,6 +1335,7 @@ func (h *Handler) GetSuperAdminDevoteeBirthdaysReport(c *gin.Context) {
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_DEVOTEE_BIRTHDAYS_REPORT_VIEWED", details, ip, "success")
 
 		c.JSON(http.StatusOK, gin.H{
+			"pagination": req.Page.Info(),
 			"data": data,
 			"meta": gin.H{
 				"tenant_ids": validTenantIDs,
@@ -1204,7 +1349,7 @@ func (h *Handler) GetSuperAdminDevoteeBirthdaysReport(c *gin.Context) {
 	}
 
 	// Export file (format is present)
-	bytes, fname, mime, err := h.service.ExportDevoteeBirthdaysReport(c.Request.Context(), req, allEntityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportDevoteeBirthdaysReport(h.exportContext(c, ctx), req, allEntityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1247,11 +1392,14 @@ func (h *Handler) GetSuperAdminTenantDevoteeBirthdaysReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeBirthdays, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeBirthdays, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -1303,11 +1451,19 @@ func (h *Handler) GetSuperAdminTenantDevoteeBirthdaysReport(c *gin.Context) {
 		reportType = ReportTypeDevoteeBirthdays
 	default:
 		// If no format is specified, return JSON preview
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeBirthdaysReport(req, entityIDStrs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log report view
 		details := map[string]interface{}{
@@ -1322,6 +1478,7 @@ func (h *Handler) GetSuperAdminTenantDevoteeBirthdaysReport(c *gin.Context) {
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TENANT_DEVOTEE_BIRTHDAYS_REPORT_VIEWED", details, ip, "success")
 
 		c.JSON(http.StatusOK, gin.H{
+			"pagination": req.Page.Info(),
 			"data": data,
 			"meta": gin.H{
 				"tenant_id":  tenantID,
@@ -1335,7 +1492,7 @@ func (h *Handler) GetSuperAdminTenantDevoteeBirthdaysReport(c *gin.Context) {
 	}
 
 	// Export file (format is present)
-	bytes, fname, mime, err := h.service.ExportDevoteeBirthdaysReport(c.Request.Context(), req, entityIDStrs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportDevoteeBirthdaysReport(h.exportContext(c, ctx), req, entityIDStrs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1367,10 +1524,13 @@ func (h *Handler) GetDevoteeListReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // active|inactive|blocked etc
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeList, format) {
+		return
+	}
 
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeList, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -1405,8 +1565,8 @@ case "superadmin":
 				return
 			}
 		case "templeadmin":
-			// Templeadmin uses their user ID as tenant ID
-			tenantID = ctx.UserID
+			// Templeadmin: every temple of their tenant
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user entities"})
@@ -1420,12 +1580,12 @@ case "superadmin":
 				entityIDs = append(entityIDs, fmt.Sprint(id))
 			}
 		case "standarduser", "monitoringuser":
-			// standarduser/monitoringuser use their assigned entity as tenant
-			if ctx.AssignedEntityID == nil {
+			// standarduser/monitoringuser use their assigned tenant
+			if ctx.TenantID == 0 {
 				c.JSON(http.StatusForbidden, gin.H{"error": "no accessible entity"})
 				return
 			}
-			tenantID = *ctx.AssignedEntityID
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tenant entities"})
@@ -1480,11 +1640,19 @@ case "superadmin":
 	case "csv":
 		reportType = ReportTypeDevoteeListCSV
 	default:
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeListReport(req, entityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type":  "devotee_list",
@@ -1495,11 +1663,25 @@ case "superadmin":
 			"date_range":   dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "DEVOTEE_LIST_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportDevoteeListReport(c.Request.Context(), req, entityIDs, reportType, &ctx.UserID, ip)
+	if wantsStream(c, format) {
+		details := map[string]interface{}{
+			"report_type": "devotee_list",
+			"entity_ids":  entityIDs,
+			"status":      status,
+		}
+		h.streamResponse(c, "devotee_list", format, details, "DEVOTEE_LIST_REPORT_DOWNLOADED", "DEVOTEE_LIST_REPORT_DOWNLOAD_FAILED",
+			func(w io.Writer, progress func(done, total int64)) (int64, error) {
+				return h.service.StreamDevoteeList(h.exportContext(c, ctx), w, format, req, entityIDs, progress)
+			})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportDevoteeListReport(h.exportContext(c, ctx), req, entityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1545,11 +1727,14 @@ func (h *Handler) GetSuperAdminDevoteeListReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // active|inactive|blocked etc
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeList, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeList, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -1607,11 +1792,19 @@ func (h *Handler) GetSuperAdminDevoteeListReport(c *gin.Context) {
 	case "csv":
 		reportType = ReportTypeDevoteeListCSV
 	default:
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeListReport(req, allEntityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type": "devotee_list",
@@ -1622,11 +1815,26 @@ func (h *Handler) GetSuperAdminDevoteeListReport(c *gin.Context) {
 			"date_range":  dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_DEVOTEE_LIST_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportDevoteeListReport(c.Request.Context(), req, allEntityIDs, reportType, &ctx.UserID, ip)
+	if wantsStream(c, format) {
+		details := map[string]interface{}{
+			"report_type": "devotee_list",
+			"tenant_ids":  validTenantIDs,
+			"entity_ids":  allEntityIDs,
+			"status":      status,
+		}
+		h.streamResponse(c, "devotee_list", format, details, "DEVOTEE_LIST_REPORT_DOWNLOADED", "DEVOTEE_LIST_REPORT_DOWNLOAD_FAILED",
+			func(w io.Writer, progress func(done, total int64)) (int64, error) {
+				return h.service.StreamDevoteeList(h.exportContext(c, ctx), w, format, req, allEntityIDs, progress)
+			})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportDevoteeListReport(h.exportContext(c, ctx), req, allEntityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1670,11 +1878,14 @@ func (h *Handler) GetSuperAdminTenantDevoteeListReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // active|inactive|blocked etc
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeList, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeList, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -1722,11 +1933,19 @@ func (h *Handler) GetSuperAdminTenantDevoteeListReport(c *gin.Context) {
 	case "csv":
 		reportType = ReportTypeDevoteeListCSV
 	default:
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeListReport(req, entityIDStrs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type": "devotee_list",
@@ -1737,11 +1956,26 @@ func (h *Handler) GetSuperAdminTenantDevoteeListReport(c *gin.Context) {
 			"date_range":  dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TENANT_DEVOTEE_LIST_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportDevoteeListReport(c.Request.Context(), req, entityIDStrs, reportType, &ctx.UserID, ip)
+	if wantsStream(c, format) {
+		details := map[string]interface{}{
+			"report_type": "devotee_list",
+			"tenant_id":   tenantID,
+			"entity_ids":  entityIDStrs,
+			"status":      status,
+		}
+		h.streamResponse(c, "devotee_list", format, details, "DEVOTEE_LIST_REPORT_DOWNLOADED", "DEVOTEE_LIST_REPORT_DOWNLOAD_FAILED",
+			func(w io.Writer, progress func(done, total int64)) (int64, error) {
+				return h.service.StreamDevoteeList(h.exportContext(c, ctx), w, format, req, entityIDStrs, progress)
+			})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportDevoteeListReport(h.exportContext(c, ctx), req, entityIDStrs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1773,10 +2007,13 @@ func (h *Handler) GetDevoteeProfileReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // active|inactive|blocked etc
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeProfile, format) {
+		return
+	}
 
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeProfile, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -1811,8 +2048,8 @@ case "superadmin":
 				return
 			}
 		case "templeadmin":
-			// Templeadmin uses their user ID as tenant ID
-			tenantID = ctx.UserID
+			// Templeadmin: every temple of their tenant
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user entities"})
@@ -1826,12 +2063,12 @@ case "superadmin":
 				entityIDs = append(entityIDs, fmt.Sprint(id))
 			}
 		case "standarduser", "monitoringuser":
-			// standarduser/monitoringuser use their assigned entity as tenant
-			if ctx.AssignedEntityID == nil {
+			// standarduser/monitoringuser use their assigned tenant
+			if ctx.TenantID == 0 {
 				c.JSON(http.StatusForbidden, gin.H{"error": "no accessible entity"})
 				return
 			}
-			tenantID = *ctx.AssignedEntityID
+			tenantID = ctx.TenantID
 			ids, err := h.repo.GetEntitiesByTenant(tenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch tenant entities"})
@@ -1883,11 +2120,19 @@ case "superadmin":
 	case "csv":
 		reportType = ReportTypeDevoteeProfileCSV
 	default:
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeProfileReport(req, entityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type":  "devotee_profile",
@@ -1898,11 +2143,12 @@ case "superadmin":
 			"date_range":   dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "DEVOTEE_PROFILE_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportDevoteeProfileReport(c.Request.Context(), req, entityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportDevoteeProfileReport(h.exportContext(c, ctx), req, entityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -1947,11 +2193,14 @@ func (h *Handler) GetSuperAdminDevoteeProfileReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // active|inactive|blocked etc
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeProfile, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeProfile, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -2009,11 +2258,19 @@ func (h *Handler) GetSuperAdminDevoteeProfileReport(c *gin.Context) {
 	case "csv":
 		reportType = ReportTypeDevoteeProfileCSV
 	default:
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeProfileReport(req, allEntityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type": "devotee_profile",
@@ -2024,11 +2281,12 @@ func (h *Handler) GetSuperAdminDevoteeProfileReport(c *gin.Context) {
 			"date_range":  dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_DEVOTEE_PROFILE_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportDevoteeProfileReport(c.Request.Context(), req, allEntityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportDevoteeProfileReport(h.exportContext(c, ctx), req, allEntityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2072,11 +2330,14 @@ func (h *Handler) GetSuperAdminTenantDevoteeProfileReport(c *gin.Context) {
 	endDateStr := c.Query("end_date")
 	status := c.Query("status") // active|inactive|blocked etc
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeDevoteeProfile, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeDevoteeProfile, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -2124,11 +2385,19 @@ func (h *Handler) GetSuperAdminTenantDevoteeProfileReport(c *gin.Context) {
 	case "csv":
 		reportType = ReportTypeDevoteeProfileCSV
 	default:
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetDevoteeProfileReport(req, entityIDStrs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type": "devotee_profile",
@@ -2139,11 +2408,12 @@ func (h *Handler) GetSuperAdminTenantDevoteeProfileReport(c *gin.Context) {
 			"date_range":  dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TENANT_DEVOTEE_PROFILE_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportDevoteeProfileReport(c.Request.Context(), req, entityIDStrs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportDevoteeProfileReport(h.exportContext(c, ctx), req, entityIDStrs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2161,7 +2431,7 @@ func (h *Handler) canAccessEntity(ctx middleware.AccessContext, entityID uint) b
 
 	if ctx.RoleName == "templeadmin" {
 		// Templeadmin can access only entities they created
-		ids, err := h.repo.GetEntitiesByTenant(ctx.UserID)
+		ids, err := h.repo.GetEntitiesByTenant(ctx.TenantID)
 		fmt.Println("entity:", ids, ctx.UserID)
 		if err != nil {
 			fmt.Println("err1=", err)
@@ -2216,6 +2486,9 @@ func (h *Handler) GetAuditLogsReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeAuditLogs, format) {
+		return
+	}
 
 	// 🔍 DEBUG: Log the received parameters
 	fmt.Printf("\n🔍 DEBUG: Audit Logs Request Parameters\n")
@@ -2226,9 +2499,9 @@ func (h *Handler) GetAuditLogsReport(c *gin.Context) {
 	fmt.Printf("   Date Range: %s\n", dateRange)
 	fmt.Printf("   Format: %s\n", format)
 
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeAuditLogs, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -2248,7 +2521,7 @@ func (h *Handler) GetAuditLogsReport(c *gin.Context) {
 			}
 
 		case "templeadmin":
-			ids, err := h.repo.GetEntitiesByTenant(ctx.UserID)
+			ids, err := h.repo.GetEntitiesByTenant(ctx.TenantID)
 			if err != nil {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch admin entities"})
 				return
@@ -2312,12 +2585,20 @@ func (h *Handler) GetAuditLogsReport(c *gin.Context) {
 
 	// JSON preview (no export format)
 	if format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetAuditLogsReport(req, entityIDs)
 		if err != nil {
 			fmt.Printf("   ❌ Error fetching audit logs: %v\n", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		fmt.Printf("   ✅ Fetched %d audit log records\n", len(data))
 
@@ -2339,6 +2620,7 @@ func (h *Handler) GetAuditLogsReport(c *gin.Context) {
 			"success",
 		)
 
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
@@ -2357,8 +2639,22 @@ func (h *Handler) GetAuditLogsReport(c *gin.Context) {
 		return
 	}
 
+	if wantsStream(c, format) {
+		details := map[string]interface{}{
+			"report_type": "audit_logs",
+			"entity_ids":  entityIDs,
+			"action":      action,
+			"status":      status,
+		}
+		h.streamResponse(c, "audit_logs", format, details, "AUDIT_LOGS_REPORT_DOWNLOADED", "AUDIT_LOGS_REPORT_DOWNLOAD_FAILED",
+			func(w io.Writer, progress func(done, total int64)) (int64, error) {
+				return h.service.StreamAuditLogs(h.exportContext(c, ctx), w, format, req, entityIDs, progress)
+			})
+		return
+	}
+
 	bytes, fname, mime, err := h.service.ExportAuditLogsReport(
-		c.Request.Context(),
+		h.exportContext(c, ctx),
 		req,
 		entityIDs,
 		reportType,
@@ -2411,11 +2707,14 @@ func (h *Handler) GetSuperAdminAuditLogsReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format") // json preview, csv, excel, pdf
+	if !h.allowExportFormat(c, ctx, ReportTypeAuditLogs, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeAuditLogs, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -2457,11 +2756,19 @@ func (h *Handler) GetSuperAdminAuditLogsReport(c *gin.Context) {
 
 	// Handle JSON preview
 	if format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetAuditLogsReport(req, allEntityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type": "audit_logs",
@@ -2473,6 +2780,7 @@ func (h *Handler) GetSuperAdminAuditLogsReport(c *gin.Context) {
 			"date_range":  dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_AUDIT_LOGS_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
@@ -2491,7 +2799,22 @@ func (h *Handler) GetSuperAdminAuditLogsReport(c *gin.Context) {
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportAuditLogsReport(c.Request.Context(), req, allEntityIDs, reportType, &ctx.UserID, ip)
+	if wantsStream(c, format) {
+		details := map[string]interface{}{
+			"report_type": "audit_logs",
+			"tenant_ids":  tenantIDs,
+			"entity_ids":  allEntityIDs,
+			"action":      action,
+			"status":      status,
+		}
+		h.streamResponse(c, "audit_logs", format, details, "AUDIT_LOGS_REPORT_DOWNLOADED", "AUDIT_LOGS_REPORT_DOWNLOAD_FAILED",
+			func(w io.Writer, progress func(done, total int64)) (int64, error) {
+				return h.service.StreamAuditLogs(h.exportContext(c, ctx), w, format, req, allEntityIDs, progress)
+			})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportAuditLogsReport(h.exportContext(c, ctx), req, allEntityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2536,11 +2859,14 @@ func (h *Handler) GetSuperAdminTenantAuditLogsReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format") // json preview, csv, excel, pdf
+	if !h.allowExportFormat(c, ctx, ReportTypeAuditLogs, format) {
+		return
+	}
 
 	// Compute date range
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeAuditLogs, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -2582,11 +2908,19 @@ func (h *Handler) GetSuperAdminTenantAuditLogsReport(c *gin.Context) {
 
 	// Handle JSON preview
 	if format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetAuditLogsReport(req, entityIDStrs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		details := map[string]interface{}{
 			"report_type": "audit_logs",
@@ -2598,6 +2932,7 @@ func (h *Handler) GetSuperAdminTenantAuditLogsReport(c *gin.Context) {
 			"date_range":  dateRange,
 		}
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "SUPERADMIN_TENANT_AUDIT_LOGS_REPORT_VIEWED", details, ip, "success")
+		setPageHeaders(c, req.Page)
 		c.JSON(http.StatusOK, data)
 		return
 	}
@@ -2616,7 +2951,22 @@ func (h *Handler) GetSuperAdminTenantAuditLogsReport(c *gin.Context) {
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportAuditLogsReport(c.Request.Context(), req, entityIDStrs, reportType, &ctx.UserID, ip)
+	if wantsStream(c, format) {
+		details := map[string]interface{}{
+			"report_type": "audit_logs",
+			"tenant_id":   tenantID,
+			"entity_ids":  entityIDStrs,
+			"action":      action,
+			"status":      status,
+		}
+		h.streamResponse(c, "audit_logs", format, details, "AUDIT_LOGS_REPORT_DOWNLOADED", "AUDIT_LOGS_REPORT_DOWNLOAD_FAILED",
+			func(w io.Writer, progress func(done, total int64)) (int64, error) {
+				return h.service.StreamAuditLogs(h.exportContext(c, ctx), w, format, req, entityIDStrs, progress)
+			})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportAuditLogsReport(h.exportContext(c, ctx), req, entityIDStrs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -2646,6 +2996,9 @@ func (h *Handler) GetApprovalStatusReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format") // excel, csv, pdf -> empty = JSON
+	if !h.allowExportFormat(c, ctx, ReportTypeApprovalStatus, format) {
+		return
+	}
 
 	fmt.Printf("\n📋 Handler: Processing approval status report\n")
 	fmt.Printf("   Role: '%s'\n", role)
@@ -2658,9 +3011,9 @@ func (h *Handler) GetApprovalStatusReport(c *gin.Context) {
 	var err error
 
 	if startDateStr != "" && endDateStr != "" {
-		start, end, err = GetDateRange(dateRange, startDateStr, endDateStr)
+		start, end, err = GetDateRange(ReportTypeApprovalStatus, dateRange, startDateStr, endDateStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 			return
 		}
 		fmt.Printf("   ✅ Date filter: %s to %s\n", start.Format("2006-01-02"), end.Format("2006-01-02"))
@@ -2692,7 +3045,7 @@ func (h *Handler) GetApprovalStatusReport(c *gin.Context) {
 		
 	case "templeadmin":
 		// Temple admin can only see their own entities
-		ids, err := h.repo.GetEntitiesByTenant(ctx.UserID)
+		ids, err := h.repo.GetEntitiesByTenant(ctx.TenantID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch temple entities"})
 			return
@@ -2709,11 +3062,19 @@ func (h *Handler) GetApprovalStatusReport(c *gin.Context) {
 
 	// Return JSON preview if format not specified
 	if req.Format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetApprovalStatusReport(req, entityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 
 		// Log the view action
 		h.auditSvc.LogAction(
@@ -2734,6 +3095,7 @@ func (h *Handler) GetApprovalStatusReport(c *gin.Context) {
 		)
 
 		c.JSON(http.StatusOK, gin.H{
+			"pagination": req.Page.Info(),
 			"report_type": "approval-status",
 			"data":        data,
 			"meta": gin.H{
@@ -2763,7 +3125,7 @@ func (h *Handler) GetApprovalStatusReport(c *gin.Context) {
 
 	// Export report if format is specified
 	bytes, fname, mime, err := h.service.ExportApprovalStatusReport(
-		c.Request.Context(),
+		h.exportContext(c, ctx),
 		req,
 		entityIDs,
 		reportType,
@@ -2803,11 +3165,14 @@ func (h *Handler) GetUserDetailsReport(c *gin.Context) {
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
 	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeUserDetails, format) {
+		return
+	}
 
 	// Compute start & end
-	start, end, err := GetDateRange(dateRange, startDateStr, endDateStr)
+	start, end, err := GetDateRange(ReportTypeUserDetails, dateRange, startDateStr, endDateStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
 		return
 	}
 
@@ -2827,7 +3192,7 @@ func (h *Handler) GetUserDetailsReport(c *gin.Context) {
 	case "superadmin":
 		entityIDs = nil // all entities
 	case "templeadmin":
-		ids, err := h.repo.GetEntitiesByTenant(ctx.UserID)
+		ids, err := h.repo.GetEntitiesByTenant(ctx.TenantID)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch user entities"})
 			return
@@ -2846,11 +3211,19 @@ func (h *Handler) GetUserDetailsReport(c *gin.Context) {
 
 	// JSON preview
 	if req.Format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
 		data, err := h.service.GetUserDetailsReport(req, entityIDs)
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
+		h.maskPreview(ctx, &data)
 		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "USER_DETAILS_REPORT_VIEWED", map[string]interface{}{
 			"report_type": "user_details",
 			"entity_ids":  entityIDs,
@@ -2859,6 +3232,7 @@ func (h *Handler) GetUserDetailsReport(c *gin.Context) {
 			"date_range":  req.DateRange,
 		}, ip, "success")
 		c.JSON(http.StatusOK, gin.H{
+			"pagination": req.Page.Info(),
 			"report_type": "user-details",
 			"data":        data,
 		})
@@ -2880,7 +3254,7 @@ func (h *Handler) GetUserDetailsReport(c *gin.Context) {
 		return
 	}
 
-	bytes, fname, mime, err := h.service.ExportUserDetailsReport(c.Request.Context(), req, entityIDs, reportType, &ctx.UserID, ip)
+	bytes, fname, mime, err := h.service.ExportUserDetailsReport(h.exportContext(c, ctx), req, entityIDs, reportType, &ctx.UserID, ip)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
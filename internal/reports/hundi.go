@@ -0,0 +1,177 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetHundiCollections returns the temples' hundi counts made in the range
+// with their denominations, counters and any flagged discrepancy
+func (r *repository) GetHundiCollections(entityIDs []uint, start, end time.Time, page *PageRequest) ([]HundiCollectionReportRow, error) {
+	var out []HundiCollectionReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("hundi_counting_sessions s").
+		Select(`s.id, COALESCE(e.name, '') as temple_name, s.hundi_name, s.counted_on,
+			COALESCE((SELECT STRING_AGG((d->>'value') || ' x ' || (d->>'count'), ', ')
+				FROM jsonb_array_elements(s.denominations) d), '') as denominations,
+			COALESCE((SELECT STRING_AGG(n, ', ') FROM jsonb_array_elements_text(s.counters) n), '') as counters,
+			s.counted_total, s.declared_total, s.discrepancy, s.flagged, s.flag_reason, s.status,
+			COALESCE(rec.full_name, '') as recorded_by, COALESCE(rev.full_name, '') as reviewed_by,
+			s.reviewed_at, s.review_note`).
+		Joins("LEFT JOIN entities e ON e.id = s.entity_id").
+		Joins("LEFT JOIN users rec ON rec.id = s.recorded_by").
+		Joins("LEFT JOIN users rev ON rev.id = s.reviewed_by").
+		Where("s.entity_id IN ? AND s.deleted_at IS NULL", entityIDs).
+		Where("s.counted_on BETWEEN ?::date AND ?::date", start.Format("2006-01-02"), end.Format("2006-01-02"))
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"temple_name":   "temple_name",
+		"hundi_name":    "s.hundi_name",
+		"counted_on":    "s.counted_on",
+		"counted_total": "s.counted_total",
+		"discrepancy":   "s.discrepancy",
+		"status":        "s.status",
+	}, "s.counted_on DESC, s.id DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Hundi Collections by format
+func (e *reportExporter) exportHundiCollectionsByFormat(format, timestamp string, rows []HundiCollectionReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportHundiCollectionsExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("hundi_collection_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportHundiCollectionsCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("hundi_collection_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportHundiCollectionsPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("hundi_collection_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for hundi collection: %s", format)
+	}
+}
+
+var hundiCollectionHeaders = []string{"Count ID", "Temple Name", "Hundi", "Counted On", "Denominations", "Counters", "Counted Total", "Declared Total", "Discrepancy", "Flagged", "Status", "Recorded By", "Reviewed By"}
+
+func hundiCollectionRecord(row HundiCollectionReportRow) []string {
+	flagged := ""
+	if row.Flagged {
+		flagged = "Yes: " + row.FlagReason
+	}
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.TempleName,
+		row.HundiName,
+		row.CountedOn.Format("2006-01-02"),
+		row.Denominations,
+		row.Counters,
+		fmt.Sprintf("%.2f", row.CountedTotal),
+		fmt.Sprintf("%.2f", row.DeclaredTotal),
+		fmt.Sprintf("%.2f", row.Discrepancy),
+		flagged,
+		row.Status,
+		row.RecordedBy,
+		row.ReviewedBy,
+	}
+}
+
+func (e *reportExporter) exportHundiCollectionsExcel(rows []HundiCollectionReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Hundi Collection"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range hundiCollectionHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range hundiCollectionRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportHundiCollectionsCSV(rows []HundiCollectionReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(hundiCollectionHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(hundiCollectionRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportHundiCollectionsPDF(rows []HundiCollectionReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Hundi Collection Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 8)
+	widths := []float64{14, 28, 22, 20, 40, 30, 20, 20, 18, 24, 16, 22, 22}
+	for i, header := range hundiCollectionHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range hundiCollectionRecord(row) {
+			value = truncateText(value, 28)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
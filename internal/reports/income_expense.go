@@ -0,0 +1,325 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+	"gorm.io/gorm"
+)
+
+// GetIncomeExpense builds the temple's statement: successful donations by
+// type against approved expenses by category. Pending expenses are totalled
+// separately and left out of the net.
+func (r *repository) GetIncomeExpense(entityID uint, req IncomeExpenseReportRequest) (*IncomeExpenseStatement, error) {
+	out := &IncomeExpenseStatement{
+		EntityID:  entityID,
+		StartDate: req.StartDate.Format("2006-01-02"),
+		EndDate:   req.EndDate.Format("2006-01-02"),
+		Income:    []IncomeExpenseLine{},
+		Expenses:  []IncomeExpenseLine{},
+	}
+	if err := r.db.Table("entities").Select("name").Where("id = ?", entityID).Scan(&out.TempleName).Error; err != nil {
+		return nil, err
+	}
+
+	err := r.db.Table("donations").
+		Select("COALESCE(NULLIF(donation_type, ''), 'general') as category, COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+		Where("entity_id = ? AND status = ? AND deleted_at IS NULL", entityID, "SUCCESS").
+		Where("COALESCE(donated_at, created_at) BETWEEN ? AND ?", req.StartDate, req.EndDate).
+		Group("1").
+		Order("amount DESC").
+		Scan(&out.Income).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Expenses are dated by day, the range is compared on dates
+	expenses := r.db.Table("temple_expenses").
+		Where("entity_id = ? AND deleted_at IS NULL", entityID).
+		Where("expense_date BETWEEN ?::date AND ?::date", out.StartDate, out.EndDate)
+	err = expenses.Session(&gorm.Session{}).
+		Select("category, COUNT(*) as count, COALESCE(SUM(amount), 0) as amount").
+		Where("status = ?", "approved").
+		Group("category").
+		Order("amount DESC").
+		Scan(&out.Expenses).Error
+	if err != nil {
+		return nil, err
+	}
+	err = expenses.Session(&gorm.Session{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("status = ?", "pending").
+		Scan(&out.PendingExpenses).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range out.Income {
+		out.TotalIncome += line.Amount
+	}
+	for _, line := range out.Expenses {
+		out.TotalExpenses += line.Amount
+	}
+	out.Net = out.TotalIncome - out.TotalExpenses
+	return out, nil
+}
+
+// ===============================
+// Service
+// ===============================
+
+func (s *reportService) GetIncomeExpenseStatement(entityID uint, req IncomeExpenseReportRequest) (*IncomeExpenseStatement, error) {
+	return s.repo.GetIncomeExpense(entityID, req)
+}
+
+func (s *reportService) ExportIncomeExpenseStatement(ctx context.Context, entityID uint, req IncomeExpenseReportRequest, userID *uint, ip string) ([]byte, string, string, error) {
+	fail := func(err error) ([]byte, string, string, error) {
+		s.auditSvc.LogAction(ctx, userID, &entityID, "INCOME_EXPENSE_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type": ReportTypeIncomeExpense,
+			"format":      req.Format,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	var (
+		bytes              []byte
+		filename, mimeType string
+		recordCount        int
+	)
+	if isAccountingFormat(req.Format) {
+		// Vouchers are posted per donation and expense, not per line of the statement
+		txns, err := s.repo.GetLedgerTransactions(entityID, req)
+		if err != nil {
+			return fail(err)
+		}
+		bytes, filename, mimeType, err = s.exportVouchers(ReportTypeIncomeExpense, req.Format, []uint{entityID}, txns)
+		if err != nil {
+			return fail(err)
+		}
+		recordCount = len(txns)
+	} else {
+		statement, err := s.GetIncomeExpenseStatement(entityID, req)
+		if err != nil {
+			return fail(err)
+		}
+		bytes, filename, mimeType, err = s.render(ctx, ReportTypeIncomeExpense, req.Format, ReportData{IncomeExpense: statement})
+		if err != nil {
+			return fail(err)
+		}
+		recordCount = len(statement.Income) + len(statement.Expenses)
+	}
+
+	s.auditSvc.LogAction(ctx, userID, &entityID, "INCOME_EXPENSE_REPORT_DOWNLOADED", map[string]interface{}{
+		"report_type":  ReportTypeIncomeExpense,
+		"format":       req.Format,
+		"filename":     filename,
+		"date_range":   req.DateRange,
+		"record_count": recordCount,
+	}, ip, "success")
+	return bytes, filename, mimeType, nil
+}
+
+// ===============================
+// Exporter
+// ===============================
+
+func (e *reportExporter) exportIncomeExpenseByFormat(format, timestamp string, statement *IncomeExpenseStatement) ([]byte, string, string, error) {
+	if statement == nil {
+		return nil, "", "", fmt.Errorf("no income and expense statement to export")
+	}
+	switch format {
+	case FormatExcel:
+		data, err := e.exportIncomeExpenseExcel(statement)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("income_expense_statement_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatPDF:
+		data, err := e.exportIncomeExpensePDF(statement)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("income_expense_statement_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for income and expense statement: %s", format)
+	}
+}
+
+// categoryLabel turns a donation type or expense category into a heading,
+// e.g. pooja_supplies -> Pooja Supplies
+func categoryLabel(category string) string {
+	words := strings.Fields(strings.ReplaceAll(category, "_", " "))
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// incomeExpenseRows lays out the statement as label, count, amount rows;
+// a nil row is a blank line
+func incomeExpenseRows(s *IncomeExpenseStatement) [][]string {
+	money := func(v float64) string { return fmt.Sprintf("%.2f", v) }
+	rows := [][]string{{"Income", "Count", "Amount"}}
+	for _, line := range s.Income {
+		rows = append(rows, []string{categoryLabel(line.Category) + " donations", strconv.FormatInt(line.Count, 10), money(line.Amount)})
+	}
+	rows = append(rows, []string{"Total income", "", money(s.TotalIncome)}, nil, []string{"Expenses", "Count", "Amount"})
+	for _, line := range s.Expenses {
+		rows = append(rows, []string{categoryLabel(line.Category), strconv.FormatInt(line.Count, 10), money(line.Amount)})
+	}
+	netLabel := "Surplus"
+	if s.Net < 0 {
+		netLabel = "Deficit"
+	}
+	return append(rows,
+		[]string{"Total expenses", "", money(s.TotalExpenses)},
+		nil,
+		[]string{netLabel, "", money(s.Net)},
+		[]string{"Expenses awaiting approval (not included)", "", money(s.PendingExpenses)},
+	)
+}
+
+func (e *reportExporter) exportIncomeExpenseExcel(s *IncomeExpenseStatement) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Income vs Expense"
+	f.SetSheetName("Sheet1", sheetName)
+
+	f.SetCellValue(sheetName, "A1", "Income and Expense Statement - "+s.TempleName)
+	f.SetCellValue(sheetName, "A2", fmt.Sprintf("%s to %s", s.StartDate, s.EndDate))
+	for i, row := range incomeExpenseRows(s) {
+		for j, value := range row {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+4), value)
+		}
+	}
+	f.SetColWidth(sheetName, "A", "A", 40)
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportIncomeExpensePDF(s *IncomeExpenseStatement) ([]byte, error) {
+	pdf := i18n.NewPDF("P")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Income and Expense Statement")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(0, 8, fmt.Sprintf("%s, %s to %s", s.TempleName, s.StartDate, s.EndDate))
+	pdf.Ln(14)
+
+	widths := []float64{110, 25, 45}
+	for _, row := range incomeExpenseRows(s) {
+		if row == nil {
+			pdf.Ln(4)
+			continue
+		}
+		style := ""
+		if row[1] == "" || row[1] == "Count" {
+			style = "B"
+		}
+		pdf.SetFont("Arial", style, 10)
+		for i, value := range row {
+			align := "R"
+			if i == 0 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, value, "1", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ===============================
+// Handler
+// ===============================
+
+// GetIncomeExpenseReport - GET /entities/:id/reports/income-expense
+// Income (successful donations) against approved expenses over a date range
+// (date_range, start_date, end_date as for other reports, default monthly);
+// ?format=excel|pdf exports the statement; ?format=tally|ledger exports its
+// donations and expenses as vouchers.
+func (h *Handler) GetIncomeExpenseReport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity_id"})
+		return
+	}
+	if !h.canAccessEntity(ctx, uint(entityID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for this entity"})
+		return
+	}
+
+	format := c.Query("format")
+	if format != "" && format != FormatExcel && format != FormatPDF && !isAccountingFormat(format) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be excel, pdf, tally or ledger"})
+		return
+	}
+	if !h.allowExportFormat(c, ctx, ReportTypeIncomeExpense, format) {
+		return
+	}
+
+	dateRange := c.Query("date_range")
+	if dateRange == "" {
+		dateRange = DateRangeMonthly
+	}
+	start, end, err := GetDateRange(ReportTypeIncomeExpense, dateRange, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	req := IncomeExpenseReportRequest{DateRange: dateRange, StartDate: start, EndDate: end, Format: format}
+
+	if format == "" {
+		statement, err := h.service.GetIncomeExpenseStatement(uint(entityID), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &statement)
+		eid := uint(entityID)
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, &eid, "INCOME_EXPENSE_REPORT_VIEWED", map[string]interface{}{
+			"report_type": ReportTypeIncomeExpense,
+			"date_range":  dateRange,
+		}, ip, "success")
+		c.JSON(http.StatusOK, gin.H{"report_type": ReportTypeIncomeExpense, "data": statement})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportIncomeExpenseStatement(h.exportContext(c, ctx), uint(entityID), req, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
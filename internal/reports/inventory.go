@@ -0,0 +1,310 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetInventoryValuation values the temple's items at their average cost, with
+// what was purchased and consumed in the range
+func (r *repository) GetInventoryValuation(entityID uint, req InventoryValuationReportRequest) ([]InventoryValuationReportRow, error) {
+	var out []InventoryValuationReportRow
+	query := r.db.Table("inventory_items i").
+		Select(`i.id, i.name, i.kind, i.category, i.unit, i.quantity, i.unit_cost,
+			ROUND(i.quantity * i.unit_cost, 2) as stock_value, i.reorder_level,
+			(i.reorder_level > 0 AND i.quantity <= i.reorder_level) as low_stock,
+			COALESCE(SUM(m.quantity) FILTER (WHERE m.type = 'purchase'), 0) as purchased_quantity,
+			COALESCE(SUM(m.quantity * m.unit_cost) FILTER (WHERE m.type = 'purchase'), 0) as purchased_value,
+			COALESCE(-SUM(m.quantity) FILTER (WHERE m.type = 'consumption'), 0) as consumed_quantity,
+			COALESCE(-SUM(m.quantity * m.unit_cost) FILTER (WHERE m.type = 'consumption'), 0) as consumed_value`).
+		Joins("LEFT JOIN inventory_movements m ON m.item_id = i.id AND m.created_at BETWEEN ? AND ?", req.StartDate, req.EndDate).
+		Where("i.entity_id = ? AND i.deleted_at IS NULL", entityID).
+		Group("i.id").
+		Order("stock_value DESC, i.name ASC")
+	if req.Kind != "" {
+		query = query.Where("i.kind = ?", req.Kind)
+	}
+	if !req.IncludeInactive {
+		query = query.Where("i.is_active = ?", true)
+	}
+	err := query.Scan(&out).Error
+	return out, err
+}
+
+// ===============================
+// Service
+// ===============================
+
+func (s *reportService) GetInventoryValuation(entityID uint, req InventoryValuationReportRequest) ([]InventoryValuationReportRow, error) {
+	return s.repo.GetInventoryValuation(entityID, req)
+}
+
+func (s *reportService) ExportInventoryValuation(ctx context.Context, entityID uint, req InventoryValuationReportRequest, userID *uint, ip string) ([]byte, string, string, error) {
+	fail := func(err error) ([]byte, string, string, error) {
+		s.auditSvc.LogAction(ctx, userID, &entityID, "INVENTORY_VALUATION_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
+			"report_type": ReportTypeInventoryValuation,
+			"format":      req.Format,
+			"error":       err.Error(),
+		}, ip, "failure")
+		return nil, "", "", err
+	}
+
+	rows, err := s.GetInventoryValuation(entityID, req)
+	if err != nil {
+		return fail(err)
+	}
+	bytes, filename, mimeType, err := s.render(ctx, ReportTypeInventoryValuation, req.Format, ReportData{InventoryValuation: rows})
+	if err != nil {
+		return fail(err)
+	}
+
+	s.auditSvc.LogAction(ctx, userID, &entityID, "INVENTORY_VALUATION_REPORT_DOWNLOADED", map[string]interface{}{
+		"report_type":  ReportTypeInventoryValuation,
+		"format":       req.Format,
+		"filename":     filename,
+		"date_range":   req.DateRange,
+		"record_count": len(rows),
+	}, ip, "success")
+	return bytes, filename, mimeType, nil
+}
+
+// ===============================
+// Exporter
+// ===============================
+
+func (e *reportExporter) exportInventoryValuationByFormat(format, timestamp string, rows []InventoryValuationReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportInventoryValuationExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("inventory_valuation_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportInventoryValuationCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("inventory_valuation_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportInventoryValuationPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("inventory_valuation_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for inventory valuation: %s", format)
+	}
+}
+
+var inventoryValuationHeaders = []string{"Item ID", "Item", "Kind", "Category", "Unit", "In Stock", "Unit Cost", "Stock Value", "Reorder Level", "Low Stock", "Purchased", "Purchase Value", "Consumed", "Consumption Value"}
+
+func inventoryValuationRecord(row InventoryValuationReportRow) []string {
+	quantity := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	lowStock := ""
+	if row.LowStock {
+		lowStock = "Yes"
+	}
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.Name,
+		row.Kind,
+		row.Category,
+		row.Unit,
+		quantity(row.Quantity),
+		fmt.Sprintf("%.2f", row.UnitCost),
+		fmt.Sprintf("%.2f", row.StockValue),
+		quantity(row.ReorderLevel),
+		lowStock,
+		quantity(row.PurchasedQuantity),
+		fmt.Sprintf("%.2f", row.PurchasedValue),
+		quantity(row.ConsumedQuantity),
+		fmt.Sprintf("%.2f", row.ConsumedValue),
+	}
+}
+
+// inventoryValuationTotal is the value of all stock in rows
+func inventoryValuationTotal(rows []InventoryValuationReportRow) float64 {
+	total := 0.0
+	for _, row := range rows {
+		total += row.StockValue
+	}
+	return total
+}
+
+func (e *reportExporter) exportInventoryValuationExcel(rows []InventoryValuationReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Inventory Valuation"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range inventoryValuationHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range inventoryValuationRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+	totalRow := len(rows) + 3
+	f.SetCellValue(sheetName, fmt.Sprintf("A%d", totalRow), "Total stock value")
+	f.SetCellValue(sheetName, fmt.Sprintf("H%d", totalRow), fmt.Sprintf("%.2f", inventoryValuationTotal(rows)))
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportInventoryValuationCSV(rows []InventoryValuationReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(inventoryValuationHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(inventoryValuationRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportInventoryValuationPDF(rows []InventoryValuationReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Inventory Valuation Report")
+	pdf.Ln(8)
+	pdf.SetFont("Arial", "", 11)
+	pdf.Cell(0, 8, fmt.Sprintf("Total stock value: %.2f", inventoryValuationTotal(rows)))
+	pdf.Ln(14)
+
+	pdf.SetFont("Arial", "B", 7)
+	widths := []float64{12, 36, 18, 22, 12, 16, 16, 20, 18, 14, 18, 22, 18, 24}
+	for i, header := range inventoryValuationHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range inventoryValuationRecord(row) {
+			value = truncateText(value, 24)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ===============================
+// Handler
+// ===============================
+
+// GetInventoryValuationReport - GET /entities/:id/reports/inventory-valuation
+// Current stock valued at average cost, with purchases and consumption over a
+// date range (default monthly). kind=consumable|asset and include_inactive=true
+// narrow or widen the items; ?format=excel|csv|pdf exports.
+func (h *Handler) GetInventoryValuationReport(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+
+	entityID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity_id"})
+		return
+	}
+	if !h.canAccessEntity(ctx, uint(entityID)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "not authorized for this entity"})
+		return
+	}
+
+	format := c.Query("format")
+	if !h.allowExportFormat(c, ctx, ReportTypeInventoryValuation, format) {
+		return
+	}
+
+	dateRange := c.Query("date_range")
+	if dateRange == "" {
+		dateRange = DateRangeMonthly
+	}
+	start, end, err := GetDateRange(ReportTypeInventoryValuation, dateRange, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	req := InventoryValuationReportRequest{
+		Kind:            c.Query("kind"),
+		IncludeInactive: c.Query("include_inactive") == "true",
+		DateRange:       dateRange,
+		StartDate:       start,
+		EndDate:         end,
+		Format:          format,
+	}
+	if req.Kind != "" && req.Kind != "consumable" && req.Kind != "asset" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "kind must be consumable or asset"})
+		return
+	}
+
+	if format == "" {
+		rows, err := h.service.GetInventoryValuation(uint(entityID), req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &rows)
+		eid := uint(entityID)
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, &eid, "INVENTORY_VALUATION_REPORT_VIEWED", map[string]interface{}{
+			"report_type": ReportTypeInventoryValuation,
+			"date_range":  dateRange,
+			"kind":        req.Kind,
+		}, ip, "success")
+		c.JSON(http.StatusOK, gin.H{
+			"report_type": ReportTypeInventoryValuation,
+			"data":        rows,
+			"total_value": inventoryValuationTotal(rows),
+		})
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportInventoryValuation(h.exportContext(c, ctx), uint(entityID), req, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
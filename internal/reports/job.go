@@ -0,0 +1,266 @@
+package reports
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Report job statuses
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+)
+
+// Report kinds that can be generated asynchronously
+const (
+	JobReportActivities       = "activities"
+	JobReportTempleRegistered = "temple-registered"
+	JobReportDevoteeBirthdays = "devotee-birthdays"
+	JobReportDevoteeList      = "devotee-list"
+	JobReportDevoteeProfile   = "devotee-profile"
+	JobReportAuditLogs        = "audit-logs"
+	JobReportApprovalStatus   = "approval-status"
+	JobReportUserDetails      = "user-details"
+	JobReportExportAudit      = "export-audit"
+)
+
+// Report job priorities; higher runs first within the same fair-share round
+const (
+	JobPriorityNormal = 0
+	JobPriorityHigh   = 1 // small exports
+)
+
+var ErrJobNotFound = errors.New("report job not found")
+
+// ReportJob is a background export request and its result
+type ReportJob struct {
+	ID          string         `gorm:"type:varchar(36);primaryKey" json:"id"`
+	UserID      uint           `gorm:"not null;index" json:"user_id"`
+	EntityID    *uint          `gorm:"index" json:"entity_id,omitempty"`
+	TenantID    uint           `gorm:"default:0;index" json:"tenant_id"` // fair-share bucket, 0 = platform (superadmin)
+	Priority    int            `gorm:"default:0" json:"priority"`
+	Report      string         `gorm:"size:50;not null" json:"report"`
+	Format      string         `gorm:"size:10;not null" json:"format"`
+	Stream      bool           `gorm:"default:false" json:"stream"` // written to storage batch by batch
+	Params      datatypes.JSON `gorm:"type:jsonb" json:"params"`
+	Status      string         `gorm:"size:20;not null;index" json:"status"`
+	Error       string         `gorm:"type:text" json:"error,omitempty"`
+	Attempts    int            `gorm:"default:0" json:"attempts"`
+	FileKey     string         `gorm:"size:255" json:"-"`
+	DataRegion  string         `gorm:"size:20;default:''" json:"data_region,omitempty"` // storage region of the file
+	FileName    string         `gorm:"size:255" json:"file_name,omitempty"`
+	MimeType    string         `gorm:"size:100" json:"mime_type,omitempty"`
+	FileSize    int64          `json:"file_size,omitempty"`
+	RowsDone    int64          `gorm:"default:0" json:"rows_done"`
+	RowsTotal   int64          `gorm:"default:0" json:"rows_total"`
+	IPAddress   string         `gorm:"size:45" json:"-"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	StartedAt   *time.Time     `json:"started_at,omitempty"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time     `gorm:"index" json:"expires_at,omitempty"`
+}
+
+func (ReportJob) TableName() string {
+	return "report_jobs"
+}
+
+// JobParams are the report filters captured when the job was created
+type JobParams struct {
+	EntityParam string    `json:"entity"`
+	EntityIDs   []string  `json:"entity_ids"`
+	Type        string    `json:"type,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	Role        string    `json:"role,omitempty"`
+	Action      string    `json:"action,omitempty"`
+	TenantID    uint      `json:"tenant_id,omitempty"`
+	PIIOnly     bool      `json:"pii_only,omitempty"`
+	Tithis      []string  `json:"tithis,omitempty"`
+	DateRange   string    `json:"date_range"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	Language    string    `json:"language,omitempty"`    // headers of the exported file
+	ViewerRole  string    `json:"viewer_role,omitempty"` // role of the requester; its masked columns are masked in the file
+}
+
+// CreateReportJobRequest is the body of POST /reports/jobs
+type CreateReportJobRequest struct {
+	Report    string `json:"report" binding:"required"`
+	Format    string `json:"format" binding:"required"`
+	EntityID  string `json:"entity_id"` // numeric id or "all"
+	Type      string `json:"type"`      // activities: events|sevas|bookings|donations|waitlist|disputes|campaigns|recurring-donations|hundi-collection|volunteers|rsvps; export-audit: exported report type
+	Status    string `json:"status"`
+	Role      string `json:"role"`
+	Action    string `json:"action"`
+	TenantID  uint   `json:"tenant_id"` // export-audit only
+	PIIOnly   bool   `json:"pii_only"`  // export-audit only
+	Tithi     string `json:"tithi"`     // activities bookings only: comma separated tithis or observances
+	DateRange string `json:"date_range"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+	Stream    bool   `json:"stream"` // audit-logs csv only: stream straight to storage and return a signed URL
+}
+
+// JobRepository persists report jobs
+type JobRepository interface {
+	Create(ctx context.Context, job *ReportJob) error
+	GetByID(ctx context.Context, id string) (*ReportJob, error)
+	ListByUser(ctx context.Context, userID uint, limit int) ([]ReportJob, error)
+	// MarkRunning claims a queued job; returns false if another worker already has it
+	MarkRunning(ctx context.Context, id string) (bool, error)
+	MarkCompleted(ctx context.Context, id, fileKey, fileName, mimeType string, size int64, expiresAt time.Time) error
+	MarkFailed(ctx context.Context, id, reason string) error
+	UpdateProgress(ctx context.Context, id string, done, total int64) error
+	Requeue(ctx context.Context, id string) error
+	ListStale(ctx context.Context, status string, olderThan time.Time) ([]ReportJob, error)
+	// ListQueued returns the oldest queued jobs, scheduling columns only
+	ListQueued(ctx context.Context, limit int) ([]ReportJob, error)
+	// RunningByTenant counts running jobs per tenant across all instances
+	RunningByTenant(ctx context.Context) (map[uint]int, error)
+	ListExpired(ctx context.Context, now time.Time) ([]ReportJob, error)
+	ClearFile(ctx context.Context, id string) error
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+func (r *jobRepository) Create(ctx context.Context, job *ReportJob) error {
+	return r.db.WithContext(ctx).Create(job).Error
+}
+
+func (r *jobRepository) GetByID(ctx context.Context, id string) (*ReportJob, error) {
+	var job ReportJob
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrJobNotFound
+	}
+	return &job, err
+}
+
+func (r *jobRepository) ListByUser(ctx context.Context, userID uint, limit int) ([]ReportJob, error) {
+	var jobs []ReportJob
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *jobRepository) MarkRunning(ctx context.Context, id string) (bool, error) {
+	now := time.Now()
+	res := r.db.WithContext(ctx).Model(&ReportJob{}).
+		Where("id = ? AND status = ?", id, JobStatusQueued).
+		Updates(map[string]interface{}{
+			"status":     JobStatusRunning,
+			"started_at": now,
+			"attempts":   gorm.Expr("attempts + 1"),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+func (r *jobRepository) MarkCompleted(ctx context.Context, id, fileKey, fileName, mimeType string, size int64, expiresAt time.Time) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&ReportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       JobStatusCompleted,
+			"file_key":     fileKey,
+			"file_name":    fileName,
+			"mime_type":    mimeType,
+			"file_size":    size,
+			"error":        "",
+			"completed_at": now,
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+func (r *jobRepository) MarkFailed(ctx context.Context, id, reason string) error {
+	now := time.Now()
+	return r.db.WithContext(ctx).Model(&ReportJob{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"status":       JobStatusFailed,
+			"error":        reason,
+			"completed_at": now,
+		}).Error
+}
+
+func (r *jobRepository) UpdateProgress(ctx context.Context, id string, done, total int64) error {
+	return r.db.WithContext(ctx).Model(&ReportJob{}).
+		Where("id = ? AND status = ?", id, JobStatusRunning).
+		Updates(map[string]interface{}{
+			"rows_done":  done,
+			"rows_total": total,
+		}).Error
+}
+
+func (r *jobRepository) Requeue(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&ReportJob{}).
+		Where("id = ? AND status = ?", id, JobStatusRunning).
+		Update("status", JobStatusQueued).Error
+}
+
+func (r *jobRepository) ListStale(ctx context.Context, status string, olderThan time.Time) ([]ReportJob, error) {
+	var jobs []ReportJob
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND updated_at < ?", status, olderThan).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *jobRepository) ListQueued(ctx context.Context, limit int) ([]ReportJob, error) {
+	var jobs []ReportJob
+	err := r.db.WithContext(ctx).
+		Select("id", "tenant_id", "priority", "created_at").
+		Where("status = ?", JobStatusQueued).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *jobRepository) RunningByTenant(ctx context.Context) (map[uint]int, error) {
+	var rows []struct {
+		TenantID uint
+		Running  int
+	}
+	err := r.db.WithContext(ctx).Model(&ReportJob{}).
+		Select("tenant_id, COUNT(*) AS running").
+		Where("status = ?", JobStatusRunning).
+		Group("tenant_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		counts[row.TenantID] = row.Running
+	}
+	return counts, nil
+}
+
+func (r *jobRepository) ListExpired(ctx context.Context, now time.Time) ([]ReportJob, error) {
+	var jobs []ReportJob
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND file_key <> '' AND expires_at < ?", JobStatusCompleted, now).
+		Find(&jobs).Error
+	return jobs, err
+}
+
+func (r *jobRepository) ClearFile(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Model(&ReportJob{}).
+		Where("id = ?", id).
+		Update("file_key", "").Error
+}
@@ -0,0 +1,395 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/internal/panchang"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// JobHandler exposes asynchronous report generation
+type JobHandler struct {
+	jobs *JobService
+	h    *Handler // reused for entity resolution and access checks
+}
+
+// NewJobHandler creates a new report job handler
+func NewJobHandler(jobs *JobService, h *Handler) *JobHandler {
+	return &JobHandler{jobs: jobs, h: h}
+}
+
+// CreateJob - POST /reports/jobs
+// Queues a report export and returns 202 with the job to poll.
+func (jh *JobHandler) CreateJob(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	var req CreateReportJobRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request", "details": err.Error()})
+		return
+	}
+	req.Report = strings.ToLower(strings.TrimSpace(req.Report))
+	req.Format = strings.ToLower(strings.TrimSpace(req.Format))
+
+	if err := ValidateJobFormat(req.Report, req.Format); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Stream && (req.Report != JobReportAuditLogs || req.Format != FormatCSV) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrUnsupportedStream.Error()})
+		return
+	}
+	if req.Report == JobReportActivities && req.Type == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type is required for activities: events|sevas|bookings|donations|waitlist|disputes|campaigns|recurring-donations|hundi-collection|volunteers|rsvps"})
+		return
+	}
+	if !jh.h.allowExportFormat(c, ctx, jobReportType(req), req.Format) {
+		return
+	}
+
+	if req.DateRange == "" {
+		req.DateRange = DateRangeWeekly
+	}
+	start, end, err := GetDateRange(jobReportType(req), req.DateRange, req.StartDate, req.EndDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+
+	var tithis []string
+	if req.Report == JobReportActivities {
+		if tithis, err = panchang.ParseFilter(req.Tithi); err == nil && len(tithis) > 0 && req.Type != ReportTypeBookings {
+			err = errors.New("the tithi filter applies to the bookings report only")
+		}
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "tithi"})
+			return
+		}
+	}
+
+	entityParam, entityIDs, status, err := jh.resolveEntities(ctx, req)
+	if err != nil {
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+
+	job := &ReportJob{
+		UserID:    ctx.UserID,
+		TenantID:  jobTenantID(ctx),
+		Report:    req.Report,
+		Format:    req.Format,
+		Stream:    req.Stream,
+		IPAddress: middleware.GetIPFromContext(c),
+	}
+	if id, err := strconv.ParseUint(entityParam, 10, 64); err == nil {
+		eid := uint(id)
+		job.EntityID = &eid
+	}
+
+	params := JobParams{
+		EntityParam: entityParam,
+		EntityIDs:   entityIDs,
+		Type:        req.Type,
+		Status:      req.Status,
+		Role:        req.Role,
+		Action:      req.Action,
+		TenantID:    req.TenantID,
+		PIIOnly:     req.PIIOnly,
+		Tithis:      tithis,
+		DateRange:   req.DateRange,
+		StartDate:   start,
+		EndDate:     end,
+		Language:    i18n.FromContext(c.Request.Context()),
+		ViewerRole:  ctx.RoleName,
+	}
+	if err := jh.jobs.Enqueue(c.Request.Context(), job, params); err != nil {
+		if errors.Is(err, storage.ErrCrossRegion) || errors.Is(err, storage.ErrRegionUnavailable) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue report", "details": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "Report queued",
+		"job":        job,
+		"status_url": fmt.Sprintf("/api/v1/reports/jobs/%s", job.ID),
+	})
+}
+
+// jobTenantID is the fair-share bucket of the caller's jobs: the tenant they
+// work for, their own bucket when no tenant is known, or 0 for platform-wide
+// superadmin exports
+func jobTenantID(ctx middleware.AccessContext) uint {
+	switch {
+	case ctx.TenantID != 0:
+		return ctx.TenantID
+	case ctx.RoleName != middleware.RoleSuperAdmin:
+		return ctx.UserID
+	}
+	return 0
+}
+
+// jobReportType is the report type a job's date span and export format rules
+// are checked against; activities jobs use the activity type
+func jobReportType(req CreateReportJobRequest) string {
+	if req.Report == JobReportActivities {
+		return req.Type
+	}
+	return req.Report
+}
+
+// ListJobs - GET /reports/jobs?limit=
+func (jh *JobHandler) ListJobs(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	jobs, err := jh.jobs.ListJobs(c.Request.Context(), ctx.UserID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch report jobs"})
+		return
+	}
+
+	positions := jh.queuePositions(c, jobs...)
+	out := make([]gin.H, 0, len(jobs))
+	for i := range jobs {
+		resp := jobResponse(&jobs[i])
+		if pos, ok := positions[jobs[i].ID]; ok {
+			resp["queue_position"] = pos
+		}
+		out = append(out, resp)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": out, "total": len(out)})
+}
+
+// GetJob - GET /reports/jobs/:id
+// Returns the job status and progress, its queue position while it waits and,
+// once completed, a download URL.
+// On object storage backends a presigned signed_url is included as well.
+func (jh *JobHandler) GetJob(c *gin.Context) {
+	job, ok := jh.loadOwnJob(c)
+	if !ok {
+		return
+	}
+	resp := jobResponse(job)
+	if pos, ok := jh.queuePositions(c, *job)[job.ID]; ok {
+		resp["queue_position"] = pos
+	}
+	if url, expiresAt, err := jh.jobs.SignedURL(c.Request.Context(), job); err == nil {
+		resp["signed_url"] = url
+		resp["signed_url_expires_at"] = expiresAt
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// DownloadJob - GET /reports/jobs/:id/download
+func (jh *JobHandler) DownloadJob(c *gin.Context) {
+	job, ok := jh.loadOwnJob(c)
+	if !ok {
+		return
+	}
+	if job.Status != JobStatusCompleted {
+		c.JSON(http.StatusConflict, gin.H{"error": "report is not ready", "status": job.Status})
+		return
+	}
+
+	rc, info, err := jh.jobs.OpenResult(c.Request.Context(), job)
+	if errors.Is(err, storage.ErrNotFound) {
+		c.JSON(http.StatusGone, gin.H{"error": "report file has expired, please generate it again"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open report file"})
+		return
+	}
+	defer rc.Close()
+
+	c.DataFromReader(http.StatusOK, info.Size, job.MimeType, rc, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%s", job.FileName),
+	})
+}
+
+// queuePositions looks up queue positions only when one of jobs is waiting.
+// A failed lookup just leaves the positions out.
+func (jh *JobHandler) queuePositions(c *gin.Context, jobs ...ReportJob) map[string]int {
+	for _, job := range jobs {
+		if job.Status != JobStatusQueued {
+			continue
+		}
+		positions, err := jh.jobs.QueuePositions(c.Request.Context())
+		if err != nil {
+			return nil
+		}
+		return positions
+	}
+	return nil
+}
+
+// loadOwnJob fetches :id and checks it belongs to the caller (superadmin sees all)
+func (jh *JobHandler) loadOwnJob(c *gin.Context) (*ReportJob, bool) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return nil, false
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	job, err := jh.jobs.GetJob(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, ErrJobNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "report job not found"})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch report job"})
+		return nil, false
+	}
+	if ctx.RoleName != middleware.RoleSuperAdmin &&
+		(job.UserID != ctx.UserID || (job.EntityID != nil && !ctx.IsEntityStaff(*job.EntityID))) {
+		// Don't reveal other users' job IDs, nor reports of temples the
+		// caller no longer works at
+		c.JSON(http.StatusNotFound, gin.H{"error": "report job not found"})
+		return nil, false
+	}
+	return job, true
+}
+
+func jobResponse(job *ReportJob) gin.H {
+	resp := gin.H{
+		"id":           job.ID,
+		"report":       job.Report,
+		"format":       job.Format,
+		"status":       job.Status,
+		"priority":     job.Priority,
+		"created_at":   job.CreatedAt,
+		"started_at":   job.StartedAt,
+		"completed_at": job.CompletedAt,
+	}
+	if job.EntityID != nil {
+		resp["entity_id"] = *job.EntityID
+	}
+	if job.Stream {
+		resp["stream"] = true
+		progress := gin.H{"rows_done": job.RowsDone, "rows_total": job.RowsTotal, "percent": 0}
+		if job.Status == JobStatusCompleted {
+			progress["percent"] = 100
+		} else if job.RowsTotal > 0 {
+			progress["percent"] = int(job.RowsDone * 100 / job.RowsTotal)
+		}
+		resp["progress"] = progress
+	}
+	switch job.Status {
+	case JobStatusCompleted:
+		resp["file_name"] = job.FileName
+		resp["file_size"] = job.FileSize
+		resp["expires_at"] = job.ExpiresAt
+		if job.FileKey != "" {
+			resp["download_url"] = fmt.Sprintf("/api/v1/reports/jobs/%s/download", job.ID)
+		} else {
+			resp["expired"] = true
+		}
+	case JobStatusFailed:
+		resp["error"] = job.Error
+	}
+	return resp
+}
+
+// resolveEntities applies the same scoping rules as the synchronous report endpoints
+func (jh *JobHandler) resolveEntities(ctx middleware.AccessContext, req CreateReportJobRequest) (string, []string, int, error) {
+	var entityIDs []string
+	appendIDs := func(ids []uint) {
+		for _, id := range ids {
+			entityIDs = append(entityIDs, fmt.Sprint(id))
+		}
+	}
+
+	// The export audit covers the whole platform
+	if req.Report == JobReportExportAudit {
+		if ctx.RoleName != middleware.RoleSuperAdmin {
+			return "", nil, http.StatusForbidden, errors.New("only superadmin can export the export audit report")
+		}
+		return "all", nil, 0, nil
+	}
+
+	// Approval status and user details are scoped by role only
+	if req.Report == JobReportApprovalStatus || req.Report == JobReportUserDetails {
+		switch ctx.RoleName {
+		case middleware.RoleSuperAdmin:
+			return "all", nil, 0, nil
+		case middleware.RoleTempleAdmin:
+			ids, err := jh.h.repo.GetEntitiesByTenant(ctx.TenantID)
+			if err != nil {
+				return "", nil, http.StatusInternalServerError, errors.New("failed to fetch user entities")
+			}
+			appendIDs(ids)
+			return "all", entityIDs, 0, nil
+		}
+		if req.Report == JobReportApprovalStatus {
+			return "", nil, http.StatusForbidden, errors.New("role not allowed for approval reports")
+		}
+		accessibleEntityID := ctx.GetAccessibleEntityID()
+		if accessibleEntityID == nil || !ctx.IsEntityStaff(*accessibleEntityID) {
+			return "", nil, http.StatusForbidden, errors.New("no accessible entity")
+		}
+		return fmt.Sprint(*accessibleEntityID), []string{fmt.Sprint(*accessibleEntityID)}, 0, nil
+	}
+
+	entityParam := strings.ToLower(strings.TrimSpace(req.EntityID))
+	if entityParam == "" {
+		return "", nil, http.StatusBadRequest, errors.New("entity_id is required (numeric id or \"all\")")
+	}
+
+	if entityParam != "all" {
+		eid, err := strconv.ParseUint(entityParam, 10, 64)
+		if err != nil {
+			return "", nil, http.StatusBadRequest, errors.New("invalid entity_id")
+		}
+		if !ctx.IsEntityStaff(uint(eid)) {
+			return "", nil, http.StatusForbidden, errors.New("not authorized for this entity")
+		}
+		return fmt.Sprint(eid), []string{fmt.Sprint(eid)}, 0, nil
+	}
+
+	var (
+		ids []uint
+		err error
+	)
+	switch ctx.RoleName {
+	case middleware.RoleSuperAdmin:
+		if ctx.AssignedEntityID != nil {
+			ids, err = jh.h.repo.GetEntitiesByTenant(*ctx.AssignedEntityID)
+		} else {
+			ids, err = jh.h.repo.GetAllEntityIDs()
+		}
+	case middleware.RoleTempleAdmin, middleware.RoleStandardUser, middleware.RoleMonitoringUser:
+		// Only the temples the caller really works at
+		ids = ctx.EntityIDs
+	default:
+		return "", nil, http.StatusForbidden, errors.New("role not authorized for this endpoint")
+	}
+	if err != nil {
+		return "", nil, http.StatusInternalServerError, errors.New("failed to fetch tenant entities")
+	}
+	if len(ids) == 0 {
+		return "", nil, http.StatusUnprocessableEntity, errors.New("no entities found for tenant")
+	}
+	appendIDs(ids)
+	return "all", entityIDs, 0, nil
+}
@@ -0,0 +1,129 @@
+package reports
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+const (
+	// smallJobEntityDays is the entity x day budget under which an export
+	// counts as small, e.g. one temple for a month
+	smallJobEntityDays = 31
+	// jobPriorityAging promotes jobs that have waited this long, so large
+	// exports are not starved by a steady stream of small ones
+	jobPriorityAging = 10 * time.Minute
+	// schedulerWindow caps how many queued jobs are considered per pick
+	schedulerWindow = 500
+)
+
+// jobPriority boosts exports expected to be small: few entities over a short
+// span. Streamed exports are the large ones by definition.
+func jobPriority(job *ReportJob, p JobParams) int {
+	if job.Stream || len(p.EntityIDs) == 0 {
+		return JobPriorityNormal
+	}
+	days := int(p.EndDate.Sub(p.StartDate).Hours()/24) + 1
+	if len(p.EntityIDs)*days <= smallJobEntityDays {
+		return JobPriorityHigh
+	}
+	return JobPriorityNormal
+}
+
+// scheduleOrder returns queued jobs in the order workers should take them.
+// Each pick goes to the tenant with the fewest running (and already picked)
+// jobs, then to the higher priority, then to the oldest. With perTenant > 0,
+// tenants at their limit are left out; their jobs wait for a running one to
+// finish.
+func scheduleOrder(queued []ReportJob, running map[uint]int, perTenant int, now time.Time) []ReportJob {
+	load := make(map[uint]int, len(running))
+	for tenant, n := range running {
+		load[tenant] = n
+	}
+
+	// Per tenant, jobs are taken by priority then age; sort once and merge
+	pending := append([]ReportJob(nil), queued...)
+	sort.SliceStable(pending, func(i, j int) bool {
+		pi, pj := effectivePriority(&pending[i], now), effectivePriority(&pending[j], now)
+		if pi != pj {
+			return pi > pj
+		}
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+
+	order := make([]ReportJob, 0, len(pending))
+	taken := make([]bool, len(pending))
+	for len(order) < len(pending) {
+		best := -1
+		for i := range pending {
+			if taken[i] {
+				continue
+			}
+			tenant := pending[i].TenantID
+			if perTenant > 0 && load[tenant] >= perTenant {
+				continue
+			}
+			if best == -1 || load[tenant] < load[pending[best].TenantID] {
+				best = i // pending is sorted, so the first job per load level wins ties
+			}
+		}
+		if best == -1 {
+			break
+		}
+		taken[best] = true
+		load[pending[best].TenantID]++
+		order = append(order, pending[best])
+	}
+	return order
+}
+
+func effectivePriority(job *ReportJob, now time.Time) int {
+	if now.Sub(job.CreatedAt) >= jobPriorityAging {
+		return JobPriorityHigh
+	}
+	return job.Priority
+}
+
+// next claims the queued job the scheduler picks first. It returns "" when
+// nothing is runnable: the queue is empty or every waiting tenant is at its
+// limit.
+func (s *JobService) next(ctx context.Context) (string, error) {
+	queued, err := s.repo.ListQueued(ctx, schedulerWindow)
+	if err != nil || len(queued) == 0 {
+		return "", err
+	}
+	running, err := s.repo.RunningByTenant(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, job := range scheduleOrder(queued, running, s.perTenant, time.Now()) {
+		// Another worker or instance may claim it first; try the next one
+		claimed, err := s.repo.MarkRunning(ctx, job.ID)
+		if err != nil {
+			return "", err
+		}
+		if claimed {
+			return job.ID, nil
+		}
+	}
+	return "", nil
+}
+
+// QueuePositions estimates the 1-based position of every queued job: the
+// order workers would take them if no new jobs arrived, ignoring tenant
+// limits (a capped tenant's jobs still run, just later)
+func (s *JobService) QueuePositions(ctx context.Context) (map[string]int, error) {
+	queued, err := s.repo.ListQueued(ctx, schedulerWindow)
+	if err != nil {
+		return nil, err
+	}
+	running, err := s.repo.RunningByTenant(ctx)
+	if err != nil {
+		return nil, err
+	}
+	positions := make(map[string]int, len(queued))
+	for i, job := range scheduleOrder(queued, running, 0, time.Now()) {
+		positions[job.ID] = i + 1
+	}
+	return positions, nil
+}
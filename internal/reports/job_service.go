@@ -0,0 +1,501 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+const (
+	// jobQueueKey is the Redis list that wakes idle workers when jobs are queued
+	jobQueueKey = "report_jobs:queue"
+
+	jobPollTimeout   = 5 * time.Second
+	jobTimeout       = 10 * time.Minute
+	streamJobTimeout = 2 * time.Hour // streamed exports report progress, so the janitor never sees them as stale
+	janitorInterval  = 5 * time.Minute
+	maxJobAttempts   = 3
+	defaultRetention = 24 * time.Hour
+)
+
+var (
+	ErrUnsupportedReport = errors.New("unsupported report")
+	ErrUnsupportedFormat = errors.New("unsupported format, use excel, csv or pdf")
+	ErrUnsupportedStream = errors.New("streaming is only supported for audit-logs in csv format")
+)
+
+// JobService queues report exports and generates them in background workers.
+// Workers pick jobs from the report_jobs table by fair share across tenants
+// (see scheduleOrder). New job IDs are pushed to a Redis list to wake idle
+// workers; when Redis is not configured an in-process channel is used so
+// single-instance deployments still work.
+type JobService struct {
+	repo      JobRepository
+	reports   ReportService
+	store     storage.Storage
+	auditSvc  auditlog.Service
+	retention time.Duration
+	perTenant int // concurrent jobs per tenant, 0 = unlimited
+
+	local     chan string
+	startOnce sync.Once
+}
+
+// NewJobService creates the async report job service
+func NewJobService(repo JobRepository, reports ReportService, store storage.Storage, auditSvc auditlog.Service, retention time.Duration) *JobService {
+	if retention <= 0 {
+		retention = defaultRetention
+	}
+	return &JobService{
+		repo:      repo,
+		reports:   reports,
+		store:     store,
+		auditSvc:  auditSvc,
+		retention: retention,
+		local:     make(chan string, 256),
+	}
+}
+
+// ValidateJobFormat checks the report kind and output format of a job request
+func ValidateJobFormat(report, format string) error {
+	switch report {
+	case JobReportActivities, JobReportTempleRegistered, JobReportDevoteeBirthdays,
+		JobReportDevoteeList, JobReportDevoteeProfile, JobReportAuditLogs,
+		JobReportApprovalStatus, JobReportUserDetails, JobReportExportAudit:
+	default:
+		return ErrUnsupportedReport
+	}
+	switch format {
+	case FormatExcel, FormatCSV, FormatPDF:
+		return nil
+	}
+	return ErrUnsupportedFormat
+}
+
+// Enqueue persists a new job and hands it to the workers
+func (s *JobService) Enqueue(ctx context.Context, job *ReportJob, params JobParams) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	job.ID = uuid.NewString()
+	job.Params = raw
+	job.Status = JobStatusQueued
+	job.Priority = jobPriority(job, params)
+
+	// The file stays in the region of the exported temples; temples of
+	// different regions cannot be exported together
+	job.DataRegion, err = storage.TargetRegion(ctx, s.store, "report export", convertUintSlice(params.EntityIDs))
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &job.UserID, job.EntityID, "REPORT_JOB_FAILED", map[string]interface{}{
+			"report": job.Report,
+			"format": job.Format,
+			"error":  err.Error(),
+		}, job.IPAddress, "failure")
+		return err
+	}
+
+	if err := s.repo.Create(ctx, job); err != nil {
+		return err
+	}
+	if err := s.push(ctx, job.ID); err != nil {
+		// The row is queued; workers find it on their next poll
+		log.Printf("⚠️ Report job %s saved but not queued: %v", job.ID, err)
+	}
+
+	s.auditSvc.LogAction(ctx, &job.UserID, job.EntityID, "REPORT_JOB_QUEUED", map[string]interface{}{
+		"job_id":   job.ID,
+		"report":   job.Report,
+		"format":   job.Format,
+		"priority": job.Priority,
+	}, job.IPAddress, "success")
+	return nil
+}
+
+// GetJob returns a job by ID
+func (s *JobService) GetJob(ctx context.Context, id string) (*ReportJob, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+// ListJobs returns the latest jobs of a user
+func (s *JobService) ListJobs(ctx context.Context, userID uint, limit int) ([]ReportJob, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.ListByUser(ctx, userID, limit)
+}
+
+// OpenResult opens the generated file of a completed job
+func (s *JobService) OpenResult(ctx context.Context, job *ReportJob) (io.ReadCloser, *storage.ObjectInfo, error) {
+	if job.Status != JobStatusCompleted || job.FileKey == "" {
+		return nil, nil, storage.ErrNotFound
+	}
+	return s.store.Get(storage.WithRegion(ctx, job.DataRegion), job.FileKey)
+}
+
+// Start launches the workers and the janitor; they stop when ctx is cancelled.
+// perTenant caps the jobs one tenant may have running at once.
+func (s *JobService) Start(ctx context.Context, workers, perTenant int) {
+	s.startOnce.Do(func() {
+		if workers <= 0 {
+			workers = 1
+		}
+		s.perTenant = max(perTenant, 0)
+		for i := 0; i < workers; i++ {
+			go s.worker(ctx, i+1)
+		}
+		go s.janitor(ctx)
+		log.Printf("✅ Report job workers started (%d, %d per tenant)", workers, perTenant)
+	})
+}
+
+func (s *JobService) push(ctx context.Context, id string) error {
+	if utils.RedisClient != nil {
+		return utils.RedisClient.LPush(ctx, jobQueueKey, id).Err()
+	}
+	select {
+	case s.local <- id:
+		return nil
+	default:
+		return errors.New("local report queue is full")
+	}
+}
+
+func (s *JobService) pop(ctx context.Context) (string, error) {
+	if utils.RedisClient != nil {
+		res, err := utils.RedisClient.BRPop(ctx, jobPollTimeout, jobQueueKey).Result()
+		if err != nil {
+			return "", err
+		}
+		return res[1], nil
+	}
+	select {
+	case id := <-s.local:
+		return id, nil
+	case <-time.After(jobPollTimeout):
+		return "", redis.Nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+func (s *JobService) worker(ctx context.Context, n int) {
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+		id, err := s.next(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("❌ Report worker %d: scheduling error: %v", n, err)
+				time.Sleep(jobPollTimeout)
+			}
+			continue
+		}
+		if id == "" {
+			// Nothing runnable: wait for a new job, or look again after the
+			// poll timeout in case a capped tenant's running job finished
+			if _, err := s.pop(ctx); err != nil && !errors.Is(err, redis.Nil) && ctx.Err() == nil {
+				log.Printf("❌ Report worker %d: queue error: %v", n, err)
+				time.Sleep(jobPollTimeout)
+			}
+			continue
+		}
+		s.run(ctx, id)
+	}
+}
+
+// run executes a single job already claimed by next
+func (s *JobService) run(ctx context.Context, id string) {
+	job, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		log.Printf("❌ Report job %s: failed to load: %v", id, err)
+		return
+	}
+
+	timeout := jobTimeout
+	if job.Stream {
+		timeout = streamJobTimeout
+	}
+	jobCtx, cancel := context.WithTimeout(storage.WithRegion(ctx, job.DataRegion), timeout)
+	defer cancel()
+
+	start := time.Now()
+	var (
+		key, filename, mime string
+		size                int64
+	)
+	if job.Stream {
+		key, filename, mime, size, err = s.stream(jobCtx, job)
+	} else {
+		var data []byte
+		data, filename, mime, err = s.generate(jobCtx, job)
+		if err == nil {
+			size = int64(len(data))
+			key, err = storage.Key(storage.ReportsPrefix, job.ID, filename)
+			if err == nil {
+				err = s.store.Put(jobCtx, key, bytes.NewReader(data), size, mime)
+			}
+		}
+	}
+	if err == nil {
+		err = s.repo.MarkCompleted(ctx, job.ID, key, filename, mime, size, time.Now().Add(s.retention))
+	}
+
+	if err != nil {
+		log.Printf("❌ Report job %s (%s) failed: %v", job.ID, job.Report, err)
+		if mErr := s.repo.MarkFailed(ctx, job.ID, err.Error()); mErr != nil {
+			log.Printf("❌ Report job %s: failed to record failure: %v", job.ID, mErr)
+		}
+		s.auditSvc.LogAction(ctx, &job.UserID, job.EntityID, "REPORT_JOB_FAILED", map[string]interface{}{
+			"job_id": job.ID,
+			"report": job.Report,
+			"format": job.Format,
+			"error":  err.Error(),
+		}, job.IPAddress, "failure")
+		return
+	}
+
+	log.Printf("✅ Report job %s (%s/%s) completed in %s", job.ID, job.Report, job.Format, time.Since(start).Round(time.Millisecond))
+	s.auditSvc.LogAction(ctx, &job.UserID, job.EntityID, "REPORT_JOB_COMPLETED", map[string]interface{}{
+		"job_id":    job.ID,
+		"report":    job.Report,
+		"format":    job.Format,
+		"file_name": filename,
+		"file_size": size,
+		"stream":    job.Stream,
+	}, job.IPAddress, "success")
+}
+
+// generate runs the existing synchronous exporter for the job's report kind
+func (s *JobService) generate(ctx context.Context, job *ReportJob) ([]byte, string, string, error) {
+	var p JobParams
+	if err := json.Unmarshal(job.Params, &p); err != nil {
+		return nil, "", "", fmt.Errorf("invalid job params: %w", err)
+	}
+	return exportReport(ctx, s.reports, job.Report, job.Format, job.UserID, job.IPAddress, p)
+}
+
+// exportReport runs the synchronous exporter of a report kind with the
+// filters of p, on behalf of userID
+func exportReport(ctx context.Context, reports ReportService, report, format string, uid uint, ip string, p JobParams) ([]byte, string, string, error) {
+	ctx = withViewerRole(i18n.WithLanguage(ctx, p.Language), p.ViewerRole)
+	userID := &uid
+
+	switch report {
+	case JobReportActivities:
+		req := ActivitiesReportRequest{
+			EntityID: p.EntityParam, EntityIDs: p.EntityIDs, Type: p.Type,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+			Tithis: p.Tithis,
+		}
+		return reports.ExportActivities(ctx, req, userID, ip)
+
+	case JobReportTempleRegistered:
+		req := TempleRegisteredReportRequest{
+			EntityID: p.EntityParam, Status: p.Status,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+		}
+		return reports.ExportTempleRegisteredReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeTempleRegisteredExcel, ReportTypeTempleRegisteredPDF, ReportTypeTempleRegistered), userID, ip)
+
+	case JobReportDevoteeBirthdays:
+		req := DevoteeBirthdaysReportRequest{
+			EntityID:  p.EntityParam,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+		}
+		return reports.ExportDevoteeBirthdaysReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeDevoteeBirthdaysExcel, ReportTypeDevoteeBirthdaysPDF, ReportTypeDevoteeBirthdays), userID, ip)
+
+	case JobReportDevoteeList:
+		req := DevoteeListReportRequest{
+			EntityID: p.EntityParam, Status: p.Status,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+		}
+		return reports.ExportDevoteeListReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeDevoteeListExcel, ReportTypeDevoteeListPDF, ReportTypeDevoteeListCSV), userID, ip)
+
+	case JobReportDevoteeProfile:
+		req := DevoteeProfileReportRequest{
+			EntityID: p.EntityParam, Status: p.Status,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+		}
+		return reports.ExportDevoteeProfileReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeDevoteeProfileExcel, ReportTypeDevoteeProfilePDF, ReportTypeDevoteeProfileCSV), userID, ip)
+
+	case JobReportAuditLogs:
+		req := AuditLogReportRequest{
+			EntityID: p.EntityParam, Action: p.Action, Status: p.Status,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+		}
+		return reports.ExportAuditLogsReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeAuditLogsExcel, ReportTypeAuditLogsPDF, ReportTypeAuditLogsCSV), userID, ip)
+
+	case JobReportApprovalStatus:
+		req := ApprovalStatusReportRequest{
+			Role: p.Role, Status: p.Status,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format, UserID: uid,
+		}
+		return reports.ExportApprovalStatusReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeApprovalStatusExcel, ReportTypeApprovalStatusPDF, ReportTypeApprovalStatusCSV), userID, ip)
+
+	case JobReportUserDetails:
+		req := UserDetailReportRequest{
+			EntityID: p.EntityParam, Role: p.Role, Status: p.Status,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format, UserID: uid,
+		}
+		return reports.ExportUserDetailsReport(ctx, req, p.EntityIDs, pickReportType(format,
+			ReportTypeUserDetailsExcel, ReportTypeUserDetailsPDF, ReportTypeUserDetailsCSV), userID, ip)
+
+	case JobReportExportAudit:
+		req := ExportAuditReportRequest{
+			Role: p.Role, TenantID: p.TenantID, ReportType: p.Type, PIIOnly: p.PIIOnly,
+			DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: format,
+		}
+		return reports.ExportExportAuditReport(ctx, req, pickReportType(format,
+			ReportTypeExportAuditExcel, ReportTypeExportAuditPDF, ReportTypeExportAuditCSV), userID, ip)
+	}
+	return nil, "", "", ErrUnsupportedReport
+}
+
+// stream pipes a CSV export straight into storage while it is generated, so
+// the file is never held in memory. Only audit logs are supported.
+func (s *JobService) stream(ctx context.Context, job *ReportJob) (string, string, string, int64, error) {
+	if job.Report != JobReportAuditLogs {
+		return "", "", "", 0, ErrUnsupportedStream
+	}
+	var p JobParams
+	if err := json.Unmarshal(job.Params, &p); err != nil {
+		return "", "", "", 0, fmt.Errorf("invalid job params: %w", err)
+	}
+
+	filename := fmt.Sprintf("audit_logs_report_%s.csv", time.Now().Format("20060102_150405"))
+	key, err := storage.Key(storage.ReportsPrefix, job.ID, filename)
+	if err != nil {
+		return "", "", "", 0, err
+	}
+
+	req := AuditLogReportRequest{
+		EntityID: p.EntityParam, Action: p.Action, Status: p.Status,
+		DateRange: p.DateRange, StartDate: p.StartDate, EndDate: p.EndDate, Format: FormatCSV,
+	}
+	progress := func(done, total int64) {
+		if err := s.repo.UpdateProgress(ctx, job.ID, done, total); err != nil {
+			log.Printf("⚠️ Report job %s: failed to record progress: %v", job.ID, err)
+		}
+	}
+
+	pr, pw := io.Pipe()
+	counter := &countingWriter{w: pw}
+	go func() {
+		_, err := s.reports.StreamAuditLogs(withViewerRole(ctx, p.ViewerRole), counter, FormatCSV, req, p.EntityIDs, progress)
+		pw.CloseWithError(err) // nil closes with EOF
+	}()
+
+	// Unknown size (-1) makes S3 upload in multipart chunks as data arrives
+	if err := s.store.Put(ctx, key, pr, -1, "text/csv"); err != nil {
+		pr.CloseWithError(err)
+		return "", "", "", 0, err
+	}
+	return key, filename, "text/csv", counter.n, nil
+}
+
+// SignedURL returns a direct download URL for a completed job's file when the
+// storage backend supports presigning. It expires together with the file.
+func (s *JobService) SignedURL(ctx context.Context, job *ReportJob) (string, time.Time, error) {
+	signer, ok := s.store.(storage.Signer)
+	if !ok || job.Status != JobStatusCompleted || job.FileKey == "" || job.ExpiresAt == nil {
+		return "", time.Time{}, storage.ErrNotFound
+	}
+	expiry := time.Until(*job.ExpiresAt)
+	if expiry <= 0 {
+		return "", time.Time{}, storage.ErrNotFound
+	}
+	if expiry > storage.MaxSignedURLExpiry {
+		expiry = storage.MaxSignedURLExpiry
+	}
+	u, err := signer.SignedURL(storage.WithRegion(ctx, job.DataRegion), job.FileKey, expiry, job.FileName)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return u, time.Now().Add(expiry), nil
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// pickReportType maps a format to the exporter report type, as the sync handlers do
+func pickReportType(format, excel, pdf, other string) string {
+	switch strings.ToLower(format) {
+	case FormatExcel:
+		return excel
+	case FormatPDF:
+		return pdf
+	}
+	return other
+}
+
+// janitor requeues lost jobs, fails stuck ones and removes expired files
+func (s *JobService) janitor(ctx context.Context) {
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.sweep(ctx)
+		}
+	}
+}
+
+func (s *JobService) sweep(ctx context.Context) {
+	now := time.Now()
+
+	// Running rows whose worker died; retry a few times, then give up
+	if jobs, err := s.repo.ListStale(ctx, JobStatusRunning, now.Add(-jobTimeout-time.Minute)); err == nil {
+		for _, j := range jobs {
+			if j.Attempts >= maxJobAttempts {
+				s.repo.MarkFailed(ctx, j.ID, "report generation timed out")
+				continue
+			}
+			if err := s.repo.Requeue(ctx, j.ID); err == nil {
+				s.push(ctx, j.ID)
+			}
+		}
+	}
+
+	// Completed jobs past retention
+	if jobs, err := s.repo.ListExpired(ctx, now); err == nil {
+		for _, j := range jobs {
+			if err := s.store.Delete(storage.WithRegion(ctx, j.DataRegion), j.FileKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				log.Printf("⚠️ Report janitor: delete %s failed: %v", j.FileKey, err)
+				continue
+			}
+			s.repo.ClearFile(ctx, j.ID)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package reports
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Column classes that can be masked. Report row fields carry their class in
+// a mask struct tag, e.g. `mask:"phone"`.
+const (
+	MaskPhone   = "phone"
+	MaskEmail   = "email"
+	MaskAddress = "address"
+)
+
+// maskedColumns[role] lists the column classes masked in that role's reports.
+// Roles without a rule see every column.
+var maskedColumns = map[string][]string{}
+
+// SetMaskedColumns configures per role masking, e.g.
+// {"monitoringuser": {"phone", "email"}}
+func SetMaskedColumns(byRole map[string][]string) {
+	rules := make(map[string][]string, len(byRole))
+	for role, classes := range byRole {
+		normalized := make([]string, 0, len(classes))
+		for _, class := range classes {
+			normalized = append(normalized, strings.ToLower(strings.TrimSpace(class)))
+		}
+		rules[role] = normalized
+	}
+	maskedColumns = rules
+}
+
+// MaskedColumns returns the column classes masked for the role
+func MaskedColumns(role string) []string {
+	return maskedColumns[role]
+}
+
+type viewerRoleKey struct{}
+
+// withViewerRole records whose reports are being exported, so the service
+// masks the file for that role
+func withViewerRole(ctx context.Context, role string) context.Context {
+	return context.WithValue(ctx, viewerRoleKey{}, role)
+}
+
+// maskForViewer masks v for the role recorded in ctx
+func maskForViewer(ctx context.Context, v interface{}) {
+	role, _ := ctx.Value(viewerRoleKey{}).(string)
+	maskColumns(v, MaskedColumns(role))
+}
+
+// exportContext is the request context for an export by the caller
+func (h *Handler) exportContext(c *gin.Context, ctx middleware.AccessContext) context.Context {
+	return withViewerRole(c.Request.Context(), ctx.RoleName)
+}
+
+// maskPreview masks a JSON preview for the caller's role
+func (h *Handler) maskPreview(ctx middleware.AccessContext, v interface{}) {
+	maskColumns(v, MaskedColumns(ctx.RoleName))
+}
+
+// maskColumns masks, in place, every string field tagged with one of the
+// classes inside v: a row, a slice of rows or a struct holding them
+func maskColumns(v interface{}, classes []string) {
+	if len(classes) == 0 || v == nil {
+		return
+	}
+	masked := make(map[string]bool, len(classes))
+	for _, class := range classes {
+		masked[class] = true
+	}
+	maskValue(reflect.ValueOf(v), masked)
+}
+
+func maskValue(v reflect.Value, masked map[string]bool) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			maskValue(v.Elem(), masked)
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Len() == 0 || !hasMaskedFields(v.Type().Elem()) {
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			maskValue(v.Index(i), masked)
+		}
+	case reflect.Struct:
+		if !hasMaskedFields(v.Type()) {
+			return
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+			class := t.Field(i).Tag.Get("mask")
+			if class != "" && field.Kind() == reflect.String {
+				if masked[class] {
+					field.SetString(maskString(class, field.String()))
+				}
+				continue
+			}
+			maskValue(field, masked)
+		}
+	}
+}
+
+// maskableTypes caches whether a type has tagged fields at any depth, so
+// reports without personal data are skipped quickly
+var maskableTypes sync.Map
+
+func hasMaskedFields(t reflect.Type) bool {
+	if cached, ok := maskableTypes.Load(t); ok {
+		return cached.(bool)
+	}
+	maskableTypes.Store(t, false) // breaks cycles of recursive types
+	found := false
+	switch t.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Array:
+		found = hasMaskedFields(t.Elem())
+	case reflect.Struct:
+		for i := 0; i < t.NumField() && !found; i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			found = f.Tag.Get("mask") != "" || hasMaskedFields(f.Type)
+		}
+	}
+	maskableTypes.Store(t, found)
+	return found
+}
+
+// maskString hides a value, keeping enough to tell records apart:
+// phone 9876541234 -> XXXXXX1234, email ravi@example.com -> r***@example.com,
+// addresses entirely
+func maskString(class, s string) string {
+	if s == "" {
+		return s
+	}
+	switch class {
+	case MaskPhone:
+		digits := make([]byte, 0, len(s))
+		for i := 0; i < len(s); i++ {
+			if s[i] >= '0' && s[i] <= '9' {
+				digits = append(digits, s[i])
+			}
+		}
+		if len(digits) <= 4 {
+			return strings.Repeat("X", len(digits))
+		}
+		return strings.Repeat("X", len(digits)-4) + string(digits[len(digits)-4:])
+	case MaskEmail:
+		local, domain, ok := strings.Cut(s, "@")
+		if !ok || local == "" {
+			return "***"
+		}
+		return local[:1] + "***@" + domain
+	}
+	return "XXXXXX"
+}
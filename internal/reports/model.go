@@ -1,7 +1,10 @@
 package reports
 
 import (
+	"strings"
 	"time"
+
+	"gorm.io/datatypes"
 )
 
 // Add to existing constants
@@ -14,6 +17,51 @@ const (
 	// New donation report type
 	ReportTypeDonations = "donations"
 
+	// Devotees waiting for a full seva or event
+	ReportTypeWaitlist = "waitlist"
+
+	// Payment disputes (chargebacks) raised against donations
+	ReportTypeDisputes = "disputes"
+
+	// Fundraising campaigns with their progress
+	ReportTypeCampaigns = "campaigns"
+
+	// Monthly donation pledges and their payments
+	ReportTypeRecurringDonations = "recurring-donations"
+
+	// Hundi counting sessions and their tally
+	ReportTypeHundiCollection = "hundi-collection"
+
+	// Coupon codes with their redemptions
+	ReportTypeCoupons = "coupons"
+
+	// Prasadam and merchandise orders with their delivery status
+	ReportTypeOrders = "orders"
+
+	// Volunteers with their event sign-ups and attendance
+	ReportTypeVolunteers = "volunteers"
+
+	// Event RSVPs with attendance from counter check-ins
+	ReportTypeRSVPs = "rsvps"
+
+	// Income against expenses of a temple
+	ReportTypeIncomeExpense = "income-expense"
+
+	// April to March summary of a temple's accounts
+	ReportTypeFinancialYear = "financial-year"
+
+	// Top donors, first-time against repeat donors, lapsed donors and gift size
+	ReportTypeDonorAnalytics = "donor-analytics"
+
+	// Stock of pooja materials and assets valued at cost
+	ReportTypeInventoryValuation = "inventory-valuation"
+
+	// Devotee households for mailings, one row per family
+	ReportTypeFamilies = "families"
+
+	// Per-tenant totals of an organization
+	ReportTypeOrganizationSummary = "organization-summary"
+
 	// Date range constants
 	DateRangeDaily   = "daily"
 	DateRangeWeekly  = "weekly"
@@ -21,11 +69,23 @@ const (
 	DateRangeYearly  = "yearly"
 	DateRangeCustom  = "custom"
 
+	// To-date presets, ending today
+	DateRangeToday     = "today"
+	DateRangeYesterday = "yesterday"
+	DateRangeMTD       = "mtd"
+	DateRangeQTD       = "qtd"
+	DateRangeYTD       = "ytd"
+
 	// Report format constants
 	FormatCSV   = "csv"
 	FormatExcel = "excel"
 	FormatPDF   = "pdf"
 
+	// Accounting formats: Tally XML vouchers and a ledger CSV, for donations
+	// and the income and expense statement
+	FormatTally  = "tally"
+	FormatLedger = "ledger"
+
 	// Temple registered report types
 	ReportTypeTempleRegistered      = "temple-registered"
 	ReportTypeTempleRegisteredExcel = "temple-registered-excel"
@@ -70,17 +130,26 @@ const (
 	ReportTypeUserDetailsCSV   = "user-details-csv"
 	ReportTypeUserDetailsExcel = "user-details-excel"
 	ReportTypeUserDetailsPDF   = "user-details-pdf"
+
+	// Export audit report types: who exported which report
+	ReportTypeExportAudit      = "export-audit"
+	ReportTypeExportAuditCSV   = "export-audit-csv"
+	ReportTypeExportAuditExcel = "export-audit-excel"
+	ReportTypeExportAuditPDF   = "export-audit-pdf"
 )
 
 // ActivitiesReportRequest represents request parameters for temple activities report
 type ActivitiesReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	EntityIDs []string  `json:"entity_ids"`
-	Type      string    `json:"type"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Format    string    `json:"format"`
+	EntityID  string       `json:"entity_id"`
+	EntityIDs []string     `json:"entity_ids"`
+	Type      string       `json:"type"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Format    string       `json:"format"`
+	Tithis    []string     `json:"tithis,omitempty"`   // bookings only: tithis or observances (panchang filter values)
+	GroupBy   string       `json:"group_by,omitempty"` // donations only: day, month, type or method
+	Page      *PageRequest `json:"-"`                  // preview paging; nil for exports
 }
 
 // ReportData struct with all report types
@@ -89,6 +158,16 @@ type ReportData struct {
 	Sevas               []SevaReportRow               `json:"sevas,omitempty"`
 	Bookings            []SevaBookingReportRow        `json:"bookings,omitempty"`
 	Donations           []DonationReportRow           `json:"donations,omitempty"`
+	DonationGroups      *DonationGroupSummary         `json:"donation_groups,omitempty"`
+	Waitlist            []WaitlistReportRow           `json:"waitlist,omitempty"`
+	Disputes            []DisputeReportRow            `json:"disputes,omitempty"`
+	Campaigns           []CampaignReportRow           `json:"campaigns,omitempty"`
+	RecurringDonations  []RecurringDonationReportRow  `json:"recurring_donations,omitempty"`
+	HundiCollection     []HundiCollectionReportRow    `json:"hundi_collection,omitempty"`
+	Coupons             []CouponReportRow             `json:"coupons,omitempty"`
+	Orders              []OrderReportRow              `json:"orders,omitempty"`
+	Volunteers          []VolunteerReportRow          `json:"volunteers,omitempty"`
+	RSVPs               []RSVPReportRow               `json:"rsvps,omitempty"`
 	TemplesRegistered   []TempleRegisteredReportRow   `json:"temples_registered,omitempty"`
 	DevoteeBirthdays    []DevoteeBirthdayReportRow    `json:"devotee_birthdays,omitempty"`
 	DevoteeList         []DevoteeListReportRow        `json:"devotee_list,omitempty"`
@@ -97,6 +176,14 @@ type ReportData struct {
 	AuditLogs           []AuditLogReportRow           `json:"audit_logs,omitempty"`
 	UserDetails         []UserDetailsReportRow        `json:"user_details,omitempty"`
 	ApprovalStatus      []ApprovalStatusReportRow     `json:"approval_status,omitempty"`
+	ExportAudit         []ExportAuditReportRow        `json:"export_audit,omitempty"`
+	IncomeExpense       *IncomeExpenseStatement       `json:"income_expense,omitempty"`
+	FinancialYear       *FinancialYearSummary         `json:"financial_year,omitempty"`
+	DonorAnalytics      *DonorAnalytics               `json:"donor_analytics,omitempty"`
+	InventoryValuation  []InventoryValuationReportRow `json:"inventory_valuation,omitempty"`
+	Families            []FamilyReportRow             `json:"families,omitempty"`
+	Vouchers            *VoucherSet                   `json:"vouchers,omitempty"`
+	Pagination          *PageInfo                     `json:"pagination,omitempty"`
 }
 
 // EventReportRow represents a single row in the events report
@@ -112,6 +199,9 @@ type EventReportRow struct {
 	IsActive    bool      `json:"is_active"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Set on each occurrence row of a recurring event
+	RecurrenceRule string `json:"recurrence_rule,omitempty"`
 }
 
 // SevaReportRow represents a single row in the sevas report
@@ -138,11 +228,21 @@ type SevaBookingReportRow struct {
 	TempleName   string    `json:"temple_name"`
 	SevaType     string    `json:"seva_type"`
 	DevoteeName  string    `json:"devotee_name"`
-	DevoteePhone string    `json:"devotee_phone"`
+	DevoteePhone string    `json:"devotee_phone" mask:"phone" gorm:"serializer:encrypted"`
 	BookingTime  time.Time `json:"booking_time"`
 	Status       string    `json:"status"`
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
+	// Set for cancelled bookings; RefundStatus is requested, processed or declined
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CancellationReason string     `json:"cancellation_reason,omitempty"`
+	RefundStatus       string     `json:"refund_status,omitempty"`
+	RescheduleCount    int        `json:"reschedule_count"`
+	// Set once the devotee's pass was scanned at the temple counter
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	// Answers to the seva's booking form; FormFields gives their labels and order
+	FormData   datatypes.JSON `json:"form_data,omitempty"`
+	FormFields datatypes.JSON `json:"-"`
 }
 
 // DonationReportRow represents a single row in the donations report
@@ -150,7 +250,7 @@ type DonationReportRow struct {
 	ID            uint      `json:"id"`
 	DonorName     string    `json:"donor_name"`
 	TempleName    string    `json:"temple_name"`
-	DonorEmail    string    `json:"donor_email"`
+	DonorEmail    string    `json:"donor_email" mask:"email"`
 	Amount        float64   `json:"amount"`
 	DonationType  string    `json:"donation_type"`
 	PaymentMethod string    `json:"payment_method"`
@@ -164,12 +264,13 @@ type DonationReportRow struct {
 
 // TempleRegisteredReportRequest represents request parameters for temple registered report
 type TempleRegisteredReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	Status    string    `json:"status"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Format    string    `json:"format"`
+	EntityID  string       `json:"entity_id"`
+	Status    string       `json:"status"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Format    string       `json:"format"`
+	Page      *PageRequest `json:"-"` // preview paging; nil for exports
 }
 
 // TempleRegisteredReportRow represents a single row in the temples registered report
@@ -182,36 +283,39 @@ type TempleRegisteredReportRow struct {
 
 // DevoteeBirthdaysReportRequest represents request parameters for devotee birthdays report
 type DevoteeBirthdaysReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Format    string    `json:"format"`
+	EntityID  string       `json:"entity_id"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Format    string       `json:"format"`
+	Page      *PageRequest `json:"-"` // preview paging; nil for exports
 }
 
 // DevoteeBirthdayReportRow represents a single row in the devotee birthdays report
 type DevoteeBirthdayReportRow struct {
 	FullName    string    `json:"full_name"`
-	DateOfBirth time.Time `json:"date_of_birth"`
+	DateOfBirth time.Time `json:"date_of_birth" gorm:"serializer:encrypted"`
 	Gender      string    `json:"gender"`
-	Phone       string    `json:"phone"`
-	Email       string    `json:"email"`
+	Phone       string    `json:"phone" mask:"phone" gorm:"serializer:encrypted"`
+	Email       string    `json:"email" mask:"email"`
 	TempleName  string    `json:"temple_name"`
 	MemberSince time.Time `json:"member_since"`
 }
 
 // DevoteeListReportRequest represents request parameters for devotee list report
 type DevoteeListReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Status    string    `json:"status"` // active, inactive, blocked, etc.
-	Format    string    `json:"format"`
+	EntityID  string       `json:"entity_id"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Status    string       `json:"status"` // active, inactive, blocked, etc.
+	Format    string       `json:"format"`
+	Page      *PageRequest `json:"-"` // preview paging; nil for exports
 }
 
 // DevoteeListReportRow represents a single row in the devotee list report
 type DevoteeListReportRow struct {
+	MembershipID  uint      `json:"-"` // orders streamed exports
 	UserID        string    `json:"user_id"`
 	DevoteeName   string    `json:"devotee_name"`
 	TempleName    string    `json:"temple_name"`
@@ -222,12 +326,13 @@ type DevoteeListReportRow struct {
 
 // DevoteeProfileReportRequest represents request parameters for devotee profile report
 type DevoteeProfileReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Status    string    `json:"status"` // active, inactive, blocked, etc.
-	Format    string    `json:"format"`
+	EntityID  string       `json:"entity_id"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Status    string       `json:"status"` // active, inactive, blocked, etc.
+	Format    string       `json:"format"`
+	Page      *PageRequest `json:"-"` // preview paging; nil for exports
 }
 
 // DevoteeProfileReportRow represents a single row in the devotee profile report
@@ -235,13 +340,30 @@ type DevoteeProfileReportRow struct {
 	UserID      string    `json:"user_id"`
 	FullName    string    `json:"full_name"`
 	TempleName  string    `json:"temple_name"`
-	DOB         time.Time `json:"dob"`
+	DOB         time.Time `json:"dob" gorm:"serializer:encrypted"`
 	Gender      string    `json:"gender"`
-	FullAddress string    `json:"full_address"`
+	FullAddress string    `json:"full_address" mask:"address"`
 	Gotra       string    `json:"gotra"`
 	Nakshatra   string    `json:"nakshatra"`
 	Rashi       string    `json:"rashi"`
 	Lagna       string    `json:"lagna"`
+
+	ProfileAddress // scanned parts of FullAddress
+}
+
+// ProfileAddress is a devotee profile address as stored. Street and pincode
+// are encrypted at rest, so the full address is joined after scanning.
+type ProfileAddress struct {
+	StreetAddress string `json:"-" gorm:"serializer:encrypted"`
+	City          string `json:"-"`
+	State         string `json:"-"`
+	Country       string `json:"-"`
+	Pincode       string `json:"-" gorm:"serializer:encrypted"`
+}
+
+// Join returns the address as "street city state country pincode"
+func (a ProfileAddress) Join() string {
+	return strings.Join([]string{a.StreetAddress, a.City, a.State, a.Country, a.Pincode}, " ")
 }
 
 // DevoteeProfileReportRow_ext represents an extended row with temple name
@@ -249,24 +371,27 @@ type DevoteeProfileReportRow_ext struct {
 	UserID      string    `json:"user_id"`
 	FullName    string    `json:"full_name"`
 	TempleName  string    `json:"temple_name"`
-	DOB         time.Time `json:"dob"`
+	DOB         time.Time `json:"dob" gorm:"serializer:encrypted"`
 	Gender      string    `json:"gender"`
-	FullAddress string    `json:"full_address"`
+	FullAddress string    `json:"full_address" mask:"address"`
 	Gotra       string    `json:"gotra"`
 	Nakshatra   string    `json:"nakshatra"`
 	Rashi       string    `json:"rashi"`
 	Lagna       string    `json:"lagna"`
+
+	ProfileAddress // scanned parts of FullAddress
 }
 
 // AuditLogReportRequest represents request parameters for audit logs report
 type AuditLogReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	Action    string    `json:"action"`
-	Status    string    `json:"status"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Format    string    `json:"format"`
+	EntityID  string       `json:"entity_id"`
+	Action    string       `json:"action"`
+	Status    string       `json:"status"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Format    string       `json:"format"`
+	Page      *PageRequest `json:"-"` // preview paging; nil for exports
 }
 
 type AuditLogReportRow struct {
@@ -286,13 +411,14 @@ type AuditLogReportRow struct {
 
 // ApprovalStatusReportRequest defines filters for approval status report
 type ApprovalStatusReportRequest struct {
-	Role      string    // "tenantadmin" or "templeadmin" or empty (both)
-	Status    string    // "approved", "rejected", "pending"
-	DateRange string    // "weekly", "monthly", etc.
+	Role      string // "tenantadmin" or "templeadmin" or empty (both)
+	Status    string // "approved", "rejected", "pending"
+	DateRange string // "weekly", "monthly", etc.
 	StartDate time.Time
 	EndDate   time.Time
-	Format    string // "excel", "csv", "pdf", or empty for JSON
-	UserID    uint   // Current user requesting the report
+	Format    string       // "excel", "csv", "pdf", or empty for JSON
+	UserID    uint         // Current user requesting the report
+	Page      *PageRequest // preview paging; nil for exports
 }
 
 // ApprovalStatusReportRow represents a single row in the approval status report
@@ -304,28 +430,439 @@ type ApprovalStatusReportRow struct {
 	Status     string     `json:"status"`
 	CreatedAt  time.Time  `json:"created_at"`
 	ApprovedAt *time.Time `json:"approved_at"`
-	Email      string     `json:"email"`
+	Email      string     `json:"email" mask:"email"`
 	//Role       string     `json:"role"` // "tenantadmin" or "templeadmin"
 }
 type UserDetailsReportRow = UserDetailReportRow
 
 type UserDetailReportRequest struct {
-	EntityID  string    `json:"entity_id"`
-	Role      string    `json:"role"`
-	Status    string    `json:"status"`
-	DateRange string    `json:"date_range"`
-	StartDate time.Time `json:"start_date"`
-	EndDate   time.Time `json:"end_date"`
-	Format    string    `json:"format"`
-	UserID    uint      `json:"user_id"`
+	EntityID  string       `json:"entity_id"`
+	Role      string       `json:"role"`
+	Status    string       `json:"status"`
+	DateRange string       `json:"date_range"`
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	Format    string       `json:"format"`
+	UserID    uint         `json:"user_id"`
+	Page      *PageRequest `json:"-"` // preview paging; nil for exports
 }
 
 type UserDetailReportRow struct {
 	ID         uint      `json:"id"`
 	Name       string    `json:"name"`
 	EntityName string    `json:"entity_name"`
-	Email      string    `json:"email"`
+	Email      string    `json:"email" mask:"email"`
 	Role       string    `json:"role"`
 	Status     string    `json:"status"`
 	CreatedAt  time.Time `json:"created_at"`
-}
\ No newline at end of file
+}
+
+// OrganizationTenantSummaryRow holds one tenant's totals in an organization's consolidated report
+type OrganizationTenantSummaryRow struct {
+	TenantID         uint    `json:"tenant_id"`
+	TenantName       string  `json:"tenant_name"`
+	TempleName       string  `json:"temple_name"`
+	TempleCount      int64   `json:"temple_count"`
+	DonationCount    int64   `json:"donation_count"`
+	DonationAmount   float64 `json:"donation_amount"`
+	SevaBookingCount int64   `json:"seva_booking_count"`
+	ApprovedBookings int64   `json:"approved_bookings"`
+}
+
+// WaitlistReportRow is a devotee queued for a full seva or event
+type WaitlistReportRow struct {
+	Kind           string     `json:"kind"` // seva or event
+	ItemName       string     `json:"item_name"`
+	TempleName     string     `json:"temple_name"`
+	DevoteeName    string     `json:"devotee_name"`
+	DevoteePhone   string     `json:"devotee_phone" mask:"phone" gorm:"serializer:encrypted"`
+	Status         string     `json:"status"`   // waitlisted, or offered for events holding a seat offer
+	Position       int64      `json:"position"` // 1-based queue position, 0 once offered a seat
+	WaitingSince   time.Time  `json:"waiting_since"`
+	OfferExpiresAt *time.Time `json:"offer_expires_at,omitempty"`
+}
+
+// DisputeReportRow is a payment dispute raised against a temple's donation
+type DisputeReportRow struct {
+	ID               uint       `json:"id"`
+	GatewayDisputeID string     `json:"gateway_dispute_id"`
+	PaymentID        string     `json:"payment_id"`
+	TempleName       string     `json:"temple_name"`
+	DonorName        string     `json:"donor_name"`
+	Amount           float64    `json:"amount"`
+	AmountDeducted   float64    `json:"amount_deducted"`
+	ReasonCode       string     `json:"reason_code"`
+	Phase            string     `json:"phase"`
+	Status           string     `json:"status"` // open, responded, won or lost
+	RespondBy        *time.Time `json:"respond_by,omitempty"`
+	RespondedAt      *time.Time `json:"responded_at,omitempty"`
+	ResolvedAt       *time.Time `json:"resolved_at,omitempty"`
+	EvidenceCount    int64      `json:"evidence_count"`
+	CreatedAt        time.Time  `json:"created_at"`
+}
+
+// CampaignReportRow is a temple's fundraising campaign with what it has raised
+type CampaignReportRow struct {
+	ID                uint       `json:"id"`
+	Title             string     `json:"title"`
+	TempleName        string     `json:"temple_name"`
+	Status            string     `json:"status"` // active, paused or closed
+	TargetAmount      float64    `json:"target_amount"`
+	RaisedAmount      float64    `json:"raised_amount"`
+	CompletionPercent float64    `json:"completion_percent"`
+	DonationCount     int64      `json:"donation_count"`
+	DonorCount        int64      `json:"donor_count"`
+	StartDate         time.Time  `json:"start_date"`
+	EndDate           *time.Time `json:"end_date,omitempty"`
+	TopDonors         string     `json:"top_donors"` // "Name (amount), ..." largest first
+}
+
+// RecurringDonationReportRow is a devotee's monthly pledge with the payments
+// made against it in the report range
+type RecurringDonationReportRow struct {
+	ID           uint       `json:"id"`
+	DonorName    string     `json:"donor_name"`
+	DonorPhone   string     `json:"donor_phone" mask:"phone" gorm:"serializer:encrypted"`
+	TempleName   string     `json:"temple_name"`
+	Amount       float64    `json:"amount"` // per month
+	DonationType string     `json:"donation_type"`
+	DayOfMonth   int        `json:"day_of_month"`
+	Status       string     `json:"status"` // active, paused or cancelled
+	NextDueDate  time.Time  `json:"next_due_date"`
+	PaidCount    int64      `json:"paid_count"`
+	FailedCount  int64      `json:"failed_count"`
+	TotalPaid    float64    `json:"total_paid"`
+	LastPaidAt   *time.Time `json:"last_paid_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// CouponReportRow is a temple's coupon with its redemptions in the report
+// range. Released redemptions are those of bookings rejected or cancelled and
+// of failed payments; the amounts count applied redemptions only.
+type CouponReportRow struct {
+	ID              uint       `json:"id"`
+	Code            string     `json:"code"`
+	TempleName      string     `json:"temple_name"`
+	DiscountType    string     `json:"discount_type"` // percentage or flat
+	Value           float64    `json:"value"`
+	AppliesTo       string     `json:"applies_to"`
+	IsActive        bool       `json:"is_active"`
+	UsageLimit      int        `json:"usage_limit"` // 0 = unlimited
+	ValidFrom       *time.Time `json:"valid_from,omitempty"`
+	ValidUntil      *time.Time `json:"valid_until,omitempty"`
+	Redemptions     int64      `json:"redemptions"`
+	Released        int64      `json:"released"`
+	UniqueUsers     int64      `json:"unique_users"`
+	GrossAmount     float64    `json:"gross_amount"` // before the discount
+	DiscountTotal   float64    `json:"discount_total"`
+	NetAmount       float64    `json:"net_amount"` // paid by devotees
+	FirstRedeemedAt *time.Time `json:"first_redeemed_at,omitempty"`
+	LastRedeemedAt  *time.Time `json:"last_redeemed_at,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"`
+}
+
+// OrderReportRow is a paid shop order. Orders awaiting payment and those
+// that expired unpaid are left out.
+type OrderReportRow struct {
+	ID             uint       `json:"id"`
+	TempleName     string     `json:"temple_name"`
+	BuyerName      string     `json:"buyer_name"`
+	BuyerEmail     string     `json:"buyer_email"`
+	Items          string     `json:"items"` // "Laddu x2, Calendar x1"
+	ItemCount      int64      `json:"item_count"`
+	Total          float64    `json:"total"`
+	Fulfilment     string     `json:"fulfilment"` // delivery or pickup
+	Status         string     `json:"status"`
+	City           string     `json:"city"`
+	Pincode        string     `json:"pincode"`
+	Courier        string     `json:"courier"`
+	TrackingNumber string     `json:"tracking_number"`
+	PaymentID      string     `json:"payment_id"`
+	PaidAt         *time.Time `json:"paid_at,omitempty"`
+	ShippedAt      *time.Time `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CancelledAt    *time.Time `json:"cancelled_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// HundiCollectionReportRow is one hundi counting session
+type HundiCollectionReportRow struct {
+	ID            uint       `json:"id"`
+	TempleName    string     `json:"temple_name"`
+	HundiName     string     `json:"hundi_name"`
+	CountedOn     time.Time  `json:"counted_on"`
+	Denominations string     `json:"denominations"` // "500 x 12, 100 x 40"
+	Counters      string     `json:"counters"`      // names, comma separated
+	CountedTotal  float64    `json:"counted_total"`
+	DeclaredTotal float64    `json:"declared_total"`
+	Discrepancy   float64    `json:"discrepancy"` // declared minus counted
+	Flagged       bool       `json:"flagged"`
+	FlagReason    string     `json:"flag_reason"`
+	Status        string     `json:"status"` // pending, approved or rejected
+	RecordedBy    string     `json:"recorded_by"`
+	ReviewedBy    string     `json:"reviewed_by"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNote    string     `json:"review_note"`
+}
+
+// VolunteerReportRow is a temple volunteer with their sign-ups for slots in
+// the report range
+type VolunteerReportRow struct {
+	ID             uint      `json:"id"`
+	VolunteerName  string    `json:"volunteer_name"`
+	VolunteerPhone string    `json:"volunteer_phone" mask:"phone" gorm:"serializer:encrypted"`
+	VolunteerEmail string    `json:"volunteer_email" mask:"email"`
+	TempleName     string    `json:"temple_name"`
+	Skills         string    `json:"skills"` // comma separated tags
+	Status         string    `json:"status"` // active or inactive
+	Signups        int64     `json:"signups"`
+	Attended       int64     `json:"attended"`
+	NoShows        int64     `json:"no_shows"`
+	RegisteredAt   time.Time `json:"registered_at"`
+}
+
+// RSVPReportRow is a devotee's RSVP to an event day in the report range.
+// Attendance is present once checked in at the counter, absent for attending
+// devotees after the day has passed, and empty otherwise.
+type RSVPReportRow struct {
+	ID           uint       `json:"id"`
+	EventTitle   string     `json:"event_title"`
+	TempleName   string     `json:"temple_name"`
+	EventDate    time.Time  `json:"event_date"`
+	DevoteeName  string     `json:"devotee_name"`
+	DevoteePhone string     `json:"devotee_phone" mask:"phone" gorm:"serializer:encrypted"`
+	Status       string     `json:"status"`
+	RSVPDate     time.Time  `json:"rsvp_date"`
+	CheckedInAt  *time.Time `json:"checked_in_at,omitempty"`
+	Attendance   string     `json:"attendance"`
+}
+
+// ExportAuditReportRequest filters the export audit report
+type ExportAuditReportRequest struct {
+	Role       string       `json:"role"`        // role of the exporting user
+	TenantID   uint         `json:"tenant_id"`   // 0 = every tenant
+	ReportType string       `json:"report_type"` // as recorded in the export's audit log
+	PIIOnly    bool         `json:"pii_only"`
+	DateRange  string       `json:"date_range"`
+	StartDate  time.Time    `json:"start_date"`
+	EndDate    time.Time    `json:"end_date"`
+	Format     string       `json:"format"`
+	Page       *PageRequest `json:"-"` // preview paging; nil for exports
+}
+
+// ExportAuditReportRow is one report export: who ran it, for which tenant
+// and how many rows it held
+type ExportAuditReportRow struct {
+	ID          uint      `json:"id"` // audit log ID
+	ExportedAt  time.Time `json:"exported_at"`
+	UserID      *uint     `json:"user_id"`
+	UserName    string    `json:"user_name"`
+	UserEmail   string    `json:"user_email" mask:"email"`
+	UserRole    string    `json:"user_role"`
+	TenantID    uint      `json:"tenant_id"` // 0 for platform users
+	TenantName  string    `json:"tenant_name"`
+	ReportType  string    `json:"report_type"`
+	Format      string    `json:"format"`
+	FileName    string    `json:"file_name"`
+	RecordCount *int64    `json:"record_count"` // unknown for older exports
+	PII         bool      `json:"pii"`          // the report holds personal data
+	Streamed    bool      `json:"streamed"`     // written straight to storage by a report job
+}
+
+// ExportAuditSummary totals the exports matching the report filters
+type ExportAuditSummary struct {
+	Exports    int64 `json:"exports"`
+	PIIExports int64 `json:"pii_exports"`
+	Records    int64 `json:"records"`
+	Users      int64 `json:"users"`
+	Tenants    int64 `json:"tenants"`
+}
+
+// IncomeExpenseReportRequest is the period of an income and expense statement
+type IncomeExpenseReportRequest struct {
+	DateRange string    `json:"date_range"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	Format    string    `json:"format"` // excel or pdf, empty for JSON
+}
+
+// IncomeExpenseLine totals one donation type or expense category
+type IncomeExpenseLine struct {
+	Category string  `json:"category"`
+	Count    int64   `json:"count"`
+	Amount   float64 `json:"amount"`
+}
+
+// IncomeExpenseStatement is a temple's income against its approved expenses
+type IncomeExpenseStatement struct {
+	EntityID        uint                `json:"entity_id"`
+	TempleName      string              `json:"temple_name"`
+	StartDate       string              `json:"start_date"`
+	EndDate         string              `json:"end_date"`
+	Income          []IncomeExpenseLine `json:"income"`   // successful donations by type
+	Expenses        []IncomeExpenseLine `json:"expenses"` // approved expenses by category
+	TotalIncome     float64             `json:"total_income"`
+	TotalExpenses   float64             `json:"total_expenses"`
+	Net             float64             `json:"net"`              // surplus, or deficit when negative
+	PendingExpenses float64             `json:"pending_expenses"` // awaiting approval, not in the totals
+}
+
+// FinancialYearReportRequest picks the financial year of the summary
+type FinancialYearReportRequest struct {
+	StartYear int    `json:"start_year"` // 2024 for FY 2024-25 (April 2024 to March 2025)
+	Format    string `json:"format"`     // excel or pdf, empty for JSON
+}
+
+// FinancialYearMonth is one month of the financial year summary
+type FinancialYearMonth struct {
+	Month      string  `json:"month"` // 2024-04
+	Label      string  `json:"label"` // Apr 2024
+	Donations  float64 `json:"donations"`
+	SevaIncome float64 `json:"seva_income"`
+	Expenses   float64 `json:"expenses"`
+	Net        float64 `json:"net"`
+	Closing    float64 `json:"closing"` // balance at the end of the month
+}
+
+// FinancialYearSummary is a temple's accounts for one April to March year.
+// Balances count successful donations and approved seva bookings as income
+// and approved expenses as spending, from the temple's first record.
+type FinancialYearSummary struct {
+	EntityID        uint                 `json:"entity_id"`
+	TempleName      string               `json:"temple_name"`
+	FinancialYear   string               `json:"financial_year"` // 2024-25
+	StartDate       string               `json:"start_date"`
+	EndDate         string               `json:"end_date"`
+	OpeningBalance  float64              `json:"opening_balance"`
+	Donations       []IncomeExpenseLine  `json:"donations"` // successful donations by type
+	TotalDonations  float64              `json:"total_donations"`
+	SevaBookings    int64                `json:"seva_bookings"` // approved bookings of paid sevas
+	SevaIncome      float64              `json:"seva_income"`
+	TotalIncome     float64              `json:"total_income"`
+	Expenses        []IncomeExpenseLine  `json:"expenses"` // approved expenses by category
+	TotalExpenses   float64              `json:"total_expenses"`
+	Net             float64              `json:"net"`
+	ClosingBalance  float64              `json:"closing_balance"`
+	PendingExpenses float64              `json:"pending_expenses"` // awaiting approval, not in the totals
+	Months          []FinancialYearMonth `json:"months"`
+}
+
+// DonorAnalyticsReportRequest is the period and thresholds of the donor
+// analytics report
+type DonorAnalyticsReportRequest struct {
+	DateRange    string    `json:"date_range"`
+	StartDate    time.Time `json:"start_date"`
+	EndDate      time.Time `json:"end_date"`
+	Top          int       `json:"top"`           // number of top donors
+	LapsedMonths int       `json:"lapsed_months"` // months without a donation before a donor counts as lapsed
+	Format       string    `json:"format"`
+}
+
+// TopDonorRow is a donor's giving in the report period
+type TopDonorRow struct {
+	Rank           int       `json:"rank"`
+	UserID         uint      `json:"user_id"`
+	DonorName      string    `json:"donor_name"`
+	DonorEmail     string    `json:"donor_email" mask:"email"`
+	DonationCount  int64     `json:"donation_count"`
+	TotalAmount    float64   `json:"total_amount"`
+	LastDonationAt time.Time `json:"last_donation_at"`
+}
+
+// DonorRetention splits the period's donors into those giving to the temple
+// for the first time and those who gave before
+type DonorRetention struct {
+	Donors          int64   `json:"donors"`
+	FirstTime       int64   `json:"first_time"`
+	Repeat          int64   `json:"repeat"`
+	RepeatRate      float64 `json:"repeat_rate"` // percent of donors who gave before
+	FirstTimeAmount float64 `json:"first_time_amount"`
+	RepeatAmount    float64 `json:"repeat_amount"`
+}
+
+// LapsedDonorRow is a past donor with no donation in the lapse window
+type LapsedDonorRow struct {
+	UserID         uint      `json:"user_id"`
+	DonorName      string    `json:"donor_name"`
+	DonorEmail     string    `json:"donor_email" mask:"email"`
+	DonationCount  int64     `json:"donation_count"`
+	LifetimeAmount float64   `json:"lifetime_amount"`
+	LastDonationAt time.Time `json:"last_donation_at"`
+}
+
+// GiftSizePoint is one month of the average gift size trend
+type GiftSizePoint struct {
+	Month       string  `json:"month"` // 2025-04
+	Label       string  `json:"label"` // Apr 2025
+	Donations   int64   `json:"donations"`
+	Amount      float64 `json:"amount"`
+	AverageGift float64 `json:"average_gift"`
+}
+
+// DonorAnalytics describes a temple's donors over a period from successful
+// donations
+type DonorAnalytics struct {
+	EntityID      uint             `json:"entity_id"`
+	TempleName    string           `json:"temple_name"`
+	StartDate     string           `json:"start_date"`
+	EndDate       string           `json:"end_date"`
+	TopDonors     []TopDonorRow    `json:"top_donors"`
+	Retention     DonorRetention   `json:"retention"`
+	LapsedMonths  int              `json:"lapsed_months"`
+	LapsedSince   string           `json:"lapsed_since"` // no donation on or after this date
+	LapsedDonors  []LapsedDonorRow `json:"lapsed_donors"`
+	GiftSizeTrend []GiftSizePoint  `json:"gift_size_trend"`
+}
+
+// InventoryValuationReportRequest filters the inventory valuation report
+type InventoryValuationReportRequest struct {
+	Kind            string    `json:"kind"` // consumable or asset, empty for both
+	IncludeInactive bool      `json:"include_inactive"`
+	DateRange       string    `json:"date_range"`
+	StartDate       time.Time `json:"start_date"` // purchases and consumption from
+	EndDate         time.Time `json:"end_date"`
+	Format          string    `json:"format"`
+}
+
+// InventoryValuationReportRow is an item's stock value and its movement in
+// the report range
+type InventoryValuationReportRow struct {
+	ID                uint    `json:"id"`
+	Name              string  `json:"name"`
+	Kind              string  `json:"kind"`
+	Category          string  `json:"category"`
+	Unit              string  `json:"unit"`
+	Quantity          float64 `json:"quantity"`
+	UnitCost          float64 `json:"unit_cost"` // weighted average
+	StockValue        float64 `json:"stock_value"`
+	ReorderLevel      float64 `json:"reorder_level"`
+	LowStock          bool    `json:"low_stock"`
+	PurchasedQuantity float64 `json:"purchased_quantity"`
+	PurchasedValue    float64 `json:"purchased_value"`
+	ConsumedQuantity  float64 `json:"consumed_quantity"`
+	ConsumedValue     float64 `json:"consumed_value"`
+}
+
+// FamilyReportRequest filters the family mailing report
+type FamilyReportRequest struct {
+	City   string `json:"city"`
+	Format string `json:"format"`
+}
+
+// FamilyReportRow is a household of devotees with the address mailings go
+// to: the family's own address, else the head's profile address
+type FamilyReportRow struct {
+	FamilyID      uint   `json:"family_id"`
+	FamilyName    string `json:"family_name"`
+	HeadName      string `json:"head_name"`
+	HeadPhone     string `json:"head_phone" mask:"phone" gorm:"serializer:encrypted"`
+	HeadEmail     string `json:"head_email" mask:"email"`
+	StreetAddress string `json:"street_address" mask:"address" gorm:"serializer:encrypted"`
+	City          string `json:"city"`
+	State         string `json:"state"`
+	Pincode       string `json:"pincode" gorm:"serializer:encrypted"`
+	Country       string `json:"country"`
+	MemberCount   int    `json:"member_count"`
+	Members       string `json:"members"` // names, head first
+}
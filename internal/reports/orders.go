@@ -0,0 +1,185 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetOrders returns the temples' paid shop orders placed in the range
+func (r *repository) GetOrders(entityIDs []uint, start, end time.Time, page *PageRequest) ([]OrderReportRow, error) {
+	var out []OrderReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("shop_orders o").
+		Select(`o.id, COALESCE(e.name, '') as temple_name, COALESCE(u.full_name, '') as buyer_name,
+			COALESCE(u.email, '') as buyer_email, COALESCE(li.items, '') as items, COALESCE(li.item_count, 0) as item_count,
+			o.total, o.fulfilment, o.status, o.city, o.pincode, o.courier, o.tracking_number, o.payment_id,
+			o.paid_at, o.shipped_at, o.delivered_at, o.cancelled_at, o.created_at`).
+		Joins("LEFT JOIN entities e ON e.id = o.entity_id").
+		Joins("LEFT JOIN users u ON u.id = o.user_id").
+		Joins(`LEFT JOIN (
+				SELECT order_id, STRING_AGG(name || ' x' || quantity, ', ' ORDER BY id) as items, SUM(quantity) as item_count
+				FROM shop_order_items GROUP BY order_id
+			) li ON li.order_id = o.id`).
+		Where("o.entity_id IN ?", entityIDs).
+		Where("o.status NOT IN ?", []string{"pending_payment", "expired"}).
+		Where("o.created_at BETWEEN ? AND ?", start, end)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"temple_name": "temple_name",
+		"buyer_name":  "buyer_name",
+		"total":       "o.total",
+		"status":      "o.status",
+		"paid_at":     "o.paid_at",
+		"created_at":  "o.created_at",
+	}, "o.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Orders by format
+func (e *reportExporter) exportOrdersByFormat(format, timestamp string, rows []OrderReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportOrdersExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("orders_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportOrdersCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("orders_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportOrdersPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("orders_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for orders: %s", format)
+	}
+}
+
+var orderHeaders = []string{"Order ID", "Temple Name", "Devotee", "Items", "Units", "Total", "Fulfilment", "Status", "City", "Courier", "Tracking No.", "Paid At", "Delivered At", "Ordered At"}
+
+func orderRecord(row OrderReportRow) []string {
+	paidAt, deliveredAt := "", ""
+	if row.PaidAt != nil {
+		paidAt = row.PaidAt.Format("2006-01-02 15:04")
+	}
+	if row.DeliveredAt != nil {
+		deliveredAt = row.DeliveredAt.Format("2006-01-02 15:04")
+	}
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.TempleName,
+		row.BuyerName,
+		row.Items,
+		strconv.FormatInt(row.ItemCount, 10),
+		fmt.Sprintf("%.2f", row.Total),
+		row.Fulfilment,
+		row.Status,
+		row.City,
+		row.Courier,
+		row.TrackingNumber,
+		paidAt,
+		deliveredAt,
+		row.CreatedAt.Format("2006-01-02 15:04"),
+	}
+}
+
+func (e *reportExporter) exportOrdersExcel(rows []OrderReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Orders"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range orderHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range orderRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportOrdersCSV(rows []OrderReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(orderHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(orderRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportOrdersPDF(rows []OrderReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Orders Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 8)
+	widths := []float64{14, 24, 24, 39, 11, 16, 17, 16, 18, 18, 20, 20, 20, 20}
+	for i, header := range orderHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range orderRecord(row) {
+			limit := 18
+			if i == 3 {
+				limit = 32
+			}
+			value = truncateText(value, limit)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
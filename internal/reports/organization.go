@@ -0,0 +1,288 @@
+package reports
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+var errNoOrganization = errors.New("you are not an administrator of any organization")
+
+// ======================
+// Organization scope
+// ======================
+
+// GetOrganizationIDForAdmin returns the organization an orgadmin user manages
+func (r *repository) GetOrganizationIDForAdmin(userID uint) (uint, error) {
+	var ids []uint
+	if err := r.db.Table("organization_admins").
+		Select("organization_id").
+		Where("user_id = ?", userID).
+		Limit(1).
+		Scan(&ids).Error; err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return ids[0], nil
+}
+
+// GetEntitiesByOrganization returns the temples of every tenant in the
+// organization. Sandbox tenants are left out like in platform reports.
+func (r *repository) GetEntitiesByOrganization(orgID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Table("entities").
+		Select("id").
+		Where("created_by IN (SELECT tenant_id FROM organization_tenants WHERE organization_id = ?)", orgID).
+		Where("created_by NOT IN (SELECT id FROM users WHERE sandbox = true)").
+		Scan(&ids).Error
+	return ids, err
+}
+
+// GetOrganizationTenantSummary totals successful donations and seva bookings
+// per tenant of the organization within the date range
+func (r *repository) GetOrganizationTenantSummary(orgID uint, start, end time.Time) ([]OrganizationTenantSummaryRow, error) {
+	var out []OrganizationTenantSummaryRow
+	err := r.db.Table("organization_tenants ot").
+		Select(`
+			ot.tenant_id,
+			u.full_name as tenant_name,
+			COALESCE(td.temple_name, '') as temple_name,
+			COALESCE(et.temple_count, 0) as temple_count,
+			COALESCE(ds.donation_count, 0) as donation_count,
+			COALESCE(ds.donation_amount, 0) as donation_amount,
+			COALESCE(bs.seva_booking_count, 0) as seva_booking_count,
+			COALESCE(bs.approved_bookings, 0) as approved_bookings
+		`).
+		Joins("JOIN users u ON u.id = ot.tenant_id").
+		Joins("LEFT JOIN tenant_details td ON td.user_id = ot.tenant_id").
+		Joins(`LEFT JOIN (
+			SELECT created_by as tenant_id, COUNT(*) as temple_count
+			FROM entities GROUP BY created_by
+		) et ON et.tenant_id = ot.tenant_id`).
+		Joins(`LEFT JOIN (
+			SELECT e.created_by as tenant_id, COUNT(*) as donation_count, SUM(d.amount) as donation_amount
+			FROM donations d JOIN entities e ON e.id = d.entity_id
+			WHERE LOWER(d.status) = 'success' AND d.created_at BETWEEN ? AND ?
+			GROUP BY e.created_by
+		) ds ON ds.tenant_id = ot.tenant_id`, start, end).
+		Joins(`LEFT JOIN (
+			SELECT e.created_by as tenant_id, COUNT(*) as seva_booking_count,
+				COUNT(*) FILTER (WHERE sb.status = 'approved') as approved_bookings
+			FROM seva_bookings sb JOIN entities e ON e.id = sb.entity_id
+			WHERE sb.created_at BETWEEN ? AND ?
+			GROUP BY e.created_by
+		) bs ON bs.tenant_id = ot.tenant_id`, start, end).
+		Where("ot.organization_id = ?", orgID).
+		Where("u.sandbox = false").
+		Order("u.full_name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// resolveOrganizationID picks the organization a report is for: superadmins
+// name it in the URL, organization admins always get their own
+func (h *Handler) resolveOrganizationID(c *gin.Context, ctx middleware.AccessContext) (uint, bool) {
+	switch ctx.RoleName {
+	case middleware.RoleSuperAdmin:
+		orgID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+		if err != nil || orgID == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid organization ID format"})
+			return 0, false
+		}
+		return uint(orgID), true
+	case middleware.RoleOrgAdmin:
+		orgID, err := h.repo.GetOrganizationIDForAdmin(ctx.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusForbidden, gin.H{"error": errNoOrganization.Error()})
+			return 0, false
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to resolve organization"})
+			return 0, false
+		}
+		return orgID, true
+	default:
+		c.JSON(http.StatusForbidden, gin.H{"error": "only superadmin or organization admins can access this endpoint"})
+		return 0, false
+	}
+}
+
+// GetOrganizationActivities returns the events, sevas, bookings or donations
+// report across all temples of an organization
+func (h *Handler) GetOrganizationActivities(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+
+	orgID, ok := h.resolveOrganizationID(c, ctx)
+	if !ok {
+		return
+	}
+
+	reportType := c.Query("type")
+	if reportType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "type query param required: events|sevas|bookings|donations|waitlist|disputes|campaigns|recurring-donations|hundi-collection|volunteers|rsvps"})
+		return
+	}
+
+	dateRange := c.Query("date_range")
+	if dateRange == "" {
+		dateRange = DateRangeWeekly
+	}
+	format := c.Query("format") // excel, csv, pdf -> if empty return JSON
+	if !h.allowExportFormat(c, ctx, reportType, format) {
+		return
+	}
+
+	start, end, err := GetDateRange(reportType, dateRange, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+	tithis, err := parseTithiFilter(c, reportType)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "tithi"})
+		return
+	}
+
+	entityIDs, err := h.repo.GetEntitiesByOrganization(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch organization entities"})
+		return
+	}
+	if len(entityIDs) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"data":            ReportData{},
+			"message":         "No entities found for the organization",
+			"organization_id": orgID,
+		})
+		return
+	}
+
+	entityIDStrs := make([]string, 0, len(entityIDs))
+	for _, id := range entityIDs {
+		entityIDStrs = append(entityIDStrs, fmt.Sprint(id))
+	}
+
+	req := ActivitiesReportRequest{
+		EntityID:  fmt.Sprintf("organization_%d", orgID),
+		Type:      reportType,
+		DateRange: dateRange,
+		StartDate: start,
+		EndDate:   end,
+		Format:    format,
+		EntityIDs: entityIDStrs,
+		Tithis:    tithis,
+	}
+
+	if req.Format == "" {
+		page, err := ParsePageRequest(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		req.Page = page
+
+		data, err := h.service.GetActivities(req)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		h.maskPreview(ctx, &data)
+
+		details := map[string]interface{}{
+			"report_type":     req.Type,
+			"format":          "json_preview",
+			"organization_id": orgID,
+			"entity_count":    len(req.EntityIDs),
+			"date_range":      req.DateRange,
+		}
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "ORGANIZATION_ACTIVITIES_REPORT_VIEWED", details, ip, "success")
+
+		setPageHeaders(c, req.Page)
+		c.JSON(http.StatusOK, data)
+		return
+	}
+
+	bytes, fname, mime, err := h.service.ExportActivities(h.exportContext(c, ctx), req, &ctx.UserID, ip)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", fname))
+	c.Data(http.StatusOK, mime, bytes)
+}
+
+// GetOrganizationSummary returns donation and booking totals per tenant of an
+// organization, plus the organization-wide totals
+func (h *Handler) GetOrganizationSummary(c *gin.Context) {
+	accessContext, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	ctx := accessContext.(middleware.AccessContext)
+
+	orgID, ok := h.resolveOrganizationID(c, ctx)
+	if !ok {
+		return
+	}
+
+	dateRange := c.Query("date_range")
+	if dateRange == "" {
+		dateRange = DateRangeMonthly
+	}
+	start, end, err := GetDateRange(ReportTypeOrganizationSummary, dateRange, c.Query("start_date"), c.Query("end_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dateRangeErrorJSON(err))
+		return
+	}
+
+	rows, err := h.repo.GetOrganizationTenantSummary(orgID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build organization summary"})
+		return
+	}
+
+	var totals OrganizationTenantSummaryRow
+	for _, row := range rows {
+		totals.TempleCount += row.TempleCount
+		totals.DonationCount += row.DonationCount
+		totals.DonationAmount += row.DonationAmount
+		totals.SevaBookingCount += row.SevaBookingCount
+		totals.ApprovedBookings += row.ApprovedBookings
+	}
+
+	h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, "ORGANIZATION_SUMMARY_REPORT_VIEWED", map[string]interface{}{
+		"organization_id": orgID,
+		"date_range":      dateRange,
+	}, middleware.GetIPFromContext(c), "success")
+
+	c.JSON(http.StatusOK, gin.H{
+		"organization_id": orgID,
+		"start_date":      start,
+		"end_date":        end,
+		"tenants":         rows,
+		"totals": gin.H{
+			"tenant_count":       len(rows),
+			"temple_count":       totals.TempleCount,
+			"donation_count":     totals.DonationCount,
+			"donation_amount":    totals.DonationAmount,
+			"seva_booking_count": totals.SevaBookingCount,
+			"approved_bookings":  totals.ApprovedBookings,
+		},
+	})
+}
@@ -0,0 +1,144 @@
+package reports
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Preview pagination defaults
+const (
+	DefaultPageLimit = 100
+	MaxPageLimit     = 1000
+)
+
+// PageRequest carries page/limit/sort_by/order for JSON previews.
+// A nil *PageRequest means "no paging" (file exports). Total is filled in by
+// the repository after the query runs.
+type PageRequest struct {
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+	SortBy string `json:"sort_by,omitempty"`
+	Order  string `json:"order"`
+	Total  int64  `json:"total"`
+}
+
+// PageInfo is the pagination block returned with previews
+type PageInfo struct {
+	Page       int    `json:"page"`
+	Limit      int    `json:"limit"`
+	Total      int64  `json:"total"`
+	TotalPages int    `json:"total_pages"`
+	SortBy     string `json:"sort_by,omitempty"`
+	Order      string `json:"order"`
+}
+
+// ParsePageRequest reads ?page=&limit=&sort_by=&order= with sane defaults
+func ParsePageRequest(c *gin.Context) (*PageRequest, error) {
+	p := &PageRequest{Page: 1, Limit: DefaultPageLimit, Order: "desc"}
+
+	if v := c.Query("page"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New("page must be a positive integer")
+		}
+		p.Page = n
+	}
+	if v := c.Query("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return nil, errors.New("limit must be a positive integer")
+		}
+		if n > MaxPageLimit {
+			n = MaxPageLimit
+		}
+		p.Limit = n
+	}
+	p.SortBy = strings.ToLower(strings.TrimSpace(c.Query("sort_by")))
+	if v := strings.ToLower(strings.TrimSpace(c.Query("order"))); v != "" {
+		if v != "asc" && v != "desc" {
+			return nil, errors.New("order must be asc or desc")
+		}
+		p.Order = v
+	}
+	return p, nil
+}
+
+// Offset returns the row offset of the current page
+func (p *PageRequest) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Info builds the response block once Total is known
+func (p *PageRequest) Info() *PageInfo {
+	if p == nil {
+		return nil
+	}
+	pages := 0
+	if p.Limit > 0 {
+		pages = int((p.Total + int64(p.Limit) - 1) / int64(p.Limit))
+	}
+	return &PageInfo{
+		Page:       p.Page,
+		Limit:      p.Limit,
+		Total:      p.Total,
+		TotalPages: pages,
+		SortBy:     p.SortBy,
+		Order:      p.Order,
+	}
+}
+
+// setPageHeaders exposes pagination on previews whose body is a bare array
+func setPageHeaders(c *gin.Context, p *PageRequest) {
+	info := p.Info()
+	if info == nil {
+		return
+	}
+	c.Header("X-Total-Count", strconv.FormatInt(info.Total, 10))
+	c.Header("X-Page", strconv.Itoa(info.Page))
+	c.Header("X-Limit", strconv.Itoa(info.Limit))
+	c.Header("X-Total-Pages", strconv.Itoa(info.TotalPages))
+}
+
+// paginate counts the filtered rows into page.Total, then applies the sort
+// (sort_by must be a key of sortable, otherwise defaultOrder is used) and
+// LIMIT/OFFSET. With a nil page only defaultOrder is applied.
+func paginate(db, query *gorm.DB, page *PageRequest, sortable map[string]string, defaultOrder string) (*gorm.DB, error) {
+	if page == nil {
+		return query.Order(defaultOrder), nil
+	}
+
+	var total int64
+	if err := db.Table("(?) AS page_count", query.Session(&gorm.Session{})).Count(&total).Error; err != nil {
+		return nil, err
+	}
+	page.Total = total
+
+	if col, ok := sortable[page.SortBy]; ok {
+		query = query.Order(col + " " + strings.ToUpper(page.Order))
+	} else {
+		page.SortBy = ""
+		query = query.Order(defaultOrder)
+	}
+	return query.Limit(page.Limit).Offset(page.Offset()), nil
+}
+
+// paginateSlice pages rows that were assembled in memory
+func paginateSlice[T any](rows []T, page *PageRequest) []T {
+	if page == nil {
+		return rows
+	}
+	page.Total = int64(len(rows))
+	start := page.Offset()
+	if start >= len(rows) {
+		return []T{}
+	}
+	end := start + page.Limit
+	if end > len(rows) {
+		end = len(rows)
+	}
+	return rows[start:end]
+}
@@ -0,0 +1,178 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetRecurringDonations returns the temples' monthly pledges that existed in
+// the range, with the payment links paid and failed within it
+func (r *repository) GetRecurringDonations(entityIDs []uint, start, end time.Time, page *PageRequest) ([]RecurringDonationReportRow, error) {
+	var out []RecurringDonationReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("donation_pledges p").
+		Select(`p.id, COALESCE(u.full_name, '') as donor_name, COALESCE(u.phone, '') as donor_phone,
+			COALESCE(e.name, '') as temple_name, p.amount, p.donation_type, p.day_of_month, p.status,
+			p.next_due_date, p.last_paid_at, p.created_at, p.cancelled_at,
+			COUNT(c.id) FILTER (WHERE c.status = 'paid') as paid_count,
+			COUNT(c.id) FILTER (WHERE c.status = 'failed') as failed_count,
+			COALESCE(SUM(c.amount) FILTER (WHERE c.status = 'paid'), 0) as total_paid`).
+		Joins("LEFT JOIN users u ON u.id = p.user_id").
+		Joins("LEFT JOIN entities e ON e.id = p.entity_id").
+		Joins("LEFT JOIN donation_pledge_charges c ON c.pledge_id = p.id AND c.created_at BETWEEN ? AND ?", start, end).
+		Where("p.entity_id IN ?", entityIDs).
+		Where("p.created_at <= ? AND (p.cancelled_at IS NULL OR p.cancelled_at >= ?)", end, start).
+		Group("p.id, u.full_name, u.phone, e.name")
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"donor_name":    "donor_name",
+		"temple_name":   "temple_name",
+		"amount":        "p.amount",
+		"status":        "p.status",
+		"next_due_date": "p.next_due_date",
+		"total_paid":    "total_paid",
+		"created_at":    "p.created_at",
+	}, "p.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Recurring Donations by format
+func (e *reportExporter) exportRecurringDonationsByFormat(format, timestamp string, rows []RecurringDonationReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportRecurringDonationsExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("recurring_donations_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportRecurringDonationsCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("recurring_donations_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportRecurringDonationsPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("recurring_donations_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for recurring donations: %s", format)
+	}
+}
+
+var recurringDonationHeaders = []string{"Pledge ID", "Donor Name", "Donor Phone", "Temple Name", "Monthly Amount", "Day", "Status", "Next Due", "Paid", "Failed", "Total Paid", "Last Paid At", "Started At"}
+
+func recurringDonationRecord(row RecurringDonationReportRow) []string {
+	lastPaid := ""
+	if row.LastPaidAt != nil {
+		lastPaid = row.LastPaidAt.Format("2006-01-02 15:04:05")
+	}
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.DonorName,
+		row.DonorPhone,
+		row.TempleName,
+		fmt.Sprintf("%.2f", row.Amount),
+		strconv.Itoa(row.DayOfMonth),
+		row.Status,
+		row.NextDueDate.Format("2006-01-02"),
+		strconv.FormatInt(row.PaidCount, 10),
+		strconv.FormatInt(row.FailedCount, 10),
+		fmt.Sprintf("%.2f", row.TotalPaid),
+		lastPaid,
+		row.CreatedAt.Format("2006-01-02"),
+	}
+}
+
+func (e *reportExporter) exportRecurringDonationsExcel(rows []RecurringDonationReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Recurring Donations"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range recurringDonationHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range recurringDonationRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportRecurringDonationsCSV(rows []RecurringDonationReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(recurringDonationHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(recurringDonationRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportRecurringDonationsPDF(rows []RecurringDonationReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Recurring Donations Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 9)
+	widths := []float64{16, 32, 24, 32, 24, 10, 18, 20, 12, 13, 22, 30, 24}
+	for i, header := range recurringDonationHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range recurringDonationRecord(row) {
+			value = truncateText(value, 22)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -2,8 +2,12 @@ package reports
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/sharath018/temple-management-backend/internal/event"
+	"github.com/sharath018/temple-management-backend/internal/panchang"
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -16,18 +20,60 @@ type ReportRepository interface {
 	GetAllEntityIDs() ([]uint, error)
 	GetEntitiesByTenantID(tenantID uint) ([]uint, error)
 
-	GetEvents(entityIDs []uint, start, end time.Time) ([]EventReportRow, error)
-	GetSevas(entityIDs []uint, start, end time.Time) ([]SevaReportRow, error)
-	GetSevaBookings(entityIDs []uint, start, end time.Time) ([]SevaBookingReportRow, error)
-	GetTemplesRegistered(entityIDs []uint, start, end time.Time, status string) ([]TempleRegisteredReportRow, error)
-	GetDevoteeBirthdays(entityIDs []uint, start, end time.Time) ([]DevoteeBirthdayReportRow, error)
-	GetDonations(entityIDs []uint, start, end time.Time) ([]DonationReportRow, error)
-	GetDevoteeList(entityIDs []uint, start, end time.Time, status string) ([]DevoteeListReportRow, error)
-	GetDevoteeProfiles(entityIDs []uint, start, end time.Time, status string) ([]DevoteeProfileReportRow, error)
-	GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Time, status string, all string) ([]DevoteeProfileReportRow_ext, error)
-	GetAuditLogs(entityIDs []uint, start, end time.Time, actionTypes []string, status string) ([]AuditLogReportRow, error)
-	GetApprovalStatus(entityIDs []uint, start, end time.Time, role, status string) ([]ApprovalStatusReportRow, error)
-	GetUserDetails(entityIDs []uint, start, end time.Time, role, status string) ([]UserDetailsReportRow, error)
+	// Organization scope: temples of every tenant the organization owns
+	GetOrganizationIDForAdmin(userID uint) (uint, error)
+	GetEntitiesByOrganization(orgID uint) ([]uint, error)
+	GetOrganizationTenantSummary(orgID uint, start, end time.Time) ([]OrganizationTenantSummaryRow, error)
+
+	GetEvents(entityIDs []uint, start, end time.Time, page *PageRequest) ([]EventReportRow, error)
+	GetSevas(entityIDs []uint, start, end time.Time, page *PageRequest) ([]SevaReportRow, error)
+	GetSevaBookings(entityIDs []uint, start, end time.Time, tithis []string, page *PageRequest) ([]SevaBookingReportRow, error)
+	GetTemplesRegistered(entityIDs []uint, start, end time.Time, status string, page *PageRequest) ([]TempleRegisteredReportRow, error)
+	GetDevoteeBirthdays(entityIDs []uint, start, end time.Time, page *PageRequest) ([]DevoteeBirthdayReportRow, error)
+	GetDonations(entityIDs []uint, start, end time.Time, page *PageRequest) ([]DonationReportRow, error)
+	SummarizeDonations(entityIDs []uint, start, end time.Time, groupBy string) ([]DonationGroup, error)
+	GetWaitlist(entityIDs []uint, start, end time.Time, page *PageRequest) ([]WaitlistReportRow, error)
+	GetDisputes(entityIDs []uint, start, end time.Time, page *PageRequest) ([]DisputeReportRow, error)
+	GetCampaigns(entityIDs []uint, start, end time.Time, page *PageRequest) ([]CampaignReportRow, error)
+	GetRecurringDonations(entityIDs []uint, start, end time.Time, page *PageRequest) ([]RecurringDonationReportRow, error)
+	GetHundiCollections(entityIDs []uint, start, end time.Time, page *PageRequest) ([]HundiCollectionReportRow, error)
+	GetCoupons(entityIDs []uint, start, end time.Time, page *PageRequest) ([]CouponReportRow, error)
+	GetOrders(entityIDs []uint, start, end time.Time, page *PageRequest) ([]OrderReportRow, error)
+	GetVolunteers(entityIDs []uint, start, end time.Time, page *PageRequest) ([]VolunteerReportRow, error)
+	GetRSVPs(entityIDs []uint, start, end time.Time, page *PageRequest) ([]RSVPReportRow, error)
+	GetDevoteeList(entityIDs []uint, start, end time.Time, status string, page *PageRequest) ([]DevoteeListReportRow, error)
+	GetDevoteeProfiles(entityIDs []uint, start, end time.Time, status string, page *PageRequest) ([]DevoteeProfileReportRow, error)
+	GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Time, status string, all string, page *PageRequest) ([]DevoteeProfileReportRow_ext, error)
+	GetAuditLogs(entityIDs []uint, start, end time.Time, actionTypes []string, status string, page *PageRequest) ([]AuditLogReportRow, error)
+	// CountDevoteeList and GetDevoteeListAfter back streamed exports, paging by membership ID
+	CountDevoteeList(entityIDs []uint, start, end time.Time, status string) (int64, error)
+	GetDevoteeListAfter(entityIDs []uint, start, end time.Time, status string, afterID uint, limit int) ([]DevoteeListReportRow, error)
+	// CountAuditLogs and GetAuditLogsAfter back streamed exports, which page by ID instead of offset
+	CountAuditLogs(entityIDs []uint, start, end time.Time, actionTypes []string, status string) (int64, error)
+	GetAuditLogsAfter(entityIDs []uint, start, end time.Time, actionTypes []string, status string, afterID uint, limit int) ([]AuditLogReportRow, error)
+	GetApprovalStatus(entityIDs []uint, start, end time.Time, role, status string, page *PageRequest) ([]ApprovalStatusReportRow, error)
+	GetUserDetails(entityIDs []uint, start, end time.Time, role, status string, page *PageRequest) ([]UserDetailsReportRow, error)
+	// Export audit: report exports recorded in the audit log, platform wide
+	GetExportAudit(req ExportAuditReportRequest) ([]ExportAuditReportRow, error)
+	SummarizeExportAudit(req ExportAuditReportRequest) (ExportAuditSummary, error)
+	GetIncomeExpense(entityID uint, req IncomeExpenseReportRequest) (*IncomeExpenseStatement, error)
+	GetFinancialYear(entityID uint, req FinancialYearReportRequest) (*FinancialYearSummary, error)
+	GetDonorAnalytics(entityID uint, req DonorAnalyticsReportRequest) (*DonorAnalytics, error)
+	GetInventoryValuation(entityID uint, req InventoryValuationReportRequest) ([]InventoryValuationReportRow, error)
+	GetFamilies(entityID uint, req FamilyReportRequest) ([]FamilyReportRow, error)
+
+	// Export templates: per tenant columns and date format of exported reports
+	GetExportTemplate(tenantID uint, reportType string) (*ExportTemplate, error)
+	ListExportTemplates(tenantID uint) ([]ExportTemplate, error)
+	SaveExportTemplate(tmpl *ExportTemplate) error
+	DeleteExportTemplate(tenantID uint, reportType string) error
+	GetTenantIDsForEntities(entityIDs []uint) ([]uint, error)
+
+	// Ledger mappings: per tenant ledgers of accounting exports
+	GetLedgerMapping(tenantID uint) (*LedgerMapping, error)
+	SaveLedgerMapping(m *LedgerMapping) error
+	DeleteLedgerMapping(tenantID uint) error
+	GetLedgerTransactions(entityID uint, req IncomeExpenseReportRequest) ([]LedgerTransaction, error)
 }
 
 type repository struct {
@@ -53,11 +99,13 @@ func (r *repository) GetEntitiesByTenant(userID uint) ([]uint, error) {
 	return ids, err
 }
 
-// Get all entities (for superadmin)
+// Get all entities (for superadmin). Temples of sandbox tenants are left
+// out so test data doesn't show up in platform-wide reports.
 func (r *repository) GetAllEntityIDs() ([]uint, error) {
 	var ids []uint
 	err := r.db.Table("entities").
 		Select("id").
+		Where("created_by NOT IN (SELECT id FROM users WHERE sandbox = true)").
 		Scan(&ids).Error
 	return ids, err
 }
@@ -77,12 +125,18 @@ func (r *repository) GetEntitiesByTenantID(tenantID uint) ([]uint, error) {
 // Reports
 // ======================
 
-func (r *repository) GetEvents(entityIDs []uint, start, end time.Time) ([]EventReportRow, error) {
+// GetEvents lists events in [start, end]. Recurring events are expanded
+// into one row per occurrence, so sorting and paging happen in memory.
+func (r *repository) GetEvents(entityIDs []uint, start, end time.Time, page *PageRequest) ([]EventReportRow, error) {
 	var out []EventReportRow
 	if len(entityIDs) == 0 {
 		return out, nil
 	}
 
+	var rows []struct {
+		EventReportRow
+		RecurrenceExceptions datatypes.JSON
+	}
 	err := r.db.Table("events e").
 		Select(`
 			e.title,
@@ -92,6 +146,8 @@ func (r *repository) GetEvents(entityIDs []uint, start, end time.Time) ([]EventR
 			e.event_date,
 			TO_CHAR(e.event_time, 'HH24:MI') as event_time,
 			e.location,
+			e.recurrence_rule,
+			e.recurrence_exceptions,
 			e.created_by,
 			e.is_active,
 			e.created_at,
@@ -99,18 +155,66 @@ func (r *repository) GetEvents(entityIDs []uint, start, end time.Time) ([]EventR
 		`).
 		Joins("LEFT JOIN entities ent ON e.entity_id = ent.id").
 		Where("e.entity_id IN ?", entityIDs).
-		Where("e.event_date BETWEEN ? AND ?", start, end).
-		Order("e.event_date DESC").
-		Scan(&out).Error
-	return out, err
+		Where(`(e.event_date BETWEEN ? AND ?) OR (e.recurrence_rule <> '' AND e.event_date <= ?
+			AND (e.recurrence_end IS NULL OR e.recurrence_end >= ?))`, start, end, end, start).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		ev := event.Event{
+			EventDate:            row.EventDate,
+			RecurrenceRule:       row.RecurrenceRule,
+			RecurrenceExceptions: row.RecurrenceExceptions,
+		}
+		for _, date := range ev.Occurrences(start, end) {
+			occurrence := row.EventReportRow
+			occurrence.EventDate = date
+			out = append(out, occurrence)
+		}
+	}
+
+	sortEventRows(out, page)
+	return paginateSlice(out, page), nil
 }
-func (r *repository) GetSevas(entityIDs []uint, start, end time.Time) ([]SevaReportRow, error) {
+
+// sortEventRows applies sort_by/order to the expanded event rows, newest
+// event date first by default
+func sortEventRows(rows []EventReportRow, page *PageRequest) {
+	less := func(a, b EventReportRow) bool { return a.EventDate.Before(b.EventDate) }
+	asc := false
+	if page != nil {
+		switch page.SortBy {
+		case "title":
+			less = func(a, b EventReportRow) bool { return a.Title < b.Title }
+		case "temple_name":
+			less = func(a, b EventReportRow) bool { return a.TempleName < b.TempleName }
+		case "event_type":
+			less = func(a, b EventReportRow) bool { return a.EventType < b.EventType }
+		case "event_date":
+		case "created_at":
+			less = func(a, b EventReportRow) bool { return a.CreatedAt.Before(b.CreatedAt) }
+		default:
+			page.SortBy = ""
+		}
+		asc = page.SortBy != "" && page.Order == "asc"
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if asc {
+			return less(rows[i], rows[j])
+		}
+		return less(rows[j], rows[i])
+	})
+}
+
+func (r *repository) GetSevas(entityIDs []uint, start, end time.Time, page *PageRequest) ([]SevaReportRow, error) {
 	var out []SevaReportRow
 	if len(entityIDs) == 0 {
 		return out, nil
 	}
 
-	err := r.db.Table("sevas s").
+	query := r.db.Table("sevas s").
 		Select(`
 			s.name,
 			ent.name as temple_name,
@@ -128,20 +232,33 @@ func (r *repository) GetSevas(entityIDs []uint, start, end time.Time) ([]SevaRep
 		`).
 		Joins("LEFT JOIN entities ent ON s.entity_id = ent.id").
 		Where("s.entity_id IN ?", entityIDs).
-		Where("s.created_at BETWEEN ? AND ?", start, end).
-		Order("s.created_at DESC").
-		Scan(&out).Error
-
+		Where("s.created_at BETWEEN ? AND ?", start, end)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"name":        "s.name",
+		"temple_name": "ent.name",
+		"seva_type":   "s.seva_type",
+		"price":       "s.price",
+		"status":      "s.status",
+		"created_at":  "s.created_at",
+	}, "s.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
 	return out, err
 }
 
-func (r *repository) GetSevaBookings(entityIDs []uint, start, end time.Time) ([]SevaBookingReportRow, error) {
+// GetSevaBookings lists bookings created in the range; tithis keeps those
+// whose day has one of the tithis or observances: the seva's date for dated
+// sevas, else the booking's own date
+func (r *repository) GetSevaBookings(entityIDs []uint, start, end time.Time, tithis []string, page *PageRequest) ([]SevaBookingReportRow, error) {
 	var out []SevaBookingReportRow
 	if len(entityIDs) == 0 {
 		return out, nil
 	}
 
-	err := r.db.Table("seva_bookings sb").
+	query := r.db.Table("seva_bookings sb").
 		Select(`
 			s.name as seva_name,
 			ent.name as temple_name,
@@ -151,25 +268,50 @@ func (r *repository) GetSevaBookings(entityIDs []uint, start, end time.Time) ([]
 			sb.booking_time,
 			sb.status,
 			sb.created_at,
-			sb.updated_at
+			sb.updated_at,
+			sb.cancelled_at,
+			COALESCE(sb.cancellation_reason, '') as cancellation_reason,
+			COALESCE(sb.refund_status, '') as refund_status,
+			COALESCE(sb.reschedule_count, 0) as reschedule_count,
+			sb.checked_in_at,
+			sb.form_data,
+			s.form_fields
 		`).
 		Joins("LEFT JOIN sevas s ON sb.seva_id = s.id").
 		Joins("LEFT JOIN entities ent ON sb.entity_id = ent.id").
 		Joins("LEFT JOIN users u ON sb.user_id = u.id").
 		Where("sb.entity_id IN ?", entityIDs).
-		Where("sb.created_at BETWEEN ? AND ?", start, end).
-		Order("sb.created_at DESC").
-		Scan(&out).Error
+		Where("sb.created_at BETWEEN ? AND ?", start, end)
+	if len(tithis) > 0 {
+		dates := panchang.MatchingDates(start, end, tithis)
+		query = query.Where(`(COALESCE(s.date, '') <> '' AND (LOWER(s.tithi) IN ? OR LOWER(s.paksha || '_' || s.tithi) IN ?
+				OR jsonb_exists_any(s.panchang_tags, ARRAY[?]::text[])))
+			OR (COALESCE(s.date, '') = '' AND TO_CHAR(sb.booking_time AT TIME ZONE ?, 'YYYY-MM-DD') IN ?)`,
+			tithis, tithis, tithis, panchang.Timezone, dates)
+	}
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"seva_name":    "s.name",
+		"temple_name":  "ent.name",
+		"devotee_name": "u.full_name",
+		"booking_time": "sb.booking_time",
+		"status":       "sb.status",
+		"created_at":   "sb.created_at",
+	}, "sb.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
 	return out, err
 }
 
-func (r *repository) GetDonations(entityIDs []uint, start, end time.Time) ([]DonationReportRow, error) {
+func (r *repository) GetDonations(entityIDs []uint, start, end time.Time, page *PageRequest) ([]DonationReportRow, error) {
 	var out []DonationReportRow
 	if len(entityIDs) == 0 {
 		return out, nil
 	}
 
-	err := r.db.Table("donations d").
+	query := r.db.Table("donations d").
 		Select(`
 			d.id,
 			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as donor_name,
@@ -188,13 +330,25 @@ func (r *repository) GetDonations(entityIDs []uint, start, end time.Time) ([]Don
 		Joins("LEFT JOIN users u ON d.user_id = u.id").
 		Joins("LEFT JOIN entities ent ON d.entity_id = ent.id").
 		Where("d.entity_id IN ?", entityIDs).
-		Where("d.created_at BETWEEN ? AND ?", start, end).
-		Order("d.created_at DESC").
-		Scan(&out).Error
+		Where("d.created_at BETWEEN ? AND ?", start, end)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"donor_name":    "u.full_name",
+		"temple_name":   "ent.name",
+		"amount":        "d.amount",
+		"donation_type": "d.donation_type",
+		"status":        "d.status",
+		"donation_date": "COALESCE(d.donated_at, d.created_at)",
+		"created_at":    "d.created_at",
+	}, "d.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
 	return out, err
 }
 
-func (r *repository) GetTemplesRegistered(entityIDs []uint, start, end time.Time, status string) ([]TempleRegisteredReportRow, error) {
+func (r *repository) GetTemplesRegistered(entityIDs []uint, start, end time.Time, status string, page *PageRequest) ([]TempleRegisteredReportRow, error) {
 	var rows []TempleRegisteredReportRow
 	if len(entityIDs) == 0 {
 		return rows, nil
@@ -209,12 +363,21 @@ func (r *repository) GetTemplesRegistered(entityIDs []uint, start, end time.Time
 		query = query.Where("status = ?", status)
 	}
 
-	err := query.Order("created_at DESC").Scan(&rows).Error
+	query, err := paginate(r.db, query, page, map[string]string{
+		"id":         "id",
+		"name":       "name",
+		"status":     "status",
+		"created_at": "created_at",
+	}, "created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&rows).Error
 	return rows, err
 }
 
 // GetDevoteeBirthdays - Fixed version with proper date handling
-func (r *repository) GetDevoteeBirthdays(entityIDs []uint, start, end time.Time) ([]DevoteeBirthdayReportRow, error) {
+func (r *repository) GetDevoteeBirthdays(entityIDs []uint, start, end time.Time, page *PageRequest) ([]DevoteeBirthdayReportRow, error) {
 	var rows []DevoteeBirthdayReportRow
 	if len(entityIDs) == 0 {
 		return rows, nil
@@ -246,27 +409,35 @@ func (r *repository) GetDevoteeBirthdays(entityIDs []uint, start, end time.Time)
 		Where("u.role_id = ?", 3). // Role ID 3 is devotee
 		Where("uem.status = ?", "active").
 		Where("uem.entity_id IN ?", entityIDs).
-		Where("dp.dob IS NOT NULL")
+		Where("dp.birth_day IS NOT NULL") // DOB is encrypted; its MM-DD is kept in plaintext
 
 	// Handle year wrap-around (e.g., Dec 25 to Jan 5)
 	if startMMDD > endMMDD {
 		// Birthday range crosses year boundary
 		query = query.Where(
-			"(TO_CHAR(dp.dob, 'MM-DD') >= ? OR TO_CHAR(dp.dob, 'MM-DD') <= ?)",
+			"(dp.birth_day >= ? OR dp.birth_day <= ?)",
 			startMMDD, endMMDD,
 		)
 	} else {
 		// Normal date range within same year
 		query = query.Where(
-			"TO_CHAR(dp.dob, 'MM-DD') BETWEEN ? AND ?",
+			"dp.birth_day BETWEEN ? AND ?",
 			startMMDD, endMMDD,
 		)
 	}
 
-	query = query.Order("TO_CHAR(dp.dob, 'MM-DD') ASC")
+	query, err := paginate(r.db, query, page, map[string]string{
+		"full_name":     "u.full_name",
+		"temple_name":   "e.name",
+		"date_of_birth": "dp.birth_day",
+		"member_since":  "uem.joined_at",
+	}, "dp.birth_day ASC")
+	if err != nil {
+		return nil, err
+	}
 
 	// Execute query with debug output
-	err := query.Debug().Scan(&rows).Error
+	err = query.Debug().Scan(&rows).Error
 	if err != nil {
 		fmt.Printf("❌ Error fetching birthdays: %v\n", err)
 		return nil, err
@@ -285,14 +456,53 @@ func (r *repository) GetDevoteeBirthdays(entityIDs []uint, start, end time.Time)
 	return rows, nil
 }
 
-func (r *repository) GetDevoteeList(entityIDs []uint, start, end time.Time, status string) ([]DevoteeListReportRow, error) {
+func (r *repository) GetDevoteeList(entityIDs []uint, start, end time.Time, status string, page *PageRequest) ([]DevoteeListReportRow, error) {
 	var rows []DevoteeListReportRow
 	if len(entityIDs) == 0 {
 		return rows, nil
 	}
 
+	query, err := paginate(r.db, r.devoteeListQuery(entityIDs, start, end, status), page, map[string]string{
+		"devotee_name":   "u.full_name",
+		"temple_name":    "en.name",
+		"joined_at":      "uem.joined_at",
+		"devotee_status": "uem.status",
+		"created_at":     "u.created_at",
+	}, "uem.joined_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&rows).Error
+	return rows, err
+}
+
+func (r *repository) CountDevoteeList(entityIDs []uint, start, end time.Time, status string) (int64, error) {
+	var total int64
+	if len(entityIDs) == 0 {
+		return 0, nil
+	}
+	err := r.devoteeListQuery(entityIDs, start, end, status).Count(&total).Error
+	return total, err
+}
+
+func (r *repository) GetDevoteeListAfter(entityIDs []uint, start, end time.Time, status string, afterID uint, limit int) ([]DevoteeListReportRow, error) {
+	var rows []DevoteeListReportRow
+	if len(entityIDs) == 0 {
+		return rows, nil
+	}
+	err := r.devoteeListQuery(entityIDs, start, end, status).
+		Where("uem.id > ?", afterID).
+		Order("uem.id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// devoteeListQuery builds the filtered devotee list query shared by previews and exports
+func (r *repository) devoteeListQuery(entityIDs []uint, start, end time.Time, status string) *gorm.DB {
 	query := r.db.Table("users u").
 		Select(`
+			uem.id as membership_id,
 			u.id as user_id,
 			u.full_name as devotee_name,
 			en.name as temple_name,
@@ -308,12 +518,10 @@ func (r *repository) GetDevoteeList(entityIDs []uint, start, end time.Time, stat
 		query = query.Where("uem.status = ?", status)
 	}
 
-	query = query.Where("uem.joined_at BETWEEN ? AND ?", start, end).Order("uem.joined_at DESC")
-	err := query.Scan(&rows).Error
-	return rows, err
+	return query.Where("uem.joined_at BETWEEN ? AND ?", start, end)
 }
 
-func (r *repository) GetDevoteeProfiles(entityIDs []uint, start, end time.Time, status string) ([]DevoteeProfileReportRow, error) {
+func (r *repository) GetDevoteeProfiles(entityIDs []uint, start, end time.Time, status string, page *PageRequest) ([]DevoteeProfileReportRow, error) {
 	var rows []DevoteeProfileReportRow
 	if len(entityIDs) == 0 {
 		return rows, nil
@@ -326,13 +534,11 @@ func (r *repository) GetDevoteeProfiles(entityIDs []uint, start, end time.Time,
 			en.name as temple_name,  -- ADDED THIS LINE
 			dp.dob,
 			dp.gender,
-			CONCAT(
-				COALESCE(dp.street_address, ''), ' ',
-				COALESCE(dp.city, ''), ' ',
-				COALESCE(dp.state, ''), ' ',
-				COALESCE(dp.country, ''), ' ',
-				COALESCE(dp.pincode, '')
-			) as full_address,
+			dp.street_address,
+			COALESCE(dp.city, '') as city,
+			COALESCE(dp.state, '') as state,
+			COALESCE(dp.country, '') as country,
+			dp.pincode,
 			COALESCE(dp.gotra, '') as gotra,
 			COALESCE(dp.nakshatra, '') as nakshatra,
 			COALESCE(dp.rashi, '') as rashi,
@@ -348,13 +554,28 @@ func (r *repository) GetDevoteeProfiles(entityIDs []uint, start, end time.Time,
 		query = query.Where("uem.status = ?", status)
 	}
 
-	query = query.Where("uem.joined_at BETWEEN ? AND ?", start, end).Order("u.full_name ASC")
-	err := query.Scan(&rows).Error
+	query = query.Where("uem.joined_at BETWEEN ? AND ?", start, end)
+	query, err := paginate(r.db, query, page, map[string]string{
+		"full_name":   "u.full_name",
+		"temple_name": "en.name",
+		"dob":         "dp.birth_day", // DOB is encrypted
+		"gender":      "dp.gender",
+		"gotra":       "dp.gotra",
+		"nakshatra":   "dp.nakshatra",
+		"rashi":       "dp.rashi",
+	}, "u.full_name ASC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&rows).Error
+	for i := range rows {
+		rows[i].FullAddress = rows[i].ProfileAddress.Join()
+	}
 
 	return rows, err
 }
 
-func (r *repository) GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Time, status string, all string) ([]DevoteeProfileReportRow_ext, error) {
+func (r *repository) GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Time, status string, all string, page *PageRequest) ([]DevoteeProfileReportRow_ext, error) {
 	var rows []DevoteeProfileReportRow_ext
 	if len(entityIDs) == 0 {
 		return rows, nil
@@ -367,13 +588,11 @@ func (r *repository) GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Ti
 			en.name as temple_name,
 			dp.dob,
 			dp.gender,
-			CONCAT(
-				COALESCE(dp.street_address, ''), ' ',
-				COALESCE(dp.city, ''), ' ',
-				COALESCE(dp.state, ''), ' ',
-				COALESCE(dp.country, ''), ' ',
-				COALESCE(dp.pincode, '')
-			) as full_address,
+			dp.street_address,
+			COALESCE(dp.city, '') as city,
+			COALESCE(dp.state, '') as state,
+			COALESCE(dp.country, '') as country,
+			dp.pincode,
 			COALESCE(dp.gotra, '') as gotra,
 			COALESCE(dp.nakshatra, '') as nakshatra,
 			COALESCE(dp.rashi, '') as rashi,
@@ -389,19 +608,85 @@ func (r *repository) GetDevoteeProfiles_ext(entityIDs []uint, start, end time.Ti
 		query = query.Where("uem.status = ?", status)
 	}
 
-	query = query.Where("uem.joined_at BETWEEN ? AND ?", start, end).Order("u.full_name ASC")
+	query = query.Where("uem.joined_at BETWEEN ? AND ?", start, end)
+	query, err := paginate(r.db, query, page, map[string]string{
+		"full_name":   "u.full_name",
+		"temple_name": "en.name",
+		"dob":         "dp.birth_day", // DOB is encrypted
+		"gender":      "dp.gender",
+		"gotra":       "dp.gotra",
+		"nakshatra":   "dp.nakshatra",
+		"rashi":       "dp.rashi",
+	}, "u.full_name ASC")
+	if err != nil {
+		return nil, err
+	}
 
-	err := query.Scan(&rows).Error
+	err = query.Scan(&rows).Error
+	for i := range rows {
+		rows[i].FullAddress = rows[i].ProfileAddress.Join()
+	}
 
 	return rows, err
 }
 
-func (r *repository) GetAuditLogs(entityIDs []uint, start, end time.Time, actionTypes []string, status string) ([]AuditLogReportRow, error) {
+func (r *repository) GetAuditLogs(entityIDs []uint, start, end time.Time, actionTypes []string, status string, page *PageRequest) ([]AuditLogReportRow, error) {
 	var rows []AuditLogReportRow
 	if len(entityIDs) == 0 {
 		return rows, nil
 	}
 
+	query := r.auditLogsQuery(entityIDs, start, end, actionTypes, status)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"id":          "al.id",
+		"user_name":   "u.full_name",
+		"entity_name": "e.name",
+		"action":      "al.action",
+		"status":      "al.status",
+		"timestamp":   "al.created_at",
+		"created_at":  "al.created_at",
+	}, "al.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&rows).Error
+	return rows, err
+}
+
+func (r *repository) CountAuditLogs(entityIDs []uint, start, end time.Time, actionTypes []string, status string) (int64, error) {
+	var total int64
+	if len(entityIDs) == 0 {
+		return 0, nil
+	}
+	query := r.db.Table("audit_logs al").
+		Where("al.entity_id IN ?", entityIDs).
+		Where("al.created_at BETWEEN ? AND ?", start, end)
+	if len(actionTypes) > 0 {
+		query = query.Where("al.action IN ?", actionTypes)
+	}
+	if status != "" {
+		query = query.Where("al.status = ?", status)
+	}
+	err := query.Count(&total).Error
+	return total, err
+}
+
+func (r *repository) GetAuditLogsAfter(entityIDs []uint, start, end time.Time, actionTypes []string, status string, afterID uint, limit int) ([]AuditLogReportRow, error) {
+	var rows []AuditLogReportRow
+	if len(entityIDs) == 0 {
+		return rows, nil
+	}
+	err := r.auditLogsQuery(entityIDs, start, end, actionTypes, status).
+		Where("al.id > ?", afterID).
+		Order("al.id ASC").
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, err
+}
+
+// auditLogsQuery builds the filtered audit log report query shared by previews and exports
+func (r *repository) auditLogsQuery(entityIDs []uint, start, end time.Time, actionTypes []string, status string) *gorm.DB {
 	query := r.db.Table("audit_logs al").
 		Select(`
 			al.id,
@@ -430,12 +715,10 @@ func (r *repository) GetAuditLogs(entityIDs []uint, start, end time.Time, action
 	if status != "" {
 		query = query.Where("al.status = ?", status)
 	}
-
-	err := query.Order("al.created_at DESC").Scan(&rows).Error
-	return rows, err
+	return query
 }
 
-func (r *repository) GetApprovalStatus(entityIDs []uint, start, end time.Time, role, status string) ([]ApprovalStatusReportRow, error) {
+func (r *repository) GetApprovalStatus(entityIDs []uint, start, end time.Time, role, status string, page *PageRequest) ([]ApprovalStatusReportRow, error) {
 	var rows []ApprovalStatusReportRow
 
 applyDateFilter := !start.IsZero() && !end.IsZero() && start.Year() > 1
@@ -624,10 +907,41 @@ applyDateFilter := !start.IsZero() && !end.IsZero() && start.Year() > 1
 		}
 	}
 
+	if page != nil {
+		sortApprovalRows(rows, page)
+	}
+	rows = paginateSlice(rows, page)
+
 	fmt.Printf("✅ Returning %d approval status records\n", len(rows))
 	return rows, nil
 }
-func (r *repository) GetUserDetails(entityIDs []uint, start, end time.Time, role, status string) ([]UserDetailsReportRow, error) {
+// sortApprovalRows applies sort_by/order to the merged tenant + temple rows
+func sortApprovalRows(rows []ApprovalStatusReportRow, page *PageRequest) {
+	var less func(a, b ApprovalStatusReportRow) bool
+	switch page.SortBy {
+	case "tenant_name":
+		less = func(a, b ApprovalStatusReportRow) bool { return a.TenantName < b.TenantName }
+	case "entity_name":
+		less = func(a, b ApprovalStatusReportRow) bool { return a.EntityName < b.EntityName }
+	case "status":
+		less = func(a, b ApprovalStatusReportRow) bool { return a.Status < b.Status }
+	case "email":
+		less = func(a, b ApprovalStatusReportRow) bool { return a.Email < b.Email }
+	case "created_at":
+		less = func(a, b ApprovalStatusReportRow) bool { return a.CreatedAt.Before(b.CreatedAt) }
+	default:
+		page.SortBy = ""
+		return // already newest first
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if page.Order == "asc" {
+			return less(rows[i], rows[j])
+		}
+		return less(rows[j], rows[i])
+	})
+}
+
+func (r *repository) GetUserDetails(entityIDs []uint, start, end time.Time, role, status string, page *PageRequest) ([]UserDetailsReportRow, error) {
 	var rows []UserDetailsReportRow
 
 	query := r.db.Table("users u").
@@ -660,6 +974,18 @@ func (r *repository) GetUserDetails(entityIDs []uint, start, end time.Time, role
 		query = query.Where("uem.status = ?", status)
 	}
 
-	err := query.Order("u.created_at DESC").Scan(&rows).Error
+	query, err := paginate(r.db, query, page, map[string]string{
+		"id":          "u.id",
+		"name":        "u.full_name",
+		"entity_name": "e.name",
+		"email":       "u.email",
+		"role":        "ur.role_name",
+		"status":      "uem.status",
+		"created_at":  "u.created_at",
+	}, "u.created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&rows).Error
 	return rows, err
 }
\ No newline at end of file
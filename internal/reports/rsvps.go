@@ -0,0 +1,176 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetRSVPs returns the RSVPs to the temples' events taking place in the range,
+// with attendance from counter check-ins. Attending devotees who never
+// checked in are absent once the event day has passed.
+func (r *repository) GetRSVPs(entityIDs []uint, start, end time.Time, page *PageRequest) ([]RSVPReportRow, error) {
+	var out []RSVPReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	eventDay := "COALESCE(NULLIF(r.occurrence, '')::date, ev.event_date::date)"
+	query := r.db.Table("rsvps r").
+		Select(`r.id, COALESCE(ev.title, '') as event_title, COALESCE(e.name, '') as temple_name,
+			`+eventDay+` as event_date,
+			COALESCE(u.full_name, '') as devotee_name, COALESCE(u.phone, '') as devotee_phone,
+			r.status, r.rsvp_date, r.checked_in_at,
+			CASE WHEN r.checked_in_at IS NOT NULL THEN 'present'
+				WHEN r.status = 'attending' AND `+eventDay+` < CURRENT_DATE THEN 'absent'
+				ELSE '' END as attendance`).
+		Joins("JOIN events ev ON ev.id = r.event_id").
+		Joins("LEFT JOIN entities e ON e.id = ev.entity_id").
+		Joins("LEFT JOIN users u ON u.id = r.user_id").
+		Where("ev.entity_id IN ?", entityIDs).
+		Where(eventDay+" BETWEEN ?::date AND ?::date", start.Format("2006-01-02"), end.Format("2006-01-02"))
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"event_title":   "event_title",
+		"event_date":    "event_date",
+		"devotee_name":  "devotee_name",
+		"status":        "r.status",
+		"rsvp_date":     "r.rsvp_date",
+		"checked_in_at": "r.checked_in_at",
+	}, "event_date DESC, event_title ASC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export RSVPs by format
+func (e *reportExporter) exportRSVPsByFormat(format, timestamp string, rows []RSVPReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportRSVPsExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("rsvps_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportRSVPsCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("rsvps_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportRSVPsPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("rsvps_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for rsvps: %s", format)
+	}
+}
+
+var rsvpHeaders = []string{"RSVP ID", "Event", "Temple Name", "Event Date", "Devotee Name", "Devotee Phone", "Status", "RSVP Date", "Checked In At", "Attendance"}
+
+func rsvpRecord(row RSVPReportRow) []string {
+	checkedIn := ""
+	if row.CheckedInAt != nil {
+		checkedIn = row.CheckedInAt.Format("2006-01-02 15:04:05")
+	}
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.EventTitle,
+		row.TempleName,
+		row.EventDate.Format("2006-01-02"),
+		row.DevoteeName,
+		row.DevoteePhone,
+		row.Status,
+		row.RSVPDate.Format("2006-01-02"),
+		checkedIn,
+		row.Attendance,
+	}
+}
+
+func (e *reportExporter) exportRSVPsExcel(rows []RSVPReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "RSVPs"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range rsvpHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range rsvpRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportRSVPsCSV(rows []RSVPReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(rsvpHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(rsvpRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportRSVPsPDF(rows []RSVPReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Event RSVPs Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 9)
+	widths := []float64{16, 44, 36, 22, 36, 26, 22, 22, 32, 20}
+	for i, header := range rsvpHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range rsvpRecord(row) {
+			value = truncateText(value, 30)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -3,6 +3,7 @@ package reports
 import (
 	"context"
 	"fmt"
+	"io"
 	"strconv"
 
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
@@ -21,18 +22,46 @@ type ReportService interface {
 
 	GetDevoteeListReport(req DevoteeListReportRequest, entityIDs []string) ([]DevoteeListReportRow, error)
 	ExportDevoteeListReport(ctx context.Context, req DevoteeListReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error)
+	StreamDevoteeList(ctx context.Context, w io.Writer, format string, req DevoteeListReportRequest, entityIDs []string, progress func(done, total int64)) (int64, error)
 
 	GetDevoteeProfileReport(req DevoteeProfileReportRequest, entityIDs []string) ([]DevoteeProfileReportRow, error)
 	ExportDevoteeProfileReport(ctx context.Context, req DevoteeProfileReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error)
 
 	GetAuditLogsReport(req AuditLogReportRequest, entityIDs []string) ([]AuditLogReportRow, error)
 	ExportAuditLogsReport(ctx context.Context, req AuditLogReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error)
+	StreamAuditLogs(ctx context.Context, w io.Writer, format string, req AuditLogReportRequest, entityIDs []string, progress func(done, total int64)) (int64, error)
 
 	GetApprovalStatusReport(req ApprovalStatusReportRequest, entityIDs []string) ([]ApprovalStatusReportRow, error)
 	ExportApprovalStatusReport(ctx context.Context, req ApprovalStatusReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error)
 
 	GetUserDetailsReport(req UserDetailReportRequest, entityIDs []string) ([]UserDetailsReportRow, error)
 	ExportUserDetailsReport(ctx context.Context, req UserDetailReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error)
+
+	GetExportAuditReport(req ExportAuditReportRequest) ([]ExportAuditReportRow, error)
+	SummarizeExportAudit(req ExportAuditReportRequest) (ExportAuditSummary, error)
+	ExportExportAuditReport(ctx context.Context, req ExportAuditReportRequest, reportType string, userID *uint, ip string) ([]byte, string, string, error)
+
+	GetIncomeExpenseStatement(entityID uint, req IncomeExpenseReportRequest) (*IncomeExpenseStatement, error)
+	ExportIncomeExpenseStatement(ctx context.Context, entityID uint, req IncomeExpenseReportRequest, userID *uint, ip string) ([]byte, string, string, error)
+
+	GetFinancialYearSummary(entityID uint, req FinancialYearReportRequest) (*FinancialYearSummary, error)
+	ExportFinancialYearSummary(ctx context.Context, entityID uint, req FinancialYearReportRequest, userID *uint, ip string) ([]byte, string, string, error)
+
+	GetDonorAnalytics(entityID uint, req DonorAnalyticsReportRequest) (*DonorAnalytics, error)
+	ExportDonorAnalytics(ctx context.Context, entityID uint, req DonorAnalyticsReportRequest, userID *uint, ip string) ([]byte, string, string, error)
+
+	GetInventoryValuation(entityID uint, req InventoryValuationReportRequest) ([]InventoryValuationReportRow, error)
+	ExportInventoryValuation(ctx context.Context, entityID uint, req InventoryValuationReportRequest, userID *uint, ip string) ([]byte, string, string, error)
+	GetFamilies(entityID uint, req FamilyReportRequest) ([]FamilyReportRow, error)
+	ExportFamilies(ctx context.Context, entityID uint, req FamilyReportRequest, userID *uint, ip string) ([]byte, string, string, error)
+
+	ListExportTemplates(tenantID uint) ([]ExportTemplate, error)
+	SaveExportTemplate(ctx context.Context, tenantID uint, reportType string, req SaveExportTemplateRequest, userID *uint, ip string) (*ExportTemplate, error)
+	DeleteExportTemplate(ctx context.Context, tenantID uint, reportType string, userID *uint, ip string) error
+
+	GetLedgerMapping(tenantID uint) (*LedgerMapping, error)
+	SaveLedgerMapping(ctx context.Context, tenantID uint, req SaveLedgerMappingRequest, userID *uint, ip string) (*LedgerMapping, error)
+	DeleteLedgerMapping(ctx context.Context, tenantID uint, userID *uint, ip string) error
 }
 
 type reportService struct {
@@ -64,15 +93,42 @@ func convertUintSlice(strs []string) []uint {
 	return out
 }
 
+// activityRowCount is the number of rows of whichever activity report data holds
+func activityRowCount(data ReportData) int {
+	return len(data.Events) + len(data.Sevas) + len(data.Bookings) +
+		len(data.Donations) + len(data.Waitlist) + len(data.Disputes) + len(data.Campaigns) +
+		len(data.RecurringDonations) + len(data.HundiCollection) + len(data.Coupons) + len(data.Orders) +
+		len(data.Volunteers) + len(data.RSVPs)
+}
+
 // ===============================
 // Activities Reports
 // ===============================
 
 func (s *reportService) GetActivities(req ActivitiesReportRequest) (ReportData, error) {
 	if req.Type != ReportTypeEvents && req.Type != ReportTypeSevas &&
-		req.Type != ReportTypeBookings && req.Type != ReportTypeDonations {
+		req.Type != ReportTypeBookings && req.Type != ReportTypeDonations &&
+		req.Type != ReportTypeWaitlist && req.Type != ReportTypeDisputes &&
+		req.Type != ReportTypeCampaigns && req.Type != ReportTypeRecurringDonations &&
+		req.Type != ReportTypeHundiCollection && req.Type != ReportTypeCoupons &&
+		req.Type != ReportTypeOrders && req.Type != ReportTypeVolunteers && req.Type != ReportTypeRSVPs {
 		return ReportData{}, fmt.Errorf("invalid report type: %s", req.Type)
 	}
+	if req.Page == nil {
+		return s.loadActivities(req) // exports aren't cached
+	}
+
+	var data ReportData
+	err := cached("activities", convertUintSlice(req.EntityIDs), req, req.Page, &data, func() error {
+		var err error
+		data, err = s.loadActivities(req)
+		return err
+	})
+	data.Pagination = req.Page.Info()
+	return data, err
+}
+
+func (s *reportService) loadActivities(req ActivitiesReportRequest) (ReportData, error) {
 	start := req.StartDate
 	end := req.EndDate
 
@@ -80,17 +136,52 @@ func (s *reportService) GetActivities(req ActivitiesReportRequest) (ReportData,
 	var err error
 	switch req.Type {
 	case ReportTypeEvents:
-		data.Events, err = s.repo.GetEvents(convertUintSlice(req.EntityIDs), start, end)
+		data.Events, err = s.repo.GetEvents(convertUintSlice(req.EntityIDs), start, end, req.Page)
 	case ReportTypeSevas:
-		data.Sevas, err = s.repo.GetSevas(convertUintSlice(req.EntityIDs), start, end)
+		data.Sevas, err = s.repo.GetSevas(convertUintSlice(req.EntityIDs), start, end, req.Page)
 	case ReportTypeBookings:
-		data.Bookings, err = s.repo.GetSevaBookings(convertUintSlice(req.EntityIDs), start, end)
+		data.Bookings, err = s.repo.GetSevaBookings(convertUintSlice(req.EntityIDs), start, end, req.Tithis, req.Page)
 	case ReportTypeDonations:
-		data.Donations, err = s.repo.GetDonations(convertUintSlice(req.EntityIDs), start, end)
+		data.Donations, err = s.repo.GetDonations(convertUintSlice(req.EntityIDs), start, end, req.Page)
+		if err == nil && req.GroupBy != "" {
+			data.DonationGroups, err = s.groupDonations(req, data.Donations)
+		}
+	case ReportTypeWaitlist:
+		data.Waitlist, err = s.repo.GetWaitlist(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeDisputes:
+		data.Disputes, err = s.repo.GetDisputes(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeCampaigns:
+		data.Campaigns, err = s.repo.GetCampaigns(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeRecurringDonations:
+		data.RecurringDonations, err = s.repo.GetRecurringDonations(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeHundiCollection:
+		data.HundiCollection, err = s.repo.GetHundiCollections(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeCoupons:
+		data.Coupons, err = s.repo.GetCoupons(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeOrders:
+		data.Orders, err = s.repo.GetOrders(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeVolunteers:
+		data.Volunteers, err = s.repo.GetVolunteers(convertUintSlice(req.EntityIDs), start, end, req.Page)
+	case ReportTypeRSVPs:
+		data.RSVPs, err = s.repo.GetRSVPs(convertUintSlice(req.EntityIDs), start, end, req.Page)
 	}
 	return data, err
 }
 
+// groupDonations totals the donations report per group. A preview shows one
+// page of rows, so its totals come from the database; exports group the rows
+// they hold.
+func (s *reportService) groupDonations(req ActivitiesReportRequest, rows []DonationReportRow) (*DonationGroupSummary, error) {
+	if req.Page == nil {
+		return groupDonations(req.GroupBy, rows), nil
+	}
+	groups, err := s.repo.SummarizeDonations(convertUintSlice(req.EntityIDs), req.StartDate, req.EndDate, req.GroupBy)
+	if err != nil {
+		return nil, err
+	}
+	return summarizeDonationGroups(req.GroupBy, groups), nil
+}
+
 func (s *reportService) ExportActivities(ctx context.Context, req ActivitiesReportRequest, userID *uint, ip string) ([]byte, string, string, error) {
 	data, err := s.GetActivities(req)
 	if err != nil {
@@ -103,7 +194,7 @@ func (s *reportService) ExportActivities(ctx context.Context, req ActivitiesRepo
 		return nil, "", "", err
 	}
 
-	bytes, filename, mimeType, err := s.exporter.Export(req.Type, req.Format, data)
+	bytes, filename, mimeType, err := s.export(ctx, req.EntityIDs, req.Type, req.Format, data)
 	if err != nil {
 		details := map[string]interface{}{
 			"report_type": req.Type,
@@ -115,11 +206,12 @@ func (s *reportService) ExportActivities(ctx context.Context, req ActivitiesRepo
 	}
 
 	details := map[string]interface{}{
-		"report_type": req.Type,
-		"format":      req.Format,
-		"filename":    filename,
-		"entity_ids":  req.EntityIDs,
-		"date_range":  req.DateRange,
+		"report_type":  req.Type,
+		"format":       req.Format,
+		"filename":     filename,
+		"entity_ids":   req.EntityIDs,
+		"date_range":   req.DateRange,
+		"record_count": activityRowCount(data),
 	}
 	s.auditSvc.LogAction(ctx, userID, nil, "TEMPLE_ACTIVITIES_REPORT_DOWNLOADED", details, ip, "success")
 
@@ -131,7 +223,17 @@ func (s *reportService) ExportActivities(ctx context.Context, req ActivitiesRepo
 // ===============================
 
 func (s *reportService) GetTempleRegisteredReport(req TempleRegisteredReportRequest, entityIDs []string) ([]TempleRegisteredReportRow, error) {
-	return s.repo.GetTemplesRegistered(convertUintSlice(entityIDs), req.StartDate, req.EndDate, req.Status)
+	ids := convertUintSlice(entityIDs)
+	if req.Page == nil {
+		return s.repo.GetTemplesRegistered(ids, req.StartDate, req.EndDate, req.Status, nil)
+	}
+	var rows []TempleRegisteredReportRow
+	err := cached("temple_registered", ids, req, req.Page, &rows, func() error {
+		var err error
+		rows, err = s.repo.GetTemplesRegistered(ids, req.StartDate, req.EndDate, req.Status, req.Page)
+		return err
+	})
+	return rows, err
 }
 
 func (s *reportService) ExportTempleRegisteredReport(ctx context.Context, req TempleRegisteredReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
@@ -147,7 +249,7 @@ func (s *reportService) ExportTempleRegisteredReport(ctx context.Context, req Te
 	}
 
 	data := ReportData{TemplesRegistered: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.render(ctx, reportType, req.Format, data)
 	if err != nil {
 		details := map[string]interface{}{
 			"report_type": "temple_registered",
@@ -193,7 +295,7 @@ func (s *reportService) GetDevoteeBirthdaysReport(req DevoteeBirthdaysReportRequ
 		req.EndDate.Format("2006-01-02"))
 	
 	// ✅ Call repository with proper uint slice
-	return s.repo.GetDevoteeBirthdays(entityUintIDs, req.StartDate, req.EndDate)
+	return s.repo.GetDevoteeBirthdays(entityUintIDs, req.StartDate, req.EndDate, req.Page)
 }
 
 func (s *reportService) ExportDevoteeBirthdaysReport(
@@ -236,7 +338,7 @@ func (s *reportService) ExportDevoteeBirthdaysReport(
 
 	// Prepare data for export
 	data := ReportData{DevoteeBirthdays: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.export(ctx, entityIDs, reportType, req.Format, data)
 	if err != nil {
 		fmt.Printf("❌ Export failed: %v\n", err)
 		details := map[string]interface{}{
@@ -270,7 +372,7 @@ func (s *reportService) ExportDevoteeBirthdaysReport(
 // ===============================
 
 func (s *reportService) GetDevoteeListReport(req DevoteeListReportRequest, entityIDs []string) ([]DevoteeListReportRow, error) {
-	return s.repo.GetDevoteeList(convertUintSlice(entityIDs), req.StartDate, req.EndDate, req.Status)
+	return s.repo.GetDevoteeList(convertUintSlice(entityIDs), req.StartDate, req.EndDate, req.Status, req.Page)
 }
 
 func (s *reportService) ExportDevoteeListReport(ctx context.Context, req DevoteeListReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
@@ -287,7 +389,7 @@ func (s *reportService) ExportDevoteeListReport(ctx context.Context, req Devotee
 	}
 
 	data := ReportData{DevoteeList: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.export(ctx, entityIDs, reportType, req.Format, data)
 	if err != nil {
 		details := map[string]interface{}{
 			"report_type": "devotee_list",
@@ -318,7 +420,7 @@ func (s *reportService) ExportDevoteeListReport(ctx context.Context, req Devotee
 // ===============================
 
 func (s *reportService) GetDevoteeProfileReport(req DevoteeProfileReportRequest, entityIDs []string) ([]DevoteeProfileReportRow, error) {
-	return s.repo.GetDevoteeProfiles(convertUintSlice(entityIDs), req.StartDate, req.EndDate, req.Status)
+	return s.repo.GetDevoteeProfiles(convertUintSlice(entityIDs), req.StartDate, req.EndDate, req.Status, req.Page)
 }
 
 func (s *reportService) ExportDevoteeProfileReport(ctx context.Context, req DevoteeProfileReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
@@ -335,7 +437,7 @@ func (s *reportService) ExportDevoteeProfileReport(ctx context.Context, req Devo
 	}
 
 	data := ReportData{DevoteeProfiles: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.render(ctx, reportType, req.Format, data)
 	if err != nil {
 		details := map[string]interface{}{
 			"report_type": "devotee_profile",
@@ -373,7 +475,7 @@ func (s *reportService) GetAuditLogsReport(req AuditLogReportRequest, entityIDs
 		actionFilters = append(actionFilters, req.Action)
 	}
 
-	return s.repo.GetAuditLogs(ids, req.StartDate, req.EndDate, actionFilters, req.Status)
+	return s.repo.GetAuditLogs(ids, req.StartDate, req.EndDate, actionFilters, req.Status, req.Page)
 }
 
 func (s *reportService) ExportAuditLogsReport(ctx context.Context, req AuditLogReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
@@ -391,7 +493,7 @@ func (s *reportService) ExportAuditLogsReport(ctx context.Context, req AuditLogR
 	}
 
 	data := ReportData{AuditLogs: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.render(ctx, reportType, req.Format, data)
 	if err != nil {
 		details := map[string]interface{}{
 			"report_type": "audit_logs",
@@ -427,7 +529,7 @@ func (s *reportService) GetApprovalStatusReport(req ApprovalStatusReportRequest,
 	fmt.Printf("   Status: '%s'\n", req.Status)
 	fmt.Printf("   Entity IDs: %v\n", ids)
 	
-	return s.repo.GetApprovalStatus(ids, req.StartDate, req.EndDate, req.Role, req.Status)
+	return s.repo.GetApprovalStatus(ids, req.StartDate, req.EndDate, req.Role, req.Status, req.Page)
 }
 
 func (s *reportService) ExportApprovalStatusReport(ctx context.Context, req ApprovalStatusReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
@@ -444,7 +546,7 @@ func (s *reportService) ExportApprovalStatusReport(ctx context.Context, req Appr
 	}
 
 	data := ReportData{ApprovalStatus: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.render(ctx, reportType, req.Format, data)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, userID, nil, "APPROVAL_STATUS_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
 			"report_type": "approval_status",
@@ -482,7 +584,7 @@ func (s *reportService) GetUserDetailsReport(req UserDetailReportRequest, entity
 		ids = nil
 	}
 
-	return s.repo.GetUserDetails(ids, req.StartDate, req.EndDate, req.Role, req.Status)
+	return s.repo.GetUserDetails(ids, req.StartDate, req.EndDate, req.Role, req.Status, req.Page)
 }
 
 func (s *reportService) ExportUserDetailsReport(ctx context.Context, req UserDetailReportRequest, entityIDs []string, reportType string, userID *uint, ip string) ([]byte, string, string, error) {
@@ -499,7 +601,7 @@ func (s *reportService) ExportUserDetailsReport(ctx context.Context, req UserDet
 	}
 
 	data := ReportData{UserDetails: rows}
-	bytes, filename, mimeType, err := s.exporter.Export(reportType, req.Format, data)
+	bytes, filename, mimeType, err := s.render(ctx, reportType, req.Format, data)
 	if err != nil {
 		s.auditSvc.LogAction(ctx, userID, nil, "USER_DETAILS_REPORT_DOWNLOAD_FAILED", map[string]interface{}{
 			"report_type": "user_details",
@@ -0,0 +1,260 @@
+package reports
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/xuri/excelize/v2"
+)
+
+// streamBatchSize is the number of rows fetched per query when streaming
+const streamBatchSize = 5000
+
+var ErrUnsupportedStreamFormat = errors.New("streaming is only supported for csv and excel formats")
+
+// rowWriter writes an export file row by row. Flush pushes buffered rows to
+// the underlying writer where the format allows; Close completes the file.
+type rowWriter interface {
+	Write(record []string) error
+	Flush() error
+	Close() error
+}
+
+// newRowWriter starts a CSV or Excel file with the header row
+func newRowWriter(w io.Writer, format, sheet string, headers []string) (rowWriter, error) {
+	var rw rowWriter
+	switch format {
+	case FormatCSV:
+		rw = &csvRowWriter{w: csv.NewWriter(w)}
+	case FormatExcel:
+		f := excelize.NewFile()
+		f.SetSheetName("Sheet1", sheet)
+		sw, err := f.NewStreamWriter(sheet)
+		if err != nil {
+			return nil, err
+		}
+		rw = &excelRowWriter{out: w, file: f, sheet: sw}
+	default:
+		return nil, ErrUnsupportedStreamFormat
+	}
+	if err := rw.Write(headers); err != nil {
+		return nil, err
+	}
+	return rw, nil
+}
+
+type csvRowWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvRowWriter) Write(record []string) error {
+	return c.w.Write(record)
+}
+
+func (c *csvRowWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvRowWriter) Close() error {
+	return c.Flush()
+}
+
+// excelRowWriter uses excelize's stream writer, which spills rows to a temp
+// file past a few MB. The xlsx zip can only be written once all rows are in.
+type excelRowWriter struct {
+	out   io.Writer
+	file  *excelize.File
+	sheet *excelize.StreamWriter
+	row   int
+}
+
+func (e *excelRowWriter) Write(record []string) error {
+	e.row++
+	values := make([]interface{}, len(record))
+	for i, v := range record {
+		values[i] = v
+	}
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.sheet.SetRow(cell, values)
+}
+
+func (e *excelRowWriter) Flush() error {
+	return nil
+}
+
+func (e *excelRowWriter) Close() error {
+	defer e.file.Close() // removes the temp files
+	if err := e.sheet.Flush(); err != nil {
+		return err
+	}
+	return e.file.Write(e.out)
+}
+
+func streamFileName(name, format string) (string, string) {
+	timestamp := time.Now().Format("20060102_150405")
+	if format == FormatExcel {
+		return fmt.Sprintf("%s_report_%s.xlsx", name, timestamp), "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return fmt.Sprintf("%s_report_%s.csv", name, timestamp), "text/csv"
+}
+
+// =========================== SERVICE ===========================
+
+// StreamAuditLogs writes the audit log report as CSV or Excel to w in ID
+// order, batch by batch, so exports of any size run in constant memory.
+// progress is called after every batch with the rows written so far and the
+// total.
+func (s *reportService) StreamAuditLogs(ctx context.Context, w io.Writer, format string, req AuditLogReportRequest, entityIDs []string, progress func(done, total int64)) (int64, error) {
+	ids := convertUintSlice(entityIDs)
+
+	var actionFilters []string
+	if req.Action != "" {
+		actionFilters = append(actionFilters, req.Action)
+	}
+
+	total, err := s.repo.CountAuditLogs(ids, req.StartDate, req.EndDate, actionFilters, req.Status)
+	if err != nil {
+		return 0, err
+	}
+	if progress != nil {
+		progress(0, total)
+	}
+
+	rw, err := newRowWriter(w, format, "Audit Logs", auditLogCSVHeaders)
+	if err != nil {
+		return 0, err
+	}
+
+	var done int64
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		rows, err := s.repo.GetAuditLogsAfter(ids, req.StartDate, req.EndDate, actionFilters, req.Status, afterID, streamBatchSize)
+		if err != nil {
+			return done, err
+		}
+		maskForViewer(ctx, rows)
+		for _, row := range rows {
+			if err := rw.Write(auditLogCSVRecord(row)); err != nil {
+				return done, err
+			}
+		}
+		if err := rw.Flush(); err != nil {
+			return done, err
+		}
+
+		done += int64(len(rows))
+		if total < done {
+			total = done // rows logged while the export runs
+		}
+		if progress != nil {
+			progress(done, total)
+		}
+		if len(rows) < streamBatchSize {
+			return done, rw.Close()
+		}
+		afterID = rows[len(rows)-1].ID
+	}
+}
+
+// StreamDevoteeList writes the devotee list report as CSV or Excel to w in
+// membership order, batch by batch
+func (s *reportService) StreamDevoteeList(ctx context.Context, w io.Writer, format string, req DevoteeListReportRequest, entityIDs []string, progress func(done, total int64)) (int64, error) {
+	ids := convertUintSlice(entityIDs)
+
+	total, err := s.repo.CountDevoteeList(ids, req.StartDate, req.EndDate, req.Status)
+	if err != nil {
+		return 0, err
+	}
+	if progress != nil {
+		progress(0, total)
+	}
+
+	rw, err := newRowWriter(w, format, "Devotee List", devoteeListHeaders)
+	if err != nil {
+		return 0, err
+	}
+
+	var done int64
+	var afterID uint
+	for {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		rows, err := s.repo.GetDevoteeListAfter(ids, req.StartDate, req.EndDate, req.Status, afterID, streamBatchSize)
+		if err != nil {
+			return done, err
+		}
+		maskForViewer(ctx, rows)
+		for _, row := range rows {
+			if err := rw.Write(devoteeListRecord(row)); err != nil {
+				return done, err
+			}
+		}
+		if err := rw.Flush(); err != nil {
+			return done, err
+		}
+
+		done += int64(len(rows))
+		if total < done {
+			total = done
+		}
+		if progress != nil {
+			progress(done, total)
+		}
+		if len(rows) < streamBatchSize {
+			return done, rw.Close()
+		}
+		afterID = rows[len(rows)-1].MembershipID
+	}
+}
+
+// =========================== HANDLER ===========================
+
+// wantsStream reports whether a CSV or Excel export should be streamed
+// (?stream=true) instead of built in memory first
+func wantsStream(c *gin.Context, format string) bool {
+	return c.Query("stream") == "true" && (format == FormatCSV || format == FormatExcel)
+}
+
+// streamResponse writes an export straight to the response with chunked
+// transfer encoding, flushing after every batch. Once the first bytes are
+// out the status can't change, so a failure part way only ends the
+// download early; it is recorded under failedAction.
+func (h *Handler) streamResponse(c *gin.Context, name, format string, details map[string]interface{}, doneAction, failedAction string,
+	run func(w io.Writer, progress func(done, total int64)) (int64, error)) {
+	ctx := c.MustGet("access_context").(middleware.AccessContext)
+	ip := middleware.GetIPFromContext(c)
+	filename, mime := streamFileName(name, format)
+
+	c.Header("Content-Type", mime)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+
+	rows, err := run(c.Writer, func(done, total int64) { c.Writer.Flush() })
+
+	details["format"] = format
+	details["filename"] = filename
+	details["stream"] = true
+	details["record_count"] = rows
+	if err != nil {
+		details["error"] = err.Error()
+		h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, failedAction, details, ip, "failure")
+		return
+	}
+	h.auditSvc.LogAction(c.Request.Context(), &ctx.UserID, nil, doneAction, details, ip, "success")
+}
@@ -0,0 +1,613 @@
+package reports
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Accounting exports: successful donations and approved expenses as vouchers
+// accountants import into Tally, or as a ledger CSV for other accounting
+// software. Each tenant maps donation types, expense categories and payment
+// methods to the ledgers of their books.
+
+// Ledgers and voucher types used until a tenant saves a mapping, named as
+// in a new Tally company
+const (
+	defaultBankLedger         = "Bank Account"
+	defaultDonationLedger     = "Donations"
+	defaultExpenseLedger      = "Temple Expenses"
+	defaultReceiptVoucherType = "Receipt"
+	defaultPaymentVoucherType = "Payment"
+)
+
+var (
+	ErrAccountingFormatUnsupported = errors.New("tally and ledger formats are only available for the donations and income-expense reports")
+	ErrLedgerMappingNotFound       = errors.New("ledger mapping not found")
+)
+
+// isAccountingFormat reports whether format exports vouchers
+func isAccountingFormat(format string) bool {
+	return format == FormatTally || format == FormatLedger
+}
+
+// LedgerMapping is a tenant's names for the ledgers donations and expenses
+// are posted to. Donation types, expense categories and payment methods
+// without an entry go to the default ledgers.
+type LedgerMapping struct {
+	ID                   uint           `gorm:"primaryKey;autoIncrement" json:"id"`
+	TenantID             uint           `gorm:"not null;uniqueIndex" json:"tenant_id"`
+	CompanyName          string         `gorm:"size:255" json:"company_name"`                  // Tally company to import into; empty for the open one
+	BankLedger           string         `gorm:"size:255;not null" json:"bank_ledger"`          // receives donations and pays expenses
+	DonationLedger       string         `gorm:"size:255;not null" json:"donation_ledger"`      // income of unmapped donation types
+	ExpenseLedger        string         `gorm:"size:255;not null" json:"expense_ledger"`       // unmapped expense categories
+	DonationTypes        datatypes.JSON `gorm:"type:jsonb" json:"donation_types"`              // {"annadanam": "Annadanam Donations"}
+	ExpenseCategories    datatypes.JSON `gorm:"type:jsonb" json:"expense_categories"`          // {"salaries": "Salaries"}
+	PaymentMethods       datatypes.JSON `gorm:"type:jsonb" json:"payment_methods"`             // {"cash": "Cash"}, instead of the bank ledger
+	ExpensePaymentMethod string         `gorm:"size:50" json:"expense_payment_method"`         // how expenses are paid, e.g. cash; empty for the bank ledger
+	ReceiptVoucherType   string         `gorm:"size:100;not null" json:"receipt_voucher_type"` // vouchers of donations
+	PaymentVoucherType   string         `gorm:"size:100;not null" json:"payment_voucher_type"` // vouchers of expenses
+	UpdatedBy            uint           `json:"updated_by"`
+	CreatedAt            time.Time      `json:"created_at"`
+	UpdatedAt            time.Time      `json:"updated_at"`
+}
+
+func (LedgerMapping) TableName() string {
+	return "report_ledger_mappings"
+}
+
+// SaveLedgerMappingRequest is the body of PUT /reports/ledger-mapping.
+// Empty ledgers and voucher types fall back to the defaults.
+type SaveLedgerMappingRequest struct {
+	CompanyName          string            `json:"company_name"`
+	BankLedger           string            `json:"bank_ledger"`
+	DonationLedger       string            `json:"donation_ledger"`
+	ExpenseLedger        string            `json:"expense_ledger"`
+	DonationTypes        map[string]string `json:"donation_types"`
+	ExpenseCategories    map[string]string `json:"expense_categories"`
+	PaymentMethods       map[string]string `json:"payment_methods"`
+	ExpensePaymentMethod string            `json:"expense_payment_method"`
+	ReceiptVoucherType   string            `json:"receipt_voucher_type"`
+	PaymentVoucherType   string            `json:"payment_voucher_type"`
+}
+
+// LedgerTransaction is a donation received or an expense paid
+type LedgerTransaction struct {
+	Kind        string    `json:"kind"` // donation or expense
+	ID          uint      `json:"id"`
+	Date        time.Time `json:"date"`
+	Category    string    `json:"category"` // donation type or expense category
+	Method      string    `json:"method"`   // payment method of donations
+	Amount      float64   `json:"amount"`
+	Party       string    `json:"party"` // donor or vendor
+	Reference   string    `json:"reference"`
+	Description string    `json:"description"`
+}
+
+// Kinds of ledger transactions
+const (
+	ledgerDonation = "donation"
+	ledgerExpense  = "expense"
+)
+
+// Voucher is one accounting entry; its debits equal its credits
+type Voucher struct {
+	Type      string         `json:"type"`
+	Number    string         `json:"number"`
+	Date      time.Time      `json:"date"`
+	Narration string         `json:"narration"`
+	Entries   []VoucherEntry `json:"entries"`
+}
+
+// VoucherEntry posts an amount to a ledger
+type VoucherEntry struct {
+	Ledger string  `json:"ledger"`
+	Debit  float64 `json:"debit"`
+	Credit float64 `json:"credit"`
+}
+
+// VoucherSet is what accounting exports render
+type VoucherSet struct {
+	Company  string    `json:"company"`
+	Vouchers []Voucher `json:"vouchers"`
+}
+
+// defaultLedgerMapping is used for tenants without a saved mapping
+func defaultLedgerMapping(tenantID uint) *LedgerMapping {
+	return &LedgerMapping{
+		TenantID:           tenantID,
+		BankLedger:         defaultBankLedger,
+		DonationLedger:     defaultDonationLedger,
+		ExpenseLedger:      defaultExpenseLedger,
+		DonationTypes:      datatypes.JSON("{}"),
+		ExpenseCategories:  datatypes.JSON("{}"),
+		PaymentMethods:     datatypes.JSON("{}"),
+		ReceiptVoucherType: defaultReceiptVoucherType,
+		PaymentVoucherType: defaultPaymentVoucherType,
+	}
+}
+
+// ledgerNames looks up the ledger of a donation type, expense category or
+// payment method, falling back to a default ledger
+type ledgerNames map[string]string
+
+func decodeLedgerNames(raw datatypes.JSON) ledgerNames {
+	names := ledgerNames{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &names)
+	}
+	return names
+}
+
+func (n ledgerNames) get(key, fallback string) string {
+	if name := n[strings.ToLower(strings.TrimSpace(key))]; name != "" {
+		return name
+	}
+	return fallback
+}
+
+// Vouchers posts successful donations as receipts (bank or payment method
+// ledger Dr, donation ledger Cr) and expenses as payments (expense ledger Dr,
+// bank or payment method ledger Cr), ordered by date
+func (m *LedgerMapping) Vouchers(txns []LedgerTransaction) *VoucherSet {
+	donationTypes := decodeLedgerNames(m.DonationTypes)
+	expenseCategories := decodeLedgerNames(m.ExpenseCategories)
+	methods := decodeLedgerNames(m.PaymentMethods)
+
+	set := &VoucherSet{Company: m.CompanyName, Vouchers: make([]Voucher, 0, len(txns))}
+	for _, t := range txns {
+		if t.Amount <= 0 {
+			continue
+		}
+		category := t.Category
+		if category == "" {
+			category = "general"
+		}
+		switch t.Kind {
+		case ledgerDonation:
+			narration := fmt.Sprintf("%s donation from %s", categoryLabel(category), t.Party)
+			if t.Reference != "" {
+				narration += ", order " + t.Reference
+			}
+			set.Vouchers = append(set.Vouchers, Voucher{
+				Type:      m.ReceiptVoucherType,
+				Number:    fmt.Sprintf("DON-%d", t.ID),
+				Date:      t.Date,
+				Narration: narration,
+				Entries: []VoucherEntry{
+					{Ledger: methods.get(t.Method, m.BankLedger), Debit: t.Amount},
+					{Ledger: donationTypes.get(category, m.DonationLedger), Credit: t.Amount},
+				},
+			})
+		case ledgerExpense:
+			narration := categoryLabel(category)
+			if t.Party != "" {
+				narration += ", " + t.Party
+			}
+			if t.Description != "" {
+				narration += ": " + t.Description
+			}
+			set.Vouchers = append(set.Vouchers, Voucher{
+				Type:      m.PaymentVoucherType,
+				Number:    fmt.Sprintf("EXP-%d", t.ID),
+				Date:      t.Date,
+				Narration: narration,
+				Entries: []VoucherEntry{
+					{Ledger: expenseCategories.get(category, m.ExpenseLedger), Debit: t.Amount},
+					{Ledger: methods.get(m.ExpensePaymentMethod, m.BankLedger), Credit: t.Amount},
+				},
+			})
+		}
+	}
+	sort.SliceStable(set.Vouchers, func(i, j int) bool {
+		return set.Vouchers[i].Date.Before(set.Vouchers[j].Date)
+	})
+	return set
+}
+
+// donationTransactions turns the successful donations of a report into
+// ledger transactions
+func donationTransactions(rows []DonationReportRow) []LedgerTransaction {
+	txns := make([]LedgerTransaction, 0, len(rows))
+	for _, d := range rows {
+		if !strings.EqualFold(d.Status, "SUCCESS") {
+			continue
+		}
+		txns = append(txns, LedgerTransaction{
+			Kind:      ledgerDonation,
+			ID:        d.ID,
+			Date:      d.DonationDate,
+			Category:  d.DonationType,
+			Method:    d.PaymentMethod,
+			Amount:    d.Amount,
+			Party:     d.DonorName,
+			Reference: d.OrderID,
+		})
+	}
+	return txns
+}
+
+// =========================== REPOSITORY ===========================
+
+func (r *repository) GetLedgerMapping(tenantID uint) (*LedgerMapping, error) {
+	var m LedgerMapping
+	if err := r.db.Where("tenant_id = ?", tenantID).First(&m).Error; err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// SaveLedgerMapping creates the tenant's mapping or replaces it
+func (r *repository) SaveLedgerMapping(m *LedgerMapping) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"company_name", "bank_ledger", "donation_ledger", "expense_ledger",
+			"donation_types", "expense_categories", "payment_methods", "expense_payment_method",
+			"receipt_voucher_type", "payment_voucher_type", "updated_by", "updated_at",
+		}),
+	}).Create(m).Error
+}
+
+func (r *repository) DeleteLedgerMapping(tenantID uint) error {
+	res := r.db.Where("tenant_id = ?", tenantID).Delete(&LedgerMapping{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetLedgerTransactions returns the temple's successful donations and
+// approved expenses over the period of an income and expense statement
+func (r *repository) GetLedgerTransactions(entityID uint, req IncomeExpenseReportRequest) ([]LedgerTransaction, error) {
+	var donations []LedgerTransaction
+	err := r.db.Table("donations d").
+		Select(`
+			'donation' as kind,
+			d.id,
+			COALESCE(d.donated_at, d.created_at) as date,
+			COALESCE(NULLIF(d.donation_type, ''), 'general') as category,
+			d.method,
+			d.amount,
+			COALESCE(NULLIF(u.full_name, ''), u.email, 'Anonymous') as party,
+			COALESCE(d.order_id, '') as reference
+		`).
+		Joins("LEFT JOIN users u ON d.user_id = u.id").
+		Where("d.entity_id = ? AND d.status = ? AND d.deleted_at IS NULL", entityID, "SUCCESS").
+		Where("COALESCE(d.donated_at, d.created_at) BETWEEN ? AND ?", req.StartDate, req.EndDate).
+		Order("date ASC, d.id ASC").
+		Scan(&donations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var expenses []LedgerTransaction
+	err = r.db.Table("temple_expenses").
+		Select("'expense' as kind, id, expense_date as date, category, amount, COALESCE(vendor, '') as party, COALESCE(description, '') as description").
+		Where("entity_id = ? AND status = ? AND deleted_at IS NULL", entityID, "approved").
+		Where("expense_date BETWEEN ?::date AND ?::date", req.StartDate.Format("2006-01-02"), req.EndDate.Format("2006-01-02")).
+		Order("expense_date ASC, id ASC").
+		Scan(&expenses).Error
+	if err != nil {
+		return nil, err
+	}
+	return append(donations, expenses...), nil
+}
+
+// =========================== SERVICE ===========================
+
+// GetLedgerMapping returns the tenant's mapping, or the defaults when none
+// is saved
+func (s *reportService) GetLedgerMapping(tenantID uint) (*LedgerMapping, error) {
+	m, err := s.repo.GetLedgerMapping(tenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return defaultLedgerMapping(tenantID), nil
+	}
+	return m, err
+}
+
+func (s *reportService) SaveLedgerMapping(ctx context.Context, tenantID uint, req SaveLedgerMappingRequest, userID *uint, ip string) (*LedgerMapping, error) {
+	ledger := func(field, value, fallback string, max int) (string, error) {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return fallback, nil
+		}
+		if len(value) > max {
+			return "", fmt.Errorf("%s must be at most %d characters", field, max)
+		}
+		return value, nil
+	}
+	names := func(field string, in map[string]string) (datatypes.JSON, error) {
+		out := make(map[string]string, len(in))
+		for key, name := range in {
+			key = strings.ToLower(strings.TrimSpace(key))
+			name = strings.TrimSpace(name)
+			if key == "" || name == "" {
+				return nil, fmt.Errorf("%s must map names to ledgers, neither empty", field)
+			}
+			if len(name) > 255 {
+				return nil, fmt.Errorf("ledger %q in %s must be at most 255 characters", name, field)
+			}
+			out[key] = name
+		}
+		raw, err := json.Marshal(out)
+		return datatypes.JSON(raw), err
+	}
+
+	m := &LedgerMapping{TenantID: tenantID, ExpensePaymentMethod: strings.ToLower(strings.TrimSpace(req.ExpensePaymentMethod))}
+	var err error
+	if m.CompanyName, err = ledger("company_name", req.CompanyName, "", 255); err != nil {
+		return nil, err
+	}
+	if m.BankLedger, err = ledger("bank_ledger", req.BankLedger, defaultBankLedger, 255); err != nil {
+		return nil, err
+	}
+	if m.DonationLedger, err = ledger("donation_ledger", req.DonationLedger, defaultDonationLedger, 255); err != nil {
+		return nil, err
+	}
+	if m.ExpenseLedger, err = ledger("expense_ledger", req.ExpenseLedger, defaultExpenseLedger, 255); err != nil {
+		return nil, err
+	}
+	if m.ReceiptVoucherType, err = ledger("receipt_voucher_type", req.ReceiptVoucherType, defaultReceiptVoucherType, 100); err != nil {
+		return nil, err
+	}
+	if m.PaymentVoucherType, err = ledger("payment_voucher_type", req.PaymentVoucherType, defaultPaymentVoucherType, 100); err != nil {
+		return nil, err
+	}
+	if len(m.ExpensePaymentMethod) > 50 {
+		return nil, errors.New("expense_payment_method must be at most 50 characters")
+	}
+	if m.DonationTypes, err = names("donation_types", req.DonationTypes); err != nil {
+		return nil, err
+	}
+	if m.ExpenseCategories, err = names("expense_categories", req.ExpenseCategories); err != nil {
+		return nil, err
+	}
+	if m.PaymentMethods, err = names("payment_methods", req.PaymentMethods); err != nil {
+		return nil, err
+	}
+	if userID != nil {
+		m.UpdatedBy = *userID
+	}
+	if err := s.repo.SaveLedgerMapping(m); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, userID, nil, "REPORT_LEDGER_MAPPING_SAVED", map[string]interface{}{
+		"tenant_id":       tenantID,
+		"bank_ledger":     m.BankLedger,
+		"donation_ledger": m.DonationLedger,
+		"expense_ledger":  m.ExpenseLedger,
+	}, ip, "success")
+
+	return s.repo.GetLedgerMapping(tenantID)
+}
+
+func (s *reportService) DeleteLedgerMapping(ctx context.Context, tenantID uint, userID *uint, ip string) error {
+	if err := s.repo.DeleteLedgerMapping(tenantID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrLedgerMappingNotFound
+		}
+		return err
+	}
+
+	s.auditSvc.LogAction(ctx, userID, nil, "REPORT_LEDGER_MAPPING_DELETED", map[string]interface{}{
+		"tenant_id": tenantID,
+	}, ip, "success")
+	return nil
+}
+
+// ledgerMappingFor returns the mapping of the tenant owning the entities.
+// Like export templates, mappings only apply when all entities belong to the
+// same tenant.
+func (s *reportService) ledgerMappingFor(entityIDs []uint) (*LedgerMapping, error) {
+	tenantIDs, err := s.repo.GetTenantIDsForEntities(entityIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(tenantIDs) != 1 {
+		return defaultLedgerMapping(0), nil
+	}
+	return s.GetLedgerMapping(tenantIDs[0])
+}
+
+// exportVouchers renders transactions as vouchers in the accounting format
+func (s *reportService) exportVouchers(reportType, format string, entityIDs []uint, txns []LedgerTransaction) ([]byte, string, string, error) {
+	mapping, err := s.ledgerMappingFor(entityIDs)
+	if err != nil {
+		return nil, "", "", err
+	}
+	return s.exporter.Export(reportType, format, ReportData{Vouchers: mapping.Vouchers(txns)})
+}
+
+// =========================== EXPORTER ===========================
+
+func (e *reportExporter) exportVouchersByFormat(reportType, format, timestamp string, set *VoucherSet) ([]byte, string, string, error) {
+	if set == nil {
+		return nil, "", "", ErrAccountingFormatUnsupported
+	}
+	name := strings.ReplaceAll(reportType, "-", "_")
+	switch format {
+	case FormatTally:
+		data, err := tallyXML(set)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return data, fmt.Sprintf("%s_tally_%s.xml", name, timestamp), "application/xml", nil
+
+	case FormatLedger:
+		data, err := ledgerCSV(set)
+		if err != nil {
+			return nil, "", "", err
+		}
+		return data, fmt.Sprintf("%s_ledger_%s.csv", name, timestamp), "text/csv", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported accounting format: %s", format)
+	}
+}
+
+type tallyEnvelope struct {
+	XMLName xml.Name `xml:"ENVELOPE"`
+	Request string   `xml:"HEADER>TALLYREQUEST"`
+	Body    struct {
+		ReportName string         `xml:"IMPORTDATA>REQUESTDESC>REPORTNAME"`
+		Company    string         `xml:"IMPORTDATA>REQUESTDESC>STATICVARIABLES>SVCURRENTCOMPANY,omitempty"`
+		Messages   []tallyMessage `xml:"IMPORTDATA>REQUESTDATA>TALLYMESSAGE"`
+	} `xml:"BODY"`
+}
+
+type tallyMessage struct {
+	Voucher tallyVoucher `xml:"VOUCHER"`
+}
+
+type tallyVoucher struct {
+	VoucherType   string             `xml:"VCHTYPE,attr"`
+	Action        string             `xml:"ACTION,attr"`
+	Date          string             `xml:"DATE"`
+	TypeName      string             `xml:"VOUCHERTYPENAME"`
+	VoucherNumber string             `xml:"VOUCHERNUMBER"`
+	Narration     string             `xml:"NARRATION,omitempty"`
+	Entries       []tallyLedgerEntry `xml:"ALLLEDGERENTRIES.LIST"`
+}
+
+// tallyLedgerEntry is one side of a voucher. Tally writes debits as negative
+// amounts deemed positive, credits as positive amounts.
+type tallyLedgerEntry struct {
+	Ledger         string `xml:"LEDGERNAME"`
+	DeemedPositive string `xml:"ISDEEMEDPOSITIVE"`
+	Amount         string `xml:"AMOUNT"`
+}
+
+// tallyXML renders vouchers as a Tally import file (Gateway of Tally >
+// Import > Transactions)
+func tallyXML(set *VoucherSet) ([]byte, error) {
+	var env tallyEnvelope
+	env.Request = "Import Data"
+	env.Body.ReportName = "Vouchers"
+	env.Body.Company = set.Company
+	env.Body.Messages = make([]tallyMessage, 0, len(set.Vouchers))
+	for _, v := range set.Vouchers {
+		tv := tallyVoucher{
+			VoucherType:   v.Type,
+			Action:        "Create",
+			Date:          v.Date.Format("20060102"),
+			TypeName:      v.Type,
+			VoucherNumber: v.Number,
+			Narration:     v.Narration,
+		}
+		for _, entry := range v.Entries {
+			if entry.Debit > 0 {
+				tv.Entries = append(tv.Entries, tallyLedgerEntry{Ledger: entry.Ledger, DeemedPositive: "Yes", Amount: fmt.Sprintf("-%.2f", entry.Debit)})
+			} else {
+				tv.Entries = append(tv.Entries, tallyLedgerEntry{Ledger: entry.Ledger, DeemedPositive: "No", Amount: fmt.Sprintf("%.2f", entry.Credit)})
+			}
+		}
+		env.Body.Messages = append(env.Body.Messages, tallyMessage{Voucher: tv})
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(env); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ledgerCSV renders vouchers as one row per ledger posting
+func ledgerCSV(set *VoucherSet) ([]byte, error) {
+	headers := []string{"Date", "Voucher Type", "Voucher No", "Ledger", "Debit", "Credit", "Narration"}
+	records := make([][]string, 0, 2*len(set.Vouchers))
+	amount := func(v float64) string {
+		if v == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%.2f", v)
+	}
+	for _, v := range set.Vouchers {
+		for _, entry := range v.Entries {
+			records = append(records, []string{
+				v.Date.Format("2006-01-02"), v.Type, v.Number, entry.Ledger, amount(entry.Debit), amount(entry.Credit), v.Narration,
+			})
+		}
+	}
+	return templatedCSV(headers, records)
+}
+
+// =========================== HANDLER ===========================
+
+// GetLedgerMapping - GET /reports/ledger-mapping
+// The ledgers tally and ledger exports post to; defaults until one is saved.
+func (h *Handler) GetLedgerMapping(c *gin.Context) {
+	ctx, ok := templeAdminTenant(c)
+	if !ok {
+		return
+	}
+
+	m, err := h.service.GetLedgerMapping(ctx.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch ledger mapping"})
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// SaveLedgerMapping - PUT /reports/ledger-mapping
+// {"bank_ledger": "SBI Current A/c", "donation_types": {"annadanam": "Annadanam Donations"}, "payment_methods": {"cash": "Cash"}}
+func (h *Handler) SaveLedgerMapping(c *gin.Context) {
+	ctx, ok := templeAdminTenant(c)
+	if !ok {
+		return
+	}
+
+	var req SaveLedgerMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+
+	m, err := h.service.SaveLedgerMapping(c.Request.Context(), ctx.UserID, req, &ctx.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, m)
+}
+
+// DeleteLedgerMapping - DELETE /reports/ledger-mapping
+// Accounting exports go back to the default ledgers.
+func (h *Handler) DeleteLedgerMapping(c *gin.Context) {
+	ctx, ok := templeAdminTenant(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.DeleteLedgerMapping(c.Request.Context(), ctx.UserID, &ctx.UserID, middleware.GetIPFromContext(c))
+	if errors.Is(err, ErrLedgerMappingNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete ledger mapping"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Ledger mapping deleted"})
+}
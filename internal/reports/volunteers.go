@@ -0,0 +1,174 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// GetVolunteers returns the temples' volunteers registered by the end of the
+// range, with their event sign-ups and attendance for slots within it
+func (r *repository) GetVolunteers(entityIDs []uint, start, end time.Time, page *PageRequest) ([]VolunteerReportRow, error) {
+	var out []VolunteerReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("volunteers v").
+		Select(`v.id, COALESCE(u.full_name, '') as volunteer_name, COALESCE(u.phone, '') as volunteer_phone,
+			COALESCE(u.email, '') as volunteer_email, COALESCE(e.name, '') as temple_name,
+			COALESCE((SELECT STRING_AGG(t, ', ') FROM jsonb_array_elements_text(v.skills) t), '') as skills,
+			v.status, v.created_at as registered_at,
+			COUNT(s.id) as signups,
+			COUNT(s.id) FILTER (WHERE s.attendance = 'present') as attended,
+			COUNT(s.id) FILTER (WHERE s.attendance = 'absent') as no_shows`).
+		Joins("LEFT JOIN users u ON u.id = v.user_id").
+		Joins("LEFT JOIN entities e ON e.id = v.entity_id").
+		Joins(`LEFT JOIN event_volunteer_signups s ON s.volunteer_id = v.id AND s.status = 'confirmed'
+			AND s.slot_id IN (SELECT id FROM event_volunteer_slots WHERE service_date BETWEEN ?::date AND ?::date)`,
+			start.Format("2006-01-02"), end.Format("2006-01-02")).
+		Where("v.entity_id IN ?", entityIDs).
+		Where("v.created_at <= ?", end).
+		Group("v.id, u.full_name, u.phone, u.email, e.name")
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"volunteer_name": "volunteer_name",
+		"temple_name":    "temple_name",
+		"status":         "v.status",
+		"signups":        "signups",
+		"attended":       "attended",
+		"registered_at":  "v.created_at",
+	}, "volunteer_name ASC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Volunteers by format
+func (e *reportExporter) exportVolunteersByFormat(format, timestamp string, rows []VolunteerReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportVolunteersExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("volunteers_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportVolunteersCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("volunteers_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportVolunteersPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("volunteers_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for volunteers: %s", format)
+	}
+}
+
+var volunteerHeaders = []string{"Volunteer ID", "Name", "Phone", "Email", "Temple Name", "Skills", "Status", "Sign-ups", "Attended", "No-shows", "Registered At"}
+
+func volunteerRecord(row VolunteerReportRow) []string {
+	return []string{
+		strconv.FormatUint(uint64(row.ID), 10),
+		row.VolunteerName,
+		row.VolunteerPhone,
+		row.VolunteerEmail,
+		row.TempleName,
+		row.Skills,
+		row.Status,
+		strconv.FormatInt(row.Signups, 10),
+		strconv.FormatInt(row.Attended, 10),
+		strconv.FormatInt(row.NoShows, 10),
+		row.RegisteredAt.Format("2006-01-02"),
+	}
+}
+
+func (e *reportExporter) exportVolunteersExcel(rows []VolunteerReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Volunteers"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range volunteerHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range volunteerRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportVolunteersCSV(rows []VolunteerReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(volunteerHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(volunteerRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportVolunteersPDF(rows []VolunteerReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Volunteers Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 9)
+	widths := []float64{20, 32, 24, 40, 32, 46, 16, 16, 16, 16, 22}
+	for i, header := range volunteerHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 7)
+	for _, row := range rows {
+		for i, value := range volunteerRecord(row) {
+			value = truncateText(value, 30)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,204 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/xuri/excelize/v2"
+)
+
+// waitlistQuery lists waitlisted seva bookings and event RSVPs with their
+// queue position. Positions are numbered before the date filter is applied
+// so they match what devotees are told.
+const waitlistQuery = `
+	SELECT 'seva' as kind, s.name as item_name, ent.name as temple_name,
+		u.full_name as devotee_name, u.phone as devotee_phone, q.status,
+		q.position, q.booking_time as waiting_since, NULL::timestamptz as offer_expires_at
+	FROM (
+		SELECT sb.*, ROW_NUMBER() OVER (
+			PARTITION BY sb.seva_id, CASE WHEN COALESCE(sv.date, '') = '' THEN DATE(sb.booking_time) END
+			ORDER BY sb.booking_time, sb.id
+		) as position
+		FROM seva_bookings sb
+		JOIN sevas sv ON sv.id = sb.seva_id
+		WHERE sb.status = 'waitlisted' AND sb.entity_id IN ?
+	) q
+	JOIN sevas s ON s.id = q.seva_id
+	LEFT JOIN entities ent ON ent.id = q.entity_id
+	LEFT JOIN users u ON u.id = q.user_id
+
+	UNION ALL
+
+	SELECT 'event' as kind, e.title as item_name, ent.name as temple_name,
+		u.full_name as devotee_name, u.phone as devotee_phone, q.status,
+		CASE WHEN q.status = 'waitlisted' THEN q.position ELSE 0 END as position,
+		q.waitlisted_at as waiting_since, q.offer_expires_at
+	FROM (
+		SELECT r.*, ROW_NUMBER() OVER (
+			PARTITION BY r.event_id, r.occurrence, r.status ORDER BY r.waitlisted_at, r.id
+		) as position
+		FROM rsvps r
+		JOIN events ev ON ev.id = r.event_id
+		WHERE r.status IN ('waitlisted', 'offered') AND ev.entity_id IN ?
+	) q
+	JOIN events e ON e.id = q.event_id
+	LEFT JOIN entities ent ON ent.id = e.entity_id
+	LEFT JOIN users u ON u.id = q.user_id`
+
+func (r *repository) GetWaitlist(entityIDs []uint, start, end time.Time, page *PageRequest) ([]WaitlistReportRow, error) {
+	var out []WaitlistReportRow
+	if len(entityIDs) == 0 {
+		return out, nil
+	}
+
+	query := r.db.Table("(?) as w", r.db.Raw(waitlistQuery, entityIDs, entityIDs)).
+		Select("w.*").
+		Where("w.waiting_since BETWEEN ? AND ?", start, end)
+
+	query, err := paginate(r.db, query, page, map[string]string{
+		"kind":          "w.kind",
+		"item_name":     "w.item_name",
+		"temple_name":   "w.temple_name",
+		"devotee_name":  "w.devotee_name",
+		"status":        "w.status",
+		"position":      "w.position",
+		"waiting_since": "w.waiting_since",
+	}, "w.item_name ASC, w.position ASC")
+	if err != nil {
+		return nil, err
+	}
+	err = query.Scan(&out).Error
+	return out, err
+}
+
+// Export Waitlist by format
+func (e *reportExporter) exportWaitlistByFormat(format, timestamp string, rows []WaitlistReportRow) ([]byte, string, string, error) {
+	switch format {
+	case FormatExcel:
+		data, err := e.exportWaitlistExcel(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("waitlist_report_%s.xlsx", timestamp)
+		return data, filename, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+
+	case FormatCSV:
+		data, err := e.exportWaitlistCSV(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("waitlist_report_%s.csv", timestamp)
+		return data, filename, "text/csv", nil
+
+	case FormatPDF:
+		data, err := e.exportWaitlistPDF(rows)
+		if err != nil {
+			return nil, "", "", err
+		}
+		filename := fmt.Sprintf("waitlist_report_%s.pdf", timestamp)
+		return data, filename, "application/pdf", nil
+
+	default:
+		return nil, "", "", fmt.Errorf("unsupported format for waitlist: %s", format)
+	}
+}
+
+var waitlistHeaders = []string{"Type", "Seva / Event", "Temple Name", "Devotee Name", "Devotee Phone", "Status", "Position", "Waiting Since", "Offer Expires At"}
+
+func waitlistRecord(row WaitlistReportRow) []string {
+	offerExpires := ""
+	if row.OfferExpiresAt != nil {
+		offerExpires = row.OfferExpiresAt.Format("2006-01-02 15:04:05")
+	}
+	position := ""
+	if row.Position > 0 {
+		position = strconv.FormatInt(row.Position, 10)
+	}
+	return []string{
+		row.Kind,
+		row.ItemName,
+		row.TempleName,
+		row.DevoteeName,
+		row.DevoteePhone,
+		row.Status,
+		position,
+		row.WaitingSince.Format("2006-01-02 15:04:05"),
+		offerExpires,
+	}
+}
+
+func (e *reportExporter) exportWaitlistExcel(rows []WaitlistReportRow) ([]byte, error) {
+	f := excelize.NewFile()
+	sheetName := "Waitlist"
+	f.SetSheetName("Sheet1", sheetName)
+
+	for i, header := range waitlistHeaders {
+		cell := fmt.Sprintf("%c1", 'A'+i)
+		f.SetCellValue(sheetName, cell, header)
+	}
+	for i, row := range rows {
+		for j, value := range waitlistRecord(row) {
+			f.SetCellValue(sheetName, fmt.Sprintf("%c%d", 'A'+j, i+2), value)
+		}
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportWaitlistCSV(rows []WaitlistReportRow) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+
+	if err := writer.Write(waitlistHeaders); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if err := writer.Write(waitlistRecord(row)); err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e *reportExporter) exportWaitlistPDF(rows []WaitlistReportRow) ([]byte, error) {
+	pdf := i18n.NewPDF("L")
+	pdf.AddPage()
+	pdf.SetFont("Arial", "B", 16)
+	pdf.Cell(0, 10, "Waitlist Report")
+	pdf.Ln(20)
+
+	pdf.SetFont("Arial", "B", 10)
+	widths := []float64{15, 45, 40, 40, 28, 22, 18, 35, 35}
+	for i, header := range waitlistHeaders {
+		pdf.CellFormat(widths[i], 7, header, "1", 0, "C", false, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Arial", "", 8)
+	for _, row := range rows {
+		for i, value := range waitlistRecord(row) {
+			value = truncateText(value, 28)
+			pdf.CellFormat(widths[i], 6, value, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,100 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+const (
+	defaultClamAVAddress = "localhost:3310"
+	clamChunkSize        = 64 << 10
+)
+
+type clamAV struct {
+	address string
+	timeout time.Duration
+}
+
+// NewClamAV returns a scanner that streams files to a clamd daemon at
+// address ("host:port", or a unix socket path)
+func NewClamAV(address string, timeout time.Duration) Scanner {
+	if strings.TrimSpace(address) == "" {
+		address = defaultClamAVAddress
+	}
+	if timeout <= 0 {
+		timeout = defaultScanTimeout
+	}
+	return &clamAV{address: address, timeout: timeout}
+}
+
+func (s *clamAV) Name() string { return BackendClamAV }
+
+// Scan sends r with clamd's INSTREAM command: length-prefixed chunks ended
+// by a zero length, answered by "stream: OK" or "stream: <threat> FOUND"
+func (s *clamAV) Scan(ctx context.Context, r io.Reader) error {
+	network := "tcp"
+	if strings.HasPrefix(s.address, "/") {
+		network = "unix"
+	}
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, network, s.address)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(s.timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	buf := make([]byte, clamChunkSize)
+	var size [4]byte
+	for {
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size[:], uint32(n))
+			w.Write(size[:])
+			if _, err := w.Write(buf[:n]); err != nil {
+				return fmt.Errorf("%w: %v", ErrUnavailable, err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return fmt.Errorf("failed to read file for scanning: %w", readErr)
+		}
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	w.Write(size[:])
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return &InfectedError{Threat: strings.TrimSuffix(reply, " FOUND")}
+	default:
+		// e.g. "INSTREAM size limit exceeded. ERROR"
+		return fmt.Errorf("%w: clamd replied %q", ErrUnavailable, reply)
+	}
+}
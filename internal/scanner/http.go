@@ -0,0 +1,65 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+type httpScanner struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// NewHTTPScanner returns a scanner for an external scanning API. The file is
+// POSTed as the raw request body and the API answers
+// {"clean": bool, "threat": "<name>"}.
+func NewHTTPScanner(url, apiKey string, timeout time.Duration) Scanner {
+	if timeout <= 0 {
+		timeout = defaultScanTimeout
+	}
+	return &httpScanner{url: url, apiKey: apiKey, client: &http.Client{Timeout: timeout}}
+}
+
+func (s *httpScanner) Name() string { return BackendHTTP }
+
+func (s *httpScanner) Scan(ctx context.Context, r io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: status %d: %s", ErrUnavailable, resp.StatusCode, bytes.TrimSpace(body))
+	}
+	var result struct {
+		Clean  *bool  `json:"clean"`
+		Threat string `json:"threat"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil || result.Clean == nil {
+		return fmt.Errorf("%w: unexpected response %q", ErrUnavailable, bytes.TrimSpace(body))
+	}
+	if !*result.Clean {
+		threat := result.Threat
+		if threat == "" {
+			threat = "unknown"
+		}
+		return &InfectedError{Threat: threat}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/storage"
+)
+
+// Supported scanner backends (SCANNER_BACKEND)
+const (
+	BackendNone   = "none"
+	BackendClamAV = "clamav"
+	BackendHTTP   = "http"
+)
+
+const defaultScanTimeout = 60 * time.Second
+
+var (
+	ErrInfected    = errors.New("malware detected")
+	ErrUnavailable = errors.New("malware scanner unavailable")
+)
+
+// InfectedError is returned for a file the scanner flagged
+type InfectedError struct {
+	Threat        string
+	FileName      string
+	QuarantineKey string // where the file was kept for review, if it was
+}
+
+func (e *InfectedError) Error() string {
+	if e.FileName != "" {
+		return fmt.Sprintf("%s: %s (%s)", e.FileName, ErrInfected, e.Threat)
+	}
+	return fmt.Sprintf("%s (%s)", ErrInfected, e.Threat)
+}
+
+func (e *InfectedError) Is(target error) bool { return target == ErrInfected }
+
+// Scanner checks file content for malware. Scan returns an *InfectedError
+// for an infected file and an error wrapping ErrUnavailable when the file
+// could not be scanned.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) error
+	Name() string
+}
+
+// New builds the scanner selected in config, or returns nil when scanning
+// is turned off
+func New(cfg *config.Config) (Scanner, error) {
+	timeout := time.Duration(cfg.ScannerTimeoutSeconds) * time.Second
+
+	var s Scanner
+	switch strings.ToLower(strings.TrimSpace(cfg.ScannerBackend)) {
+	case "", BackendNone:
+		return nil, nil
+	case BackendClamAV:
+		s = NewClamAV(cfg.ClamAVAddress, timeout)
+	case BackendHTTP:
+		if strings.TrimSpace(cfg.ScannerAPIURL) == "" {
+			return nil, errors.New("SCANNER_API_URL is required for the http scanner")
+		}
+		s = NewHTTPScanner(cfg.ScannerAPIURL, cfg.ScannerAPIKey, timeout)
+	default:
+		return nil, fmt.Errorf("unsupported scanner backend %q", cfg.ScannerBackend)
+	}
+	if cfg.ScannerFailOpen {
+		s = failOpen{s}
+	}
+	return s, nil
+}
+
+// failOpen lets files through when the scanner can't be reached, so an
+// outage doesn't block uploads. Infected files are still rejected.
+type failOpen struct {
+	Scanner
+}
+
+func (f failOpen) Scan(ctx context.Context, r io.Reader) error {
+	err := f.Scanner.Scan(ctx, r)
+	if errors.Is(err, ErrUnavailable) {
+		log.Printf("⚠️ %s scanner unavailable, accepting file unscanned: %v", f.Name(), err)
+		return nil
+	}
+	return err
+}
+
+// Quarantine keeps an infected file under quarantine/<date>/ for review.
+// The prefix belongs to no entity, so the file download routes can't serve it.
+func Quarantine(ctx context.Context, store storage.Storage, fileName string, r io.Reader, size int64) (string, error) {
+	name := uuid.New().String() + strings.ToLower(filepath.Ext(fileName))
+	key, err := storage.Key(storage.QuarantinePrefix, time.Now().Format("2006-01-02"), name)
+	if err != nil {
+		return "", err
+	}
+	if err := store.Put(ctx, key, r, size, "application/octet-stream"); err != nil {
+		return "", err
+	}
+	return key, nil
+}
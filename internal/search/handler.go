@@ -0,0 +1,65 @@
+package search
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the global search endpoint
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new search handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Search - GET /search?q=...&types=temple,devotee,event,seva&page=1&limit=20
+func (h *Handler) Search(c *gin.Context) {
+	accessVal, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	access, ok := accessVal.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "invalid access context"})
+		return
+	}
+
+	text := strings.TrimSpace(c.Query("q"))
+	if n := utf8.RuneCountInString(text); n < MinQueryLength || n > MaxQueryLength {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "q must be between " + strconv.Itoa(MinQueryLength) + " and " + strconv.Itoa(MaxQueryLength) + " characters",
+			"param": "q",
+		})
+		return
+	}
+
+	types, err := ParseTypes(c.Query("types"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "param": "types"})
+		return
+	}
+
+	q := Query{
+		Text:  text,
+		Types: types,
+		Page:  httpx.PositiveQuery(c, "page", 1),
+		Limit: min(httpx.PositiveQuery(c, "limit", defaultLimit), maxLimit),
+	}
+
+	resp, err := h.Service.Search(c.Request.Context(), q, access)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Search failed"})
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,61 @@
+package search
+
+// Result types, also accepted in the types query parameter
+const (
+	TypeTemple  = "temple"
+	TypeDevotee = "devotee"
+	TypeEvent   = "event"
+	TypeSeva    = "seva"
+)
+
+// AllTypes lists every searchable type in result order of ties
+var AllTypes = []string{TypeTemple, TypeDevotee, TypeEvent, TypeSeva}
+
+const (
+	// MinQueryLength is the shortest query searched, shorter ones match too much
+	MinQueryLength = 2
+	// MaxQueryLength caps the query text
+	MaxQueryLength = 100
+
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// Scope limits the temples a search may look into. All covers every temple;
+// otherwise only EntityIDs. Devotees are only searched when IncludeDevotees is
+// set, and PublicTemples additionally matches any approved, active temple.
+type Scope struct {
+	All             bool
+	EntityIDs       []uint
+	IncludeDevotees bool
+	PublicTemples   bool
+}
+
+// Query is a parsed search request
+type Query struct {
+	Text  string
+	Types []string
+	Page  int
+	Limit int
+}
+
+// Result is a single typed search hit
+type Result struct {
+	Type     string `json:"type"`
+	ID       uint   `json:"id"`
+	EntityID uint   `json:"entity_id"`
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Rank     int    `json:"-"`
+}
+
+// Response is the paginated search response
+type Response struct {
+	Query      string         `json:"query"`
+	Data       []Result       `json:"data"`
+	Counts     map[string]int `json:"counts"` // hits per type across all pages
+	Total      int            `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"total_pages"`
+}
@@ -0,0 +1,232 @@
+package search
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"gorm.io/gorm"
+)
+
+// Repository runs search queries over the temple tables
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new search repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// trigramIndexes back the ILIKE '%...%' matches below. They need the pg_trgm
+// extension; without it search still works, only slower.
+var trigramIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_entities_name_trgm ON entities USING gin (name gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_entities_city_trgm ON entities USING gin (city gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_users_full_name_trgm ON users USING gin (full_name gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_events_title_trgm ON events USING gin (title gin_trgm_ops)`,
+	`CREATE INDEX IF NOT EXISTS idx_sevas_name_trgm ON sevas USING gin (name gin_trgm_ops)`,
+}
+
+// EnsureIndexes creates the trigram indexes used by search. Failures are
+// logged, not fatal, since managed databases may not allow the extension.
+func EnsureIndexes(db *gorm.DB) {
+	// Phones are encrypted and matched by blind index, not by trigram
+	if err := db.Exec(`DROP INDEX IF EXISTS idx_users_phone_trgm`).Error; err != nil {
+		log.Printf("⚠️  failed to drop search index: %v", err)
+	}
+	if err := db.Exec(`CREATE EXTENSION IF NOT EXISTS pg_trgm`).Error; err != nil {
+		log.Printf("⚠️  pg_trgm unavailable, search runs without trigram indexes: %v", err)
+		return
+	}
+	for _, stmt := range trigramIndexes {
+		if err := db.Exec(stmt).Error; err != nil {
+			log.Printf("⚠️  failed to create search index: %v", err)
+		}
+	}
+}
+
+// ScopeEntityIDs returns the temples created by the given tenants
+func (r *Repository) ScopeEntityIDs(ctx context.Context, tenantIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(tenantIDs) == 0 {
+		return ids, nil
+	}
+	err := r.DB.WithContext(ctx).Table("entities").
+		Where("created_by IN ?", tenantIDs).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// OrganizationTenantIDs returns the tenants of the organization the user administers
+func (r *Repository) OrganizationTenantIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.WithContext(ctx).Table("organization_tenants ot").
+		Joins("JOIN organization_admins oa ON oa.organization_id = ot.organization_id").
+		Where("oa.user_id = ?", userID).
+		Pluck("ot.tenant_id", &ids).Error
+	return ids, err
+}
+
+// MemberEntityIDs returns the temples a devotee or volunteer has joined
+func (r *Repository) MemberEntityIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.DB.WithContext(ctx).Table("user_entity_memberships").
+		Where("user_id = ? AND status = ?", userID, "active").
+		Pluck("entity_id", &ids).Error
+	return ids, err
+}
+
+// typeQuery returns the SELECT of one result type, or "" when the scope
+// allows no rows of that type. Every SELECT yields
+// (type, id, entity_id, title, subtitle, rank), rank 0 for an exact title
+// match, 1 for a prefix, 2 for a title substring and 3 for other fields.
+func typeQuery(resultType string, scope Scope, exact, prefix, contains string) (string, []interface{}) {
+	rank := func(col string) string {
+		return "CASE WHEN LOWER(" + col + ") = ? THEN 0 WHEN " + col + " ILIKE ? THEN 1 WHEN " + col + " ILIKE ? THEN 2 ELSE 3 END"
+	}
+	rankArgs := []interface{}{exact, prefix, contains}
+
+	// entityFilter restricts rows to the scoped temples by their entity column
+	entityFilter := func(col string) (string, []interface{}, bool) {
+		if scope.All {
+			return "", nil, true
+		}
+		if len(scope.EntityIDs) == 0 {
+			return "", nil, false
+		}
+		return " AND " + col + " IN ?", []interface{}{scope.EntityIDs}, true
+	}
+
+	switch resultType {
+	case TypeTemple:
+		sql := `SELECT 'temple' AS type, e.id AS id, e.id AS entity_id, e.name AS title,
+			CONCAT_WS(', ', NULLIF(e.city, ''), NULLIF(e.state, '')) AS subtitle, ` + rank("e.name") + ` AS rank
+			FROM entities e
+			WHERE (e.name ILIKE ? OR e.city ILIKE ? OR e.district ILIKE ? OR e.state ILIKE ? OR e.pincode ILIKE ?)`
+		args := append(rankArgs, contains, contains, contains, contains, contains)
+		switch {
+		case scope.All:
+		case scope.PublicTemples && len(scope.EntityIDs) > 0:
+			sql += ` AND (e.id IN ? OR (LOWER(e.status) = 'approved' AND e.isactive = true))`
+			args = append(args, scope.EntityIDs)
+		case scope.PublicTemples:
+			sql += ` AND LOWER(e.status) = 'approved' AND e.isactive = true`
+		case len(scope.EntityIDs) > 0:
+			sql += ` AND e.id IN ?`
+			args = append(args, scope.EntityIDs)
+		default:
+			return "", nil
+		}
+		return sql, args
+
+	case TypeDevotee:
+		if !scope.IncludeDevotees {
+			return "", nil
+		}
+		filter, filterArgs, ok := entityFilter("m.entity_id")
+		if !ok {
+			return "", nil
+		}
+		sql := `SELECT 'devotee' AS type, u.id AS id, m.entity_id AS entity_id, u.full_name AS title,
+			u.phone AS subtitle, ` + rank("u.full_name") + ` AS rank
+			FROM users u
+			JOIN user_entity_memberships m ON m.user_id = u.id AND m.status = 'active'
+			JOIN user_roles ur ON ur.id = u.role_id AND ur.role_name = 'devotee'
+			WHERE u.deleted_at IS NULL AND (u.full_name ILIKE ? OR u.email ILIKE ?
+				OR (u.phone_index <> '' AND u.phone_index = ?))` + filter
+		args := append(rankArgs, contains, contains, fieldcrypto.PhoneIndex(exact))
+		return sql, append(args, filterArgs...)
+
+	case TypeEvent:
+		filter, filterArgs, ok := entityFilter("ev.entity_id")
+		if !ok {
+			return "", nil
+		}
+		sql := `SELECT 'event' AS type, ev.id AS id, ev.entity_id AS entity_id, ev.title AS title,
+			TO_CHAR(ev.event_date, 'YYYY-MM-DD') AS subtitle, ` + rank("ev.title") + ` AS rank
+			FROM events ev
+			WHERE ev.is_active = true AND (ev.title ILIKE ? OR ev.location ILIKE ?)` + filter
+		args := append(rankArgs, contains, contains)
+		return sql, append(args, filterArgs...)
+
+	case TypeSeva:
+		filter, filterArgs, ok := entityFilter("s.entity_id")
+		if !ok {
+			return "", nil
+		}
+		sql := `SELECT 'seva' AS type, s.id AS id, s.entity_id AS entity_id, s.name AS title,
+			s.seva_type AS subtitle, ` + rank("s.name") + ` AS rank
+			FROM sevas s
+			WHERE s.is_active = true AND s.name ILIKE ?` + filter
+		args := append(rankArgs, contains)
+		return sql, append(args, filterArgs...)
+	}
+	return "", nil
+}
+
+// Search returns one page of results over the requested types, best matches
+// first, and the number of hits per type
+func (r *Repository) Search(ctx context.Context, q Query, scope Scope) ([]Result, map[string]int, error) {
+	text := strings.ToLower(q.Text)
+	escaped := escapeLike(text)
+	exact, prefix, contains := text, escaped+"%", "%"+escaped+"%"
+
+	var parts []string
+	var args []interface{}
+	for _, t := range q.Types {
+		sql, typeArgs := typeQuery(t, scope, exact, prefix, contains)
+		if sql == "" {
+			continue
+		}
+		parts = append(parts, sql)
+		args = append(args, typeArgs...)
+	}
+
+	counts := make(map[string]int, len(q.Types))
+	for _, t := range q.Types {
+		counts[t] = 0
+	}
+	if len(parts) == 0 {
+		return []Result{}, counts, nil
+	}
+	union := strings.Join(parts, "\nUNION ALL\n")
+
+	var typeCounts []struct {
+		Type  string
+		Count int
+	}
+	if err := r.DB.WithContext(ctx).
+		Raw(`SELECT type, COUNT(*) AS count FROM (`+union+`) hits GROUP BY type`, args...).
+		Scan(&typeCounts).Error; err != nil {
+		return nil, nil, err
+	}
+	for _, tc := range typeCounts {
+		counts[tc.Type] = tc.Count
+	}
+
+	results := []Result{}
+	pageArgs := append(append([]interface{}{}, args...), q.Limit, (q.Page-1)*q.Limit)
+	err := r.DB.WithContext(ctx).
+		Raw(`SELECT * FROM (`+union+`) hits ORDER BY rank, LOWER(title), type, id LIMIT ? OFFSET ?`, pageArgs...).
+		Scan(&results).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	// A devotee's subtitle is their encrypted phone
+	for i := range results {
+		if results[i].Type != TypeDevotee {
+			continue
+		}
+		if results[i].Subtitle, err = fieldcrypto.Decrypt(results[i].Subtitle); err != nil {
+			return nil, nil, err
+		}
+	}
+	return results, counts, nil
+}
+
+// escapeLike escapes LIKE wildcards so the query text matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
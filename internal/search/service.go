@@ -0,0 +1,108 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// ErrUnknownType is returned for an unsupported value in types
+var ErrUnknownType = errors.New("unknown search type")
+
+// Service resolves the caller's search scope and runs searches
+type Service struct {
+	Repo *Repository
+}
+
+// NewService initializes the search service
+func NewService(repo *Repository) *Service {
+	return &Service{Repo: repo}
+}
+
+// ParseTypes validates a comma separated types parameter; empty means all types
+func ParseTypes(raw string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return AllTypes, nil
+	}
+	seen := map[string]bool{}
+	var types []string
+	for _, t := range strings.Split(raw, ",") {
+		t = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(t)), "s")
+		if t == "" || seen[t] {
+			continue
+		}
+		valid := false
+		for _, known := range AllTypes {
+			valid = valid || known == t
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w %q: use %s", ErrUnknownType, t, strings.Join(AllTypes, ", "))
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// Scope returns what the caller may search. Platform admins see everything,
+// temple staff the temples they work at including devotees, organization admins
+// the temples of their organization, and devotees and volunteers approved
+// temples plus the events and sevas of temples they joined.
+func (s *Service) Scope(ctx context.Context, access middleware.AccessContext) (Scope, error) {
+	switch access.RoleName {
+	case middleware.RoleSuperAdmin:
+		if access.AssignedEntityID == nil {
+			return Scope{All: true, IncludeDevotees: true}, nil
+		}
+		// Superadmin acting as a tenant
+		ids, err := s.Repo.ScopeEntityIDs(ctx, []uint{*access.AssignedEntityID})
+		return Scope{EntityIDs: ids, IncludeDevotees: true}, err
+
+	case middleware.RoleTempleAdmin, middleware.RoleStandardUser, middleware.RoleMonitoringUser:
+		// Only the temples the caller really works at, never a header value
+		return Scope{EntityIDs: access.EntityIDs, IncludeDevotees: true}, nil
+
+	case middleware.RoleOrgAdmin:
+		tenantIDs, err := s.Repo.OrganizationTenantIDs(ctx, access.UserID)
+		if err != nil {
+			return Scope{}, err
+		}
+		ids, err := s.Repo.ScopeEntityIDs(ctx, tenantIDs)
+		return Scope{EntityIDs: ids, IncludeDevotees: true}, err
+
+	case middleware.RoleDevotee, middleware.RoleVolunteer:
+		ids, err := s.Repo.MemberEntityIDs(ctx, access.UserID)
+		return Scope{EntityIDs: ids, PublicTemples: true}, err
+	}
+	return Scope{}, nil
+}
+
+// Search runs the query within the caller's scope
+func (s *Service) Search(ctx context.Context, q Query, access middleware.AccessContext) (*Response, error) {
+	scope, err := s.Scope(ctx, access)
+	if err != nil {
+		return nil, err
+	}
+
+	results, counts, err := s.Repo.Search(ctx, q, scope)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 0
+	for _, n := range counts {
+		total += n
+	}
+	return &Response{
+		Query:      q.Text,
+		Data:       results,
+		Counts:     counts,
+		Total:      total,
+		Page:       q.Page,
+		Limit:      q.Limit,
+		TotalPages: (total + q.Limit - 1) / q.Limit,
+	}, nil
+}
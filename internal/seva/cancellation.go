@@ -0,0 +1,494 @@
+package seva
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Refund states of a cancelled booking for a paid seva. Refunds are paid out
+// by the temple; the booking records that one was asked for and the outcome.
+const (
+	RefundRequested = "requested"
+	RefundProcessed = "processed"
+	RefundDeclined  = "declined"
+)
+
+var (
+	ErrBookingAccessDenied      = errors.New("this booking belongs to another temple")
+	ErrCancellationWindowClosed = errors.New("the cancellation window for this seva has closed")
+	ErrBookingNotReschedulable  = errors.New("only pending or approved bookings can be rescheduled")
+	ErrInvalidRescheduleTarget  = errors.New("choose another open seva of the same temple, or another date")
+	ErrInvalidRescheduleDate    = errors.New("date must be yyyy-mm-dd and the new service must not have started")
+	ErrNoRefundDue              = errors.New("refunds apply only to paid bookings that held a slot")
+	ErrInvalidRefundStatus      = errors.New("refund status must be processed or declined")
+	ErrRefundNotRequested       = errors.New("no refund is awaiting a decision for this booking")
+)
+
+// BookingActor is who changes a booking: the devotee who made it, or temple
+// staff. EntityID is the staff member's temple, nil for superadmins.
+type BookingActor struct {
+	UserID   uint
+	Staff    bool
+	EntityID *uint
+}
+
+// CancelBookingRequest is the optional body of a cancellation
+type CancelBookingRequest struct {
+	Reason        string `json:"reason"`
+	RequestRefund bool   `json:"request_refund"` // paid sevas only
+}
+
+// RescheduleBookingRequest moves a booking to another seva of the temple, or
+// to another service day of a seva without a fixed date
+type RescheduleBookingRequest struct {
+	SevaID uint   `json:"seva_id"` // the booking's own seva when 0
+	Date   string `json:"date"`    // yyyy-mm-dd, for sevas without a fixed date
+}
+
+// RefundStatusRequest records the temple's decision on a requested refund
+type RefundStatusRequest struct {
+	Status string `json:"status" binding:"required"` // processed or declined
+	Note   string `json:"note"`
+}
+
+// serviceStart is when the booked service begins: the seva's date, or the
+// booking's day for undated sevas, at the seva's start time
+func serviceStart(seva *Seva, bookingTime time.Time) time.Time {
+	day := serviceDay(seva, bookingTime)
+	if t, err := time.Parse("15:04", seva.StartTime); err == nil {
+		return day.Add(time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute)
+	}
+	return day
+}
+
+// withinCancellationWindow reports whether a devotee may still cancel or
+// reschedule a booking
+func withinCancellationWindow(seva *Seva, bookingTime, now time.Time) bool {
+	deadline := serviceStart(seva, bookingTime).Add(-time.Duration(seva.CancellationWindowHours) * time.Hour)
+	return now.Before(deadline)
+}
+
+// authorize checks the actor may change the booking
+func (a BookingActor) authorize(booking *SevaBooking) error {
+	if !a.Staff {
+		if booking.UserID != a.UserID {
+			return ErrNotBookingOwner
+		}
+		return nil
+	}
+	if a.EntityID != nil && *a.EntityID != booking.EntityID {
+		return ErrBookingAccessDenied
+	}
+	return nil
+}
+
+// ========== Repository ==========
+
+// RecordCancellation stores who cancelled a booking, why, and whether a
+// refund was asked for
+func (r *repository) RecordCancellation(ctx context.Context, bookingID, cancelledBy uint, reason, refundStatus string) error {
+	return r.db.WithContext(ctx).Model(&SevaBooking{}).Where("id = ?", bookingID).Updates(map[string]interface{}{
+		"cancelled_at":        time.Now(),
+		"cancelled_by":        cancelledBy,
+		"cancellation_reason": reason,
+		"refund_status":       refundStatus,
+	}).Error
+}
+
+// UpdateRefundStatus settles a requested refund; it fails with
+// ErrRefundNotRequested when none is awaiting a decision
+func (r *repository) UpdateRefundStatus(ctx context.Context, bookingID uint, status string) error {
+	res := r.db.WithContext(ctx).Model(&SevaBooking{}).
+		Where("id = ? AND refund_status = ?", bookingID, RefundRequested).
+		Update("refund_status", status)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrRefundNotRequested
+	}
+	return nil
+}
+
+// RescheduleBooking moves a booking to another seva or service day under the
+// locks of both sevas. The booking goes back to pending approval and needs a
+// free slot at its new time; there is no waitlisting on reschedule. It returns
+// the status the booking had and any waitlisted bookings promoted into the
+// slot it left.
+func (r *repository) RescheduleBooking(ctx context.Context, bookingID, targetSevaID uint, bookingTime time.Time, formData datatypes.JSON) (string, []SevaBooking, error) {
+	var (
+		booking   SevaBooking
+		oldStatus string
+		promoted  []SevaBooking
+	)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+
+		// Lock in id order so concurrent reschedules cannot deadlock
+		first, second := booking.SevaID, targetSevaID
+		if second < first {
+			first, second = second, first
+		}
+		locked := map[uint]*Seva{}
+		for _, id := range []uint{first, second} {
+			if locked[id] != nil {
+				continue
+			}
+			seva, err := lockSeva(tx, id)
+			if err != nil {
+				return err
+			}
+			locked[id] = seva
+		}
+		if err := tx.First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+		oldStatus = booking.Status
+		if !holdsSlot(oldStatus) {
+			return ErrBookingNotReschedulable
+		}
+		current, target := locked[booking.SevaID], locked[targetSevaID]
+		if current == nil || target == nil {
+			return ErrBookingNotReschedulable // moved by a concurrent reschedule
+		}
+
+		if target.AvailableSlots > 0 {
+			var held, waiting int64
+			if err := bookingsInWindow(tx, target, bookingTime).
+				Where("status IN ? AND id <> ?", []string{"pending", "approved"}, booking.ID).
+				Count(&held).Error; err != nil {
+				return err
+			}
+			if err := bookingsInWindow(tx, target, bookingTime).
+				Where("status = ?", "waitlisted").
+				Count(&waiting).Error; err != nil {
+				return err
+			}
+			if held >= int64(target.AvailableSlots) || waiting > 0 {
+				return ErrSevaFull
+			}
+		}
+
+		if oldStatus == "approved" {
+			if err := tx.Model(&Seva{}).Where("id = ?", current.ID).Updates(map[string]interface{}{
+				"booked_slots":    gorm.Expr("GREATEST(booked_slots - 1, 0)"),
+				"remaining_slots": gorm.Expr("remaining_slots + ?", 1),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&SevaBooking{}).Where("id = ?", booking.ID).Updates(map[string]interface{}{
+			"seva_id":          target.ID,
+			"booking_time":     bookingTime,
+			"status":           "pending",
+			"form_data":        formData,
+			"rescheduled_at":   time.Now(),
+			"reschedule_count": gorm.Expr("reschedule_count + ?", 1),
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		promoted, err = promoteWaitlist(tx, current, booking.BookingTime)
+		return err
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return oldStatus, promoted, nil
+}
+
+// ========== Service ==========
+
+// CancelBooking withdraws a booking. Devotees cancel their own bookings within
+// the seva's cancellation window; temple staff may cancel any booking of
+// their temple. A freed slot goes to the next booking on the waitlist.
+func (s *service) CancelBooking(ctx context.Context, bookingID uint, actor BookingActor, req CancelBookingRequest, ip string) error {
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return err
+	}
+	if err := actor.authorize(booking); err != nil {
+		s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_CANCEL_FAILED", map[string]interface{}{
+			"booking_id": bookingID,
+			"reason":     err.Error(),
+		}, ip, "failure")
+		return err
+	}
+	if !holdsSlot(booking.Status) && booking.Status != "waitlisted" {
+		return ErrBookingNotCancellable
+	}
+
+	seva, err := s.repo.GetSevaByID(ctx, booking.SevaID)
+	if err != nil {
+		return err
+	}
+	// A waitlisted booking holds no slot and may be withdrawn at any time
+	if !actor.Staff && booking.Status != "waitlisted" && !withinCancellationWindow(seva, booking.BookingTime, time.Now()) {
+		s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_CANCEL_FAILED", map[string]interface{}{
+			"booking_id":                bookingID,
+			"seva_id":                   booking.SevaID,
+			"reason":                    "cancellation window closed",
+			"cancellation_window_hours": seva.CancellationWindowHours,
+		}, ip, "failure")
+		return ErrCancellationWindowClosed
+	}
+	refundStatus := ""
+	if req.RequestRefund {
+		if booking.amountPaid(seva) <= 0 || !holdsSlot(booking.Status) {
+			return ErrNoRefundDue
+		}
+		refundStatus = RefundRequested
+	}
+
+	previous, promoted, err := s.repo.TransitionBooking(ctx, bookingID, "cancelled")
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_CANCEL_FAILED", map[string]interface{}{
+			"booking_id": bookingID,
+			"seva_id":    booking.SevaID,
+			"error":      err.Error(),
+		}, ip, "failure")
+		return fmt.Errorf("failed to cancel booking: %v", err)
+	}
+	if previous == "approved" {
+		s.counter.Release(ctx, seva, booking.BookingTime)
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if err := s.repo.RecordCancellation(ctx, bookingID, actor.UserID, reason, refundStatus); err != nil {
+		return fmt.Errorf("booking cancelled but its details were not saved: %v", err)
+	}
+	s.releaseCoupon(ctx, booking)
+
+	s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_CANCELLED", map[string]interface{}{
+		"booking_id":    bookingID,
+		"seva_id":       booking.SevaID,
+		"seva_name":     seva.Name,
+		"devotee_id":    booking.UserID,
+		"old_status":    previous,
+		"by_staff":      actor.Staff,
+		"reason":        reason,
+		"refund_status": refundStatus,
+		"promoted":      len(promoted),
+	}, ip, "success")
+	if refundStatus != "" {
+		s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_REFUND_REQUESTED", map[string]interface{}{
+			"booking_id": bookingID,
+			"seva_id":    booking.SevaID,
+			"seva_name":  seva.Name,
+			"amount":     booking.amountPaid(seva),
+		}, ip, "success")
+	}
+
+	s.notifyPromoted(ctx, actor.UserID, seva, promoted, ip)
+
+	if s.notifSvc != nil {
+		message := "Your booking for " + seva.Name + " has been cancelled"
+		if actor.Staff {
+			message = "The temple cancelled your booking for " + seva.Name
+			if reason != "" {
+				message += ": " + reason
+			}
+		}
+		if refundStatus != "" {
+			message += ". Your refund request has been sent to the temple"
+		}
+		_ = s.notifSvc.CreateInAppNotification(ctx, booking.UserID, booking.EntityID, "Seva Booking Cancelled", message, "seva")
+
+		if !actor.Staff {
+			staffMessage := "A devotee cancelled their booking for " + seva.Name
+			if refundStatus != "" {
+				staffMessage += fmt.Sprintf(" and asked for a refund of %.2f", booking.amountPaid(seva))
+			}
+			_ = s.notifSvc.CreateInAppForEntityRoles(
+				ctx,
+				booking.EntityID,
+				[]string{"templeadmin", "standarduser"},
+				"Seva Booking Cancelled",
+				staffMessage,
+				"seva",
+			)
+		}
+	}
+
+	return nil
+}
+
+// RescheduleBooking moves a pending or approved booking to another seva of
+// the same temple or another day. Devotees reschedule within the current
+// seva's cancellation window. The booking needs approval again.
+func (s *service) RescheduleBooking(ctx context.Context, bookingID uint, actor BookingActor, req RescheduleBookingRequest, ip string) (*SevaBooking, error) {
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if err := actor.authorize(booking); err != nil {
+		return nil, err
+	}
+	if !holdsSlot(booking.Status) {
+		return nil, ErrBookingNotReschedulable
+	}
+	current, err := s.repo.GetSevaByID(ctx, booking.SevaID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !actor.Staff && !withinCancellationWindow(current, booking.BookingTime, now) {
+		return nil, ErrCancellationWindowClosed
+	}
+
+	target := current
+	if req.SevaID != 0 && req.SevaID != current.ID {
+		if target, err = s.repo.GetSevaByID(ctx, req.SevaID); err != nil {
+			return nil, err
+		}
+	}
+	if target.EntityID != booking.EntityID || !target.IsActive || (target.Status != "upcoming" && target.Status != "ongoing") {
+		return nil, ErrInvalidRescheduleTarget
+	}
+
+	// Dated sevas keep the original booking time; undated ones take the
+	// new day at the time of day the booking was made
+	newTime := booking.BookingTime
+	if target.Date == "" {
+		if req.Date == "" {
+			if target.ID == current.ID {
+				return nil, ErrInvalidRescheduleDate
+			}
+		} else {
+			day, err := time.ParseInLocation("2006-01-02", req.Date, time.Local)
+			if err != nil {
+				return nil, ErrInvalidRescheduleDate
+			}
+			h, m, sec := booking.BookingTime.In(time.Local).Clock()
+			newTime = day.Add(time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second)
+		}
+	}
+	if target.ID == current.ID && serviceDay(target, newTime).Equal(serviceDay(current, booking.BookingTime)) {
+		return nil, ErrInvalidRescheduleTarget
+	}
+	if !serviceStart(target, newTime).After(now) {
+		return nil, ErrInvalidRescheduleDate
+	}
+
+	// The answers given at booking must suit the new seva's form
+	formData := booking.FormData
+	if target.ID != current.ID {
+		fields, err := target.BookingFormFields()
+		if err != nil {
+			return nil, err
+		}
+		answers := map[string]interface{}{}
+		if len(booking.FormData) > 0 {
+			if err := json.Unmarshal(booking.FormData, &answers); err != nil {
+				return nil, err
+			}
+		}
+		if formData, err = validateBookingForm(fields, answers); err != nil {
+			return nil, err
+		}
+	}
+
+	previous, promoted, err := s.repo.RescheduleBooking(ctx, bookingID, target.ID, newTime, formData)
+	if err != nil {
+		s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_RESCHEDULE_FAILED", map[string]interface{}{
+			"booking_id":   bookingID,
+			"from_seva_id": current.ID,
+			"to_seva_id":   target.ID,
+			"error":        err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+	if previous == "approved" {
+		s.counter.Release(ctx, current, booking.BookingTime)
+	}
+
+	s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_RESCHEDULED", map[string]interface{}{
+		"booking_id":     bookingID,
+		"devotee_id":     booking.UserID,
+		"from_seva_id":   current.ID,
+		"from_seva_name": current.Name,
+		"from_service":   serviceStart(current, booking.BookingTime),
+		"to_seva_id":     target.ID,
+		"to_seva_name":   target.Name,
+		"to_service":     serviceStart(target, newTime),
+		"old_status":     previous,
+		"by_staff":       actor.Staff,
+		"promoted":       len(promoted),
+	}, ip, "success")
+
+	s.notifyPromoted(ctx, actor.UserID, current, promoted, ip)
+
+	if s.notifSvc != nil {
+		when := serviceStart(target, newTime).Format("02 Jan 2006 15:04")
+		_ = s.notifSvc.CreateInAppNotification(
+			ctx,
+			booking.UserID,
+			booking.EntityID,
+			"Seva Booking Rescheduled",
+			"Your booking is now for "+target.Name+" on "+when+" and is pending approval",
+			"seva",
+		)
+		if !actor.Staff {
+			_ = s.notifSvc.CreateInAppForEntityRoles(
+				ctx,
+				booking.EntityID,
+				[]string{"templeadmin", "standarduser"},
+				"Seva Booking Rescheduled",
+				"A devotee moved their booking to "+target.Name+" on "+when,
+				"seva",
+			)
+		}
+	}
+
+	return s.repo.GetBookingByID(ctx, bookingID)
+}
+
+// UpdateRefundStatus records whether the temple refunded a cancelled booking
+func (s *service) UpdateRefundStatus(ctx context.Context, bookingID uint, actor BookingActor, req RefundStatusRequest, ip string) (*SevaBooking, error) {
+	if req.Status != RefundProcessed && req.Status != RefundDeclined {
+		return nil, ErrInvalidRefundStatus
+	}
+	booking, err := s.repo.GetBookingByID(ctx, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	if !actor.Staff {
+		return nil, ErrBookingAccessDenied
+	}
+	if err := actor.authorize(booking); err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateRefundStatus(ctx, bookingID, req.Status); err != nil {
+		return nil, err
+	}
+
+	s.auditSvc.LogAction(ctx, &actor.UserID, &booking.EntityID, "SEVA_BOOKING_REFUND_UPDATED", map[string]interface{}{
+		"booking_id":    bookingID,
+		"devotee_id":    booking.UserID,
+		"refund_status": req.Status,
+		"note":          req.Note,
+	}, ip, "success")
+
+	if s.notifSvc != nil {
+		message := "Your refund for a cancelled seva booking has been processed"
+		if req.Status == RefundDeclined {
+			message = "Your refund request for a cancelled seva booking was declined"
+		}
+		if note := strings.TrimSpace(req.Note); note != "" {
+			message += ": " + note
+		}
+		_ = s.notifSvc.CreateInAppNotification(ctx, booking.UserID, booking.EntityID, "Seva Booking Refund", message, "seva")
+	}
+
+	return s.repo.GetBookingByID(ctx, bookingID)
+}
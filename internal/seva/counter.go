@@ -0,0 +1,323 @@
+package seva
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Redis keys: seva_booking_count:<sevaID>:<yyyymmdd> holds the number of
+// approved bookings of a seva for one service day.
+const (
+	bookingCounterPrefix = "seva_booking_count"
+	bookingCounterGrace  = 24 * time.Hour // keep yesterday's keys around for reconciliation
+	dayKeyLayout         = "20060102"
+)
+
+// reserveScript increments the counter only while it is below capacity.
+// KEYS[1] counter, ARGV[1] capacity, ARGV[2] unix expiry. Returns the new
+// count, or -1 when the seva is full.
+var reserveScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current >= tonumber(ARGV[1]) then
+	return -1
+end
+current = redis.call('INCR', KEYS[1])
+redis.call('EXPIREAT', KEYS[1], ARGV[2])
+return current
+`)
+
+// releaseScript decrements the counter without going below zero
+var releaseScript = redis.NewScript(`
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+if current <= 0 then
+	return 0
+end
+return redis.call('DECR', KEYS[1])
+`)
+
+// CounterMetrics is a snapshot of booking counter activity since startup
+type CounterMetrics struct {
+	Enabled           bool      `json:"enabled"`
+	Reservations      int64     `json:"reservations"`
+	Rejections        int64     `json:"rejections"`
+	Releases          int64     `json:"releases"`
+	Seeds             int64     `json:"seeds"`           // counters initialised from the DB
+	Fallbacks         int64     `json:"fallbacks"`       // Redis errors that fell back to SQL
+	Reconciliations   int64     `json:"reconciliations"` // reconcile runs
+	KeysChecked       int64     `json:"keys_checked"`
+	KeysDrifted       int64     `json:"keys_drifted"`
+	TotalDrift        int64     `json:"total_drift"` // sum of |redis - db| corrected
+	LastDrift         int64     `json:"last_drift"`
+	LastReconciledAt  time.Time `json:"last_reconciled_at"`
+	ReconcileInterval string    `json:"reconcile_interval"`
+}
+
+// BookingCounter is the fast path for per-seva daily capacity checks.
+// The seva_bookings table stays the source of truth: counters are seeded from
+// it on first use and corrected by the reconciler. When Redis isn't available
+// every call reports handled=false and callers use the SQL checks.
+type BookingCounter struct {
+	repo Repository
+
+	reservations, rejections, releases, seeds, fallbacks atomic.Int64
+	reconciliations, keysChecked, keysDrifted, totalDrift  atomic.Int64
+	lastDrift                                              atomic.Int64
+
+	mu               sync.Mutex
+	lastReconciledAt time.Time
+	interval         time.Duration
+}
+
+// NewBookingCounter creates the Redis backed booking counter
+func NewBookingCounter(repo Repository) *BookingCounter {
+	return &BookingCounter{repo: repo}
+}
+
+func (bc *BookingCounter) enabled() bool {
+	return bc != nil && utils.RedisClient != nil
+}
+
+// serviceDay returns the day a booking counts against: the seva's own date
+// when it has one, otherwise the day the booking was made
+func serviceDay(seva *Seva, bookingTime time.Time) time.Time {
+	if seva.Date != "" {
+		if d, err := time.ParseInLocation("02-01-2006", seva.Date, time.Local); err == nil {
+			return d
+		}
+	}
+	y, m, d := bookingTime.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.Local)
+}
+
+func counterKey(sevaID uint, day time.Time) string {
+	return fmt.Sprintf("%s:%d:%s", bookingCounterPrefix, sevaID, day.Format(dayKeyLayout))
+}
+
+func parseCounterKey(key string) (uint, time.Time, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 3 || parts[0] != bookingCounterPrefix {
+		return 0, time.Time{}, false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	day, err := time.ParseInLocation(dayKeyLayout, parts[2], time.Local)
+	if err != nil {
+		return 0, time.Time{}, false
+	}
+	return uint(id), day, true
+}
+
+func counterExpiry(day time.Time) int64 {
+	return day.AddDate(0, 0, 1).Add(bookingCounterGrace).Unix()
+}
+
+// dbCount counts approved bookings for the seva/day in the database
+func (bc *BookingCounter) dbCount(ctx context.Context, seva *Seva, day time.Time) (int64, error) {
+	if seva.Date != "" {
+		return bc.repo.CountApprovedBookingsForSevaDay(ctx, seva.ID, nil, nil)
+	}
+	end := day.AddDate(0, 0, 1)
+	return bc.repo.CountApprovedBookingsForSevaDay(ctx, seva.ID, &day, &end)
+}
+
+// ensureSeeded initialises a missing counter from the DB (SETNX, so
+// concurrent seeders agree on one value)
+func (bc *BookingCounter) ensureSeeded(ctx context.Context, seva *Seva, day time.Time, key string) error {
+	exists, err := utils.RedisClient.Exists(ctx, key).Result()
+	if err != nil || exists == 1 {
+		return err
+	}
+	n, err := bc.dbCount(ctx, seva, day)
+	if err != nil {
+		return err
+	}
+	ok, err := utils.RedisClient.SetNX(ctx, key, n, time.Until(time.Unix(counterExpiry(day), 0))).Result()
+	if err == nil && ok {
+		bc.seeds.Add(1)
+	}
+	return err
+}
+
+// Count returns the approved bookings for the seva's service day
+func (bc *BookingCounter) Count(ctx context.Context, seva *Seva, bookingTime time.Time) (count int64, handled bool) {
+	if !bc.enabled() {
+		return 0, false
+	}
+	day := serviceDay(seva, bookingTime)
+	key := counterKey(seva.ID, day)
+	if err := bc.ensureSeeded(ctx, seva, day, key); err != nil {
+		bc.fallback("count", key, err)
+		return 0, false
+	}
+	n, err := utils.RedisClient.Get(ctx, key).Int64()
+	if err != nil {
+		bc.fallback("count", key, err)
+		return 0, false
+	}
+	return n, true
+}
+
+// Reserve atomically takes one slot. ok=false with handled=true means the
+// seva is full for that day.
+func (bc *BookingCounter) Reserve(ctx context.Context, seva *Seva, bookingTime time.Time) (ok bool, handled bool) {
+	if !bc.enabled() {
+		return false, false
+	}
+	day := serviceDay(seva, bookingTime)
+	key := counterKey(seva.ID, day)
+	if err := bc.ensureSeeded(ctx, seva, day, key); err != nil {
+		bc.fallback("reserve", key, err)
+		return false, false
+	}
+	res, err := reserveScript.Run(ctx, utils.RedisClient, []string{key}, seva.AvailableSlots, counterExpiry(day)).Int64()
+	if err != nil {
+		bc.fallback("reserve", key, err)
+		return false, false
+	}
+	if res < 0 {
+		bc.rejections.Add(1)
+		return false, true
+	}
+	bc.reservations.Add(1)
+	return true, true
+}
+
+// Release gives a slot back (approval rolled back, or approved booking cancelled)
+func (bc *BookingCounter) Release(ctx context.Context, seva *Seva, bookingTime time.Time) {
+	if !bc.enabled() {
+		return
+	}
+	key := counterKey(seva.ID, serviceDay(seva, bookingTime))
+	if err := releaseScript.Run(ctx, utils.RedisClient, []string{key}).Err(); err != nil {
+		bc.fallback("release", key, err)
+		return
+	}
+	bc.releases.Add(1)
+}
+
+func (bc *BookingCounter) fallback(op, key string, err error) {
+	bc.fallbacks.Add(1)
+	log.Printf("⚠️ Booking counter %s failed for %s, using SQL: %v", op, key, err)
+}
+
+// Reconcile compares every live counter with the DB and corrects drift
+func (bc *BookingCounter) Reconcile(ctx context.Context) error {
+	if !bc.enabled() {
+		return nil
+	}
+	var (
+		cursor  uint64
+		checked int64
+		drift   int64
+		drifted int64
+	)
+	for {
+		keys, next, err := utils.RedisClient.Scan(ctx, cursor, bookingCounterPrefix+":*", 200).Result()
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			sevaID, day, ok := parseCounterKey(key)
+			if !ok {
+				continue
+			}
+			seva, err := bc.repo.GetSevaByID(ctx, sevaID)
+			if err != nil {
+				// Seva deleted; drop its counter
+				utils.RedisClient.Del(ctx, key)
+				continue
+			}
+			dbN, err := bc.dbCount(ctx, seva, day)
+			if err != nil {
+				log.Printf("⚠️ Booking counter reconcile: DB count for %s failed: %v", key, err)
+				continue
+			}
+			redisN, err := utils.RedisClient.Get(ctx, key).Int64()
+			if err != nil && err != redis.Nil {
+				continue
+			}
+			checked++
+			if redisN != dbN {
+				d := redisN - dbN
+				if d < 0 {
+					d = -d
+				}
+				drift += d
+				drifted++
+				log.Printf("🔁 Booking counter drift on %s: redis=%d db=%d", key, redisN, dbN)
+				utils.RedisClient.Set(ctx, key, dbN, time.Until(time.Unix(counterExpiry(day), 0)))
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+
+	bc.reconciliations.Add(1)
+	bc.keysChecked.Add(checked)
+	bc.keysDrifted.Add(drifted)
+	bc.totalDrift.Add(drift)
+	bc.lastDrift.Store(drift)
+	bc.mu.Lock()
+	bc.lastReconciledAt = time.Now()
+	bc.mu.Unlock()
+	return nil
+}
+
+// StartReconciler runs Reconcile every interval until ctx is cancelled
+func (bc *BookingCounter) StartReconciler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	bc.mu.Lock()
+	bc.interval = interval
+	bc.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := bc.Reconcile(ctx); err != nil {
+					log.Printf("❌ Booking counter reconcile failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// Metrics returns a snapshot of counter activity and drift
+func (bc *BookingCounter) Metrics() CounterMetrics {
+	bc.mu.Lock()
+	defer bc.mu.Unlock()
+	return CounterMetrics{
+		Enabled:           bc.enabled(),
+		Reservations:      bc.reservations.Load(),
+		Rejections:        bc.rejections.Load(),
+		Releases:          bc.releases.Load(),
+		Seeds:             bc.seeds.Load(),
+		Fallbacks:         bc.fallbacks.Load(),
+		Reconciliations:   bc.reconciliations.Load(),
+		KeysChecked:       bc.keysChecked.Load(),
+		KeysDrifted:       bc.keysDrifted.Load(),
+		TotalDrift:        bc.totalDrift.Load(),
+		LastDrift:         bc.lastDrift.Load(),
+		LastReconciledAt:  bc.lastReconciledAt,
+		ReconcileInterval: bc.interval.String(),
+	}
+}
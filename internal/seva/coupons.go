@@ -0,0 +1,69 @@
+package seva
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sharath018/temple-management-backend/internal/coupon"
+	"gorm.io/gorm"
+)
+
+// Devotees may give a temple coupon code when booking. The code is redeemed
+// in the booking's transaction, so a booking never exists without its
+// discount or the other way round. Rejecting or cancelling the booking frees
+// the coupon use again.
+
+var ErrCouponsUnavailable = errors.New("coupons are not available")
+
+// SetCouponService enables coupon codes on bookings
+func (s *service) SetCouponService(c *coupon.Service) {
+	s.coupons = c
+}
+
+// couponRedeemer prices the booking and, when it carries a code, returns the
+// step that redeems it inside CreateBookingWithinCapacity
+func (s *service) couponRedeemer(booking *SevaBooking, sv *Seva) (func(tx *gorm.DB) error, error) {
+	booking.Price = sv.Price
+	booking.DiscountAmount = 0
+	booking.CouponCode = coupon.NormalizeCode(booking.CouponCode)
+	if booking.CouponCode == "" {
+		return nil, nil
+	}
+	if s.coupons == nil {
+		return nil, ErrCouponsUnavailable
+	}
+	return func(tx *gorm.DB) error {
+		r, err := s.coupons.RedeemTx(tx, booking.CouponCode, coupon.Target{
+			EntityID:  booking.EntityID,
+			UserID:    booking.UserID,
+			Kind:      coupon.KindBooking,
+			SevaID:    &booking.SevaID,
+			Amount:    sv.Price,
+			BookingID: &booking.ID,
+		})
+		if err != nil {
+			return err
+		}
+		booking.DiscountAmount = r.Discount
+		return tx.Model(booking).Update("discount_amount", r.Discount).Error
+	}, nil
+}
+
+// releaseCoupon frees the coupon use of a booking that was rejected or
+// cancelled
+func (s *service) releaseCoupon(ctx context.Context, booking *SevaBooking) {
+	if booking.CouponCode == "" || s.coupons == nil {
+		return
+	}
+	_ = s.coupons.ReleaseBooking(ctx, booking.ID)
+}
+
+// amountPaid is what the devotee pays for the booking: the seva's price, less
+// any coupon discount. Bookings made before prices were recorded on them go
+// by the seva's current price.
+func (b *SevaBooking) amountPaid(sv *Seva) float64 {
+	if b.Price == 0 && b.CouponCode == "" {
+		return sv.Price
+	}
+	return b.Price - b.DiscountAmount
+}
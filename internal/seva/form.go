@@ -0,0 +1,248 @@
+package seva
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Booking form field types
+const (
+	FormFieldText     = "text"
+	FormFieldNumber   = "number"
+	FormFieldDate     = "date" // yyyy-mm-dd
+	FormFieldSelect   = "select"
+	FormFieldPhone    = "phone"
+	FormFieldEmail    = "email"
+	FormFieldCheckbox = "checkbox"
+)
+
+const (
+	maxFormFields       = 20
+	defaultTextMaxLen   = 500
+	formFieldDateLayout = "2006-01-02"
+)
+
+var (
+	formFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+	phonePattern        = regexp.MustCompile(`^\+?[0-9][0-9 \-]{6,18}$`)
+)
+
+// BookingFormField describes one extra detail a devotee fills in when booking
+// a seva, e.g. the vehicle number for a vahana pooja
+type BookingFormField struct {
+	Key       string   `json:"key"`
+	Label     string   `json:"label"`
+	Type      string   `json:"type"`
+	Required  bool     `json:"required"`
+	Options   []string `json:"options,omitempty"`    // select only
+	MaxLength int      `json:"max_length,omitempty"` // text only
+}
+
+// FormValidationError reports which form field or definition is invalid
+type FormValidationError struct {
+	Field   string
+	Message string
+}
+
+func (e *FormValidationError) Error() string {
+	if e.Field == "" {
+		return e.Message
+	}
+	return e.Field + ": " + e.Message
+}
+
+func formError(field, format string, args ...interface{}) error {
+	return &FormValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+// BookingFormFields decodes the seva's booking form definition
+func (s *Seva) BookingFormFields() ([]BookingFormField, error) {
+	if len(s.FormFields) == 0 {
+		return nil, nil
+	}
+	var fields []BookingFormField
+	if err := json.Unmarshal(s.FormFields, &fields); err != nil {
+		return nil, fmt.Errorf("invalid booking form definition for seva %d: %w", s.ID, err)
+	}
+	return fields, nil
+}
+
+// encodeFormFields validates form field definitions set by a temple admin and
+// encodes them for storage on the seva
+func encodeFormFields(fields []BookingFormField) (datatypes.JSON, error) {
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if len(fields) > maxFormFields {
+		return nil, formError("form_fields", "at most %d fields are allowed", maxFormFields)
+	}
+
+	seen := make(map[string]bool, len(fields))
+	for i := range fields {
+		f := &fields[i]
+		f.Key = strings.TrimSpace(f.Key)
+		f.Label = strings.TrimSpace(f.Label)
+		f.Type = strings.ToLower(strings.TrimSpace(f.Type))
+
+		if !formFieldKeyPattern.MatchString(f.Key) {
+			return nil, formError(f.Key, "key must be lowercase letters, digits or underscores and start with a letter")
+		}
+		if seen[f.Key] {
+			return nil, formError(f.Key, "duplicate field key")
+		}
+		seen[f.Key] = true
+		if f.Label == "" {
+			f.Label = f.Key
+		}
+
+		switch f.Type {
+		case FormFieldText:
+			if f.MaxLength < 0 {
+				return nil, formError(f.Key, "max_length can't be negative")
+			}
+		case FormFieldSelect:
+			var options []string
+			for _, o := range f.Options {
+				if o = strings.TrimSpace(o); o != "" {
+					options = append(options, o)
+				}
+			}
+			if len(options) == 0 {
+				return nil, formError(f.Key, "select fields need at least one option")
+			}
+			f.Options = options
+		case FormFieldNumber, FormFieldDate, FormFieldPhone, FormFieldEmail, FormFieldCheckbox:
+		default:
+			return nil, formError(f.Key, "unsupported field type %q", f.Type)
+		}
+		if f.Type != FormFieldSelect {
+			f.Options = nil
+		}
+		if f.Type != FormFieldText {
+			f.MaxLength = 0
+		}
+	}
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(raw), nil
+}
+
+// validateBookingForm checks the values a devotee submitted against the
+// seva's form and returns them normalised for storage on the booking
+func validateBookingForm(fields []BookingFormField, input map[string]interface{}) (datatypes.JSON, error) {
+	known := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		known[f.Key] = true
+	}
+	for key := range input {
+		if !known[key] {
+			return nil, formError(key, "unknown field")
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+
+	values := make(map[string]interface{}, len(fields))
+	for _, f := range fields {
+		raw, ok := input[f.Key]
+		if s, isString := raw.(string); isString && strings.TrimSpace(s) == "" {
+			ok = false
+		}
+		if !ok || raw == nil {
+			if f.Required {
+				return nil, formError(f.Key, "%s is required", f.Label)
+			}
+			continue
+		}
+
+		value, err := normaliseFormValue(f, raw)
+		if err != nil {
+			return nil, err
+		}
+		values[f.Key] = value
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return nil, err
+	}
+	return datatypes.JSON(raw), nil
+}
+
+func normaliseFormValue(f BookingFormField, raw interface{}) (interface{}, error) {
+	switch f.Type {
+	case FormFieldNumber:
+		switch v := raw.(type) {
+		case float64:
+			return v, nil
+		case string:
+			n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+			if err != nil {
+				return nil, formError(f.Key, "%s must be a number", f.Label)
+			}
+			return n, nil
+		}
+		return nil, formError(f.Key, "%s must be a number", f.Label)
+
+	case FormFieldCheckbox:
+		switch v := raw.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			if err == nil {
+				return b, nil
+			}
+		}
+		return nil, formError(f.Key, "%s must be true or false", f.Label)
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		return nil, formError(f.Key, "%s must be text", f.Label)
+	}
+	s = strings.TrimSpace(s)
+
+	switch f.Type {
+	case FormFieldText:
+		limit := f.MaxLength
+		if limit == 0 {
+			limit = defaultTextMaxLen
+		}
+		if len([]rune(s)) > limit {
+			return nil, formError(f.Key, "%s must be at most %d characters", f.Label, limit)
+		}
+	case FormFieldDate:
+		if _, err := time.Parse(formFieldDateLayout, s); err != nil {
+			return nil, formError(f.Key, "%s must be a date in yyyy-mm-dd format", f.Label)
+		}
+	case FormFieldSelect:
+		for _, o := range f.Options {
+			if strings.EqualFold(o, s) {
+				return o, nil
+			}
+		}
+		return nil, formError(f.Key, "%s must be one of: %s", f.Label, strings.Join(f.Options, ", "))
+	case FormFieldPhone:
+		if !phonePattern.MatchString(s) {
+			return nil, formError(f.Key, "%s must be a valid phone number", f.Label)
+		}
+	case FormFieldEmail:
+		addr, err := mail.ParseAddress(s)
+		if err != nil || addr.Address != s {
+			return nil, formError(f.Key, "%s must be a valid email address", f.Label)
+		}
+	}
+	return s, nil
+}
@@ -1,6 +1,8 @@
 package seva
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -8,8 +10,11 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/coupon"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/event"
 	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -54,6 +59,8 @@ type CreateSevaRequest struct {
 	EndTime        string  `json:"end_time"`
 	Duration       int     `json:"duration"`
 	AvailableSlots int     `json:"available_slots"` // ✅ UPDATED field name
+	FormFields     []BookingFormField `json:"form_fields"` // extra details asked at booking time
+	CancellationWindowHours int `json:"cancellation_window_hours"` // devotees cancel or reschedule until this many hours before the start
 }
 
 type UpdateSevaRequest struct {
@@ -67,10 +74,15 @@ type UpdateSevaRequest struct {
 	Duration       *int     `json:"duration,omitempty"`
 	AvailableSlots *int     `json:"available_slots,omitempty"` // ✅ UPDATED field name
 	Status         *string  `json:"status,omitempty"`
+	FormFields     *[]BookingFormField `json:"form_fields,omitempty"` // an empty list removes the form
+	CancellationWindowHours *int `json:"cancellation_window_hours,omitempty"`
 }
 
 type BookSevaRequest struct {
-	SevaID uint `json:"seva_id" binding:"required"`
+	SevaID       uint `json:"seva_id" binding:"required"`
+	JoinWaitlist bool `json:"join_waitlist"` // join the waitlist if the seva is full
+	FormData     map[string]interface{} `json:"form_data"` // answers to the seva's booking form
+	CouponCode   string `json:"coupon_code"` // optional temple coupon
 }
 
 // ========================= SEVA HANDLERS =============================
@@ -118,6 +130,16 @@ func (h *Handler) CreateSeva(c *gin.Context) {
 		return
 	}
 
+	formFields, err := encodeFormFields(input.FormFields)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form fields: " + err.Error()})
+		return
+	}
+	if input.CancellationWindowHours < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cancellation_window_hours cannot be negative"})
+		return
+	}
+
 	ip := middleware.GetIPFromContext(c)
 
 	// ✅ UPDATED: Initialize with new slot fields
@@ -134,6 +156,8 @@ func (h *Handler) CreateSeva(c *gin.Context) {
 		AvailableSlots: input.AvailableSlots, // ✅ UPDATED
 		BookedSlots:    0,                     // ✅ NEW: Initialize to 0
 		RemainingSlots: input.AvailableSlots,  // ✅ NEW: Initially same as available
+		FormFields:     formFields,
+		CancellationWindowHours: input.CancellationWindowHours,
 		Status:         "upcoming",
 	}
 
@@ -411,6 +435,21 @@ func (h *Handler) UpdateSeva(c *gin.Context) {
 		}
 		updatedSeva.Status = *input.Status
 	}
+	if input.FormFields != nil {
+		formFields, err := encodeFormFields(*input.FormFields)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form fields: " + err.Error()})
+			return
+		}
+		updatedSeva.FormFields = formFields
+	}
+	if input.CancellationWindowHours != nil {
+		if *input.CancellationWindowHours < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "cancellation_window_hours cannot be negative"})
+			return
+		}
+		updatedSeva.CancellationWindowHours = *input.CancellationWindowHours
+	}
 
 	if err := h.service.UpdateSeva(c, &updatedSeva, accessContext, ip); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update seva: " + err.Error()})
@@ -551,13 +590,45 @@ func (h *Handler) BookSeva(c *gin.Context) {
 		EntityID:    seva.EntityID,
 		BookingTime: time.Now(),
 		Status:      "pending",
+		CouponCode:  input.CouponCode,
 	}
 
-	if err := h.service.BookSeva(c, &booking, "devotee", user.ID, seva.EntityID, ip); err != nil {
+	if err := h.service.BookSeva(c, &booking, "devotee", user.ID, seva.EntityID, input.FormData, input.JoinWaitlist, ip); err != nil {
+		var formErr *FormValidationError
+		if errors.As(err, &formErr) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": formErr.Error(), "field": formErr.Field})
+			return
+		}
+		if errors.Is(err, ErrSevaFull) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":              err.Error(),
+				"waitlist_available": true,
+				"message":            "Resend the booking with \"join_waitlist\": true to join the waitlist",
+			})
+			return
+		}
+		if status, ok := coupon.ErrorStatus(err); ok {
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, ErrCouponsUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Booking failed: " + err.Error()})
 		return
 	}
 
+	if booking.Status == "waitlisted" {
+		position, _ := h.service.GetWaitlistPosition(c, &booking)
+		c.JSON(http.StatusCreated, gin.H{
+			"message":           "Seva is full, you have been added to the waitlist",
+			"booking":           booking,
+			"waitlist_position": position,
+		})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "Seva booked successfully",
 		"booking": booking,
@@ -643,6 +714,11 @@ func (h *Handler) GetBookingByID(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"booking": booking})
 }
 
+// 📊 Booking counter metrics (Redis fast path vs DB drift) - superadmin only
+func (h *Handler) GetBookingCounterMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, h.service.BookingCounterMetrics())
+}
+
 // 📊 Get Booking Counts
 func (h *Handler) GetBookingCounts(c *gin.Context) {
 	user := c.MustGet("user").(auth.User)
@@ -705,9 +781,459 @@ func (h *Handler) UpdateBookingStatus(c *gin.Context) {
 	ip := middleware.GetIPFromContext(c)
 
 	if err := h.service.UpdateBookingStatus(c, uint(id), input.Status, user.ID, ip); err != nil {
+		if errors.Is(err, ErrSevaFull) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Status update failed: " + err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Booking status updated successfully"})
-}
\ No newline at end of file
+}
+// ========================= CANCELLATION & RESCHEDULING =============================
+
+// bookingActor is the caller changing a booking: a devotee acting on their
+// own bookings, or temple staff with write access acting for their temple
+func bookingActor(c *gin.Context) (BookingActor, bool) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return BookingActor{}, false
+	}
+	actor := BookingActor{UserID: accessContext.UserID}
+	switch accessContext.RoleName {
+	case middleware.RoleDevotee, middleware.RoleVolunteer:
+		return actor, true
+	case middleware.RoleSuperAdmin:
+		actor.Staff = true
+		return actor, true
+	}
+	if !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return BookingActor{}, false
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "no accessible temple"})
+		return BookingActor{}, false
+	}
+	actor.Staff = true
+	actor.EntityID = entityID
+	return actor, true
+}
+
+func bookingIDParam(c *gin.Context) (uint, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid booking ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeBookingChangeError(c *gin.Context, err error, fallback string) {
+	var formErr *FormValidationError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+	case errors.As(err, &formErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "The booking details do not suit the new seva: " + formErr.Error(), "field": formErr.Field})
+	case errors.Is(err, ErrInvalidRescheduleTarget), errors.Is(err, ErrInvalidRescheduleDate),
+		errors.Is(err, ErrNoRefundDue), errors.Is(err, ErrInvalidRefundStatus):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotBookingOwner), errors.Is(err, ErrBookingAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrBookingNotCancellable), errors.Is(err, ErrBookingNotReschedulable),
+		errors.Is(err, ErrCancellationWindowClosed), errors.Is(err, ErrSevaFull), errors.Is(err, ErrRefundNotRequested):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback + ": " + err.Error()})
+	}
+}
+
+// ❎ Cancel booking - PATCH /bookings/:id/cancel (also /sevas/bookings/:id/cancel)
+// Body (optional): {"reason": "...", "request_refund": true}
+func (h *Handler) CancelBooking(c *gin.Context) {
+	actor, ok := bookingActor(c)
+	if !ok {
+		return
+	}
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input CancelBookingRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	if err := h.service.CancelBooking(c, id, actor, input, ip); err != nil {
+		writeBookingChangeError(c, err, "Cancellation failed")
+		return
+	}
+
+	booking, _ := h.service.GetBookingByID(c, id)
+	c.JSON(http.StatusOK, gin.H{"message": "Booking cancelled successfully", "booking": booking})
+}
+
+// 🔁 Reschedule booking - PATCH /bookings/:id/reschedule
+// Body: {"seva_id": 12, "date": "2026-11-02"}; the booking returns to pending
+func (h *Handler) RescheduleBooking(c *gin.Context) {
+	actor, ok := bookingActor(c)
+	if !ok {
+		return
+	}
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input RescheduleBookingRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	booking, err := h.service.RescheduleBooking(c, id, actor, input, ip)
+	if err != nil {
+		writeBookingChangeError(c, err, "Reschedule failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Booking rescheduled and pending approval", "booking": booking})
+}
+
+// 💸 Record refund decision - PATCH /bookings/:id/refund (temple staff)
+// Body: {"status": "processed" | "declined", "note": "..."}
+func (h *Handler) UpdateRefundStatus(c *gin.Context) {
+	actor, ok := bookingActor(c)
+	if !ok {
+		return
+	}
+	id, ok := bookingIDParam(c)
+	if !ok {
+		return
+	}
+
+	var input RefundStatusRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	ip := middleware.GetIPFromContext(c)
+
+	booking, err := h.service.UpdateRefundStatus(c, id, actor, input, ip)
+	if err != nil {
+		writeBookingChangeError(c, err, "Refund update failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Refund " + input.Status, "booking": booking})
+}
+
+// ========================= RECOMMENDATIONS =============================
+
+// 🌟 Seva recommendations - GET /sevas/recommendations?entity_id=&limit=
+// Scores the temple's open sevas against the devotee's nakshatra, rashi,
+// upcoming special days and booking history; each result says why.
+func (h *Handler) GetRecommendations(c *gin.Context) {
+	user := c.MustGet("user").(auth.User)
+
+	var entityID uint
+	if id, err := strconv.ParseUint(c.Query("entity_id"), 10, 32); err == nil {
+		entityID = uint(id)
+	}
+	if entityID == 0 {
+		if user.EntityID == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "user not linked to a temple and no entity_id provided"})
+			return
+		}
+		entityID = *user.EntityID
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	recs, err := h.service.RecommendSevas(c, user.ID, entityID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entity_id": entityID, "recommendations": recs, "total": len(recs)})
+}
+
+// ⚙️ Recommendation rules of the admin's temple - GET /sevas/recommendation-rules
+func (h *Handler) GetRecommendationRules(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not linked to a temple"})
+		return
+	}
+
+	rules, err := h.service.GetRecommendationRules(c, *entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendation rules: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"entity_id": *entityID, "rules": rules})
+}
+
+// ⚙️ Replace recommendation rules - PUT /sevas/recommendation-rules
+func (h *Handler) UpdateRecommendationRules(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not linked to a temple"})
+		return
+	}
+	if !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return
+	}
+
+	var input RecommendationRules
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	rules, err := h.service.UpdateRecommendationRules(c, *entityID, input, accessContext.UserID, middleware.GetIPFromContext(c))
+	if errors.Is(err, ErrInvalidRecommendationRules) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recommendation rules: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Recommendation rules updated", "rules": rules})
+}
+
+// ========================= TEMPLATES & GENERATION =============================
+
+// templeWriteAccess returns the caller's temple for the template and bulk
+// endpoints, which need write access
+func templeWriteAccess(c *gin.Context) (middleware.AccessContext, uint, bool) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return accessContext, 0, false
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not linked to a temple"})
+		return accessContext, 0, false
+	}
+	if !accessContext.CanWrite() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "write access denied"})
+		return accessContext, 0, false
+	}
+	return accessContext, *entityID, true
+}
+
+func idParam(c *gin.Context, what string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + what + " ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func writeTemplateError(c *gin.Context, err error, fallback string) {
+	var formErr *FormValidationError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.As(err, &formErr):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form fields: " + formErr.Error(), "field": formErr.Field})
+	case errors.Is(err, ErrInvalidSevaTemplate), errors.Is(err, ErrInvalidGenerateRequest),
+		errors.Is(err, ErrInvalidBulkStatus), errors.Is(err, event.ErrInvalidRecurrence):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrSevaAccessDenied):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fallback + ": " + err.Error()})
+	}
+}
+
+// 📋 Seva templates of the temple - GET /sevas/templates
+func (h *Handler) ListSevaTemplates(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not linked to a temple"})
+		return
+	}
+	templates, err := h.service.ListSevaTemplates(c, *entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch seva templates: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"templates": templates, "total": len(templates)})
+}
+
+// 📋 One seva template - GET /sevas/templates/:id
+func (h *Handler) GetSevaTemplate(c *gin.Context) {
+	accessContext, ok := getAccessContextFromContext(c)
+	if !ok {
+		return
+	}
+	entityID := accessContext.GetAccessibleEntityID()
+	if entityID == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not linked to a temple"})
+		return
+	}
+	id, ok := idParam(c, "template")
+	if !ok {
+		return
+	}
+	t, err := h.service.GetSevaTemplate(c, *entityID, id)
+	if err != nil {
+		writeTemplateError(c, err, "Failed to fetch seva template")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"template": t})
+}
+
+// ➕ Create seva template - POST /sevas/templates
+func (h *Handler) CreateSevaTemplate(c *gin.Context) {
+	accessContext, entityID, ok := templeWriteAccess(c)
+	if !ok {
+		return
+	}
+	var input SevaTemplateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	t, err := h.service.CreateSevaTemplate(c, entityID, input, accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		writeTemplateError(c, err, "Failed to create seva template")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Seva template created", "template": t})
+}
+
+// 🛠 Replace seva template - PUT /sevas/templates/:id
+// Sevas already generated from it are not changed.
+func (h *Handler) UpdateSevaTemplate(c *gin.Context) {
+	accessContext, entityID, ok := templeWriteAccess(c)
+	if !ok {
+		return
+	}
+	id, ok := idParam(c, "template")
+	if !ok {
+		return
+	}
+	var input SevaTemplateRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	t, err := h.service.UpdateSevaTemplate(c, entityID, id, input, accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		writeTemplateError(c, err, "Failed to update seva template")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Seva template updated", "template": t})
+}
+
+// 🗑 Delete seva template - DELETE /sevas/templates/:id
+func (h *Handler) DeleteSevaTemplate(c *gin.Context) {
+	accessContext, entityID, ok := templeWriteAccess(c)
+	if !ok {
+		return
+	}
+	id, ok := idParam(c, "template")
+	if !ok {
+		return
+	}
+	if err := h.service.DeleteSevaTemplate(c, entityID, id, accessContext.UserID, middleware.GetIPFromContext(c)); err != nil {
+		writeTemplateError(c, err, "Failed to delete seva template")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Seva template deleted"})
+}
+
+// 🗓 Generate sevas from a template - POST /sevas/templates/:id/generate
+// Body: {"start_date": "01-11-2026", "end_date": "30-11-2026",
+// "recurrence_rule": "FREQ=WEEKLY;BYDAY=MO,FR", "skip_conflicts": false, "dry_run": true}
+func (h *Handler) GenerateSevas(c *gin.Context) {
+	h.generateSevas(c, "template", h.service.GenerateSevas)
+}
+
+// 📑 Clone a seva onto other dates - POST /sevas/:id/clone
+// Body as for GenerateSevas
+func (h *Handler) CloneSeva(c *gin.Context) {
+	h.generateSevas(c, "seva", h.service.CloneSeva)
+}
+
+func (h *Handler) generateSevas(c *gin.Context, what string,
+	generate func(context.Context, uint, uint, GenerateSevasRequest, uint, string) (*GenerateSevasResult, error)) {
+	accessContext, entityID, ok := templeWriteAccess(c)
+	if !ok {
+		return
+	}
+	id, ok := idParam(c, what)
+	if !ok {
+		return
+	}
+	var input GenerateSevasRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	result, err := generate(c, entityID, id, input, accessContext.UserID, middleware.GetIPFromContext(c))
+	if errors.Is(err, ErrSevaConflicts) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error() + "; set skip_conflicts to create the other dates", "result": result})
+		return
+	}
+	if err != nil {
+		writeTemplateError(c, err, "Failed to generate sevas")
+		return
+	}
+	if result.DryRun {
+		c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d sevas would be created", len(result.Sevas)), "result": result})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": fmt.Sprintf("%d sevas created", len(result.Sevas)), "result": result})
+}
+
+// 🔁 Activate or deactivate sevas in bulk - PATCH /sevas/bulk-status
+// Body: {"template_id": 3, "start_date": "01-11-2026", "end_date": "30-11-2026", "is_active": false}
+func (h *Handler) BulkUpdateSevaStatus(c *gin.Context) {
+	accessContext, entityID, ok := templeWriteAccess(c)
+	if !ok {
+		return
+	}
+	var input BulkSevaStatusRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+	ids, err := h.service.BulkUpdateSevaStatus(c, entityID, input, accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		writeTemplateError(c, err, "Failed to update sevas")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": fmt.Sprintf("%d sevas updated", len(ids)), "seva_ids": ids, "is_active": *input.IsActive})
+}
@@ -2,6 +2,8 @@ package seva
 
 import (
 	"time"
+
+	"gorm.io/datatypes"
 )
 
 // ======================
@@ -27,6 +29,22 @@ type Seva struct {
 	BookedSlots    int       `json:"booked_slots" gorm:"default:0"`    // Number of approved bookings
 	RemainingSlots int       `json:"remaining_slots" gorm:"default:0"` // Calculated: AvailableSlots - BookedSlots
 
+	// Devotees may cancel or reschedule until this many hours before the
+	// service starts; 0 allows it up to the start
+	CancellationWindowHours int `gorm:"default:0" json:"cancellation_window_hours"`
+
+	// Template the seva was generated from, if any (see template.go)
+	TemplateID     *uint          `gorm:"index" json:"template_id,omitempty"`
+
+	// Extra details asked for at booking time, a JSON array of BookingFormField
+	FormFields     datatypes.JSON `gorm:"type:jsonb" json:"form_fields,omitempty"`
+
+	// Panchang of Date, set on create and update; empty for undated sevas
+	Tithi          string         `gorm:"type:varchar(30)" json:"tithi,omitempty"`
+	Paksha         string         `gorm:"type:varchar(10)" json:"paksha,omitempty"`
+	Nakshatra      string         `gorm:"type:varchar(30)" json:"nakshatra,omitempty"`
+	PanchangTags   datatypes.JSON `gorm:"type:jsonb" json:"panchang_tags,omitempty"` // e.g. ["pournami"]
+
 	Status         string    `gorm:"type:varchar(20);default:'upcoming'" json:"status"` // upcoming/ongoing/completed
 	IsActive       bool      `gorm:"default:true" json:"is_active"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -43,7 +61,28 @@ type SevaBooking struct {
 	UserID      uint      `gorm:"not null" json:"user_id"`        // Who is booking (devotee)
 	EntityID    uint      `gorm:"not null" json:"entity_id"`      // Temple where the seva is hosted
 	BookingTime time.Time `json:"booking_time"`                   // Auto-timestamp
-	Status      string    `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending / approved / rejected
+	Status      string    `gorm:"type:varchar(20);default:'pending'" json:"status"` // pending / approved / rejected / waitlisted
+	FormData    datatypes.JSON `gorm:"type:jsonb" json:"form_data,omitempty"`    // Answers to the seva's booking form, keyed by field
+	FamilyID     *uint          `gorm:"index" json:"family_id,omitempty"`         // Set when booked for a household (see internal/family)
+	Participants datatypes.JSON `gorm:"type:jsonb" json:"participants,omitempty"` // Family members taking part, ["name"]
+
+	// Seva price at booking time and the discount of a coupon applied to it
+	// (see internal/coupon); the devotee pays Price minus DiscountAmount
+	Price          float64 `gorm:"type:decimal(10,2);default:0" json:"price"`
+	CouponCode     string  `gorm:"size:40" json:"coupon_code,omitempty"`
+	DiscountAmount float64 `gorm:"type:decimal(10,2);default:0" json:"discount_amount"`
+
+	// Cancellation and rescheduling (see cancellation.go)
+	CancelledAt        *time.Time `json:"cancelled_at,omitempty"`
+	CancelledBy        *uint      `json:"cancelled_by,omitempty"` // the devotee, or the temple staff member
+	CancellationReason string     `gorm:"type:text" json:"cancellation_reason,omitempty"`
+	RefundStatus       string     `gorm:"type:varchar(20)" json:"refund_status,omitempty"` // requested / processed / declined
+	RescheduledAt      *time.Time `json:"rescheduled_at,omitempty"`
+	RescheduleCount    int        `gorm:"default:0" json:"reschedule_count"`
+
+	// Attendance, marked when temple staff scan the devotee's QR pass (see internal/checkin)
+	CheckedInAt *time.Time `json:"checked_in_at,omitempty"`
+	CheckedInBy *uint      `json:"checked_in_by,omitempty"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
@@ -68,4 +107,6 @@ type BookingStatusCounts struct {
 	Approved int64 `json:"approved"`
 	Pending  int64 `json:"pending"`
 	Rejected int64 `json:"rejected"`
+	Waitlisted int64 `json:"waitlisted"`
+	Cancelled int64 `json:"cancelled"`
 }
\ No newline at end of file
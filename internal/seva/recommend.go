@@ -0,0 +1,484 @@
+package seva
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Seva recommendations score a temple's open sevas for one devotee from the
+// nakshatra and rashi in their profile, the temple's special days (including
+// the devotee's birth star days and birthday) and what they booked before.
+// Each temple tunes the weights and mappings in its recommendation rules.
+
+const (
+	sevaDateLayout    = "02-01-2006" // Seva.Date
+	specialDayLayout  = "2006-01-02"
+	defaultLookahead  = 30
+	maxLookahead      = 366
+	historyCap        = 3 // bookings of a seva type beyond this add no weight
+	defaultRecommends = 10
+)
+
+var ErrInvalidRecommendationRules = errors.New("invalid recommendation rules")
+
+// RecommendationWeights is the score each kind of match adds
+type RecommendationWeights struct {
+	Nakshatra  float64 `json:"nakshatra"`   // seva mapped to the devotee's nakshatra
+	Rashi      float64 `json:"rashi"`       // seva mapped to the devotee's rashi
+	BirthStar  float64 `json:"birth_star"`  // seva held on a day of the devotee's nakshatra
+	Birthday   float64 `json:"birthday"`    // seva suited to the devotee's upcoming birthday
+	SpecialDay float64 `json:"special_day"` // seva suited to an upcoming special day
+	History    float64 `json:"history"`     // at the history cap; fewer past bookings add less
+	Preference float64 `json:"preference"`  // seva preferences ticked in the profile
+}
+
+// SpecialDay is a temple calendar day, e.g. a festival or the day a
+// nakshatra falls on
+type SpecialDay struct {
+	Name      string   `json:"name"`
+	Date      string   `json:"date"`                 // YYYY-MM-DD
+	Nakshatra string   `json:"nakshatra,omitempty"`  // star of the day, for birth star matches
+	SevaTypes []string `json:"seva_types,omitempty"` // seva types or names suited to the day
+}
+
+// RecommendationRules is a temple's recommendation configuration. Seva lists
+// hold seva types or names and match case-insensitively.
+type RecommendationRules struct {
+	Weights        RecommendationWeights `json:"weights"`
+	NakshatraSevas map[string][]string   `json:"nakshatra_sevas"`
+	RashiSevas     map[string][]string   `json:"rashi_sevas"`
+	BirthdaySevas  []string              `json:"birthday_sevas"`
+	SpecialDays    []SpecialDay          `json:"special_days"`
+	LookaheadDays  int                   `json:"lookahead_days"` // how far ahead special days and birthdays count
+}
+
+// RecommendationConfig stores one temple's rules
+type RecommendationConfig struct {
+	EntityID  uint           `gorm:"primaryKey;autoIncrement:false" json:"entity_id"`
+	Rules     datatypes.JSON `gorm:"type:jsonb" json:"rules"`
+	UpdatedBy uint           `json:"updated_by"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+func (RecommendationConfig) TableName() string {
+	return "seva_recommendation_rules"
+}
+
+// DefaultRecommendationRules apply until a temple saves its own
+func DefaultRecommendationRules() RecommendationRules {
+	return RecommendationRules{
+		Weights: RecommendationWeights{
+			Nakshatra:  3,
+			Rashi:      2,
+			BirthStar:  4,
+			Birthday:   3,
+			SpecialDay: 2,
+			History:    1.5,
+			Preference: 1,
+		},
+		NakshatraSevas: map[string][]string{},
+		RashiSevas:     map[string][]string{},
+		BirthdaySevas:  []string{"Archana", "Abhishekam"},
+		LookaheadDays:  defaultLookahead,
+	}
+}
+
+// SevaRecommendation is one scored seva with the reasons behind its score
+type SevaRecommendation struct {
+	Seva          Seva     `json:"seva"`
+	Score         float64  `json:"score"`
+	Reasons       []string `json:"reasons"`
+	SuggestedDate string   `json:"suggested_date,omitempty"` // YYYY-MM-DD of the best matching day
+}
+
+// devoteeAstroProfile is the part of the devotee profile recommendations use
+type devoteeAstroProfile struct {
+	Nakshatra     *string
+	Rashi         *string
+	DOB           *time.Time `gorm:"serializer:encrypted"`
+	SevaAbhisheka *bool
+	SevaArti      *bool
+	SevaAnnadana  *bool
+	SevaArchana   *bool
+	SevaKalyanam  *bool
+	SevaHomam     *bool
+}
+
+// normalize validates the rules and fills in defaults
+func (r *RecommendationRules) normalize() error {
+	w := r.Weights
+	for name, v := range map[string]float64{
+		"nakshatra": w.Nakshatra, "rashi": w.Rashi, "birth_star": w.BirthStar, "birthday": w.Birthday,
+		"special_day": w.SpecialDay, "history": w.History, "preference": w.Preference,
+	} {
+		if v < 0 {
+			return fmt.Errorf("%w: weights.%s must not be negative", ErrInvalidRecommendationRules, name)
+		}
+	}
+	if r.LookaheadDays == 0 {
+		r.LookaheadDays = defaultLookahead
+	}
+	if r.LookaheadDays < 1 || r.LookaheadDays > maxLookahead {
+		return fmt.Errorf("%w: lookahead_days must be between 1 and %d", ErrInvalidRecommendationRules, maxLookahead)
+	}
+	if r.NakshatraSevas == nil {
+		r.NakshatraSevas = map[string][]string{}
+	}
+	if r.RashiSevas == nil {
+		r.RashiSevas = map[string][]string{}
+	}
+	for i := range r.SpecialDays {
+		d := &r.SpecialDays[i]
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" {
+			return fmt.Errorf("%w: special_days[%d].name is required", ErrInvalidRecommendationRules, i)
+		}
+		if _, err := time.Parse(specialDayLayout, d.Date); err != nil {
+			return fmt.Errorf("%w: special_days[%d].date must be YYYY-MM-DD", ErrInvalidRecommendationRules, i)
+		}
+		d.Nakshatra = strings.TrimSpace(d.Nakshatra)
+	}
+	sort.SliceStable(r.SpecialDays, func(i, j int) bool { return r.SpecialDays[i].Date < r.SpecialDays[j].Date })
+	return nil
+}
+
+// ========== Repository ==========
+
+func (r *repository) GetRecommendationRules(ctx context.Context, entityID uint) (*RecommendationConfig, error) {
+	var cfg RecommendationConfig
+	err := r.db.WithContext(ctx).Where("entity_id = ?", entityID).First(&cfg).Error
+	if err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+func (r *repository) SaveRecommendationRules(ctx context.Context, cfg *RecommendationConfig) error {
+	return r.db.WithContext(ctx).Save(cfg).Error
+}
+
+// GetDevoteeAstroProfile prefers the devotee's profile at this temple and
+// falls back to their most recently updated one
+func (r *repository) GetDevoteeAstroProfile(ctx context.Context, userID, entityID uint) (*devoteeAstroProfile, error) {
+	var p devoteeAstroProfile
+	res := r.db.WithContext(ctx).
+		Table("devotee_profiles").
+		Select("nakshatra, rashi, dob, seva_abhisheka, seva_arti, seva_annadana, seva_archana, seva_kalyanam, seva_homam").
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL: "entity_id = ? DESC, updated_at DESC", Vars: []interface{}{entityID}, WithoutParentheses: true,
+		}}).
+		Limit(1).
+		Scan(&p)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+// CountBookedSevaTypes counts the devotee's approved and pending bookings per
+// lowercased seva type
+func (r *repository) CountBookedSevaTypes(ctx context.Context, userID uint) (map[string]int, error) {
+	var rows []struct {
+		SevaType string
+		Bookings int
+	}
+	err := r.db.WithContext(ctx).
+		Table("seva_bookings AS b").
+		Select("LOWER(s.seva_type) AS seva_type, COUNT(*) AS bookings").
+		Joins("JOIN sevas s ON s.id = b.seva_id").
+		Where("b.user_id = ? AND b.status IN ?", userID, []string{"approved", "pending"}).
+		Group("LOWER(s.seva_type)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(rows))
+	for _, row := range rows {
+		counts[row.SevaType] = row.Bookings
+	}
+	return counts, nil
+}
+
+// ========== Service ==========
+
+// GetRecommendationRules returns the temple's rules, or the defaults
+func (s *service) GetRecommendationRules(ctx context.Context, entityID uint) (RecommendationRules, error) {
+	cfg, err := s.repo.GetRecommendationRules(ctx, entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return DefaultRecommendationRules(), nil
+	}
+	if err != nil {
+		return RecommendationRules{}, err
+	}
+	var rules RecommendationRules
+	if err := json.Unmarshal(cfg.Rules, &rules); err != nil {
+		return RecommendationRules{}, err
+	}
+	if err := rules.normalize(); err != nil {
+		return RecommendationRules{}, err
+	}
+	return rules, nil
+}
+
+// UpdateRecommendationRules validates and stores the temple's rules
+func (s *service) UpdateRecommendationRules(ctx context.Context, entityID uint, rules RecommendationRules, userID uint, ip string) (RecommendationRules, error) {
+	if err := rules.normalize(); err != nil {
+		return RecommendationRules{}, err
+	}
+	raw, err := json.Marshal(rules)
+	if err != nil {
+		return RecommendationRules{}, err
+	}
+	cfg := &RecommendationConfig{EntityID: entityID, Rules: raw, UpdatedBy: userID}
+	if err := s.repo.SaveRecommendationRules(ctx, cfg); err != nil {
+		return RecommendationRules{}, err
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_RECOMMENDATION_RULES_UPDATED", map[string]interface{}{
+		"nakshatra_mappings": len(rules.NakshatraSevas),
+		"rashi_mappings":     len(rules.RashiSevas),
+		"special_days":       len(rules.SpecialDays),
+		"lookahead_days":     rules.LookaheadDays,
+	}, ip, "success")
+	return rules, nil
+}
+
+// RecommendSevas returns the temple's open sevas that match the devotee,
+// best first. Sevas nothing matches are left out.
+func (s *service) RecommendSevas(ctx context.Context, userID, entityID uint, limit int) ([]SevaRecommendation, error) {
+	if limit <= 0 || limit > 50 {
+		limit = defaultRecommends
+	}
+	rules, err := s.GetRecommendationRules(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.repo.GetDevoteeAstroProfile(ctx, userID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if profile == nil {
+		profile = &devoteeAstroProfile{}
+	}
+	history, err := s.repo.CountBookedSevaTypes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sevas, err := s.repo.ListSevasByEntityID(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	sc := newRecommendationScorer(rules, profile, history, today)
+
+	var out []SevaRecommendation
+	for _, sv := range sevas {
+		if !sevaOpen(&sv, today) {
+			continue
+		}
+		if rec := sc.score(sv); rec.Score > 0 {
+			out = append(out, rec)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Seva.ID < out[j].Seva.ID
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+// sevaOpen reports whether a devotee can still book the seva: active, not
+// completed, not in the past and, for dated sevas, not full
+func sevaOpen(sv *Seva, today time.Time) bool {
+	if !sv.IsActive || sv.Status == "completed" {
+		return false
+	}
+	if sv.Date == "" {
+		return true
+	}
+	date, err := time.ParseInLocation(sevaDateLayout, sv.Date, time.Local)
+	if err != nil || date.Before(today) {
+		return false
+	}
+	return sv.AvailableSlots <= 0 || sv.RemainingSlots > 0
+}
+
+// upcomingDay is a special day or birthday within the lookahead window
+type upcomingDay struct {
+	date      time.Time
+	label     string
+	sevaTypes []string
+	birthStar bool // the devotee's nakshatra falls on this day
+	birthday  bool
+}
+
+type recommendationScorer struct {
+	rules     RecommendationRules
+	nakshatra string
+	rashi     string
+	prefs     []string // keywords of ticked seva preferences
+	history   map[string]int
+	days      []upcomingDay
+}
+
+func newRecommendationScorer(rules RecommendationRules, p *devoteeAstroProfile, history map[string]int, today time.Time) *recommendationScorer {
+	sc := &recommendationScorer{rules: rules, history: history}
+	if p.Nakshatra != nil {
+		sc.nakshatra = strings.TrimSpace(*p.Nakshatra)
+	}
+	if p.Rashi != nil {
+		sc.rashi = strings.TrimSpace(*p.Rashi)
+	}
+	for _, pref := range []struct {
+		set      *bool
+		keywords []string
+	}{
+		{p.SevaAbhisheka, []string{"abhishek"}},
+		{p.SevaArti, []string{"arti", "aarti", "aarathi", "arathi"}},
+		{p.SevaAnnadana, []string{"annadan"}},
+		{p.SevaArchana, []string{"archana"}},
+		{p.SevaKalyanam, []string{"kalyan"}},
+		{p.SevaHomam, []string{"homa", "havan"}},
+	} {
+		if pref.set != nil && *pref.set {
+			sc.prefs = append(sc.prefs, pref.keywords...)
+		}
+	}
+
+	end := today.AddDate(0, 0, rules.LookaheadDays)
+	for _, d := range rules.SpecialDays {
+		date, err := time.ParseInLocation(specialDayLayout, d.Date, time.Local)
+		if err != nil || date.Before(today) || date.After(end) {
+			continue
+		}
+		sc.days = append(sc.days, upcomingDay{
+			date:      date,
+			label:     d.Name,
+			sevaTypes: d.SevaTypes,
+			birthStar: sc.nakshatra != "" && strings.EqualFold(d.Nakshatra, sc.nakshatra),
+		})
+	}
+	if p.DOB != nil {
+		birthday := time.Date(today.Year(), p.DOB.Month(), p.DOB.Day(), 0, 0, 0, 0, time.Local)
+		if birthday.Before(today) {
+			birthday = birthday.AddDate(1, 0, 0)
+		}
+		if !birthday.After(end) {
+			sc.days = append(sc.days, upcomingDay{date: birthday, label: "your birthday", sevaTypes: rules.BirthdaySevas, birthday: true})
+		}
+	}
+	sort.SliceStable(sc.days, func(i, j int) bool { return sc.days[i].date.Before(sc.days[j].date) })
+	return sc
+}
+
+func (sc *recommendationScorer) score(sv Seva) SevaRecommendation {
+	rec := SevaRecommendation{Seva: sv, Reasons: []string{}}
+	w := sc.rules.Weights
+	add := func(weight float64, reason string) {
+		if weight <= 0 {
+			return
+		}
+		rec.Score += weight
+		rec.Reasons = append(rec.Reasons, reason)
+	}
+
+	if sc.nakshatra != "" && matchesSeva(sv, lookupFold(sc.rules.NakshatraSevas, sc.nakshatra)) {
+		add(w.Nakshatra, fmt.Sprintf("Recommended for your nakshatra, %s", sc.nakshatra))
+	}
+	if sc.rashi != "" && matchesSeva(sv, lookupFold(sc.rules.RashiSevas, sc.rashi)) {
+		add(w.Rashi, fmt.Sprintf("Recommended for your rashi, %s", sc.rashi))
+	}
+
+	// Days the seva is held on: its own date, or any day for undated sevas
+	var sevaDate *time.Time
+	if sv.Date != "" {
+		if d, err := time.ParseInLocation(sevaDateLayout, sv.Date, time.Local); err == nil {
+			sevaDate = &d
+		}
+	}
+	var birthStarDone, birthdayDone, specialDone bool
+	for _, day := range sc.days {
+		if sevaDate != nil && !sevaDate.Equal(day.date) {
+			continue
+		}
+		when := day.date.Format("Mon, 2 Jan")
+		suited := matchesSeva(sv, day.sevaTypes)
+		switch {
+		case day.birthStar && !birthStarDone && (sevaDate != nil || suited):
+			birthStarDone = true
+			add(w.BirthStar, fmt.Sprintf("Available on your birth star day (%s) on %s", sc.nakshatra, when))
+		case day.birthday && !birthdayDone && suited:
+			birthdayDone = true
+			add(w.Birthday, fmt.Sprintf("A fitting offering for your birthday on %s", when))
+		case !day.birthStar && !day.birthday && !specialDone && suited:
+			specialDone = true
+			add(w.SpecialDay, fmt.Sprintf("Suited for %s on %s", day.label, when))
+		default:
+			continue
+		}
+		if rec.SuggestedDate == "" {
+			rec.SuggestedDate = day.date.Format(specialDayLayout)
+		}
+	}
+
+	if n := sc.history[strings.ToLower(sv.SevaType)]; n > 0 {
+		add(w.History*float64(min(n, historyCap))/historyCap, fmt.Sprintf("You have booked %s %d time(s) before", sv.SevaType, n))
+	}
+	if containsKeyword(sv, sc.prefs) {
+		add(w.Preference, "Matches the seva preferences in your profile")
+	}
+	return rec
+}
+
+// matchesSeva reports whether names lists the seva's type or name
+func matchesSeva(sv Seva, names []string) bool {
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n != "" && (strings.EqualFold(n, sv.SevaType) || strings.EqualFold(n, sv.Name)) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKeyword(sv Seva, keywords []string) bool {
+	text := strings.ToLower(sv.SevaType + " " + sv.Name)
+	for _, k := range keywords {
+		if strings.Contains(text, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupFold finds a map entry ignoring case and surrounding spaces
+func lookupFold(m map[string][]string, key string) []string {
+	if v, ok := m[key]; ok {
+		return v
+	}
+	for k, v := range m {
+		if strings.EqualFold(strings.TrimSpace(k), key) {
+			return v
+		}
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"gorm.io/datatypes"
 	"gorm.io/gorm"
 )
 
@@ -29,9 +30,20 @@ type Repository interface {
 	IncrementBookedSlots(ctx context.Context, sevaID uint) error
 	DecrementBookedSlots(ctx context.Context, sevaID uint) error
 
+	// Transactional slot allocation (seva row locked FOR UPDATE)
+	CreateBookingWithinCapacity(ctx context.Context, booking *SevaBooking, joinWaitlist bool, afterCreate func(tx *gorm.DB) error) error
+	TransitionBooking(ctx context.Context, bookingID uint, newStatus string) (string, []SevaBooking, error)
+	WaitlistPosition(ctx context.Context, booking *SevaBooking) (int64, error)
+
+	// Cancellation, rescheduling and refunds (cancellation.go)
+	RecordCancellation(ctx context.Context, bookingID, cancelledBy uint, reason, refundStatus string) error
+	UpdateRefundStatus(ctx context.Context, bookingID uint, status string) error
+	RescheduleBooking(ctx context.Context, bookingID, targetSevaID uint, bookingTime time.Time, formData datatypes.JSON) (string, []SevaBooking, error)
+
 	// Booking limits
 	CountBookingsForSlot(ctx context.Context, sevaID uint, date time.Time, slot string) (int64, error)
 	CountApprovedBookingsForSeva(ctx context.Context, sevaID uint) (int64, error)
+	CountApprovedBookingsForSevaDay(ctx context.Context, sevaID uint, from, to *time.Time) (int64, error)
 	GetApprovedBookingsCountPerSeva(ctx context.Context, entityID uint) (map[uint]int64, error)
 
 	// Composite list with Seva + User info
@@ -42,6 +54,21 @@ type Repository interface {
 	CountBookingsByStatus(ctx context.Context, entityID uint) (BookingStatusCounts, error)
 
 	ListPaginatedSevas(ctx context.Context, entityID uint, sevaType string, search string, limit int, offset int) ([]Seva, error)
+
+	// Recommendations (recommend.go)
+	GetRecommendationRules(ctx context.Context, entityID uint) (*RecommendationConfig, error)
+	SaveRecommendationRules(ctx context.Context, cfg *RecommendationConfig) error
+	GetDevoteeAstroProfile(ctx context.Context, userID, entityID uint) (*devoteeAstroProfile, error)
+	CountBookedSevaTypes(ctx context.Context, userID uint) (map[string]int, error)
+
+	// Templates, generated sevas and bulk activation (template.go)
+	CreateSevaTemplate(ctx context.Context, t *SevaTemplate) error
+	GetSevaTemplateByID(ctx context.Context, id uint) (*SevaTemplate, error)
+	ListSevaTemplates(ctx context.Context, entityID uint) ([]SevaTemplate, error)
+	UpdateSevaTemplate(ctx context.Context, t *SevaTemplate) error
+	DeleteSevaTemplate(ctx context.Context, id uint) error
+	CreateSevas(ctx context.Context, sevas []Seva) error
+	SetSevasActive(ctx context.Context, entityID uint, ids []uint, active bool) (int64, error)
 }
 
 type repository struct {
@@ -226,6 +253,19 @@ func (r *repository) CountApprovedBookingsForSeva(ctx context.Context, sevaID ui
 	return count, err
 }
 
+// Count approved bookings for a seva, optionally limited to a booking_time window [from, to)
+func (r *repository) CountApprovedBookingsForSevaDay(ctx context.Context, sevaID uint, from, to *time.Time) (int64, error) {
+	var count int64
+	q := r.db.WithContext(ctx).
+		Model(&SevaBooking{}).
+		Where("seva_id = ? AND status = ?", sevaID, "approved")
+	if from != nil && to != nil {
+		q = q.Where("booking_time >= ? AND booking_time < ?", *from, *to)
+	}
+	err := q.Count(&count).Error
+	return count, err
+}
+
 // Get approved bookings count per seva for an entity
 func (r *repository) GetApprovedBookingsCountPerSeva(ctx context.Context, entityID uint) (map[uint]int64, error) {
 	type Result struct {
@@ -262,7 +302,7 @@ type DetailedBooking struct {
 	SevaName     string `json:"seva_name"`
 	SevaType     string `json:"seva_type"`
 	DevoteeName  string `json:"devotee_name"`
-	DevoteePhone string `json:"devotee_phone"`
+	DevoteePhone string `json:"devotee_phone" gorm:"serializer:encrypted"`
 }
 
 func (r *repository) ListBookingsWithDetails(ctx context.Context, entityID uint) ([]DetailedBooking, error) {
@@ -386,6 +426,8 @@ func (r *repository) CountBookingsByStatus(ctx context.Context, entityID uint) (
 	counts.Approved = statusCounts["approved"]
 	counts.Pending = statusCounts["pending"]
 	counts.Rejected = statusCounts["rejected"]
+	counts.Waitlisted = statusCounts["waitlisted"]
+	counts.Cancelled = statusCounts["cancelled"]
 
 	return counts, nil
 }
\ No newline at end of file
@@ -2,12 +2,16 @@ package seva
 
 import (
     "context"
+    "encoding/json"
     "errors"
     "fmt"
+    "strings"
     "time"
 
     "github.com/sharath018/temple-management-backend/internal/auditlog"
+    "github.com/sharath018/temple-management-backend/internal/coupon"
     "github.com/sharath018/temple-management-backend/internal/notification"
+    "github.com/sharath018/temple-management-backend/internal/panchang"
     "github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -23,7 +27,11 @@ type Service interface {
     GetSevasWithFilters(ctx context.Context, entityID uint, sevaType, search, status string, limit, offset int) ([]Seva, int64, error)
 
     // Booking Core
-    BookSeva(ctx context.Context, booking *SevaBooking, userRole string, userID uint, entityID uint, ip string) error
+    BookSeva(ctx context.Context, booking *SevaBooking, userRole string, userID uint, entityID uint, formData map[string]interface{}, joinWaitlist bool, ip string) error
+    GetWaitlistPosition(ctx context.Context, booking *SevaBooking) (int64, error)
+    CancelBooking(ctx context.Context, bookingID uint, actor BookingActor, req CancelBookingRequest, ip string) error
+    RescheduleBooking(ctx context.Context, bookingID uint, actor BookingActor, req RescheduleBookingRequest, ip string) (*SevaBooking, error)
+    UpdateRefundStatus(ctx context.Context, bookingID uint, actor BookingActor, req RefundStatusRequest, ip string) (*SevaBooking, error)
     GetBookingsForUser(ctx context.Context, userID uint) ([]SevaBooking, error)
     GetBookingsForEntity(ctx context.Context, entityID uint) ([]SevaBooking, error)
     UpdateBookingStatus(ctx context.Context, bookingID uint, newStatus string, userID uint, ip string) error
@@ -46,13 +54,40 @@ type Service interface {
     // Get approved booking counts per seva
     GetApprovedBookingCountsPerSeva(ctx context.Context, entityID uint) (map[uint]int64, error)
 
+    // Weighted seva recommendations for devotees and the per-temple rules behind them
+    RecommendSevas(ctx context.Context, userID, entityID uint, limit int) ([]SevaRecommendation, error)
+    GetRecommendationRules(ctx context.Context, entityID uint) (RecommendationRules, error)
+    UpdateRecommendationRules(ctx context.Context, entityID uint, rules RecommendationRules, userID uint, ip string) (RecommendationRules, error)
+
+    // Seva templates, generating sevas from a template or an existing seva,
+    // and bulk activation
+    ListSevaTemplates(ctx context.Context, entityID uint) ([]SevaTemplate, error)
+    GetSevaTemplate(ctx context.Context, entityID, id uint) (*SevaTemplate, error)
+    CreateSevaTemplate(ctx context.Context, entityID uint, req SevaTemplateRequest, userID uint, ip string) (*SevaTemplate, error)
+    UpdateSevaTemplate(ctx context.Context, entityID, id uint, req SevaTemplateRequest, userID uint, ip string) (*SevaTemplate, error)
+    DeleteSevaTemplate(ctx context.Context, entityID, id uint, userID uint, ip string) error
+    GenerateSevas(ctx context.Context, entityID, templateID uint, req GenerateSevasRequest, userID uint, ip string) (*GenerateSevasResult, error)
+    CloneSeva(ctx context.Context, entityID, sevaID uint, req GenerateSevasRequest, userID uint, ip string) (*GenerateSevasResult, error)
+    BulkUpdateSevaStatus(ctx context.Context, entityID uint, req BulkSevaStatusRequest, userID uint, ip string) ([]uint, error)
+
     SetNotifService(n notification.Service)
+    SetMailer(m *notification.Mailer)
+    SetMessenger(m *notification.Messenger)
+    SetCouponService(c *coupon.Service)
+
+    // Redis booking counters (fast path for capacity checks)
+    SetBookingCounter(c *BookingCounter)
+    BookingCounterMetrics() CounterMetrics
 }
 
 type service struct {
-    repo     Repository
-    auditSvc auditlog.Service
-    notifSvc notification.Service
+    repo      Repository
+    auditSvc  auditlog.Service
+    notifSvc  notification.Service
+    mailer    *notification.Mailer
+    messenger *notification.Messenger
+    counter   *BookingCounter
+    coupons   *coupon.Service
 }
 
 func NewService(repo Repository, auditSvc auditlog.Service) Service {
@@ -66,6 +101,25 @@ func (s *service) SetNotifService(n notification.Service) {
     s.notifSvc = n
 }
 
+func (s *service) SetMailer(m *notification.Mailer) {
+    s.mailer = m
+}
+
+func (s *service) SetMessenger(m *notification.Messenger) {
+    s.messenger = m
+}
+
+func (s *service) SetBookingCounter(c *BookingCounter) {
+    s.counter = c
+}
+
+func (s *service) BookingCounterMetrics() CounterMetrics {
+    if s.counter == nil {
+        return CounterMetrics{}
+    }
+    return s.counter.Metrics()
+}
+
 func (s *service) CreateSeva(ctx context.Context, seva *Seva, accessContext middleware.AccessContext, ip string) error {
     if !accessContext.CanWrite() {
         s.auditSvc.LogAction(ctx, &accessContext.UserID, accessContext.GetAccessibleEntityID(), "SEVA_CREATE_FAILED", map[string]interface{}{
@@ -97,6 +151,7 @@ func (s *service) CreateSeva(ctx context.Context, seva *Seva, accessContext midd
     }
 
     seva.EntityID = *entityID
+    applyPanchang(seva)
     // ✅ UPDATED: Initialize slot fields
     seva.BookedSlots = 0
     seva.RemainingSlots = seva.AvailableSlots
@@ -138,6 +193,19 @@ func (s *service) CreateSeva(ctx context.Context, seva *Seva, accessContext midd
     return nil
 }
 
+// applyPanchang tags a dated seva with the tithi, nakshatra and observances
+// of its date
+func applyPanchang(seva *Seva) {
+    seva.Tithi, seva.Paksha, seva.Nakshatra, seva.PanchangTags = "", "", "", nil
+    date, err := time.Parse("02-01-2006", strings.TrimSpace(seva.Date))
+    if err != nil {
+        return
+    }
+    day := panchang.ForDate(date)
+    seva.Tithi, seva.Paksha, seva.Nakshatra = day.Tithi, day.Paksha, day.Nakshatra
+    seva.PanchangTags, _ = json.Marshal(day.Tags)
+}
+
 func (s *service) UpdateSeva(ctx context.Context, seva *Seva, accessContext middleware.AccessContext, ip string) error {
     if !accessContext.CanWrite() {
         s.auditSvc.LogAction(ctx, &accessContext.UserID, accessContext.GetAccessibleEntityID(), "SEVA_UPDATE_FAILED", map[string]interface{}{
@@ -168,6 +236,8 @@ func (s *service) UpdateSeva(ctx context.Context, seva *Seva, accessContext midd
         }
     }
 
+    applyPanchang(seva)
+
     // ✅ UPDATED: Recalculate remaining slots before update
     seva.RemainingSlots = seva.AvailableSlots - seva.BookedSlots
     if seva.RemainingSlots < 0 {
@@ -311,8 +381,10 @@ func (s *service) GetSevasWithFilters(ctx context.Context, entityID uint, sevaTy
     return s.repo.GetSevasWithFilters(ctx, entityID, sevaType, search, status, limit, offset)
 }
 
-// ✅ UPDATED: BookSeva with slot availability check using RemainingSlots
-func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole string, userID uint, entityID uint, ip string) error {
+// BookSeva creates a pending booking while the seva has free slots. When it
+// is full the booking joins the waitlist if joinWaitlist is set, otherwise
+// ErrSevaFull is returned. formData must satisfy the seva's booking form.
+func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole string, userID uint, entityID uint, formData map[string]interface{}, joinWaitlist bool, ip string) error {
     if userRole != "devotee" {
         s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
             "reason":  "unauthorized access",
@@ -343,27 +415,45 @@ func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole s
         return errors.New("seva is not available for booking")
     }
 
-    // ✅ CRITICAL: Check remaining slots (booking will be pending, not yet approved)
-    // We only check if there are available slots, approval will increment BookedSlots
-    if seva.AvailableSlots > 0 && seva.RemainingSlots <= 0 {
+    // Validate the seva specific booking details
+    fields, err := seva.BookingFormFields()
+    if err != nil {
+        return err
+    }
+    booking.FormData, err = validateBookingForm(fields, formData)
+    if err != nil {
         s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
-            "seva_id":         booking.SevaID,
-            "seva_name":       seva.Name,
-            "reason":          "no slots available",
-            "available_slots": seva.AvailableSlots,
-            "booked_slots":    seva.BookedSlots,
-            "remaining_slots": seva.RemainingSlots,
+            "seva_id":   booking.SevaID,
+            "seva_name": seva.Name,
+            "reason":    "invalid booking form",
+            "error":     err.Error(),
         }, ip, "failure")
-        return errors.New("no slots available for this seva")
+        return err
+    }
+
+    // Fast rejection when the Redis day counter already shows every slot
+    // approved. The locked transaction below is what enforces capacity.
+    if count, ok := s.counter.Count(ctx, seva, time.Now()); ok && !joinWaitlist &&
+        seva.AvailableSlots > 0 && int(count) >= seva.AvailableSlots {
+        s.logSevaFull(ctx, userID, entityID, seva, ip)
+        return ErrSevaFull
     }
 
     booking.UserID = userID
     booking.EntityID = entityID
     booking.BookingTime = time.Now()
-    booking.Status = "pending"
 
-    // Create booking
-    err = s.repo.BookSeva(ctx, booking)
+    redeemCoupon, err := s.couponRedeemer(booking, seva)
+    if err != nil {
+        return err
+    }
+
+    // Create the booking as pending, or waitlisted when the seva is full
+    err = s.repo.CreateBookingWithinCapacity(ctx, booking, joinWaitlist, redeemCoupon)
+    if errors.Is(err, ErrSevaFull) {
+        s.logSevaFull(ctx, userID, entityID, seva, ip)
+        return err
+    }
     if err != nil {
         s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
             "seva_id":   booking.SevaID,
@@ -373,6 +463,27 @@ func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole s
         return err
     }
 
+    if booking.Status == "waitlisted" {
+        s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_WAITLISTED", map[string]interface{}{
+            "booking_id":      booking.ID,
+            "seva_id":         booking.SevaID,
+            "seva_name":       seva.Name,
+            "available_slots": seva.AvailableSlots,
+        }, ip, "success")
+
+        if s.notifSvc != nil {
+            _ = s.notifSvc.CreateInAppNotification(
+                ctx,
+                userID,
+                entityID,
+                "Added to Waitlist",
+                seva.Name+" is full. You are on the waitlist and will be notified when a slot opens up",
+                "seva",
+            )
+        }
+        return nil
+    }
+
     s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKED", map[string]interface{}{
         "booking_id":      booking.ID,
         "seva_id":         booking.SevaID,
@@ -383,6 +494,8 @@ func (s *service) BookSeva(ctx context.Context, booking *SevaBooking, userRole s
         "available_slots": seva.AvailableSlots,
         "booked_slots":    seva.BookedSlots,
         "remaining_slots": seva.RemainingSlots,
+        "coupon_code":     booking.CouponCode,
+        "discount_amount": booking.DiscountAmount,
     }, ip, "success")
 
     if s.notifSvc != nil {
@@ -441,60 +554,53 @@ func (s *service) UpdateBookingStatus(ctx context.Context, bookingID uint, newSt
 
     oldStatus := booking.Status
 
-    // ✅ CRITICAL: Handle slot management based on status transitions
-    // Case 1: Approving a booking (pending/rejected -> approved)
-    if newStatus == "approved" && oldStatus != "approved" {
-        // Check if slots are available
-        if seva.RemainingSlots <= 0 {
-            s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
-                "booking_id":      bookingID,
-                "new_status":      newStatus,
-                "reason":          "no slots available",
-                "available_slots": seva.AvailableSlots,
-                "booked_slots":    seva.BookedSlots,
-                "remaining_slots": seva.RemainingSlots,
-            }, ip, "failure")
-            return errors.New("no slots available for this seva")
-        }
-
-        // Increment booked slots and decrement remaining slots
-        if err := s.repo.IncrementBookedSlots(ctx, booking.SevaID); err != nil {
-            s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
-                "booking_id": bookingID,
-                "new_status": newStatus,
-                "reason":     "failed to update slots",
-                "error":      err.Error(),
-            }, ip, "failure")
-            return fmt.Errorf("failed to update slots: %v", err)
+    // Approving takes a slot: the Redis day counter rejects quickly when the
+    // seva is full, the locked transaction in TransitionBooking is authoritative
+    reserved := false
+    if newStatus == "approved" && oldStatus != "approved" && seva.AvailableSlots > 0 {
+        ok, handled := s.counter.Reserve(ctx, seva, booking.BookingTime)
+        if handled && !ok {
+            s.logApprovalFull(ctx, userID, booking, seva, newStatus, ip)
+            return ErrSevaFull
         }
+        reserved = handled
     }
 
-    // Case 2: Rejecting/Canceling an approved booking (approved -> rejected/pending)
-    if oldStatus == "approved" && newStatus != "approved" {
-        // Decrement booked slots and increment remaining slots
-        if err := s.repo.DecrementBookedSlots(ctx, booking.SevaID); err != nil {
-            s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
-                "booking_id": bookingID,
-                "new_status": newStatus,
-                "reason":     "failed to update slots",
-                "error":      err.Error(),
-            }, ip, "failure")
-            return fmt.Errorf("failed to update slots: %v", err)
-        }
-    }
-
-    // Update booking status
-    err = s.repo.UpdateBookingStatus(ctx, bookingID, newStatus)
+    previous, promoted, err := s.repo.TransitionBooking(ctx, bookingID, newStatus)
     if err != nil {
+        if reserved {
+            s.counter.Release(ctx, seva, booking.BookingTime)
+        }
+        if errors.Is(err, ErrSevaFull) {
+            s.logApprovalFull(ctx, userID, booking, seva, newStatus, ip)
+            return err
+        }
         s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
             "booking_id": bookingID,
             "seva_id":    booking.SevaID,
             "new_status": newStatus,
             "error":      err.Error(),
         }, ip, "failure")
-        return err
+        return fmt.Errorf("failed to update booking: %v", err)
+    }
+
+    // The status may have changed since it was read above; go by the one
+    // seen under the lock
+    if reserved && previous == "approved" {
+        s.counter.Release(ctx, seva, booking.BookingTime) // Already approved, nothing was taken
+    }
+    oldStatus = previous
+
+    // An approved booking was cancelled/rejected: give its slot back
+    if oldStatus == "approved" && newStatus != "approved" {
+        s.counter.Release(ctx, seva, booking.BookingTime)
+    }
+    if newStatus == "rejected" || newStatus == "cancelled" {
+        s.releaseCoupon(ctx, booking)
     }
 
+    s.notifyPromoted(ctx, userID, seva, promoted, ip)
+
     action := "SEVA_BOOKING_STATUS_UPDATED"
     switch newStatus {
     case "approved":
@@ -538,9 +644,61 @@ func (s *service) UpdateBookingStatus(ctx context.Context, bookingID uint, newSt
         )
     }
 
+    if newStatus == "approved" && oldStatus != "approved" {
+        s.emailBookingConfirmation(booking, seva)
+        s.textBookingConfirmation(booking, seva)
+    }
+
     return nil
 }
 
+// emailBookingConfirmation emails the devotee once their booking is approved,
+// in the background so a slow mail server doesn't hold up the temple staff
+func (s *service) emailBookingConfirmation(booking *SevaBooking, seva *Seva) {
+    if s.mailer == nil || seva == nil {
+        return
+    }
+    data := map[string]interface{}{
+        "SevaName":  seva.Name,
+        "BookingID": booking.ID,
+        "SevaDate":  seva.Date,
+        "SevaTime":  seva.StartTime,
+        "Amount":    "",
+    }
+    if seva.Date == "" {
+        data["SevaDate"] = booking.BookingTime.Format("02-01-2006")
+    }
+    if seva.Price > 0 {
+        data["Amount"] = fmt.Sprintf("%.2f", seva.Price)
+    }
+    go func() {
+        if err := s.mailer.SendToUser(context.Background(), booking.EntityID, booking.UserID, notification.EmailBookingConfirmation, data); err != nil {
+            fmt.Printf("⚠️ Failed to email booking confirmation %d: %v\n", booking.ID, err)
+        }
+    }()
+}
+
+// textBookingConfirmation sends the confirmation over WhatsApp or SMS too,
+// for devotees who don't use the app or read email
+func (s *service) textBookingConfirmation(booking *SevaBooking, seva *Seva) {
+    if s.messenger == nil || seva == nil {
+        return
+    }
+    date := seva.Date
+    if date == "" {
+        date = booking.BookingTime.Format("02-01-2006")
+    }
+    body := fmt.Sprintf("Your booking #%d for %s on %s is confirmed.", booking.ID, seva.Name, date)
+    if seva.StartTime != "" {
+        body = fmt.Sprintf("Your booking #%d for %s on %s at %s is confirmed.", booking.ID, seva.Name, date, seva.StartTime)
+    }
+    go func() {
+        if err := s.messenger.SendToUser(context.Background(), booking.EntityID, booking.UserID, notification.MessageBookingConfirmation, body); err != nil {
+            fmt.Printf("⚠️ Failed to text booking confirmation %d: %v\n", booking.ID, err)
+        }
+    }()
+}
+
 func (s *service) GetDetailedBookingsForEntity(ctx context.Context, entityID uint) ([]DetailedBooking, error) {
     return s.repo.ListBookingsWithDetails(ctx, entityID)
 }
@@ -583,4 +741,65 @@ func (s *service) GetPaginatedSevas(ctx context.Context, entityID uint, sevaType
 // Get approved booking counts per seva
 func (s *service) GetApprovedBookingCountsPerSeva(ctx context.Context, entityID uint) (map[uint]int64, error) {
     return s.repo.GetApprovedBookingsCountPerSeva(ctx, entityID)
-}
\ No newline at end of file
+}
+
+// GetWaitlistPosition returns the queue position of a waitlisted booking
+func (s *service) GetWaitlistPosition(ctx context.Context, booking *SevaBooking) (int64, error) {
+    return s.repo.WaitlistPosition(ctx, booking)
+}
+
+func (s *service) logSevaFull(ctx context.Context, userID, entityID uint, seva *Seva, ip string) {
+    s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_BOOKING_FAILED", map[string]interface{}{
+        "seva_id":         seva.ID,
+        "seva_name":       seva.Name,
+        "reason":          "no slots available",
+        "available_slots": seva.AvailableSlots,
+        "booked_slots":    seva.BookedSlots,
+        "remaining_slots": seva.RemainingSlots,
+    }, ip, "failure")
+}
+
+func (s *service) logApprovalFull(ctx context.Context, userID uint, booking *SevaBooking, seva *Seva, newStatus, ip string) {
+    s.auditSvc.LogAction(ctx, &userID, &booking.EntityID, "SEVA_BOOKING_STATUS_UPDATE_FAILED", map[string]interface{}{
+        "booking_id":      booking.ID,
+        "new_status":      newStatus,
+        "reason":          "no slots available",
+        "available_slots": seva.AvailableSlots,
+        "booked_slots":    seva.BookedSlots,
+        "remaining_slots": seva.RemainingSlots,
+    }, ip, "failure")
+}
+
+// notifyPromoted tells devotees moved off the waitlist that their booking is
+// now pending approval
+func (s *service) notifyPromoted(ctx context.Context, userID uint, seva *Seva, promoted []SevaBooking, ip string) {
+    for _, b := range promoted {
+        s.auditSvc.LogAction(ctx, &userID, &b.EntityID, "SEVA_WAITLIST_PROMOTED", map[string]interface{}{
+            "booking_id": b.ID,
+            "seva_id":    b.SevaID,
+            "seva_name":  seva.Name,
+            "devotee_id": b.UserID,
+        }, ip, "success")
+
+        if s.notifSvc != nil {
+            _ = s.notifSvc.CreateInAppNotification(
+                ctx,
+                b.UserID,
+                b.EntityID,
+                "Seva Slot Available",
+                "A slot opened up for "+seva.Name+". Your booking is now pending approval",
+                "seva",
+            )
+            // Push is best effort, the devotee may not have a device registered
+            _ = s.notifSvc.SendPushNotification(
+                ctx,
+                userID,
+                b.EntityID,
+                "Seva Slot Available",
+                "A slot opened up for "+seva.Name+". Your booking is now pending approval",
+                []uint{b.UserID},
+                ip,
+            )
+        }
+    }
+}
@@ -0,0 +1,225 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrSevaFull is returned when every slot of the seva's service day is held
+	ErrSevaFull = errors.New("no slots available for this seva")
+
+	ErrNotBookingOwner       = errors.New("you can only cancel your own bookings")
+	ErrBookingNotCancellable = errors.New("only pending, approved or waitlisted bookings can be cancelled")
+)
+
+// holdsSlot reports whether a booking in this status takes up capacity.
+// Pending bookings hold their slot until an admin approves or rejects them.
+func holdsSlot(status string) bool {
+	return status == "pending" || status == "approved"
+}
+
+// bookingWindow limits slot counting to the booking's service day for sevas
+// without a fixed date; dated sevas count every booking
+func bookingWindow(seva *Seva, bookingTime time.Time) (*time.Time, *time.Time) {
+	if seva.Date != "" {
+		return nil, nil
+	}
+	from := serviceDay(seva, bookingTime)
+	to := from.AddDate(0, 0, 1)
+	return &from, &to
+}
+
+func bookingsInWindow(tx *gorm.DB, seva *Seva, bookingTime time.Time) *gorm.DB {
+	q := tx.Model(&SevaBooking{}).Where("seva_id = ?", seva.ID)
+	if from, to := bookingWindow(seva, bookingTime); from != nil {
+		q = q.Where("booking_time >= ? AND booking_time < ?", *from, *to)
+	}
+	return q
+}
+
+// lockSeva loads the seva holding its row lock, which serialises slot
+// allocation for it until the transaction ends
+func lockSeva(tx *gorm.DB, sevaID uint) (*Seva, error) {
+	var seva Seva
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&seva, sevaID).Error; err != nil {
+		return nil, err
+	}
+	return &seva, nil
+}
+
+// CreateBookingWithinCapacity inserts the booking as pending when a slot is
+// free, as waitlisted when the seva is full and joinWaitlist is set, and
+// fails with ErrSevaFull otherwise. Sevas without slots configured are
+// unlimited. afterCreate, when set, runs in the same transaction once the
+// booking has its ID, e.g. to redeem a coupon on it.
+func (r *repository) CreateBookingWithinCapacity(ctx context.Context, booking *SevaBooking, joinWaitlist bool, afterCreate func(tx *gorm.DB) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		seva, err := lockSeva(tx, booking.SevaID)
+		if err != nil {
+			return err
+		}
+
+		booking.Status = "pending"
+		if seva.AvailableSlots > 0 {
+			var held, waiting int64
+			if err := bookingsInWindow(tx, seva, booking.BookingTime).
+				Where("status IN ?", []string{"pending", "approved"}).
+				Count(&held).Error; err != nil {
+				return err
+			}
+			if err := bookingsInWindow(tx, seva, booking.BookingTime).
+				Where("status = ?", "waitlisted").
+				Count(&waiting).Error; err != nil {
+				return err
+			}
+
+			// A free slot goes to the waitlist first, never to a newcomer
+			if held >= int64(seva.AvailableSlots) || waiting > 0 {
+				if !joinWaitlist {
+					return ErrSevaFull
+				}
+				booking.Status = "waitlisted"
+			}
+		}
+
+		if err := tx.Create(booking).Error; err != nil {
+			return err
+		}
+		if afterCreate != nil {
+			return afterCreate(tx)
+		}
+		return nil
+	})
+}
+
+// TransitionBooking changes a booking's status under the seva lock. Approval
+// fails with ErrSevaFull once the day's approved bookings reach capacity.
+// It returns the status the booking had. When the change frees a slot, the
+// longest waiting bookings are moved to pending and returned.
+func (r *repository) TransitionBooking(ctx context.Context, bookingID uint, newStatus string) (string, []SevaBooking, error) {
+	var (
+		booking   SevaBooking
+		oldStatus string
+		promoted  []SevaBooking
+	)
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+		seva, err := lockSeva(tx, booking.SevaID)
+		if err != nil {
+			return err
+		}
+		// Re-read under the lock so concurrent updates see each other's status
+		if err := tx.First(&booking, bookingID).Error; err != nil {
+			return err
+		}
+		oldStatus = booking.Status
+
+		if newStatus == "approved" && oldStatus != "approved" {
+			if seva.AvailableSlots > 0 {
+				var approved int64
+				if err := bookingsInWindow(tx, seva, booking.BookingTime).
+					Where("status = ?", "approved").
+					Count(&approved).Error; err != nil {
+					return err
+				}
+				if approved >= int64(seva.AvailableSlots) {
+					return ErrSevaFull
+				}
+			}
+			if err := tx.Model(&Seva{}).Where("id = ?", seva.ID).Updates(map[string]interface{}{
+				"booked_slots":    gorm.Expr("booked_slots + ?", 1),
+				"remaining_slots": gorm.Expr("GREATEST(remaining_slots - 1, 0)"),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if oldStatus == "approved" && newStatus != "approved" {
+			if err := tx.Model(&Seva{}).Where("id = ?", seva.ID).Updates(map[string]interface{}{
+				"booked_slots":    gorm.Expr("GREATEST(booked_slots - 1, 0)"),
+				"remaining_slots": gorm.Expr("remaining_slots + ?", 1),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Model(&SevaBooking{}).Where("id = ?", booking.ID).Update("status", newStatus).Error; err != nil {
+			return err
+		}
+		booking.Status = newStatus
+
+		if holdsSlot(oldStatus) && !holdsSlot(newStatus) {
+			promoted, err = promoteWaitlist(tx, seva, booking.BookingTime)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	return oldStatus, promoted, nil
+}
+
+// promoteWaitlist moves waitlisted bookings to pending, oldest first, while
+// the service day has free slots
+func promoteWaitlist(tx *gorm.DB, seva *Seva, bookingTime time.Time) ([]SevaBooking, error) {
+	if seva.AvailableSlots <= 0 {
+		return nil, nil
+	}
+	var held int64
+	if err := bookingsInWindow(tx, seva, bookingTime).
+		Where("status IN ?", []string{"pending", "approved"}).
+		Count(&held).Error; err != nil {
+		return nil, err
+	}
+	free := int(int64(seva.AvailableSlots) - held)
+	if free <= 0 {
+		return nil, nil
+	}
+
+	var next []SevaBooking
+	if err := bookingsInWindow(tx, seva, bookingTime).
+		Where("status = ?", "waitlisted").
+		Order("booking_time ASC, id ASC").
+		Limit(free).
+		Find(&next).Error; err != nil {
+		return nil, err
+	}
+	if len(next) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uint, len(next))
+	for i := range next {
+		ids[i] = next[i].ID
+		next[i].Status = "pending"
+	}
+	if err := tx.Model(&SevaBooking{}).Where("id IN ?", ids).Update("status", "pending").Error; err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// WaitlistPosition returns the 1-based queue position of a waitlisted booking
+func (r *repository) WaitlistPosition(ctx context.Context, booking *SevaBooking) (int64, error) {
+	if booking.Status != "waitlisted" {
+		return 0, nil
+	}
+	seva, err := r.GetSevaByID(ctx, booking.SevaID)
+	if err != nil {
+		return 0, err
+	}
+	var ahead int64
+	err = bookingsInWindow(r.db.WithContext(ctx), seva, booking.BookingTime).
+		Where("status = ? AND (booking_time < ? OR (booking_time = ? AND id < ?))",
+			"waitlisted", booking.BookingTime, booking.BookingTime, booking.ID).
+		Count(&ahead).Error
+	return ahead + 1, err
+}
@@ -0,0 +1,543 @@
+package seva
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/event"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// Seva templates hold the details of a seva that repeats, e.g. the daily
+// archana. Generating from a template, or cloning an existing seva, creates
+// one dated seva per day of a date range or recurrence rule. A date that
+// already has a seva of the same name at an overlapping time is a conflict:
+// the request fails unless the caller asks to skip those dates.
+
+const (
+	sevaTimeLayout    = "15:04" // Seva.StartTime and EndTime
+	maxGeneratedSevas = 366
+)
+
+var (
+	ErrInvalidSevaTemplate    = errors.New("invalid seva template")
+	ErrInvalidGenerateRequest = errors.New("invalid generate request")
+	ErrInvalidBulkStatus      = errors.New("invalid bulk status request")
+	ErrSevaConflicts          = errors.New("sevas already exist on some of the dates")
+	ErrSevaAccessDenied       = errors.New("access denied to this seva")
+)
+
+// SevaTemplate is a temple's reusable seva definition
+type SevaTemplate struct {
+	ID                      uint           `gorm:"primaryKey" json:"id"`
+	EntityID                uint           `gorm:"not null;index" json:"entity_id"`
+	Name                    string         `gorm:"type:varchar(255);not null" json:"name"`
+	SevaType                string         `gorm:"type:varchar(50);not null" json:"seva_type"`
+	Description             string         `gorm:"type:text" json:"description"`
+	Price                   float64        `gorm:"type:decimal(10,2);default:0" json:"price"`
+	StartTime               string         `gorm:"type:varchar(10)" json:"start_time"` // HH:mm
+	EndTime                 string         `gorm:"type:varchar(10)" json:"end_time"`   // HH:mm
+	Duration                int            `json:"duration"`                           // in minutes
+	AvailableSlots          int            `gorm:"default:0" json:"available_slots"`
+	CancellationWindowHours int            `gorm:"default:0" json:"cancellation_window_hours"`
+	FormFields              datatypes.JSON `gorm:"type:jsonb" json:"form_fields,omitempty"`
+	CreatedBy               uint           `json:"created_by"`
+	CreatedAt               time.Time      `json:"created_at"`
+	UpdatedAt               time.Time      `json:"updated_at"`
+}
+
+func (SevaTemplate) TableName() string {
+	return "seva_templates"
+}
+
+// SevaTemplateRequest creates a template or replaces one
+type SevaTemplateRequest struct {
+	Name                    string             `json:"name" binding:"required"`
+	SevaType                string             `json:"seva_type" binding:"required"`
+	Description             string             `json:"description"`
+	Price                   float64            `json:"price"`
+	StartTime               string             `json:"start_time"`
+	EndTime                 string             `json:"end_time"`
+	Duration                int                `json:"duration"`
+	AvailableSlots          int                `json:"available_slots"`
+	CancellationWindowHours int                `json:"cancellation_window_hours"`
+	FormFields              []BookingFormField `json:"form_fields"`
+}
+
+// GenerateSevasRequest picks the dates to create sevas on: every day from
+// start_date to end_date, or the dates of recurrence_rule starting at
+// start_date. end_date may be left out when the rule has UNTIL or COUNT.
+type GenerateSevasRequest struct {
+	StartDate      string `json:"start_date" binding:"required"` // DD-MM-YYYY
+	EndDate        string `json:"end_date"`                      // DD-MM-YYYY, inclusive
+	RecurrenceRule string `json:"recurrence_rule"`               // e.g. FREQ=WEEKLY;BYDAY=MO,FR
+	SkipConflicts  bool   `json:"skip_conflicts"`                // create the other dates instead of failing
+	DryRun         bool   `json:"dry_run"`                       // report what would be created
+	Inactive       bool   `json:"inactive"`                      // create the sevas hidden from devotees
+}
+
+// SevaConflict is an existing seva that a generated one would duplicate
+type SevaConflict struct {
+	Date      string `json:"date"`
+	SevaID    uint   `json:"seva_id"`
+	Name      string `json:"name"`
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// GenerateSevasResult lists the sevas created, or on a dry run the sevas
+// that would be, and the dates left out for conflicts
+type GenerateSevasResult struct {
+	TemplateID   *uint          `json:"template_id,omitempty"`
+	SourceSevaID *uint          `json:"source_seva_id,omitempty"`
+	DryRun       bool           `json:"dry_run"`
+	Dates        []string       `json:"dates"`
+	Sevas        []Seva         `json:"sevas"`
+	Conflicts    []SevaConflict `json:"conflicts"`
+}
+
+// BulkSevaStatusRequest activates or deactivates the temple's sevas picked by
+// id, by template and/or by date range
+type BulkSevaStatusRequest struct {
+	SevaIDs    []uint `json:"seva_ids"`
+	TemplateID *uint  `json:"template_id"`
+	StartDate  string `json:"start_date"` // DD-MM-YYYY, inclusive
+	EndDate    string `json:"end_date"`   // DD-MM-YYYY, inclusive
+	IsActive   *bool  `json:"is_active" binding:"required"`
+}
+
+// toTemplate validates the request
+func (req SevaTemplateRequest) toTemplate() (*SevaTemplate, error) {
+	t := &SevaTemplate{
+		Name:                    strings.TrimSpace(req.Name),
+		SevaType:                strings.TrimSpace(req.SevaType),
+		Description:             req.Description,
+		Price:                   req.Price,
+		StartTime:               strings.TrimSpace(req.StartTime),
+		EndTime:                 strings.TrimSpace(req.EndTime),
+		Duration:                req.Duration,
+		AvailableSlots:          req.AvailableSlots,
+		CancellationWindowHours: req.CancellationWindowHours,
+	}
+	switch {
+	case t.Name == "" || t.SevaType == "":
+		return nil, fmt.Errorf("%w: name and seva_type are required", ErrInvalidSevaTemplate)
+	case t.Price < 0:
+		return nil, fmt.Errorf("%w: price cannot be negative", ErrInvalidSevaTemplate)
+	case t.Duration < 0 || t.AvailableSlots < 0 || t.CancellationWindowHours < 0:
+		return nil, fmt.Errorf("%w: duration, available_slots and cancellation_window_hours cannot be negative", ErrInvalidSevaTemplate)
+	}
+	for _, v := range []string{t.StartTime, t.EndTime} {
+		if _, err := time.Parse(sevaTimeLayout, v); v != "" && err != nil {
+			return nil, fmt.Errorf("%w: start_time and end_time must be HH:mm", ErrInvalidSevaTemplate)
+		}
+	}
+	if t.StartTime != "" && t.EndTime != "" && t.EndTime <= t.StartTime {
+		return nil, fmt.Errorf("%w: end_time must be after start_time", ErrInvalidSevaTemplate)
+	}
+	formFields, err := encodeFormFields(req.FormFields)
+	if err != nil {
+		return nil, err
+	}
+	t.FormFields = formFields
+	return t, nil
+}
+
+// templateFromSeva copies the details of an existing seva for cloning
+func templateFromSeva(sv *Seva) *SevaTemplate {
+	return &SevaTemplate{
+		EntityID:                sv.EntityID,
+		Name:                    sv.Name,
+		SevaType:                sv.SevaType,
+		Description:             sv.Description,
+		Price:                   sv.Price,
+		StartTime:               sv.StartTime,
+		EndTime:                 sv.EndTime,
+		Duration:                sv.Duration,
+		AvailableSlots:          sv.AvailableSlots,
+		CancellationWindowHours: sv.CancellationWindowHours,
+		FormFields:              sv.FormFields,
+	}
+}
+
+// instance is the template's seva on date
+func (t *SevaTemplate) instance(date time.Time, templateID *uint, active bool) Seva {
+	sv := Seva{
+		EntityID:                t.EntityID,
+		TemplateID:              templateID,
+		Name:                    t.Name,
+		SevaType:                t.SevaType,
+		Description:             t.Description,
+		Price:                   t.Price,
+		Date:                    date.Format(sevaDateLayout),
+		StartTime:               t.StartTime,
+		EndTime:                 t.EndTime,
+		Duration:                t.Duration,
+		AvailableSlots:          t.AvailableSlots,
+		RemainingSlots:          t.AvailableSlots,
+		CancellationWindowHours: t.CancellationWindowHours,
+		FormFields:              t.FormFields,
+		Status:                  "upcoming",
+		IsActive:                active,
+	}
+	applyPanchang(&sv)
+	return sv
+}
+
+// dates expands the request into the dates to create sevas on
+func (req GenerateSevasRequest) dates() ([]time.Time, error) {
+	start, err := time.Parse(sevaDateLayout, strings.TrimSpace(req.StartDate))
+	if err != nil {
+		return nil, fmt.Errorf("%w: start_date must be DD-MM-YYYY", ErrInvalidGenerateRequest)
+	}
+	var end time.Time
+	if strings.TrimSpace(req.EndDate) != "" {
+		if end, err = time.Parse(sevaDateLayout, strings.TrimSpace(req.EndDate)); err != nil {
+			return nil, fmt.Errorf("%w: end_date must be DD-MM-YYYY", ErrInvalidGenerateRequest)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("%w: end_date is before start_date", ErrInvalidGenerateRequest)
+		}
+	}
+
+	rule := &event.Recurrence{Freq: event.FreqDaily, Interval: 1}
+	if strings.TrimSpace(req.RecurrenceRule) != "" {
+		if rule, err = event.ParseRecurrence(req.RecurrenceRule); err != nil {
+			return nil, err
+		}
+	}
+	if end.IsZero() {
+		if rule.Until == nil && rule.Count == 0 {
+			return nil, fmt.Errorf("%w: end_date is required unless recurrence_rule has UNTIL or COUNT", ErrInvalidGenerateRequest)
+		}
+		end = start.AddDate(0, 0, maxGeneratedSevas*7)
+	}
+
+	dates := rule.Dates(start, end)
+	switch {
+	case len(dates) == 0:
+		return nil, fmt.Errorf("%w: no dates match", ErrInvalidGenerateRequest)
+	case len(dates) > maxGeneratedSevas:
+		return nil, fmt.Errorf("%w: at most %d sevas can be generated at once", ErrInvalidGenerateRequest, maxGeneratedSevas)
+	}
+	return dates, nil
+}
+
+// sevasConflict reports whether two sevas on the same day duplicate each
+// other: the same name at overlapping times. Sevas without a time window
+// conflict when their start times match.
+func sevasConflict(a, b *Seva) bool {
+	if a.Date != b.Date || !strings.EqualFold(strings.TrimSpace(a.Name), strings.TrimSpace(b.Name)) {
+		return false
+	}
+	as, err1 := time.Parse(sevaTimeLayout, a.StartTime)
+	ae, err2 := time.Parse(sevaTimeLayout, a.EndTime)
+	bs, err3 := time.Parse(sevaTimeLayout, b.StartTime)
+	be, err4 := time.Parse(sevaTimeLayout, b.EndTime)
+	if err1 != nil || err2 != nil || err3 != nil || err4 != nil {
+		return a.StartTime == b.StartTime
+	}
+	return as.Before(be) && bs.Before(ae)
+}
+
+// ========== Repository ==========
+
+func (r *repository) CreateSevaTemplate(ctx context.Context, t *SevaTemplate) error {
+	return r.db.WithContext(ctx).Create(t).Error
+}
+
+func (r *repository) GetSevaTemplateByID(ctx context.Context, id uint) (*SevaTemplate, error) {
+	var t SevaTemplate
+	if err := r.db.WithContext(ctx).First(&t, id).Error; err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (r *repository) ListSevaTemplates(ctx context.Context, entityID uint) ([]SevaTemplate, error) {
+	var templates []SevaTemplate
+	err := r.db.WithContext(ctx).Where("entity_id = ?", entityID).Order("name ASC").Find(&templates).Error
+	return templates, err
+}
+
+func (r *repository) UpdateSevaTemplate(ctx context.Context, t *SevaTemplate) error {
+	return r.db.WithContext(ctx).Save(t).Error
+}
+
+// DeleteSevaTemplate removes the template; sevas generated from it stay and
+// lose the link
+func (r *repository) DeleteSevaTemplate(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&Seva{}).Where("template_id = ?", id).Update("template_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&SevaTemplate{}, id).Error
+	})
+}
+
+// CreateSevas creates the sevas in one transaction
+func (r *repository) CreateSevas(ctx context.Context, sevas []Seva) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(sevas, 100).Error; err != nil {
+			return err
+		}
+		// is_active defaults to true, so gorm leaves false out of the insert
+		var inactive []uint
+		for _, sv := range sevas {
+			if !sv.IsActive {
+				inactive = append(inactive, sv.ID)
+			}
+		}
+		if len(inactive) == 0 {
+			return nil
+		}
+		return tx.Model(&Seva{}).Where("id IN ?", inactive).Update("is_active", false).Error
+	})
+}
+
+// SetSevasActive activates or deactivates the temple's sevas with the ids
+func (r *repository) SetSevasActive(ctx context.Context, entityID uint, ids []uint, active bool) (int64, error) {
+	res := r.db.WithContext(ctx).Model(&Seva{}).
+		Where("entity_id = ? AND id IN ?", entityID, ids).
+		Updates(map[string]interface{}{"is_active": active, "updated_at": time.Now()})
+	return res.RowsAffected, res.Error
+}
+
+// ========== Service ==========
+
+func (s *service) ListSevaTemplates(ctx context.Context, entityID uint) ([]SevaTemplate, error) {
+	return s.repo.ListSevaTemplates(ctx, entityID)
+}
+
+// GetSevaTemplate returns the temple's template; other temples' templates
+// are not found
+func (s *service) GetSevaTemplate(ctx context.Context, entityID, id uint) (*SevaTemplate, error) {
+	t, err := s.repo.GetSevaTemplateByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if t.EntityID != entityID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return t, nil
+}
+
+func (s *service) CreateSevaTemplate(ctx context.Context, entityID uint, req SevaTemplateRequest, userID uint, ip string) (*SevaTemplate, error) {
+	t, err := req.toTemplate()
+	if err != nil {
+		return nil, err
+	}
+	t.EntityID = entityID
+	t.CreatedBy = userID
+	if err := s.repo.CreateSevaTemplate(ctx, t); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_TEMPLATE_CREATED", map[string]interface{}{
+		"template_id": t.ID,
+		"name":        t.Name,
+		"seva_type":   t.SevaType,
+	}, ip, "success")
+	return t, nil
+}
+
+func (s *service) UpdateSevaTemplate(ctx context.Context, entityID, id uint, req SevaTemplateRequest, userID uint, ip string) (*SevaTemplate, error) {
+	existing, err := s.GetSevaTemplate(ctx, entityID, id)
+	if err != nil {
+		return nil, err
+	}
+	t, err := req.toTemplate()
+	if err != nil {
+		return nil, err
+	}
+	t.ID, t.EntityID, t.CreatedBy, t.CreatedAt = existing.ID, existing.EntityID, existing.CreatedBy, existing.CreatedAt
+	if err := s.repo.UpdateSevaTemplate(ctx, t); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_TEMPLATE_UPDATED", map[string]interface{}{
+		"template_id": t.ID,
+		"name":        t.Name,
+	}, ip, "success")
+	return t, nil
+}
+
+func (s *service) DeleteSevaTemplate(ctx context.Context, entityID, id uint, userID uint, ip string) error {
+	t, err := s.GetSevaTemplate(ctx, entityID, id)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteSevaTemplate(ctx, id); err != nil {
+		return err
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVA_TEMPLATE_DELETED", map[string]interface{}{
+		"template_id": id,
+		"name":        t.Name,
+	}, ip, "success")
+	return nil
+}
+
+// GenerateSevas creates the template's sevas on the requested dates
+func (s *service) GenerateSevas(ctx context.Context, entityID, templateID uint, req GenerateSevasRequest, userID uint, ip string) (*GenerateSevasResult, error) {
+	t, err := s.GetSevaTemplate(ctx, entityID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	result := &GenerateSevasResult{TemplateID: &t.ID}
+	return result, s.generate(ctx, t, &t.ID, req, result, userID, ip)
+}
+
+// CloneSeva copies an existing seva onto the requested dates. The copies
+// keep the source's template, if it had one.
+func (s *service) CloneSeva(ctx context.Context, entityID, sevaID uint, req GenerateSevasRequest, userID uint, ip string) (*GenerateSevasResult, error) {
+	source, err := s.repo.GetSevaByID(ctx, sevaID)
+	if err != nil {
+		return nil, err
+	}
+	if source.EntityID != entityID {
+		return nil, ErrSevaAccessDenied
+	}
+	result := &GenerateSevasResult{SourceSevaID: &source.ID}
+	return result, s.generate(ctx, templateFromSeva(source), source.TemplateID, req, result, userID, ip)
+}
+
+// generate fills in result and, unless it is a dry run, creates the sevas.
+// Conflicts fail the request with ErrSevaConflicts unless skip_conflicts is
+// set; the result still lists them.
+func (s *service) generate(ctx context.Context, t *SevaTemplate, templateID *uint, req GenerateSevasRequest, result *GenerateSevasResult, userID uint, ip string) error {
+	dates, err := req.dates()
+	if err != nil {
+		return err
+	}
+	existing, err := s.repo.ListSevasByEntityID(ctx, t.EntityID)
+	if err != nil {
+		return err
+	}
+	byDate := make(map[string][]Seva, len(existing))
+	for _, sv := range existing {
+		byDate[sv.Date] = append(byDate[sv.Date], sv)
+	}
+
+	result.DryRun = req.DryRun
+	result.Dates = make([]string, 0, len(dates))
+	result.Sevas = []Seva{}
+	result.Conflicts = []SevaConflict{}
+	for _, d := range dates {
+		sv := t.instance(d, templateID, !req.Inactive)
+		result.Dates = append(result.Dates, sv.Date)
+		conflicted := false
+		for _, other := range byDate[sv.Date] {
+			if sevasConflict(&sv, &other) {
+				conflicted = true
+				result.Conflicts = append(result.Conflicts, SevaConflict{
+					Date: other.Date, SevaID: other.ID, Name: other.Name, StartTime: other.StartTime, EndTime: other.EndTime,
+				})
+			}
+		}
+		if !conflicted {
+			result.Sevas = append(result.Sevas, sv)
+		}
+	}
+	sort.SliceStable(result.Conflicts, func(i, j int) bool { return result.Conflicts[i].SevaID < result.Conflicts[j].SevaID })
+
+	if len(result.Conflicts) > 0 && !req.SkipConflicts {
+		return ErrSevaConflicts
+	}
+	if req.DryRun || len(result.Sevas) == 0 {
+		return nil
+	}
+	if err := s.repo.CreateSevas(ctx, result.Sevas); err != nil {
+		s.auditSvc.LogAction(ctx, &userID, &t.EntityID, "SEVAS_GENERATE_FAILED", map[string]interface{}{
+			"template_id":    result.TemplateID,
+			"source_seva_id": result.SourceSevaID,
+			"error":          err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	s.auditSvc.LogAction(ctx, &userID, &t.EntityID, "SEVAS_GENERATED", map[string]interface{}{
+		"template_id":       result.TemplateID,
+		"source_seva_id":    result.SourceSevaID,
+		"name":              t.Name,
+		"created":           len(result.Sevas),
+		"skipped_conflicts": len(result.Dates) - len(result.Sevas),
+		"first_date":        result.Sevas[0].Date,
+		"last_date":         result.Sevas[len(result.Sevas)-1].Date,
+		"recurrence_rule":   req.RecurrenceRule,
+	}, ip, "success")
+
+	if s.notifSvc != nil && !req.Inactive {
+		_ = s.notifSvc.CreateInAppForEntityRoles(
+			ctx,
+			t.EntityID,
+			[]string{"devotee", "volunteer"},
+			"New Sevas",
+			fmt.Sprintf("%s has been scheduled on %d dates", t.Name, len(result.Sevas)),
+			"seva",
+		)
+	}
+	return nil
+}
+
+// BulkUpdateSevaStatus activates or deactivates the temple's sevas matching
+// every given filter and returns their ids
+func (s *service) BulkUpdateSevaStatus(ctx context.Context, entityID uint, req BulkSevaStatusRequest, userID uint, ip string) ([]uint, error) {
+	if req.IsActive == nil {
+		return nil, fmt.Errorf("%w: is_active is required", ErrInvalidBulkStatus)
+	}
+	if len(req.SevaIDs) == 0 && req.TemplateID == nil && req.StartDate == "" && req.EndDate == "" {
+		return nil, fmt.Errorf("%w: give seva_ids, template_id or a date range", ErrInvalidBulkStatus)
+	}
+	var from, to time.Time
+	var err error
+	if req.StartDate != "" {
+		if from, err = time.Parse(sevaDateLayout, strings.TrimSpace(req.StartDate)); err != nil {
+			return nil, fmt.Errorf("%w: start_date must be DD-MM-YYYY", ErrInvalidBulkStatus)
+		}
+	}
+	if req.EndDate != "" {
+		if to, err = time.Parse(sevaDateLayout, strings.TrimSpace(req.EndDate)); err != nil {
+			return nil, fmt.Errorf("%w: end_date must be DD-MM-YYYY", ErrInvalidBulkStatus)
+		}
+	}
+
+	wanted := make(map[uint]bool, len(req.SevaIDs))
+	for _, id := range req.SevaIDs {
+		wanted[id] = true
+	}
+	sevas, err := s.repo.ListSevasByEntityID(ctx, entityID)
+	if err != nil {
+		return nil, err
+	}
+	ids := []uint{}
+	for _, sv := range sevas {
+		if len(wanted) > 0 && !wanted[sv.ID] {
+			continue
+		}
+		if req.TemplateID != nil && (sv.TemplateID == nil || *sv.TemplateID != *req.TemplateID) {
+			continue
+		}
+		if !from.IsZero() || !to.IsZero() {
+			date, err := time.Parse(sevaDateLayout, strings.TrimSpace(sv.Date))
+			if err != nil || (!from.IsZero() && date.Before(from)) || (!to.IsZero() && date.After(to)) {
+				continue
+			}
+		}
+		ids = append(ids, sv.ID)
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	if _, err := s.repo.SetSevasActive(ctx, entityID, ids, *req.IsActive); err != nil {
+		return nil, err
+	}
+	s.auditSvc.LogAction(ctx, &userID, &entityID, "SEVAS_BULK_STATUS_UPDATED", map[string]interface{}{
+		"seva_ids":  ids,
+		"is_active": *req.IsActive,
+	}, ip, "success")
+	return ids, nil
+}
@@ -0,0 +1,447 @@
+package shop
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the shop endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new shop handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// ===== Catalog =====
+
+// ListProducts - GET /shop/products?entity_id=&category=prasadam&search=&page=1&limit=20
+// Devotees see the active products; temple staff see the whole catalog.
+func (h *Handler) ListProducts(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	category := c.Query("category")
+	if category != "" && category != CategoryPrasadam && category != CategoryMerchandise {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category must be prasadam or merchandise"})
+		return
+	}
+
+	f := ProductFilter{
+		EntityID:   entityID,
+		Category:   category,
+		Search:     strings.TrimSpace(c.Query("search")),
+		ActiveOnly: !access.IsEntityStaff(entityID),
+		Page:       httpx.PositiveQuery(c, "page", 1),
+		Limit:      min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	items, total, err := h.Service.Repo.ListProducts(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch products"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// CreateProduct - POST /shop/products
+func (h *Handler) CreateProduct(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	p, err := h.Service.CreateProduct(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to create product")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Product created", "data": p})
+}
+
+// UpdateProduct - PUT /shop/products/:id
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	access, p, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.UpdateProduct(c.Request.Context(), p, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update product")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Product updated", "data": p})
+}
+
+// DeleteProduct - DELETE /shop/products/:id
+func (h *Handler) DeleteProduct(c *gin.Context) {
+	access, p, ok := h.loadProduct(c)
+	if !ok {
+		return
+	}
+	if err := h.Service.DeleteProduct(c.Request.Context(), p, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to delete product")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Product deleted"})
+}
+
+// ===== Cart and checkout (devotee) =====
+
+// GetCart - GET /shop/cart?entity_id=
+func (h *Handler) GetCart(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	cart, err := h.Service.GetCart(c.Request.Context(), access.UserID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cart})
+}
+
+// SetCartItem - PUT /shop/cart/items
+// Body: {"product_id": 3, "quantity": 2}; quantity 0 removes the product.
+func (h *Handler) SetCartItem(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req CartItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	cart, err := h.Service.SetCartItem(c.Request.Context(), access.UserID, req)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to update cart")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": cart})
+}
+
+// ClearCart - DELETE /shop/cart?entity_id=
+func (h *Handler) ClearCart(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if err := h.Service.Repo.ClearCart(c.Request.Context(), access.UserID, entityID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared"})
+}
+
+// Checkout - POST /shop/checkout?entity_id=
+func (h *Handler) Checkout(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	var req CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	res, err := h.Service.Checkout(c.Request.Context(), access.UserID, entityID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to place order")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Order placed, complete the payment to confirm it", "data": res})
+}
+
+// VerifyPayment - POST /shop/orders/verify
+func (h *Handler) VerifyPayment(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var req VerifyPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	o, err := h.Service.VerifyPayment(c.Request.Context(), access.UserID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to verify payment")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Payment confirmed", "data": o})
+}
+
+// Webhook - POST /payments/shop/webhook (Razorpay payment events)
+func (h *Handler) Webhook(c *gin.Context) {
+	body, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unable to read request body"})
+		return
+	}
+
+	result, err := h.Service.HandleWebhook(donation.WebhookRequest{
+		Body:      body,
+		Signature: c.GetHeader("X-Razorpay-Signature"),
+		EventID:   c.GetHeader("X-Razorpay-Event-Id"),
+		IPAddress: middleware.GetIPFromContext(c),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, donation.ErrInvalidWebhookSignature):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		case errors.Is(err, donation.ErrWebhookNotConfigured):
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		default:
+			// Non-2xx makes Razorpay retry the delivery
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    result,
+		"success": true,
+	})
+}
+
+// ===== Orders =====
+
+// ListMine - GET /shop/orders/my?entity_id=
+func (h *Handler) ListMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	var entityID uint
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity_id"})
+			return
+		}
+		entityID = uint(id)
+	}
+
+	items, err := h.Service.Repo.ListByUser(c.Request.Context(), access.UserID, entityID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// Cancel - POST /shop/orders/:id/cancel (devotee, before paying)
+func (h *Handler) Cancel(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, ok := orderID(c)
+	if !ok {
+		return
+	}
+	o, err := h.Service.GetOwned(c.Request.Context(), id, access.UserID)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch order")
+		return
+	}
+	if err := h.Service.Cancel(c.Request.Context(), o, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to cancel order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled", "data": o})
+}
+
+// Get - GET /shop/orders/:id
+// The devotee who ordered or staff of the temple see the order with its
+// lines and tracking history.
+func (h *Handler) Get(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, ok := orderID(c)
+	if !ok {
+		return
+	}
+	o, err := h.Service.Repo.GetOrder(c.Request.Context(), id)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch order")
+		return
+	}
+	if o.UserID != access.UserID && !access.IsEntityStaff(o.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this order"})
+		return
+	}
+	detail, err := h.Service.Repo.GetDetail(c.Request.Context(), o)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": detail})
+}
+
+// ListByEntity - GET /shop/orders?status=paid&page=1&limit=20 (temple staff)
+func (h *Handler) ListByEntity(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", StatusPendingPayment, StatusExpired, StatusPaid, StatusPacked, StatusShipped, StatusDelivered, StatusCancelled:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+
+	f := OrderFilter{
+		EntityID: entityID,
+		Status:   status,
+		Page:     httpx.PositiveQuery(c, "page", 1),
+		Limit:    min(httpx.PositiveQuery(c, "limit", 20), 100),
+	}
+	items, total, err := h.Service.Repo.ListByEntity(c.Request.Context(), f)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  f.Page,
+		"limit": f.Limit,
+	})
+}
+
+// UpdateStatus - PATCH /shop/orders/:id/status
+// Body: {"status": "shipped", "courier": "India Post", "tracking_number": "EE123456789IN"}
+func (h *Handler) UpdateStatus(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, ok := orderID(c)
+	if !ok {
+		return
+	}
+	o, err := h.Service.Repo.GetOrder(c.Request.Context(), id)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch order")
+		return
+	}
+	if !access.CanManageEntity(o.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this order"})
+		return
+	}
+
+	var req StatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.UpdateStatus(c.Request.Context(), o, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update order")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Order updated", "data": o})
+}
+
+// loadProduct loads the :id product for a write by staff of its temple
+func (h *Handler) loadProduct(c *gin.Context) (middleware.AccessContext, *Product, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return access, nil, false
+	}
+	p, err := h.Service.Repo.GetProduct(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch product")
+		return access, nil, false
+	}
+	if !access.CanManageEntity(p.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this product"})
+		return access, nil, false
+	}
+	return access, p, true
+}
+
+func orderID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusForbidden:          {ErrNotOwner},
+		http.StatusBadRequest:         {ErrEmptyCart, ErrAddressRequired, ErrNotDeliverable, ErrInvalidSignature, ErrPaymentNotCaptured},
+		http.StatusConflict:           {ErrProductUnavailable, ErrOutOfStock, ErrInvalidStatus, ErrCannotCancel, ErrStatusChanged},
+		http.StatusServiceUnavailable: {ErrGatewayNotEnabled},
+	},
+}
@@ -0,0 +1,261 @@
+package shop
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Product categories
+const (
+	CategoryPrasadam    = "prasadam"
+	CategoryMerchandise = "merchandise"
+)
+
+// How an order reaches the devotee
+const (
+	FulfilmentDelivery = "delivery" // shipped to the devotee's address
+	FulfilmentPickup   = "pickup"   // collected at the temple counter
+)
+
+// Order statuses. A checkout waits for payment, then temple staff pack the
+// order and ship it, or hand it over at the counter for pickup orders.
+// Unpaid orders expire after paymentWindow and give their stock back.
+const (
+	StatusPendingPayment = "pending_payment"
+	StatusExpired        = "expired"
+	StatusPaid           = "paid"
+	StatusPacked         = "packed"
+	StatusShipped        = "shipped"
+	StatusDelivered      = "delivered"
+	StatusCancelled      = "cancelled"
+)
+
+// Product is an item of a temple's catalog
+type Product struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID    uint    `gorm:"not null;index" json:"entity_id"`
+	Name        string  `gorm:"size:150;not null" json:"name"`
+	Description string  `gorm:"type:text" json:"description,omitempty"`
+	Category    string  `gorm:"size:20;not null;index" json:"category"` // prasadam or merchandise
+	Price       float64 `gorm:"type:decimal(10,2);not null" json:"price"`
+	Stock       *int    `json:"stock"` // units left; nil for made to order
+	ImageURL    string  `gorm:"size:500" json:"image_url,omitempty"`
+	Deliverable bool    `gorm:"not null;default:true" json:"deliverable"` // false for pickup only, e.g. fresh prasadam
+	IsActive    bool    `gorm:"not null;default:true;index" json:"is_active"`
+
+	CreatedBy uint           `gorm:"not null" json:"created_by"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName returns the table name for the Product model
+func (Product) TableName() string {
+	return "shop_products"
+}
+
+// CartItem is a product in a devotee's cart. A devotee has one cart per
+// temple.
+type CartItem struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	UserID    uint `gorm:"not null;uniqueIndex:idx_shop_cart_user_product" json:"user_id"`
+	EntityID  uint `gorm:"not null;index" json:"entity_id"`
+	ProductID uint `gorm:"not null;uniqueIndex:idx_shop_cart_user_product" json:"product_id"`
+	Quantity  int  `gorm:"not null" json:"quantity"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the CartItem model
+func (CartItem) TableName() string {
+	return "shop_cart_items"
+}
+
+// Order is a devotee's checkout of their cart at a temple
+type Order struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	UserID     uint    `gorm:"not null;index" json:"user_id"`
+	EntityID   uint    `gorm:"not null;index" json:"entity_id"`
+	Status     string  `gorm:"size:20;not null;index" json:"status"`
+	Fulfilment string  `gorm:"size:20;not null" json:"fulfilment"` // delivery or pickup
+	Total      float64 `gorm:"type:decimal(10,2);not null" json:"total"`
+
+	// Delivery address, empty for pickup orders
+	ShippingName  string `gorm:"size:150" json:"shipping_name,omitempty"`
+	ShippingPhone string `gorm:"size:20" json:"shipping_phone,omitempty"`
+	AddressLine   string `gorm:"type:text" json:"address_line,omitempty"`
+	City          string `gorm:"size:100" json:"city,omitempty"`
+	State         string `gorm:"size:100" json:"state,omitempty"`
+	Pincode       string `gorm:"size:10" json:"pincode,omitempty"`
+	Note          string `gorm:"type:text" json:"note,omitempty"` // devotee's instructions
+
+	Courier        string `gorm:"size:100" json:"courier,omitempty"`
+	TrackingNumber string `gorm:"size:100" json:"tracking_number,omitempty"`
+
+	RazorpayOrderID string `gorm:"size:100;uniqueIndex;not null" json:"razorpay_order_id"`
+	PaymentID       string `gorm:"size:100;index" json:"payment_id,omitempty"`
+	Method          string `gorm:"size:50" json:"method,omitempty"`
+	Sandbox         bool   `gorm:"default:false;index" json:"sandbox"` // Paid through Razorpay test mode
+
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+	PackedAt     *time.Time `json:"packed_at,omitempty"`
+	ShippedAt    *time.Time `json:"shipped_at,omitempty"`
+	DeliveredAt  *time.Time `json:"delivered_at,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+	CancelReason string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Order model
+func (Order) TableName() string {
+	return "shop_orders"
+}
+
+// OrderItem is a product line of an order, priced when the order was placed
+type OrderItem struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	OrderID   uint    `gorm:"not null;index" json:"order_id"`
+	ProductID uint    `gorm:"not null;index" json:"product_id"`
+	Name      string  `gorm:"size:150;not null" json:"name"`
+	Category  string  `gorm:"size:20;not null" json:"category"`
+	Price     float64 `gorm:"type:decimal(10,2);not null" json:"price"`
+	Quantity  int     `gorm:"not null" json:"quantity"`
+	Amount    float64 `gorm:"type:decimal(10,2);not null" json:"amount"`
+}
+
+// TableName returns the table name for the OrderItem model
+func (OrderItem) TableName() string {
+	return "shop_order_items"
+}
+
+// StatusEvent is one step of an order's tracking history
+type StatusEvent struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	OrderID   uint      `gorm:"not null;index" json:"order_id"`
+	Status    string    `gorm:"size:20;not null" json:"status"`
+	Note      string    `gorm:"type:text" json:"note,omitempty"`
+	ActorID   *uint     `json:"actor_id,omitempty"` // nil for payment and expiry events
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// TableName returns the table name for the StatusEvent model
+func (StatusEvent) TableName() string {
+	return "shop_order_events"
+}
+
+// ProductRequest creates a product or replaces one
+type ProductRequest struct {
+	Name        string  `json:"name" binding:"required,max=150"`
+	Description string  `json:"description"`
+	Category    string  `json:"category" binding:"required,oneof=prasadam merchandise"`
+	Price       float64 `json:"price" binding:"gt=0"`
+	Stock       *int    `json:"stock" binding:"omitempty,gte=0"` // omit for made to order
+	ImageURL    string  `json:"image_url" binding:"omitempty,url,max=500"`
+	Deliverable *bool   `json:"deliverable"` // defaults to true
+	IsActive    *bool   `json:"is_active"`   // defaults to true
+}
+
+// CartItemRequest sets the quantity of a product in the cart; 0 removes it
+type CartItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"gte=0,lte=50"`
+}
+
+// CheckoutRequest places an order for the devotee's cart at a temple
+type CheckoutRequest struct {
+	Fulfilment    string `json:"fulfilment" binding:"required,oneof=delivery pickup"`
+	ShippingName  string `json:"shipping_name" binding:"max=150"`
+	ShippingPhone string `json:"shipping_phone" binding:"max=20"`
+	AddressLine   string `json:"address_line"`
+	City          string `json:"city" binding:"max=100"`
+	State         string `json:"state" binding:"max=100"`
+	Pincode       string `json:"pincode" binding:"max=10"`
+	Note          string `json:"note"`
+}
+
+// CheckoutResponse is what the client needs to open Razorpay checkout
+type CheckoutResponse struct {
+	Order       *Order  `json:"order"`
+	OrderID     string  `json:"order_id"` // Razorpay order ID
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	RazorpayKey string  `json:"razorpay_key"`
+	Sandbox     bool    `json:"sandbox"`
+}
+
+// VerifyPaymentRequest confirms a Razorpay checkout payment
+type VerifyPaymentRequest struct {
+	OrderID     string `json:"orderID" binding:"required"`     // Razorpay order ID
+	PaymentID   string `json:"paymentID" binding:"required"`   // Razorpay payment ID
+	RazorpaySig string `json:"razorpaySig" binding:"required"` // Signature to verify payment
+}
+
+// StatusRequest moves an order along as staff fulfil it
+type StatusRequest struct {
+	Status         string `json:"status" binding:"required,oneof=packed shipped delivered cancelled"`
+	Courier        string `json:"courier" binding:"max=100"`
+	TrackingNumber string `json:"tracking_number" binding:"max=100"`
+	Note           string `json:"note"` // shown to the devotee; the reason for a cancellation
+}
+
+// CartLine is a cart item with its product
+type CartLine struct {
+	CartItem
+	Name        string  `json:"name"`
+	Category    string  `json:"category"`
+	Price       float64 `json:"price"`
+	Stock       *int    `json:"stock"`
+	Deliverable bool    `json:"deliverable"`
+	ImageURL    string  `json:"image_url,omitempty"`
+	Available   bool    `json:"available"` // active and not deleted
+	Amount      float64 `json:"amount"`
+}
+
+// Cart is a devotee's cart at a temple
+type Cart struct {
+	EntityID uint       `json:"entity_id"`
+	Items    []CartLine `json:"items"`
+	Total    float64    `json:"total"`
+}
+
+// OrderDetail is an order with its lines and tracking history
+type OrderDetail struct {
+	Order
+	Items  []OrderItem   `json:"items"`
+	Events []StatusEvent `json:"events"`
+}
+
+// OrderWithBuyer is an order as temple staff see it
+type OrderWithBuyer struct {
+	Order
+	BuyerName  string `json:"buyer_name"`
+	BuyerEmail string `json:"buyer_email"`
+	ItemCount  int    `json:"item_count"`
+}
+
+// ProductFilter narrows the catalog
+type ProductFilter struct {
+	EntityID   uint
+	Category   string
+	Search     string
+	ActiveOnly bool
+	Page       int
+	Limit      int
+}
+
+// OrderFilter narrows a temple's orders
+type OrderFilter struct {
+	EntityID uint
+	Status   string
+	Page     int
+	Limit    int
+}
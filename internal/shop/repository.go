@@ -0,0 +1,312 @@
+package shop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	// ErrOrderSettled is returned when a payment arrives for an order that is
+	// already paid or was cancelled
+	ErrOrderSettled = errors.New("order already settled")
+	// ErrStatusChanged is returned when an order moved on since it was loaded
+	ErrStatusChanged = errors.New("order status changed, reload and try again")
+)
+
+// Repository reads and writes the catalog, carts and orders
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new shop repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// CreateProduct stores a new product
+func (r *Repository) CreateProduct(ctx context.Context, p *Product) error {
+	return r.DB.WithContext(ctx).Create(p).Error
+}
+
+// UpdateProduct saves every field of a product
+func (r *Repository) UpdateProduct(ctx context.Context, p *Product) error {
+	return r.DB.WithContext(ctx).Save(p).Error
+}
+
+// DeleteProduct soft-deletes a product and takes it out of carts; orders
+// keep their copy of it
+func (r *Repository) DeleteProduct(ctx context.Context, id uint) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", id).Delete(&CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Product{}, id).Error
+	})
+}
+
+// GetProduct loads a product
+func (r *Repository) GetProduct(ctx context.Context, id uint) (*Product, error) {
+	var p Product
+	if err := r.DB.WithContext(ctx).First(&p, id).Error; err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListProducts returns a temple's products by name and how many match f
+func (r *Repository) ListProducts(ctx context.Context, f ProductFilter) ([]Product, int64, error) {
+	query := r.DB.WithContext(ctx).Model(&Product{}).Where("entity_id = ?", f.EntityID)
+	if f.Category != "" {
+		query = query.Where("category = ?", f.Category)
+	}
+	if f.ActiveOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if f.Search != "" {
+		query = query.Where("name ILIKE ? OR description ILIKE ?", "%"+f.Search+"%", "%"+f.Search+"%")
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []Product
+	err := query.Order("name ASC, id ASC").Limit(f.Limit).Offset((f.Page - 1) * f.Limit).Find(&out).Error
+	return out, total, err
+}
+
+// GetCart returns the devotee's cart lines at a temple, oldest first
+func (r *Repository) GetCart(ctx context.Context, userID, entityID uint) ([]CartLine, error) {
+	var out []CartLine
+	err := r.DB.WithContext(ctx).Table("shop_cart_items ci").
+		Select(`ci.*, p.name, p.category, p.price, p.stock, p.deliverable, p.image_url,
+			(p.is_active AND p.deleted_at IS NULL) as available, p.price * ci.quantity as amount`).
+		Joins("JOIN shop_products p ON p.id = ci.product_id").
+		Where("ci.user_id = ? AND ci.entity_id = ?", userID, entityID).
+		Order("ci.created_at ASC, ci.id ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// SetCartItem adds a product to the cart or changes its quantity
+func (r *Repository) SetCartItem(ctx context.Context, item *CartItem) error {
+	return r.DB.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "product_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"quantity": item.Quantity, "updated_at": time.Now()}),
+	}).Create(item).Error
+}
+
+// RemoveCartItem takes a product out of the cart
+func (r *Repository) RemoveCartItem(ctx context.Context, userID, productID uint) error {
+	return r.DB.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID).Delete(&CartItem{}).Error
+}
+
+// ClearCart empties the devotee's cart at a temple
+func (r *Repository) ClearCart(ctx context.Context, userID, entityID uint) error {
+	return r.DB.WithContext(ctx).Where("user_id = ? AND entity_id = ?", userID, entityID).Delete(&CartItem{}).Error
+}
+
+// CreateOrder reserves stock for the lines and stores the order with its
+// first tracking event in one transaction. It returns ErrOutOfStock naming
+// the product when one has run out.
+func (r *Repository) CreateOrder(ctx context.Context, o *Order, items []OrderItem) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			res := tx.Model(&Product{}).
+				Where("id = ? AND stock IS NOT NULL AND stock >= ?", item.ProductID, item.Quantity).
+				Update("stock", gorm.Expr("stock - ?", item.Quantity))
+			if res.Error != nil {
+				return res.Error
+			}
+			if res.RowsAffected == 0 {
+				var tracked int64
+				if err := tx.Model(&Product{}).Where("id = ? AND stock IS NOT NULL", item.ProductID).Count(&tracked).Error; err != nil {
+					return err
+				}
+				if tracked > 0 {
+					return fmt.Errorf("%w: %s", ErrOutOfStock, item.Name)
+				}
+			}
+		}
+		if err := tx.Create(o).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].OrderID = o.ID
+		}
+		if err := tx.Create(&items).Error; err != nil {
+			return err
+		}
+		return tx.Create(&StatusEvent{OrderID: o.ID, Status: o.Status}).Error
+	})
+}
+
+// GetOrder loads an order
+func (r *Repository) GetOrder(ctx context.Context, id uint) (*Order, error) {
+	var o Order
+	if err := r.DB.WithContext(ctx).First(&o, id).Error; err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// GetByRazorpayOrder returns the order paid through a Razorpay order, or nil
+func (r *Repository) GetByRazorpayOrder(ctx context.Context, razorpayOrderID string) (*Order, error) {
+	var o Order
+	err := r.DB.WithContext(ctx).Where("razorpay_order_id = ?", razorpayOrderID).First(&o).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// GetDetail loads an order's lines and tracking history
+func (r *Repository) GetDetail(ctx context.Context, o *Order) (*OrderDetail, error) {
+	d := &OrderDetail{Order: *o}
+	if err := r.DB.WithContext(ctx).Where("order_id = ?", o.ID).Order("id ASC").Find(&d.Items).Error; err != nil {
+		return nil, err
+	}
+	if err := r.DB.WithContext(ctx).Where("order_id = ?", o.ID).Order("created_at ASC, id ASC").Find(&d.Events).Error; err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// ListByUser returns a devotee's orders, newest first; entityID 0 means every
+// temple. Expired checkouts are left out.
+func (r *Repository) ListByUser(ctx context.Context, userID, entityID uint) ([]Order, error) {
+	query := r.DB.WithContext(ctx).Where("user_id = ? AND status <> ?", userID, StatusExpired)
+	if entityID != 0 {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	var out []Order
+	err := query.Order("created_at DESC, id DESC").Find(&out).Error
+	return out, err
+}
+
+// ListByEntity returns a temple's orders with their buyers, newest first.
+// Without a status filter, unpaid and expired checkouts are left out.
+func (r *Repository) ListByEntity(ctx context.Context, f OrderFilter) ([]OrderWithBuyer, int64, error) {
+	query := r.DB.WithContext(ctx).Table("shop_orders o").Where("o.entity_id = ?", f.EntityID)
+	if f.Status != "" {
+		query = query.Where("o.status = ?", f.Status)
+	} else {
+		query = query.Where("o.status NOT IN ?", []string{StatusPendingPayment, StatusExpired})
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []OrderWithBuyer
+	err := query.
+		Select(`o.*, COALESCE(u.full_name, '') as buyer_name, COALESCE(u.email, '') as buyer_email,
+			(SELECT COALESCE(SUM(i.quantity), 0) FROM shop_order_items i WHERE i.order_id = o.id) as item_count`).
+		Joins("LEFT JOIN users u ON u.id = o.user_id").
+		Order("o.created_at DESC, o.id DESC").
+		Limit(f.Limit).Offset((f.Page - 1) * f.Limit).
+		Scan(&out).Error
+	return out, total, err
+}
+
+// MarkPaid records the payment of an order that is waiting for it, or that
+// expired before a late payment arrived, in which case its stock is taken
+// again. The paid products leave the devotee's cart. It returns the status
+// the order was in, or ErrOrderSettled when it no longer takes a payment.
+func (r *Repository) MarkPaid(ctx context.Context, o *Order, paymentID, method string, paidAt time.Time) (string, error) {
+	var from string
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var cur Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&cur, o.ID).Error; err != nil {
+			return err
+		}
+		if cur.Status != StatusPendingPayment && cur.Status != StatusExpired {
+			return ErrOrderSettled
+		}
+		from = cur.Status
+		if from == StatusExpired {
+			// Late payments are honoured even if the stock has since run out
+			if err := tx.Exec(`UPDATE shop_products p SET stock = GREATEST(p.stock - i.quantity, 0)
+				FROM shop_order_items i WHERE i.order_id = ? AND p.id = i.product_id AND p.stock IS NOT NULL`, o.ID).Error; err != nil {
+				return err
+			}
+		}
+		err := tx.Model(&Order{}).Where("id = ?", o.ID).Updates(map[string]interface{}{
+			"status":     StatusPaid,
+			"payment_id": paymentID,
+			"method":     method,
+			"paid_at":    paidAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&StatusEvent{OrderID: o.ID, Status: StatusPaid}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ? AND entity_id = ? AND product_id IN (SELECT product_id FROM shop_order_items WHERE order_id = ?)",
+			o.UserID, o.EntityID, o.ID).Delete(&CartItem{}).Error
+	})
+	if err != nil {
+		return "", err
+	}
+	o.Status, o.PaymentID, o.Method, o.PaidAt = StatusPaid, paymentID, method, &paidAt
+	return from, nil
+}
+
+// Transition moves an order from one status to another with the given
+// column updates and records the tracking event, giving the stock back when
+// restock is set. It returns ErrStatusChanged when the order is no longer in
+// from.
+func (r *Repository) Transition(ctx context.Context, o *Order, from string, updates map[string]interface{}, event *StatusEvent, restock bool) error {
+	return r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Order{}).Where("id = ? AND status = ?", o.ID, from).Updates(updates)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return ErrStatusChanged
+		}
+		if restock {
+			if err := tx.Exec(`UPDATE shop_products p SET stock = p.stock + i.quantity
+				FROM shop_order_items i WHERE i.order_id = ? AND p.id = i.product_id AND p.stock IS NOT NULL`, o.ID).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(event).Error
+	})
+}
+
+// UnpaidBefore returns orders still waiting for payment that were placed
+// before the time
+func (r *Repository) UnpaidBefore(ctx context.Context, before time.Time) ([]Order, error) {
+	var out []Order
+	err := r.DB.WithContext(ctx).Where("status = ? AND created_at < ?", StatusPendingPayment, before).Find(&out).Error
+	return out, err
+}
+
+// GetEntityName returns the temple's name
+func (r *Repository) GetEntityName(ctx context.Context, entityID uint) (string, error) {
+	var name string
+	err := r.DB.WithContext(ctx).Table("entities").Select("name").Where("id = ?", entityID).Scan(&name).Error
+	return name, err
+}
+
+// IsSandboxEntity reports whether the temple belongs to a sandbox tenant
+func (r *Repository) IsSandboxEntity(ctx context.Context, entityID uint) (bool, error) {
+	var sandbox bool
+	err := r.DB.WithContext(ctx).
+		Table("entities e").
+		Select("COALESCE(u.sandbox, false)").
+		Joins("LEFT JOIN users u ON u.id = e.created_by").
+		Where("e.id = ?", entityID).
+		Scan(&sandbox).Error
+	return sandbox, err
+}
@@ -0,0 +1,718 @@
+package shop
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	razorpay "github.com/razorpay/razorpay-go"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/internal/donation"
+	"gorm.io/gorm"
+)
+
+// paymentWindow is how long a checkout holds its stock waiting for payment
+const paymentWindow = 30 * time.Minute
+
+// Razorpay webhook events handled for orders
+const (
+	WebhookPaymentCaptured = "payment.captured"
+	WebhookOrderPaid       = "order.paid"
+	WebhookPaymentFailed   = "payment.failed"
+)
+
+var (
+	ErrNotOwner           = errors.New("order belongs to another devotee")
+	ErrProductUnavailable = errors.New("product is not available")
+	ErrOutOfStock         = errors.New("not enough stock")
+	ErrEmptyCart          = errors.New("cart is empty")
+	ErrNotDeliverable     = errors.New("product can only be collected at the temple")
+	ErrAddressRequired    = errors.New("shipping name, phone, address, city and pincode are required for delivery")
+	ErrInvalidStatus      = errors.New("order cannot move to this status")
+	ErrCannotCancel       = errors.New("only orders awaiting payment can be cancelled, please contact the temple")
+	ErrGatewayNotEnabled  = errors.New("payment gateway is not configured for this temple")
+	ErrInvalidSignature   = errors.New("invalid payment signature")
+	ErrPaymentNotCaptured = errors.New("payment has not been completed")
+)
+
+// nextStatuses are the moves temple staff can make from each status. Only
+// delivery orders are shipped; pickup orders go from packed to delivered.
+var nextStatuses = map[string][]string{
+	StatusPaid:    {StatusPacked, StatusCancelled},
+	StatusPacked:  {StatusShipped, StatusDelivered, StatusCancelled},
+	StatusShipped: {StatusDelivered},
+}
+
+// Notifier delivers in-app notifications (notification.Service)
+type Notifier interface {
+	CreateInAppNotification(ctx context.Context, userID, entityID uint, title, message, category string) error
+	CreateInAppForEntityRoles(ctx context.Context, entityID uint, roleNames []string, title, message, category string) error
+}
+
+// Service runs the prasadam and merchandise shop: the catalog, carts,
+// checkout through Razorpay and order fulfilment
+type Service struct {
+	Repo     *Repository
+	Cfg      *config.Config
+	Audit    auditlog.Service
+	Notifier Notifier // nil disables notifications
+
+	client     *razorpay.Client
+	testClient *razorpay.Client // Razorpay test mode, for sandbox tenants
+}
+
+// NewService initializes the shop service
+func NewService(repo *Repository, cfg *config.Config, auditSvc auditlog.Service) *Service {
+	s := &Service{Repo: repo, Cfg: cfg, Audit: auditSvc}
+	if cfg.RazorpayKey != "" && cfg.RazorpaySecret != "" {
+		s.client = razorpay.NewClient(cfg.RazorpayKey, cfg.RazorpaySecret)
+	}
+	if cfg.RazorpayTestKey != "" && cfg.RazorpayTestSecret != "" {
+		s.testClient = razorpay.NewClient(cfg.RazorpayTestKey, cfg.RazorpayTestSecret)
+	}
+	return s
+}
+
+// gateway is the Razorpay account an order is paid through
+type gateway struct {
+	client *razorpay.Client
+	key    string
+	secret string
+}
+
+func (s *Service) gateway(sandbox bool) (*gateway, error) {
+	if sandbox {
+		if s.testClient == nil {
+			return nil, ErrGatewayNotEnabled
+		}
+		return &gateway{client: s.testClient, key: s.Cfg.RazorpayTestKey, secret: s.Cfg.RazorpayTestSecret}, nil
+	}
+	if s.client == nil {
+		return nil, ErrGatewayNotEnabled
+	}
+	return &gateway{client: s.client, key: s.Cfg.RazorpayKey, secret: s.Cfg.RazorpaySecret}, nil
+}
+
+// ===== Catalog =====
+
+func applyProduct(p *Product, req ProductRequest) {
+	p.Name = strings.TrimSpace(req.Name)
+	p.Description = strings.TrimSpace(req.Description)
+	p.Category = req.Category
+	p.Price = req.Price
+	p.Stock = req.Stock
+	p.ImageURL = strings.TrimSpace(req.ImageURL)
+	p.Deliverable = req.Deliverable == nil || *req.Deliverable
+	p.IsActive = req.IsActive == nil || *req.IsActive
+}
+
+// CreateProduct adds a product to a temple's catalog
+func (s *Service) CreateProduct(ctx context.Context, entityID uint, req ProductRequest, userID uint, ip string) (*Product, error) {
+	p := &Product{EntityID: entityID, CreatedBy: userID}
+	applyProduct(p, req)
+	if err := s.Repo.CreateProduct(ctx, p); err != nil {
+		return nil, err
+	}
+	// Create skips false booleans that have a default, so save them again
+	if !p.Deliverable || !p.IsActive {
+		if err := s.Repo.UpdateProduct(ctx, p); err != nil {
+			return nil, err
+		}
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "SHOP_PRODUCT_CREATED", map[string]interface{}{
+		"product_id": p.ID,
+		"name":       p.Name,
+		"category":   p.Category,
+		"price":      p.Price,
+		"stock":      p.Stock,
+	}, ip, "success")
+	return p, nil
+}
+
+// UpdateProduct replaces a product. Orders already placed keep the price
+// they were placed at.
+func (s *Service) UpdateProduct(ctx context.Context, p *Product, req ProductRequest, userID uint, ip string) error {
+	before := map[string]interface{}{"name": p.Name, "price": p.Price, "stock": p.Stock, "is_active": p.IsActive}
+	applyProduct(p, req)
+	if err := s.Repo.UpdateProduct(ctx, p); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &p.EntityID, "SHOP_PRODUCT_UPDATED", map[string]interface{}{
+		"product_id": p.ID,
+		"before":     before,
+		"after":      map[string]interface{}{"name": p.Name, "price": p.Price, "stock": p.Stock, "is_active": p.IsActive},
+	}, ip, "success")
+	return nil
+}
+
+// DeleteProduct removes a product from the catalog and from carts
+func (s *Service) DeleteProduct(ctx context.Context, p *Product, userID uint, ip string) error {
+	if err := s.Repo.DeleteProduct(ctx, p.ID); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &p.EntityID, "SHOP_PRODUCT_DELETED", map[string]interface{}{
+		"product_id": p.ID,
+		"name":       p.Name,
+	}, ip, "success")
+	return nil
+}
+
+// ===== Cart =====
+
+// GetCart returns the devotee's cart at a temple. Products that were taken
+// off the catalog stay in it, marked unavailable, until removed.
+func (s *Service) GetCart(ctx context.Context, userID, entityID uint) (*Cart, error) {
+	lines, err := s.Repo.GetCart(ctx, userID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	cart := &Cart{EntityID: entityID, Items: lines}
+	for _, l := range lines {
+		if l.Available {
+			cart.Total += l.Amount
+		}
+	}
+	return cart, nil
+}
+
+// SetCartItem puts a product in the devotee's cart, changes its quantity or,
+// for quantity 0, takes it out. It returns the cart of the product's temple.
+func (s *Service) SetCartItem(ctx context.Context, userID uint, req CartItemRequest) (*Cart, error) {
+	p, err := s.Repo.GetProduct(ctx, req.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Quantity == 0 {
+		if err := s.Repo.RemoveCartItem(ctx, userID, p.ID); err != nil {
+			return nil, err
+		}
+		return s.GetCart(ctx, userID, p.EntityID)
+	}
+	if !p.IsActive {
+		return nil, ErrProductUnavailable
+	}
+	if p.Stock != nil && req.Quantity > *p.Stock {
+		return nil, fmt.Errorf("%w: %s has %d left", ErrOutOfStock, p.Name, *p.Stock)
+	}
+	item := &CartItem{UserID: userID, EntityID: p.EntityID, ProductID: p.ID, Quantity: req.Quantity}
+	if err := s.Repo.SetCartItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, userID, p.EntityID)
+}
+
+// ===== Checkout and payment =====
+
+// Checkout places an order for the devotee's cart at a temple and opens a
+// Razorpay order for it. The stock is held for paymentWindow; the cart is
+// emptied once the payment is confirmed.
+func (s *Service) Checkout(ctx context.Context, userID, entityID uint, req CheckoutRequest, ip string) (*CheckoutResponse, error) {
+	lines, err := s.Repo.GetCart(ctx, userID, entityID)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, ErrEmptyCart
+	}
+
+	delivery := req.Fulfilment == FulfilmentDelivery
+	if delivery && (strings.TrimSpace(req.ShippingName) == "" || strings.TrimSpace(req.ShippingPhone) == "" ||
+		strings.TrimSpace(req.AddressLine) == "" || strings.TrimSpace(req.City) == "" || strings.TrimSpace(req.Pincode) == "") {
+		return nil, ErrAddressRequired
+	}
+
+	var total float64
+	items := make([]OrderItem, 0, len(lines))
+	for _, l := range lines {
+		switch {
+		case !l.Available:
+			return nil, fmt.Errorf("%w: %s", ErrProductUnavailable, l.Name)
+		case delivery && !l.Deliverable:
+			return nil, fmt.Errorf("%w: %s", ErrNotDeliverable, l.Name)
+		case l.Stock != nil && l.Quantity > *l.Stock:
+			return nil, fmt.Errorf("%w: %s has %d left", ErrOutOfStock, l.Name, *l.Stock)
+		}
+		items = append(items, OrderItem{
+			ProductID: l.ProductID,
+			Name:      l.Name,
+			Category:  l.Category,
+			Price:     l.Price,
+			Quantity:  l.Quantity,
+			Amount:    l.Amount,
+		})
+		total += l.Amount
+	}
+
+	sandbox, err := s.Repo.IsSandboxEntity(ctx, entityID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve temple: %w", err)
+	}
+	gw, err := s.gateway(sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	rzpOrder, err := gw.client.Order.Create(map[string]interface{}{
+		"amount":          int(math.Round(total * 100)),
+		"currency":        "INR",
+		"payment_capture": 1,
+		"notes": map[string]interface{}{
+			"user_id":   userID,
+			"entity_id": entityID,
+			"purpose":   "shop_order",
+		},
+	}, nil)
+	if err != nil {
+		s.Audit.LogAction(ctx, &userID, &entityID, "SHOP_ORDER_PLACED", map[string]interface{}{
+			"total": total,
+			"error": err.Error(),
+		}, ip, "failure")
+		return nil, fmt.Errorf("razorpay order creation failed: %w", err)
+	}
+	rzpOrderID, _ := rzpOrder["id"].(string)
+	if rzpOrderID == "" {
+		return nil, errors.New("unable to extract order_id from Razorpay response")
+	}
+
+	o := &Order{
+		UserID:          userID,
+		EntityID:        entityID,
+		Status:          StatusPendingPayment,
+		Fulfilment:      req.Fulfilment,
+		Total:           total,
+		Note:            strings.TrimSpace(req.Note),
+		RazorpayOrderID: rzpOrderID,
+		Sandbox:         sandbox,
+	}
+	if delivery {
+		o.ShippingName = strings.TrimSpace(req.ShippingName)
+		o.ShippingPhone = strings.TrimSpace(req.ShippingPhone)
+		o.AddressLine = strings.TrimSpace(req.AddressLine)
+		o.City = strings.TrimSpace(req.City)
+		o.State = strings.TrimSpace(req.State)
+		o.Pincode = strings.TrimSpace(req.Pincode)
+	}
+	if err := s.Repo.CreateOrder(ctx, o, items); err != nil {
+		s.Audit.LogAction(ctx, &userID, &entityID, "SHOP_ORDER_PLACED", map[string]interface{}{
+			"total":             total,
+			"razorpay_order_id": rzpOrderID,
+			"error":             err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &entityID, "SHOP_ORDER_PLACED", map[string]interface{}{
+		"order_id":          o.ID,
+		"razorpay_order_id": rzpOrderID,
+		"total":             total,
+		"items":             len(items),
+		"fulfilment":        o.Fulfilment,
+		"sandbox":           sandbox,
+	}, ip, "success")
+
+	return &CheckoutResponse{
+		Order:       o,
+		OrderID:     rzpOrderID,
+		Amount:      total,
+		Currency:    "INR",
+		RazorpayKey: gw.key,
+		Sandbox:     sandbox,
+	}, nil
+}
+
+// VerifyPayment checks the Razorpay checkout signature and confirms the
+// devotee's order once its payment is captured. Confirming an order that is
+// already paid is not an error.
+func (s *Service) VerifyPayment(ctx context.Context, userID uint, req VerifyPaymentRequest, ip string) (*Order, error) {
+	o, err := s.Repo.GetByRazorpayOrder(ctx, req.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if o.UserID != userID {
+		return nil, ErrNotOwner
+	}
+	gw, err := s.gateway(o.Sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	mac := hmac.New(sha256.New, []byte(gw.secret))
+	mac.Write([]byte(req.OrderID + "|" + req.PaymentID))
+	if !hmac.Equal([]byte(hex.EncodeToString(mac.Sum(nil))), []byte(req.RazorpaySig)) {
+		s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_ORDER_PAYMENT_FAILED", map[string]interface{}{
+			"order_id":   o.ID,
+			"payment_id": req.PaymentID,
+			"reason":     "invalid payment signature",
+		}, ip, "failure")
+		return nil, ErrInvalidSignature
+	}
+
+	payment, err := gw.client.Payment.Fetch(req.PaymentID, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("razorpay payment fetch failed: %w", err)
+	}
+	if status, _ := payment["status"].(string); status != "captured" {
+		return nil, ErrPaymentNotCaptured
+	}
+	method, _ := payment["method"].(string)
+
+	if _, err := s.settlePaid(ctx, o, req.PaymentID, method, ip); err != nil {
+		if errors.Is(err, ErrOrderSettled) {
+			return s.Repo.GetOrder(ctx, o.ID)
+		}
+		return nil, err
+	}
+	return o, nil
+}
+
+// settlePaid confirms an order's payment and tells the devotee and the
+// temple. It returns the status the order was paid from.
+func (s *Service) settlePaid(ctx context.Context, o *Order, paymentID, method, ip string) (string, error) {
+	if method == "" {
+		method = "UNKNOWN"
+	}
+	from, err := s.Repo.MarkPaid(ctx, o, paymentID, method, time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_ORDER_PAID", map[string]interface{}{
+		"order_id":    o.ID,
+		"payment_id":  paymentID,
+		"method":      method,
+		"total":       o.Total,
+		"from_status": from,
+	}, ip, "success")
+
+	templeName, _ := s.Repo.GetEntityName(ctx, o.EntityID)
+	s.notify(ctx, o, "Order confirmed", fmt.Sprintf(
+		"Your order #%d of ₹%.2f at %s is confirmed. We will let you know when it is packed.", o.ID, o.Total, templeName))
+	if s.Notifier != nil {
+		message := fmt.Sprintf("Order #%d of ₹%.2f was placed for %s.", o.ID, o.Total, o.Fulfilment)
+		if err := s.Notifier.CreateInAppForEntityRoles(ctx, o.EntityID, []string{"templeadmin", "standarduser"}, "New shop order", message, "order"); err != nil {
+			log.Printf("⚠️ Shop order notification for temple %d failed: %v", o.EntityID, err)
+		}
+	}
+	return from, nil
+}
+
+// razorpayPaymentPayload is the subset of a payment webhook body we use
+type razorpayPaymentPayload struct {
+	Event   string `json:"event"`
+	Payload struct {
+		Payment struct {
+			Entity struct {
+				ID          string `json:"id"`
+				OrderID     string `json:"order_id"`
+				Amount      int64  `json:"amount"` // paise
+				Method      string `json:"method"`
+				ErrorReason string `json:"error_reason"`
+			} `json:"entity"`
+		} `json:"payment"`
+	} `json:"payload"`
+}
+
+// HandleWebhook verifies a Razorpay payment webhook and confirms the matching
+// order. Orders of other modules and retried deliveries are ignored; a failed
+// payment leaves the order open for the devotee to retry until it expires.
+func (s *Service) HandleWebhook(req donation.WebhookRequest) (*donation.WebhookResult, error) {
+	ctx := context.Background()
+
+	if s.Cfg.RazorpayWebhookSecret == "" && s.Cfg.RazorpayTestWebhookSecret == "" {
+		return nil, donation.ErrWebhookNotConfigured
+	}
+	sandbox := false
+	verified := s.Cfg.RazorpayWebhookSecret != "" && donation.VerifyWebhookSignature(req.Body, req.Signature, s.Cfg.RazorpayWebhookSecret)
+	if !verified && s.Cfg.RazorpayTestWebhookSecret != "" && donation.VerifyWebhookSignature(req.Body, req.Signature, s.Cfg.RazorpayTestWebhookSecret) {
+		verified, sandbox = true, true
+	}
+	if !verified {
+		s.Audit.LogAction(ctx, nil, nil, "SHOP_WEBHOOK_REJECTED", map[string]interface{}{
+			"event_id": req.EventID,
+			"reason":   "invalid webhook signature",
+		}, req.IPAddress, "failure")
+		return nil, donation.ErrInvalidWebhookSignature
+	}
+
+	var payload razorpayPaymentPayload
+	if err := json.Unmarshal(req.Body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid webhook payload: %w", err)
+	}
+	payment := payload.Payload.Payment.Entity
+	result := &donation.WebhookResult{Event: payload.Event, Sandbox: sandbox, OrderID: payment.OrderID}
+
+	if payment.OrderID == "" ||
+		(payload.Event != WebhookPaymentCaptured && payload.Event != WebhookOrderPaid && payload.Event != WebhookPaymentFailed) {
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+	o, err := s.Repo.GetByRazorpayOrder(ctx, payment.OrderID)
+	if err != nil {
+		return nil, err
+	}
+	if o == nil {
+		// Not a shop order (donations share the Razorpay account)
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+	if o.Sandbox != sandbox {
+		s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_WEBHOOK_REJECTED", map[string]interface{}{
+			"event":    payload.Event,
+			"event_id": req.EventID,
+			"order_id": o.ID,
+			"reason":   "webhook mode does not match the order (live vs sandbox)",
+		}, req.IPAddress, "failure")
+		result.Status = donation.WebhookIgnored
+		return result, nil
+	}
+	result.FromStatus = o.Status
+
+	if payload.Event == WebhookPaymentFailed {
+		s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_ORDER_PAYMENT_FAILED", map[string]interface{}{
+			"event_id":     req.EventID,
+			"order_id":     o.ID,
+			"payment_id":   payment.ID,
+			"error_reason": payment.ErrorReason,
+		}, req.IPAddress, "failure")
+		result.Status, result.ToStatus = donation.WebhookProcessed, o.Status
+		return result, nil
+	}
+
+	from, err := s.settlePaid(ctx, o, payment.ID, payment.Method, req.IPAddress)
+	if errors.Is(err, ErrOrderSettled) {
+		if o.Status == StatusCancelled && o.PaymentID == "" {
+			// Paid after the devotee cancelled: the temple has to refund it
+			s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_ORDER_PAYMENT_UNMATCHED", map[string]interface{}{
+				"event_id":   req.EventID,
+				"order_id":   o.ID,
+				"payment_id": payment.ID,
+				"amount":     float64(payment.Amount) / 100,
+				"reason":     "payment captured for a cancelled order, refund it from the Razorpay dashboard",
+			}, req.IPAddress, "failure")
+		}
+		result.Status, result.ToStatus = donation.WebhookDuplicate, o.Status
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Status, result.FromStatus, result.ToStatus = donation.WebhookProcessed, from, StatusPaid
+	return result, nil
+}
+
+// ===== Fulfilment =====
+
+// GetOwned loads an order of the devotee
+func (s *Service) GetOwned(ctx context.Context, id, userID uint) (*Order, error) {
+	o, err := s.Repo.GetOrder(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if o.UserID != userID {
+		return nil, ErrNotOwner
+	}
+	return o, nil
+}
+
+// canMove reports whether staff can move the order to the status
+func canMove(o *Order, to string) bool {
+	if to == StatusShipped && o.Fulfilment != FulfilmentDelivery {
+		return false
+	}
+	if to == StatusDelivered && o.Status == StatusPacked && o.Fulfilment == FulfilmentDelivery {
+		return false
+	}
+	for _, next := range nextStatuses[o.Status] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// UpdateStatus moves an order along as temple staff pack, ship and deliver
+// it, or cancels it. A cancelled order gives its stock back; a paid one must
+// be refunded from the Razorpay dashboard.
+func (s *Service) UpdateStatus(ctx context.Context, o *Order, req StatusRequest, actorID uint, ip string) error {
+	if !canMove(o, req.Status) {
+		return fmt.Errorf("%w: a %s order cannot go from %s to %s", ErrInvalidStatus, o.Fulfilment, o.Status, req.Status)
+	}
+
+	now := time.Now()
+	note := strings.TrimSpace(req.Note)
+	updates := map[string]interface{}{"status": req.Status}
+	switch req.Status {
+	case StatusPacked:
+		updates["packed_at"] = now
+	case StatusShipped:
+		updates["shipped_at"] = now
+		updates["courier"] = strings.TrimSpace(req.Courier)
+		updates["tracking_number"] = strings.TrimSpace(req.TrackingNumber)
+	case StatusDelivered:
+		updates["delivered_at"] = now
+	case StatusCancelled:
+		updates["cancelled_at"] = now
+		updates["cancel_reason"] = note
+	}
+	from := o.Status
+	event := &StatusEvent{OrderID: o.ID, Status: req.Status, Note: note, ActorID: &actorID}
+	if err := s.Repo.Transition(ctx, o, from, updates, event, req.Status == StatusCancelled); err != nil {
+		return err
+	}
+
+	o.Status = req.Status
+	switch req.Status {
+	case StatusPacked:
+		o.PackedAt = &now
+	case StatusShipped:
+		o.ShippedAt = &now
+		o.Courier, o.TrackingNumber = updates["courier"].(string), updates["tracking_number"].(string)
+	case StatusDelivered:
+		o.DeliveredAt = &now
+	case StatusCancelled:
+		o.CancelledAt, o.CancelReason = &now, note
+	}
+
+	s.Audit.LogAction(ctx, &actorID, &o.EntityID, "SHOP_ORDER_STATUS_UPDATED", map[string]interface{}{
+		"order_id":        o.ID,
+		"from_status":     from,
+		"to_status":       o.Status,
+		"courier":         o.Courier,
+		"tracking_number": o.TrackingNumber,
+		"note":            note,
+	}, ip, "success")
+
+	s.notifyStatus(ctx, o, note)
+	return nil
+}
+
+// Cancel lets a devotee drop an order they have not paid for yet
+func (s *Service) Cancel(ctx context.Context, o *Order, ip string) error {
+	if o.Status != StatusPendingPayment {
+		return ErrCannotCancel
+	}
+	now := time.Now()
+	updates := map[string]interface{}{"status": StatusCancelled, "cancelled_at": now, "cancel_reason": "cancelled by devotee"}
+	event := &StatusEvent{OrderID: o.ID, Status: StatusCancelled, Note: "cancelled by devotee", ActorID: &o.UserID}
+	if err := s.Repo.Transition(ctx, o, StatusPendingPayment, updates, event, true); err != nil {
+		return err
+	}
+	o.Status, o.CancelledAt, o.CancelReason = StatusCancelled, &now, "cancelled by devotee"
+
+	s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_ORDER_CANCELLED", map[string]interface{}{
+		"order_id": o.ID,
+		"total":    o.Total,
+	}, ip, "success")
+	return nil
+}
+
+// StartScheduler expires unpaid orders every interval until ctx is cancelled
+func (s *Service) StartScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 5 * time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.ExpireUnpaid(ctx)
+			}
+		}
+	}()
+}
+
+// ExpireUnpaid closes orders not paid within paymentWindow and gives their
+// stock back. A payment that still arrives later reopens the order.
+func (s *Service) ExpireUnpaid(ctx context.Context) {
+	orders, err := s.Repo.UnpaidBefore(ctx, time.Now().Add(-paymentWindow))
+	if err != nil {
+		log.Printf("❌ Shop order expiry sweep failed: %v", err)
+		return
+	}
+	for i := range orders {
+		o := &orders[i]
+		updates := map[string]interface{}{"status": StatusExpired}
+		event := &StatusEvent{OrderID: o.ID, Status: StatusExpired, Note: "payment not completed"}
+		if err := s.Repo.Transition(ctx, o, StatusPendingPayment, updates, event, true); err != nil {
+			if !errors.Is(err, ErrStatusChanged) {
+				log.Printf("❌ Expiring shop order %d failed: %v", o.ID, err)
+			}
+			continue
+		}
+		s.Audit.LogAction(ctx, &o.UserID, &o.EntityID, "SHOP_ORDER_EXPIRED", map[string]interface{}{
+			"order_id": o.ID,
+			"total":    o.Total,
+		}, "", "success")
+	}
+}
+
+// notifyStatus tells the devotee where their order is
+func (s *Service) notifyStatus(ctx context.Context, o *Order, note string) {
+	var title, message string
+	switch o.Status {
+	case StatusPacked:
+		title = "Order packed"
+		message = fmt.Sprintf("Your order #%d has been packed and will be shipped soon.", o.ID)
+		if o.Fulfilment == FulfilmentPickup {
+			templeName, _ := s.Repo.GetEntityName(ctx, o.EntityID)
+			message = fmt.Sprintf("Your order #%d is packed and ready to collect at %s.", o.ID, templeName)
+		}
+	case StatusShipped:
+		title = "Order shipped"
+		message = fmt.Sprintf("Your order #%d has been shipped", o.ID)
+		if o.Courier != "" {
+			message += " via " + o.Courier
+		}
+		if o.TrackingNumber != "" {
+			message += ", tracking number " + o.TrackingNumber
+		}
+		message += "."
+	case StatusDelivered:
+		title = "Order delivered"
+		message = fmt.Sprintf("Your order #%d has been delivered.", o.ID)
+		if o.Fulfilment == FulfilmentPickup {
+			message = fmt.Sprintf("Your order #%d has been collected.", o.ID)
+		}
+	case StatusCancelled:
+		title = "Order cancelled"
+		message = fmt.Sprintf("Your order #%d was cancelled by the temple.", o.ID)
+		if note != "" {
+			message += " Reason: " + note + "."
+		}
+		if o.PaidAt != nil {
+			message += fmt.Sprintf(" Your payment of ₹%.2f will be refunded.", o.Total)
+		}
+	default:
+		return
+	}
+	if note != "" && o.Status != StatusCancelled {
+		message += " " + note
+	}
+	s.notify(ctx, o, title, message)
+}
+
+func (s *Service) notify(ctx context.Context, o *Order, title, message string) {
+	if s.Notifier == nil {
+		return
+	}
+	if err := s.Notifier.CreateInAppNotification(ctx, o.UserID, o.EntityID, title, message, "order"); err != nil {
+		log.Printf("⚠️ Shop order notification for user %d failed: %v", o.UserID, err)
+	}
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// Storage quotas: with a UsageMeter set, Router meters every write and delete
+// of an entity's keys (<entityID>/...). Temp uploads, reports and quarantined
+// files belong to no entity and are not counted.
+
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// QuotaError is returned for a write that would take an entity over its quota
+type QuotaError struct {
+	EntityID uint
+	Used     int64
+	Quota    int64
+	Size     int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%s: the temple uses %s of its %s, the file needs %s more",
+		ErrQuotaExceeded, FormatBytes(e.Used), FormatBytes(e.Quota), FormatBytes(e.Size))
+}
+
+func (e *QuotaError) Is(target error) bool { return target == ErrQuotaExceeded }
+
+// UsageMeter keeps the bytes each entity stores. Reserve adds size (negative
+// when an object shrinks) and returns a *QuotaError instead when the entity
+// would go over its quota; Release gives back the space of removed objects.
+type UsageMeter interface {
+	Reserve(ctx context.Context, entityID uint, size int64) error
+	Release(ctx context.Context, entityID uint, size int64)
+}
+
+// MeterUsage registers the meter entity writes are counted against
+func (r *Router) MeterUsage(m UsageMeter) {
+	r.meter = m
+}
+
+// meteredPut puts an entity's object after reserving the growth in its usage
+func (r *Router) meteredPut(ctx context.Context, store Storage, entityID uint, put func() error, key string, size int64) error {
+	delta := size
+	if info, err := store.Stat(ctx, key); err == nil {
+		delta -= info.Size // overwrite
+	}
+	if err := r.meter.Reserve(ctx, entityID, delta); err != nil {
+		return err
+	}
+	if err := put(); err != nil {
+		r.meter.Release(ctx, entityID, delta)
+		return err
+	}
+	return nil
+}
+
+// meteredDelete deletes an entity's object and releases its size
+func (r *Router) meteredDelete(ctx context.Context, store Storage, entityID uint, key string) error {
+	info, statErr := store.Stat(ctx, key)
+	if err := store.Delete(ctx, key); err != nil {
+		return err
+	}
+	if statErr != nil {
+		if !errors.Is(statErr, ErrNotFound) {
+			log.Printf("⚠️ Deleted %s without knowing its size, usage of entity %d is off until recalculated: %v", key, entityID, statErr)
+		}
+		return nil
+	}
+	r.meter.Release(ctx, entityID, info.Size)
+	return nil
+}
+
+// FormatBytes renders a size for people, e.g. 1.5 GB
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,373 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/config"
+	"gorm.io/gorm"
+)
+
+// Data residency: a tenant tagged with a region (users.data_region) keeps its
+// files in that region's store, configured through STORAGE_REGIONS. Router
+// picks the store per call: a region set on the context wins, otherwise the
+// entity ID a key starts with is resolved to its tenant's region. Keys of no
+// entity and untagged tenants use the default store.
+
+var (
+	ErrRegionUnavailable = errors.New("no file storage is configured for the tenant's data region")
+	ErrCrossRegion       = errors.New("cross-region data transfer blocked by data residency rules")
+)
+
+// CrossRegionError is returned when data would move out of its region
+type CrossRegionError struct {
+	From, To string
+}
+
+func (e *CrossRegionError) Error() string {
+	return fmt.Sprintf("%s: data of region %q cannot be moved to %s", ErrCrossRegion, e.From, regionLabel(e.To))
+}
+
+func (e *CrossRegionError) Is(target error) bool { return target == ErrCrossRegion }
+
+func regionLabel(region string) string {
+	if region == "" {
+		return "the default storage"
+	}
+	return fmt.Sprintf("region %q", region)
+}
+
+// regionCacheTTL bounds how long an entity's region is remembered, so a
+// region change made on another instance is picked up
+const regionCacheTTL = time.Minute
+
+type regionCtxKey struct{}
+
+// WithRegion pins storage calls made with ctx to region ("" = default store).
+// Used for keys that carry no entity ID, such as report files.
+func WithRegion(ctx context.Context, region string) context.Context {
+	return context.WithValue(ctx, regionCtxKey{}, region)
+}
+
+func regionFromContext(ctx context.Context) (string, bool) {
+	region, ok := ctx.Value(regionCtxKey{}).(string)
+	return region, ok
+}
+
+// RegionResolver returns the data region of each entity's tenant; entities
+// without a region may be left out
+type RegionResolver func(ctx context.Context, entityIDs []uint) (map[uint]string, error)
+
+// BlockedTransfer describes an operation refused by data residency rules
+type BlockedTransfer struct {
+	Op        string
+	Key       string
+	EntityIDs []uint
+	Err       error
+}
+
+type cachedRegion struct {
+	region  string
+	expires time.Time
+}
+
+// Router routes storage calls to the default or a regional store
+type Router struct {
+	def       Storage
+	regions   map[string]Storage
+	resolve   RegionResolver
+	onBlocked func(ctx context.Context, b BlockedTransfer)
+	meter     UsageMeter // nil leaves entity storage unmetered
+
+	mu    sync.Mutex
+	cache map[uint]cachedRegion
+}
+
+// NewRouter wraps def with the regional stores configured in cfg. Regional
+// stores use the same backend as def.
+func NewRouter(cfg *config.Config, def Storage, resolve RegionResolver) (*Router, error) {
+	r := &Router{
+		def:     def,
+		regions: make(map[string]Storage, len(cfg.StorageRegions)),
+		resolve: resolve,
+		cache:   make(map[uint]cachedRegion),
+	}
+	for code, region := range cfg.StorageRegions {
+		store, err := newRegionStore(cfg, code, region)
+		if err != nil {
+			return nil, fmt.Errorf("storage region %s: %w", code, err)
+		}
+		r.regions[code] = store
+		log.Printf("📁 Storage region %s: %s", code, store.Backend())
+	}
+	return r, nil
+}
+
+func newRegionStore(cfg *config.Config, code string, region config.StorageRegion) (Storage, error) {
+	c := *cfg
+	c.UploadDir = region.UploadDir
+	c.S3Bucket = region.S3Bucket
+	if region.S3Endpoint != "" {
+		c.S3Endpoint = region.S3Endpoint
+	}
+	if region.S3Region != "" {
+		c.S3Region = region.S3Region
+	}
+	if strings.EqualFold(strings.TrimSpace(c.StorageBackend), BackendS3) && c.S3Bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET_%s is required for the s3 storage backend", strings.ToUpper(code))
+	}
+	return New(&c)
+}
+
+// OnBlocked registers a callback for refused operations, e.g. to audit them
+func (r *Router) OnBlocked(fn func(ctx context.Context, b BlockedTransfer)) {
+	r.onBlocked = fn
+}
+
+// HasRegion reports whether region has a store; "" is the default store
+func (r *Router) HasRegion(region string) bool {
+	if region == "" {
+		return true
+	}
+	_, ok := r.regions[region]
+	return ok
+}
+
+// Regions lists the configured region codes
+func (r *Router) Regions() []string {
+	codes := make([]string, 0, len(r.regions))
+	for code := range r.regions {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Forget drops cached regions of entities, after their tenant's region changed
+func (r *Router) Forget(entityIDs ...uint) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range entityIDs {
+		delete(r.cache, id)
+	}
+}
+
+// EntityRegions returns the region of each entity ("" = default store)
+func (r *Router) EntityRegions(ctx context.Context, entityIDs []uint) (map[uint]string, error) {
+	regions := make(map[uint]string, len(entityIDs))
+	var missing []uint
+	now := time.Now()
+
+	r.mu.Lock()
+	for _, id := range entityIDs {
+		if c, ok := r.cache[id]; ok && now.Before(c.expires) {
+			regions[id] = c.region
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(missing) == 0 || r.resolve == nil {
+		return regions, nil
+	}
+	resolved, err := r.resolve(ctx, missing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve data region: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, id := range missing {
+		regions[id] = resolved[id]
+		r.cache[id] = cachedRegion{region: resolved[id], expires: now.Add(regionCacheTTL)}
+	}
+	return regions, nil
+}
+
+// TargetRegion returns the region data combined from entityIDs must be
+// stored in. Untagged data may join a region; data of two different regions
+// may not be combined.
+func (r *Router) TargetRegion(ctx context.Context, op string, entityIDs []uint) (string, error) {
+	regions, err := r.EntityRegions(ctx, entityIDs)
+	if err != nil {
+		return "", err
+	}
+	target := ""
+	for _, id := range entityIDs {
+		region := regions[id]
+		if region == "" || region == target {
+			continue
+		}
+		if target != "" {
+			return "", r.blocked(ctx, BlockedTransfer{Op: op, EntityIDs: entityIDs, Err: &CrossRegionError{From: region, To: target}})
+		}
+		target = region
+	}
+	if !r.HasRegion(target) {
+		return "", r.blocked(ctx, BlockedTransfer{Op: op, EntityIDs: entityIDs, Err: ErrRegionUnavailable})
+	}
+	return target, nil
+}
+
+func (r *Router) blocked(ctx context.Context, b BlockedTransfer) error {
+	if r.onBlocked != nil {
+		r.onBlocked(ctx, b)
+	}
+	return b.Err
+}
+
+// keyEntity returns the entity ID a key or prefix starts with
+func keyEntity(key string) (uint, bool) {
+	first, _, _ := strings.Cut(key, "/")
+	id, err := strconv.ParseUint(first, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// storeFor picks the store of a key. A region without a store is refused
+// rather than falling back to the default store.
+func (r *Router) storeFor(ctx context.Context, op, key string) (Storage, error) {
+	region, ok := regionFromContext(ctx)
+	var entityIDs []uint
+	if !ok {
+		if id, found := keyEntity(key); found {
+			entityIDs = []uint{id}
+			regions, err := r.EntityRegions(ctx, entityIDs)
+			if err != nil {
+				return nil, err
+			}
+			region = regions[id]
+		}
+	}
+	if region == "" {
+		return r.def, nil
+	}
+	if store, ok := r.regions[region]; ok {
+		return store, nil
+	}
+	return nil, r.blocked(ctx, BlockedTransfer{Op: op, Key: key, EntityIDs: entityIDs, Err: ErrRegionUnavailable})
+}
+
+func (r *Router) Backend() string { return r.def.Backend() }
+
+func (r *Router) Put(ctx context.Context, key string, rd io.Reader, size int64, contentType string) error {
+	store, err := r.storeFor(ctx, "put", key)
+	if err != nil {
+		return err
+	}
+	if id, found := keyEntity(key); found && r.meter != nil {
+		return r.meteredPut(ctx, store, id, func() error { return store.Put(ctx, key, rd, size, contentType) }, key, size)
+	}
+	return store.Put(ctx, key, rd, size, contentType)
+}
+
+func (r *Router) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	store, err := r.storeFor(ctx, "get", key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return store.Get(ctx, key)
+}
+
+func (r *Router) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	store, err := r.storeFor(ctx, "stat", key)
+	if err != nil {
+		return nil, err
+	}
+	return store.Stat(ctx, key)
+}
+
+func (r *Router) Delete(ctx context.Context, key string) error {
+	store, err := r.storeFor(ctx, "delete", key)
+	if err != nil {
+		return err
+	}
+	if id, found := keyEntity(key); found && r.meter != nil {
+		return r.meteredDelete(ctx, store, id, key)
+	}
+	return store.Delete(ctx, key)
+}
+
+// List lists one store for an entity prefix or pinned region, and every
+// store otherwise (e.g. the storage overview)
+func (r *Router) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	_, pinned := regionFromContext(ctx)
+	if _, found := keyEntity(prefix); pinned || found {
+		store, err := r.storeFor(ctx, "list", prefix)
+		if err != nil {
+			return nil, err
+		}
+		return store.List(ctx, prefix)
+	}
+
+	objects, err := r.def.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, code := range r.Regions() {
+		more, err := r.regions[code].List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("storage region %s: %w", code, err)
+		}
+		objects = append(objects, more...)
+	}
+	return objects, nil
+}
+
+// SignedURL presigns through the key's store when its backend supports it
+func (r *Router) SignedURL(ctx context.Context, key string, expiry time.Duration, filename string) (string, error) {
+	store, err := r.storeFor(ctx, "sign", key)
+	if err != nil {
+		return "", err
+	}
+	signer, ok := store.(Signer)
+	if !ok {
+		return "", ErrNotFound
+	}
+	return signer.SignedURL(ctx, key, expiry, filename)
+}
+
+// TargetRegion is Router.TargetRegion for any store; without a Router every
+// entity lives in the default store
+func TargetRegion(ctx context.Context, s Storage, op string, entityIDs []uint) (string, error) {
+	if r, ok := s.(*Router); ok {
+		return r.TargetRegion(ctx, op, entityIDs)
+	}
+	return "", nil
+}
+
+// EntityRegionResolver looks up entity regions through the owning tenant
+// (entities.created_by -> users.data_region)
+func EntityRegionResolver(db *gorm.DB) RegionResolver {
+	return func(ctx context.Context, entityIDs []uint) (map[uint]string, error) {
+		var rows []struct {
+			ID         uint
+			DataRegion string
+		}
+		err := db.WithContext(ctx).
+			Table("entities e").
+			Select("e.id, COALESCE(u.data_region, '') AS data_region").
+			Joins("LEFT JOIN users u ON u.id = e.created_by").
+			Where("e.id IN ?", entityIDs).
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		regions := make(map[uint]string, len(rows))
+		for _, row := range rows {
+			regions[row.ID] = row.DataRegion
+		}
+		return regions, nil
+	}
+}
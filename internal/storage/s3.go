@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/sharath018/temple-management-backend/config"
+)
+
+// S3Storage stores objects in an S3 compatible bucket (AWS S3, MinIO)
+type S3Storage struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+func NewS3Storage(cfg *config.Config) (*S3Storage, error) {
+	if cfg.S3Bucket == "" {
+		return nil, fmt.Errorf("S3_BUCKET is required for the s3 storage backend")
+	}
+	endpoint := cfg.S3Endpoint
+	if endpoint == "" {
+		endpoint = "s3.amazonaws.com"
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.S3AccessKey, cfg.S3SecretKey, ""),
+		Secure: cfg.S3UseSSL,
+		Region: cfg.S3Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	exists, err := client.BucketExists(ctx, cfg.S3Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check bucket %s: %w", cfg.S3Bucket, err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, cfg.S3Bucket, minio.MakeBucketOptions{Region: cfg.S3Region}); err != nil {
+			return nil, fmt.Errorf("failed to create bucket %s: %w", cfg.S3Bucket, err)
+		}
+		log.Printf("✅ Created storage bucket %s", cfg.S3Bucket)
+	}
+
+	return &S3Storage{
+		client: client,
+		bucket: cfg.S3Bucket,
+		prefix: strings.Trim(cfg.S3Prefix, "/"),
+	}, nil
+}
+
+func (s *S3Storage) Backend() string { return BackendS3 }
+
+func (s *S3Storage) objectName(key string) (string, error) {
+	if _, err := Key(key); err != nil {
+		return "", err
+	}
+	if s.prefix == "" {
+		return key, nil
+	}
+	return s.prefix + "/" + key, nil
+}
+
+func (s *S3Storage) keyFromObject(name string) string {
+	if s.prefix == "" {
+		return name
+	}
+	return strings.TrimPrefix(name, s.prefix+"/")
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	if contentType == "" {
+		contentType = contentTypeByKey(key)
+	}
+	_, err = s.client.PutObject(ctx, s.bucket, name, r, size, minio.PutObjectOptions{ContentType: contentType})
+	return err
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	name, _ := s.objectName(key)
+	obj, err := s.client.GetObject(ctx, s.bucket, name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, nil, translateS3Error(err)
+	}
+	return obj, info, nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*ObjectInfo, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return nil, err
+	}
+	st, err := s.client.StatObject(ctx, s.bucket, name, minio.StatObjectOptions{})
+	if err != nil {
+		return nil, translateS3Error(err)
+	}
+	return &ObjectInfo{
+		Key:         key,
+		Size:        st.Size,
+		ContentType: st.ContentType,
+		ModTime:     st.LastModified,
+		ETag:        st.ETag,
+	}, nil
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	listPrefix := prefix
+	if s.prefix != "" {
+		listPrefix = s.prefix + "/" + prefix
+	}
+
+	var out []ObjectInfo
+	for obj := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: listPrefix, Recursive: true}) {
+		if obj.Err != nil {
+			return nil, translateS3Error(obj.Err)
+		}
+		key := s.keyFromObject(obj.Key)
+		contentType := obj.ContentType
+		if contentType == "" {
+			contentType = contentTypeByKey(key)
+		}
+		out = append(out, ObjectInfo{
+			Key:         key,
+			Size:        obj.Size,
+			ContentType: contentType,
+			ModTime:     obj.LastModified,
+		})
+	}
+	return out, nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	name, err := s.objectName(key)
+	if err != nil {
+		return err
+	}
+	return translateS3Error(s.client.RemoveObject(ctx, s.bucket, name, minio.RemoveObjectOptions{}))
+}
+
+// SignedURL returns a presigned GET URL; filename sets the download name
+func (s *S3Storage) SignedURL(ctx context.Context, key string, expiry time.Duration, filename string) (string, error) {
+	name, err := s.objectName(key)
+	if err != nil {
+		return "", err
+	}
+	if expiry > MaxSignedURLExpiry {
+		expiry = MaxSignedURLExpiry
+	}
+	params := url.Values{}
+	if filename != "" {
+		params.Set("response-content-disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	}
+	u, err := s.client.PresignedGetObject(ctx, s.bucket, name, expiry, params)
+	if err != nil {
+		return "", translateS3Error(err)
+	}
+	return u.String(), nil
+}
+
+func translateS3Error(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch minio.ToErrorResponse(err).Code {
+	case "NoSuchKey", "NotFound":
+		return ErrNotFound
+	}
+	return err
+}
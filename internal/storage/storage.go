@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/config"
+)
+
+// Supported storage backends (STORAGE_BACKEND)
+const (
+	BackendLocal = "local"
+	BackendS3    = "s3"
+)
+
+// Reserved key prefixes that do not belong to an entity
+const (
+	TempPrefix       = "temp_uploads" // in-flight uploads
+	ReportsPrefix    = "reports"      // generated report files
+	QuarantinePrefix = "quarantine"   // uploads rejected by the malware scanner
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// ObjectInfo describes a stored object
+type ObjectInfo struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"modified_time"`
+	ETag        string    `json:"etag,omitempty"` // set by backends that keep one (S3)
+}
+
+// Storage is the file backend used for entity documents and other uploads.
+// Keys are slash separated and relative, e.g. "<entityID>/<file>".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	Backend() string
+}
+
+// MaxSignedURLExpiry is the longest lifetime S3 accepts for a presigned URL
+const MaxSignedURLExpiry = 7 * 24 * time.Hour
+
+// Signer is implemented by backends that can hand out time-limited download
+// URLs, so clients fetch large objects without going through the API
+type Signer interface {
+	SignedURL(ctx context.Context, key string, expiry time.Duration, filename string) (string, error)
+}
+
+// New builds the storage backend selected in config
+func New(cfg *config.Config) (Storage, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.StorageBackend)) {
+	case "", BackendLocal:
+		return NewLocalStorage(cfg.UploadDir)
+	case BackendS3:
+		return NewS3Storage(cfg)
+	default:
+		return nil, fmt.Errorf("unsupported storage backend %q", cfg.StorageBackend)
+	}
+}
+
+// Key joins path segments into a clean object key and rejects traversal
+func Key(parts ...string) (string, error) {
+	for _, p := range parts {
+		if strings.ContainsAny(p, "\\\x00") {
+			return "", ErrInvalidKey
+		}
+		for _, seg := range strings.Split(p, "/") {
+			if seg == "" || seg == "." || seg == ".." {
+				return "", ErrInvalidKey
+			}
+		}
+	}
+	return path.Join(parts...), nil
+}
+
+// DeletePrefix removes every object stored under prefix
+func DeletePrefix(ctx context.Context, s Storage, prefix string) error {
+	objects, err := s.List(ctx, prefix)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objects {
+		if err := s.Delete(ctx, obj.Key); err != nil && !errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("failed to delete %s: %w", obj.Key, err)
+		}
+	}
+	return nil
+}
+
+// GroupByTopLevel groups object keys by their first path segment,
+// skipping reserved prefixes. Used for per-entity listings.
+func GroupByTopLevel(objects []ObjectInfo) map[string][]ObjectInfo {
+	groups := make(map[string][]ObjectInfo)
+	for _, obj := range objects {
+		dir, _, found := strings.Cut(obj.Key, "/")
+		if !found || dir == TempPrefix || dir == ReportsPrefix || dir == QuarantinePrefix {
+			continue
+		}
+		groups[dir] = append(groups[dir], obj)
+	}
+	return groups
+}
@@ -0,0 +1,240 @@
+package superadmin
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+const (
+	analyticsDefaultMonths = 12
+	analyticsMaxMonths     = 36
+	analyticsTopEntities   = 10
+)
+
+// =========================== REPOSITORY ===========================
+
+// monthSeries is a CTE of the first day of each month since since, so months
+// without rows still show up with zeros
+const monthSeries = `months AS (
+	SELECT generate_series(DATE_TRUNC('month', ?::timestamp), DATE_TRUNC('month', NOW()), INTERVAL '1 month') AS month
+)`
+
+func (r *Repository) CountNewTenantsByMonth(ctx context.Context, since time.Time) ([]MonthlyCount, error) {
+	var out []MonthlyCount
+	err := r.db.WithContext(ctx).Raw(`
+		WITH `+monthSeries+`
+		SELECT TO_CHAR(m.month, 'YYYY-MM') AS month, COUNT(u.id) AS count
+		FROM months m
+		LEFT JOIN users u ON DATE_TRUNC('month', u.created_at) = m.month
+			AND u.role_id = (SELECT id FROM user_roles WHERE role_name = 'templeadmin' LIMIT 1)
+		GROUP BY m.month
+		ORDER BY m.month`, since).Scan(&out).Error
+	return out, err
+}
+
+func (r *Repository) CountTemplesByState(ctx context.Context) ([]StateCount, error) {
+	var out []StateCount
+	err := r.db.WithContext(ctx).
+		Table("entities").
+		Select("COALESCE(NULLIF(TRIM(state), ''), 'Unknown') AS state, COUNT(*) AS temples").
+		Where("LOWER(status) = ?", "approved").
+		Group("1").
+		Order("temples DESC, state ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+func (r *Repository) GetDonationTrend(ctx context.Context, since time.Time) ([]DonationTrendPoint, error) {
+	var out []DonationTrendPoint
+	err := r.db.WithContext(ctx).Raw(`
+		WITH `+monthSeries+`, paid AS (
+			SELECT d.amount, COALESCE(d.donated_at, d.created_at) AS paid_at, en.created_by AS tenant_id
+			FROM donations d
+			JOIN entities en ON en.id = d.entity_id
+			WHERE d.status = 'SUCCESS' AND COALESCE(d.donated_at, d.created_at) >= DATE_TRUNC('month', ?::timestamp)
+		)
+		SELECT TO_CHAR(m.month, 'YYYY-MM') AS month,
+			COALESCE(SUM(p.amount), 0) AS amount,
+			COUNT(p.paid_at) AS count,
+			COUNT(DISTINCT p.tenant_id) AS tenants
+		FROM months m
+		LEFT JOIN paid p ON DATE_TRUNC('month', p.paid_at) = m.month
+		GROUP BY m.month
+		ORDER BY m.month`, since, since).Scan(&out).Error
+	return out, err
+}
+
+// GetMostActiveEntities ranks temples by bookings, donations and RSVPs made since since
+func (r *Repository) GetMostActiveEntities(ctx context.Context, since time.Time, limit int) ([]ActiveEntity, error) {
+	var out []ActiveEntity
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT en.id AS entity_id, en.name, en.state, en.created_by AS tenant_id,
+			COALESCE(b.n, 0) AS bookings, COALESCE(d.n, 0) AS donations, COALESCE(rv.n, 0) AS rsvps,
+			COALESCE(b.n, 0) + COALESCE(d.n, 0) + COALESCE(rv.n, 0) AS activity
+		FROM entities en
+		LEFT JOIN (SELECT entity_id, COUNT(*) AS n FROM seva_bookings WHERE booking_time >= ? GROUP BY entity_id) b ON b.entity_id = en.id
+		LEFT JOIN (SELECT entity_id, COUNT(*) AS n FROM donations WHERE status = 'SUCCESS' AND COALESCE(donated_at, created_at) >= ? GROUP BY entity_id) d ON d.entity_id = en.id
+		LEFT JOIN (SELECT e.entity_id, COUNT(*) AS n FROM rsvps r JOIN events e ON e.id = r.event_id WHERE r.created_at >= ? GROUP BY e.entity_id) rv ON rv.entity_id = en.id
+		WHERE COALESCE(b.n, 0) + COALESCE(d.n, 0) + COALESCE(rv.n, 0) > 0
+		ORDER BY activity DESC, en.id ASC
+		LIMIT ?`, since, since, since, limit).Scan(&out).Error
+	return out, err
+}
+
+// CountReportExports counts report downloads since since from the audit log
+func (r *Repository) CountReportExports(ctx context.Context, since time.Time) ([]ReportExportCount, error) {
+	var out []ReportExportCount
+	err := r.db.WithContext(ctx).Raw(`
+		SELECT REGEXP_REPLACE(action, '_(DOWNLOADED|DOWNLOAD_FAILED)$', '') AS report,
+			COUNT(*) FILTER (WHERE action LIKE '%\_DOWNLOADED') AS count,
+			COUNT(*) FILTER (WHERE action LIKE '%\_DOWNLOAD\_FAILED') AS failed
+		FROM audit_logs
+		WHERE action LIKE '%\_REPORT\_DOWNLOAD%' AND created_at >= ?
+		GROUP BY 1
+		ORDER BY count DESC, report ASC`, since).Scan(&out).Error
+	return out, err
+}
+
+// =========================== SERVICE ===========================
+
+// GetPlatformAnalytics gathers the platform metrics of the last months months,
+// the current one included
+func (s *Service) GetPlatformAnalytics(ctx context.Context, months int) (*PlatformAnalytics, error) {
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, -(months - 1), 0)
+	a := &PlatformAnalytics{Months: months, Since: since, GeneratedAt: now}
+
+	var err error
+	if a.NewTenants, err = s.repo.CountNewTenantsByMonth(ctx, since); err != nil {
+		return nil, fmt.Errorf("failed to count new tenants: %w", err)
+	}
+	if a.TemplesByState, err = s.repo.CountTemplesByState(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count temples by state: %w", err)
+	}
+	if a.DonationTrend, err = s.repo.GetDonationTrend(ctx, since); err != nil {
+		return nil, fmt.Errorf("failed to load donation trend: %w", err)
+	}
+	if a.ActiveEntities, err = s.repo.GetMostActiveEntities(ctx, since, analyticsTopEntities); err != nil {
+		return nil, fmt.Errorf("failed to rank active temples: %w", err)
+	}
+	if a.ReportExports, err = s.repo.CountReportExports(ctx, since); err != nil {
+		return nil, fmt.Errorf("failed to count report exports: %w", err)
+	}
+	return a, nil
+}
+
+// ExportPlatformAnalyticsCSV returns the platform analytics as a CSV download
+func (s *Service) ExportPlatformAnalyticsCSV(ctx context.Context, months int, adminID uint, ip string) ([]byte, error) {
+	analytics, err := s.GetPlatformAnalytics(ctx, months)
+	if err != nil {
+		return nil, err
+	}
+	data, err := analyticsCSV(analytics)
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, "PLATFORM_ANALYTICS_DOWNLOADED", map[string]interface{}{
+		"months": months,
+		"format": "csv",
+	}, ip, "success")
+	return data, nil
+}
+
+// analyticsCSV writes each metric as its own section, headed by its name
+func analyticsCSV(a *PlatformAnalytics) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	section := func(title string, headers []string, rows [][]string) {
+		w.Write([]string{title})
+		w.Write(headers)
+		for _, row := range rows {
+			w.Write(row)
+		}
+		w.Write(nil)
+	}
+	itoa := func(n int64) string { return strconv.FormatInt(n, 10) }
+
+	var rows [][]string
+	for _, m := range a.NewTenants {
+		rows = append(rows, []string{m.Month, itoa(m.Count)})
+	}
+	section("New Tenants", []string{"Month", "Tenants"}, rows)
+
+	rows = nil
+	for _, s := range a.TemplesByState {
+		rows = append(rows, []string{s.State, itoa(s.Temples)})
+	}
+	section("Temples by State", []string{"State", "Temples"}, rows)
+
+	rows = nil
+	for _, d := range a.DonationTrend {
+		rows = append(rows, []string{d.Month, strconv.FormatFloat(d.Amount, 'f', 2, 64), itoa(d.Count), itoa(d.Tenants)})
+	}
+	section("Donation Volume", []string{"Month", "Amount", "Donations", "Tenants"}, rows)
+
+	rows = nil
+	for _, e := range a.ActiveEntities {
+		rows = append(rows, []string{strconv.FormatUint(uint64(e.EntityID), 10), e.Name, e.State, strconv.FormatUint(uint64(e.TenantID), 10),
+			itoa(e.Bookings), itoa(e.Donations), itoa(e.RSVPs), itoa(e.Activity)})
+	}
+	section("Most Active Temples", []string{"Entity ID", "Temple", "State", "Tenant ID", "Bookings", "Donations", "RSVPs", "Activity"}, rows)
+
+	rows = nil
+	for _, r := range a.ReportExports {
+		rows = append(rows, []string{r.Report, itoa(r.Count), itoa(r.Failed)})
+	}
+	section("Report Exports", []string{"Report", "Downloads", "Failed"}, rows)
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// =========================== HANDLERS ===========================
+
+// GET /superadmin/analytics?months=12&format=csv
+func (h *Handler) GetPlatformAnalytics(c *gin.Context) {
+	months := analyticsDefaultMonths
+	if v := c.Query("months"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > analyticsMaxMonths {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("months must be between 1 and %d", analyticsMaxMonths)})
+			return
+		}
+		months = n
+	}
+	format := strings.ToLower(c.DefaultQuery("format", "json"))
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	if format == "json" {
+		analytics, err := h.service.GetPlatformAnalytics(c.Request.Context(), months)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch platform analytics"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": analytics})
+		return
+	}
+
+	data, err := h.service.ExportPlatformAnalyticsCSV(c.Request.Context(), months, c.GetUint("user_id"), middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export platform analytics"})
+		return
+	}
+
+	filename := fmt.Sprintf("platform_analytics_%s.csv", time.Now().Format("20060102_150405"))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	c.Data(http.StatusOK, "text/csv", data)
+}
@@ -0,0 +1,156 @@
+package superadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// maxBulkApprovalItems caps one batch so a request stays well within timeouts
+const maxBulkApprovalItems = 100
+
+var (
+	errBulkNoIDs        = errors.New("ids must list at least one ID")
+	errBulkTooManyIDs   = fmt.Errorf("at most %d IDs can be processed at once", maxBulkApprovalItems)
+	errBulkInvalidState = errors.New("invalid status. Use APPROVED or REJECTED")
+	errBulkNoReason     = errors.New("rejection reason required")
+)
+
+// BulkApprovalRequest approves or rejects several tenants or temples at once.
+// A rejection reason applies to every item.
+type BulkApprovalRequest struct {
+	IDs    []uint `json:"ids" binding:"required"`
+	Status string `json:"status" binding:"required"`
+	Reason string `json:"reason"`
+}
+
+// BulkApprovalItem is the outcome for one ID of a batch
+type BulkApprovalItem struct {
+	ID      uint   `json:"id"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkApprovalResult reports every item of a batch
+type BulkApprovalResult struct {
+	Status    string             `json:"status"`
+	Total     int                `json:"total"`
+	Succeeded int                `json:"succeeded"`
+	Failed    int                `json:"failed"`
+	Results   []BulkApprovalItem `json:"results"`
+}
+
+// =========================== SERVICE ===========================
+
+// BulkUpdateTenantApproval approves or rejects each tenant in turn. Every item
+// is audited as a single decision would be, and the batch gets one more
+// entry summarizing it.
+func (s *Service) BulkUpdateTenantApproval(ctx context.Context, req BulkApprovalRequest, adminID uint, ip string) (*BulkApprovalResult, error) {
+	return s.bulkApproval(ctx, req, adminID, ip, "TENANTS_BULK_APPROVAL", func(id uint, approve bool) error {
+		if approve {
+			return s.ApproveTenant(ctx, id, adminID, ip)
+		}
+		return s.RejectTenant(ctx, id, adminID, req.Reason, ip)
+	})
+}
+
+// BulkUpdateEntityApproval approves or rejects each temple in turn
+func (s *Service) BulkUpdateEntityApproval(ctx context.Context, req BulkApprovalRequest, adminID uint, ip string) (*BulkApprovalResult, error) {
+	return s.bulkApproval(ctx, req, adminID, ip, "ENTITIES_BULK_APPROVAL", func(id uint, approve bool) error {
+		if approve {
+			return s.ApproveEntity(ctx, id, adminID, ip)
+		}
+		return s.RejectEntity(ctx, id, adminID, req.Reason, ip)
+	})
+}
+
+func (s *Service) bulkApproval(ctx context.Context, req BulkApprovalRequest, adminID uint, ip, action string, decide func(id uint, approve bool) error) (*BulkApprovalResult, error) {
+	status := strings.ToLower(strings.TrimSpace(req.Status))
+	switch {
+	case status != "approved" && status != "rejected":
+		return nil, errBulkInvalidState
+	case status == "rejected" && strings.TrimSpace(req.Reason) == "":
+		return nil, errBulkNoReason
+	}
+
+	// Each ID is processed once, in the order given
+	ids := make([]uint, 0, len(req.IDs))
+	seen := map[uint]bool{}
+	for _, id := range req.IDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	switch {
+	case len(ids) == 0:
+		return nil, errBulkNoIDs
+	case len(ids) > maxBulkApprovalItems:
+		return nil, errBulkTooManyIDs
+	}
+
+	result := &BulkApprovalResult{Status: status, Total: len(ids), Results: make([]BulkApprovalItem, 0, len(ids))}
+	succeeded := []uint{}
+	failed := []uint{}
+	for _, id := range ids {
+		item := BulkApprovalItem{ID: id, Success: true}
+		if err := decide(id, status == "approved"); err != nil {
+			item.Success = false
+			item.Error = err.Error()
+			failed = append(failed, id)
+		} else {
+			succeeded = append(succeeded, id)
+		}
+		result.Results = append(result.Results, item)
+	}
+	result.Succeeded = len(succeeded)
+	result.Failed = len(failed)
+
+	outcome := "success"
+	if len(succeeded) == 0 {
+		outcome = "failure"
+	}
+	details := map[string]interface{}{
+		"status":        status,
+		"requested":     len(ids),
+		"succeeded_ids": succeeded,
+		"failed_ids":    failed,
+	}
+	if status == "rejected" {
+		details["reason"] = req.Reason
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, action, details, ip, outcome)
+	return result, nil
+}
+
+// =========================== HANDLERS ===========================
+
+// PATCH /superadmin/tenants/bulk-approval {"ids": [4, 7], "status": "APPROVED"}
+func (h *Handler) BulkUpdateTenantApproval(c *gin.Context) {
+	h.bulkApproval(c, h.service.BulkUpdateTenantApproval)
+}
+
+// PATCH /superadmin/entities/bulk-approval {"ids": [12, 15], "status": "REJECTED", "reason": "..."}
+func (h *Handler) BulkUpdateEntityApproval(c *gin.Context) {
+	h.bulkApproval(c, h.service.BulkUpdateEntityApproval)
+}
+
+// bulkApproval answers 200 with per-item results, even when some items fail
+func (h *Handler) bulkApproval(c *gin.Context, process func(context.Context, BulkApprovalRequest, uint, string) (*BulkApprovalResult, error)) {
+	var req BulkApprovalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids and status are required"})
+		return
+	}
+	result, err := process(c.Request.Context(), req, c.GetUint("userID"), middleware.GetIPFromContext(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
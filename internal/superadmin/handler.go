@@ -1,6 +1,7 @@
 package superadmin
 
 import (
+	"errors"
 	"log"
 	"net/http"
 	"strconv"
@@ -8,6 +9,8 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/storage"
 	"github.com/sharath018/temple-management-backend/middleware"
 )
 
@@ -99,6 +102,102 @@ func (h *Handler) UpdateTenantApprovalStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Tenant status updated successfully"})
 }
 
+// PATCH /superadmin/tenants/:id/sandbox
+func (h *Handler) UpdateTenantSandbox(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var body struct {
+		Sandbox *bool `json:"sandbox" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sandbox (true/false) is required"})
+		return
+	}
+
+	adminID := c.GetUint("user_id")
+	ip := middleware.GetIPFromContext(c)
+
+	if err := h.service.SetTenantSandbox(c.Request.Context(), uint(userID), *body.Sandbox, adminID, ip); err != nil {
+		if err.Error() == "tenant not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant sandbox mode updated", "sandbox": *body.Sandbox})
+}
+
+// PATCH /superadmin/tenants/:id/change-review {"enabled": true}
+func (h *Handler) UpdateTenantChangeReview(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var body struct {
+		Enabled *bool `json:"enabled" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "enabled (true/false) is required"})
+		return
+	}
+
+	adminID := c.GetUint("user_id")
+	ip := middleware.GetIPFromContext(c)
+
+	if err := h.service.SetTenantChangeReview(c.Request.Context(), uint(userID), *body.Enabled, adminID, ip); err != nil {
+		if err.Error() == "tenant not found" {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant change review updated", "entity_change_review": *body.Enabled})
+}
+
+// PATCH /superadmin/tenants/:id/data-region
+func (h *Handler) UpdateTenantDataRegion(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+		return
+	}
+
+	var body struct {
+		Region *string `json:"region" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "region is required (empty string for the default storage)"})
+		return
+	}
+
+	adminID := c.GetUint("user_id")
+	ip := middleware.GetIPFromContext(c)
+
+	if err := h.service.SetTenantDataRegion(c.Request.Context(), uint(userID), *body.Region, adminID, ip); err != nil {
+		switch {
+		case err.Error() == "tenant not found":
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		case errors.Is(err, storage.ErrCrossRegion):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant data region updated", "data_region": strings.ToLower(strings.TrimSpace(*body.Region))})
+}
+
 // =========================== ENTITY APPROVAL ===========================
 
 // GET /superadmin/entities?status=pending&limit=10&page=1
@@ -762,6 +861,7 @@ func (h *Handler) BulkUploadUsers(c *gin.Context) {
         c.JSON(http.StatusBadRequest, gin.H{"error": "CSV file is required"})
         return
     }
+    metrics.UploadSize.Observe(float64(file.Size), "user_import")
 
     f, err := file.Open()
     if err != nil {
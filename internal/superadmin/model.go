@@ -188,7 +188,8 @@ type User struct {
 	FullName             string         `gorm:"size:255;not null" json:"full_name"`
 	Email                string         `gorm:"size:255;unique;not null" json:"email"`
 	PasswordHash         string         `gorm:"size:255;not null" json:"-"`
-	Phone                string         `gorm:"size:20;not null" json:"phone"`
+	Phone                string         `gorm:"type:text;not null;serializer:encrypted" json:"phone"`
+	PhoneIndex           string         `gorm:"size:70;index" json:"-"` // fieldcrypto.PhoneIndex of Phone, which is encrypted
 	RoleID               uint           `gorm:"not null" json:"role_id"`
 	Role                 UserRole       `gorm:"foreignKey:RoleID;references:ID" json:"role"`
 	
@@ -219,4 +220,157 @@ type ApprovalRequest struct {
 	RejectedAt  *time.Time `json:"rejected_at,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
-}
\ No newline at end of file
+}
+// ================ ORGANIZATIONS ================
+
+// Organization is a temple trust that owns several tenant accounts
+type Organization struct {
+	ID          uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	Name        string    `gorm:"size:255;uniqueIndex;not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	Status      string    `gorm:"size:20;default:active" json:"status"` // active, inactive
+	CreatedBy   uint      `gorm:"not null" json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// OrganizationTenant links a tenant (templeadmin user) to its organization.
+// A tenant belongs to at most one organization.
+type OrganizationTenant struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	TenantID       uint      `gorm:"uniqueIndex;not null" json:"tenant_id"`
+	AddedBy        uint      `gorm:"not null" json:"added_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (OrganizationTenant) TableName() string {
+	return "organization_tenants"
+}
+
+// OrganizationAdmin links an orgadmin user to the organization they manage
+type OrganizationAdmin struct {
+	ID             uint      `gorm:"primaryKey;autoIncrement" json:"id"`
+	OrganizationID uint      `gorm:"index;not null" json:"organization_id"`
+	UserID         uint      `gorm:"uniqueIndex;not null" json:"user_id"`
+	AddedBy        uint      `gorm:"not null" json:"added_by"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+func (OrganizationAdmin) TableName() string {
+	return "organization_admins"
+}
+
+type CreateOrganizationRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+type UpdateOrganizationRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Status      *string `json:"status"`
+}
+
+type OrganizationMemberRequest struct {
+	TenantID uint `json:"tenantId"`
+	UserID   uint `json:"userId"`
+}
+
+// OrganizationSummary is a row of the organization list
+type OrganizationSummary struct {
+	Organization
+	TenantCount int64 `json:"tenant_count"`
+	AdminCount  int64 `json:"admin_count"`
+}
+
+type OrganizationTenantInfo struct {
+	TenantID   uint      `json:"tenant_id"`
+	FullName   string    `json:"full_name"`
+	Email      string    `json:"email"`
+	Status     string    `json:"status"`
+	TempleName string    `json:"temple_name"`
+	AddedAt    time.Time `json:"added_at"`
+}
+
+type OrganizationAdminInfo struct {
+	UserID   uint      `json:"user_id"`
+	FullName string    `json:"full_name"`
+	Email    string    `json:"email"`
+	Status   string    `json:"status"`
+	AddedAt  time.Time `json:"added_at"`
+}
+
+// OrganizationUserAssignment is a staff user assigned to one of the organization's tenants
+type OrganizationUserAssignment struct {
+	UserID     uint      `json:"user_id"`
+	FullName   string    `json:"full_name"`
+	Email      string    `json:"email"`
+	RoleName   string    `json:"role_name"`
+	TenantID   uint      `json:"tenant_id"`
+	TempleName string    `json:"temple_name"`
+	AssignedOn time.Time `json:"assigned_on"`
+}
+
+type OrganizationDetails struct {
+	Organization
+	Tenants []OrganizationTenantInfo `json:"tenants"`
+	Admins  []OrganizationAdminInfo  `json:"admins"`
+}
+
+// ================ PLATFORM ANALYTICS ================
+
+// PlatformAnalytics are platform-wide metrics over the last Months months
+type PlatformAnalytics struct {
+	Months         int                  `json:"months"`
+	Since          time.Time            `json:"since"`
+	NewTenants     []MonthlyCount       `json:"new_tenants"`
+	TemplesByState []StateCount         `json:"temples_by_state"`
+	DonationTrend  []DonationTrendPoint `json:"donation_trend"`
+	ActiveEntities []ActiveEntity       `json:"most_active_entities"`
+	ReportExports  []ReportExportCount  `json:"report_exports"`
+	GeneratedAt    time.Time            `json:"generated_at"`
+}
+
+// MonthlyCount is a count for one month, "2006-01"
+type MonthlyCount struct {
+	Month string `json:"month"`
+	Count int64  `json:"count"`
+}
+
+// StateCount is the number of approved temples in a state
+type StateCount struct {
+	State   string `json:"state"`
+	Temples int64  `json:"temples"`
+}
+
+// DonationTrendPoint is the successful donation volume of one month across tenants
+type DonationTrendPoint struct {
+	Month   string  `json:"month"`
+	Amount  float64 `json:"amount"`
+	Count   int64   `json:"count"`
+	Tenants int64   `json:"tenants"` // tenants that received at least one donation
+}
+
+// ActiveEntity is a temple ranked by bookings, donations and RSVPs in the period
+type ActiveEntity struct {
+	EntityID  uint   `json:"entity_id"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	TenantID  uint   `json:"tenant_id"`
+	Bookings  int64  `json:"bookings"`
+	Donations int64  `json:"donations"`
+	RSVPs     int64  `json:"rsvps"`
+	Activity  int64  `json:"activity"` // sum of the three
+}
+
+// ReportExportCount is the number of report downloads of one report action
+type ReportExportCount struct {
+	Report string `json:"report"` // audit action without the _DOWNLOADED suffix, e.g. DEVOTEE_LIST_REPORT
+	Count  int64  `json:"count"`
+	Failed int64  `json:"failed"`
+}
@@ -0,0 +1,641 @@
+package superadmin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrOrganizationNotFound      = errors.New("organization not found")
+	ErrOrganizationNameTaken     = errors.New("an organization with this name already exists")
+	ErrNotOrganizationAdmin      = errors.New("you are not an administrator of any organization")
+	ErrTenantNotInOrganization   = errors.New("tenant does not belong to this organization")
+	ErrUserAssignedOutsideOrg    = errors.New("user is assigned to a tenant outside your organization")
+	errInvalidOrganizationStatus = errors.New("invalid status. Must be 'active' or 'inactive'")
+)
+
+// =========================== REPOSITORY ===========================
+
+func (r *Repository) CreateOrganization(ctx context.Context, org *Organization) error {
+	return r.db.WithContext(ctx).Create(org).Error
+}
+
+// getUserWithRole loads a user along with their role
+func (r *Repository) getUserWithRole(ctx context.Context, userID uint) (auth.User, error) {
+	var user auth.User
+	err := r.db.WithContext(ctx).Preload("Role").Where("id = ?", userID).First(&user).Error
+	return user, err
+}
+
+func (r *Repository) GetOrganization(ctx context.Context, id uint) (Organization, error) {
+	var org Organization
+	err := r.db.WithContext(ctx).First(&org, id).Error
+	return org, err
+}
+
+func (r *Repository) OrganizationNameExists(ctx context.Context, name string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&Organization{}).
+		Where("LOWER(name) = LOWER(?) AND id <> ?", name, excludeID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *Repository) UpdateOrganization(ctx context.Context, org *Organization) error {
+	return r.db.WithContext(ctx).Save(org).Error
+}
+
+func (r *Repository) ListOrganizations(ctx context.Context) ([]OrganizationSummary, error) {
+	var out []OrganizationSummary
+	err := r.db.WithContext(ctx).Table("organizations o").
+		Select(`o.*,
+			(SELECT COUNT(*) FROM organization_tenants t WHERE t.organization_id = o.id) AS tenant_count,
+			(SELECT COUNT(*) FROM organization_admins a WHERE a.organization_id = o.id) AS admin_count`).
+		Order("o.name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+func (r *Repository) ListOrganizationTenants(ctx context.Context, orgID uint) ([]OrganizationTenantInfo, error) {
+	var out []OrganizationTenantInfo
+	err := r.db.WithContext(ctx).Table("organization_tenants ot").
+		Select(`ot.tenant_id, u.full_name, u.email, u.status,
+			COALESCE(td.temple_name, '') AS temple_name, ot.created_at AS added_at`).
+		Joins("JOIN users u ON u.id = ot.tenant_id").
+		Joins("LEFT JOIN tenant_details td ON td.user_id = ot.tenant_id").
+		Where("ot.organization_id = ?", orgID).
+		Order("u.full_name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+func (r *Repository) ListOrganizationAdmins(ctx context.Context, orgID uint) ([]OrganizationAdminInfo, error) {
+	var out []OrganizationAdminInfo
+	err := r.db.WithContext(ctx).Table("organization_admins oa").
+		Select("oa.user_id, u.full_name, u.email, u.status, oa.created_at AS added_at").
+		Joins("JOIN users u ON u.id = oa.user_id").
+		Where("oa.organization_id = ?", orgID).
+		Order("u.full_name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// GetTenantOrganization returns the organization link of a tenant, if any
+func (r *Repository) GetTenantOrganization(ctx context.Context, tenantID uint) (*OrganizationTenant, error) {
+	var link OrganizationTenant
+	err := r.db.WithContext(ctx).Where("tenant_id = ?", tenantID).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &link, err
+}
+
+func (r *Repository) AddOrganizationTenant(ctx context.Context, link *OrganizationTenant) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+func (r *Repository) RemoveOrganizationTenant(ctx context.Context, orgID, tenantID uint) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("organization_id = ? AND tenant_id = ?", orgID, tenantID).
+		Delete(&OrganizationTenant{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetAdminOrganization returns the organization link of an orgadmin user, if any
+func (r *Repository) GetAdminOrganization(ctx context.Context, userID uint) (*OrganizationAdmin, error) {
+	var link OrganizationAdmin
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &link, err
+}
+
+func (r *Repository) AddOrganizationAdmin(ctx context.Context, link *OrganizationAdmin) error {
+	return r.db.WithContext(ctx).Create(link).Error
+}
+
+func (r *Repository) RemoveOrganizationAdmin(ctx context.Context, orgID, userID uint) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Where("organization_id = ? AND user_id = ?", orgID, userID).
+		Delete(&OrganizationAdmin{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetUserAssignedTenantID returns the tenant a staff user is assigned to, if any
+func (r *Repository) GetUserAssignedTenantID(ctx context.Context, userID uint) (*uint, error) {
+	var assignment auth.TenantUserAssignment
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).First(&assignment).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &assignment.TenantID, nil
+}
+
+func (r *Repository) ListOrganizationUserAssignments(ctx context.Context, orgID uint) ([]OrganizationUserAssignment, error) {
+	var out []OrganizationUserAssignment
+	err := r.db.WithContext(ctx).Table("tenant_user_assignments tua").
+		Select(`tua.user_id, u.full_name, u.email, ur.role_name, tua.tenant_id,
+			COALESCE(td.temple_name, '') AS temple_name, tua.updated_at AS assigned_on`).
+		Joins("JOIN organization_tenants ot ON ot.tenant_id = tua.tenant_id").
+		Joins("JOIN users u ON u.id = tua.user_id").
+		Joins("JOIN user_roles ur ON ur.id = u.role_id").
+		Joins("LEFT JOIN tenant_details td ON td.user_id = tua.tenant_id").
+		Where("ot.organization_id = ? AND u.deleted_at IS NULL", orgID).
+		Order("u.full_name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// =========================== SERVICE ===========================
+
+func (s *Service) getOrganization(ctx context.Context, id uint) (Organization, error) {
+	org, err := s.repo.GetOrganization(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return org, ErrOrganizationNotFound
+	}
+	return org, err
+}
+
+func (s *Service) CreateOrganization(ctx context.Context, req CreateOrganizationRequest, adminID uint, ip string) (*Organization, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("organization name is required")
+	}
+	taken, err := s.repo.OrganizationNameExists(ctx, name, 0)
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, ErrOrganizationNameTaken
+	}
+
+	org := &Organization{
+		Name:        name,
+		Description: req.Description,
+		Status:      "active",
+		CreatedBy:   adminID,
+	}
+	if err := s.repo.CreateOrganization(ctx, org); err != nil {
+		s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_CREATE_FAILED", map[string]interface{}{
+			"name":  name,
+			"error": err.Error(),
+		}, ip, "failure")
+		return nil, err
+	}
+
+	s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_CREATED", map[string]interface{}{
+		"organization_id": org.ID,
+		"name":            org.Name,
+	}, ip, "success")
+	return org, nil
+}
+
+func (s *Service) ListOrganizations(ctx context.Context) ([]OrganizationSummary, error) {
+	return s.repo.ListOrganizations(ctx)
+}
+
+func (s *Service) GetOrganizationDetails(ctx context.Context, id uint) (*OrganizationDetails, error) {
+	org, err := s.getOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	tenants, err := s.repo.ListOrganizationTenants(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	admins, err := s.repo.ListOrganizationAdmins(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &OrganizationDetails{Organization: org, Tenants: tenants, Admins: admins}, nil
+}
+
+func (s *Service) UpdateOrganization(ctx context.Context, id uint, req UpdateOrganizationRequest, adminID uint, ip string) (*Organization, error) {
+	org, err := s.getOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("organization name is required")
+		}
+		taken, err := s.repo.OrganizationNameExists(ctx, name, id)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, ErrOrganizationNameTaken
+		}
+		org.Name = name
+	}
+	if req.Description != nil {
+		org.Description = *req.Description
+	}
+	if req.Status != nil {
+		status := strings.ToLower(*req.Status)
+		if status != "active" && status != "inactive" {
+			return nil, errInvalidOrganizationStatus
+		}
+		org.Status = status
+	}
+
+	if err := s.repo.UpdateOrganization(ctx, &org); err != nil {
+		return nil, err
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_UPDATED", map[string]interface{}{
+		"organization_id": org.ID,
+		"name":            org.Name,
+		"status":          org.Status,
+	}, ip, "success")
+	return &org, nil
+}
+
+func (s *Service) AddTenantToOrganization(ctx context.Context, orgID, tenantID, adminID uint, ip string) error {
+	if _, err := s.getOrganization(ctx, orgID); err != nil {
+		return err
+	}
+
+	tenant, err := s.repo.getUserWithRole(ctx, tenantID)
+	if err != nil {
+		return errors.New("tenant not found")
+	}
+	if strings.ToLower(tenant.Role.RoleName) != "templeadmin" {
+		return errors.New("only temple admin accounts can be added to an organization")
+	}
+
+	existing, err := s.repo.GetTenantOrganization(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.OrganizationID == orgID {
+			return nil
+		}
+		return fmt.Errorf("tenant already belongs to organization %d", existing.OrganizationID)
+	}
+
+	if err := s.repo.AddOrganizationTenant(ctx, &OrganizationTenant{
+		OrganizationID: orgID,
+		TenantID:       tenantID,
+		AddedBy:        adminID,
+	}); err != nil {
+		return err
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_TENANT_ADDED", map[string]interface{}{
+		"organization_id": orgID,
+		"tenant_id":       tenantID,
+	}, ip, "success")
+	return nil
+}
+
+func (s *Service) RemoveTenantFromOrganization(ctx context.Context, orgID, tenantID, adminID uint, ip string) error {
+	removed, err := s.repo.RemoveOrganizationTenant(ctx, orgID, tenantID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrTenantNotInOrganization
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_TENANT_REMOVED", map[string]interface{}{
+		"organization_id": orgID,
+		"tenant_id":       tenantID,
+	}, ip, "success")
+	return nil
+}
+
+func (s *Service) AddOrganizationAdmin(ctx context.Context, orgID, userID, adminID uint, ip string) error {
+	if _, err := s.getOrganization(ctx, orgID); err != nil {
+		return err
+	}
+
+	user, err := s.repo.getUserWithRole(ctx, userID)
+	if err != nil {
+		return errors.New("user not found")
+	}
+	if strings.ToLower(user.Role.RoleName) != middleware.RoleOrgAdmin {
+		return fmt.Errorf("user must have the '%s' role to administer an organization", middleware.RoleOrgAdmin)
+	}
+
+	existing, err := s.repo.GetAdminOrganization(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.OrganizationID == orgID {
+			return nil
+		}
+		return fmt.Errorf("user already administers organization %d", existing.OrganizationID)
+	}
+
+	if err := s.repo.AddOrganizationAdmin(ctx, &OrganizationAdmin{
+		OrganizationID: orgID,
+		UserID:         userID,
+		AddedBy:        adminID,
+	}); err != nil {
+		return err
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_ADMIN_ADDED", map[string]interface{}{
+		"organization_id": orgID,
+		"user_id":         userID,
+	}, ip, "success")
+	return nil
+}
+
+func (s *Service) RemoveOrganizationAdmin(ctx context.Context, orgID, userID, adminID uint, ip string) error {
+	removed, err := s.repo.RemoveOrganizationAdmin(ctx, orgID, userID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return errors.New("user is not an administrator of this organization")
+	}
+	s.auditService.LogAction(ctx, &adminID, nil, "ORGANIZATION_ADMIN_REMOVED", map[string]interface{}{
+		"organization_id": orgID,
+		"user_id":         userID,
+	}, ip, "success")
+	return nil
+}
+
+// OrganizationIDForAdmin resolves the organization managed by an orgadmin user
+func (s *Service) OrganizationIDForAdmin(ctx context.Context, userID uint) (uint, error) {
+	link, err := s.repo.GetAdminOrganization(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if link == nil {
+		return 0, ErrNotOrganizationAdmin
+	}
+	return link.OrganizationID, nil
+}
+
+func (s *Service) GetMyOrganization(ctx context.Context, userID uint) (*OrganizationDetails, error) {
+	orgID, err := s.OrganizationIDForAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetOrganizationDetails(ctx, orgID)
+}
+
+func (s *Service) ListOrganizationUserAssignments(ctx context.Context, orgAdminID uint) ([]OrganizationUserAssignment, error) {
+	orgID, err := s.OrganizationIDForAdmin(ctx, orgAdminID)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.ListOrganizationUserAssignments(ctx, orgID)
+}
+
+// AssignUserInOrganization lets an organization admin assign a staff user to
+// one of the organization's tenants. Users already assigned to a tenant of
+// another organization can't be taken over.
+func (s *Service) AssignUserInOrganization(ctx context.Context, orgAdminID, userID, tenantID uint, ip string) error {
+	orgID, err := s.OrganizationIDForAdmin(ctx, orgAdminID)
+	if err != nil {
+		return err
+	}
+
+	link, err := s.repo.GetTenantOrganization(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if link == nil || link.OrganizationID != orgID {
+		return ErrTenantNotInOrganization
+	}
+
+	current, err := s.repo.GetUserAssignedTenantID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		currentLink, err := s.repo.GetTenantOrganization(ctx, *current)
+		if err != nil {
+			return err
+		}
+		if currentLink == nil || currentLink.OrganizationID != orgID {
+			s.auditService.LogAction(ctx, &orgAdminID, nil, "ORGANIZATION_USER_ASSIGN_FAILED", map[string]interface{}{
+				"organization_id": orgID,
+				"user_id":         userID,
+				"tenant_id":       tenantID,
+				"reason":          "user assigned outside organization",
+			}, ip, "failure")
+			return ErrUserAssignedOutsideOrg
+		}
+	}
+
+	if err := s.AssignUsersToTenant(ctx, userID, tenantID, orgAdminID); err != nil {
+		s.auditService.LogAction(ctx, &orgAdminID, nil, "ORGANIZATION_USER_ASSIGN_FAILED", map[string]interface{}{
+			"organization_id": orgID,
+			"user_id":         userID,
+			"tenant_id":       tenantID,
+			"error":           err.Error(),
+		}, ip, "failure")
+		return err
+	}
+
+	s.auditService.LogAction(ctx, &orgAdminID, nil, "ORGANIZATION_USER_ASSIGNED", map[string]interface{}{
+		"organization_id": orgID,
+		"user_id":         userID,
+		"tenant_id":       tenantID,
+	}, ip, "success")
+	return nil
+}
+
+// =========================== HANDLERS ===========================
+
+func respondOrganizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrOrganizationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrNotOrganizationAdmin), errors.Is(err, ErrTenantNotInOrganization), errors.Is(err, ErrUserAssignedOutsideOrg):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, ErrOrganizationNameTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func parseIDParam(c *gin.Context, name, label string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + label})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// POST /superadmin/organizations
+func (h *Handler) CreateOrganization(c *gin.Context) {
+	var req CreateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	org, err := h.service.CreateOrganization(c.Request.Context(), req, c.GetUint("user_id"), middleware.GetIPFromContext(c))
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Organization created", "data": org})
+}
+
+// GET /superadmin/organizations
+func (h *Handler) ListOrganizations(c *gin.Context) {
+	orgs, err := h.service.ListOrganizations(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch organizations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": orgs})
+}
+
+// GET /superadmin/organizations/:id
+func (h *Handler) GetOrganization(c *gin.Context) {
+	orgID, ok := parseIDParam(c, "id", "organization ID")
+	if !ok {
+		return
+	}
+	details, err := h.service.GetOrganizationDetails(c.Request.Context(), orgID)
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": details})
+}
+
+// PUT /superadmin/organizations/:id
+func (h *Handler) UpdateOrganization(c *gin.Context) {
+	orgID, ok := parseIDParam(c, "id", "organization ID")
+	if !ok {
+		return
+	}
+	var req UpdateOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body: " + err.Error()})
+		return
+	}
+	org, err := h.service.UpdateOrganization(c.Request.Context(), orgID, req, c.GetUint("user_id"), middleware.GetIPFromContext(c))
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Organization updated", "data": org})
+}
+
+// POST /superadmin/organizations/:id/tenants  {"tenantId": 12}
+func (h *Handler) AddOrganizationTenant(c *gin.Context) {
+	orgID, ok := parseIDParam(c, "id", "organization ID")
+	if !ok {
+		return
+	}
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.TenantID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tenantId is required"})
+		return
+	}
+	if err := h.service.AddTenantToOrganization(c.Request.Context(), orgID, req.TenantID, c.GetUint("user_id"), middleware.GetIPFromContext(c)); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant added to organization"})
+}
+
+// DELETE /superadmin/organizations/:id/tenants/:tenantId
+func (h *Handler) RemoveOrganizationTenant(c *gin.Context) {
+	orgID, ok := parseIDParam(c, "id", "organization ID")
+	if !ok {
+		return
+	}
+	tenantID, ok := parseIDParam(c, "tenantId", "tenant ID")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveTenantFromOrganization(c.Request.Context(), orgID, tenantID, c.GetUint("user_id"), middleware.GetIPFromContext(c)); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Tenant removed from organization"})
+}
+
+// POST /superadmin/organizations/:id/admins  {"userId": 34}
+func (h *Handler) AddOrganizationAdmin(c *gin.Context) {
+	orgID, ok := parseIDParam(c, "id", "organization ID")
+	if !ok {
+		return
+	}
+	var req OrganizationMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.UserID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "userId is required"})
+		return
+	}
+	if err := h.service.AddOrganizationAdmin(c.Request.Context(), orgID, req.UserID, c.GetUint("user_id"), middleware.GetIPFromContext(c)); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Organization admin added"})
+}
+
+// DELETE /superadmin/organizations/:id/admins/:userId
+func (h *Handler) RemoveOrganizationAdmin(c *gin.Context) {
+	orgID, ok := parseIDParam(c, "id", "organization ID")
+	if !ok {
+		return
+	}
+	userID, ok := parseIDParam(c, "userId", "user ID")
+	if !ok {
+		return
+	}
+	if err := h.service.RemoveOrganizationAdmin(c.Request.Context(), orgID, userID, c.GetUint("user_id"), middleware.GetIPFromContext(c)); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Organization admin removed"})
+}
+
+// GET /organization - organization of the calling orgadmin
+func (h *Handler) GetMyOrganization(c *gin.Context) {
+	details, err := h.service.GetMyOrganization(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": details})
+}
+
+// GET /organization/user-assignments
+func (h *Handler) ListOrganizationUserAssignments(c *gin.Context) {
+	assignments, err := h.service.ListOrganizationUserAssignments(c.Request.Context(), c.GetUint("user_id"))
+	if err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": assignments})
+}
+
+// POST /organization/user-assignments  {"userId": 5, "tenantId": 12}
+func (h *Handler) AssignOrganizationUser(c *gin.Context) {
+	var req AssignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload. 'userId' and 'tenantId' are required"})
+		return
+	}
+	if err := h.service.AssignUserInOrganization(c.Request.Context(), c.GetUint("user_id"), req.UserID, req.TenantID, middleware.GetIPFromContext(c)); err != nil {
+		respondOrganizationError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User assigned successfully"})
+}
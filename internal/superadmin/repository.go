@@ -9,6 +9,7 @@ import (
 
 	"github.com/sharath018/temple-management-backend/internal/auth"
 	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
 	"gorm.io/gorm"
 )
 
@@ -127,6 +128,11 @@ func (r *Repository) GetTenantsWithFilters(ctx context.Context, status string, l
 			return nil, 0, err
 		}
 
+		// The phone is encrypted and raw row scans skip its serializer
+		if tenant.Phone, err = fieldcrypto.Decrypt(tenant.Phone); err != nil {
+			return nil, 0, err
+		}
+
 		// If temple details exist, populate them
 		if templeID != nil && templeName != nil {
 			tenant.TempleDetails = &TenantTempleDetails{
@@ -199,6 +205,67 @@ func (r *Repository) MarkTenantApprovalApproved(ctx context.Context, userID uint
 		}).Error
 }
 
+// SetTenantSandbox updates the sandbox flag of a templeadmin. Returns false
+// when the user is not a tenant.
+func (r *Repository) SetTenantSandbox(ctx context.Context, userID uint, sandbox bool) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&auth.User{}).
+		Where("id = ? AND role_id = (SELECT id FROM user_roles WHERE role_name = ?)", userID, "templeadmin").
+		Update("sandbox", sandbox)
+	return res.RowsAffected > 0, res.Error
+}
+
+// SetTenantChangeReview updates whether a templeadmin's edits to approved
+// temples need review. Returns false when the user is not a tenant.
+func (r *Repository) SetTenantChangeReview(ctx context.Context, userID uint, enabled bool) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&auth.User{}).
+		Where("id = ? AND role_id = (SELECT id FROM user_roles WHERE role_name = ?)", userID, "templeadmin").
+		Update("entity_change_review", enabled)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetTenantDataRegion returns the data region of a templeadmin
+func (r *Repository) GetTenantDataRegion(ctx context.Context, userID uint) (string, error) {
+	var user auth.User
+	err := r.db.WithContext(ctx).
+		Select("data_region").
+		Where("id = ? AND role_id = (SELECT id FROM user_roles WHERE role_name = ?)", userID, "templeadmin").
+		First(&user).Error
+	return user.DataRegion, err
+}
+
+// SetTenantDataRegion updates the data region of a templeadmin. Returns false
+// when the user is not a tenant.
+func (r *Repository) SetTenantDataRegion(ctx context.Context, userID uint, region string) (bool, error) {
+	res := r.db.WithContext(ctx).
+		Model(&auth.User{}).
+		Where("id = ? AND role_id = (SELECT id FROM user_roles WHERE role_name = ?)", userID, "templeadmin").
+		Update("data_region", region)
+	return res.RowsAffected > 0, res.Error
+}
+
+// GetTenantEntityIDs returns the IDs of the temples a tenant created
+func (r *Repository) GetTenantEntityIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.WithContext(ctx).
+		Model(&entity.Entity{}).
+		Where("created_by = ?", userID).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// CountSandboxDonations counts test donations left in the tenant's temples
+func (r *Repository) CountSandboxDonations(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Table("donations d").
+		Joins("JOIN entities e ON e.id = d.entity_id").
+		Where("e.created_by = ? AND d.sandbox = true AND d.deleted_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
 // =========================== ENTITY ===========================
 
 func (r *Repository) GetPendingEntities(ctx context.Context) ([]entity.Entity, error) {
@@ -402,8 +469,8 @@ func (r *Repository) GetUsers(
 		base = base.Where(`
 			users.full_name ILIKE ? OR 
 			users.email ILIKE ? OR 
-			users.phone ILIKE ?
-		`, s, s, s)
+			(users.phone_index <> '' AND users.phone_index = ?)
+		`, s, s, fieldcrypto.PhoneIndex(search))
 	}
 
 	// Status filter
@@ -452,8 +519,8 @@ func (r *Repository) GetUsers(
 		query = query.Where(`
 			users.full_name ILIKE ? OR 
 			users.email ILIKE ? OR 
-			users.phone ILIKE ?
-		`, s, s, s)
+			(users.phone_index <> '' AND users.phone_index = ?)
+		`, s, s, fieldcrypto.PhoneIndex(search))
 	}
 
 	// status
@@ -485,6 +552,10 @@ func (r *Repository) GetUsers(
 			return nil, 0, err
 		}
 
+		if u.Phone, err = fieldcrypto.Decrypt(u.Phone); err != nil {
+			return nil, 0, err
+		}
+
 		users = append(users, u)
 	}
 
@@ -564,6 +635,10 @@ func (r *Repository) GetUsersWithDetails(ctx context.Context) ([]UserResponse, i
 			return nil, 0, err
 		}
 
+		if user.Phone, err = fieldcrypto.Decrypt(user.Phone); err != nil {
+			return nil, 0, err
+		}
+
 		// Temple details
 		if templeID != nil {
 			user.TempleDetails = &TenantTempleDetails{
@@ -664,6 +739,10 @@ func (r *Repository) GetUserWithDetails(ctx context.Context, userID uint) (*User
 		return nil, err
 	}
 
+	if user.Phone, err = fieldcrypto.Decrypt(user.Phone); err != nil {
+		return nil, err
+	}
+
 	// Temple details
 	if templeID != nil {
 		user.TempleDetails = &TenantTempleDetails{
@@ -16,6 +16,9 @@ import (
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/internal/auth"
 	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/storage"
 	"github.com/sharath018/temple-management-backend/utils"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -23,6 +26,8 @@ import (
 type Service struct {
 	repo         *Repository
 	auditService auditlog.Service
+	store        storage.Storage
+	mailer       *notification.Mailer
 }
 
 func NewService(repo *Repository, auditService auditlog.Service) *Service {
@@ -88,6 +93,14 @@ func (s *Service) ApproveTenant(ctx context.Context, userID uint, adminID uint,
 		"target_user_name":  user.FullName,
 	}, ip, "success")
 
+	notification.PublishApprovalStatus(ctx, userID, notification.ApprovalStatusChange{
+		Subject: "tenant",
+		ID:      userID,
+		Name:    user.FullName,
+		Status:  "active",
+	})
+	s.emailApprovalResult(userID, "tenant", "", true, "")
+
 	return nil
 }
 
@@ -139,6 +152,15 @@ func (s *Service) RejectTenant(ctx context.Context, userID uint, adminID uint, r
 		"rejection_reason":  reason,
 	}, ip, "success")
 
+	notification.PublishApprovalStatus(ctx, userID, notification.ApprovalStatusChange{
+		Subject: "tenant",
+		ID:      userID,
+		Name:    user.FullName,
+		Status:  "rejected",
+		Reason:  reason,
+	})
+	s.emailApprovalResult(userID, "tenant", "", false, reason)
+
 	return nil
 }
 
@@ -198,6 +220,154 @@ func (s *Service) UpdateTenantApprovalStatus(ctx context.Context, userID, adminI
 	}
 }
 
+// SetTenantSandbox switches a tenant in or out of sandbox mode. Leaving
+// sandbox mode requires the tenant's test data to be wiped first so test
+// payments don't end up in live reports.
+func (s *Service) SetTenantSandbox(ctx context.Context, userID uint, sandbox bool, adminID uint, ip string) error {
+	if !sandbox {
+		leftover, err := s.repo.CountSandboxDonations(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if leftover > 0 {
+			s.auditService.LogAction(ctx, &adminID, nil, "TENANT_SANDBOX_UPDATE_FAILED", map[string]interface{}{
+				"tenant_id":         userID,
+				"sandbox":           sandbox,
+				"sandbox_donations": leftover,
+				"reason":            "sandbox data not wiped",
+			}, ip, "failure")
+			return fmt.Errorf("tenant still has %d sandbox donations; wipe the sandbox data before disabling sandbox mode", leftover)
+		}
+	}
+
+	updated, err := s.repo.SetTenantSandbox(ctx, userID, sandbox)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("tenant not found")
+	}
+
+	s.auditService.LogAction(ctx, &adminID, nil, "TENANT_SANDBOX_UPDATED", map[string]interface{}{
+		"tenant_id": userID,
+		"sandbox":   sandbox,
+	}, ip, "success")
+	return nil
+}
+
+// SetTenantChangeReview turns the change-request workflow on or off for a
+// tenant. Change requests already pending stay open for review.
+func (s *Service) SetTenantChangeReview(ctx context.Context, userID uint, enabled bool, adminID uint, ip string) error {
+	updated, err := s.repo.SetTenantChangeReview(ctx, userID, enabled)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("tenant not found")
+	}
+
+	s.auditService.LogAction(ctx, &adminID, nil, "TENANT_CHANGE_REVIEW_UPDATED", map[string]interface{}{
+		"tenant_id": userID,
+		"enabled":   enabled,
+	}, ip, "success")
+	return nil
+}
+
+// SetStorage wires the file storage, used to check data residency
+func (s *Service) SetStorage(store storage.Storage) {
+	s.store = store
+}
+
+// SetMailer wires the email channel used to tell applicants about approval decisions
+func (s *Service) SetMailer(mailer *notification.Mailer) {
+	s.mailer = mailer
+}
+
+// emailApprovalResult emails an approval decision to userID in the background.
+// Approval emails come from the platform, so they are sent without a temple.
+func (s *Service) emailApprovalResult(userID uint, kind, templeName string, approved bool, reason string) {
+	if s.mailer == nil {
+		return
+	}
+	go func() {
+		err := s.mailer.SendToUser(context.Background(), 0, userID, notification.EmailApprovalResult, map[string]interface{}{
+			"Kind":       kind,
+			"TempleName": templeName,
+			"Approved":   approved,
+			"Reason":     reason,
+		})
+		if err != nil {
+			fmt.Printf("⚠️ Failed to email %s approval result to user %d: %v\n", kind, userID, err)
+		}
+	}()
+}
+
+// SetTenantDataRegion tags a tenant with the region its files must stay in
+// ("" for the default storage). Files are not migrated, so the change is
+// refused while the tenant's temples still have files stored elsewhere.
+func (s *Service) SetTenantDataRegion(ctx context.Context, userID uint, region string, adminID uint, ip string) error {
+	region = strings.ToLower(strings.TrimSpace(region))
+	router, _ := s.store.(*storage.Router)
+	if region != "" && (router == nil || !router.HasRegion(region)) {
+		return fmt.Errorf("unknown data region %q", region)
+	}
+
+	current, err := s.repo.GetTenantDataRegion(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("tenant not found")
+		}
+		return err
+	}
+	if current == region {
+		return nil
+	}
+
+	entityIDs, err := s.repo.GetTenantEntityIDs(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if s.store != nil {
+		for _, id := range entityIDs {
+			files, err := s.store.List(storage.WithRegion(ctx, current), fmt.Sprintf("%d/", id))
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				continue
+			}
+			blocked := &storage.CrossRegionError{From: current, To: region}
+			s.auditService.LogAction(ctx, &adminID, &id, "DATA_RESIDENCY_BLOCKED", map[string]interface{}{
+				"tenant_id":   userID,
+				"operation":   "change data region",
+				"from_region": current,
+				"to_region":   region,
+				"files":       len(files),
+				"error":       blocked.Error(),
+			}, ip, "failure")
+			return fmt.Errorf("%w; temple %d still has %d stored files, move them before changing the data region", blocked, id, len(files))
+		}
+	}
+
+	updated, err := s.repo.SetTenantDataRegion(ctx, userID, region)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return errors.New("tenant not found")
+	}
+	if router != nil {
+		router.Forget(entityIDs...)
+	}
+
+	s.auditService.LogAction(ctx, &adminID, nil, "TENANT_DATA_REGION_UPDATED", map[string]interface{}{
+		"tenant_id":   userID,
+		"from_region": current,
+		"to_region":   region,
+	}, ip, "success")
+	return nil
+}
+
 // ================== ENTITY ==================
 
 func (s *Service) GetPendingEntities(ctx context.Context) ([]entity.Entity, error) {
@@ -261,6 +431,14 @@ func (s *Service) ApproveEntity(ctx context.Context, entityID uint, adminID uint
 		"created_by":  ent.CreatedBy,
 	}, ip, "success")
 
+	notification.PublishApprovalStatus(ctx, ent.CreatedBy, notification.ApprovalStatusChange{
+		Subject: "entity",
+		ID:      entityID,
+		Name:    ent.Name,
+		Status:  "approved",
+	})
+	s.emailApprovalResult(ent.CreatedBy, "entity", ent.Name, true, "")
+
 	return nil
 }
 
@@ -314,6 +492,15 @@ func (s *Service) RejectEntity(ctx context.Context, entityID uint, adminID uint,
 		"rejection_reason": reason,
 	}, ip, "success")
 
+	notification.PublishApprovalStatus(ctx, ent.CreatedBy, notification.ApprovalStatusChange{
+		Subject: "entity",
+		ID:      entityID,
+		Name:    ent.Name,
+		Status:  "rejected",
+		Reason:  reason,
+	})
+	s.emailApprovalResult(ent.CreatedBy, "entity", ent.Name, false, reason)
+
 	return nil
 }
 
@@ -442,6 +629,7 @@ func (s *Service) CreateUser(ctx context.Context, req CreateUserRequest, adminID
 		Email:        req.Email,
 		PasswordHash: string(hash),
 		Phone:        phone,
+		PhoneIndex:   fieldcrypto.PhoneIndex(phone),
 		RoleID:       role.ID,
 		Status:       "active",
 	}
@@ -556,6 +744,7 @@ func (s *Service) UpdateUser(ctx context.Context, userID uint, req UpdateUserReq
 			return err
 		}
 		userUpdates.Phone = phone
+		userUpdates.PhoneIndex = fieldcrypto.PhoneIndex(phone)
 		changes["phone"] = phone
 	}
 
@@ -1222,6 +1411,7 @@ func (s *Service) BulkUploadUsers(ctx context.Context, file multipart.File, admi
 			FullName:     fullName,
 			Email:        email,
 			Phone:        cleanPhoneNo,
+			PhoneIndex:   fieldcrypto.PhoneIndex(cleanPhoneNo),
 			RoleID:       role.ID,
 			Status:       status,
 			PasswordHash: passwordHash,
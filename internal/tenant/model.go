@@ -10,7 +10,8 @@ type User struct {
     ID                   uint           `gorm:"primaryKey" json:"id"`
     FullName             string         `gorm:"column:full_name;size:100;not null" json:"name"`
     Email                string         `gorm:"size:100;uniqueIndex;not null" json:"email"`
-    Phone                string         `gorm:"size:20" json:"phone"`
+    Phone                string         `gorm:"type:text;serializer:encrypted" json:"phone"`
+    PhoneIndex           string         `gorm:"size:70;index" json:"-"` // fieldcrypto.PhoneIndex of Phone
     PasswordHash         string         `gorm:"column:password_hash;size:255;not null" json:"-"` // stored hashed, hidden from JSON
     RoleID               uint           `gorm:"column:role_id" json:"-"`
     EntityID             uint           `gorm:"column:entity_id" json:"-"`
@@ -62,7 +63,7 @@ type UserResponse struct {
     ID        uint      `json:"id"`
     Name      string    `json:"name"`
     Email     string    `json:"email"`
-    Phone     string    `json:"phone"`
+    Phone     string    `gorm:"serializer:encrypted" json:"phone"`
     Status    string    `json:"status"`
     CreatedAt time.Time `json:"created_at"`
     Role      string    `json:"role,omitempty"` // Added for frontend compatibility
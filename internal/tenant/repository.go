@@ -5,6 +5,8 @@ import (
 	"time"
     "gorm.io/gorm"
     "log"
+
+    "github.com/sharath018/temple-management-backend/internal/fieldcrypto"
 )
 
 // Repository handles database operations
@@ -163,11 +165,18 @@ func (r *Repository) UpdateUserDetails(userID uint, input UserInput) error {
         }
     }
 
+    // Updates by column bypass the user model's encryption
+    phone, err := fieldcrypto.Encrypt(input.Phone)
+    if err != nil {
+        return err
+    }
+
     // Prepare updates map
     updates := map[string]interface{}{
         "full_name": input.Name,
         "email":     input.Email,
-        "phone":     input.Phone,
+        "phone":     phone,
+        "phone_index": fieldcrypto.PhoneIndex(input.Phone),
         "updated_at": time.Now(),
     }
     
@@ -0,0 +1,136 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"github.com/sharath018/temple-management-backend/middleware"
+	"gorm.io/gorm"
+)
+
+// ErrNotSandbox is returned when a wipe is requested for a live tenant
+var ErrNotSandbox = errors.New("tenant is not in sandbox mode")
+
+// sandboxWipeSteps are the transactional tables cleared by a sandbox wipe, in
+// dependency order. Temples, users, templates and configuration are kept.
+// Each statement takes the tenant ID as its only argument.
+var sandboxWipeSteps = []struct {
+	table string
+	query string
+}{
+	{"payment_webhook_events", `DELETE FROM payment_webhook_events WHERE order_id IN (
+		SELECT d.order_id FROM donations d JOIN entities e ON e.id = d.entity_id WHERE e.created_by = ?)`},
+	{"shop_order_events", `DELETE FROM shop_order_events WHERE order_id IN (
+		SELECT o.id FROM shop_orders o JOIN entities e ON e.id = o.entity_id WHERE e.created_by = ?)`},
+	{"shop_order_items", `DELETE FROM shop_order_items WHERE order_id IN (
+		SELECT o.id FROM shop_orders o JOIN entities e ON e.id = o.entity_id WHERE e.created_by = ?)`},
+	{"shop_orders", `DELETE FROM shop_orders WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"shop_cart_items", `DELETE FROM shop_cart_items WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"coupon_redemptions", `DELETE FROM coupon_redemptions WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"donations", `DELETE FROM donations WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"rsvps", `DELETE FROM rsvps WHERE event_id IN (
+		SELECT ev.id FROM events ev JOIN entities e ON e.id = ev.entity_id WHERE e.created_by = ?)`},
+	{"events", `DELETE FROM events WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"seva_bookings", `DELETE FROM seva_bookings WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"sevas", `DELETE FROM sevas WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"notification_logs", `DELETE FROM notification_logs WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+	{"in_app_notifications", `DELETE FROM in_app_notifications WHERE entity_id IN (SELECT id FROM entities WHERE created_by = ?)`},
+}
+
+// WipeSandboxData permanently deletes the transactional data of a sandbox
+// tenant's temples and returns the number of rows removed per table
+func (r *Repository) WipeSandboxData(ctx context.Context, tenantID uint) (map[string]int64, error) {
+	deleted := make(map[string]int64, len(sandboxWipeSteps))
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the tenant row so sandbox mode can't be switched off mid-wipe
+		var sandbox bool
+		if err := tx.Raw("SELECT sandbox FROM users WHERE id = ? FOR UPDATE", tenantID).Scan(&sandbox).Error; err != nil {
+			return err
+		}
+		if !sandbox {
+			return ErrNotSandbox
+		}
+
+		for _, step := range sandboxWipeSteps {
+			res := tx.Exec(step.query, tenantID)
+			if res.Error != nil {
+				return res.Error
+			}
+			deleted[step.table] = res.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// SetAuditService enables audit logging of sandbox wipes
+func (s *Service) SetAuditService(auditSvc auditlog.Service) {
+	s.auditSvc = auditSvc
+}
+
+// WipeSandboxData clears the test data of a sandbox tenant
+func (s *Service) WipeSandboxData(ctx context.Context, tenantID, userID uint, ip string) (map[string]int64, error) {
+	deleted, err := s.repo.WipeSandboxData(ctx, tenantID)
+
+	if s.auditSvc != nil {
+		details := map[string]interface{}{"tenant_id": tenantID}
+		status := "success"
+		if err != nil {
+			details["error"] = err.Error()
+			status = "failure"
+		} else {
+			details["deleted"] = deleted
+		}
+		s.auditSvc.LogAction(ctx, &userID, nil, "SANDBOX_DATA_WIPED", details, ip, status)
+	}
+
+	if err != nil {
+		log.Printf("Service: sandbox wipe for tenant %d failed: %v", tenantID, err)
+		return nil, err
+	}
+	return deleted, nil
+}
+
+// WipeSandboxData handles DELETE /tenant/sandbox/data for the tenant admin
+func (h *Handler) WipeSandboxData(c *gin.Context) {
+	accessContextRaw, exists := c.Get("access_context")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "access context missing"})
+		return
+	}
+	accessContext, ok := accessContextRaw.(middleware.AccessContext)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid access context"})
+		return
+	}
+
+	var body struct {
+		Confirm bool `json:"confirm"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || !body.Confirm {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Send {\"confirm\": true} to permanently delete all sandbox data"})
+		return
+	}
+
+	deleted, err := h.service.WipeSandboxData(c.Request.Context(), accessContext.TenantID, accessContext.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		if errors.Is(err, ErrNotSandbox) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to wipe sandbox data"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Sandbox data wiped",
+		"deleted": deleted,
+	})
+}
@@ -5,11 +5,15 @@ import (
     "time"
     "golang.org/x/crypto/bcrypt"
     "log"
+
+    "github.com/sharath018/temple-management-backend/internal/auditlog"
+    "github.com/sharath018/temple-management-backend/internal/fieldcrypto"
 )
 
 // Service provides tenant user management functionality
 type Service struct {
-    repo *Repository
+    repo     *Repository
+    auditSvc auditlog.Service
 }
 
 // NewService creates a new service instance
@@ -168,6 +172,7 @@ func (s *Service) CreateOrUpdateTenantUser(tenantID uint, input UserInput, creat
             FullName:     input.Name,
             Email:        input.Email,
             Phone:        input.Phone,
+            PhoneIndex:   fieldcrypto.PhoneIndex(input.Phone),
             PasswordHash: string(hashedPassword),
             RoleID:       roleID,
             Status:       "active",
@@ -0,0 +1,35 @@
+package userprofile
+
+import (
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// birthDay is the MM-DD kept in plaintext next to the encrypted DOB, so
+// birthday greetings and reports can match days in SQL
+func birthDay(dob *time.Time) *string {
+	if dob == nil {
+		return nil
+	}
+	day := dob.Format("01-02")
+	return &day
+}
+
+// BackfillBirthDays sets the birth day of profiles saved before it was kept
+func BackfillBirthDays(db *gorm.DB) {
+	var profiles []DevoteeProfile
+	err := db.Unscoped().Select("id", "dob").
+		Where("birth_day IS NULL AND dob IS NOT NULL").
+		Find(&profiles).Error
+	if err != nil {
+		log.Printf("⚠️  failed to load profiles without a birth day: %v", err)
+		return
+	}
+	for _, p := range profiles {
+		if err := db.Unscoped().Model(&DevoteeProfile{}).Where("id = ?", p.ID).UpdateColumn("birth_day", birthDay(p.DOB)).Error; err != nil {
+			log.Printf("⚠️  failed to set birth day of profile %d: %v", p.ID, err)
+		}
+	}
+}
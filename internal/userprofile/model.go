@@ -15,13 +15,15 @@ type DevoteeProfile struct {
 
 	// SECTION 1: Personal Details
 	FullName                   *string        `json:"full_name,omitempty"`
-	DOB                        *time.Time     `json:"dob,omitempty"`
+	DOB                        *time.Time     `gorm:"type:text;serializer:encrypted" json:"dob,omitempty"`
+	BirthDay                   *string        `gorm:"size:5;index" json:"-"` // MM-DD of DOB in plaintext, so birthdays can be matched in SQL
 	Gender                     *string        `json:"gender,omitempty"`
-	StreetAddress              *string        `json:"street_address,omitempty"`
+	StreetAddress              *string        `gorm:"type:text;serializer:encrypted" json:"street_address,omitempty"`
 	City                       *string        `json:"city,omitempty"`
 	State                      *string        `json:"state,omitempty"`
-	Pincode                    *string        `json:"pincode,omitempty"`
+	Pincode                    *string        `gorm:"type:text;serializer:encrypted" json:"pincode,omitempty"`
 	Country                    *string        `json:"country,omitempty"`
+	PreferredLanguage          *string        `gorm:"size:10" json:"preferred_language,omitempty"` // ISO 639-1, used for announcements
 
 	// SECTION 2: Spiritual Info
 	Gotra                      *string        `json:"gotra,omitempty"`
@@ -65,10 +67,11 @@ type DevoteeProfile struct {
 	// SECTION 5: Family Members
 	SpouseName                 *string        `json:"spouse_name,omitempty"`
 	SpouseEmail                *string        `json:"spouse_email,omitempty"`
-	SpousePhone                *string        `json:"spouse_phone,omitempty"`
-	SpouseDOB                  *time.Time     `json:"spouse_dob,omitempty"`
+	SpousePhone                *string        `gorm:"type:text;serializer:encrypted" json:"spouse_phone,omitempty"`
+	SpouseDOB                  *time.Time     `gorm:"type:text;serializer:encrypted" json:"spouse_dob,omitempty"`
 	SpouseGotra                *string        `json:"spouse_gotra,omitempty"`
 	SpouseNakshatra            *string        `json:"spouse_nakshatra,omitempty"`
+	WeddingAnniversary         *time.Time     `json:"wedding_anniversary,omitempty"`
 
 	Children                   []*Child       `gorm:"foreignKey:ProfileID" json:"children,omitempty"`
 	EmergencyContacts          []*EmergencyContact `gorm:"foreignKey:ProfileID" json:"emergency_contacts,omitempty"`
@@ -81,6 +84,9 @@ type DevoteeProfile struct {
 	PersonalSankalpa           *string        `json:"personal_sankalpa,omitempty"`
 	AdditionalNotes            *string        `json:"additional_notes,omitempty"`
 
+	// Birthday and anniversary wishes from the devotee's temples (see internal/greeting)
+	GreetingsOptOut            bool           `gorm:"not null;default:false" json:"greetings_opt_out"`
+
 	// Profile Completion
 	ProfileCompletionPercentage int           `json:"profile_completion_percentage"`
 
@@ -95,7 +101,7 @@ type Child struct {
 	ID              uint       `gorm:"primaryKey" json:"id"`
 	ProfileID       uint       `gorm:"not null;index" json:"-"`
 	ChildName       *string    `json:"child_name,omitempty"`
-	ChildDOB        *time.Time `json:"child_dob,omitempty"`
+	ChildDOB        *time.Time `gorm:"type:text;serializer:encrypted" json:"child_dob,omitempty"`
 	ChildGender     *string    `json:"child_gender,omitempty"`
 	ChildEducation  *string    `json:"child_education,omitempty"`
 	ChildInterests  *string    `json:"child_interests,omitempty"`
@@ -110,8 +116,8 @@ type EmergencyContact struct {
 	ProfileID           uint       `gorm:"not null;index" json:"-"`
 	ContactName         *string    `json:"contact_name,omitempty"`
 	ContactRelationship *string    `json:"contact_relationship,omitempty"`
-	ContactPhone        *string    `json:"contact_phone,omitempty"`
-	ContactAddress      *string    `json:"contact_address,omitempty"`
+	ContactPhone        *string    `gorm:"type:text;serializer:encrypted" json:"contact_phone,omitempty"`
+	ContactAddress      *string    `gorm:"type:text;serializer:encrypted" json:"contact_address,omitempty"`
 	CreatedAt           time.Time  `json:"created_at"`
 	UpdatedAt           time.Time  `json:"updated_at"`
 }
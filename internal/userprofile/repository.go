@@ -21,6 +21,8 @@ type Repository interface {
 	GetMembership(userID, entityID uint) (*UserEntityMembership, error)
 	ListMembershipsByUser(userID uint) ([]UserEntityMembership, error)
 	ListUserIDsByEntity(entityID uint) ([]uint, error)
+	// ConvertInvitations marks the temple's invitations of the user as converted
+	ConvertInvitations(userID, entityID uint) error
 
 	// Temple Search
 	SearchTemples(query string, state string, templeType string) ([]entity.Entity, error)
@@ -138,6 +140,10 @@ func (r *repository) GetMembership(userID, entityID uint) (*UserEntityMembership
 	return &membership, err
 }
 
+func (r *repository) ConvertInvitations(userID, entityID uint) error {
+	return entity.NewRepository(r.db).ConvertDevoteeInvitations(userID, entityID)
+}
+
 func (r *repository) ListMembershipsByUser(userID uint) ([]UserEntityMembership, error) {
 	var memberships []UserEntityMembership
 	err := r.db.Where("user_id = ?", userID).Find(&memberships).Error
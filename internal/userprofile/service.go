@@ -3,6 +3,7 @@ package userprofile
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
@@ -22,6 +23,14 @@ type Service interface {
 	SearchTemples(query, state, templeType string) ([]entity.Entity, error)
 	GetRecentTemples() ([]entity.Entity, error)
 	UpdateMembershipStatus(userID uint, entityID uint, status string) error
+	SetTopicSubscriber(t TopicSubscriber)
+}
+
+// TopicSubscriber keeps a member's devices subscribed to the push topic of
+// the temples they belong to
+type TopicSubscriber interface {
+	SubscribeToEntityTopic(ctx context.Context, userID, entityID uint) error
+	UnsubscribeFromEntityTopic(ctx context.Context, userID, entityID uint) error
 }
 
 // ========== SERVICE INIT ==========
@@ -30,6 +39,7 @@ type service struct {
 	repo     Repository
 	authRepo auth.Repository
 	auditSvc auditlog.Service
+	topics   TopicSubscriber
 }
 
 func NewService(repo Repository, authRepo auth.Repository, auditSvc auditlog.Service) Service {
@@ -40,6 +50,10 @@ func NewService(repo Repository, authRepo auth.Repository, auditSvc auditlog.Ser
 	}
 }
 
+func (s *service) SetTopicSubscriber(t TopicSubscriber) {
+	s.topics = t
+}
+
 // ========== PROFILE DTO ==========
 
 type DevoteeProfileInput struct {
@@ -53,6 +67,8 @@ type DevoteeProfileInput struct {
 	Pincode       *string    `json:"pincode"`
 	Country       *string    `json:"country"`
 
+	PreferredLanguage *string `json:"preferred_language"` // e.g. en, te, kn; announcements fall back to the temple default
+
 	// Section 2
 	Gotra     *string `json:"gotra"`
 	Nakshatra *string `json:"nakshatra"`
@@ -89,14 +105,15 @@ type DevoteeProfileInput struct {
 	SpecialInterestsOrNotes    *string `json:"special_interests_or_notes"`
 
 	// Section 5
-	SpouseName        *string             `json:"spouse_name"`
-	SpouseEmail       *string             `json:"spouse_email"`
-	SpousePhone       *string             `json:"spouse_phone"`
-	SpouseDOB         *time.Time          `json:"spouse_dob"`
-	SpouseGotra       *string             `json:"spouse_gotra"`
-	SpouseNakshatra   *string             `json:"spouse_nakshatra"`
-	Children          []*Child            `json:"children"`
-	EmergencyContacts []*EmergencyContact `json:"emergency_contacts"`
+	SpouseName         *string             `json:"spouse_name"`
+	SpouseEmail        *string             `json:"spouse_email"`
+	SpousePhone        *string             `json:"spouse_phone"`
+	SpouseDOB          *time.Time          `json:"spouse_dob"`
+	SpouseGotra        *string             `json:"spouse_gotra"`
+	SpouseNakshatra    *string             `json:"spouse_nakshatra"`
+	WeddingAnniversary *time.Time          `json:"wedding_anniversary"`
+	Children           []*Child            `json:"children"`
+	EmergencyContacts  []*EmergencyContact `json:"emergency_contacts"`
 
 	// Section 6
 	HealthNotes           *string `json:"health_notes"`
@@ -104,6 +121,8 @@ type DevoteeProfileInput struct {
 	DietaryRestrictions   *string `json:"dietary_restrictions"`
 	PersonalSankalpa      *string `json:"personal_sankalpa"`
 	AdditionalNotes       *string `json:"additional_notes"`
+
+	GreetingsOptOut *bool `json:"greetings_opt_out"` // omitted keeps the current choice
 }
 
 // ========== PROFILE LOGIC ==========
@@ -132,12 +151,14 @@ func (s *service) CreateOrUpdateProfile(ctx context.Context, userID, entityID ui
 		EntityID:                    entityID,
 		FullName:                    input.FullName,
 		DOB:                         input.DOB,
+		BirthDay:                    birthDay(input.DOB),
 		Gender:                      input.Gender,
 		StreetAddress:               input.StreetAddress,
 		City:                        input.City,
 		State:                       input.State,
 		Pincode:                     input.Pincode,
 		Country:                     input.Country,
+		PreferredLanguage:           normalizeLanguage(input.PreferredLanguage),
 		Gotra:                       input.Gotra,
 		Nakshatra:                   input.Nakshatra,
 		Rashi:                       input.Rashi,
@@ -173,6 +194,7 @@ func (s *service) CreateOrUpdateProfile(ctx context.Context, userID, entityID ui
 		SpouseDOB:                   input.SpouseDOB,
 		SpouseGotra:                 input.SpouseGotra,
 		SpouseNakshatra:             input.SpouseNakshatra,
+		WeddingAnniversary:          input.WeddingAnniversary,
 		Children:                    input.Children,
 		EmergencyContacts:           input.EmergencyContacts,
 		HealthNotes:                 input.HealthNotes,
@@ -180,10 +202,15 @@ func (s *service) CreateOrUpdateProfile(ctx context.Context, userID, entityID ui
 		DietaryRestrictions:         input.DietaryRestrictions,
 		PersonalSankalpa:            input.PersonalSankalpa,
 		AdditionalNotes:             input.AdditionalNotes,
+		GreetingsOptOut:             input.GreetingsOptOut != nil && *input.GreetingsOptOut,
 		ProfileCompletionPercentage: calculateCompletionPercentage(input),
 		UpdatedAt:                   time.Now(),
 	}
 
+	if input.GreetingsOptOut == nil && existing != nil {
+		profile.GreetingsOptOut = existing.GreetingsOptOut
+	}
+
 	var action string
 	var status string
 
@@ -254,6 +281,14 @@ func (s *service) JoinTemple(ctx context.Context, userID uint, entityID uint, us
 	}
 
 	err = s.repo.CreateMembership(membership)
+	if err == nil {
+		// A contact invited during a membership drive has now joined
+		s.repo.ConvertInvitations(userID, entityID)
+
+		if s.topics != nil {
+			_ = s.topics.SubscribeToEntityTopic(ctx, userID, entityID)
+		}
+	}
 
 	// ✅ AUDIT LOG: Temple Join
 	var action string
@@ -349,6 +384,30 @@ func (s *service) GetRecentTemples() ([]entity.Entity, error) {
 	return s.repo.FetchRecentTemples()
 }
 
+// normalizeLanguage lower-cases a language code and drops empty values
+func normalizeLanguage(lang *string) *string {
+	if lang == nil {
+		return nil
+	}
+	v := strings.ToLower(strings.TrimSpace(*lang))
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
 func (s *service) UpdateMembershipStatus(userID uint, entityID uint, status string) error {
-	return s.repo.UpdateMembershipStatus(userID, entityID, status)
+	if err := s.repo.UpdateMembershipStatus(userID, entityID, status); err != nil {
+		return err
+	}
+
+	// Only active members receive the temple's push announcements
+	if s.topics != nil {
+		if status == "active" {
+			_ = s.topics.SubscribeToEntityTopic(context.Background(), userID, entityID)
+		} else {
+			_ = s.topics.UnsubscribeFromEntityTopic(context.Background(), userID, entityID)
+		}
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,369 @@
+package volunteer
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/internal/httpx"
+	"github.com/sharath018/temple-management-backend/middleware"
+)
+
+// Handler exposes the volunteer endpoints
+type Handler struct {
+	Service *Service
+}
+
+// NewHandler creates a new volunteer handler
+func NewHandler(s *Service) *Handler {
+	return &Handler{Service: s}
+}
+
+// Register - POST /volunteers/register?entity_id=
+// Registers the caller as a volunteer of the temple, or updates their skills.
+func (h *Handler) Register(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+
+	var req RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	v, err := h.Service.Register(c.Request.Context(), access.UserID, entityID, req, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to register volunteer")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer registration saved", "data": v})
+}
+
+// GetMine - GET /volunteers/me?entity_id=
+func (h *Handler) GetMine(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	v, err := h.Service.Repo.GetByUser(c.Request.Context(), access.UserID, entityID)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch volunteer registration")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": v})
+}
+
+// ListMySignups - GET /volunteers/my-signups
+func (h *Handler) ListMySignups(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	items, err := h.Service.Repo.ListUserSignups(c.Request.Context(), access.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sign-ups"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+// GetSkills - GET /volunteers/skills
+func (h *Handler) GetSkills(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": SuggestedSkills})
+}
+
+// List - GET /volunteers?status=active&skill=cooking&q=&page=1&limit=20 (temple staff)
+func (h *Handler) List(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.IsEntityStaff(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+	status := c.Query("status")
+	if status != "" && status != StatusActive && status != StatusInactive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": ErrInvalidStatus.Error()})
+		return
+	}
+
+	page := httpx.PositiveQuery(c, "page", 1)
+	limit := min(httpx.PositiveQuery(c, "limit", 20), 100)
+	skill := strings.ToLower(strings.TrimSpace(c.Query("skill")))
+	items, total, err := h.Service.Repo.ListVolunteers(c.Request.Context(), entityID, status, skill, strings.TrimSpace(c.Query("q")), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch volunteers"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data":  items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// SetStatus - PUT /volunteers/:id/status (temple staff)
+func (h *Handler) SetStatus(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid volunteer ID"})
+		return
+	}
+	v, err := h.Service.Repo.GetVolunteer(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch volunteer")
+		return
+	}
+	if !access.CanManageEntity(v.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this volunteer"})
+		return
+	}
+
+	var req StatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.SetStatus(c.Request.Context(), v, req.Status, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update volunteer")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer " + v.Status, "data": v})
+}
+
+// ListSlots - GET /volunteers/slots?event_id=&upcoming=true
+// Volunteers see the upcoming open slots; temple staff see every slot unless
+// upcoming=true.
+func (h *Handler) ListSlots(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	var eventID uint
+	if v := c.Query("event_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event_id"})
+			return
+		}
+		eventID = uint(id)
+	}
+	upcoming := !access.IsEntityStaff(entityID) || c.Query("upcoming") == "true"
+
+	slots, err := h.Service.Repo.ListSlots(c.Request.Context(), entityID, eventID, upcoming)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch volunteer slots"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": slots})
+}
+
+// GetSlot - GET /volunteers/slots/:id
+func (h *Handler) GetSlot(c *gin.Context) {
+	_, slot, ok := h.loadSlot(c, false, false)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": slot})
+}
+
+// CreateSlot - POST /volunteers/slots (temple staff)
+func (h *Handler) CreateSlot(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	entityID, ok := middleware.RequestEntityID(c, access)
+	if !ok {
+		return
+	}
+	if !access.CanManageEntity(entityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this temple"})
+		return
+	}
+
+	var req SlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	slot, err := h.Service.CreateSlot(c.Request.Context(), entityID, req, access.UserID, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to create volunteer slot")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Volunteer slot created", "data": slot})
+}
+
+// UpdateSlot - PUT /volunteers/slots/:id (temple staff)
+func (h *Handler) UpdateSlot(c *gin.Context) {
+	access, slot, ok := h.loadSlot(c, true, true)
+	if !ok {
+		return
+	}
+	var req SlotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.UpdateSlot(c.Request.Context(), slot, req, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to update volunteer slot")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer slot updated", "data": slot})
+}
+
+// CancelSlot - POST /volunteers/slots/:id/cancel (temple staff)
+func (h *Handler) CancelSlot(c *gin.Context) {
+	access, slot, ok := h.loadSlot(c, true, true)
+	if !ok {
+		return
+	}
+	if err := h.Service.CancelSlot(c.Request.Context(), slot, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to cancel volunteer slot")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Volunteer slot cancelled", "data": slot})
+}
+
+// ListSignups - GET /volunteers/slots/:id/signups (temple staff)
+func (h *Handler) ListSignups(c *gin.Context) {
+	_, slot, ok := h.loadSlot(c, true, false)
+	if !ok {
+		return
+	}
+	items, err := h.Service.Repo.ListSignups(c.Request.Context(), slot.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch sign-ups"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": items, "slot": slot})
+}
+
+// SignUp - POST /volunteers/slots/:id/signup
+func (h *Handler) SignUp(c *gin.Context) {
+	access, slot, ok := h.loadSlot(c, false, false)
+	if !ok {
+		return
+	}
+	signup, err := h.Service.SignUp(c.Request.Context(), access.UserID, slot, middleware.GetIPFromContext(c))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to sign up")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Signed up", "data": signup})
+}
+
+// CancelSignup - DELETE /volunteers/slots/:id/signup
+func (h *Handler) CancelSignup(c *gin.Context) {
+	access, slot, ok := h.loadSlot(c, false, false)
+	if !ok {
+		return
+	}
+	if err := h.Service.CancelSignup(c.Request.Context(), access.UserID, slot, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to cancel sign-up")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Sign-up cancelled"})
+}
+
+// MarkAttendance - POST /volunteers/signups/:id/attendance (temple staff)
+func (h *Handler) MarkAttendance(c *gin.Context) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid sign-up ID"})
+		return
+	}
+	signup, err := h.Service.Repo.GetSignup(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch sign-up")
+		return
+	}
+	if !access.CanManageEntity(signup.EntityID) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this sign-up"})
+		return
+	}
+	slot, err := h.Service.Repo.GetSlot(c.Request.Context(), signup.SlotID)
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch volunteer slot")
+		return
+	}
+
+	var req AttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request", "details": err.Error()})
+		return
+	}
+	if err := h.Service.MarkAttendance(c.Request.Context(), signup, slot, req.Attendance, access.UserID, middleware.GetIPFromContext(c)); err != nil {
+		httpErrors.Write(c, err, "Failed to mark attendance")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Attendance marked", "data": signup})
+}
+
+// loadSlot loads the :id slot. Staff must run its temple; anyone else may
+// only see it unless staffOnly. Writes also need write access.
+func (h *Handler) loadSlot(c *gin.Context, staffOnly, write bool) (middleware.AccessContext, *Slot, bool) {
+	access, ok := httpx.AccessContext(c)
+	if !ok {
+		return access, nil, false
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid slot ID"})
+		return access, nil, false
+	}
+	slot, err := h.Service.Repo.GetSlot(c.Request.Context(), uint(id))
+	if err != nil {
+		httpErrors.Write(c, err, "Failed to fetch volunteer slot")
+		return access, nil, false
+	}
+	allowed := !staffOnly || access.IsEntityStaff(slot.EntityID)
+	if write {
+		allowed = access.CanManageEntity(slot.EntityID)
+	}
+	if !allowed {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied to this slot"})
+		return access, nil, false
+	}
+	return access, slot, true
+}
+
+var httpErrors = httpx.ErrorMap{
+	NotFound: "Not found",
+	Statuses: map[int][]error{
+		http.StatusBadRequest: {ErrTooManySkills, ErrInvalidStatus, ErrInvalidEvent, ErrInvalidSlotTime, ErrInvalidAttendance,
+			ErrCapacityTooLow},
+		http.StatusForbidden: {ErrNotRegistered, ErrVolunteerInactive, ErrSkillRequired},
+		http.StatusConflict: {ErrSlotFull, ErrAlreadySignedUp, ErrNotSignedUp, ErrSlotClosed, ErrAttendanceTooSoon,
+			ErrSignupCancelled},
+	},
+}
@@ -0,0 +1,157 @@
+package volunteer
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// Volunteer statuses. Registrations are active straight away; temple staff
+// may set a volunteer inactive.
+const (
+	StatusActive   = "active"
+	StatusInactive = "inactive"
+)
+
+// Sign-up statuses
+const (
+	SignupConfirmed = "confirmed"
+	SignupCancelled = "cancelled"
+)
+
+// Attendance of a confirmed sign-up
+const (
+	AttendancePending = "pending"
+	AttendancePresent = "present"
+	AttendanceAbsent  = "absent"
+)
+
+// SuggestedSkills are offered when volunteers tag themselves; other tags are
+// accepted too
+var SuggestedSkills = []string{
+	"annadanam", "cooking", "crowd_management", "decoration", "first_aid",
+	"music", "parking", "photography", "pooja_assistance", "cleaning",
+	"reception", "security", "teaching", "it_support", "driving",
+}
+
+// Volunteer is a user registered to serve at a temple
+type Volunteer struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	UserID       uint           `gorm:"not null;uniqueIndex:idx_volunteer_user_entity" json:"user_id"`
+	EntityID     uint           `gorm:"not null;uniqueIndex:idx_volunteer_user_entity;index" json:"entity_id"`
+	Skills       datatypes.JSON `gorm:"type:jsonb" json:"skills"`                // tags, ["cooking"]
+	Availability string         `gorm:"type:text" json:"availability,omitempty"` // e.g. "weekends, festival days"
+	Status       string         `gorm:"size:20;not null;default:'active';index" json:"status"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Volunteer model
+func (Volunteer) TableName() string {
+	return "volunteers"
+}
+
+// Slot is a volunteering role at an event, e.g. "Prasadam counter", with a
+// fixed number of places
+type Slot struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	EntityID    uint       `gorm:"not null;index" json:"entity_id"`
+	EventID     uint       `gorm:"not null;index" json:"event_id"`
+	Title       string     `gorm:"size:255;not null" json:"title"`
+	Description string     `gorm:"type:text" json:"description,omitempty"`
+	Skill       string     `gorm:"size:50" json:"skill,omitempty"` // volunteers need this tag; empty = anyone
+	ServiceDate time.Time  `gorm:"type:date;not null;index" json:"service_date"`
+	StartTime   string     `gorm:"size:5" json:"start_time,omitempty"` // HH:MM
+	EndTime     string     `gorm:"size:5" json:"end_time,omitempty"`
+	Capacity    int        `gorm:"not null" json:"capacity"`
+	CreatedBy   uint       `gorm:"not null" json:"created_by"`
+	CancelledAt *time.Time `json:"cancelled_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+
+	SignedUp int `gorm:"-" json:"signed_up"` // confirmed sign-ups
+}
+
+// TableName returns the table name for the Slot model
+func (Slot) TableName() string {
+	return "event_volunteer_slots"
+}
+
+// Signup is a volunteer's place in a slot
+type Signup struct {
+	ID uint `gorm:"primaryKey" json:"id"`
+
+	SlotID      uint       `gorm:"not null;uniqueIndex:idx_signup_slot_volunteer" json:"slot_id"`
+	VolunteerID uint       `gorm:"not null;uniqueIndex:idx_signup_slot_volunteer;index" json:"volunteer_id"`
+	EventID     uint       `gorm:"not null;index" json:"event_id"`
+	EntityID    uint       `gorm:"not null;index" json:"entity_id"`
+	Status      string     `gorm:"size:20;not null;default:'confirmed'" json:"status"`
+	Attendance  string     `gorm:"size:20;not null;default:'pending'" json:"attendance"`
+	MarkedBy    *uint      `json:"marked_by,omitempty"`
+	MarkedAt    *time.Time `json:"marked_at,omitempty"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// TableName returns the table name for the Signup model
+func (Signup) TableName() string {
+	return "event_volunteer_signups"
+}
+
+// VolunteerWithUser is a volunteer with their name and contact
+type VolunteerWithUser struct {
+	Volunteer
+	FullName string `json:"full_name"`
+	Email    string `json:"email"`
+	Phone    string `json:"phone" gorm:"serializer:encrypted"`
+}
+
+// SignupWithVolunteer is a sign-up as temple staff see it
+type SignupWithVolunteer struct {
+	Signup
+	FullName string `json:"full_name"`
+	Phone    string `json:"phone" gorm:"serializer:encrypted"`
+}
+
+// SignupWithSlot is a sign-up as the volunteer sees it
+type SignupWithSlot struct {
+	Signup
+	SlotTitle   string    `json:"slot_title"`
+	EventTitle  string    `json:"event_title"`
+	ServiceDate time.Time `json:"service_date"`
+	StartTime   string    `json:"start_time"`
+	EndTime     string    `json:"end_time"`
+}
+
+// RegisterRequest registers the caller as a volunteer, or updates them
+type RegisterRequest struct {
+	Skills       []string `json:"skills"`
+	Availability string   `json:"availability"`
+}
+
+// StatusRequest sets a volunteer active or inactive
+type StatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// SlotRequest creates or edits a slot
+type SlotRequest struct {
+	EventID     uint   `json:"event_id" binding:"required"`
+	Title       string `json:"title" binding:"required"`
+	Description string `json:"description"`
+	Skill       string `json:"skill"`
+	ServiceDate string `json:"service_date"` // 2006-01-02, the event date when empty
+	StartTime   string `json:"start_time"`
+	EndTime     string `json:"end_time"`
+	Capacity    int    `json:"capacity" binding:"required,gt=0"`
+}
+
+// AttendanceRequest marks whether a volunteer turned up
+type AttendanceRequest struct {
+	Attendance string `json:"attendance" binding:"required"` // present or absent
+}
@@ -0,0 +1,259 @@
+package volunteer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrSlotFull        = errors.New("all places in this slot are taken")
+	ErrAlreadySignedUp = errors.New("already signed up for this slot")
+	ErrNotSignedUp     = errors.New("not signed up for this slot")
+)
+
+// Repository reads and writes volunteers, event slots and sign-ups
+type Repository struct {
+	DB *gorm.DB
+}
+
+// NewRepository returns a new volunteer repository
+func NewRepository(db *gorm.DB) *Repository {
+	return &Repository{DB: db}
+}
+
+// SaveVolunteer creates or updates a volunteer
+func (r *Repository) SaveVolunteer(ctx context.Context, v *Volunteer) error {
+	return r.DB.WithContext(ctx).Save(v).Error
+}
+
+// GetVolunteer loads a volunteer
+func (r *Repository) GetVolunteer(ctx context.Context, id uint) (*Volunteer, error) {
+	var v Volunteer
+	if err := r.DB.WithContext(ctx).First(&v, id).Error; err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// GetByUser loads the user's registration at a temple
+func (r *Repository) GetByUser(ctx context.Context, userID, entityID uint) (*Volunteer, error) {
+	var v Volunteer
+	err := r.DB.WithContext(ctx).
+		Where("user_id = ? AND entity_id = ?", userID, entityID).
+		First(&v).Error
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
+
+// ListVolunteers returns a temple's volunteers by name, optionally those with
+// a status or skill tag, and how many match
+func (r *Repository) ListVolunteers(ctx context.Context, entityID uint, status, skill, search string, page, limit int) ([]VolunteerWithUser, int64, error) {
+	query := r.DB.WithContext(ctx).Table("volunteers v").
+		Joins("JOIN users u ON u.id = v.user_id").
+		Where("v.entity_id = ?", entityID)
+	if status != "" {
+		query = query.Where("v.status = ?", status)
+	}
+	if skill != "" {
+		query = query.Where("v.skills @> jsonb_build_array(?::text)", skill)
+	}
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("u.full_name ILIKE ? OR u.email ILIKE ? OR (u.phone_index <> '' AND u.phone_index = ?)",
+			like, like, fieldcrypto.PhoneIndex(search))
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var out []VolunteerWithUser
+	err := query.Select("v.*, u.full_name, u.email, u.phone").
+		Order("u.full_name ASC, v.id ASC").
+		Limit(limit).Offset((page - 1) * limit).
+		Scan(&out).Error
+	return out, total, err
+}
+
+// EventDate returns the date of the temple's event, ErrRecordNotFound when
+// the event is not the temple's
+func (r *Repository) EventDate(ctx context.Context, eventID, entityID uint) (time.Time, error) {
+	var event struct{ EventDate time.Time }
+	err := r.DB.WithContext(ctx).Table("events").
+		Select("event_date").
+		Where("id = ? AND entity_id = ?", eventID, entityID).
+		Take(&event).Error
+	return event.EventDate, err
+}
+
+// CreateSlot stores a new slot
+func (r *Repository) CreateSlot(ctx context.Context, s *Slot) error {
+	return r.DB.WithContext(ctx).Create(s).Error
+}
+
+// UpdateSlot saves every field of a slot
+func (r *Repository) UpdateSlot(ctx context.Context, s *Slot) error {
+	return r.DB.WithContext(ctx).Save(s).Error
+}
+
+// GetSlot loads a slot with its confirmed sign-ups counted
+func (r *Repository) GetSlot(ctx context.Context, id uint) (*Slot, error) {
+	var s Slot
+	if err := r.DB.WithContext(ctx).First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	count, err := r.countSignups(r.DB.WithContext(ctx), s.ID)
+	s.SignedUp = int(count)
+	return &s, err
+}
+
+// ListSlots returns a temple's slots by date with their sign-ups counted.
+// eventID narrows to one event; upcoming leaves out past and cancelled slots.
+func (r *Repository) ListSlots(ctx context.Context, entityID, eventID uint, upcoming bool) ([]Slot, error) {
+	query := r.DB.WithContext(ctx).Where("entity_id = ?", entityID)
+	if eventID != 0 {
+		query = query.Where("event_id = ?", eventID)
+	}
+	if upcoming {
+		query = query.Where("service_date >= CURRENT_DATE AND cancelled_at IS NULL")
+	}
+	var out []Slot
+	if err := query.Order("service_date ASC, start_time ASC, id ASC").Find(&out).Error; err != nil || len(out) == 0 {
+		return out, err
+	}
+
+	ids := make([]uint, len(out))
+	for i, s := range out {
+		ids[i] = s.ID
+	}
+	var counts []struct {
+		SlotID uint
+		Count  int
+	}
+	err := r.DB.WithContext(ctx).Model(&Signup{}).
+		Select("slot_id, COUNT(*) as count").
+		Where("slot_id IN ? AND status = ?", ids, SignupConfirmed).
+		Group("slot_id").
+		Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	bySlot := make(map[uint]int, len(counts))
+	for _, c := range counts {
+		bySlot[c.SlotID] = c.Count
+	}
+	for i := range out {
+		out[i].SignedUp = bySlot[out[i].ID]
+	}
+	return out, nil
+}
+
+func (r *Repository) countSignups(tx *gorm.DB, slotID uint) (int64, error) {
+	var count int64
+	err := tx.Model(&Signup{}).
+		Where("slot_id = ? AND status = ?", slotID, SignupConfirmed).
+		Count(&count).Error
+	return count, err
+}
+
+// SignUp takes a place in the slot for the volunteer, reusing a cancelled
+// sign-up. The slot is locked so its capacity holds under concurrent sign-ups.
+func (r *Repository) SignUp(ctx context.Context, slotID uint, v *Volunteer) (*Signup, error) {
+	var signup Signup
+	err := r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var slot Slot
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&slot, slotID).Error; err != nil {
+			return err
+		}
+
+		err := tx.Where("slot_id = ? AND volunteer_id = ?", slotID, v.ID).First(&signup).Error
+		switch {
+		case err == nil && signup.Status == SignupConfirmed:
+			return ErrAlreadySignedUp
+		case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		count, err := r.countSignups(tx, slotID)
+		if err != nil {
+			return err
+		}
+		if int(count) >= slot.Capacity {
+			return ErrSlotFull
+		}
+
+		signup.SlotID, signup.VolunteerID = slot.ID, v.ID
+		signup.EventID, signup.EntityID = slot.EventID, slot.EntityID
+		signup.Status, signup.Attendance = SignupConfirmed, AttendancePending
+		signup.MarkedBy, signup.MarkedAt = nil, nil
+		return tx.Save(&signup).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &signup, nil
+}
+
+// CancelSignup gives up the volunteer's place in the slot
+func (r *Repository) CancelSignup(ctx context.Context, slotID, volunteerID uint) error {
+	res := r.DB.WithContext(ctx).Model(&Signup{}).
+		Where("slot_id = ? AND volunteer_id = ? AND status = ?", slotID, volunteerID, SignupConfirmed).
+		Update("status", SignupCancelled)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotSignedUp
+	}
+	return nil
+}
+
+// GetSignup loads a sign-up
+func (r *Repository) GetSignup(ctx context.Context, id uint) (*Signup, error) {
+	var s Signup
+	if err := r.DB.WithContext(ctx).First(&s, id).Error; err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// UpdateSignup saves every field of a sign-up
+func (r *Repository) UpdateSignup(ctx context.Context, s *Signup) error {
+	return r.DB.WithContext(ctx).Save(s).Error
+}
+
+// ListSignups returns the slot's sign-ups with the volunteers' names
+func (r *Repository) ListSignups(ctx context.Context, slotID uint) ([]SignupWithVolunteer, error) {
+	var out []SignupWithVolunteer
+	err := r.DB.WithContext(ctx).Table("event_volunteer_signups s").
+		Select("s.*, u.full_name, u.phone").
+		Joins("JOIN volunteers v ON v.id = s.volunteer_id").
+		Joins("JOIN users u ON u.id = v.user_id").
+		Where("s.slot_id = ?", slotID).
+		Order("s.status ASC, u.full_name ASC").
+		Scan(&out).Error
+	return out, err
+}
+
+// ListUserSignups returns the user's sign-ups at every temple, latest slot
+// first
+func (r *Repository) ListUserSignups(ctx context.Context, userID uint) ([]SignupWithSlot, error) {
+	var out []SignupWithSlot
+	err := r.DB.WithContext(ctx).Table("event_volunteer_signups s").
+		Select(`s.*, sl.title as slot_title, COALESCE(e.title, '') as event_title,
+			sl.service_date, sl.start_time, sl.end_time`).
+		Joins("JOIN volunteers v ON v.id = s.volunteer_id").
+		Joins("JOIN event_volunteer_slots sl ON sl.id = s.slot_id").
+		Joins("LEFT JOIN events e ON e.id = s.event_id").
+		Where("v.user_id = ?", userID).
+		Order("sl.service_date DESC, s.id DESC").
+		Scan(&out).Error
+	return out, err
+}
@@ -0,0 +1,318 @@
+package volunteer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sharath018/temple-management-backend/internal/auditlog"
+	"gorm.io/gorm"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	maxSkills  = 20
+)
+
+var clockPattern = regexp.MustCompile(`^([01]\d|2[0-3]):[0-5]\d$`)
+
+var (
+	ErrTooManySkills     = errors.New("at most 20 skills may be listed")
+	ErrInvalidStatus     = errors.New("status must be active or inactive")
+	ErrNotRegistered     = errors.New("register as a volunteer of this temple first")
+	ErrVolunteerInactive = errors.New("your volunteer registration at this temple is inactive")
+	ErrInvalidEvent      = errors.New("event not found for this temple")
+	ErrInvalidSlotTime   = errors.New("service_date must be yyyy-mm-dd and times HH:MM, with the end after the start")
+	ErrSlotClosed        = errors.New("this slot is cancelled or already over")
+	ErrSkillRequired     = errors.New("this slot needs a skill you have not listed")
+	ErrCapacityTooLow    = errors.New("capacity is below the number already signed up")
+	ErrInvalidAttendance = errors.New("attendance must be present or absent")
+	ErrAttendanceTooSoon = errors.New("attendance can be marked from the service date")
+	ErrSignupCancelled   = errors.New("the sign-up was cancelled")
+)
+
+// Service runs volunteer registration, event slots and attendance
+type Service struct {
+	Repo  *Repository
+	Audit auditlog.Service
+}
+
+// NewService initializes the volunteer service
+func NewService(repo *Repository, auditSvc auditlog.Service) *Service {
+	return &Service{Repo: repo, Audit: auditSvc}
+}
+
+// normalizeSkills lower-cases skill tags, joins words with underscores and
+// drops blanks and repeats
+func normalizeSkills(skills []string) ([]string, error) {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, s := range skills {
+		tag := strings.Join(strings.Fields(strings.ToLower(s)), "_")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		out = append(out, tag)
+	}
+	if len(out) > maxSkills {
+		return nil, ErrTooManySkills
+	}
+	return out, nil
+}
+
+// Register signs the user up as a volunteer of the temple, or updates the
+// skills and availability of an existing registration
+func (s *Service) Register(ctx context.Context, userID, entityID uint, req RegisterRequest, ip string) (*Volunteer, error) {
+	skills, err := normalizeSkills(req.Skills)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := json.Marshal(skills)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.Repo.GetByUser(ctx, userID, entityID)
+	action := "VOLUNTEER_UPDATED"
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		v = &Volunteer{UserID: userID, EntityID: entityID, Status: StatusActive}
+		action = "VOLUNTEER_REGISTERED"
+	case err != nil:
+		return nil, err
+	}
+	v.Skills = tags
+	v.Availability = strings.TrimSpace(req.Availability)
+	if err := s.Repo.SaveVolunteer(ctx, v); err != nil {
+		return nil, err
+	}
+
+	s.Audit.LogAction(ctx, &userID, &entityID, action, map[string]interface{}{
+		"volunteer_id": v.ID,
+		"skills":       skills,
+	}, ip, "success")
+	return v, nil
+}
+
+// SetStatus activates or deactivates a volunteer
+func (s *Service) SetStatus(ctx context.Context, v *Volunteer, status string, userID uint, ip string) error {
+	if status != StatusActive && status != StatusInactive {
+		return ErrInvalidStatus
+	}
+	from := v.Status
+	v.Status = status
+	if err := s.Repo.SaveVolunteer(ctx, v); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &v.EntityID, "VOLUNTEER_STATUS_CHANGED", map[string]interface{}{
+		"volunteer_id": v.ID,
+		"from":         from,
+		"to":           status,
+	}, ip, "success")
+	return nil
+}
+
+// applySlot validates req and copies it onto slot
+func (s *Service) applySlot(ctx context.Context, slot *Slot, req SlotRequest) error {
+	eventDate, err := s.Repo.EventDate(ctx, req.EventID, slot.EntityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidEvent
+	}
+	if err != nil {
+		return err
+	}
+
+	date := time.Date(eventDate.Year(), eventDate.Month(), eventDate.Day(), 0, 0, 0, 0, time.UTC)
+	if req.ServiceDate != "" {
+		if date, err = time.Parse(dateLayout, req.ServiceDate); err != nil {
+			return ErrInvalidSlotTime
+		}
+	}
+	for _, t := range []string{req.StartTime, req.EndTime} {
+		if t != "" && !clockPattern.MatchString(t) {
+			return ErrInvalidSlotTime
+		}
+	}
+	if req.StartTime != "" && req.EndTime != "" && req.EndTime <= req.StartTime {
+		return ErrInvalidSlotTime
+	}
+	skill, _ := normalizeSkills([]string{req.Skill})
+
+	slot.EventID = req.EventID
+	slot.Title = strings.TrimSpace(req.Title)
+	slot.Description = strings.TrimSpace(req.Description)
+	slot.Skill = ""
+	if len(skill) == 1 {
+		slot.Skill = skill[0]
+	}
+	slot.ServiceDate = date
+	slot.StartTime, slot.EndTime = req.StartTime, req.EndTime
+	slot.Capacity = req.Capacity
+	return nil
+}
+
+// CreateSlot adds a volunteering slot to one of the temple's events
+func (s *Service) CreateSlot(ctx context.Context, entityID uint, req SlotRequest, userID uint, ip string) (*Slot, error) {
+	slot := &Slot{EntityID: entityID, CreatedBy: userID}
+	if err := s.applySlot(ctx, slot, req); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.CreateSlot(ctx, slot); err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &entityID, "VOLUNTEER_SLOT_CREATED", map[string]interface{}{
+		"slot_id":      slot.ID,
+		"event_id":     slot.EventID,
+		"title":        slot.Title,
+		"capacity":     slot.Capacity,
+		"service_date": slot.ServiceDate.Format(dateLayout),
+	}, ip, "success")
+	return slot, nil
+}
+
+// UpdateSlot edits a slot; its capacity cannot drop below those signed up
+func (s *Service) UpdateSlot(ctx context.Context, slot *Slot, req SlotRequest, userID uint, ip string) error {
+	if slot.CancelledAt != nil {
+		return ErrSlotClosed
+	}
+	if req.Capacity < slot.SignedUp {
+		return ErrCapacityTooLow
+	}
+	if err := s.applySlot(ctx, slot, req); err != nil {
+		return err
+	}
+	if err := s.Repo.UpdateSlot(ctx, slot); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &slot.EntityID, "VOLUNTEER_SLOT_UPDATED", map[string]interface{}{
+		"slot_id":  slot.ID,
+		"title":    slot.Title,
+		"capacity": slot.Capacity,
+	}, ip, "success")
+	return nil
+}
+
+// CancelSlot withdraws a slot; its sign-ups stay for the record
+func (s *Service) CancelSlot(ctx context.Context, slot *Slot, userID uint, ip string) error {
+	if slot.CancelledAt != nil {
+		return ErrSlotClosed
+	}
+	now := time.Now()
+	slot.CancelledAt = &now
+	if err := s.Repo.UpdateSlot(ctx, slot); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &slot.EntityID, "VOLUNTEER_SLOT_CANCELLED", map[string]interface{}{
+		"slot_id":   slot.ID,
+		"signed_up": slot.SignedUp,
+	}, ip, "success")
+	return nil
+}
+
+// open reports whether volunteers may still sign up for or leave the slot
+func open(slot *Slot) bool {
+	today := time.Now().Format(dateLayout)
+	return slot.CancelledAt == nil && slot.ServiceDate.Format(dateLayout) >= today
+}
+
+// SignUp takes a place in the slot for the user, who must be an active
+// volunteer of the temple with the slot's skill
+func (s *Service) SignUp(ctx context.Context, userID uint, slot *Slot, ip string) (*Signup, error) {
+	if !open(slot) {
+		return nil, ErrSlotClosed
+	}
+	v, err := s.volunteerFor(ctx, userID, slot.EntityID)
+	if err != nil {
+		return nil, err
+	}
+	if slot.Skill != "" {
+		var skills []string
+		_ = json.Unmarshal(v.Skills, &skills)
+		has := false
+		for _, skill := range skills {
+			has = has || skill == slot.Skill
+		}
+		if !has {
+			return nil, ErrSkillRequired
+		}
+	}
+
+	signup, err := s.Repo.SignUp(ctx, slot.ID, v)
+	if err != nil {
+		return nil, err
+	}
+	s.Audit.LogAction(ctx, &userID, &slot.EntityID, "VOLUNTEER_SIGNED_UP", map[string]interface{}{
+		"slot_id":      slot.ID,
+		"event_id":     slot.EventID,
+		"volunteer_id": v.ID,
+		"signup_id":    signup.ID,
+	}, ip, "success")
+	return signup, nil
+}
+
+// CancelSignup gives up the user's place in the slot
+func (s *Service) CancelSignup(ctx context.Context, userID uint, slot *Slot, ip string) error {
+	if !open(slot) {
+		return ErrSlotClosed
+	}
+	v, err := s.Repo.GetByUser(ctx, userID, slot.EntityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotSignedUp
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.CancelSignup(ctx, slot.ID, v.ID); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &slot.EntityID, "VOLUNTEER_SIGNUP_CANCELLED", map[string]interface{}{
+		"slot_id":      slot.ID,
+		"volunteer_id": v.ID,
+	}, ip, "success")
+	return nil
+}
+
+// MarkAttendance records whether the volunteer turned up, from the slot's
+// service date on
+func (s *Service) MarkAttendance(ctx context.Context, signup *Signup, slot *Slot, attendance string, userID uint, ip string) error {
+	if attendance != AttendancePresent && attendance != AttendanceAbsent {
+		return ErrInvalidAttendance
+	}
+	if signup.Status != SignupConfirmed {
+		return ErrSignupCancelled
+	}
+	if slot.ServiceDate.Format(dateLayout) > time.Now().Format(dateLayout) {
+		return ErrAttendanceTooSoon
+	}
+	now := time.Now()
+	signup.Attendance, signup.MarkedBy, signup.MarkedAt = attendance, &userID, &now
+	if err := s.Repo.UpdateSignup(ctx, signup); err != nil {
+		return err
+	}
+	s.Audit.LogAction(ctx, &userID, &signup.EntityID, "VOLUNTEER_ATTENDANCE_MARKED", map[string]interface{}{
+		"signup_id":    signup.ID,
+		"slot_id":      signup.SlotID,
+		"volunteer_id": signup.VolunteerID,
+		"attendance":   attendance,
+	}, ip, "success")
+	return nil
+}
+
+// volunteerFor loads the user's active registration at the temple
+func (s *Service) volunteerFor(ctx context.Context, userID, entityID uint) (*Volunteer, error) {
+	v, err := s.Repo.GetByUser(ctx, userID, entityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotRegistered
+	}
+	if err != nil {
+		return nil, err
+	}
+	if v.Status != StatusActive {
+		return nil, ErrVolunteerInactive
+	}
+	return v, nil
+}
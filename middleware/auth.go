@@ -12,10 +12,28 @@ import (
 	"github.com/sharath018/temple-management-backend/internal/auth"
 )
 
+// APIKeyContextKey holds the key ID of requests authenticated with an API
+// key (apikey.Middleware). They already carry the key's tenant as user and
+// access context.
+const APIKeyContextKey = "api_key_id"
+
 // AuthMiddleware handles JWT authentication and sets up access context
 func AuthMiddleware(cfg *config.Config, authSvc auth.Service) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if c.GetString(APIKeyContextKey) != "" {
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
+
+		// EventSource cannot send headers; SSE requests may pass the access token as ?token=
+		if authHeader == "" && strings.Contains(c.GetHeader("Accept"), "text/event-stream") {
+			if token := c.Query("token"); token != "" {
+				authHeader = "Bearer " + token
+			}
+		}
+
 		if authHeader == "" {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing Authorization header"})
 			return
@@ -49,6 +67,11 @@ func AuthMiddleware(cfg *config.Config, authSvc auth.Service) gin.HandlerFunc {
 		}
 
 		userID := uint(userIDFloat)
+		if authSvc.IsTokenRevoked(userID, claims) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+			return
+		}
+
 		user, err := authSvc.GetUserByID(userID)
 		if err != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
@@ -60,11 +83,18 @@ func AuthMiddleware(cfg *config.Config, authSvc auth.Service) gin.HandlerFunc {
 		c.Set("user_id", user.ID)
 		c.Set("claims", claims)
 
+		// The temples a templeadmin or their staff works at; nil for other roles
+		entityIDs, err := authSvc.StaffEntityIDs(&user)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to load temples"})
+			return
+		}
+
 		// Determine correct entity ID
-		entityID := ResolveEntityIDForOperation(c, user, claims)
+		entityID := ResolveEntityIDForOperation(c, user, claims, entityIDs)
 
 		// Create access context (now includes TenantID)
-		accessContext := CreateAccessContext(c, user, claims, entityID)
+		accessContext := CreateAccessContext(c, user, claims, entityID, entityIDs)
 		c.Set("access_context", accessContext)
 
 		// Set resolved entity ID for quick access
@@ -76,11 +106,35 @@ func AuthMiddleware(cfg *config.Config, authSvc auth.Service) gin.HandlerFunc {
 	}
 }
 
-// ResolveEntityIDForOperation determines the correct entity ID for the current operation
-func ResolveEntityIDForOperation(c *gin.Context, user auth.User, claims jwt.MapClaims) *uint {
+// ResolveEntityIDForOperation determines the correct entity ID for the current operation.
+// entityIDs are the caller's temples from auth.Service.StaffEntityIDs: a
+// temple the request names (header, path or query) or the switched-to temple
+// outside them is ignored. Nil entityIDs leave the request's choice unchecked.
+func ResolveEntityIDForOperation(c *gin.Context, user auth.User, claims jwt.MapClaims, entityIDs []uint) *uint {
+	allowed := func(id uint) bool {
+		if entityIDs == nil {
+			return true
+		}
+		for _, e := range entityIDs {
+			if e == id {
+				return true
+			}
+		}
+		// Standard and monitoring users may also name their tenant
+		if user.Role.RoleName == RoleStandardUser || user.Role.RoleName == RoleMonitoringUser {
+			for _, key := range []string{"tenant_id", "assigned_tenant_id"} {
+				if tenantID := claimUint(claims, key); tenantID != nil && *tenantID == id {
+					return true
+				}
+			}
+		}
+		fmt.Printf("⚠️ %s %d named entity %d outside their temples, ignoring it\n", user.Role.RoleName, user.ID, id)
+		return false
+	}
+
 	// Priority 1: X-Entity-ID header
 	if entityHeader := c.GetHeader("X-Entity-ID"); entityHeader != "" && entityHeader != "all" {
-		if eid, err := strconv.ParseUint(entityHeader, 10, 32); err == nil {
+		if eid, err := strconv.ParseUint(entityHeader, 10, 32); err == nil && allowed(uint(eid)) {
 			id := uint(eid)
 			fmt.Printf("%s using entity ID from X-Entity-ID header: %d\n", user.Role.RoleName, id)
 			return &id
@@ -88,21 +142,29 @@ func ResolveEntityIDForOperation(c *gin.Context, user auth.User, claims jwt.MapC
 	}
 
 	// Priority 2: Entity ID from URL path (/entity/123/...)
-	if entityIDFromPath := ExtractEntityIDFromPath(c); entityIDFromPath != nil {
+	if entityIDFromPath := ExtractEntityIDFromPath(c); entityIDFromPath != nil && allowed(*entityIDFromPath) {
 		fmt.Printf("%s using entity ID from URL path: %d\n", user.Role.RoleName, *entityIDFromPath)
 		return entityIDFromPath
 	}
 
 	// Priority 3: Query parameter entity_id
 	if entityQuery := c.Query("entity_id"); entityQuery != "" && entityQuery != "all" {
-		if eid, err := strconv.ParseUint(entityQuery, 10, 32); err == nil {
+		if eid, err := strconv.ParseUint(entityQuery, 10, 32); err == nil && allowed(uint(eid)) {
 			id := uint(eid)
 			fmt.Printf("%s using entity ID from query parameter: %d\n", user.Role.RoleName, id)
 			return &id
 		}
 	}
 
-	// Priority 4: Role-specific fallback logic
+	// Priority 4: The temple the user switched to (POST /auth/switch-entity)
+	if user.Role.RoleName == RoleTempleAdmin || user.Role.RoleName == RoleStandardUser || user.Role.RoleName == RoleMonitoringUser {
+		if active := claimUint(claims, "active_entity_id"); active != nil && allowed(*active) {
+			fmt.Printf("%s using active entity ID: %d\n", user.Role.RoleName, *active)
+			return active
+		}
+	}
+
+	// Priority 5: Role-specific fallback logic
 	switch user.Role.RoleName {
 	case RoleSuperAdmin:
 		if tenantID := ResolveTenantIDFromRequest(c, claims); tenantID != nil {
@@ -183,11 +245,13 @@ func ExtractEntityIDFromPath(c *gin.Context) *uint {
 	return nil
 }
 
-// CreateAccessContext creates the access context with proper entity + tenant resolution
-func CreateAccessContext(c *gin.Context, user auth.User, claims jwt.MapClaims, entityID *uint) AccessContext {
+// CreateAccessContext creates the access context with proper entity + tenant resolution.
+// entityIDs are the caller's temples, as given to ResolveEntityIDForOperation.
+func CreateAccessContext(c *gin.Context, user auth.User, claims jwt.MapClaims, entityID *uint, entityIDs []uint) AccessContext {
 	accessContext := AccessContext{
-		UserID:   user.ID,
-		RoleName: user.Role.RoleName,
+		UserID:    user.ID,
+		RoleName:  user.Role.RoleName,
+		EntityIDs: entityIDs,
 	}
 
 	switch user.Role.RoleName {
@@ -198,6 +262,9 @@ func CreateAccessContext(c *gin.Context, user auth.User, claims jwt.MapClaims, e
 	case RoleTempleAdmin:
 		accessContext.PermissionType = "full"
 		accessContext.DirectEntityID = user.EntityID
+		if active := claimUint(claims, "active_entity_id"); active != nil && (entityIDs == nil || accessContext.HasEntity(*active)) {
+			accessContext.DirectEntityID = active
+		}
 		accessContext.AssignedEntityID = entityID
 
 	case RoleStandardUser:
@@ -208,6 +275,11 @@ func CreateAccessContext(c *gin.Context, user auth.User, claims jwt.MapClaims, e
 		accessContext.PermissionType = "readonly"
 		accessContext.AssignedEntityID = entityID
 
+	case RoleOrgAdmin:
+		// Organization admins work across their organization's tenants
+		accessContext.PermissionType = "full"
+		accessContext.AssignedEntityID = entityID
+
 	case RoleDevotee, RoleVolunteer:
 		accessContext.PermissionType = "readonly"
 		if entityID != nil {
@@ -217,16 +289,29 @@ func CreateAccessContext(c *gin.Context, user auth.User, claims jwt.MapClaims, e
 		}
 	}
 
-	// ✅ Extract TenantID (important for standard & monitoring users)
-// ✅ Extract TenantID (important for standard & monitoring users)
-if tenantIDFloat, ok := claims["tenant_id"].(float64); ok {
-	accessContext.TenantID = uint(tenantIDFloat)
-} else if assignedTenantIDFloat, ok := claims["assigned_tenant_id"].(float64); ok {
-	accessContext.TenantID = uint(assignedTenantIDFloat)
-}
+	// ✅ Extract TenantID: a templeadmin is their own tenant, standard and
+	// monitoring users work for the tenant they are assigned to
+	switch {
+	case user.Role.RoleName == RoleTempleAdmin:
+		accessContext.TenantID = user.ID
+	case claimUint(claims, "tenant_id") != nil:
+		accessContext.TenantID = *claimUint(claims, "tenant_id")
+	case claimUint(claims, "assigned_tenant_id") != nil:
+		accessContext.TenantID = *claimUint(claims, "assigned_tenant_id")
+	}
 
 	fmt.Printf("✅ AccessContext initialized: Role=%s, TenantID=%d, EntityID=%v\n",
 		accessContext.RoleName, accessContext.TenantID, accessContext.AssignedEntityID)
 
 	return accessContext
 }
+
+// claimUint returns a positive numeric claim, or nil when it is missing
+func claimUint(claims jwt.MapClaims, key string) *uint {
+	v, ok := claims[key].(float64)
+	if !ok || v <= 0 {
+		return nil
+	}
+	id := uint(v)
+	return &id
+}
@@ -0,0 +1,251 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Responses with these content types are already compressed or are file
+// downloads, and are passed through as is
+var uncompressibleTypes = []string{
+	"application/zip",
+	"application/gzip",
+	"application/pdf",
+	"application/octet-stream",
+	"application/vnd.openxmlformats-officedocument", // xlsx, docx: zip containers
+	"image/",
+	"video/",
+	"audio/",
+	"text/event-stream",
+}
+
+var (
+	gzipPool  = sync.Pool{New: func() any { w, _ := gzip.NewWriterLevel(io.Discard, gzip.DefaultCompression); return w }}
+	flatePool = sync.Pool{New: func() any { w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression); return w }}
+)
+
+// Compress gzip or deflate encodes responses of at least minSize bytes for
+// clients that accept it. Bodies are buffered up to minSize to decide, so
+// small responses go out unchanged. Requests under skipPaths, responses that
+// set Content-Length or Content-Disposition (file and zip downloads) or an
+// uncompressible type, and streams that flush early are never compressed.
+// A negative minSize disables compression.
+func Compress(minSize int, skipPaths ...string) gin.HandlerFunc {
+	if minSize < 0 {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return func(c *gin.Context) {
+		encoding := acceptedEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead || c.GetHeader("Range") != "" || hasPathPrefix(c.Request.URL.Path, skipPaths) {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize}
+		c.Writer = w
+		defer w.finish()
+		c.Next()
+	}
+}
+
+// acceptedEncoding picks gzip, then deflate, from an Accept-Encoding header
+func acceptedEncoding(header string) string {
+	accepted := map[string]bool{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				continue // explicitly refused
+			}
+		}
+		accepted[strings.ToLower(name)] = true
+	}
+	switch {
+	case accepted["gzip"]:
+		return "gzip"
+	case accepted["deflate"]:
+		return "deflate"
+	}
+	return ""
+}
+
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// compressWriter holds the status and the first minSize bytes back until it
+// knows whether to compress. Size reports the uncompressed payload, which
+// the metrics middleware records per route.
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	size    int
+	decided bool
+	enc     encoder
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if !w.decided {
+		w.status = code
+		return
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *compressWriter) Status() int {
+	if !w.decided && w.status != 0 {
+		return w.status
+	}
+	return w.ResponseWriter.Status()
+}
+
+func (w *compressWriter) Size() int {
+	if w.size == 0 && !w.Written() {
+		return -1
+	}
+	return w.size
+}
+
+func (w *compressWriter) Written() bool {
+	return w.decided || len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	w.size += len(p)
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) >= w.minSize {
+			if err := w.decide(); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+	if w.enc != nil {
+		return w.enc.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Flush sends what is buffered. A response flushed before reaching minSize
+// is a stream and is passed through uncompressed.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.enc != nil {
+		w.enc.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide forwards the held status and buffer, compressed or not
+func (w *compressWriter) decide() error {
+	w.decided = true
+	if len(w.buf) >= w.minSize && w.compressible() {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Add("Vary", "Accept-Encoding")
+		h.Del("Content-Length")
+		w.enc = w.newEncoder()
+	}
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	status := w.status
+	if status == 0 {
+		status = w.ResponseWriter.Status()
+	}
+	if status < http.StatusOK || status == http.StatusNoContent || status == http.StatusNotModified {
+		return false
+	}
+	h := w.Header()
+	if h.Get("Content-Encoding") != "" || h.Get("Content-Length") != "" || h.Get("Content-Disposition") != "" {
+		return false
+	}
+	contentType := strings.ToLower(h.Get("Content-Type"))
+	for _, t := range uncompressibleTypes {
+		if strings.HasPrefix(contentType, t) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *compressWriter) newEncoder() encoder {
+	if w.encoding == "gzip" {
+		gz := gzipPool.Get().(*gzip.Writer)
+		gz.Reset(w.ResponseWriter)
+		return gz
+	}
+	fl := flatePool.Get().(*flate.Writer)
+	fl.Reset(w.ResponseWriter)
+	return fl
+}
+
+// finish sends a response that stayed under minSize and closes the encoder
+func (w *compressWriter) finish() {
+	if !w.decided {
+		w.decide()
+	}
+	if w.enc == nil {
+		return
+	}
+	w.enc.Close()
+	switch enc := w.enc.(type) {
+	case *gzip.Writer:
+		enc.Reset(io.Discard)
+		gzipPool.Put(enc)
+	case *flate.Writer:
+		enc.Reset(io.Discard)
+		flatePool.Put(enc)
+	}
+	w.enc = nil
+}
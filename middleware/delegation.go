@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DelegatedGrant is a temple-scoped staff role held by a devotee or volunteer
+type DelegatedGrant struct {
+	EntityID uint
+	TenantID uint // the templeadmin that owns the temple
+	Role     string
+}
+
+// DelegationChecker finds the staff role allowing the user an area of a
+// temple. Without an entity it looks for the user's only temple with one.
+type DelegationChecker interface {
+	DelegatedGrant(ctx context.Context, userID uint, entityID *uint, permission string) (*DelegatedGrant, error)
+}
+
+// Delegated lets devotees and volunteers that a temple admin made staff
+// (finance operator, event manager, ...) work on one area of that temple.
+// With a matching role the request continues as a standard user of the
+// temple with full access; without one it continues unchanged, so the route's
+// own checks apply. Place it before RequireTempleAccess and RBACMiddleware.
+func Delegated(checker DelegationChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		accessVal, exists := c.Get("access_context")
+		if !exists {
+			c.Next()
+			return
+		}
+		access, ok := accessVal.(AccessContext)
+		if !ok || (access.RoleName != RoleDevotee && access.RoleName != RoleVolunteer) {
+			c.Next()
+			return
+		}
+
+		grant, err := checker.DelegatedGrant(c.Request.Context(), access.UserID, requestedEntityID(c), permission)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "failed to check staff roles"})
+			return
+		}
+		if grant == nil {
+			c.Next()
+			return
+		}
+
+		entityID := grant.EntityID
+		access.RoleName = RoleStandardUser
+		access.DelegatedRole = grant.Role
+		access.AssignedEntityID = &entityID
+		access.EntityIDs = []uint{entityID}
+		access.PermissionType = "full"
+		access.TenantID = grant.TenantID
+		c.Set("access_context", access)
+		c.Set("entity_id", entityID)
+		c.Set("delegated_role", grant.Role)
+		c.Next()
+	}
+}
+
+// isDelegated reports whether Delegated granted the request a staff role
+func isDelegated(c *gin.Context) bool {
+	return c.GetString("delegated_role") != ""
+}
+
+// requestedEntityID returns the temple the request names explicitly, nil
+// when it relies on the user's defaults
+func requestedEntityID(c *gin.Context) *uint {
+	for _, v := range []string{c.GetHeader("X-Entity-ID"), c.Query("entity_id")} {
+		if v == "" || v == "all" {
+			continue
+		}
+		if id, err := strconv.ParseUint(v, 10, 32); err == nil {
+			entityID := uint(id)
+			return &entityID
+		}
+	}
+	return ExtractEntityIDFromPath(c)
+}
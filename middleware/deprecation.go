@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/config"
+)
+
+// Deprecations announces endpoints slated for removal. A request matching an
+// entry of deprecations, by "METHOD /route" or else by the longest path
+// prefix, is answered as usual with
+//
+//	Deprecation: true
+//	Sunset: <date>                                  (RFC 8594)
+//	Link: </api/v2/...>; rel="successor-version"
+//
+// Requests past the sunset date still succeed; the headers tell clients the
+// route may go away at any time.
+func Deprecations(deprecations map[string]config.APIDeprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if d, ok := FindDeprecation(deprecations, c.Request.Method, c.FullPath(), c.Request.URL.Path); ok {
+			SetDeprecationHeaders(c, d)
+		}
+		c.Next()
+	}
+}
+
+// FindDeprecation looks up the entry for a route, falling back to the
+// longest prefix of its path
+func FindDeprecation(deprecations map[string]config.APIDeprecation, method, route, path string) (config.APIDeprecation, bool) {
+	if len(deprecations) == 0 {
+		return config.APIDeprecation{}, false
+	}
+	if route != "" {
+		if d, ok := deprecations[method+" "+route]; ok {
+			return d, true
+		}
+		if d, ok := deprecations[route]; ok {
+			return d, true
+		}
+	}
+	var (
+		found config.APIDeprecation
+		best  = -1
+	)
+	for prefix, d := range deprecations {
+		if strings.Contains(prefix, " ") || len(prefix) <= best {
+			continue
+		}
+		if path == prefix || strings.HasPrefix(path, strings.TrimSuffix(prefix, "/")+"/") {
+			found, best = d, len(prefix)
+		}
+	}
+	return found, best >= 0
+}
+
+// SetDeprecationHeaders marks the response of a deprecated endpoint
+func SetDeprecationHeaders(c *gin.Context, d config.APIDeprecation) {
+	c.Header("Deprecation", "true")
+	if !d.Sunset.IsZero() {
+		c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if d.Successor != "" {
+		c.Writer.Header().Add("Link", "<"+d.Successor+`>; rel="successor-version"`)
+	}
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// Set on responses replayed from an earlier request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	idempotencyKeyPrefix = "idempotency"
+	idempotencyMaxKeyLen = 255
+	// A request still running after this long is presumed lost and its key
+	// may be retried
+	idempotencyLockTTL = 2 * time.Minute
+)
+
+// idempotencyRecord is kept in Redis per key: while the first request runs
+// it only holds the request fingerprint, afterwards also its response
+type idempotencyRecord struct {
+	Fingerprint string `json:"fingerprint"`
+	Done        bool   `json:"done"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency makes a creating endpoint safe to retry. A request carrying an
+// Idempotency-Key runs once per user and route; repeating it with the same
+// key within ttl returns the first response, marked Idempotent-Replayed,
+// instead of creating a duplicate. Reusing a key for a different request body
+// is rejected with 422, and a repeat arriving while the first is still
+// running gets 409. Server errors are not stored, so those requests may be
+// retried with the same key. Requests without the header, or while Redis is
+// unavailable, run as usual.
+func Idempotency(ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > idempotencyMaxKeyLen {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Idempotency-Key must be at most 255 characters"})
+			return
+		}
+		if utils.RedisClient == nil {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		redisKey := idempotencyRedisKey(ByUser(c), c.Request.Method, c.FullPath(), key)
+		bodySum := sha256.Sum256(body)
+		fingerprint := hex.EncodeToString(bodySum[:])
+
+		pending, _ := json.Marshal(idempotencyRecord{Fingerprint: fingerprint})
+		acquired, err := utils.RedisClient.SetNX(ctx, redisKey, pending, idempotencyLockTTL).Result()
+		if err != nil {
+			log.Printf("⚠️ Idempotency check failed for %s %s, running the request: %v", c.Request.Method, c.FullPath(), err)
+			c.Next()
+			return
+		}
+		if !acquired {
+			replayIdempotent(c, redisKey, fingerprint)
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Detached from the request so a client hanging up doesn't leave the key locked
+		storeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			utils.RedisClient.Del(storeCtx, redisKey)
+			return
+		}
+		done, _ := json.Marshal(idempotencyRecord{
+			Fingerprint: fingerprint,
+			Done:        true,
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        recorder.body.Bytes(),
+		})
+		if err := utils.RedisClient.Set(storeCtx, redisKey, done, ttl).Err(); err != nil {
+			log.Printf("⚠️ Failed to store idempotent response for %s %s: %v", c.Request.Method, c.FullPath(), err)
+		}
+	}
+}
+
+// replayIdempotent answers a request whose key was already used
+func replayIdempotent(c *gin.Context, redisKey, fingerprint string) {
+	raw, err := utils.RedisClient.Get(c.Request.Context(), redisKey).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// The first request failed and released the key in the meantime
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key was just retried, please try again"})
+		return
+	}
+	var record idempotencyRecord
+	if err == nil {
+		err = json.Unmarshal(raw, &record)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to read idempotent response %s: %v", redisKey, err)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Could not check the Idempotency-Key, please try again"})
+		return
+	}
+
+	switch {
+	case record.Fingerprint != fingerprint:
+		c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"error": "Idempotency-Key was already used for a different request"})
+	case !record.Done:
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "A request with this Idempotency-Key is still being processed"})
+	default:
+		c.Header(IdempotentReplayedHeader, "true")
+		c.Data(record.Status, record.ContentType, record.Body)
+		c.Abort()
+	}
+}
+
+func idempotencyRedisKey(scope, method, route, key string) string {
+	sum := sha256.Sum256([]byte(scope + "\n" + method + " " + route + "\n" + key))
+	return idempotencyKeyPrefix + ":" + hex.EncodeToString(sum[:])
+}
+
+// idempotencyRecorder keeps a copy of the response body as it is written
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -3,6 +3,7 @@ package middleware
 import (
 	"strconv"
 	"fmt"
+	"net/http"
 	
 	"github.com/gin-gonic/gin"
 )
@@ -15,16 +16,26 @@ const (
 	RoleMonitoringUser = "monitoringuser"
 	RoleDevotee        = "devotee"
 	RoleVolunteer      = "volunteer"
+	RoleOrgAdmin       = "orgadmin"
 )
 
 // AccessContext stores user access information
 type AccessContext struct {
 	UserID           uint
 	RoleName         string
-	DirectEntityID   *uint  // User's own entity (for templeadmin)
+	DirectEntityID   *uint  // User's own entity; for templeadmin the temple switched to, else their first
 	AssignedEntityID *uint  // Assigned tenant entity (for standarduser/monitoringuser)
 	PermissionType   string // "full" or "readonly"
-	TenantID        uint 
+	// TenantID is the templeadmin account that owns the temples: the
+	// templeadmin themself, or the tenant a standard/monitoring user is assigned to
+	TenantID uint
+	// DelegatedRole is the temple staff role a devotee or volunteer works
+	// under on this request (see Delegated)
+	DelegatedRole string
+	// EntityIDs are the temples a templeadmin or standard/monitoring user
+	// really works at, loaded from the database by AuthMiddleware. Nil for
+	// other roles.
+	EntityIDs []uint
 }
 
 // GetAccessibleEntityID returns the entity ID the user can access
@@ -57,12 +68,27 @@ func (ac *AccessContext) CanRead() bool {
 	return ac.PermissionType == "full" || ac.PermissionType == "readonly"
 }
 
+// HasEntity reports whether the temple is one of the caller's EntityIDs
+func (ac *AccessContext) HasEntity(entityID uint) bool {
+	for _, id := range ac.EntityIDs {
+		if id == entityID {
+			return true
+		}
+	}
+	return false
+}
+
 // CanAccessEntity checks if the user can access a specific entity
 func (ac *AccessContext) CanAccessEntity(entityID uint) bool {
 	// SuperAdmin can access any entity
 	if ac.RoleName == RoleSuperAdmin {
 		return true
 	}
+
+	// Temple staff only reach the temples they work at, whatever the request names
+	if ac.EntityIDs != nil {
+		return ac.HasEntity(entityID)
+	}
 	
 	// Check if this entity matches user's accessible entity
 	accessibleEntityID := ac.GetAccessibleEntityID()
@@ -72,6 +98,48 @@ func (ac *AccessContext) CanAccessEntity(entityID uint) bool {
 	
 	return false
 }
+
+// IsEntityStaff reports whether the caller works at the temple: superadmins,
+// or its admin and staff with read access. Devotees and volunteers are not
+// staff, unless Delegated made them so for the request.
+func (ac *AccessContext) IsEntityStaff(entityID uint) bool {
+	if ac.RoleName == RoleSuperAdmin {
+		return true
+	}
+	if ac.RoleName == RoleDevotee || ac.RoleName == RoleVolunteer || !ac.CanRead() {
+		return false
+	}
+	return ac.CanAccessEntity(entityID)
+}
+
+// CanManageEntity reports whether the caller may change the temple's
+// records: its staff with write access. Handlers check it on every write so
+// that a read-only user is refused even on a route without RequireWriteAccess.
+func (ac *AccessContext) CanManageEntity(entityID uint) bool {
+	return ac.IsEntityStaff(entityID) && ac.CanWrite()
+}
+
+// RequestEntityID is the temple of the request: the entity_id query parameter
+// when given, else the caller's own, or their only temple when the own one is
+// a tenant. Callers still check access to it. It writes the 400 response itself.
+func RequestEntityID(c *gin.Context, ac AccessContext) (uint, bool) {
+	if v := c.Query("entity_id"); v != "" {
+		id, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid entity_id"})
+			return 0, false
+		}
+		return uint(id), true
+	}
+	if id := ac.GetAccessibleEntityID(); id != nil && (ac.EntityIDs == nil || ac.HasEntity(*id)) {
+		return *id, true
+	}
+	if len(ac.EntityIDs) == 1 {
+		return ac.EntityIDs[0], true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "entity_id is required"})
+	return 0, false
+}
 /*
 // ResolveAccessContext helper to create access context from user and assignment
 func ResolveAccessContext(user interface{}, assignedTenantID *uint) AccessContext {
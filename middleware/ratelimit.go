@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/config"
+	"github.com/sharath018/temple-management-backend/utils"
+)
+
+// Rate limited route groups, configured through config.RateLimits
+const (
+	RateLimitLogin        = "login"
+	RateLimitReportExport = "report-export"
+	RateLimitUpload       = "upload"
+	RateLimitPublic       = "public"  // unauthenticated directory reads
+	RateLimitAPIKey       = "api-key" // third-party integrations, per key
+)
+
+const rateLimitKeyPrefix = "ratelimit"
+
+// RateLimitKey picks the counter a request is charged to
+type RateLimitKey func(c *gin.Context) string
+
+// ByIP charges requests to the client IP
+func ByIP(c *gin.Context) string {
+	return "ip:" + GetIPFromContext(c)
+}
+
+// ByUser charges requests to the authenticated user, or to the client IP
+// when the route has no user
+func ByUser(c *gin.Context) string {
+	if userID, exists := c.Get("user_id"); exists {
+		return fmt.Sprintf("user:%v", userID)
+	}
+	return ByIP(c)
+}
+
+// ByAPIKey charges requests to the API key they were made with, or to the
+// client IP without one
+func ByAPIKey(c *gin.Context) string {
+	if keyID := c.GetString(APIKeyContextKey); keyID != "" {
+		return "key:" + keyID
+	}
+	return ByIP(c)
+}
+
+// RateLimit counts requests of a route group per key in fixed windows and
+// rejects them with 429 and Retry-After once the group's limit is used up.
+// Counters live in Redis so every instance shares them; without Redis they
+// are kept in memory. It never calls c.Next, so it can be wrapped by When.
+func RateLimit(cfg *config.Config, group string, key RateLimitKey) gin.HandlerFunc {
+	rule := cfg.RateLimits[group]
+	if rule.Limit <= 0 || rule.Period <= 0 {
+		return func(c *gin.Context) {}
+	}
+	return func(c *gin.Context) {
+		now := time.Now()
+		window := now.Truncate(rule.Period)
+		reset := window.Add(rule.Period)
+		counterKey := fmt.Sprintf("%s:%s:%s:%d", rateLimitKeyPrefix, group, key(c), window.Unix())
+
+		count := rateLimitTake(c.Request.Context(), counterKey, reset)
+		remaining := int64(rule.Limit) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-RateLimit-Limit", strconv.Itoa(rule.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+
+		if count > int64(rule.Limit) {
+			retryAfter := int64(reset.Sub(now).Seconds()) + 1
+			c.Header("Retry-After", strconv.FormatInt(retryAfter, 10))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"error":       "too many requests",
+				"message":     fmt.Sprintf("Rate limit of %d requests per %s exceeded. Please try again later.", rule.Limit, rule.Period),
+				"retry_after": retryAfter,
+			})
+		}
+	}
+}
+
+// When runs h only for requests matching cond, e.g. report GETs that ask
+// for a file export
+func When(cond func(c *gin.Context) bool, h gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if cond(c) {
+			h(c)
+		}
+	}
+}
+
+// In-memory fallback counters, pruned as their windows pass
+var (
+	rateLimitMu     sync.Mutex
+	rateLimitLocal  = map[string]rateLimitCounter{}
+	rateLimitPruned time.Time
+)
+
+type rateLimitCounter struct {
+	count int64
+	reset time.Time
+}
+
+// rateLimitTake counts one request and returns the window's total so far
+func rateLimitTake(ctx context.Context, key string, reset time.Time) int64 {
+	if utils.RedisClient != nil {
+		n, err := utils.RedisClient.Incr(ctx, key).Result()
+		if err == nil {
+			if n == 1 {
+				utils.RedisClient.ExpireAt(ctx, key, reset.Add(time.Minute))
+			}
+			return n
+		}
+		log.Printf("⚠️ Rate limit counter failed for %s, counting in memory: %v", key, err)
+	}
+
+	now := time.Now()
+	rateLimitMu.Lock()
+	defer rateLimitMu.Unlock()
+	if now.Sub(rateLimitPruned) > time.Minute {
+		for k, counter := range rateLimitLocal {
+			if now.After(counter.reset) {
+				delete(rateLimitLocal, k)
+			}
+		}
+		rateLimitPruned = now
+	}
+	counter := rateLimitLocal[key]
+	counter.count++
+	counter.reset = reset
+	rateLimitLocal[key] = counter
+	return counter.count
+}
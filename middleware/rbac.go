@@ -47,6 +47,11 @@ func RBACMiddleware(allowedRoles ...string) gin.HandlerFunc {
 				c.Next()
 				return
 			}
+			// Delegated temple staff act as standard users of the temple
+			if role == RoleStandardUser && isDelegated(c) {
+				c.Next()
+				return
+			}
 		}
 
 		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "unauthorized"})
@@ -89,6 +94,12 @@ func RequireTempleAccess() gin.HandlerFunc {
 			return
 		}
 		
+		// Delegated already checked the staff role's temple
+		if isDelegated(c) {
+			c.Next()
+			return
+		}
+
 		// FIXED: Role-based access control with tenant isolation
 		switch user.Role.RoleName {
 		case RoleSuperAdmin:
@@ -1,109 +1,118 @@
 package routes
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"io/fs"
 	"net/http"
 	"os"
-	"path/filepath"
+	"path"
 	"strings"
 	"time"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/sharath018/temple-management-backend/config"
 	"github.com/sharath018/temple-management-backend/database"
+	"github.com/sharath018/temple-management-backend/internal/apidocs"
+	"github.com/sharath018/temple-management-backend/internal/apikey"
+	"github.com/sharath018/temple-management-backend/internal/apiusage"
 	"github.com/sharath018/temple-management-backend/internal/auditlog"
 	"github.com/sharath018/temple-management-backend/internal/auth"
+	"github.com/sharath018/temple-management-backend/internal/campaign"
+	"github.com/sharath018/temple-management-backend/internal/checkin"
+	"github.com/sharath018/temple-management-backend/internal/coupon"
+	"github.com/sharath018/temple-management-backend/internal/dashboard"
+	"github.com/sharath018/temple-management-backend/internal/delegation"
+	"github.com/sharath018/temple-management-backend/internal/dispute"
 	"github.com/sharath018/temple-management-backend/internal/donation"
 	"github.com/sharath018/temple-management-backend/internal/entity"
+	"github.com/sharath018/temple-management-backend/internal/health"
+	"github.com/sharath018/temple-management-backend/internal/metrics"
+	"github.com/sharath018/temple-management-backend/internal/entityconfig"
+	"github.com/sharath018/temple-management-backend/internal/migration"
 	"github.com/sharath018/temple-management-backend/internal/event"
 	"github.com/sharath018/temple-management-backend/internal/eventrsvp"
+	"github.com/sharath018/temple-management-backend/internal/expense"
+	"github.com/sharath018/temple-management-backend/internal/exportcrypto"
+	"github.com/sharath018/temple-management-backend/internal/family"
+	"github.com/sharath018/temple-management-backend/internal/fieldcrypto"
+	"github.com/sharath018/temple-management-backend/internal/greeting"
+	"github.com/sharath018/temple-management-backend/internal/hundi"
+	"github.com/sharath018/temple-management-backend/internal/i18n"
+	"github.com/sharath018/temple-management-backend/internal/inventory"
 	"github.com/sharath018/temple-management-backend/internal/notification"
+	"github.com/sharath018/temple-management-backend/internal/panchang"
+	"github.com/sharath018/temple-management-backend/internal/pledge"
+	"github.com/sharath018/temple-management-backend/internal/privacy"
+	"github.com/sharath018/temple-management-backend/internal/portal"
+	"github.com/sharath018/temple-management-backend/internal/publicpage"
 	"github.com/sharath018/temple-management-backend/internal/reports"
+	"github.com/sharath018/temple-management-backend/internal/scanner"
+	"github.com/sharath018/temple-management-backend/internal/search"
 	"github.com/sharath018/temple-management-backend/internal/seva"
+	"github.com/sharath018/temple-management-backend/internal/shop"
+	"github.com/sharath018/temple-management-backend/internal/storage"
 	"github.com/sharath018/temple-management-backend/internal/superadmin"
 	"github.com/sharath018/temple-management-backend/internal/tenant"
 	"github.com/sharath018/temple-management-backend/internal/userprofile"
+	"github.com/sharath018/temple-management-backend/internal/volunteer"
 	"github.com/sharath018/temple-management-backend/middleware"
+	"github.com/sharath018/temple-management-backend/utils"
 
-	_ "github.com/sharath018/temple-management-backend/docs"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 )
 
 // Debug route to check upload directory structure
-func addUploadDebugging(r *gin.Engine) {
-	// Debug route to check upload directory structure - Updated to use persistent volume
+func addUploadDebugging(r *gin.Engine, store storage.Storage) {
+	// Debug route to list everything in the configured file storage
 	r.GET("/debug/uploads", func(c *gin.Context) {
-		uploadPath := "/data/uploads" // Changed from ./uploads to /data/uploads
-
-		// Check if uploads directory exists
-		if _, err := os.Stat(uploadPath); os.IsNotExist(err) {
-			c.JSON(http.StatusOK, gin.H{
-				"error": "uploads directory does not exist",
-				"path":  uploadPath,
+		objects, err := store.List(c.Request.Context(), "")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error":   "failed to list storage",
+				"details": err.Error(),
 			})
 			return
 		}
 
-		// Walk through the uploads directory
-		var files []map[string]interface{}
-
-		err := filepath.WalkDir(uploadPath, func(path string, d fs.DirEntry, err error) error {
-			if err != nil {
-				return err
-			}
-
-			// Get file info
-			info, err := d.Info()
-			if err != nil {
-				return err
-			}
-
-			relativePath := strings.TrimPrefix(path, uploadPath)
-			if relativePath == "" {
-				relativePath = "/"
-			}
-
+		files := make([]map[string]interface{}, 0, len(objects))
+		for _, obj := range objects {
 			files = append(files, map[string]interface{}{
-				"path":     relativePath,
-				"name":     d.Name(),
-				"is_dir":   d.IsDir(),
-				"size":     info.Size(),
-				"mod_time": info.ModTime().Format(time.RFC3339),
-			})
-
-			return nil
-		})
-
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error":   "failed to walk directory",
-				"details": err.Error(),
+				"path":     "/" + obj.Key,
+				"name":     path.Base(obj.Key),
+				"is_dir":   false,
+				"size":     obj.Size,
+				"mod_time": obj.ModTime.Format(time.RFC3339),
 			})
-			return
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"upload_path": uploadPath,
-			"files":       files,
-			"total_files": len(files),
+			"storage_backend": store.Backend(),
+			"files":           files,
+			"total_files":     len(files),
 		})
 	})
 
-	// Debug route to check specific file - Updated path
+	// Debug route to check specific file
 	r.GET("/debug/file/*filepath", func(c *gin.Context) {
 		filePath := c.Param("filepath")
-		fullPath := filepath.Join("/data/uploads", filePath) // Changed from ./uploads
 
-		// Check if file exists
-		info, err := os.Stat(fullPath)
-		if os.IsNotExist(err) {
+		key, err := storage.Key(strings.TrimPrefix(filePath, "/"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":     "invalid file path",
+				"file_path": filePath,
+			})
+			return
+		}
+
+		info, err := store.Stat(c.Request.Context(), key)
+		if errors.Is(err, storage.ErrNotFound) {
 			c.JSON(http.StatusNotFound, gin.H{
 				"error":     "file not found",
 				"file_path": filePath,
-				"full_path": fullPath,
+				"key":       key,
 			})
 			return
 		}
@@ -117,20 +126,20 @@ func addUploadDebugging(r *gin.Engine) {
 		}
 
 		c.JSON(http.StatusOK, gin.H{
-			"file_path": filePath,
-			"full_path": fullPath,
-			"exists":    true,
-			"size":      info.Size(),
-			"is_dir":    info.IsDir(),
-			"mod_time":  info.ModTime().Format(time.RFC3339),
+			"file_path":       filePath,
+			"key":             key,
+			"storage_backend": store.Backend(),
+			"exists":          true,
+			"size":            info.Size,
+			"is_dir":          false,
+			"mod_time":        info.ModTime.Format(time.RFC3339),
 		})
 	})
 }
 
-func Setup(r *gin.Engine, cfg *config.Config) {
-	// Ensure uploads directory exists - Updated to use persistent volume
-	uploadPath := "/data/uploads" // Changed from ./uploads
-	if err := os.MkdirAll(uploadPath, 0755); err != nil {
+func Setup(r *gin.Engine, cfg *config.Config, store storage.Storage) {
+	// Ensure the local upload/scratch directory exists (temp uploads are staged here)
+	if err := os.MkdirAll(cfg.UploadDir, 0755); err != nil {
 		fmt.Printf("Warning: Could not create uploads directory: %v\n", err)
 	}
 
@@ -141,12 +150,24 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 	// File serving is now handled by the /files/*filepath route in main.go
 
 	// Add debugging routes (remove in production)
-	addUploadDebugging(r)
-
-	r.GET("/healthz", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "OK"})
-	})
-	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	addUploadDebugging(r, store)
+
+	// Kubernetes probes: /healthz (liveness) and /readyz (DB, Redis, Kafka)
+	health.NewHandler(database.DB).Register(r)
+
+	// Prometheus scrape endpoint (request, report export, Kafka, FCM and upload metrics)
+	r.GET("/metrics", metrics.Handler(cfg.MetricsToken))
+
+	// Swagger UI and the OpenAPI spec of every registered route, when enabled
+	if cfg.APIDocsEnabled {
+		apidocs.New(r)
+		docsUI := ginSwagger.WrapHandler(swaggerFiles.Handler, ginSwagger.InstanceName(apidocs.InstanceName), ginSwagger.PersistAuthorization(true))
+		r.GET("/api/docs", apidocs.Redirect)
+		r.GET("/api/docs/*any", apidocs.Handler(docsUI, cfg.APIDocsUser, cfg.APIDocsPassword)...)
+		r.GET("/swagger/*any", func(c *gin.Context) {
+			c.Redirect(http.StatusMovedPermanently, "/api/docs/index.html")
+		})
+	}
 
 	// NEW: Add a direct route for reset password
 	r.GET("/auth-pages/reset-password", func(c *gin.Context) {
@@ -162,35 +183,114 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 		})
 	})
 
-	api := r.Group("/api/v1")
-	api.Use(middleware.RateLimiter())     // Global rate limit: 5 req/sec per IP
-	api.Use(middleware.AuditMiddleware()) // Audit middleware to capture IP
-
 	// ========== Initialize Audit Log Module ==========
 	auditRepo := auditlog.NewRepository(database.DB)
 	auditSvc := auditlog.NewService(auditRepo)
 	auditHandler := auditlog.NewHandler(auditSvc)
 
-	// ========== Auth ==========
+	// Auth service; also loads the tenant an API key acts for
 	authRepo := auth.NewRepository(database.DB)
 	authSvc := auth.NewService(authRepo, cfg)
+	authSvc.SetAuditService(auditSvc)
+
+	// Per API key usage metering and daily quota throttling
+	apiUsageRepo := apiusage.NewRepository(database.DB)
+	apiUsageTracker := apiusage.NewTracker(apiUsageRepo)
+	apiUsageTracker.Start(context.Background())
+	apiUsageService := apiusage.NewService(apiUsageRepo, apiUsageTracker)
+
+	// Third-party integrations call the API as their tenant with X-API-Key,
+	// within the key's scopes and a per key rate limit (RATE_LIMITS api-key)
+	apiKeyService := apikey.NewService(apikey.NewRepository(database.DB), auditSvc, authSvc, apiUsageService)
+	apiKeyLimit := middleware.When(apikey.IsAPIKeyRequest, middleware.RateLimit(cfg, middleware.RateLimitAPIKey, middleware.ByAPIKey))
+
+	// Every API version gets the same middleware. Routes below stay on v1;
+	// handlers that change a contract go under versions.group("v2").
+	versions := newAPIVersions(r, cfg.APIDeprecations,
+		i18n.Middleware(database.DB), // Request language; translates error messages
+		middleware.RateLimiter(),     // Global rate limit: 5 req/sec per IP
+		middleware.AuditMiddleware(), // Audit middleware to capture IP
+		apiKeyService.Middleware(),
+		apiUsageTracker.Middleware(),
+		apiKeyLimit,
+	)
+	r.GET("/api/versions", versions.list)
+	api := versions.group("v1")
+
+	// Per route group limits (RATE_LIMITS), counted in Redis across instances.
+	// Report GETs only count when they export a file (?format=).
+	loginLimit := middleware.RateLimit(cfg, middleware.RateLimitLogin, middleware.ByIP)
+	exportLimit := middleware.RateLimit(cfg, middleware.RateLimitReportExport, middleware.ByUser)
+	uploadLimit := middleware.RateLimit(cfg, middleware.RateLimitUpload, middleware.ByUser)
+	publicLimit := middleware.RateLimit(cfg, middleware.RateLimitPublic, middleware.ByIP)
+	fileExportLimit := middleware.When(func(c *gin.Context) bool { return c.Query("format") != "" }, exportLimit)
+
+	// Bookings and payments replay their first response when retried with the
+	// same Idempotency-Key instead of creating duplicates
+	idempotent := middleware.Idempotency(time.Duration(cfg.IdempotencyTTLHours) * time.Hour)
+
+	// Audit file operations refused by data residency rules
+	if router, ok := store.(*storage.Router); ok {
+		router.OnBlocked(func(ctx context.Context, b storage.BlockedTransfer) {
+			var entityID *uint
+			if len(b.EntityIDs) == 1 {
+				entityID = &b.EntityIDs[0]
+			}
+			auditSvc.LogAction(ctx, nil, entityID, "DATA_RESIDENCY_BLOCKED", map[string]interface{}{
+				"operation":  b.Op,
+				"key":        b.Key,
+				"entity_ids": b.EntityIDs,
+				"error":      b.Err.Error(),
+			}, "", "failure")
+		})
+	}
+
+	// Synchronous report exports are kept for re-download (GET /reports/history)
+	exportHistory := reports.NewExportHistory(reports.NewExportHistoryRepository(database.DB), store, auditSvc, time.Duration(cfg.ReportRetentionHours)*time.Hour)
+	exportHistory.Start(context.Background())
+	recordExport := exportHistory.Record()
+
+	// ========== Auth ==========
 	authHandler := auth.NewHandler(authSvc)
 
 	authGroup := api.Group("/auth")
 	{
 		authGroup.POST("/register", authHandler.Register)
-		authGroup.POST("/login", authHandler.Login)
+
+		// Self-serve temple admin registration with email/phone verification
+		authGroup.POST("/tenant-registration", authHandler.StartTenantRegistration)
+		authGroup.POST("/tenant-registration/verify", authHandler.VerifyTenantRegistration)
+		authGroup.POST("/tenant-registration/resend", authHandler.ResendTenantRegistrationCode)
+		authGroup.POST("/tenant-registration/complete", authHandler.CompleteTenantRegistration)
+
+		authGroup.POST("/login", loginLimit, authHandler.Login)
 		authGroup.POST("/refresh", authHandler.Refresh)
 
 		// Forgot/Reset/Logout
-		authGroup.POST("/forgot-password", authHandler.ForgotPassword)
-		authGroup.POST("/reset-password", authHandler.ResetPassword)
+		authGroup.POST("/forgot-password", loginLimit, authHandler.ForgotPassword)
+		authGroup.POST("/reset-password", loginLimit, authHandler.ResetPassword)
 
 		// Public roles endpoint for registration (no auth required)
 		authGroup.GET("/public-roles", authHandler.GetPublicRoles)
 
 		// Logout requires Auth Middleware
 		authGroup.POST("/logout", middleware.AuthMiddleware(cfg, authSvc), authHandler.Logout)
+
+		// Temple admins and their staff switch between the temples of their tenant
+		authGroup.GET("/entities", middleware.AuthMiddleware(cfg, authSvc), authHandler.ListTenantEntities)
+		authGroup.POST("/switch-entity", middleware.AuthMiddleware(cfg, authSvc), authHandler.SwitchEntity)
+
+		// Two-factor authentication: verify finishes a login, the rest manage enrollment
+		authGroup.POST("/2fa/verify", loginLimit, authHandler.VerifyTwoFactor)
+		twoFactorRoutes := authGroup.Group("/2fa")
+		twoFactorRoutes.Use(middleware.AuthMiddleware(cfg, authSvc), middleware.RBACMiddleware("superadmin", "templeadmin"))
+		{
+			twoFactorRoutes.GET("", authHandler.GetTwoFactorStatus)
+			twoFactorRoutes.POST("/setup", authHandler.SetupTwoFactor)
+			twoFactorRoutes.POST("/enable", authHandler.EnableTwoFactor)
+			twoFactorRoutes.POST("/disable", authHandler.DisableTwoFactor)
+			twoFactorRoutes.POST("/backup-codes", authHandler.RegenerateBackupCodes)
+		}
 	}
 
 	protected := api.Group("/")
@@ -212,13 +312,53 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 	auditRoutes.Use(middleware.RBACMiddleware("superadmin"))
 	{
 		auditRoutes.GET("/", auditHandler.GetAuditLogs)
+		auditRoutes.GET("/actions", auditHandler.GetActionCatalog)
 		auditRoutes.GET("/:id", auditHandler.GetAuditLogByID)
 		auditRoutes.GET("/stats", auditHandler.GetAuditLogStats)
 	}
 
+	// ========== API Usage ==========
+	apiUsageHandler := apiusage.NewHandler(apiUsageService)
+	protected.GET("/api-usage", middleware.RBACMiddleware("templeadmin"), apiUsageHandler.GetTenantUsage)
+
+	// ========== Transactional Emails ==========
+	// Approval results, booking confirmations and donation receipts
+	mailer := notification.NewMailer(notification.NewRepository(database.DB), cfg, auditSvc)
+
+	// ========== SMS / WhatsApp ==========
+	// Booking confirmations, OTPs and event reminders for devotees who don't use the app
+	messenger := notification.NewMessenger(notification.NewRepository(database.DB), cfg, auditSvc)
+	utils.SMSGateway = messenger.SendOTP
+
+	// ========== Temple Staff Roles ==========
+	// Devotees and volunteers a temple admin invites as finance operator,
+	// event or seva manager work on that area of the temple (middleware.Delegated)
+	delegationService := delegation.NewService(delegation.NewRepository(database.DB), auditSvc)
+	delegationService.Mailer = mailer
+	delegationService.FrontendURL = cfg.FrontendURL
+	{
+		delegationHandler := delegation.NewHandler(delegationService)
+
+		staffRoutes := protected.Group("/entities/:id/staff")
+		staffRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin"))
+		{
+			staffRoutes.GET("", delegationHandler.ListStaff)
+			staffRoutes.DELETE("/:assignmentId", delegationHandler.RevokeStaff)
+			staffRoutes.GET("/invitations", delegationHandler.ListInvitations)
+			staffRoutes.POST("/invitations", delegationHandler.Invite)
+			staffRoutes.DELETE("/invitations/:invitationId", delegationHandler.RevokeInvitation)
+		}
+
+		protected.GET("/staff/roles", delegationHandler.ListRoles)
+		protected.POST("/staff/invitations/accept", middleware.RBACMiddleware("devotee", "volunteer"), delegationHandler.Accept)
+		protected.GET("/staff/me", middleware.RBACMiddleware("devotee", "volunteer"), delegationHandler.MyRoles)
+	}
+
 	// ========== Super Admin ==========
 	superadminRepo := superadmin.NewRepository(database.DB)
 	superadminService := superadmin.NewService(superadminRepo, auditSvc)
+	superadminService.SetStorage(store)
+	superadminService.SetMailer(mailer)
 	superadminHandler := superadmin.NewHandler(superadminService)
 
 	superadminRoutes := protected.Group("/superadmin")
@@ -229,17 +369,25 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 		// Paginated list of all tenants with optional ?status=pending&limit=10&page=1
 		superadminRoutes.GET("/tenants", superadminHandler.GetTenantsWithFilters)
 		superadminRoutes.PATCH("/tenants/:id/approval", superadminHandler.UpdateTenantApprovalStatus)
+		superadminRoutes.PATCH("/tenants/bulk-approval", superadminHandler.BulkUpdateTenantApproval)
+		superadminRoutes.PATCH("/tenants/:id/sandbox", superadminHandler.UpdateTenantSandbox)
+		superadminRoutes.PATCH("/tenants/:id/change-review", superadminHandler.UpdateTenantChangeReview)
+		superadminRoutes.PATCH("/tenants/:id/data-region", superadminHandler.UpdateTenantDataRegion)
 
 		// ================ ENTITY APPROVAL MANAGEMENT ================
 		// Paginated list of entities with optional ?status=pending&limit=10&page=1
 		superadminRoutes.GET("/entities", superadminHandler.GetEntitiesWithFilters)
 		superadminRoutes.PATCH("/entities/:id/approval", superadminHandler.UpdateEntityApprovalStatus)
+		superadminRoutes.PATCH("/entities/bulk-approval", superadminHandler.BulkUpdateEntityApproval)
 
 		superadminRoutes.GET("/tenant-details/:id", superadminHandler.GetTenantDetails)
 		superadminRoutes.GET("/tenant-details", superadminHandler.GetTenantDetails)
 
 		// ================ DASHBOARD METRICS ================
 		superadminRoutes.GET("/tenant-approval-count", superadminHandler.GetTenantApprovalCounts)
+
+		// Platform-wide trends across tenants; ?format=csv downloads them
+		superadminRoutes.GET("/analytics", fileExportLimit, superadminHandler.GetPlatformAnalytics)
 		superadminRoutes.GET("/temple-approval-count", superadminHandler.GetTempleApprovalCounts)
 
 		// ================ USER MANAGEMENT ================
@@ -273,12 +421,28 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 
 		// Reset user password (superadmin resets any user's password)
 		superadminRoutes.POST("/users/:id/reset-password", superadminHandler.ResetUserPassword)
+		superadminRoutes.DELETE("/users/:id/2fa", authHandler.AdminResetTwoFactor)
+		// Lift a lockout caused by repeated failed logins
+		superadminRoutes.POST("/users/:id/unlock", authHandler.AdminUnlockAccount)
 		superadminRoutes.GET("/users/search", superadminHandler.SearchUserByEmail)
 		superadminRoutes.GET("/tenants/assignable", superadminHandler.GetTenantsForAssignment)
 		// Assigns a list of users to a selected temple/tenant
 		superadminRoutes.POST("/users/assign", superadminHandler.AssignUsersToTenant)
 		// Bulk upload users via CSV
-		superadminRoutes.POST("/users/bulk-upload", superadminHandler.BulkUploadUsers)
+		superadminRoutes.POST("/users/bulk-upload", uploadLimit, superadminHandler.BulkUploadUsers)
+
+		// API usage rolled up per tenant
+		superadminRoutes.GET("/api-usage", apiUsageHandler.GetRollup)
+
+		// API keys of tenants' integrations, with their usage
+		apiKeyHandler := apikey.NewHandler(apiKeyService)
+		superadminRoutes.GET("/api-keys/scopes", apiKeyHandler.ListScopes)
+		superadminRoutes.GET("/api-keys", apiKeyHandler.List)
+		superadminRoutes.POST("/api-keys", apiKeyHandler.Create)
+		superadminRoutes.GET("/api-keys/:id", apiKeyHandler.Get)
+		superadminRoutes.PATCH("/api-keys/:id", apiKeyHandler.Update)
+		superadminRoutes.DELETE("/api-keys/:id", apiKeyHandler.Revoke)
+		superadminRoutes.GET("/api-keys/:id/usage", apiKeyHandler.GetUsage)
 
 		// ================ SUPERADMIN REPORTS ================
 		// Add dedicated routes for reports with multiple tenants
@@ -288,22 +452,49 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 		reportsHandler := reports.NewHandler(reportsService, reportsRepo, auditSvc)
 
 		// Reports endpoints for superadmin with multiple tenants support
-		superadminRoutes.GET("/reports/activities", reportsHandler.GetSuperAdminActivities)
-		superadminRoutes.GET("/reports/temple-registered", reportsHandler.GetSuperAdminTempleRegisteredReport)
-		superadminRoutes.GET("/reports/devotee-birthdays", reportsHandler.GetSuperAdminDevoteeBirthdaysReport)
-		superadminRoutes.GET("/reports/devotee-list", reportsHandler.GetSuperAdminDevoteeListReport)
-		superadminRoutes.GET("/reports/devotee-profile", reportsHandler.GetSuperAdminDevoteeProfileReport)
-		superadminRoutes.GET("/reports/audit-logs", reportsHandler.GetSuperAdminAuditLogsReport)
-		superadminRoutes.GET("/reports/approval-status", reportsHandler.GetApprovalStatusReport)
-		superadminRoutes.GET("/reports/user-details", reportsHandler.GetUserDetailsReport)
+		superadminRoutes.GET("/reports/activities", fileExportLimit, recordExport, reportsHandler.GetSuperAdminActivities)
+		superadminRoutes.GET("/reports/temple-registered", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTempleRegisteredReport)
+		superadminRoutes.GET("/reports/devotee-birthdays", fileExportLimit, recordExport, reportsHandler.GetSuperAdminDevoteeBirthdaysReport)
+		superadminRoutes.GET("/reports/devotee-list", fileExportLimit, recordExport, reportsHandler.GetSuperAdminDevoteeListReport)
+		superadminRoutes.GET("/reports/devotee-profile", fileExportLimit, recordExport, reportsHandler.GetSuperAdminDevoteeProfileReport)
+		superadminRoutes.GET("/reports/audit-logs", fileExportLimit, recordExport, reportsHandler.GetSuperAdminAuditLogsReport)
+		superadminRoutes.GET("/reports/approval-status", fileExportLimit, recordExport, reportsHandler.GetApprovalStatusReport)
+		superadminRoutes.GET("/reports/user-details", fileExportLimit, recordExport, reportsHandler.GetUserDetailsReport)
+		// Who exported which report, for compliance reviews
+		superadminRoutes.GET("/reports/export-audit", fileExportLimit, recordExport, reportsHandler.GetExportAuditReport)
 
 		// Support for tenant-specific routes (for backwards compatibility)
-		superadminRoutes.GET("/tenants/:id/reports/activities", reportsHandler.GetSuperAdminTenantActivities)
-		superadminRoutes.GET("/tenants/:id/reports/temple-registered", reportsHandler.GetSuperAdminTenantTempleRegisteredReport)
-		superadminRoutes.GET("/tenants/:id/reports/devotee-birthdays", reportsHandler.GetSuperAdminTenantDevoteeBirthdaysReport)
-		superadminRoutes.GET("/tenants/:id/reports/devotee-list", reportsHandler.GetSuperAdminTenantDevoteeListReport)
-		superadminRoutes.GET("/tenants/:id/reports/devotee-profile", reportsHandler.GetSuperAdminTenantDevoteeProfileReport)
-		superadminRoutes.GET("/tenants/:id/reports/audit-logs", reportsHandler.GetSuperAdminTenantAuditLogsReport)
+		superadminRoutes.GET("/tenants/:id/reports/activities", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTenantActivities)
+		superadminRoutes.GET("/tenants/:id/reports/temple-registered", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTenantTempleRegisteredReport)
+		superadminRoutes.GET("/tenants/:id/reports/devotee-birthdays", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTenantDevoteeBirthdaysReport)
+		superadminRoutes.GET("/tenants/:id/reports/devotee-list", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTenantDevoteeListReport)
+		superadminRoutes.GET("/tenants/:id/reports/devotee-profile", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTenantDevoteeProfileReport)
+		superadminRoutes.GET("/tenants/:id/reports/audit-logs", fileExportLimit, recordExport, reportsHandler.GetSuperAdminTenantAuditLogsReport)
+		superadminRoutes.POST("/tenants/:id/reports/bundle", exportLimit, recordExport, reportsHandler.ExportTenantReportBundle)
+
+		// ================ ORGANIZATIONS ================
+		// Temple trusts owning several tenants
+		superadminRoutes.GET("/organizations", superadminHandler.ListOrganizations)
+		superadminRoutes.POST("/organizations", superadminHandler.CreateOrganization)
+		superadminRoutes.GET("/organizations/:id", superadminHandler.GetOrganization)
+		superadminRoutes.PUT("/organizations/:id", superadminHandler.UpdateOrganization)
+		superadminRoutes.POST("/organizations/:id/tenants", superadminHandler.AddOrganizationTenant)
+		superadminRoutes.DELETE("/organizations/:id/tenants/:tenantId", superadminHandler.RemoveOrganizationTenant)
+		superadminRoutes.POST("/organizations/:id/admins", superadminHandler.AddOrganizationAdmin)
+		superadminRoutes.DELETE("/organizations/:id/admins/:userId", superadminHandler.RemoveOrganizationAdmin)
+		superadminRoutes.GET("/organizations/:id/reports/activities", reportsHandler.GetOrganizationActivities)
+		superadminRoutes.GET("/organizations/:id/reports/summary", reportsHandler.GetOrganizationSummary)
+
+		// Organization admins manage their own organization
+		orgAdminRoutes := protected.Group("/organization")
+		orgAdminRoutes.Use(middleware.RBACMiddleware("orgadmin"))
+		{
+			orgAdminRoutes.GET("", superadminHandler.GetMyOrganization)
+			orgAdminRoutes.GET("/user-assignments", superadminHandler.ListOrganizationUserAssignments)
+			orgAdminRoutes.POST("/user-assignments", superadminHandler.AssignOrganizationUser)
+			orgAdminRoutes.GET("/reports/activities", reportsHandler.GetOrganizationActivities)
+			orgAdminRoutes.GET("/reports/summary", reportsHandler.GetOrganizationSummary)
+		}
 	}
 
 	protected.GET("/tenants/selection",
@@ -315,24 +506,29 @@ func Setup(r *gin.Engine, cfg *config.Config) {
 
 sevaRepo := seva.NewRepository(database.DB)
 sevaService := seva.NewService(sevaRepo, auditSvc)
+sevaService.SetMailer(mailer)
+sevaService.SetMessenger(messenger)
 sevaHandler := seva.NewHandler(sevaService, auditSvc)
 
-// 🔥 FIX: Ensure protected group has CORS enabled (very important)
-protected.Use(cors.New(cors.Config{
-    AllowOrigins:     []string{"http://localhost:4173", "http://127.0.0.1:4173", "http://localhost:5173"},
-    AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-    AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Tenant-ID"},
-    AllowCredentials: true,
-}))
+// Temple coupons, redeemed on seva bookings and donations
+couponService := coupon.NewService(coupon.NewRepository(database.DB), auditSvc)
+sevaService.SetCouponService(couponService)
+
+// Redis day counters for seva capacity, reconciled against seva_bookings
+bookingCounter := seva.NewBookingCounter(sevaRepo)
+sevaService.SetBookingCounter(bookingCounter)
+bookingCounter.StartReconciler(context.Background(), 10*time.Minute)
 
 // All Seva routes under: /api/v1/sevas
 sevaRoutes := protected.Group("sevas")
 
 
 sevaRoutes.GET("/booking-counts", sevaHandler.GetBookingCounts)
+sevaRoutes.GET("/booking-counters/metrics", middleware.RBACMiddleware("superadmin"), sevaHandler.GetBookingCounterMetrics)
 
 
 templeSevaRoutes := sevaRoutes.Group("")
+templeSevaRoutes.Use(middleware.Delegated(delegationService, delegation.PermissionSevas)) // seva managers
 templeSevaRoutes.Use(middleware.RequireTempleAccess()) // access check
 
 {
@@ -348,10 +544,24 @@ templeSevaRoutes.Use(middleware.RequireTempleAccess()) // access check
 
 		// Booking status update
 		writeRoutes.PATCH("/bookings/:id/status", sevaHandler.UpdateBookingStatus)
+
+		// Recommendation rules (nakshatra/rashi mappings, special days, weights)
+		writeRoutes.PUT("/recommendation-rules", sevaHandler.UpdateRecommendationRules)
+
+		// Seva templates, generating and cloning sevas across dates, bulk activation
+		writeRoutes.POST("/templates", sevaHandler.CreateSevaTemplate)
+		writeRoutes.PUT("/templates/:id", sevaHandler.UpdateSevaTemplate)
+		writeRoutes.DELETE("/templates/:id", sevaHandler.DeleteSevaTemplate)
+		writeRoutes.POST("/templates/:id/generate", sevaHandler.GenerateSevas)
+		writeRoutes.POST("/:id/clone", sevaHandler.CloneSeva)
+		writeRoutes.PATCH("/bulk-status", sevaHandler.BulkUpdateSevaStatus)
 	}
 
 	
 	templeSevaRoutes.GET("/entity-sevas", sevaHandler.ListEntitySevas)
+	templeSevaRoutes.GET("/recommendation-rules", sevaHandler.GetRecommendationRules)
+	templeSevaRoutes.GET("/templates", sevaHandler.ListSevaTemplates)
+	templeSevaRoutes.GET("/templates/:id", sevaHandler.GetSevaTemplate)
 	templeSevaRoutes.GET("/:id", sevaHandler.GetSevaByID)
 	templeSevaRoutes.GET("/entity-bookings", sevaHandler.GetEntityBookings)
 	templeSevaRoutes.GET("/bookings/:id", sevaHandler.GetBookingByID)
@@ -363,21 +573,75 @@ devoteeSevaRoutes := sevaRoutes.Group("")
 devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 
 {
-	devoteeSevaRoutes.POST("/bookings", sevaHandler.BookSeva)
+	devoteeSevaRoutes.POST("/bookings", idempotent, sevaHandler.BookSeva)
 	devoteeSevaRoutes.GET("/my-bookings", sevaHandler.GetMyBookings)
+	devoteeSevaRoutes.GET("/recommendations", sevaHandler.GetRecommendations)
+	devoteeSevaRoutes.PATCH("/bookings/:id/cancel", sevaHandler.CancelBooking)
 	devoteeSevaRoutes.GET("/", sevaHandler.GetSevas)
 }
 
+// Booking changes under /api/v1/bookings: devotees act on their own bookings
+// within the seva's cancellation window, temple staff on any of their temple's
+bookingRoutes := protected.Group("/bookings")
+bookingRoutes.Use(middleware.RBACMiddleware("devotee", "superadmin", "templeadmin", "standarduser"))
+{
+	bookingRoutes.PATCH("/:id/cancel", sevaHandler.CancelBooking)
+	bookingRoutes.PATCH("/:id/reschedule", sevaHandler.RescheduleBooking)
+	bookingRoutes.PATCH("/:id/refund", middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser"), sevaHandler.UpdateRefundStatus)
+}
+
 // ========== Entity ==========
+// Membership changes made through entity routes also drive push topics and
+// quota alerts; the notification service is injected once it exists
+var entityProfileService userprofile.Service
+var storageQuota *entity.StorageQuota
 {
 	entityRepo := entity.NewRepository(database.DB)
 	profileRepo := userprofile.NewRepository(database.DB)
 	profileService := userprofile.NewService(profileRepo, authRepo, auditSvc)
+	entityProfileService = profileService
 	profileHandler := userprofile.NewHandler(profileService)
 
 	entityService := entity.NewService(entityRepo, profileService, auditSvc)
-	// UPDATED: Use persistent volume path and proper file serving path
-	entityHandler := entity.NewHandler(entityService, "/data/uploads", "/files")
+	entityService.Geocoder = entity.NewNominatimGeocoder(cfg.GeocoderURL, cfg.GeocoderUserAgent)
+	// Temp uploads are staged under cfg.UploadDir, final documents go to store
+	entityHandler := entity.NewHandler(entityService, store, cfg.UploadDir, "/files")
+	fileScanner, err := scanner.New(cfg)
+	if err != nil {
+		fmt.Printf("⚠️ Malware scanning disabled: %v\n", err)
+	}
+	entityHandler.Scanner = fileScanner
+
+	// Per-temple storage quotas (STORAGE_QUOTA_MB), metered on every write to a temple's files
+	storageQuota = entity.NewStorageQuota(entityRepo, store, cfg.StorageQuotaMB, auditSvc)
+	if router, ok := store.(*storage.Router); ok {
+		router.MeterUsage(storageQuota)
+	}
+	entityHandler.Quota = storageQuota
+
+	// Configuration bundles (settings, seva catalog, templates) for moving between environments
+	entityConfigRepo := entityconfig.NewRepository(database.DB)
+	exportKey, err := exportcrypto.ParseMasterKey(cfg.ExportMasterKey)
+	if err != nil {
+		fmt.Printf("⚠️ Export encryption disabled: %v\n", err)
+	}
+	entityConfigService := entityconfig.NewService(entityConfigRepo, auditSvc, exportKey)
+	entityConfigHandler := entityconfig.NewHandler(entityConfigService)
+
+	// Guided migration of legacy Excel registers (devotees, donations, bookings)
+	migrationService := migration.NewService(migration.NewRepository(database.DB), auditSvc)
+	migrationHandler := migration.NewHandler(migrationService)
+
+	// Public landing page content (theme, sections, images) per temple
+	publicPageService := publicpage.NewService(publicpage.NewRepository(database.DB), store, auditSvc)
+	publicPageHandler := publicpage.NewHandler(publicPageService)
+
+	// Public rendering API for the temple directory, no auth
+	api.GET("/public/temples", publicLimit, publicPageHandler.ListTemples)
+	api.GET("/public/temples/nearby", publicLimit, publicPageHandler.NearbyTemples)
+	api.GET("/public/temples/:id", publicLimit, publicPageHandler.GetTemple) // :id is the slug or ID
+	api.GET("/public/temples/:id/page", publicPageHandler.GetPublicPage)
+	api.GET("/public/temples/:id/page/images/:name", publicPageHandler.GetImage)
 
 	// Add special endpoint for templeadmins to view their created entities
 	protected.GET("/entities/by-creator", middleware.RBACMiddleware("templeadmin"), func(c *gin.Context) {
@@ -416,18 +680,78 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 			writeRoutes.PUT("/:id", entityHandler.UpdateEntity)
 			writeRoutes.DELETE("/:id", entityHandler.DeleteEntity)
 			writeRoutes.PATCH("/:id/devotees/:userID/status", entityHandler.UpdateDevoteeMembershipStatus)
+			writeRoutes.POST("/:id/devotees/bulk-upload", uploadLimit, entityHandler.BulkImportDevotees)
+			writeRoutes.POST("/:id/devotees/invitations/import", uploadLimit, entityHandler.ImportDevoteeContacts)
+			writeRoutes.POST("/:id/config/import", uploadLimit, entityConfigHandler.ImportConfig)
+
+			// Document uploads: several files at once, or one large file in resumable chunks
+			writeRoutes.POST("/:id/files", uploadLimit, entityHandler.UploadEntityFiles)
+			writeRoutes.POST("/:id/files/uploads", uploadLimit, entityHandler.StartFileUpload)
+			writeRoutes.GET("/:id/files/uploads/:uploadId", entityHandler.GetFileUpload)
+			writeRoutes.PUT("/:id/files/uploads/:uploadId/chunks/:index", entityHandler.UploadFileChunk)
+			writeRoutes.POST("/:id/files/uploads/:uploadId/complete", entityHandler.CompleteFileUpload)
+			writeRoutes.DELETE("/:id/files/uploads/:uploadId", entityHandler.AbortFileUpload)
+			writeRoutes.PUT("/:id/storage-quota", middleware.RBACMiddleware("superadmin"), entityHandler.SetStorageQuota)
+
+			// Legacy register migration: stage, map and validate, commit, roll back
+			writeRoutes.POST("/:id/migrations", uploadLimit, migrationHandler.StageBatch)
+			writeRoutes.PUT("/:id/migrations/:batchId/mapping", migrationHandler.UpdateMapping)
+			writeRoutes.POST("/:id/migrations/:batchId/commit", migrationHandler.CommitBatch)
+			writeRoutes.POST("/:id/migrations/:batchId/rollback", migrationHandler.RollbackBatch)
+			// Past donations from a CSV in one step, built on the migration batches
+			writeRoutes.POST("/:id/donations/import", uploadLimit, migrationHandler.ImportDonations)
+
+			// Landing page editing
+			writeRoutes.PUT("/:id/page/theme", publicPageHandler.UpdateTheme)
+			writeRoutes.POST("/:id/page/sections", publicPageHandler.CreateSection)
+			writeRoutes.POST("/:id/page/sections/reorder", publicPageHandler.ReorderSections)
+			writeRoutes.PUT("/:id/page/sections/:sectionId", publicPageHandler.UpdateSection)
+			writeRoutes.DELETE("/:id/page/sections/:sectionId", publicPageHandler.DeleteSection)
+			writeRoutes.POST("/:id/page/images", uploadLimit, publicPageHandler.UploadImage)
+			writeRoutes.POST("/:id/page/publish", publicPageHandler.Publish)
+			writeRoutes.POST("/:id/page/unpublish", publicPageHandler.Unpublish)
 		}
 
 		// Read operations - all three roles can access
 		entityRoutes.GET("/:id", entityHandler.GetEntityByID)
 		entityRoutes.GET("/:id/devotees", entityHandler.GetDevoteesByEntity)
 		entityRoutes.GET("/:id/devotee-stats", entityHandler.GetDevoteeStats)
+		entityRoutes.GET("/:id/activity-heatmap", entityHandler.GetActivityHeatmap)
+		entityRoutes.GET("/:id/change-requests", entityHandler.ListEntityChangeRequests)
+		entityRoutes.GET("/:id/devotees/invitations", entityHandler.GetDevoteeInvitations)
 		entityRoutes.GET("/:id/devotees/:userId/profile", profileHandler.GetDevoteeProfileByEntity) // ✅ UPDATED: Changed :entityId to :id
 		entityRoutes.GET("/dashboard-summary", entityHandler.GetDashboardSummary)
 		
 		// File routes for entity documents
 		entityRoutes.GET("/:id/files", entityHandler.GetEntityFiles)
+		entityRoutes.GET("/:id/storage-usage", entityHandler.GetStorageUsage)
 		entityRoutes.GET("/directories", entityHandler.GetAllEntityDirectories)
+
+		// Configuration export (import lives under writeRoutes)
+		entityRoutes.GET("/:id/config/export", exportLimit, entityConfigHandler.ExportConfig)
+		entityRoutes.POST("/:id/config/export", exportLimit, entityConfigHandler.ExportConfigEncrypted)
+
+		// Legacy register migrations and their summary reports
+		entityRoutes.GET("/:id/migrations/fields", migrationHandler.GetFields)
+		entityRoutes.GET("/:id/migrations", migrationHandler.ListBatches)
+		entityRoutes.GET("/:id/migrations/:batchId", migrationHandler.GetBatch)
+		entityRoutes.GET("/:id/migrations/:batchId/rows", migrationHandler.ListRows)
+		entityRoutes.GET("/:id/migrations/:batchId/report", migrationHandler.DownloadReport)
+
+		// Landing page draft and preview (editing lives under writeRoutes)
+		entityRoutes.GET("/:id/page", publicPageHandler.GetPage)
+		entityRoutes.GET("/:id/page/preview", publicPageHandler.PreviewPage)
+	}
+
+	// Edits to key details of approved temples awaiting review (tenants with
+	// change review turned on, PATCH /superadmin/tenants/:id/change-review)
+	changeRequestRoutes := protected.Group("/superadmin/entity-change-requests")
+	changeRequestRoutes.Use(middleware.RBACMiddleware("superadmin"))
+	{
+		changeRequestRoutes.GET("", entityHandler.ListChangeRequests)
+		changeRequestRoutes.GET("/:id", entityHandler.GetChangeRequest)
+		changeRequestRoutes.POST("/:id/approve", entityHandler.ApproveChangeRequest)
+		changeRequestRoutes.POST("/:id/reject", entityHandler.RejectChangeRequest)
 	}
 
 	// Special endpoints that bypass temple access check
@@ -437,6 +761,18 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 		entityHandler.CreateEntity,
 	)
 
+	// Multipart form contract for create/update, shared with web and mobile clients
+	protected.GET("/entities/form-schema",
+		middleware.RBACMiddleware("templeadmin", "superadmin", "standarduser", "monitoringuser"),
+		entityHandler.GetFormSchema,
+	)
+
+	// Validates a map link and previews its coordinates and address before saving
+	protected.POST("/entities/map-link/preview",
+		middleware.RBACMiddleware("templeadmin", "superadmin", "standarduser"),
+		entityHandler.PreviewMapLink,
+	)
+
 	// GetAllEntities - allowed for templeadmin, superadmin, standarduser, monitoringuser
 	protected.GET("/entities",
 		middleware.RBACMiddleware("templeadmin", "superadmin", "standarduser", "monitoringuser"),
@@ -447,11 +783,13 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 	// ========== Event & RSVP ==========
 	eventRepo := event.NewRepository(database.DB)
 	eventService := event.NewService(eventRepo, auditSvc)
+	eventService.CalendarSecret = []byte(cfg.CalendarFeedSecret)
 	var notifSvc notification.Service
 	eventHandler := event.NewHandler(eventService)
 
 	// Event routes - all require temple access
 	eventRoutes := protected.Group("/events")
+	eventRoutes.Use(middleware.Delegated(delegationService, delegation.PermissionEvents)) // event managers
 	eventRoutes.Use(middleware.RequireTempleAccess())
 	{
 		// Write operations - only templeadmin and standarduser can access
@@ -468,17 +806,38 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 		eventRoutes.GET("/", eventHandler.ListEvents)
 		eventRoutes.GET("/:id", eventHandler.GetEventByID)
 		eventRoutes.GET("/upcoming", eventHandler.GetUpcomingEvents)
+		eventRoutes.GET("/calendar", eventHandler.GetCalendar)
 		eventRoutes.GET("/stats", eventHandler.GetEventStats)
 	}
 
+	// Tithi, nakshatra and observances of dates, for scheduling on auspicious days
+	panchangHandler := panchang.NewHandler()
+	protected.GET("/panchang", panchangHandler.GetPanchang)
+
+	// iCal subscription: a signed link is issued to signed-in users, the feed
+	// itself is public so calendar apps can fetch it
+	protected.GET("/entities/:id/events/calendar-feed", middleware.RequireTempleAccess(), eventHandler.GetCalendarFeedURL)
+	api.GET("/entities/:id/events/calendar.ics", eventHandler.GetCalendarICS)
+
 	// Event RSVP routes (keeping existing logic for devotee/volunteer access)
 	{
 		rsvpRepo := eventrsvp.NewRepository(database.DB)
 		rsvpService := eventrsvp.NewService(rsvpRepo, eventService)
+		rsvpService.ReminderOffsets = cfg.EventReminderOffsets
+		rsvpService.ReminderChannels = cfg.EventReminderChannels
+		rsvpService.Mailer = mailer
+		rsvpService.Messenger = messenger
 		rsvpHandler := eventrsvp.NewHandler(rsvpService, eventService)
 
+		// Offers freed seats to waitlisted devotees and passes on unclaimed offers
+		rsvpService.StartWaitlistSweeper(context.Background(), time.Minute)
+
+		// Reminds attending devotees ahead of their event (EVENT_REMINDER_OFFSETS)
+		rsvpService.StartReminderSender(context.Background(), 5*time.Minute)
+
 		rsvpRoutes := protected.Group("/event-rsvps")
 		rsvpRoutes.POST("/:eventID", middleware.RBACMiddleware("devotee", "volunteer"), rsvpHandler.CreateRSVP)
+		rsvpRoutes.POST("/:eventID/claim", middleware.RBACMiddleware("devotee", "volunteer"), rsvpHandler.ClaimSeat)
 		rsvpRoutes.GET("/:eventID", middleware.RBACMiddleware("devotee"), rsvpHandler.GetRSVPsByEvent)
 		rsvpRoutes.GET("/my", middleware.RBACMiddleware("devotee", "volunteer"), rsvpHandler.GetMyRSVPs)
 	}
@@ -503,6 +862,13 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 		membershipRoutes.GET("", middleware.RBACMiddleware("devotee", "volunteer"), profileHandler.ListMemberships)
 	}
 
+	// ========== Global Search ==========
+	{
+		searchHandler := search.NewHandler(search.NewService(search.NewRepository(database.DB)))
+		// Results are scoped by the caller's access context
+		protected.GET("/search", searchHandler.Search)
+	}
+
 	// ========== Temple Search ==========
 	templeSearchRoutes := protected.Group("/temples")
 	{
@@ -511,10 +877,18 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 	}
 
 	// ========== Donations with New Permission System ==========
+	// The devotee portal serves donation receipts through the donation service
+	var portalService *portal.Service
 	{
 		donationRepo := donation.NewRepository(database.DB)
-		donationService := donation.NewService(donationRepo, cfg, auditSvc)
+		donationService := donation.NewService(donationRepo, cfg, auditSvc, store)
+		donationService.SetMailer(mailer)
+		donationService.SetCouponService(couponService)
 		donationHandler := donation.NewHandler(donationService)
+		portalService = portal.NewService(portal.NewRepository(database.DB), auditSvc, donationService)
+
+		// Razorpay webhook - public, authenticated by the webhook signature
+		api.POST("/payments/webhook", donationHandler.PaymentWebhook)
 
 		donationRoutes := protected.Group("/donations")
 		{
@@ -522,13 +896,14 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 			devoteeRoutes := donationRoutes.Group("")
 			devoteeRoutes.Use(middleware.RBACMiddleware("devotee"))
 			{
-				devoteeRoutes.POST("/", donationHandler.CreateDonation)
-				devoteeRoutes.POST("/verify", donationHandler.VerifyDonation)
+				devoteeRoutes.POST("/", idempotent, donationHandler.CreateDonation)
+				devoteeRoutes.POST("/verify", idempotent, donationHandler.VerifyDonation)
 				devoteeRoutes.GET("/my", donationHandler.GetMyDonations)
 			}
 
 			// ========== TEMPLE ADMIN ROUTES (UPDATED PERMISSIONS) ==========
 			templeRoutes := donationRoutes.Group("")
+			templeRoutes.Use(middleware.Delegated(delegationService, delegation.PermissionDonations)) // finance operators
 			templeRoutes.Use(middleware.RequireTempleAccess()) // Allow templeadmin, standarduser, monitoringuser
 			{
 				// Read-only operations - all three roles can access
@@ -541,7 +916,7 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 				writeRoutes := templeRoutes.Group("")
 				writeRoutes.Use(middleware.RequireWriteAccess())
 				{
-					writeRoutes.GET("/export", donationHandler.ExportDonations)
+					writeRoutes.GET("/export", exportLimit, donationHandler.ExportDonations)
 				}
 			}
 
@@ -557,11 +932,443 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 				donationHandler.GetRecentDonations)
 		}
 	}
+
+	// ========== Devotee Portal ==========
+	// A devotee's own profile, bookings, donations, receipts and preferences
+	// across the temples they joined
+	{
+		portalHandler := portal.NewHandler(portalService)
+
+		portalRoutes := protected.Group("/portal")
+		portalRoutes.Use(middleware.RBACMiddleware("devotee"))
+		{
+			portalRoutes.GET("/me", portalHandler.GetMe)
+			portalRoutes.PATCH("/me/profile", portalHandler.UpdateProfile)
+			portalRoutes.GET("/bookings", portalHandler.ListBookings)
+			portalRoutes.GET("/donations", portalHandler.ListDonations)
+			portalRoutes.GET("/donations/:id/receipt", portalHandler.DownloadReceipt)
+			portalRoutes.GET("/preferences", portalHandler.GetPreferences)
+			portalRoutes.PUT("/preferences", portalHandler.UpdatePreferences)
+		}
+	}
+
+	// ========== Donation Campaigns ==========
+	{
+		campaignService := campaign.NewService(campaign.NewRepository(database.DB), auditSvc, store)
+		campaignHandler := campaign.NewHandler(campaignService)
+
+		// Cover images are public so temple pages can show them
+		api.GET("/public/campaigns/:id/image", campaignHandler.GetImage)
+
+		campaignRoutes := protected.Group("/campaigns")
+		campaignRoutes.Use(middleware.Delegated(delegationService, delegation.PermissionDonations)) // finance operators
+		campaignRoutes.Use(middleware.RequireTempleAccess())
+		{
+			campaignRoutes.GET("", campaignHandler.List)
+			campaignRoutes.GET("/:id", campaignHandler.Get)
+			campaignRoutes.GET("/:id/progress", campaignHandler.GetProgress)
+
+			writeRoutes := campaignRoutes.Group("")
+			writeRoutes.Use(middleware.RequireWriteAccess())
+			{
+				writeRoutes.POST("", campaignHandler.Create)
+				writeRoutes.PUT("/:id", campaignHandler.Update)
+				writeRoutes.POST("/:id/image", uploadLimit, campaignHandler.UploadImage)
+			}
+		}
+	}
+
+	// ========== Recurring Donations (monthly pledges) ==========
+	pledgeService := pledge.NewService(pledge.NewRepository(database.DB), cfg, auditSvc)
+	{
+		pledgeHandler := pledge.NewHandler(pledgeService)
+
+		// Razorpay payment link webhook - public, authenticated by the webhook signature
+		api.POST("/payments/pledges/webhook", pledgeHandler.Webhook)
+
+		// Sends reminders and payment links for due pledges, retries unpaid ones
+		pledgeService.StartScheduler(context.Background(), 15*time.Minute)
+
+		pledgeRoutes := protected.Group("/pledges")
+		{
+			devoteeRoutes := pledgeRoutes.Group("")
+			devoteeRoutes.Use(middleware.RBACMiddleware("devotee"))
+			{
+				devoteeRoutes.POST("", idempotent, pledgeHandler.Create)
+				devoteeRoutes.GET("/my", pledgeHandler.ListMine)
+				devoteeRoutes.PUT("/:id", pledgeHandler.Update)
+				devoteeRoutes.POST("/:id/cancel", pledgeHandler.Cancel)
+			}
+
+			pledgeRoutes.GET("",
+				middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"),
+				middleware.RequireTempleAccess(),
+				pledgeHandler.ListByEntity)
+			pledgeRoutes.GET("/:id/charges",
+				middleware.RBACMiddleware("devotee", "superadmin", "templeadmin", "standarduser", "monitoringuser"),
+				pledgeHandler.ListCharges)
+		}
+	}
+
+	// ========== Prasadam & Merchandise Shop ==========
+	shopService := shop.NewService(shop.NewRepository(database.DB), cfg, auditSvc)
+	{
+		shopHandler := shop.NewHandler(shopService)
+
+		// Razorpay payment webhook for shop orders - public, authenticated by the webhook signature
+		api.POST("/payments/shop/webhook", shopHandler.Webhook)
+
+		// Gives the stock of unpaid checkouts back
+		shopService.StartScheduler(context.Background(), 5*time.Minute)
+
+		shopRoutes := protected.Group("/shop")
+		{
+			shopRoutes.GET("/products",
+				middleware.RBACMiddleware("devotee", "superadmin", "templeadmin", "standarduser", "monitoringuser"),
+				shopHandler.ListProducts)
+			shopRoutes.GET("/orders/:id",
+				middleware.RBACMiddleware("devotee", "superadmin", "templeadmin", "standarduser", "monitoringuser"),
+				shopHandler.Get)
+
+			devoteeRoutes := shopRoutes.Group("")
+			devoteeRoutes.Use(middleware.RBACMiddleware("devotee"))
+			{
+				devoteeRoutes.GET("/cart", shopHandler.GetCart)
+				devoteeRoutes.PUT("/cart/items", shopHandler.SetCartItem)
+				devoteeRoutes.DELETE("/cart", shopHandler.ClearCart)
+				devoteeRoutes.POST("/checkout", idempotent, shopHandler.Checkout)
+				devoteeRoutes.POST("/orders/verify", shopHandler.VerifyPayment)
+				devoteeRoutes.GET("/orders/my", shopHandler.ListMine)
+				devoteeRoutes.POST("/orders/:id/cancel", shopHandler.Cancel)
+			}
+
+			staffRoutes := shopRoutes.Group("")
+			staffRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+			staffRoutes.Use(middleware.RequireTempleAccess())
+			{
+				staffRoutes.GET("/orders", shopHandler.ListByEntity)
+
+				writeRoutes := staffRoutes.Group("")
+				writeRoutes.Use(middleware.RequireWriteAccess())
+				{
+					writeRoutes.POST("/products", shopHandler.CreateProduct)
+					writeRoutes.PUT("/products/:id", shopHandler.UpdateProduct)
+					writeRoutes.DELETE("/products/:id", shopHandler.DeleteProduct)
+					writeRoutes.PATCH("/orders/:id/status", shopHandler.UpdateStatus)
+				}
+			}
+		}
+	}
+
+	// ========== Birthday & Anniversary Greetings ==========
+	greetingService := greeting.NewService(greeting.NewRepository(database.DB), auditSvc)
+	greetingService.Mailer = mailer
+	greetingService.Messenger = messenger
+	{
+		greetingHandler := greeting.NewHandler(greetingService)
+
+		// Wishes the day's celebrants of temples with greetings on
+		greetingService.StartScheduler(context.Background(), 15*time.Minute)
+
+		greetingRoutes := protected.Group("/greetings")
+		{
+			greetingRoutes.GET("/me", middleware.RBACMiddleware("devotee"), greetingHandler.GetMine)
+			greetingRoutes.PUT("/me", middleware.RBACMiddleware("devotee"), greetingHandler.UpdateMine)
+
+			staffRoutes := greetingRoutes.Group("")
+			staffRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+			staffRoutes.Use(middleware.RequireTempleAccess())
+			{
+				staffRoutes.GET("/settings", greetingHandler.GetSettings)
+				staffRoutes.GET("/log", greetingHandler.ListLogs)
+				staffRoutes.PUT("/settings", middleware.RequireWriteAccess(), greetingHandler.UpdateSettings)
+			}
+		}
+	}
+
+	// ========== Temple Expenses ==========
+	{
+		expenseService := expense.NewService(expense.NewRepository(database.DB), auditSvc, store)
+		expenseHandler := expense.NewHandler(expenseService)
+
+		expenseRoutes := protected.Group("/expenses")
+		expenseRoutes.Use(middleware.Delegated(delegationService, delegation.PermissionExpenses)) // finance operators; approval stays with templeadmin
+		expenseRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		expenseRoutes.Use(middleware.RequireTempleAccess())
+		{
+			expenseRoutes.GET("", expenseHandler.List)
+			expenseRoutes.GET("/categories", expenseHandler.GetCategories)
+			expenseRoutes.GET("/:id", expenseHandler.Get)
+			expenseRoutes.GET("/:id/receipt", expenseHandler.DownloadReceipt)
+
+			writeRoutes := expenseRoutes.Group("")
+			writeRoutes.Use(middleware.RequireWriteAccess())
+			{
+				writeRoutes.POST("", expenseHandler.Create)
+				writeRoutes.PUT("/:id", expenseHandler.Update)
+				writeRoutes.POST("/:id/receipt", uploadLimit, expenseHandler.UploadReceipt)
+
+				// Only the temple admin signs off on spending
+				writeRoutes.POST("/:id/approve", middleware.RBACMiddleware("templeadmin"), expenseHandler.Approve)
+				writeRoutes.POST("/:id/reject", middleware.RBACMiddleware("templeadmin"), expenseHandler.Reject)
+			}
+		}
+	}
+
+	// ========== Hundi Collections ==========
+	{
+		hundiHandler := hundi.NewHandler(hundi.NewService(hundi.NewRepository(database.DB), auditSvc))
+
+		hundiRoutes := protected.Group("/hundi")
+		hundiRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		hundiRoutes.Use(middleware.RequireTempleAccess())
+		{
+			hundiRoutes.GET("/denominations", hundiHandler.GetDenominations)
+			hundiRoutes.GET("/sessions", hundiHandler.List)
+			hundiRoutes.GET("/sessions/:id", hundiHandler.Get)
+
+			writeRoutes := hundiRoutes.Group("")
+			writeRoutes.Use(middleware.RequireWriteAccess())
+			{
+				writeRoutes.POST("/sessions", hundiHandler.Record)
+				writeRoutes.PUT("/sessions/:id", hundiHandler.Update)
+				writeRoutes.POST("/sessions/:id/flag", hundiHandler.Flag)
+
+				// Only the temple admin signs off on a count
+				writeRoutes.POST("/sessions/:id/approve", middleware.RBACMiddleware("templeadmin"), hundiHandler.Approve)
+				writeRoutes.POST("/sessions/:id/reject", middleware.RBACMiddleware("templeadmin"), hundiHandler.Reject)
+			}
+		}
+	}
+
+	// ========== Coupons ==========
+	{
+		couponHandler := coupon.NewHandler(couponService)
+
+		// Devotees check a code before booking or donating
+		protected.POST("/coupons/validate", middleware.RBACMiddleware("devotee"), couponHandler.Validate)
+
+		couponRoutes := protected.Group("/coupons")
+		couponRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		couponRoutes.Use(middleware.RequireTempleAccess())
+		{
+			couponRoutes.GET("", couponHandler.List)
+			couponRoutes.GET("/:id", couponHandler.Get)
+			couponRoutes.GET("/:id/redemptions", couponHandler.ListRedemptions)
+
+			writeRoutes := couponRoutes.Group("")
+			writeRoutes.Use(middleware.RequireWriteAccess())
+			{
+				writeRoutes.POST("", couponHandler.Create)
+				writeRoutes.PUT("/:id", couponHandler.Update)
+				writeRoutes.DELETE("/:id", couponHandler.Delete)
+			}
+		}
+	}
+
+	// ========== Inventory ==========
+	inventoryService := inventory.NewService(inventory.NewRepository(database.DB), auditSvc)
+	{
+		inventoryHandler := inventory.NewHandler(inventoryService)
+
+		inventoryRoutes := protected.Group("/inventory")
+		inventoryRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		inventoryRoutes.Use(middleware.RequireTempleAccess())
+		{
+			inventoryRoutes.GET("/units", inventoryHandler.GetUnits)
+			inventoryRoutes.GET("/items", inventoryHandler.ListItems)
+			inventoryRoutes.GET("/items/:id", inventoryHandler.GetItem)
+			inventoryRoutes.GET("/items/:id/movements", inventoryHandler.ListItemMovements)
+			inventoryRoutes.GET("/movements", inventoryHandler.ListMovements)
+
+			writeRoutes := inventoryRoutes.Group("")
+			writeRoutes.Use(middleware.RequireWriteAccess())
+			{
+				writeRoutes.POST("/items", inventoryHandler.CreateItem)
+				writeRoutes.PUT("/items/:id", inventoryHandler.UpdateItem)
+				writeRoutes.POST("/items/:id/movements", inventoryHandler.RecordMovement)
+			}
+		}
+	}
+
+	// ========== Volunteers ==========
+	{
+		volunteerHandler := volunteer.NewHandler(volunteer.NewService(volunteer.NewRepository(database.DB), auditSvc))
+
+		volunteerRoutes := protected.Group("/volunteers")
+		{
+			// Devotees and volunteers register themselves and take up slots
+			selfRoutes := volunteerRoutes.Group("")
+			selfRoutes.Use(middleware.RBACMiddleware("devotee", "volunteer"))
+			{
+				selfRoutes.POST("/register", volunteerHandler.Register)
+				selfRoutes.GET("/me", volunteerHandler.GetMine)
+				selfRoutes.GET("/my-signups", volunteerHandler.ListMySignups)
+				selfRoutes.POST("/slots/:id/signup", volunteerHandler.SignUp)
+				selfRoutes.DELETE("/slots/:id/signup", volunteerHandler.CancelSignup)
+			}
+
+			volunteerRoutes.GET("/skills", volunteerHandler.GetSkills)
+			volunteerRoutes.GET("/slots",
+				middleware.RBACMiddleware("devotee", "volunteer", "superadmin", "templeadmin", "standarduser", "monitoringuser"),
+				volunteerHandler.ListSlots)
+			volunteerRoutes.GET("/slots/:id",
+				middleware.RBACMiddleware("devotee", "volunteer", "superadmin", "templeadmin", "standarduser", "monitoringuser"),
+				volunteerHandler.GetSlot)
+
+			staffRoutes := volunteerRoutes.Group("")
+			staffRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+			staffRoutes.Use(middleware.RequireTempleAccess())
+			{
+				staffRoutes.GET("", volunteerHandler.List)
+				staffRoutes.GET("/slots/:id/signups", volunteerHandler.ListSignups)
+
+				writeRoutes := staffRoutes.Group("")
+				writeRoutes.Use(middleware.RequireWriteAccess())
+				{
+					writeRoutes.PUT("/:id/status", volunteerHandler.SetStatus)
+					writeRoutes.POST("/slots", volunteerHandler.CreateSlot)
+					writeRoutes.PUT("/slots/:id", volunteerHandler.UpdateSlot)
+					writeRoutes.POST("/slots/:id/cancel", volunteerHandler.CancelSlot)
+					writeRoutes.POST("/signups/:id/attendance", volunteerHandler.MarkAttendance)
+				}
+			}
+		}
+	}
+
+	// ========== QR Check-in ==========
+	{
+		checkinHandler := checkin.NewHandler(checkin.NewService(checkin.NewRepository(database.DB), auditSvc, []byte(cfg.CheckInSecret)))
+
+		checkinRoutes := protected.Group("/check-in")
+		{
+			// Devotees fetch the pass of a confirmed booking or RSVP and show it as a QR code
+			checkinRoutes.GET("/bookings/:id/pass", middleware.RBACMiddleware("devotee", "volunteer"), checkinHandler.GetBookingPass)
+			checkinRoutes.GET("/rsvps/:id/pass", middleware.RBACMiddleware("devotee", "volunteer"), checkinHandler.GetRSVPPass)
+
+			// Counter staff scan passes to mark attendance
+			checkinRoutes.POST("/scan",
+				middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser"),
+				middleware.RequireTempleAccess(),
+				middleware.RequireWriteAccess(),
+				checkinHandler.Scan)
+		}
+	}
+
+	// ========== Home Dashboard ==========
+	{
+		dashboardHandler := dashboard.NewHandler(dashboard.NewService(dashboard.NewRepository(database.DB)))
+
+		// Today's bookings, pending approvals, donation totals, upcoming events,
+		// notifications and devotee growth in one call
+		protected.GET("/dashboard",
+			middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"),
+			middleware.RequireTempleAccess(),
+			dashboardHandler.Get)
+	}
+
+	// ========== Families ==========
+	{
+		familyHandler := family.NewHandler(family.NewService(family.NewRepository(database.DB), auditSvc, sevaService))
+
+		familyRoutes := protected.Group("/families")
+		familyRoutes.Use(middleware.RBACMiddleware("devotee", "volunteer"))
+		{
+			familyRoutes.GET("/relationships", familyHandler.GetRelationships)
+			familyRoutes.POST("", familyHandler.Create)
+			familyRoutes.POST("/join", familyHandler.Join)
+			familyRoutes.GET("/mine", familyHandler.GetMine)
+			familyRoutes.PUT("/mine", familyHandler.UpdateMine)
+			familyRoutes.POST("/mine/leave", familyHandler.Leave)
+			familyRoutes.POST("/mine/members", familyHandler.AddMember)
+			familyRoutes.PUT("/mine/members/:memberId", familyHandler.UpdateMember)
+			familyRoutes.DELETE("/mine/members/:memberId", familyHandler.RemoveMember)
+			familyRoutes.POST("/mine/head", familyHandler.TransferHead)
+			familyRoutes.POST("/mine/join-code", familyHandler.RegenerateJoinCode)
+			familyRoutes.GET("/mine/seva-bookings", familyHandler.ListBookings)
+			// Seva bookings are made by devotees, as with individual bookings
+			familyRoutes.POST("/mine/seva-bookings", middleware.RBACMiddleware("devotee"), idempotent, familyHandler.BookSeva)
+		}
+	}
+
+	// ========== Personal Data (GDPR / DPDP) ==========
+	{
+		privacyHandler := privacy.NewHandler(privacy.NewService(privacy.NewRepository(database.DB), auditSvc, authSvc))
+
+		// Devotees export or erase their own personal data
+		privacyRoutes := protected.Group("/privacy/me")
+		privacyRoutes.Use(middleware.RBACMiddleware("devotee", "volunteer"))
+		{
+			privacyRoutes.GET("/export", exportLimit, privacyHandler.ExportMine)
+			privacyRoutes.POST("/erasure", privacyHandler.EraseMine)
+			privacyRoutes.GET("/requests", privacyHandler.ListMine)
+		}
+
+		// Superadmins act on a devotee's behalf and review the processing log
+		privacyAdminRoutes := protected.Group("/superadmin/privacy")
+		privacyAdminRoutes.Use(middleware.RBACMiddleware("superadmin"))
+		{
+			privacyAdminRoutes.GET("/users/:id/export", exportLimit, privacyHandler.ExportUser)
+			privacyAdminRoutes.POST("/users/:id/erasure", privacyHandler.EraseUser)
+			privacyAdminRoutes.GET("/requests", privacyHandler.ListRequests)
+			privacyAdminRoutes.GET("/requests/:id", privacyHandler.GetRequest)
+		}
+	}
+
+	// ========== Field Encryption ==========
+	{
+		// Moves encrypted devotee columns to the current key, e.g. after a key rotation
+		reencryptor := fieldcrypto.NewReencryptor(database.DB,
+			&auth.User{}, &userprofile.DevoteeProfile{}, &userprofile.Child{}, &userprofile.EmergencyContact{}, &family.Family{})
+		if fieldcrypto.Enabled() {
+			_ = reencryptor.Start(context.Background())
+		}
+		encryptionHandler := fieldcrypto.NewHandler(reencryptor, auditSvc)
+
+		encryptionRoutes := protected.Group("/superadmin/encryption")
+		encryptionRoutes.Use(middleware.RBACMiddleware("superadmin"))
+		{
+			encryptionRoutes.GET("", encryptionHandler.GetStatus)
+			encryptionRoutes.POST("/reencrypt", encryptionHandler.Reencrypt)
+		}
+	}
+
+	// ========== Payment Disputes ==========
+	disputeService := dispute.NewService(dispute.NewRepository(database.DB), cfg, auditSvc, store)
+	{
+		disputeHandler := dispute.NewHandler(disputeService)
+
+		// Razorpay dispute webhook - public, authenticated by the webhook signature
+		api.POST("/payments/disputes/webhook", disputeHandler.Webhook)
+
+		disputeRoutes := protected.Group("/disputes")
+		disputeRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		disputeRoutes.Use(middleware.RequireTempleAccess())
+		{
+			disputeRoutes.GET("", disputeHandler.List)
+			disputeRoutes.GET("/:id", disputeHandler.Get)
+			disputeRoutes.GET("/:id/evidence/:evidenceId", disputeHandler.DownloadEvidence)
+
+			writeRoutes := disputeRoutes.Group("")
+			writeRoutes.Use(middleware.RequireWriteAccess())
+			{
+				writeRoutes.POST("/:id/evidence", uploadLimit, disputeHandler.UploadEvidence)
+				writeRoutes.POST("/:id/respond", disputeHandler.Respond)
+				writeRoutes.PUT("/:id/booking", disputeHandler.LinkBooking)
+			}
+
+			// Manual outcomes for disputes the gateway never closed
+			disputeRoutes.PATCH("/:id/status", middleware.RBACMiddleware("superadmin"), disputeHandler.UpdateStatus)
+		}
+	}
 // ========== Notifications (UPDATED WITH FCM) ==========
 {
 	notificationRepo := notification.NewRepository(database.DB)
 	notifSvc = notification.NewService(notificationRepo, authRepo, cfg, auditSvc)
 	notificationHandler := notification.NewHandler(notifSvc, auditSvc)
+	notifSvc.SetMessenger(messenger)
+	notificationHandler.Mailer = mailer
+	notificationHandler.Messenger = messenger
 
 	// Updated to use new middleware system
 	notificationRoutes := protected.Group("/notifications")
@@ -576,16 +1383,31 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 			writeRoutes.PUT("/templates/:id", notificationHandler.UpdateTemplate)
 			writeRoutes.DELETE("/templates/:id", notificationHandler.DeleteTemplate)
 
+			// Template translations (machine translations are created as drafts)
+			writeRoutes.PUT("/templates/:id/translations/:lang", notificationHandler.SaveTranslation)
+			writeRoutes.DELETE("/templates/:id/translations/:lang", notificationHandler.DeleteTranslation)
+			writeRoutes.POST("/templates/:id/translations/machine", notificationHandler.MachineTranslate)
+
 			// Send Notification (Email, SMS, WhatsApp, Push)
 			writeRoutes.POST("/send", notificationHandler.SendNotification)
 
 			// ✅ NEW: FCM Push Notifications (Write Access Required)
 			writeRoutes.POST("/fcm/send", notificationHandler.SendFCMNotification)
+
+			// Sender name and address of the temple's transactional emails
+			writeRoutes.PUT("/email/settings", notificationHandler.UpdateEmailSettings)
+
+			// The temple's own SMS/WhatsApp provider account
+			writeRoutes.PUT("/messaging/settings/:channel", notificationHandler.UpdateMessagingSettings)
+			writeRoutes.DELETE("/messaging/settings/:channel", notificationHandler.DeleteMessagingSettings)
 		}
 
 		// Read operations - all three roles can access
 		notificationRoutes.GET("/templates", notificationHandler.GetTemplates)
 		notificationRoutes.GET("/templates/:id", notificationHandler.GetTemplateByID)
+		notificationRoutes.GET("/templates/:id/translations", notificationHandler.ListTranslations)
+		notificationRoutes.GET("/template-variables", notificationHandler.GetTemplateVariables)
+		notificationRoutes.POST("/templates/preview", notificationHandler.PreviewTemplate)
 
 		// View Logs
 		notificationRoutes.GET("/logs", notificationHandler.GetMyNotifications)
@@ -593,9 +1415,34 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 		// In-app
 		notificationRoutes.GET("/inapp", notificationHandler.GetMyInApp)
 		notificationRoutes.PUT("/inapp/:id/read", notificationHandler.MarkInAppRead)
-		notificationRoutes.GET("/stream", notificationHandler.StreamInApp)
+
+		// Transactional email settings and per-recipient delivery status
+		notificationRoutes.GET("/email/settings", notificationHandler.GetEmailSettings)
+		notificationRoutes.GET("/email/deliveries", notificationHandler.ListEmailDeliveries)
+
+		// SMS/WhatsApp settings and delivery reports
+		notificationRoutes.GET("/messaging/settings", notificationHandler.GetMessagingSettings)
+		notificationRoutes.GET("/messaging/deliveries", notificationHandler.ListMessageDeliveries)
 	}
 
+	// Delivery reports from SMS/WhatsApp providers - public, authenticated by the token in the URL
+	api.POST("/notifications/messaging/reports/:provider/:scope/:token", notificationHandler.MessageDeliveryReport)
+	api.GET("/notifications/messaging/reports/:provider/:scope/:token", notificationHandler.MessageDeliveryReport)
+
+	// Real-time stream (SSE) - any signed-in user, including tenants still
+	// awaiting approval, so approval decisions reach them as they happen
+	protected.GET("/notifications/stream", notificationHandler.StreamInApp)
+
+	// Per-user notification preferences - any signed-in user manages their own
+	protected.GET("/notifications/preferences", notificationHandler.GetPreferences)
+	protected.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+
+	// Kafka producer health and local buffer depth
+	protected.GET("/notifications/producer/metrics", middleware.RBACMiddleware("superadmin"), notificationHandler.GetProducerMetrics)
+
+	// Legacy path of the stream for clients passing ?token=; authenticated the same way
+	api.GET("/notifications/stream-token", middleware.AuthMiddleware(cfg, authSvc), notificationHandler.StreamInApp)
+
 	// ✅ NEW: FCM Device Token Management (All authenticated users can register their devices)
 	fcmRoutes := protected.Group("/notifications/fcm")
 	{
@@ -604,23 +1451,28 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 		fcmRoutes.DELETE("/unregister", notificationHandler.UnregisterFCMToken)
 	}
 }
-	// Public token-based SSE stream (no auth middleware required)
-	api.GET("/notifications/stream-token", func(c *gin.Context) {
-		notificationRepo := notification.NewRepository(database.DB)
-		notifSvc := notification.NewService(notificationRepo, authRepo, cfg, auditSvc)
-		handler := notification.NewHandler(notifSvc, auditSvc)
-		handler.StreamInAppWithToken(c)
-	})
-
 	// Now inject notifSvc into eventService
 	eventService.NotifSvc = notifSvc
 	sevaService.SetNotifService(notifSvc)
+	disputeService.Notifier = notifSvc
+	pledgeService.Notifier = notifSvc
+	shopService.Notifier = notifSvc
+	inventoryService.Notifier = notifSvc
+	greetingService.Notifier = notifSvc
+	portalService.Preferences = notifSvc
+	storageQuota.Notifier = notifSvc
+	profileService.SetTopicSubscriber(notifSvc)
+	entityProfileService.SetTopicSubscriber(notifSvc)
 
 	// ========== Tenant User Management ==========
 	tenantRepo := tenant.NewRepository(database.DB)
 	tenantService := tenant.NewService(tenantRepo)
+	tenantService.SetAuditService(auditSvc)
 	tenantHandler := tenant.NewHandler(tenantService)
 
+	// Sandbox tenants can wipe their test data
+	protected.DELETE("/tenant/sandbox/data", middleware.RBACMiddleware("templeadmin"), tenantHandler.WipeSandboxData)
+
 	// Tenant user routes (templeadmin + standarduser manage, monitoringuser read-only)
 	tenantRoutes := protected.Group("/tenants/:id/user")
 	tenantRoutes.Use(middleware.RequireTempleAccess()) // restrict to members of this temple
@@ -641,13 +1493,64 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 
 	// ========== Reports ==========
 	{
+		reports.SetMaxRangeDays(cfg.ReportMaxRangeDays, cfg.ReportMaxRangeDaysByType)
+		reports.SetAllowedExportFormats(cfg.ReportAllowedFormats)
+		reports.SetMaskedColumns(cfg.ReportMaskedColumns)
+		reports.SetCacheTTL(time.Duration(cfg.ReportCacheTTLSeconds) * time.Second)
+		if err := reports.RegisterCacheInvalidation(database.DB); err != nil {
+			fmt.Printf("⚠️ Report cache invalidation not registered: %v\n", err)
+		}
+
 		reportsRepo := reports.NewRepository(database.DB)
 		reportsExporter := reports.NewReportExporter()
 		reportsService := reports.NewReportService(reportsRepo, reportsExporter, auditSvc)
 		reportsHandler := reports.NewHandler(reportsService, reportsRepo, auditSvc)
 
+		// Asynchronous exports: POST queues a job, clients poll for status and download
+		jobsRepo := reports.NewJobRepository(database.DB)
+		jobsService := reports.NewJobService(jobsRepo, reportsService, store, auditSvc, time.Duration(cfg.ReportRetentionHours)*time.Hour)
+		jobsService.Start(context.Background(), cfg.ReportWorkers, cfg.ReportJobsPerTenant)
+		jobsHandler := reports.NewJobHandler(jobsService, reportsHandler)
+
+		jobRoutes := protected.Group("/reports/jobs")
+		jobRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		{
+			jobRoutes.POST("", exportLimit, jobsHandler.CreateJob)
+			jobRoutes.GET("", jobsHandler.ListJobs)
+			jobRoutes.GET("/:id", jobsHandler.GetJob)
+			jobRoutes.GET("/:id/download", jobsHandler.DownloadJob)
+		}
+
+		// Files of past synchronous exports, downloadable until they expire
+		historyRoutes := protected.Group("/reports/history")
+		historyRoutes.Use(middleware.RBACMiddleware("superadmin", "templeadmin", "standarduser", "monitoringuser"))
+		{
+			historyRoutes.GET("", exportHistory.ListExports)
+			historyRoutes.GET("/:id/download", exportHistory.DownloadExport)
+		}
+
+		// Export templates: temple admins choose columns and date format of their exports
+		exportTemplateRoutes := protected.Group("/reports/export-templates")
+		exportTemplateRoutes.Use(middleware.RBACMiddleware("templeadmin"))
+		{
+			exportTemplateRoutes.GET("", reportsHandler.ListExportTemplates)
+			exportTemplateRoutes.GET("/columns", reportsHandler.GetExportTemplateColumns)
+			exportTemplateRoutes.PUT("/:type", reportsHandler.SaveExportTemplate)
+			exportTemplateRoutes.DELETE("/:type", reportsHandler.DeleteExportTemplate)
+		}
+
+		// Ledger mapping: the Tally ledgers accounting exports of donations and expenses post to
+		ledgerMappingRoutes := protected.Group("/reports/ledger-mapping")
+		ledgerMappingRoutes.Use(middleware.RBACMiddleware("templeadmin"))
+		{
+			ledgerMappingRoutes.GET("", reportsHandler.GetLedgerMapping)
+			ledgerMappingRoutes.PUT("", reportsHandler.SaveLedgerMapping)
+			ledgerMappingRoutes.DELETE("", reportsHandler.DeleteLedgerMapping)
+		}
+
 		reportsRoutes := protected.Group("/entities/:id/reports")
 		reportsRoutes.Use(middleware.RequireTempleAccess()) // Allow templeadmin, standarduser, monitoringuser
+		reportsRoutes.Use(fileExportLimit, recordExport)
 		{
 			// All report endpoints are read-only by default, but may generate downloadable files
 			// Since report generation can be considered a "sensitive" operation, we can optionally require write access
@@ -659,6 +1562,11 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 			reportsRoutes.GET("/devotee-list", reportsHandler.GetDevoteeListReport)
 			reportsRoutes.GET("/devotee-profile", reportsHandler.GetDevoteeProfileReport)
 			reportsRoutes.GET("/audit-logs", reportsHandler.GetAuditLogsReport)
+			reportsRoutes.GET("/income-expense", reportsHandler.GetIncomeExpenseReport)
+			reportsRoutes.GET("/financial-year", reportsHandler.GetFinancialYearReport)
+			reportsRoutes.GET("/donor-analytics", reportsHandler.GetDonorAnalyticsReport)
+			reportsRoutes.GET("/inventory-valuation", reportsHandler.GetInventoryValuationReport)
+			reportsRoutes.GET("/families", reportsHandler.GetFamiliesReport)
 
 			// If you want to restrict export functionality to only users with write access,
 			// you can create a separate group with write access requirement:
@@ -689,7 +1597,7 @@ devoteeSevaRoutes.Use(middleware.RBACMiddleware("devotee"))
 				"error":   "File not found",
 				"message": "The requested file does not exist or has been moved",
 				"path":    c.Request.URL.Path,
-				"note":    "Files are now served from /data/uploads directory",
+				"note":    "Files are served from the configured file storage",
 			})
 			return
 		}
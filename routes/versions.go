@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sharath018/temple-management-backend/config"
+)
+
+// apiVersions hands out one route group per API version (/api/v1, /api/v2,
+// ...), each with the middleware all versions share. Handlers that change a
+// contract are registered under the next version while the previous one
+// keeps serving its clients until its sunset (API_DEPRECATIONS).
+type apiVersions struct {
+	engine       *gin.Engine
+	shared       []gin.HandlerFunc
+	deprecations map[string]config.APIDeprecation
+
+	groups map[string]*gin.RouterGroup
+	order  []string
+}
+
+func newAPIVersions(r *gin.Engine, deprecations map[string]config.APIDeprecation, shared ...gin.HandlerFunc) *apiVersions {
+	return &apiVersions{
+		engine:       r,
+		shared:       shared,
+		deprecations: deprecations,
+		groups:       map[string]*gin.RouterGroup{},
+	}
+}
+
+// group returns the route group of version, e.g. "v2", creating it on first use
+func (v *apiVersions) group(version string) *gin.RouterGroup {
+	if g, ok := v.groups[version]; ok {
+		return g
+	}
+	g := v.engine.Group("/api/"+version, v.shared...)
+	v.groups[version] = g
+	v.order = append(v.order, version)
+	return g
+}
+
+// list answers GET /api/versions with every version and its sunset, if any
+func (v *apiVersions) list(c *gin.Context) {
+	versions := make([]gin.H, 0, len(v.order))
+	for _, version := range v.order {
+		basePath := v.groups[version].BasePath()
+		entry := gin.H{"version": version, "base_path": basePath, "status": "active"}
+		if d, ok := v.deprecations[basePath]; ok {
+			entry["status"] = "deprecated"
+			if !d.Sunset.IsZero() {
+				entry["sunset"] = d.Sunset.Format(time.DateOnly)
+			}
+			if d.Successor != "" {
+				entry["successor"] = d.Successor
+			}
+		}
+		versions = append(versions, entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"data": versions})
+}
@@ -197,4 +197,21 @@ func SendEntityRejectionEmail(toEmail, fullName, templeName, reason string) {
 	subject := fmt.Sprintf("Your Temple \"%s\" Was Rejected", templeName)
 	body := fmt.Sprintf("Hello %s, your temple \"%s\" was rejected.\nReason: %s", fullName, templeName, reason)
 	_ = sendEmail(toEmail, subject, body)
-}
\ No newline at end of file
+}
+// ======================
+// Verification Emails
+// ======================
+func SendVerificationCodeEmail(toEmail, fullName, code string, validFor time.Duration) error {
+	subject := "Your temple registration verification code"
+	body := fmt.Sprintf("Hello %s,\n\nYour verification code is %s. It expires in %d minutes.\n\nIf you did not start a temple registration, please ignore this email.", fullName, code, int(validFor.Minutes()))
+	return sendEmail(toEmail, subject, body)
+}
+
+// ======================
+// Security Emails
+// ======================
+func SendAccountLockedEmail(toEmail, fullName, ip string, until time.Time) {
+	subject := "Your account has been locked"
+	body := fmt.Sprintf("Hello %s,\n\nYour account was locked after several failed sign-in attempts (last from IP %s). You can sign in again after %s, or ask an administrator to unlock it sooner.\n\nIf these attempts were not you, please reset your password once you can sign in.", fullName, ip, until.Format("02 Jan 2006 15:04 MST"))
+	_ = sendEmail(toEmail, subject, body)
+}
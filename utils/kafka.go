@@ -30,3 +30,11 @@ func GetKafkaWriter() *kafka.Writer {
 	}
 	return kafkaWriter
 }
+
+// CloseKafka flushes and closes the shared writer
+func CloseKafka() error {
+	if kafkaWriter == nil {
+		return nil
+	}
+	return kafkaWriter.Close()
+}
@@ -42,3 +42,11 @@ func GetToken(key string) (string, error) {
 func DeleteToken(key string) error {
 	return RedisClient.Del(Ctx, key).Err()
 }
+
+// CloseRedis closes the shared client
+func CloseRedis() error {
+	if RedisClient == nil {
+		return nil
+	}
+	return RedisClient.Close()
+}
@@ -0,0 +1,17 @@
+package utils
+
+import "fmt"
+
+// SMSGateway delivers the text messages SendSMS is given, e.g. registration
+// OTPs. It is set at startup to the notification messenger's platform SMS
+// gateway; when nil the message is only logged.
+var SMSGateway func(phone, message string) error
+
+// SendSMS delivers a text message through SMSGateway, or logs it
+func SendSMS(phone, message string) error {
+	if SMSGateway != nil {
+		return SMSGateway(phone, message)
+	}
+	fmt.Printf("📲 Sending SMS to: %s\nMessage: %s\n\n", phone, message)
+	return nil
+}